@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package conformance runs the upstream Gateway API conformance suite against
+// the API gateway controller. It is its own module since the suite needs newer
+// Kubernetes client libraries than the acceptance tests are built with.
+package conformance
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/gateway-api/conformance"
+	"sigs.k8s.io/gateway-api/conformance/utils/flags"
+	"sigs.k8s.io/gateway-api/conformance/utils/suite"
+	"sigs.k8s.io/gateway-api/pkg/features"
+)
+
+// supportedFeatures are the core features of the conformance profiles below
+// and the extended features that the API gateway controller supports.
+var supportedFeatures = []features.SupportedFeature{
+	features.SupportGateway,
+	features.SupportReferenceGrant,
+	features.SupportHTTPRoute,
+	features.SupportHTTPRouteMethodMatching,
+	features.SupportHTTPRouteQueryParamMatching,
+	features.SupportGRPCRoute,
+}
+
+var conformanceProfiles = []suite.ConformanceProfileName{
+	suite.GatewayHTTPConformanceProfileName,
+	suite.GatewayGRPCConformanceProfileName,
+}
+
+func TestMain(m *testing.M) {
+	// Default to the GatewayClass created by the Helm chart and the features
+	// of the API gateway controller. Flags passed with -args take precedence.
+	var featureNames, profileNames []string
+	for _, feature := range supportedFeatures {
+		featureNames = append(featureNames, string(feature))
+	}
+	for _, profile := range conformanceProfiles {
+		profileNames = append(profileNames, string(profile))
+	}
+	*flags.GatewayClassName = "consul"
+	*flags.SupportedFeatures = strings.Join(featureNames, ",")
+	*flags.ConformanceProfiles = strings.Join(profileNames, ",")
+
+	os.Exit(m.Run())
+}
+
+// TestGatewayAPIConformance runs the suite in the cluster of the current
+// kubeconfig context, which must have Consul installed by the Helm chart with
+// connectInject.enabled=true.
+func TestGatewayAPIConformance(t *testing.T) {
+	conformance.RunConformance(t)
+}
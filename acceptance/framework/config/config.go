@@ -46,6 +46,10 @@ type TestConfig struct {
 
 	DisablePeering bool
 
+	EnableWindows               bool
+	ConsulK8SImageWindows       string
+	ConsulDataplaneImageWindows string
+
 	HelmChartVersion       string
 	ConsulImage            string
 	ConsulK8SImage         string
@@ -114,6 +118,11 @@ func (t *TestConfig) HelmValuesFromConfig() (map[string]string, error) {
 	setIfNotEmpty(helmValues, "global.imageEnvoy", t.EnvoyImage)
 	setIfNotEmpty(helmValues, "global.imageConsulDataplane", t.ConsulDataplaneImage)
 
+	if t.EnableWindows {
+		setIfNotEmpty(helmValues, "connectInject.windows.imageK8S", t.ConsulK8SImageWindows)
+		setIfNotEmpty(helmValues, "connectInject.windows.imageConsulDataplane", t.ConsulDataplaneImageWindows)
+	}
+
 	return helmValues, nil
 }
 
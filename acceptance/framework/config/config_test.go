@@ -119,6 +119,29 @@ func TestConfig_HelmValuesFromConfig(t *testing.T) {
 				"connectInject.transparentProxy.defaultEnabled": "false",
 			},
 		},
+		{
+			"sets the Windows images when -enable-windows is set",
+			TestConfig{
+				EnableWindows:               true,
+				ConsulK8SImageWindows:       "hashicorp/consul-k8s-control-plane:windows",
+				ConsulDataplaneImageWindows: "hashicorp/consul-dataplane:windows",
+			},
+			map[string]string{
+				"connectInject.windows.imageK8S":                "hashicorp/consul-k8s-control-plane:windows",
+				"connectInject.windows.imageConsulDataplane":    "hashicorp/consul-dataplane:windows",
+				"connectInject.transparentProxy.defaultEnabled": "false",
+			},
+		},
+		{
+			"does not set the Windows images when -enable-windows is not set",
+			TestConfig{
+				ConsulK8SImageWindows:       "hashicorp/consul-k8s-control-plane:windows",
+				ConsulDataplaneImageWindows: "hashicorp/consul-dataplane:windows",
+			},
+			map[string]string{
+				"connectInject.transparentProxy.defaultEnabled": "false",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -58,6 +58,10 @@ type TestFlags struct {
 
 	flagDisablePeering bool
 
+	flagEnableWindows               bool
+	flagConsulK8sImageWindows       string
+	flagConsulDataplaneImageWindows string
+
 	once sync.Once
 }
 
@@ -136,6 +140,12 @@ func (t *TestFlags) init() {
 	flag.BoolVar(&t.flagDisablePeering, "disable-peering", false,
 		"If true, the peering tests will not run.")
 
+	flag.BoolVar(&t.flagEnableWindows, "enable-windows", false,
+		"If true, the tests that inject pods on Windows nodes will be run. Requires a cluster with Windows nodes, "+
+			"and the -consul-k8s-image-windows and -consul-dataplane-image-windows flags.")
+	flag.StringVar(&t.flagConsulK8sImageWindows, "consul-k8s-image-windows", "", "The Windows consul-k8s image to use for Windows pods.")
+	flag.StringVar(&t.flagConsulDataplaneImageWindows, "consul-dataplane-image-windows", "", "The Windows consul-dataplane image to use for Windows pods.")
+
 	if t.flagEnterpriseLicense == "" {
 		t.flagEnterpriseLicense = os.Getenv("CONSUL_ENT_LICENSE")
 	}
@@ -151,6 +161,10 @@ func (t *TestFlags) Validate() error {
 	if t.flagEnableEnterprise && t.flagEnterpriseLicense == "" {
 		return errors.New("-enable-enterprise provided without setting env var CONSUL_ENT_LICENSE with consul license")
 	}
+
+	if t.flagEnableWindows && (t.flagConsulK8sImageWindows == "" || t.flagConsulDataplaneImageWindows == "") {
+		return errors.New("-consul-k8s-image-windows and -consul-dataplane-image-windows must be provided if -enable-windows is set")
+	}
 	return nil
 }
 
@@ -185,6 +199,10 @@ func (t *TestFlags) TestConfigFromFlags() *config.TestConfig {
 
 		DisablePeering: t.flagDisablePeering,
 
+		EnableWindows:               t.flagEnableWindows,
+		ConsulK8SImageWindows:       t.flagConsulK8sImageWindows,
+		ConsulDataplaneImageWindows: t.flagConsulDataplaneImageWindows,
+
 		HelmChartVersion:       t.flagHelmChartVersion,
 		ConsulImage:            t.flagConsulImage,
 		ConsulK8SImage:         t.flagConsulK8sImage,
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/consul-k8s/acceptance/framework/connhelper"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/consul"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/helpers"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/k8s"
+	"github.com/hashicorp/consul-k8s/acceptance/framework/logger"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestConnectInject_Windows tests that a pod on a Windows node is injected with the Windows images,
+// registered with Consul, and reachable through its sidecar from a Linux pod.
+func TestConnectInject_Windows(t *testing.T) {
+	cfg := suite.Config()
+	if !cfg.EnableWindows {
+		t.Skipf("skipping this test because -enable-windows is not set")
+	}
+	ctx := suite.Environment().DefaultContext(t)
+
+	helmValues := map[string]string{
+		"connectInject.enabled": "true",
+	}
+
+	releaseName := helpers.RandomName()
+	consulCluster := consul.NewHelmCluster(t, helmValues, ctx, cfg, releaseName)
+	consulCluster.Create(t)
+
+	logger.Log(t, "creating static-server deployment on a Windows node")
+	k8s.DeployKustomize(t, ctx.KubectlOptions(t), cfg.NoCleanupOnFailure, cfg.DebugDirectory, "../fixtures/cases/static-server-inject-windows")
+	logger.Log(t, "creating static-client deployment")
+	k8s.DeployKustomize(t, ctx.KubectlOptions(t), cfg.NoCleanupOnFailure, cfg.DebugDirectory, "../fixtures/cases/static-client-inject")
+
+	ns := ctx.KubectlOptions(t).Namespace
+	pods, err := ctx.KubernetesClient(t).CoreV1().Pods(ns).List(context.Background(), metav1.ListOptions{LabelSelector: "app=static-server"})
+	require.NoError(t, err)
+	require.Len(t, pods.Items, 1)
+	pod := pods.Items[0]
+
+	// The init container runs connect-init without a shell, so it only succeeds if every flag
+	// was passed as its own argument.
+	var initContainerFound bool
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name != "consul-connect-inject-init" {
+			continue
+		}
+		initContainerFound = true
+		require.NotNil(t, status.State.Terminated)
+		require.Equal(t, int32(0), status.State.Terminated.ExitCode)
+	}
+	require.True(t, initContainerFound)
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == "consul-connect-inject-init" {
+			require.Equal(t, cfg.ConsulK8SImageWindows, container.Image)
+		}
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == "consul-dataplane" {
+			require.Equal(t, cfg.ConsulDataplaneImageWindows, container.Image)
+		}
+	}
+
+	logger.Log(t, "checking that static-server is registered with Consul and healthy")
+	consulClient, _ := consulCluster.SetupConsulClient(t, false)
+	retry.Run(t, func(r *retry.R) {
+		for _, name := range []string{"static-server", "static-server-sidecar-proxy"} {
+			instances, _, err := consulClient.Health().Service(name, "", true, nil)
+			r.Check(err)
+			if len(instances) != 1 {
+				r.Errorf("expected 1 passing instance of %s, got %d", name, len(instances))
+			}
+		}
+	})
+
+	// Transparent proxy is disabled for Windows pods, so the server must not be registered in that mode.
+	services, _, err := consulClient.Catalog().Service("static-server-sidecar-proxy", "", nil)
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	require.NotEqual(t, api.ProxyModeTransparent, services[0].ServiceProxy.Mode)
+
+	logger.Log(t, "checking that static-client can reach static-server through the sidecars")
+	k8s.CheckStaticServerConnectionSuccessfulWithMessage(t, ctx.KubectlOptions(t), connhelper.StaticClientName, pod.Name, "http://localhost:1234/hostname")
+}
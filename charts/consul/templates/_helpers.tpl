@@ -15,6 +15,37 @@ as well as the global.name setting.
 {{- end -}}
 {{- end -}}
 
+{{/*
+Prefix of the names of the ACL resources created by the server-acl-init job,
+e.g. auth methods. If externalServers.clusterName is set, it is added so that
+Kubernetes clusters sharing a Consul datacenter don't use the same resources.
+*/}}
+{{- define "consul.aclResourcePrefix" -}}
+{{- template "consul.fullname" . -}}
+{{- if .Values.externalServers.clusterName -}}-{{ .Values.externalServers.clusterName }}{{- end -}}
+{{- end -}}
+
+{{/*
+Name of the Consul node that the catalog sync registers services on.
+*/}}
+{{- define "consul.syncCatalogConsulNodeName" -}}
+{{- .Values.syncCatalog.consulNodeName -}}
+{{- if .Values.externalServers.clusterName -}}-{{ .Values.externalServers.clusterName }}{{- end -}}
+{{- end -}}
+
+{{/*
+Fails if externalServers.clusterName is set without external servers, or is
+not a valid name.
+
+Usage: {{ template "consul.validateExternalServersClusterName" . }}
+*/}}
+{{- define "consul.validateExternalServersClusterName" -}}
+{{- if .Values.externalServers.clusterName }}
+{{- if not .Values.externalServers.enabled }}{{ fail "externalServers.clusterName can only be set if externalServers.enabled is true" }}{{ end }}
+{{- if not (regexMatch "^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$" (.Values.externalServers.clusterName | toString)) }}{{ fail "externalServers.clusterName must be at most 63 characters long and only contain lowercase letters, digits and '-', starting and ending with a letter or digit" }}{{ end }}
+{{- end }}
+{{- end -}}
+
 {{- define "consul.vaultSecretTemplate" -}}
  |
             {{ "{{" }}- with secret "{{ .secretName }}" -{{ "}}" }}
@@ -175,6 +206,53 @@ Add a special case for replicas=1, where it should default to 0 as well.
 {{- end -}}
 {{- end -}}
 
+{{/*
+Compute the platform the consul-cni plugin is installed on. When connectInject.cni.platform is
+"auto", GKE Autopilot and OpenShift are detected from the APIs served by the cluster.
+*/}}
+{{- define "consul.cniPlatform" -}}
+{{- $platform := .Values.connectInject.cni.platform | default "" -}}
+{{- if eq $platform "auto" -}}
+{{- if .Capabilities.APIVersions.Has "auto.gke.io/v1" -}}
+gke-autopilot
+{{- else if .Capabilities.APIVersions.Has "security.openshift.io/v1" -}}
+openshift
+{{- end -}}
+{{- else if or (eq $platform "") (eq $platform "gke-autopilot") (eq $platform "openshift") -}}
+{{ $platform }}
+{{- else -}}
+{{ fail "connectInject.cni.platform must be one of \"\", \"auto\", \"gke-autopilot\" or \"openshift\"" }}
+{{- end -}}
+{{- end -}}
+
+{{/*
+Compute the host directories for CNI binaries and configuration. Directories set explicitly
+take precedence over the platform-managed directories.
+*/}}
+{{- define "consul.cniBinDir" -}}
+{{- $platform := include "consul.cniPlatform" . -}}
+{{- if ne .Values.connectInject.cni.cniBinDir "/opt/cni/bin" -}}
+{{ .Values.connectInject.cni.cniBinDir }}
+{{- else if eq $platform "gke-autopilot" -}}
+/home/kubernetes/bin
+{{- else if eq $platform "openshift" -}}
+/var/lib/cni/bin
+{{- else -}}
+{{ .Values.connectInject.cni.cniBinDir }}
+{{- end -}}
+{{- end -}}
+
+{{- define "consul.cniNetDir" -}}
+{{- $platform := include "consul.cniPlatform" . -}}
+{{- if ne .Values.connectInject.cni.cniNetDir "/etc/cni/net.d" -}}
+{{ .Values.connectInject.cni.cniNetDir }}
+{{- else if eq $platform "openshift" -}}
+/etc/kubernetes/cni/net.d
+{{- else -}}
+{{ .Values.connectInject.cni.cniNetDir }}
+{{- end -}}
+{{- end -}}
+
 {{/*
 Inject extra environment vars in the format key:value, if populated
 */}}
@@ -240,6 +318,20 @@ This template is for an init container.
       cpu: "50m"
 {{- end -}}
 
+{{/*
+Fails when global.tls.controlPlane has an unknown minimum TLS version, or has
+cipher suites even though TLS 1.2 isn't accepted.
+
+Usage: {{ template "consul.validateControlPlaneTLS" . }}
+
+*/}}
+{{- define "consul.validateControlPlaneTLS" -}}
+{{- with .Values.global.tls.controlPlane }}
+{{- if and .minVersion (ne .minVersion "TLSv1_2") (ne .minVersion "TLSv1_3") }}{{ fail "global.tls.controlPlane.minVersion must be \"TLSv1_2\" or \"TLSv1_3\"" }}{{ end -}}
+{{- if and .cipherSuites (eq (.minVersion | toString) "TLSv1_3") }}{{ fail "global.tls.controlPlane.cipherSuites can't be set if global.tls.controlPlane.minVersion is \"TLSv1_3\"" }}{{ end -}}
+{{- end }}
+{{- end -}}
+
 {{/*
 Fails when a reserved name is passed in. This should be used to test against
 Consul namespaces and partition names.
@@ -337,6 +429,28 @@ Consul server environment variables for consul-k8s commands.
 - name: CONSUL_SKIP_SERVER_WATCH
   value: "true"
 {{- end }}
+{{- with .Values.global.serverConnectionManager }}
+{{- if .serverWatchDisabledInterval }}
+- name: CONSUL_SERVER_WATCH_DISABLED_INTERVAL
+  value: {{ .serverWatchDisabledInterval | quote }}
+{{- end }}
+{{- if .serverCIDRs }}
+- name: CONSUL_SERVER_CIDRS
+  value: {{ join "," .serverCIDRs | quote }}
+{{- end }}
+{{- if .backoff.initialInterval }}
+- name: CONSUL_BACKOFF_INITIAL_INTERVAL
+  value: {{ .backoff.initialInterval | quote }}
+{{- end }}
+{{- if .backoff.maxInterval }}
+- name: CONSUL_BACKOFF_MAX_INTERVAL
+  value: {{ .backoff.maxInterval | quote }}
+{{- end }}
+{{- if .backoff.resetInterval }}
+- name: CONSUL_BACKOFF_RESET_INTERVAL
+  value: {{ .backoff.resetInterval | quote }}
+{{- end }}
+{{- end }}
 {{- end -}}
 
 {{/*
@@ -407,6 +521,112 @@ Usage: {{ template "consul.validateTelemetryCollectorCloud" . }}
 {{- end }}
 {{- end }}
 
+{{/*
+Prints "true" if the telemetry collector has a configuration file, i.e. if
+telemetryCollector.customExporterConfig or the endpoint of any of telemetryCollector.exporters is set.
+
+Usage: {{ if (include "consul.telemetryCollectorHasConfig" .) }}
+
+*/}}
+{{- define "consul.telemetryCollectorHasConfig" -}}
+{{- with .Values.telemetryCollector }}
+{{- if or .customExporterConfig .exporters.otlp.endpoint .exporters.prometheusRemoteWrite.endpoint -}}
+true
+{{- end }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Renders the configuration file of the telemetry collector. The endpoints of telemetryCollector.exporters
+are merged into the "exporters" of telemetryCollector.customExporterConfig, so that the collector exports
+metrics to each of them. The values of their headers are references to the environment variables set by
+"consul.telemetryCollectorExporterHeaderEnvVars", so that they're not stored in the ConfigMap.
+
+Usage: {{ include "consul.telemetryCollectorConfig" . }}
+
+*/}}
+{{- define "consul.telemetryCollectorConfig" -}}
+{{- $exporters := dict }}
+{{- with .Values.telemetryCollector.exporters.otlp }}
+{{- if .endpoint }}
+{{- if not (regexMatch "^https?://" .endpoint) }}{{ fail "telemetryCollector.exporters.otlp.endpoint must be an http or https URL" }}{{ end }}
+{{- $headers := include "consul.telemetryCollectorExporterHeaders" (dict "headers" .headers "value" "telemetryCollector.exporters.otlp.headers" "envPrefix" "OTLP_EXPORTER") | fromJson }}
+{{- $_ := set $exporters "otlphttp" (dict "endpoint" .endpoint "headers" $headers) }}
+{{- end }}
+{{- end }}
+{{- with .Values.telemetryCollector.exporters.prometheusRemoteWrite }}
+{{- if .endpoint }}
+{{- if not (regexMatch "^https?://" .endpoint) }}{{ fail "telemetryCollector.exporters.prometheusRemoteWrite.endpoint must be an http or https URL" }}{{ end }}
+{{- $headers := include "consul.telemetryCollectorExporterHeaders" (dict "headers" .headers "value" "telemetryCollector.exporters.prometheusRemoteWrite.headers" "envPrefix" "PROMETHEUS_REMOTE_WRITE_EXPORTER") | fromJson }}
+{{- $_ := set $exporters "prometheusremotewrite" (dict "endpoint" .endpoint "headers" $headers) }}
+{{- end }}
+{{- end }}
+{{- if not $exporters }}
+{{- tpl .Values.telemetryCollector.customExporterConfig . | trimAll "\"" }}
+{{- else }}
+{{- $config := dict }}
+{{- if .Values.telemetryCollector.customExporterConfig }}
+{{- $config = tpl .Values.telemetryCollector.customExporterConfig . | trimAll "\"" | fromJson }}
+{{- if hasKey $config "Error" }}{{ fail "telemetryCollector.customExporterConfig must be a JSON object if telemetryCollector.exporters are set" }}{{ end }}
+{{- end }}
+{{- $customExporters := get $config "exporters" | default dict }}
+{{- if not (kindIs "map" $customExporters) }}{{ fail "the exporters of telemetryCollector.customExporterConfig must be a JSON object if telemetryCollector.exporters are set" }}{{ end }}
+{{- range $name, $_ := $exporters }}
+{{- if hasKey $customExporters $name }}{{ fail (printf "the %s exporter is configured in both telemetryCollector.customExporterConfig and telemetryCollector.exporters" $name) }}{{ end }}
+{{- end }}
+{{- $_ := set $config "exporters" (merge $customExporters $exporters) }}
+{{- toPrettyJson $config }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Renders the headers of an exporter of the telemetry collector as a JSON object whose values reference the
+environment variables that hold them.
+
+Usage: {{ include "consul.telemetryCollectorExporterHeaders" (dict "headers" .headers "value" "telemetryCollector.exporters.otlp.headers" "envPrefix" "OTLP_EXPORTER") }}
+
+*/}}
+{{- define "consul.telemetryCollectorExporterHeaders" -}}
+{{- $headers := dict }}
+{{- $value := .value }}
+{{- if not (kindIs "slice" (default list .headers)) }}{{ fail (printf "%s must be a list of headers with a name, secretName and secretKey" $value) }}{{ end }}
+{{- range $i, $header := .headers }}
+{{- if not (and $header.name $header.secretName $header.secretKey) }}{{ fail (printf "%s must each have a name, secretName and secretKey" $value) }}{{ end }}
+{{- $_ := set $headers $header.name (printf "${env:%s_HEADER_%d}" $.envPrefix $i) }}
+{{- end }}
+{{- toJson $headers }}
+{{- end -}}
+
+{{/*
+Sets the environment variables with the headers of the exporters of the telemetry collector from their
+Kubernetes secrets.
+
+Usage: {{ include "consul.telemetryCollectorExporterHeaderEnvVars" . }}
+
+*/}}
+{{- define "consul.telemetryCollectorExporterHeaderEnvVars" -}}
+{{- with .Values.telemetryCollector.exporters }}
+{{- if .otlp.endpoint }}
+{{- range $i, $header := .otlp.headers }}
+- name: OTLP_EXPORTER_HEADER_{{ $i }}
+  valueFrom:
+    secretKeyRef:
+      name: {{ $header.secretName }}
+      key: {{ $header.secretKey }}
+{{- end }}
+{{- end }}
+{{- if .prometheusRemoteWrite.endpoint }}
+{{- range $i, $header := .prometheusRemoteWrite.headers }}
+- name: PROMETHEUS_REMOTE_WRITE_EXPORTER_HEADER_{{ $i }}
+  valueFrom:
+    secretKeyRef:
+      name: {{ $header.secretName }}
+      key: {{ $header.secretKey }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end -}}
+
 {{/**/}}
 
 {{- define "consul.validateTelemetryCollectorCloudSecretKeys" -}}
@@ -422,4 +642,55 @@ Usage: {{ template "consul.validateTelemetryCollectorCloud" . }}
 {{- if or (and .Values.telemetryCollector.cloud.clientSecret.secretName .Values.telemetryCollector.cloud.clientSecret.secretKey .Values.telemetryCollector.cloud.clientId.secretName .Values.telemetryCollector.cloud.clientId.secretKey (not .Values.global.cloud.resourceId.secretKey)) }}
 {{fail "When telemetryCollector has clientId and clientSecret .global.cloud.resourceId.secretKey must be set"}}
 {{- end }}
-{{- end -}}
\ No newline at end of file
+{{- end -}}
+
+{{/*
+Renders the scrape interval, scrape timeout and metric relabelings of a
+Prometheus Operator PodMonitor or ServiceMonitor endpoint.
+
+Usage: {{ include "consul.prometheusOperatorEndpoint" . }}
+*/}}
+{{- define "consul.prometheusOperatorEndpoint" -}}
+{{- $operator := .Values.global.metrics.prometheusOperator }}
+{{- if $operator.interval }}
+interval: {{ $operator.interval }}
+{{- end }}
+{{- if $operator.scrapeTimeout }}
+scrapeTimeout: {{ $operator.scrapeTimeout }}
+{{- end }}
+{{- with $operator.metricRelabelings }}
+metricRelabelings:
+  {{- toYaml . | nindent 2 }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Prints "true" if the uninstall cleanup job runs, i.e. if externalServers.uninstallCleanup.enabled is set
+and the connect injector or the catalog sync register services in the external servers.
+
+Usage: {{ if (include "consul.uninstallCleanupEnabled" .) }}
+
+*/}}
+{{- define "consul.uninstallCleanupEnabled" -}}
+{{- $connectInjectEnabled := (or (and (ne (.Values.connectInject.enabled | toString) "-") .Values.connectInject.enabled) (and (eq (.Values.connectInject.enabled | toString) "-") .Values.global.enabled)) }}
+{{- $syncCatalogEnabled := (or (and (ne (.Values.syncCatalog.enabled | toString) "-") .Values.syncCatalog.enabled) (and (eq (.Values.syncCatalog.enabled | toString) "-") .Values.global.enabled)) }}
+{{- if and .Values.externalServers.enabled .Values.externalServers.uninstallCleanup.enabled (not .Values.global.secretsBackend.vault.enabled) (or $connectInjectEnabled $syncCatalogEnabled) -}}
+true
+{{- end }}
+{{- end -}}
+
+{{/*
+Prints "true" if the connect injector rotates the ACL tokens of the sync catalog, the snapshot agent,
+the gateways and the API gateway controller, i.e. if global.acls.tokenRotationInterval is set. They then
+read their tokens from the Secrets in acl-token-rotation-secrets.yaml rather than logging in with the
+component auth method.
+
+Usage: {{ if (include "consul.aclTokenRotationEnabled" .) }}
+
+*/}}
+{{- define "consul.aclTokenRotationEnabled" -}}
+{{- $connectInjectEnabled := (or (and (ne (.Values.connectInject.enabled | toString) "-") .Values.connectInject.enabled) (and (eq (.Values.connectInject.enabled | toString) "-") .Values.global.enabled)) }}
+{{- if and .Values.global.acls.manageSystemACLs .Values.global.acls.tokenRotationInterval $connectInjectEnabled -}}
+true
+{{- end }}
+{{- end -}}
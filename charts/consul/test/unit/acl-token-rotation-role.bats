@@ -0,0 +1,79 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "aclTokenRotation/Role: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/acl-token-rotation-role.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      .
+}
+
+@test "aclTokenRotation/Role: has no rules without rotated components" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/acl-token-rotation-role.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -c '.rules' | tee /dev/stderr)
+  [ "${actual}" = "[]" ]
+}
+
+@test "aclTokenRotation/Role: only allows rotating the tokens of the components in the release namespace" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/acl-token-rotation-role.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      --set 'syncCatalog.enabled=true' \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'ingressGateways.enabled=true' \
+      --set 'ingressGateways.gateways[0].name=ingress1' \
+      --set 'terminatingGateways.enabled=true' \
+      --set 'terminatingGateways.gateways[0].name=terminating1' \
+      --set 'apiGateway.enabled=true' \
+      --set 'apiGateway.image=foo' \
+      --namespace foo \
+      . | tee /dev/stderr |
+      yq -c '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.kind' | tee /dev/stderr)
+  [ "${actual}" = "Role" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.namespace' | tee /dev/stderr)
+  [ "${actual}" = "foo" ]
+
+  local actual=$(echo "$object" | yq -r '.rules[] | select(.resources == ["serviceaccounts/token"]) | .resourceNames | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-sync-catalog,release-name-consul-server,release-name-consul-mesh-gateway,release-name-consul-ingress1,release-name-consul-terminating1,release-name-consul-api-gateway-controller" ]
+
+  local actual=$(echo "$object" | yq -r '.rules[] | select(.resources == ["secrets"]) | .resourceNames | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-sync-catalog-acl-token,release-name-consul-snapshot-agent-acl-token,release-name-consul-mesh-gateway-acl-token,release-name-consul-ingress1-acl-token,release-name-consul-terminating1-acl-token,release-name-consul-api-gateway-controller-acl-token" ]
+
+  local actual=$(echo "$object" | yq -r '.rules[] | select(.resources == ["secrets"]) | .verbs | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "get,update" ]
+
+  local actual=$(echo "$object" | yq -r '.rules[] | select(.resources == ["deployments"]) | .resourceNames | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-mesh-gateway,release-name-consul-ingress1,release-name-consul-terminating1,release-name-consul-api-gateway-controller" ]
+
+  local actual=$(echo "$object" | yq -r '.rules[] | select(.resources == ["deployments"]) | .verbs | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "patch" ]
+}
+
+@test "aclTokenRotation/Role: cannot patch deployments without gateways" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/acl-token-rotation-role.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '[.rules[].resources[]] | any(. == "deployments")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
@@ -0,0 +1,29 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "aclTokenRotation/RoleBinding: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/acl-token-rotation-rolebinding.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      .
+}
+
+@test "aclTokenRotation/RoleBinding: binds the connect injector service account" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/acl-token-rotation-rolebinding.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -c '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.roleRef.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-acl-token-rotation" ]
+
+  local actual=$(echo "$object" | yq -r '.subjects[0].name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-connect-injector" ]
+}
@@ -0,0 +1,185 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "aclTokenRotation/Secret: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      .
+}
+
+@test "aclTokenRotation/Secret: disabled without global.acls.manageSystemACLs" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      .
+}
+
+@test "aclTokenRotation/Secret: disabled when connectInject is disabled" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'connectInject.enabled=false' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      .
+}
+
+@test "aclTokenRotation/Secret: creates the Secret of the sync catalog" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -s -r '.[] | select(.metadata.name == "release-name-consul-sync-catalog-acl-token")' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.labels."consul.hashicorp.com/rotate-acl-token"' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/service-account"' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-sync-catalog" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/auth-method"' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-k8s-component-auth-method" ]
+
+  local actual=$(echo "$object" | yq -r '.data' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
+@test "aclTokenRotation/Secret: sync catalog logs in to the primary datacenter with federation and namespaces" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      --set 'global.enableConsulNamespaces=true' \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.primaryDatacenter=dc1' \
+      --set 'global.datacenter=dc2' \
+      --set 'global.tls.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      . | tee /dev/stderr |
+      yq -s -r '.[] | select(.metadata.name == "release-name-consul-sync-catalog-acl-token")' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/auth-method"' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-k8s-component-auth-method-dc2" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/auth-method-datacenter"' | tee /dev/stderr)
+  [ "${actual}" = "dc1" ]
+}
+
+@test "aclTokenRotation/Secret: creates the Secret of the snapshot agent" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -s -r '.[] | select(.metadata.name == "release-name-consul-snapshot-agent-acl-token")' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/service-account"' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-server" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/component"' | tee /dev/stderr)
+  [ "${actual}" = "snapshot-agent" ]
+}
+
+@test "aclTokenRotation/Secret: creates the Secret of the mesh gateway" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -s -r '.[] | select(.metadata.name == "release-name-consul-mesh-gateway-acl-token")' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/service-account"' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-mesh-gateway" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/restart-deployment"' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-mesh-gateway" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/auth-method-datacenter"' | tee /dev/stderr)
+  [ "${actual}" = "dc1" ]
+}
+
+@test "aclTokenRotation/Secret: mesh gateway logs in to the primary datacenter with federation" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      --set 'global.federation.enabled=true' \
+      --set 'global.federation.primaryDatacenter=dc1' \
+      --set 'global.datacenter=dc2' \
+      --set 'global.tls.enabled=true' \
+      . | tee /dev/stderr |
+      yq -s -r '.[] | select(.metadata.name == "release-name-consul-mesh-gateway-acl-token")' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/auth-method"' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-k8s-component-auth-method-dc2" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/auth-method-datacenter"' | tee /dev/stderr)
+  [ "${actual}" = "dc1" ]
+}
+
+@test "aclTokenRotation/Secret: creates a Secret for each ingress and terminating gateway" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'ingressGateways.enabled=true' \
+      --set 'ingressGateways.gateways[0].name=ingress1' \
+      --set 'ingressGateways.gateways[1].name=ingress2' \
+      --set 'terminatingGateways.enabled=true' \
+      --set 'terminatingGateways.gateways[0].name=terminating1' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -s -c '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '[.[].metadata.name] | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-ingress1-acl-token,release-name-consul-ingress2-acl-token,release-name-consul-terminating1-acl-token" ]
+
+  local actual=$(echo "$object" | yq -r '[.[].metadata.annotations."consul.hashicorp.com/restart-deployment"] | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-ingress1,release-name-consul-ingress2,release-name-consul-terminating1" ]
+
+  local actual=$(echo "$object" | yq -r '[.[].metadata.annotations."consul.hashicorp.com/component"] | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "ingress-gateway,ingress-gateway,terminating-gateway" ]
+}
+
+@test "aclTokenRotation/Secret: creates the Secret of the API gateway controller" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/acl-token-rotation-secrets.yaml  \
+      --set 'apiGateway.enabled=true' \
+      --set 'apiGateway.image=foo' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -s -r '.[] | select(.metadata.name == "release-name-consul-api-gateway-controller-acl-token")' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/service-account"' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-api-gateway-controller" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations."consul.hashicorp.com/restart-deployment"' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-api-gateway-controller" ]
+}
@@ -276,6 +276,31 @@ load _helpers
   [ "${object}" = "true" ]
 }
 
+@test "apiGateway/Deployment: reads the rotated ACL token with global.acls.tokenRotationInterval" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s templates/api-gateway-controller-deployment.yaml \
+      --set 'apiGateway.enabled=true' \
+      --set 'apiGateway.image=foo' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -c '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo $spec | yq -r '.containers[0].env[] | select(.name == "CONSUL_HTTP_TOKEN_FILE") | .value' | tee /dev/stderr)
+  [ "${actual}" = "/consul/acl-token/token" ]
+
+  local actual=$(echo $spec | yq -r '.volumes[] | select(.name == "acl-token") | .secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-api-gateway-controller-acl-token" ]
+
+  local actual=$(echo $spec | yq -r '.containers[0].lifecycle' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+
+  local actual=$(echo $spec | yq -r '.initContainers' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
 @test "apiGateway/Deployment: CONSUL_HTTP_TOKEN_FILE is not set when acls are disabled" {
   cd `chart_dir`
   local actual=$(helm template \
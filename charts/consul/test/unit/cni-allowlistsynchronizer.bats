@@ -0,0 +1,47 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "cni/AllowlistSynchronizer: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/cni-allowlistsynchronizer.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      .
+}
+
+@test "cni/AllowlistSynchronizer: disabled when cni is disabled" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/cni-allowlistsynchronizer.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.gkeAutopilot.allowlistSynchronizer.enabled=true' \
+      --set 'connectInject.cni.gkeAutopilot.allowlistSynchronizer.allowlistPaths[0]=Hashicorp/consul/*' \
+      .
+}
+
+@test "cni/AllowlistSynchronizer: fails if no allowlist paths are set" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/cni-allowlistsynchronizer.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.gkeAutopilot.allowlistSynchronizer.enabled=true' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.cni.gkeAutopilot.allowlistSynchronizer.allowlistPaths must be set if connectInject.cni.gkeAutopilot.allowlistSynchronizer.enabled is true" ]]
+}
+
+@test "cni/AllowlistSynchronizer: sets the allowlist paths" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/cni-allowlistsynchronizer.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.gkeAutopilot.allowlistSynchronizer.enabled=true' \
+      --set 'connectInject.cni.gkeAutopilot.allowlistSynchronizer.allowlistPaths[0]=Hashicorp/consul/*' \
+      . | tee /dev/stderr |
+      yq -r -c '.spec.allowlistPaths' | tee /dev/stderr)
+  [ "${actual}" = '["Hashicorp/consul/*"]' ]
+}
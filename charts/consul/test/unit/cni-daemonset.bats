@@ -295,6 +295,139 @@ rollingUpdate:
       [ "${actual}" = '{"mountPath":"bar","name":"cni-net-dir"}' ]
 }
 
+#--------------------------------------------------------------------
+# platform
+
+@test "cni/DaemonSet: uses GKE Autopilot CNI directories when platform is gke-autopilot" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.platform=gke-autopilot' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-bin-dir=/home/kubernetes/bin"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-net-dir=/etc/cni/net.d"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "cni/DaemonSet: uses OpenShift CNI directories when platform is openshift" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.platform=openshift' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-bin-dir=/var/lib/cni/bin"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-net-dir=/etc/kubernetes/cni/net.d"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "cni/DaemonSet: explicit CNI directories take precedence over the platform" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.platform=openshift' \
+      --set 'connectInject.cni.cniBinDir=foo' \
+      --set 'connectInject.cni.cniNetDir=bar' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-bin-dir=foo"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-net-dir=bar"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "cni/DaemonSet: detects GKE Autopilot when platform is auto" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.platform=auto' \
+      --api-versions 'auto.gke.io/v1' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-bin-dir=/home/kubernetes/bin"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-net-dir=/etc/cni/net.d"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "cni/DaemonSet: detects OpenShift when platform is auto" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.platform=auto' \
+      --api-versions 'security.openshift.io/v1' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-bin-dir=/var/lib/cni/bin"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-net-dir=/etc/kubernetes/cni/net.d"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "cni/DaemonSet: uses default CNI directories when platform is auto and no platform is detected" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.platform=auto' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-bin-dir=/opt/cni/bin"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-cni-net-dir=/etc/cni/net.d"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "cni/DaemonSet: fails with an unknown platform" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.platform=foo' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.cni.platform must be one of" ]]
+}
+
 @test "cni/DaemonSet: cni namespace has a default when not set" {
   cd `chart_dir`
   local actual=$(helm template \
@@ -387,3 +520,29 @@ rollingUpdate:
   [ "${actualTemplateFoo}" = "bar" ]
   [ "${actualTemplateBaz}" = "qux" ]
 }
+
+#--------------------------------------------------------------------
+# gkeAutopilot
+
+@test "cni/DaemonSet: no matching allowlist label by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.labels["cloud.google.com/matching-allowlist"]' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
+@test "cni/DaemonSet: sets the matching allowlist label with connectInject.cni.gkeAutopilot.allowlistName" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/cni-daemonset.yaml  \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.gkeAutopilot.allowlistName=consul-cni' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.labels["cloud.google.com/matching-allowlist"]' | tee /dev/stderr)
+  [ "${actual}" = "consul-cni" ]
+}
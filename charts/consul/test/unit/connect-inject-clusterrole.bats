@@ -127,6 +127,38 @@ load _helpers
   [ "${actual}" != null ]
 }
 
+@test "connectInject/ClusterRole: does not grant access to endpointslices by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '[.rules[] | select(.apiGroups[0] == "discovery.k8s.io")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "connectInject/ClusterRole: sets get, list, and watch access to endpointslices when connectInject.enableEndpointSlices is true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.enableEndpointSlices=true' \
+      . | tee /dev/stderr |
+      yq -r '.rules[] | select(.apiGroups[0] == "discovery.k8s.io")' | tee /dev/stderr)
+
+  local actual=$(echo $object | yq -r '.resources | index("endpointslices")' | tee /dev/stderr)
+  [ "${actual}" != null ]
+
+  local actual=$(echo $object | yq -r '.verbs | index("get")' | tee /dev/stderr)
+  [ "${actual}" != null ]
+
+  local actual=$(echo $object | yq -r '.verbs | index("list")' | tee /dev/stderr)
+  [ "${actual}" != null ]
+
+  local actual=$(echo $object | yq -r '.verbs | index("watch")' | tee /dev/stderr)
+  [ "${actual}" != null ]
+}
+
 @test "connectInject/ClusterRole: sets get access to serviceaccounts and secrets when manageSystemACLSis true" {
   cd `chart_dir`
   local object=$(helm template \
@@ -151,6 +183,53 @@ load _helpers
   [ "${actual}" != null ]
 }
 
+#--------------------------------------------------------------------
+# server.snapshotAgent.snapshotSchedule
+
+@test "connectInject/ClusterRole: pod logs can't be read by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.snapshotAgent.enabled=true' \
+      . | tee /dev/stderr |
+      yq '[.rules[] | select(.resources | index("pods/log"))] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "connectInject/ClusterRole: pod logs can be read with server.snapshotAgent.snapshotSchedule.enabled=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'server.snapshotAgent.snapshotSchedule.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules[] | select(.resources | index("pods/log"))' | tee /dev/stderr)
+
+  local actual=$(echo $object | yq -r '.verbs | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "get" ]
+}
+
+#--------------------------------------------------------------------
+# server.upgradeController
+
+@test "connectInject/ClusterRole: server pods can't be deleted and StatefulSets can't be read with server.upgradeController.enabled=true" {
+  cd `chart_dir`
+  local rules=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.upgradeController.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules' | tee /dev/stderr)
+
+  local actual=$(echo "$rules" | yq '[.[] | select(.resources | index("statefulsets"))] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+
+  local actual=$(echo "$rules" | yq '[.[] | select(.resources | index("pods")) | .verbs[] | select(. == "delete")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
 #--------------------------------------------------------------------
 # global.enablePodSecurityPolicies
 
@@ -217,3 +296,29 @@ load _helpers
   local actual=$(echo $object | yq -r '.verbs | index("watch")' | tee /dev/stderr)
   [ "${actual}" != null ]
 }
+
+#--------------------------------------------------------------------
+# global.acls.tokenRotationInterval
+
+@test "connectInject/ClusterRole: cannot create service account tokens by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq '[.rules[].resources[]] | any(. == "serviceaccounts/token")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/ClusterRole: cannot create service account tokens with global.acls.tokenRotationInterval" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-clusterrole.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq '[.rules[].resources[]] | any(. == "serviceaccounts/token")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
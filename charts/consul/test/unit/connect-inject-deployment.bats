@@ -652,6 +652,48 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+@test "connectInject/Deployment: -config-entry-partition is not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.enableConsulNamespaces=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-config-entry-partition"))' | tee /dev/stderr)
+
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: -config-entry-partition is set for each of .global.adminPartitions.crossPartitionConfigEntries" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.adminPartitions.crossPartitionConfigEntries={ap1,ap2}' \
+      --set 'global.enableConsulNamespaces=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-config-entry-partition=\"ap1\"") and contains("-config-entry-partition=\"ap2\""))' | tee /dev/stderr)
+
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: fails if .global.adminPartitions.crossPartitionConfigEntries is set in a non-default partition" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.adminPartitions.name=foo' \
+      --set 'global.adminPartitions.crossPartitionConfigEntries={ap1,ap2}' \
+      --set 'global.enableConsulNamespaces=true' \
+      .
+
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.adminPartitions.crossPartitionConfigEntries is only supported if global.adminPartitions.name is \"default\"" ]]
+}
+
 @test "connectInject/Deployment: consul env var default set with .global.adminPartitions.enabled=true" {
   cd `chart_dir`
   local env=$(helm template \
@@ -1315,6 +1357,33 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+@test "connectInject/Deployment: by default sidecar proxy lifecycle management preStop drain is disabled" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-enable-sidecar-proxy-lifecycle-prestop-drain=false"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: sidecar proxy lifecycle management preStop drain can be enabled" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.sidecarProxy.lifecycle.defaultEnablePreStopDrain=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-enable-sidecar-proxy-lifecycle-prestop-drain=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 @test "connectInject/Deployment: by default sidecar proxy lifecycle management port is set to 20600" {
   cd `chart_dir`
   local cmd=$(helm template \
@@ -1370,231 +1439,1187 @@ load _helpers
 }
 
 #--------------------------------------------------------------------
-# priorityClassName
+# sidecarProxy.accessLogs
 
-@test "connectInject/Deployment: no priorityClassName by default" {
+@test "connectInject/Deployment: by default envoy access logs are not enabled" {
   cd `chart_dir`
-  local actual=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml \
       --set 'connectInject.enabled=true' \
       . | tee /dev/stderr |
-      yq -r '.spec.template.spec.priorityClassName' | tee /dev/stderr)
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
 
-  [ "${actual}" = "null" ]
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-enable-envoy-access-logs"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-envoy-access-logs-type=stdout"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
 }
 
-@test "connectInject/Deployment: can set a priorityClassName" {
+@test "connectInject/Deployment: envoy access logs can be configured" {
   cd `chart_dir`
-  local actual=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml \
       --set 'connectInject.enabled=true' \
-      --set 'connectInject.priorityClassName=name' \
+      --set 'connectInject.sidecarProxy.accessLogs.enabled=true' \
+      --set 'connectInject.sidecarProxy.accessLogs.type=file' \
+      --set 'connectInject.sidecarProxy.accessLogs.path=/var/log/envoy.log' \
+      --set 'connectInject.sidecarProxy.accessLogs.textFormat=%START_TIME% %RESPONSE_CODE%' \
       . | tee /dev/stderr |
-      yq -r '.spec.template.spec.priorityClassName' | tee /dev/stderr)
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
 
-  [ "${actual}" = "name" ]
-}
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-enable-envoy-access-logs=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
 
-#--------------------------------------------------------------------
-# extraLabels
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-envoy-access-logs-type=file"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
 
-@test "connectInject/Deployment: no extra labels defined by default" {
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-envoy-access-logs-path=\"/var/log/envoy.log\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-envoy-access-logs-text-format='"'"'%START_TIME% %RESPONSE_CODE%'"'"'"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: envoy access logs path is only set for file type" {
   cd `chart_dir`
   local actual=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
+      -s templates/connect-inject-deployment.yaml \
       --set 'connectInject.enabled=true' \
+      --set 'connectInject.sidecarProxy.accessLogs.enabled=true' \
+      --set 'connectInject.sidecarProxy.accessLogs.type=stdout' \
+      --set 'connectInject.sidecarProxy.accessLogs.path=/var/log/envoy.log' \
       . | tee /dev/stderr |
-      yq -r '.spec.template.metadata.labels | del(."app") | del(."chart") | del(."release") | del(."component")' | tee /dev/stderr)
-  [ "${actual}" = "{}" ]
+      yq '.spec.template.spec.containers[0].command | any(contains("-default-envoy-access-logs-path"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
 }
 
-@test "connectInject/Deployment: can set extra labels" {
+#--------------------------------------------------------------------
+# sidecarProxy.tracing
+
+@test "connectInject/Deployment: by default envoy tracing is not enabled" {
   cd `chart_dir`
-  local actual=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml \
       --set 'connectInject.enabled=true' \
-      --set 'connectInject.extraLabels.foo=bar' \
       . | tee /dev/stderr |
-      yq -r '.spec.template.metadata.labels.foo' | tee /dev/stderr)
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
 
-  [ "${actual}" = "bar" ]
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-enable-envoy-tracing"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
 }
 
-@test "connectInject/Deployment: can set extra global labels" {
+@test "connectInject/Deployment: envoy tracing fails without a collector address" {
   cd `chart_dir`
-  local actual=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
+  run helm template \
+      -s templates/connect-inject-deployment.yaml \
       --set 'connectInject.enabled=true' \
-      --set 'global.extraLabels.foo=bar' \
-      . | tee /dev/stderr)
-  local actualBar=$(echo "${actual}" | yq -r '.metadata.labels.foo' | tee /dev/stderr)
-  [ "${actualBar}" = "bar" ]
-  local actualTemplateBar=$(echo "${actual}" | yq -r '.spec.template.metadata.labels.foo' | tee /dev/stderr)
-  [ "${actualTemplateBar}" = "bar" ]
+      --set 'connectInject.sidecarProxy.tracing.enabled=true' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.sidecarProxy.tracing.collectorAddress must be set when connectInject.sidecarProxy.tracing.enabled is true" ]]
 }
 
-@test "connectInject/Deployment: can set multiple extra global labels" {
+@test "connectInject/Deployment: envoy tracing can be configured" {
   cd `chart_dir`
-  local actual=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml \
       --set 'connectInject.enabled=true' \
-      --set 'global.extraLabels.foo=bar' \
-      --set 'global.extraLabels.baz=qux' \
-      . | tee /dev/stderr)
+      --set 'connectInject.sidecarProxy.tracing.enabled=true' \
+      --set 'connectInject.sidecarProxy.tracing.collectorAddress=otel-collector:4317' \
+      --set 'connectInject.sidecarProxy.tracing.samplingRate=25' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
 
-  local actualFoo=$(echo "${actual}" | yq -r '.metadata.labels.foo' | tee /dev/stderr)
-  local actualBaz=$(echo "${actual}" | yq -r '.metadata.labels.baz' | tee /dev/stderr)
-  [ "${actualFoo}" = "bar" ]
-  [ "${actualBaz}" = "qux" ]
-  local actualTemplateFoo=$(echo "${actual}" | yq -r '.spec.template.metadata.labels.foo' | tee /dev/stderr)
-  local actualTemplateBaz=$(echo "${actual}" | yq -r '.spec.template.metadata.labels.baz' | tee /dev/stderr)
-  [ "${actualTemplateFoo}" = "bar" ]
-  [ "${actualTemplateBaz}" = "qux" ]
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-enable-envoy-tracing=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-envoy-tracing-collector-address=\"otel-collector:4317\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-envoy-tracing-sampling-rate=25"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
 }
 
 #--------------------------------------------------------------------
-# annotations
+# sidecarProxy.dogstatsd
 
-@test "connectInject/Deployment: no annotations defined by default" {
+@test "connectInject/Deployment: by default envoy dogstatsd is not enabled" {
   cd `chart_dir`
-  local actual=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml \
       --set 'connectInject.enabled=true' \
       . | tee /dev/stderr |
-      yq -r '.spec.template.metadata.annotations | del(."consul.hashicorp.com/connect-inject")' | tee /dev/stderr)
-  [ "${actual}" = "{}" ]
-}
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
 
-@test "connectInject/Deployment: annotations can be set" {
-  cd `chart_dir`
-  local actual=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
-      --set 'connectInject.enabled=true' \
-      --set 'connectInject.annotations=foo: bar' \
-      . | tee /dev/stderr |
-      yq -r '.spec.template.metadata.annotations.foo' | tee /dev/stderr)
-  [ "${actual}" = "bar" ]
-}
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-enable-envoy-dogstatsd"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
 
-#--------------------------------------------------------------------
-# logLevel
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-envoy-dogstatsd-socket-path=\"/var/run/datadog/dsd.socket\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
 
-@test "connectInject/Deployment: logLevel info by default from global" {
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-envoy-dogstatsd-tag"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-envoy-dogstatsd-host-port"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-envoy-dogstatsd-pod-tags"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: envoy dogstatsd can be configured" {
   cd `chart_dir`
   local cmd=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
+      -s templates/connect-inject-deployment.yaml \
       --set 'connectInject.enabled=true' \
+      --set 'connectInject.sidecarProxy.dogstatsd.enabled=true' \
+      --set 'connectInject.sidecarProxy.dogstatsd.socketPath=/run/datadog/dsd.socket' \
+      --set 'connectInject.sidecarProxy.dogstatsd.tags={env:prod,team:payments}' \
       . | tee /dev/stderr |
       yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
 
   local actual=$(echo "$cmd" |
-    yq 'any(contains("-log-level=info"))' | tee /dev/stderr)
+    yq 'any(contains("-default-enable-envoy-dogstatsd=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-envoy-dogstatsd-socket-path=\"/run/datadog/dsd.socket\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-envoy-dogstatsd-tag=\"env:prod\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-default-envoy-dogstatsd-tag=\"team:payments\""))' | tee /dev/stderr)
   [ "${actual}" = "true" ]
 }
 
-@test "connectInject/Deployment: logLevel can be overridden" {
+@test "connectInject/Deployment: envoy dogstatsd can use the host port and pod tags" {
   cd `chart_dir`
   local cmd=$(helm template \
-      -s templates/connect-inject-deployment.yaml  \
+      -s templates/connect-inject-deployment.yaml \
       --set 'connectInject.enabled=true' \
-      --set 'connectInject.logLevel=debug' \
+      --set 'connectInject.sidecarProxy.dogstatsd.enabled=true' \
+      --set 'connectInject.sidecarProxy.dogstatsd.hostPort=8125' \
+      --set 'connectInject.sidecarProxy.dogstatsd.podTags=true' \
       . | tee /dev/stderr |
       yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
 
   local actual=$(echo "$cmd" |
-    yq 'any(contains("-log-level=debug"))' | tee /dev/stderr)
+    yq 'any(contains("-envoy-dogstatsd-host-port=8125"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-envoy-dogstatsd-pod-tags=true"))' | tee /dev/stderr)
   [ "${actual}" = "true" ]
 }
 
 #--------------------------------------------------------------------
-# transparent proxy
+# enableEndpointSlices
 
-@test "connectInject/Deployment: transparent proxy is enabled by default" {
+@test "connectInject/Deployment: endpoint slices are not enabled by default" {
   cd `chart_dir`
   local actual=$(helm template \
       -s templates/connect-inject-deployment.yaml  \
       --set 'connectInject.enabled=true' \
       . | tee /dev/stderr |
-      yq '.spec.template.spec.containers[0].command | any(contains("-enable-transparent-proxy=true"))' | tee /dev/stderr)
-
-  [ "${actual}" = "true" ]
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-endpoint-slices"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
 }
 
-@test "connectInject/Deployment: transparent proxy can be disabled by setting connectInject.transparentProxy.defaultEnabled=false" {
+@test "connectInject/Deployment: endpoint slices can be enabled" {
   cd `chart_dir`
   local actual=$(helm template \
       -s templates/connect-inject-deployment.yaml  \
       --set 'connectInject.enabled=true' \
-      --set 'connectInject.transparentProxy.defaultEnabled=false' \
+      --set 'connectInject.enableEndpointSlices=true' \
       . | tee /dev/stderr |
-      yq '.spec.template.spec.containers[0].command | any(contains("-enable-transparent-proxy=false"))' | tee /dev/stderr)
-
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-endpoint-slices=true"))' | tee /dev/stderr)
   [ "${actual}" = "true" ]
 }
 
-@test "connectInject/Deployment: overwrite probes is enabled by default" {
+#--------------------------------------------------------------------
+# endpointSliceResyncInterval
+
+@test "connectInject/Deployment: endpoint slice resync interval is not set by default" {
   cd `chart_dir`
   local actual=$(helm template \
       -s templates/connect-inject-deployment.yaml  \
       --set 'connectInject.enabled=true' \
+      --set 'connectInject.enableEndpointSlices=true' \
       . | tee /dev/stderr |
-      yq '.spec.template.spec.containers[0].command | any(contains("-transparent-proxy-default-overwrite-probes=true"))' | tee /dev/stderr)
+      yq '.spec.template.spec.containers[0].command | any(contains("-endpoint-slice-resync-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
 
+@test "connectInject/Deployment: endpoint slice resync interval can be set" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.enableEndpointSlices=true' \
+      --set 'connectInject.endpointSliceResyncInterval=5m' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-endpoint-slice-resync-interval=5m"))' | tee /dev/stderr)
   [ "${actual}" = "true" ]
 }
 
-@test "connectInject/Deployment: overwrite probes can be disabled" {
+@test "connectInject/Deployment: endpoint slice resync interval is not set when endpoint slices are disabled" {
   cd `chart_dir`
   local actual=$(helm template \
       -s templates/connect-inject-deployment.yaml  \
       --set 'connectInject.enabled=true' \
-      --set 'connectInject.transparentProxy.defaultOverwriteProbes=false' \
+      --set 'connectInject.endpointSliceResyncInterval=5m' \
       . | tee /dev/stderr |
-      yq '.spec.template.spec.containers[0].command | any(contains("-transparent-proxy-default-overwrite-probes=false"))' | tee /dev/stderr)
-
-  [ "${actual}" = "true" ]
+      yq '.spec.template.spec.containers[0].command | any(contains("-endpoint-slice-resync-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
 }
 
 #--------------------------------------------------------------------
-# cni
+# catalogCacheTTL
 
-@test "connectInject/Deployment: cni is disabled by default" {
+@test "connectInject/Deployment: catalog cache TTL is not set by default" {
   cd `chart_dir`
-  local cmd=$(helm template \
+  local actual=$(helm template \
       -s templates/connect-inject-deployment.yaml  \
       --set 'connectInject.enabled=true' \
       . | tee /dev/stderr |
-      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
-
-  local actual=$(echo "$cmd" |
-    yq 'any(contains("-enable-cni=false"))' | tee /dev/stderr)
-  [ "${actual}" = "true" ]
+      yq '.spec.template.spec.containers[0].command | any(contains("-endpoints-catalog-cache-ttl"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
 }
 
-@test "connectInject/Deployment: cni can be enabled by setting connectInject.cni.enabled=true" {
+@test "connectInject/Deployment: catalog cache TTL can be set" {
   cd `chart_dir`
-  local cmd=$(helm template \
+  local actual=$(helm template \
       -s templates/connect-inject-deployment.yaml  \
       --set 'connectInject.enabled=true' \
-      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.catalogCacheTTL=5s' \
       . | tee /dev/stderr |
-      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
-
-  local actual=$(echo "$cmd" |
-    yq 'any(contains("-enable-cni=true"))' | tee /dev/stderr)
+      yq '.spec.template.spec.containers[0].command | any(contains("-endpoints-catalog-cache-ttl=5s"))' | tee /dev/stderr)
   [ "${actual}" = "true" ]
 }
 
 #--------------------------------------------------------------------
-# peering
+# enableDualStackAddresses
 
-@test "connectInject/Deployment: peering is not set by default" {
+@test "connectInject/Deployment: dual-stack addresses are not registered by default" {
   cd `chart_dir`
   local actual=$(helm template \
       -s templates/connect-inject-deployment.yaml  \
       --set 'connectInject.enabled=true' \
       . | tee /dev/stderr |
-      yq '.spec.template.spec.containers[0].command | any(contains("-enable-peering=true"))' | tee /dev/stderr)
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-dual-stack-addresses"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: dual-stack addresses can be enabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.enableDualStackAddresses=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-dual-stack-addresses=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# validateConfigEntriesWithConsul
+
+@test "connectInject/Deployment: config entries are not validated with Consul by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-config-entry-consul-validation"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: config entries can be validated with Consul" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.validateConfigEntriesWithConsul=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-config-entry-consul-validation=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# configEntryResyncInterval
+
+@test "connectInject/Deployment: config entries are not resynced by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-config-entry-resync-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: config entry resync interval can be set" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.configEntryResyncInterval=5m' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-config-entry-resync-interval=5m"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# global.acls.tokenRotationInterval
+
+@test "connectInject/Deployment: ACL tokens are not rotated by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-acl-token-rotation-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: ACL token rotation interval can be set" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-acl-token-rotation-interval=24h"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: ACL tokens are not rotated without manageSystemACLs" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-acl-token-rotation-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+#--------------------------------------------------------------------
+# serviceAccountToken
+
+@test "connectInject/Deployment: projected service account tokens are disabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-projected-service-account-token"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: projected service account tokens can be enabled" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'connectInject.serviceAccountToken.projected=true' \
+      --set 'connectInject.serviceAccountToken.audience=consul' \
+      --set 'connectInject.serviceAccountToken.expiration=30m' \
+      . | tee /dev/stderr)
+
+  local actual=$(echo "$object" |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-projected-service-account-token=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" |
+      yq '.spec.template.spec.containers[0].command | any(contains("-service-account-token-audience=consul"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" |
+      yq '.spec.template.spec.containers[0].command | any(contains("-service-account-token-expiration=30m"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: projected service account tokens are not used without ACLs" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.serviceAccountToken.projected=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-projected-service-account-token"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: ACL token cleanup is disabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-acl-token-cleanup-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: ACL token cleanup interval can be set" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenCleanupInterval=1h' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-acl-token-cleanup-interval=1h"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: ACL tokens are not cleaned up without manageSystemACLs" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.tokenCleanupInterval=1h' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-acl-token-cleanup-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+#--------------------------------------------------------------------
+# server.snapshotAgent.snapshotSchedule
+
+@test "connectInject/Deployment: SnapshotSchedules are not reconciled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.snapshotAgent.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-snapshot-agent-"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: SnapshotSchedules are reconciled with server.snapshotAgent.snapshotSchedule.enabled=true" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'server.snapshotAgent.snapshotSchedule.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" | yq 'any(contains("-snapshot-agent-config-secret-name=release-name-consul-snapshot-agent-schedule"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+  local actual=$(echo "$cmd" | yq 'any(contains("-snapshot-agent-pod-selector=\"app=consul,release=release-name,component=server\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# server.upgradeController
+
+@test "connectInject/Deployment: server rollouts are not orchestrated by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-server-statefulset-name"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: server rollouts are orchestrated with server.upgradeController.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.upgradeController.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-server-statefulset-name=release-name-consul-server"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# connectInject.networkPolicyIntentions
+
+@test "connectInject/Deployment: NetworkPolicies are not translated by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-network-policy-intentions"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: NetworkPolicies are translated with connectInject.networkPolicyIntentions.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.networkPolicyIntentions.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-network-policy-intentions=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# connectInject.appProtocolServiceDefaults
+
+@test "connectInject/Deployment: ServiceDefaults are not generated from appProtocol by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-app-protocol-service-defaults"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: ServiceDefaults are generated from appProtocol with connectInject.appProtocolServiceDefaults.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.appProtocolServiceDefaults.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-app-protocol-service-defaults=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# connectInject.dryRun
+
+@test "connectInject/Deployment: dry-run mode is disabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-dry-run"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: dry-run mode is enabled with connectInject.dryRun=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.dryRun=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-dry-run=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# connectInject.auditLog
+
+@test "connectInject/Deployment: audit log is disabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("audit-log"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: audit log is enabled with connectInject.auditLog.enabled=true" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.auditLog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" | yq 'any(contains("-enable-audit-log=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" | yq 'any(contains("-audit-log-webhook-url"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: audit log webhook URL is set with connectInject.auditLog.webhookURL" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.auditLog.enabled=true' \
+      --set 'connectInject.auditLog.webhookURL=https://audit.example.com/events' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-audit-log-webhook-url=\"https://audit.example.com/events\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: fails if connectInject.auditLog.webhookURL is set without connectInject.auditLog.enabled" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.auditLog.webhookURL=https://audit.example.com/events' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.auditLog.enabled must be true if connectInject.auditLog.webhookURL is set" ]]
+}
+
+#--------------------------------------------------------------------
+# sharding
+
+@test "connectInject/Deployment: sharding is not configured by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-connect-injector" ]
+
+  local actual=$(echo "$object" | yq '.spec.selector.matchLabels | has("shard")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$object" | yq '.spec.template.spec.containers[0].command | any(contains("-shard-"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: fails if sharding.shardCount is less than 1" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.sharding.shardCount=0' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.sharding.shardCount must be at least 1" ]]
+}
+
+@test "connectInject/Deployment: renders one deployment per shard" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.sharding.shardCount=2' \
+      . | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -s 'length' | tee /dev/stderr)
+  [ "${actual}" = "2" ]
+
+  local actual=$(echo "$object" | yq -s -r '.[1].metadata.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-connect-injector-shard-1" ]
+
+  local actual=$(echo "$object" | yq -s -r '.[1].spec.selector.matchLabels.shard' | tee /dev/stderr)
+  [ "${actual}" = "1" ]
+
+  local actual=$(echo "$object" | yq -s -r '.[1].spec.template.metadata.labels.shard' | tee /dev/stderr)
+  [ "${actual}" = "1" ]
+
+  local actual=$(echo "$object" | yq -s '.[1].spec.template.spec.containers[0].command | any(contains("-shard-count=2"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" | yq -s '.[1].spec.template.spec.containers[0].command | any(contains("-shard-index=1"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# controllerTuning
+
+@test "connectInject/Deployment: controller tuning flags are not set by default" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("max-concurrent-reconciles") or contains("-reconcile-") or contains("-api-qps") or contains("-api-burst"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: controller tuning flags can be set" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.controllerTuning.endpointsMaxConcurrentReconciles=8' \
+      --set 'connectInject.controllerTuning.peeringMaxConcurrentReconciles=2' \
+      --set 'connectInject.controllerTuning.reconcileBaseDelay=10ms' \
+      --set 'connectInject.controllerTuning.reconcileMaxDelay=5m' \
+      --set 'connectInject.controllerTuning.reconcileQPS=50' \
+      --set 'connectInject.controllerTuning.reconcileBurst=500' \
+      --set 'connectInject.controllerTuning.kubeAPIQPS=100' \
+      --set 'connectInject.controllerTuning.kubeAPIBurst=200' \
+      --set 'connectInject.controllerTuning.consulAPIQPS=300' \
+      --set 'connectInject.controllerTuning.consulAPIBurst=600' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-endpoints-controller-max-concurrent-reconciles=8"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-peering-controller-max-concurrent-reconciles=2"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-reconcile-base-delay=10ms"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-reconcile-max-delay=5m"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-reconcile-qps=50"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-reconcile-burst=500"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-kube-api-qps=100"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-kube-api-burst=200"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-consul-api-qps=300"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-consul-api-burst=600"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: Consul API retry and circuit breaker flags are not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-consul-api-max-retries") or contains("-consul-api-retry-") or contains("-consul-api-circuit-breaker-"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: Consul API retry and circuit breaker flags can be set" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.controllerTuning.consulAPIMaxRetries=3' \
+      --set 'connectInject.controllerTuning.consulAPIRetryBackoff=200ms' \
+      --set 'connectInject.controllerTuning.consulAPIRetryBudget=5' \
+      --set 'connectInject.controllerTuning.consulAPICircuitBreakerThreshold=10' \
+      --set 'connectInject.controllerTuning.consulAPICircuitBreakerOpenDuration=1m' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-consul-api-max-retries=3"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-consul-api-retry-backoff=200ms"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-consul-api-retry-budget=5"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-consul-api-circuit-breaker-threshold=10"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-consul-api-circuit-breaker-open-duration=1m"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# windows
+
+@test "connectInject/Deployment: Windows images are not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-image-windows"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: Windows images can be set" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.windows.imageK8S=foo:windows' \
+      --set 'connectInject.windows.imageConsulDataplane=bar:windows' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-consul-k8s-image-windows=\"foo:windows\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-consul-dataplane-image-windows=\"bar:windows\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# priorityClassName
+
+@test "connectInject/Deployment: no priorityClassName by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.priorityClassName' | tee /dev/stderr)
+
+  [ "${actual}" = "null" ]
+}
+
+@test "connectInject/Deployment: can set a priorityClassName" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.priorityClassName=name' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.priorityClassName' | tee /dev/stderr)
+
+  [ "${actual}" = "name" ]
+}
+
+#--------------------------------------------------------------------
+# extraLabels
+
+@test "connectInject/Deployment: no extra labels defined by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.labels | del(."app") | del(."chart") | del(."release") | del(."component")' | tee /dev/stderr)
+  [ "${actual}" = "{}" ]
+}
+
+@test "connectInject/Deployment: can set extra labels" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.extraLabels.foo=bar' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.labels.foo' | tee /dev/stderr)
+
+  [ "${actual}" = "bar" ]
+}
+
+@test "connectInject/Deployment: can set extra global labels" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.extraLabels.foo=bar' \
+      . | tee /dev/stderr)
+  local actualBar=$(echo "${actual}" | yq -r '.metadata.labels.foo' | tee /dev/stderr)
+  [ "${actualBar}" = "bar" ]
+  local actualTemplateBar=$(echo "${actual}" | yq -r '.spec.template.metadata.labels.foo' | tee /dev/stderr)
+  [ "${actualTemplateBar}" = "bar" ]
+}
+
+@test "connectInject/Deployment: can set multiple extra global labels" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.extraLabels.foo=bar' \
+      --set 'global.extraLabels.baz=qux' \
+      . | tee /dev/stderr)
+
+  local actualFoo=$(echo "${actual}" | yq -r '.metadata.labels.foo' | tee /dev/stderr)
+  local actualBaz=$(echo "${actual}" | yq -r '.metadata.labels.baz' | tee /dev/stderr)
+  [ "${actualFoo}" = "bar" ]
+  [ "${actualBaz}" = "qux" ]
+  local actualTemplateFoo=$(echo "${actual}" | yq -r '.spec.template.metadata.labels.foo' | tee /dev/stderr)
+  local actualTemplateBaz=$(echo "${actual}" | yq -r '.spec.template.metadata.labels.baz' | tee /dev/stderr)
+  [ "${actualTemplateFoo}" = "bar" ]
+  [ "${actualTemplateBaz}" = "qux" ]
+}
+
+#--------------------------------------------------------------------
+# annotations
+
+@test "connectInject/Deployment: no annotations defined by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.annotations | del(."consul.hashicorp.com/connect-inject")' | tee /dev/stderr)
+  [ "${actual}" = "{}" ]
+}
+
+@test "connectInject/Deployment: annotations can be set" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.annotations=foo: bar' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.metadata.annotations.foo' | tee /dev/stderr)
+  [ "${actual}" = "bar" ]
+}
+
+#--------------------------------------------------------------------
+# logLevel
+
+@test "connectInject/Deployment: logLevel info by default from global" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-log-level=info"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: logLevel can be overridden" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.logLevel=debug' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-log-level=debug"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: log level ConfigMap disabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-log-level-configmap"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: log level ConfigMap can be enabled with global.logLevelConfigMap.enabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.logLevelConfigMap.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-log-level-configmap=release-name-consul-log-levels"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: JSON logs use zap's keys by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-log-json-hclog-keys"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: JSON logs can use hclog's keys with global.logJSONHCLogKeys" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.logJSONHCLogKeys=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-log-json-hclog-keys=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# transparent proxy
+
+@test "connectInject/Deployment: transparent proxy is enabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-transparent-proxy=true"))' | tee /dev/stderr)
+
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: transparent proxy can be disabled by setting connectInject.transparentProxy.defaultEnabled=false" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.transparentProxy.defaultEnabled=false' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-transparent-proxy=false"))' | tee /dev/stderr)
+
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: overwrite probes is enabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-transparent-proxy-default-overwrite-probes=true"))' | tee /dev/stderr)
+
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: overwrite probes can be disabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.transparentProxy.defaultOverwriteProbes=false' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-transparent-proxy-default-overwrite-probes=false"))' | tee /dev/stderr)
+
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: IPv6 traffic redirection is disabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-transparent-proxy-default-enable-ipv6"))' | tee /dev/stderr)
+
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: IPv6 traffic redirection can be enabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.transparentProxy.defaultEnableIPv6=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-transparent-proxy-default-enable-ipv6=true"))' | tee /dev/stderr)
+
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# cni
+
+@test "connectInject/Deployment: cni is disabled by default" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-enable-cni=false"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: cni can be enabled by setting connectInject.cni.enabled=true" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-enable-cni=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: cni redirection is not required by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-cni-require-redirection"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: cni redirection can be required by setting connectInject.cni.requireRedirection=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.enabled=true' \
+      --set 'connectInject.cni.requireRedirection=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-cni-require-redirection=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: cni redirection is not required when cni is disabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.cni.requireRedirection=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-cni-require-redirection"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+#--------------------------------------------------------------------
+# peering
+
+@test "connectInject/Deployment: peering is not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-peering=true"))' | tee /dev/stderr)
 
   [ "${actual}" = "false" ]
 }
@@ -1613,36 +2638,112 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
-@test "connectInject/Deployment: fails if peering is enabled but connect inject is not" {
+@test "connectInject/Deployment: fails if peering is enabled but connect inject is not" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=false' \
+      --set 'global.peering.enabled=true' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "setting global.peering.enabled to true requires connectInject.enabled to be true" ]]
+}
+
+@test "connectInject/Deployment: fails if peering is enabled but tls is not" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'global.peering.enabled=true' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "setting global.peering.enabled to true requires global.tls.enabled to be true" ]]
+}
+
+@test "connectInject/Deployment: fails if peering is enabled but mesh gateways are not" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'global.peering.enabled=true' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "setting global.peering.enabled to true requires meshGateway.enabled to be true" ]]
+}
+
+@test "connectInject/Deployment: -peer-through-mesh-gateways is not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.peering.enabled=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-peer-through-mesh-gateways"))' | tee /dev/stderr)
+
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: -peer-through-mesh-gateways is set when global.peering.peerThroughMeshGateways is true" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.peering.enabled=true' \
+      --set 'global.peering.peerThroughMeshGateways=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'meshGateway.consulServiceName=my-mesh-gateway' \
+      --set 'global.tls.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-peer-through-mesh-gateways=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-mesh-gateway-consul-service-name=my-mesh-gateway"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: fails if peerThroughMeshGateways is enabled but peering is not" {
   cd `chart_dir`
   run helm template \
       -s templates/connect-inject-deployment.yaml  \
-      --set 'connectInject.enabled=false' \
-      --set 'global.peering.enabled=true' .
+      --set 'connectInject.enabled=true' \
+      --set 'global.peering.peerThroughMeshGateways=true' .
   [ "$status" -eq 1 ]
-  [[ "$output" =~ "setting global.peering.enabled to true requires connectInject.enabled to be true" ]]
+  [[ "$output" =~ "setting global.peering.peerThroughMeshGateways to true requires global.peering.enabled to be true" ]]
 }
 
-@test "connectInject/Deployment: fails if peering is enabled but tls is not" {
+@test "connectInject/Deployment: fails if peerThroughMeshGateways is enabled in a non-default partition" {
   cd `chart_dir`
   run helm template \
       -s templates/connect-inject-deployment.yaml  \
       --set 'connectInject.enabled=true' \
+      --set 'global.peering.enabled=true' \
+      --set 'global.peering.peerThroughMeshGateways=true' \
       --set 'meshGateway.enabled=true' \
-      --set 'global.peering.enabled=true' .
+      --set 'global.tls.enabled=true' \
+      --set 'global.enableConsulNamespaces=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.adminPartitions.name=foo' .
   [ "$status" -eq 1 ]
-  [[ "$output" =~ "setting global.peering.enabled to true requires global.tls.enabled to be true" ]]
+  [[ "$output" =~ "global.peering.peerThroughMeshGateways can only be set in the default partition" ]]
 }
 
-@test "connectInject/Deployment: fails if peering is enabled but mesh gateways are not" {
+@test "connectInject/Deployment: fails if peerThroughMeshGateways is enabled and the mesh gateway WAN address is a ClusterIP" {
   cd `chart_dir`
   run helm template \
       -s templates/connect-inject-deployment.yaml  \
       --set 'connectInject.enabled=true' \
-      --set 'global.tls.enabled=true' \
-      --set 'global.peering.enabled=true' .
+      --set 'global.peering.enabled=true' \
+      --set 'global.peering.peerThroughMeshGateways=true' \
+      --set 'meshGateway.enabled=true' \
+      --set 'meshGateway.service.type=ClusterIP' \
+      --set 'global.tls.enabled=true' .
   [ "$status" -eq 1 ]
-  [[ "$output" =~ "setting global.peering.enabled to true requires meshGateway.enabled to be true" ]]
+  [[ "$output" =~ "global.peering.peerThroughMeshGateways requires a mesh gateway WAN address that is reachable from peers" ]]
 }
 
 #--------------------------------------------------------------------
@@ -1706,6 +2807,50 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+#--------------------------------------------------------------------
+# labelsToService
+
+@test "connectInject/Deployment: labelsToService is not set by default" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-label-to-tag"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-label-to-meta"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: can set labelsToService" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml \
+      --set 'connectInject.enabled=true' \
+      --set 'connectInject.labelsToService.tags[0]=version' \
+      --set 'connectInject.labelsToService.tags[1]=tier' \
+      --set 'connectInject.labelsToService.meta.app\.kubernetes\.io/version=version' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-label-to-tag=version"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-label-to-tag=tier"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-label-to-meta=app.kubernetes.io/version=version"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 #--------------------------------------------------------------------
 # replicas
 
@@ -1732,6 +2877,73 @@ load _helpers
   [ "${actual}" = "3" ]
 }
 
+#--------------------------------------------------------------------
+# global.tls.controlPlane
+
+@test "connectInject/Deployment: TLS version and cipher suites are not set by default" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-tls-min-version"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-tls-cipher-suite"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: TLS version and cipher suites can be set" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.tls.controlPlane.minVersion=TLSv1_2' \
+      --set 'global.tls.controlPlane.cipherSuites[0]=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256' \
+      --set 'global.tls.controlPlane.cipherSuites[1]=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-tls-min-version=TLSv1_2"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-tls-cipher-suite=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-tls-cipher-suite=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: fails if the TLS version is not supported" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.tls.controlPlane.minVersion=TLSv1_1' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.tls.controlPlane.minVersion must be \"TLSv1_2\" or \"TLSv1_3\"" ]]
+}
+
+@test "connectInject/Deployment: fails if cipher suites are set with TLS 1.3" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.tls.controlPlane.minVersion=TLSv1_3' \
+      --set 'global.tls.controlPlane.cipherSuites[0]=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.tls.controlPlane.cipherSuites can't be set if global.tls.controlPlane.minVersion is \"TLSv1_3\"" ]]
+}
+
 #--------------------------------------------------------------------
 # Vault
 
@@ -1821,6 +3033,50 @@ load _helpers
 #--------------------------------------------------------------------
 # Vault
 
+@test "connectInject/Deployment: secret-reload-interval is not set without vault" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-secret-reload-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/Deployment: secret-reload-interval is set when TLS and vault are enabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'global.tls.caCert.secretName=foo' \
+      --set 'global.secretsBackend.vault.enabled=true' \
+      --set 'global.secretsBackend.vault.consulClientRole=foo' \
+      --set 'global.secretsBackend.vault.consulServerRole=bar' \
+      --set 'global.secretsBackend.vault.consulCARole=test2' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-secret-reload-interval=1m"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/Deployment: secret-reload-interval can be disabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'global.tls.caCert.secretName=foo' \
+      --set 'global.secretsBackend.vault.enabled=true' \
+      --set 'global.secretsBackend.vault.consulClientRole=foo' \
+      --set 'global.secretsBackend.vault.consulServerRole=bar' \
+      --set 'global.secretsBackend.vault.consulCARole=test2' \
+      --set 'global.secretsBackend.vault.secretReloadInterval=' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-secret-reload-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
 @test "connectInject/Deployment: vault CA is not configured by default" {
   cd `chart_dir`
   local object=$(helm template \
@@ -2345,6 +3601,102 @@ reservedNameTest() {
   [ "${actual}" = "true" ]
 }
 
+@test "connectInject/Deployment: fails if externalServers.clusterName is set without externalServers.enabled" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml \
+      --set 'connectInject.enabled=true' \
+      --set 'externalServers.clusterName=east' \
+       .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "externalServers.clusterName can only be set if externalServers.enabled is true" ]]
+}
+
+@test "connectInject/Deployment: fails if externalServers.clusterName is invalid" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml \
+      --set 'connectInject.enabled=true' \
+      --set 'server.enabled=false' \
+      --set 'externalServers.enabled=true' \
+      --set 'externalServers.hosts[0]=consul' \
+      --set 'externalServers.clusterName=East_1' \
+       .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "externalServers.clusterName must be at most 63 characters long" ]]
+}
+
+@test "connectInject/Deployment: sets the cluster name and its auth methods with externalServers.clusterName" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.enabled=false' \
+      --set 'externalServers.enabled=true' \
+      --set 'externalServers.hosts[0]=consul' \
+      --set 'externalServers.clusterName=east' \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0]' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq '.command | any(contains("-cluster-name=east"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  actual=$(echo "$object" | yq '.command | any(contains("-acl-auth-method=\"release-name-consul-east-k8s-auth-method\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  actual=$(echo "$object" | yq -r '.env[] | select(.name == "CONSUL_LOGIN_AUTH_METHOD").value' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-east-k8s-component-auth-method" ]
+}
+
+#--------------------------------------------------------------------
+# global.serverConnectionManager
+
+@test "connectInject/Deployment: server connection manager env not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '[.spec.template.spec.containers[0].env[] | select(.name | test("CONSUL_BACKOFF_|CONSUL_SERVER_"))] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "connectInject/Deployment: configures the server connection manager with global.serverConnectionManager" {
+  cd `chart_dir`
+  local env=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.serverConnectionManager.backoff.initialInterval=1s' \
+      --set 'global.serverConnectionManager.backoff.maxInterval=2m' \
+      --set 'global.serverConnectionManager.backoff.resetInterval=10m' \
+      --set 'global.serverConnectionManager.serverWatchDisabledInterval=30s' \
+      --set 'global.serverConnectionManager.serverCIDRs[0]=10.0.0.0/16' \
+      --set 'global.serverConnectionManager.serverCIDRs[1]=10.1.0.0/16' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].env[]' | tee /dev/stderr)
+
+  local actual=$(echo "$env" |
+    jq -r '. | select( .name == "CONSUL_BACKOFF_INITIAL_INTERVAL").value' | tee /dev/stderr)
+  [ "${actual}" = "1s" ]
+
+  local actual=$(echo "$env" |
+    jq -r '. | select( .name == "CONSUL_BACKOFF_MAX_INTERVAL").value' | tee /dev/stderr)
+  [ "${actual}" = "2m" ]
+
+  local actual=$(echo "$env" |
+    jq -r '. | select( .name == "CONSUL_BACKOFF_RESET_INTERVAL").value' | tee /dev/stderr)
+  [ "${actual}" = "10m" ]
+
+  local actual=$(echo "$env" |
+    jq -r '. | select( .name == "CONSUL_SERVER_WATCH_DISABLED_INTERVAL").value' | tee /dev/stderr)
+  [ "${actual}" = "30s" ]
+
+  local actual=$(echo "$env" |
+    jq -r '. | select( .name == "CONSUL_SERVER_CIDRS").value' | tee /dev/stderr)
+  [ "${actual}" = "10.0.0.0/16,10.1.0.0/16" ]
+}
+
 #--------------------------------------------------------------------
 # global.cloud
 
@@ -2587,3 +3939,87 @@ reservedNameTest() {
     jq -r '. | select( .name == "CONSUL_TLS_SERVER_NAME").value' | tee /dev/stderr)
   [ "${actual}" = "server.dc1.consul" ]
 }
+
+#--------------------------------------------------------------------
+# global.spire.connectCA
+
+@test "connectInject/Deployment: SPIRE Connect CA is not configured by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" |
+    yq '.containers[0].command | any(contains("-enable-spire-connect-ca"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$object" |
+    yq '.volumes | map(select(.name == "spire-agent-socket")) | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "connectInject/Deployment: SPIRE Connect CA is configured with global.spire.connectCA.enabled=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.spire.connectCA.enabled=true' \
+      --set 'global.spire.connectCA.serverAddress=spire-server.spire:8081' \
+      --set 'global.spire.connectCA.agentSocketDir=/run/spire/agent-sockets' \
+      --set 'global.spire.connectCA.agentSocketName=spire-agent.sock' \
+      --set 'global.spire.connectCA.caTTL=48h' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local cmd=$(echo "$object" | yq '.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-enable-spire-connect-ca=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-spire-agent-socket-path=/run/spire/sockets/spire-agent.sock"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-spire-server-address=spire-server.spire:8081"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-spire-ca-ttl=48h"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" |
+    yq -r '.volumes[] | select(.name == "spire-agent-socket") | .hostPath.path' | tee /dev/stderr)
+  [ "${actual}" = "/run/spire/agent-sockets" ]
+
+  local actual=$(echo "$object" |
+    yq -r '.containers[0].volumeMounts[] | select(.name == "spire-agent-socket") | .mountPath' | tee /dev/stderr)
+  [ "${actual}" = "/run/spire/sockets" ]
+}
+
+@test "connectInject/Deployment: fails if global.spire.connectCA.enabled is true without a server address" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.spire.connectCA.enabled=true' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.spire.connectCA.serverAddress must be set if global.spire.connectCA.enabled is true" ]]
+}
+
+@test "connectInject/Deployment: fails if global.spire.connectCA.enabled is true in a non-default partition" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/connect-inject-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'global.spire.connectCA.enabled=true' \
+      --set 'global.spire.connectCA.serverAddress=spire-server.spire:8081' \
+      --set 'global.enableConsulNamespaces=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.adminPartitions.name=foo' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.spire.connectCA.enabled is only supported if global.adminPartitions.name is \"default\"" ]]
+}
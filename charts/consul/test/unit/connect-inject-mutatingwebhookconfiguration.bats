@@ -72,3 +72,46 @@ load _helpers
       yq '.webhooks[13].name | contains("peeringdialers.consul.hashicorp.com")' | tee /dev/stderr)
   [ "${actual}" = "true" ]
 }
+
+@test "connectInject/MutatingWebhookConfiguration: webhook for registrations exists" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-mutatingwebhookconfiguration.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '[.webhooks[] | select(.name == "mutate-registrations.consul.hashicorp.com")] | length == 1' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "connectInject/MutatingWebhookConfiguration: webhook for samenessgroups exists when peering is disabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-mutatingwebhookconfiguration.yaml  \
+      --set 'connectInject.enabled=true' \
+      . | tee /dev/stderr |
+      yq '[.webhooks[] | select(.name == "mutate-samenessgroup.consul.hashicorp.com")] | length == 1' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# webhookCertManager.certManager
+
+@test "connectInject/MutatingWebhookConfiguration: no cert-manager CA injection by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-mutatingwebhookconfiguration.yaml  \
+      . | tee /dev/stderr |
+      yq -r '.metadata.annotations["cert-manager.io/inject-ca-from"]' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
+@test "connectInject/MutatingWebhookConfiguration: CA is injected by cert-manager with webhookCertManager.certManager.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-mutatingwebhookconfiguration.yaml  \
+      --set 'webhookCertManager.certManager.enabled=true' \
+      --set 'webhookCertManager.certManager.issuerRef.name=ca-issuer' \
+      . | tee /dev/stderr |
+      yq -r '.metadata.annotations["cert-manager.io/inject-ca-from"]' | tee /dev/stderr)
+  [ "${actual}" = "default/release-name-consul-connect-inject-webhook-cert" ]
+}
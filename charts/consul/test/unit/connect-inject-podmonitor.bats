@@ -0,0 +1,109 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "connectInject/PodMonitor: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-podmonitor.yaml  \
+      .
+}
+
+@test "connectInject/PodMonitor: disabled with global.metrics.prometheusOperator.enabled=true and global.metrics.enabled=false" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-podmonitor.yaml  \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      .
+}
+
+@test "connectInject/PodMonitor: disabled with connectInject.enabled=false" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-podmonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      --set 'connectInject.enabled=false' \
+      .
+}
+
+@test "connectInject/PodMonitor: selects connect-injected pods in all namespaces" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s templates/connect-inject-podmonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | yq '.namespaceSelector.any' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$spec" | yq -r '.selector.matchLabels."consul.hashicorp.com/connect-inject-status"' | tee /dev/stderr)
+  [ "${actual}" = "injected" ]
+
+  local actual=$(echo "$spec" | yq -r '.podMetricsEndpoints[0].port' | tee /dev/stderr)
+  [ "${actual}" = "prometheus" ]
+
+  local actual=$(echo "$spec" | yq -r '.podMetricsEndpoints[0].path' | tee /dev/stderr)
+  [ "${actual}" = "/metrics" ]
+
+  local actual=$(echo "$spec" | yq -r '.podMetricsEndpoints[0].relabelings[0].targetLabel' | tee /dev/stderr)
+  [ "${actual}" = "__metrics_path__" ]
+
+  local actual=$(echo "$spec" | yq '.podMetricsEndpoints[0] | has("interval")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/PodMonitor: uses connectInject.metrics.defaultPrometheusScrapePath" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/connect-inject-podmonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      --set 'connectInject.metrics.defaultPrometheusScrapePath=/scrape' \
+      . | tee /dev/stderr |
+      yq -r '.spec.podMetricsEndpoints[0].path' | tee /dev/stderr)
+  [ "${actual}" = "/scrape" ]
+}
+
+@test "connectInject/PodMonitor: can set labels, interval, timeout and relabelings" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/connect-inject-podmonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      --set 'global.metrics.prometheusOperator.additionalLabels.prometheus=kube-prometheus' \
+      --set 'global.metrics.prometheusOperator.interval=30s' \
+      --set 'global.metrics.prometheusOperator.scrapeTimeout=10s' \
+      --set 'global.metrics.prometheusOperator.relabelings[0].sourceLabels[0]=__meta_kubernetes_pod_node_name' \
+      --set 'global.metrics.prometheusOperator.relabelings[0].targetLabel=node' \
+      --set 'global.metrics.prometheusOperator.metricRelabelings[0].sourceLabels[0]=__name__' \
+      --set 'global.metrics.prometheusOperator.metricRelabelings[0].regex=envoy_.*_bucket' \
+      --set 'global.metrics.prometheusOperator.metricRelabelings[0].action=drop' \
+      . | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.labels.prometheus' | tee /dev/stderr)
+  [ "${actual}" = "kube-prometheus" ]
+
+  local endpoint=$(echo "$object" | yq '.spec.podMetricsEndpoints[0]' | tee /dev/stderr)
+
+  local actual=$(echo "$endpoint" | yq -r '.interval' | tee /dev/stderr)
+  [ "${actual}" = "30s" ]
+
+  local actual=$(echo "$endpoint" | yq -r '.scrapeTimeout' | tee /dev/stderr)
+  [ "${actual}" = "10s" ]
+
+  # The chart's relabelings are applied first.
+  local actual=$(echo "$endpoint" | yq -r '.relabelings[0].targetLabel' | tee /dev/stderr)
+  [ "${actual}" = "__metrics_path__" ]
+
+  local actual=$(echo "$endpoint" | yq -r '.relabelings[1].targetLabel' | tee /dev/stderr)
+  [ "${actual}" = "node" ]
+
+  local actual=$(echo "$endpoint" | yq -r '.metricRelabelings[0].action' | tee /dev/stderr)
+  [ "${actual}" = "drop" ]
+
+  local actual=$(echo "$endpoint" | yq -r '.metricRelabelings[0].regex' | tee /dev/stderr)
+  [ "${actual}" = "envoy_.*_bucket" ]
+}
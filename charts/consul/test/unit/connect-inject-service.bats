@@ -39,3 +39,22 @@ load _helpers
       --set 'global.enabled=false' \
       .
 }
+
+@test "connectInject/Service: exposes the metrics port with global.metrics.prometheusOperator.enabled=true" {
+  cd `chart_dir`
+  local ports=$(helm template \
+      -s templates/connect-inject-service.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.ports' | tee /dev/stderr)
+
+  local actual=$(echo "$ports" | yq -r '.[0].name' | tee /dev/stderr)
+  [ "${actual}" = "webhook" ]
+
+  local actual=$(echo "$ports" | yq -r '.[1].name' | tee /dev/stderr)
+  [ "${actual}" = "metrics" ]
+
+  local actual=$(echo "$ports" | yq -r '.[1].port' | tee /dev/stderr)
+  [ "${actual}" = "9444" ]
+}
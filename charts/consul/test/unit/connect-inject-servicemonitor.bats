@@ -0,0 +1,62 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "connectInject/ServiceMonitor: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-servicemonitor.yaml  \
+      .
+}
+
+@test "connectInject/ServiceMonitor: disabled with connectInject.enabled=false" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/connect-inject-servicemonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      --set 'connectInject.enabled=false' \
+      .
+}
+
+@test "connectInject/ServiceMonitor: scrapes the metrics port of the connect injector service" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s templates/connect-inject-servicemonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      --namespace foo \
+      . | tee /dev/stderr |
+      yq '.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | yq -r '.namespaceSelector.matchNames[0]' | tee /dev/stderr)
+  [ "${actual}" = "foo" ]
+
+  local actual=$(echo "$spec" | yq -r '.selector.matchLabels.component' | tee /dev/stderr)
+  [ "${actual}" = "connect-injector" ]
+
+  local actual=$(echo "$spec" | yq -r '.endpoints[0].port' | tee /dev/stderr)
+  [ "${actual}" = "metrics" ]
+
+  local actual=$(echo "$spec" | yq '.endpoints[0] | has("relabelings")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "connectInject/ServiceMonitor: can set relabelings" {
+  cd `chart_dir`
+  local endpoint=$(helm template \
+      -s templates/connect-inject-servicemonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      --set 'global.metrics.prometheusOperator.relabelings[0].targetLabel=cluster' \
+      --set 'global.metrics.prometheusOperator.relabelings[0].replacement=dc1' \
+      --set 'global.metrics.prometheusOperator.metricRelabelings[0].action=labeldrop' \
+      . | tee /dev/stderr |
+      yq '.spec.endpoints[0]' | tee /dev/stderr)
+
+  local actual=$(echo "$endpoint" | yq -r '.relabelings[0].targetLabel' | tee /dev/stderr)
+  [ "${actual}" = "cluster" ]
+
+  local actual=$(echo "$endpoint" | yq -r '.metricRelabelings[0].action' | tee /dev/stderr)
+  [ "${actual}" = "labeldrop" ]
+}
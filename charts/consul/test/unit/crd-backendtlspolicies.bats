@@ -0,0 +1,28 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "backendtlspolicies/CustomResourceDefinition: enabled by default" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s templates/crd-backendtlspolicies.yaml \
+        . | tee /dev/stderr |
+        yq 'length > 0' | tee /dev/stderr)
+    [ "$actual" = "true" ]
+}
+
+@test "backendtlspolicies/CustomResourceDefinition: disabled with connectInject.enabled=false" {
+    cd `chart_dir`
+    assert_empty helm template \
+        -s templates/crd-backendtlspolicies.yaml \
+        --set 'connectInject.enabled=false' \
+        . 
+}
+
+@test "backendtlspolicies/CustomResourceDefinition: disabled with connectInject.apiGateway.manageExternalCRDs=false" {
+    cd `chart_dir`
+    assert_empty helm template \
+        -s templates/crd-backendtlspolicies.yaml \
+        --set 'connectInject.apiGateway.manageExternalCRDs=false' \
+        . 
+}
@@ -26,3 +26,12 @@ load _helpers
         --set 'connectInject.apiGateway.manageExternalCRDs=false' \
         . 
 }
+
+@test "gateways/CustomResourceDefinition: bundles Gateway API v1.1" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s templates/crd-gateways.yaml \
+        . | tee /dev/stderr |
+        yq -r '.metadata.annotations["gateway.networking.k8s.io/bundle-version"]' | tee /dev/stderr)
+    [ "${actual}" = "v1.1.0" ]
+}
@@ -0,0 +1,26 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "dnsProxy/CoreDNSCleanupJob: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/dns-proxy-coredns-cleanup-job.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      .
+}
+
+@test "dnsProxy/CoreDNSCleanupJob: removes the CoreDNS server block before the release is deleted" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-coredns-cleanup-job.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.coreDNS.enabled=true' \
+      . | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations["helm.sh/hook"]' | tee /dev/stderr)
+  [ "${actual}" = "pre-delete" ]
+
+  local actual=$(echo "$object" | yq -r '.spec.template.spec.containers[0].command | join(" ") | contains("-remove")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
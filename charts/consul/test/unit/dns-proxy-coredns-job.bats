@@ -0,0 +1,53 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "dnsProxy/CoreDNSJob: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/dns-proxy-coredns-job.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      .
+}
+
+@test "dnsProxy/CoreDNSJob: fails if dns.proxy.coreDNS.enabled=true and dns.proxy.enabled=false" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/dns-proxy-coredns-job.yaml  \
+      --set 'dns.proxy.coreDNS.enabled=true' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "dns.proxy.enabled must be true if dns.proxy.coreDNS.enabled is true" ]]
+}
+
+@test "dnsProxy/CoreDNSJob: configures the CoreDNS ConfigMap after install and upgrade" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-coredns-job.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.coreDNS.enabled=true' \
+      --set 'dns.proxy.coreDNS.namespace=dns' \
+      --set 'dns.proxy.coreDNS.configMapName=custom-coredns' \
+      --set 'global.domain=example' \
+      . | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations["helm.sh/hook"]' | tee /dev/stderr)
+  [ "${actual}" = "post-install,post-upgrade" ]
+
+  local cmd=$(echo "$object" | yq -r '.spec.template.spec.containers[0].command | join(" ")' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" | yq 'contains("-dns-service-name=release-name-consul-dns-proxy")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" | yq 'contains("-coredns-namespace=dns")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" | yq 'contains("-coredns-configmap=custom-coredns")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" | yq 'contains("-domain=example")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" | yq 'contains("-remove")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
@@ -0,0 +1,32 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "dnsProxy/CoreDNSRole: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/dns-proxy-coredns-role.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      .
+}
+
+@test "dnsProxy/CoreDNSRole: allows updating only the CoreDNS ConfigMap" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-coredns-role.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.coreDNS.enabled=true' \
+      --set 'dns.proxy.coreDNS.namespace=dns' \
+      --set 'dns.proxy.coreDNS.configMapName=custom-coredns' \
+      . | tee /dev/stderr |
+      yq -s '.[1]' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.namespace' | tee /dev/stderr)
+  [ "${actual}" = "dns" ]
+
+  local actual=$(echo "$object" | yq -r '.rules[0].resourceNames[0]' | tee /dev/stderr)
+  [ "${actual}" = "custom-coredns" ]
+
+  local actual=$(echo "$object" | yq -c '.rules[0].verbs' | tee /dev/stderr)
+  [ "${actual}" = '["get","update"]' ]
+}
@@ -0,0 +1,27 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "dnsProxy/CoreDNSRoleBinding: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/dns-proxy-coredns-rolebinding.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      .
+}
+
+@test "dnsProxy/CoreDNSRoleBinding: binds the CoreDNS namespace Role to the Job's service account" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-coredns-rolebinding.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.coreDNS.enabled=true' \
+      . | tee /dev/stderr |
+      yq -s '.[1]' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.namespace' | tee /dev/stderr)
+  [ "${actual}" = "kube-system" ]
+
+  local actual=$(echo "$object" | yq -r '.subjects[0].namespace' | tee /dev/stderr)
+  [ "${actual}" = "default" ]
+}
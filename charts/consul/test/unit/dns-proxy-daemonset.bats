@@ -0,0 +1,164 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "dnsProxy/DaemonSet: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/dns-proxy-daemonset.yaml  \
+      .
+}
+
+@test "dnsProxy/DaemonSet: enabled with dns.proxy.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/dns-proxy-daemonset.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.image=foo' \
+      . | tee /dev/stderr |
+      yq 'length > 0' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "dnsProxy/DaemonSet: runs consul-dataplane in DNS proxy mode" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-daemonset.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.image=foo' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0]' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.command[0]' | tee /dev/stderr)
+  [ "${actual}" = "consul-dataplane" ]
+
+  local actual=$(echo "$object" | yq '.args | any(contains("-mode=dns-proxy"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" | yq '.args | any(contains("-consul-dns-bind-port=8600"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" | yq '.args | any(contains("-addresses=release-name-consul-server.default.svc"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" | yq '.args | any(contains("-tls-disabled"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "dnsProxy/DaemonSet: fails if dns.proxy.image is not set" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/dns-proxy-daemonset.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "dns.proxy.image must be set to a consul-dataplane 1.4.0+ image" ]]
+}
+
+@test "dnsProxy/DaemonSet: image can be set with dns.proxy.image" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/dns-proxy-daemonset.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'global.imageConsulDataplane=bar' \
+      --set 'dns.proxy.image=foo' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].image' | tee /dev/stderr)
+  [ "${actual}" = "foo" ]
+}
+
+@test "dnsProxy/DaemonSet: uses external servers when externalServers.enabled=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-daemonset.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.image=foo' \
+      --set 'server.enabled=false' \
+      --set 'externalServers.enabled=true' \
+      --set 'externalServers.hosts[0]=consul.example.com' \
+      --set 'externalServers.grpcPort=9502' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].args' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq 'any(contains("-addresses=consul.example.com"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" | yq 'any(contains("-grpc-port=9502"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# global.tls.enabled
+
+@test "dnsProxy/DaemonSet: mounts the CA certificate when global.tls.enabled=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-daemonset.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.image=foo' \
+      --set 'global.tls.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq '.containers[0].args | any(contains("-ca-certs=/consul/tls/ca/tls.crt"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" | yq '.containers[0].args | any(contains("-tls-disabled"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$object" | yq -r '.volumes[0].secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-ca-cert" ]
+
+  local actual=$(echo "$object" | yq -r '.containers[0].volumeMounts[0].mountPath' | tee /dev/stderr)
+  [ "${actual}" = "/consul/tls/ca" ]
+}
+
+@test "dnsProxy/DaemonSet: uses the Vault CA certificate when global.secretsBackend.vault.enabled=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-daemonset.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.image=foo' \
+      --set 'global.tls.enabled=true' \
+      --set 'global.tls.caCert.secretName=pki_int/issue/test' \
+      --set 'global.secretsBackend.vault.enabled=true' \
+      --set 'global.secretsBackend.vault.consulClientRole=foo' \
+      --set 'global.secretsBackend.vault.consulServerRole=bar' \
+      --set 'global.secretsBackend.vault.consulCARole=carole' \
+      . | tee /dev/stderr |
+      yq '.spec.template' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq '.spec.containers[0].args | any(contains("-ca-certs=/vault/secrets/serverca.crt"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations["vault.hashicorp.com/role"]' | tee /dev/stderr)
+  [ "${actual}" = "carole" ]
+
+  local actual=$(echo "$object" | yq '.spec.volumes' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
+#--------------------------------------------------------------------
+# scheduling
+
+@test "dnsProxy/DaemonSet: priorityClassName, tolerations and nodeSelector can be set" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-daemonset.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.image=foo' \
+      --set 'dns.proxy.priorityClassName=system-node-critical' \
+      --set 'dns.proxy.tolerations=- key: foo' \
+      --set 'dns.proxy.nodeSelector=kubernetes.io/os: linux' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.priorityClassName' | tee /dev/stderr)
+  [ "${actual}" = "system-node-critical" ]
+
+  local actual=$(echo "$object" | yq -r '.tolerations[0].key' | tee /dev/stderr)
+  [ "${actual}" = "foo" ]
+
+  local actual=$(echo "$object" | yq -r '.nodeSelector["kubernetes.io/os"]' | tee /dev/stderr)
+  [ "${actual}" = "linux" ]
+}
@@ -0,0 +1,39 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "dnsProxy/Service: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/dns-proxy-service.yaml  \
+      .
+}
+
+@test "dnsProxy/Service: routes queries to the DNS proxy on the local node" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/dns-proxy-service.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.internalTrafficPolicy' | tee /dev/stderr)
+  [ "${actual}" = "Local" ]
+
+  local actual=$(echo "$object" | yq -r '.selector.component' | tee /dev/stderr)
+  [ "${actual}" = "dns-proxy" ]
+
+  local actual=$(echo "$object" | yq -r '.ports[0].port' | tee /dev/stderr)
+  [ "${actual}" = "53" ]
+}
+
+@test "dnsProxy/Service: port can be set" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/dns-proxy-service.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      --set 'dns.proxy.port=5353' \
+      . | tee /dev/stderr |
+      yq -r '.spec.ports[1].port' | tee /dev/stderr)
+  [ "${actual}" = "5353" ]
+}
@@ -0,0 +1,20 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "dnsProxy/ServiceAccount: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/dns-proxy-serviceaccount.yaml  \
+      .
+}
+
+@test "dnsProxy/ServiceAccount: enabled with dns.proxy.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/dns-proxy-serviceaccount.yaml  \
+      --set 'dns.proxy.enabled=true' \
+      . | tee /dev/stderr |
+      yq 'length > 0' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
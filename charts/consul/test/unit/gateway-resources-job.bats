@@ -116,3 +116,23 @@ target=templates/gateway-resources-job.yaml
   local actual=$(echo "$spec" | jq '.[14]')
   [ "${actual}" = "\"-service-annotations=- bingo\"" ]
 }
+
+@test "apiGateway/GatewayClassConfig: scheduling configuration" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s $target  \
+      --set 'connectInject.apiGateway.managedGatewayClass.affinity=nodeAffinity: {}' \
+      --set 'connectInject.apiGateway.managedGatewayClass.topologySpreadConstraints=- maxSkew: 1' \
+      --set 'connectInject.apiGateway.managedGatewayClass.priorityClassName=gateway-critical' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].args' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | jq 'any(index("-affinity=nodeAffinity: {}"))')
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$spec" | jq 'any(index("-topology-spread-constraints=- maxSkew: 1"))')
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$spec" | jq 'any(index("-priority-class-name=gateway-critical"))')
+  [ "${actual}" = "true" ]
+}
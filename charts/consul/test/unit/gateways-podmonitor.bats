@@ -0,0 +1,65 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "gateways/PodMonitor: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/gateways-podmonitor.yaml  \
+      .
+}
+
+@test "gateways/PodMonitor: disabled without gateways" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/gateways-podmonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      .
+}
+
+@test "gateways/PodMonitor: disabled with global.metrics.enableGatewayMetrics=false" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/gateways-podmonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.enableGatewayMetrics=false' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'terminatingGateways.enabled=true' \
+      .
+}
+
+@test "gateways/PodMonitor: selects gateway pods" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s templates/gateways-podmonitor.yaml  \
+      --set 'global.metrics.enabled=true' \
+      --set 'global.metrics.prometheusOperator.enabled=true' \
+      --set 'global.metrics.prometheusOperator.interval=1m' \
+      --set 'connectInject.enabled=true' \
+      --set 'ingressGateways.enabled=true' \
+      --set 'global.metrics.prometheusOperator.relabelings[0].targetLabel=cluster' \
+      --set 'global.metrics.prometheusOperator.relabelings[0].replacement=dc1' \
+      --namespace foo \
+      . | tee /dev/stderr |
+      yq '.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | yq -r '.namespaceSelector.matchNames[0]' | tee /dev/stderr)
+  [ "${actual}" = "foo" ]
+
+  local actual=$(echo "$spec" | yq -c '.selector.matchExpressions[0].values' | tee /dev/stderr)
+  [ "${actual}" = '["mesh-gateway","ingress-gateway","terminating-gateway"]' ]
+
+  local actual=$(echo "$spec" | yq -r '.podMetricsEndpoints[0].port' | tee /dev/stderr)
+  [ "${actual}" = "prometheus" ]
+
+  local actual=$(echo "$spec" | yq -r '.podMetricsEndpoints[0].interval' | tee /dev/stderr)
+  [ "${actual}" = "1m" ]
+
+  local actual=$(echo "$spec" | yq -r '.podMetricsEndpoints[0].relabelings[0].targetLabel' | tee /dev/stderr)
+  [ "${actual}" = "gateway_kind" ]
+
+  local actual=$(echo "$spec" | yq -r '.podMetricsEndpoints[0].relabelings[1].targetLabel' | tee /dev/stderr)
+  [ "${actual}" = "cluster" ]
+}
@@ -0,0 +1,137 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "gossipEncryptionRotate/Job: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/gossip-encryption-rotate-job.yaml  \
+      .
+}
+
+@test "gossipEncryptionRotate/Job: fails if global.secretsBackend.vault.enabled=true" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/gossip-encryption-rotate-job.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.secretName=path/to/secret' \
+      --set 'global.gossipEncryption.secretKey=key' \
+      --set 'global.secretsBackend.vault.enabled=true' \
+      --set 'global.secretsBackend.vault.consulClientRole=foo' \
+      --set 'global.secretsBackend.vault.consulServerRole=bar' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.gossipEncryption.rotationID is not supported when global.secretsBackend.vault.enabled is true" ]]
+}
+
+@test "gossipEncryptionRotate/Job: fails if no gossip encryption key is configured" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/gossip-encryption-rotate-job.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.gossipEncryption.autoGenerate or global.gossipEncryption.secretName must be set if global.gossipEncryption.rotationID is set" ]]
+}
+
+@test "gossipEncryptionRotate/Job: rotates the auto-generated key after upgrades" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/gossip-encryption-rotate-job.yaml  \
+      --set 'global.gossipEncryption.rotationID=2023-10-01' \
+      --set 'global.gossipEncryption.autoGenerate=true' \
+      . | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.metadata.annotations["helm.sh/hook"]' | tee /dev/stderr)
+  [ "${actual}" = "post-upgrade" ]
+
+  local cmd=$(echo "$object" | yq -r '.spec.template.spec.containers[0].command | join(" ")' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" | yq 'contains("-secret-name=release-name-consul-gossip-encryption-key")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" | yq 'contains("-rotation-id=\"2023-10-01\"")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "gossipEncryptionRotate/Job: rotates the key of global.gossipEncryption.secretName" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/gossip-encryption-rotate-job.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.secretName=foo' \
+      --set 'global.gossipEncryption.secretKey=bar' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].command | join(" ")' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" | yq 'contains("-secret-name=foo")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" | yq 'contains("-secret-key=bar")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# global.acls.manageSystemACLs
+
+@test "gossipEncryptionRotate/Job: does not set a token when ACLs are disabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/gossip-encryption-rotate-job.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.autoGenerate=true' \
+      . | tee /dev/stderr |
+      yq '[.spec.template.spec.containers[0].env[].name] | any(contains("CONSUL_ACL_TOKEN"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "gossipEncryptionRotate/Job: uses the bootstrap token when global.acls.manageSystemACLs=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/gossip-encryption-rotate-job.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.autoGenerate=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].env[] | select(.name == "CONSUL_ACL_TOKEN") | .valueFrom.secretKeyRef.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-bootstrap-acl-token" ]
+}
+
+@test "gossipEncryptionRotate/Job: uses global.acls.bootstrapToken when set" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/gossip-encryption-rotate-job.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.autoGenerate=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.bootstrapToken.secretName=my-token' \
+      --set 'global.acls.bootstrapToken.secretKey=my-key' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].env[] | select(.name == "CONSUL_ACL_TOKEN") | .valueFrom.secretKeyRef' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.name' | tee /dev/stderr)
+  [ "${actual}" = "my-token" ]
+
+  local actual=$(echo "$object" | yq -r '.key' | tee /dev/stderr)
+  [ "${actual}" = "my-key" ]
+}
+
+#--------------------------------------------------------------------
+# global.tls.enabled
+
+@test "gossipEncryptionRotate/Job: mounts the CA certificate when global.tls.enabled=true" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/gossip-encryption-rotate-job.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.autoGenerate=true' \
+      --set 'global.tls.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.volumes[0].secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-ca-cert" ]
+
+  local actual=$(echo "$object" | yq -r '.containers[0].env[] | select(.name == "CONSUL_CACERT_FILE") | .value' | tee /dev/stderr)
+  [ "${actual}" = "/consul/tls/ca/tls.crt" ]
+}
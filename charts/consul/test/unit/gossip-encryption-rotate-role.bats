@@ -0,0 +1,33 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "gossipEncryptionRotate/Role: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/gossip-encryption-rotate-role.yaml  \
+      .
+}
+
+@test "gossipEncryptionRotate/Role: allows updating the auto-generated gossip key secret" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/gossip-encryption-rotate-role.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.autoGenerate=true' \
+      . | tee /dev/stderr |
+      yq -r '.rules[0].resourceNames[0]' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-gossip-encryption-key" ]
+}
+
+@test "gossipEncryptionRotate/Role: allows updating global.gossipEncryption.secretName" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/gossip-encryption-rotate-role.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.secretName=foo' \
+      --set 'global.gossipEncryption.secretKey=bar' \
+      . | tee /dev/stderr |
+      yq -r '.rules[0].resourceNames[0]' | tee /dev/stderr)
+  [ "${actual}" = "foo" ]
+}
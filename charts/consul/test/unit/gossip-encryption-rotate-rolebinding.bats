@@ -0,0 +1,21 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "gossipEncryptionRotate/RoleBinding: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/gossip-encryption-rotate-rolebinding.yaml  \
+      .
+}
+
+@test "gossipEncryptionRotate/RoleBinding: enabled with global.gossipEncryption.rotationID" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/gossip-encryption-rotate-rolebinding.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.autoGenerate=true' \
+      . | tee /dev/stderr |
+      yq 'length > 0' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
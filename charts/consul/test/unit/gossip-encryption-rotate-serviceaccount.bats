@@ -0,0 +1,21 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "gossipEncryptionRotate/ServiceAccount: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/gossip-encryption-rotate-serviceaccount.yaml  \
+      .
+}
+
+@test "gossipEncryptionRotate/ServiceAccount: enabled with global.gossipEncryption.rotationID" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/gossip-encryption-rotate-serviceaccount.yaml  \
+      --set 'global.gossipEncryption.rotationID=1' \
+      --set 'global.gossipEncryption.autoGenerate=true' \
+      . | tee /dev/stderr |
+      yq 'length > 0' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
@@ -241,6 +241,27 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+@test "ingressGateways/Deployment: uses the rotated ACL token with global.acls.tokenRotationInterval" {
+  cd `chart_dir`
+  local container=$(helm template \
+      -s templates/ingress-gateways-deployment.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'ingressGateways.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -s -c '.[0].spec.template.spec.containers[0]' | tee /dev/stderr)
+
+  local actual=$(echo $container | yq -r '.args | any(contains("-credential-type=static"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $container | yq -r '.args | any(contains("-login-"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo $container | yq -r '.env[] | select(.name == "DP_CREDENTIAL_STATIC_TOKEN") | .valueFrom.secretKeyRef.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-ingress-gateway-acl-token" ]
+}
+
 @test "ingressGateways/Deployment: add consul-dataplane envvars on ingress-gateway container" {
   cd `chart_dir`
   local env=$(helm template \
@@ -300,6 +321,31 @@ load _helpers
   [ "${actual}" = "/metrics" ]
 }
 
+@test "ingressGateways/Deployment: when global.metrics.enabled=true, adds prometheus container port" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/ingress-gateways-deployment.yaml  \
+      --set 'ingressGateways.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.metrics.enabled=true'  \
+      . | tee /dev/stderr |
+      yq -s -r '.[0].spec.template.spec.containers[0].ports[] | select(.name == "prometheus") | .containerPort' | tee /dev/stderr)
+  [ "${actual}" = "20200" ]
+}
+
+@test "ingressGateways/Deployment: when global.metrics.enableGatewayMetrics=false, does not add prometheus container port" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/ingress-gateways-deployment.yaml  \
+      --set 'ingressGateways.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.metrics.enabled=true'  \
+      --set 'global.metrics.enableGatewayMetrics=false'  \
+      . | tee /dev/stderr |
+      yq -s -r '[.[0].spec.template.spec.containers[0].ports[] | select(.name == "prometheus")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
 @test "ingressGateways/Deployment: when global.metrics.enableGatewayMetrics=false, does not set proxy setting" {
   cd `chart_dir`
   local object=$(helm template \
@@ -99,6 +99,31 @@ key2: value2' \
   [ "${actual}" = "/metrics" ]
 }
 
+@test "meshGateway/Deployment: when global.metrics.enabled=true, adds prometheus container port" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/mesh-gateway-deployment.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.metrics.enabled=true'  \
+      . | tee /dev/stderr |
+      yq -s -r '.[0].spec.template.spec.containers[0].ports[] | select(.name == "prometheus") | .containerPort' | tee /dev/stderr)
+  [ "${actual}" = "20200" ]
+}
+
+@test "meshGateway/Deployment: when global.metrics.enableGatewayMetrics=false, does not add prometheus container port" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/mesh-gateway-deployment.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.metrics.enabled=true'  \
+      --set 'global.metrics.enableGatewayMetrics=false'  \
+      . | tee /dev/stderr |
+      yq -s -r '[.[0].spec.template.spec.containers[0].ports[] | select(.name == "prometheus")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
 @test "meshGateway/Deployment: when global.metrics.enableGatewayMetrics=false, does not set annotations" {
   cd `chart_dir`
   local object=$(helm template \
@@ -512,6 +537,27 @@ key2: value2' \
       [ "${actual}" = "true" ]
 }
 
+@test "meshGateway/Deployment: uses the rotated ACL token with global.acls.tokenRotationInterval" {
+  cd `chart_dir`
+  local container=$(helm template \
+      -s templates/mesh-gateway-deployment.yaml  \
+      --set 'meshGateway.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -c '.spec.template.spec.containers[0]' | tee /dev/stderr)
+
+  local actual=$(echo $container | yq -r '.args | any(contains("-credential-type=static"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $container | yq -r '.args | any(contains("-login-"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo $container | yq -r '.env[] | select(.name == "DP_CREDENTIAL_STATIC_TOKEN") | .valueFrom.secretKeyRef.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-mesh-gateway-acl-token" ]
+}
+
 @test "meshGateway/Deployment: correct login-method and login-datacenter are set with federation is enabled and in secondary DC" {
   cd `chart_dir`
   local command=$(helm template \
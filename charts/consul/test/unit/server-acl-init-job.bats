@@ -114,6 +114,27 @@ load _helpers
 #--------------------------------------------------------------------
 # dns
 
+@test "serverACLInit/Job: -use-templated-policies is not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-use-templated-policies"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "serverACLInit/Job: -use-templated-policies is set with .global.acls.useTemplatedPolicies=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.useTemplatedPolicies=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-use-templated-policies=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 @test "serverACLInit/Job: dns acl option enabled with .dns.enabled=- due to inheriting from connectInject.transparentProxy.defaultEnabled" {
   cd `chart_dir`
   local actual=$(helm template \
@@ -169,6 +190,18 @@ load _helpers
   [ "${actual}" = "false" ]
 }
 
+@test "serverACLInit/Job: dns acl option enabled with .dns.proxy.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'dns.enabled=false' \
+      --set 'dns.proxy.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("allow-dns"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 #--------------------------------------------------------------------
 # aclBindingRuleSelector/global.acls.manageSystemACLs
 
@@ -280,6 +313,49 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+@test "serverACLInit/Job: sync-node-topology not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-sync-node-topology"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "serverACLInit/Job: sync-node-topology set with syncCatalog.syncNodeTopology=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.syncNodeTopology=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-sync-node-topology=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "serverACLInit/Job: sets the cluster name with externalServers.clusterName" {
+  cd `chart_dir`
+  local command=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'syncCatalog.enabled=true' \
+      --set 'server.enabled=false' \
+      --set 'externalServers.enabled=true' \
+      --set 'externalServers.hosts[0]=consul' \
+      --set 'externalServers.clusterName=east' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$command" | yq 'any(contains("-cluster-name=east"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  actual=$(echo "$command" | yq 'any(contains("-sync-consul-node-name=k8s-sync-east"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 #--------------------------------------------------------------------
 # meshGateway.enabled
 
@@ -1507,6 +1583,174 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+#--------------------------------------------------------------------
+# jwtAuthMethod
+
+@test "serverACLInit/Job: JWT auth method is not configured by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-jwt-auth-method"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "serverACLInit/Job: JWT auth method can be configured" {
+  cd `chart_dir`
+  local command=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.jwtAuthMethod.enabled=true' \
+      --set 'global.acls.jwtAuthMethod.oidcDiscoveryURL=https://issuer.example.com' \
+      --set 'global.acls.jwtAuthMethod.boundIssuer=https://issuer.example.com' \
+      --set 'global.acls.jwtAuthMethod.boundAudiences[0]=consul' \
+      --set 'global.acls.jwtAuthMethod.claimMappings.sub=subject' \
+      --set 'global.acls.jwtAuthMethod.listClaimMappings.groups=groups' \
+      --set 'global.acls.jwtAuthMethod.maxTokenTTL=1h' \
+      --set 'global.acls.jwtAuthMethod.bindingRules[0].bindType=role' \
+      --set 'global.acls.jwtAuthMethod.bindingRules[0].bindName=operators' \
+      --set 'global.acls.jwtAuthMethod.bindingRules[0].selector=admins in list.groups' \
+      --set 'global.acls.jwtAuthMethod.bindingRules[1].bindType=service' \
+      --set 'global.acls.jwtAuthMethod.bindingRules[1].bindName=${value.subject}' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method-oidc-discovery-url=https://issuer.example.com"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method-jwks-url"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method-bound-issuer=https://issuer.example.com"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method-bound-audience=consul"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method-claim-mapping=sub=subject"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method-list-claim-mapping=groups=groups"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method-max-token-ttl=1h"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method-binding-rule='"'"'role:operators:admins in list.groups'"'"'"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $command | jq -r '. | any(contains("-jwt-auth-method-binding-rule='"'"'service:${value.subject}'"'"'"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "serverACLInit/Job: -config-entry-partition is not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.enableConsulNamespaces=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-config-entry-partition"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "serverACLInit/Job: -config-entry-partition is set for each of .global.adminPartitions.crossPartitionConfigEntries" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.adminPartitions.crossPartitionConfigEntries={ap1,ap2}' \
+      --set 'global.enableConsulNamespaces=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-config-entry-partition=\"ap1\"") and contains("-config-entry-partition=\"ap2\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "serverACLInit/Job: -default-partition-auth-method is set in non-default partitions" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.adminPartitions.name=ap1' \
+      --set 'global.adminPartitions.defaultPartitionAuthMethod=consul-k8s-auth-method' \
+      --set 'global.enableConsulNamespaces=true' \
+      --set 'server.enabled=false' \
+      --set 'externalServers.enabled=true' \
+      --set 'externalServers.hosts[0]=foo' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-default-partition-auth-method=consul-k8s-auth-method"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "serverACLInit/Job: -default-partition-auth-method is not set in the default partition" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.adminPartitions.enabled=true' \
+      --set 'global.adminPartitions.defaultPartitionAuthMethod=consul-k8s-auth-method' \
+      --set 'global.enableConsulNamespaces=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-default-partition-auth-method"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+#--------------------------------------------------------------------
+# global.spire.connectCA
+
+@test "serverACLInit/Job: -enable-spire-connect-ca is not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-spire-connect-ca"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "serverACLInit/Job: -enable-spire-connect-ca is set with global.spire.connectCA.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.spire.connectCA.enabled=true' \
+      --set 'global.spire.connectCA.serverAddress=spire-server.spire:8081' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-spire-connect-ca=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# server.upgradeController
+
+@test "serverACLInit/Job: -enable-server-upgrade-controller is not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-server-upgrade-controller"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "serverACLInit/Job: -enable-server-upgrade-controller is set with server.upgradeController.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-acl-init-job.yaml  \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'server.upgradeController.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-enable-server-upgrade-controller=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 #--------------------------------------------------------------------
 # global.acls.createReplicationToken
 
@@ -815,6 +815,52 @@ load _helpers
   [ "${actual}" = '{"https":8501}' ]
 }
 
+@test "server/ConfigMap: TLS version and cipher suites are not set by default" {
+  cd `chart_dir`
+  local config=$(helm template \
+      -s templates/server-config-configmap.yaml \
+      --set 'global.tls.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.data["tls-config.json"]' | tee /dev/stderr)
+
+  local actual
+  actual=$(echo $config | jq -r .tls.defaults.tls_min_version | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+
+  actual=$(echo $config | jq -r .tls.defaults.tls_cipher_suites | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
+@test "server/ConfigMap: TLS version and cipher suites can be set with global.tls.controlPlane" {
+  cd `chart_dir`
+  local config=$(helm template \
+      -s templates/server-config-configmap.yaml \
+      --set 'global.tls.enabled=true' \
+      --set 'global.tls.controlPlane.minVersion=TLSv1_2' \
+      --set 'global.tls.controlPlane.cipherSuites[0]=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256' \
+      --set 'global.tls.controlPlane.cipherSuites[1]=TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256' \
+      . | tee /dev/stderr |
+      yq -r '.data["tls-config.json"]' | tee /dev/stderr)
+
+  local actual
+  actual=$(echo $config | jq -r .tls.defaults.tls_min_version | tee /dev/stderr)
+  [ "${actual}" = "TLSv1_2" ]
+
+  actual=$(echo $config | jq -r .tls.defaults.tls_cipher_suites | tee /dev/stderr)
+  [ "${actual}" = "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" ]
+}
+
+@test "server/ConfigMap: fails if global.tls.controlPlane.minVersion is invalid" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/server-config-configmap.yaml \
+      --set 'global.tls.enabled=true' \
+      --set 'global.tls.controlPlane.minVersion=TLSv1_1' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "global.tls.controlPlane.minVersion must be \"TLSv1_2\" or \"TLSv1_3\"" ]]
+}
+
 #--------------------------------------------------------------------
 # global.tls.enableAutoEncrypt
 
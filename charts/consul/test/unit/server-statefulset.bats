@@ -152,6 +152,38 @@ load _helpers
   [ "${actual}" = "2" ]
 }
 
+@test "server/StatefulSet: updateStrategy is OnDelete with server.upgradeController.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.upgradeController.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.updateStrategy.type' | tee /dev/stderr)
+  [ "${actual}" = "OnDelete" ]
+}
+
+@test "server/StatefulSet: server.upgradeController fails if updatePartition is set" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.upgradeController.enabled=true' \
+      --set 'server.updatePartition=2' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "server.updatePartition must be 0 if server.upgradeController.enabled is true." ]]
+}
+
+@test "server/StatefulSet: server.upgradeController fails if connectInject is disabled" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'connectInject.enabled=false' \
+      --set 'server.upgradeController.enabled=true' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.enabled must be true if server.upgradeController.enabled is true." ]]
+}
+
 #--------------------------------------------------------------------
 # volumeClaim name
 
@@ -2473,6 +2505,34 @@ load _helpers
   [ "${actual}" = 'true' ]
 }
 
+@test "server/StatefulSet: snapshot-agent: reads the rotated ACL token with global.acls.tokenRotationInterval" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -r -c '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo $spec | yq -r '.volumes[] | select(.name == "snapshot-agent-acl-token") | .secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = 'release-name-consul-snapshot-agent-acl-token' ]
+
+  local actual=$(echo $spec | yq -r '.containers[1].volumeMounts[] | select(.name == "snapshot-agent-acl-token") | .mountPath' | tee /dev/stderr)
+  [ "${actual}" = '/consul/acl-token' ]
+
+  local actual=$(echo $spec | yq -r '.containers[1].command[2] | contains("-config-file=/consul/config/snapshot-login.json")' | tee /dev/stderr)
+  [ "${actual}" = 'false' ]
+
+  local actual=$(echo $spec | yq -r '.containers[1].command[2] | contains("export CONSUL_HTTP_TOKEN_FILE=\"${token_file}\"")' | tee /dev/stderr)
+  [ "${actual}" = 'true' ]
+
+  # The snapshot agent is restarted when the token changes rather than exec'd.
+  local actual=$(echo $spec | yq -r '.containers[1].command[2] | contains("exec /bin/consul snapshot agent")' | tee /dev/stderr)
+  [ "${actual}" = 'false' ]
+}
+
 @test "server/StatefulSet: snapshot-agent: uses default consul addr when TLS is disabled" {
   cd `chart_dir`
   local env=$(helm template \
@@ -2586,6 +2646,85 @@ MIICFjCCAZsCCQCdwLtdjbzlYzAKBggqhkjOPQQDAjB0MQswCQYDVQQGEwJDQTEL' \
 }
 
 
+#--------------------------------------------------------------------
+# server.snapshotAgent.snapshotSchedule
+
+@test "server/StatefulSet: snapshot-agent: snapshotSchedule fails if configSecret is set" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'server.snapshotAgent.snapshotSchedule.enabled=true' \
+      --set 'server.snapshotAgent.configSecret.secretName=foo' \
+      --set 'server.snapshotAgent.configSecret.secretKey=bar' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "server.snapshotAgent.configSecret cannot be set if server.snapshotAgent.snapshotSchedule.enabled is true." ]]
+}
+
+@test "server/StatefulSet: snapshot-agent: snapshotSchedule fails if connectInject is disabled" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'server.snapshotAgent.snapshotSchedule.enabled=true' \
+      --set 'connectInject.enabled=false' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "connectInject.enabled must be true if server.snapshotAgent.snapshotSchedule.enabled is true." ]]
+}
+
+@test "server/StatefulSet: snapshot-agent: snapshotSchedule mounts the rendered config secret" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'server.snapshotAgent.snapshotSchedule.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r -c '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | jq -r '.volumes[] | select(.name == "snapshot-agent-schedule-config") | .secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-snapshot-agent-schedule" ]
+  local actual=$(echo "$spec" | jq -r '.volumes[] | select(.name == "snapshot-agent-schedule-config") | .secret.optional' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$spec" | jq -r '.containers[1].volumeMounts[] | select(.name == "snapshot-agent-schedule-config") | .mountPath' | tee /dev/stderr)
+  [ "${actual}" = "/consul/user-config" ]
+}
+
+@test "server/StatefulSet: snapshot-agent: snapshotSchedule restarts the snapshot agent on config changes instead of setting the interval" {
+  cd `chart_dir`
+  local container=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'server.snapshotAgent.snapshotSchedule.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq -r -c '.spec.template.spec.containers[1]' | tee /dev/stderr)
+
+  local actual=$(echo "$container" | jq -r '.command[2] | contains("-config-dir=\"${config_dir}\" &")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+  local actual=$(echo "$container" | jq -r '.command[2] | contains("-config-file=/consul/config/snapshot-login.json")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+  local actual=$(echo "$container" | jq -r '.command[2] | contains("-interval=")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "server/StatefulSet: snapshot-agent: snapshotSchedule forwards SIGTERM to the snapshot agent" {
+  cd `chart_dir`
+  local command=$(helm template \
+      -s templates/server-statefulset.yaml  \
+      --set 'server.snapshotAgent.enabled=true' \
+      --set 'server.snapshotAgent.snapshotSchedule.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[1].command[2]' | tee /dev/stderr)
+
+  local actual=$(echo "$command" | grep -c "kill -TERM \"\${pid}\"" | tee /dev/stderr)
+  [ "${actual}" = "1" ]
+  local actual=$(echo "$command" | grep -c "' TERM INT$" | tee /dev/stderr)
+  [ "${actual}" = "1" ]
+}
+
 #--------------------------------------------------------------------
 # global.trustedCAs
 
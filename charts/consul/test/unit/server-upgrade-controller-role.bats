@@ -0,0 +1,43 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "serverUpgradeController/Role: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/server-upgrade-controller-role.yaml  \
+      --set 'connectInject.enabled=true' \
+      .
+}
+
+@test "serverUpgradeController/Role: disabled with connectInject.enabled=false" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/server-upgrade-controller-role.yaml  \
+      --set 'connectInject.enabled=false' \
+      --set 'server.upgradeController.enabled=true' \
+      .
+}
+
+@test "serverUpgradeController/Role: allows deleting server pods in the release namespace" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/server-upgrade-controller-role.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.upgradeController.enabled=true' \
+      --namespace foo \
+      . | tee /dev/stderr |
+      yq -c '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.kind' | tee /dev/stderr)
+  [ "${actual}" = "Role" ]
+
+  local actual=$(echo "$object" | yq -r '.metadata.namespace' | tee /dev/stderr)
+  [ "${actual}" = "foo" ]
+
+  local actual=$(echo "$object" | yq -r '[.rules[] | select(.resources == ["pods"]) | .verbs[]] | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "get,list,watch,delete" ]
+
+  local actual=$(echo "$object" | yq -r '[.rules[] | select(.resources == ["statefulsets"]) | .verbs[]] | join(",")' | tee /dev/stderr)
+  [ "${actual}" = "get,list,watch" ]
+}
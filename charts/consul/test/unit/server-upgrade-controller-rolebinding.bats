@@ -0,0 +1,27 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "serverUpgradeController/RoleBinding: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/server-upgrade-controller-rolebinding.yaml  \
+      --set 'connectInject.enabled=true' \
+      .
+}
+
+@test "serverUpgradeController/RoleBinding: binds the connect injector service account" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/server-upgrade-controller-rolebinding.yaml  \
+      --set 'connectInject.enabled=true' \
+      --set 'server.upgradeController.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.roleRef.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-server-upgrade-controller" ]
+
+  local actual=$(echo "$object" | yq -r '.subjects[0].name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-connect-injector" ]
+}
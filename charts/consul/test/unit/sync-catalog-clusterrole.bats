@@ -84,3 +84,75 @@ load _helpers
       yq -c '.rules[0].verbs' | tee /dev/stderr)
   [ "${actual}" = '["get","list","watch","update","patch","delete","create"]' ]
 }
+
+#--------------------------------------------------------------------
+# syncCatalog.toK8SEndpointSlices
+
+@test "syncCatalog/ClusterRole: no endpointslices access by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '[.rules[] | select(.resources[0] == "endpointslices")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "syncCatalog/ClusterRole: allows endpointslices access with syncCatalog.toK8SEndpointSlices=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.toK8SEndpointSlices=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules[] | select(.resources[0] == "endpointslices") | .verbs' | tee /dev/stderr)
+  [ "${actual}" = '["get","list","watch","create","update","delete"]' ]
+}
+
+#--------------------------------------------------------------------
+# global.logLevelConfigMap
+
+@test "syncCatalog/ClusterRole: no configmaps access by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '[.rules[] | select(.resources[0] == "configmaps")] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "syncCatalog/ClusterRole: allows getting the log level ConfigMap with global.logLevelConfigMap.enabled=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'global.logLevelConfigMap.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules[] | select(.resources[0] == "configmaps")' | tee /dev/stderr)
+  [ "${actual}" = '{"apiGroups":[""],"resources":["configmaps"],"resourceNames":["release-name-consul-log-levels"],"verbs":["get"]}' ]
+}
+
+#--------------------------------------------------------------------
+# syncNodeTopology
+
+@test "syncCatalog/ClusterRole: only allows getting nodes by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules[] | select(.resources[0] == "nodes") | .verbs' | tee /dev/stderr)
+  [ "${actual}" = '["get"]' ]
+}
+
+@test "syncCatalog/ClusterRole: allows watching nodes with syncCatalog.syncNodeTopology=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-clusterrole.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.syncNodeTopology=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules[] | select(.resources[0] == "nodes") | .verbs' | tee /dev/stderr)
+  [ "${actual}" = '["get","list","watch"]' ]
+}
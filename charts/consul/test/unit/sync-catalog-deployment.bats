@@ -173,6 +173,190 @@ load _helpers
   [ "${actual}" = "false" ]
 }
 
+#--------------------------------------------------------------------
+# destinationDatacenters
+
+@test "syncCatalog/Deployment: no destination datacenters by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-destination-datacenter"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: can set destinationDatacenters" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.destinationDatacenters[0].name=dc2' \
+      --set 'syncCatalog.destinationDatacenters[1].name=dc3' \
+      --set 'syncCatalog.destinationDatacenters[1].k8sNamespaces={foo,bar}' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo $object |
+    yq 'any(contains("-destination-datacenter=\"dc2\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $object |
+    yq 'any(contains("-destination-datacenter=\"dc3=foo,bar\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "syncCatalog/Deployment: destinationDatacenters are not set when syncCatalog.toConsul=false" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.toConsul=false' \
+      --set 'syncCatalog.destinationDatacenters[0].name=dc2' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-destination-datacenter"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: fails if a destination datacenter has no name" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.destinationDatacenters[0].k8sNamespaces={foo}' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "syncCatalog.destinationDatacenters[].name is required" ]]
+}
+
+@test "syncCatalog/Deployment: destination datacenter servers are not set by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.destinationDatacenters[0].name=dc2' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo $object |
+    yq '.containers[0].command | any(contains("-destination-server-addresses"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo $object |
+    yq '[.volumes[] | select(.name | startswith("destination-"))] | length' | tee /dev/stderr)
+  [ "${actual}" = "0" ]
+}
+
+@test "syncCatalog/Deployment: destination datacenter servers, token and CA can be set" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.destinationDatacenters[0].name=dc2' \
+      --set 'syncCatalog.destinationDatacenters[1].name=dr' \
+      --set 'syncCatalog.destinationDatacenters[1].serverAddresses=consul.dr.example.com' \
+      --set 'syncCatalog.destinationDatacenters[1].tokenSecret.secretName=dr-token' \
+      --set 'syncCatalog.destinationDatacenters[1].tokenSecret.secretKey=token' \
+      --set 'syncCatalog.destinationDatacenters[1].caCertSecret.secretName=dr-ca' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo $object |
+    yq '.containers[0].command | any(contains("-destination-server-addresses=\"dr=consul.dr.example.com\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $object |
+    yq '.containers[0].command | any(contains("-destination-token-file=\"dr=/consul/destinations/1/acl-token/token\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $object |
+    yq '.containers[0].command | any(contains("-destination-ca-file=\"dr=/consul/destinations/1/tls/ca/tls.crt\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $object |
+    yq -r '.volumes[] | select(.name == "destination-1-acl-token") | .secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = "dr-token" ]
+
+  local actual=$(echo $object |
+    yq -r '.volumes[] | select(.name == "destination-1-ca-cert") | .secret.items[0].key' | tee /dev/stderr)
+  [ "${actual}" = "tls.crt" ]
+
+  local actual=$(echo $object |
+    yq -r '.containers[0].volumeMounts[] | select(.name == "destination-1-acl-token") | .mountPath' | tee /dev/stderr)
+  [ "${actual}" = "/consul/destinations/1/acl-token" ]
+}
+
+@test "syncCatalog/Deployment: fails if a destination datacenter token is set without serverAddresses" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.destinationDatacenters[0].name=dc2' \
+      --set 'syncCatalog.destinationDatacenters[0].tokenSecret.secretName=foo' \
+      --set 'syncCatalog.destinationDatacenters[0].tokenSecret.secretKey=token' .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "syncCatalog.destinationDatacenters[].tokenSecret and caCertSecret require serverAddresses" ]]
+}
+
+#--------------------------------------------------------------------
+# toK8SEndpointSlices
+
+@test "syncCatalog/Deployment: to-k8s-endpoint-slices not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-to-k8s-endpoint-slices"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: to-k8s-endpoint-slices set with syncCatalog.toK8SEndpointSlices=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.toK8SEndpointSlices=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-to-k8s-endpoint-slices=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "syncCatalog/Deployment: to-k8s-endpoint-slices not set when syncCatalog.toK8S=false" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.toK8S=false' \
+      --set 'syncCatalog.toK8SEndpointSlices=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-to-k8s-endpoint-slices"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+#--------------------------------------------------------------------
+# k8sServiceSelector
+
+@test "syncCatalog/Deployment: no k8sServiceSelector by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-k8s-service-selector"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: can specify k8sServiceSelector" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.k8sServiceSelector=tier in (frontend\, api)' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-k8s-service-selector=\"tier in (frontend, api)\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 #--------------------------------------------------------------------
 # k8sPrefix
 
@@ -280,6 +464,20 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+@test "syncCatalog/Deployment: appends externalServers.clusterName to consulNodeName" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'server.enabled=false' \
+      --set 'externalServers.enabled=true' \
+      --set 'externalServers.hosts[0]=consul' \
+      --set 'externalServers.clusterName=east' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-consul-node-name=k8s-sync-east"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 #--------------------------------------------------------------------
 # serviceAccount
 
@@ -293,6 +491,54 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+#--------------------------------------------------------------------
+# syncNotReadyEndpoints
+
+@test "syncCatalog/Deployment: sync-not-ready-endpoints not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-sync-not-ready-endpoints"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: sync-not-ready-endpoints set with syncCatalog.syncNotReadyEndpoints=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.syncNotReadyEndpoints=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-sync-not-ready-endpoints=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# syncNodeTopology
+
+@test "syncCatalog/Deployment: sync-node-topology not set by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-sync-node-topology"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: sync-node-topology set with syncCatalog.syncNodeTopology=true" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.syncNodeTopology=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-sync-node-topology=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 #--------------------------------------------------------------------
 # nodePortSyncType
 
@@ -839,6 +1085,37 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+@test "syncCatalog/Deployment: namespace mappings can be set with .syncCatalog.consulNamespaces.namespaceMapping" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'global.enableConsulNamespaces=true' \
+      --set 'syncCatalog.consulNamespaces.namespaceMapping.dev=team-a' \
+      --set 'syncCatalog.consulNamespaces.namespaceMapping.prod=team-b' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo $object |
+    yq 'any(contains("-k8s-namespace-mapping=\"dev=team-a\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $object |
+    yq 'any(contains("-k8s-namespace-mapping=\"prod=team-b\""))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "syncCatalog/Deployment: namespace mappings are not set when namespaces are disabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.consulNamespaces.namespaceMapping.dev=team-a' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-k8s-namespace-mapping"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
 #--------------------------------------------------------------------
 # namespaces + global.acls.manageSystemACLs
 
@@ -918,6 +1195,68 @@ load _helpers
   [ "${actual}" = "name" ]
 }
 
+#--------------------------------------------------------------------
+# global.acls.tokenRotationInterval
+
+@test "syncCatalog/Deployment: logs in with the component auth method by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0]' | tee /dev/stderr)
+
+  local actual=$(echo "$object" |
+      yq '[.env[].name] | any(. == "CONSUL_LOGIN_AUTH_METHOD")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" |
+      yq '[.env[].name] | any(. == "CONSUL_ACL_TOKEN_FILE")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: reads the rotated ACL token with global.acls.tokenRotationInterval" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'connectInject.enabled=true' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" |
+      yq '[.containers[0].env[].name] | any(. == "CONSUL_LOGIN_AUTH_METHOD")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$object" |
+      yq -r '.containers[0].env[] | select(.name == "CONSUL_ACL_TOKEN_FILE") | .value' | tee /dev/stderr)
+  [ "${actual}" = "/consul/acl-token/token" ]
+
+  local actual=$(echo "$object" |
+      yq -r '.volumes[] | select(.name == "acl-token") | .secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-sync-catalog-acl-token" ]
+
+  local actual=$(echo "$object" |
+      yq -r '.containers[0].volumeMounts[] | select(.name == "acl-token") | .mountPath' | tee /dev/stderr)
+  [ "${actual}" = "/consul/acl-token" ]
+}
+
+@test "syncCatalog/Deployment: ACL token is not rotated if connectInject is disabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'connectInject.enabled=false' \
+      --set 'global.acls.manageSystemACLs=true' \
+      --set 'global.acls.tokenRotationInterval=24h' \
+      . | tee /dev/stderr |
+      yq '[.spec.template.spec.containers[0].env[].name] | any(. == "CONSUL_ACL_TOKEN_FILE")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
 #--------------------------------------------------------------------
 # extraLabels
 
@@ -1029,9 +1368,82 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+@test "syncCatalog/Deployment: log level ConfigMap disabled by default" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-log-level-configmap"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: log level ConfigMap can be enabled with global.logLevelConfigMap.enabled" {
+  cd `chart_dir`
+  local cmd=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'global.logLevelConfigMap.enabled=true' \
+      --namespace foo \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-log-level-configmap=release-name-consul-log-levels"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-log-level-configmap-namespace=foo"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
 #--------------------------------------------------------------------
 # Vault
 
+@test "syncCatalog/Deployment: secret-reload-interval is not set without vault" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-secret-reload-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: secret-reload-interval is set when TLS and vault are enabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'global.tls.caCert.secretName=foo' \
+      --set 'global.secretsBackend.vault.enabled=true' \
+      --set 'global.secretsBackend.vault.consulClientRole=test' \
+      --set 'global.secretsBackend.vault.consulServerRole=foo' \
+      --set 'global.secretsBackend.vault.consulCARole=carole' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-secret-reload-interval=1m"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "syncCatalog/Deployment: secret-reload-interval can be disabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'global.tls.enabled=true' \
+      --set 'global.tls.caCert.secretName=foo' \
+      --set 'global.secretsBackend.vault.enabled=true' \
+      --set 'global.secretsBackend.vault.consulClientRole=test' \
+      --set 'global.secretsBackend.vault.consulServerRole=foo' \
+      --set 'global.secretsBackend.vault.consulCARole=carole' \
+      --set 'global.secretsBackend.vault.secretReloadInterval=' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0].command | any(contains("-secret-reload-interval"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
 @test "syncCatalog/Deployment: configures server CA to come from vault when vault is enabled" {
   cd `chart_dir`
   local object=$(helm template \
@@ -1398,3 +1810,71 @@ reservedNameTest() {
   
   [[ "$output" =~ "When either global.cloud.scadaAddress.secretName or global.cloud.scadaAddress.secretKey is defined, both must be set." ]]
 }
+
+#--------------------------------------------------------------------
+# leaderElection
+
+@test "syncCatalog/Deployment: one replica and no leader election by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      . | tee /dev/stderr |
+      yq -r '.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.replicas' | tee /dev/stderr)
+  [ "${actual}" = "1" ]
+
+  local actual=$(echo "$object" |
+    yq '.template.spec.containers[0].command | any(contains("-enable-leader-election"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "syncCatalog/Deployment: fails with more than one replica and leader election disabled" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.replicas=2' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "syncCatalog.leaderElection.enabled must be true to run more than one syncCatalog replica" ]]
+}
+
+@test "syncCatalog/Deployment: multiple replicas with leader election enabled" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/sync-catalog-deployment.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.replicas=3' \
+      --set 'syncCatalog.leaderElection.enabled=true' \
+      --set 'syncCatalog.leaderElection.leaseDuration=30s' \
+      --namespace foo \
+      . | tee /dev/stderr |
+      yq -r '.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" | yq -r '.replicas' | tee /dev/stderr)
+  [ "${actual}" = "3" ]
+
+  local cmd=$(echo "$object" | yq '.template.spec.containers[0].command' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-enable-leader-election=true"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-leader-election-namespace=foo"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-leader-election-id=release-name-consul-sync-catalog-leader"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-leader-election-lease-duration=30s"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'any(contains("-leader-election-renew-deadline=10s"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
@@ -0,0 +1,48 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "syncCatalog/LeaderElectionRole: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/sync-catalog-leader-election-role.yaml  \
+      .
+}
+
+@test "syncCatalog/LeaderElectionRole: disabled with sync enabled" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/sync-catalog-leader-election-role.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      .
+}
+
+@test "syncCatalog/LeaderElectionRole: disabled with leader election enabled and sync disabled" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/sync-catalog-leader-election-role.yaml  \
+      --set 'syncCatalog.leaderElection.enabled=true' \
+      .
+}
+
+@test "syncCatalog/LeaderElectionRole: enabled with sync and leader election enabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-leader-election-role.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.leaderElection.enabled=true' \
+      . | tee /dev/stderr |
+      yq -s 'length > 0' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "syncCatalog/LeaderElectionRole: only allows updating the leader election Lease" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-leader-election-role.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.leaderElection.enabled=true' \
+      . | tee /dev/stderr |
+      yq -c '.rules[1]' | tee /dev/stderr)
+  [ "${actual}" = '{"apiGroups":["coordination.k8s.io"],"resources":["leases"],"resourceNames":["release-name-consul-sync-catalog-leader"],"verbs":["get","update"]}' ]
+}
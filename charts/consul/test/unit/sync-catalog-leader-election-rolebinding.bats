@@ -0,0 +1,37 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "syncCatalog/LeaderElectionRoleBinding: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/sync-catalog-leader-election-rolebinding.yaml  \
+      .
+}
+
+@test "syncCatalog/LeaderElectionRoleBinding: disabled with sync enabled" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/sync-catalog-leader-election-rolebinding.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      .
+}
+
+@test "syncCatalog/LeaderElectionRoleBinding: disabled with leader election enabled and sync disabled" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/sync-catalog-leader-election-rolebinding.yaml  \
+      --set 'syncCatalog.leaderElection.enabled=true' \
+      .
+}
+
+@test "syncCatalog/LeaderElectionRoleBinding: enabled with sync and leader election enabled" {
+  cd `chart_dir`
+  local actual=$(helm template \
+      -s templates/sync-catalog-leader-election-rolebinding.yaml  \
+      --set 'syncCatalog.enabled=true' \
+      --set 'syncCatalog.leaderElection.enabled=true' \
+      . | tee /dev/stderr |
+      yq -s 'length > 0' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
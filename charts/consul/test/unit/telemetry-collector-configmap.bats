@@ -26,4 +26,101 @@ load _helpers
       -s templates/telemetry-collector-configmap.yaml  \
       --set 'telemetryCollector.enabled=true' \
       .
-}
\ No newline at end of file
+}
+#--------------------------------------------------------------------
+# exporters
+
+@test "telemetryCollector/ConfigMap: enabled with telemetryCollector.exporters.otlp.endpoint" {
+  cd `chart_dir`
+  local config=$(helm template \
+      -s templates/telemetry-collector-configmap.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.exporters.otlp.endpoint=https://otel-collector:4318' \
+      --set 'telemetryCollector.exporters.otlp.headers[0].name=x-api-key' \
+      --set 'telemetryCollector.exporters.otlp.headers[0].secretName=otlp' \
+      --set 'telemetryCollector.exporters.otlp.headers[0].secretKey=key' \
+      . | tee /dev/stderr |
+      yq -r '.data["config.json"]' | tee /dev/stderr)
+
+  local actual=$(echo "$config" | jq -r '.exporters.otlphttp.endpoint' | tee /dev/stderr)
+  [ "${actual}" = "https://otel-collector:4318" ]
+
+  local actual=$(echo "$config" | jq -r '.exporters.otlphttp.headers["x-api-key"]' | tee /dev/stderr)
+  [ "${actual}" = '${env:OTLP_EXPORTER_HEADER_0}' ]
+
+  local actual=$(echo "$config" | jq -r '.exporters | has("prometheusremotewrite")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "telemetryCollector/ConfigMap: exporters are merged into telemetryCollector.customExporterConfig" {
+  cd `chart_dir`
+  local config=$(helm template \
+      -s templates/telemetry-collector-configmap.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.customExporterConfig="{\"http_collector_endpoint\": \"other-otel-collector\"\, \"exporters\": {\"logging\": {}}}"' \
+      --set 'telemetryCollector.exporters.prometheusRemoteWrite.endpoint=https://prometheus:9090/api/v1/write' \
+      --set 'telemetryCollector.exporters.prometheusRemoteWrite.headers[0].name=X-Scope-OrgID' \
+      --set 'telemetryCollector.exporters.prometheusRemoteWrite.headers[0].secretName=remote-write' \
+      --set 'telemetryCollector.exporters.prometheusRemoteWrite.headers[0].secretKey=tenant' \
+      . | tee /dev/stderr |
+      yq -r '.data["config.json"]' | tee /dev/stderr)
+
+  local actual=$(echo "$config" | jq -r '.http_collector_endpoint' | tee /dev/stderr)
+  [ "${actual}" = "other-otel-collector" ]
+
+  local actual=$(echo "$config" | jq -r '.exporters.prometheusremotewrite.endpoint' | tee /dev/stderr)
+  [ "${actual}" = "https://prometheus:9090/api/v1/write" ]
+
+  local actual=$(echo "$config" | jq -r '.exporters.prometheusremotewrite.headers["X-Scope-OrgID"]' | tee /dev/stderr)
+  [ "${actual}" = '${env:PROMETHEUS_REMOTE_WRITE_EXPORTER_HEADER_0}' ]
+
+  local actual=$(echo "$config" | jq -c '.exporters.logging' | tee /dev/stderr)
+  [ "${actual}" = "{}" ]
+}
+
+@test "telemetryCollector/ConfigMap: fails if an exporter is also configured in telemetryCollector.customExporterConfig" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/telemetry-collector-configmap.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.customExporterConfig="{\"exporters\": {\"otlphttp\": {}}}"' \
+      --set 'telemetryCollector.exporters.otlp.endpoint=https://otel-collector:4318' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "the otlphttp exporter is configured in both telemetryCollector.customExporterConfig and telemetryCollector.exporters" ]]
+}
+
+@test "telemetryCollector/ConfigMap: fails if an exporter header has no secret" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/telemetry-collector-configmap.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.exporters.otlp.endpoint=https://otel-collector:4318' \
+      --set 'telemetryCollector.exporters.otlp.headers[0].name=x-api-key' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "telemetryCollector.exporters.otlp.headers must each have a name, secretName and secretKey" ]]
+}
+
+@test "telemetryCollector/ConfigMap: fails if an exporter endpoint is not an http or https URL" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/telemetry-collector-configmap.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.exporters.prometheusRemoteWrite.endpoint=prometheus:9090' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "telemetryCollector.exporters.prometheusRemoteWrite.endpoint must be an http or https URL" ]]
+}
+
+@test "telemetryCollector/ConfigMap: fails if exporters are set and telemetryCollector.customExporterConfig is not a JSON object" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/telemetry-collector-configmap.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.customExporterConfig=foo' \
+      --set 'telemetryCollector.exporters.otlp.endpoint=https://otel-collector:4318' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "telemetryCollector.customExporterConfig must be a JSON object if telemetryCollector.exporters are set" ]]
+}
@@ -927,6 +927,53 @@ load _helpers
   [ "${actual}" = "true" ]
 }
 
+@test "telemetryCollector/Deployment: config flag is set when exporters are configured" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/telemetry-collector-deployment.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.image=bar' \
+      --set 'telemetryCollector.exporters.otlp.endpoint=https://otel-collector:4318' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[0]')
+
+  local actual=$(echo $object | yq -r '.command | any(contains("-config-file-path /consul/config/config.json"))' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo $object | yq -r '.volumeMounts[] | select(.name == "config") | .mountPath' | tee /dev/stderr)
+  [ "${actual}" = "/consul/config" ]
+}
+
+@test "telemetryCollector/Deployment: exporter headers are set from their secrets" {
+  cd `chart_dir`
+  local env=$(helm template \
+      -s templates/telemetry-collector-deployment.yaml  \
+      --set 'telemetryCollector.enabled=true' \
+      --set 'telemetryCollector.image=bar' \
+      --set 'telemetryCollector.exporters.otlp.endpoint=https://otel-collector:4318' \
+      --set 'telemetryCollector.exporters.otlp.headers[0].name=x-api-key' \
+      --set 'telemetryCollector.exporters.otlp.headers[0].secretName=otlp' \
+      --set 'telemetryCollector.exporters.otlp.headers[0].secretKey=key' \
+      --set 'telemetryCollector.exporters.prometheusRemoteWrite.endpoint=https://prometheus:9090/api/v1/write' \
+      --set 'telemetryCollector.exporters.prometheusRemoteWrite.headers[0].name=X-Scope-OrgID' \
+      --set 'telemetryCollector.exporters.prometheusRemoteWrite.headers[0].secretName=remote-write' \
+      --set 'telemetryCollector.exporters.prometheusRemoteWrite.headers[0].secretKey=tenant' \
+      . | tee /dev/stderr |
+      yq -r '.spec.template.spec.containers[0].env[]' | tee /dev/stderr)
+
+  local actual=$(echo $env | jq -r '. | select(.name == "OTLP_EXPORTER_HEADER_0") | .valueFrom.secretKeyRef.name' | tee /dev/stderr)
+  [ "${actual}" = "otlp" ]
+
+  local actual=$(echo $env | jq -r '. | select(.name == "OTLP_EXPORTER_HEADER_0") | .valueFrom.secretKeyRef.key' | tee /dev/stderr)
+  [ "${actual}" = "key" ]
+
+  local actual=$(echo $env | jq -r '. | select(.name == "PROMETHEUS_REMOTE_WRITE_EXPORTER_HEADER_0") | .valueFrom.secretKeyRef.name' | tee /dev/stderr)
+  [ "${actual}" = "remote-write" ]
+
+  local actual=$(echo $env | jq -r '. | select(.name == "PROMETHEUS_REMOTE_WRITE_EXPORTER_HEADER_0") | .valueFrom.secretKeyRef.key' | tee /dev/stderr)
+  [ "${actual}" = "tenant" ]
+}
+
 @test "telemetryCollector/Deployment: consul-ca-cert volume mount is not set on acl-init when using externalServers and useSystemRoots" {
   cd `chart_dir`
   local actual=$(helm template \
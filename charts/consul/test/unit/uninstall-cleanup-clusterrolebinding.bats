@@ -0,0 +1,35 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+target=templates/uninstall-cleanup-clusterrolebinding.yaml
+
+@test "uninstallCleanup/ClusterRoleBinding: disabled by default" {
+    cd `chart_dir`
+    assert_empty helm template \
+        -s $target \
+        .
+}
+
+@test "uninstallCleanup/ClusterRoleBinding: enabled with externalServers.enabled=true" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        . | tee /dev/stderr |
+        yq 'length > 0' | tee /dev/stderr)
+    [ "$actual" = "true" ]
+}
+
+@test "uninstallCleanup/ClusterRoleBinding: disabled with externalServers.uninstallCleanup.enabled=false" {
+    cd `chart_dir`
+    assert_empty helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'externalServers.uninstallCleanup.enabled=false' \
+        .
+}
@@ -0,0 +1,361 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+target=templates/uninstall-cleanup-job.yaml
+
+@test "uninstallCleanup/Job: disabled by default" {
+    cd `chart_dir`
+    assert_empty helm template \
+        -s $target \
+        .
+}
+
+@test "uninstallCleanup/Job: enabled with externalServers.enabled=true" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        . | tee /dev/stderr |
+        yq 'length > 0' | tee /dev/stderr)
+    [ "$actual" = "true" ]
+}
+
+@test "uninstallCleanup/Job: disabled with externalServers.uninstallCleanup.enabled=false" {
+    cd `chart_dir`
+    assert_empty helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'externalServers.uninstallCleanup.enabled=false' \
+        .
+}
+
+@test "uninstallCleanup/Job: disabled with connectInject.enabled=false and syncCatalog.enabled=false" {
+    cd `chart_dir`
+    assert_empty helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'connectInject.enabled=false' \
+        --set 'syncCatalog.enabled=false' \
+        .
+}
+
+@test "uninstallCleanup/Job: disabled with global.secretsBackend.vault.enabled=true" {
+    cd `chart_dir`
+    assert_empty helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'global.secretsBackend.vault.enabled=true' \
+        --set 'global.secretsBackend.vault.consulClientRole=foo' \
+        --set 'global.secretsBackend.vault.consulServerRole=bar' \
+        --set 'global.secretsBackend.vault.manageSystemACLsRole=baz' \
+        .
+}
+
+@test "uninstallCleanup/Job: is a pre-delete hook" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        . | tee /dev/stderr |
+        yq -r '.metadata.annotations["helm.sh/hook"]' | tee /dev/stderr)
+    [ "${actual}" = "pre-delete" ]
+}
+
+#--------------------------------------------------------------------
+# deployments
+
+@test "uninstallCleanup/Job: stops the connect injector by default" {
+    cd `chart_dir`
+    local cmd=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        . | tee /dev/stderr |
+        yq '.spec.template.spec.containers[0].command[2]' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-deployment=release-name-consul-connect-injector \\")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-deployment=release-name-consul-sync-catalog")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-sync-consul-node-name")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "uninstallCleanup/Job: stops every shard of the connect injector" {
+    cd `chart_dir`
+    local cmd=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'connectInject.sharding.shardCount=2' \
+        . | tee /dev/stderr |
+        yq '.spec.template.spec.containers[0].command[2]' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-deployment=release-name-consul-connect-injector-shard-0")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-deployment=release-name-consul-connect-injector-shard-1")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "uninstallCleanup/Job: stops the catalog sync with syncCatalog.enabled=true" {
+    cd `chart_dir`
+    local cmd=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'syncCatalog.enabled=true' \
+        --set 'syncCatalog.k8sTag=foo' \
+        . | tee /dev/stderr |
+        yq '.spec.template.spec.containers[0].command[2]' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-deployment=release-name-consul-sync-catalog")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-sync-consul-node-name=k8s-sync")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-sync-consul-k8s-tag=foo")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# externalServers.clusterName
+
+@test "uninstallCleanup/Job: sets -cluster-name with externalServers.clusterName" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'externalServers.clusterName=east' \
+        . | tee /dev/stderr |
+        yq -r '.spec.template.spec.containers[0].command[2] | contains("-cluster-name=east")' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# global.acls.manageSystemACLs
+
+@test "uninstallCleanup/Job: ACL token and auth methods are not set by default" {
+    cd `chart_dir`
+    local object=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        . | tee /dev/stderr |
+        yq '.spec.template.spec.containers[0]' | tee /dev/stderr)
+
+  local actual=$(echo "$object" |
+    yq '[.env[].name] | any(contains("CONSUL_ACL_TOKEN"))' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$object" |
+    yq -r '.command[2] | contains("-auth-method")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+}
+
+@test "uninstallCleanup/Job: sets the ACL token and auth methods with global.acls.manageSystemACLs=true" {
+    cd `chart_dir`
+    local object=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'global.acls.manageSystemACLs=true' \
+        . | tee /dev/stderr |
+        yq '.spec.template.spec.containers[0]' | tee /dev/stderr)
+
+  local actual=$(echo "$object" |
+    yq -r '.env[] | select(.name == "CONSUL_ACL_TOKEN") | .valueFrom.secretKeyRef.name' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-bootstrap-acl-token" ]
+
+  local actual=$(echo "$object" |
+    yq -r '.command[2] | contains("-auth-method=release-name-consul-k8s-auth-method")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$object" |
+    yq -r '.command[2] | contains("-auth-method=release-name-consul-k8s-component-auth-method")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "uninstallCleanup/Job: uses the bootstrap token secret with global.acls.bootstrapToken.secretName" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'global.acls.manageSystemACLs=true' \
+        --set 'global.acls.bootstrapToken.secretName=foo' \
+        --set 'global.acls.bootstrapToken.secretKey=bar' \
+        . | tee /dev/stderr |
+        yq -c '.spec.template.spec.containers[0].env[] | select(.name == "CONSUL_ACL_TOKEN") | .valueFrom.secretKeyRef' | tee /dev/stderr)
+    [ "${actual}" = '{"name":"foo","key":"bar"}' ]
+}
+
+@test "uninstallCleanup/Job: uses connectInject.overrideAuthMethodName" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'global.acls.manageSystemACLs=true' \
+        --set 'connectInject.overrideAuthMethodName=override' \
+        . | tee /dev/stderr |
+        yq -r '.spec.template.spec.containers[0].command[2] | contains("-auth-method=override")' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# global.tls.enabled
+
+@test "uninstallCleanup/Job: mounts the CA with global.tls.enabled=true" {
+    cd `chart_dir`
+    local object=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'global.tls.enabled=true' \
+        . | tee /dev/stderr |
+        yq '.spec.template.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$object" |
+    yq -r '.volumes[] | select(.name == "consul-ca-cert") | .secret.secretName' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-ca-cert" ]
+
+  local actual=$(echo "$object" |
+    yq -r '.containers[0].volumeMounts[] | select(.name == "consul-ca-cert") | .mountPath' | tee /dev/stderr)
+  [ "${actual}" = "/consul/tls/ca" ]
+}
+
+@test "uninstallCleanup/Job: does not mount the CA with externalServers.useSystemRoots=true" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'externalServers.useSystemRoots=true' \
+        --set 'global.tls.enabled=true' \
+        . | tee /dev/stderr |
+        yq '.spec.template.spec.volumes' | tee /dev/stderr)
+    [ "${actual}" = "null" ]
+}
+
+#--------------------------------------------------------------------
+# externalServers.uninstallCleanup
+
+@test "uninstallCleanup/Job: keeps namespaces and partitions by default" {
+    cd `chart_dir`
+    local cmd=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        . | tee /dev/stderr |
+        yq '.spec.template.spec.containers[0].command[2]' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-delete-namespaces")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-delete-partition")' | tee /dev/stderr)
+  [ "${actual}" = "false" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-timeout=2m")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "uninstallCleanup/Job: deletes namespaces with externalServers.uninstallCleanup.deleteNamespaces=true" {
+    cd `chart_dir`
+    local cmd=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'global.enableConsulNamespaces=true' \
+        --set 'externalServers.uninstallCleanup.deleteNamespaces=true' \
+        . | tee /dev/stderr |
+        yq '.spec.template.spec.containers[0].command[2]' | tee /dev/stderr)
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-enable-namespaces=true")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+
+  local actual=$(echo "$cmd" |
+    yq 'contains("-delete-namespaces=true")' | tee /dev/stderr)
+  [ "${actual}" = "true" ]
+}
+
+@test "uninstallCleanup/Job: fails if deleteNamespaces is true and global.enableConsulNamespaces is false" {
+    cd `chart_dir`
+    run helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'externalServers.uninstallCleanup.deleteNamespaces=true' \
+        .
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "global.enableConsulNamespaces must be true if externalServers.uninstallCleanup.deleteNamespaces is true" ]]
+}
+
+@test "uninstallCleanup/Job: deletes the partition with externalServers.uninstallCleanup.deletePartition=true" {
+    cd `chart_dir`
+    local actual=$(helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'global.enableConsulNamespaces=true' \
+        --set 'global.adminPartitions.enabled=true' \
+        --set 'global.adminPartitions.name=east' \
+        --set 'externalServers.uninstallCleanup.deletePartition=true' \
+        . | tee /dev/stderr |
+        yq -r '.spec.template.spec.containers[0].command[2] | contains("-delete-partition=true")' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+}
+
+@test "uninstallCleanup/Job: fails if deletePartition is true and global.adminPartitions.enabled is false" {
+    cd `chart_dir`
+    run helm template \
+        -s $target \
+        --set 'server.enabled=false' \
+        --set 'externalServers.enabled=true' \
+        --set 'externalServers.hosts[0]=foo' \
+        --set 'externalServers.uninstallCleanup.deletePartition=true' \
+        .
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "global.adminPartitions.enabled must be true if externalServers.uninstallCleanup.deletePartition is true" ]]
+}
@@ -0,0 +1,95 @@
+#!/usr/bin/env bats
+
+load _helpers
+
+@test "webhookCertManager/Certificate: disabled by default" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/webhook-cert-manager-certificate.yaml  \
+      .
+}
+
+@test "webhookCertManager/Certificate: disabled with connectInject.enabled=false" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/webhook-cert-manager-certificate.yaml  \
+      --set 'connectInject.enabled=false' \
+      --set 'webhookCertManager.certManager.enabled=true' \
+      --set 'webhookCertManager.certManager.issuerRef.name=ca-issuer' \
+      .
+}
+
+@test "webhookCertManager/Certificate: fails if issuerRef.name is not set" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/webhook-cert-manager-certificate.yaml  \
+      --set 'webhookCertManager.certManager.enabled=true' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "webhookCertManager.certManager.issuerRef.name must be set if webhookCertManager.certManager.enabled is true" ]]
+}
+
+@test "webhookCertManager/Certificate: fails if the webhook certificates are issued by Vault" {
+  cd `chart_dir`
+  run helm template \
+      -s templates/webhook-cert-manager-certificate.yaml  \
+      --set 'webhookCertManager.certManager.enabled=true' \
+      --set 'webhookCertManager.certManager.issuerRef.name=ca-issuer' \
+      --set 'global.secretsBackend.vault.enabled=true' \
+      --set 'global.secretsBackend.vault.consulClientRole=foo' \
+      --set 'global.secretsBackend.vault.consulServerRole=bar' \
+      --set 'global.secretsBackend.vault.consulCARole=test' \
+      --set 'global.secretsBackend.vault.connectInjectRole=inject-ca-role' \
+      --set 'global.secretsBackend.vault.connectInject.tlsCert.secretName=pki/issue/connect-webhook-cert-dc1' \
+      --set 'global.secretsBackend.vault.connectInject.caCert.secretName=pki/issue/connect-webhook-cert-dc1' \
+      .
+  [ "$status" -eq 1 ]
+  [[ "$output" =~ "webhookCertManager.certManager.enabled can't be set when the webhook certificates are issued by Vault" ]]
+}
+
+@test "webhookCertManager/Certificate: issues the webhook certificate with the issuer" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s templates/webhook-cert-manager-certificate.yaml  \
+      --set 'webhookCertManager.certManager.enabled=true' \
+      --set 'webhookCertManager.certManager.issuerRef.name=ca-issuer' \
+      --set 'webhookCertManager.certManager.issuerRef.kind=ClusterIssuer' \
+      . | tee /dev/stderr |
+      yq -r '.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | yq -r '.secretName' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-connect-inject-webhook-cert" ]
+
+  local actual=$(echo "$spec" | yq -r '.dnsNames | length' | tee /dev/stderr)
+  [ "${actual}" = "4" ]
+
+  local actual=$(echo "$spec" | yq -r '.dnsNames[2]' | tee /dev/stderr)
+  [ "${actual}" = "release-name-consul-connect-injector.default.svc" ]
+
+  local actual=$(echo "$spec" | yq -r '.issuerRef.name' | tee /dev/stderr)
+  [ "${actual}" = "ca-issuer" ]
+
+  local actual=$(echo "$spec" | yq -r '.issuerRef.kind' | tee /dev/stderr)
+  [ "${actual}" = "ClusterIssuer" ]
+
+  local actual=$(echo "$spec" | yq -r '.duration' | tee /dev/stderr)
+  [ "${actual}" = "null" ]
+}
+
+@test "webhookCertManager/Certificate: duration and renewBefore can be set" {
+  cd `chart_dir`
+  local spec=$(helm template \
+      -s templates/webhook-cert-manager-certificate.yaml  \
+      --set 'webhookCertManager.certManager.enabled=true' \
+      --set 'webhookCertManager.certManager.issuerRef.name=ca-issuer' \
+      --set 'webhookCertManager.certManager.duration=2160h' \
+      --set 'webhookCertManager.certManager.renewBefore=360h' \
+      . | tee /dev/stderr |
+      yq -r '.spec' | tee /dev/stderr)
+
+  local actual=$(echo "$spec" | yq -r '.duration' | tee /dev/stderr)
+  [ "${actual}" = "2160h" ]
+
+  local actual=$(echo "$spec" | yq -r '.renewBefore' | tee /dev/stderr)
+  [ "${actual}" = "360h" ]
+}
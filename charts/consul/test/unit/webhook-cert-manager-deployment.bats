@@ -63,6 +63,18 @@ load _helpers
   [ "${actual}" = "value" ]
 }
 
+#--------------------------------------------------------------------
+# certManager
+
+@test "webhookCertManager/Deployment: disabled with webhookCertManager.certManager.enabled=true" {
+  cd `chart_dir`
+  assert_empty helm template \
+      -s templates/webhook-cert-manager-deployment.yaml  \
+      --set 'webhookCertManager.certManager.enabled=true' \
+      --set 'webhookCertManager.certManager.issuerRef.name=ca-issuer' \
+      .
+}
+
 #--------------------------------------------------------------------
 # Vault
 
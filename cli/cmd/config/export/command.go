@@ -0,0 +1,251 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package export
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+const (
+	flagNameNamespace   = "namespace"
+	flagNameOutputFile  = "output-file"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+
+	// lastAppliedAnnotation is set by kubectl apply and refers to the
+	// resource in the source cluster, so it is not exported.
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// ExportCommand writes the config entry custom resources in a cluster to a
+// YAML bundle which can be applied to another cluster with config import.
+type ExportCommand struct {
+	*common.BaseCommand
+
+	dynamicK8sClient dynamic.Interface
+
+	set *flag.Sets
+
+	flagNamespace  string
+	flagOutputFile string
+
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *ExportCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameNamespace,
+		Target:  &c.flagNamespace,
+		Usage:   "The namespace to export config entries from. Defaults to all namespaces.",
+		Aliases: []string{"n"},
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameOutputFile,
+		Target:  &c.flagOutputFile,
+		Usage:   "The file to write the bundle to. Defaults to stdout.",
+		Aliases: []string{"o"},
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run exports the config entry custom resources.
+func (c *ExportCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	c.Log.ResetNamed("config export")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.setupKubeClient(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	bundle, count, err := c.export()
+	if err != nil {
+		c.UI.Output("Error exporting config entries: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.flagOutputFile == "" {
+		c.UI.Output("%s", strings.TrimSuffix(bundle, "\n"))
+		return 0
+	}
+
+	if err := os.WriteFile(c.flagOutputFile, []byte(bundle), 0600); err != nil {
+		c.UI.Output("Error writing config entries to %s: %v", c.flagOutputFile, err, terminal.WithErrorStyle())
+		return 1
+	}
+	c.UI.Output("Exported %d config entries to %s", count, c.flagOutputFile, terminal.WithSuccessStyle())
+
+	return 0
+}
+
+// export lists the config entry custom resources and returns them as a
+// multi-document YAML bundle along with the number of resources exported.
+func (c *ExportCommand) export() (string, int, error) {
+	var docs []string
+	for _, resource := range common.ConfigEntryResources {
+		list, err := c.dynamicK8sClient.Resource(resource.GVR()).Namespace(c.flagNamespace).List(c.Ctx, metav1.ListOptions{})
+		// Skip custom resources whose definitions are not installed.
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return "", 0, fmt.Errorf("error listing %s: %w", resource.Resource, err)
+		}
+
+		for _, cr := range list.Items {
+			out, err := yaml.Marshal(portable(cr).Object)
+			if err != nil {
+				return "", 0, err
+			}
+			docs = append(docs, string(out))
+		}
+	}
+
+	return strings.Join(docs, "---\n"), len(docs), nil
+}
+
+// portable returns a copy of the custom resource with only the fields needed
+// to recreate it in another cluster. Status and server populated metadata
+// such as the UID and resource version are dropped.
+func portable(cr unstructured.Unstructured) *unstructured.Unstructured {
+	out := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	out.SetAPIVersion(cr.GetAPIVersion())
+	out.SetKind(cr.GetKind())
+	out.SetName(cr.GetName())
+	out.SetNamespace(cr.GetNamespace())
+	if labels := cr.GetLabels(); len(labels) > 0 {
+		out.SetLabels(labels)
+	}
+
+	annotations := cr.GetAnnotations()
+	delete(annotations, lastAppliedAnnotation)
+	if len(annotations) > 0 {
+		out.SetAnnotations(annotations)
+	}
+
+	if spec, ok := cr.Object["spec"]; ok {
+		out.Object["spec"] = spec
+	}
+	return out
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *ExportCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if errs := validation.ValidateNamespaceName(c.flagNamespace, false); c.flagNamespace != "" && len(errs) > 0 {
+		return fmt.Errorf("invalid namespace name passed for -namespace/-n: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// setupKubeClient initializes the dynamic Kubernetes client used to read the
+// custom resources.
+func (c *ExportCommand) setupKubeClient() error {
+	if c.dynamicK8sClient != nil {
+		return nil
+	}
+
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return fmt.Errorf("error retrieving Kubernetes authentication: %v", err)
+	}
+	c.dynamicK8sClient, err = dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("error initializing Kubernetes client: %v", err)
+	}
+	return nil
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *ExportCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameOutputFile):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *ExportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Help returns a description of the command and how it is used.
+func (c *ExportCommand) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s config export [flags]\n\n" +
+		"Writes the config entry custom resources managed by consul-k8s to a YAML bundle\n" +
+		"which can be applied to another cluster with consul-k8s config import.\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *ExportCommand) Synopsis() string {
+	return "Export the config entry custom resources of a Consul installation on Kubernetes."
+}
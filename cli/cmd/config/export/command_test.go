@@ -0,0 +1,204 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package export
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	cmnFlag "github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+func TestExport(t *testing.T) {
+	serviceDefaults := configEntry("ServiceDefaults", "web", "default", map[string]interface{}{"protocol": "http"})
+	serviceDefaults.SetUID("6f1c7a38-0c0e-4a64-9b8e-1f5d0c2a1f11")
+	serviceDefaults.SetResourceVersion("42")
+	serviceDefaults.SetFinalizers([]string{"finalizers.consul.hashicorp.com"})
+	serviceDefaults.SetAnnotations(map[string]string{
+		"kubectl.kubernetes.io/last-applied-configuration": "{}",
+		"consul.hashicorp.com/migrate-entry":               "true",
+	})
+	serviceDefaults.Object["status"] = map[string]interface{}{"lastSyncedTime": "2023-06-01T00:00:00Z"}
+
+	router := configEntry("ServiceRouter", "web", "other", map[string]interface{}{"routes": []interface{}{}})
+
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"all namespaces": {
+			expected: `apiVersion: consul.hashicorp.com/v1alpha1
+kind: ServiceDefaults
+metadata:
+  annotations:
+    consul.hashicorp.com/migrate-entry: "true"
+  name: web
+  namespace: default
+spec:
+  protocol: http
+---
+apiVersion: consul.hashicorp.com/v1alpha1
+kind: ServiceRouter
+metadata:
+  name: web
+  namespace: other
+spec:
+  routes: []
+`,
+		},
+		"single namespace": {
+			args: []string{"-namespace", "other"},
+			expected: `apiVersion: consul.hashicorp.com/v1alpha1
+kind: ServiceRouter
+metadata:
+  name: web
+  namespace: other
+spec:
+  routes: []
+`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			c.dynamicK8sClient = newFakeDynamicClient(t, serviceDefaults, router)
+
+			outputFile := filepath.Join(t.TempDir(), "bundle.yaml")
+			returnCode := c.Run(append(tc.args, "-output-file", outputFile))
+			require.Equal(t, 0, returnCode, buf.String())
+
+			bundle, err := os.ReadFile(outputFile)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(bundle))
+		})
+	}
+}
+
+func TestExport_Stdout(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.dynamicK8sClient = newFakeDynamicClient(t, configEntry("Mesh", "mesh", "consul", map[string]interface{}{}))
+
+	returnCode := c.Run([]string{})
+	require.Equal(t, 0, returnCode)
+	require.Equal(t, "apiVersion: consul.hashicorp.com/v1alpha1\nkind: Mesh\nmetadata:\n  name: mesh\n  namespace: consul\nspec: {}\n", buf.String())
+}
+
+func TestExport_InvalidFlags(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+
+	returnCode := c.Run([]string{"-namespace", "Not_A_Namespace"})
+	require.Equal(t, 1, returnCode)
+	require.Contains(t, buf.String(), "invalid namespace name passed for -namespace/-n")
+}
+
+func TestTaskCreateCommand_AutocompleteFlags(t *testing.T) {
+	t.Parallel()
+	cmd := getInitializedCommand(t, nil)
+
+	predictor := cmd.AutocompleteFlags()
+
+	// Test that we get the expected number of predictions
+	args := complete.Args{Last: "-"}
+	res := predictor.Predict(args)
+
+	// Grab the list of flags from the Flag object
+	flags := make([]string, 0)
+	cmd.set.VisitSets(func(name string, set *cmnFlag.Set) {
+		set.VisitAll(func(flag *flag.Flag) {
+			flags = append(flags, fmt.Sprintf("-%s", flag.Name))
+		})
+	})
+
+	// Verify that there is a prediction for each flag associated with the command
+	assert.Equal(t, len(flags), len(res))
+	assert.ElementsMatch(t, flags, res, "flags and predictions didn't match, make sure to add "+
+		"new flags to the command AutoCompleteFlags function")
+}
+
+func TestTaskCreateCommand_AutocompleteArgs(t *testing.T) {
+	cmd := getInitializedCommand(t, nil)
+	c := cmd.AutocompleteArgs()
+	assert.Equal(t, complete.PredictNothing, c)
+}
+
+// getInitializedCommand sets up a command struct for tests.
+func getInitializedCommand(t *testing.T, buf io.Writer) *ExportCommand {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &ExportCommand{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
+
+// newFakeDynamicClient returns a fake dynamic client which knows about all of
+// the config entry custom resources and contains the given objects.
+func newFakeDynamicClient(t *testing.T, objects ...*unstructured.Unstructured) dynamic.Interface {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, resource := range common.ConfigEntryResources {
+		listKinds[resource.GVR()] = resource.Kind + "List"
+	}
+
+	client := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	for _, obj := range objects {
+		resource, ok := common.ConfigEntryResourceForKind(obj.GetKind())
+		require.True(t, ok)
+		_, err := client.Resource(resource.GVR()).Namespace(obj.GetNamespace()).Create(context.Background(), obj, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	return client
+}
+
+func configEntry(kind, name, namespace string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": common.ConsulCRDGroup + "/" + common.ConsulCRDVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": spec,
+		},
+	}
+}
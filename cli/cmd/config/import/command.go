@@ -0,0 +1,273 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package configimport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+const (
+	flagNameFile        = "file"
+	flagNameNamespace   = "namespace"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+)
+
+// ImportCommand applies a bundle of config entry custom resources written by
+// config export to a cluster.
+type ImportCommand struct {
+	*common.BaseCommand
+
+	dynamicK8sClient dynamic.Interface
+
+	set *flag.Sets
+
+	flagFile      string
+	flagNamespace string
+
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *ImportCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameFile,
+		Target:  &c.flagFile,
+		Usage:   "The bundle of config entries to import, as written by config export.",
+		Aliases: []string{"f"},
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameNamespace,
+		Target:  &c.flagNamespace,
+		Usage:   "The namespace to import all config entries into. Defaults to the namespace each config entry was exported from.",
+		Aliases: []string{"n"},
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run imports the config entry custom resources.
+func (c *ImportCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	c.Log.ResetNamed("config import")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	bundle, err := os.ReadFile(c.flagFile)
+	if err != nil {
+		c.UI.Output("Error reading %s: %v", c.flagFile, err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	// Parse the whole bundle before applying anything so that an invalid
+	// bundle does not leave the cluster partially imported.
+	crs, err := c.parseBundle(bundle)
+	if err != nil {
+		c.UI.Output("Error parsing %s: %v", c.flagFile, err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	if err := c.setupKubeClient(); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	for _, cr := range crs {
+		if err := c.apply(cr); err != nil {
+			c.UI.Output("Error importing %s %s/%s: %v", cr.GetKind(), cr.GetNamespace(), cr.GetName(), err, terminal.WithErrorStyle())
+			return 1
+		}
+	}
+	c.UI.Output("Imported %d config entries", len(crs), terminal.WithSuccessStyle())
+
+	return 0
+}
+
+// parseBundle decodes the YAML documents in the bundle and checks that each
+// of them is a config entry custom resource.
+func (c *ImportCommand) parseBundle(bundle []byte) ([]*unstructured.Unstructured, error) {
+	var crs []*unstructured.Unstructured
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(bundle), 4096)
+	for {
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		// Skip empty documents, e.g. after a trailing separator.
+		if len(obj) == 0 {
+			continue
+		}
+
+		cr := &unstructured.Unstructured{Object: obj}
+		gv, err := schema.ParseGroupVersion(cr.GetAPIVersion())
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := common.ConfigEntryResourceForKind(cr.GetKind()); !ok || gv.Group != common.ConsulCRDGroup {
+			return nil, fmt.Errorf("%s %q is not a config entry custom resource", cr.GetKind(), cr.GetName())
+		}
+		if cr.GetName() == "" {
+			return nil, fmt.Errorf("%s is missing a name", cr.GetKind())
+		}
+
+		if c.flagNamespace != "" {
+			cr.SetNamespace(c.flagNamespace)
+		} else if cr.GetNamespace() == "" {
+			cr.SetNamespace(metav1.NamespaceDefault)
+		}
+		crs = append(crs, cr)
+	}
+
+	return crs, nil
+}
+
+// apply creates the custom resource, or updates it if it already exists.
+func (c *ImportCommand) apply(cr *unstructured.Unstructured) error {
+	resource, _ := common.ConfigEntryResourceForKind(cr.GetKind())
+	client := c.dynamicK8sClient.Resource(resource.GVR()).Namespace(cr.GetNamespace())
+
+	_, err := client.Create(c.Ctx, cr, metav1.CreateOptions{})
+	if err == nil {
+		c.UI.Output("Created %s %s/%s", cr.GetKind(), cr.GetNamespace(), cr.GetName(), terminal.WithSuccessStyle())
+		return nil
+	}
+	if !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	existing, err := client.Get(c.Ctx, cr.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	cr.SetResourceVersion(existing.GetResourceVersion())
+	if _, err := client.Update(c.Ctx, cr, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	c.UI.Output("Updated %s %s/%s", cr.GetKind(), cr.GetNamespace(), cr.GetName(), terminal.WithSuccessStyle())
+	return nil
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *ImportCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagFile == "" {
+		return errors.New("-file must be set")
+	}
+	if errs := validation.ValidateNamespaceName(c.flagNamespace, false); c.flagNamespace != "" && len(errs) > 0 {
+		return fmt.Errorf("invalid namespace name passed for -namespace/-n: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// setupKubeClient initializes the dynamic Kubernetes client used to write the
+// custom resources.
+func (c *ImportCommand) setupKubeClient() error {
+	if c.dynamicK8sClient != nil {
+		return nil
+	}
+
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return fmt.Errorf("error retrieving Kubernetes authentication: %v", err)
+	}
+	c.dynamicK8sClient, err = dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("error initializing Kubernetes client: %v", err)
+	}
+	return nil
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *ImportCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameFile):        complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *ImportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Help returns a description of the command and how it is used.
+func (c *ImportCommand) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s config import -file <bundle> [flags]\n\n" +
+		"Creates or updates the config entry custom resources from a bundle written by\n" +
+		"consul-k8s config export.\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *ImportCommand) Synopsis() string {
+	return "Import config entry custom resources into a Consul installation on Kubernetes."
+}
@@ -0,0 +1,248 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package configimport
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	cmnFlag "github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+)
+
+const bundle = `apiVersion: consul.hashicorp.com/v1alpha1
+kind: ServiceDefaults
+metadata:
+  name: web
+  namespace: default
+spec:
+  protocol: http
+---
+apiVersion: consul.hashicorp.com/v1alpha1
+kind: ServiceRouter
+metadata:
+  name: web
+spec:
+  routes: []
+---
+`
+
+func TestImport(t *testing.T) {
+	cases := map[string]struct {
+		args       []string
+		existing   []*unstructured.Unstructured
+		messages   []string
+		namespaces map[string]string
+	}{
+		"creates config entries": {
+			messages: []string{
+				" ✓ Created ServiceDefaults default/web\n",
+				" ✓ Created ServiceRouter default/web\n",
+				" ✓ Imported 2 config entries\n",
+			},
+			namespaces: map[string]string{"ServiceDefaults": "default", "ServiceRouter": "default"},
+		},
+		"updates existing config entries": {
+			existing: []*unstructured.Unstructured{
+				configEntry("ServiceDefaults", "web", "default", map[string]interface{}{"protocol": "tcp"}),
+			},
+			messages: []string{
+				" ✓ Updated ServiceDefaults default/web\n",
+				" ✓ Created ServiceRouter default/web\n",
+			},
+			namespaces: map[string]string{"ServiceDefaults": "default", "ServiceRouter": "default"},
+		},
+		"overrides the namespace": {
+			args: []string{"-namespace", "restored"},
+			messages: []string{
+				" ✓ Created ServiceDefaults restored/web\n",
+				" ✓ Created ServiceRouter restored/web\n",
+			},
+			namespaces: map[string]string{"ServiceDefaults": "restored", "ServiceRouter": "restored"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			c.dynamicK8sClient = newFakeDynamicClient(t, tc.existing...)
+
+			returnCode := c.Run(append(tc.args, "-file", writeBundle(t, bundle)))
+			require.Equal(t, 0, returnCode, buf.String())
+
+			output := buf.String()
+			for _, msg := range tc.messages {
+				require.Contains(t, output, msg)
+			}
+
+			for kind, namespace := range tc.namespaces {
+				resource, _ := common.ConfigEntryResourceForKind(kind)
+				cr, err := c.dynamicK8sClient.Resource(resource.GVR()).Namespace(namespace).Get(context.Background(), "web", metav1.GetOptions{})
+				require.NoError(t, err)
+				if kind == "ServiceDefaults" {
+					protocol, _, _ := unstructured.NestedString(cr.Object, "spec", "protocol")
+					require.Equal(t, "http", protocol)
+				}
+			}
+		})
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	cases := map[string]struct {
+		args     []string
+		bundle   string
+		expected string
+	}{
+		"missing file flag": {
+			expected: "-file must be set",
+		},
+		"file does not exist": {
+			args:     []string{"-file", "/does/not/exist.yaml"},
+			expected: "Error reading /does/not/exist.yaml",
+		},
+		"not a config entry": {
+			bundle:   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n",
+			expected: `ConfigMap "web" is not a config entry custom resource`,
+		},
+		"peering is not a config entry": {
+			bundle:   "apiVersion: consul.hashicorp.com/v1alpha1\nkind: PeeringDialer\nmetadata:\n  name: web\n",
+			expected: `PeeringDialer "web" is not a config entry custom resource`,
+		},
+		"missing name": {
+			bundle:   "apiVersion: consul.hashicorp.com/v1alpha1\nkind: ServiceDefaults\nspec: {}\n",
+			expected: "ServiceDefaults is missing a name",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			c.dynamicK8sClient = newFakeDynamicClient(t)
+
+			args := tc.args
+			if tc.bundle != "" {
+				args = append(args, "-file", writeBundle(t, tc.bundle))
+			}
+			returnCode := c.Run(args)
+			require.Equal(t, 1, returnCode)
+			require.Contains(t, buf.String(), tc.expected)
+		})
+	}
+}
+
+func TestTaskCreateCommand_AutocompleteFlags(t *testing.T) {
+	t.Parallel()
+	cmd := getInitializedCommand(t, nil)
+
+	predictor := cmd.AutocompleteFlags()
+
+	// Test that we get the expected number of predictions
+	args := complete.Args{Last: "-"}
+	res := predictor.Predict(args)
+
+	// Grab the list of flags from the Flag object
+	flags := make([]string, 0)
+	cmd.set.VisitSets(func(name string, set *cmnFlag.Set) {
+		set.VisitAll(func(flag *flag.Flag) {
+			flags = append(flags, fmt.Sprintf("-%s", flag.Name))
+		})
+	})
+
+	// Verify that there is a prediction for each flag associated with the command
+	assert.Equal(t, len(flags), len(res))
+	assert.ElementsMatch(t, flags, res, "flags and predictions didn't match, make sure to add "+
+		"new flags to the command AutoCompleteFlags function")
+}
+
+func TestTaskCreateCommand_AutocompleteArgs(t *testing.T) {
+	cmd := getInitializedCommand(t, nil)
+	c := cmd.AutocompleteArgs()
+	assert.Equal(t, complete.PredictNothing, c)
+}
+
+// getInitializedCommand sets up a command struct for tests.
+func getInitializedCommand(t *testing.T, buf io.Writer) *ImportCommand {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &ImportCommand{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
+
+func writeBundle(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bundle.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+// newFakeDynamicClient returns a fake dynamic client which knows about all of
+// the config entry custom resources and contains the given objects.
+func newFakeDynamicClient(t *testing.T, objects ...*unstructured.Unstructured) dynamic.Interface {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, resource := range common.ConfigEntryResources {
+		listKinds[resource.GVR()] = resource.Kind + "List"
+	}
+
+	client := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	for _, obj := range objects {
+		resource, ok := common.ConfigEntryResourceForKind(obj.GetKind())
+		require.True(t, ok)
+		_, err := client.Resource(resource.GVR()).Namespace(obj.GetNamespace()).Create(context.Background(), obj, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	return client
+}
+
+func configEntry(kind, name, namespace string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": common.ConsulCRDGroup + "/" + common.ConsulCRDVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": spec,
+		},
+	}
+}
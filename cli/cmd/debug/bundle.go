@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package debug
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const redacted = "<redacted>"
+
+// sensitiveValueKeys are substrings of Helm value keys whose values may hold
+// secrets. Keys referencing a Kubernetes secret by name or key are kept since
+// they don't hold the secret itself.
+var sensitiveValueKeys = []string{"token", "password", "secret", "encrypt", "license", "privatekey", "extraconfig"}
+
+// bundle is the set of files collected for the debug archive, keyed by their
+// path within the archive.
+type bundle struct {
+	files map[string][]byte
+	// errors are the non-fatal errors hit while collecting, which are
+	// written to the archive so that a partial bundle is still useful.
+	errors []string
+}
+
+func newBundle() *bundle {
+	return &bundle{files: make(map[string][]byte)}
+}
+
+func (b *bundle) add(name string, contents []byte) {
+	b.files[name] = contents
+}
+
+func (b *bundle) addError(format string, args ...interface{}) {
+	b.errors = append(b.errors, fmt.Sprintf(format, args...))
+}
+
+// write writes the bundle as a gzipped tarball with all files under a top
+// level directory named after the archive.
+func (b *bundle) write(file string, now time.Time) error {
+	if len(b.errors) > 0 {
+		b.add("errors.txt", []byte(strings.Join(b.errors, "\n")+"\n"))
+	}
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	root := strings.TrimSuffix(path.Base(file), ".tar.gz")
+	names := make([]string, 0, len(b.files))
+	for name := range b.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		contents := b.files[name]
+		hdr := &tar.Header{
+			Name:    path.Join(root, name),
+			Mode:    0600,
+			Size:    int64(len(contents)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(contents); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// redactValues returns a copy of the Helm values with the string values of
+// sensitive keys replaced.
+func redactValues(values map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		// Only strings can hold secrets, flags such as enableAutoEncrypt are kept.
+		if s, ok := v.(string); ok && s != "" && isSensitiveKey(k) {
+			out[k] = redacted
+			continue
+		}
+
+		switch value := v.(type) {
+		case map[string]interface{}:
+			out[k] = redactValues(value)
+		case []interface{}:
+			items := make([]interface{}, len(value))
+			for i, item := range value {
+				if m, ok := item.(map[string]interface{}); ok {
+					items[i] = redactValues(m)
+				} else {
+					items[i] = item
+				}
+			}
+			out[k] = items
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasSuffix(key, "secretname") || strings.HasSuffix(key, "secretkey") {
+		return false
+	}
+	for _, s := range sensitiveValueKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package debug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactValues(t *testing.T) {
+	values := map[string]interface{}{
+		"global": map[string]interface{}{
+			"name": "consul",
+			"acls": map[string]interface{}{
+				"manageSystemACLs": true,
+				"bootstrapToken": map[string]interface{}{
+					"secretName": "bootstrap-token",
+					"secretKey":  "token",
+				},
+				"replicationToken": "3a1f8e2c",
+			},
+			"tls": map[string]interface{}{
+				"enableAutoEncrypt": true,
+			},
+			"enterpriseLicense": map[string]interface{}{
+				"secretName": "license",
+			},
+		},
+		"server": map[string]interface{}{
+			"extraConfig": `{"encrypt": "c2VjcmV0"}`,
+			"extraVolumes": []interface{}{
+				map[string]interface{}{"name": "vault", "password": "hunter2"},
+			},
+		},
+		"connectInject": map[string]interface{}{
+			"envoyExtraArgs": "",
+		},
+	}
+
+	require.Equal(t, map[string]interface{}{
+		"global": map[string]interface{}{
+			"name": "consul",
+			"acls": map[string]interface{}{
+				"manageSystemACLs": true,
+				"bootstrapToken": map[string]interface{}{
+					"secretName": "bootstrap-token",
+					"secretKey":  "token",
+				},
+				"replicationToken": redacted,
+			},
+			"tls": map[string]interface{}{
+				"enableAutoEncrypt": true,
+			},
+			"enterpriseLicense": map[string]interface{}{
+				"secretName": "license",
+			},
+		},
+		"server": map[string]interface{}{
+			"extraConfig": redacted,
+			"extraVolumes": []interface{}{
+				map[string]interface{}{"name": "vault", "password": redacted},
+			},
+		},
+		"connectInject": map[string]interface{}{
+			"envoyExtraArgs": "",
+		},
+	}, redactValues(values))
+}
@@ -0,0 +1,475 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package debug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/posener/complete"
+	"helm.sh/helm/v3/pkg/action"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/envoy"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	flagNameOutputFile  = "output-file"
+	flagNameProxySample = "proxy-sample"
+	flagNameSince       = "since"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+
+	defaultProxySample = 3
+	defaultSince       = time.Hour
+
+	// defaultAdminPort is the port of the Envoy admin API of the first
+	// service in a Pod.
+	defaultAdminPort = 19000
+
+	// dataplaneContainer is the sidecar container whose logs show the state
+	// of the agentless connection to the Consul servers.
+	dataplaneContainer = "consul-dataplane"
+)
+
+// consulCustomResources are the Consul custom resources whose specs and
+// statuses are collected, on top of the config entries.
+var consulCustomResources = []string{"peeringacceptors", "peeringdialers", "registrations", "gatewayclassconfigs"}
+
+// DebugCommand collects the state of a Consul installation on Kubernetes into
+// a tarball which can be attached to support tickets.
+type DebugCommand struct {
+	*common.BaseCommand
+
+	helmActionsRunner helm.HelmActionsRunner
+
+	kubernetes       kubernetes.Interface
+	dynamicK8sClient dynamic.Interface
+	restConfig       *rest.Config
+
+	fetchConfig func(context.Context, common.PortForwarder) (*envoy.EnvoyConfig, error)
+
+	set *flag.Sets
+
+	flagOutputFile  string
+	flagProxySample int
+	flagSince       time.Duration
+
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *DebugCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameOutputFile,
+		Target:  &c.flagOutputFile,
+		Usage:   "The file to write the debug bundle to. Defaults to consul-k8s-debug-<timestamp>.tar.gz.",
+		Aliases: []string{"o"},
+	})
+	f.IntVar(&flag.IntVar{
+		Name:    flagNameProxySample,
+		Target:  &c.flagProxySample,
+		Default: defaultProxySample,
+		Usage:   "The number of injected Pods to collect Envoy config dumps and dataplane logs from.",
+	})
+	f.DurationVar(&flag.DurationVar{
+		Name:    flagNameSince,
+		Target:  &c.flagSince,
+		Default: defaultSince,
+		Usage:   "Only collect logs newer than this duration, e.g. 30m.",
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run collects the debug bundle.
+func (c *DebugCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	if c.helmActionsRunner == nil {
+		c.helmActionsRunner = &helm.ActionRunner{}
+	}
+	if c.fetchConfig == nil {
+		c.fetchConfig = envoy.FetchConfig
+	}
+
+	c.Log.ResetNamed("debug")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	// helmCLI.New() will create a settings object which is used by the Helm Go SDK calls.
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if err := c.setupKubeClient(settings); err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	// Setup logger to stream Helm library logs.
+	var uiLogger = func(s string, args ...interface{}) {
+		logMsg := fmt.Sprintf(s, args...)
+		c.UI.Output(logMsg, terminal.WithLibraryStyle())
+	}
+
+	_, releaseName, namespace, err := c.helmActionsRunner.CheckForInstallations(&helm.CheckForInstallationsOptions{
+		Settings:    settings,
+		ReleaseName: common.DefaultReleaseName,
+		DebugLog:    uiLogger,
+	})
+	if err != nil {
+		c.UI.Output(err.Error(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	now := time.Now()
+	if c.flagOutputFile == "" {
+		c.flagOutputFile = fmt.Sprintf("consul-k8s-debug-%s.tar.gz", now.UTC().Format("20060102T150405Z"))
+	}
+
+	c.UI.Output("Collecting Debug Bundle", terminal.WithHeaderStyle())
+
+	// Each collector records its failures in the bundle rather than aborting
+	// so that a partially broken installation can still be debugged.
+	b := newBundle()
+	c.collectHelmValues(b, settings, uiLogger, releaseName, namespace)
+	c.collectLogs(b, namespace)
+	c.collectWebhooks(b)
+	c.collectCustomResources(b)
+	c.collectProxies(b)
+
+	if err := b.write(c.flagOutputFile, now); err != nil {
+		c.UI.Output("Error writing debug bundle to %s: %v", c.flagOutputFile, err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	if len(b.errors) > 0 {
+		c.UI.Output("%d items could not be collected, see errors.txt in the bundle", len(b.errors), terminal.WithWarningStyle())
+	}
+	c.UI.Output("Debug bundle written to %s", c.flagOutputFile, terminal.WithSuccessStyle())
+
+	return 0
+}
+
+// collectHelmValues collects the Helm release information and its values with
+// secrets redacted.
+func (c *DebugCommand) collectHelmValues(b *bundle, settings *helmCLI.EnvSettings, uiLogger action.DebugLog, releaseName, namespace string) {
+	statusConfig := new(action.Configuration)
+	statusConfig, err := helm.InitActionConfig(statusConfig, namespace, settings, uiLogger)
+	if err != nil {
+		b.addError("helm: %v", err)
+		return
+	}
+
+	rel, err := c.helmActionsRunner.GetStatus(action.NewStatus(statusConfig), releaseName)
+	if err != nil {
+		b.addError("helm: couldn't get release %s: %v", releaseName, err)
+		return
+	}
+
+	release := map[string]interface{}{
+		"name":      releaseName,
+		"namespace": namespace,
+		"revision":  rel.Version,
+	}
+	if rel.Info != nil {
+		release["status"] = rel.Info.Status.String()
+		release["lastDeployed"] = rel.Info.LastDeployed.String()
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		release["chartVersion"] = rel.Chart.Metadata.Version
+		release["appVersion"] = rel.Chart.Metadata.AppVersion
+	}
+	c.addYAML(b, "helm/release.yaml", release)
+	c.addYAML(b, "helm/values.yaml", redactValues(rel.Config))
+	c.UI.Output("Collected Helm release %s", releaseName, terminal.WithSuccessStyle())
+}
+
+// collectLogs collects the logs of the Consul Pods in the installation
+// namespace, e.g. the servers, connect injector and gateways, and of the API
+// gateway Pods in all namespaces.
+func (c *DebugCommand) collectLogs(b *bundle, namespace string) {
+	selectors := []struct {
+		namespace string
+		selector  string
+	}{
+		{namespace: namespace, selector: "app=consul"},
+		{namespace: metav1.NamespaceAll, selector: "api-gateway.consul.hashicorp.com/managed=true"},
+	}
+
+	count := 0
+	for _, s := range selectors {
+		pods, err := c.kubernetes.CoreV1().Pods(s.namespace).List(c.Ctx, metav1.ListOptions{LabelSelector: s.selector})
+		if err != nil {
+			b.addError("logs: error listing pods with %s: %v", s.selector, err)
+			continue
+		}
+		for _, pod := range pods.Items {
+			for _, container := range pod.Spec.Containers {
+				c.collectContainerLogs(b, pod, container.Name)
+			}
+			count++
+		}
+	}
+	c.UI.Output("Collected logs from %d pods", count, terminal.WithSuccessStyle())
+}
+
+func (c *DebugCommand) collectContainerLogs(b *bundle, pod corev1.Pod, container string) {
+	since := int64(c.flagSince.Seconds())
+	req := c.kubernetes.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:    container,
+		SinceSeconds: &since,
+	})
+	stream, err := req.Stream(c.Ctx)
+	if err != nil {
+		b.addError("logs: error getting logs for %s/%s container %s: %v", pod.Namespace, pod.Name, container, err)
+		return
+	}
+	defer stream.Close()
+
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		b.addError("logs: error reading logs for %s/%s container %s: %v", pod.Namespace, pod.Name, container, err)
+		return
+	}
+	b.add(path.Join("logs", pod.Namespace, pod.Name, container+".log"), logs)
+}
+
+// collectWebhooks collects the mutating and validating webhook configurations
+// installed by the chart.
+func (c *DebugCommand) collectWebhooks(b *bundle) {
+	opts := metav1.ListOptions{LabelSelector: "app=consul"}
+
+	mutating, err := c.kubernetes.AdmissionregistrationV1().MutatingWebhookConfigurations().List(c.Ctx, opts)
+	if err != nil {
+		b.addError("webhooks: error listing mutating webhook configurations: %v", err)
+	} else {
+		for _, webhook := range mutating.Items {
+			webhook.ManagedFields = nil
+			c.addYAML(b, path.Join("webhooks", "mutating", webhook.Name+".yaml"), webhook)
+		}
+	}
+
+	validating, err := c.kubernetes.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(c.Ctx, opts)
+	if err != nil {
+		b.addError("webhooks: error listing validating webhook configurations: %v", err)
+	} else {
+		for _, webhook := range validating.Items {
+			webhook.ManagedFields = nil
+			c.addYAML(b, path.Join("webhooks", "validating", webhook.Name+".yaml"), webhook)
+		}
+	}
+
+	c.UI.Output("Collected webhook configurations", terminal.WithSuccessStyle())
+}
+
+// collectCustomResources collects the Consul custom resources, including
+// their sync status.
+func (c *DebugCommand) collectCustomResources(b *bundle) {
+	resources := make([]string, 0, len(common.ConfigEntryResources)+len(consulCustomResources))
+	for _, r := range common.ConfigEntryResources {
+		resources = append(resources, r.Resource)
+	}
+	resources = append(resources, consulCustomResources...)
+
+	count := 0
+	for _, resource := range resources {
+		gvr := schema.GroupVersionResource{Group: common.ConsulCRDGroup, Version: common.ConsulCRDVersion, Resource: resource}
+		list, err := c.dynamicK8sClient.Resource(gvr).Namespace(metav1.NamespaceAll).List(c.Ctx, metav1.ListOptions{})
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			b.addError("custom resources: error listing %s: %v", resource, err)
+			continue
+		}
+		if len(list.Items) == 0 {
+			continue
+		}
+		for i := range list.Items {
+			list.Items[i].SetManagedFields(nil)
+		}
+		c.addYAML(b, path.Join("crds", resource+".yaml"), list.Items)
+		count += len(list.Items)
+	}
+	c.UI.Output("Collected %d custom resources", count, terminal.WithSuccessStyle())
+}
+
+// collectProxies collects the Envoy config dumps and the consul-dataplane
+// logs, which show the state of the connection to the Consul servers, from a
+// sample of the injected Pods.
+func (c *DebugCommand) collectProxies(b *bundle) {
+	pods, err := c.kubernetes.CoreV1().Pods(metav1.NamespaceAll).List(c.Ctx, metav1.ListOptions{
+		LabelSelector: "consul.hashicorp.com/connect-inject-status=injected",
+	})
+	if err != nil {
+		b.addError("proxies: error listing injected pods: %v", err)
+		return
+	}
+
+	count := 0
+	for _, pod := range pods.Items {
+		if count >= c.flagProxySample {
+			break
+		}
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		count++
+
+		c.collectContainerLogs(b, pod, dataplaneContainer)
+
+		pf := common.PortForward{
+			Namespace:  pod.Namespace,
+			PodName:    pod.Name,
+			RemotePort: defaultAdminPort,
+			KubeClient: c.kubernetes,
+			RestConfig: c.restConfig,
+		}
+		config, err := c.fetchConfig(c.Ctx, &pf)
+		if err != nil {
+			b.addError("proxies: error fetching Envoy config for %s/%s: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+		b.add(path.Join("envoy", pod.Namespace, pod.Name, "config_dump.json"), config.JSON())
+	}
+	c.UI.Output("Collected proxy state from %d of %d injected pods", count, len(pods.Items), terminal.WithSuccessStyle())
+}
+
+func (c *DebugCommand) addYAML(b *bundle, name string, obj interface{}) {
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		b.addError("%s: %v", name, err)
+		return
+	}
+	b.add(name, out)
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *DebugCommand) validateFlags() error {
+	if len(c.set.Args()) > 0 {
+		return errors.New("should have no non-flag arguments")
+	}
+	if c.flagProxySample < 0 {
+		return errors.New("-proxy-sample must not be negative")
+	}
+	if c.flagSince <= 0 {
+		return errors.New("-since must be greater than zero")
+	}
+	return nil
+}
+
+// setupKubeClient to use for non Helm SDK calls to the Kubernetes API The Helm SDK will use
+// settings.RESTClientGetter for its calls as well, so this will use a consistent method to
+// target the right cluster for both Helm SDK and non Helm SDK calls.
+func (c *DebugCommand) setupKubeClient(settings *helmCLI.EnvSettings) error {
+	if c.restConfig == nil && (c.kubernetes == nil || c.dynamicK8sClient == nil) {
+		restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+		if err != nil {
+			return fmt.Errorf("error retrieving Kubernetes authentication: %v", err)
+		}
+		c.restConfig = restConfig
+	}
+
+	if c.kubernetes == nil {
+		var err error
+		if c.kubernetes, err = kubernetes.NewForConfig(c.restConfig); err != nil {
+			return fmt.Errorf("error initializing Kubernetes client: %v", err)
+		}
+	}
+
+	if c.dynamicK8sClient == nil {
+		var err error
+		if c.dynamicK8sClient, err = dynamic.NewForConfig(c.restConfig); err != nil {
+			return fmt.Errorf("error initializing Kubernetes client: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *DebugCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameOutputFile):  complete.PredictFiles("*.tar.gz"),
+		fmt.Sprintf("-%s", flagNameProxySample): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameSince):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+// Since argument completion is not supported, this will return
+// complete.PredictNothing.
+func (c *DebugCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+// Help returns a description of the command and how it is used.
+func (c *DebugCommand) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s debug [flags]\n\n" +
+		"Collects the Helm values with secrets redacted, the logs of the Consul Pods, the webhook\n" +
+		"configurations, the Consul custom resources, and Envoy config dumps and consul-dataplane\n" +
+		"logs from a sample of injected Pods into a tarball to attach to support tickets.\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *DebugCommand) Synopsis() string {
+	return "Collect a debug bundle of a Consul installation on Kubernetes."
+}
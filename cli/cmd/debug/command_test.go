@@ -0,0 +1,290 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package debug
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	helmRelease "helm.sh/helm/v3/pkg/release"
+	helmTime "helm.sh/helm/v3/pkg/time"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/envoy"
+	cmnFlag "github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+func TestDebug(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.kubernetes = fake.NewSimpleClientset(
+		consulPod("consul-server-0", "consul", map[string]string{"app": "consul", "component": "server"}, "consul"),
+		consulPod("consul-connect-injector-abc", "consul", map[string]string{"app": "consul", "component": "connect-injector"}, "sidecar-injector"),
+		consulPod("web-1", "default", map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"}, "web", "consul-dataplane"),
+		consulPod("web-2", "default", map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"}, "web", "consul-dataplane"),
+		&admissionv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-connect-injector", Labels: map[string]string{"app": "consul"}},
+		},
+		&admissionv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-consul"},
+		},
+	)
+	c.dynamicK8sClient = newFakeDynamicClient(t, &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "consul.hashicorp.com/v1alpha1",
+			"kind":       "ServiceDefaults",
+			"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+			"spec":       map[string]interface{}{"protocol": "http"},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Synced", "status": "False", "reason": "ConsulAgentError"}},
+			},
+		},
+	})
+	c.helmActionsRunner = &helm.MockActionRunner{
+		CheckForInstallationsFunc: func(options *helm.CheckForInstallationsOptions) (bool, string, string, error) {
+			return true, "consul", "consul", nil
+		},
+		GetStatusFunc: func(status *action.Status, name string) (*helmRelease.Release, error) {
+			return &helmRelease.Release{
+				Name: "consul", Namespace: "consul", Version: 2,
+				Info:  &helmRelease.Info{LastDeployed: helmTime.Now(), Status: helmRelease.StatusDeployed},
+				Chart: &chart.Chart{Metadata: &chart.Metadata{Version: "1.2.0"}},
+				Config: map[string]interface{}{
+					"global": map[string]interface{}{
+						"gossipEncryption": map[string]interface{}{"secretName": "gossip", "secretKey": "key"},
+						"acls":             map[string]interface{}{"bootstrapToken": map[string]interface{}{"secretName": "bootstrap"}},
+					},
+					"server": map[string]interface{}{"extraConfig": `{"encrypt": "c2VjcmV0"}`},
+				},
+			}, nil
+		},
+	}
+	var fetched []string
+	c.fetchConfig = func(ctx context.Context, pf common.PortForwarder) (*envoy.EnvoyConfig, error) {
+		portForward := pf.(*common.PortForward)
+		fetched = append(fetched, portForward.PodName)
+		require.Equal(t, defaultAdminPort, portForward.RemotePort)
+		return &envoy.EnvoyConfig{RawCfg: []byte(`{"config_dump":{}}`)}, nil
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	returnCode := c.Run([]string{"-output-file", outputFile, "-proxy-sample", "1"})
+	require.Equal(t, 0, returnCode, buf.String())
+	require.Contains(t, buf.String(), "Debug bundle written to "+outputFile)
+	require.Equal(t, []string{"web-1"}, fetched)
+
+	files := readBundle(t, outputFile)
+	require.Contains(t, files, "bundle/helm/release.yaml")
+	require.Contains(t, files["bundle/helm/release.yaml"], "chartVersion: 1.2.0")
+
+	values := files["bundle/helm/values.yaml"]
+	require.Contains(t, values, "secretName: gossip")
+	require.Contains(t, values, "extraConfig: <redacted>")
+	require.NotContains(t, values, "c2VjcmV0")
+
+	require.Equal(t, "fake logs", files["bundle/logs/consul/consul-server-0/consul.log"])
+	require.Equal(t, "fake logs", files["bundle/logs/consul/consul-connect-injector-abc/sidecar-injector.log"])
+	require.Equal(t, "fake logs", files["bundle/logs/default/web-1/consul-dataplane.log"])
+	require.NotContains(t, files, "bundle/logs/default/web-2/consul-dataplane.log")
+
+	require.Contains(t, files, "bundle/webhooks/mutating/consul-connect-injector.yaml")
+	require.NotContains(t, files, "bundle/webhooks/mutating/not-consul.yaml")
+
+	require.Contains(t, files["bundle/crds/servicedefaults.yaml"], "reason: ConsulAgentError")
+	require.Equal(t, `{"config_dump":{}}`, files["bundle/envoy/default/web-1/config_dump.json"])
+	require.NotContains(t, files, "bundle/errors.txt")
+}
+
+func TestDebug_PartialFailures(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.kubernetes = fake.NewSimpleClientset(
+		consulPod("web-1", "default", map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"}, "web", "consul-dataplane"),
+	)
+	c.dynamicK8sClient = newFakeDynamicClient(t)
+	c.helmActionsRunner = &helm.MockActionRunner{
+		GetStatusFunc: func(status *action.Status, name string) (*helmRelease.Release, error) {
+			return nil, errors.New("kaboom")
+		},
+	}
+	c.fetchConfig = func(ctx context.Context, pf common.PortForwarder) (*envoy.EnvoyConfig, error) {
+		return nil, errors.New("port forward failed")
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	returnCode := c.Run([]string{"-output-file", outputFile})
+	require.Equal(t, 0, returnCode, buf.String())
+	require.Contains(t, buf.String(), " * 2 items could not be collected, see errors.txt in the bundle")
+
+	errorsTxt := readBundle(t, outputFile)["bundle/errors.txt"]
+	require.Contains(t, errorsTxt, "kaboom")
+	require.Contains(t, errorsTxt, "error fetching Envoy config for default/web-1: port forward failed")
+}
+
+func TestDebug_InvalidFlags(t *testing.T) {
+	cases := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"negative proxy sample": {
+			args:     []string{"-proxy-sample", "-1"},
+			expected: "-proxy-sample must not be negative",
+		},
+		"zero since": {
+			args:     []string{"-since", "0s"},
+			expected: "-since must be greater than zero",
+		},
+		"non-flag arguments": {
+			args:     []string{"foo"},
+			expected: "should have no non-flag arguments",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			require.Equal(t, 1, c.Run(tc.args))
+			require.Contains(t, buf.String(), tc.expected)
+		})
+	}
+}
+
+func TestTaskCreateCommand_AutocompleteFlags(t *testing.T) {
+	t.Parallel()
+	cmd := getInitializedCommand(t, nil)
+
+	predictor := cmd.AutocompleteFlags()
+
+	// Test that we get the expected number of predictions
+	args := complete.Args{Last: "-"}
+	res := predictor.Predict(args)
+
+	// Grab the list of flags from the Flag object
+	flags := make([]string, 0)
+	cmd.set.VisitSets(func(name string, set *cmnFlag.Set) {
+		set.VisitAll(func(flag *flag.Flag) {
+			flags = append(flags, fmt.Sprintf("-%s", flag.Name))
+		})
+	})
+
+	// Verify that there is a prediction for each flag associated with the command
+	assert.Equal(t, len(flags), len(res))
+	assert.ElementsMatch(t, flags, res, "flags and predictions didn't match, make sure to add "+
+		"new flags to the command AutoCompleteFlags function")
+}
+
+func TestTaskCreateCommand_AutocompleteArgs(t *testing.T) {
+	cmd := getInitializedCommand(t, nil)
+	c := cmd.AutocompleteArgs()
+	assert.Equal(t, complete.PredictNothing, c)
+}
+
+// getInitializedCommand sets up a command struct for tests.
+func getInitializedCommand(t *testing.T, buf io.Writer) *DebugCommand {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Ctx: context.Background(),
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &DebugCommand{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
+
+func consulPod(name, namespace string, labels map[string]string, containers ...string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+	}
+	return pod
+}
+
+func newFakeDynamicClient(t *testing.T, objects ...*unstructured.Unstructured) *dynamicFake.FakeDynamicClient {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, resource := range common.ConfigEntryResources {
+		listKinds[resource.GVR()] = resource.Kind + "List"
+	}
+	for _, resource := range consulCustomResources {
+		listKinds[schema.GroupVersionResource{Group: common.ConsulCRDGroup, Version: common.ConsulCRDVersion, Resource: resource}] = resource + "List"
+	}
+
+	client := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	for _, obj := range objects {
+		resource, ok := common.ConfigEntryResourceForKind(obj.GetKind())
+		require.True(t, ok)
+		_, err := client.Resource(resource.GVR()).Namespace(obj.GetNamespace()).Create(context.Background(), obj, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	return client
+}
+
+// readBundle returns the contents of the files in the debug bundle keyed by
+// their path in the archive.
+func readBundle(t *testing.T, file string) map[string]string {
+	t.Helper()
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	files := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		contents, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[hdr.Name] = string(contents)
+	}
+	return files
+}
@@ -4,6 +4,7 @@
 package list
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -18,11 +19,16 @@ import (
 	"k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/strings/slices"
 )
 
 const (
+	Table = "table"
+	JSON  = "json"
+
 	flagNameNamespace     = "namespace"
 	flagNameAllNamespaces = "all-namespaces"
+	flagNameOutput        = "output"
 	flagNameKubeConfig    = "kubeconfig"
 	flagNameKubeContext   = "context"
 )
@@ -37,6 +43,7 @@ type ListCommand struct {
 
 	flagNamespace     string
 	flagAllNamespaces bool
+	flagOutput        string
 
 	flagKubeConfig  string
 	flagKubeContext string
@@ -63,6 +70,13 @@ func (c *ListCommand) init() {
 		Usage:   "List pods in all namespaces.",
 		Aliases: []string{"A"},
 	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameOutput,
+		Target:  &c.flagOutput,
+		Usage:   "Output the list of proxies as 'table' or 'json'.",
+		Default: Table,
+		Aliases: []string{"o"},
+	})
 
 	f = c.set.NewSet("Global Options")
 	f.StringVar(&flag.StringVar{
@@ -113,6 +127,14 @@ func (c *ListCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagOutput == JSON {
+		if err := c.outputJSON(pods); err != nil {
+			c.UI.Output("Error converting proxies to JSON:", err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		return 0
+	}
+
 	c.output(pods)
 	return 0
 }
@@ -135,6 +157,7 @@ func (c *ListCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
 		fmt.Sprintf("-%s", flagNameNamespace):     complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameAllNamespaces): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameOutput):        complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameKubeConfig):    complete.PredictFiles("*"),
 		fmt.Sprintf("-%s", flagNameKubeContext):   complete.PredictNothing,
 	}
@@ -155,6 +178,9 @@ func (c *ListCommand) validateFlags() error {
 	if errs := validation.ValidateNamespaceName(c.flagNamespace, false); c.flagNamespace != "" && len(errs) > 0 {
 		return fmt.Errorf("invalid namespace name passed for -namespace/-n: %v", strings.Join(errs, "; "))
 	}
+	if outputs := []string{Table, JSON}; !slices.Contains(outputs, c.flagOutput) {
+		return fmt.Errorf("-output must be one of %s.", strings.Join(outputs, ", "))
+	}
 
 	return nil
 }
@@ -247,40 +273,66 @@ func (c *ListCommand) output(pods []v1.Pod) {
 
 	var tbl *terminal.Table
 	if c.flagAllNamespaces {
-		tbl = terminal.NewTable("Namespace", "Name", "Type")
+		tbl = terminal.NewTable("Namespace", "Name", "Type", "Status")
 	} else {
-		tbl = terminal.NewTable("Name", "Type")
+		tbl = terminal.NewTable("Name", "Type", "Status")
 	}
 
 	for _, pod := range pods {
-		var proxyType string
-
-		// Get the type for ingress, mesh, and terminating gateways.
-		switch pod.Labels["component"] {
-		case "ingress-gateway":
-			proxyType = "Ingress Gateway"
-		case "mesh-gateway":
-			proxyType = "Mesh Gateway"
-		case "terminating-gateway":
-			proxyType = "Terminating Gateway"
-		}
-
-		// Determine if the pod is an API Gateway.
-		if pod.Labels["api-gateway.consul.hashicorp.com/managed"] == "true" {
-			proxyType = "API Gateway"
-		}
-
-		// Fallback to "Sidecar" as a default
-		if proxyType == "" {
-			proxyType = "Sidecar"
-		}
-
 		if c.flagAllNamespaces {
-			tbl.AddRow([]string{pod.Namespace, pod.Name, proxyType}, []string{})
+			tbl.AddRow([]string{pod.Namespace, pod.Name, proxyType(pod), string(pod.Status.Phase)}, []string{})
 		} else {
-			tbl.AddRow([]string{pod.Name, proxyType}, []string{})
+			tbl.AddRow([]string{pod.Name, proxyType(pod), string(pod.Status.Phase)}, []string{})
 		}
 	}
 
 	c.UI.Table(tbl)
 }
+
+// outputJSON prints the list of pods as JSON to the terminal.
+func (c *ListCommand) outputJSON(pods []v1.Pod) error {
+	type proxy struct {
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+		Type      string `json:"type"`
+		Status    string `json:"status"`
+	}
+
+	proxies := make([]proxy, 0, len(pods))
+	for _, pod := range pods {
+		proxies = append(proxies, proxy{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Type:      proxyType(pod),
+			Status:    string(pod.Status.Phase),
+		})
+	}
+
+	out, err := json.MarshalIndent(proxies, "", "\t")
+	if err != nil {
+		return err
+	}
+	c.UI.Output(string(out))
+	return nil
+}
+
+// proxyType returns the kind of proxy the pod runs based on its labels.
+func proxyType(pod v1.Pod) string {
+	// Determine if the pod is an API Gateway.
+	if pod.Labels["api-gateway.consul.hashicorp.com/managed"] == "true" {
+		return "API Gateway"
+	}
+
+	// Get the type for ingress, mesh, and terminating gateways.
+	switch pod.Labels["component"] {
+	case "ingress-gateway":
+		return "Ingress Gateway"
+	case "mesh-gateway":
+		return "Mesh Gateway"
+	case "terminating-gateway":
+		return "Terminating Gateway"
+	}
+
+	// Fallback to "Sidecar" as a default
+	return "Sidecar"
+}
@@ -6,6 +6,7 @@ package list
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
@@ -41,6 +42,10 @@ func TestFlagParsing(t *testing.T) {
 			args: []string{"-namespace", "YOLO"},
 			out:  1,
 		},
+		"Invalid output passed, -output yaml": {
+			args: []string{"-output", "yaml"},
+			out:  1,
+		},
 	}
 
 	for name, tc := range cases {
@@ -232,8 +237,8 @@ func TestFetchPods(t *testing.T) {
 func TestListCommandOutput(t *testing.T) {
 	// These regular expressions must be present in the output.
 	expected := []string{
-		"Namespace.*Name.*Type",
-		"consul.*mesh-gateway.*Mesh Gateway",
+		"Namespace.*Name.*Type.*Status",
+		"consul.*mesh-gateway.*Mesh Gateway.*Running",
 		"consul.*terminating-gateway.*Terminating Gateway",
 		"default.*ingress-gateway.*Ingress Gateway",
 		"consul.*api-gateway.*API Gateway",
@@ -263,6 +268,7 @@ func TestListCommandOutput(t *testing.T) {
 					"chart":     "consul-helm",
 				},
 			},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
 		},
 		{
 			ObjectMeta: metav1.ObjectMeta{
@@ -319,6 +325,40 @@ func TestListCommandOutput(t *testing.T) {
 	}
 }
 
+func TestListCommandOutputJSON(t *testing.T) {
+	pods := []v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "api-gateway",
+				Namespace: "default",
+				Labels: map[string]string{
+					"api-gateway.consul.hashicorp.com/managed": "true",
+				},
+			},
+			Status: v1.PodStatus{Phase: v1.PodPending},
+		},
+	}
+	client := fake.NewSimpleClientset(&v1.PodList{Items: pods})
+
+	buf := new(bytes.Buffer)
+	c := setupCommand(buf)
+	c.kubernetes = client
+
+	out := c.Run([]string{"-n", "default", "-o", "json"})
+	require.Equal(t, 0, out)
+
+	var actual []map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &actual))
+	require.Equal(t, []map[string]string{
+		{
+			"namespace": "default",
+			"name":      "api-gateway",
+			"type":      "API Gateway",
+			"status":    "Pending",
+		},
+	}, actual)
+}
+
 func TestNoPodsFound(t *testing.T) {
 	cases := map[string]struct {
 		args     []string
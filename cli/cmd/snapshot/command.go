@@ -0,0 +1,29 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshot
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/mitchellh/cli"
+)
+
+// SnapshotCommand provides a synopsis for the snapshot subcommands (e.g. save).
+type SnapshotCommand struct {
+	*common.BaseCommand
+}
+
+// Run prints out information about the subcommands.
+func (c *SnapshotCommand) Run([]string) int {
+	return cli.RunResultHelp
+}
+
+func (c *SnapshotCommand) Help() string {
+	return fmt.Sprintf("%s\n\nUsage: consul-k8s snapshot <subcommand>", c.Synopsis())
+}
+
+func (c *SnapshotCommand) Synopsis() string {
+	return "Save and restore snapshots of the Consul servers."
+}
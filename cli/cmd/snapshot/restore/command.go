@@ -0,0 +1,258 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package restore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	flagNameAutoApprove = "auto-approve"
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+)
+
+// RestoreCommand restores a snapshot from a local file or an HTTP(S) URL to the
+// Consul servers running in Kubernetes.
+type RestoreCommand struct {
+	*common.BaseCommand
+
+	helmActionsRunner helm.HelmActionsRunner
+
+	kubernetes kubernetes.Interface
+	restConfig *rest.Config
+
+	newServerClient common.ServerClientFunc
+
+	set *flag.Sets
+
+	flagAutoApprove bool
+
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *RestoreCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Command Options")
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNameAutoApprove,
+		Target:  &c.flagAutoApprove,
+		Default: false,
+		Usage:   "Skip confirming the restore, which overwrites the state of the Consul servers.",
+	})
+
+	f = c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run restores a snapshot to the Consul servers.
+func (c *RestoreCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	if c.helmActionsRunner == nil {
+		c.helmActionsRunner = &helm.ActionRunner{}
+	}
+	if c.newServerClient == nil {
+		c.newServerClient = common.NewServerClient
+	}
+
+	c.Log.ResetNamed("snapshot restore")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+	source := c.set.Args()[0]
+
+	// Copy the snapshot to a temporary file first so it can be verified
+	// before anything is sent to the servers.
+	tmp, err := os.CreateTemp("", "consul-snapshot-*.snap")
+	if err != nil {
+		c.UI.Output("Error creating temporary file: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	snap, err := common.OpenSnapshot(source)
+	if err != nil {
+		c.UI.Output("Error reading snapshot from %s: %v", common.SnapshotLocationString(source), err, terminal.WithErrorStyle())
+		return 1
+	}
+	_, err = io.Copy(tmp, snap)
+	snap.Close()
+	if err != nil {
+		c.UI.Output("Error reading snapshot from %s: %v", common.SnapshotLocationString(source), err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		c.UI.Output("Error reading snapshot: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	metadata, err := common.VerifySnapshot(tmp)
+	if err != nil {
+		c.UI.Output("Error verifying snapshot: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	c.UI.Output("Verified snapshot at index %d, term %d", metadata.Index, metadata.Term, terminal.WithSuccessStyle())
+
+	// helmCLI.New() will create a settings object which is used by the Helm Go SDK calls.
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if c.kubernetes == nil {
+		var err error
+		if c.kubernetes, c.restConfig, err = common.SetupKubeClient(settings); err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	if !c.flagAutoApprove {
+		confirmation, err := c.UI.Input(&terminal.Input{
+			Prompt: "WARNING: Restoring the snapshot overwrites the current state of the Consul servers. Proceed? (y/N)",
+			Style:  terminal.WarningStyle,
+			Secret: false,
+		})
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		if common.Abort(confirmation) {
+			c.UI.Output("Restore aborted. Use the command `consul-k8s snapshot restore -auto-approve` to restore without being prompted.")
+			return 1
+		}
+	}
+
+	client, pf, err := c.connect(settings)
+	if err != nil {
+		c.UI.Output("Error connecting to the Consul servers: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	defer pf.Close()
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		c.UI.Output("Error reading snapshot: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	if err := client.Snapshot().Restore(nil, tmp); err != nil {
+		c.UI.Output("Error restoring snapshot: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.UI.Output("Restored snapshot from %s", common.SnapshotLocationString(source), terminal.WithSuccessStyle())
+	return 0
+}
+
+// connect finds the Consul installation and returns a client for one of its
+// servers.
+func (c *RestoreCommand) connect(settings *helmCLI.EnvSettings) (*api.Client, common.PortForwarder, error) {
+	// Setup logger to stream Helm library logs.
+	var uiLogger = func(s string, args ...interface{}) {
+		logMsg := fmt.Sprintf(s, args...)
+		c.UI.Output(logMsg, terminal.WithLibraryStyle())
+	}
+
+	_, releaseName, namespace, err := c.helmActionsRunner.CheckForInstallations(&helm.CheckForInstallationsOptions{
+		Settings:    settings,
+		ReleaseName: common.DefaultReleaseName,
+		DebugLog:    uiLogger,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := helm.FetchChartValues(c.helmActionsRunner, namespace, releaseName, settings, uiLogger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't get release %s: %w", releaseName, err)
+	}
+
+	return common.ConnectToServer(c.Ctx, c.kubernetes, c.restConfig, namespace, releaseName, values, c.newServerClient)
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *RestoreCommand) validateFlags() error {
+	if len(c.set.Args()) != 1 {
+		return errors.New("a single snapshot file or URL must be specified")
+	}
+	return common.ValidateSnapshotLocation(c.set.Args()[0])
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *RestoreCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameAutoApprove): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+func (c *RestoreCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+// Help returns a description of the command and how it is used.
+func (c *RestoreCommand) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s snapshot restore [flags] <file or URL>\n\n" +
+		"Verifies the snapshot and restores it to the Consul servers through a port forward to a\n" +
+		"server Pod. The bootstrap ACL token is read from its Kubernetes secret unless\n" +
+		"CONSUL_HTTP_TOKEN is set, and the CA certificate of the installation is trusted when TLS\n" +
+		"is enabled. HTTP(S) sources are downloaded with GET. To restore from an\n" +
+		"S3 compatible object store, use a pre-signed GET URL for the object; s3:// URLs are not\n" +
+		"supported.\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *RestoreCommand) Synopsis() string {
+	return "Restore a snapshot to the Consul servers."
+}
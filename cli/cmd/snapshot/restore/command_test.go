@@ -0,0 +1,243 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package restore
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	helmRelease "helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	cmnFlag "github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+func TestRestore(t *testing.T) {
+	cases := map[string]struct {
+		snapshot    []byte
+		fromURL     bool
+		returnCode  int
+		restored    bool
+		expectedOut string
+	}{
+		"restores a snapshot from a file": {
+			snapshot:    testSnapshot(t, "raft state"),
+			returnCode:  0,
+			restored:    true,
+			expectedOut: "Restored snapshot from",
+		},
+		"restores a snapshot from a URL": {
+			snapshot:    testSnapshot(t, "raft state"),
+			fromURL:     true,
+			returnCode:  0,
+			restored:    true,
+			expectedOut: "Restored snapshot from",
+		},
+		"does not restore an invalid snapshot": {
+			snapshot:    []byte("not a snapshot"),
+			returnCode:  1,
+			restored:    false,
+			expectedOut: "Error verifying snapshot: snapshot is not a gzip archive",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(api.HTTPTokenEnvName, "")
+			var restored []byte
+			consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodPut, r.Method)
+				require.Equal(t, "/v1/snapshot", r.URL.Path)
+				require.Equal(t, "bootstrap-token", r.Header.Get("X-Consul-Token"))
+				var err error
+				restored, err = io.ReadAll(r.Body)
+				require.NoError(t, err)
+			}))
+			t.Cleanup(consul.Close)
+
+			source := filepath.Join(t.TempDir(), "backup.snap")
+			require.NoError(t, os.WriteFile(source, tc.snapshot, 0600))
+			if tc.fromURL {
+				store := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					require.Equal(t, http.MethodGet, r.Method)
+					_, _ = w.Write(tc.snapshot)
+				}))
+				t.Cleanup(store.Close)
+				source = store.URL + "/backup.snap?X-Amz-Signature=secret"
+			}
+
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			c.kubernetes = fakeKubernetes()
+			c.helmActionsRunner = mockActionRunner()
+			c.newServerClient = func(_ context.Context, _ kubernetes.Interface, _ *rest.Config, pod *corev1.Pod, token string, caCert []byte) (*api.Client, common.PortForwarder, error) {
+				require.Empty(t, caCert)
+				require.Equal(t, "consul-server-0", pod.Name)
+				client, err := api.NewClient(&api.Config{Address: consul.URL, Token: token})
+				return client, &noopPortForward{}, err
+			}
+
+			returnCode := c.Run([]string{"-auto-approve", source})
+			require.Equal(t, tc.returnCode, returnCode, buf.String())
+			require.Contains(t, buf.String(), tc.expectedOut)
+			require.NotContains(t, buf.String(), "X-Amz-Signature")
+			if tc.restored {
+				require.Contains(t, buf.String(), "Verified snapshot at index 100, term 2")
+				require.Equal(t, tc.snapshot, restored)
+			} else {
+				require.Nil(t, restored)
+			}
+		})
+	}
+}
+
+func TestRestore_InvalidArgs(t *testing.T) {
+	for _, args := range [][]string{{}, {"a.snap", "b.snap"}} {
+		buf := new(bytes.Buffer)
+		c := getInitializedCommand(t, buf)
+		require.Equal(t, 1, c.Run(args))
+		require.Contains(t, buf.String(), "a single snapshot file or URL must be specified")
+	}
+
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	require.Equal(t, 1, c.Run([]string{"s3://bucket/backup.snap"}))
+	require.Contains(t, buf.String(), `unsupported snapshot URL scheme "s3"`)
+}
+
+func TestTaskCreateCommand_AutocompleteFlags(t *testing.T) {
+	t.Parallel()
+	cmd := getInitializedCommand(t, nil)
+
+	predictor := cmd.AutocompleteFlags()
+
+	// Test that we get the expected number of predictions
+	args := complete.Args{Last: "-"}
+	res := predictor.Predict(args)
+
+	// Grab the list of flags from the Flag object
+	flags := make([]string, 0)
+	cmd.set.VisitSets(func(name string, set *cmnFlag.Set) {
+		set.VisitAll(func(flag *flag.Flag) {
+			flags = append(flags, fmt.Sprintf("-%s", flag.Name))
+		})
+	})
+
+	// Verify that there is a prediction for each flag associated with the command
+	assert.Equal(t, len(flags), len(res))
+	assert.ElementsMatch(t, flags, res, "flags and predictions didn't match, make sure to add "+
+		"new flags to the command AutoCompleteFlags function")
+}
+
+// getInitializedCommand sets up a command struct for tests.
+func getInitializedCommand(t *testing.T, buf io.Writer) *RestoreCommand {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Ctx: context.Background(),
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &RestoreCommand{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
+
+type noopPortForward struct{}
+
+func (*noopPortForward) Open(context.Context) (string, error) { return "", nil }
+func (*noopPortForward) Close()                               {}
+
+func mockActionRunner() *helm.MockActionRunner {
+	return &helm.MockActionRunner{
+		CheckForInstallationsFunc: func(options *helm.CheckForInstallationsOptions) (bool, string, string, error) {
+			return true, "consul", "consul", nil
+		},
+		GetStatusFunc: func(status *action.Status, name string) (*helmRelease.Release, error) {
+			return &helmRelease.Release{
+				Name: "consul", Namespace: "consul",
+				Config: map[string]interface{}{"global": map[string]interface{}{"name": "consul"}},
+			}, nil
+		},
+	}
+}
+
+func fakeKubernetes() kubernetes.Interface {
+	return fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "consul-server-0",
+				Namespace: "consul",
+				Labels:    map[string]string{"app": "consul", "component": "server"},
+			},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-bootstrap-acl-token", Namespace: "consul"},
+			Data:       map[string][]byte{"token": []byte("bootstrap-token")},
+		},
+	)
+}
+
+// testSnapshot builds a Consul snapshot archive with valid checksums.
+func testSnapshot(t *testing.T, state string) []byte {
+	t.Helper()
+	meta := []byte(`{"Version":1,"ID":"2-100-1690000000000","Index":100,"Term":2}`)
+	sums := fmt.Sprintf("%x  meta.json\n%x  state.bin\n", sha256.Sum256(meta), sha256.Sum256([]byte(state)))
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range []struct {
+		name     string
+		contents []byte
+	}{{"meta.json", meta}, {"state.bin", []byte(state)}, {"SHA256SUMS", []byte(sums)}} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0600, Size: int64(len(f.contents))}))
+		_, err := tw.Write(f.contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,229 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package save
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/posener/complete"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+const (
+	flagNameKubeConfig  = "kubeconfig"
+	flagNameKubeContext = "context"
+)
+
+// SaveCommand saves a snapshot of the Consul servers running in Kubernetes to
+// a local file or an HTTP(S) URL.
+type SaveCommand struct {
+	*common.BaseCommand
+
+	helmActionsRunner helm.HelmActionsRunner
+
+	kubernetes kubernetes.Interface
+	restConfig *rest.Config
+
+	newServerClient common.ServerClientFunc
+
+	set *flag.Sets
+
+	flagKubeConfig  string
+	flagKubeContext string
+
+	once sync.Once
+	help string
+}
+
+func (c *SaveCommand) init() {
+	c.set = flag.NewSets()
+
+	f := c.set.NewSet("Global Options")
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeConfig,
+		Aliases: []string{"c"},
+		Target:  &c.flagKubeConfig,
+		Default: "",
+		Usage:   "Path to kubeconfig file.",
+	})
+	f.StringVar(&flag.StringVar{
+		Name:    flagNameKubeContext,
+		Target:  &c.flagKubeContext,
+		Default: "",
+		Usage:   "Kubernetes context to use.",
+	})
+
+	c.help = c.set.Help()
+}
+
+// Run saves a snapshot of the Consul servers.
+func (c *SaveCommand) Run(args []string) int {
+	c.once.Do(c.init)
+	if c.helmActionsRunner == nil {
+		c.helmActionsRunner = &helm.ActionRunner{}
+	}
+	if c.newServerClient == nil {
+		c.newServerClient = common.NewServerClient
+	}
+
+	c.Log.ResetNamed("snapshot save")
+	defer common.CloseWithError(c.BaseCommand)
+
+	if err := c.set.Parse(args); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Output(err.Error())
+		return 1
+	}
+	destination := c.set.Args()[0]
+
+	// helmCLI.New() will create a settings object which is used by the Helm Go SDK calls.
+	settings := helmCLI.New()
+	if c.flagKubeConfig != "" {
+		settings.KubeConfig = c.flagKubeConfig
+	}
+	if c.flagKubeContext != "" {
+		settings.KubeContext = c.flagKubeContext
+	}
+
+	if c.kubernetes == nil {
+		var err error
+		if c.kubernetes, c.restConfig, err = common.SetupKubeClient(settings); err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	client, pf, err := c.connect(settings)
+	if err != nil {
+		c.UI.Output("Error connecting to the Consul servers: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	defer pf.Close()
+
+	// Stream the snapshot to a temporary file first so it can be verified
+	// before it is written to the destination.
+	tmp, err := os.CreateTemp("", "consul-snapshot-*.snap")
+	if err != nil {
+		c.UI.Output("Error creating temporary file: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	snap, _, err := client.Snapshot().Save(nil)
+	if err != nil {
+		c.UI.Output("Error saving snapshot: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	size, err := io.Copy(tmp, snap)
+	snap.Close()
+	if err != nil {
+		c.UI.Output("Error saving snapshot: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		c.UI.Output("Error reading snapshot: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	metadata, err := common.VerifySnapshot(tmp)
+	if err != nil {
+		c.UI.Output("Error verifying snapshot: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		c.UI.Output("Error reading snapshot: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+	if err := common.WriteSnapshot(destination, tmp, size); err != nil {
+		c.UI.Output("Error writing snapshot to %s: %v", common.SnapshotLocationString(destination), err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.UI.Output("Saved and verified snapshot to %s at index %d, term %d", common.SnapshotLocationString(destination),
+		metadata.Index, metadata.Term, terminal.WithSuccessStyle())
+	return 0
+}
+
+// connect finds the Consul installation and returns a client for one of its
+// servers.
+func (c *SaveCommand) connect(settings *helmCLI.EnvSettings) (*api.Client, common.PortForwarder, error) {
+	// Setup logger to stream Helm library logs.
+	var uiLogger = func(s string, args ...interface{}) {
+		logMsg := fmt.Sprintf(s, args...)
+		c.UI.Output(logMsg, terminal.WithLibraryStyle())
+	}
+
+	_, releaseName, namespace, err := c.helmActionsRunner.CheckForInstallations(&helm.CheckForInstallationsOptions{
+		Settings:    settings,
+		ReleaseName: common.DefaultReleaseName,
+		DebugLog:    uiLogger,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := helm.FetchChartValues(c.helmActionsRunner, namespace, releaseName, settings, uiLogger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't get release %s: %w", releaseName, err)
+	}
+
+	return common.ConnectToServer(c.Ctx, c.kubernetes, c.restConfig, namespace, releaseName, values, c.newServerClient)
+}
+
+// validateFlags checks the command line flags and values for errors.
+func (c *SaveCommand) validateFlags() error {
+	if len(c.set.Args()) != 1 {
+		return errors.New("a single destination file or URL must be specified")
+	}
+	return common.ValidateSnapshotLocation(c.set.Args()[0])
+}
+
+// AutocompleteFlags returns a mapping of supported flags and autocomplete
+// options for this command. The map key for the Flags map should be the
+// complete flag such as "-foo" or "--foo".
+func (c *SaveCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+	}
+}
+
+// AutocompleteArgs returns the argument predictor for this command.
+func (c *SaveCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+// Help returns a description of the command and how it is used.
+func (c *SaveCommand) Help() string {
+	c.once.Do(c.init)
+	return c.Synopsis() + "\n\nUsage: consul-k8s snapshot save [flags] <file or URL>\n\n" +
+		"Saves a snapshot of the Consul servers through a port forward to a server Pod. The\n" +
+		"bootstrap ACL token is read from its Kubernetes secret unless CONSUL_HTTP_TOKEN is set, and\n" +
+		"the CA certificate of the installation is trusted when TLS is enabled.\n" +
+		"HTTP(S) destinations are uploaded with PUT. To save to an S3 compatible object store,\n" +
+		"use a pre-signed PUT URL for the object; s3:// URLs are not supported.\n\n" + c.help
+}
+
+// Synopsis returns a one-line command summary.
+func (c *SaveCommand) Synopsis() string {
+	return "Save a snapshot of the Consul servers."
+}
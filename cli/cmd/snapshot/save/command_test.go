@@ -0,0 +1,221 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package save
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/posener/complete"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	helmRelease "helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	cmnFlag "github.com/hashicorp/consul-k8s/cli/common/flag"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+func TestSave(t *testing.T) {
+	cases := map[string]struct {
+		snapshot    []byte
+		returnCode  int
+		expectedOut string
+	}{
+		"saves and verifies the snapshot": {
+			snapshot:    testSnapshot(t, "raft state"),
+			returnCode:  0,
+			expectedOut: "Saved and verified snapshot to %s at index 100, term 2",
+		},
+		"fails verification": {
+			snapshot:    []byte("not a snapshot"),
+			returnCode:  1,
+			expectedOut: "Error verifying snapshot: snapshot is not a gzip archive",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(api.HTTPTokenEnvName, "")
+			consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodGet, r.Method)
+				require.Equal(t, "/v1/snapshot", r.URL.Path)
+				require.Equal(t, "bootstrap-token", r.Header.Get("X-Consul-Token"))
+				_, _ = w.Write(tc.snapshot)
+			}))
+			t.Cleanup(consul.Close)
+
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			c.kubernetes = fakeKubernetes()
+			c.helmActionsRunner = mockActionRunner()
+			c.newServerClient = func(_ context.Context, _ kubernetes.Interface, _ *rest.Config, pod *corev1.Pod, token string, caCert []byte) (*api.Client, common.PortForwarder, error) {
+				require.Empty(t, caCert)
+				require.Equal(t, "consul-server-0", pod.Name)
+				client, err := api.NewClient(&api.Config{Address: consul.URL, Token: token})
+				return client, &noopPortForward{}, err
+			}
+
+			destination := filepath.Join(t.TempDir(), "backup.snap")
+			returnCode := c.Run([]string{destination})
+			require.Equal(t, tc.returnCode, returnCode, buf.String())
+			if tc.returnCode != 0 {
+				require.Contains(t, buf.String(), tc.expectedOut)
+				require.NoFileExists(t, destination)
+				return
+			}
+
+			require.Contains(t, buf.String(), fmt.Sprintf(tc.expectedOut, destination))
+			saved, err := os.ReadFile(destination)
+			require.NoError(t, err)
+			require.Equal(t, tc.snapshot, saved)
+		})
+	}
+}
+
+func TestSave_InvalidArgs(t *testing.T) {
+	for _, args := range [][]string{{}, {"a.snap", "b.snap"}} {
+		buf := new(bytes.Buffer)
+		c := getInitializedCommand(t, buf)
+		require.Equal(t, 1, c.Run(args))
+		require.Contains(t, buf.String(), "a single destination file or URL must be specified")
+	}
+
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	require.Equal(t, 1, c.Run([]string{"s3://bucket/backup.snap"}))
+	require.Contains(t, buf.String(), `unsupported snapshot URL scheme "s3"`)
+}
+
+func TestTaskCreateCommand_AutocompleteFlags(t *testing.T) {
+	t.Parallel()
+	cmd := getInitializedCommand(t, nil)
+
+	predictor := cmd.AutocompleteFlags()
+
+	// Test that we get the expected number of predictions
+	args := complete.Args{Last: "-"}
+	res := predictor.Predict(args)
+
+	// Grab the list of flags from the Flag object
+	flags := make([]string, 0)
+	cmd.set.VisitSets(func(name string, set *cmnFlag.Set) {
+		set.VisitAll(func(flag *flag.Flag) {
+			flags = append(flags, fmt.Sprintf("-%s", flag.Name))
+		})
+	})
+
+	// Verify that there is a prediction for each flag associated with the command
+	assert.Equal(t, len(flags), len(res))
+	assert.ElementsMatch(t, flags, res, "flags and predictions didn't match, make sure to add "+
+		"new flags to the command AutoCompleteFlags function")
+}
+
+// getInitializedCommand sets up a command struct for tests.
+func getInitializedCommand(t *testing.T, buf io.Writer) *SaveCommand {
+	t.Helper()
+	log := hclog.New(&hclog.LoggerOptions{
+		Name:   "cli",
+		Level:  hclog.Info,
+		Output: os.Stdout,
+	})
+	var ui terminal.UI
+	if buf != nil {
+		ui = terminal.NewUI(context.Background(), buf)
+	} else {
+		ui = terminal.NewBasicUI(context.Background())
+	}
+	baseCommand := &common.BaseCommand{
+		Ctx: context.Background(),
+		Log: log,
+		UI:  ui,
+	}
+
+	c := &SaveCommand{
+		BaseCommand: baseCommand,
+	}
+	c.init()
+	return c
+}
+
+type noopPortForward struct{}
+
+func (*noopPortForward) Open(context.Context) (string, error) { return "", nil }
+func (*noopPortForward) Close()                               {}
+
+func mockActionRunner() *helm.MockActionRunner {
+	return &helm.MockActionRunner{
+		CheckForInstallationsFunc: func(options *helm.CheckForInstallationsOptions) (bool, string, string, error) {
+			return true, "consul", "consul", nil
+		},
+		GetStatusFunc: func(status *action.Status, name string) (*helmRelease.Release, error) {
+			return &helmRelease.Release{
+				Name: "consul", Namespace: "consul",
+				Config: map[string]interface{}{"global": map[string]interface{}{"name": "consul"}},
+			}, nil
+		},
+	}
+}
+
+func fakeKubernetes() kubernetes.Interface {
+	return fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "consul-server-0",
+				Namespace: "consul",
+				Labels:    map[string]string{"app": "consul", "component": "server"},
+			},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-bootstrap-acl-token", Namespace: "consul"},
+			Data:       map[string][]byte{"token": []byte("bootstrap-token")},
+		},
+	)
+}
+
+// testSnapshot builds a Consul snapshot archive with valid checksums.
+func testSnapshot(t *testing.T, state string) []byte {
+	t.Helper()
+	meta := []byte(`{"Version":1,"ID":"2-100-1690000000000","Index":100,"Term":2}`)
+	sums := fmt.Sprintf("%x  meta.json\n%x  state.bin\n", sha256.Sum256(meta), sha256.Sum256([]byte(state)))
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range []struct {
+		name     string
+		contents []byte
+	}{{"meta.json", meta}, {"state.bin", []byte(state)}, {"SHA256SUMS", []byte(sums)}} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0600, Size: int64(len(f.contents))}))
+		_, err := tw.Write(f.contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
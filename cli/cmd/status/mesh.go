@@ -0,0 +1,247 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package status
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/consul/api"
+	"helm.sh/helm/v3/pkg/action"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+)
+
+// peeringResources maps the peering custom resources to the peering role they
+// represent.
+var peeringResources = []struct {
+	resource string
+	role     string
+}{
+	{resource: "peeringacceptors", role: "Acceptor"},
+	{resource: "peeringdialers", role: "Dialer"},
+}
+
+// checkRaft prints the raft and autopilot status of the Consul servers by
+// port forwarding to a ready server Pod. It does not report anything if no
+// ready server Pods are running in the Kubernetes cluster.
+func (c *Command) checkRaft(settings *helmCLI.EnvSettings, uiLogger action.DebugLog, releaseName, namespace string) error {
+	server, err := common.ReadyServerPod(c.Ctx, c.kubernetes, namespace)
+	if errors.Is(err, common.ErrNoReadyServer) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	values, err := helm.FetchChartValues(c.helmActionsRunner, namespace, releaseName, settings, uiLogger)
+	if err != nil {
+		return err
+	}
+	caCert, err := common.CACert(c.Ctx, c.kubernetes, namespace, releaseName, values)
+	if err != nil {
+		return err
+	}
+
+	client, pf, err := common.NewServerClient(c.Ctx, c.kubernetes, c.restConfig, server, "", caCert)
+	if err != nil {
+		return err
+	}
+	defer pf.Close()
+
+	return c.printRaftStatus(client)
+}
+
+// printRaftStatus prints raft leadership and the autopilot health of each
+// Consul server.
+func (c *Command) printRaftStatus(client *api.Client) error {
+	health, err := client.Operator().AutopilotServerHealth(nil)
+	if err != nil {
+		return err
+	}
+
+	c.UI.Output("Consul Server Raft Status:", terminal.WithHeaderStyle())
+
+	leader := ""
+	tbl := terminal.NewTable("Name", "Address", "Leader", "Voter", "Healthy", "Last Contact", "Serf Status")
+	for _, server := range health.Servers {
+		if server.Leader {
+			leader = server.Name
+		}
+
+		lastContact := "-"
+		if server.LastContact != nil && !server.Leader {
+			lastContact = server.LastContact.String()
+		}
+
+		var colors []string
+		if !server.Healthy {
+			colors = []string{terminal.Red, terminal.Red, terminal.Red, terminal.Red, terminal.Red, terminal.Red, terminal.Red}
+		}
+		tbl.AddRow([]string{server.Name, server.Address, strconv.FormatBool(server.Leader), strconv.FormatBool(server.Voter),
+			strconv.FormatBool(server.Healthy), lastContact, server.SerfStatus}, colors)
+	}
+	c.UI.Table(tbl)
+
+	if leader == "" {
+		c.UI.Output("No raft leader elected", terminal.WithErrorStyle())
+	} else {
+		c.UI.Output("Raft leader: %s", leader, terminal.WithSuccessStyle())
+	}
+
+	if health.Healthy {
+		c.UI.Output("Autopilot healthy, failure tolerance: %d", health.FailureTolerance, terminal.WithSuccessStyle())
+	} else {
+		c.UI.Output("Autopilot unhealthy, failure tolerance: %d", health.FailureTolerance, terminal.WithErrorStyle())
+	}
+
+	return nil
+}
+
+// checkInjectedWorkloads prints how many of the Pods injected with a Consul
+// dataplane sidecar are running.
+func (c *Command) checkInjectedWorkloads() error {
+	pods, err := c.kubernetes.CoreV1().Pods(metav1.NamespaceAll).List(c.Ctx, metav1.ListOptions{
+		LabelSelector: "consul.hashicorp.com/connect-inject-status=injected",
+	})
+	if err != nil {
+		return err
+	}
+
+	running := 0
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			running++
+		}
+	}
+
+	if running < len(pods.Items) {
+		c.UI.Output("Injected workloads running %d/%d", running, len(pods.Items), terminal.WithErrorStyle())
+	} else {
+		c.UI.Output("Injected workloads running %d/%d", running, len(pods.Items))
+	}
+
+	return nil
+}
+
+// checkConfigEntries prints how many config entry custom resources are
+// synced to Consul and lists the ones which failed to sync.
+func (c *Command) checkConfigEntries() error {
+	var total int
+	var failing []unstructured.Unstructured
+	for _, resource := range common.ConfigEntryResources {
+		crs, err := c.listCustomResources(resource.Resource)
+		if err != nil {
+			return err
+		}
+		for _, cr := range crs {
+			total++
+			if status, _, _ := syncedCondition(cr); status == string(metav1.ConditionFalse) {
+				failing = append(failing, cr)
+			}
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	if len(failing) == 0 {
+		c.UI.Output("Config entries synced %d/%d", total, total)
+		return nil
+	}
+
+	c.UI.Output("Config entries synced %d/%d", total-len(failing), total, terminal.WithErrorStyle())
+	c.UI.Output("Config Entries Failing To Sync:", terminal.WithHeaderStyle())
+	tbl := terminal.NewTable("Kind", "Name", "Namespace", "Reason", "Message")
+	for _, cr := range failing {
+		_, reason, message := syncedCondition(cr)
+		tbl.AddRow([]string{cr.GetKind(), cr.GetName(), cr.GetNamespace(), reason, message}, []string{})
+	}
+	c.UI.Table(tbl)
+
+	return nil
+}
+
+// checkPeerings prints the sync state of the peering acceptor and dialer
+// custom resources.
+func (c *Command) checkPeerings() error {
+	tbl := terminal.NewTable("Name", "Namespace", "Role", "Synced", "Last Synced")
+	found := false
+	for _, peering := range peeringResources {
+		crs, err := c.listCustomResources(peering.resource)
+		if err != nil {
+			return err
+		}
+		for _, cr := range crs {
+			found = true
+
+			status, _, _ := syncedCondition(cr)
+			if status == "" {
+				status = string(metav1.ConditionUnknown)
+			}
+			lastSynced, _, _ := unstructured.NestedString(cr.Object, "status", "lastSyncedTime")
+			if lastSynced == "" {
+				lastSynced = "-"
+			}
+
+			var colors []string
+			if status != string(metav1.ConditionTrue) {
+				colors = []string{terminal.Red, terminal.Red, terminal.Red, terminal.Red, terminal.Red}
+			}
+			tbl.AddRow([]string{cr.GetName(), cr.GetNamespace(), peering.role, status, lastSynced}, colors)
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	c.UI.Output("Peerings:", terminal.WithHeaderStyle())
+	c.UI.Table(tbl)
+
+	return nil
+}
+
+// listCustomResources lists the Consul custom resources of the given resource
+// type across all namespaces. It returns no resources if the custom resource
+// definition is not installed.
+func (c *Command) listCustomResources(resource string) ([]unstructured.Unstructured, error) {
+	gvr := schema.GroupVersionResource{Group: common.ConsulCRDGroup, Version: common.ConsulCRDVersion, Resource: resource}
+	list, err := c.dynamicK8sClient.Resource(gvr).Namespace(metav1.NamespaceAll).List(c.Ctx, metav1.ListOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", resource, err)
+	}
+	return list.Items, nil
+}
+
+// syncedCondition returns the status, reason and message of the Synced
+// condition of a Consul custom resource. The status is empty if the resource
+// has not been reconciled yet.
+func syncedCondition(cr unstructured.Unstructured) (string, string, string) {
+	conditions, _, _ := unstructured.NestedSlice(cr.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Synced" {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		return status, reason, message
+	}
+	return "", "", ""
+}
@@ -0,0 +1,233 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package status
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicFake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckInjectedWorkloads(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.kubernetes = fake.NewSimpleClientset(
+		injectedPod("web", "default", corev1.PodRunning),
+		injectedPod("api", "other", corev1.PodRunning),
+		injectedPod("db", "default", corev1.PodPending),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "not-injected", Namespace: "default"}},
+	)
+
+	require.NoError(t, c.checkInjectedWorkloads())
+	require.Contains(t, buf.String(), "Injected workloads running 2/3")
+}
+
+func TestCheckConfigEntries(t *testing.T) {
+	cases := map[string]struct {
+		resources []*unstructured.Unstructured
+		expected  []string
+		absent    []string
+	}{
+		"no config entries": {
+			absent: []string{"Config entries synced"},
+		},
+		"all config entries synced": {
+			resources: []*unstructured.Unstructured{
+				consulResource("servicedefaults", "ServiceDefaults", "web", "default", "True", "", ""),
+				consulResource("meshes", "Mesh", "mesh", "consul", "True", "", ""),
+			},
+			expected: []string{"Config entries synced 2/2"},
+			absent:   []string{"Config Entries Failing To Sync"},
+		},
+		"config entry failing to sync": {
+			resources: []*unstructured.Unstructured{
+				consulResource("servicedefaults", "ServiceDefaults", "web", "default", "True", "", ""),
+				consulResource("serviceintentions", "ServiceIntentions", "api", "default", "False", "ConsulAgentError", "kaboom"),
+			},
+			expected: []string{
+				"Config entries synced 1/2",
+				"Config Entries Failing To Sync:",
+				"ServiceIntentions",
+				"ConsulAgentError",
+				"kaboom",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			c.dynamicK8sClient = newFakeDynamicClient(tc.resources...)
+
+			require.NoError(t, c.checkConfigEntries())
+			actual := buf.String()
+			for _, msg := range tc.expected {
+				require.Contains(t, actual, msg)
+			}
+			for _, msg := range tc.absent {
+				require.NotContains(t, actual, msg)
+			}
+		})
+	}
+}
+
+func TestCheckPeerings(t *testing.T) {
+	buf := new(bytes.Buffer)
+	c := getInitializedCommand(t, buf)
+	c.dynamicK8sClient = newFakeDynamicClient()
+
+	require.NoError(t, c.checkPeerings())
+	require.NotContains(t, buf.String(), "Peerings:")
+
+	acceptor := consulResource("peeringacceptors", "PeeringAcceptor", "cluster-02", "default", "True", "", "")
+	require.NoError(t, unstructured.SetNestedField(acceptor.Object, "2023-06-01T00:00:00Z", "status", "lastSyncedTime"))
+	dialer := consulResource("peeringdialers", "PeeringDialer", "cluster-03", "default", "False", "ErrorCreatingPeering", "kaboom")
+	c.dynamicK8sClient = newFakeDynamicClient(acceptor, dialer)
+
+	require.NoError(t, c.checkPeerings())
+	actual := buf.String()
+	require.Contains(t, actual, "Peerings:")
+	require.Regexp(t, `cluster-02\s+default\s+Acceptor\s+True\s+2023-06-01T00:00:00Z`, actual)
+	// Peerings which are not synced are colored, so only check the fields.
+	require.Contains(t, actual, "cluster-03")
+	require.Contains(t, actual, "Dialer")
+	require.Contains(t, actual, "False")
+}
+
+func TestPrintRaftStatus(t *testing.T) {
+	cases := map[string]struct {
+		reply    api.OperatorHealthReply
+		expected []string
+	}{
+		"healthy cluster": {
+			reply: api.OperatorHealthReply{
+				Healthy:          true,
+				FailureTolerance: 1,
+				Servers: []api.ServerHealth{
+					{Name: "consul-server-0", Address: "10.0.0.1:8300", Leader: true, Voter: true, Healthy: true, SerfStatus: "alive"},
+					{Name: "consul-server-1", Address: "10.0.0.2:8300", Voter: true, Healthy: true, SerfStatus: "alive"},
+					{Name: "consul-server-2", Address: "10.0.0.3:8300", Voter: true, Healthy: true, SerfStatus: "alive"},
+				},
+			},
+			expected: []string{
+				"Consul Server Raft Status:",
+				"consul-server-2",
+				"Raft leader: consul-server-0",
+				"Autopilot healthy, failure tolerance: 1",
+			},
+		},
+		"cluster without a leader": {
+			reply: api.OperatorHealthReply{
+				Servers: []api.ServerHealth{
+					{Name: "consul-server-0", Address: "10.0.0.1:8300", Voter: true, SerfStatus: "failed"},
+				},
+			},
+			expected: []string{
+				"No raft leader elected",
+				"Autopilot unhealthy, failure tolerance: 0",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/operator/autopilot/health", r.URL.Path)
+				if !tc.reply.Healthy {
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+				require.NoError(t, json.NewEncoder(w).Encode(tc.reply))
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := api.NewClient(&api.Config{Address: server.URL})
+			require.NoError(t, err)
+
+			buf := new(bytes.Buffer)
+			c := getInitializedCommand(t, buf)
+			require.NoError(t, c.printRaftStatus(client))
+
+			actual := buf.String()
+			for _, msg := range tc.expected {
+				require.Contains(t, actual, msg)
+			}
+		})
+	}
+}
+
+// newFakeDynamicClient returns a fake dynamic client which knows how to list
+// all the Consul custom resources that the status command inspects.
+func newFakeDynamicClient(objects ...*unstructured.Unstructured) dynamic.Interface {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, resource := range common.ConfigEntryResources {
+		listKinds[resource.GVR()] = resource.Kind + "List"
+	}
+	for _, peering := range peeringResources {
+		listKinds[schema.GroupVersionResource{Group: common.ConsulCRDGroup, Version: common.ConsulCRDVersion, Resource: peering.resource}] = peering.resource + "List"
+	}
+
+	client := dynamicFake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	for _, obj := range objects {
+		gvr := schema.GroupVersionResource{Group: common.ConsulCRDGroup, Version: common.ConsulCRDVersion, Resource: obj.GetAnnotations()["resource"]}
+		_, err := client.Resource(gvr).Namespace(obj.GetNamespace()).Create(context.Background(), obj, metav1.CreateOptions{})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return client
+}
+
+// consulResource creates a Consul custom resource with a Synced condition.
+// The resource name is stored in an annotation so newFakeDynamicClient can
+// find the GroupVersionResource to create it under.
+func consulResource(resource, kind, name, namespace, synced, reason, message string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": common.ConsulCRDGroup + "/" + common.ConsulCRDVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":        name,
+				"namespace":   namespace,
+				"annotations": map[string]interface{}{"resource": resource},
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":    "Synced",
+						"status":  synced,
+						"reason":  reason,
+						"message": message,
+					},
+				},
+			},
+		},
+	}
+}
+
+func injectedPod(name, namespace string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
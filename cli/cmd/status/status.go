@@ -19,7 +19,9 @@ import (
 	"github.com/hashicorp/consul-k8s/cli/helm"
 	"helm.sh/helm/v3/pkg/action"
 	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/yaml"
 )
 
@@ -33,7 +35,9 @@ type Command struct {
 
 	helmActionsRunner helm.HelmActionsRunner
 
-	kubernetes kubernetes.Interface
+	kubernetes       kubernetes.Interface
+	dynamicK8sClient dynamic.Interface
+	restConfig       *rest.Config
 
 	set *flag.Sets
 
@@ -127,6 +131,27 @@ func (c *Command) Run(args []string) int {
 		return 1
 	}
 
+	// Raft and autopilot state come from the Consul API, which may require
+	// an ACL token, so failing to read it is reported but not fatal.
+	if err := c.checkRaft(settings, uiLogger, releaseName, namespace); err != nil {
+		c.UI.Output("Unable to check Consul server raft status: %v", err, terminal.WithWarningStyle())
+	}
+
+	if err := c.checkInjectedWorkloads(); err != nil {
+		c.UI.Output("Unable to check Kubernetes cluster for injected workloads: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	if err := c.checkConfigEntries(); err != nil {
+		c.UI.Output("Unable to check config entry custom resources: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+
+	if err := c.checkPeerings(); err != nil {
+		c.UI.Output("Unable to check peering custom resources: %v", err, terminal.WithErrorStyle())
+		return 1
+	}
+
 	return 0
 }
 
@@ -241,13 +266,27 @@ func (c *Command) checkConsulServers(namespace string) error {
 // settings.RESTClientGetter for its calls as well, so this will use a consistent method to
 // target the right cluster for both Helm SDK and non Helm SDK calls.
 func (c *Command) setupKubeClient(settings *helmCLI.EnvSettings) error {
-	if c.kubernetes == nil {
+	if c.restConfig == nil && (c.kubernetes == nil || c.dynamicK8sClient == nil) {
 		restConfig, err := settings.RESTClientGetter().ToRESTConfig()
 		if err != nil {
 			c.UI.Output("Error retrieving Kubernetes authentication: %v", err, terminal.WithErrorStyle())
 			return err
 		}
-		c.kubernetes, err = kubernetes.NewForConfig(restConfig)
+		c.restConfig = restConfig
+	}
+
+	if c.kubernetes == nil {
+		var err error
+		c.kubernetes, err = kubernetes.NewForConfig(c.restConfig)
+		if err != nil {
+			c.UI.Output("Error initializing Kubernetes client: %v", err, terminal.WithErrorStyle())
+			return err
+		}
+	}
+
+	if c.dynamicK8sClient == nil {
+		var err error
+		c.dynamicK8sClient, err = dynamic.NewForConfig(c.restConfig)
 		if err != nil {
 			c.UI.Output("Error initializing Kubernetes client: %v", err, terminal.WithErrorStyle())
 			return err
@@ -260,7 +299,9 @@ func (c *Command) setupKubeClient(settings *helmCLI.EnvSettings) error {
 // Help returns a description of the command and how it is used.
 func (c *Command) Help() string {
 	c.once.Do(c.init)
-	return c.Synopsis() + "\n\nUsage: consul-k8s status [flags]\n\n" + c.help
+	return c.Synopsis() + "\n\nUsage: consul-k8s status [flags]\n\n" +
+		"The raft and autopilot status is read from the Consul servers through a port forward.\n" +
+		"Set CONSUL_HTTP_TOKEN to a token with operator:read if ACLs are enabled.\n\n" + c.help
 }
 
 // Synopsis returns a one-line command summary.
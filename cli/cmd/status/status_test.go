@@ -195,6 +195,7 @@ func TestStatus(t *testing.T) {
 			buf := new(bytes.Buffer)
 			c := getInitializedCommand(t, buf)
 			c.kubernetes = fake.NewSimpleClientset()
+			c.dynamicK8sClient = newFakeDynamicClient()
 			c.helmActionsRunner = tc.helmActionsRunner
 			if tc.preProcessingFunc != nil {
 				err := tc.preProcessingFunc(c.kubernetes)
@@ -103,10 +103,10 @@ func (c *ProxyCommand) init() {
 	c.help = c.set.Help()
 }
 
-// Run executes the list command.
+// Run executes the troubleshoot command.
 func (c *ProxyCommand) Run(args []string) int {
 	c.once.Do(c.init)
-	c.Log.ResetNamed("list")
+	c.Log.ResetNamed("troubleshoot")
 	defer common.CloseWithError(c.BaseCommand)
 
 	// Parse the command line flags.
@@ -136,11 +136,11 @@ func (c *ProxyCommand) Run(args []string) int {
 	return 0
 }
 
-// validateFlags ensures that the flags passed in by the can be used.
+// validateFlags ensures that the flags passed in by the user can be used.
 func (c *ProxyCommand) validateFlags() error {
 
 	if (c.flagUpstreamEnvoyID == "" && c.flagUpstreamIP == "") || (c.flagUpstreamEnvoyID != "" && c.flagUpstreamIP != "") {
-		return fmt.Errorf("-upstream-envoy-id OR -upstream-ip is required.\n Please run `consul troubleshoot upstreams` to find the corresponding upstream.")
+		return fmt.Errorf("-upstream-envoy-id OR -upstream-ip is required.\n Please run `consul-k8s troubleshoot upstreams` to find the corresponding upstream.")
 	}
 
 	if c.flagPod == "" {
@@ -186,6 +186,10 @@ func (c *ProxyCommand) initKubernetes() (err error) {
 }
 
 func (c *ProxyCommand) Troubleshoot() error {
+	if err := common.ValidateProxyPod(c.Ctx, c.kubernetes, c.flagNamespace, c.flagPod); err != nil {
+		return err
+	}
+
 	pf := common.PortForward{
 		Namespace:  c.flagNamespace,
 		PodName:    c.flagPod,
@@ -215,11 +219,6 @@ func (c *ProxyCommand) Troubleshoot() error {
 		return err
 	}
 
-	// err = t.GetEnvoyConfigDump()
-	// if err != nil {
-	// 	return err
-	// }
-
 	messages, err := t.RunAllTests(c.flagUpstreamEnvoyID, c.flagUpstreamIP)
 	if err != nil {
 		return err
@@ -245,9 +244,12 @@ func (c *ProxyCommand) Troubleshoot() error {
 // complete flag such as "-foo" or "--foo".
 func (c *ProxyCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
-		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
-		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNamePod):             complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameUpstreamEnvoyID): complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameUpstreamIP):      complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameNamespace):       complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNameKubeConfig):      complete.PredictFiles("*"),
+		fmt.Sprintf("-%s", flagNameKubeContext):     complete.PredictNothing,
 	}
 }
 
@@ -276,10 +278,10 @@ Usage: consul-k8s troubleshoot proxy [options]
   Requires a pod and upstream service SNI.
 
   Examples:
-    $ consul-k8s troubleshoot proxy -pod pod1 -upstream foo
-	
-	where 'pod1' is the pod running a consul proxy and 'foo' is the upstream envoy ID which 
-	can be obtained by running:
-	$ consul-k8s troubleshoot upstreams [options]
+    $ consul-k8s troubleshoot proxy -pod pod1 -upstream-envoy-id foo
+
+    where 'pod1' is the pod running a consul proxy and 'foo' is the upstream envoy ID which
+    can be obtained by running:
+    $ consul-k8s troubleshoot upstreams [options]
 `
 )
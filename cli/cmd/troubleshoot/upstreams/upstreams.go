@@ -86,10 +86,10 @@ func (c *UpstreamsCommand) init() {
 	c.help = c.set.Help()
 }
 
-// Run executes the list command.
+// Run executes the troubleshoot command.
 func (c *UpstreamsCommand) Run(args []string) int {
 	c.once.Do(c.init)
-	c.Log.ResetNamed("list")
+	c.Log.ResetNamed("troubleshoot")
 	defer common.CloseWithError(c.BaseCommand)
 
 	// Parse the command line flags.
@@ -119,7 +119,7 @@ func (c *UpstreamsCommand) Run(args []string) int {
 	return 0
 }
 
-// validateFlags ensures that the flags passed in by the can be used.
+// validateFlags ensures that the flags passed in by the user can be used.
 func (c *UpstreamsCommand) validateFlags() error {
 
 	if c.flagPod == "" {
@@ -165,6 +165,10 @@ func (c *UpstreamsCommand) initKubernetes() (err error) {
 }
 
 func (c *UpstreamsCommand) Troubleshoot() error {
+	if err := common.ValidateProxyPod(c.Ctx, c.kubernetes, c.flagNamespace, c.flagPod); err != nil {
+		return err
+	}
+
 	pf := common.PortForward{
 		Namespace:  c.flagNamespace,
 		PodName:    c.flagPod,
@@ -226,6 +230,7 @@ func (c *UpstreamsCommand) Troubleshoot() error {
 // complete flag such as "-foo" or "--foo".
 func (c *UpstreamsCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
+		fmt.Sprintf("-%s", flagNamePod):         complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameNamespace):   complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameKubeConfig):  complete.PredictFiles("*"),
 		fmt.Sprintf("-%s", flagNameKubeContext): complete.PredictNothing,
@@ -0,0 +1,350 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package upgrade
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	consulChart "github.com/hashicorp/consul-k8s/charts"
+	"github.com/hashicorp/consul-k8s/cli/common"
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/hashicorp/consul-k8s/cli/helm"
+	"github.com/hashicorp/go-version"
+	"github.com/pmezard/go-difflib/difflib"
+	"helm.sh/helm/v3/pkg/action"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/yaml"
+)
+
+// maxMinorVersionSkew is the number of minor versions that Consul and the
+// Helm chart can be upgraded by at once.
+const maxMinorVersionSkew = 2
+
+// deprecatedValues maps the path of deprecated Helm values to a message
+// explaining what to use instead.
+var deprecatedValues = map[string]string{
+	"apiGateway":                     "Use connectInject.apiGateway instead. The apiGateway stanza will be removed with the release of Consul 1.17.",
+	"syncCatalog.k8sSourceNamespace": "Use syncCatalog.k8sAllowNamespaces and syncCatalog.k8sDenyNamespaces instead.",
+}
+
+// preflightResult is the outcome of a single preflight check.
+type preflightResult struct {
+	message string
+	// style is the terminal style of the message. Results with the error
+	// style fail the preflight checks.
+	style string
+}
+
+// preflight checks that the installed release can be upgraded to the embedded
+// chart with the given values and prints the diff between the installed and the
+// rendered Kubernetes manifests. It doesn't modify the cluster and returns
+// false if any of the checks failed.
+func (c *Command) preflight(settings *helmCLI.EnvSettings, releaseName, namespace string, values map[string]interface{}) (bool, error) {
+	c.UI.Output("Running preflight checks", terminal.WithHeaderStyle())
+
+	uiLogger := c.createUILogger()
+	actionConfig, err := helm.InitActionConfig(new(action.Configuration), namespace, settings, uiLogger)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := c.helmActionsRunner.GetStatus(action.NewStatus(actionConfig), releaseName)
+	if err != nil {
+		return false, fmt.Errorf("couldn't get the status of release %q: %s", releaseName, err)
+	}
+	chart, err := c.helmActionsRunner.LoadChart(consulChart.ConsulHelmChart, common.TopLevelChartDirName)
+	if err != nil {
+		return false, err
+	}
+
+	// Render the upgraded manifests without applying them.
+	upgrade := action.NewUpgrade(actionConfig)
+	upgrade.Namespace = namespace
+	upgrade.DryRun = true
+	rendered, err := c.helmActionsRunner.Upgrade(upgrade, releaseName, chart, values)
+	if err != nil {
+		return false, fmt.Errorf("couldn't render the upgraded chart: %s", err)
+	}
+
+	var currentChartVersion string
+	var currentImage string
+	if current.Chart != nil && current.Chart.Metadata != nil {
+		currentChartVersion = current.Chart.Metadata.Version
+		currentImage = imageValue(current.Config, current.Chart.Values)
+	}
+	var upgradedChartVersion string
+	if chart.Metadata != nil {
+		upgradedChartVersion = chart.Metadata.Version
+	}
+	var results []preflightResult
+	results = append(results, checkVersionSkew("Helm chart", currentChartVersion, upgradedChartVersion))
+	results = append(results, checkVersionSkew("Consul server", imageVersion(currentImage), imageVersion(imageValue(values, chart.Values))))
+	crdResults, err := checkCRDVersions(current.Manifest, rendered.Manifest)
+	if err != nil {
+		return false, err
+	}
+	results = append(results, crdResults...)
+	results = append(results, checkDeprecatedValues(values)...)
+
+	passed := true
+	for _, result := range results {
+		if result.style == terminal.ErrorStyle {
+			passed = false
+		}
+		c.UI.Output(result.message, terminal.WithStyle(result.style))
+	}
+
+	diff, err := manifestDiff(current.Manifest, rendered.Manifest)
+	if err != nil {
+		return false, err
+	}
+	c.UI.Output("\nDifference between installed and upgraded Kubernetes manifests"+
+		"\n--------------------------------------------------------------", terminal.WithInfoStyle())
+	if diff == "" {
+		c.UI.Output("No changes.", terminal.WithInfoStyle())
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "+"):
+			c.UI.Output(line, terminal.WithDiffAddedStyle())
+		case strings.HasPrefix(line, "-"):
+			c.UI.Output(line, terminal.WithDiffRemovedStyle())
+		default:
+			c.UI.Output(line, terminal.WithDiffUnchangedStyle())
+		}
+	}
+
+	return passed, nil
+}
+
+// checkVersionSkew checks that the upgrade from version `from` to `to` is not a
+// downgrade and doesn't skip more than maxMinorVersionSkew minor versions.
+func checkVersionSkew(name, from, to string) preflightResult {
+	fromVersion, err := version.NewVersion(from)
+	if err != nil {
+		return preflightResult{fmt.Sprintf("Could not determine the installed %s version %q, skipping the version skew check.", name, from), terminal.WarningStyle}
+	}
+	toVersion, err := version.NewVersion(to)
+	if err != nil {
+		return preflightResult{fmt.Sprintf("Could not determine the upgraded %s version %q, skipping the version skew check.", name, to), terminal.WarningStyle}
+	}
+
+	if toVersion.LessThan(fromVersion) {
+		return preflightResult{fmt.Sprintf("%s version %s would be downgraded to %s.", name, fromVersion, toVersion), terminal.ErrorStyle}
+	}
+	fromSegments, toSegments := fromVersion.Segments(), toVersion.Segments()
+	if fromSegments[0] != toSegments[0] {
+		return preflightResult{fmt.Sprintf("%s major version changes from %s to %s, review the upgrade notes before upgrading.", name, fromVersion, toVersion), terminal.WarningStyle}
+	}
+	if skew := toSegments[1] - fromSegments[1]; skew > maxMinorVersionSkew {
+		return preflightResult{fmt.Sprintf("%s upgrade from %s to %s skips %d minor versions, upgrade at most %d minor versions at a time.",
+			name, fromVersion, toVersion, skew-1, maxMinorVersionSkew), terminal.ErrorStyle}
+	}
+	return preflightResult{fmt.Sprintf("%s version can be upgraded from %s to %s.", name, fromVersion, toVersion), terminal.SuccessStyle}
+}
+
+// checkCRDVersions checks that the upgraded manifest doesn't remove any of the
+// installed CRDs or any of their versions, since this would delete or make the
+// existing custom resources inaccessible.
+func checkCRDVersions(currentManifest, upgradedManifest string) ([]preflightResult, error) {
+	current, err := crdVersions(currentManifest)
+	if err != nil {
+		return nil, err
+	}
+	upgraded, err := crdVersions(upgradedManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []preflightResult
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		versions, ok := upgraded[name]
+		if !ok {
+			results = append(results, preflightResult{fmt.Sprintf("CRD %s would be removed along with all of its custom resources.", name), terminal.ErrorStyle})
+			continue
+		}
+		for _, v := range current[name] {
+			if !slices.Contains(versions, v) {
+				results = append(results, preflightResult{fmt.Sprintf("Version %s of CRD %s would no longer be served.", v, name), terminal.ErrorStyle})
+			}
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, preflightResult{"CRD versions are compatible.", terminal.SuccessStyle})
+	}
+	return results, nil
+}
+
+// crdVersions returns the versions of each CRD in the manifest by name.
+func crdVersions(manifest string) (map[string][]string, error) {
+	crds := make(map[string][]string)
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		var crd struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Versions []struct {
+					Name string `json:"name"`
+				} `json:"versions"`
+			} `json:"spec"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &crd); err != nil {
+			return nil, fmt.Errorf("couldn't parse manifest: %s", err)
+		}
+		if crd.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		var versions []string
+		for _, v := range crd.Spec.Versions {
+			versions = append(versions, v.Name)
+		}
+		crds[crd.Metadata.Name] = versions
+	}
+	return crds, nil
+}
+
+// checkDeprecatedValues warns about deprecated Helm values that are set.
+func checkDeprecatedValues(values map[string]interface{}) []preflightResult {
+	paths := make([]string, 0, len(deprecatedValues))
+	for path := range deprecatedValues {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var results []preflightResult
+	for _, path := range paths {
+		if v, ok := lookupValue(values, path); ok && v != nil {
+			results = append(results, preflightResult{fmt.Sprintf("Helm value %s is deprecated. %s", path, deprecatedValues[path]), terminal.WarningStyle})
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, preflightResult{"No deprecated Helm values are set.", terminal.SuccessStyle})
+	}
+	return results
+}
+
+// manifestDiff returns a unified diff of each Kubernetes resource that differs
+// between the two manifests. Resources are matched by kind and name.
+func manifestDiff(currentManifest, upgradedManifest string) (string, error) {
+	current, err := resourcesByName(currentManifest)
+	if err != nil {
+		return "", err
+	}
+	upgraded, err := resourcesByName(upgradedManifest)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	for name := range current {
+		names = append(names, name)
+	}
+	for name := range upgraded {
+		if _, ok := current[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	buf := new(strings.Builder)
+	for _, name := range names {
+		if current[name] == upgraded[name] {
+			continue
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(current[name]),
+			B:        splitLines(upgraded[name]),
+			FromFile: name,
+			ToFile:   name,
+			Context:  3,
+		})
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(diff)
+	}
+	return buf.String(), nil
+}
+
+// resourcesByName splits the manifest into its resources keyed by kind and name.
+func resourcesByName(manifest string) (map[string]string, error) {
+	resources := make(map[string]string)
+	for _, doc := range releaseutil.SplitManifests(manifest) {
+		var resource struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(doc), &resource); err != nil {
+			return nil, fmt.Errorf("couldn't parse manifest: %s", err)
+		}
+		if resource.Kind == "" {
+			continue
+		}
+		resources[fmt.Sprintf("%s/%s", resource.Kind, resource.Metadata.Name)] = strings.TrimSpace(doc)
+	}
+	return resources, nil
+}
+
+// splitLines splits the text into lines for difflib. Unlike difflib.SplitLines,
+// an empty text has no lines.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return difflib.SplitLines(text)
+}
+
+// imageValue returns global.image from the user supplied values, falling back
+// to the chart defaults.
+func imageValue(values, defaults map[string]interface{}) string {
+	for _, vals := range []map[string]interface{}{values, defaults} {
+		if image, ok := lookupValue(vals, "global.image"); ok {
+			if s, ok := image.(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// imageVersion returns the tag of the image, e.g. 1.16.0 for hashicorp/consul:1.16.0.
+func imageVersion(image string) string {
+	if strings.Contains(image, "@") {
+		return ""
+	}
+	idx := strings.LastIndex(image, ":")
+	if idx < 0 || strings.Contains(image[idx:], "/") {
+		return ""
+	}
+	return image[idx+1:]
+}
+
+// lookupValue returns the value at the dot separated path of nested Helm values.
+func lookupValue(values map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
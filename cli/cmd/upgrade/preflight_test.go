@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package upgrade
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul-k8s/cli/common/terminal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckVersionSkew(t *testing.T) {
+	cases := map[string]struct {
+		from, to      string
+		expectedStyle string
+	}{
+		"patch upgrade":             {from: "1.16.0", to: "1.16.1", expectedStyle: terminal.SuccessStyle},
+		"two minor versions":        {from: "1.14.3", to: "1.16.0", expectedStyle: terminal.SuccessStyle},
+		"three minor versions":      {from: "1.13.0", to: "1.16.0", expectedStyle: terminal.ErrorStyle},
+		"downgrade":                 {from: "1.16.0", to: "1.15.2", expectedStyle: terminal.ErrorStyle},
+		"major version":             {from: "1.16.0", to: "2.0.0", expectedStyle: terminal.WarningStyle},
+		"enterprise version":        {from: "1.15.0-ent", to: "1.16.0-ent", expectedStyle: terminal.SuccessStyle},
+		"unknown installed version": {from: "", to: "1.16.0", expectedStyle: terminal.WarningStyle},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.expectedStyle, checkVersionSkew("Consul", c.from, c.to).style)
+		})
+	}
+}
+
+func TestCheckCRDVersions(t *testing.T) {
+	current := `---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: meshes.consul.hashicorp.com
+spec:
+  versions:
+  - name: v1alpha1
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: jwtproviders.consul.hashicorp.com
+spec:
+  versions:
+  - name: v1alpha1
+`
+	upgraded := `---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: meshes.consul.hashicorp.com
+spec:
+  versions:
+  - name: v1alpha1
+  - name: v1beta1
+`
+	results, err := checkCRDVersions(current, upgraded)
+	require.NoError(t, err)
+	require.Equal(t, []preflightResult{
+		{"CRD jwtproviders.consul.hashicorp.com would be removed along with all of its custom resources.", terminal.ErrorStyle},
+	}, results)
+
+	results, err = checkCRDVersions(current, current)
+	require.NoError(t, err)
+	require.Equal(t, []preflightResult{{"CRD versions are compatible.", terminal.SuccessStyle}}, results)
+}
+
+func TestCheckDeprecatedValues(t *testing.T) {
+	results := checkDeprecatedValues(map[string]interface{}{
+		"syncCatalog": map[string]interface{}{"k8sSourceNamespace": "default"},
+	})
+	require.Equal(t, []preflightResult{
+		{"Helm value syncCatalog.k8sSourceNamespace is deprecated. Use syncCatalog.k8sAllowNamespaces and syncCatalog.k8sDenyNamespaces instead.", terminal.WarningStyle},
+	}, results)
+
+	results = checkDeprecatedValues(map[string]interface{}{"global": map[string]interface{}{"name": "consul"}})
+	require.Equal(t, []preflightResult{{"No deprecated Helm values are set.", terminal.SuccessStyle}}, results)
+}
+
+func TestManifestDiff(t *testing.T) {
+	current := `---
+# Source: consul/templates/a.yaml
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  foo: bar
+---
+# Source: consul/templates/b.yaml
+kind: ConfigMap
+metadata:
+  name: b
+`
+	upgraded := `---
+# Source: consul/templates/a.yaml
+kind: ConfigMap
+metadata:
+  name: a
+data:
+  foo: baz
+---
+# Source: consul/templates/c.yaml
+kind: Secret
+metadata:
+  name: c
+`
+	diff, err := manifestDiff(current, upgraded)
+	require.NoError(t, err)
+	require.Equal(t, `--- ConfigMap/a
++++ ConfigMap/a
+@@ -3,4 +3,4 @@
+ metadata:
+   name: a
+ data:
+-  foo: bar
++  foo: baz
+--- ConfigMap/b
++++ ConfigMap/b
+@@ -1,4 +0,0 @@
+-# Source: consul/templates/b.yaml
+-kind: ConfigMap
+-metadata:
+-  name: b
+--- Secret/c
++++ Secret/c
+@@ -0,0 +1,4 @@
++# Source: consul/templates/c.yaml
++kind: Secret
++metadata:
++  name: c
+`, diff)
+
+	diff, err = manifestDiff(current, current)
+	require.NoError(t, err)
+	require.Empty(t, diff)
+}
+
+func TestImageVersion(t *testing.T) {
+	require.Equal(t, "1.16.0", imageVersion("hashicorp/consul:1.16.0"))
+	require.Equal(t, "1.16.0", imageVersion("localhost:5000/hashicorp/consul:1.16.0"))
+	require.Equal(t, "", imageVersion("localhost:5000/hashicorp/consul"))
+	require.Equal(t, "", imageVersion("hashicorp/consul@sha256:abcd"))
+}
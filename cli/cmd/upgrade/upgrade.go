@@ -39,6 +39,9 @@ const (
 	flagNameDryRun = "dry-run"
 	defaultDryRun  = false
 
+	flagNamePreflight = "preflight"
+	defaultPreflight  = false
+
 	flagNameAutoApprove = "auto-approve"
 	defaultAutoApprove  = false
 
@@ -75,6 +78,7 @@ type Command struct {
 
 	flagPreset            string
 	flagDryRun            bool
+	flagPreflight         bool
 	flagAutoApprove       bool
 	flagValueFiles        []string
 	flagSetStringValues   []string
@@ -109,6 +113,13 @@ func (c *Command) init() {
 		Default: defaultDryRun,
 		Usage:   "Perform pre-upgrade checks and display summary of upgrade.",
 	})
+	f.BoolVar(&flag.BoolVar{
+		Name:    flagNamePreflight,
+		Target:  &c.flagPreflight,
+		Default: defaultPreflight,
+		Usage: "Check the version skew, CRD compatibility and deprecated values of the upgrade and display the diff " +
+			"of the rendered Kubernetes manifests without upgrading.",
+	})
 	f.StringSliceVar(&flag.StringSliceVar{
 		Name:    flagNameConfigFile,
 		Aliases: []string{"f"},
@@ -294,6 +305,20 @@ func (c *Command) Run(args []string) int {
 	// aren't double prefixed with "consul-consul-...".
 	chartValues = common.MergeMaps(config.ConvertToMap(config.GlobalNameConsul), chartValues)
 
+	if c.flagPreflight {
+		passed, err := c.preflight(settings, consulName, consulNamespace, chartValues)
+		if err != nil {
+			c.UI.Output(err.Error(), terminal.WithErrorStyle())
+			return 1
+		}
+		if !passed {
+			c.UI.Output("Preflight checks failed. Resolve the errors above before upgrading.", terminal.WithErrorStyle())
+			return 1
+		}
+		c.UI.Output("Preflight checks passed. No changes were made to the Kubernetes cluster.", terminal.WithSuccessStyle())
+		return 0
+	}
+
 	timeout, err := time.ParseDuration(c.flagTimeout)
 	if err != nil {
 		c.UI.Output(err.Error(), terminal.WithErrorStyle())
@@ -397,6 +422,7 @@ func (c *Command) AutocompleteFlags() complete.Flags {
 		fmt.Sprintf("-%s", flagNameSetValues):       complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameFileValues):      complete.PredictFiles("*"),
 		fmt.Sprintf("-%s", flagNameDryRun):          complete.PredictNothing,
+		fmt.Sprintf("-%s", flagNamePreflight):       complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameAutoApprove):     complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameTimeout):         complete.PredictNothing,
 		fmt.Sprintf("-%s", flagNameVerbose):         complete.PredictNothing,
@@ -423,6 +449,9 @@ func (c *Command) validateFlags(args []string) error {
 	if len(c.set.Args()) > 0 {
 		return errors.New("should have no non-flag arguments")
 	}
+	if c.flagPreflight && c.flagDryRun {
+		return fmt.Errorf("cannot set both -%s and -%s", flagNamePreflight, flagNameDryRun)
+	}
 	if len(c.flagValueFiles) != 0 && c.flagPreset != defaultPreset {
 		return fmt.Errorf("cannot set both -%s and -%s", flagNameConfigFile, flagNamePreset)
 	}
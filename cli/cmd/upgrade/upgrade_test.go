@@ -6,6 +6,7 @@ package upgrade
 import (
 	"bytes"
 	"context"
+	"embed"
 	"errors"
 	"flag"
 	"fmt"
@@ -524,6 +525,66 @@ func TestUpgrade(t *testing.T) {
 			expectConsulUpgraded:                    false,
 			expectConsulDemoUpgraded:                false,
 		},
+		"upgrade with -preflight flag when checks pass returns success": {
+			input: []string{
+				"-preflight",
+			},
+			messages: []string{
+				"\n==> Running preflight checks\n ✓ Helm chart version can be upgraded from 1.1.0 to 1.2.0.\n ✓ Consul server version can be upgraded from 1.15.0 to 1.16.0.\n ✓ CRD versions are compatible.\n ✓ No deprecated Helm values are set.\n",
+				"--- ConfigMap/consul-config\n  +++ ConfigMap/consul-config\n",
+				"  -  foo: bar\n",
+				"  +  foo: baz\n",
+				" ✓ Preflight checks passed. No changes were made to the Kubernetes cluster.\n",
+			},
+			helmActionsRunner: &helm.MockActionRunner{
+				CheckForInstallationsFunc: func(options *helm.CheckForInstallationsOptions) (bool, string, string, error) {
+					if options.ReleaseName == "consul" {
+						return true, "consul", "consul", nil
+					} else {
+						return false, "", "", nil
+					}
+				},
+				GetStatusFunc: preflightStatus("1.1.0", "hashicorp/consul:1.15.0", "v1alpha1"),
+				LoadChartFunc: preflightChart("1.2.0", "hashicorp/consul:1.16.0"),
+				UpgradeFunc:   preflightUpgrade("baz", "v1alpha1"),
+			},
+			expectedReturnCode:                      0,
+			expectCheckedForConsulInstallations:     true,
+			expectCheckedForConsulDemoInstallations: true,
+			// The upgrade is only rendered with a dry run.
+			expectConsulUpgraded:     true,
+			expectConsulDemoUpgraded: false,
+		},
+		"upgrade with -preflight flag when checks fail returns error": {
+			input: []string{
+				"-preflight",
+				"--set", "apiGateway.enabled=true",
+			},
+			messages: []string{
+				" ! Helm chart version 1.2.0 would be downgraded to 1.1.0.\n",
+				" ! Consul server upgrade from 1.12.0 to 1.16.0 skips 3 minor versions, upgrade at most 2 minor versions at a time.\n",
+				" ! Version v1alpha1 of CRD meshes.consul.hashicorp.com would no longer be served.\n",
+				" * Helm value apiGateway is deprecated.",
+				" ! Preflight checks failed. Resolve the errors above before upgrading.\n",
+			},
+			helmActionsRunner: &helm.MockActionRunner{
+				CheckForInstallationsFunc: func(options *helm.CheckForInstallationsOptions) (bool, string, string, error) {
+					if options.ReleaseName == "consul" {
+						return true, "consul", "consul", nil
+					} else {
+						return false, "", "", nil
+					}
+				},
+				GetStatusFunc: preflightStatus("1.2.0", "hashicorp/consul:1.12.0", "v1alpha1"),
+				LoadChartFunc: preflightChart("1.1.0", "hashicorp/consul:1.16.0"),
+				UpgradeFunc:   preflightUpgrade("bar", "v2beta1"),
+			},
+			expectedReturnCode:                      1,
+			expectCheckedForConsulInstallations:     true,
+			expectCheckedForConsulDemoInstallations: true,
+			expectConsulUpgraded:                    true,
+			expectConsulDemoUpgraded:                false,
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -553,3 +614,56 @@ func TestUpgrade(t *testing.T) {
 		})
 	}
 }
+
+// preflightManifest returns a rendered manifest with a CRD of the given version
+// and a ConfigMap with the given value.
+func preflightManifest(value, crdVersion string) string {
+	return fmt.Sprintf(`---
+# Source: consul/templates/crd-meshes.yaml
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: meshes.consul.hashicorp.com
+spec:
+  versions:
+  - name: %s
+---
+# Source: consul/templates/config.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: consul-config
+data:
+  foo: %s
+`, crdVersion, value)
+}
+
+func preflightStatus(chartVersion, image, crdVersion string) func(*action.Status, string) (*helmRelease.Release, error) {
+	return func(status *action.Status, name string) (*helmRelease.Release, error) {
+		return &helmRelease.Release{
+			Chart: &chart.Chart{
+				Metadata: &chart.Metadata{Version: chartVersion},
+			},
+			Config:   map[string]interface{}{"global": map[string]interface{}{"image": image}},
+			Manifest: preflightManifest("bar", crdVersion),
+		}, nil
+	}
+}
+
+func preflightChart(chartVersion, image string) func(embed.FS, string) (*chart.Chart, error) {
+	return func(chrt embed.FS, chartDirName string) (*chart.Chart, error) {
+		return &chart.Chart{
+			Metadata: &chart.Metadata{Version: chartVersion},
+			Values:   map[string]interface{}{"global": map[string]interface{}{"image": image}},
+		}, nil
+	}
+}
+
+func preflightUpgrade(value, crdVersion string) func(*action.Upgrade, string, *chart.Chart, map[string]interface{}) (*helmRelease.Release, error) {
+	return func(upgrade *action.Upgrade, name string, chrt *chart.Chart, vals map[string]interface{}) (*helmRelease.Release, error) {
+		if !upgrade.DryRun {
+			return nil, errors.New("preflight must not upgrade the release")
+		}
+		return &helmRelease.Release{Manifest: preflightManifest(value, crdVersion)}, nil
+	}
+}
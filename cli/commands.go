@@ -7,12 +7,18 @@ import (
 	"context"
 
 	"github.com/hashicorp/consul-k8s/cli/cmd/config"
+	config_export "github.com/hashicorp/consul-k8s/cli/cmd/config/export"
+	config_import "github.com/hashicorp/consul-k8s/cli/cmd/config/import"
 	config_read "github.com/hashicorp/consul-k8s/cli/cmd/config/read"
+	"github.com/hashicorp/consul-k8s/cli/cmd/debug"
 	"github.com/hashicorp/consul-k8s/cli/cmd/install"
 	"github.com/hashicorp/consul-k8s/cli/cmd/proxy"
 	"github.com/hashicorp/consul-k8s/cli/cmd/proxy/list"
 	"github.com/hashicorp/consul-k8s/cli/cmd/proxy/loglevel"
 	"github.com/hashicorp/consul-k8s/cli/cmd/proxy/read"
+	"github.com/hashicorp/consul-k8s/cli/cmd/snapshot"
+	snapshot_restore "github.com/hashicorp/consul-k8s/cli/cmd/snapshot/restore"
+	snapshot_save "github.com/hashicorp/consul-k8s/cli/cmd/snapshot/save"
 	"github.com/hashicorp/consul-k8s/cli/cmd/status"
 	"github.com/hashicorp/consul-k8s/cli/cmd/troubleshoot"
 	troubleshoot_proxy "github.com/hashicorp/consul-k8s/cli/cmd/troubleshoot/proxy"
@@ -45,6 +51,26 @@ func initializeCommands(ctx context.Context, log hclog.Logger) (*common.BaseComm
 				BaseCommand: baseCommand,
 			}, nil
 		},
+		"debug": func() (cli.Command, error) {
+			return &debug.DebugCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"snapshot": func() (cli.Command, error) {
+			return &snapshot.SnapshotCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"snapshot save": func() (cli.Command, error) {
+			return &snapshot_save.SaveCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"snapshot restore": func() (cli.Command, error) {
+			return &snapshot_restore.RestoreCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
 		"status": func() (cli.Command, error) {
 			return &status.Command{
 				BaseCommand: baseCommand,
@@ -91,6 +117,16 @@ func initializeCommands(ctx context.Context, log hclog.Logger) (*common.BaseComm
 				BaseCommand: baseCommand,
 			}, nil
 		},
+		"config export": func() (cli.Command, error) {
+			return &config_export.ExportCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
+		"config import": func() (cli.Command, error) {
+			return &config_import.ImportCommand{
+				BaseCommand: baseCommand,
+			}, nil
+		},
 		"troubleshoot": func() (cli.Command, error) {
 			return &troubleshoot.TroubleshootCommand{
 				BaseCommand: baseCommand,
@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import "k8s.io/apimachinery/pkg/runtime/schema"
+
+const (
+	// ConsulCRDGroup and ConsulCRDVersion identify the custom resources
+	// managed by consul-k8s.
+	ConsulCRDGroup   = "consul.hashicorp.com"
+	ConsulCRDVersion = "v1alpha1"
+)
+
+// ConfigEntryResource is a custom resource which the control plane syncs to
+// Consul as a config entry.
+type ConfigEntryResource struct {
+	// Kind is the Kubernetes kind of the custom resource, e.g. ServiceDefaults.
+	Kind string
+	// Resource is the lowercase, plural resource name, e.g. servicedefaults.
+	Resource string
+}
+
+// GVR returns the GroupVersionResource used to find the custom resource with
+// the dynamic client.
+func (r ConfigEntryResource) GVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: ConsulCRDGroup, Version: ConsulCRDVersion, Resource: r.Resource}
+}
+
+// ConfigEntryResources are the config entry custom resources. They are
+// ordered so that config entries come before the ones which reference them,
+// e.g. ServiceDefaults setting a protocol come before a ServiceRouter.
+var ConfigEntryResources = []ConfigEntryResource{
+	{Kind: "ProxyDefaults", Resource: "proxydefaults"},
+	{Kind: "Mesh", Resource: "meshes"},
+	{Kind: "SamenessGroup", Resource: "samenessgroups"},
+	{Kind: "JWTProvider", Resource: "jwtproviders"},
+	{Kind: "ServiceDefaults", Resource: "servicedefaults"},
+	{Kind: "ServiceResolver", Resource: "serviceresolvers"},
+	{Kind: "ServiceSplitter", Resource: "servicesplitters"},
+	{Kind: "ServiceRouter", Resource: "servicerouters"},
+	{Kind: "ServiceIntentions", Resource: "serviceintentions"},
+	{Kind: "ExportedServices", Resource: "exportedservices"},
+	{Kind: "IngressGateway", Resource: "ingressgateways"},
+	{Kind: "TerminatingGateway", Resource: "terminatinggateways"},
+	{Kind: "ControlPlaneRequestLimit", Resource: "controlplanerequestlimits"},
+}
+
+// ConfigEntryResourceForKind returns the config entry custom resource with
+// the given kind.
+func ConfigEntryResourceForKind(kind string) (ConfigEntryResource, bool) {
+	for _, r := range ConfigEntryResources {
+		if r.Kind == kind {
+			return r, true
+		}
+	}
+	return ConfigEntryResource{}, false
+}
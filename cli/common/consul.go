@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	helmCLI "helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	consulHTTPPort  = 8500
+	consulHTTPSPort = 8501
+
+	// bootstrapTokenSecretKey is the default key of the bootstrap ACL token
+	// in the secret written by server-acl-init.
+	bootstrapTokenSecretKey = "token"
+
+	// caCertSecretKey is the default key of the CA certificate in the secret
+	// written by tls-init.
+	caCertSecretKey = "tls.crt"
+)
+
+// ServerClientFunc returns a Consul API client for a Consul server Pod and the
+// port forward it goes through. NewServerClient is the default implementation.
+type ServerClientFunc func(ctx context.Context, client kubernetes.Interface, restConfig *rest.Config, server *corev1.Pod, token string, caCert []byte) (*api.Client, PortForwarder, error)
+
+// ErrNoReadyServer is returned by ReadyServerPod when none of the Consul
+// server Pods are ready.
+var ErrNoReadyServer = errors.New("no ready Consul server pods found")
+
+// ConsulFullName returns the prefix used for the resources of a release. It
+// mirrors the consul.fullname template in the Helm chart.
+func ConsulFullName(releaseName string, values map[string]interface{}) string {
+	name := releaseName + "-consul"
+	if override, ok := lookupString(values, "fullnameOverride"); ok && override != "" {
+		name = override
+	} else if globalName, ok := lookupString(values, "global", "name"); ok && globalName != "" {
+		name = globalName
+	} else if nameOverride, ok := lookupString(values, "nameOverride"); ok && nameOverride != "" {
+		name = releaseName + "-" + nameOverride
+	}
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.TrimSuffix(name, "-")
+}
+
+// BootstrapToken returns the bootstrap ACL token of a release from its
+// Kubernetes secret. It returns an empty token if the secret doesn't exist,
+// e.g. because ACLs are not enabled.
+func BootstrapToken(ctx context.Context, client kubernetes.Interface, namespace, releaseName string, values map[string]interface{}) (string, error) {
+	secretName := ConsulFullName(releaseName, values) + "-bootstrap-acl-token"
+	if name, ok := lookupString(values, "global", "acls", "bootstrapToken", "secretName"); ok && name != "" {
+		secretName = name
+	}
+	secretKey := bootstrapTokenSecretKey
+	if key, ok := lookupString(values, "global", "acls", "bootstrapToken", "secretKey"); ok && key != "" {
+		secretKey = key
+	}
+
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading bootstrap token secret %s: %w", secretName, err)
+	}
+	token, ok := secret.Data[secretKey]
+	if !ok {
+		return "", fmt.Errorf("secret %s does not have data key %q", secretName, secretKey)
+	}
+	return string(token), nil
+}
+
+// CACert returns the CA certificate of a release from its Kubernetes secret. It
+// returns nil if TLS is not enabled, or if the CA is stored in Vault, in which
+// case CONSUL_CACERT must be set to verify the servers.
+func CACert(ctx context.Context, client kubernetes.Interface, namespace, releaseName string, values map[string]interface{}) ([]byte, error) {
+	if tls, _ := lookupBool(values, "global", "tls", "enabled"); !tls {
+		return nil, nil
+	}
+	if vault, _ := lookupBool(values, "global", "secretsBackend", "vault", "enabled"); vault {
+		return nil, nil
+	}
+
+	secretName := ConsulFullName(releaseName, values) + "-ca-cert"
+	if name, ok := lookupString(values, "global", "tls", "caCert", "secretName"); ok && name != "" {
+		secretName = name
+	}
+	secretKey := caCertSecretKey
+	if key, ok := lookupString(values, "global", "tls", "caCert", "secretKey"); ok && key != "" {
+		secretKey = key
+	}
+
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA certificate secret %s: %w", secretName, err)
+	}
+	caCert, ok := secret.Data[secretKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s does not have data key %q", secretName, secretKey)
+	}
+	return caCert, nil
+}
+
+// ConnectToServer returns a Consul API client for a ready server Pod of the
+// release, created with newServerClient. The client trusts the CA of the release
+// and is authenticated with its bootstrap ACL token, unless CONSUL_HTTP_TOKEN is
+// set. The caller must close the port forward.
+func ConnectToServer(ctx context.Context, client kubernetes.Interface, restConfig *rest.Config, namespace, releaseName string, values map[string]interface{}, newServerClient ServerClientFunc) (*api.Client, PortForwarder, error) {
+	// A token in the environment takes precedence over the bootstrap token.
+	var token string
+	if os.Getenv(api.HTTPTokenEnvName) == "" {
+		var err error
+		if token, err = BootstrapToken(ctx, client, namespace, releaseName, values); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	caCert, err := CACert(ctx, client, namespace, releaseName, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	server, err := ReadyServerPod(ctx, client, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newServerClient(ctx, client, restConfig, server, token, caCert)
+}
+
+// SetupKubeClient returns a client for non Helm SDK calls to the Kubernetes API
+// and its REST config. The Helm SDK uses settings.RESTClientGetter for its calls
+// as well, so both target the same cluster.
+func SetupKubeClient(settings *helmCLI.EnvSettings) (kubernetes.Interface, *rest.Config, error) {
+	restConfig, err := settings.RESTClientGetter().ToRESTConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error retrieving Kubernetes authentication: %v", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error initializing Kubernetes client: %v", err)
+	}
+	return client, restConfig, nil
+}
+
+// ReadyServerPod returns the first running and ready Consul server Pod in the
+// namespace, or ErrNoReadyServer if there is none.
+func ReadyServerPod(ctx context.Context, client kubernetes.Interface, namespace string) (*corev1.Pod, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "app=consul,component=server"})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		if PodReady(pods.Items[i]) {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, ErrNoReadyServer
+}
+
+// NewServerClient port forwards to the HTTP API of the Consul server Pod and
+// returns a Consul API client for it. The default client configuration picks
+// up CONSUL_HTTP_TOKEN and the TLS settings from the environment; a non-empty
+// token overrides CONSUL_HTTP_TOKEN and a non-empty CA certificate overrides
+// CONSUL_CACERT. The caller must close the port forward.
+func NewServerClient(ctx context.Context, client kubernetes.Interface, restConfig *rest.Config, server *corev1.Pod, token string, caCert []byte) (*api.Client, PortForwarder, error) {
+	port, scheme := consulHTTPPort, "http"
+	if hasContainerPort(*server, "https") {
+		port, scheme = consulHTTPSPort, "https"
+	}
+
+	pf := &PortForward{
+		Namespace:  server.Namespace,
+		PodName:    server.Name,
+		RemotePort: port,
+		KubeClient: client,
+		RestConfig: restConfig,
+	}
+	endpoint, err := pf.Open(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := api.DefaultConfig()
+	cfg.Address = endpoint
+	cfg.Scheme = scheme
+	if token != "" {
+		cfg.Token = token
+	}
+	if len(caCert) > 0 {
+		cfg.TLSConfig.CAFile = ""
+		cfg.TLSConfig.CAPath = ""
+		cfg.TLSConfig.CAPem = caCert
+	}
+	consulClient, err := api.NewClient(cfg)
+	if err != nil {
+		pf.Close()
+		return nil, nil, err
+	}
+	return consulClient, pf, nil
+}
+
+// PodReady returns true if the Pod is running and its Ready condition is true.
+func PodReady(pod corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// hasContainerPort returns true if any container in the Pod exposes a port
+// with the given name.
+func hasContainerPort(pod corev1.Pod, name string) bool {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lookupString returns the string at the path in the Helm values.
+func lookupString(values map[string]interface{}, path ...string) (string, bool) {
+	var current interface{} = values
+	for _, key := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if current, ok = m[key]; !ok {
+			return "", false
+		}
+	}
+	s, ok := current.(string)
+	return s, ok
+}
+
+// lookupBool returns the bool at the path in the Helm values.
+func lookupBool(values map[string]interface{}, path ...string) (bool, bool) {
+	var current interface{} = values
+	for _, key := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return false, false
+		}
+		if current, ok = m[key]; !ok {
+			return false, false
+		}
+	}
+	b, ok := current.(bool)
+	return b, ok
+}
@@ -0,0 +1,245 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestConsulFullName(t *testing.T) {
+	cases := map[string]struct {
+		releaseName string
+		values      map[string]interface{}
+		expected    string
+	}{
+		"default": {
+			releaseName: "consul",
+			expected:    "consul-consul",
+		},
+		"global name": {
+			releaseName: "consul",
+			values:      map[string]interface{}{"global": map[string]interface{}{"name": "consul"}},
+			expected:    "consul",
+		},
+		"fullname override": {
+			releaseName: "consul",
+			values: map[string]interface{}{
+				"fullnameOverride": "override",
+				"global":           map[string]interface{}{"name": "consul"},
+			},
+			expected: "override",
+		},
+		"name override": {
+			releaseName: "prod",
+			values:      map[string]interface{}{"nameOverride": "mesh"},
+			expected:    "prod-mesh",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, ConsulFullName(tc.releaseName, tc.values))
+		})
+	}
+}
+
+func TestBootstrapToken(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-bootstrap-acl-token", Namespace: "consul"},
+			Data:       map[string][]byte{"token": []byte("default-token")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-token", Namespace: "consul"},
+			Data:       map[string][]byte{"acl": []byte("custom-token")},
+		},
+	)
+	globalName := map[string]interface{}{"global": map[string]interface{}{"name": "consul"}}
+
+	token, err := BootstrapToken(context.Background(), client, "consul", "consul", globalName)
+	require.NoError(t, err)
+	require.Equal(t, "default-token", token)
+
+	token, err = BootstrapToken(context.Background(), client, "consul", "consul", map[string]interface{}{
+		"global": map[string]interface{}{
+			"acls": map[string]interface{}{
+				"bootstrapToken": map[string]interface{}{"secretName": "my-token", "secretKey": "acl"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "custom-token", token)
+
+	// ACLs are disabled so there is no secret.
+	token, err = BootstrapToken(context.Background(), client, "other", "consul", globalName)
+	require.NoError(t, err)
+	require.Equal(t, "", token)
+}
+
+func TestCACert(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-ca-cert", Namespace: "consul"},
+			Data:       map[string][]byte{"tls.crt": []byte("default-ca")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-ca", Namespace: "consul"},
+			Data:       map[string][]byte{"ca.pem": []byte("custom-ca")},
+		},
+	)
+	tlsValues := func(tls map[string]interface{}) map[string]interface{} {
+		tls["enabled"] = true
+		return map[string]interface{}{"global": map[string]interface{}{"name": "consul", "tls": tls}}
+	}
+
+	cases := map[string]struct {
+		values   map[string]interface{}
+		expected []byte
+		err      string
+	}{
+		"TLS disabled": {
+			values: map[string]interface{}{"global": map[string]interface{}{"name": "consul"}},
+		},
+		"default secret": {
+			values:   tlsValues(map[string]interface{}{}),
+			expected: []byte("default-ca"),
+		},
+		"custom secret": {
+			values: tlsValues(map[string]interface{}{
+				"caCert": map[string]interface{}{"secretName": "my-ca", "secretKey": "ca.pem"},
+			}),
+			expected: []byte("custom-ca"),
+		},
+		"missing key": {
+			values: tlsValues(map[string]interface{}{
+				"caCert": map[string]interface{}{"secretName": "my-ca"},
+			}),
+			err: `secret my-ca does not have data key "tls.crt"`,
+		},
+		"vault": {
+			values: map[string]interface{}{"global": map[string]interface{}{
+				"tls":            map[string]interface{}{"enabled": true},
+				"secretsBackend": map[string]interface{}{"vault": map[string]interface{}{"enabled": true}},
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			caCert, err := CACert(context.Background(), client, "consul", "consul", tc.values)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, caCert)
+		})
+	}
+}
+
+func TestConnectToServer(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "consul-server-0",
+				Namespace: "consul",
+				Labels:    map[string]string{"app": "consul", "component": "server"},
+			},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-bootstrap-acl-token", Namespace: "consul"},
+			Data:       map[string][]byte{"token": []byte("bootstrap-token")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-ca-cert", Namespace: "consul"},
+			Data:       map[string][]byte{"tls.crt": []byte("ca")},
+		},
+	)
+	values := map[string]interface{}{"global": map[string]interface{}{
+		"name": "consul",
+		"tls":  map[string]interface{}{"enabled": true},
+	}}
+
+	cases := map[string]struct {
+		envToken      string
+		expectedToken string
+	}{
+		"bootstrap token": {
+			expectedToken: "bootstrap-token",
+		},
+		"token from the environment": {
+			envToken: "env-token",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(api.HTTPTokenEnvName, tc.envToken)
+			var called bool
+			newServerClient := func(_ context.Context, _ kubernetes.Interface, _ *rest.Config, server *corev1.Pod, token string, caCert []byte) (*api.Client, PortForwarder, error) {
+				called = true
+				require.Equal(t, "consul-server-0", server.Name)
+				require.Equal(t, tc.expectedToken, token)
+				require.Equal(t, []byte("ca"), caCert)
+				return nil, nil, nil
+			}
+			_, _, err := ConnectToServer(context.Background(), client, nil, "consul", "consul", values, newServerClient)
+			require.NoError(t, err)
+			require.True(t, called)
+		})
+	}
+}
+
+func TestReadyServerPod(t *testing.T) {
+	labels := map[string]string{"app": "consul", "component": "server"}
+	ready := corev1.PodStatus{
+		Phase:      corev1.PodRunning,
+		Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+	}
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-server-0", Namespace: "consul", Labels: labels},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-server-1", Namespace: "consul", Labels: labels},
+			Status:     ready,
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "consul"},
+			Status:     ready,
+		},
+	)
+
+	pod, err := ReadyServerPod(context.Background(), client, "consul")
+	require.NoError(t, err)
+	require.Equal(t, "consul-server-1", pod.Name)
+
+	_, err = ReadyServerPod(context.Background(), client, "other")
+	require.ErrorIs(t, err, ErrNoReadyServer)
+}
+
+func TestPodReady(t *testing.T) {
+	pod := corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+	require.False(t, PodReady(pod))
+
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	require.True(t, PodReady(pod))
+
+	pod.Status.Phase = corev1.PodPending
+	require.False(t, PodReady(pod))
+}
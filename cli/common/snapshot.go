@@ -0,0 +1,212 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	snapshotMetaFile  = "meta.json"
+	snapshotStateFile = "state.bin"
+	snapshotSumsFile  = "SHA256SUMS"
+)
+
+// SnapshotMetadata is the metadata of a Consul snapshot.
+type SnapshotMetadata struct {
+	ID      string
+	Index   uint64
+	Term    uint64
+	Version int
+}
+
+// VerifySnapshot checks that the Consul snapshot archive is well formed and
+// that its contents match the checksums stored in the archive, and returns
+// the snapshot metadata.
+func VerifySnapshot(r io.Reader) (*SnapshotMetadata, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot is not a gzip archive: %w", err)
+	}
+	defer gz.Close()
+
+	sums := make(map[string]string)
+	var expectedSums []byte
+	var metadata *SnapshotMetadata
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading snapshot archive: %w", err)
+		}
+
+		h := sha256.New()
+		switch hdr.Name {
+		case snapshotMetaFile:
+			var buf bytes.Buffer
+			if _, err := io.Copy(io.MultiWriter(h, &buf), tr); err != nil {
+				return nil, err
+			}
+			metadata = &SnapshotMetadata{}
+			if err := json.Unmarshal(buf.Bytes(), metadata); err != nil {
+				return nil, fmt.Errorf("error decoding snapshot metadata: %w", err)
+			}
+		case snapshotStateFile:
+			if _, err := io.Copy(h, tr); err != nil {
+				return nil, err
+			}
+		case snapshotSumsFile:
+			if expectedSums, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+			continue
+		default:
+			return nil, fmt.Errorf("unexpected file %q in snapshot", hdr.Name)
+		}
+		sums[hdr.Name] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	if metadata == nil {
+		return nil, fmt.Errorf("snapshot is missing %s", snapshotMetaFile)
+	}
+	if _, ok := sums[snapshotStateFile]; !ok {
+		return nil, fmt.Errorf("snapshot is missing %s", snapshotStateFile)
+	}
+	if expectedSums == nil {
+		return nil, fmt.Errorf("snapshot is missing %s", snapshotSumsFile)
+	}
+
+	// Each line of the checksums file is "<sha256>  <file>".
+	scanner := bufio.NewScanner(bytes.NewReader(expectedSums))
+	verified := 0
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line in %s: %q", snapshotSumsFile, scanner.Text())
+		}
+		actual, ok := sums[fields[1]]
+		if !ok {
+			return nil, fmt.Errorf("%s references unknown file %q", snapshotSumsFile, fields[1])
+		}
+		if actual != fields[0] {
+			return nil, fmt.Errorf("checksum mismatch for %s", fields[1])
+		}
+		verified++
+	}
+	if verified != len(sums) {
+		return nil, fmt.Errorf("%s does not cover every file in the snapshot", snapshotSumsFile)
+	}
+
+	return metadata, nil
+}
+
+// IsSnapshotURL returns true if the snapshot location is an HTTP(S) URL, such
+// as a pre-signed URL of an S3 compatible object store, rather than a local file.
+func IsSnapshotURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// OpenSnapshot opens the snapshot at the location, which is either a local
+// file or an HTTP(S) URL fetched with GET.
+func OpenSnapshot(location string) (io.ReadCloser, error) {
+	if !IsSnapshotURL(location) {
+		return os.Open(location)
+	}
+
+	resp, err := http.Get(location)
+	if err != nil {
+		return nil, withoutURL(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response downloading snapshot: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// WriteSnapshot writes the snapshot to the location, which is either a local
+// file or an HTTP(S) URL uploaded to with PUT.
+func WriteSnapshot(location string, snapshot io.Reader, size int64) error {
+	if !IsSnapshotURL(location) {
+		f, err := os.OpenFile(location, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, snapshot); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	req, err := http.NewRequest(http.MethodPut, location, snapshot)
+	if err != nil {
+		return withoutURL(err)
+	}
+	// S3 compatible stores reject chunked uploads, so the size must be set.
+	req.ContentLength = size
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return withoutURL(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response uploading snapshot: %s", resp.Status)
+	}
+	return nil
+}
+
+// ValidateSnapshotLocation returns an error if the snapshot location is a URL
+// with a scheme other than HTTP(S). Object stores are only supported through
+// pre-signed HTTP(S) URLs, since the commands don't handle object store
+// credentials, so e.g. s3:// URLs are rejected rather than used as file names.
+func ValidateSnapshotLocation(location string) error {
+	if IsSnapshotURL(location) {
+		return nil
+	}
+	if u, err := url.Parse(location); err == nil && len(u.Scheme) > 1 && u.Host != "" {
+		return fmt.Errorf("unsupported snapshot URL scheme %q: use a local file or a pre-signed HTTP(S) URL", u.Scheme)
+	}
+	return nil
+}
+
+// SnapshotLocationString returns the snapshot location for display. The query
+// of URLs is dropped since pre-signed URLs carry credentials in it.
+func SnapshotLocationString(location string) string {
+	if !IsSnapshotURL(location) {
+		return location
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	u.RawQuery = ""
+	return u.String()
+}
+
+// withoutURL unwraps URL errors so that pre-signed URLs aren't printed.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
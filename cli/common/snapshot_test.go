@@ -0,0 +1,164 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySnapshot(t *testing.T) {
+	meta := []byte(`{"Version":1,"ID":"2-100-1690000000000","Index":100,"Term":2}`)
+	state := []byte("raft state")
+
+	cases := map[string]struct {
+		files       []snapshotFile
+		expectedErr string
+	}{
+		"valid snapshot": {
+			files: []snapshotFile{{snapshotMetaFile, meta}, {snapshotStateFile, state}, {snapshotSumsFile, sums(meta, state)}},
+		},
+		"tampered state": {
+			files:       []snapshotFile{{snapshotMetaFile, meta}, {snapshotStateFile, []byte("tampered")}, {snapshotSumsFile, sums(meta, state)}},
+			expectedErr: "checksum mismatch for state.bin",
+		},
+		"missing state": {
+			files:       []snapshotFile{{snapshotMetaFile, meta}, {snapshotSumsFile, sums(meta, state)}},
+			expectedErr: "snapshot is missing state.bin",
+		},
+		"missing checksums": {
+			files:       []snapshotFile{{snapshotMetaFile, meta}, {snapshotStateFile, state}},
+			expectedErr: "snapshot is missing SHA256SUMS",
+		},
+		"checksums missing a file": {
+			files: []snapshotFile{{snapshotMetaFile, meta}, {snapshotStateFile, state},
+				{snapshotSumsFile, []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256(meta), snapshotMetaFile))}},
+			expectedErr: "SHA256SUMS does not cover every file in the snapshot",
+		},
+		"unexpected file": {
+			files:       []snapshotFile{{"other", nil}},
+			expectedErr: `unexpected file "other" in snapshot`,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			metadata, err := VerifySnapshot(bytes.NewReader(buildSnapshot(t, tc.files)))
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, &SnapshotMetadata{ID: "2-100-1690000000000", Index: 100, Term: 2, Version: 1}, metadata)
+		})
+	}
+
+	_, err := VerifySnapshot(bytes.NewReader([]byte("not a snapshot")))
+	require.ErrorContains(t, err, "snapshot is not a gzip archive")
+}
+
+func TestWriteAndOpenSnapshot(t *testing.T) {
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "backup.snap")
+		require.NoError(t, WriteSnapshot(path, bytes.NewReader([]byte("snapshot")), 8))
+
+		r, err := OpenSnapshot(path)
+		require.NoError(t, err)
+		defer r.Close()
+		contents, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "snapshot", string(contents))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		var stored []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/bucket/backup.snap", r.URL.Path)
+			switch r.Method {
+			case http.MethodPut:
+				require.Equal(t, int64(8), r.ContentLength)
+				stored, _ = io.ReadAll(r.Body)
+			case http.MethodGet:
+				_, _ = w.Write(stored)
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		location := server.URL + "/bucket/backup.snap?X-Amz-Signature=secret"
+		require.NoError(t, WriteSnapshot(location, bytes.NewReader([]byte("snapshot")), 8))
+
+		r, err := OpenSnapshot(location)
+		require.NoError(t, err)
+		defer r.Close()
+		contents, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, "snapshot", string(contents))
+	})
+
+	t.Run("URL error responses", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		t.Cleanup(server.Close)
+
+		err := WriteSnapshot(server.URL, bytes.NewReader(nil), 0)
+		require.EqualError(t, err, "unexpected response uploading snapshot: 403 Forbidden")
+		_, err = OpenSnapshot(server.URL)
+		require.EqualError(t, err, "unexpected response downloading snapshot: 403 Forbidden")
+	})
+}
+
+func TestSnapshotLocationString(t *testing.T) {
+	require.Equal(t, "backup.snap", SnapshotLocationString("backup.snap"))
+	require.Equal(t, "https://s3.example.com/bucket/backup.snap",
+		SnapshotLocationString("https://s3.example.com/bucket/backup.snap?X-Amz-Signature=secret"))
+}
+
+func TestValidateSnapshotLocation(t *testing.T) {
+	for _, location := range []string{"backup.snap", "/tmp/backup.snap", `C:\backup.snap`, "https://s3.example.com/bucket/backup.snap?X-Amz-Signature=secret"} {
+		require.NoError(t, ValidateSnapshotLocation(location), location)
+	}
+	require.EqualError(t, ValidateSnapshotLocation("s3://bucket/backup.snap"),
+		`unsupported snapshot URL scheme "s3": use a local file or a pre-signed HTTP(S) URL`)
+}
+
+type snapshotFile struct {
+	name     string
+	contents []byte
+}
+
+func sums(meta, state []byte) []byte {
+	return []byte(fmt.Sprintf("%x  %s\n%x  %s\n", sha256.Sum256(meta), snapshotMetaFile, sha256.Sum256(state), snapshotStateFile))
+}
+
+// buildSnapshot builds a Consul snapshot archive with the given files.
+func buildSnapshot(t *testing.T, files []snapshotFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0600, Size: int64(len(f.contents))}))
+		_, err := tw.Write(f.contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
@@ -4,8 +4,13 @@
 package common
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -84,3 +89,22 @@ func IsValidLabel(label string) bool {
 
 	return true
 }
+
+// ValidateProxyPod returns an error if the pod doesn't exist or doesn't run a
+// Consul managed Envoy proxy, i.e. it is neither connect injected nor a gateway.
+func ValidateProxyPod(ctx context.Context, client kubernetes.Interface, namespace, name string) error {
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error getting pod %s/%s: %w", namespace, name, err)
+	}
+
+	if pod.Labels["consul.hashicorp.com/connect-inject-status"] == "injected" ||
+		pod.Labels["api-gateway.consul.hashicorp.com/managed"] == "true" {
+		return nil
+	}
+	switch pod.Labels["component"] {
+	case "ingress-gateway", "mesh-gateway", "terminating-gateway":
+		return nil
+	}
+	return fmt.Errorf("pod %s/%s does not run a Consul proxy, run `consul-k8s proxy list` to find pods with proxies", namespace, name)
+}
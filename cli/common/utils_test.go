@@ -4,9 +4,13 @@
 package common
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestMergeMaps(t *testing.T) {
@@ -68,3 +72,42 @@ func TestIsValidLabel(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateProxyPod(t *testing.T) {
+	cases := map[string]struct {
+		labels map[string]string
+		expErr string
+	}{
+		"injected pod": {
+			labels: map[string]string{"consul.hashicorp.com/connect-inject-status": "injected"},
+		},
+		"gateway pod": {
+			labels: map[string]string{"component": "terminating-gateway"},
+		},
+		"api gateway pod": {
+			labels: map[string]string{"api-gateway.consul.hashicorp.com/managed": "true"},
+		},
+		"pod without proxy": {
+			labels: map[string]string{"app": "web"},
+			expErr: "pod default/web does not run a Consul proxy, run `consul-k8s proxy list` to find pods with proxies",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: c.labels},
+			})
+			err := ValidateProxyPod(context.Background(), client, "default", "web")
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("pod not found", func(t *testing.T) {
+		err := ValidateProxyPod(context.Background(), fake.NewSimpleClientset(), "default", "web")
+		require.ErrorContains(t, err, "error getting pod default/web")
+	})
+}
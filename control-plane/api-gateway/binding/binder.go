@@ -4,6 +4,8 @@
 package binding
 
 import (
+	"sort"
+
 	mapset "github.com/deckarep/golang-set"
 	"github.com/go-logr/logr"
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
@@ -48,6 +50,8 @@ type BinderConfig struct {
 	HTTPRoutes []gwv1beta1.HTTPRoute
 	// TCPRoutes is a list of TCPRoute objects that ought to be bound to the Gateway.
 	TCPRoutes []gwv1alpha2.TCPRoute
+	// GRPCRoutes is a list of GRPCRoute objects that ought to be bound to the Gateway.
+	GRPCRoutes []gwv1alpha2.GRPCRoute
 	// Pods are any pods that are part of the Gateway deployment.
 	Pods []corev1.Pod
 	// Service is the deployed service associated with the Gateway deployment.
@@ -61,6 +65,10 @@ type BinderConfig struct {
 	// Resources is a map containing all service targets to verify
 	// against the routing backends.
 	Resources *common.ResourceMap
+
+	// ClusterName is added to the names of the virtual Consul nodes that the
+	// gateway pods are registered on if set.
+	ClusterName string
 }
 
 // Binder is used for generating a Snapshot of all operations that should occur both
@@ -146,7 +154,13 @@ func (b *Binder) Snapshot() *Snapshot {
 		b.bindRoute(common.PointerTo(r), boundCounts, snapshot)
 	}
 
-	for _, r := range b.config.TCPRoutes {
+	for _, r := range b.config.GRPCRoutes {
+		b.bindRoute(common.PointerTo(r), boundCounts, snapshot)
+	}
+
+	// the oldest TCPRoute wins when multiple routes attempt to bind to the
+	// same TCP listener
+	for _, r := range sortedTCPRoutes(b.config.TCPRoutes) {
 		b.bindRoute(common.PointerTo(r), boundCounts, snapshot)
 	}
 
@@ -188,7 +202,7 @@ func (b *Binder) Snapshot() *Snapshot {
 			OnUpdate: b.handleGatewaySyncStatus(snapshot, &b.config.Gateway, consulStatus),
 		})
 
-		registrations := registrationsForPods(entry.Namespace, b.config.Gateway, registrationPods)
+		registrations := registrationsForPods(entry.Namespace, b.config.Gateway, registrationPods, b.config.ClusterName)
 		snapshot.Consul.Registrations = registrations
 
 		// deregister any not explicitly registered service
@@ -264,8 +278,7 @@ func secretsForGateway(gateway gwv1beta1.Gateway, resources *common.ResourceMap)
 
 		for _, cert := range listener.TLS.CertificateRefs {
 			if resources.GatewayCanReferenceSecret(gateway, cert) {
-				if common.NilOrEqual(cert.Group, "") && common.NilOrEqual(cert.Kind, common.KindSecret) {
-					key := common.IndexedNamespacedNameWithDefault(cert.Name, cert.Namespace, gateway.Namespace)
+				if key, ok := resources.CertificateSecretKey(cert, gateway.Namespace); ok {
 					set.Add(key)
 				}
 			}
@@ -387,3 +400,21 @@ func supportedKinds(listener gwv1beta1.Listener) []gwv1beta1.RouteGroupKind {
 	}
 	return supportedKindsForProtocol[listener.Protocol]
 }
+
+// sortedTCPRoutes returns a copy of the routes sorted by creation time, using
+// the namespace and name to break ties, so that conflicts are resolved in the
+// same way on every reconciliation.
+func sortedTCPRoutes(routes []gwv1alpha2.TCPRoute) []gwv1alpha2.TCPRoute {
+	sorted := make([]gwv1alpha2.TCPRoute, len(routes))
+	copy(sorted, routes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreationTimestamp.Equal(&sorted[j].CreationTimestamp) {
+			return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+		}
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -2104,6 +2104,280 @@ func TestBinder_BindingRulesKitchenSink(t *testing.T) {
 	}
 }
 
+func TestBinder_TCPRouteConflict(t *testing.T) {
+	t.Parallel()
+
+	gateway := gatewayWithFinalizer(gwv1beta1.GatewaySpec{
+		Listeners: []gwv1beta1.Listener{{
+			Name:     "tcp-listener",
+			Protocol: gwv1beta1.TCPProtocolType,
+		}},
+	})
+	gateway.Namespace = "default"
+	g := *addClassConfig(gateway)
+
+	parentRefs := []gwv1beta1.ParentReference{{Name: "gateway"}}
+	older := testTCPRouteBackends("older", "default", nil, parentRefs)
+	older.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	newer := testTCPRouteBackends("newer", "default", nil, parentRefs)
+	newer.CreationTimestamp = metav1.NewTime(time.Now())
+
+	resources := resourceMapResources{
+		gateways: []gwv1beta1.Gateway{g},
+		// list the newer route first to ensure the binding order doesn't
+		// depend on the order routes are listed in
+		tcpRoutes: []gwv1alpha2.TCPRoute{*newer, *older},
+	}
+
+	binder := NewBinder(controlledBinder(BinderConfig{
+		Gateway:            g,
+		GatewayClassConfig: &v1alpha1.GatewayClassConfig{},
+		Namespaces: map[string]corev1.Namespace{
+			"default": {ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		},
+		Resources: newTestResourceMap(t, resources),
+		TCPRoutes: resources.tcpRoutes,
+	}))
+	actual := binder.Snapshot()
+
+	resolvedRefs := metav1.Condition{
+		Type:    "ResolvedRefs",
+		Status:  metav1.ConditionTrue,
+		Reason:  "ResolvedRefs",
+		Message: "resolved backend references",
+	}
+
+	expectedOlder := testTCPRouteStatusBackends("older", "default", nil, []gwv1beta1.RouteParentStatus{
+		{ControllerName: testControllerName, ParentRef: gwv1beta1.ParentReference{Name: "gateway"}, Conditions: []metav1.Condition{
+			resolvedRefs,
+			{
+				Type:    "Accepted",
+				Status:  metav1.ConditionTrue,
+				Reason:  "Accepted",
+				Message: "route accepted",
+			},
+		}},
+	})
+	expectedOlder.CreationTimestamp = older.CreationTimestamp
+
+	expectedNewer := testTCPRouteStatusBackends("newer", "default", nil, []gwv1beta1.RouteParentStatus{
+		{ControllerName: testControllerName, ParentRef: gwv1beta1.ParentReference{Name: "gateway"}, Conditions: []metav1.Condition{
+			resolvedRefs,
+			{
+				Type:    "Accepted",
+				Status:  metav1.ConditionFalse,
+				Reason:  "NotAllowedByListeners",
+				Message: "tcp-listener: listener already has a TCPRoute bound to it",
+			},
+		}},
+	})
+	expectedNewer.CreationTimestamp = newer.CreationTimestamp
+
+	compareUpdates(t, []client.Object{expectedOlder, expectedNewer}, actual.Kubernetes.StatusUpdates.Operations())
+}
+
+func TestBinder_GRPCRoute(t *testing.T) {
+	t.Parallel()
+
+	gateway := gatewayWithFinalizer(gwv1beta1.GatewaySpec{
+		Listeners: []gwv1beta1.Listener{{
+			Name:     "http-listener",
+			Protocol: gwv1beta1.HTTPProtocolType,
+		}, {
+			Name:     "tcp-listener",
+			Protocol: gwv1beta1.TCPProtocolType,
+		}},
+	})
+	gateway.Namespace = "default"
+	g := *addClassConfig(gateway)
+
+	grpcTypeMeta := metav1.TypeMeta{}
+	grpcTypeMeta.SetGroupVersionKind(gwv1alpha2.SchemeGroupVersion.WithKind("GRPCRoute"))
+	route := gwv1alpha2.GRPCRoute{
+		TypeMeta:   grpcTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default", Finalizers: []string{common.GatewayFinalizer}},
+		Spec: gwv1alpha2.GRPCRouteSpec{
+			CommonRouteSpec: gwv1beta1.CommonRouteSpec{
+				ParentRefs: []gwv1beta1.ParentReference{{Name: "gateway"}},
+			},
+			Rules: []gwv1alpha2.GRPCRouteRule{{
+				Matches: []gwv1alpha2.GRPCRouteMatch{{
+					Method: &gwv1alpha2.GRPCMethodMatch{Service: common.PointerTo("echo.Echo")},
+				}},
+				BackendRefs: []gwv1alpha2.GRPCBackendRef{{
+					BackendRef: gwv1beta1.BackendRef{
+						BackendObjectReference: gwv1beta1.BackendObjectReference{Name: "echo"},
+					},
+				}},
+			}},
+		},
+	}
+
+	resources := resourceMapResources{
+		gateways: []gwv1beta1.Gateway{g},
+		services: []types.NamespacedName{{Name: "echo", Namespace: "default"}},
+	}
+	// an HTTPRoute with the same name is written to a different config entry
+	httpTypeMeta := metav1.TypeMeta{}
+	httpTypeMeta.SetGroupVersionKind(gwv1beta1.SchemeGroupVersion.WithKind("HTTPRoute"))
+	httpRoute := gwv1beta1.HTTPRoute{
+		TypeMeta:   httpTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: "default", Finalizers: []string{common.GatewayFinalizer}},
+		Spec: gwv1beta1.HTTPRouteSpec{
+			CommonRouteSpec: gwv1beta1.CommonRouteSpec{
+				ParentRefs: []gwv1beta1.ParentReference{{Name: "gateway"}},
+			},
+			Rules: []gwv1beta1.HTTPRouteRule{{
+				BackendRefs: []gwv1beta1.HTTPBackendRef{{
+					BackendRef: gwv1beta1.BackendRef{
+						BackendObjectReference: gwv1beta1.BackendObjectReference{Name: "echo"},
+					},
+				}},
+			}},
+		},
+	}
+
+	resourceMap := newTestResourceMap(t, resources)
+	resourceMap.ReferenceCountGRPCRoute(route)
+	resourceMap.ReferenceCountHTTPRoute(httpRoute)
+
+	binder := NewBinder(controlledBinder(BinderConfig{
+		Gateway:            g,
+		GatewayClassConfig: &v1alpha1.GatewayClassConfig{},
+		Namespaces: map[string]corev1.Namespace{
+			"default": {ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		},
+		Resources:  resourceMap,
+		HTTPRoutes: []gwv1beta1.HTTPRoute{httpRoute},
+		GRPCRoutes: []gwv1alpha2.GRPCRoute{route},
+	}))
+	actual := binder.Snapshot()
+
+	expected := route.DeepCopy()
+	expected.Status.Parents = []gwv1beta1.RouteParentStatus{{
+		ControllerName: testControllerName,
+		ParentRef:      gwv1beta1.ParentReference{Name: "gateway"},
+		Conditions: []metav1.Condition{{
+			Type:    "ResolvedRefs",
+			Status:  metav1.ConditionTrue,
+			Reason:  "ResolvedRefs",
+			Message: "resolved backend references",
+		}, {
+			Type:    "Accepted",
+			Status:  metav1.ConditionTrue,
+			Reason:  "Accepted",
+			Message: "route accepted",
+		}},
+	}}
+	expectedHTTPRoute := httpRoute.DeepCopy()
+	expectedHTTPRoute.Status.Parents = expected.Status.Parents
+	compareUpdates(t, []client.Object{expectedHTTPRoute, expected}, actual.Kubernetes.StatusUpdates.Operations())
+
+	// the route is written to Consul as an HTTPRoute bound to the HTTP listener only
+	entries := make(map[string]*api.HTTPRouteConfigEntry)
+	for _, update := range actual.Consul.Updates {
+		if entry, ok := update.Entry.(*api.HTTPRouteConfigEntry); ok {
+			entries[entry.Name] = entry
+		}
+	}
+	require.Len(t, entries, 2)
+	require.Contains(t, entries, "route")
+	grpcEntry := entries["grpcroute_route"]
+	require.NotNil(t, grpcEntry)
+	require.Equal(t, []api.ResourceReference{{
+		Kind:        api.APIGateway,
+		Name:        "gateway",
+		SectionName: "http-listener",
+	}}, grpcEntry.Parents)
+	require.Equal(t, api.HTTPPathMatch{
+		Match: api.HTTPPathMatchPrefix,
+		Value: "/echo.Echo/",
+	}, grpcEntry.Rules[0].Matches[0].Path)
+}
+
+func TestBinder_CertManagerCertificate(t *testing.T) {
+	t.Parallel()
+
+	gateway := gatewayWithFinalizer(gwv1beta1.GatewaySpec{
+		Listeners: []gwv1beta1.Listener{{
+			Name:     "https-listener",
+			Protocol: gwv1beta1.HTTPSProtocolType,
+			TLS: &gwv1beta1.GatewayTLSConfig{
+				CertificateRefs: []gwv1beta1.SecretObjectReference{{
+					Group: common.PointerTo(gwv1beta1.Group(common.CertManagerGroup)),
+					Kind:  common.PointerTo(gwv1beta1.Kind(common.KindCertificate)),
+					Name:  "certificate",
+				}},
+			},
+		}},
+	})
+	gateway.Namespace = "default"
+	g := *addClassConfig(gateway)
+
+	_, secret := generateTestCertificate(t, "default", "certificate-tls")
+
+	for name, tt := range map[string]struct {
+		issued         bool
+		expectedReason string
+	}{
+		"issued": {
+			issued:         true,
+			expectedReason: "ResolvedRefs",
+		},
+		"not yet issued": {
+			expectedReason: "InvalidCertificateRef",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tt := tt
+
+			t.Parallel()
+
+			resourceMap := common.NewResourceMap(common.ResourceTranslator{}, NewReferenceValidator(nil), logrtest.NewTestLogger(t))
+			if tt.issued {
+				resourceMap.ReferenceCountCertManagerCertificate(types.NamespacedName{Name: "certificate", Namespace: "default"}, secret)
+			}
+			resourceMap.ReferenceCountGateway(g)
+
+			binder := NewBinder(controlledBinder(BinderConfig{
+				Gateway:            g,
+				GatewayClassConfig: &v1alpha1.GatewayClassConfig{},
+				Namespaces: map[string]corev1.Namespace{
+					"default": {ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+				},
+				Resources: resourceMap,
+			}))
+			actual := binder.Snapshot()
+
+			var certificates []*api.InlineCertificateConfigEntry
+			for _, update := range actual.Consul.Updates {
+				if entry, ok := update.Entry.(*api.InlineCertificateConfigEntry); ok {
+					certificates = append(certificates, entry)
+				}
+			}
+			if tt.issued {
+				// the certificate is synced to Consul under the name of the issued Secret
+				require.Len(t, certificates, 1)
+				require.Equal(t, "certificate-tls", certificates[0].Name)
+			} else {
+				require.Empty(t, certificates)
+			}
+
+			var reason string
+			for _, update := range actual.Kubernetes.StatusUpdates.Operations() {
+				if updated, ok := update.(*gwv1beta1.Gateway); ok {
+					for _, condition := range updated.Status.Listeners[0].Conditions {
+						if condition.Type == "ResolvedRefs" {
+							reason = condition.Reason
+						}
+					}
+				}
+			}
+			require.Equal(t, tt.expectedReason, reason)
+		})
+	}
+}
+
 func compareUpdates(t *testing.T, expected []client.Object, actual []client.Object) {
 	t.Helper()
 
@@ -2114,6 +2388,9 @@ func compareUpdates(t *testing.T, expected []client.Object, actual []client.Obje
 		if _, ok := o.(*gwv1alpha2.TCPRoute); ok {
 			return false
 		}
+		if _, ok := o.(*gwv1alpha2.GRPCRoute); ok {
+			return false
+		}
 		return true
 	})
 
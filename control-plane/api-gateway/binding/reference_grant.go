@@ -13,6 +13,10 @@ import (
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
 )
 
+// referenceValidator checks cross-namespace references against ReferenceGrants.
+// The GroupKind of the referencing object is fixed per method rather than read
+// from its TypeMeta, since objects read from the API server don't reliably have
+// their TypeMeta populated.
 type referenceValidator struct {
 	grants map[string]map[types.NamespacedName]gwv1beta1.ReferenceGrant
 }
@@ -35,8 +39,8 @@ func NewReferenceValidator(grants []gwv1beta1.ReferenceGrant) common.ReferenceVa
 func (rv *referenceValidator) GatewayCanReferenceSecret(gateway gwv1beta1.Gateway, secretRef gwv1beta1.SecretObjectReference) bool {
 	fromNS := gateway.GetNamespace()
 	fromGK := metav1.GroupKind{
-		Group: gateway.GroupVersionKind().Group,
-		Kind:  gateway.GroupVersionKind().Kind,
+		Group: common.BetaGroup,
+		Kind:  common.KindGateway,
 	}
 
 	// Kind should default to Secret if not set
@@ -49,8 +53,8 @@ func (rv *referenceValidator) GatewayCanReferenceSecret(gateway gwv1beta1.Gatewa
 func (rv *referenceValidator) HTTPRouteCanReferenceBackend(httproute gwv1beta1.HTTPRoute, backendRef gwv1beta1.BackendRef) bool {
 	fromNS := httproute.GetNamespace()
 	fromGK := metav1.GroupKind{
-		Group: httproute.GroupVersionKind().Group,
-		Kind:  httproute.GroupVersionKind().Kind,
+		Group: common.BetaGroup,
+		Kind:  common.KindHTTPRoute,
 	}
 
 	// Kind should default to Service if not set
@@ -63,8 +67,8 @@ func (rv *referenceValidator) HTTPRouteCanReferenceBackend(httproute gwv1beta1.H
 func (rv *referenceValidator) TCPRouteCanReferenceBackend(tcpRoute gwv1alpha2.TCPRoute, backendRef gwv1beta1.BackendRef) bool {
 	fromNS := tcpRoute.GetNamespace()
 	fromGK := metav1.GroupKind{
-		Group: tcpRoute.GroupVersionKind().Group,
-		Kind:  tcpRoute.GroupVersionKind().Kind,
+		Group: gwv1alpha2.GroupName,
+		Kind:  common.KindTCPRoute,
 	}
 
 	// Kind should default to Service if not set
@@ -74,6 +78,20 @@ func (rv *referenceValidator) TCPRouteCanReferenceBackend(tcpRoute gwv1alpha2.TC
 	return rv.referenceAllowed(fromGK, fromNS, toGK, toNS, string(backendRef.Name))
 }
 
+func (rv *referenceValidator) GRPCRouteCanReferenceBackend(grpcRoute gwv1alpha2.GRPCRoute, backendRef gwv1beta1.BackendRef) bool {
+	fromNS := grpcRoute.GetNamespace()
+	fromGK := metav1.GroupKind{
+		Group: gwv1alpha2.GroupName,
+		Kind:  common.KindGRPCRoute,
+	}
+
+	// Kind should default to Service if not set
+	// https://github.com/kubernetes-sigs/gateway-api/blob/v0.6.2/apis/v1beta1/object_reference_types.go#L106
+	toNS, toGK := createValuesFromRef(backendRef.Namespace, backendRef.Group, backendRef.Kind, "", common.KindService)
+
+	return rv.referenceAllowed(fromGK, fromNS, toGK, toNS, string(backendRef.Name))
+}
+
 func createValuesFromRef(ns *gwv1beta1.Namespace, group *gwv1beta1.Group, kind *gwv1beta1.Kind, defaultGroup, defaultKind string) (string, metav1.GroupKind) {
 	toNS := ""
 	if ns != nil {
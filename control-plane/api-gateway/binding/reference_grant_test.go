@@ -23,6 +23,7 @@ const (
 	V1Alpha2         = "/v1alpha2"
 	HTTPRouteKind    = "HTTPRoute"
 	TCPRouteKind     = "TCPRoute"
+	GRPCRouteKind    = "GRPCRoute"
 	GatewayKind      = "Gateway"
 	BackendRefKind   = "Service"
 	SecretKind       = "Secret"
@@ -174,6 +175,45 @@ func TestHTTPRouteCanReferenceBackend(t *testing.T) {
 				basicValidReferenceGrant,
 			},
 		},
+		"httproute without type meta allowed to gateway": {
+			canReference: true,
+			err:          nil,
+			ctx:          context.TODO(),
+			httpRoute: gwv1beta1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: FromNamespace,
+				},
+			},
+			backendRef: gwv1beta1.BackendRef{
+				BackendObjectReference: gwv1beta1.BackendObjectReference{
+					Group:     &backendRefGroup,
+					Kind:      &backendRefKind,
+					Name:      objName,
+					Namespace: &backendRefNamespace,
+				},
+			},
+			k8sReferenceGrants: []gwv1beta1.ReferenceGrant{
+				basicValidReferenceGrant,
+			},
+		},
+		"httproute not allowed without reference grant": {
+			canReference: false,
+			err:          nil,
+			ctx:          context.TODO(),
+			httpRoute: gwv1beta1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: FromNamespace,
+				},
+			},
+			backendRef: gwv1beta1.BackendRef{
+				BackendObjectReference: gwv1beta1.BackendObjectReference{
+					Group:     &backendRefGroup,
+					Kind:      &backendRefKind,
+					Name:      objName,
+					Namespace: &backendRefNamespace,
+				},
+			},
+		},
 	}
 
 	for name, tc := range cases {
@@ -267,6 +307,87 @@ func TestTCPRouteCanReferenceBackend(t *testing.T) {
 	}
 }
 
+func TestGRPCRouteCanReferenceBackend(t *testing.T) {
+	t.Parallel()
+
+	objName := gwv1beta1.ObjectName("myBackendRef")
+
+	basicValidReferenceGrant := gwv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ToNamespace,
+		},
+		Spec: gwv1beta1.ReferenceGrantSpec{
+			From: []gwv1beta1.ReferenceGrantFrom{
+				{
+					Group:     Group,
+					Kind:      GRPCRouteKind,
+					Namespace: FromNamespace,
+				},
+			},
+			To: []gwv1beta1.ReferenceGrantTo{
+				{
+					Kind: BackendRefKind,
+					Name: &objName,
+				},
+			},
+		},
+	}
+
+	backendRefNamespace := gwv1beta1.Namespace(ToNamespace)
+
+	grpcRoute := gwv1alpha2.GRPCRoute{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       GRPCRouteKind,
+			APIVersion: Group + V1Alpha2,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: FromNamespace,
+		},
+	}
+
+	backendRef := gwv1beta1.BackendRef{
+		BackendObjectReference: gwv1beta1.BackendObjectReference{
+			Name:      objName,
+			Namespace: &backendRefNamespace,
+		},
+	}
+
+	cases := map[string]struct {
+		canReference       bool
+		backendRef         gwv1beta1.BackendRef
+		k8sReferenceGrants []gwv1beta1.ReferenceGrant
+	}{
+		"grpcRoute allowed to backend": {
+			canReference: true,
+			backendRef:   backendRef,
+			k8sReferenceGrants: []gwv1beta1.ReferenceGrant{
+				basicValidReferenceGrant,
+			},
+		},
+		"grpcRoute not allowed to backend without reference grant": {
+			canReference: false,
+			backendRef:   backendRef,
+		},
+		"grpcRoute allowed to backend in the same namespace": {
+			canReference: true,
+			backendRef: gwv1beta1.BackendRef{
+				BackendObjectReference: gwv1beta1.BackendObjectReference{
+					Name: objName,
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			rv := NewReferenceValidator(tc.k8sReferenceGrants)
+			canReference := rv.GRPCRouteCanReferenceBackend(grpcRoute, tc.backendRef)
+
+			require.Equal(t, tc.canReference, canReference)
+		})
+	}
+}
+
 func TestReferenceAllowed(t *testing.T) {
 	t.Parallel()
 
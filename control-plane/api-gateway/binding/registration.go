@@ -24,22 +24,22 @@ const (
 	consulKubernetesCheckName = "Kubernetes Readiness Check"
 )
 
-func registrationsForPods(namespace string, gateway gwv1beta1.Gateway, pods []corev1.Pod) []api.CatalogRegistration {
+func registrationsForPods(namespace string, gateway gwv1beta1.Gateway, pods []corev1.Pod, clusterName string) []api.CatalogRegistration {
 	registrations := []api.CatalogRegistration{}
 	for _, pod := range pods {
-		registrations = append(registrations, registrationForPod(namespace, gateway, pod))
+		registrations = append(registrations, registrationForPod(namespace, gateway, pod, clusterName))
 	}
 	return registrations
 }
 
-func registrationForPod(namespace string, gateway gwv1beta1.Gateway, pod corev1.Pod) api.CatalogRegistration {
+func registrationForPod(namespace string, gateway gwv1beta1.Gateway, pod corev1.Pod, clusterName string) api.CatalogRegistration {
 	healthStatus := api.HealthCritical
 	if isPodReady(pod) {
 		healthStatus = api.HealthPassing
 	}
 
 	return api.CatalogRegistration{
-		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName),
+		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName, clusterName),
 		Address: pod.Status.HostIP,
 		NodeMeta: map[string]string{
 			metaKeySyntheticNode: "true",
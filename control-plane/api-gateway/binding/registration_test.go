@@ -68,7 +68,7 @@ func TestRegistrationsForPods_Health(t *testing.T) {
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
-			registrations := registrationsForPods(tt.consulNamespace, tt.gateway, tt.pods)
+			registrations := registrationsForPods(tt.consulNamespace, tt.gateway, tt.pods, "")
 			require.Len(t, registrations, len(tt.expected))
 
 			for i := range registrations {
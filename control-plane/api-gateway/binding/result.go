@@ -31,6 +31,7 @@ var (
 	// then we distinguish those two usages with errRoute*_Usage.
 	errRouteNotAllowedByListeners_Namespace = errors.New("listener does not allow binding routes from the given namespace")
 	errRouteNotAllowedByListeners_Protocol  = errors.New("listener does not support route protocol")
+	errRouteNotAllowedByListeners_TCPRoute  = errors.New("listener already has a TCPRoute bound to it")
 	errRouteNoMatchingListenerHostname      = errors.New("listener cannot bind route with a non-aligned hostname")
 	errRouteInvalidKind                     = errors.New("invalid backend kind")
 	errRouteBackendNotFound                 = errors.New("backend not found")
@@ -19,7 +19,7 @@ import (
 func (r *Binder) bindRoute(route client.Object, boundCount map[gwv1beta1.SectionName]int, snapshot *Snapshot) {
 	// use the non-normalized key since we can't write back enterprise metadata
 	// on non-enterprise installations
-	routeConsulKey := r.config.Translator.NonNormalizedConfigEntryReference(entryKind(route), client.ObjectKeyFromObject(route))
+	routeConsulKey := r.config.Translator.NonNormalizedConfigEntryReference(entryKind(route), entryKey(route))
 	filteredParents := filterParentRefs(r.key, route.GetNamespace(), getRouteParents(route))
 	filteredParentStatuses := filterParentRefs(r.key, route.GetNamespace(),
 		common.ConvertSliceFunc(getRouteParentsStatus(route), func(parentStatus gwv1beta1.RouteParentStatus) gwv1beta1.ParentReference {
@@ -53,7 +53,7 @@ func (r *Binder) bindRoute(route client.Object, boundCount map[gwv1beta1.Section
 	}
 
 	if r.isGatewayDeleted() {
-		if canGCOnUnbind(routeConsulKey, r.config.Resources) && common.RemoveFinalizer(route) {
+		if canGCOnUnbind(route, routeConsulKey, r.config.Resources) && common.RemoveFinalizer(route) {
 			kubernetesNeedsUpdate = true
 		} else {
 			// Remove the condition since we no longer know if we should
@@ -137,6 +137,17 @@ func (r *Binder) bindRoute(route client.Object, boundCount map[gwv1beta1.Section
 				continue
 			}
 
+			// TCP listeners proxy all of their connections to a single route
+			// since there is nothing to match on, so only the first TCPRoute
+			// binds to them.
+			if listener.Protocol == gwv1beta1.TCPProtocolType && boundCount[listener.Name] > 0 {
+				result = append(result, bindResult{
+					section: listener.Name,
+					err:     errRouteNotAllowedByListeners_TCPRoute,
+				})
+				continue
+			}
+
 			result = append(result, bindResult{
 				section: listener.Name,
 			})
@@ -215,14 +226,18 @@ func consulParentMatches(namespace string, gatewayKey api.ResourceReference, par
 }
 
 func (r *Binder) dropConsulRouteParent(snapshot *Snapshot, object client.Object, gateway api.ResourceReference, resources *common.ResourceMap) {
+	dropHTTPRouteParent := func(entry api.HTTPRouteConfigEntry) api.HTTPRouteConfigEntry {
+		entry.Parents = common.Filter(entry.Parents, func(parent api.ResourceReference) bool {
+			return consulParentMatches(entry.Namespace, gateway, parent)
+		})
+		return entry
+	}
+
 	switch object.(type) {
 	case *gwv1beta1.HTTPRoute:
-		resources.MutateHTTPRoute(client.ObjectKeyFromObject(object), r.handleRouteSyncStatus(snapshot, object), func(entry api.HTTPRouteConfigEntry) api.HTTPRouteConfigEntry {
-			entry.Parents = common.Filter(entry.Parents, func(parent api.ResourceReference) bool {
-				return consulParentMatches(entry.Namespace, gateway, parent)
-			})
-			return entry
-		})
+		resources.MutateHTTPRoute(client.ObjectKeyFromObject(object), r.handleRouteSyncStatus(snapshot, object), dropHTTPRouteParent)
+	case *gwv1alpha2.GRPCRoute:
+		resources.MutateGRPCRoute(client.ObjectKeyFromObject(object), r.handleRouteSyncStatus(snapshot, object), dropHTTPRouteParent)
 	case *gwv1alpha2.TCPRoute:
 		resources.MutateTCPRoute(client.ObjectKeyFromObject(object), r.handleRouteSyncStatus(snapshot, object), func(entry api.TCPRouteConfigEntry) api.TCPRouteConfigEntry {
 			entry.Parents = common.Filter(entry.Parents, func(parent api.ResourceReference) bool {
@@ -257,30 +272,35 @@ func (r *Binder) mutateRouteWithBindingResults(snapshot *Snapshot, object client
 		normalized[common.NormalizeMeta(ref)] = ref
 	}
 
-	switch object.(type) {
-	case *gwv1beta1.HTTPRoute:
-		resources.TranslateAndMutateHTTPRoute(key, r.handleRouteSyncStatus(snapshot, object), func(old *api.HTTPRouteConfigEntry, new api.HTTPRouteConfigEntry) api.HTTPRouteConfigEntry {
-			if old != nil {
-				for _, parent := range old.Parents {
-					// drop any references that already exist
-					if parents.Contains(parent) {
-						parents.Remove(parent)
-					}
-					if id, ok := normalized[parent]; ok {
-						parents.Remove(id)
-					}
+	// GRPCRoutes are translated into Consul HTTPRoutes, so both are mutated the same way
+	mutateHTTPRoute := func(old *api.HTTPRouteConfigEntry, new api.HTTPRouteConfigEntry) api.HTTPRouteConfigEntry {
+		if old != nil {
+			for _, parent := range old.Parents {
+				// drop any references that already exist
+				if parents.Contains(parent) {
+					parents.Remove(parent)
+				}
+				if id, ok := normalized[parent]; ok {
+					parents.Remove(id)
 				}
-
-				// set the old parent states
-				new.Parents = old.Parents
-				new.Status = old.Status
-			}
-			// and now add what is left
-			for parent := range parents.Iter() {
-				new.Parents = append(new.Parents, parent.(api.ResourceReference))
 			}
-			return new
-		})
+
+			// set the old parent states
+			new.Parents = old.Parents
+			new.Status = old.Status
+		}
+		// and now add what is left
+		for parent := range parents.Iter() {
+			new.Parents = append(new.Parents, parent.(api.ResourceReference))
+		}
+		return new
+	}
+
+	switch object.(type) {
+	case *gwv1beta1.HTTPRoute:
+		resources.TranslateAndMutateHTTPRoute(key, r.handleRouteSyncStatus(snapshot, object), mutateHTTPRoute)
+	case *gwv1alpha2.GRPCRoute:
+		resources.TranslateAndMutateGRPCRoute(key, r.handleRouteSyncStatus(snapshot, object), mutateHTTPRoute)
 	case *gwv1alpha2.TCPRoute:
 		resources.TranslateAndMutateTCPRoute(key, r.handleRouteSyncStatus(snapshot, object), func(old *api.TCPRouteConfigEntry, new api.TCPRouteConfigEntry) api.TCPRouteConfigEntry {
 			if old != nil {
@@ -306,7 +326,8 @@ func (r *Binder) mutateRouteWithBindingResults(snapshot *Snapshot, object client
 
 func entryKind(object client.Object) string {
 	switch object.(type) {
-	case *gwv1beta1.HTTPRoute:
+	case *gwv1beta1.HTTPRoute, *gwv1alpha2.GRPCRoute:
+		// GRPCRoutes are written to Consul as HTTPRoutes
 		return api.HTTPRoute
 	case *gwv1alpha2.TCPRoute:
 		return api.TCPRoute
@@ -314,11 +335,22 @@ func entryKind(object client.Object) string {
 	return ""
 }
 
-func canGCOnUnbind(id api.ResourceReference, resources *common.ResourceMap) bool {
-	switch id.Kind {
-	case api.HTTPRoute:
+// entryKey returns the key of the Consul config entry of a route.
+func entryKey(object client.Object) types.NamespacedName {
+	key := client.ObjectKeyFromObject(object)
+	if _, ok := object.(*gwv1alpha2.GRPCRoute); ok {
+		key.Name = common.GRPCRouteConsulName(key.Name)
+	}
+	return key
+}
+
+func canGCOnUnbind(object client.Object, id api.ResourceReference, resources *common.ResourceMap) bool {
+	switch object.(type) {
+	case *gwv1beta1.HTTPRoute:
 		return resources.CanGCHTTPRouteOnUnbind(id)
-	case api.TCPRoute:
+	case *gwv1alpha2.GRPCRoute:
+		return resources.CanGCGRPCRouteOnUnbind(id)
+	case *gwv1alpha2.TCPRoute:
 		return resources.CanGCTCPRouteOnUnbind(id)
 	}
 	return true
@@ -328,6 +360,8 @@ func getRouteHostnames(object client.Object) []gwv1beta1.Hostname {
 	switch v := object.(type) {
 	case *gwv1beta1.HTTPRoute:
 		return v.Spec.Hostnames
+	case *gwv1alpha2.GRPCRoute:
+		return v.Spec.Hostnames
 	}
 	return nil
 }
@@ -338,6 +372,8 @@ func getRouteParents(object client.Object) []gwv1beta1.ParentReference {
 		return v.Spec.ParentRefs
 	case *gwv1alpha2.TCPRoute:
 		return v.Spec.ParentRefs
+	case *gwv1alpha2.GRPCRoute:
+		return v.Spec.ParentRefs
 	}
 	return nil
 }
@@ -348,6 +384,8 @@ func getRouteParentsStatus(object client.Object) []gwv1beta1.RouteParentStatus {
 		return v.Status.RouteStatus.Parents
 	case *gwv1alpha2.TCPRoute:
 		return v.Status.RouteStatus.Parents
+	case *gwv1alpha2.GRPCRoute:
+		return v.Status.RouteStatus.Parents
 	}
 	return nil
 }
@@ -358,6 +396,8 @@ func setRouteParentsStatus(object client.Object, parents []gwv1beta1.RouteParent
 		v.Status.RouteStatus.Parents = parents
 	case *gwv1alpha2.TCPRoute:
 		v.Status.RouteStatus.Parents = parents
+	case *gwv1alpha2.GRPCRoute:
+		v.Status.RouteStatus.Parents = parents
 	}
 }
 
@@ -373,6 +413,12 @@ func getRouteBackends(object client.Object) []gwv1beta1.BackendRef {
 		return common.Flatten(common.ConvertSliceFunc(v.Spec.Rules, func(rule gwv1alpha2.TCPRouteRule) []gwv1beta1.BackendRef {
 			return rule.BackendRefs
 		}))
+	case *gwv1alpha2.GRPCRoute:
+		return common.Flatten(common.ConvertSliceFunc(v.Spec.Rules, func(rule gwv1alpha2.GRPCRouteRule) []gwv1beta1.BackendRef {
+			return common.ConvertSliceFunc(rule.BackendRefs, func(rule gwv1alpha2.GRPCBackendRef) gwv1beta1.BackendRef {
+				return rule.BackendRef
+			})
+		}))
 	}
 	return nil
 }
@@ -383,6 +429,8 @@ func canReferenceBackend(object client.Object, ref gwv1beta1.BackendRef, resourc
 		return resources.HTTPRouteCanReferenceBackend(*v, ref)
 	case *gwv1alpha2.TCPRoute:
 		return resources.TCPRouteCanReferenceBackend(*v, ref)
+	case *gwv1alpha2.GRPCRoute:
+		return resources.GRPCRouteCanReferenceBackend(*v, ref)
 	}
 	return false
 }
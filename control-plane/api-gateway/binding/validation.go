@@ -25,10 +25,16 @@ var (
 		gwv1beta1.HTTPProtocolType: {{
 			Group: (*gwv1beta1.Group)(&gwv1beta1.GroupVersion.Group),
 			Kind:  "HTTPRoute",
+		}, {
+			Group: (*gwv1alpha2.Group)(&gwv1alpha2.GroupVersion.Group),
+			Kind:  "GRPCRoute",
 		}},
 		gwv1beta1.HTTPSProtocolType: {{
 			Group: (*gwv1beta1.Group)(&gwv1beta1.GroupVersion.Group),
 			Kind:  "HTTPRoute",
+		}, {
+			Group: (*gwv1alpha2.Group)(&gwv1alpha2.GroupVersion.Group),
+			Kind:  "GRPCRoute",
 		}},
 		gwv1beta1.TCPProtocolType: {{
 			Group: (*gwv1alpha2.Group)(&gwv1alpha2.GroupVersion.Group),
@@ -38,6 +44,7 @@ var (
 	allSupportedRouteKinds = map[gwv1beta1.Kind]struct{}{
 		gwv1beta1.Kind("HTTPRoute"): {},
 		gwv1beta1.Kind("TCPRoute"):  {},
+		gwv1beta1.Kind("GRPCRoute"): {},
 	}
 )
 
@@ -175,7 +182,7 @@ func validateTLS(gateway gwv1beta1.Gateway, tls *gwv1beta1.GatewayTLSConfig, res
 
 	for _, cert := range tls.CertificateRefs {
 		// break on the first error
-		if !common.NilOrEqual(cert.Group, "") || !common.NilOrEqual(cert.Kind, common.KindSecret) {
+		if !common.IsSecretCertificateRef(cert) && !common.IsCertManagerCertificateRef(cert) {
 			err = errListenerInvalidCertificateRef_NotSupported
 			break
 		}
@@ -185,8 +192,12 @@ func validateTLS(gateway gwv1beta1.Gateway, tls *gwv1beta1.GatewayTLSConfig, res
 			break
 		}
 
-		key := common.IndexedNamespacedNameWithDefault(cert.Name, cert.Namespace, namespace)
-		secret := resources.Certificate(key)
+		// cert-manager Certificates resolve to the Secret issued for them, which
+		// may not exist yet if the Certificate hasn't been issued
+		var secret *corev1.Secret
+		if key, ok := resources.CertificateSecretKey(cert, namespace); ok {
+			secret = resources.Certificate(key)
+		}
 
 		if secret == nil {
 			err = errListenerInvalidCertificateRef_NotFound
@@ -17,6 +17,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
@@ -64,6 +65,8 @@ type Config struct {
 	Datacenter              string
 	CrossNamespaceACLPolicy string
 	Logger                  logr.Logger
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
 }
 
 // Cache subscribes to and caches Consul objects, it also responsible for mainting subscriptions to
@@ -72,6 +75,7 @@ type Cache struct {
 	config    *consul.Config
 	serverMgr consul.ServerConnectionManager
 	logger    logr.Logger
+	auditor   *audit.Auditor
 
 	cache      map[string]*common.ReferenceMap
 	cacheMutex *sync.Mutex
@@ -107,6 +111,7 @@ func New(config Config) *Cache {
 		kinds:                   Kinds,
 		synced:                  make(chan struct{}, len(Kinds)),
 		logger:                  config.Logger,
+		auditor:                 config.Auditor,
 		crossNamespaceACLPolicy: config.CrossNamespaceACLPolicy,
 		datacenter:              config.Datacenter,
 	}
@@ -314,7 +319,15 @@ func (c *Cache) Write(ctx context.Context, entry api.ConfigEntry) error {
 	}
 
 	if c.namespacesEnabled {
-		if _, err := namespaces.EnsureExists(client, entry.GetNamespace(), c.crossNamespaceACLPolicy); err != nil {
+		created, err := namespaces.EnsureExists(client, entry.GetNamespace(), c.crossNamespaceACLPolicy)
+		if created || err != nil {
+			c.recordWrite(audit.Event{
+				Operation: audit.OperationCreate,
+				Resource:  audit.ResourceNamespace,
+				Name:      entry.GetNamespace(),
+			}, err)
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -322,6 +335,19 @@ func (c *Cache) Write(ctx context.Context, entry api.ConfigEntry) error {
 	options := &api.WriteOptions{}
 
 	_, _, err = client.ConfigEntries().Set(entry, options.WithContext(ctx))
+	op := audit.OperationUpdate
+	if old == nil {
+		op = audit.OperationCreate
+	}
+	c.recordWrite(audit.Event{
+		Operation: op,
+		Resource:  audit.ResourceConfigEntry,
+		Name:      fmt.Sprintf("%s/%s", entry.GetKind(), entry.GetName()),
+		Namespace: entry.GetNamespace(),
+		Partition: entry.GetPartition(),
+		Before:    old,
+		After:     entry,
+	}, err)
 	if err != nil {
 		return err
 	}
@@ -341,6 +367,15 @@ func (c *Cache) ensurePolicy(client *api.Client) (string, error) {
 		}
 		return existing.ID, nil
 	}
+	// Without ACLs there is nothing to write, so that failure is not recorded.
+	if err == nil || ignoreACLsDisabled(err) != nil {
+		c.recordWrite(audit.Event{
+			Operation: audit.OperationCreate,
+			Resource:  audit.ResourceACLPolicy,
+			Name:      policy.Name,
+			After:     policy,
+		}, err)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -370,6 +405,12 @@ func (c *Cache) ensureRole(client *api.Client) (string, error) {
 	}
 
 	_, _, err = client.ACL().RoleCreate(role, &api.WriteOptions{})
+	c.recordWrite(audit.Event{
+		Operation: audit.OperationCreate,
+		Resource:  audit.ResourceACLRole,
+		Name:      aclRoleName,
+		After:     role,
+	}, err)
 	return aclRoleName, err
 }
 
@@ -414,7 +455,8 @@ func (c *Cache) Delete(ctx context.Context, ref api.ResourceReference) error {
 		return nil
 	}
 
-	if entryMap.Get(ref) == nil {
+	old := entryMap.Get(ref)
+	if old == nil {
 		c.logger.Info("cached object not found, not deleting")
 		return nil
 	}
@@ -427,6 +469,14 @@ func (c *Cache) Delete(ctx context.Context, ref api.ResourceReference) error {
 	options := &api.WriteOptions{}
 
 	_, err = client.ConfigEntries().Delete(ref.Kind, ref.Name, options.WithContext(ctx))
+	c.recordWrite(audit.Event{
+		Operation: audit.OperationDelete,
+		Resource:  audit.ResourceConfigEntry,
+		Name:      fmt.Sprintf("%s/%s", ref.Kind, ref.Name),
+		Namespace: ref.Namespace,
+		Partition: ref.Partition,
+		Before:    old,
+	}, err)
 	return err
 }
 
@@ -467,17 +517,29 @@ func (c *Cache) EnsureRoleBinding(authMethod, service, namespace string) error {
 		return err
 	}
 
+	var existing *api.ACLBindingRule
 	for _, existingRule := range existingRules {
 		if existingRule.BindName == bindingRule.BindName && existingRule.Description == bindingRule.Description {
 			bindingRule.ID = existingRule.ID
+			existing = existingRule
 		}
 	}
 
+	event := audit.Event{
+		Resource: audit.ResourceACLBindingRule,
+		Name:     bindingRule.Description,
+		After:    bindingRule,
+	}
 	if bindingRule.ID == "" {
 		_, _, err := client.ACL().BindingRuleCreate(bindingRule, &api.WriteOptions{})
+		event.Operation = audit.OperationCreate
+		c.recordWrite(event, err)
 		return err
 	}
 	_, _, err = client.ACL().BindingRuleUpdate(bindingRule, &api.WriteOptions{})
+	event.Operation = audit.OperationUpdate
+	event.Before = existing
+	c.recordWrite(event, err)
 	return err
 }
 
@@ -491,6 +553,19 @@ func (c *Cache) Register(ctx context.Context, registration api.CatalogRegistrati
 	options := &api.WriteOptions{}
 
 	_, err = client.Catalog().Register(&registration, options.WithContext(ctx))
+	event := audit.Event{
+		Operation: audit.OperationUpsert,
+		Resource:  audit.ResourceCatalogNode,
+		Name:      registration.Node,
+		Partition: registration.Partition,
+		After:     registration,
+	}
+	if registration.Service != nil {
+		event.Resource = audit.ResourceCatalogService
+		event.Name = fmt.Sprintf("%s/%s", registration.Node, registration.Service.ID)
+		event.Namespace = registration.Service.Namespace
+	}
+	c.recordWrite(event, err)
 	return err
 }
 
@@ -504,9 +579,27 @@ func (c *Cache) Deregister(ctx context.Context, deregistration api.CatalogDeregi
 	options := &api.WriteOptions{}
 
 	_, err = client.Catalog().Deregister(&deregistration, options.WithContext(ctx))
+	event := audit.Event{
+		Operation: audit.OperationDelete,
+		Resource:  audit.ResourceCatalogNode,
+		Name:      deregistration.Node,
+		Namespace: deregistration.Namespace,
+		Partition: deregistration.Partition,
+	}
+	if deregistration.ServiceID != "" {
+		event.Resource = audit.ResourceCatalogService
+		event.Name = fmt.Sprintf("%s/%s", deregistration.Node, deregistration.ServiceID)
+	}
+	c.recordWrite(event, err)
 	return err
 }
 
+// recordWrite records a write to Consul made by the API gateway controllers in the audit log.
+func (c *Cache) recordWrite(event audit.Event, err error) {
+	event.Actor = "controller/api-gateway"
+	c.auditor.Record(event, err)
+}
+
 func ignoreACLsDisabled(err error) error {
 	if err == nil {
 		return nil
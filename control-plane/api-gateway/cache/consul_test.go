@@ -22,6 +22,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
@@ -1315,6 +1316,7 @@ func TestCache_Write(t *testing.T) {
 			port, err := strconv.Atoi(serverURL.Port())
 			require.NoError(t, err)
 
+			sink := &eventSink{}
 			c := New(Config{
 				ConsulClientConfig: &consul.Config{
 					APIClientConfig: &api.Config{},
@@ -1325,6 +1327,7 @@ func TestCache_Write(t *testing.T) {
 				ConsulServerConnMgr: test.MockConnMgrForIPAndPort(serverURL.Hostname(), port),
 				NamespacesEnabled:   false,
 				Logger:              logrtest.NewTestLogger(t),
+				Auditor:             audit.New(logrtest.NewTestLogger(t), sink),
 			})
 
 			entry := &api.HTTPRouteConfigEntry{
@@ -1413,6 +1416,12 @@ func TestCache_Write(t *testing.T) {
 
 			err = c.Write(context.Background(), entry)
 			require.Equal(t, err, tt.expectedErr)
+
+			require.Len(t, sink.events, 1)
+			require.Equal(t, "controller/api-gateway", sink.events[0].Actor)
+			require.Equal(t, audit.OperationCreate, sink.events[0].Operation)
+			require.Equal(t, audit.ResourceConfigEntry, sink.events[0].Resource)
+			require.Equal(t, "http-route/my route", sink.events[0].Name)
 		})
 	}
 }
@@ -2012,6 +2021,16 @@ func TestCache_Delete(t *testing.T) {
 	}
 }
 
+// eventSink keeps the audit events in memory.
+type eventSink struct {
+	events []audit.Event
+}
+
+func (s *eventSink) Write(event audit.Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
 func loadedReferenceMaps(entries []api.ConfigEntry) map[string]*common.ReferenceMap {
 	refs := make(map[string]*common.ReferenceMap)
 
@@ -7,4 +7,8 @@ const (
 	GatewayClassControllerName = "consul.hashicorp.com/gateway-controller"
 
 	AnnotationGatewayClassConfig = "consul.hashicorp.com/gateway-class-config"
+
+	// AnnotationCertManagerCertificateName is added by cert-manager to every Secret
+	// it issues and holds the name of the Certificate that the Secret belongs to.
+	AnnotationCertManagerCertificateName = "cert-manager.io/certificate-name"
 )
@@ -18,10 +18,15 @@ const (
 
 var (
 	// constants extracted for ease of use.
-	KindGateway = "Gateway"
-	KindSecret  = "Secret"
-	KindService = "Service"
-	BetaGroup   = gwv1beta1.GroupVersion.Group
+	KindGateway      = "Gateway"
+	KindSecret       = "Secret"
+	KindService      = "Service"
+	KindCertificate  = "Certificate"
+	KindHTTPRoute    = "HTTPRoute"
+	KindTCPRoute     = "TCPRoute"
+	KindGRPCRoute    = "GRPCRoute"
+	BetaGroup        = gwv1beta1.GroupVersion.Group
+	CertManagerGroup = "cert-manager.io"
 )
 
 // EnsureFinalizer ensures that our finalizer is set on an object
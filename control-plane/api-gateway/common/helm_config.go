@@ -30,6 +30,17 @@ type HelmConfig struct {
 	ConsulTLSServerName string
 	ConsulCACert        string
 	ConsulConfig        ConsulConfig
+	// ListenerTLSMinVersion is the minimum TLS version of the gateway
+	// listeners with TLS, e.g. "TLSv1_2". Consul's default is used if empty.
+	ListenerTLSMinVersion string
+	// ListenerTLSCipherSuites are the TLS 1.2 cipher suites of the gateway
+	// listeners with TLS. Consul's defaults are used if empty.
+	ListenerTLSCipherSuites []string
+	// ClusterName distinguishes this Kubernetes cluster from the other
+	// Kubernetes clusters that are registered with the same Consul datacenter.
+	// It is added to the names of the virtual nodes that gateways are
+	// registered on.
+	ClusterName string
 }
 
 type ConsulConfig struct {
@@ -64,6 +64,7 @@ type ReferenceValidator interface {
 	GatewayCanReferenceSecret(gateway gwv1beta1.Gateway, secretRef gwv1beta1.SecretObjectReference) bool
 	HTTPRouteCanReferenceBackend(httproute gwv1beta1.HTTPRoute, backendRef gwv1beta1.BackendRef) bool
 	TCPRouteCanReferenceBackend(tcpRoute gwv1alpha2.TCPRoute, backendRef gwv1beta1.BackendRef) bool
+	GRPCRouteCanReferenceBackend(grpcRoute gwv1alpha2.GRPCRoute, backendRef gwv1beta1.BackendRef) bool
 }
 
 type certificate struct {
@@ -81,6 +82,11 @@ type tcpRoute struct {
 	gateways mapset.Set
 }
 
+type grpcRoute struct {
+	route    gwv1alpha2.GRPCRoute
+	gateways mapset.Set
+}
+
 type consulHTTPRoute struct {
 	route    api.HTTPRouteConfigEntry
 	gateways mapset.Set
@@ -94,6 +100,7 @@ type consulTCPRoute struct {
 type resourceSet struct {
 	httpRoutes   mapset.Set
 	tcpRoutes    mapset.Set
+	grpcRoutes   mapset.Set
 	certificates mapset.Set
 
 	consulObjects *ReferenceSet
@@ -108,12 +115,16 @@ type ResourceMap struct {
 	meshServices map[types.NamespacedName]api.ResourceReference
 	certificates mapset.Set
 
+	// maps cert-manager Certificates to the Secrets issued for them
+	certManagerCertificates map[types.NamespacedName]types.NamespacedName
+
 	// this acts a a secondary store of what has not yet
 	// been processed for the sake of garbage collection.
 	processedCertificates mapset.Set
 	certificateGateways   map[api.ResourceReference]*certificate
 	tcpRouteGateways      map[api.ResourceReference]*tcpRoute
 	httpRouteGateways     map[api.ResourceReference]*httpRoute
+	grpcRouteGateways     map[api.ResourceReference]*grpcRoute
 	gatewayResources      map[api.ResourceReference]*resourceSet
 
 	// consul resources for a gateway
@@ -126,19 +137,21 @@ type ResourceMap struct {
 
 func NewResourceMap(translator ResourceTranslator, validator ReferenceValidator, logger logr.Logger) *ResourceMap {
 	return &ResourceMap{
-		translator:            translator,
-		referenceValidator:    validator,
-		logger:                logger,
-		processedCertificates: mapset.NewSet(),
-		services:              make(map[types.NamespacedName]api.ResourceReference),
-		meshServices:          make(map[types.NamespacedName]api.ResourceReference),
-		certificates:          mapset.NewSet(),
-		consulTCPRoutes:       make(map[api.ResourceReference]*consulTCPRoute),
-		consulHTTPRoutes:      make(map[api.ResourceReference]*consulHTTPRoute),
-		certificateGateways:   make(map[api.ResourceReference]*certificate),
-		tcpRouteGateways:      make(map[api.ResourceReference]*tcpRoute),
-		httpRouteGateways:     make(map[api.ResourceReference]*httpRoute),
-		gatewayResources:      make(map[api.ResourceReference]*resourceSet),
+		translator:              translator,
+		referenceValidator:      validator,
+		logger:                  logger,
+		processedCertificates:   mapset.NewSet(),
+		services:                make(map[types.NamespacedName]api.ResourceReference),
+		meshServices:            make(map[types.NamespacedName]api.ResourceReference),
+		certificates:            mapset.NewSet(),
+		certManagerCertificates: make(map[types.NamespacedName]types.NamespacedName),
+		consulTCPRoutes:         make(map[api.ResourceReference]*consulTCPRoute),
+		consulHTTPRoutes:        make(map[api.ResourceReference]*consulHTTPRoute),
+		certificateGateways:     make(map[api.ResourceReference]*certificate),
+		tcpRouteGateways:        make(map[api.ResourceReference]*tcpRoute),
+		httpRouteGateways:       make(map[api.ResourceReference]*httpRoute),
+		grpcRouteGateways:       make(map[api.ResourceReference]*grpcRoute),
+		gatewayResources:        make(map[api.ResourceReference]*resourceSet),
 	}
 }
 
@@ -204,6 +217,30 @@ func (s *ResourceMap) ReferenceCountCertificate(secret corev1.Secret) {
 	}
 }
 
+// ReferenceCountCertManagerCertificate reference counts the Secret that cert-manager
+// issued for the given Certificate so that listeners referencing the Certificate
+// resolve to it.
+func (s *ResourceMap) ReferenceCountCertManagerCertificate(key types.NamespacedName, secret corev1.Secret) {
+	s.certManagerCertificates[key] = client.ObjectKeyFromObject(&secret)
+	s.ReferenceCountCertificate(secret)
+}
+
+// CertificateSecretKey returns the key of the Secret holding the TLS material for
+// a listener certificate reference, and whether the reference could be resolved.
+func (s *ResourceMap) CertificateSecretKey(ref gwv1beta1.SecretObjectReference, namespace string) (types.NamespacedName, bool) {
+	key := IndexedNamespacedNameWithDefault(ref.Name, ref.Namespace, namespace)
+
+	switch {
+	case IsSecretCertificateRef(ref):
+		return key, true
+	case IsCertManagerCertificateRef(ref):
+		secretKey, ok := s.certManagerCertificates[key]
+		return secretKey, ok
+	}
+
+	return types.NamespacedName{}, false
+}
+
 func (s *ResourceMap) ReferenceCountGateway(gateway gwv1beta1.Gateway) {
 	key := client.ObjectKeyFromObject(&gateway)
 	consulKey := NormalizeMeta(s.toConsulReference(api.APIGateway, key))
@@ -211,6 +248,7 @@ func (s *ResourceMap) ReferenceCountGateway(gateway gwv1beta1.Gateway) {
 	set := &resourceSet{
 		httpRoutes:    mapset.NewSet(),
 		tcpRoutes:     mapset.NewSet(),
+		grpcRoutes:    mapset.NewSet(),
 		certificates:  mapset.NewSet(),
 		consulObjects: NewReferenceSet(),
 	}
@@ -220,9 +258,7 @@ func (s *ResourceMap) ReferenceCountGateway(gateway gwv1beta1.Gateway) {
 			continue
 		}
 		for _, cert := range listener.TLS.CertificateRefs {
-			if NilOrEqual(cert.Group, "") && NilOrEqual(cert.Kind, "Secret") {
-				certificateKey := IndexedNamespacedNameWithDefault(cert.Name, cert.Namespace, gateway.Namespace)
-
+			if certificateKey, ok := s.CertificateSecretKey(cert, gateway.Namespace); ok {
 				set.certificates.Add(certificateKey)
 
 				consulCertificateKey := s.toConsulReference(api.InlineCertificate, certificateKey)
@@ -383,6 +419,26 @@ func (s *ResourceMap) ReferenceCountTCPRoute(route gwv1alpha2.TCPRoute) {
 	s.tcpRouteGateways[consulKey] = set
 }
 
+// ReferenceCountGRPCRoute reference counts a GRPCRoute. GRPCRoutes are written
+// to Consul as HTTPRoute config entries named with GRPCRouteConsulName.
+func (s *ResourceMap) ReferenceCountGRPCRoute(route gwv1alpha2.GRPCRoute) {
+	consulKey := NormalizeMeta(s.toGRPCRouteConsulReference(client.ObjectKeyFromObject(&route)))
+
+	set := &grpcRoute{
+		route:    route,
+		gateways: mapset.NewSet(),
+	}
+
+	for gatewayKey := range s.gatewaysForRoute(route.Namespace, route.Spec.ParentRefs).Iter() {
+		set.gateways.Add(gatewayKey.(api.ResourceReference))
+
+		gateway := s.gatewayResources[gatewayKey.(api.ResourceReference)]
+		gateway.grpcRoutes.Add(consulKey)
+	}
+
+	s.grpcRouteGateways[consulKey] = set
+}
+
 func (s *ResourceMap) gatewaysForRoute(namespace string, refs []gwv1beta1.ParentReference) mapset.Set {
 	gateways := mapset.NewSet()
 
@@ -467,6 +523,46 @@ func (s *ResourceMap) CanGCHTTPRouteOnUnbind(id api.ResourceReference) bool {
 	return true
 }
 
+func (s *ResourceMap) TranslateAndMutateGRPCRoute(key types.NamespacedName, onUpdate func(error, api.ConfigEntryStatus), mutateFn func(old *api.HTTPRouteConfigEntry, new api.HTTPRouteConfigEntry) api.HTTPRouteConfigEntry) {
+	consulKey := NormalizeMeta(s.toGRPCRouteConsulReference(key))
+
+	route, ok := s.grpcRouteGateways[consulKey]
+	if !ok {
+		return
+	}
+
+	translated := s.translator.ToGRPCRoute(route.route, s)
+
+	var old *api.HTTPRouteConfigEntry
+	if consulRoute, ok := s.consulHTTPRoutes[consulKey]; ok {
+		old = &consulRoute.route
+	}
+	mutated := mutateFn(old, *translated)
+	if len(mutated.Parents) != 0 {
+		// if we don't have any parents set, we keep this around to allow the route
+		// to be GC'd.
+		delete(s.consulHTTPRoutes, consulKey)
+		s.consulMutations = append(s.consulMutations, &ConsulUpdateOperation{
+			Entry: &mutated,
+			OnUpdate: func(err error) {
+				onUpdate(err, mutated.Status)
+			},
+		})
+	}
+}
+
+// MutateGRPCRoute mutates the Consul HTTPRoute config entry of a GRPCRoute.
+func (s *ResourceMap) MutateGRPCRoute(key types.NamespacedName, onUpdate func(error, api.ConfigEntryStatus), mutateFn func(api.HTTPRouteConfigEntry) api.HTTPRouteConfigEntry) {
+	s.MutateHTTPRoute(types.NamespacedName{Namespace: key.Namespace, Name: GRPCRouteConsulName(key.Name)}, onUpdate, mutateFn)
+}
+
+func (s *ResourceMap) CanGCGRPCRouteOnUnbind(id api.ResourceReference) bool {
+	if set := s.grpcRouteGateways[NormalizeMeta(id)]; set != nil {
+		return set.gateways.Cardinality() <= 1
+	}
+	return true
+}
+
 func (s *ResourceMap) TranslateAndMutateTCPRoute(key types.NamespacedName, onUpdate func(error, api.ConfigEntryStatus), mutateFn func(*api.TCPRouteConfigEntry, api.TCPRouteConfigEntry) api.TCPRouteConfigEntry) {
 	consulKey := NormalizeMeta(s.toConsulReference(api.TCPRoute, key))
 
@@ -597,6 +693,10 @@ func (s *ResourceMap) toConsulReference(kind string, key types.NamespacedName) a
 	}
 }
 
+func (s *ResourceMap) toGRPCRouteConsulReference(key types.NamespacedName) api.ResourceReference {
+	return s.toConsulReference(api.HTTPRoute, types.NamespacedName{Namespace: key.Namespace, Name: GRPCRouteConsulName(key.Name)})
+}
+
 func (s *ResourceMap) GatewayCanReferenceSecret(gateway gwv1beta1.Gateway, ref gwv1beta1.SecretObjectReference) bool {
 	return s.referenceValidator.GatewayCanReferenceSecret(gateway, ref)
 }
@@ -608,3 +708,7 @@ func (s *ResourceMap) HTTPRouteCanReferenceBackend(route gwv1beta1.HTTPRoute, re
 func (s *ResourceMap) TCPRouteCanReferenceBackend(route gwv1alpha2.TCPRoute, ref gwv1beta1.BackendRef) bool {
 	return s.referenceValidator.TCPRouteCanReferenceBackend(route, ref)
 }
+
+func (s *ResourceMap) GRPCRouteCanReferenceBackend(route gwv1alpha2.GRPCRoute, ref gwv1beta1.BackendRef) bool {
+	return s.referenceValidator.GRPCRouteCanReferenceBackend(route, ref)
+}
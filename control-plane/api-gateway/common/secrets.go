@@ -12,8 +12,22 @@ import (
 
 	"github.com/miekg/dns"
 	corev1 "k8s.io/api/core/v1"
+	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
+// IsSecretCertificateRef returns whether a listener certificate reference
+// points directly at a Kubernetes Secret.
+func IsSecretCertificateRef(ref gwv1beta1.SecretObjectReference) bool {
+	return NilOrEqual(ref.Group, "") && NilOrEqual(ref.Kind, KindSecret)
+}
+
+// IsCertManagerCertificateRef returns whether a listener certificate reference
+// points at a cert-manager Certificate, in which case the TLS material is read
+// from the Secret that cert-manager issues for it.
+func IsCertManagerCertificateRef(ref gwv1beta1.SecretObjectReference) bool {
+	return DerefEqual(ref.Group, CertManagerGroup) && DerefEqual(ref.Kind, KindCertificate)
+}
+
 func ParseCertificateData(secret corev1.Secret) (cert string, privateKey string, err error) {
 	decodedPrivateKey := secret.Data[corev1.TLSPrivateKeyKey]
 	decodedCertificate := secret.Data[corev1.TLSCertKey]
@@ -4,6 +4,7 @@
 package common
 
 import (
+	"regexp"
 	"strings"
 
 	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
@@ -24,6 +25,9 @@ type ResourceTranslator struct {
 	MirroringPrefix        string
 	ConsulPartition        string
 	Datacenter             string
+	// TLSMinVersion and TLSCipherSuites are set on the listeners with TLS.
+	TLSMinVersion   string
+	TLSCipherSuites []string
 }
 
 func (t ResourceTranslator) NonNormalizedConfigEntryReference(kind string, id types.NamespacedName) api.ResourceReference {
@@ -83,9 +87,11 @@ var listenerProtocolMap = map[string]string{
 func (t ResourceTranslator) toAPIGatewayListener(gateway gwv1beta1.Gateway, listener gwv1beta1.Listener, resources *ResourceMap) (api.APIGatewayListener, bool) {
 	namespace := gateway.Namespace
 
-	var certificates []api.ResourceReference
+	var tls api.APIGatewayTLSConfiguration
 
 	if listener.TLS != nil {
+		tls.MinVersion = t.TLSMinVersion
+		tls.CipherSuites = t.TLSCipherSuites
 		for _, ref := range listener.TLS.CertificateRefs {
 			if !resources.GatewayCanReferenceSecret(gateway, ref) {
 				return api.APIGatewayListener{}, false
@@ -98,7 +104,7 @@ func (t ResourceTranslator) toAPIGatewayListener(gateway gwv1beta1.Gateway, list
 
 			ref := IndexedNamespacedNameWithDefault(ref.Name, ref.Namespace, namespace)
 			if resources.Certificate(ref) != nil {
-				certificates = append(certificates, t.NonNormalizedConfigEntryReference(api.InlineCertificate, ref))
+				tls.Certificates = append(tls.Certificates, t.NonNormalizedConfigEntryReference(api.InlineCertificate, ref))
 			}
 		}
 	}
@@ -108,9 +114,7 @@ func (t ResourceTranslator) toAPIGatewayListener(gateway gwv1beta1.Gateway, list
 		Hostname: DerefStringOr(listener.Hostname, ""),
 		Port:     int(listener.Port),
 		Protocol: listenerProtocolMap[strings.ToLower(string(listener.Protocol))],
-		TLS: api.APIGatewayTLSConfiguration{
-			Certificates: certificates,
-		},
+		TLS:      tls,
 	}, true
 }
 
@@ -277,6 +281,177 @@ func (t ResourceTranslator) translateHTTPFilters(filters []gwv1beta1.HTTPRouteFi
 	}
 }
 
+// GRPCRouteConsulName returns the name of the Consul HTTPRoute config entry
+// that a GRPCRoute is written to. Kubernetes names can't contain underscores,
+// so the prefix keeps it from colliding with the config entry of an HTTPRoute
+// with the same name.
+func GRPCRouteConsulName(name string) string {
+	return "grpcroute_" + name
+}
+
+// ToGRPCRoute translates a GRPCRoute into a Consul HTTPRoute config entry
+// named with GRPCRouteConsulName. gRPC requests are HTTP/2 POSTs to
+// /<service>/<method>, so method matches are translated into path matches.
+func (t ResourceTranslator) ToGRPCRoute(route gwv1alpha2.GRPCRoute, resources *ResourceMap) *api.HTTPRouteConfigEntry {
+	namespace := t.Namespace(route.Namespace)
+
+	// we don't translate parent refs
+
+	hostnames := StringLikeSlice(route.Spec.Hostnames)
+	rules := ConvertSliceFuncIf(route.Spec.Rules, func(rule gwv1alpha2.GRPCRouteRule) (api.HTTPRouteRule, bool) {
+		return t.translateGRPCRouteRule(route, rule, resources)
+	})
+
+	return &api.HTTPRouteConfigEntry{
+		Kind:      api.HTTPRoute,
+		Name:      GRPCRouteConsulName(route.Name),
+		Namespace: namespace,
+		Partition: t.ConsulPartition,
+		Meta: t.addDatacenterToMeta(map[string]string{
+			constants.MetaKeyKubeNS:   route.Namespace,
+			constants.MetaKeyKubeName: route.Name,
+		}),
+		Hostnames: hostnames,
+		Rules:     rules,
+	}
+}
+
+func (t ResourceTranslator) translateGRPCRouteRule(route gwv1alpha2.GRPCRoute, rule gwv1alpha2.GRPCRouteRule, resources *ResourceMap) (api.HTTPRouteRule, bool) {
+	services := ConvertSliceFuncIf(rule.BackendRefs, func(ref gwv1alpha2.GRPCBackendRef) (api.HTTPService, bool) {
+		return t.translateGRPCBackendRef(route, ref, resources)
+	})
+
+	if len(services) == 0 {
+		return api.HTTPRouteRule{}, false
+	}
+
+	matches := ConvertSliceFunc(rule.Matches, t.translateGRPCMatch)
+	filters := t.translateGRPCFilters(rule.Filters)
+
+	return api.HTTPRouteRule{
+		Services: services,
+		Matches:  matches,
+		Filters:  filters,
+	}, true
+}
+
+func (t ResourceTranslator) translateGRPCBackendRef(route gwv1alpha2.GRPCRoute, ref gwv1alpha2.GRPCBackendRef, resources *ResourceMap) (api.HTTPService, bool) {
+	id := types.NamespacedName{
+		Name:      string(ref.Name),
+		Namespace: DerefStringOr(ref.Namespace, route.Namespace),
+	}
+
+	isServiceRef := NilOrEqual(ref.Group, "") && NilOrEqual(ref.Kind, "Service")
+
+	if isServiceRef && resources.HasService(id) && resources.GRPCRouteCanReferenceBackend(route, ref.BackendRef) {
+		filters := t.translateGRPCFilters(ref.Filters)
+		service := resources.Service(id)
+
+		return api.HTTPService{
+			Name:      service.Name,
+			Namespace: service.Namespace,
+			Partition: t.ConsulPartition,
+			Filters:   filters,
+			Weight:    DerefIntOr(ref.Weight, 1),
+		}, true
+	}
+
+	isMeshServiceRef := DerefEqual(ref.Group, v1alpha1.ConsulHashicorpGroup) && DerefEqual(ref.Kind, v1alpha1.MeshServiceKind)
+	if isMeshServiceRef && resources.HasMeshService(id) && resources.GRPCRouteCanReferenceBackend(route, ref.BackendRef) {
+		filters := t.translateGRPCFilters(ref.Filters)
+		service := resources.MeshService(id)
+
+		return api.HTTPService{
+			Name:      service.Name,
+			Namespace: service.Namespace,
+			Partition: t.ConsulPartition,
+			Filters:   filters,
+			Weight:    DerefIntOr(ref.Weight, 1),
+		}, true
+	}
+
+	return api.HTTPService{}, false
+}
+
+func (t ResourceTranslator) translateGRPCMatch(match gwv1alpha2.GRPCRouteMatch) api.HTTPMatch {
+	headers := ConvertSliceFunc(match.Headers, t.translateGRPCHeaderMatch)
+
+	return api.HTTPMatch{
+		Headers: headers,
+		Path:    DerefConvertFunc(match.Method, t.translateGRPCMethodMatch),
+	}
+}
+
+// grpcPathSegment matches any gRPC service or method name.
+const grpcPathSegment = "[^/]+"
+
+func (t ResourceTranslator) translateGRPCMethodMatch(match gwv1alpha2.GRPCMethodMatch) api.HTTPPathMatch {
+	service := DerefStringOr(match.Service, "")
+	method := DerefStringOr(match.Method, "")
+
+	if DerefEqual(match.Type, string(gwv1alpha2.GRPCMethodMatchRegularExpression)) {
+		return api.HTTPPathMatch{
+			Match: api.HTTPPathMatchRegularExpression,
+			Value: "/" + orDefault(service, grpcPathSegment) + "/" + orDefault(method, grpcPathSegment),
+		}
+	}
+
+	// the match type defaults to Exact
+	switch {
+	case service != "" && method != "":
+		return api.HTTPPathMatch{
+			Match: api.HTTPPathMatchExact,
+			Value: "/" + service + "/" + method,
+		}
+	case service != "":
+		return api.HTTPPathMatch{
+			Match: api.HTTPPathMatchPrefix,
+			Value: "/" + service + "/",
+		}
+	case method != "":
+		return api.HTTPPathMatch{
+			Match: api.HTTPPathMatchRegularExpression,
+			Value: "/" + grpcPathSegment + "/" + regexp.QuoteMeta(method),
+		}
+	}
+	return api.HTTPPathMatch{}
+}
+
+func (t ResourceTranslator) translateGRPCHeaderMatch(match gwv1alpha2.GRPCHeaderMatch) api.HTTPHeaderMatch {
+	return api.HTTPHeaderMatch{
+		Name:  string(match.Name),
+		Value: match.Value,
+		Match: DerefLookup(match.Type, headerMatchTypeTranslation),
+	}
+}
+
+func (t ResourceTranslator) translateGRPCFilters(filters []gwv1alpha2.GRPCRouteFilter) api.HTTPFilters {
+	consulFilter := api.HTTPHeaderFilter{
+		Add: make(map[string]string),
+		Set: make(map[string]string),
+	}
+
+	// only request header modifiers are supported
+	for _, filter := range filters {
+		if filter.RequestHeaderModifier == nil {
+			continue
+		}
+
+		consulFilter.Remove = append(consulFilter.Remove, filter.RequestHeaderModifier.Remove...)
+
+		for _, toAdd := range filter.RequestHeaderModifier.Add {
+			consulFilter.Add[string(toAdd.Name)] = toAdd.Value
+		}
+
+		for _, toSet := range filter.RequestHeaderModifier.Set {
+			consulFilter.Set[string(toSet.Name)] = toSet.Value
+		}
+	}
+	return api.HTTPFilters{
+		Headers: []api.HTTPHeaderFilter{consulFilter},
+	}
+}
+
 func (t ResourceTranslator) ToTCPRoute(route gwv1alpha2.TCPRoute, resources *ResourceMap) *api.TCPRouteConfigEntry {
 	namespace := t.Namespace(route.Namespace)
 
@@ -44,6 +44,10 @@ func (v fakeReferenceValidator) TCPRouteCanReferenceBackend(tcpRoute gwv1alpha2.
 	return true
 }
 
+func (v fakeReferenceValidator) GRPCRouteCanReferenceBackend(grpcRoute gwv1alpha2.GRPCRoute, backendRef gwv1beta1.BackendRef) bool {
+	return true
+}
+
 func TestTranslator_Namespace(t *testing.T) {
 	testCases := []struct {
 		EnableConsulNamespaces bool
@@ -329,6 +333,47 @@ func TestTranslator_ToAPIGateway(t *testing.T) {
 	}
 }
 
+func TestTranslator_ToAPIGateway_ListenerTLSSettings(t *testing.T) {
+	t.Parallel()
+
+	input := gwv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "gateway",
+			Namespace: "default",
+		},
+		Spec: gwv1beta1.GatewaySpec{
+			Listeners: []gwv1beta1.Listener{
+				{
+					Name:     "https",
+					Port:     443,
+					Protocol: gwv1beta1.HTTPSProtocolType,
+					TLS:      &gwv1beta1.GatewayTLSConfig{},
+				},
+				{
+					Name:     "http",
+					Port:     80,
+					Protocol: gwv1beta1.HTTPProtocolType,
+				},
+			},
+		},
+	}
+
+	translator := ResourceTranslator{
+		TLSMinVersion:   "TLSv1_2",
+		TLSCipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+	}
+	resources := NewResourceMap(translator, fakeReferenceValidator{}, logrtest.NewTestLogger(t))
+
+	actual := translator.ToAPIGateway(input, resources)
+
+	require.Len(t, actual.Listeners, 2)
+	require.Equal(t, api.APIGatewayTLSConfiguration{
+		MinVersion:   "TLSv1_2",
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+	}, actual.Listeners[0].TLS)
+	require.Equal(t, api.APIGatewayTLSConfiguration{}, actual.Listeners[1].TLS)
+}
+
 func TestTranslator_ToHTTPRoute(t *testing.T) {
 	t.Parallel()
 	type args struct {
@@ -1372,3 +1417,183 @@ func generateTestCertificate(t *testing.T, namespace, name string) corev1.Secret
 		},
 	}
 }
+
+func TestTranslator_ToGRPCRoute(t *testing.T) {
+	t.Parallel()
+	type args struct {
+		k8sRoute gwv1alpha2.GRPCRoute
+		services []types.NamespacedName
+	}
+	tests := map[string]struct {
+		args args
+		want api.HTTPRouteConfigEntry
+	}{
+		"base test": {
+			args: args{
+				k8sRoute: gwv1alpha2.GRPCRoute{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "grpc-route",
+						Namespace: "k8s-ns",
+					},
+					Spec: gwv1alpha2.GRPCRouteSpec{
+						Hostnames: []gwv1alpha2.Hostname{"grpc.example.com"},
+						Rules: []gwv1alpha2.GRPCRouteRule{
+							{
+								Matches: []gwv1alpha2.GRPCRouteMatch{
+									{
+										Method: &gwv1alpha2.GRPCMethodMatch{
+											Service: PointerTo("echo.Echo"),
+											Method:  PointerTo("Ping"),
+										},
+										Headers: []gwv1alpha2.GRPCHeaderMatch{
+											{
+												Type:  PointerTo(gwv1beta1.HeaderMatchExact),
+												Name:  "tenant",
+												Value: "a",
+											},
+										},
+									},
+									{
+										Method: &gwv1alpha2.GRPCMethodMatch{
+											Service: PointerTo("echo.Echo"),
+										},
+									},
+									{
+										Method: &gwv1alpha2.GRPCMethodMatch{
+											Method: PointerTo("Ping"),
+										},
+									},
+									{
+										Method: &gwv1alpha2.GRPCMethodMatch{
+											Type:    PointerTo(gwv1alpha2.GRPCMethodMatchRegularExpression),
+											Service: PointerTo(`echo\..+`),
+										},
+									},
+								},
+								Filters: []gwv1alpha2.GRPCRouteFilter{
+									{
+										Type: gwv1alpha2.GRPCRouteFilterRequestHeaderModifier,
+										RequestHeaderModifier: &gwv1beta1.HTTPHeaderFilter{
+											Set:    []gwv1beta1.HTTPHeader{{Name: "x-set", Value: "set"}},
+											Add:    []gwv1beta1.HTTPHeader{{Name: "x-add", Value: "add"}},
+											Remove: []string{"x-remove"},
+										},
+									},
+									{
+										Type: gwv1alpha2.GRPCRouteFilterRequestMirror,
+									},
+								},
+								BackendRefs: []gwv1alpha2.GRPCBackendRef{
+									{
+										BackendRef: gwv1beta1.BackendRef{
+											BackendObjectReference: gwv1beta1.BackendObjectReference{
+												Name:      "echo",
+												Namespace: PointerTo(gwv1beta1.Namespace("svc-ns")),
+											},
+											Weight: PointerTo(int32(2)),
+										},
+									},
+									{
+										BackendRef: gwv1beta1.BackendRef{
+											BackendObjectReference: gwv1beta1.BackendObjectReference{
+												Name: "missing",
+											},
+										},
+									},
+								},
+							},
+							{
+								// dropped since none of its backends exist
+								BackendRefs: []gwv1alpha2.GRPCBackendRef{
+									{
+										BackendRef: gwv1beta1.BackendRef{
+											BackendObjectReference: gwv1beta1.BackendObjectReference{
+												Name: "missing",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				services: []types.NamespacedName{
+					{Name: "echo", Namespace: "svc-ns"},
+				},
+			},
+			want: api.HTTPRouteConfigEntry{
+				Kind:      api.HTTPRoute,
+				Name:      "grpcroute_grpc-route",
+				Namespace: "k8s-ns",
+				Hostnames: []string{"grpc.example.com"},
+				Rules: []api.HTTPRouteRule{
+					{
+						Matches: []api.HTTPMatch{
+							{
+								Path: api.HTTPPathMatch{Match: api.HTTPPathMatchExact, Value: "/echo.Echo/Ping"},
+								Headers: []api.HTTPHeaderMatch{
+									{Match: api.HTTPHeaderMatchExact, Name: "tenant", Value: "a"},
+								},
+							},
+							{
+								Path:    api.HTTPPathMatch{Match: api.HTTPPathMatchPrefix, Value: "/echo.Echo/"},
+								Headers: []api.HTTPHeaderMatch{},
+							},
+							{
+								Path:    api.HTTPPathMatch{Match: api.HTTPPathMatchRegularExpression, Value: "/[^/]+/Ping"},
+								Headers: []api.HTTPHeaderMatch{},
+							},
+							{
+								Path:    api.HTTPPathMatch{Match: api.HTTPPathMatchRegularExpression, Value: `/echo\..+/[^/]+`},
+								Headers: []api.HTTPHeaderMatch{},
+							},
+						},
+						Filters: api.HTTPFilters{
+							Headers: []api.HTTPHeaderFilter{
+								{
+									Add:    map[string]string{"x-add": "add"},
+									Set:    map[string]string{"x-set": "set"},
+									Remove: []string{"x-remove"},
+								},
+							},
+						},
+						Services: []api.HTTPService{
+							{
+								Name:      "echo",
+								Namespace: "svc-ns",
+								Weight:    2,
+								Filters: api.HTTPFilters{
+									Headers: []api.HTTPHeaderFilter{
+										{Add: map[string]string{}, Set: map[string]string{}},
+									},
+								},
+							},
+						},
+					},
+				},
+				Meta: map[string]string{
+					constants.MetaKeyKubeNS:   "k8s-ns",
+					constants.MetaKeyKubeName: "grpc-route",
+				},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tr := ResourceTranslator{
+				EnableConsulNamespaces: true,
+				EnableK8sMirroring:     true,
+			}
+
+			resources := NewResourceMap(tr, fakeReferenceValidator{}, logrtest.NewTestLogger(t))
+			for _, service := range tt.args.services {
+				resources.AddService(service, service.Name)
+			}
+
+			got := tr.ToGRPCRoute(tt.args.k8sRoute, resources)
+			if diff := cmp.Diff(&tt.want, got); diff != "" {
+				t.Errorf("Translator.ToGRPCRoute() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -34,6 +36,7 @@ import (
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/gatekeeper"
 	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul/api"
 )
@@ -49,6 +52,7 @@ type GatewayControllerConfig struct {
 	Datacenter              string
 	AllowK8sNamespacesSet   mapset.Set
 	DenyK8sNamespacesSet    mapset.Set
+	Auditor                 *audit.Auditor
 }
 
 // GatewayController reconciles a Gateway object.
@@ -161,7 +165,14 @@ func (r *GatewayController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
-	if err := r.fetchServicesForRoutes(ctx, resources, tcpRoutes, httpRoutes); err != nil {
+	// get all grpc routes referencing this gateway
+	grpcRoutes, err := r.getRelatedGRPCRoutes(ctx, req.NamespacedName, resources)
+	if err != nil {
+		log.Error(err, "unable to list GRPCRoutes")
+		return ctrl.Result{}, err
+	}
+
+	if err := r.fetchServicesForRoutes(ctx, resources, tcpRoutes, httpRoutes, grpcRoutes); err != nil {
 		log.Error(err, "unable to fetch services for routes")
 		return ctrl.Result{}, err
 	}
@@ -184,9 +195,11 @@ func (r *GatewayController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		Service:               service,
 		HTTPRoutes:            httpRoutes,
 		TCPRoutes:             tcpRoutes,
+		GRPCRoutes:            grpcRoutes,
 		Resources:             resources,
 		ConsulGateway:         consulGateway,
 		ConsulGatewayServices: consulServices,
+		ClusterName:           r.HelmConfig.ClusterName,
 	})
 
 	updates := binder.Snapshot()
@@ -299,10 +312,24 @@ func (r *GatewayController) deregisterAllServices(ctx context.Context, consulKey
 	return nil
 }
 
+// updateAndResetStatus writes the finalizers and annotations of o. The binder only
+// changes the metadata of the objects it updates, so only the metadata is patched.
+// A full update would drop the fields of newer Gateway API versions that our types
+// don't have.
 func (r *GatewayController) updateAndResetStatus(ctx context.Context, o client.Object) error {
 	// we create a copy so that we can re-update its status if need be
 	status := reflect.ValueOf(o.DeepCopyObject()).Elem().FieldByName("Status")
-	if err := r.Client.Update(ctx, o); err != nil {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations":     o.GetAnnotations(),
+			"finalizers":      o.GetFinalizers(),
+			"resourceVersion": o.GetResourceVersion(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := r.Client.Patch(ctx, o, client.RawPatch(types.MergePatchType, patch)); err != nil {
 		return err
 	}
 	// reset the status in case it needs to be updated below
@@ -350,6 +377,7 @@ func SetupGatewayControllerWithManager(ctx context.Context, mgr ctrl.Manager, co
 		Datacenter:              config.Datacenter,
 		CrossNamespaceACLPolicy: config.CrossNamespaceACLPolicy,
 		Logger:                  mgr.GetLogger(),
+		Auditor:                 config.Auditor,
 	}
 	c := cache.New(cacheConfig)
 	gwc := cache.NewGatewayCache(ctx, cacheConfig)
@@ -371,6 +399,8 @@ func SetupGatewayControllerWithManager(ctx context.Context, mgr ctrl.Manager, co
 			MirroringPrefix:        config.HelmConfig.NamespaceMirroringPrefix,
 			ConsulPartition:        config.HelmConfig.ConsulPartition,
 			Datacenter:             config.Datacenter,
+			TLSMinVersion:          config.HelmConfig.ListenerTLSMinVersion,
+			TLSCipherSuites:        config.HelmConfig.ListenerTLSCipherSuites,
 		},
 		denyK8sNamespacesSet:  config.DenyK8sNamespacesSet,
 		allowK8sNamespacesSet: config.AllowK8sNamespacesSet,
@@ -391,6 +421,10 @@ func SetupGatewayControllerWithManager(ctx context.Context, mgr ctrl.Manager, co
 			source.NewKindWithCache(&gwv1beta1.GatewayClass{}, mgr.GetCache()),
 			handler.EnqueueRequestsFromMapFunc(r.transformGatewayClass(ctx)),
 		).
+		Watches(
+			source.NewKindWithCache(&v1alpha1.GatewayClassConfig{}, mgr.GetCache()),
+			handler.EnqueueRequestsFromMapFunc(r.transformGatewayClassConfig(ctx)),
+		).
 		Watches(
 			source.NewKindWithCache(&gwv1beta1.HTTPRoute{}, mgr.GetCache()),
 			handler.EnqueueRequestsFromMapFunc(r.transformHTTPRoute(ctx)),
@@ -399,6 +433,10 @@ func SetupGatewayControllerWithManager(ctx context.Context, mgr ctrl.Manager, co
 			source.NewKindWithCache(&gwv1alpha2.TCPRoute{}, mgr.GetCache()),
 			handler.EnqueueRequestsFromMapFunc(r.transformTCPRoute(ctx)),
 		).
+		Watches(
+			source.NewKindWithCache(&gwv1alpha2.GRPCRoute{}, mgr.GetCache()),
+			handler.EnqueueRequestsFromMapFunc(r.transformGRPCRoute(ctx)),
+		).
 		Watches(
 			source.NewKindWithCache(&corev1.Secret{}, mgr.GetCache()),
 			handler.EnqueueRequestsFromMapFunc(r.transformSecret(ctx)),
@@ -453,6 +491,26 @@ func (r *GatewayController) transformGatewayClass(ctx context.Context) func(o cl
 	}
 }
 
+// transformGatewayClassConfig will check the list of GatewayClass objects referencing
+// the GatewayClassConfig, then return a list of reconcile Requests for the Gateways
+// of those classes, so that changes to the config are rolled out to their deployments.
+func (r *GatewayController) transformGatewayClassConfig(ctx context.Context) func(o client.Object) []reconcile.Request {
+	return func(o client.Object) []reconcile.Request {
+		gatewayClassList := &gwv1beta1.GatewayClassList{}
+		if err := r.Client.List(ctx, gatewayClassList, &client.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector(GatewayClass_GatewayClassConfigIndex, o.GetName()),
+		}); err != nil {
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, gatewayClass := range gatewayClassList.Items {
+			requests = append(requests, r.transformGatewayClass(ctx)(&gatewayClass)...)
+		}
+		return requests
+	}
+}
+
 // transformHTTPRoute will check the HTTPRoute object for a matching
 // class, then return a list of reconcile Requests for Gateways referring to it.
 func (r *GatewayController) transformHTTPRoute(ctx context.Context) func(o client.Object) []reconcile.Request {
@@ -481,6 +539,20 @@ func (r *GatewayController) transformTCPRoute(ctx context.Context) func(o client
 	}
 }
 
+// transformGRPCRoute will check the GRPCRoute object for a matching
+// class, then return a list of reconcile Requests for Gateways referring to it.
+func (r *GatewayController) transformGRPCRoute(ctx context.Context) func(o client.Object) []reconcile.Request {
+	return func(o client.Object) []reconcile.Request {
+		route := o.(*gwv1alpha2.GRPCRoute)
+
+		refs := refsToRequests(common.ParentRefs(common.BetaGroup, common.KindGateway, route.Namespace, route.Spec.ParentRefs))
+		statusRefs := refsToRequests(common.ParentRefs(common.BetaGroup, common.KindGateway, route.Namespace, common.ConvertSliceFunc(route.Status.Parents, func(parentStatus gwv1beta1.RouteParentStatus) gwv1beta1.ParentReference {
+			return parentStatus.ParentRef
+		})))
+		return append(refs, statusRefs...)
+	}
+}
+
 // transformSecret will check the Secret object for a matching
 // class, then return a list of reconcile Requests for Gateways referring to it.
 func (r *GatewayController) transformSecret(ctx context.Context) func(o client.Object) []reconcile.Request {
@@ -492,7 +564,21 @@ func (r *GatewayController) transformSecret(ctx context.Context) func(o client.O
 		}); err != nil {
 			return nil
 		}
-		return common.ObjectsToReconcileRequests(pointersOf(gatewayList.Items))
+		requests := common.ObjectsToReconcileRequests(pointersOf(gatewayList.Items))
+
+		// also reconcile any gateways referencing the cert-manager Certificate
+		// that this Secret was issued for, so that renewals are picked up
+		for _, certificate := range secretForCertificate(secret) {
+			certificateGatewayList := &gwv1beta1.GatewayList{}
+			if err := r.Client.List(ctx, certificateGatewayList, &client.ListOptions{
+				FieldSelector: fields.OneTermEqualSelector(Certificate_GatewayIndex, certificate),
+			}); err != nil {
+				return nil
+			}
+			requests = append(requests, common.ObjectsToReconcileRequests(pointersOf(certificateGatewayList.Items))...)
+		}
+
+		return requests
 	}
 }
 
@@ -513,13 +599,13 @@ func (r *GatewayController) transformReferenceGrant(ctx context.Context) func(o
 }
 
 // transformMeshService will return a list of gateways that are referenced
-// by a TCPRoute or HTTPRoute that references the mesh service.
+// by a TCPRoute, HTTPRoute or GRPCRoute that references the mesh service.
 func (r *GatewayController) transformMeshService(ctx context.Context) func(o client.Object) []reconcile.Request {
 	return func(o client.Object) []reconcile.Request {
 		service := o.(*v1alpha1.MeshService)
 		key := client.ObjectKeyFromObject(service).String()
 
-		return r.gatewaysForRoutesReferencing(ctx, TCPRoute_MeshServiceIndex, HTTPRoute_MeshServiceIndex, key)
+		return r.gatewaysForRoutesReferencing(ctx, TCPRoute_MeshServiceIndex, HTTPRoute_MeshServiceIndex, GRPCRoute_MeshServiceIndex, key)
 	}
 }
 
@@ -620,7 +706,7 @@ func (r *GatewayController) transformPods(ctx context.Context) func(o client.Obj
 }
 
 // transformEndpoints will return a list of gateways that are referenced
-// by a TCPRoute or HTTPRoute that references the service.
+// by a TCPRoute, HTTPRoute or GRPCRoute that references the service.
 func (r *GatewayController) transformEndpoints(ctx context.Context) func(o client.Object) []reconcile.Request {
 	return func(o client.Object) []reconcile.Request {
 		key := client.ObjectKeyFromObject(o)
@@ -630,13 +716,13 @@ func (r *GatewayController) transformEndpoints(ctx context.Context) func(o clien
 			return nil
 		}
 
-		return r.gatewaysForRoutesReferencing(ctx, TCPRoute_ServiceIndex, HTTPRoute_ServiceIndex, key.String())
+		return r.gatewaysForRoutesReferencing(ctx, TCPRoute_ServiceIndex, HTTPRoute_ServiceIndex, GRPCRoute_ServiceIndex, key.String())
 	}
 }
 
 // gatewaysForRoutesReferencing returns a mapping of all gateways that are referenced by routes that
 // have a backend associated with the given key and index.
-func (r *GatewayController) gatewaysForRoutesReferencing(ctx context.Context, tcpIndex, httpIndex, key string) []reconcile.Request {
+func (r *GatewayController) gatewaysForRoutesReferencing(ctx context.Context, tcpIndex, httpIndex, grpcIndex, key string) []reconcile.Request {
 	requestSet := make(map[types.NamespacedName]struct{})
 
 	tcpRouteList := &gwv1alpha2.TCPRouteList{}
@@ -663,6 +749,18 @@ func (r *GatewayController) gatewaysForRoutesReferencing(ctx context.Context, tc
 		}
 	}
 
+	grpcRouteList := &gwv1alpha2.GRPCRouteList{}
+	if err := r.Client.List(ctx, grpcRouteList, &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(grpcIndex, key),
+	}); err != nil {
+		r.Log.Error(err, "unable to list GRPCRoutes")
+	}
+	for _, route := range grpcRouteList.Items {
+		for _, ref := range common.ParentRefs(common.BetaGroup, common.KindGateway, route.Namespace, route.Spec.ParentRefs) {
+			requestSet[ref] = struct{}{}
+		}
+	}
+
 	requests := []reconcile.Request{}
 	for request := range requestSet {
 		requests = append(requests, reconcile.Request{NamespacedName: request})
@@ -777,6 +875,22 @@ func (c *GatewayController) getRelatedTCPRoutes(ctx context.Context, gateway typ
 	return list.Items, nil
 }
 
+func (c *GatewayController) getRelatedGRPCRoutes(ctx context.Context, gateway types.NamespacedName, resources *common.ResourceMap) ([]gwv1alpha2.GRPCRoute, error) {
+	var list gwv1alpha2.GRPCRouteList
+
+	if err := c.Client.List(ctx, &list, &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(GRPCRoute_GatewayIndex, gateway.String()),
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, route := range list.Items {
+		resources.ReferenceCountGRPCRoute(route)
+	}
+
+	return list.Items, nil
+}
+
 func (c *GatewayController) getConfigForGatewayClass(ctx context.Context, gatewayClassConfig *gwv1beta1.GatewayClass) (*v1alpha1.GatewayClassConfig, error) {
 	if gatewayClassConfig == nil {
 		// if we don't have a gateway class we can't fetch the corresponding config
@@ -837,12 +951,17 @@ func (c *GatewayController) fetchControlledGateways(ctx context.Context, resourc
 
 func (c *GatewayController) fetchCertificatesForGateway(ctx context.Context, resources *common.ResourceMap, gateway gwv1beta1.Gateway) error {
 	certificates := mapset.NewSet()
+	certManagerCertificates := mapset.NewSet()
 
 	for _, listener := range gateway.Spec.Listeners {
 		if listener.TLS != nil {
 			for _, cert := range listener.TLS.CertificateRefs {
-				if common.NilOrEqual(cert.Group, "") && common.NilOrEqual(cert.Kind, common.KindSecret) {
-					certificates.Add(common.IndexedNamespacedNameWithDefault(cert.Name, cert.Namespace, gateway.Namespace))
+				key := common.IndexedNamespacedNameWithDefault(cert.Name, cert.Namespace, gateway.Namespace)
+				switch {
+				case common.IsSecretCertificateRef(cert):
+					certificates.Add(key)
+				case common.IsCertManagerCertificateRef(cert):
+					certManagerCertificates.Add(key)
 				}
 			}
 		}
@@ -854,6 +973,12 @@ func (c *GatewayController) fetchCertificatesForGateway(ctx context.Context, res
 		}
 	}
 
+	for key := range certManagerCertificates.Iter() {
+		if err := c.fetchCertManagerSecret(ctx, resources, key.(types.NamespacedName)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -868,7 +993,34 @@ func (c *GatewayController) fetchSecret(ctx context.Context, resources *common.R
 	return nil
 }
 
-func (c *GatewayController) fetchServicesForRoutes(ctx context.Context, resources *common.ResourceMap, tcpRoutes []gwv1alpha2.TCPRoute, httpRoutes []gwv1beta1.HTTPRoute) error {
+// fetchCertManagerSecret looks up the Secret that cert-manager issued for the
+// given Certificate, based on the annotation cert-manager adds to the Secret.
+// Nothing is reference counted if the Certificate has not been issued yet.
+func (c *GatewayController) fetchCertManagerSecret(ctx context.Context, resources *common.ResourceMap, key types.NamespacedName) error {
+	secrets := &corev1.SecretList{}
+	if err := c.Client.List(ctx, secrets, &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(Secret_CertificateIndex, key.String()),
+	}); err != nil {
+		return err
+	}
+
+	// sort so that we consistently pick the same Secret if more than one
+	// claims to be issued for the Certificate
+	sort.SliceStable(secrets.Items, func(i, j int) bool {
+		return secrets.Items[i].Name < secrets.Items[j].Name
+	})
+
+	for _, secret := range secrets.Items {
+		if secret.Type == corev1.SecretTypeTLS {
+			resources.ReferenceCountCertManagerCertificate(key, secret)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (c *GatewayController) fetchServicesForRoutes(ctx context.Context, resources *common.ResourceMap, tcpRoutes []gwv1alpha2.TCPRoute, httpRoutes []gwv1beta1.HTTPRoute, grpcRoutes []gwv1alpha2.GRPCRoute) error {
 	serviceBackends := mapset.NewSet()
 	meshServiceBackends := mapset.NewSet()
 
@@ -898,6 +1050,19 @@ func (c *GatewayController) fetchServicesForRoutes(ctx context.Context, resource
 		}
 	}
 
+	for _, route := range grpcRoutes {
+		for _, rule := range route.Spec.Rules {
+			for _, backend := range rule.BackendRefs {
+				if common.DerefEqual(backend.Group, v1alpha1.ConsulHashicorpGroup) &&
+					common.DerefEqual(backend.Kind, v1alpha1.MeshServiceKind) {
+					meshServiceBackends.Add(common.IndexedNamespacedNameWithDefault(backend.Name, backend.Namespace, route.Namespace))
+				} else if common.NilOrEqual(backend.Group, "") && common.NilOrEqual(backend.Kind, "Service") {
+					serviceBackends.Add(common.IndexedNamespacedNameWithDefault(backend.Name, backend.Namespace, route.Namespace))
+				}
+			}
+		}
+	}
+
 	for key := range meshServiceBackends.Iter() {
 		if err := c.fetchMeshService(ctx, resources, key.(types.NamespacedName)); err != nil {
 			return err
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	mapset "github.com/deckarep/golang-set"
+	logrtest "github.com/go-logr/logr/testr"
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
 	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
@@ -555,6 +556,56 @@ func TestTransformTCPRoute(t *testing.T) {
 	}
 }
 
+func TestTransformGatewayClassConfig(t *testing.T) {
+	t.Parallel()
+
+	gatewayClass := func(name, config string) *gwv1beta1.GatewayClass {
+		return &gwv1beta1.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: gwv1beta1.GatewayClassSpec{
+				ControllerName: common.GatewayClassControllerName,
+				ParametersRef: &gwv1beta1.ParametersReference{
+					Group: gwv1beta1.Group(v1alpha1.GroupVersion.Group),
+					Kind:  v1alpha1.GatewayClassConfigKind,
+					Name:  config,
+				},
+			},
+		}
+	}
+	gateway := func(name, class string) *gwv1beta1.Gateway {
+		return &gwv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       gwv1beta1.GatewaySpec{GatewayClassName: gwv1beta1.ObjectName(class)},
+		}
+	}
+
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, gwv1alpha2.Install(s))
+	require.NoError(t, gwv1beta1.Install(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+
+	fakeClient := registerFieldIndexersForTest(fake.NewClientBuilder().WithScheme(s)).WithRuntimeObjects(
+		gatewayClass("class-one", "config"),
+		gatewayClass("class-two", "config"),
+		gatewayClass("class-other", "other-config"),
+		gateway("gateway-one", "class-one"),
+		gateway("gateway-two", "class-two"),
+		gateway("gateway-other", "class-other"),
+	).Build()
+
+	controller := GatewayController{
+		Client: fakeClient,
+	}
+
+	fn := controller.transformGatewayClassConfig(context.Background())
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "gateway-one", Namespace: "default"}},
+		{NamespacedName: types.NamespacedName{Name: "gateway-two", Namespace: "default"}},
+	}, fn(&v1alpha1.GatewayClassConfig{ObjectMeta: metav1.ObjectMeta{Name: "config"}}))
+	require.Empty(t, fn(&v1alpha1.GatewayClassConfig{ObjectMeta: metav1.ObjectMeta{Name: "unused"}}))
+}
+
 func TestTransformSecret(t *testing.T) {
 	t.Parallel()
 
@@ -570,6 +621,11 @@ func TestTransformSecret(t *testing.T) {
 					CertificateRefs: []gwv1beta1.SecretObjectReference{
 						{Name: "secret-no-namespace"},
 						{Name: "secret-namespace", Namespace: common.PointerTo(gwv1beta1.Namespace("other"))},
+						{
+							Group: common.PointerTo(gwv1beta1.Group(common.CertManagerGroup)),
+							Kind:  common.PointerTo(gwv1beta1.Kind(common.KindCertificate)),
+							Name:  "certificate",
+						},
 					},
 				}},
 				{Name: "passthrough", TLS: &gwv1beta1.GatewayTLSConfig{
@@ -617,6 +673,31 @@ func TestTransformSecret(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: "passthrough", Namespace: "other"},
 			},
 		},
+		"issued for referenced cert-manager certificate": {
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "certificate-tls",
+					Namespace: "test",
+					Annotations: map[string]string{
+						common.AnnotationCertManagerCertificateName: "certificate",
+					},
+				},
+			},
+			expected: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Name: "gateway", Namespace: "test"}},
+			},
+		},
+		"issued for cert-manager certificate in another namespace": {
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "certificate-tls",
+					Namespace: "other",
+					Annotations: map[string]string{
+						common.AnnotationCertManagerCertificateName: "certificate",
+					},
+				},
+			},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			tt := tt
@@ -640,3 +721,124 @@ func TestTransformSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchCertManagerSecret(t *testing.T) {
+	t.Parallel()
+
+	certificate := types.NamespacedName{Name: "certificate", Namespace: "test"}
+	issuedFor := func(name, namespace, certificate string, secretType corev1.SecretType) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Annotations: map[string]string{
+					common.AnnotationCertManagerCertificateName: certificate,
+				},
+			},
+			Type: secretType,
+		}
+	}
+
+	for name, tt := range map[string]struct {
+		secrets  []runtime.Object
+		expected *types.NamespacedName
+	}{
+		"issued": {
+			secrets: []runtime.Object{
+				issuedFor("certificate-tls", "test", "certificate", corev1.SecretTypeTLS),
+			},
+			expected: &types.NamespacedName{Name: "certificate-tls", Namespace: "test"},
+		},
+		"not yet issued": {},
+		"issued for another certificate": {
+			secrets: []runtime.Object{
+				issuedFor("other-tls", "test", "other", corev1.SecretTypeTLS),
+			},
+		},
+		"issued in another namespace": {
+			secrets: []runtime.Object{
+				issuedFor("certificate-tls", "other", "certificate", corev1.SecretTypeTLS),
+			},
+		},
+		"ignores non-tls secrets": {
+			secrets: []runtime.Object{
+				issuedFor("a-certificate-opaque", "test", "certificate", corev1.SecretTypeOpaque),
+				issuedFor("certificate-tls", "test", "certificate", corev1.SecretTypeTLS),
+			},
+			expected: &types.NamespacedName{Name: "certificate-tls", Namespace: "test"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tt := tt
+
+			t.Parallel()
+
+			s := runtime.NewScheme()
+			require.NoError(t, clientgoscheme.AddToScheme(s))
+			require.NoError(t, gwv1alpha2.Install(s))
+			require.NoError(t, gwv1beta1.Install(s))
+			require.NoError(t, v1alpha1.AddToScheme(s))
+
+			fakeClient := registerFieldIndexersForTest(fake.NewClientBuilder().WithScheme(s)).WithRuntimeObjects(tt.secrets...).Build()
+
+			controller := GatewayController{
+				Client: fakeClient,
+			}
+
+			resources := common.NewResourceMap(common.ResourceTranslator{}, nil, logrtest.New(t))
+			require.NoError(t, controller.fetchCertManagerSecret(context.Background(), resources, certificate))
+
+			ref := gwv1beta1.SecretObjectReference{
+				Group: common.PointerTo(gwv1beta1.Group(common.CertManagerGroup)),
+				Kind:  common.PointerTo(gwv1beta1.Kind(common.KindCertificate)),
+				Name:  gwv1beta1.ObjectName(certificate.Name),
+			}
+			key, ok := resources.CertificateSecretKey(ref, certificate.Namespace)
+			if tt.expected == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, *tt.expected, key)
+			require.NotNil(t, resources.Certificate(key))
+		})
+	}
+}
+
+func TestUpdateAndResetStatus(t *testing.T) {
+	t.Parallel()
+
+	s := runtime.NewScheme()
+	require.NoError(t, gwv1beta1.Install(s))
+
+	gateway := &gwv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway", Namespace: "default"},
+		Spec:       gwv1beta1.GatewaySpec{GatewayClassName: "consul"},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(gateway).Build()
+	controller := GatewayController{
+		Client: fakeClient,
+	}
+
+	update := &gwv1beta1.Gateway{}
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "gateway", Namespace: "default"}, update))
+	update.SetFinalizers([]string{common.GatewayFinalizer})
+	update.SetAnnotations(map[string]string{"annotation": "value"})
+	update.Status.Conditions = []metav1.Condition{{Type: "Accepted", Status: metav1.ConditionTrue}}
+	require.NoError(t, controller.updateAndResetStatus(context.Background(), update))
+
+	// the status set before the update is kept for the status update
+	require.Len(t, update.Status.Conditions, 1)
+
+	actual := &gwv1beta1.Gateway{}
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "gateway", Namespace: "default"}, actual))
+	require.Equal(t, []string{common.GatewayFinalizer}, actual.Finalizers)
+	require.Equal(t, map[string]string{"annotation": "value"}, actual.Annotations)
+	require.Equal(t, gwv1beta1.ObjectName("consul"), actual.Spec.GatewayClassName)
+
+	// removing the last finalizer removes it from the object
+	update.SetFinalizers(nil)
+	require.NoError(t, controller.updateAndResetStatus(context.Background(), update))
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: "gateway", Namespace: "default"}, actual))
+	require.Empty(t, actual.Finalizers)
+}
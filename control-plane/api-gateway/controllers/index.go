@@ -6,6 +6,8 @@ package controllers
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
@@ -26,8 +28,13 @@ const (
 	TCPRoute_GatewayIndex                = "__tcproute_referencing_gateway"
 	TCPRoute_ServiceIndex                = "__tcproute_referencing_service"
 	TCPRoute_MeshServiceIndex            = "__tcproute_referencing_mesh_service"
+	GRPCRoute_GatewayIndex               = "__grpcroute_referencing_gateway"
+	GRPCRoute_ServiceIndex               = "__grpcroute_referencing_service"
+	GRPCRoute_MeshServiceIndex           = "__grpcroute_referencing_mesh_service"
 	MeshService_PeerIndex                = "__meshservice_referencing_peer"
 	Secret_GatewayIndex                  = "__secret_referencing_gateway"
+	Certificate_GatewayIndex             = "__certificate_referencing_gateway"
+	Secret_CertificateIndex              = "__secret_referencing_certificate"
 )
 
 // RegisterFieldIndexes registers all of the field indexes for the API gateway controllers.
@@ -69,6 +76,16 @@ var indexes = []index{
 		target:      &gwv1beta1.Gateway{},
 		indexerFunc: gatewayForSecret,
 	},
+	{
+		name:        Certificate_GatewayIndex,
+		target:      &gwv1beta1.Gateway{},
+		indexerFunc: gatewayForCertificate,
+	},
+	{
+		name:        Secret_CertificateIndex,
+		target:      &corev1.Secret{},
+		indexerFunc: secretForCertificate,
+	},
 	{
 		name:        HTTPRoute_GatewayIndex,
 		target:      &gwv1beta1.HTTPRoute{},
@@ -99,6 +116,21 @@ var indexes = []index{
 		target:      &gwv1alpha2.TCPRoute{},
 		indexerFunc: meshServicesForTCPRoute,
 	},
+	{
+		name:        GRPCRoute_GatewayIndex,
+		target:      &gwv1alpha2.GRPCRoute{},
+		indexerFunc: gatewaysForGRPCRoute,
+	},
+	{
+		name:        GRPCRoute_ServiceIndex,
+		target:      &gwv1alpha2.GRPCRoute{},
+		indexerFunc: servicesForGRPCRoute,
+	},
+	{
+		name:        GRPCRoute_MeshServiceIndex,
+		target:      &gwv1alpha2.GRPCRoute{},
+		indexerFunc: meshServicesForGRPCRoute,
+	},
 	{
 		name:        MeshService_PeerIndex,
 		target:      &v1alpha1.MeshService{},
@@ -143,20 +175,38 @@ func peersForMeshService(o client.Object) []string {
 }
 
 func gatewayForSecret(o client.Object) []string {
-	gateway := o.(*gwv1beta1.Gateway)
-	var secretReferences []string
+	return certificateRefsForGateway(o.(*gwv1beta1.Gateway), common.IsSecretCertificateRef)
+}
+
+// gatewayForCertificate creates an index of every cert-manager Certificate referenced by a Gateway.
+func gatewayForCertificate(o client.Object) []string {
+	return certificateRefsForGateway(o.(*gwv1beta1.Gateway), common.IsCertManagerCertificateRef)
+}
+
+func certificateRefsForGateway(gateway *gwv1beta1.Gateway, matches func(gwv1beta1.SecretObjectReference) bool) []string {
+	var references []string
 	for _, listener := range gateway.Spec.Listeners {
-		if listener.TLS == nil || *listener.TLS.Mode != gwv1beta1.TLSModeTerminate {
+		if listener.TLS == nil || (listener.TLS.Mode != nil && *listener.TLS.Mode != gwv1beta1.TLSModeTerminate) {
 			continue
 		}
 		for _, cert := range listener.TLS.CertificateRefs {
-			if common.NilOrEqual(cert.Group, "") && common.NilOrEqual(cert.Kind, "Secret") {
-				// If an explicit Secret namespace is not provided, use the Gateway namespace to lookup the provided Secret Name.
-				secretReferences = append(secretReferences, common.IndexedNamespacedNameWithDefault(cert.Name, cert.Namespace, gateway.Namespace).String())
+			if matches(cert) {
+				// If an explicit namespace is not provided, use the Gateway namespace to lookup the provided name.
+				references = append(references, common.IndexedNamespacedNameWithDefault(cert.Name, cert.Namespace, gateway.Namespace).String())
 			}
 		}
 	}
-	return secretReferences
+	return references
+}
+
+// secretForCertificate creates an index of the cert-manager Certificate that a Secret was issued for.
+func secretForCertificate(o client.Object) []string {
+	secret := o.(*corev1.Secret)
+	name, ok := secret.Annotations[common.AnnotationCertManagerCertificateName]
+	if !ok || name == "" {
+		return nil
+	}
+	return []string{types.NamespacedName{Namespace: secret.Namespace, Name: name}.String()}
 }
 
 func gatewaysForHTTPRoute(o client.Object) []string {
@@ -175,6 +225,14 @@ func gatewaysForTCPRoute(o client.Object) []string {
 	return gatewaysForRoute(route.Namespace, route.Spec.ParentRefs, statusRefs)
 }
 
+func gatewaysForGRPCRoute(o client.Object) []string {
+	route := o.(*gwv1alpha2.GRPCRoute)
+	statusRefs := common.ConvertSliceFunc(route.Status.Parents, func(parentStatus gwv1beta1.RouteParentStatus) gwv1beta1.ParentReference {
+		return parentStatus.ParentRef
+	})
+	return gatewaysForRoute(route.Namespace, route.Spec.ParentRefs, statusRefs)
+}
+
 func servicesForHTTPRoute(o client.Object) []string {
 	route := o.(*gwv1beta1.HTTPRoute)
 	refs := []string{}
@@ -255,6 +313,46 @@ func meshServicesForTCPRoute(o client.Object) []string {
 	return refs
 }
 
+func servicesForGRPCRoute(o client.Object) []string {
+	route := o.(*gwv1alpha2.GRPCRoute)
+	refs := []string{}
+	for _, rule := range route.Spec.Rules {
+	BACKEND_LOOP:
+		for _, ref := range rule.BackendRefs {
+			if common.NilOrEqual(ref.Group, "") && common.NilOrEqual(ref.Kind, common.KindService) {
+				backendRef := common.IndexedNamespacedNameWithDefault(ref.Name, ref.Namespace, route.Namespace).String()
+				for _, member := range refs {
+					if member == backendRef {
+						continue BACKEND_LOOP
+					}
+				}
+				refs = append(refs, backendRef)
+			}
+		}
+	}
+	return refs
+}
+
+func meshServicesForGRPCRoute(o client.Object) []string {
+	route := o.(*gwv1alpha2.GRPCRoute)
+	refs := []string{}
+	for _, rule := range route.Spec.Rules {
+	BACKEND_LOOP:
+		for _, ref := range rule.BackendRefs {
+			if common.DerefEqual(ref.Group, v1alpha1.ConsulHashicorpGroup) && common.DerefEqual(ref.Kind, v1alpha1.MeshServiceKind) {
+				backendRef := common.IndexedNamespacedNameWithDefault(ref.Name, ref.Namespace, route.Namespace).String()
+				for _, member := range refs {
+					if member == backendRef {
+						continue BACKEND_LOOP
+					}
+				}
+				refs = append(refs, backendRef)
+			}
+		}
+	}
+	return refs
+}
+
 func gatewaysForRoute(namespace string, refs []gwv1beta1.ParentReference, statusRefs []gwv1beta1.ParentReference) []string {
 	var references []string
 	for _, parent := range refs {
@@ -11,6 +11,7 @@ import (
 	"k8s.io/utils/pointer"
 
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
+	injectcommon "github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -74,7 +75,7 @@ func consulDataplaneContainer(config common.HelmConfig, name, namespace string)
 			},
 			{
 				Name:  "DP_SERVICE_NODE_NAME",
-				Value: "$(NODE_NAME)-virtual",
+				Value: injectcommon.ConsulNodeNameFromK8sNode("$(NODE_NAME)", config.ClusterName),
 			},
 		},
 		VolumeMounts: []corev1.VolumeMount{
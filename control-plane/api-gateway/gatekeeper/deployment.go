@@ -12,6 +12,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -128,28 +129,40 @@ func (g *Gatekeeper) deployment(gateway gwv1beta1.Gateway, gcc v1alpha1.GatewayC
 					Containers: []corev1.Container{
 						container,
 					},
-					Affinity: &corev1.Affinity{
-						PodAntiAffinity: &corev1.PodAntiAffinity{
-							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
-								{
-									Weight: 1,
-									PodAffinityTerm: corev1.PodAffinityTerm{
-										LabelSelector: &metav1.LabelSelector{
-											MatchLabels: common.LabelsForGateway(&gateway),
-										},
-										TopologyKey: "kubernetes.io/hostname",
-									},
-								},
-							},
+					Affinity:                  deploymentAffinity(gateway, gcc),
+					NodeSelector:              gcc.Spec.NodeSelector,
+					Tolerations:               gcc.Spec.Tolerations,
+					TopologySpreadConstraints: gcc.Spec.TopologySpreadConstraints,
+					PriorityClassName:         gcc.Spec.PriorityClassName,
+					ServiceAccountName:        g.serviceAccountName(gateway, config),
+				},
+			},
+		},
+	}, nil
+}
+
+// deploymentAffinity returns the affinity configured on the GatewayClassConfig,
+// falling back to preferring that gateway pods are spread across nodes.
+func deploymentAffinity(gateway gwv1beta1.Gateway, gcc v1alpha1.GatewayClassConfig) *corev1.Affinity {
+	if gcc.Spec.Affinity != nil {
+		return gcc.Spec.Affinity
+	}
+
+	return &corev1.Affinity{
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{
+					Weight: 1,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: common.LabelsForGateway(&gateway),
 						},
+						TopologyKey: "kubernetes.io/hostname",
 					},
-					NodeSelector:       gcc.Spec.NodeSelector,
-					Tolerations:        gcc.Spec.Tolerations,
-					ServiceAccountName: g.serviceAccountName(gateway, config),
 				},
 			},
 		},
-	}, nil
+	}
 }
 
 func mergeDeployments(gcc v1alpha1.GatewayClassConfig, a, b *appsv1.Deployment) *appsv1.Deployment {
@@ -184,6 +197,12 @@ func compareDeployments(a, b *appsv1.Deployment) bool {
 		}
 	}
 
+	// scheduling constraints come from the GatewayClassConfig and may change
+	// independently of the gateway itself
+	if !compareScheduling(a.Spec.Template.Spec, b.Spec.Template.Spec) {
+		return false
+	}
+
 	if b.Spec.Replicas == nil && a.Spec.Replicas == nil {
 		return true
 	} else if b.Spec.Replicas == nil {
@@ -195,6 +214,14 @@ func compareDeployments(a, b *appsv1.Deployment) bool {
 	return *b.Spec.Replicas == *a.Spec.Replicas
 }
 
+func compareScheduling(a, b corev1.PodSpec) bool {
+	return equality.Semantic.DeepEqual(a.NodeSelector, b.NodeSelector) &&
+		equality.Semantic.DeepEqual(a.Tolerations, b.Tolerations) &&
+		equality.Semantic.DeepEqual(a.Affinity, b.Affinity) &&
+		equality.Semantic.DeepEqual(a.TopologySpreadConstraints, b.TopologySpreadConstraints) &&
+		a.PriorityClassName == b.PriorityClassName
+}
+
 func newDeploymentMutator(deployment, mutated *appsv1.Deployment, gcc v1alpha1.GatewayClassConfig, gateway gwv1beta1.Gateway, scheme *runtime.Scheme) resourceMutator {
 	return func() error {
 		mutated = mergeDeployments(gcc, deployment, mutated)
@@ -47,6 +47,39 @@ var (
 			Protocol: "TCP",
 		},
 	}
+	nodeSelector = map[string]string{"pool": "edge"}
+	tolerations  = []corev1.Toleration{
+		{
+			Key:      "edge",
+			Operator: corev1.TolerationOpExists,
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+	}
+	affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      "topology.kubernetes.io/zone",
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{"us-east-1a", "us-east-1b"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	topologySpreadConstraints = []corev1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       "topology.kubernetes.io/zone",
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+		},
+	}
+	priorityClassName = "gateway-critical"
 )
 
 type testCase struct {
@@ -479,6 +512,83 @@ func TestUpsert(t *testing.T) {
 				serviceAccounts: []*corev1.ServiceAccount{},
 			},
 		},
+		"create a new gateway deployment with scheduling constraints": {
+			gateway: gwv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: gwv1beta1.GatewaySpec{
+					Listeners: listeners,
+				},
+			},
+			gatewayClassConfig: v1alpha1.GatewayClassConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "consul-gatewayclassconfig",
+				},
+				Spec: v1alpha1.GatewayClassConfigSpec{
+					DeploymentSpec: v1alpha1.DeploymentSpec{
+						DefaultInstances: common.PointerTo(int32(1)),
+					},
+					CopyAnnotations:           v1alpha1.CopyAnnotationsSpec{},
+					ServiceType:               (*corev1.ServiceType)(common.PointerTo("NodePort")),
+					NodeSelector:              nodeSelector,
+					Tolerations:               tolerations,
+					Affinity:                  affinity,
+					TopologySpreadConstraints: topologySpreadConstraints,
+					PriorityClassName:         priorityClassName,
+				},
+			},
+			helmConfig:       common.HelmConfig{},
+			initialResources: resources{},
+			finalResources: resources{
+				deployments: []*appsv1.Deployment{
+					withScheduling(configureDeployment(name, namespace, labels, 1, nodeSelector, tolerations, "", "1"), affinity, topologySpreadConstraints, priorityClassName),
+				},
+				roles:           []*rbac.Role{},
+				services:        []*corev1.Service{},
+				serviceAccounts: []*corev1.ServiceAccount{},
+			},
+		},
+		"update a gateway deployment when the scheduling constraints on the GatewayClassConfig change": {
+			gateway: gwv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: gwv1beta1.GatewaySpec{
+					Listeners: listeners,
+				},
+			},
+			gatewayClassConfig: v1alpha1.GatewayClassConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "consul-gatewayclassconfig",
+				},
+				Spec: v1alpha1.GatewayClassConfigSpec{
+					CopyAnnotations:           v1alpha1.CopyAnnotationsSpec{},
+					ServiceType:               (*corev1.ServiceType)(common.PointerTo("NodePort")),
+					NodeSelector:              nodeSelector,
+					Tolerations:               tolerations,
+					Affinity:                  affinity,
+					TopologySpreadConstraints: topologySpreadConstraints,
+					PriorityClassName:         priorityClassName,
+				},
+			},
+			helmConfig: common.HelmConfig{},
+			initialResources: resources{
+				deployments: []*appsv1.Deployment{
+					withScheduling(configureDeployment(name, namespace, labels, 1, nil, nil, "", "1"), nil, nil, "gateway-low"),
+				},
+			},
+			finalResources: resources{
+				deployments: []*appsv1.Deployment{
+					withScheduling(configureDeployment(name, namespace, labels, 1, nodeSelector, tolerations, "", "2"), affinity, topologySpreadConstraints, priorityClassName),
+				},
+				roles:           []*rbac.Role{},
+				services:        []*corev1.Service{},
+				serviceAccounts: []*corev1.ServiceAccount{},
+			},
+		},
 		"update a gateway deployment by scaling it when no min or max number of instances is defined on the GatewayClassConfig": {
 			gateway: gwv1beta1.Gateway{
 				ObjectMeta: metav1.ObjectMeta{
@@ -847,6 +957,11 @@ func validateResourcesExist(t *testing.T, client client.Client, resources resour
 		actual.Labels[createdAtLabelKey] = createdAtLabelValue
 		actual.Spec.Selector.MatchLabels[createdAtLabelKey] = createdAtLabelValue
 		actual.Spec.Template.ObjectMeta.Labels[createdAtLabelKey] = createdAtLabelValue
+		if actual.Spec.Template.Spec.Affinity != nil && actual.Spec.Template.Spec.Affinity.PodAntiAffinity != nil {
+			for _, term := range actual.Spec.Template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+				term.PodAffinityTerm.LabelSelector.MatchLabels[createdAtLabelKey] = createdAtLabelValue
+			}
+		}
 
 		require.Equal(t, expected.Name, actual.Name)
 		require.Equal(t, expected.Namespace, actual.Namespace)
@@ -856,6 +971,11 @@ func validateResourcesExist(t *testing.T, client client.Client, resources resour
 			require.NotNil(t, actual.Spec.Replicas)
 			require.EqualValues(t, *expected.Spec.Replicas, *actual.Spec.Replicas)
 		}
+		require.Equal(t, expected.Spec.Template.Spec.NodeSelector, actual.Spec.Template.Spec.NodeSelector)
+		require.Equal(t, expected.Spec.Template.Spec.Tolerations, actual.Spec.Template.Spec.Tolerations)
+		require.Equal(t, expected.Spec.Template.Spec.Affinity, actual.Spec.Template.Spec.Affinity)
+		require.Equal(t, expected.Spec.Template.Spec.TopologySpreadConstraints, actual.Spec.Template.Spec.TopologySpreadConstraints)
+		require.Equal(t, expected.Spec.Template.Spec.PriorityClassName, actual.Spec.Template.Spec.PriorityClassName)
 	}
 
 	for _, expected := range resources.roles {
@@ -1050,6 +1170,13 @@ func configureDeployment(name, namespace string, labels map[string]string, repli
 	}
 }
 
+func withScheduling(deployment *appsv1.Deployment, affinity *corev1.Affinity, topologySpreadConstraints []corev1.TopologySpreadConstraint, priorityClassName string) *appsv1.Deployment {
+	deployment.Spec.Template.Spec.Affinity = affinity
+	deployment.Spec.Template.Spec.TopologySpreadConstraints = topologySpreadConstraints
+	deployment.Spec.Template.Spec.PriorityClassName = priorityClassName
+	return deployment
+}
+
 func configureRole(name, namespace string, labels map[string]string, resourceVersion string) *rbac.Role {
 	return &rbac.Role{
 		TypeMeta: metav1.TypeMeta{
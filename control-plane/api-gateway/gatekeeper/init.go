@@ -12,6 +12,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
+	injectcommon "github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 	"k8s.io/utils/pointer"
 )
@@ -109,7 +110,7 @@ func initContainer(config common.HelmConfig, name, namespace string) (corev1.Con
 			},
 			{
 				Name:  "CONSUL_NODE_NAME",
-				Value: "$(NODE_NAME)-virtual",
+				Value: injectcommon.ConsulNodeNameFromK8sNode("$(NODE_NAME)", config.ClusterName),
 			},
 		},
 		VolumeMounts: volMounts,
@@ -28,5 +28,6 @@ const (
 	DatacenterKey    string = "consul.hashicorp.com/source-datacenter"
 	MigrateEntryKey  string = "consul.hashicorp.com/migrate-entry"
 	MigrateEntryTrue string = "true"
+	PartitionKey     string = "consul.hashicorp.com/partition"
 	SourceValue      string = "kubernetes"
 )
@@ -45,10 +45,21 @@ type ConfigEntryResource interface {
 	SetSyncedCondition(status corev1.ConditionStatus, reason, message string)
 	// SetLastSyncedTime updates the last synced time.
 	SetLastSyncedTime(time *metav1.Time)
+	// SetLastSyncedGeneration updates the generation of the resource that
+	// was last synced.
+	SetLastSyncedGeneration(generation int64)
+	// GetLastSyncedGeneration returns the generation of the resource that was
+	// last synced.
+	GetLastSyncedGeneration() int64
 	// SyncedCondition gets the synced condition.
 	SyncedCondition() (status corev1.ConditionStatus, reason, message string)
 	// SyncedConditionStatus returns the status of the synced condition.
 	SyncedConditionStatus() corev1.ConditionStatus
+	// SetCondition updates the condition of the given type, e.g. "Applied",
+	// without changing the other conditions.
+	SetCondition(conditionType string, status corev1.ConditionStatus, reason, message string)
+	// ConditionStatus returns the status of the condition of the given type.
+	ConditionStatus(conditionType string) corev1.ConditionStatus
 	// ToConsul converts the resource to the corresponding Consul API definition.
 	// Its return type is the generic ConfigEntry but a specific config entry
 	// type should be constructed e.g. ServiceConfigEntry.
@@ -99,4 +110,13 @@ type ConsulMeta struct {
 	// service in the k8s `staging` namespace will be registered into the
 	// `k8s-staging` Consul namespace.
 	Prefix string
+
+	// ConfigEntryPartitions are the admin partitions that config entries may
+	// be written to with the partition annotation. It is only supported when
+	// running in the default partition.
+	ConfigEntryPartitions []string
+
+	// ConsulValidator, if set, is used by the webhooks to validate config
+	// entries against the state of the Consul servers.
+	ConsulValidator ConsulValidator
 }
@@ -10,8 +10,10 @@ import (
 	"net/http"
 
 	"github.com/go-logr/logr"
+	"golang.org/x/exp/slices"
 	"gomodules.xyz/jsonpatch/v2"
 	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -22,6 +24,15 @@ type ConfigEntryLister interface {
 	List(ctx context.Context) ([]ConfigEntryResource, error)
 }
 
+// ConsulValidator validates config entries against the state of the Consul
+// servers. It catches errors that can't be detected from the resource alone,
+// such as routing a service whose protocol doesn't support it, which would
+// otherwise only surface as a failed sync once the entry is written to Consul.
+type ConsulValidator interface {
+	// ValidateWithConsul returns an error if Consul would reject cfgEntry.
+	ValidateWithConsul(ctx context.Context, cfgEntry ConfigEntryResource, consulMeta ConsulMeta) error
+}
+
 // ValidateConfigEntry validates cfgEntry. It is a generic method that
 // can be used by all CRD-specific validators.
 // Callers should pass themselves as validator and kind should be the custom
@@ -52,7 +63,7 @@ func ValidateConfigEntry(
 			return admission.Errored(http.StatusInternalServerError, err)
 		}
 		for _, item := range list {
-			if item.KubernetesName() == cfgEntry.KubernetesName() {
+			if item.KubernetesName() == cfgEntry.KubernetesName() && PartitionAnnotation(item) == PartitionAnnotation(cfgEntry) {
 				return admission.Errored(http.StatusBadRequest,
 					fmt.Errorf("%s resource with name %q is already defined – all %s resources must have unique names across namespaces",
 						cfgEntry.KubeKind(),
@@ -61,12 +72,54 @@ func ValidateConfigEntry(
 			}
 		}
 	}
+	consulMeta, err = WithPartitionAnnotation(req, cfgEntry, consulMeta)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
 	if err := cfgEntry.Validate(consulMeta); err != nil {
 		return admission.Errored(http.StatusBadRequest, err)
 	}
+	if consulMeta.ConsulValidator != nil {
+		if err := consulMeta.ConsulValidator.ValidateWithConsul(ctx, cfgEntry, consulMeta); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
 	return admission.Patched(fmt.Sprintf("valid %s request", cfgEntry.KubeKind()), defaultingPatches...)
 }
 
+// PartitionAnnotation returns the admin partition that cfgEntry is written to
+// if it is set with the partition annotation.
+func PartitionAnnotation(cfgEntry ConfigEntryResource) string {
+	return cfgEntry.GetObjectMeta().Annotations[PartitionKey]
+}
+
+// WithPartitionAnnotation returns consulMeta with its partition set to the
+// partition annotation of cfgEntry. It returns an error if the annotation is
+// changed on update, since the config entry would otherwise be left behind
+// in the previous partition, or if config entries can't be written to the
+// partition.
+func WithPartitionAnnotation(req admission.Request, cfgEntry ConfigEntryResource, consulMeta ConsulMeta) (ConsulMeta, error) {
+	partition := PartitionAnnotation(cfgEntry)
+	if req.Operation == admissionv1.Update {
+		var prev metav1.PartialObjectMetadata
+		if err := json.Unmarshal(req.OldObject.Raw, &prev); err != nil {
+			return consulMeta, fmt.Errorf("unmarshalling previous object: %s", err)
+		}
+		if prev.Annotations[PartitionKey] != partition {
+			return consulMeta, fmt.Errorf("the %s annotation cannot be changed", PartitionKey)
+		}
+	}
+	if partition == "" {
+		return consulMeta, nil
+	}
+	if !consulMeta.PartitionsEnabled || !slices.Contains(consulMeta.ConfigEntryPartitions, partition) {
+		return consulMeta, fmt.Errorf("the %s annotation must be one of the partitions config entries are managed in: %q",
+			PartitionKey, consulMeta.ConfigEntryPartitions)
+	}
+	consulMeta.Partition = partition
+	return consulMeta, nil
+}
+
 // DefaultingPatches returns the patches needed to set fields to their
 // defaults.
 func DefaultingPatches(cfgEntry ConfigEntryResource, consulMeta ConsulMeta) ([]jsonpatch.Operation, error) {
@@ -26,14 +26,20 @@ func TestValidateConfigEntry(t *testing.T) {
 	otherNS := "other"
 
 	cases := map[string]struct {
-		existingResources   []ConfigEntryResource
-		newResource         ConfigEntryResource
-		enableNamespaces    bool
-		nsMirroring         bool
-		consulDestinationNS string
-		nsMirroringPrefix   string
-		expAllow            bool
-		expErrMessage       string
+		existingResources     []ConfigEntryResource
+		newResource           ConfigEntryResource
+		enableNamespaces      bool
+		nsMirroring           bool
+		consulDestinationNS   string
+		nsMirroringPrefix     string
+		configEntryPartitions []string
+		// update is true if the request updates a resource with
+		// oldAnnotations.
+		update          bool
+		oldAnnotations  map[string]string
+		consulValidator ConsulValidator
+		expAllow        bool
+		expErrMessage   string
 	}{
 		"no duplicates, valid": {
 			existingResources: nil,
@@ -95,6 +101,97 @@ func TestValidateConfigEntry(t *testing.T) {
 			nsMirroring:      true,
 			expAllow:         true,
 		},
+		"duplicate name in another partition": {
+			existingResources: []ConfigEntryResource{&mockConfigEntry{
+				MockName:      "foo",
+				MockNamespace: "default",
+			}},
+			newResource: &mockConfigEntry{
+				MockName:        "foo",
+				MockNamespace:   otherNS,
+				MockAnnotations: map[string]string{PartitionKey: "ap1"},
+				Valid:           true,
+			},
+			configEntryPartitions: []string{"ap1"},
+			expAllow:              true,
+		},
+		"partition annotation without config entry partitions": {
+			newResource: &mockConfigEntry{
+				MockName:        "foo",
+				MockNamespace:   otherNS,
+				MockAnnotations: map[string]string{PartitionKey: "ap1"},
+				Valid:           true,
+			},
+			expAllow:      false,
+			expErrMessage: `the consul.hashicorp.com/partition annotation must be one of the partitions config entries are managed in: []`,
+		},
+		"partition annotation not in config entry partitions": {
+			newResource: &mockConfigEntry{
+				MockName:        "foo",
+				MockNamespace:   otherNS,
+				MockAnnotations: map[string]string{PartitionKey: "ap2"},
+				Valid:           true,
+			},
+			configEntryPartitions: []string{"ap1"},
+			expAllow:              false,
+			expErrMessage:         `the consul.hashicorp.com/partition annotation must be one of the partitions config entries are managed in: ["ap1"]`,
+		},
+		"partition annotation unchanged on update": {
+			newResource: &mockConfigEntry{
+				MockName:        "foo",
+				MockNamespace:   otherNS,
+				MockAnnotations: map[string]string{PartitionKey: "ap1"},
+				Valid:           true,
+			},
+			configEntryPartitions: []string{"ap1"},
+			update:                true,
+			oldAnnotations:        map[string]string{PartitionKey: "ap1"},
+			expAllow:              true,
+		},
+		"partition annotation changed on update": {
+			newResource: &mockConfigEntry{
+				MockName:        "foo",
+				MockNamespace:   otherNS,
+				MockAnnotations: map[string]string{PartitionKey: "ap2"},
+				Valid:           true,
+			},
+			configEntryPartitions: []string{"ap1", "ap2"},
+			update:                true,
+			oldAnnotations:        map[string]string{PartitionKey: "ap1"},
+			expAllow:              false,
+			expErrMessage:         "the consul.hashicorp.com/partition annotation cannot be changed",
+		},
+		"partition annotation added on update": {
+			newResource: &mockConfigEntry{
+				MockName:        "foo",
+				MockNamespace:   otherNS,
+				MockAnnotations: map[string]string{PartitionKey: "ap1"},
+				Valid:           true,
+			},
+			configEntryPartitions: []string{"ap1"},
+			update:                true,
+			expAllow:              false,
+			expErrMessage:         "the consul.hashicorp.com/partition annotation cannot be changed",
+		},
+		"valid, consul validation passes": {
+			newResource: &mockConfigEntry{
+				MockName:      "foo",
+				MockNamespace: otherNS,
+				Valid:         true,
+			},
+			consulValidator: &mockConsulValidator{},
+			expAllow:        true,
+		},
+		"valid, consul validation fails": {
+			newResource: &mockConfigEntry{
+				MockName:      "foo",
+				MockNamespace: otherNS,
+				Valid:         true,
+			},
+			consulValidator: &mockConsulValidator{Err: errors.New("rejected by consul")},
+			expAllow:        false,
+			expErrMessage:   "rejected by consul",
+		},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -105,7 +202,7 @@ func TestValidateConfigEntry(t *testing.T) {
 			lister := &mockConfigEntryLister{
 				Resources: c.existingResources,
 			}
-			response := ValidateConfigEntry(ctx, admission.Request{
+			req := admission.Request{
 				AdmissionRequest: admissionv1.AdmissionRequest{
 					Name:      c.newResource.KubernetesName(),
 					Namespace: otherNS,
@@ -114,7 +211,16 @@ func TestValidateConfigEntry(t *testing.T) {
 						Raw: marshalledRequestObject,
 					},
 				},
-			},
+			}
+			if c.update {
+				marshalledOldObject, err := json.Marshal(metav1.PartialObjectMetadata{
+					ObjectMeta: metav1.ObjectMeta{Annotations: c.oldAnnotations},
+				})
+				require.NoError(t, err)
+				req.Operation = admissionv1.Update
+				req.OldObject = runtime.RawExtension{Raw: marshalledOldObject}
+			}
+			response := ValidateConfigEntry(ctx, req,
 				logrtest.New(t),
 				lister,
 				c.newResource,
@@ -123,6 +229,10 @@ func TestValidateConfigEntry(t *testing.T) {
 					DestinationNamespace: c.consulDestinationNS,
 					Mirroring:            c.nsMirroring,
 					Prefix:               c.nsMirroringPrefix,
+					ConsulValidator:      c.consulValidator,
+
+					PartitionsEnabled:     c.configEntryPartitions != nil,
+					ConfigEntryPartitions: c.configEntryPartitions,
 				})
 			require.Equal(t, c.expAllow, response.Allowed)
 			if c.expErrMessage != "" {
@@ -159,10 +269,19 @@ func (in *mockConfigEntryLister) List(_ context.Context) ([]ConfigEntryResource,
 	return in.Resources, nil
 }
 
+type mockConsulValidator struct {
+	Err error
+}
+
+func (v *mockConsulValidator) ValidateWithConsul(_ context.Context, _ ConfigEntryResource, _ ConsulMeta) error {
+	return v.Err
+}
+
 type mockConfigEntry struct {
-	MockName      string
-	MockNamespace string
-	Valid         bool
+	MockName        string
+	MockNamespace   string
+	MockAnnotations map[string]string
+	Valid           bool
 }
 
 func (in *mockConfigEntry) GetNamespace() string {
@@ -236,7 +355,7 @@ func (in *mockConfigEntry) GetLabels() map[string]string {
 func (in *mockConfigEntry) SetLabels(_ map[string]string) {}
 
 func (in *mockConfigEntry) GetAnnotations() map[string]string {
-	return nil
+	return in.MockAnnotations
 }
 
 func (in *mockConfigEntry) SetAnnotations(_ map[string]string) {}
@@ -274,7 +393,7 @@ func (in *mockConfigEntry) ConsulMirroringNS() string {
 }
 
 func (in *mockConfigEntry) GetObjectMeta() metav1.ObjectMeta {
-	return metav1.ObjectMeta{}
+	return metav1.ObjectMeta{Annotations: in.MockAnnotations}
 }
 
 func (in *mockConfigEntry) GetObjectKind() schema.ObjectKind {
@@ -313,6 +432,12 @@ func (in *mockConfigEntry) SetSyncedCondition(_ corev1.ConditionStatus, _ string
 
 func (in *mockConfigEntry) SetLastSyncedTime(_ *metav1.Time) {}
 
+func (in *mockConfigEntry) SetLastSyncedGeneration(_ int64) {}
+
+func (in *mockConfigEntry) GetLastSyncedGeneration() int64 {
+	return 0
+}
+
 func (in *mockConfigEntry) SyncedCondition() (status corev1.ConditionStatus, reason string, message string) {
 	return corev1.ConditionTrue, "", ""
 }
@@ -321,6 +446,12 @@ func (in *mockConfigEntry) SyncedConditionStatus() corev1.ConditionStatus {
 	return corev1.ConditionTrue
 }
 
+func (in *mockConfigEntry) SetCondition(_ string, _ corev1.ConditionStatus, _ string, _ string) {}
+
+func (in *mockConfigEntry) ConditionStatus(_ string) corev1.ConditionStatus {
+	return corev1.ConditionTrue
+}
+
 func (in *mockConfigEntry) ToConsul(string) capi.ConfigEntry {
 	return &capi.ServiceConfigEntry{}
 }
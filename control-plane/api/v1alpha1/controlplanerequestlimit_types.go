@@ -29,6 +29,7 @@ func init() {
 
 // ControlPlaneRequestLimit is the Schema for the controlplanerequestlimits API.
 // +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status",description="The sync status of the resource with Consul"
+// +kubebuilder:printcolumn:name="Last Synced",type="date",JSONPath=".status.lastSyncedTime",description="The last successful synced time of the resource with Consul"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="The age of the resource"
 type ControlPlaneRequestLimit struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -48,7 +49,9 @@ type ControlPlaneRequestLimitList struct {
 }
 
 type ReadWriteRatesConfig struct {
-	ReadRate  float64 `json:"readRate,omitempty"`
+	// ReadRate is the maximum number of read requests per second.
+	ReadRate float64 `json:"readRate,omitempty"`
+	// WriteRate is the maximum number of write requests per second.
 	WriteRate float64 `json:"writeRate,omitempty"`
 }
 
@@ -81,21 +84,63 @@ func (c *ReadWriteRatesConfig) validate(path *field.Path) field.ErrorList {
 
 // ControlPlaneRequestLimitSpec defines the desired state of ControlPlaneRequestLimit.
 type ControlPlaneRequestLimitSpec struct {
-	Mode                 string `json:"mode,omitempty"`
+	// Mode is the rate limiting mode: permissive, enforcing or disabled.
+	// In permissive mode, requests over the limits are only logged.
+	Mode string `json:"mode,omitempty"`
+	// ReadRate and WriteRate are the overall limits of requests to each server.
 	ReadWriteRatesConfig `json:",inline"`
-	ACL                  *ReadWriteRatesConfig `json:"acl,omitempty"`
-	Catalog              *ReadWriteRatesConfig `json:"catalog,omitempty"`
-	ConfigEntry          *ReadWriteRatesConfig `json:"configEntry,omitempty"`
-	ConnectCA            *ReadWriteRatesConfig `json:"connectCA,omitempty"`
-	Coordinate           *ReadWriteRatesConfig `json:"coordinate,omitempty"`
-	DiscoveryChain       *ReadWriteRatesConfig `json:"discoveryChain,omitempty"`
-	Health               *ReadWriteRatesConfig `json:"health,omitempty"`
-	Intention            *ReadWriteRatesConfig `json:"intention,omitempty"`
-	KV                   *ReadWriteRatesConfig `json:"kv,omitempty"`
-	Tenancy              *ReadWriteRatesConfig `json:"tenancy,omitempty"`
-	PreparedQuery        *ReadWriteRatesConfig `json:"perparedQuery,omitempty"`
-	Session              *ReadWriteRatesConfig `json:"session,omitempty"`
-	Txn                  *ReadWriteRatesConfig `json:"txn,omitempty"`
+	// ACL limits the rate of ACL requests.
+	ACL *ReadWriteRatesConfig `json:"acl,omitempty"`
+	// Catalog limits the rate of catalog requests.
+	Catalog *ReadWriteRatesConfig `json:"catalog,omitempty"`
+	// ConfigEntry limits the rate of config entry requests.
+	ConfigEntry *ReadWriteRatesConfig `json:"configEntry,omitempty"`
+	// ConnectCA limits the rate of Connect CA requests.
+	ConnectCA *ReadWriteRatesConfig `json:"connectCA,omitempty"`
+	// Coordinate limits the rate of network coordinate requests.
+	Coordinate *ReadWriteRatesConfig `json:"coordinate,omitempty"`
+	// DiscoveryChain limits the rate of discovery chain requests.
+	DiscoveryChain *ReadWriteRatesConfig `json:"discoveryChain,omitempty"`
+	// ServerDiscovery limits the rate of server discovery requests.
+	ServerDiscovery *ReadWriteRatesConfig `json:"serverDiscovery,omitempty"`
+	// Health limits the rate of health requests.
+	Health *ReadWriteRatesConfig `json:"health,omitempty"`
+	// Intention limits the rate of intention requests.
+	Intention *ReadWriteRatesConfig `json:"intention,omitempty"`
+	// KV limits the rate of KV store requests.
+	KV *ReadWriteRatesConfig `json:"kv,omitempty"`
+	// Tenancy limits the rate of partition and namespace requests.
+	Tenancy *ReadWriteRatesConfig `json:"tenancy,omitempty"`
+	// PreparedQuery limits the rate of prepared query requests.
+	PreparedQuery *ReadWriteRatesConfig `json:"preparedQuery,omitempty"`
+	// DeprecatedPreparedQuery is the misspelled field that PreparedQuery was
+	// previously read from. It is still read if PreparedQuery isn't set.
+	//
+	// Deprecated: use preparedQuery instead.
+	DeprecatedPreparedQuery *ReadWriteRatesConfig `json:"perparedQuery,omitempty"`
+	// Session limits the rate of session requests.
+	Session *ReadWriteRatesConfig `json:"session,omitempty"`
+	// Txn limits the rate of transaction requests.
+	Txn *ReadWriteRatesConfig `json:"txn,omitempty"`
+	// AutoConfig limits the rate of auto config requests.
+	AutoConfig *ReadWriteRatesConfig `json:"autoConfig,omitempty"`
+	// FederationState limits the rate of federation state requests.
+	FederationState *ReadWriteRatesConfig `json:"federationState,omitempty"`
+	// Internal limits the rate of internal requests, e.g. from the UI.
+	Internal *ReadWriteRatesConfig `json:"internal,omitempty"`
+	// PeerStream limits the rate of peering stream requests.
+	PeerStream *ReadWriteRatesConfig `json:"peerStream,omitempty"`
+	// Peering limits the rate of peering requests.
+	Peering *ReadWriteRatesConfig `json:"peering,omitempty"`
+}
+
+// preparedQuery returns the prepared query limits, which are read from the
+// deprecated perparedQuery field if preparedQuery isn't set.
+func (s ControlPlaneRequestLimitSpec) preparedQuery() *ReadWriteRatesConfig {
+	if s.PreparedQuery != nil {
+		return s.PreparedQuery
+	}
+	return s.DeprecatedPreparedQuery
 }
 
 // GetObjectMeta returns object meta.
@@ -161,15 +206,7 @@ func (c *ControlPlaneRequestLimit) KubernetesName() string {
 
 // SetSyncedCondition updates the synced condition.
 func (c *ControlPlaneRequestLimit) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	c.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	c.Status.setSyncedCondition(c.Generation, status, reason, message)
 }
 
 // SetLastSyncedTime updates the last synced time.
@@ -200,25 +237,31 @@ func (c *ControlPlaneRequestLimit) SyncedConditionStatus() corev1.ConditionStatu
 // type should be constructed e.g. ServiceConfigEntry.
 func (c *ControlPlaneRequestLimit) ToConsul(datacenter string) consul.ConfigEntry {
 	return &consul.RateLimitIPConfigEntry{
-		Kind:           c.ConsulKind(),
-		Name:           c.ConsulName(),
-		Mode:           c.Spec.Mode,
-		ReadRate:       c.Spec.ReadRate,
-		WriteRate:      c.Spec.WriteRate,
-		Meta:           meta(datacenter),
-		ACL:            c.Spec.ACL.toConsul(),
-		Catalog:        c.Spec.Catalog.toConsul(),
-		ConfigEntry:    c.Spec.ConfigEntry.toConsul(),
-		ConnectCA:      c.Spec.ConnectCA.toConsul(),
-		Coordinate:     c.Spec.Coordinate.toConsul(),
-		DiscoveryChain: c.Spec.DiscoveryChain.toConsul(),
-		Health:         c.Spec.Health.toConsul(),
-		Intention:      c.Spec.Intention.toConsul(),
-		KV:             c.Spec.KV.toConsul(),
-		Tenancy:        c.Spec.Tenancy.toConsul(),
-		PreparedQuery:  c.Spec.PreparedQuery.toConsul(),
-		Session:        c.Spec.Session.toConsul(),
-		Txn:            c.Spec.Txn.toConsul(),
+		Kind:            c.ConsulKind(),
+		Name:            c.ConsulName(),
+		Mode:            c.Spec.Mode,
+		ReadRate:        c.Spec.ReadRate,
+		WriteRate:       c.Spec.WriteRate,
+		Meta:            meta(datacenter),
+		ACL:             c.Spec.ACL.toConsul(),
+		Catalog:         c.Spec.Catalog.toConsul(),
+		ConfigEntry:     c.Spec.ConfigEntry.toConsul(),
+		ConnectCA:       c.Spec.ConnectCA.toConsul(),
+		Coordinate:      c.Spec.Coordinate.toConsul(),
+		DiscoveryChain:  c.Spec.DiscoveryChain.toConsul(),
+		ServerDiscovery: c.Spec.ServerDiscovery.toConsul(),
+		Health:          c.Spec.Health.toConsul(),
+		Intention:       c.Spec.Intention.toConsul(),
+		KV:              c.Spec.KV.toConsul(),
+		Tenancy:         c.Spec.Tenancy.toConsul(),
+		PreparedQuery:   c.Spec.preparedQuery().toConsul(),
+		Session:         c.Spec.Session.toConsul(),
+		Txn:             c.Spec.Txn.toConsul(),
+		AutoConfig:      c.Spec.AutoConfig.toConsul(),
+		FederationState: c.Spec.FederationState.toConsul(),
+		Internal:        c.Spec.Internal.toConsul(),
+		PeerStream:      c.Spec.PeerStream.toConsul(),
+		Peering:         c.Spec.Peering.toConsul(),
 	}
 }
 
@@ -249,13 +292,24 @@ func (c *ControlPlaneRequestLimit) Validate(consulMeta common.ConsulMeta) error
 	errs = append(errs, c.Spec.ConnectCA.validate(path.Child("connectCA"))...)
 	errs = append(errs, c.Spec.Coordinate.validate(path.Child("coordinate"))...)
 	errs = append(errs, c.Spec.DiscoveryChain.validate(path.Child("discoveryChain"))...)
+	errs = append(errs, c.Spec.ServerDiscovery.validate(path.Child("serverDiscovery"))...)
 	errs = append(errs, c.Spec.Health.validate(path.Child("health"))...)
 	errs = append(errs, c.Spec.Intention.validate(path.Child("intention"))...)
 	errs = append(errs, c.Spec.KV.validate(path.Child("kv"))...)
 	errs = append(errs, c.Spec.Tenancy.validate(path.Child("tenancy"))...)
 	errs = append(errs, c.Spec.PreparedQuery.validate(path.Child("preparedQuery"))...)
+	errs = append(errs, c.Spec.DeprecatedPreparedQuery.validate(path.Child("perparedQuery"))...)
+	if c.Spec.PreparedQuery != nil && c.Spec.DeprecatedPreparedQuery != nil {
+		errs = append(errs, field.Invalid(path.Child("perparedQuery"), c.Spec.DeprecatedPreparedQuery,
+			"perparedQuery is deprecated and cannot be set together with preparedQuery"))
+	}
 	errs = append(errs, c.Spec.Session.validate(path.Child("session"))...)
 	errs = append(errs, c.Spec.Txn.validate(path.Child("txn"))...)
+	errs = append(errs, c.Spec.AutoConfig.validate(path.Child("autoConfig"))...)
+	errs = append(errs, c.Spec.FederationState.validate(path.Child("federationState"))...)
+	errs = append(errs, c.Spec.Internal.validate(path.Child("internal"))...)
+	errs = append(errs, c.Spec.PeerStream.validate(path.Child("peerStream"))...)
+	errs = append(errs, c.Spec.Peering.validate(path.Child("peering"))...)
 
 	if len(errs) > 0 {
 		return apierrors.NewInvalid(
@@ -4,6 +4,7 @@
 package v1alpha1
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -108,6 +109,30 @@ func TestControlPlaneRequestLimit_ToConsul(t *testing.T) {
 						ReadRate:  100.0,
 						WriteRate: 100.0,
 					},
+					ServerDiscovery: &ReadWriteRatesConfig{
+						ReadRate:  100.0,
+						WriteRate: 100.0,
+					},
+					AutoConfig: &ReadWriteRatesConfig{
+						ReadRate:  100.0,
+						WriteRate: 100.0,
+					},
+					FederationState: &ReadWriteRatesConfig{
+						ReadRate:  100.0,
+						WriteRate: 100.0,
+					},
+					Internal: &ReadWriteRatesConfig{
+						ReadRate:  100.0,
+						WriteRate: 100.0,
+					},
+					PeerStream: &ReadWriteRatesConfig{
+						ReadRate:  100.0,
+						WriteRate: 100.0,
+					},
+					Peering: &ReadWriteRatesConfig{
+						ReadRate:  100.0,
+						WriteRate: 100.0,
+					},
 				},
 			},
 			&consul.RateLimitIPConfigEntry{
@@ -172,6 +197,30 @@ func TestControlPlaneRequestLimit_ToConsul(t *testing.T) {
 					ReadRate:  100.0,
 					WriteRate: 100.0,
 				},
+				ServerDiscovery: &consul.ReadWriteRatesConfig{
+					ReadRate:  100.0,
+					WriteRate: 100.0,
+				},
+				AutoConfig: &consul.ReadWriteRatesConfig{
+					ReadRate:  100.0,
+					WriteRate: 100.0,
+				},
+				FederationState: &consul.ReadWriteRatesConfig{
+					ReadRate:  100.0,
+					WriteRate: 100.0,
+				},
+				Internal: &consul.ReadWriteRatesConfig{
+					ReadRate:  100.0,
+					WriteRate: 100.0,
+				},
+				PeerStream: &consul.ReadWriteRatesConfig{
+					ReadRate:  100.0,
+					WriteRate: 100.0,
+				},
+				Peering: &consul.ReadWriteRatesConfig{
+					ReadRate:  100.0,
+					WriteRate: 100.0,
+				},
 			},
 		},
 	}
@@ -184,6 +233,24 @@ func TestControlPlaneRequestLimit_ToConsul(t *testing.T) {
 	}
 }
 
+// Test that the limits of the deprecated perparedQuery field are still
+// converted when preparedQuery isn't set.
+func TestControlPlaneRequestLimit_ToConsulDeprecatedPreparedQuery(t *testing.T) {
+	var limit ControlPlaneRequestLimit
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"metadata": {"name": "controlplanerequestlimit"},
+		"spec": {"mode": "permissive", "perparedQuery": {"readRate": 10, "writeRate": 20}}
+	}`), &limit))
+
+	entry := limit.ToConsul("datacenter").(*consul.RateLimitIPConfigEntry)
+	require.Equal(t, &consul.ReadWriteRatesConfig{ReadRate: 10, WriteRate: 20}, entry.PreparedQuery)
+	require.True(t, limit.MatchesConsul(entry))
+
+	limit.Spec.PreparedQuery = &ReadWriteRatesConfig{ReadRate: 30, WriteRate: 40}
+	entry = limit.ToConsul("datacenter").(*consul.RateLimitIPConfigEntry)
+	require.Equal(t, &consul.ReadWriteRatesConfig{ReadRate: 30, WriteRate: 40}, entry.PreparedQuery)
+}
+
 func TestControlPlaneRequestLimit_MatchesConsul(t *testing.T) {
 	cases := map[string]struct {
 		internal *ControlPlaneRequestLimit
@@ -386,20 +453,26 @@ func TestControlPlaneRequestLimit_Validate(t *testing.T) {
 					Name: common.ControlPlaneRequestLimit,
 				},
 				Spec: ControlPlaneRequestLimitSpec{
-					Mode:           "invalid",
-					ACL:            invalidReadWriteRatesConfig,
-					Catalog:        invalidReadWriteRatesConfig,
-					ConfigEntry:    invalidReadWriteRatesConfig,
-					ConnectCA:      invalidReadWriteRatesConfig,
-					Coordinate:     invalidReadWriteRatesConfig,
-					DiscoveryChain: invalidReadWriteRatesConfig,
-					Health:         invalidReadWriteRatesConfig,
-					Intention:      invalidReadWriteRatesConfig,
-					KV:             invalidReadWriteRatesConfig,
-					Tenancy:        invalidReadWriteRatesConfig,
-					PreparedQuery:  invalidReadWriteRatesConfig,
-					Session:        invalidReadWriteRatesConfig,
-					Txn:            invalidReadWriteRatesConfig,
+					Mode:            "invalid",
+					ACL:             invalidReadWriteRatesConfig,
+					Catalog:         invalidReadWriteRatesConfig,
+					ConfigEntry:     invalidReadWriteRatesConfig,
+					ConnectCA:       invalidReadWriteRatesConfig,
+					Coordinate:      invalidReadWriteRatesConfig,
+					DiscoveryChain:  invalidReadWriteRatesConfig,
+					Health:          invalidReadWriteRatesConfig,
+					Intention:       invalidReadWriteRatesConfig,
+					KV:              invalidReadWriteRatesConfig,
+					Tenancy:         invalidReadWriteRatesConfig,
+					PreparedQuery:   invalidReadWriteRatesConfig,
+					Session:         invalidReadWriteRatesConfig,
+					Txn:             invalidReadWriteRatesConfig,
+					ServerDiscovery: invalidReadWriteRatesConfig,
+					AutoConfig:      invalidReadWriteRatesConfig,
+					FederationState: invalidReadWriteRatesConfig,
+					Internal:        invalidReadWriteRatesConfig,
+					PeerStream:      invalidReadWriteRatesConfig,
+					Peering:         invalidReadWriteRatesConfig,
 				},
 			},
 			expectedErrMsgs: []string{
@@ -417,6 +490,12 @@ func TestControlPlaneRequestLimit_Validate(t *testing.T) {
 				`spec.preparedQuery.readRate: Invalid value: -1: readRate must be >= 0, spec.preparedQuery.writeRate: Invalid value: 0: writeRate must be > 0`,
 				`spec.session.readRate: Invalid value: -1: readRate must be >= 0, spec.session.writeRate: Invalid value: 0: writeRate must be > 0`,
 				`spec.txn.readRate: Invalid value: -1: readRate must be >= 0, spec.txn.writeRate: Invalid value: 0: writeRate must be > 0`,
+				`spec.serverDiscovery.readRate: Invalid value: -1: readRate must be >= 0, spec.serverDiscovery.writeRate: Invalid value: 0: writeRate must be > 0`,
+				`spec.autoConfig.readRate: Invalid value: -1: readRate must be >= 0, spec.autoConfig.writeRate: Invalid value: 0: writeRate must be > 0`,
+				`spec.federationState.readRate: Invalid value: -1: readRate must be >= 0, spec.federationState.writeRate: Invalid value: 0: writeRate must be > 0`,
+				`spec.internal.readRate: Invalid value: -1: readRate must be >= 0, spec.internal.writeRate: Invalid value: 0: writeRate must be > 0`,
+				`spec.peerStream.readRate: Invalid value: -1: readRate must be >= 0, spec.peerStream.writeRate: Invalid value: 0: writeRate must be > 0`,
+				`spec.peering.readRate: Invalid value: -1: readRate must be >= 0, spec.peering.writeRate: Invalid value: 0: writeRate must be > 0`,
 			},
 		},
 		"valid": {
@@ -440,10 +519,47 @@ func TestControlPlaneRequestLimit_Validate(t *testing.T) {
 					PreparedQuery:        validReadWriteRatesConfig,
 					Session:              validReadWriteRatesConfig,
 					Txn:                  validReadWriteRatesConfig,
+					ServerDiscovery:      validReadWriteRatesConfig,
+					AutoConfig:           validReadWriteRatesConfig,
+					FederationState:      validReadWriteRatesConfig,
+					Internal:             validReadWriteRatesConfig,
+					PeerStream:           validReadWriteRatesConfig,
+					Peering:              validReadWriteRatesConfig,
 				},
 			},
 			expectedErrMsgs: []string{},
 		},
+		"deprecated perparedQuery": {
+			input: &ControlPlaneRequestLimit{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.ControlPlaneRequestLimit,
+				},
+				Spec: ControlPlaneRequestLimitSpec{
+					Mode:                    "permissive",
+					ReadWriteRatesConfig:    *validReadWriteRatesConfig,
+					DeprecatedPreparedQuery: invalidReadWriteRatesConfig,
+				},
+			},
+			expectedErrMsgs: []string{
+				`spec.perparedQuery.readRate: Invalid value: -1: readRate must be >= 0, spec.perparedQuery.writeRate: Invalid value: 0: writeRate must be > 0`,
+			},
+		},
+		"preparedQuery and deprecated perparedQuery": {
+			input: &ControlPlaneRequestLimit{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.ControlPlaneRequestLimit,
+				},
+				Spec: ControlPlaneRequestLimitSpec{
+					Mode:                    "permissive",
+					ReadWriteRatesConfig:    *validReadWriteRatesConfig,
+					PreparedQuery:           validReadWriteRatesConfig,
+					DeprecatedPreparedQuery: validReadWriteRatesConfig,
+				},
+			},
+			expectedErrMsgs: []string{
+				`perparedQuery is deprecated and cannot be set together with preparedQuery`,
+			},
+		},
 	}
 
 	for name, testCase := range cases {
@@ -39,6 +39,11 @@ type ExportedServices struct {
 
 	Spec   ExportedServicesSpec `json:"spec,omitempty"`
 	Status `json:"status,omitempty"`
+
+	// selectedServices are the services selected by spec.serviceSelectors.
+	// They're set by the controller before the config entry is built, since
+	// resolving the selectors requires the Kubernetes API.
+	selectedServices []ExportedService
 }
 
 //+kubebuilder:object:root=true
@@ -55,15 +60,47 @@ type ExportedServicesSpec struct {
 	// Services is a list of services to be exported and the list of partitions
 	// to expose them to.
 	Services []ExportedService `json:"services,omitempty"`
+	// ServiceSelectors export the Kubernetes services selected by labels to
+	// the given consumers. The selected services are re-evaluated whenever
+	// services or namespaces change, so the exported services stay up to
+	// date as services come and go.
+	// +optional
+	ServiceSelectors []ExportedServiceSelector `json:"serviceSelectors,omitempty"`
+}
+
+// ExportedServiceSelector exports the Kubernetes services matching its
+// selectors that are registered in the service mesh, i.e. that select
+// connect-injected pods. Each service is exported with the name its pods are
+// registered with, from the Consul namespace its Kubernetes namespace maps to.
+type ExportedServiceSelector struct {
+	// Selector selects services by their labels. It must not be empty, so that
+	// services are never exported by accident.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// NamespaceSelector selects the Kubernetes namespaces to select services
+	// from by their labels. Services in all namespaces are selected if it is
+	// unset.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Consumers is a list of downstream consumers of the selected services.
+	Consumers []ServiceConsumer `json:"consumers,omitempty"`
 }
 
 // ExportedService manages the exporting of a service in the local partition to
 // other partitions.
 type ExportedService struct {
 	// Name is the name of the service to be exported.
+	// Set to "*" to export all services in the namespace.
 	Name string `json:"name,omitempty"`
 	// Namespace is the namespace to export the service from.
+	// Set to "*" to export the services from all namespaces, in which case
+	// the name must also be "*".
 	Namespace string `json:"namespace,omitempty"`
+	// NamespaceSelector selects Kubernetes namespaces by their labels to
+	// export all services from the Consul namespaces they map to. The name
+	// must be "*" and the namespace must be unset. The selected namespaces
+	// are re-evaluated whenever namespaces change.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
 	// Consumers is a list of downstream consumers of the service to be exported.
 	Consumers []ServiceConsumer `json:"consumers,omitempty"`
 }
@@ -125,15 +162,7 @@ func (in *ExportedServices) KubernetesName() string {
 }
 
 func (in *ExportedServices) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.setSyncedCondition(in.Generation, status, reason, message)
 }
 
 func (in *ExportedServices) SetLastSyncedTime(time *metav1.Time) {
@@ -156,11 +185,29 @@ func (in *ExportedServices) SyncedConditionStatus() corev1.ConditionStatus {
 	return cond.Status
 }
 
+// SetSelectedServices sets the services selected by spec.serviceSelectors
+// and the namespace selectors of spec.services, which are exported in
+// addition to spec.services.
+func (in *ExportedServices) SetSelectedServices(services []ExportedService) {
+	in.selectedServices = services
+}
+
 func (in *ExportedServices) ToConsul(datacenter string) api.ConfigEntry {
 	var services []capi.ExportedService
 	for _, service := range in.Spec.Services {
+		// Services with a namespace selector are exported from the selected
+		// namespaces.
+		if service.NamespaceSelector != nil {
+			continue
+		}
 		services = append(services, service.toConsul())
 	}
+	// A service may be both listed explicitly and selected, or selected more
+	// than once, but Consul only allows it to be exported once, so merge the
+	// consumers of duplicates.
+	for _, selected := range in.selectedServices {
+		services = mergeExportedService(services, selected.toConsul())
+	}
 	return &capi.ExportedServicesConfigEntry{
 		Name:     in.Name,
 		Services: services,
@@ -184,6 +231,32 @@ func (in *ExportedService) toConsul() capi.ExportedService {
 	}
 }
 
+// mergeExportedService appends the service to the list, or adds its consumers
+// to the service with the same name and namespace already in the list.
+func mergeExportedService(services []capi.ExportedService, service capi.ExportedService) []capi.ExportedService {
+	for i := range services {
+		if services[i].Name != service.Name || services[i].Namespace != service.Namespace {
+			continue
+		}
+		for _, consumer := range service.Consumers {
+			if !containsConsumer(services[i].Consumers, consumer) {
+				services[i].Consumers = append(services[i].Consumers, consumer)
+			}
+		}
+		return services
+	}
+	return append(services, service)
+}
+
+func containsConsumer(consumers []capi.ServiceConsumer, consumer capi.ServiceConsumer) bool {
+	for _, c := range consumers {
+		if c == consumer {
+			return true
+		}
+	}
+	return false
+}
+
 func (in *ExportedServices) MatchesConsul(candidate api.ConfigEntry) bool {
 	configEntry, ok := candidate.(*capi.ExportedServicesConfigEntry)
 	if !ok {
@@ -201,13 +274,27 @@ func (in *ExportedServices) Validate(consulMeta common.ConsulMeta) error {
 	} else if !consulMeta.PartitionsEnabled && in.Name != "default" {
 		errs = append(errs, field.Invalid(field.NewPath("name"), in.Name, fmt.Sprintf(`%s resource name must be "default"`, in.KubeKind())))
 	}
-	if len(in.Spec.Services) == 0 {
+	if len(in.Spec.Services) == 0 && len(in.Spec.ServiceSelectors) == 0 {
 		errs = append(errs, field.Invalid(field.NewPath("spec").Child("services"), in.Spec.Services, "at least one service must be exported"))
 	}
+	seen := make(map[string]bool)
 	for i, service := range in.Spec.Services {
-		if err := service.validate(field.NewPath("spec").Child("services").Index(i), consulMeta); err != nil {
+		path := field.NewPath("spec").Child("services").Index(i)
+		if err := service.validate(path, consulMeta); err != nil {
 			errs = append(errs, err...)
 		}
+		// Services selected by several namespace selectors are merged.
+		if service.NamespaceSelector != nil {
+			continue
+		}
+		key := service.Namespace + "/" + service.Name
+		if seen[key] {
+			errs = append(errs, field.Duplicate(path, service.Name))
+		}
+		seen[key] = true
+	}
+	for i, selector := range in.Spec.ServiceSelectors {
+		errs = append(errs, selector.validate(field.NewPath("spec").Child("serviceSelectors").Index(i), consulMeta)...)
 	}
 	if len(errs) > 0 {
 		return apierrors.NewInvalid(
@@ -219,12 +306,53 @@ func (in *ExportedServices) Validate(consulMeta common.ConsulMeta) error {
 
 func (in *ExportedService) validate(path *field.Path, consulMeta common.ConsulMeta) field.ErrorList {
 	var errs field.ErrorList
+	if in.Name == "" {
+		errs = append(errs, field.Required(path.Child("name"), "service name must be set, use \"*\" to export all services"))
+	}
 	if len(in.Consumers) == 0 {
 		errs = append(errs, field.Invalid(path, in.Consumers, "service must have at least 1 consumer."))
 	}
 	if !consulMeta.NamespacesEnabled && in.Namespace != "" {
 		errs = append(errs, field.Invalid(path, in.Namespace, "Consul Namespaces must be enabled to specify service namespace."))
 	}
+	if in.Namespace == WildcardSpecifier && in.Name != WildcardSpecifier {
+		errs = append(errs, field.Invalid(path.Child("name"), in.Name, "service name must be \"*\" to export services from all namespaces"))
+	}
+	if in.NamespaceSelector != nil {
+		if !consulMeta.NamespacesEnabled {
+			errs = append(errs, field.Invalid(path.Child("namespaceSelector"), in.NamespaceSelector, "Consul Namespaces must be enabled to select namespaces."))
+		}
+		if in.Namespace != "" {
+			errs = append(errs, field.Invalid(path.Child("namespace"), in.Namespace, "namespace and namespaceSelector are mutually exclusive"))
+		}
+		if in.Name != WildcardSpecifier {
+			errs = append(errs, field.Invalid(path.Child("name"), in.Name, "service name must be \"*\" to export services from selected namespaces"))
+		}
+		if _, err := metav1.LabelSelectorAsSelector(in.NamespaceSelector); err != nil {
+			errs = append(errs, field.Invalid(path.Child("namespaceSelector"), in.NamespaceSelector, err.Error()))
+		}
+	}
+	for i, consumer := range in.Consumers {
+		if err := consumer.validate(path.Child("consumers").Index(i), consulMeta); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (in *ExportedServiceSelector) validate(path *field.Path, consulMeta common.ConsulMeta) field.ErrorList {
+	var errs field.ErrorList
+	if in.Selector == nil || (len(in.Selector.MatchLabels) == 0 && len(in.Selector.MatchExpressions) == 0) {
+		errs = append(errs, field.Required(path.Child("selector"), "selector must not be empty"))
+	} else if _, err := metav1.LabelSelectorAsSelector(in.Selector); err != nil {
+		errs = append(errs, field.Invalid(path.Child("selector"), in.Selector, err.Error()))
+	}
+	if _, err := metav1.LabelSelectorAsSelector(in.NamespaceSelector); err != nil {
+		errs = append(errs, field.Invalid(path.Child("namespaceSelector"), in.NamespaceSelector, err.Error()))
+	}
+	if len(in.Consumers) == 0 {
+		errs = append(errs, field.Invalid(path, in.Consumers, "service selector must have at least 1 consumer."))
+	}
 	for i, consumer := range in.Consumers {
 		if err := consumer.validate(path.Child("consumers").Index(i), consulMeta); err != nil {
 			errs = append(errs, err)
@@ -261,7 +389,7 @@ func (in *ServiceConsumer) validate(path *field.Path, consulMeta common.ConsulMe
 		return field.Invalid(path.Child("peer"), "", "exporting to all peers (wildcard) is not supported")
 	}
 	if in.SamenessGroup == WildcardSpecifier {
-		return field.Invalid(path.Child("samenessgroup"), "", "exporting to all sameness groups (wildcard) is not supported")
+		return field.Invalid(path.Child("samenessGroup"), "", "exporting to all sameness groups (wildcard) is not supported")
 	}
 	return nil
 }
@@ -278,6 +278,56 @@ func TestExportedServices_ToConsul(t *testing.T) {
 	}
 }
 
+func TestExportedServices_ToConsulWithSelectedServices(t *testing.T) {
+	exportedServices := &ExportedServices{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: common.DefaultConsulPartition,
+		},
+		Spec: ExportedServicesSpec{
+			Services: []ExportedService{
+				{
+					Name:      "api",
+					Namespace: "default",
+					Consumers: []ServiceConsumer{{Peer: "peer-1"}},
+				},
+			},
+		},
+	}
+	exportedServices.SetSelectedServices([]ExportedService{
+		{
+			Name:      "api",
+			Namespace: "default",
+			Consumers: []ServiceConsumer{{Peer: "peer-1"}, {Peer: "peer-2"}},
+		},
+		{
+			Name:      "web",
+			Namespace: "default",
+			Consumers: []ServiceConsumer{{Peer: "peer-2"}},
+		},
+		{
+			Name:      "web",
+			Namespace: "default",
+			Consumers: []ServiceConsumer{{SamenessGroup: "sg1"}},
+		},
+	})
+
+	entry, ok := exportedServices.ToConsul("datacenter").(*capi.ExportedServicesConfigEntry)
+	require.True(t, ok)
+	require.Equal(t, []capi.ExportedService{
+		{
+			Name:      "api",
+			Namespace: "default",
+			Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}, {Peer: "peer-2"}},
+		},
+		{
+			Name:      "web",
+			Namespace: "default",
+			Consumers: []capi.ServiceConsumer{{Peer: "peer-2"}, {SamenessGroup: "sg1"}},
+		},
+	}, entry.Services)
+	require.True(t, exportedServices.MatchesConsul(entry))
+}
+
 func TestExportedServices_Validate(t *testing.T) {
 	cases := map[string]struct {
 		input             *ExportedServices
@@ -509,6 +559,246 @@ func TestExportedServices_Validate(t *testing.T) {
 				`exporting to all sameness groups (wildcard) is not supported`,
 			},
 		},
+		"valid wildcard services": {
+			input: &ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.DefaultConsulPartition,
+				},
+				Spec: ExportedServicesSpec{
+					Services: []ExportedService{
+						{
+							Name:      "*",
+							Namespace: "frontend",
+							Consumers: []ServiceConsumer{
+								{
+									SamenessGroup: "sg1",
+								},
+							},
+						},
+						{
+							Name:      "*",
+							Namespace: "*",
+							Consumers: []ServiceConsumer{
+								{
+									Peer: "second-peer",
+								},
+							},
+						},
+					},
+				},
+			},
+			namespaceEnabled:  true,
+			partitionsEnabled: true,
+			expectedErrMsgs:   []string{},
+		},
+		"exporting a single service from all namespaces is not supported": {
+			input: &ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.DefaultConsulPartition,
+				},
+				Spec: ExportedServicesSpec{
+					Services: []ExportedService{
+						{
+							Name:      "service-frontend",
+							Namespace: "*",
+							Consumers: []ServiceConsumer{
+								{
+									Peer: "second-peer",
+								},
+							},
+						},
+					},
+				},
+			},
+			namespaceEnabled:  true,
+			partitionsEnabled: true,
+			expectedErrMsgs: []string{
+				`spec.services[0].name: Invalid value: "service-frontend": service name must be "*" to export services from all namespaces`,
+			},
+		},
+		"service name not specified": {
+			input: &ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.DefaultConsulPartition,
+				},
+				Spec: ExportedServicesSpec{
+					Services: []ExportedService{
+						{
+							Consumers: []ServiceConsumer{
+								{
+									Peer: "second-peer",
+								},
+							},
+						},
+					},
+				},
+			},
+			namespaceEnabled:  true,
+			partitionsEnabled: true,
+			expectedErrMsgs: []string{
+				`spec.services[0].name: Required value: service name must be set, use "*" to export all services`,
+			},
+		},
+		"service exported twice": {
+			input: &ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.DefaultConsulPartition,
+				},
+				Spec: ExportedServicesSpec{
+					Services: []ExportedService{
+						{
+							Name:      "*",
+							Namespace: "frontend",
+							Consumers: []ServiceConsumer{
+								{
+									Peer: "second-peer",
+								},
+							},
+						},
+						{
+							Name:      "*",
+							Namespace: "frontend",
+							Consumers: []ServiceConsumer{
+								{
+									SamenessGroup: "sg1",
+								},
+							},
+						},
+					},
+				},
+			},
+			namespaceEnabled:  true,
+			partitionsEnabled: true,
+			expectedErrMsgs: []string{
+				`spec.services[1]: Duplicate value: "*"`,
+			},
+		},
+		"valid with only service selectors": {
+			input: &ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.DefaultConsulPartition,
+				},
+				Spec: ExportedServicesSpec{
+					ServiceSelectors: []ExportedServiceSelector{
+						{
+							Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"export": "true"}},
+							Consumers: []ServiceConsumer{
+								{
+									Peer: "second-peer",
+								},
+							},
+						},
+					},
+				},
+			},
+			namespaceEnabled:  true,
+			partitionsEnabled: true,
+		},
+		"invalid service selectors": {
+			input: &ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.DefaultConsulPartition,
+				},
+				Spec: ExportedServicesSpec{
+					ServiceSelectors: []ExportedServiceSelector{
+						{
+							Selector: &metav1.LabelSelector{
+								MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "export", Operator: "Bogus"}},
+							},
+						},
+						{
+							NamespaceSelector: &metav1.LabelSelector{
+								MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: metav1.LabelSelectorOpIn}},
+							},
+							Consumers: []ServiceConsumer{
+								{},
+							},
+						},
+					},
+				},
+			},
+			namespaceEnabled:  true,
+			partitionsEnabled: true,
+			expectedErrMsgs: []string{
+				`spec.serviceSelectors[0].selector: Invalid value`,
+				`"Bogus" is not a valid label selector operator`,
+				`spec.serviceSelectors[0]: Invalid value: []v1alpha1.ServiceConsumer(nil): service selector must have at least 1 consumer.`,
+				`spec.serviceSelectors[1].selector: Required value: selector must not be empty`,
+				`spec.serviceSelectors[1].namespaceSelector: Invalid value`,
+				`spec.serviceSelectors[1].consumers[0]: Invalid value: v1alpha1.ServiceConsumer{Partition:"", Peer:"", SamenessGroup:""}: service consumer must define at least one of Peer, Partition, or SamenessGroup`,
+			},
+		},
+		"valid namespace selector": {
+			input: &ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.DefaultConsulPartition,
+				},
+				Spec: ExportedServicesSpec{
+					Services: []ExportedService{
+						{
+							Name:              "*",
+							NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+							Consumers:         []ServiceConsumer{{Peer: "peer-1"}},
+						},
+						{
+							Name:              "*",
+							NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+							Consumers:         []ServiceConsumer{{SamenessGroup: "sg1"}},
+						},
+					},
+				},
+			},
+			namespaceEnabled:  true,
+			partitionsEnabled: true,
+			expectedErrMsgs:   []string{},
+		},
+		"invalid namespace selector": {
+			input: &ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.DefaultConsulPartition,
+				},
+				Spec: ExportedServicesSpec{
+					Services: []ExportedService{
+						{
+							Name:      "service-frontend",
+							Namespace: "frontend",
+							NamespaceSelector: &metav1.LabelSelector{
+								MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Bogus"}},
+							},
+							Consumers: []ServiceConsumer{{Peer: "peer-1"}},
+						},
+					},
+				},
+			},
+			namespaceEnabled:  true,
+			partitionsEnabled: true,
+			expectedErrMsgs: []string{
+				`spec.services[0].namespace: Invalid value: "frontend": namespace and namespaceSelector are mutually exclusive`,
+				`spec.services[0].name: Invalid value: "service-frontend": service name must be "*" to export services from selected namespaces`,
+				`"Bogus" is not a valid label selector operator`,
+			},
+		},
+		"namespace selector without namespaces": {
+			input: &ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: common.DefaultConsulPartition,
+				},
+				Spec: ExportedServicesSpec{
+					Services: []ExportedService{
+						{
+							Name:              "*",
+							NamespaceSelector: &metav1.LabelSelector{},
+							Consumers:         []ServiceConsumer{{Peer: "peer-1"}},
+						},
+					},
+				},
+			},
+			partitionsEnabled: true,
+			expectedErrMsgs: []string{
+				`spec.services[0].namespaceSelector: Invalid value`,
+				`Consul Namespaces must be enabled to select namespaces.`,
+			},
+		},
 		"multiple errors": {
 			input: &ExportedServices{
 				ObjectMeta: metav1.ObjectMeta{
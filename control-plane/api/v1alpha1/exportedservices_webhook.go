@@ -48,14 +48,22 @@ func (v *ExportedServicesWebhook) Handle(ctx context.Context, req admission.Requ
 			return admission.Errored(http.StatusInternalServerError, err)
 		}
 
-		if len(exportsList.Items) > 0 {
-			return admission.Errored(http.StatusBadRequest,
-				fmt.Errorf("%s resource already defined - only one exportedservices entry is supported per Kubernetes cluster",
-					exports.KubeKind()))
+		// Exported services in other partitions can be defined with the
+		// partition annotation.
+		for _, item := range exportsList.Items {
+			if common.PartitionAnnotation(&item) == common.PartitionAnnotation(&exports) {
+				return admission.Errored(http.StatusBadRequest,
+					fmt.Errorf("%s resource already defined - only one exportedservices entry is supported per Kubernetes cluster",
+						exports.KubeKind()))
+			}
 		}
 	}
 
-	if err := exports.Validate(v.ConsulMeta); err != nil {
+	consulMeta, err := common.WithPartitionAnnotation(req, &exports, v.ConsulMeta)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if err := exports.Validate(consulMeta); err != nil {
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
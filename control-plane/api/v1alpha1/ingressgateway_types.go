@@ -6,6 +6,7 @@ package v1alpha1
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -79,6 +80,21 @@ type IngressServiceConfig struct {
 	MaxConcurrentRequests *uint32 `json:"maxConcurrentRequests,omitempty"`
 }
 
+// supportedCipherSuites are the TLS 1.2 cipher suites supported by Envoy
+// that may be set in a GatewayTLSConfig.
+var supportedCipherSuites = []string{
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305",
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA",
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA",
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+}
+
 type GatewayTLSConfig struct {
 	// Indicates that TLS should be enabled for this gateway service.
 	Enabled bool `json:"enabled"`
@@ -94,7 +110,8 @@ type GatewayTLSConfig struct {
 	// If unspecified, Envoy will default to TLS 1.3 as a max version for incoming connections.
 	TLSMaxVersion string `json:"tlsMaxVersion,omitempty"`
 	// Define a subset of cipher suites to restrict
-	// Only applicable to connections negotiated via TLS 1.2 or earlier.
+	// Only applicable to connections negotiated via TLS 1.2 or earlier,
+	// so it can't be set when TLSMinVersion is `TLSv1_3`.
 	CipherSuites []string `json:"cipherSuites,omitempty"`
 }
 
@@ -215,15 +232,7 @@ func (in *IngressGateway) KubernetesName() string {
 }
 
 func (in *IngressGateway) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.setSyncedCondition(in.Generation, status, reason, message)
 }
 
 func (in *IngressGateway) SetLastSyncedTime(time *metav1.Time) {
@@ -335,9 +344,35 @@ func (in *GatewayTLSConfig) validate(path *field.Path) field.ErrorList {
 	if !sliceContains(versions, in.TLSMinVersion) {
 		errs = append(errs, field.Invalid(path.Child("tlsMinVersion"), in.TLSMinVersion, notInSliceMessage(versions)))
 	}
+	// TLS_AUTO and unset versions let Envoy pick the version and aren't compared.
+	orderedVersions := []string{"TLSv1_0", "TLSv1_1", "TLSv1_2", "TLSv1_3"}
+	minIdx, maxIdx := indexOf(orderedVersions, in.TLSMinVersion), indexOf(orderedVersions, in.TLSMaxVersion)
+	if minIdx >= 0 && maxIdx >= 0 && maxIdx < minIdx {
+		errs = append(errs, field.Invalid(path.Child("tlsMaxVersion"), in.TLSMaxVersion,
+			fmt.Sprintf("must be greater than or equal to tlsMinVersion %q", in.TLSMinVersion)))
+	}
+
+	if len(in.CipherSuites) > 0 && in.TLSMinVersion == "TLSv1_3" {
+		errs = append(errs, field.Invalid(path.Child("cipherSuites"), strings.Join(in.CipherSuites, ","),
+			"cipher suites are only applicable to connections negotiated via TLS 1.2 or earlier, but tlsMinVersion is \"TLSv1_3\""))
+	}
+	for i, suite := range in.CipherSuites {
+		if !sliceContains(supportedCipherSuites, suite) {
+			errs = append(errs, field.Invalid(path.Child("cipherSuites").Index(i), suite, notInSliceMessage(supportedCipherSuites)))
+		}
+	}
 	return errs
 }
 
+func indexOf(list []string, str string) int {
+	for i, s := range list {
+		if s == str {
+			return i
+		}
+	}
+	return -1
+}
+
 func (in IngressListener) toConsul() capi.IngressListener {
 	var services []capi.IngressService
 	for _, s := range in.Services {
@@ -723,6 +723,70 @@ func TestIngressGateway_Validate(t *testing.T) {
 				`spec.listeners[0].tls.tlsMaxVersion: Invalid value: "foo": must be one of "TLS_AUTO", "TLSv1_0", "TLSv1_1", "TLSv1_2", "TLSv1_3", ""`,
 			},
 		},
+		"listeners.tls.maxTLSVersion less than minTLSVersion": {
+			input: &IngressGateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+				Spec: IngressGatewaySpec{
+					Listeners: []IngressListener{
+						{
+							Protocol: "tcp",
+							TLS: &GatewayTLSConfig{
+								TLSMinVersion: "TLSv1_3",
+								TLSMaxVersion: "TLSv1_2",
+							},
+						},
+					},
+				},
+			},
+			expectedErrMsgs: []string{
+				`spec.listeners[0].tls.tlsMaxVersion: Invalid value: "TLSv1_2": must be greater than or equal to tlsMinVersion "TLSv1_3"`,
+			},
+		},
+		"listeners.tls.cipherSuites set with TLSv1_3 minTLSVersion": {
+			input: &IngressGateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+				Spec: IngressGatewaySpec{
+					Listeners: []IngressListener{
+						{
+							Protocol: "tcp",
+							TLS: &GatewayTLSConfig{
+								TLSMinVersion: "TLSv1_3",
+								CipherSuites:  []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+							},
+						},
+					},
+				},
+			},
+			expectedErrMsgs: []string{
+				`spec.listeners[0].tls.cipherSuites: Invalid value: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256": cipher suites are only applicable to connections negotiated via TLS 1.2 or earlier, but tlsMinVersion is "TLSv1_3"`,
+			},
+		},
+		"listeners.tls.cipherSuites invalid": {
+			input: &IngressGateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "foo",
+				},
+				Spec: IngressGatewaySpec{
+					Listeners: []IngressListener{
+						{
+							Protocol: "tcp",
+							TLS: &GatewayTLSConfig{
+								TLSMinVersion: "TLSv1_2",
+								TLSMaxVersion: "TLS_AUTO",
+								CipherSuites:  []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_RC4_128_SHA"},
+							},
+						},
+					},
+				},
+			},
+			expectedErrMsgs: []string{
+				`spec.listeners[0].tls.cipherSuites[1]: Invalid value: "TLS_RSA_WITH_RC4_128_SHA": must be one of`,
+			},
+		},
 		"service.namespace set when namespaces disabled": {
 			input: &IngressGateway{
 				ObjectMeta: metav1.ObjectMeta{
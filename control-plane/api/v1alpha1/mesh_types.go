@@ -178,15 +178,7 @@ func (in *Mesh) KubernetesName() string {
 }
 
 func (in *Mesh) SetSyncedCondition(status corev1.ConditionStatus, reason string, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.setSyncedCondition(in.Generation, status, reason, message)
 }
 
 func (in *Mesh) SetLastSyncedTime(time *metav1.Time) {
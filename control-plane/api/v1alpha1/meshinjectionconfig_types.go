@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"net"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+const MeshInjectionConfigKubeKind = "meshinjectionconfigs"
+
+func init() {
+	SchemeBuilder.Register(&MeshInjectionConfig{}, &MeshInjectionConfigList{})
+}
+
+//+kubebuilder:object:root=true
+
+// MeshInjectionConfig is the Schema for the meshinjectionconfigs API. It sets
+// the defaults for injecting the pods in its namespace. Its settings take
+// precedence over the Helm chart's, while pod annotations and namespace labels
+// take precedence over its settings. Only one MeshInjectionConfig is allowed
+// per namespace. Changes only apply to pods that are created afterwards.
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="The age of the resource"
+// +kubebuilder:resource:shortName="mesh-injection-config"
+type MeshInjectionConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MeshInjectionConfigSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MeshInjectionConfigList contains a list of MeshInjectionConfig.
+type MeshInjectionConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MeshInjectionConfig `json:"items"`
+}
+
+// MeshInjectionConfigSpec defines the injection defaults of a namespace. Each
+// setting corresponds to a pod annotation, which is set on the pods that
+// don't already have it.
+type MeshInjectionConfigSpec struct {
+	// TransparentProxy configures transparent proxy.
+	// +optional
+	TransparentProxy *InjectionTransparentProxy `json:"transparentProxy,omitempty"`
+	// Upstreams are the upstreams of the pods that don't set the
+	// "consul.hashicorp.com/connect-service-upstreams" annotation, in the
+	// format of its entries, e.g. "db:1234".
+	// +optional
+	Upstreams []string `json:"upstreams,omitempty"`
+	// Metrics configures the metrics of the sidecar proxies.
+	// +optional
+	Metrics *InjectionMetrics `json:"metrics,omitempty"`
+	// SidecarProxyLifecycle configures the startup and shutdown of the sidecar proxies.
+	// +optional
+	SidecarProxyLifecycle *InjectionSidecarProxyLifecycle `json:"sidecarProxyLifecycle,omitempty"`
+}
+
+type InjectionTransparentProxy struct {
+	// Enabled enables transparent proxy, like the
+	// "consul.hashicorp.com/transparent-proxy" annotation. The
+	// "consul.hashicorp.com/transparent-proxy" namespace label takes
+	// precedence over it.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// OverwriteProbes overwrites the Kubernetes probes of the pods to point
+	// to the sidecar proxies, like the
+	// "consul.hashicorp.com/transparent-proxy-overwrite-probes" annotation.
+	// +optional
+	OverwriteProbes *bool `json:"overwriteProbes,omitempty"`
+	// ExcludeOutboundCIDRs are the IP addresses and CIDRs whose outbound
+	// traffic isn't redirected to the sidecar proxies, like the
+	// "consul.hashicorp.com/transparent-proxy-exclude-outbound-cidrs" annotation.
+	// +optional
+	ExcludeOutboundCIDRs []string `json:"excludeOutboundCIDRs,omitempty"`
+}
+
+type InjectionMetrics struct {
+	// Enabled exposes the metrics of the sidecar proxies, like the
+	// "consul.hashicorp.com/enable-metrics" annotation.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// MergingEnabled merges the metrics of the sidecar proxies and the
+	// services, like the "consul.hashicorp.com/enable-metrics-merging" annotation.
+	// +optional
+	MergingEnabled *bool `json:"mergingEnabled,omitempty"`
+}
+
+type InjectionSidecarProxyLifecycle struct {
+	// Enabled enables the lifecycle management of the sidecar proxies, like
+	// the "consul.hashicorp.com/enable-sidecar-proxy-lifecycle" annotation.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// ShutdownDrainListeners drains the inbound listeners of the sidecar
+	// proxies when they shut down, like the
+	// "consul.hashicorp.com/enable-sidecar-proxy-lifecycle-shutdown-drain-listeners" annotation.
+	// +optional
+	ShutdownDrainListeners *bool `json:"shutdownDrainListeners,omitempty"`
+	// ShutdownGracePeriodSeconds is how long the sidecar proxies keep running
+	// after shutdown starts, like the
+	// "consul.hashicorp.com/sidecar-proxy-lifecycle-shutdown-grace-period-seconds" annotation.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ShutdownGracePeriodSeconds *int32 `json:"shutdownGracePeriodSeconds,omitempty"`
+	// GracefulPort is the port of the lifecycle endpoints of the sidecar
+	// proxies, like the "consul.hashicorp.com/sidecar-proxy-lifecycle-graceful-port" annotation.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	GracefulPort *int32 `json:"gracefulPort,omitempty"`
+	// GracefulShutdownPath is the path of the graceful shutdown endpoint of
+	// the sidecar proxies, like the
+	// "consul.hashicorp.com/sidecar-proxy-lifecycle-graceful-shutdown-path" annotation.
+	// +optional
+	GracefulShutdownPath string `json:"gracefulShutdownPath,omitempty"`
+}
+
+func (c *MeshInjectionConfig) KubeKind() string {
+	return MeshInjectionConfigKubeKind
+}
+
+func (c *MeshInjectionConfig) KubernetesName() string {
+	return c.ObjectMeta.Name
+}
+
+func (c *MeshInjectionConfig) Validate() error {
+	var errs field.ErrorList
+	path := field.NewPath("spec")
+
+	if tproxy := c.Spec.TransparentProxy; tproxy != nil {
+		for i, cidr := range tproxy.ExcludeOutboundCIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil && net.ParseIP(cidr) == nil {
+				errs = append(errs, field.Invalid(path.Child("transparentProxy", "excludeOutboundCIDRs").Index(i), cidr,
+					"must be an IP address or CIDR"))
+			}
+		}
+	}
+	for i, upstream := range c.Spec.Upstreams {
+		if strings.TrimSpace(upstream) == "" || strings.Contains(upstream, ",") {
+			errs = append(errs, field.Invalid(path.Child("upstreams").Index(i), upstream,
+				"must be a single upstream, e.g. \"db:1234\""))
+		}
+	}
+	if lifecycle := c.Spec.SidecarProxyLifecycle; lifecycle != nil {
+		if lifecycle.ShutdownGracePeriodSeconds != nil && *lifecycle.ShutdownGracePeriodSeconds < 0 {
+			errs = append(errs, field.Invalid(path.Child("sidecarProxyLifecycle", "shutdownGracePeriodSeconds"),
+				*lifecycle.ShutdownGracePeriodSeconds, "must not be negative"))
+		}
+		if lifecycle.GracefulPort != nil && (*lifecycle.GracefulPort < 1 || *lifecycle.GracefulPort > 65535) {
+			errs = append(errs, field.Invalid(path.Child("sidecarProxyLifecycle", "gracefulPort"),
+				*lifecycle.GracefulPort, "must be between 1 and 65535"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(
+			schema.GroupKind{Group: ConsulHashicorpGroup, Kind: MeshInjectionConfigKubeKind},
+			c.KubernetesName(), errs)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMeshInjectionConfig_Validate(t *testing.T) {
+	enabled := true
+	negative := int32(-1)
+	port := int32(70000)
+	cases := map[string]struct {
+		spec            MeshInjectionConfigSpec
+		expectedErrMsgs []string
+	}{
+		"empty": {},
+		"valid": {
+			spec: MeshInjectionConfigSpec{
+				TransparentProxy: &InjectionTransparentProxy{
+					Enabled:              &enabled,
+					ExcludeOutboundCIDRs: []string{"10.0.0.0/8", "1.1.1.1", "fd00::/8"},
+				},
+				Upstreams: []string{"db:1234", "cache.svc.dc2:6379"},
+				Metrics:   &InjectionMetrics{Enabled: &enabled, MergingEnabled: &enabled},
+				SidecarProxyLifecycle: &InjectionSidecarProxyLifecycle{
+					Enabled:                    &enabled,
+					ShutdownGracePeriodSeconds: new(int32),
+				},
+			},
+		},
+		"invalid": {
+			spec: MeshInjectionConfigSpec{
+				TransparentProxy: &InjectionTransparentProxy{ExcludeOutboundCIDRs: []string{"10.0.0.0/33"}},
+				Upstreams:        []string{"db:1234,cache:6379", " "},
+				SidecarProxyLifecycle: &InjectionSidecarProxyLifecycle{
+					ShutdownGracePeriodSeconds: &negative,
+					GracefulPort:               &port,
+				},
+			},
+			expectedErrMsgs: []string{
+				`spec.transparentProxy.excludeOutboundCIDRs[0]: Invalid value: "10.0.0.0/33": must be an IP address or CIDR`,
+				`spec.upstreams[0]: Invalid value: "db:1234,cache:6379": must be a single upstream, e.g. "db:1234"`,
+				`spec.upstreams[1]: Invalid value: " "`,
+				`spec.sidecarProxyLifecycle.shutdownGracePeriodSeconds: Invalid value: -1: must not be negative`,
+				`spec.sidecarProxyLifecycle.gracefulPort: Invalid value: 70000: must be between 1 and 65535`,
+			},
+		},
+	}
+
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			config := &MeshInjectionConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "defaults"},
+				Spec:       testCase.spec,
+			}
+			err := config.Validate()
+			if len(testCase.expectedErrMsgs) != 0 {
+				require.Error(t, err)
+				for _, s := range testCase.expectedErrMsgs {
+					require.Contains(t, err.Error(), s)
+				}
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:object:generate=false
+
+type MeshInjectionConfigWebhook struct {
+	client.Client
+	Logger  logr.Logger
+	decoder *admission.Decoder
+}
+
+// NOTE: The path value in the below line is the path to the webhook.
+// If it is updated, run code-gen, update subcommand/controller/command.go
+// and the consul-helm value for the path to the webhook.
+//
+// NOTE: The below line cannot be combined with any other comment. If it is
+// it will break the code generation.
+//
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-v1alpha1-meshinjectionconfigs,mutating=true,failurePolicy=fail,groups=consul.hashicorp.com,resources=meshinjectionconfigs,versions=v1alpha1,name=mutate-meshinjectionconfigs.consul.hashicorp.com,sideEffects=None,admissionReviewVersions=v1beta1;v1
+
+func (v *MeshInjectionConfigWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var config MeshInjectionConfig
+	err := v.decoder.Decode(req, &config)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if req.Operation == admissionv1.Create {
+		v.Logger.Info("validate create", "name", config.KubernetesName())
+
+		var configList MeshInjectionConfigList
+		if err := v.Client.List(ctx, &configList, client.InNamespace(req.Namespace)); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		// Pods would get different defaults depending on which config is consulted.
+		for _, item := range configList.Items {
+			if item.Name != config.Name {
+				return admission.Errored(http.StatusBadRequest,
+					fmt.Errorf("an existing MeshInjectionConfig resource is in the namespace `name: %s, namespace: %s`", item.Name, item.Namespace))
+			}
+		}
+	}
+
+	return admission.Allowed(fmt.Sprintf("valid %s request", config.KubeKind()))
+}
+
+func (v *MeshInjectionConfigWebhook) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
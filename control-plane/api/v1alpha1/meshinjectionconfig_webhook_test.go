@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidateMeshInjectionConfig(t *testing.T) {
+	config := func(name, namespace string, upstreams ...string) *MeshInjectionConfig {
+		return &MeshInjectionConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: MeshInjectionConfigSpec{Upstreams: upstreams},
+		}
+	}
+
+	cases := map[string]struct {
+		existingResources []runtime.Object
+		oldResource       *MeshInjectionConfig
+		newResource       *MeshInjectionConfig
+		expAllow          bool
+		expErrMessage     string
+	}{
+		"valid create": {
+			existingResources: []runtime.Object{config("defaults", "other")},
+			newResource:       config("defaults", "default", "db:1234"),
+			expAllow:          true,
+		},
+		"invalid create": {
+			newResource:   config("defaults", "default", ""),
+			expAllow:      false,
+			expErrMessage: `meshinjectionconfigs.consul.hashicorp.com "defaults" is invalid: spec.upstreams[0]: Invalid value: "": must be a single upstream, e.g. "db:1234"`,
+		},
+		"second config in the namespace": {
+			existingResources: []runtime.Object{config("defaults", "default")},
+			newResource:       config("more-defaults", "default"),
+			expAllow:          false,
+			expErrMessage:     "an existing MeshInjectionConfig resource is in the namespace `name: defaults, namespace: default`",
+		},
+		"valid update": {
+			existingResources: []runtime.Object{config("defaults", "default")},
+			oldResource:       config("defaults", "default"),
+			newResource:       config("defaults", "default", "db:1234"),
+			expAllow:          true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			marshalledRequestObject, err := json.Marshal(c.newResource)
+			require.NoError(t, err)
+			s := runtime.NewScheme()
+			s.AddKnownTypes(GroupVersion, &MeshInjectionConfig{}, &MeshInjectionConfigList{})
+			client := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(c.existingResources...).Build()
+			decoder, err := admission.NewDecoder(s)
+			require.NoError(t, err)
+
+			validator := &MeshInjectionConfigWebhook{
+				Client:  client,
+				Logger:  logrtest.New(t),
+				decoder: decoder,
+			}
+			req := admissionv1.AdmissionRequest{
+				Name:      c.newResource.KubernetesName(),
+				Namespace: c.newResource.Namespace,
+				Operation: admissionv1.Create,
+				Object: runtime.RawExtension{
+					Raw: marshalledRequestObject,
+				},
+			}
+			if c.oldResource != nil {
+				marshalledOldObject, err := json.Marshal(c.oldResource)
+				require.NoError(t, err)
+				req.Operation = admissionv1.Update
+				req.OldObject = runtime.RawExtension{Raw: marshalledOldObject}
+			}
+			response := validator.Handle(ctx, admission.Request{AdmissionRequest: req})
+
+			require.Equal(t, c.expAllow, response.Allowed)
+			if c.expErrMessage != "" {
+				require.Equal(t, c.expErrMessage, response.AdmissionResponse.Result.Message)
+			}
+		})
+	}
+}
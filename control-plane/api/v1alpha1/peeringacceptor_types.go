@@ -4,6 +4,9 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,6 +19,10 @@ import (
 const PeeringAcceptorKubeKind = "peeringacceptors"
 const SecretBackendTypeKubernetes = "kubernetes"
 
+// MinPeeringTokenRotationInterval is the shortest allowed interval between
+// scheduled peering token rotations.
+const MinPeeringTokenRotationInterval = 5 * time.Minute
+
 func init() {
 	SchemeBuilder.Register(&PeeringAcceptor{}, &PeeringAcceptorList{})
 }
@@ -49,6 +56,26 @@ type PeeringAcceptorList struct {
 type PeeringAcceptorSpec struct {
 	// Peer describes the information needed to create a peering.
 	Peer *Peer `json:"peer"`
+	// TokenRotation configures automatic regeneration of the peering token.
+	// If unset, a new token is only generated when the secret changes or the
+	// peering version annotation is incremented.
+	// +optional
+	TokenRotation *TokenRotation `json:"tokenRotation,omitempty"`
+}
+
+// TokenRotation configures when a new peering token is generated and stored
+// in the secret backend. PeeringDialers referencing the secret re-establish
+// the peering with the new token once the secret is updated.
+type TokenRotation struct {
+	// Interval is how often a new peering token is generated, e.g. "720h".
+	// Tokens are not rotated on a schedule if unset.
+	// +optional
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// OnFailure generates a new peering token when the peering is failing or
+	// has been terminated, e.g. because the token expired before it was used.
+	// Tokens are regenerated at most every 5 minutes while the peering fails.
+	// +optional
+	OnFailure bool `json:"onFailure,omitempty"`
 }
 
 type Peer struct {
@@ -80,6 +107,13 @@ type PeeringAcceptorStatus struct {
 	// LastSyncedTime is the last time the resource successfully synced with Consul.
 	// +optional
 	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty" description:"last time the condition transitioned from one status to another"`
+	// LastTokenGenerationTime is the last time a peering token was generated.
+	// +optional
+	LastTokenGenerationTime *metav1.Time `json:"lastTokenGenerationTime,omitempty"`
+	// ObservedGeneration is the generation of the resource that was last
+	// reconciled, whether or not it was synced successfully.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" description:"generation of the resource that was last reconciled"`
 }
 
 type SecretRefStatus struct {
@@ -120,6 +154,10 @@ func (pa *PeeringAcceptor) Validate() error {
 	if pa.Spec.Peer.Secret.Backend != SecretBackendTypeKubernetes {
 		errs = append(errs, field.Invalid(field.NewPath("spec").Child("peer").Child("secret").Child("backend"), pa.Spec.Peer.Secret.Backend, `backend must be "kubernetes"`))
 	}
+	if rotation := pa.Spec.TokenRotation; rotation != nil && rotation.Interval.Duration != 0 && rotation.Interval.Duration < MinPeeringTokenRotationInterval {
+		errs = append(errs, field.Invalid(field.NewPath("spec").Child("tokenRotation").Child("interval"), rotation.Interval.Duration.String(),
+			fmt.Sprintf("interval must be at least %s", MinPeeringTokenRotationInterval)))
+	}
 	if len(errs) > 0 {
 		return apierrors.NewInvalid(
 			schema.GroupKind{Group: ConsulHashicorpGroup, Kind: PeeringAcceptorKubeKind},
@@ -129,13 +167,6 @@ func (pa *PeeringAcceptor) Validate() error {
 }
 
 func (pa *PeeringAcceptor) SetSyncedCondition(status corev1.ConditionStatus, reason string, message string) {
-	pa.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	pa.Status.Conditions = syncedConditions(pa.Status.Conditions, status, reason, message)
+	pa.Status.ObservedGeneration = pa.Generation
 }
@@ -5,6 +5,7 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -72,6 +73,48 @@ func TestPeeringAcceptor_Validate(t *testing.T) {
 				`spec.peer.secret.backend: Invalid value: "invalid": backend must be "kubernetes"`,
 			},
 		},
+		"valid token rotation": {
+			acceptor: &PeeringAcceptor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "api",
+				},
+				Spec: PeeringAcceptorSpec{
+					Peer: &Peer{
+						Secret: &Secret{
+							Name:    "api-token",
+							Key:     "data",
+							Backend: SecretBackendTypeKubernetes,
+						},
+					},
+					TokenRotation: &TokenRotation{
+						Interval:  metav1.Duration{Duration: 720 * time.Hour},
+						OnFailure: true,
+					},
+				},
+			},
+		},
+		"token rotation interval too short": {
+			acceptor: &PeeringAcceptor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "api",
+				},
+				Spec: PeeringAcceptorSpec{
+					Peer: &Peer{
+						Secret: &Secret{
+							Name:    "api-token",
+							Key:     "data",
+							Backend: SecretBackendTypeKubernetes,
+						},
+					},
+					TokenRotation: &TokenRotation{
+						Interval: metav1.Duration{Duration: time.Minute},
+					},
+				},
+			},
+			expectedErrMsgs: []string{
+				`spec.tokenRotation.interval: Invalid value: "1m0s": interval must be at least 5m0s`,
+			},
+		},
 	}
 
 	for name, testCase := range cases {
@@ -65,6 +65,10 @@ type PeeringDialerStatus struct {
 	// LastSyncedTime is the last time the resource successfully synced with Consul.
 	// +optional
 	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty" description:"last time the condition transitioned from one status to another"`
+	// ObservedGeneration is the generation of the resource that was last
+	// reconciled, whether or not it was synced successfully.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" description:"generation of the resource that was last reconciled"`
 }
 
 func (pd *PeeringDialer) Secret() *Secret {
@@ -111,13 +115,6 @@ func (pd *PeeringDialer) Validate() error {
 }
 
 func (pd *PeeringDialer) SetSyncedCondition(status corev1.ConditionStatus, reason string, message string) {
-	pd.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	pd.Status.Conditions = syncedConditions(pd.Status.Conditions, status, reason, message)
+	pd.Status.ObservedGeneration = pd.Generation
 }
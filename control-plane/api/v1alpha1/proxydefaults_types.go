@@ -6,6 +6,8 @@ package v1alpha1
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -161,15 +163,7 @@ func (in *ProxyDefaults) KubernetesName() string {
 }
 
 func (in *ProxyDefaults) SetSyncedCondition(status corev1.ConditionStatus, reason string, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.setSyncedCondition(in.Generation, status, reason, message)
 }
 
 func (in *ProxyDefaults) SetLastSyncedTime(time *metav1.Time) {
@@ -203,7 +197,7 @@ func (in *ProxyDefaults) MatchesConsul(candidate api.ConfigEntry) bool {
 		cmp.Comparer(transparentProxyConfigComparer))
 }
 
-func (in *ProxyDefaults) Validate(_ common.ConsulMeta) error {
+func (in *ProxyDefaults) Validate(consulMeta common.ConsulMeta) error {
 	var allErrs field.ErrorList
 	path := field.NewPath("spec")
 
@@ -219,7 +213,9 @@ func (in *ProxyDefaults) Validate(_ common.ConsulMeta) error {
 	if err := in.Spec.Mode.validate(path.Child("mode")); err != nil {
 		allErrs = append(allErrs, err)
 	}
-	if err := in.validateConfig(path.Child("config")); err != nil {
+	// Escape hatches are only validated when validation against Consul is enabled
+	// since resources with invalid escape hatches were previously accepted.
+	if err := in.validateConfig(path.Child("config"), consulMeta.ConsulValidator != nil); err != nil {
 		allErrs = append(allErrs, err)
 	}
 	if err := in.Spec.AccessLogs.validate(path.Child("accessLogs")); err != nil {
@@ -259,8 +255,9 @@ func (in *ProxyDefaults) convertConfig() map[string]interface{} {
 
 // validateConfig attempts to unmarshall the provided config into a map[string]interface{}
 // and returns an error if the provided value for config isn't successfully unmarshalled
-// and it implies the provided value is an invalid config.
-func (in *ProxyDefaults) validateConfig(path *field.Path) *field.Error {
+// and it implies the provided value is an invalid config. If escapeHatches is true, the
+// Envoy escape hatches in the config are validated too.
+func (in *ProxyDefaults) validateConfig(path *field.Path, escapeHatches bool) *field.Error {
 	if in.Spec.Config == nil {
 		return nil
 	}
@@ -268,6 +265,37 @@ func (in *ProxyDefaults) validateConfig(path *field.Path) *field.Error {
 	if err := json.Unmarshal(in.Spec.Config, &outConfig); err != nil {
 		return field.Invalid(path, string(in.Spec.Config), fmt.Sprintf(`must be valid map value: %s`, err))
 	}
+	if !escapeHatches {
+		return nil
+	}
+	return validateEscapeHatches(path, outConfig)
+}
+
+// validateEscapeHatches returns an error if one of the Envoy escape hatches
+// in config, e.g. envoy_tracing_json, isn't valid JSON. Envoy only rejects
+// these once it is bootstrapped, so without this check an invalid escape hatch
+// breaks the proxies instead of the request that set it.
+func validateEscapeHatches(path *field.Path, config map[string]interface{}) *field.Error {
+	keys := make([]string, 0, len(config))
+	for k := range config {
+		if strings.HasPrefix(k, "envoy_") && strings.HasSuffix(k, "_json") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value, ok := config[k].(string)
+		if !ok {
+			return field.Invalid(path.Child(k), config[k], "must be a string containing JSON")
+		}
+		// Escape hatches such as envoy_extra_static_clusters_json may contain
+		// a comma separated list of objects, so they are validated as the
+		// elements of a JSON array.
+		var out []interface{}
+		if err := json.Unmarshal([]byte("["+value+"]"), &out); err != nil {
+			return field.Invalid(path.Child(k), value, fmt.Sprintf("must be valid JSON: %s", err))
+		}
+	}
 	return nil
 }
 
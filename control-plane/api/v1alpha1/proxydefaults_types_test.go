@@ -703,6 +703,7 @@ func TestProxyDefaults_ValidateConfigValid(t *testing.T) {
 		"envoy_tracing_json & members":           json.RawMessage(`{"envoy_tracing_json": "{\"http\":{\"name\":\"envoy.zipkin\",\"config\":{\"collector_cluster\":\"zipkin\",\"collector_endpoint\":\"/api/v1/spans\",\"shared_span_context\":false}}}","members":  3}`),
 		"protocol & members":                     json.RawMessage(`{"protocol": "https","members":  3}`),
 		"envoy_tracing_json, protocol & members": json.RawMessage(`{"envoy_tracing_json": "{\"http\":{\"name\":\"envoy.zipkin\",\"config\":{\"collector_cluster\":\"zipkin\",\"collector_endpoint\":\"/api/v1/spans\",\"shared_span_context\":false}}}","protocol":  "http", "members": 3}`),
+		"envoy_extra_static_clusters_json":       json.RawMessage(`{"envoy_extra_static_clusters_json": "{\"name\":\"a\"},{\"name\":\"b\"}"}`),
 	}
 	for name, c := range cases {
 		proxyDefaults := ProxyDefaults{
@@ -714,7 +715,7 @@ func TestProxyDefaults_ValidateConfigValid(t *testing.T) {
 			},
 		}
 		t.Run(name, func(t *testing.T) {
-			require.Nil(t, proxyDefaults.validateConfig(nil))
+			require.Nil(t, proxyDefaults.validateConfig(nil, true))
 		})
 	}
 }
@@ -736,7 +737,42 @@ func TestProxyDefaults_ValidateConfigInvalid(t *testing.T) {
 			},
 		}
 		t.Run(name, func(t *testing.T) {
-			require.Contains(t, proxyDefaults.validateConfig(field.NewPath("spec")).Detail, "must be valid map value")
+			require.Contains(t, proxyDefaults.validateConfig(field.NewPath("spec"), true).Detail, "must be valid map value")
+		})
+	}
+}
+
+func TestProxyDefaults_ValidateConfigInvalidEscapeHatch(t *testing.T) {
+	cases := map[string]struct {
+		config    json.RawMessage
+		expDetail string
+	}{
+		"invalid json": {
+			config:    json.RawMessage(`{"envoy_tracing_json": "{\"http\":"}`),
+			expDetail: "must be valid JSON",
+		},
+		"not a string": {
+			config:    json.RawMessage(`{"envoy_tracing_json": {"http": {}}}`),
+			expDetail: "must be a string containing JSON",
+		},
+	}
+	for name, c := range cases {
+		proxyDefaults := ProxyDefaults{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: common.Global,
+			},
+			Spec: ProxyDefaultsSpec{
+				Config: c.config,
+			},
+		}
+		t.Run(name, func(t *testing.T) {
+			err := proxyDefaults.validateConfig(field.NewPath("spec").Child("config"), true)
+			require.NotNil(t, err)
+			require.Equal(t, "spec.config.envoy_tracing_json", err.Field)
+			require.Contains(t, err.Detail, c.expDetail)
+
+			// Escape hatches aren't validated unless validation against Consul is enabled.
+			require.Nil(t, proxyDefaults.validateConfig(field.NewPath("spec").Child("config"), false))
 		})
 	}
 }
@@ -54,14 +54,22 @@ func (v *ProxyDefaultsWebhook) Handle(ctx context.Context, req admission.Request
 			return admission.Errored(http.StatusInternalServerError, err)
 		}
 
-		if len(proxyDefaultsList.Items) > 0 {
-			return admission.Errored(http.StatusBadRequest,
-				fmt.Errorf("%s resource already defined - only one global entry is supported",
-					proxyDefaults.KubeKind()))
+		// Proxy defaults for other partitions can be defined with the
+		// partition annotation.
+		for _, item := range proxyDefaultsList.Items {
+			if common.PartitionAnnotation(&item) == common.PartitionAnnotation(&proxyDefaults) {
+				return admission.Errored(http.StatusBadRequest,
+					fmt.Errorf("%s resource already defined - only one global entry is supported",
+						proxyDefaults.KubeKind()))
+			}
 		}
 	}
 
-	if err := proxyDefaults.Validate(v.ConsulMeta); err != nil {
+	consulMeta, err := common.WithPartitionAnnotation(req, &proxyDefaults, v.ConsulMeta)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if err := proxyDefaults.Validate(consulMeta); err != nil {
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 	return admission.Allowed(fmt.Sprintf("valid %s request", proxyDefaults.KubeKind()))
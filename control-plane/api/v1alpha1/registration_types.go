@@ -0,0 +1,250 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	capi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+const RegistrationKubeKind = "registrations"
+
+func init() {
+	SchemeBuilder.Register(&Registration{}, &RegistrationList{})
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// Registration is the Schema for the registrations API. It registers an
+// external service, i.e. a service that doesn't run in Kubernetes, in the
+// Consul catalog.
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status",description="The sync status of the resource with Consul"
+// +kubebuilder:printcolumn:name="Last Synced",type="date",JSONPath=".status.lastSyncedTime",description="The last successful synced time of the resource with Consul"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="The age of the resource"
+// +kubebuilder:resource:shortName="registration"
+type Registration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RegistrationSpec `json:"spec,omitempty"`
+	Status `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// RegistrationList contains a list of Registration.
+type RegistrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Registration `json:"items"`
+}
+
+// RegistrationSpec defines the desired state of Registration.
+type RegistrationSpec struct {
+	// Node is the name of the Consul node that the service is registered on.
+	// It usually represents the external host that runs the service.
+	Node string `json:"node"`
+	// Address is the address of the node.
+	Address string `json:"address"`
+	// NodeMeta is arbitrary metadata of the node.
+	NodeMeta map[string]string `json:"nodeMeta,omitempty"`
+	// Datacenter is the Consul datacenter to register the service in.
+	// Defaults to the datacenter of the Consul servers.
+	Datacenter string `json:"datacenter,omitempty"`
+	// Partition is the Consul admin partition to register the node and
+	// service in. Defaults to the partition of the Consul servers.
+	Partition string `json:"partition,omitempty"`
+	// Service is the service to register.
+	Service RegistrationService `json:"service"`
+	// HealthCheck is an optional health check of the service. Consul doesn't
+	// run checks of external services itself, so the status is typically
+	// updated by consul-esm.
+	HealthCheck *RegistrationHealthCheck `json:"healthCheck,omitempty"`
+	// TerminatingGateway is the name of a terminating gateway that the service
+	// is added to, so that services in the mesh can reach it through the gateway.
+	// The terminating gateway config entry must not be managed by a
+	// TerminatingGateway resource since that would overwrite the service.
+	TerminatingGateway string `json:"terminatingGateway,omitempty"`
+}
+
+// RegistrationService is an external service registered in Consul.
+type RegistrationService struct {
+	// ID is the ID of the service instance. Defaults to the service name.
+	ID string `json:"id,omitempty"`
+	// Name is the name of the service.
+	Name string `json:"name"`
+	// Tags are the tags of the service.
+	Tags []string `json:"tags,omitempty"`
+	// Meta is arbitrary metadata of the service.
+	Meta map[string]string `json:"meta,omitempty"`
+	// Address is the address of the service. Defaults to the node address.
+	Address string `json:"address,omitempty"`
+	// Port is the port of the service.
+	Port int `json:"port,omitempty"`
+	// Namespace is the Consul namespace to register the service in.
+	// Consul Enterprise only.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RegistrationHealthCheck is the health check of an external service.
+type RegistrationHealthCheck struct {
+	// CheckID is the ID of the check. Defaults to the check name.
+	CheckID string `json:"checkId,omitempty"`
+	// Name is the name of the check.
+	Name string `json:"name"`
+	// Notes are human-readable notes of the check.
+	Notes string `json:"notes,omitempty"`
+	// Status is the initial status of the check: passing, warning or critical.
+	// Defaults to critical.
+	Status string `json:"status,omitempty"`
+	// Output is the initial output of the check.
+	Output string `json:"output,omitempty"`
+	// HTTP is the URL that consul-esm sends HTTP requests to.
+	HTTP string `json:"http,omitempty"`
+	// Method is the HTTP method of the requests. Defaults to GET.
+	Method string `json:"method,omitempty"`
+	// TCP is the host:port that consul-esm opens TCP connections to.
+	TCP string `json:"tcp,omitempty"`
+	// Interval is the time between two runs of the check.
+	Interval metav1.Duration `json:"interval,omitempty"`
+	// Timeout is the timeout of a run of the check.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+	// DeregisterCriticalServiceAfter is the time after which the service is
+	// deregistered if the check stays critical.
+	DeregisterCriticalServiceAfter metav1.Duration `json:"deregisterCriticalServiceAfter,omitempty"`
+}
+
+func (r *Registration) KubeKind() string {
+	return RegistrationKubeKind
+}
+
+func (r *Registration) KubernetesName() string {
+	return r.ObjectMeta.Name
+}
+
+// ServiceID returns the ID of the service instance in Consul.
+func (r *Registration) ServiceID() string {
+	if r.Spec.Service.ID != "" {
+		return r.Spec.Service.ID
+	}
+	return r.Spec.Service.Name
+}
+
+// ToCatalogRegistration returns the Consul catalog registration of the service.
+func (r *Registration) ToCatalogRegistration() *capi.CatalogRegistration {
+	reg := &capi.CatalogRegistration{
+		Node:       r.Spec.Node,
+		Address:    r.Spec.Address,
+		NodeMeta:   r.Spec.NodeMeta,
+		Datacenter: r.Spec.Datacenter,
+		Partition:  r.Spec.Partition,
+		Service: &capi.AgentService{
+			ID:        r.ServiceID(),
+			Service:   r.Spec.Service.Name,
+			Tags:      r.Spec.Service.Tags,
+			Meta:      r.Spec.Service.Meta,
+			Address:   r.Spec.Service.Address,
+			Port:      r.Spec.Service.Port,
+			Namespace: r.Spec.Service.Namespace,
+			Partition: r.Spec.Partition,
+		},
+	}
+	if hc := r.Spec.HealthCheck; hc != nil {
+		checkID := hc.CheckID
+		if checkID == "" {
+			checkID = hc.Name
+		}
+		status := hc.Status
+		if status == "" {
+			status = capi.HealthCritical
+		}
+		reg.Check = &capi.AgentCheck{
+			Node:        r.Spec.Node,
+			CheckID:     checkID,
+			Name:        hc.Name,
+			Notes:       hc.Notes,
+			Status:      status,
+			Output:      hc.Output,
+			ServiceID:   r.ServiceID(),
+			ServiceName: r.Spec.Service.Name,
+			Namespace:   r.Spec.Service.Namespace,
+			Partition:   r.Spec.Partition,
+			Definition: capi.HealthCheckDefinition{
+				HTTP:                                   hc.HTTP,
+				Method:                                 hc.Method,
+				TCP:                                    hc.TCP,
+				IntervalDuration:                       hc.Interval.Duration,
+				TimeoutDuration:                        hc.Timeout.Duration,
+				DeregisterCriticalServiceAfterDuration: hc.DeregisterCriticalServiceAfter.Duration,
+			},
+		}
+	}
+	return reg
+}
+
+// ToCatalogDeregistration returns the Consul catalog deregistration of the service.
+func (r *Registration) ToCatalogDeregistration() *capi.CatalogDeregistration {
+	return &capi.CatalogDeregistration{
+		Node:       r.Spec.Node,
+		ServiceID:  r.ServiceID(),
+		Datacenter: r.Spec.Datacenter,
+		Namespace:  r.Spec.Service.Namespace,
+		Partition:  r.Spec.Partition,
+	}
+}
+
+func (r *Registration) Validate() error {
+	var errs field.ErrorList
+	path := field.NewPath("spec")
+	if r.Spec.Node == "" {
+		errs = append(errs, field.Required(path.Child("node"), "node must be specified"))
+	}
+	if r.Spec.Address == "" {
+		errs = append(errs, field.Required(path.Child("address"), "address must be specified"))
+	}
+	if r.Spec.Service.Name == "" {
+		errs = append(errs, field.Required(path.Child("service", "name"), "service name must be specified"))
+	}
+	if r.Spec.Service.Port < 0 || r.Spec.Service.Port > 65535 {
+		errs = append(errs, field.Invalid(path.Child("service", "port"), r.Spec.Service.Port, "port must be between 0 and 65535"))
+	}
+	if hc := r.Spec.HealthCheck; hc != nil {
+		hcPath := path.Child("healthCheck")
+		if hc.Name == "" {
+			errs = append(errs, field.Required(hcPath.Child("name"), "health check name must be specified"))
+		}
+		switch hc.Status {
+		case "", capi.HealthPassing, capi.HealthWarning, capi.HealthCritical:
+		default:
+			errs = append(errs, field.Invalid(hcPath.Child("status"), hc.Status,
+				fmt.Sprintf("must be one of %q, %q or %q", capi.HealthPassing, capi.HealthWarning, capi.HealthCritical)))
+		}
+		if hc.HTTP != "" && hc.TCP != "" {
+			errs = append(errs, field.Invalid(hcPath, hc, "only one of http or tcp may be set"))
+		}
+	}
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(
+			schema.GroupKind{Group: ConsulHashicorpGroup, Kind: RegistrationKubeKind},
+			r.KubernetesName(), errs)
+	}
+	return nil
+}
+
+func (r *Registration) SetSyncedCondition(status corev1.ConditionStatus, reason string, message string) {
+	r.Status.setSyncedCondition(r.Generation, status, reason, message)
+}
+
+func (r *Registration) SetLastSyncedTime(time *metav1.Time) {
+	r.Status.LastSyncedTime = time
+}
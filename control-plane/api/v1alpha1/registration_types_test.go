@@ -0,0 +1,161 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRegistration_ToCatalogRegistration(t *testing.T) {
+	cases := map[string]struct {
+		input    *Registration
+		expected *capi.CatalogRegistration
+	}{
+		"minimal": {
+			input: &Registration{
+				ObjectMeta: metav1.ObjectMeta{Name: "external"},
+				Spec: RegistrationSpec{
+					Node:    "external-node",
+					Address: "10.0.0.1",
+					Service: RegistrationService{
+						Name: "external",
+						Port: 443,
+					},
+				},
+			},
+			expected: &capi.CatalogRegistration{
+				Node:    "external-node",
+				Address: "10.0.0.1",
+				Service: &capi.AgentService{
+					ID:      "external",
+					Service: "external",
+					Port:    443,
+				},
+			},
+		},
+		"health check with defaults": {
+			input: &Registration{
+				ObjectMeta: metav1.ObjectMeta{Name: "external"},
+				Spec: RegistrationSpec{
+					Node:      "external-node",
+					Address:   "10.0.0.1",
+					Partition: "ap1",
+					Service: RegistrationService{
+						ID:        "external-1",
+						Name:      "external",
+						Namespace: "ns1",
+						Port:      443,
+					},
+					HealthCheck: &RegistrationHealthCheck{
+						Name:     "external-https",
+						HTTP:     "https://10.0.0.1/health",
+						Interval: metav1.Duration{Duration: 10 * time.Second},
+					},
+				},
+			},
+			expected: &capi.CatalogRegistration{
+				Node:      "external-node",
+				Address:   "10.0.0.1",
+				Partition: "ap1",
+				Service: &capi.AgentService{
+					ID:        "external-1",
+					Service:   "external",
+					Port:      443,
+					Namespace: "ns1",
+					Partition: "ap1",
+				},
+				Check: &capi.AgentCheck{
+					Node:        "external-node",
+					CheckID:     "external-https",
+					Name:        "external-https",
+					Status:      capi.HealthCritical,
+					ServiceID:   "external-1",
+					ServiceName: "external",
+					Namespace:   "ns1",
+					Partition:   "ap1",
+					Definition: capi.HealthCheckDefinition{
+						HTTP:             "https://10.0.0.1/health",
+						IntervalDuration: 10 * time.Second,
+					},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.expected, c.input.ToCatalogRegistration())
+		})
+	}
+}
+
+func TestRegistration_Validate(t *testing.T) {
+	valid := func() *Registration {
+		return &Registration{
+			ObjectMeta: metav1.ObjectMeta{Name: "external"},
+			Spec: RegistrationSpec{
+				Node:    "external-node",
+				Address: "10.0.0.1",
+				Service: RegistrationService{Name: "external", Port: 443},
+			},
+		}
+	}
+	cases := map[string]struct {
+		modify   func(*Registration)
+		expError string
+	}{
+		"valid": {
+			modify: func(*Registration) {},
+		},
+		"missing node and address": {
+			modify: func(r *Registration) {
+				r.Spec.Node = ""
+				r.Spec.Address = ""
+			},
+			expError: `registrations.consul.hashicorp.com "external" is invalid: [spec.node: Required value: node must be specified, spec.address: Required value: address must be specified]`,
+		},
+		"missing service name": {
+			modify: func(r *Registration) {
+				r.Spec.Service.Name = ""
+			},
+			expError: `spec.service.name: Required value: service name must be specified`,
+		},
+		"invalid port": {
+			modify: func(r *Registration) {
+				r.Spec.Service.Port = 70000
+			},
+			expError: `spec.service.port: Invalid value: 70000: port must be between 0 and 65535`,
+		},
+		"invalid health check status": {
+			modify: func(r *Registration) {
+				r.Spec.HealthCheck = &RegistrationHealthCheck{Name: "check", Status: "ok"}
+			},
+			expError: `spec.healthCheck.status: Invalid value: "ok": must be one of "passing", "warning" or "critical"`,
+		},
+		"health check with http and tcp": {
+			modify: func(r *Registration) {
+				r.Spec.HealthCheck = &RegistrationHealthCheck{Name: "check", HTTP: "http://10.0.0.1", TCP: "10.0.0.1:80"}
+			},
+			expError: `only one of http or tcp may be set`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := valid()
+			c.modify(r)
+			err := r.Validate()
+			if c.expError == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, c.expError)
+			}
+		})
+	}
+}
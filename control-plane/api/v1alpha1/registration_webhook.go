@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// +kubebuilder:object:generate=false
+
+type RegistrationWebhook struct {
+	client.Client
+	Logger  logr.Logger
+	decoder *admission.Decoder
+}
+
+// NOTE: The path value in the below line is the path to the webhook.
+// If it is updated, run code-gen, update subcommand/controller/command.go
+// and the consul-helm value for the path to the webhook.
+//
+// NOTE: The below line cannot be combined with any other comment. If it is
+// it will break the code generation.
+//
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-v1alpha1-registrations,mutating=true,failurePolicy=fail,groups=consul.hashicorp.com,resources=registrations,versions=v1alpha1,name=mutate-registrations.consul.hashicorp.com,sideEffects=None,admissionReviewVersions=v1beta1;v1
+
+func (v *RegistrationWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var registration Registration
+	err := v.decoder.Decode(req, &registration)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if err := registration.Validate(); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	switch req.Operation {
+	case admissionv1.Create:
+		v.Logger.Info("validate create", "name", registration.KubernetesName())
+
+		var registrationList RegistrationList
+		if err := v.Client.List(ctx, &registrationList); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		for _, item := range registrationList.Items {
+			// If another resource registers the same service instance, the resources would overwrite each other.
+			if sameServiceInstance(&item, &registration) {
+				return admission.Errored(http.StatusBadRequest,
+					fmt.Errorf("an existing Registration resource registers service ID %q on node %q `name: %s, namespace: %s`",
+						registration.ServiceID(), registration.Spec.Node, item.Name, item.Namespace))
+			}
+		}
+	case admissionv1.Update:
+		v.Logger.Info("validate update", "name", registration.KubernetesName())
+
+		var prevRegistration Registration
+		if err := v.decoder.DecodeRaw(*req.OldObject.DeepCopy(), &prevRegistration); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		// The fields that identify the service instance in Consul can't be updated, otherwise the previous
+		// registration would be left behind in Consul.
+		if !sameServiceInstance(&prevRegistration, &registration) ||
+			prevRegistration.Spec.Service.Name != registration.Spec.Service.Name ||
+			prevRegistration.Spec.TerminatingGateway != registration.Spec.TerminatingGateway {
+			return admission.Errored(http.StatusBadRequest,
+				errors.New("spec.node, spec.datacenter, spec.partition, spec.service.id, spec.service.name, spec.service.namespace and spec.terminatingGateway are immutable fields for Registration"))
+		}
+	}
+
+	return admission.Allowed(fmt.Sprintf("valid %s request", registration.KubeKind()))
+}
+
+func (v *RegistrationWebhook) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// sameServiceInstance returns true if a and b register the same service instance in Consul.
+func sameServiceInstance(a, b *Registration) bool {
+	return a.Spec.Node == b.Spec.Node &&
+		a.Spec.Datacenter == b.Spec.Datacenter &&
+		a.Spec.Partition == b.Spec.Partition &&
+		a.ServiceID() == b.ServiceID() &&
+		a.Spec.Service.Namespace == b.Spec.Service.Namespace
+}
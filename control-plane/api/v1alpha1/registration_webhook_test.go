@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidateRegistration(t *testing.T) {
+	registration := func(name, node, serviceName string) *Registration {
+		return &Registration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: RegistrationSpec{
+				Node:    node,
+				Address: "10.0.0.1",
+				Service: RegistrationService{Name: serviceName},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		existingResources []runtime.Object
+		oldResource       *Registration
+		newResource       *Registration
+		expAllow          bool
+		expErrMessage     string
+	}{
+		"valid create": {
+			existingResources: []runtime.Object{registration("other", "node", "other")},
+			newResource:       registration("external", "node", "external"),
+			expAllow:          true,
+		},
+		"invalid create": {
+			newResource:   registration("external", "", "external"),
+			expAllow:      false,
+			expErrMessage: `registrations.consul.hashicorp.com "external" is invalid: spec.node: Required value: node must be specified`,
+		},
+		"create registering the same service instance": {
+			existingResources: []runtime.Object{registration("other", "node", "external")},
+			newResource:       registration("external", "node", "external"),
+			expAllow:          false,
+			expErrMessage:     "an existing Registration resource registers service ID \"external\" on node \"node\" `name: other, namespace: default`",
+		},
+		"valid update": {
+			oldResource: registration("external", "node", "external"),
+			newResource: func() *Registration {
+				r := registration("external", "node", "external")
+				r.Spec.Address = "10.0.0.2"
+				return r
+			}(),
+			expAllow: true,
+		},
+		"update of the node": {
+			oldResource:   registration("external", "node", "external"),
+			newResource:   registration("external", "other-node", "external"),
+			expAllow:      false,
+			expErrMessage: "spec.node, spec.datacenter, spec.partition, spec.service.id, spec.service.name, spec.service.namespace and spec.terminatingGateway are immutable fields for Registration",
+		},
+		"update of the terminating gateway": {
+			oldResource: registration("external", "node", "external"),
+			newResource: func() *Registration {
+				r := registration("external", "node", "external")
+				r.Spec.TerminatingGateway = "terminating-gateway"
+				return r
+			}(),
+			expAllow:      false,
+			expErrMessage: "spec.node, spec.datacenter, spec.partition, spec.service.id, spec.service.name, spec.service.namespace and spec.terminatingGateway are immutable fields for Registration",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			marshalledRequestObject, err := json.Marshal(c.newResource)
+			require.NoError(t, err)
+			s := runtime.NewScheme()
+			s.AddKnownTypes(GroupVersion, &Registration{}, &RegistrationList{})
+			client := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(c.existingResources...).Build()
+			decoder, err := admission.NewDecoder(s)
+			require.NoError(t, err)
+
+			validator := &RegistrationWebhook{
+				Client:  client,
+				Logger:  logrtest.New(t),
+				decoder: decoder,
+			}
+			req := admissionv1.AdmissionRequest{
+				Name:      c.newResource.KubernetesName(),
+				Namespace: "default",
+				Operation: admissionv1.Create,
+				Object: runtime.RawExtension{
+					Raw: marshalledRequestObject,
+				},
+			}
+			if c.oldResource != nil {
+				marshalledOldObject, err := json.Marshal(c.oldResource)
+				require.NoError(t, err)
+				req.Operation = admissionv1.Update
+				req.OldObject = runtime.RawExtension{Raw: marshalledOldObject}
+			}
+			response := validator.Handle(ctx, admission.Request{AdmissionRequest: req})
+
+			require.Equal(t, c.expAllow, response.Allowed)
+			if c.expErrMessage != "" {
+				require.Equal(t, c.expErrMessage, response.AdmissionResponse.Result.Message)
+			}
+		})
+	}
+}
@@ -119,15 +119,7 @@ func (in *SamenessGroup) KubernetesName() string {
 }
 
 func (in *SamenessGroup) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.setSyncedCondition(in.Generation, status, reason, message)
 }
 
 func (in *SamenessGroup) SetLastSyncedTime(time *metav1.Time) {
@@ -185,7 +177,7 @@ func (in *SamenessGroup) Validate(consulMeta common.ConsulMeta) error {
 	includesLocal := in.Spec.IncludeLocal
 
 	if in.ObjectMeta.Namespace != "default" && in.ObjectMeta.Namespace != "" {
-		allErrs = append(allErrs, field.Invalid(path.Child("name"), consulMeta.DestinationNamespace, "sameness groups must reside in the default namespace"))
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata").Child("namespace"), in.ObjectMeta.Namespace, "sameness groups must reside in the default namespace"))
 	}
 
 	if len(in.Spec.Members) == 0 {
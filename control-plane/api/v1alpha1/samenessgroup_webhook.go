@@ -5,6 +5,7 @@ package v1alpha1
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/go-logr/logr"
@@ -40,9 +41,36 @@ func (v *SamenessGroupWebhook) Handle(ctx context.Context, req admission.Request
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
+	// Consul only allows one sameness group per partition to be the default for failover.
+	// Sameness groups for other partitions can be defined with the partition annotation.
+	if resource.Spec.DefaultForFailover {
+		var resourceList SamenessGroupList
+		if err := v.Client.List(ctx, &resourceList); err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		partition := v.partition(&resource)
+		for _, item := range resourceList.Items {
+			if !item.Spec.DefaultForFailover || v.partition(&item) != partition {
+				continue
+			}
+			if item.KubernetesName() != resource.KubernetesName() {
+				return admission.Errored(http.StatusBadRequest,
+					fmt.Errorf("sameness group %q is already the default for failover in partition %q - only one sameness group per partition can set defaultForFailover", item.KubernetesName(), partition))
+			}
+		}
+	}
+
 	return common.ValidateConfigEntry(ctx, req, v.Logger, v, &resource, v.ConsulMeta)
 }
 
+// partition returns the admin partition that the sameness group is written to.
+func (v *SamenessGroupWebhook) partition(resource *SamenessGroup) string {
+	if partition := common.PartitionAnnotation(resource); partition != "" {
+		return partition
+	}
+	return v.ConsulMeta.Partition
+}
+
 func (v *SamenessGroupWebhook) List(ctx context.Context) ([]common.ConfigEntryResource, error) {
 	var resourceList SamenessGroupList
 	if err := v.Client.List(ctx, &resourceList); err != nil {
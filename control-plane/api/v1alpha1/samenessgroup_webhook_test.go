@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/common"
+)
+
+func TestValidateSamenessGroup(t *testing.T) {
+	samenessGroup := func(name string, defaultForFailover bool) *SamenessGroup {
+		return &SamenessGroup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: SamenessGroupSpec{
+				DefaultForFailover: defaultForFailover,
+				IncludeLocal:       true,
+				Members: []SamenessGroupMember{
+					{Peer: "peer1"},
+				},
+			},
+		}
+	}
+
+	withPartition := func(group *SamenessGroup, partition string) *SamenessGroup {
+		group.Annotations = map[string]string{common.PartitionKey: partition}
+		return group
+	}
+
+	cases := map[string]struct {
+		existingResources []runtime.Object
+		newResource       *SamenessGroup
+		expAllow          bool
+		expErrMessage     string
+	}{
+		"no duplicates, valid": {
+			existingResources: []runtime.Object{samenessGroup("other", false)},
+			newResource:       samenessGroup("group", true),
+			expAllow:          true,
+		},
+		"name already exists": {
+			existingResources: []runtime.Object{samenessGroup("group", false)},
+			newResource:       samenessGroup("group", false),
+			expAllow:          false,
+			expErrMessage:     `samenessgroup resource with name "group" is already defined – all samenessgroup resources must have unique names across namespaces`,
+		},
+		"another sameness group is the default for failover": {
+			existingResources: []runtime.Object{samenessGroup("other", true)},
+			newResource:       samenessGroup("group", true),
+			expAllow:          false,
+			expErrMessage:     `sameness group "other" is already the default for failover in partition "default" - only one sameness group per partition can set defaultForFailover`,
+		},
+		"another sameness group is the default for failover in another partition": {
+			existingResources: []runtime.Object{withPartition(samenessGroup("other", true), "ap1")},
+			newResource:       samenessGroup("group", true),
+			expAllow:          true,
+		},
+		"another sameness group is the default for failover in the same partition set with the annotation": {
+			existingResources: []runtime.Object{withPartition(samenessGroup("other", true), "ap1")},
+			newResource:       withPartition(samenessGroup("group", true), "ap1"),
+			expAllow:          false,
+			expErrMessage:     `sameness group "other" is already the default for failover in partition "ap1" - only one sameness group per partition can set defaultForFailover`,
+		},
+		"another sameness group is the default for failover in the local partition set with the annotation": {
+			existingResources: []runtime.Object{samenessGroup("other", true)},
+			newResource:       withPartition(samenessGroup("group", true), "default"),
+			expAllow:          false,
+			expErrMessage:     `sameness group "other" is already the default for failover in partition "default" - only one sameness group per partition can set defaultForFailover`,
+		},
+		"invalid spec": {
+			newResource: &SamenessGroup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "group",
+					Namespace: "default",
+				},
+				Spec: SamenessGroupSpec{
+					Members: []SamenessGroupMember{
+						{Peer: "peer1"},
+					},
+				},
+			},
+			expAllow:      false,
+			expErrMessage: `samenessgroup.consul.hashicorp.com "group" is invalid: spec.members: Invalid value: false: the local partition must be a member of sameness groups`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			marshalledRequestObject, err := json.Marshal(c.newResource)
+			require.NoError(t, err)
+			s := runtime.NewScheme()
+			s.AddKnownTypes(GroupVersion, &SamenessGroup{}, &SamenessGroupList{})
+			client := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(c.existingResources...).Build()
+			decoder, err := admission.NewDecoder(s)
+			require.NoError(t, err)
+
+			validator := &SamenessGroupWebhook{
+				Client:     client,
+				Logger:     logrtest.New(t),
+				decoder:    decoder,
+				ConsulMeta: common.ConsulMeta{Partition: "default"},
+			}
+			response := validator.Handle(ctx, admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Name:      c.newResource.KubernetesName(),
+					Namespace: "default",
+					Operation: admissionv1.Create,
+					Object: runtime.RawExtension{
+						Raw: marshalledRequestObject,
+					},
+				},
+			})
+
+			require.Equal(t, c.expAllow, response.Allowed)
+			if c.expErrMessage != "" {
+				require.Equal(t, c.expErrMessage, response.AdmissionResponse.Result.Message)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/google/go-cmp/cmp"
@@ -217,15 +218,7 @@ func (in *ServiceIntentions) KubernetesName() string {
 }
 
 func (in *ServiceIntentions) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.setSyncedCondition(in.Generation, status, reason, message)
 }
 
 func (in *ServiceIntentions) SetLastSyncedTime(time *metav1.Time) {
@@ -632,6 +625,57 @@ func (in *IntentionJWTProvider) validate(path *field.Path) *field.Error {
 	return nil
 }
 
+// jwtProviderNames returns the names of the JWT providers referenced by the
+// intentions, keyed by the path of the reference.
+func (in *ServiceIntentions) jwtProviderNames() map[string]string {
+	names := make(map[string]string)
+	path := field.NewPath("spec")
+	in.Spec.JWT.addProviderNames(path.Child("jwt"), names)
+	for i, source := range in.Spec.Sources {
+		if source == nil {
+			continue
+		}
+		for j, permission := range source.Permissions {
+			if permission == nil {
+				continue
+			}
+			permission.JWT.addProviderNames(path.Child("sources").Index(i).Child("permissions").Index(j).Child("jwt"), names)
+		}
+	}
+	return names
+}
+
+func (in *IntentionJWTRequirement) addProviderNames(path *field.Path, names map[string]string) {
+	if in == nil {
+		return
+	}
+	for i, p := range in.Providers {
+		if p != nil && p.Name != "" {
+			names[path.Child("providers").Index(i).Child("name").String()] = p.Name
+		}
+	}
+}
+
+// jwtProviderWarnings returns a warning for every JWT provider referenced by
+// the intentions that isn't one of the given providers.
+func (in *ServiceIntentions) jwtProviderWarnings(providers map[string]bool) []string {
+	names := in.jwtProviderNames()
+	paths := make([]string, 0, len(names))
+	for p := range names {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var warnings []string
+	for _, p := range paths {
+		if !providers[names[p]] {
+			warnings = append(warnings, fmt.Sprintf("%s: JWT provider %q is not defined by a JWTProvider resource; "+
+				"Consul rejects the intentions until the provider exists in Consul", p, names[p]))
+		}
+	}
+	return warnings
+}
+
 // sourceIntentionSortKey returns a string that can be used to sort intention
 // sources.
 func sourceIntentionSortKey(ixn *capi.SourceIntention) string {
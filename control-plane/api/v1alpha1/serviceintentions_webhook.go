@@ -56,6 +56,10 @@ func (v *ServiceIntentionsWebhook) Handle(ctx context.Context, req admission.Req
 		}
 
 		for _, item := range svcIntentionsList.Items {
+			// Intentions in different partitions don't conflict.
+			if common.PartitionAnnotation(&item) != common.PartitionAnnotation(&svcIntentions) {
+				continue
+			}
 			if singleConsulDestNS {
 				// If all config entries will be registered in the same Consul namespace, then spec.name
 				// must be unique for all entries so two custom resources don't configure the same Consul resource.
@@ -86,15 +90,37 @@ func (v *ServiceIntentionsWebhook) Handle(ctx context.Context, req admission.Req
 		}
 	}
 
+	consulMeta, err := common.WithPartitionAnnotation(req, &svcIntentions, v.ConsulMeta)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
 	// ServiceIntentions are invalid if destination namespaces or source namespaces are set when Consul Namespaces are not enabled.
-	if err := svcIntentions.Validate(v.ConsulMeta); err != nil {
+	if err := svcIntentions.Validate(consulMeta); err != nil {
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
+	// Consul rejects intentions that reference a JWT provider that doesn't exist. The provider
+	// may have been written to Consul directly, so a provider that isn't defined by a
+	// JWTProvider resource only results in a warning.
+	var warnings []string
+	if len(svcIntentions.jwtProviderNames()) > 0 {
+		var jwtProviderList JWTProviderList
+		if err := v.Client.List(ctx, &jwtProviderList); err != nil {
+			v.Logger.Error(err, "failed to list JWTProviders", "name", svcIntentions.KubernetesName())
+		} else {
+			providers := make(map[string]bool)
+			for _, item := range jwtProviderList.Items {
+				providers[item.KubernetesName()] = true
+			}
+			warnings = svcIntentions.jwtProviderWarnings(providers)
+		}
+	}
+
 	// We always return an admission.Patched() response, even if there are no patches, since
 	// admission.Patched() with no patches is equal to admission.Allowed() under
 	// the hood.
-	return admission.Patched(fmt.Sprintf("valid %s request", svcIntentions.KubeKind()), defaultingPatches...)
+	return admission.Patched(fmt.Sprintf("valid %s request", svcIntentions.KubeKind()), defaultingPatches...).WithWarnings(warnings...)
 }
 
 func (v *ServiceIntentionsWebhook) InjectDecoder(d *admission.Decoder) error {
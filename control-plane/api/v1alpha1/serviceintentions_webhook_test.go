@@ -28,6 +28,7 @@ func TestHandle_ServiceIntentions_Create(t *testing.T) {
 		newResource       *ServiceIntentions
 		expAllow          bool
 		expErrMessage     string
+		expWarnings       []string
 		mirror            bool
 	}{
 		"no duplicates, valid": {
@@ -239,6 +240,88 @@ func TestHandle_ServiceIntentions_Create(t *testing.T) {
 			mirror:        false,
 			expErrMessage: "an existing ServiceIntentions resource has `spec.destination.name: foo`",
 		},
+		"jwt provider exists": {
+			existingResources: []runtime.Object{&JWTProvider{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "okta",
+				},
+			}},
+			newResource: &ServiceIntentions{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-intention",
+					Namespace: otherNS,
+				},
+				Spec: ServiceIntentionsSpec{
+					Destination: IntentionDestination{
+						Name: "foo",
+					},
+					Sources: SourceIntentions{
+						{
+							Name: "bar",
+							Permissions: IntentionPermissions{
+								{
+									Action: "allow",
+									HTTP: &IntentionHTTPPermission{
+										PathExact: "/api",
+									},
+									JWT: &IntentionJWTRequirement{
+										Providers: []*IntentionJWTProvider{{Name: "okta"}},
+									},
+								},
+							},
+						},
+					},
+					JWT: &IntentionJWTRequirement{
+						Providers: []*IntentionJWTProvider{{Name: "okta"}},
+					},
+				},
+			},
+			expAllow: true,
+			mirror:   false,
+		},
+		"jwt provider does not exist": {
+			existingResources: []runtime.Object{&JWTProvider{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "okta",
+				},
+			}},
+			newResource: &ServiceIntentions{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "foo-intention",
+					Namespace: otherNS,
+				},
+				Spec: ServiceIntentionsSpec{
+					Destination: IntentionDestination{
+						Name: "foo",
+					},
+					Sources: SourceIntentions{
+						{
+							Name: "bar",
+							Permissions: IntentionPermissions{
+								{
+									Action: "allow",
+									HTTP: &IntentionHTTPPermission{
+										PathExact: "/api",
+									},
+									JWT: &IntentionJWTRequirement{
+										Providers: []*IntentionJWTProvider{{Name: "auth0"}},
+									},
+								},
+							},
+						},
+					},
+					JWT: &IntentionJWTRequirement{
+						Providers: []*IntentionJWTProvider{{Name: "okta"}},
+					},
+				},
+			},
+			expAllow: true,
+			mirror:   false,
+			expWarnings: []string{
+				`spec.sources[0].permissions[0].jwt.providers[0].name: JWT provider "auth0" is not defined by a JWTProvider resource; ` +
+					`Consul rejects the intentions until the provider exists in Consul`,
+			},
+		},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -246,7 +329,7 @@ func TestHandle_ServiceIntentions_Create(t *testing.T) {
 			marshalledRequestObject, err := json.Marshal(c.newResource)
 			require.NoError(t, err)
 			s := runtime.NewScheme()
-			s.AddKnownTypes(GroupVersion, &ServiceIntentions{}, &ServiceIntentionsList{})
+			s.AddKnownTypes(GroupVersion, &ServiceIntentions{}, &ServiceIntentionsList{}, &JWTProvider{}, &JWTProviderList{})
 			client := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(c.existingResources...).Build()
 			decoder, err := admission.NewDecoder(s)
 			require.NoError(t, err)
@@ -275,6 +358,7 @@ func TestHandle_ServiceIntentions_Create(t *testing.T) {
 			if c.expErrMessage != "" {
 				require.Equal(t, c.expErrMessage, response.AdmissionResponse.Result.Message)
 			}
+			require.Equal(t, c.expWarnings, response.Warnings)
 		})
 	}
 }
@@ -276,15 +276,7 @@ func (in *ServiceResolver) ConsulName() string {
 }
 
 func (in *ServiceResolver) SetSyncedCondition(status corev1.ConditionStatus, reason string, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.setSyncedCondition(in.Generation, status, reason, message)
 }
 
 func (in *ServiceResolver) SetLastSyncedTime(time *metav1.Time) {
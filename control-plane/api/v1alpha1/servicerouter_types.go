@@ -200,15 +200,7 @@ func (in *ServiceRouter) KubernetesName() string {
 }
 
 func (in *ServiceRouter) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.setSyncedCondition(in.Generation, status, reason, message)
 }
 
 func (in *ServiceRouter) SetLastSyncedTime(time *metav1.Time) {
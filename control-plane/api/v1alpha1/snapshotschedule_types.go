@@ -0,0 +1,253 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+const SnapshotScheduleKubeKind = "snapshotschedules"
+
+// MinSnapshotInterval is the shortest allowed interval between snapshots.
+const MinSnapshotInterval = time.Minute
+
+func init() {
+	SchemeBuilder.Register(&SnapshotSchedule{}, &SnapshotScheduleList{})
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// SnapshotSchedule is the Schema for the snapshotschedules API. It configures
+// the snapshot agents running alongside the Consul servers.
+// +kubebuilder:printcolumn:name="Synced",type="string",JSONPath=".status.conditions[?(@.type==\"Synced\")].status",description="The sync status of the resource with the snapshot agent configuration"
+// +kubebuilder:printcolumn:name="Last Snapshot",type="date",JSONPath=".status.lastSuccessfulSnapshotTime",description="The time of the last successful snapshot"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="The age of the resource"
+// +kubebuilder:resource:shortName="snapshot-schedule"
+type SnapshotSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SnapshotScheduleSpec   `json:"spec,omitempty"`
+	Status SnapshotScheduleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SnapshotScheduleList contains a list of SnapshotSchedule.
+type SnapshotScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SnapshotSchedule `json:"items"`
+}
+
+// SnapshotScheduleSpec defines the desired state of SnapshotSchedule.
+type SnapshotScheduleSpec struct {
+	// Interval is how often a snapshot is taken, e.g. "1h".
+	Interval metav1.Duration `json:"interval"`
+	// Retain is the number of snapshots to keep in the destination. Older
+	// snapshots are deleted. All snapshots are kept if zero. Defaults to 30.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Retain *int `json:"retain,omitempty"`
+	// Destination is the object storage that snapshots are saved to.
+	Destination SnapshotDestination `json:"destination"`
+	// License references the Consul Enterprise license used by the snapshot
+	// agent. Only needed if the license isn't otherwise available to it.
+	// +optional
+	License *corev1.SecretKeySelector `json:"license,omitempty"`
+	// ACLToken references the ACL token used by the snapshot agent when the
+	// ACL system isn't managed by the Helm chart. The token needs
+	// "acl = write" permissions.
+	// +optional
+	ACLToken *corev1.SecretKeySelector `json:"aclToken,omitempty"`
+}
+
+// SnapshotDestination is the object storage that snapshots are saved to.
+// Exactly one of its fields must be set.
+type SnapshotDestination struct {
+	// S3 saves snapshots to an Amazon S3 or S3-compatible bucket.
+	// +optional
+	S3 *S3SnapshotDestination `json:"s3,omitempty"`
+	// GCS saves snapshots to a Google Cloud Storage bucket.
+	// +optional
+	GCS *GCSSnapshotDestination `json:"gcs,omitempty"`
+	// Azure saves snapshots to an Azure Blob Storage container.
+	// +optional
+	Azure *AzureSnapshotDestination `json:"azure,omitempty"`
+}
+
+type S3SnapshotDestination struct {
+	// Bucket is the name of the S3 bucket.
+	Bucket string `json:"bucket"`
+	// Region is the AWS region of the bucket.
+	Region string `json:"region"`
+	// KeyPrefix is the prefix of the object keys of the snapshots.
+	// +optional
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// Endpoint is the address of an S3-compatible storage.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// ForcePathStyle uses path-style instead of virtual-hosted-style bucket
+	// addresses, which some S3-compatible storages require.
+	// +optional
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+	// ServerSideEncryption enables AES-256 server-side encryption of the snapshots.
+	// +optional
+	ServerSideEncryption bool `json:"serverSideEncryption,omitempty"`
+	// AccessKeyID references the AWS access key ID. If unset, the snapshot
+	// agent uses the default AWS credential chain, e.g. IAM roles for
+	// service accounts.
+	// +optional
+	AccessKeyID *corev1.SecretKeySelector `json:"accessKeyID,omitempty"`
+	// SecretAccessKey references the AWS secret access key. It must be set
+	// if AccessKeyID is set.
+	// +optional
+	SecretAccessKey *corev1.SecretKeySelector `json:"secretAccessKey,omitempty"`
+}
+
+type GCSSnapshotDestination struct {
+	// Bucket is the name of the Google Cloud Storage bucket.
+	Bucket string `json:"bucket"`
+	// Credentials references a service account key in JSON format. If unset,
+	// the snapshot agent uses the application default credentials, e.g.
+	// workload identity.
+	// +optional
+	Credentials *corev1.SecretKeySelector `json:"credentials,omitempty"`
+}
+
+type AzureSnapshotDestination struct {
+	// AccountName is the name of the storage account.
+	AccountName string `json:"accountName"`
+	// AccountKey references the access key of the storage account.
+	AccountKey corev1.SecretKeySelector `json:"accountKey"`
+	// ContainerName is the name of the blob container.
+	ContainerName string `json:"containerName"`
+	// Environment is the Azure environment, e.g. "AzureUSGovernmentCloud".
+	// Defaults to the public cloud.
+	// +optional
+	Environment string `json:"environment,omitempty"`
+}
+
+// SnapshotScheduleStatus defines the observed state of SnapshotSchedule.
+type SnapshotScheduleStatus struct {
+	// Conditions indicate the latest available observations of a resource's current state.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions Conditions `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// LastSyncedTime is the last time the snapshot agent configuration was
+	// successfully written.
+	// +optional
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+	// LastSuccessfulSnapshotTime is the time of the last snapshot that a
+	// snapshot agent logged as saved. It is read from the logs of the snapshot
+	// agent containers, so it is best effort: snapshots whose log lines were
+	// rotated away before they were read are missed.
+	// +optional
+	LastSuccessfulSnapshotTime *metav1.Time `json:"lastSuccessfulSnapshotTime,omitempty"`
+	// ObservedGeneration is the generation of the resource that was last
+	// reconciled, whether or not it was synced successfully.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+func (s *SnapshotSchedule) KubeKind() string {
+	return SnapshotScheduleKubeKind
+}
+
+func (s *SnapshotSchedule) KubernetesName() string {
+	return s.ObjectMeta.Name
+}
+
+func (s *SnapshotSchedule) SetSyncedCondition(status corev1.ConditionStatus, reason string, message string) {
+	s.Status.Conditions = syncedConditions(s.Status.Conditions, status, reason, message)
+	s.Status.ObservedGeneration = s.Generation
+}
+
+func (s *SnapshotSchedule) Validate() error {
+	var errs field.ErrorList
+	path := field.NewPath("spec")
+
+	if s.Spec.Interval.Duration < MinSnapshotInterval {
+		errs = append(errs, field.Invalid(path.Child("interval"), s.Spec.Interval.Duration.String(),
+			fmt.Sprintf("interval must be at least %s", MinSnapshotInterval)))
+	}
+	if s.Spec.Retain != nil && *s.Spec.Retain < 0 {
+		errs = append(errs, field.Invalid(path.Child("retain"), *s.Spec.Retain, "retain must not be negative"))
+	}
+	errs = append(errs, s.Spec.Destination.validate(path.Child("destination"))...)
+	errs = append(errs, validateSecretKeySelector(path.Child("license"), s.Spec.License)...)
+	errs = append(errs, validateSecretKeySelector(path.Child("aclToken"), s.Spec.ACLToken)...)
+
+	if len(errs) > 0 {
+		return apierrors.NewInvalid(
+			schema.GroupKind{Group: ConsulHashicorpGroup, Kind: SnapshotScheduleKubeKind},
+			s.KubernetesName(), errs)
+	}
+	return nil
+}
+
+func (d SnapshotDestination) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	var set int
+	if d.S3 != nil {
+		set++
+		if d.S3.Bucket == "" {
+			errs = append(errs, field.Required(path.Child("s3", "bucket"), "bucket must be set"))
+		}
+		if d.S3.Region == "" {
+			errs = append(errs, field.Required(path.Child("s3", "region"), "region must be set"))
+		}
+		if (d.S3.AccessKeyID == nil) != (d.S3.SecretAccessKey == nil) {
+			errs = append(errs, field.Invalid(path.Child("s3"), "", "accessKeyID and secretAccessKey must both be set"))
+		}
+		errs = append(errs, validateSecretKeySelector(path.Child("s3", "accessKeyID"), d.S3.AccessKeyID)...)
+		errs = append(errs, validateSecretKeySelector(path.Child("s3", "secretAccessKey"), d.S3.SecretAccessKey)...)
+	}
+	if d.GCS != nil {
+		set++
+		if d.GCS.Bucket == "" {
+			errs = append(errs, field.Required(path.Child("gcs", "bucket"), "bucket must be set"))
+		}
+		errs = append(errs, validateSecretKeySelector(path.Child("gcs", "credentials"), d.GCS.Credentials)...)
+	}
+	if d.Azure != nil {
+		set++
+		if d.Azure.AccountName == "" {
+			errs = append(errs, field.Required(path.Child("azure", "accountName"), "accountName must be set"))
+		}
+		if d.Azure.ContainerName == "" {
+			errs = append(errs, field.Required(path.Child("azure", "containerName"), "containerName must be set"))
+		}
+		errs = append(errs, validateSecretKeySelector(path.Child("azure", "accountKey"), &d.Azure.AccountKey)...)
+	}
+	if set != 1 {
+		errs = append(errs, field.Invalid(path, set, "exactly one of s3, gcs or azure must be set"))
+	}
+	return errs
+}
+
+func validateSecretKeySelector(path *field.Path, ref *corev1.SecretKeySelector) field.ErrorList {
+	if ref == nil {
+		return nil
+	}
+	var errs field.ErrorList
+	if ref.Name == "" {
+		errs = append(errs, field.Required(path.Child("name"), "name must be set"))
+	}
+	if ref.Key == "" {
+		errs = append(errs, field.Required(path.Child("key"), "key must be set"))
+	}
+	return errs
+}
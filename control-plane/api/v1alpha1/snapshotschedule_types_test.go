@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSnapshotSchedule_Validate(t *testing.T) {
+	secretRef := func(name, key string) *corev1.SecretKeySelector {
+		return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+	}
+	negative := -1
+	cases := map[string]struct {
+		spec            SnapshotScheduleSpec
+		expectedErrMsgs []string
+	}{
+		"valid s3": {
+			spec: SnapshotScheduleSpec{
+				Interval: metav1.Duration{Duration: time.Hour},
+				Destination: SnapshotDestination{S3: &S3SnapshotDestination{
+					Bucket:          "snapshots",
+					Region:          "us-east-1",
+					AccessKeyID:     secretRef("aws", "id"),
+					SecretAccessKey: secretRef("aws", "secret"),
+				}},
+				ACLToken: secretRef("token", "token"),
+			},
+		},
+		"valid gcs": {
+			spec: SnapshotScheduleSpec{
+				Interval:    metav1.Duration{Duration: time.Hour},
+				Destination: SnapshotDestination{GCS: &GCSSnapshotDestination{Bucket: "snapshots"}},
+			},
+		},
+		"valid azure": {
+			spec: SnapshotScheduleSpec{
+				Interval: metav1.Duration{Duration: time.Hour},
+				Destination: SnapshotDestination{Azure: &AzureSnapshotDestination{
+					AccountName:   "account",
+					AccountKey:    *secretRef("azure", "key"),
+					ContainerName: "snapshots",
+				}},
+			},
+		},
+		"interval too short and negative retain": {
+			spec: SnapshotScheduleSpec{
+				Interval:    metav1.Duration{Duration: time.Second},
+				Retain:      &negative,
+				Destination: SnapshotDestination{GCS: &GCSSnapshotDestination{Bucket: "snapshots"}},
+			},
+			expectedErrMsgs: []string{
+				`spec.interval: Invalid value: "1s": interval must be at least 1m0s`,
+				`spec.retain: Invalid value: -1: retain must not be negative`,
+			},
+		},
+		"no destination": {
+			spec: SnapshotScheduleSpec{Interval: metav1.Duration{Duration: time.Hour}},
+			expectedErrMsgs: []string{
+				`spec.destination: Invalid value: 0: exactly one of s3, gcs or azure must be set`,
+			},
+		},
+		"multiple destinations": {
+			spec: SnapshotScheduleSpec{
+				Interval: metav1.Duration{Duration: time.Hour},
+				Destination: SnapshotDestination{
+					S3:  &S3SnapshotDestination{Bucket: "snapshots", Region: "us-east-1"},
+					GCS: &GCSSnapshotDestination{Bucket: "snapshots"},
+				},
+			},
+			expectedErrMsgs: []string{
+				`spec.destination: Invalid value: 2: exactly one of s3, gcs or azure must be set`,
+			},
+		},
+		"incomplete s3": {
+			spec: SnapshotScheduleSpec{
+				Interval: metav1.Duration{Duration: time.Hour},
+				Destination: SnapshotDestination{S3: &S3SnapshotDestination{
+					AccessKeyID: secretRef("aws", ""),
+				}},
+			},
+			expectedErrMsgs: []string{
+				`spec.destination.s3.bucket: Required value: bucket must be set`,
+				`spec.destination.s3.region: Required value: region must be set`,
+				`accessKeyID and secretAccessKey must both be set`,
+				`spec.destination.s3.accessKeyID.key: Required value: key must be set`,
+			},
+		},
+		"incomplete azure": {
+			spec: SnapshotScheduleSpec{
+				Interval:    metav1.Duration{Duration: time.Hour},
+				Destination: SnapshotDestination{Azure: &AzureSnapshotDestination{}},
+			},
+			expectedErrMsgs: []string{
+				`spec.destination.azure.accountName: Required value: accountName must be set`,
+				`spec.destination.azure.containerName: Required value: containerName must be set`,
+				`spec.destination.azure.accountKey.name: Required value: name must be set`,
+			},
+		},
+		"incomplete license": {
+			spec: SnapshotScheduleSpec{
+				Interval:    metav1.Duration{Duration: time.Hour},
+				Destination: SnapshotDestination{GCS: &GCSSnapshotDestination{Bucket: "snapshots"}},
+				License:     secretRef("", "license"),
+			},
+			expectedErrMsgs: []string{
+				`spec.license.name: Required value: name must be set`,
+			},
+		},
+	}
+
+	for name, testCase := range cases {
+		t.Run(name, func(t *testing.T) {
+			schedule := &SnapshotSchedule{
+				ObjectMeta: metav1.ObjectMeta{Name: "snapshots"},
+				Spec:       testCase.spec,
+			}
+			err := schedule.Validate()
+			if len(testCase.expectedErrMsgs) != 0 {
+				require.Error(t, err)
+				for _, s := range testCase.expectedErrMsgs {
+					require.Contains(t, err.Error(), s)
+				}
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -17,6 +17,23 @@ type ConditionType string
 const (
 	// ConditionSynced specifies that the resource has been synced with Consul.
 	ConditionSynced ConditionType = "Synced"
+
+	// ConditionValidated specifies that Consul has accepted the config entry
+	// of the resource. It is False if Consul rejected it as invalid.
+	ConditionValidated ConditionType = "Validated"
+
+	// ConditionApplied specifies that the current generation of the resource
+	// has been written to Consul.
+	ConditionApplied ConditionType = "Applied"
+
+	// ConditionInSync specifies that the config entry in Consul matches the
+	// current generation of the resource.
+	ConditionInSync ConditionType = "InSync"
+
+	// ConditionReady summarizes the state of the resource following the
+	// standard condition conventions so that tools like Argo CD and Flux can
+	// determine its health. It is True once the resource has been synced.
+	ConditionReady ConditionType = "Ready"
 )
 
 // Conditions define a readiness condition for a Consul resource.
@@ -81,6 +98,16 @@ type Status struct {
 	// LastSyncedTime is the last time the resource successfully synced with Consul.
 	// +optional
 	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty" description:"last time the condition transitioned from one status to another"`
+
+	// LastSyncedGeneration is the generation of the resource that was last
+	// successfully synced with Consul.
+	// +optional
+	LastSyncedGeneration int64 `json:"lastSyncedGeneration,omitempty" description:"generation of the resource that was last synced"`
+
+	// ObservedGeneration is the generation of the resource that was last
+	// reconciled, whether or not it was synced successfully.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" description:"generation of the resource that was last reconciled"`
 }
 
 func (s *Status) GetCondition(t ConditionType) *Condition {
@@ -91,3 +118,80 @@ func (s *Status) GetCondition(t ConditionType) *Condition {
 	}
 	return nil
 }
+
+// SetCondition sets the condition of the given type and keeps the other
+// conditions. The transition time is only updated if the status changes.
+func (s *Status) SetCondition(conditionType string, status corev1.ConditionStatus, reason, message string) {
+	s.Conditions = setCondition(s.Conditions, ConditionType(conditionType), status, reason, message)
+}
+
+// setSyncedCondition sets the Synced and Ready conditions and records the
+// generation of the resource that was reconciled.
+func (s *Status) setSyncedCondition(generation int64, status corev1.ConditionStatus, reason, message string) {
+	s.Conditions = syncedConditions(s.Conditions, status, reason, message)
+	s.ObservedGeneration = generation
+}
+
+// ConditionStatus returns the status of the condition of the given type or
+// Unknown if the condition isn't set.
+func (s *Status) ConditionStatus(conditionType string) corev1.ConditionStatus {
+	cond := s.GetCondition(ConditionType(conditionType))
+	if cond == nil {
+		return corev1.ConditionUnknown
+	}
+	return cond.Status
+}
+
+// SetLastSyncedGeneration updates the generation of the resource that was last
+// synced with Consul.
+func (s *Status) SetLastSyncedGeneration(generation int64) {
+	s.LastSyncedGeneration = generation
+}
+
+// GetLastSyncedGeneration returns the generation of the resource that was last
+// synced with Consul.
+func (s *Status) GetLastSyncedGeneration() int64 {
+	return s.LastSyncedGeneration
+}
+
+// setCondition returns conditions with the condition of type t set. The
+// transition time is only updated if the status changes.
+func setCondition(conditions Conditions, t ConditionType, status corev1.ConditionStatus, reason, message string) Conditions {
+	cond := Condition{
+		Type:               t,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+	for i, existing := range conditions {
+		if existing.Type != t {
+			continue
+		}
+		if existing.Status == status {
+			cond.LastTransitionTime = existing.LastTransitionTime
+		}
+		conditions[i] = cond
+		return conditions
+	}
+	return append(conditions, cond)
+}
+
+// syncedConditions returns conditions with the Synced condition and the Ready
+// condition that mirrors it set. Unlike Synced, Ready always has a reason as
+// required by the condition conventions.
+func syncedConditions(conditions Conditions, status corev1.ConditionStatus, reason, message string) Conditions {
+	conditions = setCondition(conditions, ConditionSynced, status, reason, message)
+	readyReason := reason
+	if readyReason == "" {
+		switch status {
+		case corev1.ConditionTrue:
+			readyReason = "Synced"
+		case corev1.ConditionFalse:
+			readyReason = "SyncFailed"
+		default:
+			readyReason = "Reconciling"
+		}
+	}
+	return setCondition(conditions, ConditionReady, status, readyReason, message)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStatus_SetCondition(t *testing.T) {
+	transitionTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	status := Status{
+		Conditions: Conditions{
+			{
+				Type:               ConditionSynced,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: transitionTime,
+			},
+			{
+				Type:               ConditionApplied,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: transitionTime,
+			},
+		},
+	}
+
+	// Setting a new condition keeps the existing conditions.
+	status.SetCondition(string(ConditionValidated), corev1.ConditionFalse, "reason", "message")
+	require.Len(t, status.Conditions, 3)
+	require.Equal(t, corev1.ConditionTrue, status.ConditionStatus(string(ConditionSynced)))
+	validated := status.GetCondition(ConditionValidated)
+	require.Equal(t, corev1.ConditionFalse, validated.Status)
+	require.Equal(t, "reason", validated.Reason)
+	require.Equal(t, "message", validated.Message)
+
+	// The transition time is kept if the status doesn't change.
+	status.SetCondition(string(ConditionSynced), corev1.ConditionTrue, "", "updated")
+	require.Len(t, status.Conditions, 3)
+	synced := status.GetCondition(ConditionSynced)
+	require.Equal(t, "updated", synced.Message)
+	require.True(t, synced.LastTransitionTime.Equal(&transitionTime))
+
+	// The transition time is updated if the status changes.
+	status.SetCondition(string(ConditionApplied), corev1.ConditionFalse, "", "")
+	require.Len(t, status.Conditions, 3)
+	applied := status.GetCondition(ConditionApplied)
+	require.Equal(t, corev1.ConditionFalse, applied.Status)
+	require.True(t, transitionTime.Before(&applied.LastTransitionTime))
+
+	require.Equal(t, corev1.ConditionUnknown, status.ConditionStatus(string(ConditionInSync)))
+}
+
+func TestStatus_setSyncedCondition(t *testing.T) {
+	cases := map[string]struct {
+		status         corev1.ConditionStatus
+		reason         string
+		expReadyReason string
+	}{
+		"synced": {
+			status:         corev1.ConditionTrue,
+			expReadyReason: "Synced",
+		},
+		"sync failed": {
+			status:         corev1.ConditionFalse,
+			reason:         "ConsulAgentError",
+			expReadyReason: "ConsulAgentError",
+		},
+		"sync failed without a reason": {
+			status:         corev1.ConditionFalse,
+			expReadyReason: "SyncFailed",
+		},
+		"unknown": {
+			status:         corev1.ConditionUnknown,
+			expReadyReason: "Reconciling",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			status := Status{}
+			status.setSyncedCondition(3, c.status, c.reason, "message")
+
+			require.Equal(t, int64(3), status.ObservedGeneration)
+			require.Equal(t, c.status, status.ConditionStatus(string(ConditionSynced)))
+			ready := status.GetCondition(ConditionReady)
+			require.Equal(t, c.status, ready.Status)
+			require.Equal(t, c.expReadyReason, ready.Reason)
+			require.Equal(t, "message", ready.Message)
+		})
+	}
+}
@@ -128,15 +128,7 @@ func (in *TerminatingGateway) KubernetesName() string {
 }
 
 func (in *TerminatingGateway) SetSyncedCondition(status corev1.ConditionStatus, reason, message string) {
-	in.Status.Conditions = Conditions{
-		{
-			Type:               ConditionSynced,
-			Status:             status,
-			LastTransitionTime: metav1.Now(),
-			Reason:             reason,
-			Message:            message,
-		},
-	}
+	in.Status.setSyncedCondition(in.Generation, status, reason, message)
 }
 
 func (in *TerminatingGateway) SetLastSyncedTime(time *metav1.Time) {
@@ -27,6 +27,22 @@ func (in *AccessLogs) DeepCopy() *AccessLogs {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureSnapshotDestination) DeepCopyInto(out *AzureSnapshotDestination) {
+	*out = *in
+	in.AccountKey.DeepCopyInto(&out.AccountKey)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureSnapshotDestination.
+func (in *AzureSnapshotDestination) DeepCopy() *AzureSnapshotDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureSnapshotDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -156,6 +172,11 @@ func (in *ControlPlaneRequestLimitSpec) DeepCopyInto(out *ControlPlaneRequestLim
 		*out = new(ReadWriteRatesConfig)
 		**out = **in
 	}
+	if in.ServerDiscovery != nil {
+		in, out := &in.ServerDiscovery, &out.ServerDiscovery
+		*out = new(ReadWriteRatesConfig)
+		**out = **in
+	}
 	if in.Health != nil {
 		in, out := &in.Health, &out.Health
 		*out = new(ReadWriteRatesConfig)
@@ -181,6 +202,11 @@ func (in *ControlPlaneRequestLimitSpec) DeepCopyInto(out *ControlPlaneRequestLim
 		*out = new(ReadWriteRatesConfig)
 		**out = **in
 	}
+	if in.DeprecatedPreparedQuery != nil {
+		in, out := &in.DeprecatedPreparedQuery, &out.DeprecatedPreparedQuery
+		*out = new(ReadWriteRatesConfig)
+		**out = **in
+	}
 	if in.Session != nil {
 		in, out := &in.Session, &out.Session
 		*out = new(ReadWriteRatesConfig)
@@ -191,6 +217,31 @@ func (in *ControlPlaneRequestLimitSpec) DeepCopyInto(out *ControlPlaneRequestLim
 		*out = new(ReadWriteRatesConfig)
 		**out = **in
 	}
+	if in.AutoConfig != nil {
+		in, out := &in.AutoConfig, &out.AutoConfig
+		*out = new(ReadWriteRatesConfig)
+		**out = **in
+	}
+	if in.FederationState != nil {
+		in, out := &in.FederationState, &out.FederationState
+		*out = new(ReadWriteRatesConfig)
+		**out = **in
+	}
+	if in.Internal != nil {
+		in, out := &in.Internal, &out.Internal
+		*out = new(ReadWriteRatesConfig)
+		**out = **in
+	}
+	if in.PeerStream != nil {
+		in, out := &in.PeerStream, &out.PeerStream
+		*out = new(ReadWriteRatesConfig)
+		**out = **in
+	}
+	if in.Peering != nil {
+		in, out := &in.Peering, &out.Peering
+		*out = new(ReadWriteRatesConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControlPlaneRequestLimitSpec.
@@ -313,6 +364,11 @@ func (in EnvoyExtensions) DeepCopy() EnvoyExtensions {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExportedService) DeepCopyInto(out *ExportedService) {
 	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Consumers != nil {
 		in, out := &in.Consumers, &out.Consumers
 		*out = make([]ServiceConsumer, len(*in))
@@ -330,6 +386,36 @@ func (in *ExportedService) DeepCopy() *ExportedService {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportedServiceSelector) DeepCopyInto(out *ExportedServiceSelector) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Consumers != nil {
+		in, out := &in.Consumers, &out.Consumers
+		*out = make([]ServiceConsumer, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportedServiceSelector.
+func (in *ExportedServiceSelector) DeepCopy() *ExportedServiceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportedServiceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExportedServices) DeepCopyInto(out *ExportedServices) {
 	*out = *in
@@ -337,6 +423,13 @@ func (in *ExportedServices) DeepCopyInto(out *ExportedServices) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
+	if in.selectedServices != nil {
+		in, out := &in.selectedServices, &out.selectedServices
+		*out = make([]ExportedService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportedServices.
@@ -399,6 +492,13 @@ func (in *ExportedServicesSpec) DeepCopyInto(out *ExportedServicesSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ServiceSelectors != nil {
+		in, out := &in.ServiceSelectors, &out.ServiceSelectors
+		*out = make([]ExportedServiceSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportedServicesSpec.
@@ -466,6 +566,26 @@ func (in *FailoverPolicy) DeepCopy() *FailoverPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GCSSnapshotDestination) DeepCopyInto(out *GCSSnapshotDestination) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GCSSnapshotDestination.
+func (in *GCSSnapshotDestination) DeepCopy() *GCSSnapshotDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(GCSSnapshotDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GatewayClassConfig) DeepCopyInto(out *GatewayClassConfig) {
 	*out = *in
@@ -546,6 +666,18 @@ func (in *GatewayClassConfigSpec) DeepCopyInto(out *GatewayClassConfigSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.DeploymentSpec.DeepCopyInto(&out.DeploymentSpec)
 	in.CopyAnnotations.DeepCopyInto(&out.CopyAnnotations)
 }
@@ -854,6 +986,96 @@ func (in *IngressServiceConfig) DeepCopy() *IngressServiceConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InjectionMetrics) DeepCopyInto(out *InjectionMetrics) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MergingEnabled != nil {
+		in, out := &in.MergingEnabled, &out.MergingEnabled
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InjectionMetrics.
+func (in *InjectionMetrics) DeepCopy() *InjectionMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(InjectionMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InjectionSidecarProxyLifecycle) DeepCopyInto(out *InjectionSidecarProxyLifecycle) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ShutdownDrainListeners != nil {
+		in, out := &in.ShutdownDrainListeners, &out.ShutdownDrainListeners
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ShutdownGracePeriodSeconds != nil {
+		in, out := &in.ShutdownGracePeriodSeconds, &out.ShutdownGracePeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.GracefulPort != nil {
+		in, out := &in.GracefulPort, &out.GracefulPort
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InjectionSidecarProxyLifecycle.
+func (in *InjectionSidecarProxyLifecycle) DeepCopy() *InjectionSidecarProxyLifecycle {
+	if in == nil {
+		return nil
+	}
+	out := new(InjectionSidecarProxyLifecycle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InjectionTransparentProxy) DeepCopyInto(out *InjectionTransparentProxy) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.OverwriteProbes != nil {
+		in, out := &in.OverwriteProbes, &out.OverwriteProbes
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ExcludeOutboundCIDRs != nil {
+		in, out := &in.ExcludeOutboundCIDRs, &out.ExcludeOutboundCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InjectionTransparentProxy.
+func (in *InjectionTransparentProxy) DeepCopy() *InjectionTransparentProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(InjectionTransparentProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IntentionDestination) DeepCopyInto(out *IntentionDestination) {
 	*out = *in
@@ -1382,6 +1604,99 @@ func (in *MeshHTTPConfig) DeepCopy() *MeshHTTPConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MeshInjectionConfig) DeepCopyInto(out *MeshInjectionConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeshInjectionConfig.
+func (in *MeshInjectionConfig) DeepCopy() *MeshInjectionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MeshInjectionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MeshInjectionConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MeshInjectionConfigList) DeepCopyInto(out *MeshInjectionConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MeshInjectionConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeshInjectionConfigList.
+func (in *MeshInjectionConfigList) DeepCopy() *MeshInjectionConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(MeshInjectionConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MeshInjectionConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MeshInjectionConfigSpec) DeepCopyInto(out *MeshInjectionConfigSpec) {
+	*out = *in
+	if in.TransparentProxy != nil {
+		in, out := &in.TransparentProxy, &out.TransparentProxy
+		*out = new(InjectionTransparentProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upstreams != nil {
+		in, out := &in.Upstreams, &out.Upstreams
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(InjectionMetrics)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SidecarProxyLifecycle != nil {
+		in, out := &in.SidecarProxyLifecycle, &out.SidecarProxyLifecycle
+		*out = new(InjectionSidecarProxyLifecycle)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MeshInjectionConfigSpec.
+func (in *MeshInjectionConfigSpec) DeepCopy() *MeshInjectionConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MeshInjectionConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MeshList) DeepCopyInto(out *MeshList) {
 	*out = *in
@@ -1666,6 +1981,11 @@ func (in *PeeringAcceptorSpec) DeepCopyInto(out *PeeringAcceptorSpec) {
 		*out = new(Peer)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TokenRotation != nil {
+		in, out := &in.TokenRotation, &out.TokenRotation
+		*out = new(TokenRotation)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringAcceptorSpec.
@@ -1702,6 +2022,10 @@ func (in *PeeringAcceptorStatus) DeepCopyInto(out *PeeringAcceptorStatus) {
 		in, out := &in.LastSyncedTime, &out.LastSyncedTime
 		*out = (*in).DeepCopy()
 	}
+	if in.LastTokenGenerationTime != nil {
+		in, out := &in.LastTokenGenerationTime, &out.LastTokenGenerationTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringAcceptorStatus.
@@ -1952,6 +2276,138 @@ func (in *ProxyDefaultsSpec) DeepCopy() *ProxyDefaultsSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Registration) DeepCopyInto(out *Registration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Registration.
+func (in *Registration) DeepCopy() *Registration {
+	if in == nil {
+		return nil
+	}
+	out := new(Registration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Registration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistrationHealthCheck) DeepCopyInto(out *RegistrationHealthCheck) {
+	*out = *in
+	out.Interval = in.Interval
+	out.Timeout = in.Timeout
+	out.DeregisterCriticalServiceAfter = in.DeregisterCriticalServiceAfter
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistrationHealthCheck.
+func (in *RegistrationHealthCheck) DeepCopy() *RegistrationHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistrationHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistrationList) DeepCopyInto(out *RegistrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Registration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistrationList.
+func (in *RegistrationList) DeepCopy() *RegistrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RegistrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistrationService) DeepCopyInto(out *RegistrationService) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Meta != nil {
+		in, out := &in.Meta, &out.Meta
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistrationService.
+func (in *RegistrationService) DeepCopy() *RegistrationService {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistrationService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistrationSpec) DeepCopyInto(out *RegistrationSpec) {
+	*out = *in
+	if in.NodeMeta != nil {
+		in, out := &in.NodeMeta, &out.NodeMeta
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Service.DeepCopyInto(&out.Service)
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(RegistrationHealthCheck)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistrationSpec.
+func (in *RegistrationSpec) DeepCopy() *RegistrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RemoteJWKS) DeepCopyInto(out *RemoteJWKS) {
 	*out = *in
@@ -2016,6 +2472,31 @@ func (in *RingHashConfig) DeepCopy() *RingHashConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3SnapshotDestination) DeepCopyInto(out *S3SnapshotDestination) {
+	*out = *in
+	if in.AccessKeyID != nil {
+		in, out := &in.AccessKeyID, &out.AccessKeyID
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretAccessKey != nil {
+		in, out := &in.SecretAccessKey, &out.SecretAccessKey
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3SnapshotDestination.
+func (in *S3SnapshotDestination) DeepCopy() *S3SnapshotDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(S3SnapshotDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SamenessGroup) DeepCopyInto(out *SamenessGroup) {
 	*out = *in
@@ -2945,6 +3426,157 @@ func (in *ServiceSplitterSpec) DeepCopy() *ServiceSplitterSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotDestination) DeepCopyInto(out *SnapshotDestination) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3SnapshotDestination)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCS != nil {
+		in, out := &in.GCS, &out.GCS
+		*out = new(GCSSnapshotDestination)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(AzureSnapshotDestination)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotDestination.
+func (in *SnapshotDestination) DeepCopy() *SnapshotDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotSchedule) DeepCopyInto(out *SnapshotSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotSchedule.
+func (in *SnapshotSchedule) DeepCopy() *SnapshotSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SnapshotSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotScheduleList) DeepCopyInto(out *SnapshotScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SnapshotSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotScheduleList.
+func (in *SnapshotScheduleList) DeepCopy() *SnapshotScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SnapshotScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotScheduleSpec) DeepCopyInto(out *SnapshotScheduleSpec) {
+	*out = *in
+	out.Interval = in.Interval
+	if in.Retain != nil {
+		in, out := &in.Retain, &out.Retain
+		*out = new(int)
+		**out = **in
+	}
+	in.Destination.DeepCopyInto(&out.Destination)
+	if in.License != nil {
+		in, out := &in.License, &out.License
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ACLToken != nil {
+		in, out := &in.ACLToken, &out.ACLToken
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotScheduleSpec.
+func (in *SnapshotScheduleSpec) DeepCopy() *SnapshotScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotScheduleStatus) DeepCopyInto(out *SnapshotScheduleStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastSyncedTime != nil {
+		in, out := &in.LastSyncedTime, &out.LastSyncedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulSnapshotTime != nil {
+		in, out := &in.LastSuccessfulSnapshotTime, &out.LastSuccessfulSnapshotTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotScheduleStatus.
+func (in *SnapshotScheduleStatus) DeepCopy() *SnapshotScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SourceIntention) DeepCopyInto(out *SourceIntention) {
 	*out = *in
@@ -3101,6 +3733,22 @@ func (in *TerminatingGatewaySpec) DeepCopy() *TerminatingGatewaySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenRotation) DeepCopyInto(out *TokenRotation) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenRotation.
+func (in *TokenRotation) DeepCopy() *TokenRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransparentProxy) DeepCopyInto(out *TransparentProxy) {
 	*out = *in
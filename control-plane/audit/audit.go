@@ -0,0 +1,166 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package audit records the writes that the controllers make to Consul, so
+// that changes to Consul made by consul-k8s can be audited.
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-logr/logr"
+	"gomodules.xyz/jsonpatch/v2"
+)
+
+// Operation is the type of write made to Consul.
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationUpdate Operation = "update"
+	OperationDelete Operation = "delete"
+	// OperationUpsert is a write that creates or replaces the object, without
+	// reading its previous value, e.g. a catalog registration.
+	OperationUpsert Operation = "upsert"
+)
+
+// Resources are the types of Consul objects that are written.
+const (
+	ResourceConfigEntry    = "config-entry"
+	ResourceCatalogService = "catalog-service"
+	ResourceCatalogNode    = "catalog-node"
+	ResourceNamespace      = "namespace"
+	ResourceACLToken       = "acl-token"
+	ResourceACLPolicy      = "acl-policy"
+	ResourceACLRole        = "acl-role"
+	ResourceACLBindingRule = "acl-binding-rule"
+	ResourcePeering        = "peering"
+	ResourceConnectCA      = "connect-ca-config"
+)
+
+// Event is a write made to Consul.
+type Event struct {
+	// Time is when the write was made. It is set by Record.
+	Time time.Time `json:"time"`
+	// Actor is the controller that made the write, e.g. "controller/endpoints".
+	Actor string `json:"actor"`
+	// Operation is the type of write.
+	Operation Operation `json:"operation"`
+	// Resource is the type of Consul object that was written, e.g. "config-entry".
+	Resource string `json:"resource"`
+	// Name identifies the Consul object within its resource type, e.g.
+	// "service-defaults/web" for a config entry.
+	Name string `json:"name"`
+	// Namespace and Partition are the Consul namespace and partition of the object.
+	Namespace string `json:"namespace,omitempty"`
+	Partition string `json:"partition,omitempty"`
+	// Source is the Kubernetes object that the write was made for.
+	Source *Source `json:"source,omitempty"`
+	// Diff is the JSON patch from the previous to the new value of the object.
+	// It is computed by Record from Before and After.
+	Diff []jsonpatch.Operation `json:"diff,omitempty"`
+	// Error is set if the write failed. It is set by Record.
+	Error string `json:"error,omitempty"`
+
+	// Before and After are the previous and new values of the object. Either
+	// may be nil, e.g. Before for a create or After for a delete.
+	Before interface{} `json:"-"`
+	After  interface{} `json:"-"`
+}
+
+// Source is a Kubernetes object.
+type Source struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// Sink stores the audit events.
+type Sink interface {
+	Write(event Event) error
+}
+
+// Auditor records the writes made to Consul in its sinks. A nil Auditor
+// records nothing so that auditing can be disabled by leaving it unset.
+type Auditor struct {
+	log   logr.Logger
+	sinks []Sink
+}
+
+// New returns an Auditor that records events in the sinks. Failures to write
+// to a sink are logged with log.
+func New(log logr.Logger, sinks ...Sink) *Auditor {
+	return &Auditor{log: log, sinks: sinks}
+}
+
+// Record records the write described by event, which failed if err is not nil.
+func (a *Auditor) Record(event Event, err error) {
+	if a == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+	if err != nil {
+		event.Error = err.Error()
+	}
+	diff, diffErr := Diff(event.Before, event.After)
+	if diffErr != nil {
+		a.log.Error(diffErr, "unable to compute the diff of audit event", "resource", event.Resource, "name", event.Name)
+	}
+	event.Diff = diff
+
+	for _, sink := range a.sinks {
+		if err := sink.Write(event); err != nil {
+			a.log.Error(err, "unable to write audit event", "resource", event.Resource, "name", event.Name)
+		}
+	}
+}
+
+// indexPaths are the paths of the Raft indexes that Consul sets on its
+// objects. They are left out of diffs since they change on every write.
+var indexPaths = map[string]bool{
+	"/CreateIndex": true,
+	"/ModifyIndex": true,
+}
+
+// Diff returns the JSON patch that changes before into after, without the
+// Raft indexes of the objects. A nil value, including a nil pointer, is
+// treated as an empty object.
+func Diff(before, after interface{}) ([]jsonpatch.Operation, error) {
+	if before == nil && after == nil {
+		return nil, nil
+	}
+	beforeJSON, err := marshalObject(before)
+	if err != nil {
+		return nil, err
+	}
+	afterJSON, err := marshalObject(after)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := jsonpatch.CreatePatch(beforeJSON, afterJSON)
+	if err != nil {
+		return nil, err
+	}
+	var diff []jsonpatch.Operation
+	for _, op := range patch {
+		if !indexPaths[op.Path] {
+			diff = append(diff, op)
+		}
+	}
+	return diff, nil
+}
+
+func marshalObject(v interface{}) ([]byte, error) {
+	if v == nil {
+		return []byte("{}"), nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return []byte("{}"), nil
+	}
+	return data, nil
+}
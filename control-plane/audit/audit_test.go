@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package audit
+
+import (
+	"errors"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	"gomodules.xyz/jsonpatch/v2"
+)
+
+type fakeSink struct {
+	events []Event
+}
+
+func (s *fakeSink) Write(event Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestAuditor_Record(t *testing.T) {
+	t.Parallel()
+	sink := &fakeSink{}
+	auditor := New(logrtest.New(t), sink)
+
+	before := &capi.ServiceConfigEntry{Kind: capi.ServiceDefaults, Name: "web", Protocol: "tcp", CreateIndex: 1, ModifyIndex: 2}
+	after := &capi.ServiceConfigEntry{Kind: capi.ServiceDefaults, Name: "web", Protocol: "http"}
+	auditor.Record(Event{
+		Actor:     "controller/servicedefaults",
+		Operation: OperationUpdate,
+		Resource:  ResourceConfigEntry,
+		Name:      "service-defaults/web",
+		Source:    &Source{Kind: "servicedefaults", Namespace: "default", Name: "web"},
+		Before:    before,
+		After:     after,
+	}, errors.New("permission denied"))
+
+	require.Len(t, sink.events, 1)
+	event := sink.events[0]
+	require.False(t, event.Time.IsZero())
+	require.Equal(t, "permission denied", event.Error)
+	require.Equal(t, []jsonpatch.Operation{
+		{Operation: "replace", Path: "/Protocol", Value: "http"},
+	}, event.Diff)
+}
+
+func TestAuditor_RecordNil(t *testing.T) {
+	t.Parallel()
+	var auditor *Auditor
+	require.NotPanics(t, func() {
+		auditor.Record(Event{Operation: OperationDelete}, nil)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	var nilPolicy *capi.ACLPolicy
+	cases := map[string]struct {
+		before, after interface{}
+		expDiff       []jsonpatch.Operation
+	}{
+		"no values": {},
+		"create": {
+			after: map[string]interface{}{"Name": "web"},
+			expDiff: []jsonpatch.Operation{
+				{Operation: "add", Path: "/Name", Value: "web"},
+			},
+		},
+		"delete from a nil pointer": {
+			before: map[string]interface{}{"Name": "web"},
+			after:  nilPolicy,
+			expDiff: []jsonpatch.Operation{
+				{Operation: "remove", Path: "/Name"},
+			},
+		},
+		"unchanged": {
+			before: map[string]interface{}{"Name": "web", "ModifyIndex": 3},
+			after:  map[string]interface{}{"Name": "web"},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			diff, err := Diff(c.before, c.after)
+			require.NoError(t, err)
+			require.Equal(t, c.expDiff, diff)
+		})
+	}
+}
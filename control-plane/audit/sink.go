@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// LogSink writes the audit events as structured log lines.
+type LogSink struct {
+	Log logr.Logger
+}
+
+func (s *LogSink) Write(event Event) error {
+	diff, err := json.Marshal(event.Diff)
+	if err != nil {
+		return err
+	}
+	keysAndValues := []interface{}{
+		"actor", event.Actor,
+		"operation", event.Operation,
+		"resource", event.Resource,
+		"name", event.Name,
+		"diff", string(diff),
+	}
+	if event.Namespace != "" {
+		keysAndValues = append(keysAndValues, "namespace", event.Namespace)
+	}
+	if event.Partition != "" {
+		keysAndValues = append(keysAndValues, "partition", event.Partition)
+	}
+	if event.Source != nil {
+		keysAndValues = append(keysAndValues,
+			"source-kind", event.Source.Kind,
+			"source-namespace", event.Source.Namespace,
+			"source-name", event.Source.Name)
+	}
+	if event.Error != "" {
+		keysAndValues = append(keysAndValues, "error", event.Error)
+	}
+	s.Log.Info("consul write", keysAndValues...)
+	return nil
+}
+
+const (
+	// webhookQueueSize is the number of events that are buffered while they
+	// are sent to the webhook. Events are dropped while the buffer is full so
+	// that a slow webhook never blocks the controllers.
+	webhookQueueSize = 1000
+
+	webhookTimeout = 10 * time.Second
+)
+
+// WebhookSink sends each audit event as a JSON object in the body of a POST
+// request to a URL. Events are sent in the background by Start, in the order
+// they are written.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+	Log    logr.Logger
+
+	queue chan Event
+}
+
+// NewWebhookSink returns a WebhookSink that sends the events to url.
+func NewWebhookSink(url string, log logr.Logger) *WebhookSink {
+	return &WebhookSink{
+		URL:    url,
+		Client: &http.Client{Timeout: webhookTimeout},
+		Log:    log,
+		queue:  make(chan Event, webhookQueueSize),
+	}
+}
+
+func (s *WebhookSink) Write(event Event) error {
+	select {
+	case s.queue <- event:
+		return nil
+	default:
+		return errors.New("audit webhook queue is full, dropping event")
+	}
+}
+
+// Start sends the queued events until ctx is cancelled. It implements
+// controller-runtime's manager.Runnable.
+func (s *WebhookSink) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-s.queue:
+			if err := s.send(ctx, event); err != nil {
+				s.Log.Error(err, "unable to send audit event to webhook", "resource", event.Resource, "name", event.Name)
+			}
+		}
+	}
+}
+
+func (s *WebhookSink) send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response code: %d", resp.StatusCode)
+	}
+	return nil
+}
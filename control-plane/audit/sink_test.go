@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSink(t *testing.T) {
+	t.Parallel()
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var event Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	t.Cleanup(server.Close)
+
+	sink := NewWebhookSink(server.URL, logrtest.New(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go sink.Start(ctx)
+
+	require.NoError(t, sink.Write(Event{
+		Actor:     "controller/endpoints",
+		Operation: OperationDelete,
+		Resource:  ResourceACLToken,
+		Name:      "accessor-id",
+		Source:    &Source{Kind: "Service", Namespace: "default", Name: "web"},
+	}))
+
+	select {
+	case event := <-received:
+		require.Equal(t, "controller/endpoints", event.Actor)
+		require.Equal(t, OperationDelete, event.Operation)
+		require.Equal(t, ResourceACLToken, event.Resource)
+		require.Equal(t, "accessor-id", event.Name)
+		require.Equal(t, &Source{Kind: "Service", Namespace: "default", Name: "web"}, event.Source)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the audit event")
+	}
+}
+
+func TestWebhookSink_QueueFull(t *testing.T) {
+	t.Parallel()
+	sink := NewWebhookSink("http://127.0.0.1", logrtest.New(t))
+	// The queue isn't drained since the sink isn't started.
+	for i := 0; i < webhookQueueSize; i++ {
+		require.NoError(t, sink.Write(Event{}))
+	}
+	require.EqualError(t, sink.Write(Event{}), "audit webhook queue is full, dropping event")
+}
@@ -32,4 +32,15 @@ const (
 	// e.g. Service `backend` in k8s cluster `A` receives 25% of the traffic
 	// compared to same `backend` service in k8s cluster `B`.
 	annotationServiceWeight = "consul.hashicorp.com/service-weight"
+
+	// annotationServiceWeightWarning is the key of the annotation that determines
+	// the traffic weight of the service instances while their health checks
+	// are warning. Defaults to 1.
+	annotationServiceWeightWarning = "consul.hashicorp.com/service-weight-warning"
+
+	// annotationServiceTaggedAddressPrefix is the prefix for setting tagged
+	// addresses of the service instances, e.g. a public load balancer address.
+	// The remainder of the key is the tag and the value is an address with an
+	// optional port. Without a port, the instance port is used.
+	annotationServiceTaggedAddressPrefix = "consul.hashicorp.com/service-tagged-address-"
 )
@@ -0,0 +1,40 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	mapset "github.com/deckarep/golang-set"
+	"github.com/hashicorp/consul/api"
+)
+
+// MultiSyncer is a Syncer that syncs the registrations to every Syncer in
+// the list, e.g. to publish the services to several Consul datacenters.
+type MultiSyncer []Syncer
+
+// Sync implements Syncer.
+func (m MultiSyncer) Sync(rs []*api.CatalogRegistration) {
+	for _, s := range m {
+		s.Sync(rs)
+	}
+}
+
+// K8SNamespaceFilterSyncer is a Syncer that only syncs the registrations of
+// services in the given k8s namespaces to the wrapped Syncer.
+type K8SNamespaceFilterSyncer struct {
+	Syncer Syncer
+
+	// K8SNamespaces is the set of k8s namespaces whose services are synced.
+	K8SNamespaces mapset.Set
+}
+
+// Sync implements Syncer.
+func (f *K8SNamespaceFilterSyncer) Sync(rs []*api.CatalogRegistration) {
+	filtered := make([]*api.CatalogRegistration, 0, len(rs))
+	for _, r := range rs {
+		if r.Service != nil && f.K8SNamespaces.Contains(r.Service.Meta[ConsulK8SNS]) {
+			filtered = append(filtered, r)
+		}
+	}
+	f.Syncer.Sync(filtered)
+}
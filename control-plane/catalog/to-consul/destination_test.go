@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiSyncer_filtered(t *testing.T) {
+	t.Parallel()
+	all := newTestSyncer()
+	filtered := newTestSyncer()
+	syncer := MultiSyncer{
+		all,
+		&K8SNamespaceFilterSyncer{
+			Syncer:        filtered,
+			K8SNamespaces: mapset.NewSet("foo"),
+		},
+	}
+
+	registration := func(name, k8sNS string) *api.CatalogRegistration {
+		return &api.CatalogRegistration{
+			Service: &api.AgentService{
+				Service: name,
+				Meta:    map[string]string{ConsulK8SNS: k8sNS},
+			},
+		}
+	}
+	rs := []*api.CatalogRegistration{
+		registration("a", "foo"),
+		registration("b", "bar"),
+		registration("c", "foo"),
+	}
+	syncer.Sync(rs)
+
+	require.Equal(t, rs, all.Registrations)
+	require.Equal(t, []*api.CatalogRegistration{rs[0], rs[2]}, filtered.Registrations)
+}
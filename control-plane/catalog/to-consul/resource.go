@@ -6,6 +6,7 @@ package catalog
 import (
 	"context"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,9 +17,11 @@ import (
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 	consulapi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-multierror"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
@@ -38,11 +41,28 @@ const (
 	ConsulK8SRefValue = "external-k8s-ref-name"
 	ConsulK8SNodeName = "external-k8s-node-name"
 
+	// ConsulK8SPodHostname and ConsulK8SPodOrdinal are the keys used in the
+	// meta to record the stable hostname and the StatefulSet ordinal of the
+	// pods backing instances of headless services.
+	ConsulK8SPodHostname = "external-k8s-pod-hostname"
+	ConsulK8SPodOrdinal  = "external-k8s-pod-ordinal"
+
+	// ConsulK8SNodeZone, ConsulK8SNodeRegion and ConsulK8SNodeInstanceType
+	// are the keys used in the service and node meta to record the topology
+	// of the k8s node running an instance. ConsulK8SSyncNode is the key used
+	// in the meta of the Consul node registered for a k8s node to record the
+	// name of the sync node.
+	ConsulK8SNodeZone         = "external-k8s-node-zone"
+	ConsulK8SNodeRegion       = "external-k8s-node-region"
+	ConsulK8SNodeInstanceType = "external-k8s-node-instance-type"
+	ConsulK8SSyncNode         = "external-k8s-sync-node"
+
 	// consulKubernetesCheckType is the type of health check in Consul for Kubernetes readiness status.
 	consulKubernetesCheckType = "kubernetes-readiness"
 	// consulKubernetesCheckName is the name of health check in Consul for Kubernetes readiness status.
 	consulKubernetesCheckName  = "Kubernetes Readiness Check"
 	kubernetesSuccessReasonMsg = "Kubernetes health checks passing"
+	kubernetesFailureReasonMsg = "Kubernetes health checks failing"
 )
 
 type NodePortSyncType string
@@ -93,10 +113,21 @@ type ServiceResource struct {
 	// enabled (aka default enabled).
 	ExplicitEnable bool
 
+	// ServiceSelector, if set, selects the services that are synced when they
+	// don't have the service-sync annotation, replacing the default set by
+	// ExplicitEnable. The annotation still takes precedence.
+	ServiceSelector labels.Selector
+
 	// ClusterIPSync set to true (the default) syncs ClusterIP-type services.
 	// Setting this to false will ignore ClusterIP services during the sync.
 	ClusterIPSync bool
 
+	// SyncNotReadyEndpoints set to true (default false) registers the not ready
+	// addresses of a service's endpoints with a critical health check instead of
+	// skipping them. Instances then stay in the Consul catalog while their pods
+	// are unready, but aren't returned by DNS or prepared queries.
+	SyncNotReadyEndpoints bool
+
 	// LoadBalancerEndpointsSync set to true (default false) will sync ServiceTypeLoadBalancer endpoints.
 	LoadBalancerEndpointsSync bool
 
@@ -133,9 +164,24 @@ type ServiceResource struct {
 	// `k8s-default` namespace.
 	K8SNSMirroringPrefix string
 
+	// K8SNSMapping maps k8s namespaces to the Consul namespaces their services
+	// are registered into. It takes precedence over mirroring and
+	// ConsulDestinationNamespace for the k8s namespaces it contains.
+	K8SNSMapping map[string]string
+
+	// ConsulPartition is the Consul admin partition that services are
+	// registered into. It's empty if admin partitions aren't enabled.
+	ConsulPartition string
+
 	// The Consul node name to register service with.
 	ConsulNodeName string
 
+	// SyncNodeTopology set to true (default false) registers instances backed
+	// by pods on a Consul node per k8s node, named <ConsulNodeName>-<k8s node
+	// name>, and records the zone, region and instance type of the k8s node in
+	// the service and node meta. This enables locality-aware prepared queries.
+	SyncNodeTopology bool
+
 	// serviceLock must be held for any read/write to these maps.
 	serviceLock sync.RWMutex
 
@@ -147,6 +193,9 @@ type ServiceResource struct {
 	// of each service.
 	endpointsMap map[string]*corev1.Endpoints
 
+	// nodeMap holds the k8s nodes by name when SyncNodeTopology is true.
+	nodeMap map[string]*corev1.Node
+
 	// EnableIngress enables syncing of the hostname from an Ingress resource
 	// to the service registration if an Ingress rule matches the service.
 	EnableIngress bool
@@ -277,6 +326,19 @@ func (t *ServiceResource) doDelete(key string) {
 
 // Run implements the controller.Backgrounder interface.
 func (t *ServiceResource) Run(ch <-chan struct{}) {
+	if t.SyncNodeTopology {
+		t.Log.Info("starting runner for nodes")
+		nodesDoneCh := make(chan struct{})
+		go func() {
+			defer close(nodesDoneCh)
+			(&controller.Controller{
+				Resource: &serviceNodeResource{Service: t, Ctx: t.Ctx},
+				Log:      t.Log.Named("controller/node"),
+			}).Run(ch)
+		}()
+		defer func() { <-nodesDoneCh }()
+	}
+
 	t.Log.Info("starting runner for endpoints")
 	// Register a controller for Endpoints which subsequently registers a
 	// controller for the Ingress resource.
@@ -320,7 +382,7 @@ func (t *ServiceResource) shouldSync(svc *corev1.Service) bool {
 	raw, ok := svc.Annotations[annotationServiceSync]
 	if !ok {
 		// If there is no explicit value, then set it to our current default.
-		return !t.ExplicitEnable
+		return t.syncByDefault(svc)
 	}
 
 	v, err := strconv.ParseBool(raw)
@@ -330,12 +392,21 @@ func (t *ServiceResource) shouldSync(svc *corev1.Service) bool {
 			"err", err)
 
 		// Fallback to default
-		return !t.ExplicitEnable
+		return t.syncByDefault(svc)
 	}
 
 	return v
 }
 
+// syncByDefault returns true if the service should be synced when it isn't
+// annotated with an explicit value.
+func (t *ServiceResource) syncByDefault(svc *corev1.Service) bool {
+	if t.ServiceSelector != nil {
+		return t.ServiceSelector.Matches(labels.Set(svc.Labels))
+	}
+	return !t.ExplicitEnable
+}
+
 // shouldTrackEndpoints returns true if the endpoints for the given key
 // should be tracked.
 //
@@ -386,6 +457,7 @@ func (t *ServiceResource) generateRegistrations(key string) {
 	// shallow copied for each instance.
 	baseNode := consulapi.CatalogRegistration{
 		SkipNodeUpdate: true,
+		Partition:      t.ConsulPartition,
 		Node:           t.ConsulNodeName,
 		Address:        "127.0.0.1",
 		NodeMeta: map[string]string{
@@ -400,6 +472,7 @@ func (t *ServiceResource) generateRegistrations(key string) {
 			ConsulSourceKey: ConsulSourceValue,
 			ConsulK8SNS:     svc.Namespace,
 		},
+		Partition: t.ConsulPartition,
 	}
 
 	// If the name is explicitly annotated, adopt that name
@@ -408,11 +481,7 @@ func (t *ServiceResource) generateRegistrations(key string) {
 	}
 
 	// Update the Consul namespace based on namespace settings
-	consulNS := namespaces.ConsulNamespace(svc.Namespace,
-		t.EnableNamespaces,
-		t.ConsulDestinationNamespace,
-		t.EnableK8SNSMirroring,
-		t.K8SNSMirroringPrefix)
+	consulNS := t.consulNamespace(svc.Namespace)
 	if consulNS != "" {
 		t.Log.Debug("[generateRegistrations] namespace being used", "key", key, "namespace", consulNS)
 		baseService.Namespace = consulNS
@@ -493,6 +562,19 @@ func (t *ServiceResource) generateRegistrations(key string) {
 		}
 	}
 
+	// Adding information about service weights.
+	if weights, ok, err := getServiceWeights(svc.Annotations); err != nil {
+		t.Log.Debug("[generateRegistrations] service weight err: ", err)
+	} else if ok {
+		baseService.Weights = weights
+	}
+
+	// Parse any tagged addresses. Invalid annotations are skipped.
+	taggedAddresses, err := getTaggedAddresses(svc.Annotations)
+	if err != nil {
+		t.Log.Debug("[generateRegistrations] tagged address err: ", err)
+	}
+
 	// Always log what we generated
 	defer func() {
 		t.Log.Debug("generated registration",
@@ -502,6 +584,11 @@ func (t *ServiceResource) generateRegistrations(key string) {
 			"instances", len(t.consulMap[key]))
 	}()
 
+	// Tagged addresses are added to each instance once all instances are
+	// generated since an address without a port uses the instance port.
+	// This is deferred after the log above so that it runs first.
+	defer t.setTaggedAddresses(key, taggedAddresses)
+
 	// If there are external IPs then those become the instance registrations
 	// for any type of service.
 	if ips := svc.Spec.ExternalIPs; len(ips) > 0 {
@@ -511,18 +598,6 @@ func (t *ServiceResource) generateRegistrations(key string) {
 			r.Service = &rs
 			r.Service.ID = serviceID(r.Service.Service, ip)
 			r.Service.Address = ip
-			// Adding information about service weight.
-			// Overrides the existing weight if present.
-			if weight, ok := svc.Annotations[annotationServiceWeight]; ok && weight != "" {
-				weightI, err := getServiceWeight(weight)
-				if err == nil {
-					r.Service.Weights = consulapi.AgentWeights{
-						Passing: weightI,
-					}
-				} else {
-					t.Log.Debug("[generateRegistrations] service weight err: ", err)
-				}
-			}
 
 			t.consulMap[key] = append(t.consulMap[key], &r)
 		}
@@ -560,19 +635,6 @@ func (t *ServiceResource) generateRegistrations(key string) {
 				r.Service.ID = serviceID(r.Service.Service, addr)
 				r.Service.Address = addr
 
-				// Adding information about service weight.
-				// Overrides the existing weight if present.
-				if weight, ok := svc.Annotations[annotationServiceWeight]; ok && weight != "" {
-					weightI, err := getServiceWeight(weight)
-					if err == nil {
-						r.Service.Weights = consulapi.AgentWeights{
-							Passing: weightI,
-						}
-					} else {
-						t.Log.Debug("[generateRegistrations] service weight err: ", err)
-					}
-				}
-
 				t.consulMap[key] = append(t.consulMap[key], &r)
 			}
 		}
@@ -592,7 +654,7 @@ func (t *ServiceResource) generateRegistrations(key string) {
 		}
 
 		for _, subset := range endpoints.Subsets {
-			for _, subsetAddr := range subset.Addresses {
+			for _, subsetAddr := range t.endpointAddresses(subset) {
 				// Check that the node name exists
 				// subsetAddr.NodeName is of type *string
 				if subsetAddr.NodeName == nil {
@@ -625,6 +687,10 @@ func (t *ServiceResource) generateRegistrations(key string) {
 						r.Service = &rs
 						r.Service.ID = serviceID(r.Service.Service, subsetAddr.IP)
 						r.Service.Address = address.Address
+						r.Check = t.readinessCheck(endpoints.Namespace, r.Service, subsetAddr.ready)
+						if t.SyncNodeTopology {
+							t.setNodeTopology(&r, node)
+						}
 
 						t.consulMap[key] = append(t.consulMap[key], &r)
 						// Only consider the first address that matches. In some cases
@@ -646,6 +712,10 @@ func (t *ServiceResource) generateRegistrations(key string) {
 							r.Service = &rs
 							r.Service.ID = serviceID(r.Service.Service, subsetAddr.IP)
 							r.Service.Address = address.Address
+							r.Check = t.readinessCheck(endpoints.Namespace, r.Service, subsetAddr.ready)
+							if t.SyncNodeTopology {
+								t.setNodeTopology(&r, node)
+							}
 
 							t.consulMap[key] = append(t.consulMap[key], &r)
 							// Only consider the first address that matches. In some cases
@@ -684,6 +754,10 @@ func (t *ServiceResource) registerServiceInstance(
 		return
 	}
 
+	// Pods backing headless services can have stable hostnames, e.g. the
+	// members of a StatefulSet.
+	headless := t.serviceMap[key] != nil && t.serviceMap[key].Spec.ClusterIP == corev1.ClusterIPNone
+
 	seen := map[string]struct{}{}
 	for _, subset := range endpoints.Subsets {
 		// For ClusterIP services and if LoadBalancerEndpointsSync is true, we use the endpoint port instead
@@ -706,7 +780,7 @@ func (t *ServiceResource) registerServiceInstance(
 				break
 			}
 		}
-		for _, subsetAddr := range subset.Addresses {
+		for _, subsetAddr := range t.endpointAddresses(subset) {
 			var addr string
 			// Use the address and port from the Ingress resource if
 			// ingress-sync is enabled and the service has an ingress
@@ -751,15 +825,24 @@ func (t *ServiceResource) registerServiceInstance(
 			if subsetAddr.NodeName != nil {
 				r.Service.Meta[ConsulK8SNodeName] = *subsetAddr.NodeName
 			}
+			if headless && subsetAddr.Hostname != "" && addr == subsetAddr.IP {
+				// Use the hostname for the ID so that the instance keeps
+				// its identity when the pod is rescheduled with a new IP.
+				// Hostnames are only unique within a k8s namespace, and services
+				// from several k8s namespaces can be synced to the same Consul
+				// namespace, so the k8s namespace is part of the ID.
+				r.Service.ID = serviceID(r.Service.Service, subsetAddr.Hostname+"."+endpoints.Namespace)
+				r.Service.Meta[ConsulK8SPodHostname] = subsetAddr.Hostname
+				if ordinal, ok := statefulSetOrdinal(subsetAddr.Hostname); ok {
+					r.Service.Meta[ConsulK8SPodOrdinal] = ordinal
+				}
+			}
 
-			r.Check = &consulapi.AgentCheck{
-				CheckID:   consulHealthCheckID(endpoints.Namespace, serviceID(r.Service.Service, addr)),
-				Name:      consulKubernetesCheckName,
-				Namespace: baseService.Namespace,
-				Type:      consulKubernetesCheckType,
-				Status:    consulapi.HealthPassing,
-				ServiceID: serviceID(r.Service.Service, addr),
-				Output:    kubernetesSuccessReasonMsg,
+			r.Check = t.readinessCheck(endpoints.Namespace, r.Service, subsetAddr.ready)
+			if t.SyncNodeTopology && subsetAddr.NodeName != nil {
+				if node, ok := t.nodeMap[*subsetAddr.NodeName]; ok {
+					t.setNodeTopology(&r, node)
+				}
 			}
 
 			t.consulMap[key] = append(t.consulMap[key], &r)
@@ -767,6 +850,109 @@ func (t *ServiceResource) registerServiceInstance(
 	}
 }
 
+// consulNamespace returns the Consul namespace that services in the k8s
+// namespace kubeNS are registered into. An explicit mapping takes precedence
+// over the same mirroring rules as connect-inject.
+func (t *ServiceResource) consulNamespace(kubeNS string) string {
+	if consulNS, ok := t.K8SNSMapping[kubeNS]; ok && t.EnableNamespaces {
+		return consulNS
+	}
+	return namespaces.ConsulNamespace(kubeNS,
+		t.EnableNamespaces,
+		t.ConsulDestinationNamespace,
+		t.EnableK8SNSMirroring,
+		t.K8SNSMirroringPrefix)
+}
+
+// endpointAddress is an address of a service's endpoints along with its
+// readiness.
+type endpointAddress struct {
+	corev1.EndpointAddress
+	ready bool
+}
+
+// endpointAddresses returns the ready addresses of subset, followed by
+// the not ready addresses if SyncNotReadyEndpoints is set.
+func (t *ServiceResource) endpointAddresses(subset corev1.EndpointSubset) []endpointAddress {
+	addresses := make([]endpointAddress, 0, len(subset.Addresses)+len(subset.NotReadyAddresses))
+	for _, addr := range subset.Addresses {
+		addresses = append(addresses, endpointAddress{EndpointAddress: addr, ready: true})
+	}
+	if t.SyncNotReadyEndpoints {
+		for _, addr := range subset.NotReadyAddresses {
+			addresses = append(addresses, endpointAddress{EndpointAddress: addr})
+		}
+	}
+	return addresses
+}
+
+// setNodeTopology registers the instance r on the Consul node for the k8s
+// node and records the topology of the k8s node in the service and node meta.
+func (t *ServiceResource) setNodeTopology(r *consulapi.CatalogRegistration, node *corev1.Node) {
+	r.Node = fmt.Sprintf("%s-%s", t.ConsulNodeName, node.Name)
+	if address := nodeInternalIP(node); address != "" {
+		r.Address = address
+	}
+	r.NodeMeta = map[string]string{
+		ConsulSourceKey:   ConsulSourceValue,
+		ConsulK8SSyncNode: t.ConsulNodeName,
+	}
+
+	// Copy the service meta since it can be shared between instances.
+	meta := make(map[string]string, len(r.Service.Meta)+3)
+	for k, v := range r.Service.Meta {
+		meta[k] = v
+	}
+	r.Service.Meta = meta
+
+	topology := map[string]string{
+		ConsulK8SNodeZone:         node.Labels[corev1.LabelTopologyZone],
+		ConsulK8SNodeRegion:       node.Labels[corev1.LabelTopologyRegion],
+		ConsulK8SNodeInstanceType: node.Labels[corev1.LabelInstanceTypeStable],
+	}
+	for k, v := range topology {
+		if v == "" {
+			continue
+		}
+		r.NodeMeta[k] = v
+		r.Service.Meta[k] = v
+	}
+}
+
+// statefulSetOrdinal returns the ordinal of a StatefulSet pod from its
+// hostname, which is the pod name, e.g. "1" for "db-1".
+func statefulSetOrdinal(hostname string) (string, bool) {
+	i := strings.LastIndex(hostname, "-")
+	if i < 0 || i == len(hostname)-1 {
+		return "", false
+	}
+	ordinal := hostname[i+1:]
+	if _, err := strconv.ParseUint(ordinal, 10, 32); err != nil {
+		return "", false
+	}
+	return ordinal, true
+}
+
+// readinessCheck returns the health check of the service instance registered
+// for an endpoint address. Its status follows the readiness of the address.
+func (t *ServiceResource) readinessCheck(k8sNS string, svc *consulapi.AgentService, ready bool) *consulapi.AgentCheck {
+	check := &consulapi.AgentCheck{
+		CheckID:   consulHealthCheckID(k8sNS, svc.ID),
+		Name:      consulKubernetesCheckName,
+		Namespace: svc.Namespace,
+		Partition: svc.Partition,
+		Type:      consulKubernetesCheckType,
+		Status:    consulapi.HealthPassing,
+		ServiceID: svc.ID,
+		Output:    kubernetesSuccessReasonMsg,
+	}
+	if !ready {
+		check.Status = consulapi.HealthCritical
+		check.Output = kubernetesFailureReasonMsg
+	}
+	return check
+}
+
 // sync calls the Syncer.Sync function from the generated registrations.
 //
 // Precondition: lock must be held.
@@ -1004,6 +1190,116 @@ func (t *serviceIngressResource) Delete(key string, _ interface{}) error {
 	return nil
 }
 
+// serviceNodeResource implements controller.Resource and starts a background
+// watcher on nodes that is used by the ServiceResource to keep track of the
+// topology of the k8s nodes when SyncNodeTopology is true.
+type serviceNodeResource struct {
+	Service *ServiceResource
+	Ctx     context.Context
+}
+
+func (t *serviceNodeResource) Informer() cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return t.Service.Client.CoreV1().Nodes().List(t.Ctx, options)
+			},
+
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return t.Service.Client.CoreV1().Nodes().Watch(t.Ctx, options)
+			},
+		},
+		&corev1.Node{},
+		0,
+		cache.Indexers{},
+	)
+}
+
+func (t *serviceNodeResource) Upsert(key string, raw interface{}) error {
+	svc := t.Service
+	node, ok := raw.(*corev1.Node)
+	if !ok {
+		svc.Log.Warn("upsert got invalid type", "raw", raw)
+		return nil
+	}
+
+	svc.serviceLock.Lock()
+	defer svc.serviceLock.Unlock()
+
+	if svc.nodeMap == nil {
+		svc.nodeMap = make(map[string]*corev1.Node)
+	}
+	old, ok := svc.nodeMap[node.Name]
+	svc.nodeMap[node.Name] = node
+	if ok && !nodeTopologyChanged(old, node) {
+		return nil
+	}
+
+	// Update the registrations of the services with endpoints on the node
+	// and trigger a sync.
+	keys := svc.endpointsOnNode(node.Name)
+	for _, k := range keys {
+		svc.generateRegistrations(k)
+	}
+	if len(keys) > 0 {
+		svc.sync()
+	}
+	svc.Log.Debug("upsert node", "key", key, "services", len(keys))
+	return nil
+}
+
+func (t *serviceNodeResource) Delete(key string, _ interface{}) error {
+	t.Service.serviceLock.Lock()
+	defer t.Service.serviceLock.Unlock()
+	delete(t.Service.nodeMap, key)
+	t.Service.Log.Debug("delete node", "key", key)
+	return nil
+}
+
+// endpointsOnNode returns the keys of the services with endpoints on the k8s
+// node.
+//
+// Precondition: assumes t.serviceLock is held.
+func (t *ServiceResource) endpointsOnNode(nodeName string) []string {
+	var keys []string
+	for key, endpoints := range t.endpointsMap {
+		if _, ok := t.serviceMap[key]; !ok || endpoints == nil {
+			continue
+		}
+	subsets:
+		for _, subset := range endpoints.Subsets {
+			for _, subsetAddr := range t.endpointAddresses(subset) {
+				if subsetAddr.NodeName != nil && *subsetAddr.NodeName == nodeName {
+					keys = append(keys, key)
+					break subsets
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// nodeTopologyChanged returns true if the k8s node changed in a way that
+// changes the registrations set by setNodeTopology.
+func nodeTopologyChanged(old, node *corev1.Node) bool {
+	for _, label := range []string{corev1.LabelTopologyZone, corev1.LabelTopologyRegion, corev1.LabelInstanceTypeStable} {
+		if old.Labels[label] != node.Labels[label] {
+			return true
+		}
+	}
+	return nodeInternalIP(old) != nodeInternalIP(node)
+}
+
+// nodeInternalIP returns the first internal IP of the k8s node.
+func nodeInternalIP(node *corev1.Node) string {
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			return address.Address
+		}
+	}
+	return ""
+}
+
 func (t *ServiceResource) addPrefixAndK8SNamespace(name, namespace string) string {
 	if t.ConsulServicePrefix != "" {
 		name = fmt.Sprintf("%s%s", t.ConsulServicePrefix, name)
@@ -1026,6 +1322,90 @@ func consulHealthCheckID(k8sNS string, serviceID string) string {
 	return fmt.Sprintf("%s/%s", k8sNS, serviceID)
 }
 
+// getServiceWeights returns the instance weights set by the service weight
+// annotations. The boolean is false if neither annotation is set. Weights
+// that aren't set default to 1 like in Consul.
+func getServiceWeights(annotations map[string]string) (consulapi.AgentWeights, bool, error) {
+	weights := consulapi.AgentWeights{Passing: 1, Warning: 1}
+	passing, hasPassing := annotations[annotationServiceWeight]
+	warning, hasWarning := annotations[annotationServiceWeightWarning]
+	hasPassing = hasPassing && passing != ""
+	hasWarning = hasWarning && warning != ""
+	if !hasPassing && !hasWarning {
+		return weights, false, nil
+	}
+
+	if hasPassing {
+		weightI, err := getServiceWeight(passing)
+		if err != nil {
+			return weights, false, err
+		}
+		weights.Passing = weightI
+	}
+	if hasWarning {
+		weightI, err := strconv.Atoi(warning)
+		if err != nil {
+			return weights, false, err
+		}
+		if weightI < 0 {
+			return weights, false, fmt.Errorf("expecting the service annotation %s value to be greater than or equal to 0", annotationServiceWeightWarning)
+		}
+		weights.Warning = weightI
+	}
+	return weights, true, nil
+}
+
+// getTaggedAddresses returns the tagged addresses set by the tagged address
+// annotations, keyed by tag. A port of 0 means the instance port. Invalid
+// annotations are skipped and returned as errors.
+func getTaggedAddresses(annotations map[string]string) (map[string]consulapi.ServiceAddress, error) {
+	var taggedAddresses map[string]consulapi.ServiceAddress
+	var errs error
+	for k, v := range annotations {
+		if !strings.HasPrefix(k, annotationServiceTaggedAddressPrefix) {
+			continue
+		}
+		tag := strings.TrimPrefix(k, annotationServiceTaggedAddressPrefix)
+		v = strings.TrimSpace(v)
+		if tag == "" || v == "" {
+			continue
+		}
+
+		address := consulapi.ServiceAddress{Address: v}
+		if host, port, err := net.SplitHostPort(v); err == nil {
+			portI, err := strconv.Atoi(port)
+			if err != nil || portI < 0 || portI > 65535 {
+				errs = multierror.Append(errs, fmt.Errorf("service annotation %s value of %s has an invalid port", k, v))
+				continue
+			}
+			address = consulapi.ServiceAddress{Address: host, Port: portI}
+		}
+		if taggedAddresses == nil {
+			taggedAddresses = make(map[string]consulapi.ServiceAddress)
+		}
+		taggedAddresses[tag] = address
+	}
+	return taggedAddresses, errs
+}
+
+// setTaggedAddresses adds the tagged addresses to the instances of the service.
+//
+// Precondition: lock must be held.
+func (t *ServiceResource) setTaggedAddresses(key string, taggedAddresses map[string]consulapi.ServiceAddress) {
+	if len(taggedAddresses) == 0 {
+		return
+	}
+	for _, r := range t.consulMap[key] {
+		r.Service.TaggedAddresses = make(map[string]consulapi.ServiceAddress, len(taggedAddresses))
+		for tag, address := range taggedAddresses {
+			if address.Port == 0 {
+				address.Port = r.Service.Port
+			}
+			r.Service.TaggedAddresses[tag] = address
+		}
+	}
+}
+
 // Calculates the passing service weight.
 func getServiceWeight(weight string) (int, error) {
 	// error validation if the input param is a number.
@@ -5,6 +5,7 @@ package catalog
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	mapset "github.com/deckarep/golang-set"
@@ -16,6 +17,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
@@ -189,6 +191,135 @@ func TestServiceWeight(t *testing.T) {
 	}
 }
 
+// Test that the passing and warning weights are set on every instance.
+func TestServiceWeights_clusterIP(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.ClusterIPSync = true
+
+	// Start the controller
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	// Insert the service
+	svc := clusterIPService("foo", metav1.NamespaceDefault)
+	svc.Annotations[annotationServiceWeight] = "10"
+	svc.Annotations[annotationServiceWeightWarning] = "0"
+	_, err := client.CoreV1().Services(metav1.NamespaceDefault).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Insert the endpoints
+	createEndpoints(t, client, "foo", metav1.NamespaceDefault)
+
+	// Verify what we got
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 2)
+		for _, reg := range actual {
+			require.Equal(r, consulapi.AgentWeights{Passing: 10, Warning: 0}, reg.Service.Weights)
+		}
+	})
+}
+
+func TestGetServiceWeights(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		annotations map[string]string
+		expWeights  consulapi.AgentWeights
+		expOK       bool
+		expErr      bool
+	}{
+		"no annotations": {
+			annotations: map[string]string{},
+			expWeights:  consulapi.AgentWeights{Passing: 1, Warning: 1},
+		},
+		"passing": {
+			annotations: map[string]string{annotationServiceWeight: "5"},
+			expWeights:  consulapi.AgentWeights{Passing: 5, Warning: 1},
+			expOK:       true,
+		},
+		"warning": {
+			annotations: map[string]string{annotationServiceWeightWarning: "3"},
+			expWeights:  consulapi.AgentWeights{Passing: 1, Warning: 3},
+			expOK:       true,
+		},
+		"passing and warning": {
+			annotations: map[string]string{annotationServiceWeight: "5", annotationServiceWeightWarning: "0"},
+			expWeights:  consulapi.AgentWeights{Passing: 5, Warning: 0},
+			expOK:       true,
+		},
+		"invalid passing": {
+			annotations: map[string]string{annotationServiceWeight: "1"},
+			expErr:      true,
+		},
+		"negative warning": {
+			annotations: map[string]string{annotationServiceWeightWarning: "-1"},
+			expErr:      true,
+		},
+		"non-int warning": {
+			annotations: map[string]string{annotationServiceWeightWarning: "foo"},
+			expErr:      true,
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			weights, ok, err := getServiceWeights(c.annotations)
+			if c.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expOK, ok)
+			require.Equal(t, c.expWeights, weights)
+		})
+	}
+}
+
+// Test that tagged addresses are set on every instance, using the instance
+// port if the annotation doesn't set one.
+func TestServiceResource_taggedAddresses(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.ClusterIPSync = true
+
+	// Start the controller
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	// Insert the service
+	svc := clusterIPService("foo", metav1.NamespaceDefault)
+	svc.Annotations[annotationServiceTaggedAddressPrefix+"public"] = "5.6.7.8:443"
+	svc.Annotations[annotationServiceTaggedAddressPrefix+"lan_ipv6"] = "fd00::1"
+	svc.Annotations[annotationServiceTaggedAddressPrefix+"invalid"] = "1.2.3.4:foo"
+	_, err := client.CoreV1().Services(metav1.NamespaceDefault).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Insert the endpoints
+	createEndpoints(t, client, "foo", metav1.NamespaceDefault)
+
+	// Verify what we got. The invalid annotation is skipped.
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 2)
+		for _, reg := range actual {
+			require.Equal(r, map[string]consulapi.ServiceAddress{
+				"public":   {Address: "5.6.7.8", Port: 443},
+				"lan_ipv6": {Address: "fd00::1", Port: 8080},
+			}, reg.Service.TaggedAddresses)
+		}
+	})
+}
+
 // Test that we're default enabled.
 func TestServiceResource_defaultEnable(t *testing.T) {
 	t.Parallel()
@@ -293,6 +424,46 @@ func TestServiceResource_defaultDisableEnable(t *testing.T) {
 	})
 }
 
+// Test that a label selector selects the services synced by default and that
+// the annotation still takes precedence.
+func TestServiceResource_serviceSelector(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	selector, err := labels.Parse("tier=frontend")
+	require.NoError(t, err)
+	serviceResource.ServiceSelector = selector
+
+	// Start the controller
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	// Insert a matching service, a service that doesn't match and a service
+	// that doesn't match but is explicitly enabled.
+	matching := lbService("matching", metav1.NamespaceDefault, "1.2.3.4")
+	matching.Labels = map[string]string{"tier": "frontend"}
+	other := lbService("other", metav1.NamespaceDefault, "2.3.4.5")
+	other.Labels = map[string]string{"tier": "backend"}
+	annotated := lbService("annotated", metav1.NamespaceDefault, "3.4.5.6")
+	annotated.Annotations[annotationServiceSync] = "true"
+	for _, svc := range []*corev1.Service{matching, other, annotated} {
+		_, err := client.CoreV1().Services(metav1.NamespaceDefault).Create(context.Background(), svc, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	// Verify what we got
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		var names []string
+		for _, reg := range syncer.Registrations {
+			names = append(names, reg.Service.Service)
+		}
+		require.ElementsMatch(r, []string{"matching", "annotated"}, names)
+	})
+}
+
 // Test changing the sync tag to false deletes the service.
 func TestServiceResource_changeSyncToFalse(t *testing.T) {
 	t.Parallel()
@@ -1180,6 +1351,287 @@ func TestServiceResource_clusterIP_healthCheck(t *testing.T) {
 	})
 }
 
+// Test that not ready addresses are registered with a critical health check
+// when SyncNotReadyEndpoints is set and skipped otherwise.
+func TestServiceResource_clusterIP_notReadyEndpoints(t *testing.T) {
+	t.Parallel()
+	for _, syncNotReady := range []bool{false, true} {
+		syncNotReady := syncNotReady
+		t.Run(fmt.Sprintf("SyncNotReadyEndpoints=%t", syncNotReady), func(t *testing.T) {
+			t.Parallel()
+			client := fake.NewSimpleClientset()
+			syncer := newTestSyncer()
+			serviceResource := defaultServiceResource(client, syncer)
+			serviceResource.ClusterIPSync = true
+			serviceResource.SyncNotReadyEndpoints = syncNotReady
+
+			// Start the controller
+			closer := controller.TestControllerRun(&serviceResource)
+			defer closer()
+
+			// Insert the service
+			svc := clusterIPService("foo", metav1.NamespaceDefault)
+			_, err := client.CoreV1().Services(metav1.NamespaceDefault).Create(context.Background(), svc, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			// Insert the endpoints with a ready and a not ready address
+			_, err = client.CoreV1().Endpoints(metav1.NamespaceDefault).Create(
+				context.Background(),
+				&corev1.Endpoints{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "foo",
+						Namespace: metav1.NamespaceDefault,
+					},
+					Subsets: []corev1.EndpointSubset{
+						{
+							Addresses:         []corev1.EndpointAddress{{IP: "1.1.1.1"}},
+							NotReadyAddresses: []corev1.EndpointAddress{{IP: "2.2.2.2"}},
+							Ports:             []corev1.EndpointPort{{Name: "http", Port: 8080}},
+						},
+					},
+				},
+				metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			// Verify what we got
+			retry.Run(t, func(r *retry.R) {
+				syncer.Lock()
+				defer syncer.Unlock()
+				actual := syncer.Registrations
+				if !syncNotReady {
+					require.Len(r, actual, 1)
+					require.Equal(r, "1.1.1.1", actual[0].Service.Address)
+					require.Equal(r, consulapi.HealthPassing, actual[0].Check.Status)
+					return
+				}
+				require.Len(r, actual, 2)
+				require.Equal(r, "1.1.1.1", actual[0].Service.Address)
+				require.Equal(r, consulapi.HealthPassing, actual[0].Check.Status)
+				require.Equal(r, kubernetesSuccessReasonMsg, actual[0].Check.Output)
+				require.Equal(r, "2.2.2.2", actual[1].Service.Address)
+				require.Equal(r, consulapi.HealthCritical, actual[1].Check.Status)
+				require.Equal(r, kubernetesFailureReasonMsg, actual[1].Check.Output)
+				require.Equal(r, actual[1].Service.ID, actual[1].Check.ServiceID)
+			})
+		})
+	}
+}
+
+// Test that the pods of a headless service are registered with their
+// stable hostname and StatefulSet ordinal.
+func TestServiceResource_headlessStatefulSet(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.ClusterIPSync = true
+
+	// Start the controller
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	// Insert the service
+	svc := clusterIPService("db", metav1.NamespaceDefault)
+	svc.Spec.ClusterIP = corev1.ClusterIPNone
+	_, err := client.CoreV1().Services(metav1.NamespaceDefault).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Insert the endpoints of two StatefulSet pods and a pod without hostname
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "db",
+			Namespace: metav1.NamespaceDefault,
+		},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{
+					{IP: "1.1.1.1", Hostname: "db-0", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "db-0"}},
+					{IP: "2.2.2.2", Hostname: "db-1", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "db-1"}},
+					{IP: "3.3.3.3", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "other"}},
+				},
+				Ports: []corev1.EndpointPort{{Name: "sql", Port: 5432}},
+			},
+		},
+	}
+	_, err = client.CoreV1().Endpoints(metav1.NamespaceDefault).Create(context.Background(), endpoints, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Verify what we got
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 3)
+		require.Equal(r, serviceID("db", "db-0.default"), actual[0].Service.ID)
+		require.Equal(r, "1.1.1.1", actual[0].Service.Address)
+		require.Equal(r, "db-0", actual[0].Service.Meta[ConsulK8SPodHostname])
+		require.Equal(r, "0", actual[0].Service.Meta[ConsulK8SPodOrdinal])
+		require.Equal(r, "db-0", actual[0].Service.Meta[ConsulK8SRefValue])
+		require.Equal(r, actual[0].Service.ID, actual[0].Check.ServiceID)
+		require.Equal(r, serviceID("db", "db-1.default"), actual[1].Service.ID)
+		require.Equal(r, "db-1", actual[1].Service.Meta[ConsulK8SPodHostname])
+		require.Equal(r, "1", actual[1].Service.Meta[ConsulK8SPodOrdinal])
+		require.Equal(r, serviceID("db", "3.3.3.3"), actual[2].Service.ID)
+		require.NotContains(r, actual[2].Service.Meta, ConsulK8SPodHostname)
+	})
+
+	// Reschedule db-0 with a new IP and verify the instance keeps its ID
+	endpoints.Subsets[0].Addresses[0].IP = "4.4.4.4"
+	_, err = client.CoreV1().Endpoints(metav1.NamespaceDefault).Update(context.Background(), endpoints, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 3)
+		require.Equal(r, serviceID("db", "db-0.default"), actual[0].Service.ID)
+		require.Equal(r, "4.4.4.4", actual[0].Service.Address)
+	})
+}
+
+// Test that the pods of headless services with the same name and hostnames
+// in different k8s namespaces get different IDs.
+func TestServiceResource_headlessStatefulSetNamespaces(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.ClusterIPSync = true
+
+	// Start the controller
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	// Insert the service and the endpoints of a StatefulSet pod in both namespaces
+	for i, ns := range []string{"foo", "bar"} {
+		svc := clusterIPService("db", ns)
+		svc.Spec.ClusterIP = corev1.ClusterIPNone
+		_, err := client.CoreV1().Services(ns).Create(context.Background(), svc, metav1.CreateOptions{})
+		require.NoError(t, err)
+
+		endpoints := &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "db",
+				Namespace: ns,
+			},
+			Subsets: []corev1.EndpointSubset{
+				{
+					Addresses: []corev1.EndpointAddress{
+						{IP: fmt.Sprintf("%d.%d.%d.%d", i+1, i+1, i+1, i+1), Hostname: "db-0"},
+					},
+					Ports: []corev1.EndpointPort{{Name: "sql", Port: 5432}},
+				},
+			},
+		}
+		_, err = client.CoreV1().Endpoints(ns).Create(context.Background(), endpoints, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	// Verify what we got
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 2)
+		require.ElementsMatch(r,
+			[]string{serviceID("db", "db-0.foo"), serviceID("db", "db-0.bar")},
+			[]string{actual[0].Service.ID, actual[1].Service.ID})
+	})
+}
+
+func TestStatefulSetOrdinal(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		ordinal string
+		ok      bool
+	}{
+		"db-0":      {ordinal: "0", ok: true},
+		"my-db-12":  {ordinal: "12", ok: true},
+		"db":        {},
+		"db-":       {},
+		"db-abc":    {},
+		"db-1-blue": {},
+	}
+	for hostname, c := range cases {
+		ordinal, ok := statefulSetOrdinal(hostname)
+		require.Equal(t, c.ok, ok, hostname)
+		require.Equal(t, c.ordinal, ordinal, hostname)
+	}
+}
+
+// Test that instances are registered on a Consul node per k8s node with the
+// node topology when SyncNodeTopology is set.
+func TestServiceResource_nodeTopology(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.ClusterIPSync = true
+	serviceResource.SyncNodeTopology = true
+
+	// Start the controller
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	// Insert the nodes with topology labels on the first one
+	node1, _ := createNodes(t, client)
+	node1.Labels = map[string]string{
+		corev1.LabelTopologyZone:       "us-east-1a",
+		corev1.LabelTopologyRegion:     "us-east-1",
+		corev1.LabelInstanceTypeStable: "m5.large",
+	}
+	_, err := client.CoreV1().Nodes().Update(context.Background(), node1, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	// Insert the service
+	svc := clusterIPService("foo", metav1.NamespaceDefault)
+	_, err = client.CoreV1().Services(metav1.NamespaceDefault).Create(context.Background(), svc, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Insert the endpoints
+	createEndpoints(t, client, "foo", metav1.NamespaceDefault)
+
+	// Verify what we got
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 2)
+
+		require.Equal(r, ConsulSyncNodeName+"-"+nodeName1, actual[0].Node)
+		require.Equal(r, "4.5.6.7", actual[0].Address)
+		require.Equal(r, map[string]string{
+			ConsulSourceKey:           ConsulSourceValue,
+			ConsulK8SSyncNode:         ConsulSyncNodeName,
+			ConsulK8SNodeZone:         "us-east-1a",
+			ConsulK8SNodeRegion:       "us-east-1",
+			ConsulK8SNodeInstanceType: "m5.large",
+		}, actual[0].NodeMeta)
+		require.Equal(r, "us-east-1a", actual[0].Service.Meta[ConsulK8SNodeZone])
+		require.Equal(r, "us-east-1", actual[0].Service.Meta[ConsulK8SNodeRegion])
+		require.Equal(r, "m5.large", actual[0].Service.Meta[ConsulK8SNodeInstanceType])
+
+		require.Equal(r, ConsulSyncNodeName+"-"+nodeName2, actual[1].Node)
+		require.Equal(r, "3.4.5.6", actual[1].Address)
+		require.NotContains(r, actual[1].Service.Meta, ConsulK8SNodeZone)
+	})
+
+	// Change the zone of the first node
+	node1.Labels[corev1.LabelTopologyZone] = "us-east-1b"
+	_, err = client.CoreV1().Nodes().Update(context.Background(), node1, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	// Verify that the new zone is synced
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 2)
+		require.Equal(r, "us-east-1b", actual[0].NodeMeta[ConsulK8SNodeZone])
+		require.Equal(r, "us-east-1b", actual[0].Service.Meta[ConsulK8SNodeZone])
+	})
+}
+
 // Test clusterIP with prefix.
 func TestServiceResource_clusterIPPrefix(t *testing.T) {
 	t.Parallel()
@@ -1665,6 +2117,42 @@ func TestServiceResource_MirroredPrefixNamespace(t *testing.T) {
 	})
 }
 
+// Test that an explicit namespace mapping takes precedence over mirroring
+// and that services are registered into the admin partition.
+func TestServiceResource_MappedNamespaceAndPartition(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+	syncer := newTestSyncer()
+	serviceResource := defaultServiceResource(client, syncer)
+	serviceResource.EnableK8SNSMirroring = true
+	serviceResource.EnableNamespaces = true
+	serviceResource.K8SNSMirroringPrefix = "prefix-"
+	serviceResource.K8SNSMapping = map[string]string{"foo": "team-a"}
+	serviceResource.ConsulPartition = "ap1"
+	closer := controller.TestControllerRun(&serviceResource)
+	defer closer()
+
+	for _, ns := range []string{"foo", "bar"} {
+		_, err := client.CoreV1().Services(ns).
+			Create(context.Background(), lbService(ns, ns, "1.2.3.4"), metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	retry.Run(t, func(r *retry.R) {
+		syncer.Lock()
+		defer syncer.Unlock()
+		actual := syncer.Registrations
+		require.Len(r, actual, 2)
+		namespaces := make(map[string]string)
+		for _, reg := range actual {
+			require.Equal(r, "ap1", reg.Partition)
+			require.Equal(r, "ap1", reg.Service.Partition)
+			namespaces[reg.Service.Service] = reg.Service.Namespace
+		}
+		require.Equal(r, map[string]string{"foo": "team-a", "bar": "prefix-bar"}, namespaces)
+	})
+}
+
 // Test k8s namespace suffix is not appended
 // when the service name annotation is provided.
 func TestServiceResource_addIngress(t *testing.T) {
@@ -105,6 +105,10 @@ type ConsulSyncer struct {
 
 // Sync implements Syncer.
 func (s *ConsulSyncer) Sync(rs []*api.CatalogRegistration) {
+	// Sync can be called before Run, e.g. on a standby replica that hasn't
+	// been elected leader yet.
+	s.once.Do(s.init)
+
 	// Grab the lock so we can replace the sync state
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -145,6 +149,7 @@ func (s *ConsulSyncer) Run(ctx context.Context) {
 
 	// Start the background watchers
 	go s.watchReapableServices(ctx)
+	go s.watchK8SNodes(ctx)
 
 	reconcileTimer := time.NewTimer(s.SyncPeriod)
 	defer reconcileTimer.Stop()
@@ -227,38 +232,123 @@ func (s *ConsulSyncer) watchReapableServices(ctx context.Context) {
 
 		// Lock so we can modify the stored state
 		s.lock.Lock()
+		s.scheduleReapInvalidServicesLocked(services.Services)
+		s.lock.Unlock()
+	}
+}
 
-		// Go through the service array and find services that should be reaped
-		for _, service := range services.Services {
-			// Check that the namespace exists in the valid service names map
-			// before checking whether it contains the service
-			svcNs := service.Namespace
-			if !s.EnableNamespaces {
-				// Set namespace to empty when namespaces are not enabled.
-				svcNs = ""
+// scheduleReapInvalidServicesLocked schedules the removal of the services
+// that aren't valid anymore.
+//
+// Precondition: lock must be held.
+func (s *ConsulSyncer) scheduleReapInvalidServicesLocked(services []*api.AgentService) {
+	// Go through the service array and find services that should be reaped
+	for _, service := range services {
+		// Check that the namespace exists in the valid service names map
+		// before checking whether it contains the service
+		svcNs := service.Namespace
+		if !s.EnableNamespaces {
+			// Set namespace to empty when namespaces are not enabled.
+			svcNs = ""
+		}
+		if _, ok := s.serviceNames[svcNs]; ok {
+			// We only care if we don't know about this service at all.
+			if s.serviceNames[svcNs].Contains(service.Service) {
+				s.Log.Debug("[watchReapableServices] serviceNames contains service",
+					"namespace", svcNs,
+					"service-name", service.Service)
+				continue
 			}
-			if _, ok := s.serviceNames[svcNs]; ok {
-				// We only care if we don't know about this service at all.
-				if s.serviceNames[svcNs].Contains(service.Service) {
-					s.Log.Debug("[watchReapableServices] serviceNames contains service",
-						"namespace", svcNs,
-						"service-name", service.Service)
-					continue
-				}
+		}
+
+		s.Log.Info("invalid service found, scheduling for delete",
+			"service-name", service.Service, "service-id", service.ID, "service-consul-namespace", svcNs)
+		if err := s.scheduleReapServiceLocked(service.Service, svcNs); err != nil {
+			s.Log.Info("error querying service for delete",
+				"service-name", service.Service,
+				"service-consul-namespace", svcNs,
+				"err", err)
+		}
+	}
+}
+
+// watchK8SNodes is a long-running task started by Run that periodically
+// looks for invalid services on the Consul nodes registered for k8s nodes,
+// which aren't watched by watchReapableServices, and deregisters the nodes
+// without services.
+func (s *ConsulSyncer) watchK8SNodes(ctx context.Context) {
+	// We must wait for the initial sync to be complete and our maps to be
+	// populated, see watchReapableServices.
+	select {
+	case <-s.initialSync:
+	case <-ctx.Done():
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.ServicePollPeriod):
+		}
+
+		consulClient, err := consul.NewClientFromConnMgr(s.ConsulClientConfig, s.ConsulServerConnMgr)
+		if err != nil {
+			s.Log.Error("failed to create Consul API client; will retry", "err", err)
+			continue
+		}
+
+		nodes, _, err := consulClient.Catalog().Nodes(&api.QueryOptions{
+			AllowStale: true,
+			NodeMeta:   map[string]string{ConsulK8SSyncNode: s.ConsulNodeName},
+		})
+		if err != nil {
+			s.Log.Warn("error querying nodes, will retry", "err", err)
+			continue
+		}
+
+		opts := &api.QueryOptions{
+			AllowStale: true,
+			Filter:     fmt.Sprintf("\"%s\" in Tags", s.ConsulK8STag),
+		}
+		if s.EnableNamespaces {
+			opts.Namespace = "*"
+		}
+		for _, node := range nodes {
+			services, _, err := consulClient.Catalog().NodeServiceList(node.Node, opts)
+			if err != nil {
+				s.Log.Warn("error querying node services, will retry", "node-name", node.Node, "err", err)
+				continue
 			}
 
-			s.Log.Info("invalid service found, scheduling for delete",
-				"service-name", service.Service, "service-id", service.ID, "service-consul-namespace", svcNs)
-			if err = s.scheduleReapServiceLocked(service.Service, svcNs); err != nil {
-				s.Log.Info("error querying service for delete",
-					"service-name", service.Service,
-					"service-consul-namespace", svcNs,
-					"err", err)
+			s.lock.Lock()
+			s.scheduleReapInvalidServicesLocked(services.Services)
+			registered := s.hasRegistrationsLocked(node.Node)
+			s.lock.Unlock()
+
+			// The node is registered again with the next registration on it.
+			if len(services.Services) == 0 && !registered {
+				s.Log.Info("deregistering node without services", "node-name", node.Node)
+				if _, err := consulClient.Catalog().Deregister(&api.CatalogDeregistration{Node: node.Node}, nil); err != nil {
+					s.Log.Warn("error deregistering node", "node-name", node.Node, "err", err)
+				}
 			}
 		}
+	}
+}
 
-		s.lock.Unlock()
+// hasRegistrationsLocked returns true if services are registered on the node.
+//
+// Precondition: lock must be held.
+func (s *ConsulSyncer) hasRegistrationsLocked(node string) bool {
+	for _, services := range s.namespaces {
+		for _, r := range services {
+			if r.Node == node {
+				return true
+			}
+		}
 	}
+	return false
 }
 
 // watchService watches all instances of a service by name for changes
@@ -313,7 +403,10 @@ func (s *ConsulSyncer) watchService(ctx context.Context, name, namespace string)
 			// Make sure the namespace exists before we run checks against it
 			if _, ok := s.serviceNames[namespace]; ok {
 				// If the service is valid and its info isn't nil, we don't deregister it
-				if s.serviceNames[namespace].Contains(svc.ServiceName) && s.namespaces[namespace][svc.ServiceID] != nil {
+				// unless it's registered on another node, e.g. when its pod moved to
+				// another k8s node.
+				if s.serviceNames[namespace].Contains(svc.ServiceName) && s.namespaces[namespace][svc.ServiceID] != nil &&
+					s.namespaces[namespace][svc.ServiceID].Node == svc.Node {
 					continue
 				}
 			}
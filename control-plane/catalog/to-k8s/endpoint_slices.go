@@ -0,0 +1,227 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package catalog
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+// endpointSliceManagedBy is the value of the managed-by label on EndpointSlices
+// created by the sink. The Kubernetes EndpointSlice controller ignores slices
+// that it doesn't manage.
+const endpointSliceManagedBy = "sync-catalog.consul.hashicorp.com"
+
+// Endpoint is the address of a healthy Consul service instance.
+type Endpoint struct {
+	Address string
+	Port    int
+}
+
+// EndpointsSink is a Sink that also registers the addresses of Consul service
+// instances, e.g. as EndpointSlices.
+type EndpointsSink interface {
+	Sink
+
+	// SetServiceEndpoints is called with the healthy instances of a service
+	// previously passed to SetServices.
+	SetServiceEndpoints(name string, endpoints []Endpoint)
+}
+
+// endpointsFromEntries returns the endpoints of Consul health entries. The
+// instance address falls back to the node address, and instances without an
+// IP address are skipped because EndpointSlices only support IP addresses.
+func endpointsFromEntries(entries []*api.ServiceEntry) []Endpoint {
+	var endpoints []Endpoint
+	for _, entry := range entries {
+		if entry.Service == nil {
+			continue
+		}
+		address := entry.Service.Address
+		if address == "" && entry.Node != nil {
+			address = entry.Node.Address
+		}
+		if net.ParseIP(address) == nil {
+			continue
+		}
+		endpoints = append(endpoints, Endpoint{Address: address, Port: entry.Service.Port})
+	}
+	return endpoints
+}
+
+// SetServiceEndpoints implements EndpointsSink.
+func (s *K8SSink) SetServiceEndpoints(name string, endpoints []Endpoint) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	name = strings.ToLower(name)
+	if s.sourceEndpoints == nil {
+		s.sourceEndpoints = make(map[string][]Endpoint)
+	}
+	s.sourceEndpoints[name] = endpoints
+	s.markEndpointsDirty(name)
+	s.trigger()
+}
+
+// markEndpointsDirty records that the EndpointSlices of a service must be
+// reconciled on the next sync. lock must be held.
+func (s *K8SSink) markEndpointsDirty(name string) {
+	if s.dirtyEndpoints == nil {
+		s.dirtyEndpoints = make(map[string]struct{})
+	}
+	s.dirtyEndpoints[name] = struct{}{}
+}
+
+// endpointSliceList returns the desired EndpointSlices of every service whose
+// endpoints changed since the last sync, keyed by Kube service name. Services
+// that haven't been created yet stay dirty until they are. lock must be held.
+func (s *K8SSink) endpointSliceList() map[string][]*discoveryv1.EndpointSlice {
+	result := make(map[string][]*discoveryv1.EndpointSlice)
+	for name := range s.dirtyEndpoints {
+		if _, ok := s.sourceServices[name]; !ok {
+			delete(s.dirtyEndpoints, name)
+			continue
+		}
+		svc, ok := s.serviceMapConsul[name]
+		if !ok {
+			continue
+		}
+		result[name] = desiredEndpointSlices(svc, s.sourceEndpoints[name])
+		delete(s.dirtyEndpoints, name)
+	}
+	return result
+}
+
+// serviceSpec returns the spec of a Kube service that points at a Consul service.
+func (s *K8SSink) serviceSpec(consulDNS string) apiv1.ServiceSpec {
+	if s.SyncEndpointSlices {
+		return apiv1.ServiceSpec{
+			Type:      apiv1.ServiceTypeClusterIP,
+			ClusterIP: apiv1.ClusterIPNone,
+		}
+	}
+	return apiv1.ServiceSpec{
+		Type:         apiv1.ServiceTypeExternalName,
+		ExternalName: consulDNS,
+	}
+}
+
+// serviceSpecMatches returns true if svc doesn't need to be updated.
+func (s *K8SSink) serviceSpecMatches(svc *apiv1.Service, consulDNS string) bool {
+	if s.SyncEndpointSlices {
+		return svc.Spec.Type == apiv1.ServiceTypeClusterIP && svc.Spec.ClusterIP == apiv1.ClusterIPNone
+	}
+	return svc.Spec.ExternalName == consulDNS
+}
+
+// desiredEndpointSlices returns one EndpointSlice per address type and port of
+// the endpoints. The slices are owned by svc so that they're garbage collected
+// with it.
+func desiredEndpointSlices(svc *apiv1.Service, endpoints []Endpoint) []*discoveryv1.EndpointSlice {
+	type sliceKey struct {
+		addressType discoveryv1.AddressType
+		port        int
+	}
+	addresses := make(map[sliceKey][]string)
+	for _, ep := range endpoints {
+		key := sliceKey{addressType: discoveryv1.AddressTypeIPv4, port: ep.Port}
+		if ip := net.ParseIP(ep.Address); ip != nil && ip.To4() == nil {
+			key.addressType = discoveryv1.AddressTypeIPv6
+		}
+		addresses[key] = append(addresses[key], ep.Address)
+	}
+
+	var slices []*discoveryv1.EndpointSlice
+	for key, addrs := range addresses {
+		sort.Strings(addrs)
+		slice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-consul-%s-%d", svc.Name, strings.ToLower(string(key.addressType)), key.port),
+				Namespace: svc.Namespace,
+				Labels: map[string]string{
+					discoveryv1.LabelServiceName: svc.Name,
+					discoveryv1.LabelManagedBy:   endpointSliceManagedBy,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: "v1",
+						Kind:       "Service",
+						Name:       svc.Name,
+						UID:        svc.UID,
+					},
+				},
+			},
+			AddressType: key.addressType,
+		}
+		for _, addr := range addrs {
+			slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+				Addresses:  []string{addr},
+				Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true)},
+			})
+		}
+		if key.port > 0 {
+			protocol := apiv1.ProtocolTCP
+			slice.Ports = []discoveryv1.EndpointPort{
+				{
+					Name:     pointer.String(""),
+					Port:     pointer.Int32(int32(key.port)),
+					Protocol: &protocol,
+				},
+			}
+		}
+		slices = append(slices, slice)
+	}
+	sort.Slice(slices, func(i, j int) bool { return slices[i].Name < slices[j].Name })
+	return slices
+}
+
+// syncEndpointSlices creates, updates and deletes the EndpointSlices of a
+// service so that they match desired.
+func (s *K8SSink) syncEndpointSlices(name string, desired []*discoveryv1.EndpointSlice) error {
+	sliceClient := s.Client.DiscoveryV1().EndpointSlices(s.namespace())
+	existing, err := sliceClient.List(s.Ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s,%s=%s", discoveryv1.LabelServiceName, name, discoveryv1.LabelManagedBy, endpointSliceManagedBy),
+	})
+	if err != nil {
+		return err
+	}
+	existingByName := make(map[string]discoveryv1.EndpointSlice, len(existing.Items))
+	for _, slice := range existing.Items {
+		existingByName[slice.Name] = slice
+	}
+
+	for _, slice := range desired {
+		current, ok := existingByName[slice.Name]
+		delete(existingByName, slice.Name)
+		if !ok {
+			if _, err := sliceClient.Create(s.Ctx, slice, metav1.CreateOptions{}); err != nil {
+				return err
+			}
+			continue
+		}
+		if reflect.DeepEqual(current.Endpoints, slice.Endpoints) && reflect.DeepEqual(current.Ports, slice.Ports) {
+			continue
+		}
+		slice.ResourceVersion = current.ResourceVersion
+		if _, err := sliceClient.Update(s.Ctx, slice, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	for sliceName := range existingByName {
+		if err := sliceClient.Delete(s.Ctx, sliceName, metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/consul-k8s/control-plane/helper/coalesce"
 	"github.com/hashicorp/go-hclog"
 	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
@@ -49,6 +50,12 @@ type K8SSink struct {
 	Namespace string               // Namespace is the namespace to sync to
 	Log       hclog.Logger         // Logger
 
+	// SyncEndpointSlices, when true, creates headless Services with EndpointSlices
+	// listing the healthy Consul service instances instead of ExternalName
+	// Services pointing at Consul DNS. This lets clusters without Consul DNS
+	// forwarding reach synced services.
+	SyncEndpointSlices bool
+
 	// SyncPeriod is the duration to wait between registering or deregistering
 	// services in Kubernetes. This can be fairly short since no work will be
 	// done if there are no changes.
@@ -57,6 +64,12 @@ type K8SSink struct {
 	// Ctx is used to cancel the Sink.
 	Ctx context.Context
 
+	// Leading, if set, is closed once this replica is elected leader. Until
+	// then the sink keeps its view of Kubernetes and Consul services up to date
+	// but doesn't write to Kubernetes, so a standby replica can take over
+	// without rebuilding its state.
+	Leading <-chan struct{}
+
 	// lock gates concurrent access to all the maps.
 	lock sync.Mutex
 
@@ -83,6 +96,14 @@ type K8SSink struct {
 	// It's populated from Kubernetes data.
 	serviceMapConsul map[string]*apiv1.Service
 	triggerCh        chan struct{}
+
+	// sourceEndpoints holds the healthy instance addresses of Consul services
+	// keyed by Kube service name. It's only used with SyncEndpointSlices.
+	sourceEndpoints map[string][]Endpoint
+
+	// dirtyEndpoints holds the Kube service names whose EndpointSlices need to
+	// be reconciled on the next sync.
+	dirtyEndpoints map[string]struct{}
 }
 
 // SetServices implements Sink.
@@ -102,6 +123,11 @@ func (s *K8SSink) SetServices(svcs map[string]string) {
 	}
 
 	s.sourceServices = lowercasedSvcs
+	for name := range s.sourceEndpoints {
+		if _, ok := lowercasedSvcs[name]; !ok {
+			delete(s.sourceEndpoints, name)
+		}
+	}
 	s.trigger() // Any service change probably requires syncing
 }
 
@@ -156,6 +182,10 @@ func (s *K8SSink) Upsert(key string, raw interface{}) error {
 		}
 
 		s.serviceMapConsul[service.Name] = service
+		if s.SyncEndpointSlices {
+			// The EndpointSlices can only be created once the service exists.
+			s.markEndpointsDirty(service.Name)
+		}
 		s.trigger() // Always trigger sync
 	}
 
@@ -207,6 +237,15 @@ func (s *K8SSink) Run(ch <-chan struct{}) {
 	}
 	s.lock.Unlock()
 
+	if s.Leading != nil {
+		select {
+		case <-ch:
+			return
+		case <-s.Leading:
+			s.Log.Info("elected leader, starting to sync services")
+		}
+	}
+
 	for {
 		select {
 		case <-ch:
@@ -225,6 +264,10 @@ func (s *K8SSink) Run(ch <-chan struct{}) {
 
 		s.lock.Lock()
 		create, update, delete := s.crudList()
+		var slices map[string][]*discoveryv1.EndpointSlice
+		if s.SyncEndpointSlices {
+			slices = s.endpointSliceList()
+		}
 		s.lock.Unlock()
 		s.Log.Debug("sync triggered", "create", len(create), "update", len(update), "delete", len(delete))
 
@@ -248,6 +291,17 @@ func (s *K8SSink) Run(ch <-chan struct{}) {
 				s.Log.Warn("error creating service", "name", svc.Name, "error", err)
 			}
 		}
+
+		for name, desired := range slices {
+			if err := s.syncEndpointSlices(name, desired); err != nil {
+				s.Log.Warn("error syncing endpoint slices", "name", name, "error", err)
+				// Retry on the next sync.
+				s.lock.Lock()
+				s.markEndpointsDirty(name)
+				s.trigger()
+				s.lock.Unlock()
+			}
+		}
 	}
 }
 
@@ -261,15 +315,12 @@ func (s *K8SSink) crudList() ([]*apiv1.Service, []*apiv1.Service, []string) {
 		// If this is an already registered service, then update it
 		if s.serviceMapConsul != nil {
 			if svc, ok := s.serviceMapConsul[consulName]; ok {
-				if svc.Spec.ExternalName == consulDNS {
+				if s.serviceSpecMatches(svc, consulDNS) {
 					// Matching service, no update required.
 					continue
 				}
 
-				svc.Spec = apiv1.ServiceSpec{
-					Type:         apiv1.ServiceTypeExternalName,
-					ExternalName: consulDNS,
-				}
+				svc.Spec = s.serviceSpec(consulDNS)
 
 				update = append(update, svc)
 				continue
@@ -293,10 +344,7 @@ func (s *K8SSink) crudList() ([]*apiv1.Service, []*apiv1.Service, []string) {
 				},
 			},
 
-			Spec: apiv1.ServiceSpec{
-				Type:         apiv1.ServiceTypeExternalName,
-				ExternalName: consulDNS,
-			},
+			Spec: s.serviceSpec(consulDNS),
 		})
 	}
 
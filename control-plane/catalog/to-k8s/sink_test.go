@@ -6,12 +6,15 @@ package catalog
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul-k8s/control-plane/helper/controller"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/require"
 	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
@@ -25,6 +28,7 @@ func TestK8SSink_impl(t *testing.T) {
 	var _ controller.Resource = &K8SSink{}
 	var _ controller.Backgrounder = &K8SSink{}
 	var _ Sink = &K8SSink{}
+	var _ EndpointsSink = &K8SSink{}
 }
 
 // Test that basic service creation works.
@@ -65,6 +69,36 @@ func TestK8SSink_create(t *testing.T) {
 	require.True(found, "found service")
 }
 
+// Test that a standby sink doesn't create services until it's elected leader.
+func TestK8SSink_leading(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+
+	leading := make(chan struct{})
+	sink := &K8SSink{
+		Client:  client,
+		Log:     hclog.Default(),
+		Ctx:     context.Background(),
+		Leading: leading,
+	}
+	closer := controller.TestControllerRun(sink)
+	defer closer()
+
+	sink.SetServices(map[string]string{"web": "web.service.local."})
+
+	// Wait for longer than the sync quiet period.
+	time.Sleep(2 * K8SQuietPeriod)
+	list, err := client.CoreV1().Services(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, list.Items)
+
+	close(leading)
+	retry.Run(t, func(r *retry.R) {
+		_, err := client.CoreV1().Services(metav1.NamespaceDefault).Get(context.Background(), "web", metav1.GetOptions{})
+		require.NoError(r, err)
+	})
+}
+
 // Test that we lowercase service names.
 func TestK8SSink_createUppercase(t *testing.T) {
 	t.Parallel()
@@ -406,3 +440,91 @@ func testSink(t *testing.T, client kubernetes.Interface) (*K8SSink, func()) {
 	closer := controller.TestControllerRun(sink)
 	return sink, closer
 }
+
+// Test that EndpointSlices are created, updated and deleted with the
+// healthy instances of a service.
+func TestK8SSink_endpointSlices(t *testing.T) {
+	t.Parallel()
+	client := fake.NewSimpleClientset()
+
+	// Start the controller
+	sink := &K8SSink{
+		Client:             client,
+		Log:                hclog.Default(),
+		Ctx:                context.Background(),
+		SyncEndpointSlices: true,
+	}
+	closer := controller.TestControllerRun(sink)
+	defer closer()
+
+	sink.SetServices(map[string]string{"web": "web.service.local."})
+	sink.SetServiceEndpoints("web", []Endpoint{
+		{Address: "10.0.0.2", Port: 8080},
+		{Address: "10.0.0.1", Port: 8080},
+		{Address: "fd00::1", Port: 8080},
+	})
+
+	// Verify a headless service and its slices get registered
+	retry.Run(t, func(r *retry.R) {
+		svc, err := client.CoreV1().Services(metav1.NamespaceDefault).Get(context.Background(), "web", metav1.GetOptions{})
+		require.NoError(r, err)
+		require.Equal(r, apiv1.ServiceTypeClusterIP, svc.Spec.Type)
+		require.Equal(r, apiv1.ClusterIPNone, svc.Spec.ClusterIP)
+		require.Empty(r, svc.Spec.ExternalName)
+
+		list, err := client.DiscoveryV1().EndpointSlices(metav1.NamespaceDefault).List(context.Background(), metav1.ListOptions{})
+		require.NoError(r, err)
+		require.Len(r, list.Items, 2)
+
+		slices := make(map[string]discoveryv1.EndpointSlice)
+		for _, slice := range list.Items {
+			slices[slice.Name] = slice
+		}
+		v4, ok := slices["web-consul-ipv4-8080"]
+		require.True(r, ok)
+		require.Equal(r, discoveryv1.AddressTypeIPv4, v4.AddressType)
+		require.Equal(r, "web", v4.Labels[discoveryv1.LabelServiceName])
+		require.Equal(r, endpointSliceManagedBy, v4.Labels[discoveryv1.LabelManagedBy])
+		require.Len(r, v4.Endpoints, 2)
+		require.Equal(r, []string{"10.0.0.1"}, v4.Endpoints[0].Addresses)
+		require.Equal(r, []string{"10.0.0.2"}, v4.Endpoints[1].Addresses)
+		require.Equal(r, int32(8080), *v4.Ports[0].Port)
+
+		v6, ok := slices["web-consul-ipv6-8080"]
+		require.True(r, ok)
+		require.Equal(r, discoveryv1.AddressTypeIPv6, v6.AddressType)
+		require.Equal(r, []string{"fd00::1"}, v6.Endpoints[0].Addresses)
+	})
+
+	// Remove the IPv6 instance and change the IPv4 ones
+	sink.SetServiceEndpoints("web", []Endpoint{{Address: "10.0.0.3", Port: 8080}})
+	retry.Run(t, func(r *retry.R) {
+		list, err := client.DiscoveryV1().EndpointSlices(metav1.NamespaceDefault).List(context.Background(), metav1.ListOptions{})
+		require.NoError(r, err)
+		require.Len(r, list.Items, 1)
+		require.Equal(r, "web-consul-ipv4-8080", list.Items[0].Name)
+		require.Len(r, list.Items[0].Endpoints, 1)
+		require.Equal(r, []string{"10.0.0.3"}, list.Items[0].Endpoints[0].Addresses)
+	})
+}
+
+func TestEndpointsFromEntries(t *testing.T) {
+	entries := []*api.ServiceEntry{
+		{
+			Node:    &api.Node{Address: "10.0.0.1"},
+			Service: &api.AgentService{Address: "10.0.0.2", Port: 8080},
+		},
+		{
+			Node:    &api.Node{Address: "10.0.0.3"},
+			Service: &api.AgentService{Port: 9090},
+		},
+		{
+			Node:    &api.Node{Address: "10.0.0.4"},
+			Service: &api.AgentService{Address: "web.example.com", Port: 8080},
+		},
+	}
+	require.Equal(t, []Endpoint{
+		{Address: "10.0.0.2", Port: 8080},
+		{Address: "10.0.0.3", Port: 9090},
+	}, endpointsFromEntries(entries))
+}
@@ -6,6 +6,7 @@ package catalog
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff"
@@ -26,6 +27,15 @@ type Source struct {
 	Prefix              string       // Prefix is a prefix to prepend to services
 	Log                 hclog.Logger // Logger
 	ConsulK8STag        string       // The tag value for services registered
+
+	// SyncEndpoints, when true, watches the healthy instances of every synced
+	// service and passes them to the Sink, which must implement EndpointsSink.
+	SyncEndpoints bool
+
+	// watchers holds the cancel funcs of the health watchers keyed by
+	// Consul service name.
+	watchers     map[string]context.CancelFunc
+	watchersLock sync.Mutex
 }
 
 // Run is the long-running runloop for watching Consul services and
@@ -53,6 +63,7 @@ func (s *Source) Run(ctx context.Context) {
 
 		// If the context is ended, then we end
 		if ctx.Err() != nil {
+			s.stopWatchers()
 			return
 		}
 
@@ -67,6 +78,7 @@ func (s *Source) Run(ctx context.Context) {
 
 		// Setup the services
 		services := make(map[string]string, len(serviceMap))
+		consulNames := make(map[string]string, len(serviceMap))
 		for name, tags := range serviceMap {
 			// We ignore services that are synced from k8s so we can avoid
 			// circular syncing. Realistically this shouldn't happen since
@@ -82,10 +94,97 @@ func (s *Source) Run(ctx context.Context) {
 
 			if !k8s {
 				services[s.Prefix+name] = fmt.Sprintf("%s.service.%s", name, s.Domain)
+				consulNames[name] = s.Prefix + name
 			}
 		}
 		s.Log.Info("received services from Consul", "count", len(services))
 
 		s.Sink.SetServices(services)
+
+		if sink, ok := s.Sink.(EndpointsSink); ok && s.SyncEndpoints {
+			s.updateWatchers(ctx, sink, consulNames)
+		}
+	}
+}
+
+// updateWatchers starts a health watcher for every new service in
+// consulNames, which maps Consul service names to Kube service names, and
+// stops the watchers of services that no longer exist.
+func (s *Source) updateWatchers(ctx context.Context, sink EndpointsSink, consulNames map[string]string) {
+	s.watchersLock.Lock()
+	defer s.watchersLock.Unlock()
+
+	if s.watchers == nil {
+		s.watchers = make(map[string]context.CancelFunc)
+	}
+	for name, cancel := range s.watchers {
+		if _, ok := consulNames[name]; !ok {
+			cancel()
+			delete(s.watchers, name)
+		}
+	}
+	for name, k8sName := range consulNames {
+		if _, ok := s.watchers[name]; ok {
+			continue
+		}
+		watchCtx, cancel := context.WithCancel(ctx)
+		s.watchers[name] = cancel
+		go s.watchEndpoints(watchCtx, s.ConsulClientConfig, s.ConsulServerConnMgr, sink, name, k8sName)
+	}
+}
+
+// stopWatchers stops all health watchers.
+func (s *Source) stopWatchers() {
+	s.watchersLock.Lock()
+	defer s.watchersLock.Unlock()
+
+	for name, cancel := range s.watchers {
+		cancel()
+		delete(s.watchers, name)
+	}
+}
+
+// watchEndpoints is the long-running runloop for watching the healthy
+// instances of a Consul service and updating the Sink.
+//
+// A new client is created on every iteration, like in Run, so that the
+// watcher follows server address changes and token rotation.
+func (s *Source) watchEndpoints(ctx context.Context, clientConfig *consul.Config, connMgr consul.ServerConnectionManager, sink EndpointsSink, name, k8sName string) {
+	opts := (&api.QueryOptions{
+		AllowStale: true,
+		WaitIndex:  1,
+		WaitTime:   1 * time.Minute,
+	}).WithContext(ctx)
+	for {
+		consulClient, err := consul.NewClientFromConnMgr(clientConfig, connMgr)
+		if err != nil {
+			s.Log.Error("failed to create Consul API client", "name", name, "err", err)
+			return
+		}
+
+		var entries []*api.ServiceEntry
+		var meta *api.QueryMeta
+		err = backoff.Retry(func() error {
+			entries, meta, err = consulClient.Health().Service(name, "", true, opts)
+			return err
+		}, backoff.WithContext(backoff.NewExponentialBackOff(), ctx))
+
+		// If the context is ended, then we end
+		if ctx.Err() != nil {
+			return
+		}
+
+		// If there was an error, handle that
+		if err != nil {
+			s.Log.Warn("error querying service health, will retry", "name", name, "err", err)
+			continue
+		}
+
+		// Update our blocking index
+		opts.WaitIndex = meta.LastIndex
+
+		endpoints := endpointsFromEntries(entries)
+		s.Log.Debug("received service endpoints from Consul", "name", name, "count", len(endpoints))
+		sink.SetServiceEndpoints(k8sName, endpoints)
 	}
 }
@@ -228,6 +228,64 @@ func TestSource_deleteServiceInstance(t *testing.T) {
 	})
 }
 
+// Test that the source passes the healthy instances of services to the sink
+// and stops watching services that are deleted.
+func TestSource_syncEndpoints(t *testing.T) {
+	t.Parallel()
+
+	// Set up server, client
+	testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+	client := testClient.APIClient
+
+	// Create services before the source is running
+	_, err := client.Catalog().Register(testEndpointRegistration("hostA", "10.0.0.1", api.HealthPassing), nil)
+	require.NoError(t, err)
+	_, err = client.Catalog().Register(testEndpointRegistration("hostB", "10.0.0.2", api.HealthPassing), nil)
+	require.NoError(t, err)
+
+	s, sink, closer := testSourceWithConfig(testClient.Cfg, testClient.Watcher, func(s *Source) {
+		s.SyncEndpoints = true
+	})
+	defer closer()
+
+	retry.Run(t, func(r *retry.R) {
+		sink.Lock()
+		defer sink.Unlock()
+		require.ElementsMatch(r, []Endpoint{
+			{Address: "10.0.0.1", Port: 8080},
+			{Address: "10.0.0.2", Port: 8080},
+		}, sink.Endpoints["svcA"])
+	})
+
+	// Fail the health check of one instance
+	_, err = client.Catalog().Register(testEndpointRegistration("hostB", "10.0.0.2", api.HealthCritical), nil)
+	require.NoError(t, err)
+
+	retry.Run(t, func(r *retry.R) {
+		sink.Lock()
+		defer sink.Unlock()
+		require.Equal(r, []Endpoint{{Address: "10.0.0.1", Port: 8080}}, sink.Endpoints["svcA"])
+	})
+
+	// Delete the service
+	for _, node := range []string{"hostA", "hostB"} {
+		_, err = client.Catalog().Deregister(&api.CatalogDeregistration{
+			Node: node, ServiceID: "svcA"}, nil)
+		require.NoError(t, err)
+	}
+
+	retry.Run(t, func(r *retry.R) {
+		s.watchersLock.Lock()
+		defer s.watchersLock.Unlock()
+		if _, ok := s.watchers["svcA"]; ok {
+			r.Fatal("watcher not stopped")
+		}
+		if _, ok := s.watchers["consul"]; !ok {
+			r.Fatal("watcher not found")
+		}
+	})
+}
+
 // testRegistration creates a Consul test registration.
 func testRegistration(node, service string, tags []string) *api.CatalogRegistration {
 	return &api.CatalogRegistration{
@@ -240,6 +298,28 @@ func testRegistration(node, service string, tags []string) *api.CatalogRegistrat
 	}
 }
 
+// testEndpointRegistration creates a Consul test registration of an instance
+// of svcA with a health check in the given state.
+func testEndpointRegistration(node, address, status string) *api.CatalogRegistration {
+	return &api.CatalogRegistration{
+		Node:    node,
+		Address: "127.0.0.1",
+		Service: &api.AgentService{
+			ID:      "svcA",
+			Service: "svcA",
+			Address: address,
+			Port:    8080,
+		},
+		Check: &api.AgentCheck{
+			Node:      node,
+			CheckID:   "svcA-check",
+			Name:      "svcA-check",
+			Status:    status,
+			ServiceID: "svcA",
+		},
+	}
+}
+
 // testSource creates a Source and Sink for testing.
 func testSource(clientCfg *consul.Config, connMgr consul.ServerConnectionManager) (*Source, *TestSink, func()) {
 	return testSourceWithConfig(clientCfg, connMgr, func(source *Source) {})
@@ -7,11 +7,12 @@ import (
 	"sync"
 )
 
-// TestSink implements Sink for tests by just storing the services.
-// Reading/writing the services should be done only while the lock is held.
+// TestSink implements EndpointsSink for tests by just storing the services
+// and endpoints. Reading/writing them should be done only while the lock is held.
 type TestSink struct {
 	sync.Mutex
-	Services map[string]string
+	Services  map[string]string
+	Endpoints map[string][]Endpoint
 }
 
 func (s *TestSink) SetServices(raw map[string]string) {
@@ -19,3 +20,12 @@ func (s *TestSink) SetServices(raw map[string]string) {
 	defer s.Unlock()
 	s.Services = raw
 }
+
+func (s *TestSink) SetServiceEndpoints(name string, endpoints []Endpoint) {
+	s.Lock()
+	defer s.Unlock()
+	if s.Endpoints == nil {
+		s.Endpoints = make(map[string][]Endpoint)
+	}
+	s.Endpoints[name] = endpoints
+}
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package ip6tables applies the transparent proxy rules generated by the Consul SDK to IPv6 traffic. It is shared
+// by the CNI plugin and connect-init.
+package ip6tables
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/consul/sdk/iptables"
+)
+
+// Executor implements iptables.Provider by running the rules generated by iptables.Setup with ip6tables. The
+// Consul SDK only generates IPv4 rules, so IPv4 specific arguments are rewritten.
+type Executor struct {
+	// NetNS is the network namespace the rules are applied in. If it is empty, the rules are applied in the
+	// current network namespace.
+	NetNS string
+
+	commands []*exec.Cmd
+}
+
+func (e *Executor) AddRule(_ string, args ...string) {
+	args = RuleArgs(args)
+	if e.NetNS != "" {
+		nsenterArgs := append([]string{fmt.Sprintf("--net=%s", e.NetNS), "--", "ip6tables"}, args...)
+		e.commands = append(e.commands, exec.Command("nsenter", nsenterArgs...))
+	} else {
+		e.commands = append(e.commands, exec.Command("ip6tables", args...))
+	}
+}
+
+func (e *Executor) ApplyRules() error {
+	if _, err := exec.LookPath("ip6tables"); err != nil {
+		return err
+	}
+
+	for _, cmd := range e.commands {
+		var cmdOutput bytes.Buffer
+		cmd.Stdout = &cmdOutput
+		cmd.Stderr = &cmdOutput
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to run command: %s, err: %v, output: %s", cmd.String(), err, cmdOutput.String())
+		}
+	}
+	return nil
+}
+
+func (e *Executor) Rules() []string {
+	var rules []string
+	for _, cmd := range e.commands {
+		rules = append(rules, cmd.String())
+	}
+	return rules
+}
+
+// RuleArgs rewrites the IPv4 loopback range in an iptables rule to the IPv6 loopback address.
+func RuleArgs(args []string) []string {
+	result := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "127.0.0.1/32" {
+			arg = "::1/128"
+		}
+		result[i] = arg
+	}
+	return result
+}
+
+// SplitConfigByFamily returns copies of cfg for the iptables and ip6tables rules. Excluded outbound CIDRs are
+// split by address family, so IPv6 traffic is redirected to the same inbound and outbound proxy ports as IPv4
+// traffic unless its destination is excluded. DNS redirection is only configured for IPv4 because
+// consul-dataplane serves DNS on the IPv4 loopback address.
+func SplitConfigByFamily(cfg iptables.Config) (iptables.Config, iptables.Config) {
+	v4, v6 := cfg, cfg
+	v4.ExcludeOutboundCIDRs, v6.ExcludeOutboundCIDRs = nil, nil
+	for _, cidr := range cfg.ExcludeOutboundCIDRs {
+		if IsIPv6(cidr) {
+			v6.ExcludeOutboundCIDRs = append(v6.ExcludeOutboundCIDRs, cidr)
+		} else {
+			v4.ExcludeOutboundCIDRs = append(v4.ExcludeOutboundCIDRs, cidr)
+		}
+	}
+	v6.ConsulDNSIP = ""
+	v6.ConsulDNSPort = 0
+	return v4, v6
+}
+
+// IsIPv6 returns true if s is an IPv6 address or CIDR.
+func IsIPv6(s string) bool {
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		var err error
+		ip, _, err = net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			return false
+		}
+	}
+	return ip.To4() == nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package ip6tables
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/sdk/iptables"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitConfigByFamily(t *testing.T) {
+	cfg := iptables.Config{
+		ConsulDNSIP:          "127.0.0.1",
+		ConsulDNSPort:        8600,
+		ProxyUserID:          "5995",
+		ProxyInboundPort:     20000,
+		ProxyOutboundPort:    15001,
+		ExcludeOutboundCIDRs: []string{"10.0.0.0/8", "fd00::/8", "2001:db8::1", "1.1.1.1"},
+	}
+
+	v4, v6 := SplitConfigByFamily(cfg)
+	require.Equal(t, []string{"10.0.0.0/8", "1.1.1.1"}, v4.ExcludeOutboundCIDRs)
+	require.Equal(t, "127.0.0.1", v4.ConsulDNSIP)
+	require.Equal(t, 8600, v4.ConsulDNSPort)
+
+	require.Equal(t, []string{"fd00::/8", "2001:db8::1"}, v6.ExcludeOutboundCIDRs)
+	require.Empty(t, v6.ConsulDNSIP)
+	require.Zero(t, v6.ConsulDNSPort)
+	require.Equal(t, "5995", v6.ProxyUserID)
+	require.Equal(t, 20000, v6.ProxyInboundPort)
+	require.Equal(t, 15001, v6.ProxyOutboundPort)
+
+	// The original config must not be modified.
+	require.Len(t, cfg.ExcludeOutboundCIDRs, 4)
+}
+
+func TestRuleArgs(t *testing.T) {
+	require.Equal(t,
+		[]string{"-t", "nat", "-A", "CONSUL_PROXY_OUTPUT", "-d", "::1/128", "-j", "RETURN"},
+		RuleArgs([]string{"-t", "nat", "-A", "CONSUL_PROXY_OUTPUT", "-d", "127.0.0.1/32", "-j", "RETURN"}))
+}
+
+func TestIPv6Rules(t *testing.T) {
+	provider := &recorder{}
+	_, v6 := SplitConfigByFamily(iptables.Config{
+		ConsulDNSIP:          "127.0.0.1",
+		ProxyUserID:          "5995",
+		ProxyInboundPort:     20000,
+		ProxyOutboundPort:    15001,
+		ExcludeOutboundCIDRs: []string{"10.0.0.0/8", "fd00::/8"},
+	})
+	v6.IptablesProvider = provider
+	require.NoError(t, iptables.Setup(v6))
+
+	require.Contains(t, provider.rules, "-t nat -A CONSUL_PROXY_OUTPUT -d ::1/128 -j RETURN")
+	require.Contains(t, provider.rules, "-t nat -I CONSUL_PROXY_OUTPUT -d fd00::/8 -j RETURN")
+	// Outbound IPv6 traffic is redirected to the proxy like IPv4 traffic.
+	require.Contains(t, provider.rules, "-t nat -A CONSUL_PROXY_REDIRECT -p tcp -j REDIRECT --to-port 15001")
+	for _, rule := range provider.rules {
+		require.NotContains(t, rule, "127.0.0.1")
+		require.NotContains(t, rule, "10.0.0.0/8")
+		require.NotContains(t, rule, "::/0")
+		require.NotContains(t, rule, "--dport 53")
+	}
+}
+
+func TestIsIPv6(t *testing.T) {
+	require.True(t, IsIPv6("fd00::/8"))
+	require.True(t, IsIPv6(" 2001:db8::1 "))
+	require.False(t, IsIPv6("10.0.0.0/8"))
+	require.False(t, IsIPv6("1.1.1.1"))
+	require.False(t, IsIPv6("not-an-ip"))
+}
+
+// recorder records rules the same way as Executor rewrites them.
+type recorder struct {
+	rules []string
+}
+
+func (r *recorder) AddRule(_ string, args ...string) {
+	r.rules = append(r.rules, strings.Join(RuleArgs(args), " "))
+}
+
+func (r *recorder) ApplyRules() error { return nil }
+
+func (r *recorder) Rules() []string { return r.rules }
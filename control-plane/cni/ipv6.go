@@ -0,0 +1,18 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	current "github.com/containernetworking/cni/pkg/types/100"
+)
+
+// hasIPv6Address returns true if a previous plugin assigned an IPv6 address to the pod.
+func hasIPv6Address(result *current.Result) bool {
+	for _, ip := range result.IPs {
+		if ip.Address.IP.To4() == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package main
+
+import (
+	"net"
+	"testing"
+
+	current "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasIPv6Address(t *testing.T) {
+	ipConfig := func(cidr string) *current.IPConfig {
+		ip, ipNet, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		ipNet.IP = ip
+		return &current.IPConfig{Address: *ipNet}
+	}
+
+	require.False(t, hasIPv6Address(&current.Result{}))
+	require.False(t, hasIPv6Address(&current.Result{IPs: []*current.IPConfig{ipConfig("10.0.0.2/24")}}))
+	require.True(t, hasIPv6Address(&current.Result{IPs: []*current.IPConfig{ipConfig("10.0.0.2/24"), ipConfig("fd00::2/64")}}))
+	require.True(t, hasIPv6Address(&current.Result{IPs: []*current.IPConfig{ipConfig("fd00::2/64")}}))
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/cni/pkg/version"
+	"github.com/hashicorp/consul-k8s/control-plane/cni/ip6tables"
 	"github.com/hashicorp/consul/sdk/iptables"
 	"github.com/hashicorp/go-hclog"
 	corev1 "k8s.io/api/core/v1"
@@ -48,6 +49,10 @@ const (
 	// annotationRedirectTraffic stores iptables.Config information so that the CNI plugin can use it to apply
 	// iptables rules.
 	annotationRedirectTraffic = "consul.hashicorp.com/redirect-traffic-config"
+
+	// annotationTransparentProxyIPv6 is set by the webhook to "true" when IPv6 traffic should be
+	// redirected as well.
+	annotationTransparentProxyIPv6 = "consul.hashicorp.com/transparent-proxy-ipv6"
 )
 
 type Command struct {
@@ -55,6 +60,8 @@ type Command struct {
 	client kubernetes.Interface
 	// iptablesProvider is the Provider that will apply iptables rules. Used for testing.
 	iptablesProvider iptables.Provider
+	// ip6tablesProvider is the Provider that will apply ip6tables rules. Used for testing.
+	ip6tablesProvider iptables.Provider
 }
 
 type CNIArgs struct {
@@ -211,11 +218,24 @@ func (c *Command) cmdAdd(args *skel.CmdArgs) error {
 	}
 
 	// Apply the iptables rules.
-	err = iptables.Setup(iptablesCfg)
+	ipv4Cfg, ipv6Cfg := ip6tables.SplitConfigByFamily(iptablesCfg)
+	err = iptables.Setup(ipv4Cfg)
 	if err != nil {
 		return fmt.Errorf("could not apply iptables setup: %v", err)
 	}
 
+	// In IPv6 and dual-stack clusters, IPv6 traffic is redirected as well when enabled.
+	if pod.Annotations[annotationTransparentProxyIPv6] == "true" && hasIPv6Address(result) {
+		ipv6Cfg.IptablesProvider = c.ip6tablesProvider
+		if ipv6Cfg.IptablesProvider == nil {
+			ipv6Cfg.IptablesProvider = &ip6tables.Executor{NetNS: args.Netns}
+		}
+		err = iptables.Setup(ipv6Cfg)
+		if err != nil {
+			return fmt.Errorf("could not apply ip6tables setup: %v", err)
+		}
+	}
+
 	// We do not throw an error here because kubernetes will often throw a benign error where the pod has been
 	// updated in between the get and update of the annotation. Eventually kubernetes will update the annotation
 	ok = c.updateTransparentProxyStatusAnnotation(podName, podNamespace, complete)
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	cmdACLInit "github.com/hashicorp/consul-k8s/control-plane/subcommand/acl-init"
+	cmdConfigureCoreDNS "github.com/hashicorp/consul-k8s/control-plane/subcommand/configure-coredns"
 	cmdConnectInit "github.com/hashicorp/consul-k8s/control-plane/subcommand/connect-init"
 	cmdConsulLogout "github.com/hashicorp/consul-k8s/control-plane/subcommand/consul-logout"
 	cmdCreateFederationSecret "github.com/hashicorp/consul-k8s/control-plane/subcommand/create-federation-secret"
@@ -16,12 +17,15 @@ import (
 	cmdGatewayResources "github.com/hashicorp/consul-k8s/control-plane/subcommand/gateway-resources"
 	cmdGetConsulClientCA "github.com/hashicorp/consul-k8s/control-plane/subcommand/get-consul-client-ca"
 	cmdGossipEncryptionAutogenerate "github.com/hashicorp/consul-k8s/control-plane/subcommand/gossip-encryption-autogenerate"
+	cmdGossipEncryptionRotate "github.com/hashicorp/consul-k8s/control-plane/subcommand/gossip-encryption-rotate"
+	cmdGracefulShutdown "github.com/hashicorp/consul-k8s/control-plane/subcommand/graceful-shutdown"
 	cmdInjectConnect "github.com/hashicorp/consul-k8s/control-plane/subcommand/inject-connect"
 	cmdInstallCNI "github.com/hashicorp/consul-k8s/control-plane/subcommand/install-cni"
 	cmdPartitionInit "github.com/hashicorp/consul-k8s/control-plane/subcommand/partition-init"
 	cmdServerACLInit "github.com/hashicorp/consul-k8s/control-plane/subcommand/server-acl-init"
 	cmdSyncCatalog "github.com/hashicorp/consul-k8s/control-plane/subcommand/sync-catalog"
 	cmdTLSInit "github.com/hashicorp/consul-k8s/control-plane/subcommand/tls-init"
+	cmdUninstallCleanup "github.com/hashicorp/consul-k8s/control-plane/subcommand/uninstall-cleanup"
 	cmdVersion "github.com/hashicorp/consul-k8s/control-plane/subcommand/version"
 	webhookCertManager "github.com/hashicorp/consul-k8s/control-plane/subcommand/webhook-cert-manager"
 	"github.com/hashicorp/consul-k8s/control-plane/version"
@@ -51,10 +55,18 @@ func init() {
 			return &cmdConsulLogout.Command{UI: ui}, nil
 		},
 
+		"graceful-shutdown": func() (cli.Command, error) {
+			return &cmdGracefulShutdown.Command{UI: ui}, nil
+		},
+
 		"gateway-cleanup": func() (cli.Command, error) {
 			return &cmdGatewayCleanup.Command{UI: ui}, nil
 		},
 
+		"uninstall-cleanup": func() (cli.Command, error) {
+			return &cmdUninstallCleanup.Command{UI: ui}, nil
+		},
+
 		"gateway-resources": func() (cli.Command, error) {
 			return &cmdGatewayResources.Command{UI: ui}, nil
 		},
@@ -98,12 +110,18 @@ func init() {
 		"gossip-encryption-autogenerate": func() (cli.Command, error) {
 			return &cmdGossipEncryptionAutogenerate.Command{UI: ui}, nil
 		},
+		"gossip-encryption-rotate": func() (cli.Command, error) {
+			return &cmdGossipEncryptionRotate.Command{UI: ui}, nil
+		},
 		"install-cni": func() (cli.Command, error) {
 			return &cmdInstallCNI.Command{UI: ui}, nil
 		},
 		"fetch-server-region": func() (cli.Command, error) {
 			return &cmdFetchServerRegion.Command{UI: ui}, nil
 		},
+		"configure-coredns": func() (cli.Command, error) {
+			return &cmdConfigureCoreDNS.Command{UI: ui}, nil
+		},
 	}
 }
 
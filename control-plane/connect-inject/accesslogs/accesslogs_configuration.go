@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package accesslogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	typeStdout = "stdout"
+	typeStderr = "stderr"
+	typeFile   = "file"
+)
+
+// Config represents configuration common to connect-inject components related to Envoy access logging.
+type Config struct {
+	DefaultEnabled    bool
+	DefaultType       string
+	DefaultPath       string
+	DefaultJSONFormat string
+	DefaultTextFormat string
+}
+
+// Enabled returns whether access logging is enabled for the pod's sidecar proxy, either via the default value
+// or if it's been overridden via the annotation.
+func (c Config) Enabled(pod corev1.Pod) (bool, error) {
+	enabled := c.DefaultEnabled
+	if raw, ok := pod.Annotations[constants.AnnotationEnvoyAccessLogsEnabled]; ok && raw != "" {
+		val, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, fmt.Errorf("%s annotation value of %s was invalid: %s", constants.AnnotationEnvoyAccessLogsEnabled, raw, err)
+		}
+		enabled = val
+	}
+	return enabled, nil
+}
+
+// AccessLogs returns the access logs configuration that should be set on the pod's proxy service registration.
+// It returns nil if access logging is not enabled for the pod so that proxy-defaults continue to apply.
+// Per-pod annotations take precedence over the defaults. Since the JSON and text formats are mutually exclusive,
+// setting either of them via annotation replaces both defaults.
+func (c Config) AccessLogs(pod corev1.Pod) (*api.AccessLogsConfig, error) {
+	enabled, err := c.Enabled(pod)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	cfg := &api.AccessLogsConfig{
+		Enabled:    true,
+		Type:       api.LogSinkType(c.DefaultType),
+		JSONFormat: c.DefaultJSONFormat,
+		TextFormat: c.DefaultTextFormat,
+	}
+	if raw, ok := pod.Annotations[constants.AnnotationEnvoyAccessLogsType]; ok && raw != "" {
+		cfg.Type = api.LogSinkType(raw)
+	}
+	// The default path only applies to file type access logs so that overriding the type
+	// of a pod with the annotation doesn't require clearing the path too.
+	if cfg.Type == typeFile {
+		cfg.Path = c.DefaultPath
+	}
+	if raw, ok := pod.Annotations[constants.AnnotationEnvoyAccessLogsPath]; ok && raw != "" {
+		cfg.Path = raw
+	}
+	jsonFormat, hasJSON := pod.Annotations[constants.AnnotationEnvoyAccessLogsJSONFormat]
+	textFormat, hasText := pod.Annotations[constants.AnnotationEnvoyAccessLogsTextFormat]
+	if hasJSON || hasText {
+		cfg.JSONFormat = jsonFormat
+		cfg.TextFormat = textFormat
+	}
+
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate mirrors the validation performed on the accessLogs field of the ProxyDefaults CRD.
+func validate(cfg *api.AccessLogsConfig) error {
+	switch cfg.Type {
+	case "", typeStdout, typeStderr:
+		if cfg.Path != "" {
+			return fmt.Errorf("%s is only valid for file type access logs", constants.AnnotationEnvoyAccessLogsPath)
+		}
+	case typeFile:
+		if cfg.Path == "" {
+			return fmt.Errorf("%s must be specified when using file type access logs", constants.AnnotationEnvoyAccessLogsPath)
+		}
+	default:
+		return fmt.Errorf("%s annotation value of %s was invalid: must be one of %q, %q or %q",
+			constants.AnnotationEnvoyAccessLogsType, cfg.Type, typeStdout, typeStderr, typeFile)
+	}
+
+	if cfg.JSONFormat != "" && cfg.TextFormat != "" {
+		return fmt.Errorf("cannot specify both %s and %s", constants.AnnotationEnvoyAccessLogsJSONFormat, constants.AnnotationEnvoyAccessLogsTextFormat)
+	}
+
+	if cfg.JSONFormat != "" {
+		msg := json.RawMessage{}
+		if err := json.Unmarshal([]byte(cfg.JSONFormat), &msg); err != nil {
+			return fmt.Errorf("%s annotation value was not valid JSON: %s", constants.AnnotationEnvoyAccessLogsJSONFormat, err)
+		}
+	}
+	return nil
+}
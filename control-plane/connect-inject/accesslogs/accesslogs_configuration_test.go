@@ -0,0 +1,204 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package accesslogs
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAccessLogsConfig_AccessLogs(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Pod              func(*corev1.Pod) *corev1.Pod
+		AccessLogsConfig Config
+		Expected         *api.AccessLogsConfig
+		Err              string
+	}{
+		{
+			Name: "Access logs disabled by default",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			AccessLogsConfig: Config{},
+			Expected:         nil,
+		},
+		{
+			Name: "Access logs enabled via meshWebhook",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			AccessLogsConfig: Config{
+				DefaultEnabled:    true,
+				DefaultType:       "stdout",
+				DefaultTextFormat: "%START_TIME% %RESPONSE_CODE%",
+			},
+			Expected: &api.AccessLogsConfig{
+				Enabled:    true,
+				Type:       api.StdOutLogSinkType,
+				TextFormat: "%START_TIME% %RESPONSE_CODE%",
+			},
+		},
+		{
+			Name: "Access logs disabled via annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsEnabled] = "false"
+				return pod
+			},
+			AccessLogsConfig: Config{
+				DefaultEnabled: true,
+				DefaultType:    "stdout",
+			},
+			Expected: nil,
+		},
+		{
+			Name: "Access logs enabled and overridden via annotations",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsEnabled] = "true"
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsType] = "file"
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsPath] = "/var/log/envoy.log"
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsJSONFormat] = `{"code":"%RESPONSE_CODE%"}`
+				return pod
+			},
+			AccessLogsConfig: Config{
+				DefaultType:       "stdout",
+				DefaultTextFormat: "%START_TIME%",
+			},
+			Expected: &api.AccessLogsConfig{
+				Enabled:    true,
+				Type:       api.FileLogSinkType,
+				Path:       "/var/log/envoy.log",
+				JSONFormat: `{"code":"%RESPONSE_CODE%"}`,
+			},
+		},
+		{
+			Name: "Default path with file type",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			AccessLogsConfig: Config{
+				DefaultEnabled: true,
+				DefaultType:    "file",
+				DefaultPath:    "/var/log/envoy.log",
+			},
+			Expected: &api.AccessLogsConfig{
+				Enabled: true,
+				Type:    api.FileLogSinkType,
+				Path:    "/var/log/envoy.log",
+			},
+		},
+		{
+			Name: "Default path is not set when type is overridden to stdout",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsType] = "stdout"
+				return pod
+			},
+			AccessLogsConfig: Config{
+				DefaultEnabled: true,
+				DefaultType:    "file",
+				DefaultPath:    "/var/log/envoy.log",
+			},
+			Expected: &api.AccessLogsConfig{
+				Enabled: true,
+				Type:    api.StdOutLogSinkType,
+			},
+		},
+		{
+			Name: "Invalid enabled annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsEnabled] = "not-a-bool"
+				return pod
+			},
+			AccessLogsConfig: Config{},
+			Err:              "consul.hashicorp.com/envoy-access-logs-enabled annotation value of not-a-bool was invalid: strconv.ParseBool: parsing \"not-a-bool\": invalid syntax",
+		},
+		{
+			Name: "Invalid type annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsType] = "syslog"
+				return pod
+			},
+			AccessLogsConfig: Config{DefaultEnabled: true},
+			Err:              "consul.hashicorp.com/envoy-access-logs-type annotation value of syslog was invalid: must be one of \"stdout\", \"stderr\" or \"file\"",
+		},
+		{
+			Name: "File type without path",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsType] = "file"
+				return pod
+			},
+			AccessLogsConfig: Config{DefaultEnabled: true},
+			Err:              "consul.hashicorp.com/envoy-access-logs-path must be specified when using file type access logs",
+		},
+		{
+			Name: "Path without file type",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsPath] = "/var/log/envoy.log"
+				return pod
+			},
+			AccessLogsConfig: Config{DefaultEnabled: true, DefaultType: "stdout"},
+			Err:              "consul.hashicorp.com/envoy-access-logs-path is only valid for file type access logs",
+		},
+		{
+			Name: "Both JSON and text format",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsJSONFormat] = `{"code":"%RESPONSE_CODE%"}`
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsTextFormat] = "%START_TIME%"
+				return pod
+			},
+			AccessLogsConfig: Config{DefaultEnabled: true},
+			Err:              "cannot specify both consul.hashicorp.com/envoy-access-logs-json-format and consul.hashicorp.com/envoy-access-logs-text-format",
+		},
+		{
+			Name: "Invalid JSON format",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyAccessLogsJSONFormat] = "{not-json"
+				return pod
+			},
+			AccessLogsConfig: Config{DefaultEnabled: true},
+			Err:              "consul.hashicorp.com/envoy-access-logs-json-format annotation value was not valid JSON: invalid character 'n' looking for beginning of object key string",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+
+			actual, err := tt.AccessLogsConfig.AccessLogs(*tt.Pod(minimal()))
+
+			if tt.Err == "" {
+				require.NoError(err)
+				require.Equal(tt.Expected, actual)
+			} else {
+				require.EqualError(err, tt.Err)
+			}
+		})
+	}
+}
+
+func minimal() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespaces.DefaultNamespace,
+			Name:      "minimal",
+			Annotations: map[string]string{
+				constants.AnnotationService: "foo",
+			},
+		},
+
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "web",
+				},
+			},
+		},
+	}
+}
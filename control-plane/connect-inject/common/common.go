@@ -5,10 +5,12 @@ package common
 
 import (
 	"fmt"
+	"hash/fnv"
 	"strconv"
 	"strings"
 
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul/api"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -90,6 +92,51 @@ func ShouldOverwriteProbes(pod corev1.Pod, globalOverwrite bool) (bool, error) {
 	return globalOverwrite, nil
 }
 
-func ConsulNodeNameFromK8sNode(nodeName string) string {
+// TransparentProxyIPv6Enabled returns true if IPv6 traffic should be redirected to the proxy for this pod.
+// It returns an error when the annotation value cannot be parsed by strconv.ParseBool.
+func TransparentProxyIPv6Enabled(pod corev1.Pod, globalEnabled bool) (bool, error) {
+	if raw, ok := pod.Annotations[constants.AnnotationTransparentProxyIPv6]; ok {
+		return strconv.ParseBool(raw)
+	}
+
+	return globalEnabled, nil
+}
+
+// MeshGatewayModeFromPod returns the mesh gateway mode set by the mesh-gateway-mode
+// annotation of the pod. It returns the default mode if the annotation isn't set, and an
+// error if it isn't a valid mode.
+func MeshGatewayModeFromPod(pod corev1.Pod) (api.MeshGatewayMode, error) {
+	raw, ok := pod.Annotations[constants.AnnotationMeshGatewayMode]
+	if !ok {
+		return api.MeshGatewayModeDefault, nil
+	}
+	switch mode := api.MeshGatewayMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case api.MeshGatewayModeLocal, api.MeshGatewayModeRemote, api.MeshGatewayModeNone:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%s annotation value of %q must be one of %q, %q or %q", constants.AnnotationMeshGatewayMode,
+			raw, api.MeshGatewayModeLocal, api.MeshGatewayModeRemote, api.MeshGatewayModeNone)
+	}
+}
+
+// ConsulNodeNameFromK8sNode returns the name of the virtual Consul node that the services of the pods
+// on a Kubernetes node are registered on. If clusterName is set, it is added to the name so that the
+// nodes of Kubernetes clusters that share a Consul datacenter don't collide.
+func ConsulNodeNameFromK8sNode(nodeName, clusterName string) string {
+	if clusterName != "" {
+		return fmt.Sprintf("%s-%s-virtual", nodeName, clusterName)
+	}
 	return fmt.Sprintf("%s-virtual", nodeName)
 }
+
+// ShardForNamespace returns the shard in [0, shardCount) that owns the given Kubernetes namespace.
+// The mapping only depends on the namespace name so that every connect-injector replica agrees
+// on it without coordination. A shardCount of 1 or less always returns 0.
+func ShardForNamespace(namespace string, shardCount int) int {
+	if shardCount <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespace))
+	return int(h.Sum32() % uint32(shardCount))
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	"github.com/hashicorp/consul/api"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -237,6 +238,70 @@ func TestPortValue(t *testing.T) {
 	}
 }
 
+func TestShardForNamespace(t *testing.T) {
+	namespaces := []string{"default", "kube-system", "consul", "team-a", "team-b", "team-c", "prod", "staging"}
+
+	t.Run("single shard owns everything", func(t *testing.T) {
+		for _, ns := range namespaces {
+			require.Equal(t, 0, ShardForNamespace(ns, 1))
+			require.Equal(t, 0, ShardForNamespace(ns, 0))
+		}
+	})
+
+	t.Run("shards are deterministic and in range", func(t *testing.T) {
+		for _, shardCount := range []int{2, 3, 5} {
+			for _, ns := range namespaces {
+				shard := ShardForNamespace(ns, shardCount)
+				require.GreaterOrEqual(t, shard, 0)
+				require.Less(t, shard, shardCount)
+				require.Equal(t, shard, ShardForNamespace(ns, shardCount))
+			}
+		}
+	})
+}
+
+func TestTransparentProxyIPv6Enabled(t *testing.T) {
+	cases := map[string]struct {
+		annotations   map[string]string
+		globalEnabled bool
+		expEnabled    bool
+		expErr        string
+	}{
+		"disabled by default": {},
+		"enabled globally": {
+			globalEnabled: true,
+			expEnabled:    true,
+		},
+		"annotation overrides global": {
+			annotations:   map[string]string{constants.AnnotationTransparentProxyIPv6: "false"},
+			globalEnabled: true,
+			expEnabled:    false,
+		},
+		"invalid annotation": {
+			annotations: map[string]string{constants.AnnotationTransparentProxyIPv6: "yes please"},
+			expErr:      "strconv.ParseBool: parsing \"yes please\": invalid syntax",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			enabled, err := TransparentProxyIPv6Enabled(pod, c.globalEnabled)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expEnabled, enabled)
+		})
+	}
+}
+
+func TestConsulNodeNameFromK8sNode(t *testing.T) {
+	require.Equal(t, "node-1-virtual", ConsulNodeNameFromK8sNode("node-1", ""))
+	require.Equal(t, "node-1-east-virtual", ConsulNodeNameFromK8sNode("node-1", "east"))
+	require.Equal(t, "$(NODE_NAME)-east-virtual", ConsulNodeNameFromK8sNode("$(NODE_NAME)", "east"))
+}
+
 func minimal() *corev1.Pod {
 	return &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -259,3 +324,43 @@ func minimal() *corev1.Pod {
 		},
 	}
 }
+
+func TestMeshGatewayModeFromPod(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		expMode     api.MeshGatewayMode
+		expErr      string
+	}{
+		"no annotation": {
+			expMode: api.MeshGatewayModeDefault,
+		},
+		"local": {
+			annotations: map[string]string{constants.AnnotationMeshGatewayMode: "local"},
+			expMode:     api.MeshGatewayModeLocal,
+		},
+		"remote with different case": {
+			annotations: map[string]string{constants.AnnotationMeshGatewayMode: " Remote "},
+			expMode:     api.MeshGatewayModeRemote,
+		},
+		"none": {
+			annotations: map[string]string{constants.AnnotationMeshGatewayMode: "none"},
+			expMode:     api.MeshGatewayModeNone,
+		},
+		"invalid": {
+			annotations: map[string]string{constants.AnnotationMeshGatewayMode: "foo"},
+			expErr:      `consul.hashicorp.com/mesh-gateway-mode annotation value of "foo" must be one of "local", "remote" or "none"`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: c.annotations}}
+			mode, err := MeshGatewayModeFromPod(pod)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expMode, mode)
+		})
+	}
+}
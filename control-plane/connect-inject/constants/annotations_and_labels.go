@@ -54,6 +54,11 @@ const (
 	// Consul namespace where a Terminating or Ingress Gateway pod is deployed.
 	AnnotationGatewayNamespace = "consul.hashicorp.com/gateway-namespace"
 
+	// AnnotationTerminatingGateway is the key of the annotation of Services of type
+	// ExternalName that registers them in Consul and links them to the terminating
+	// gateway it names.
+	AnnotationTerminatingGateway = "consul.hashicorp.com/terminating-gateway"
+
 	// AnnotationInjectMountVolumes is the key of the annotation that controls whether
 	// the data volume that connect inject uses to store data including the Consul ACL token
 	// is mounted to other containers in the pod. It is a comma-separated list of container names
@@ -80,6 +85,17 @@ const (
 	// be a named port.
 	AnnotationUpstreams = "consul.hashicorp.com/connect-service-upstreams"
 
+	// AnnotationServiceUpstreamsPrefix is the prefix of an annotation that sets the upstreams of a single
+	// service in a multi port pod, e.g. consul.hashicorp.com/connect-service-upstreams-web: "db:1234".
+	// It uses the same format as AnnotationUpstreams. Without it, only the first service in the
+	// AnnotationService list uses the upstreams from AnnotationUpstreams.
+	AnnotationServiceUpstreamsPrefix = "consul.hashicorp.com/connect-service-upstreams-"
+
+	// AnnotationMeshGatewayMode sets the mesh gateway mode of the sidecar proxy, overriding the mode
+	// of proxy-defaults and service-defaults for this pod's upstreams. It must be one of "local",
+	// "remote" or "none".
+	AnnotationMeshGatewayMode = "consul.hashicorp.com/mesh-gateway-mode"
+
 	// AnnotationTags is a list of tags to register with the service
 	// this is specified as a comma separated list e.g. abc,123.
 	AnnotationTags = "consul.hashicorp.com/service-tags"
@@ -89,11 +105,31 @@ const (
 	// e.g. consul.hashicorp.com/service-meta-foo:bar.
 	AnnotationMeta = "consul.hashicorp.com/service-meta-"
 
+	// AnnotationLabelsToTags is a comma separated list of pod labels whose values are registered
+	// as tags with the service, e.g. version,tier. It overrides the labels configured with the
+	// -label-to-tag flag of the connect injector.
+	AnnotationLabelsToTags = "consul.hashicorp.com/service-labels-to-tags"
+
+	// AnnotationLabelsToMeta is a comma separated list of pod labels whose values are added to the
+	// service metadata, e.g. app.kubernetes.io/version=version,tier. An entry is either `<label>=<key>`
+	// or `<label>`, in which case the characters of the label that aren't allowed in metadata keys
+	// are replaced by underscores. It overrides the labels configured with the -label-to-meta flag
+	// of the connect injector.
+	AnnotationLabelsToMeta = "consul.hashicorp.com/service-labels-to-meta"
+
 	// AnnotationUseProxyHealthCheck creates a readiness listener on the sidecar proxy and
 	// queries this instead of the application health check for the status of the application.
 	// Enable this only if the application does not support health checks.
 	AnnotationUseProxyHealthCheck = "consul.hashicorp.com/use-proxy-health-check"
 
+	// AnnotationConsulDataplaneImage overrides the consul-dataplane image used for the sidecar
+	// proxy of this pod, e.g. to canary a new Envoy version on a single workload.
+	AnnotationConsulDataplaneImage = "consul.hashicorp.com/consul-dataplane-image"
+
+	// AnnotationSidecarProxyImage overrides the image used for the sidecar proxy of this pod like
+	// AnnotationConsulDataplaneImage, and takes precedence over it when both are set.
+	AnnotationSidecarProxyImage = "consul.hashicorp.com/sidecar-proxy-image"
+
 	// annotations for sidecar proxy resource limits.
 	AnnotationSidecarProxyCPULimit      = "consul.hashicorp.com/sidecar-proxy-cpu-limit"
 	AnnotationSidecarProxyCPURequest    = "consul.hashicorp.com/sidecar-proxy-cpu-request"
@@ -104,6 +140,7 @@ const (
 	AnnotationEnableSidecarProxyLifecycle                       = "consul.hashicorp.com/enable-sidecar-proxy-lifecycle"
 	AnnotationEnableSidecarProxyLifecycleShutdownDrainListeners = "consul.hashicorp.com/enable-sidecar-proxy-lifecycle-shutdown-drain-listeners"
 	AnnotationSidecarProxyLifecycleShutdownGracePeriodSeconds   = "consul.hashicorp.com/sidecar-proxy-lifecycle-shutdown-grace-period-seconds"
+	AnnotationEnableSidecarProxyLifecyclePreStopDrain           = "consul.hashicorp.com/enable-sidecar-proxy-lifecycle-prestop-drain"
 	AnnotationSidecarProxyLifecycleGracefulPort                 = "consul.hashicorp.com/sidecar-proxy-lifecycle-graceful-port"
 	AnnotationSidecarProxyLifecycleGracefulShutdownPath         = "consul.hashicorp.com/sidecar-proxy-lifecycle-graceful-shutdown-path"
 
@@ -114,6 +151,28 @@ const (
 	// annotations for sidecar concurrency.
 	AnnotationEnvoyProxyConcurrency = "consul.hashicorp.com/consul-envoy-proxy-concurrency"
 
+	// annotations for Envoy access logging of the sidecar proxy. These override the
+	// defaults set on the connect-inject command and are registered on the proxy
+	// service so that they take precedence over any proxy-defaults access log settings.
+	AnnotationEnvoyAccessLogsEnabled    = "consul.hashicorp.com/envoy-access-logs-enabled"
+	AnnotationEnvoyAccessLogsType       = "consul.hashicorp.com/envoy-access-logs-type"
+	AnnotationEnvoyAccessLogsPath       = "consul.hashicorp.com/envoy-access-logs-path"
+	AnnotationEnvoyAccessLogsJSONFormat = "consul.hashicorp.com/envoy-access-logs-json-format"
+	AnnotationEnvoyAccessLogsTextFormat = "consul.hashicorp.com/envoy-access-logs-text-format"
+
+	// annotations for OpenTelemetry tracing of the sidecar proxy. These override the defaults set on the
+	// connect-inject command. The collector address is the host:port of an OTLP gRPC receiver and the
+	// sampling rate is a percentage between 0 and 100. The service name defaults to the Consul service name.
+	AnnotationEnvoyTracingEnabled          = "consul.hashicorp.com/envoy-tracing-enabled"
+	AnnotationEnvoyTracingCollectorAddress = "consul.hashicorp.com/envoy-tracing-collector-address"
+	AnnotationEnvoyTracingSamplingRate     = "consul.hashicorp.com/envoy-tracing-sampling-rate"
+	AnnotationEnvoyTracingServiceName      = "consul.hashicorp.com/envoy-tracing-service-name"
+
+	// annotations for sending the sidecar proxy's metrics to the DogStatsD socket of the Datadog agent.
+	// The tags are a comma-separated list of Datadog "key:value" tags added to every metric.
+	AnnotationEnvoyDogStatsDEnabled = "consul.hashicorp.com/envoy-dogstatsd-enabled"
+	AnnotationEnvoyDogStatsDTags    = "consul.hashicorp.com/envoy-dogstatsd-tags"
+
 	// annotations for metrics to configure where Prometheus scrapes
 	// metrics from, whether to run a merged metrics endpoint on the consul
 	// sidecar, and configure the connect service metrics.
@@ -169,6 +228,11 @@ const (
 	// to point to the Envoy proxy when running in Transparent Proxy mode.
 	AnnotationTransparentProxyOverwriteProbes = "consul.hashicorp.com/transparent-proxy-overwrite-probes"
 
+	// AnnotationTransparentProxyIPv6 controls whether IPv6 traffic is redirected to the proxy as well
+	// when running in Transparent Proxy mode. The webhook sets it to the resolved value so that the CNI plugin
+	// and the endpoints controller can read it.
+	AnnotationTransparentProxyIPv6 = "consul.hashicorp.com/transparent-proxy-ipv6"
+
 	// AnnotationRedirectTraffic stores iptables.Config information so that the CNI plugin can use it to apply
 	// iptables rules.
 	AnnotationRedirectTraffic = "consul.hashicorp.com/redirect-traffic-config"
@@ -184,6 +248,20 @@ const (
 	// AnnotationConsulK8sVersion is the current version of this binary.
 	AnnotationConsulK8sVersion = "consul.hashicorp.com/connect-k8s-version"
 
+	// AnnotationMeshInjectionConfig is the name of the MeshInjectionConfig whose defaults were applied
+	// to the pod.
+	AnnotationMeshInjectionConfig = "consul.hashicorp.com/mesh-injection-config"
+
+	// LabelDryRun is a namespace label that enables or disables the dry-run mode of the webhook for the pods
+	// in the namespace, overriding the -dry-run flag. In dry-run mode, pods are not injected. Instead,
+	// AnnotationDryRunReport is added to them.
+	// This label takes a boolean value (true/false).
+	LabelDryRun = "consul.hashicorp.com/connect-inject-dry-run"
+
+	// AnnotationDryRunReport is a JSON report of what the webhook would have injected into a pod in
+	// dry-run mode, or why it would have rejected the pod.
+	AnnotationDryRunReport = "consul.hashicorp.com/connect-inject-dry-run-report"
+
 	// LabelServiceIgnore is a label that can be added to a service to prevent it from being
 	// registered with Consul.
 	LabelServiceIgnore = "consul.hashicorp.com/service-ignore"
@@ -208,3 +286,10 @@ const (
 	AnnotationPrometheusPath   = "prometheus.io/path"
 	AnnotationPrometheusPort   = "prometheus.io/port"
 )
+
+// Labels used by Datadog unified service tagging.
+const (
+	LabelDatadogService = "tags.datadoghq.com/service"
+	LabelDatadogEnv     = "tags.datadoghq.com/env"
+	LabelDatadogVersion = "tags.datadoghq.com/version"
+)
@@ -0,0 +1,300 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package appprotocol
+
+import (
+	"context"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// GeneratedLabel is set to "true" on the ServiceDefaults generated from the
+	// appProtocol of Services. ServiceDefaults without it are never modified.
+	GeneratedLabel = "consul.hashicorp.com/generated-from-app-protocol"
+
+	wildcard = "*"
+)
+
+// protocols maps the appProtocols of Service ports to Consul protocols.
+// Other appProtocols aren't inferred.
+var protocols = map[string]string{
+	"http":              "http",
+	"http2":             "http2",
+	"kubernetes.io/h2c": "http2",
+	"grpc":              "grpc",
+	"tcp":               "tcp",
+}
+
+// Controller generates a ServiceDefaults with the protocol of each Service
+// whose ports set appProtocol, so that the L7 features of the mesh work
+// without writing a ServiceDefaults for every service.
+//
+// The protocol is only inferred if the appProtocols of all the ports that
+// set one map to the same protocol. The ServiceDefaults are named after the
+// service the pods of the Service are registered as in Consul, i.e. their
+// connect-service annotation if it names a single service, and owned by the
+// Service, so they're deleted with it. ServiceDefaults created by users or
+// for other Services take precedence and are never modified.
+type Controller struct {
+	client.Client
+	// Log is the logger for this controller.
+	Log logr.Logger
+
+	// Services in the AllowK8sNamespacesSet are inferred.
+	AllowK8sNamespacesSet mapset.Set
+	// Services in the DenyK8sNamespacesSet are ignored.
+	DenyK8sNamespacesSet mapset.Set
+}
+
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=consul.hashicorp.com,resources=servicedefaults,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile creates, updates or deletes the ServiceDefaults generated for the
+// Service in the request.
+func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var service corev1.Service
+	err := r.Client.Get(ctx, req.NamespacedName, &service)
+	if k8serrors.IsNotFound(err) {
+		// The ServiceDefaults are garbage collected with the Service.
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		r.Log.Error(err, "failed to get Service", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	protocol := ""
+	name := service.Name
+	if !shouldIgnore(req.Namespace, r.DenyK8sNamespacesSet, r.AllowK8sNamespacesSet) && service.Labels[constants.LabelServiceIgnore] != "true" {
+		var conflict bool
+		protocol, conflict = inferProtocol(service)
+		if conflict {
+			r.Log.Info("not inferring the protocol of Service whose ports have different appProtocols", "name", req.Name, "ns", req.Namespace)
+		}
+		if protocol != "" {
+			name, conflict, err = r.consulServiceName(ctx, service)
+			if err != nil {
+				r.Log.Error(err, "failed to list the pods of Service", "name", req.Name, "ns", req.Namespace)
+				return ctrl.Result{}, err
+			}
+			if conflict {
+				r.Log.Info("not inferring the protocol of Service whose pods are registered as different services", "name", req.Name, "ns", req.Namespace)
+				protocol = ""
+			}
+		}
+	}
+
+	// The ServiceDefaults generated under another name, e.g. before the pods
+	// set the connect-service annotation, are deleted.
+	if err := r.deleteStaleServiceDefaults(ctx, service, name); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var existing v1alpha1.ServiceDefaults
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: service.Namespace, Name: name}, &existing)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		r.Log.Error(err, "failed to get ServiceDefaults", "name", name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	found := err == nil
+	if found && (existing.Labels[GeneratedLabel] != "true" || !metav1.IsControlledBy(&existing, &service)) {
+		// ServiceDefaults created by users or for other Services are left
+		// alone.
+		if protocol != "" {
+			r.Log.Info("skipping ServiceDefaults that weren't generated from the appProtocol of Service", "name", name, "ns", req.Namespace, "service", req.Name)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	switch {
+	case protocol == "" && found:
+		r.Log.Info("deleting ServiceDefaults", "name", name, "ns", req.Namespace)
+		if err := r.Client.Delete(ctx, &existing); err != nil && !k8serrors.IsNotFound(err) {
+			r.Log.Error(err, "failed to delete ServiceDefaults", "name", name, "ns", req.Namespace)
+			return ctrl.Result{}, err
+		}
+	case protocol != "" && found:
+		if existing.Spec.Protocol == protocol {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Info("updating ServiceDefaults", "name", name, "ns", req.Namespace, "protocol", protocol)
+		existing.Spec.Protocol = protocol
+		if err := r.Client.Update(ctx, &existing); err != nil {
+			r.Log.Error(err, "failed to update ServiceDefaults", "name", name, "ns", req.Namespace)
+			return ctrl.Result{}, err
+		}
+	case protocol != "":
+		serviceDefaults := &v1alpha1.ServiceDefaults{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: service.Namespace,
+				Labels:    map[string]string{GeneratedLabel: "true"},
+			},
+			Spec: v1alpha1.ServiceDefaultsSpec{Protocol: protocol},
+		}
+		if err := controllerutil.SetControllerReference(&service, serviceDefaults, r.Client.Scheme()); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Log.Info("creating ServiceDefaults", "name", name, "ns", req.Namespace, "protocol", protocol)
+		err := r.Client.Create(ctx, serviceDefaults)
+		if k8serrors.IsAlreadyExists(err) {
+			// A user created the ServiceDefaults in the meantime.
+			return ctrl.Result{}, nil
+		}
+		if err != nil {
+			r.Log.Error(err, "failed to create ServiceDefaults", "name", name, "ns", req.Namespace)
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// consulServiceName returns the name that the pods of service are registered
+// with in Consul: the connect-service annotation of the pods if it names a
+// single service, or else the name of the Service, like the endpoints
+// controller does. conflict is true if the pods are registered with
+// different names.
+func (r *Controller) consulServiceName(ctx context.Context, service corev1.Service) (name string, conflict bool, err error) {
+	if len(service.Spec.Selector) == 0 {
+		return service.Name, false, nil
+	}
+	var podList corev1.PodList
+	if err := r.Client.List(ctx, &podList, client.InNamespace(service.Namespace), client.MatchingLabels(service.Spec.Selector)); err != nil {
+		return "", false, err
+	}
+	for _, pod := range podList.Items {
+		podName := service.Name
+		if annotation := pod.Annotations[constants.AnnotationService]; annotation != "" && !strings.Contains(annotation, ",") {
+			podName = annotation
+		}
+		if name != "" && name != podName {
+			return "", true, nil
+		}
+		name = podName
+	}
+	if name == "" {
+		name = service.Name
+	}
+	return name, false, nil
+}
+
+// deleteStaleServiceDefaults deletes the ServiceDefaults generated for service
+// that aren't named name.
+func (r *Controller) deleteStaleServiceDefaults(ctx context.Context, service corev1.Service, name string) error {
+	var list v1alpha1.ServiceDefaultsList
+	if err := r.Client.List(ctx, &list, client.InNamespace(service.Namespace), client.MatchingLabels{GeneratedLabel: "true"}); err != nil {
+		r.Log.Error(err, "failed to list ServiceDefaults", "ns", service.Namespace)
+		return err
+	}
+	for i := range list.Items {
+		serviceDefaults := &list.Items[i]
+		if serviceDefaults.Name == name || !metav1.IsControlledBy(serviceDefaults, &service) {
+			continue
+		}
+		r.Log.Info("deleting ServiceDefaults", "name", serviceDefaults.Name, "ns", service.Namespace)
+		if err := r.Client.Delete(ctx, serviceDefaults); err != nil && !k8serrors.IsNotFound(err) {
+			r.Log.Error(err, "failed to delete ServiceDefaults", "name", serviceDefaults.Name, "ns", service.Namespace)
+			return err
+		}
+	}
+	return nil
+}
+
+// inferProtocol returns the Consul protocol of the service's ports, or an
+// empty string if none of them has a known appProtocol. conflict is true if
+// the ports have known appProtocols that map to different protocols.
+func inferProtocol(service corev1.Service) (protocol string, conflict bool) {
+	for _, port := range service.Spec.Ports {
+		if port.AppProtocol == nil {
+			continue
+		}
+		portProtocol, ok := protocols[strings.ToLower(*port.AppProtocol)]
+		if !ok {
+			continue
+		}
+		if protocol != "" && protocol != portProtocol {
+			return "", true
+		}
+		protocol = portProtocol
+	}
+	return protocol, false
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("app-protocol-service-defaults").
+		For(&corev1.Service{}).
+		Owns(&v1alpha1.ServiceDefaults{}).
+		// The connect-service annotation of pods changes the name of the
+		// ServiceDefaults of their Services.
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.requestsForPodServices), builder.WithPredicates(serviceAnnotationChanged)).
+		Complete(r)
+}
+
+// requestsForPodServices returns requests for the Services that select the
+// pod.
+func (r *Controller) requestsForPodServices(object client.Object) []reconcile.Request {
+	var serviceList corev1.ServiceList
+	if err := r.Client.List(context.Background(), &serviceList, client.InNamespace(object.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list Services", "ns", object.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, service := range serviceList.Items {
+		if len(service.Spec.Selector) == 0 || !labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(object.GetLabels())) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: service.Namespace, Name: service.Name}})
+	}
+	return requests
+}
+
+// serviceAnnotationChanged filters the events of pods down to the ones that
+// change the services named by their connect-service annotation.
+var serviceAnnotationChanged = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return e.Object.GetAnnotations()[constants.AnnotationService] != ""
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.ObjectOld.GetAnnotations()[constants.AnnotationService] != e.ObjectNew.GetAnnotations()[constants.AnnotationService]
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return e.Object.GetAnnotations()[constants.AnnotationService] != ""
+	},
+	GenericFunc: func(event.GenericEvent) bool {
+		return false
+	},
+}
+
+func shouldIgnore(namespace string, denySet, allowSet mapset.Set) bool {
+	// Ignores system namespaces.
+	if namespace == metav1.NamespaceSystem || namespace == metav1.NamespacePublic || namespace == "local-path-storage" {
+		return true
+	}
+	if denySet.Contains(namespace) {
+		return true
+	}
+	return !allowSet.Contains(wildcard) && !allowSet.Contains(namespace)
+}
@@ -0,0 +1,260 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package appprotocol
+
+import (
+	"context"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcile_CreatesServiceDefaults(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		appProtocols []string
+		labels       map[string]string
+		// expProtocol is empty if no ServiceDefaults is expected.
+		expProtocol string
+	}{
+		"http": {
+			appProtocols: []string{"http"},
+			expProtocol:  "http",
+		},
+		"http2": {
+			appProtocols: []string{"http2"},
+			expProtocol:  "http2",
+		},
+		"h2c": {
+			appProtocols: []string{"kubernetes.io/h2c"},
+			expProtocol:  "http2",
+		},
+		"grpc": {
+			appProtocols: []string{"GRPC"},
+			expProtocol:  "grpc",
+		},
+		"tcp": {
+			appProtocols: []string{"tcp"},
+			expProtocol:  "tcp",
+		},
+		"no appProtocol": {
+			appProtocols: []string{""},
+		},
+		"unknown appProtocol": {
+			appProtocols: []string{"kubernetes.io/ws"},
+		},
+		"ports with the same protocol": {
+			appProtocols: []string{"http", "", "mysql", "http"},
+			expProtocol:  "http",
+		},
+		"ports with different protocols": {
+			appProtocols: []string{"http", "grpc"},
+		},
+		"ignored service": {
+			appProtocols: []string{"http"},
+			labels:       map[string]string{constants.LabelServiceIgnore: "true"},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			svc := service("default", "web", c.labels, c.appProtocols...)
+			r, k8s := newController(t, svc)
+
+			runReconcile(t, r, "default", "web")
+			if c.expProtocol == "" {
+				require.Empty(t, generatedServiceDefaults(t, k8s))
+				return
+			}
+			require.Equal(t, map[string]string{"default/web": c.expProtocol}, generatedServiceDefaults(t, k8s))
+
+			var serviceDefaults v1alpha1.ServiceDefaults
+			require.NoError(t, k8s.Get(context.Background(), types.NamespacedName{Name: "web", Namespace: "default"}, &serviceDefaults))
+			require.Len(t, serviceDefaults.OwnerReferences, 1)
+			require.Equal(t, "Service", serviceDefaults.OwnerReferences[0].Kind)
+			require.Equal(t, "web", serviceDefaults.OwnerReferences[0].Name)
+		})
+	}
+}
+
+func TestReconcile_UpdatesAndDeletesServiceDefaults(t *testing.T) {
+	t.Parallel()
+	web := service("default", "web", nil, "http")
+	r, k8s := newController(t, web)
+
+	runReconcile(t, r, "default", "web")
+	require.Equal(t, map[string]string{"default/web": "http"}, generatedServiceDefaults(t, k8s))
+
+	web.Spec.Ports[0].AppProtocol = stringPtr("grpc")
+	require.NoError(t, k8s.Update(context.Background(), web))
+	runReconcile(t, r, "default", "web")
+	require.Equal(t, map[string]string{"default/web": "grpc"}, generatedServiceDefaults(t, k8s))
+
+	web.Spec.Ports[0].AppProtocol = nil
+	require.NoError(t, k8s.Update(context.Background(), web))
+	runReconcile(t, r, "default", "web")
+	require.Empty(t, generatedServiceDefaults(t, k8s))
+}
+
+func TestReconcile_LeavesUserServiceDefaults(t *testing.T) {
+	t.Parallel()
+	userServiceDefaults := &v1alpha1.ServiceDefaults{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       v1alpha1.ServiceDefaultsSpec{Protocol: "tcp"},
+	}
+	r, k8s := newController(t, service("default", "web", nil, "http"), userServiceDefaults)
+
+	runReconcile(t, r, "default", "web")
+	var current v1alpha1.ServiceDefaults
+	require.NoError(t, k8s.Get(context.Background(), types.NamespacedName{Name: "web", Namespace: "default"}, &current))
+	require.Equal(t, "tcp", current.Spec.Protocol)
+	require.Empty(t, current.Labels)
+}
+
+func TestReconcile_ConnectServiceAnnotation(t *testing.T) {
+	t.Parallel()
+	web := service("default", "web", nil, "http")
+	web.Spec.Selector = map[string]string{"app": "web"}
+	webPod := pod("default", "web-1", map[string]string{"app": "web"}, "")
+	r, k8s := newController(t, web, webPod)
+
+	runReconcile(t, r, "default", "web")
+	require.Equal(t, map[string]string{"default/web": "http"}, generatedServiceDefaults(t, k8s))
+
+	// The ServiceDefaults are renamed when the pods are registered as another
+	// service.
+	webPod.Annotations = map[string]string{constants.AnnotationService: "frontend"}
+	require.NoError(t, k8s.Update(context.Background(), webPod))
+	runReconcile(t, r, "default", "web")
+	require.Equal(t, map[string]string{"default/frontend": "http"}, generatedServiceDefaults(t, k8s))
+
+	// Multi-port pods are registered with the name of the Service.
+	webPod.Annotations = map[string]string{constants.AnnotationService: "web,admin"}
+	require.NoError(t, k8s.Update(context.Background(), webPod))
+	runReconcile(t, r, "default", "web")
+	require.Equal(t, map[string]string{"default/web": "http"}, generatedServiceDefaults(t, k8s))
+
+	// The protocol isn't inferred if the pods are registered as different
+	// services.
+	webPod.Annotations = map[string]string{constants.AnnotationService: "frontend"}
+	require.NoError(t, k8s.Update(context.Background(), webPod))
+	require.NoError(t, k8s.Create(context.Background(), pod("default", "web-2", map[string]string{"app": "web"}, "backend")))
+	runReconcile(t, r, "default", "web")
+	require.Empty(t, generatedServiceDefaults(t, k8s))
+}
+
+func TestReconcile_LeavesServiceDefaultsOfOtherServices(t *testing.T) {
+	t.Parallel()
+	web := service("default", "web", nil, "http")
+	web.Spec.Selector = map[string]string{"app": "web"}
+	webAdmin := service("default", "web-admin", nil, "grpc")
+	webAdmin.Spec.Selector = map[string]string{"app": "web"}
+	r, k8s := newController(t, web, webAdmin, pod("default", "web-1", map[string]string{"app": "web"}, "frontend"))
+
+	runReconcile(t, r, "default", "web")
+	runReconcile(t, r, "default", "web-admin")
+	require.Equal(t, map[string]string{"default/frontend": "http"}, generatedServiceDefaults(t, k8s))
+}
+
+func TestRequestsForPodServices(t *testing.T) {
+	t.Parallel()
+	web := service("default", "web", nil, "http")
+	web.Spec.Selector = map[string]string{"app": "web"}
+	db := service("default", "db", nil, "tcp")
+	db.Spec.Selector = map[string]string{"app": "db"}
+	external := service("default", "external", nil, "http")
+	otherNamespace := service("other", "web", nil, "http")
+	otherNamespace.Spec.Selector = map[string]string{"app": "web"}
+	r, _ := newController(t, web, db, external, otherNamespace)
+
+	requests := r.requestsForPodServices(pod("default", "web-1", map[string]string{"app": "web", "version": "v2"}, "frontend"))
+	require.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: "default", Name: "web"}}}, requests)
+}
+
+func TestReconcile_IgnoredNamespace(t *testing.T) {
+	t.Parallel()
+	r, k8s := newController(t, service("default", "web", nil, "http"), service("kube-system", "dns", nil, "http"))
+	r.DenyK8sNamespacesSet = mapset.NewSetWith("default")
+
+	runReconcile(t, r, "default", "web")
+	runReconcile(t, r, "kube-system", "dns")
+	require.Empty(t, generatedServiceDefaults(t, k8s))
+}
+
+func TestReconcile_DeletedService(t *testing.T) {
+	t.Parallel()
+	r, _ := newController(t)
+	runReconcile(t, r, "default", "web")
+}
+
+func newController(t *testing.T, objs ...runtime.Object) (*Controller, client.Client) {
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.ServiceDefaults{}, &v1alpha1.ServiceDefaultsList{})
+
+	k8s := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).Build()
+	return &Controller{
+		Client:                k8s,
+		Log:                   logrtest.New(t),
+		AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+		DenyK8sNamespacesSet:  mapset.NewSet(),
+	}, k8s
+}
+
+func runReconcile(t *testing.T, r *Controller, namespace, name string) {
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}})
+	require.NoError(t, err)
+}
+
+// generatedServiceDefaults returns the protocols of the generated ServiceDefaults by namespace/name.
+func generatedServiceDefaults(t *testing.T, k8s client.Client) map[string]string {
+	var list v1alpha1.ServiceDefaultsList
+	require.NoError(t, k8s.List(context.Background(), &list, client.MatchingLabels{GeneratedLabel: "true"}))
+	protocols := make(map[string]string)
+	for _, serviceDefaults := range list.Items {
+		protocols[serviceDefaults.Namespace+"/"+serviceDefaults.Name] = serviceDefaults.Spec.Protocol
+	}
+	return protocols
+}
+
+func service(namespace, name string, labels map[string]string, appProtocols ...string) *corev1.Service {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels, UID: types.UID(namespace + "/" + name)},
+	}
+	for i, appProtocol := range appProtocols {
+		port := corev1.ServicePort{Port: int32(8080 + i)}
+		if appProtocol != "" {
+			port.AppProtocol = stringPtr(appProtocol)
+		}
+		svc.Spec.Ports = append(svc.Spec.Ports, port)
+	}
+	return svc
+}
+
+func pod(namespace, name string, labels map[string]string, connectService string) *corev1.Pod {
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+	}
+	if connectService != "" {
+		p.Annotations = map[string]string{constants.AnnotationService: connectService}
+	}
+	return p
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,288 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectca
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/spire"
+	capi "github.com/hashicorp/consul/api"
+)
+
+const (
+	// consulCAProvider is the name of Consul's built-in CA provider.
+	consulCAProvider = "consul"
+	// rootCertKey and privateKeyKey are the config keys of the built-in CA
+	// provider for the CA certificate and its private key. Consul signs with
+	// the first certificate in rootCertKey and distributes all of them as
+	// the roots that proxies trust.
+	rootCertKey   = "RootCert"
+	privateKeyKey = "PrivateKey"
+)
+
+// DownstreamCAClient obtains a downstream X.509 CA from SPIRE.
+type DownstreamCAClient interface {
+	// FetchX509SVID fetches the X.509 SVID of this workload.
+	FetchX509SVID(ctx context.Context) (*spire.X509SVID, error)
+	// NewDownstreamCA signs a CA certificate for the public key of the CSR.
+	NewDownstreamCA(ctx context.Context, csr []byte, ttl time.Duration) (*spire.DownstreamCA, error)
+}
+
+// SpireCAController chains Consul's Connect CA to a SPIFFE trust domain
+// managed by SPIRE. It obtains a CA certificate from SPIRE as a downstream
+// workload and configures it as the CA of Consul's built-in CA provider, so
+// that the certificates Consul issues to services in the mesh are trusted by
+// workloads with SVIDs from the same trust domain.
+//
+// The CA certificate is configured together with the rest of its chain and
+// the X.509 authorities of the trust domain, so that proxies in the mesh trust
+// the authorities, i.e. SVIDs from the trust domain, and not only the
+// downstream CA.
+//
+// The CA from SPIRE is short-lived, so it is replaced with a new one once half
+// of its lifetime has passed or when the authorities of the trust domain
+// change. Consul cross-signs the new CA with the old one, so connections keep
+// working while proxies pick up the new CA.
+type SpireCAController struct {
+	// ConsulClientConfig is the config to create a Consul API client.
+	ConsulClientConfig *consul.Config
+	// ConsulServerConnMgr is the watcher for the Consul server addresses.
+	ConsulServerConnMgr consul.ServerConnectionManager
+	// Spire is the client for the SPIRE agent and server.
+	Spire DownstreamCAClient
+	// CATTL is the preferred lifetime of the CA certificates from SPIRE. The
+	// SPIRE server default is used if it's zero.
+	CATTL time.Duration
+	// Interval is how often the Connect CA is checked.
+	Interval time.Duration
+	// Log is the logger for this controller.
+	Log logr.Logger
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
+}
+
+// Start reconciles the Connect CA immediately and then every interval until
+// ctx is cancelled. It implements manager.Runnable and only runs on the
+// leader.
+func (c *SpireCAController) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		if err := c.reconcile(ctx); err != nil {
+			c.Log.Error(err, "failed to configure the Connect CA from SPIRE")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcile configures a new CA from SPIRE if Consul's active CA isn't from
+// SPIRE's trust domain or is due to be rotated.
+func (c *SpireCAController) reconcile(ctx context.Context) error {
+	serverState, err := c.ConsulServerConnMgr.State()
+	if err != nil {
+		return fmt.Errorf("failed to get Consul server state: %w", err)
+	}
+	apiClient, err := consul.NewClientFromConnMgrState(c.ConsulClientConfig, serverState)
+	if err != nil {
+		return fmt.Errorf("failed to create Consul API client: %w", err)
+	}
+
+	svid, err := c.Spire.FetchX509SVID(ctx)
+	if err != nil {
+		return err
+	}
+	roots, _, err := apiClient.Connect().CARoots(nil)
+	if err != nil {
+		return fmt.Errorf("failed to get Connect CA roots from Consul: %w", err)
+	}
+	due, reason, err := rotationDue(roots, svid.TrustDomain(), svid.Bundle, time.Now())
+	if err != nil || !due {
+		return err
+	}
+	c.Log.Info("configuring a new Connect CA from SPIRE", "reason", reason, "trust-domain", svid.TrustDomain())
+
+	caConfig, _, err := apiClient.Connect().CAGetConfig(nil)
+	if err != nil {
+		return fmt.Errorf("failed to get Connect CA configuration from Consul: %w", err)
+	}
+	if caConfig.Provider != consulCAProvider {
+		return fmt.Errorf("the Connect CA provider is %q; a CA from SPIRE can only be used with the %q provider", caConfig.Provider, consulCAProvider)
+	}
+
+	certPEM, keyPEM, err := c.newCA(ctx)
+	if err != nil {
+		return err
+	}
+	config := make(map[string]interface{}, len(caConfig.Config)+2)
+	for k, v := range caConfig.Config {
+		config[k] = v
+	}
+	config[rootCertKey] = certPEM
+	config[privateKeyKey] = keyPEM
+	newConfig := &capi.CAConfig{Provider: consulCAProvider, Config: config}
+	_, err = apiClient.Connect().CASetConfig(newConfig, nil)
+	c.Auditor.Record(audit.Event{
+		Actor:     "controller/spire-connect-ca",
+		Operation: audit.OperationUpdate,
+		Resource:  audit.ResourceConnectCA,
+		Name:      consulCAProvider,
+		Before:    withoutPrivateKey(caConfig),
+		After:     withoutPrivateKey(newConfig),
+	}, err)
+	if err != nil {
+		return fmt.Errorf("failed to set Connect CA configuration in Consul: %w", err)
+	}
+	c.Log.Info("configured a new Connect CA from SPIRE")
+	return nil
+}
+
+// withoutPrivateKey returns a copy of the CA configuration without the private
+// key of the CA, so that it can be written to the audit log.
+func withoutPrivateKey(caConfig *capi.CAConfig) *capi.CAConfig {
+	config := make(map[string]interface{}, len(caConfig.Config))
+	for k, v := range caConfig.Config {
+		if k != privateKeyKey {
+			config[k] = v
+		}
+	}
+	return &capi.CAConfig{Provider: caConfig.Provider, Config: config}
+}
+
+// newCA creates a private key and gets a CA certificate for it from SPIRE. It
+// returns the key and the CA certificate followed by the rest of its chain and
+// the X.509 authorities of the trust domain in PEM format.
+func (c *SpireCAController) newCA(ctx context.Context) (string, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate CA private key: %w", err)
+	}
+	// SPIRE sets the subject and SPIFFE ID of downstream CAs itself, so the
+	// CSR only needs the public key.
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "Consul CA"}}, key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create CA certificate signing request: %w", err)
+	}
+	ca, err := c.Spire.NewDownstreamCA(ctx, csr, c.CATTL)
+	if err != nil {
+		return "", "", err
+	}
+	if err := spire.VerifyChain(ca.CertChain, ca.Authorities, x509.ExtKeyUsageAny); err != nil {
+		return "", "", fmt.Errorf("CA certificate from SPIRE is not signed by the trust domain's authorities: %w", err)
+	}
+	if !ca.CertChain[0].IsCA {
+		return "", "", errors.New("certificate from SPIRE is not a CA certificate")
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode CA private key: %w", err)
+	}
+	var certPEM, keyPEM bytes.Buffer
+	for _, cert := range caBundle(ca) {
+		if err := pem.Encode(&certPEM, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+			return "", "", err
+		}
+	}
+	if err := pem.Encode(&keyPEM, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}); err != nil {
+		return "", "", err
+	}
+	return certPEM.String(), keyPEM.String(), nil
+}
+
+// caBundle returns the CA certificate, the rest of its chain and the
+// authorities of the trust domain that aren't in the chain.
+func caBundle(ca *spire.DownstreamCA) []*x509.Certificate {
+	certs := append([]*x509.Certificate(nil), ca.CertChain...)
+	for _, authority := range ca.Authorities {
+		if !containsCert(certs, authority) {
+			certs = append(certs, authority)
+		}
+	}
+	return certs
+}
+
+func containsCert(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
+
+// rotationDue returns true and the reason if Consul's active CA isn't from
+// the SPIFFE trust domain, half of its lifetime has passed or it doesn't
+// include all of the trust domain's authorities.
+func rotationDue(roots *capi.CARootList, trustDomain string, authorities []*x509.Certificate, now time.Time) (bool, string, error) {
+	var active *capi.CARoot
+	for _, root := range roots.Roots {
+		if root.Active {
+			active = root
+			break
+		}
+	}
+	if active == nil {
+		return false, "", errors.New("Consul has no active Connect CA root")
+	}
+	var certs []*x509.Certificate
+	for rest := []byte(active.RootCertPEM); ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to parse the active Connect CA root: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return false, "", errors.New("failed to decode the active Connect CA root")
+	}
+	cert := certs[0]
+
+	if !inTrustDomain(cert, trustDomain) {
+		return true, fmt.Sprintf("the active Connect CA is not in trust domain %s", trustDomain), nil
+	}
+	for _, authority := range authorities {
+		if !containsCert(certs, authority) {
+			return true, fmt.Sprintf("the X.509 authorities of trust domain %s changed", trustDomain), nil
+		}
+	}
+	rotateAt := cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) / 2)
+	if !now.Before(rotateAt) {
+		return true, fmt.Sprintf("the active Connect CA expires at %s", cert.NotAfter.UTC().Format(time.RFC3339)), nil
+	}
+	return false, "", nil
+}
+
+// inTrustDomain returns true if the certificate has a SPIFFE ID in the trust
+// domain.
+func inTrustDomain(cert *x509.Certificate, trustDomain string) bool {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" && strings.EqualFold(uri.Host, trustDomain) {
+			return true
+		}
+	}
+	return false
+}
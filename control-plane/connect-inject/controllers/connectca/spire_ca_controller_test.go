@@ -0,0 +1,333 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectca
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/spire"
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotationDue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	authority := newTestCert(t, "spiffe://example.org", now.Add(-time.Hour), now.Add(24*time.Hour), nil, nil)
+	newAuthority := newTestCert(t, "spiffe://example.org", now.Add(-time.Hour), now.Add(24*time.Hour), nil, nil)
+	spireCA := newTestCert(t, "spiffe://example.org", now.Add(-time.Hour), now.Add(3*time.Hour), authority.cert, authority.key)
+	cases := map[string]struct {
+		root        *capi.CARoot
+		authorities []*x509.Certificate
+		expDue      bool
+		expReason   string
+		expErr      string
+	}{
+		"CA from SPIRE": {
+			root: &capi.CARoot{Active: true, RootCertPEM: spireCA.pem + authority.pem},
+		},
+		"CA from SPIRE past half of its lifetime": {
+			root: &capi.CARoot{
+				Active:      true,
+				RootCertPEM: newTestCert(t, "spiffe://example.org", now.Add(-3*time.Hour), now.Add(time.Hour), authority.cert, authority.key).pem + authority.pem,
+			},
+			expDue:    true,
+			expReason: "the active Connect CA expires at",
+		},
+		"CA from SPIRE without the trust domain's authorities": {
+			root:      &capi.CARoot{Active: true, RootCertPEM: spireCA.pem},
+			expDue:    true,
+			expReason: "the X.509 authorities of trust domain example.org changed",
+		},
+		"trust domain with a new authority": {
+			root:        &capi.CARoot{Active: true, RootCertPEM: spireCA.pem + authority.pem},
+			authorities: []*x509.Certificate{authority.cert, newAuthority.cert},
+			expDue:      true,
+			expReason:   "the X.509 authorities of trust domain example.org changed",
+		},
+		"CA from another trust domain": {
+			root: &capi.CARoot{
+				Active:      true,
+				RootCertPEM: newTestCert(t, "spiffe://11111111-2222-3333-4444-555555555555.consul", now.Add(-time.Hour), now.Add(time.Hour), nil, nil).pem,
+			},
+			expDue:    true,
+			expReason: "the active Connect CA is not in trust domain example.org",
+		},
+		"no active root": {
+			root:   &capi.CARoot{RootCertPEM: spireCA.pem},
+			expErr: "Consul has no active Connect CA root",
+		},
+		"invalid root": {
+			root:   &capi.CARoot{Active: true, RootCertPEM: "not a certificate"},
+			expErr: "failed to decode the active Connect CA root",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			authorities := c.authorities
+			if authorities == nil {
+				authorities = []*x509.Certificate{authority.cert}
+			}
+			due, reason, err := rotationDue(&capi.CARootList{Roots: []*capi.CARoot{c.root}}, "example.org", authorities, now)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, c.expDue, due)
+			require.Contains(t, reason, c.expReason)
+		})
+	}
+}
+
+func TestSpireCAController_reconcile(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	authority := newTestCert(t, "spiffe://example.org", now.Add(-time.Hour), now.Add(24*time.Hour), nil, nil)
+	intermediate := newTestCert(t, "spiffe://example.org", now.Add(-time.Hour), now.Add(24*time.Hour), authority.cert, authority.key)
+	consulRoot := newTestCert(t, "spiffe://11111111-2222-3333-4444-555555555555.consul", now.Add(-time.Hour), now.Add(24*time.Hour), nil, nil)
+
+	cases := map[string]struct {
+		activeRoot string
+		provider   string
+		expWrite   bool
+		expErr     string
+	}{
+		"configures a CA from SPIRE": {
+			activeRoot: consulRoot.pem,
+			provider:   "consul",
+			expWrite:   true,
+		},
+		"does nothing if the CA is from SPIRE": {
+			activeRoot: newTestCert(t, "spiffe://example.org", now.Add(-time.Hour), now.Add(3*time.Hour), authority.cert, authority.key).pem + authority.pem,
+			provider:   "consul",
+		},
+		"fails with another CA provider": {
+			activeRoot: consulRoot.pem,
+			provider:   "vault",
+			expErr:     `the Connect CA provider is "vault"; a CA from SPIRE can only be used with the "consul" provider`,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var written *capi.CAConfig
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/connect/ca/roots":
+					require.NoError(t, json.NewEncoder(w).Encode(capi.CARootList{
+						Roots: []*capi.CARoot{{ID: "root", Active: true, RootCertPEM: c.activeRoot}},
+					}))
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/connect/ca/configuration":
+					require.NoError(t, json.NewEncoder(w).Encode(capi.CAConfig{
+						Provider: c.provider,
+						Config:   map[string]interface{}{"LeafCertTTL": "72h"},
+					}))
+				case r.Method == http.MethodPut && r.URL.Path == "/v1/connect/ca/configuration":
+					written = &capi.CAConfig{}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(written))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+				}
+			}))
+			t.Cleanup(consulServer.Close)
+
+			consulClientConfig, connMgr := consulConfig(t, consulServer.URL)
+			spireClient := &fakeSpire{authority: authority, intermediate: intermediate, trustDomain: "example.org"}
+			sink := &eventSink{}
+			controller := &SpireCAController{
+				ConsulClientConfig:  consulClientConfig,
+				ConsulServerConnMgr: connMgr,
+				Spire:               spireClient,
+				CATTL:               48 * time.Hour,
+				Log:                 logrtest.New(t),
+				Auditor:             audit.New(logrtest.New(t), sink),
+			}
+			err := controller.reconcile(context.Background())
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				require.Nil(t, written)
+				return
+			}
+			require.NoError(t, err)
+			if !c.expWrite {
+				require.Nil(t, written)
+				require.Empty(t, sink.events)
+				return
+			}
+
+			require.NotNil(t, written)
+			require.Equal(t, "consul", written.Provider)
+			require.Equal(t, "72h", written.Config["LeafCertTTL"])
+			require.Equal(t, 48*time.Hour, spireClient.ttl)
+
+			// The CA certificate is the one SPIRE signed for the private key,
+			// followed by its intermediate and the trust domain's authority.
+			var certs []*x509.Certificate
+			for rest := []byte(written.Config[rootCertKey].(string)); ; {
+				var block *pem.Block
+				block, rest = pem.Decode(rest)
+				if block == nil {
+					break
+				}
+				cert, err := x509.ParseCertificate(block.Bytes)
+				require.NoError(t, err)
+				certs = append(certs, cert)
+			}
+			require.Len(t, certs, 3)
+			ca := certs[0]
+			require.True(t, ca.IsCA)
+			require.NoError(t, ca.CheckSignatureFrom(spireClient.intermediate.cert))
+			require.True(t, certs[1].Equal(spireClient.intermediate.cert))
+			require.True(t, certs[2].Equal(authority.cert))
+			block, _ := pem.Decode([]byte(written.Config[privateKeyKey].(string)))
+			require.NotNil(t, block)
+			key, err := x509.ParseECPrivateKey(block.Bytes)
+			require.NoError(t, err)
+			require.True(t, key.PublicKey.Equal(ca.PublicKey))
+
+			// The write is audited with the new CA certificate but without
+			// its private key.
+			require.Len(t, sink.events, 1)
+			event := sink.events[0]
+			require.Equal(t, audit.OperationUpdate, event.Operation)
+			require.Equal(t, audit.ResourceConnectCA, event.Resource)
+			require.Empty(t, event.Error)
+			eventJSON, err := json.Marshal(event)
+			require.NoError(t, err)
+			require.Contains(t, string(eventJSON), "/Config/"+rootCertKey)
+			require.NotContains(t, string(eventJSON), privateKeyKey)
+		})
+	}
+}
+
+// eventSink keeps the audit events in memory.
+type eventSink struct {
+	events []audit.Event
+}
+
+func (s *eventSink) Write(event audit.Event) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+// fakeSpire signs downstream CAs with an intermediate CA of the authority.
+type fakeSpire struct {
+	authority    *testCert
+	intermediate *testCert
+	trustDomain  string
+	ttl          time.Duration
+}
+
+func (f *fakeSpire) FetchX509SVID(context.Context) (*spire.X509SVID, error) {
+	return &spire.X509SVID{
+		ID:     &url.URL{Scheme: "spiffe", Host: f.trustDomain, Path: "/consul-k8s"},
+		Bundle: []*x509.Certificate{f.authority.cert},
+	}, nil
+}
+
+func (f *fakeSpire) NewDownstreamCA(_ context.Context, csr []byte, ttl time.Duration) (*spire.DownstreamCA, error) {
+	f.ttl = ttl
+	req, err := x509.ParseCertificateRequest(csr)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		URIs:                  []*url.URL{{Scheme: "spiffe", Host: f.trustDomain}},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(ttl),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, f.intermediate.cert, req.PublicKey, f.intermediate.key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &spire.DownstreamCA{
+		CertChain:   []*x509.Certificate{cert, f.intermediate.cert},
+		Authorities: []*x509.Certificate{f.authority.cert},
+	}, nil
+}
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  string
+}
+
+// newTestCert creates a CA certificate with the SPIFFE ID, signed by the
+// parent or self-signed if parent is nil.
+func newTestCert(t *testing.T, id string, notBefore, notAfter time.Time, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		URIs:                  []*url.URL{uri},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{
+		cert: cert,
+		key:  key,
+		pem:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}
+}
+
+// consulConfig returns the config and connection manager for the test Consul
+// server.
+func consulConfig(t *testing.T, serverURL string) (*consul.Config, consul.ServerConnectionManager) {
+	t.Helper()
+	u, err := url.Parse(serverURL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	addr, err := discovery.MakeAddr(u.Hostname(), port)
+	require.NoError(t, err)
+	connMgr := &consul.MockServerConnectionManager{}
+	connMgr.On("State").Return(discovery.State{Address: addr}, nil)
+	return &consul.Config{APIClientConfig: &capi.Config{}, HTTPPort: port}, connMgr
+}
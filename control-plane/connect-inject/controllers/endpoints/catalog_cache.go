@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"golang.org/x/sync/singleflight"
+)
+
+// catalogCache caches the service instances that consul-k8s registered on a
+// Consul node. The controller reads them from every node whenever it
+// reconciles a service, and the reconciles of the services on a node read the
+// same instances, so concurrent reads of a node are coalesced into a single
+// request, and with a TTL the result is reused by the following reconciles.
+// This way rollouts don't cause a burst of identical catalog queries.
+//
+// The controller invalidates the entry of a node after every registration or
+// deregistration on it, so reads never miss its own writes.
+type catalogCache struct {
+	ttl   time.Duration
+	group singleflight.Group
+	now   func() time.Time
+
+	lock    sync.Mutex
+	entries map[string]catalogCacheEntry
+	// generations is incremented when an entry is invalidated so that reads
+	// started before the invalidation don't store their result or share it
+	// with reads started after it.
+	generations map[string]uint64
+}
+
+type catalogCacheEntry struct {
+	services *api.CatalogNodeServiceList
+	expires  time.Time
+}
+
+func newCatalogCache(ttl time.Duration) *catalogCache {
+	return &catalogCache{
+		ttl:         ttl,
+		now:         time.Now,
+		entries:     make(map[string]catalogCacheEntry),
+		generations: make(map[string]uint64),
+	}
+}
+
+// nodeServiceList returns the cached instances of the node, or calls fetch to read them.
+// The result is shared and must not be modified.
+func (c *catalogCache) nodeServiceList(nodeName string, fetch func() (*api.CatalogNodeServiceList, error)) (*api.CatalogNodeServiceList, error) {
+	c.lock.Lock()
+	if entry, ok := c.entries[nodeName]; ok && c.now().Before(entry.expires) {
+		c.lock.Unlock()
+		return entry.services, nil
+	}
+	generation := c.generations[nodeName]
+	c.lock.Unlock()
+
+	result, err, _ := c.group.Do(fmt.Sprintf("%s#%d", nodeName, generation), func() (interface{}, error) {
+		services, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		if c.ttl > 0 {
+			c.lock.Lock()
+			if c.generations[nodeName] == generation {
+				c.entries[nodeName] = catalogCacheEntry{services: services, expires: c.now().Add(c.ttl)}
+			}
+			c.lock.Unlock()
+		}
+		return services, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*api.CatalogNodeServiceList), nil
+}
+
+// invalidate drops the cached instances of the node.
+func (c *catalogCache) invalidate(nodeName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, nodeName)
+	c.generations[nodeName]++
+}
@@ -0,0 +1,182 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogCache_TTL(t *testing.T) {
+	now := time.Now()
+	cache := newCatalogCache(5 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	var fetches int
+	fetch := func() (*api.CatalogNodeServiceList, error) {
+		fetches++
+		return &api.CatalogNodeServiceList{Node: &api.Node{Node: "node"}}, nil
+	}
+	key := "node"
+
+	_, err := cache.nodeServiceList(key, fetch)
+	require.NoError(t, err)
+	services, err := cache.nodeServiceList(key, fetch)
+	require.NoError(t, err)
+	require.Equal(t, "node", services.Node.Node)
+	require.Equal(t, 1, fetches)
+
+	// Other nodes aren't affected.
+	_, err = cache.nodeServiceList("other-node", fetch)
+	require.NoError(t, err)
+	require.Equal(t, 2, fetches)
+
+	// Expired entries are read again.
+	now = now.Add(5 * time.Second)
+	_, err = cache.nodeServiceList(key, fetch)
+	require.NoError(t, err)
+	require.Equal(t, 3, fetches)
+
+	// Invalidated entries are read again.
+	cache.invalidate(key)
+	_, err = cache.nodeServiceList(key, fetch)
+	require.NoError(t, err)
+	require.Equal(t, 4, fetches)
+}
+
+func TestCatalogCache_NoTTL(t *testing.T) {
+	cache := newCatalogCache(0)
+	var fetches int
+	fetch := func() (*api.CatalogNodeServiceList, error) {
+		fetches++
+		return &api.CatalogNodeServiceList{}, nil
+	}
+	key := "node"
+
+	for i := 0; i < 3; i++ {
+		_, err := cache.nodeServiceList(key, fetch)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, fetches)
+}
+
+func TestCatalogCache_ErrorsAreNotCached(t *testing.T) {
+	cache := newCatalogCache(time.Minute)
+	key := "node"
+
+	_, err := cache.nodeServiceList(key, func() (*api.CatalogNodeServiceList, error) {
+		return nil, errors.New("unavailable")
+	})
+	require.EqualError(t, err, "unavailable")
+
+	services, err := cache.nodeServiceList(key, func() (*api.CatalogNodeServiceList, error) {
+		return &api.CatalogNodeServiceList{Node: &api.Node{Node: "node"}}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "node", services.Node.Node)
+}
+
+func TestCatalogCache_CoalescesConcurrentReads(t *testing.T) {
+	cache := newCatalogCache(0)
+	key := "node"
+
+	var fetches int32
+	release := make(chan struct{})
+	fetch := func() (*api.CatalogNodeServiceList, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return &api.CatalogNodeServiceList{}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.nodeServiceList(key, fetch)
+			require.NoError(t, err)
+		}()
+	}
+	// Give the reads time to join the first one before it completes.
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+}
+
+func TestCatalogCache_InvalidateDuringRead(t *testing.T) {
+	cache := newCatalogCache(time.Minute)
+	key := "node"
+
+	// A read that started before the entry was invalidated isn't cached.
+	_, err := cache.nodeServiceList(key, func() (*api.CatalogNodeServiceList, error) {
+		cache.invalidate(key)
+		return &api.CatalogNodeServiceList{Node: &api.Node{Node: "stale"}}, nil
+	})
+	require.NoError(t, err)
+
+	services, err := cache.nodeServiceList(key, func() (*api.CatalogNodeServiceList, error) {
+		return &api.CatalogNodeServiceList{Node: &api.Node{Node: "fresh"}}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "fresh", services.Node.Node)
+}
+
+func TestServiceInstancesForK8SServiceNameAndNamespace_SharesNodeReads(t *testing.T) {
+	var reads int32
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/catalog/node-services/node", r.URL.Path)
+		require.Equal(t, `Meta["managed-by"] == "consul-k8s-endpoints-controller"`, r.URL.Query().Get("filter"))
+		atomic.AddInt32(&reads, 1)
+		service := func(id, k8sName string) *api.AgentService {
+			return &api.AgentService{ID: id, Service: k8sName, Meta: map[string]string{
+				metaKeyKubeServiceName:  k8sName,
+				constants.MetaKeyKubeNS: "default",
+				metaKeyManagedBy:        constants.ManagedByValue,
+			}}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(api.CatalogNodeServiceList{
+			Node:     &api.Node{Node: "node"},
+			Services: []*api.AgentService{service("web-1", "web"), service("api-1", "api"), service("web-2", "web")},
+		}))
+	}))
+	defer consulServer.Close()
+	consulClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+	require.NoError(t, err)
+
+	ep := Controller{CatalogCacheTTL: time.Minute}
+	web, err := ep.serviceInstancesForK8SServiceNameAndNamespace(consulClient, "web", "default", "node")
+	require.NoError(t, err)
+	require.Equal(t, "node", web.Node.Node)
+	require.Len(t, web.Services, 2)
+	require.Equal(t, "web-1", web.Services[0].ID)
+	require.Equal(t, "web-2", web.Services[1].ID)
+
+	// The instances of other services on the node are filtered from the same read.
+	apiSvcs, err := ep.serviceInstancesForK8SServiceNameAndNamespace(consulClient, "api", "default", "node")
+	require.NoError(t, err)
+	require.Len(t, apiSvcs.Services, 1)
+	require.Equal(t, "api-1", apiSvcs.Services[0].ID)
+
+	otherNS, err := ep.serviceInstancesForK8SServiceNameAndNamespace(consulClient, "web", "other", "node")
+	require.NoError(t, err)
+	require.Empty(t, otherNS.Services)
+	require.Equal(t, int32(1), atomic.LoadInt32(&reads))
+
+	// Writes to the node invalidate its instances.
+	ep.serviceInstancesCache().invalidate("node")
+	_, err = ep.serviceInstancesForK8SServiceNameAndNamespace(consulClient, "web", "default", "node")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&reads))
+}
@@ -5,6 +5,8 @@ package endpoints
 
 import (
 	"fmt"
+	"net"
+	"strconv"
 
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
@@ -50,7 +52,7 @@ func (r *Controller) consulClientCfgForNodeAgent(serverClient *api.Client, pod c
 	if r.consulClientHttpPort != 0 {
 		consulClientHttpPort = r.consulClientHttpPort
 	}
-	ccCfg.Address = fmt.Sprintf("%s:%d", pod.Status.HostIP, consulClientHttpPort)
+	ccCfg.Address = net.JoinHostPort(pod.Status.HostIP, strconv.Itoa(consulClientHttpPort))
 
 	ccCfg.Token = state.Token
 
@@ -0,0 +1,271 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// endpointSliceState holds the endpoints of the EndpointSlices of each Service as of the last
+// successful reconcile of the Service, so that the following reconciles only register the endpoints
+// of the slices that changed and only deregister the pods that were removed, instead of comparing
+// every address of the Service against Consul.
+//
+// The state is only kept in memory, so the first reconcile of each Service after the controller
+// starts compares all of its addresses against Consul. Services are compared against Consul again once
+// resyncInterval has passed since their last full comparison, so that changes made directly in Consul,
+// e.g. instances deregistered through the HTTP API, are eventually corrected.
+type endpointSliceState struct {
+	lock     sync.Mutex
+	services map[types.NamespacedName]serviceState
+	// dirtyPods are the pods that must be registered again on the next reconcile of the Service even
+	// though their endpoints didn't change, e.g. because their labels changed.
+	dirtyPods map[types.NamespacedName]map[string]bool
+	// resyncInterval is the interval at which all addresses of a Service are compared against Consul.
+	// Services are only compared in full on their first reconcile if it is 0.
+	resyncInterval time.Duration
+	now            func() time.Time
+}
+
+// serviceState is the state of a Service as of its last successful reconcile.
+type serviceState struct {
+	slices serviceSlices
+	// fullSyncAt is the time all addresses of the Service were last compared against Consul.
+	fullSyncAt time.Time
+}
+
+func newEndpointSliceState(resyncInterval time.Duration) *endpointSliceState {
+	return &endpointSliceState{
+		services:       make(map[types.NamespacedName]serviceState),
+		dirtyPods:      make(map[types.NamespacedName]map[string]bool),
+		resyncInterval: resyncInterval,
+		now:            time.Now,
+	}
+}
+
+// get returns the slices of the Service as of its last successful reconcile and takes the pods that
+// were marked dirty since. ok is false if the Service hasn't been reconciled yet or is due to be
+// compared against Consul again.
+func (s *endpointSliceState) get(name types.NamespacedName) (slices serviceSlices, dirtyPods map[string]bool, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	state, ok := s.services[name]
+	dirtyPods = s.dirtyPods[name]
+	delete(s.dirtyPods, name)
+	if ok && s.resyncInterval > 0 && s.now().Sub(state.fullSyncAt) >= s.resyncInterval {
+		return nil, dirtyPods, false
+	}
+	return state.slices, dirtyPods, ok
+}
+
+// set stores the slices of the Service after a successful reconcile. fullSync is true if all addresses
+// of the Service were compared against Consul.
+func (s *endpointSliceState) set(name types.NamespacedName, slices serviceSlices, fullSync bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	fullSyncAt := s.services[name].fullSyncAt
+	if fullSync {
+		fullSyncAt = s.now()
+	}
+	s.services[name] = serviceState{slices: slices, fullSyncAt: fullSyncAt}
+}
+
+// forget removes the state of the Service so that its next reconcile compares all of its addresses
+// against Consul.
+func (s *endpointSliceState) forget(name types.NamespacedName) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.services, name)
+	delete(s.dirtyPods, name)
+}
+
+// markDirty makes the next reconcile of the Service register the pod again.
+func (s *endpointSliceState) markDirty(name types.NamespacedName, pod types.NamespacedName) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.dirtyPods[name] == nil {
+		s.dirtyPods[name] = make(map[string]bool)
+	}
+	s.dirtyPods[name][pod.String()] = true
+}
+
+// serviceSlices are the endpoints of the EndpointSlices of a Service, keyed by the name of the slice.
+type serviceSlices map[string]sliceEndpoints
+
+// sliceEndpoints are the endpoints of an EndpointSlice at a resource version.
+type sliceEndpoints struct {
+	resourceVersion string
+	// endpoints are keyed by sliceEndpointKey.
+	endpoints map[string]sliceEndpoint
+}
+
+// sliceEndpoint is an address of an EndpointSlice and its health status.
+type sliceEndpoint struct {
+	address corev1.EndpointAddress
+	health  string
+}
+
+// equal compares the endpoints by value since their addresses hold pointers.
+func (e sliceEndpoint) equal(other sliceEndpoint) bool {
+	if e.health != other.health || e.address.IP != other.address.IP || e.address.Hostname != other.address.Hostname {
+		return false
+	}
+	if (e.address.NodeName == nil) != (other.address.NodeName == nil) ||
+		(e.address.NodeName != nil && *e.address.NodeName != *other.address.NodeName) {
+		return false
+	}
+	if (e.address.TargetRef == nil) != (other.address.TargetRef == nil) {
+		return false
+	}
+	return e.address.TargetRef == nil || *e.address.TargetRef == *other.address.TargetRef
+}
+
+// podName returns the name of the pod the endpoint points to, or an empty string if it doesn't
+// point to a pod.
+func (e sliceEndpoint) podName() string {
+	if e.address.TargetRef == nil || e.address.TargetRef.Kind != "Pod" {
+		return ""
+	}
+	return e.address.TargetRef.Name
+}
+
+func newServiceSlices(slices []discoveryv1.EndpointSlice) serviceSlices {
+	s := make(serviceSlices, len(slices))
+	for _, slice := range slices {
+		s[slice.Name] = sliceEndpoints{
+			resourceVersion: slice.ResourceVersion,
+			endpoints:       endpointsOfSlice(slice),
+		}
+	}
+	return s
+}
+
+// merged combines the endpoints of all slices. See mergeSliceEndpoints.
+func (s serviceSlices) merged() map[string]sliceEndpoint {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	// Sort the slices so that the address picked for a pod in more than one slice is stable.
+	sort.Strings(names)
+	sets := make([]map[string]sliceEndpoint, 0, len(names))
+	for _, name := range names {
+		sets = append(sets, s[name].endpoints)
+	}
+	return mergeSliceEndpoints(sets...)
+}
+
+// delta returns the endpoints to register and the names of the pods to deregister since previous. Only
+// the endpoints of the slices that were added, changed or deleted since previous are compared, and a pod
+// is only deregistered once it's been removed from all the slices of the Service since a pod can move
+// between slices. The endpoints of dirtyPods are registered again even if they didn't change.
+func (s serviceSlices) delta(previous serviceSlices, dirtyPods map[string]bool) (register map[string]sliceEndpoint, deregisterPods map[string]bool) {
+	changedKeys := make(map[string]bool)
+	for name, slice := range s {
+		prev, ok := previous[name]
+		if ok && prev.resourceVersion == slice.resourceVersion {
+			continue
+		}
+		for key := range slice.endpoints {
+			changedKeys[key] = true
+		}
+		for key := range prev.endpoints {
+			changedKeys[key] = true
+		}
+	}
+	for name, prev := range previous {
+		if _, ok := s[name]; ok {
+			continue
+		}
+		for key := range prev.endpoints {
+			changedKeys[key] = true
+		}
+	}
+	for key := range dirtyPods {
+		changedKeys[key] = true
+	}
+	if len(changedKeys) == 0 {
+		return nil, nil
+	}
+
+	current, prev := s.merged(), previous.merged()
+	register = make(map[string]sliceEndpoint)
+	deregisterPods = make(map[string]bool)
+	for key := range changedKeys {
+		cur, inCurrent := current[key]
+		old, inPrevious := prev[key]
+		switch {
+		case inCurrent && (!inPrevious || !cur.equal(old) || dirtyPods[key]):
+			register[key] = cur
+		case !inCurrent && inPrevious && old.podName() != "":
+			deregisterPods[old.podName()] = true
+		}
+	}
+	return register, deregisterPods
+}
+
+// endpointsOfSlice returns the endpoints of the slice keyed by sliceEndpointKey. FQDN slices and
+// endpoints without addresses are skipped.
+func endpointsOfSlice(slice discoveryv1.EndpointSlice) map[string]sliceEndpoint {
+	endpoints := make(map[string]sliceEndpoint)
+	if slice.AddressType == discoveryv1.AddressTypeFQDN {
+		return endpoints
+	}
+	for _, endpoint := range slice.Endpoints {
+		if len(endpoint.Addresses) == 0 {
+			continue
+		}
+		address := corev1.EndpointAddress{
+			IP:        endpoint.Addresses[0],
+			NodeName:  endpoint.NodeName,
+			TargetRef: endpoint.TargetRef,
+		}
+		if endpoint.Hostname != nil {
+			address.Hostname = *endpoint.Hostname
+		}
+		// A nil ready condition must be interpreted as ready.
+		health := api.HealthPassing
+		if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+			health = api.HealthCritical
+		}
+		e := sliceEndpoint{address: address, health: health}
+		key := sliceEndpointKey(address)
+		if existing, ok := endpoints[key]; ok && existing.health == api.HealthPassing {
+			continue
+		}
+		endpoints[key] = e
+	}
+	return endpoints
+}
+
+// mergeSliceEndpoints combines the endpoints of several slices. The same pod may appear in more than
+// one slice, so an endpoint that is ready in any slice is treated as passing.
+func mergeSliceEndpoints(sets ...map[string]sliceEndpoint) map[string]sliceEndpoint {
+	merged := make(map[string]sliceEndpoint)
+	for _, endpoints := range sets {
+		for key, e := range endpoints {
+			if existing, ok := merged[key]; ok && (existing.health == api.HealthPassing || e.health != api.HealthPassing) {
+				continue
+			}
+			merged[key] = e
+		}
+	}
+	return merged
+}
+
+// sliceEndpointKey identifies an endpoint across slices. Dual-stack services have one slice per IP
+// family, so pods are keyed by name where possible.
+func sliceEndpointKey(address corev1.EndpointAddress) string {
+	if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+		return types.NamespacedName{Namespace: address.TargetRef.Namespace, Name: address.TargetRef.Name}.String()
+	}
+	return address.IP
+}
@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestServiceSlices_Delta(t *testing.T) {
+	t.Parallel()
+	ready := true
+	notReady := false
+	endpoint := func(pod, ip string, isReady *bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{ip},
+			Conditions: discoveryv1.EndpointConditions{Ready: isReady},
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: pod, Namespace: "default"},
+		}
+	}
+	slice := func(name, resourceVersion string, addressType discoveryv1.AddressType, endpoints ...discoveryv1.Endpoint) discoveryv1.EndpointSlice {
+		return discoveryv1.EndpointSlice{
+			ObjectMeta:  metav1.ObjectMeta{Name: name, Namespace: "default", ResourceVersion: resourceVersion},
+			AddressType: addressType,
+			Endpoints:   endpoints,
+		}
+	}
+	previous := []discoveryv1.EndpointSlice{
+		slice("svc-1", "1", discoveryv1.AddressTypeIPv4, endpoint("pod1", "1.1.1.1", &ready), endpoint("pod2", "2.2.2.2", &ready)),
+		slice("svc-2", "1", discoveryv1.AddressTypeIPv4, endpoint("pod3", "3.3.3.3", &ready)),
+	}
+
+	cases := map[string]struct {
+		current       []discoveryv1.EndpointSlice
+		dirtyPods     map[string]bool
+		expRegister   map[string]string
+		expDeregister map[string]bool
+	}{
+		"no changes": {
+			current: previous,
+		},
+		"unchanged slice is not compared": {
+			current: []discoveryv1.EndpointSlice{
+				previous[0],
+				slice("svc-2", "2", discoveryv1.AddressTypeIPv4, endpoint("pod3", "3.3.3.3", &ready)),
+			},
+			expRegister:   map[string]string{},
+			expDeregister: map[string]bool{},
+		},
+		"endpoint added": {
+			current: []discoveryv1.EndpointSlice{
+				previous[0],
+				slice("svc-2", "2", discoveryv1.AddressTypeIPv4, endpoint("pod3", "3.3.3.3", &ready), endpoint("pod4", "4.4.4.4", &ready)),
+			},
+			expRegister:   map[string]string{"default/pod4": api.HealthPassing},
+			expDeregister: map[string]bool{},
+		},
+		"endpoint became not ready": {
+			current: []discoveryv1.EndpointSlice{
+				slice("svc-1", "2", discoveryv1.AddressTypeIPv4, endpoint("pod1", "1.1.1.1", &notReady), endpoint("pod2", "2.2.2.2", &ready)),
+				previous[1],
+			},
+			expRegister:   map[string]string{"default/pod1": api.HealthCritical},
+			expDeregister: map[string]bool{},
+		},
+		"endpoint removed": {
+			current: []discoveryv1.EndpointSlice{
+				slice("svc-1", "2", discoveryv1.AddressTypeIPv4, endpoint("pod1", "1.1.1.1", &ready)),
+				previous[1],
+			},
+			expRegister:   map[string]string{},
+			expDeregister: map[string]bool{"pod2": true},
+		},
+		"slice deleted": {
+			current:       previous[:1],
+			expRegister:   map[string]string{},
+			expDeregister: map[string]bool{"pod3": true},
+		},
+		"endpoint moved to another slice": {
+			current: []discoveryv1.EndpointSlice{
+				slice("svc-1", "2", discoveryv1.AddressTypeIPv4, endpoint("pod1", "1.1.1.1", &ready)),
+				slice("svc-2", "2", discoveryv1.AddressTypeIPv4, endpoint("pod3", "3.3.3.3", &ready), endpoint("pod2", "2.2.2.2", &ready)),
+			},
+			expRegister:   map[string]string{},
+			expDeregister: map[string]bool{},
+		},
+		"dual-stack slice added for existing pod": {
+			current: []discoveryv1.EndpointSlice{
+				previous[0],
+				previous[1],
+				slice("svc-3", "1", discoveryv1.AddressTypeIPv6, endpoint("pod3", "fd00::3", &notReady)),
+			},
+			expRegister:   map[string]string{},
+			expDeregister: map[string]bool{},
+		},
+		"dirty pod is registered again": {
+			current:       previous,
+			dirtyPods:     map[string]bool{"default/pod2": true, "default/gone": true},
+			expRegister:   map[string]string{"default/pod2": api.HealthPassing},
+			expDeregister: map[string]bool{},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			register, deregister := newServiceSlices(c.current).delta(newServiceSlices(previous), c.dirtyPods)
+			var actualRegister map[string]string
+			if register != nil {
+				actualRegister = make(map[string]string)
+				for key, e := range register {
+					actualRegister[key] = e.health
+				}
+			}
+			require.Equal(t, c.expRegister, actualRegister)
+			require.Equal(t, c.expDeregister, deregister)
+		})
+	}
+}
+
+func TestEndpointSliceState(t *testing.T) {
+	t.Parallel()
+	state := newEndpointSliceState(0)
+	svc := types.NamespacedName{Name: "svc", Namespace: "default"}
+
+	_, _, ok := state.get(svc)
+	require.False(t, ok)
+
+	state.set(svc, serviceSlices{"svc-1": {resourceVersion: "1"}}, true)
+	state.markDirty(svc, types.NamespacedName{Name: "pod1", Namespace: "default"})
+	slices, dirtyPods, ok := state.get(svc)
+	require.True(t, ok)
+	require.Equal(t, serviceSlices{"svc-1": {resourceVersion: "1"}}, slices)
+	require.Equal(t, map[string]bool{"default/pod1": true}, dirtyPods)
+
+	// Dirty pods are only returned once.
+	_, dirtyPods, _ = state.get(svc)
+	require.Nil(t, dirtyPods)
+
+	state.forget(svc)
+	_, _, ok = state.get(svc)
+	require.False(t, ok)
+}
+
+func TestEndpointSliceState_Resync(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	state := newEndpointSliceState(time.Minute)
+	state.now = func() time.Time { return now }
+	svc := types.NamespacedName{Name: "svc", Namespace: "default"}
+
+	state.set(svc, serviceSlices{"svc-1": {resourceVersion: "1"}}, true)
+	_, _, ok := state.get(svc)
+	require.True(t, ok)
+
+	// Reconciles of the delta don't postpone the next full comparison.
+	now = now.Add(30 * time.Second)
+	state.set(svc, serviceSlices{"svc-1": {resourceVersion: "2"}}, false)
+	now = now.Add(30 * time.Second)
+	_, _, ok = state.get(svc)
+	require.False(t, ok)
+
+	state.set(svc, serviceSlices{"svc-1": {resourceVersion: "2"}}, true)
+	now = now.Add(59 * time.Second)
+	_, _, ok = state.get(svc)
+	require.True(t, ok)
+}
@@ -10,25 +10,40 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/accesslogs"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/dogstatsd"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/tracing"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/helper/parsetags"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
 	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/iptables"
 	"github.com/hashicorp/go-multierror"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -46,9 +61,19 @@ const (
 
 	kubernetesSuccessReasonMsg           = "Kubernetes health checks passing"
 	envoyPrometheusBindAddr              = "envoy_prometheus_bind_addr"
+	envoyBindAddress                     = "bind_address"
+	ipv6OutboundListenerName             = "outbound_listener_ipv6"
+	ipv6OutboundClusterName              = "original-destination-ipv6"
 	envoyTelemetryCollectorBindSocketDir = "envoy_telemetry_collector_bind_socket_dir"
+	envoyExtraStaticClustersJSON         = "envoy_extra_static_clusters_json"
+	envoyExtraStaticListenersJSON        = "envoy_extra_static_listeners_json"
 	defaultNS                            = "default"
 
+	// lanIPv4TaggedAddressName and lanIPv6TaggedAddressName are the keys for the tagged addresses that store a
+	// pod's IPv4 and IPv6 addresses in Consul.
+	lanIPv4TaggedAddressName = "lan_ipv4"
+	lanIPv6TaggedAddressName = "lan_ipv6"
+
 	// clusterIPTaggedAddressName is the key for the tagged address to store the service's cluster IP and service port
 	// in Consul. Note: This value should not be changed without a corresponding change in Consul.
 	clusterIPTaggedAddressName = "virtual"
@@ -63,6 +88,13 @@ const (
 
 	// consulKubernetesCheckName is the name of health check in Consul for Kubernetes readiness status.
 	consulKubernetesCheckName = "Kubernetes Readiness Check"
+
+	// grpcHealthCheckPath is the HTTP/2 path the kubelet calls for gRPC probes.
+	grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+	// sidecarContainer is the name of the consul-dataplane container injected by the mesh webhook. In multiport
+	// pods the service name is appended to it.
+	sidecarContainer = "consul-dataplane"
 )
 
 type Controller struct {
@@ -124,8 +156,58 @@ type Controller struct {
 	// with config to enable telemetry forwarding.
 	EnableTelemetryCollector bool
 
+	// EnableEndpointSlices controls whether the controller reads service addresses from
+	// discovery.k8s.io/v1 EndpointSlices instead of core/v1 Endpoints. Endpoints objects are
+	// truncated at 1000 addresses, so this is required for very large services.
+	EnableEndpointSlices bool
+
+	// EndpointSliceResyncInterval is the interval at which all addresses of a Service are compared against
+	// Consul when EnableEndpointSlices is set. In between, only the endpoints of the slices that changed are
+	// registered or deregistered. Services are only compared in full on their first reconcile if it is 0.
+	EndpointSliceResyncInterval time.Duration
+
+	// EnableDualStackAddresses registers every IP of a pod as lan_ipv4 and lan_ipv6 tagged addresses on its
+	// service and proxy service so that Consul can serve both address families. The primary pod IP
+	// is always used as the service address.
+	EnableDualStackAddresses bool
+
+	// ClusterName distinguishes this Kubernetes cluster from the other Kubernetes clusters that are
+	// registered with the same Consul datacenter. It is added to the names of the virtual nodes that
+	// services are registered on, so that each cluster only manages the services on its own nodes.
+	ClusterName string
+
+	// LabelsToTags are the pod labels whose values are registered as tags with the services of
+	// pods that don't have the AnnotationLabelsToTags annotation.
+	LabelsToTags []string
+	// LabelsToMeta maps pod labels to the service metadata keys their values are added under, for
+	// pods that don't have the AnnotationLabelsToMeta annotation.
+	LabelsToMeta map[string]string
+
+	// ShardCount and ShardIndex split reconciliation across connect-injector replicas. When ShardCount
+	// is greater than one, this controller only reconciles services in namespaces for which
+	// common.ShardForNamespace returns ShardIndex.
+	ShardCount int
+	ShardIndex int
+
+	// CatalogCacheTTL is how long the service instances read from a Consul node to find the instances
+	// to deregister are reused by later reconciles of the services on the node. Concurrent reads of a
+	// node are coalesced regardless. Zero disables caching.
+	CatalogCacheTTL time.Duration
+
+	// ControllerOptions configures the concurrency and rate limiting of this controller's workqueue.
+	// The zero value uses the controller-runtime defaults.
+	ControllerOptions controller.Options
+
 	MetricsConfig metrics.Config
-	Log           logr.Logger
+	// AccessLogsConfig determines the Envoy access log settings registered on proxy services.
+	AccessLogsConfig accesslogs.Config
+	// TracingConfig determines the Envoy tracing settings registered on proxy services.
+	TracingConfig tracing.Config
+	// DogStatsDConfig determines the Envoy DogStatsD settings registered on proxy services.
+	DogStatsDConfig dogstatsd.Config
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
+	Log     logr.Logger
 
 	Scheme *runtime.Scheme
 	context.Context
@@ -133,6 +215,12 @@ type Controller struct {
 	// consulClientHttpPort is only used in tests.
 	consulClientHttpPort int
 	NodeMeta             map[string]string
+
+	catalogCacheOnce sync.Once
+	catalogCache     *catalogCache
+
+	endpointSliceStateOnce sync.Once
+	sliceState             *endpointSliceState
 }
 
 // Reconcile reads the state of an Endpoints object for a Kubernetes Service and reconciles Consul services which
@@ -146,6 +234,11 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, nil
 	}
 
+	// Ignore the request if the namespace is owned by another shard.
+	if !r.ownsNamespace(req.Namespace) {
+		return ctrl.Result{}, nil
+	}
+
 	// Create Consul client for this reconcile.
 	serverState, err := r.ConsulServerConnMgr.State()
 	if err != nil {
@@ -158,7 +251,17 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	if r.EnableEndpointSlices {
+		return r.reconcileEndpointSlices(ctx, apiClient, serverState, req)
+	}
+
 	err = r.Client.Get(ctx, req.NamespacedName, &serviceEndpoints)
+	addresses := make(map[corev1.EndpointAddress]string)
+	for _, subset := range serviceEndpoints.Subsets {
+		for address, healthStatus := range mapAddresses(subset) {
+			addresses[address] = healthStatus
+		}
+	}
 
 	// endpointPods holds a set of all pods this endpoints object is currently pointing to.
 	// We use this later when we reconcile ACL tokens to decide whether an ACL token in Consul
@@ -193,60 +296,9 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	endpointAddressMap := map[string]bool{}
 
 	// Register all addresses of this Endpoints object as service instances in Consul.
-	for _, subset := range serviceEndpoints.Subsets {
-		for address, healthStatus := range mapAddresses(subset) {
-			if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
-				var pod corev1.Pod
-				objectKey := types.NamespacedName{Name: address.TargetRef.Name, Namespace: address.TargetRef.Namespace}
-				if err = r.Client.Get(ctx, objectKey, &pod); err != nil {
-					r.Log.Error(err, "failed to get pod", "name", address.TargetRef.Name)
-					errs = multierror.Append(errs, err)
-					continue
-				}
-
-				svcName, ok := pod.Annotations[constants.AnnotationKubernetesService]
-				if ok && serviceEndpoints.Name != svcName {
-					r.Log.Info("ignoring endpoint because it doesn't match explicit service annotation", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
-					// deregistration for service instances that don't match the annotation happens
-					// later because we don't add this pod to the endpointAddressMap.
-					continue
-				}
-
-				if hasBeenInjected(pod) {
-					endpointPods.Add(address.TargetRef.Name)
-					if isConsulDataplaneSupported(pod) {
-						if err = r.registerServicesAndHealthCheck(apiClient, pod, serviceEndpoints, healthStatus, endpointAddressMap); err != nil {
-							r.Log.Error(err, "failed to register services or health check", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
-							errs = multierror.Append(errs, err)
-						}
-					} else {
-						r.Log.Info("detected an update to pre-consul-dataplane service", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
-						nodeAgentClientCfg, err := r.consulClientCfgForNodeAgent(apiClient, pod, serverState)
-						if err != nil {
-							r.Log.Error(err, "failed to create node-local Consul API client", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
-							errs = multierror.Append(errs, err)
-							continue
-						}
-						r.Log.Info("updating health check on the Consul client", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
-						if err = r.updateHealthCheckOnConsulClient(nodeAgentClientCfg, pod, serviceEndpoints, healthStatus); err != nil {
-							r.Log.Error(err, "failed to update health check on Consul client", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace, "consul-client-ip", pod.Status.HostIP)
-							errs = multierror.Append(errs, err)
-						}
-						// We want to skip the rest of the reconciliation because we only care about updating health checks for existing services
-						// in the case when Consul clients are running in the cluster. If endpoints are deleted, consul clients
-						// will detect that they are unhealthy, and we don't need to worry about keeping them up-to-date.
-						// This is so that health checks are still updated during an upgrade to consul-dataplane.
-						continue
-					}
-				}
-				if isGateway(pod) {
-					endpointPods.Add(address.TargetRef.Name)
-					if err = r.registerGateway(apiClient, pod, serviceEndpoints, healthStatus, endpointAddressMap); err != nil {
-						r.Log.Error(err, "failed to register gateway or health check", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
-						errs = multierror.Append(errs, err)
-					}
-				}
-			}
+	for address, healthStatus := range addresses {
+		if err := r.registerAddress(ctx, apiClient, serverState, serviceEndpoints, address, healthStatus, endpointAddressMap, endpointPods); err != nil {
+			errs = multierror.Append(errs, err)
 		}
 	}
 
@@ -261,16 +313,220 @@ func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	return ctrl.Result{}, errs
 }
 
+// registerAddress registers the pod the address points to, if any, as an instance of the Kubernetes service in
+// Consul. The pod's IP is added to endpointAddressMap and its name to endpointPods if it is registered.
+func (r *Controller) registerAddress(ctx context.Context, apiClient *api.Client, serverState discovery.State, serviceEndpoints corev1.Endpoints,
+	address corev1.EndpointAddress, healthStatus string, endpointAddressMap map[string]bool, endpointPods mapset.Set) error {
+	if address.TargetRef == nil || address.TargetRef.Kind != "Pod" {
+		return nil
+	}
+
+	var pod corev1.Pod
+	objectKey := types.NamespacedName{Name: address.TargetRef.Name, Namespace: address.TargetRef.Namespace}
+	if err := r.Client.Get(ctx, objectKey, &pod); err != nil {
+		r.Log.Error(err, "failed to get pod", "name", address.TargetRef.Name)
+		return err
+	}
+
+	svcName, ok := pod.Annotations[constants.AnnotationKubernetesService]
+	if ok && serviceEndpoints.Name != svcName {
+		r.Log.Info("ignoring endpoint because it doesn't match explicit service annotation", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
+		// deregistration for service instances that don't match the annotation happens
+		// later because we don't add this pod to the endpointAddressMap.
+		return nil
+	}
+
+	var errs error
+	if hasBeenInjected(pod) {
+		endpointPods.Add(address.TargetRef.Name)
+		if isConsulDataplaneSupported(pod) {
+			if err := r.registerServicesAndHealthCheck(apiClient, pod, serviceEndpoints, healthStatus, endpointAddressMap); err != nil {
+				r.Log.Error(err, "failed to register services or health check", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
+				errs = multierror.Append(errs, err)
+			}
+		} else {
+			r.Log.Info("detected an update to pre-consul-dataplane service", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
+			nodeAgentClientCfg, err := r.consulClientCfgForNodeAgent(apiClient, pod, serverState)
+			if err != nil {
+				r.Log.Error(err, "failed to create node-local Consul API client", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
+				return err
+			}
+			r.Log.Info("updating health check on the Consul client", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
+			if err = r.updateHealthCheckOnConsulClient(nodeAgentClientCfg, pod, serviceEndpoints, healthStatus); err != nil {
+				r.Log.Error(err, "failed to update health check on Consul client", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace, "consul-client-ip", pod.Status.HostIP)
+				return err
+			}
+			// We want to skip the rest of the reconciliation because we only care about updating health checks for existing services
+			// in the case when Consul clients are running in the cluster. If endpoints are deleted, consul clients
+			// will detect that they are unhealthy, and we don't need to worry about keeping them up-to-date.
+			// This is so that health checks are still updated during an upgrade to consul-dataplane.
+			return nil
+		}
+	}
+	if isGateway(pod) {
+		endpointPods.Add(address.TargetRef.Name)
+		if err := r.registerGateway(apiClient, pod, serviceEndpoints, healthStatus, endpointAddressMap); err != nil {
+			r.Log.Error(err, "failed to register gateway or health check", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
 func (r *Controller) Logger(name types.NamespacedName) logr.Logger {
 	return r.Log.WithValues("request", name)
 }
 
 func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	// Drop events for namespaces owned by other shards before they are queued.
+	shardPredicate := builder.WithPredicates(predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return r.ownsNamespace(object.GetNamespace())
+	}))
+
+	if r.EnableEndpointSlices {
+		// EndpointSlices are reconciled per Service, so every slice event is mapped back to
+		// the name of the Service it belongs to.
+		return ctrl.NewControllerManagedBy(mgr).
+			Named("endpoints").
+			WithOptions(r.ControllerOptions).
+			Watches(
+				&source.Kind{Type: &discoveryv1.EndpointSlice{}},
+				handler.EnqueueRequestsFromMapFunc(requestsForEndpointSlice),
+				shardPredicate,
+			).
+			Watches(
+				&source.Kind{Type: &corev1.Pod{}},
+				handler.EnqueueRequestsFromMapFunc(r.requestsForPod),
+				shardPredicate,
+				builder.WithPredicates(podLabelsChanged),
+			).Complete(r)
+	}
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Endpoints{}).
+		For(&corev1.Endpoints{}, shardPredicate).
+		WithOptions(r.ControllerOptions).
+		Watches(
+			&source.Kind{Type: &corev1.Pod{}},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForPod),
+			shardPredicate,
+			builder.WithPredicates(podLabelsChanged),
+		).
 		Complete(r)
 }
 
+// ownsNamespace returns true if this controller's shard is responsible for the given namespace.
+func (r *Controller) ownsNamespace(namespace string) bool {
+	if r.ShardCount <= 1 {
+		return true
+	}
+	return common.ShardForNamespace(namespace, r.ShardCount) == r.ShardIndex
+}
+
+// requestsForEndpointSlice maps an EndpointSlice to a request for the Service that owns it.
+func requestsForEndpointSlice(object client.Object) []reconcile.Request {
+	svcName, ok := object.GetLabels()[discoveryv1.LabelServiceName]
+	if !ok || svcName == "" {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: svcName, Namespace: object.GetNamespace()}},
+	}
+}
+
+// reconcileEndpointSlices reconciles the Kubernetes service in the request from its EndpointSlices. The first
+// reconcile of the service, and the first one after each EndpointSliceResyncInterval, compares all of its
+// addresses against Consul. The other reconciles only register
+// the endpoints that were added or changed in the slices that changed since the last successful reconcile, and
+// only deregister the pods that were removed from all of the service's slices.
+func (r *Controller) reconcileEndpointSlices(ctx context.Context, apiClient *api.Client, serverState discovery.State, req ctrl.Request) (ctrl.Result, error) {
+	state := r.endpointSliceState()
+	serviceEndpoints, slices, err := r.endpointsFromSlices(ctx, req.NamespacedName)
+	if k8serrors.IsNotFound(err) {
+		// Deregister all instances in Consul for this service. The function deregisterService handles
+		// the case where the Consul service name is different from the Kubernetes service name.
+		state.forget(req.NamespacedName)
+		return ctrl.Result{}, r.deregisterService(apiClient, req.Name, req.Namespace, nil)
+	} else if err != nil {
+		r.Log.Error(err, "failed to get EndpointSlices", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	r.Log.Info("retrieved", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
+
+	if isLabeledIgnore(serviceEndpoints.Labels) {
+		r.Log.Info("Ignoring endpoint labeled with `consul.hashicorp.com/service-ignore: \"true\"`", "name", req.Name, "namespace", req.Namespace)
+		state.forget(req.NamespacedName)
+		return ctrl.Result{}, r.deregisterService(apiClient, req.Name, req.Namespace, nil)
+	}
+
+	var errs error
+	endpointAddressMap := map[string]bool{}
+	endpointPods := mapset.NewSet()
+	previous, dirtyPods, ok := state.get(req.NamespacedName)
+	if !ok {
+		for _, e := range slices.merged() {
+			if err := r.registerAddress(ctx, apiClient, serverState, serviceEndpoints, e.address, e.health, endpointAddressMap, endpointPods); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+		if err := r.deregisterService(apiClient, serviceEndpoints.Name, serviceEndpoints.Namespace, endpointAddressMap); err != nil {
+			r.Log.Error(err, "failed to deregister endpoints", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
+			errs = multierror.Append(errs, err)
+		}
+	} else {
+		register, deregisterPods := slices.delta(previous, dirtyPods)
+		for _, e := range register {
+			if err := r.registerAddress(ctx, apiClient, serverState, serviceEndpoints, e.address, e.health, endpointAddressMap, endpointPods); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+		if len(deregisterPods) > 0 {
+			if err := r.deregisterPods(apiClient, serviceEndpoints.Name, serviceEndpoints.Namespace, deregisterPods); err != nil {
+				r.Log.Error(err, "failed to deregister endpoints", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace)
+				errs = multierror.Append(errs, err)
+			}
+		}
+	}
+
+	if errs != nil {
+		// Compare all of the service's addresses against Consul when the request is retried since
+		// it's not known which of the writes were made.
+		state.forget(req.NamespacedName)
+		return ctrl.Result{}, errs
+	}
+	state.set(req.NamespacedName, slices, !ok)
+	// Requeue the service so that it is compared against Consul once the resync interval has passed even
+	// if its slices don't change.
+	return ctrl.Result{RequeueAfter: r.EndpointSliceResyncInterval}, nil
+}
+
+// endpointsFromSlices looks up the Service for the request and the endpoints of all its EndpointSlices.
+// The returned Endpoints object only carries the Service's metadata so that it can be passed to the same
+// registration helpers used for core/v1 Endpoints. A NotFound error is returned if the Service no longer
+// exists.
+func (r *Controller) endpointsFromSlices(ctx context.Context, name types.NamespacedName) (corev1.Endpoints, serviceSlices, error) {
+	var service corev1.Service
+	if err := r.Client.Get(ctx, name, &service); err != nil {
+		return corev1.Endpoints{}, nil, err
+	}
+
+	var slices discoveryv1.EndpointSliceList
+	if err := r.Client.List(ctx, &slices,
+		client.InNamespace(name.Namespace),
+		client.MatchingLabels{discoveryv1.LabelServiceName: name.Name},
+	); err != nil {
+		return corev1.Endpoints{}, nil, err
+	}
+
+	serviceEndpoints := corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service.Name,
+			Namespace: service.Namespace,
+			Labels:    service.Labels,
+		},
+	}
+	return serviceEndpoints, newServiceSlices(slices.Items), nil
+}
+
 // registerServicesAndHealthCheck creates Consul registrations for the service and proxy and registers them with Consul.
 // It also upserts a Kubernetes health check for the service based on whether the endpoint address is ready.
 func (r *Controller) registerServicesAndHealthCheck(apiClient *api.Client, pod corev1.Pod, serviceEndpoints corev1.Endpoints, healthStatus string, endpointAddressMap map[string]bool) error {
@@ -294,6 +550,8 @@ func (r *Controller) registerServicesAndHealthCheck(apiClient *api.Client, pod c
 		r.Log.Info("registering service with Consul", "name", serviceRegistration.Service.Service,
 			"id", serviceRegistration.ID)
 		_, err = apiClient.Catalog().Register(serviceRegistration, nil)
+		r.serviceInstancesCache().invalidate(serviceRegistration.Node)
+		r.recordRegistration(serviceRegistration, serviceEndpoints, err)
 		if err != nil {
 			r.Log.Error(err, "failed to register service", "name", serviceRegistration.Service.Service)
 			return err
@@ -310,6 +568,8 @@ func (r *Controller) registerServicesAndHealthCheck(apiClient *api.Client, pod c
 		// Register the proxy service instance with Consul.
 		r.Log.Info("registering proxy service with Consul", "name", proxyServiceRegistration.Service.Service)
 		_, err = apiClient.Catalog().Register(proxyServiceRegistration, nil)
+		r.serviceInstancesCache().invalidate(proxyServiceRegistration.Node)
+		r.recordRegistration(proxyServiceRegistration, serviceEndpoints, err)
 		if err != nil {
 			r.Log.Error(err, "failed to register proxy service", "name", proxyServiceRegistration.Service.Service)
 			return err
@@ -318,6 +578,22 @@ func (r *Controller) registerServicesAndHealthCheck(apiClient *api.Client, pod c
 	return nil
 }
 
+// nodeLocality returns the Consul locality of the Kubernetes node the pod is scheduled on, or nil if
+// the node has no topology labels or cannot be read. Errors are only logged because we don't want
+// failures to block running services.
+func (r *Controller) nodeLocality(pod corev1.Pod) *api.Locality {
+	if pod.Spec.NodeName == "" {
+		return nil
+	}
+	var node corev1.Node
+	// Nodes are cluster-scoped so the key must not include the pod's namespace.
+	if err := r.Client.Get(context.Background(), types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+		r.Log.V(1).Info("unable to read node for locality", "node", pod.Spec.NodeName, "error", err.Error())
+		return nil
+	}
+	return parseLocality(node)
+}
+
 func parseLocality(node corev1.Node) *api.Locality {
 	region := node.Labels[corev1.LabelTopologyRegion]
 	zone := node.Labels[corev1.LabelTopologyZone]
@@ -352,7 +628,15 @@ func (r *Controller) registerGateway(apiClient *api.Client, pod corev1.Pod, serv
 		}
 
 		if r.EnableConsulNamespaces {
-			if _, err := namespaces.EnsureExists(apiClient, serviceRegistration.Service.Namespace, r.CrossNSACLPolicy); err != nil {
+			created, err := namespaces.EnsureExists(apiClient, serviceRegistration.Service.Namespace, r.CrossNSACLPolicy)
+			if created {
+				r.recordWrite(audit.Event{
+					Operation: audit.OperationCreate,
+					Resource:  audit.ResourceNamespace,
+					Name:      serviceRegistration.Service.Namespace,
+				}, serviceEndpoints.Namespace, serviceEndpoints.Name, err)
+			}
+			if err != nil {
 				r.Log.Error(err, "failed to ensure Consul namespace exists", "name", serviceEndpoints.Name, "ns", serviceEndpoints.Namespace, "consul ns", serviceRegistration.Service.Namespace)
 				return err
 			}
@@ -362,6 +646,8 @@ func (r *Controller) registerGateway(apiClient *api.Client, pod corev1.Pod, serv
 		r.Log.Info("registering gateway with Consul", "name", serviceRegistration.Service.Service,
 			"id", serviceRegistration.ID)
 		_, err = apiClient.Catalog().Register(serviceRegistration, nil)
+		r.serviceInstancesCache().invalidate(serviceRegistration.Node)
+		r.recordRegistration(serviceRegistration, serviceEndpoints, err)
 		if err != nil {
 			r.Log.Error(err, "failed to register gateway", "name", serviceRegistration.Service.Service)
 			return err
@@ -419,10 +705,13 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 		}
 	}
 
-	var node corev1.Node
-	// Ignore errors because we don't want failures to block running services.
-	_ = r.Client.Get(context.Background(), types.NamespacedName{Name: pod.Spec.NodeName, Namespace: pod.Namespace}, &node)
-	locality := parseLocality(node)
+	// In a multiport pod, each service's health also accounts for the containers that serve it, so that a
+	// service is critical as soon as its own application container or sidecar is not ready.
+	if mpIdx := getMultiPortIdx(pod, serviceEndpoints); mpIdx >= 0 && consulServicePort > 0 {
+		healthStatus = multiPortHealthStatus(pod, serviceName(pod, serviceEndpoints), consulServicePort, healthStatus)
+	}
+
+	locality := r.nodeLocality(pod)
 
 	// We only want that annotation to be present when explicitly overriding the consul svc name
 	// Otherwise, the Consul service name should equal the Kubernetes Service name.
@@ -439,6 +728,16 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 		metaKeyManagedBy:         constants.ManagedByValue,
 		metaKeySyntheticNode:     "true",
 	}
+	labelMeta, err := r.labelMeta(pod)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range labelMeta {
+		// The metadata the controller relies on can't be overridden by labels.
+		if _, ok := meta[k]; !ok {
+			meta[k] = v
+		}
+	}
 	for k, v := range pod.Annotations {
 		if strings.HasPrefix(k, constants.AnnotationMeta) && strings.TrimPrefix(k, constants.AnnotationMeta) != "" {
 			if v == "$POD_NAME" {
@@ -448,7 +747,7 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 			}
 		}
 	}
-	tags := consulTags(pod)
+	tags := r.labelTags(pod, consulTags(pod))
 
 	consulNS := r.consulNamespace(pod.Namespace)
 	service := &api.AgentService{
@@ -462,7 +761,7 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 		Locality:  locality,
 	}
 	serviceRegistration := &api.CatalogRegistration{
-		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName),
+		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName, r.ClusterName),
 		Address: pod.Status.HostIP,
 		NodeMeta: map[string]string{
 			metaKeySyntheticNode: "true",
@@ -503,7 +802,7 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 		if err != nil {
 			return nil, nil, err
 		}
-		prometheusScrapeListener := fmt.Sprintf("0.0.0.0:%s", prometheusScrapePort)
+		prometheusScrapeListener := net.JoinHostPort(wildcardAddress(pod), prometheusScrapePort)
 		proxyConfig.Config[envoyPrometheusBindAddr] = prometheusScrapeListener
 	}
 
@@ -511,6 +810,28 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 		proxyConfig.Config[envoyTelemetryCollectorBindSocketDir] = "/consul/connect-inject"
 	}
 
+	accessLogs, err := r.AccessLogsConfig.AccessLogs(pod)
+	if err != nil {
+		return nil, nil, err
+	}
+	proxyConfig.AccessLogs = accessLogs
+
+	tracingConfig, err := r.TracingConfig.ProxyConfig(pod, svcName)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range tracingConfig {
+		proxyConfig.Config[k] = v
+	}
+
+	dogStatsDConfig, err := r.DogStatsDConfig.ProxyConfig(pod, svcName)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range dogStatsDConfig {
+		proxyConfig.Config[k] = v
+	}
+
 	if consulServicePort > 0 {
 		proxyConfig.LocalServiceAddress = "127.0.0.1"
 		proxyConfig.LocalServicePort = consulServicePort
@@ -522,6 +843,12 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 	}
 	proxyConfig.Upstreams = upstreams
 
+	meshGatewayMode, err := common.MeshGatewayModeFromPod(pod)
+	if err != nil {
+		return nil, nil, err
+	}
+	proxyConfig.MeshGateway.Mode = meshGatewayMode
+
 	proxyPort := constants.ProxyDefaultInboundPort
 	if idx := getMultiPortIdx(pod, serviceEndpoints); idx >= 0 {
 		proxyPort += idx
@@ -535,6 +862,7 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 		Meta:      meta,
 		Namespace: consulNS,
 		Proxy:     proxyConfig,
+		Locality:  locality,
 		Tags:      tags,
 	}
 
@@ -612,50 +940,59 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 				return nil, nil, err
 			}
 
+			var tlsProbePaths []api.ExposePath
 			for _, mutatedContainer := range pod.Spec.Containers {
 				for _, originalContainer := range originalPod.Spec.Containers {
 					if originalContainer.Name == mutatedContainer.Name {
-						if mutatedContainer.LivenessProbe != nil && mutatedContainer.LivenessProbe.HTTPGet != nil {
-							originalLivenessPort, err := portValueFromIntOrString(originalPod, originalContainer.LivenessProbe.HTTPGet.Port)
+						for _, probes := range [][2]*corev1.Probe{
+							{originalContainer.LivenessProbe, mutatedContainer.LivenessProbe},
+							{originalContainer.ReadinessProbe, mutatedContainer.ReadinessProbe},
+							{originalContainer.StartupProbe, mutatedContainer.StartupProbe},
+						} {
+							exposePath, err := exposePathForProbe(originalPod, probes[0], probes[1])
 							if err != nil {
 								return nil, nil, err
 							}
-							proxyConfig.Expose.Paths = append(proxyConfig.Expose.Paths, api.ExposePath{
-								ListenerPort:  mutatedContainer.LivenessProbe.HTTPGet.Port.IntValue(),
-								LocalPathPort: originalLivenessPort,
-								Path:          mutatedContainer.LivenessProbe.HTTPGet.Path,
-							})
-						}
-						if mutatedContainer.ReadinessProbe != nil && mutatedContainer.ReadinessProbe.HTTPGet != nil {
-							originalReadinessPort, err := portValueFromIntOrString(originalPod, originalContainer.ReadinessProbe.HTTPGet.Port)
-							if err != nil {
-								return nil, nil, err
+							if exposePath != nil {
+								proxyConfig.Expose.Paths = append(proxyConfig.Expose.Paths, *exposePath)
 							}
-							proxyConfig.Expose.Paths = append(proxyConfig.Expose.Paths, api.ExposePath{
-								ListenerPort:  mutatedContainer.ReadinessProbe.HTTPGet.Port.IntValue(),
-								LocalPathPort: originalReadinessPort,
-								Path:          mutatedContainer.ReadinessProbe.HTTPGet.Path,
-							})
-						}
-						if mutatedContainer.StartupProbe != nil && mutatedContainer.StartupProbe.HTTPGet != nil {
-							originalStartupPort, err := portValueFromIntOrString(originalPod, originalContainer.StartupProbe.HTTPGet.Port)
+							tlsProbePath, err := tlsProbePathForProbe(originalPod, probes[0], probes[1])
 							if err != nil {
 								return nil, nil, err
 							}
-							proxyConfig.Expose.Paths = append(proxyConfig.Expose.Paths, api.ExposePath{
-								ListenerPort:  mutatedContainer.StartupProbe.HTTPGet.Port.IntValue(),
-								LocalPathPort: originalStartupPort,
-								Path:          mutatedContainer.StartupProbe.HTTPGet.Path,
-							})
+							if tlsProbePath != nil {
+								tlsProbePaths = append(tlsProbePaths, *tlsProbePath)
+							}
 						}
 					}
 				}
 			}
+			if err := addTLSProbeListeners(proxyConfig.Config, wildcardAddress(pod), tlsProbePaths); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// The webhook records whether IPv6 traffic is redirected to the proxy, in which case its public
+		// listener must accept IPv6 connections and outbound IPv6 traffic needs a listener on ::1.
+		ipv6Enabled, err := common.TransparentProxyIPv6Enabled(pod, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ipv6Enabled {
+			proxyConfig.Config[envoyBindAddress] = "::"
+			if err := addIPv6OutboundListener(proxyConfig.Config, iptables.DefaultTProxyOutboundPort); err != nil {
+				return nil, nil, err
+			}
 		}
 	}
 
+	if r.EnableDualStackAddresses {
+		service.TaggedAddresses = podIPTaggedAddresses(service.TaggedAddresses, pod, service.Port)
+		proxyService.TaggedAddresses = podIPTaggedAddresses(proxyService.TaggedAddresses, pod, proxyService.Port)
+	}
+
 	proxyServiceRegistration := &api.CatalogRegistration{
-		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName),
+		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName, r.ClusterName),
 		Address: pod.Status.HostIP,
 		NodeMeta: map[string]string{
 			metaKeySyntheticNode: "true",
@@ -677,6 +1014,188 @@ func (r *Controller) createServiceRegistrations(pod corev1.Pod, serviceEndpoints
 	return serviceRegistration, proxyServiceRegistration, nil
 }
 
+// exposePathForProbe returns the expose path that routes the mutated probe's listener port on the proxy to the
+// original probe's port on the application. It returns nil if the probe was not overwritten by the webhook.
+// HTTPS probes are handled by tlsProbePathForProbe instead.
+// gRPC probes are exposed over HTTP/2 on the standard gRPC health checking path.
+func exposePathForProbe(originalPod corev1.Pod, original, mutated *corev1.Probe) (*api.ExposePath, error) {
+	if original == nil || mutated == nil {
+		return nil, nil
+	}
+	switch {
+	case mutated.HTTPGet != nil && original.HTTPGet != nil && original.HTTPGet.Scheme != corev1.URISchemeHTTPS:
+		originalPort, err := portValueFromIntOrString(originalPod, original.HTTPGet.Port)
+		if err != nil {
+			return nil, err
+		}
+		return &api.ExposePath{
+			ListenerPort:  mutated.HTTPGet.Port.IntValue(),
+			LocalPathPort: originalPort,
+			Path:          mutated.HTTPGet.Path,
+		}, nil
+	case mutated.GRPC != nil && original.GRPC != nil:
+		return &api.ExposePath{
+			ListenerPort:  int(mutated.GRPC.Port),
+			LocalPathPort: int(original.GRPC.Port),
+			Path:          grpcHealthCheckPath,
+			Protocol:      "http2",
+		}, nil
+	}
+	return nil, nil
+}
+
+// tlsProbePathForProbe returns the listener port on the proxy and the original port on the application for an
+// HTTPS probe that was overwritten by the webhook. It returns nil for any other probe.
+// The exposed path listeners terminate plaintext HTTP, so HTTPS probes are instead forwarded as raw TCP to the
+// application, which terminates TLS itself. Only ListenerPort and LocalPathPort are set on the returned path.
+func tlsProbePathForProbe(originalPod corev1.Pod, original, mutated *corev1.Probe) (*api.ExposePath, error) {
+	if original == nil || mutated == nil || original.HTTPGet == nil || mutated.HTTPGet == nil ||
+		original.HTTPGet.Scheme != corev1.URISchemeHTTPS {
+		return nil, nil
+	}
+	originalPort, err := portValueFromIntOrString(originalPod, original.HTTPGet.Port)
+	if err != nil {
+		return nil, err
+	}
+	listenerPort := mutated.HTTPGet.Port.IntValue()
+	if listenerPort == originalPort {
+		// The probe was not overwritten.
+		return nil, nil
+	}
+	return &api.ExposePath{
+		ListenerPort:  listenerPort,
+		LocalPathPort: originalPort,
+	}, nil
+}
+
+// addTLSProbeListeners adds a static Envoy listener and cluster to the proxy config for each HTTPS probe that
+// forwards connections on the probe's listener port to the application on localhost without terminating TLS.
+// The listeners and clusters are appended to any the proxy config already has, e.g. the tracing collector cluster.
+func addTLSProbeListeners(config map[string]interface{}, bindAddress string, paths []api.ExposePath) error {
+	for _, path := range paths {
+		name := fmt.Sprintf("exposed_tls_probe_%d", path.ListenerPort)
+		clusterJSON, err := json.Marshal(map[string]interface{}{
+			"name":            name,
+			"type":            "STATIC",
+			"connect_timeout": "5s",
+			"load_assignment": map[string]interface{}{
+				"cluster_name": name,
+				"endpoints": []interface{}{
+					map[string]interface{}{
+						"lb_endpoints": []interface{}{
+							map[string]interface{}{
+								"endpoint": map[string]interface{}{
+									"address": map[string]interface{}{
+										"socket_address": map[string]interface{}{
+											"address":    "127.0.0.1",
+											"port_value": path.LocalPathPort,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		listenerJSON, err := json.Marshal(map[string]interface{}{
+			"name": name,
+			"address": map[string]interface{}{
+				"socket_address": map[string]interface{}{
+					"address":    bindAddress,
+					"port_value": path.ListenerPort,
+				},
+			},
+			"filter_chains": []interface{}{
+				map[string]interface{}{
+					"filters": []interface{}{
+						map[string]interface{}{
+							"name": "envoy.filters.network.tcp_proxy",
+							"typed_config": map[string]interface{}{
+								"@type":       "type.googleapis.com/envoy.extensions.filters.network.tcp_proxy.v3.TcpProxy",
+								"stat_prefix": name,
+								"cluster":     name,
+							},
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		appendEnvoyJSON(config, envoyExtraStaticClustersJSON, string(clusterJSON))
+		appendEnvoyJSON(config, envoyExtraStaticListenersJSON, string(listenerJSON))
+	}
+	return nil
+}
+
+// addIPv6OutboundListener adds a static Envoy listener on the IPv6 loopback address and the transparent proxy's
+// outbound port, which ip6tables redirects outbound IPv6 traffic to. Consul only binds the outbound listener
+// to 127.0.0.1, so this listener forwards IPv6 connections to their original destination. Mesh upstreams are
+// always dialed through their IPv4 virtual IPs, so only traffic leaving the mesh reaches it.
+func addIPv6OutboundListener(config map[string]interface{}, outboundPort int) error {
+	clusterJSON, err := json.Marshal(map[string]interface{}{
+		"name":            ipv6OutboundClusterName,
+		"type":            "ORIGINAL_DST",
+		"lb_policy":       "CLUSTER_PROVIDED",
+		"connect_timeout": "5s",
+	})
+	if err != nil {
+		return err
+	}
+	listenerJSON, err := json.Marshal(map[string]interface{}{
+		"name": ipv6OutboundListenerName,
+		"address": map[string]interface{}{
+			"socket_address": map[string]interface{}{
+				"address":    "::1",
+				"port_value": outboundPort,
+			},
+		},
+		"traffic_direction": "OUTBOUND",
+		"listener_filters": []interface{}{
+			map[string]interface{}{
+				"name": "envoy.filters.listener.original_dst",
+				"typed_config": map[string]interface{}{
+					"@type": "type.googleapis.com/envoy.extensions.filters.listener.original_dst.v3.OriginalDst",
+				},
+			},
+		},
+		"filter_chains": []interface{}{
+			map[string]interface{}{
+				"filters": []interface{}{
+					map[string]interface{}{
+						"name": "envoy.filters.network.tcp_proxy",
+						"typed_config": map[string]interface{}{
+							"@type":       "type.googleapis.com/envoy.extensions.filters.network.tcp_proxy.v3.TcpProxy",
+							"stat_prefix": ipv6OutboundListenerName,
+							"cluster":     ipv6OutboundClusterName,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	appendEnvoyJSON(config, envoyExtraStaticClustersJSON, string(clusterJSON))
+	appendEnvoyJSON(config, envoyExtraStaticListenersJSON, string(listenerJSON))
+	return nil
+}
+
+// appendEnvoyJSON appends a JSON object to the comma separated list of objects Envoy's bootstrap escape hatches
+// expect under key.
+func appendEnvoyJSON(config map[string]interface{}, key, value string) {
+	if existing, ok := config[key].(string); ok && existing != "" {
+		value = existing + "," + value
+	}
+	config[key] = value
+}
+
 // createGatewayRegistrations creates the gateway service registrations with the information from the Pod.
 func (r *Controller) createGatewayRegistrations(pod corev1.Pod, serviceEndpoints corev1.Endpoints, healthStatus string) (*api.CatalogRegistration, error) {
 	meta := map[string]string{
@@ -688,9 +1207,10 @@ func (r *Controller) createGatewayRegistrations(pod corev1.Pod, serviceEndpoints
 	}
 
 	service := &api.AgentService{
-		ID:      pod.Name,
-		Address: pod.Status.PodIP,
-		Meta:    meta,
+		ID:       pod.Name,
+		Address:  pod.Status.PodIP,
+		Meta:     meta,
+		Locality: r.nodeLocality(pod),
 		Proxy: &api.AgentServiceConnectProxyConfig{
 			Config: map[string]interface{}{},
 		},
@@ -798,7 +1318,7 @@ func (r *Controller) createGatewayRegistrations(pod corev1.Pod, serviceEndpoints
 	}
 
 	serviceRegistration := &api.CatalogRegistration{
-		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName),
+		Node:    common.ConsulNodeNameFromK8sNode(pod.Spec.NodeName, r.ClusterName),
 		Address: pod.Status.HostIP,
 		NodeMeta: map[string]string{
 			metaKeySyntheticNode: "true",
@@ -915,6 +1435,26 @@ func getHealthCheckStatusReason(healthCheckStatus, podName, podNamespace string)
 // them only if they are not in endpointsAddressesMap. If the map is nil, it will deregister all instances. If the map
 // has addresses, it will only deregister instances not in the map.
 func (r *Controller) deregisterService(apiClient *api.Client, k8sSvcName, k8sSvcNamespace string, endpointsAddressesMap map[string]bool) error {
+	return r.deregisterServiceInstances(apiClient, k8sSvcName, k8sSvcNamespace, func(svc *api.AgentService) bool {
+		if endpointsAddressesMap == nil {
+			return true
+		}
+		// If the service address is not in the Endpoints addresses, deregister it.
+		_, ok := endpointsAddressesMap[svc.Address]
+		return !ok
+	})
+}
+
+// deregisterPods deregisters the service instances of the given pods for the Kubernetes service.
+func (r *Controller) deregisterPods(apiClient *api.Client, k8sSvcName, k8sSvcNamespace string, podNames map[string]bool) error {
+	return r.deregisterServiceInstances(apiClient, k8sSvcName, k8sSvcNamespace, func(svc *api.AgentService) bool {
+		return podNames[svc.Meta[constants.MetaKeyPodName]]
+	})
+}
+
+// deregisterServiceInstances deregisters the instances of the Kubernetes service for which shouldDeregister
+// returns true, and deletes their ACL tokens.
+func (r *Controller) deregisterServiceInstances(apiClient *api.Client, k8sSvcName, k8sSvcNamespace string, shouldDeregister func(*api.AgentService) bool) error {
 	// Get services matching metadata.
 	nodesWithSvcs, err := r.serviceInstancesForK8sNodes(apiClient, k8sSvcName, k8sSvcNamespace)
 	if err != nil {
@@ -922,42 +1462,40 @@ func (r *Controller) deregisterService(apiClient *api.Client, k8sSvcName, k8sSvc
 		return err
 	}
 
+	// Instances on the legacy virtual nodes were registered before the cluster name was set and have been
+	// replaced by instances on the nodes named after the cluster, so they're always deregistered.
+	legacyNodesWithSvcs, err := r.serviceInstancesForLegacyK8sNodes(apiClient, k8sSvcName, k8sSvcNamespace)
+	if err != nil {
+		r.Log.Error(err, "failed to get service instances on legacy nodes", "name", k8sSvcName)
+		return err
+	}
+	legacyNodes := make(map[*api.CatalogNodeServiceList]bool, len(legacyNodesWithSvcs))
+	for _, nodeSvcs := range legacyNodesWithSvcs {
+		legacyNodes[nodeSvcs] = true
+	}
+	nodesWithSvcs = append(nodesWithSvcs, legacyNodesWithSvcs...)
+
 	// Deregister each service instance that matches the metadata.
 	for _, nodeSvcs := range nodesWithSvcs {
 		for _, svc := range nodeSvcs.Services {
 			// We need to get services matching "k8s-service-name" and "k8s-namespace" metadata.
-			// If we selectively deregister, only deregister if the address is not in the map. Otherwise, deregister
-			// every service instance.
-			var serviceDeregistered bool
-			if endpointsAddressesMap != nil {
-				if _, ok := endpointsAddressesMap[svc.Address]; !ok {
-					// If the service address is not in the Endpoints addresses, deregister it.
-					r.Log.Info("deregistering service from consul", "svc", svc.ID)
-					_, err = apiClient.Catalog().Deregister(&api.CatalogDeregistration{
-						Node:      nodeSvcs.Node.Node,
-						ServiceID: svc.ID,
-						Namespace: svc.Namespace,
-					}, nil)
-					if err != nil {
-						r.Log.Error(err, "failed to deregister service instance", "id", svc.ID)
-						return err
-					}
-					serviceDeregistered = true
-				}
-			} else {
-				r.Log.Info("deregistering service from consul", "svc", svc.ID)
-				if _, err = apiClient.Catalog().Deregister(&api.CatalogDeregistration{
-					Node:      nodeSvcs.Node.Node,
-					ServiceID: svc.ID,
-					Namespace: svc.Namespace,
-				}, nil); err != nil {
-					r.Log.Error(err, "failed to deregister service instance", "id", svc.ID)
-					return err
-				}
-				serviceDeregistered = true
+			if !legacyNodes[nodeSvcs] && !shouldDeregister(svc) {
+				continue
+			}
+			r.Log.Info("deregistering service from consul", "svc", svc.ID)
+			_, err = apiClient.Catalog().Deregister(&api.CatalogDeregistration{
+				Node:      nodeSvcs.Node.Node,
+				ServiceID: svc.ID,
+				Namespace: svc.Namespace,
+			}, nil)
+			r.serviceInstancesCache().invalidate(nodeSvcs.Node.Node)
+			r.recordDeregistration(nodeSvcs.Node.Node, svc, k8sSvcNamespace, k8sSvcName, err)
+			if err != nil {
+				r.Log.Error(err, "failed to deregister service instance", "id", svc.ID)
+				return err
 			}
 
-			if r.AuthMethod != "" && serviceDeregistered {
+			if r.AuthMethod != "" {
 				r.Log.Info("reconciling ACL tokens for service", "svc", svc.Service)
 				err = r.deleteACLTokensForServiceInstance(apiClient, svc, k8sSvcNamespace, svc.Meta[constants.MetaKeyPodName])
 				if err != nil {
@@ -971,6 +1509,37 @@ func (r *Controller) deregisterService(apiClient *api.Client, k8sSvcName, k8sSvc
 	return nil
 }
 
+// recordRegistration records the registration of a service instance of the Kubernetes service in the audit log.
+func (r *Controller) recordRegistration(registration *api.CatalogRegistration, serviceEndpoints corev1.Endpoints, err error) {
+	r.recordWrite(audit.Event{
+		Operation: audit.OperationUpsert,
+		Resource:  audit.ResourceCatalogService,
+		Name:      fmt.Sprintf("%s/%s", registration.Node, registration.Service.ID),
+		Namespace: registration.Service.Namespace,
+		Partition: registration.Partition,
+		After:     registration,
+	}, serviceEndpoints.Namespace, serviceEndpoints.Name, err)
+}
+
+// recordDeregistration records the deregistration of a service instance of the Kubernetes service in the audit log.
+func (r *Controller) recordDeregistration(node string, svc *api.AgentService, k8sSvcNamespace, k8sSvcName string, err error) {
+	r.recordWrite(audit.Event{
+		Operation: audit.OperationDelete,
+		Resource:  audit.ResourceCatalogService,
+		Name:      fmt.Sprintf("%s/%s", node, svc.ID),
+		Namespace: svc.Namespace,
+		Partition: svc.Partition,
+		Before:    svc,
+	}, k8sSvcNamespace, k8sSvcName, err)
+}
+
+// recordWrite records a write to Consul made for the Kubernetes service in the audit log.
+func (r *Controller) recordWrite(event audit.Event, k8sSvcNamespace, k8sSvcName string, err error) {
+	event.Actor = "controller/endpoints"
+	event.Source = &audit.Source{Kind: "Service", Namespace: k8sSvcNamespace, Name: k8sSvcName}
+	r.Auditor.Record(event, err)
+}
+
 // deleteACLTokensForServiceInstance finds the ACL tokens that belongs to the service instance and deletes it from Consul.
 // It will only check for ACL tokens that have been created with the auth method this controller
 // has been configured with and will only delete tokens for the provided podName.
@@ -1004,7 +1573,14 @@ func (r *Controller) deleteACLTokensForServiceInstance(apiClient *api.Client, sv
 			// If we can't find token's pod, delete it.
 			if tokenPodName == podName {
 				r.Log.Info("deleting ACL token for pod", "name", podName)
-				if _, err := apiClient.ACL().TokenDelete(token.AccessorID, &api.WriteOptions{Namespace: svc.Namespace}); err != nil {
+				_, err := apiClient.ACL().TokenDelete(token.AccessorID, &api.WriteOptions{Namespace: svc.Namespace})
+				r.recordWrite(audit.Event{
+					Operation: audit.OperationDelete,
+					Resource:  audit.ResourceACLToken,
+					Name:      token.AccessorID,
+					Namespace: svc.Namespace,
+				}, k8sNS, svc.Meta[constants.MetaKeyKubeServiceName], err)
+				if err != nil {
 					return fmt.Errorf("failed to delete token from Consul: %s", err)
 				}
 			}
@@ -1016,15 +1592,20 @@ func (r *Controller) deleteACLTokensForServiceInstance(apiClient *api.Client, sv
 // processUpstreams reads the list of upstreams from the Pod annotation and converts them into a list of api.Upstream
 // objects.
 func (r *Controller) processUpstreams(pod corev1.Pod, endpoints corev1.Endpoints) ([]api.Upstream, error) {
-	// In a multiport pod, only the first service's proxy should have upstreams configured. This skips configuring
-	// upstreams on additional services on the pod.
-	mpIdx := getMultiPortIdx(pod, endpoints)
-	if mpIdx > 0 {
-		return []api.Upstream{}, nil
+	// In a multiport pod, each service can set its own upstreams with a per-service annotation. Otherwise only the
+	// first service's proxy has the pod's upstreams configured so that additional proxies don't bind the same ports.
+	annotation := constants.AnnotationUpstreams
+	if mpIdx := getMultiPortIdx(pod, endpoints); mpIdx >= 0 {
+		perService := constants.AnnotationServiceUpstreamsPrefix + serviceName(pod, endpoints)
+		if _, ok := pod.Annotations[perService]; ok {
+			annotation = perService
+		} else if mpIdx > 0 {
+			return []api.Upstream{}, nil
+		}
 	}
 
 	var upstreams []api.Upstream
-	if raw, ok := pod.Annotations[constants.AnnotationUpstreams]; ok && raw != "" {
+	if raw, ok := pod.Annotations[annotation]; ok && raw != "" {
 		for _, raw := range strings.Split(raw, ",") {
 			var upstream api.Upstream
 
@@ -1098,28 +1679,95 @@ func (r *Controller) serviceInstancesForK8sNodes(apiClient *api.Client, k8sServi
 	}
 	for _, node := range nodeList.Items {
 		var nodeServices *api.CatalogNodeServiceList
-		nodeServices, err = r.serviceInstancesForK8SServiceNameAndNamespace(apiClient, k8sServiceName, k8sServiceNamespace, common.ConsulNodeNameFromK8sNode(node.Name))
+		nodeServices, err = r.serviceInstancesForK8SServiceNameAndNamespace(apiClient, k8sServiceName, k8sServiceNamespace, common.ConsulNodeNameFromK8sNode(node.Name, r.ClusterName))
 		serviceList = append(serviceList, nodeServices)
 	}
 
 	return serviceList, err
 }
 
-// serviceInstancesForK8SServiceNameAndNamespace calls Consul's ServicesWithFilter to get the list
-// of services instances that have the provided k8sServiceName and k8sServiceNamespace in their metadata.
+// serviceInstancesForLegacyK8sNodes returns the service instances on the virtual nodes that the Kubernetes
+// nodes had before the cluster name was set, i.e. on `<node>-virtual`. It returns nothing if no cluster name
+// is set, since those are the current nodes then. Legacy node names that are also the current node name of
+// another Kubernetes node are skipped.
+func (r *Controller) serviceInstancesForLegacyK8sNodes(apiClient *api.Client, k8sServiceName, k8sServiceNamespace string) ([]*api.CatalogNodeServiceList, error) {
+	if r.ClusterName == "" {
+		return nil, nil
+	}
+	var nodeList corev1.NodeList
+	if err := r.Client.List(r.Context, &nodeList); err != nil {
+		return nil, err
+	}
+	currentNodes := make(map[string]bool, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		currentNodes[common.ConsulNodeNameFromK8sNode(node.Name, r.ClusterName)] = true
+	}
+
+	var serviceList []*api.CatalogNodeServiceList
+	for _, node := range nodeList.Items {
+		legacyNodeName := common.ConsulNodeNameFromK8sNode(node.Name, "")
+		if currentNodes[legacyNodeName] {
+			continue
+		}
+		nodeServices, err := r.serviceInstancesForK8SServiceNameAndNamespace(apiClient, k8sServiceName, k8sServiceNamespace, legacyNodeName)
+		if err != nil {
+			return nil, err
+		}
+		// The legacy node doesn't exist once its instances have been deregistered.
+		if nodeServices == nil {
+			continue
+		}
+		serviceList = append(serviceList, nodeServices)
+	}
+	return serviceList, nil
+}
+
+// serviceInstancesForK8SServiceNameAndNamespace returns the service instances on the node that have
+// the provided k8sServiceName and k8sServiceNamespace in their metadata. The instances that consul-k8s
+// registered on the node are read with Consul's NodeServiceList, so that the reconciles of all services
+// on the node share the same read, and are cached according to CatalogCacheTTL.
 func (r *Controller) serviceInstancesForK8SServiceNameAndNamespace(apiClient *api.Client, k8sServiceName, k8sServiceNamespace, nodeName string) (*api.CatalogNodeServiceList, error) {
-	var (
-		serviceList *api.CatalogNodeServiceList
-		err         error
-	)
-	filter := fmt.Sprintf(`Meta[%q] == %q and Meta[%q] == %q and Meta[%q] == %q`,
-		metaKeyKubeServiceName, k8sServiceName, constants.MetaKeyKubeNS, k8sServiceNamespace, metaKeyManagedBy, constants.ManagedByValue)
-	if r.EnableConsulNamespaces {
-		serviceList, _, err = apiClient.Catalog().NodeServiceList(nodeName, &api.QueryOptions{Filter: filter, Namespace: namespaces.WildcardNamespace})
-	} else {
-		serviceList, _, err = apiClient.Catalog().NodeServiceList(nodeName, &api.QueryOptions{Filter: filter})
+	nodeServices, err := r.serviceInstancesCache().nodeServiceList(nodeName, func() (*api.CatalogNodeServiceList, error) {
+		filter := fmt.Sprintf(`Meta[%q] == %q`, metaKeyManagedBy, constants.ManagedByValue)
+		var (
+			serviceList *api.CatalogNodeServiceList
+			err         error
+		)
+		if r.EnableConsulNamespaces {
+			serviceList, _, err = apiClient.Catalog().NodeServiceList(nodeName, &api.QueryOptions{Filter: filter, Namespace: namespaces.WildcardNamespace})
+		} else {
+			serviceList, _, err = apiClient.Catalog().NodeServiceList(nodeName, &api.QueryOptions{Filter: filter})
+		}
+		return serviceList, err
+	})
+	if err != nil || nodeServices == nil {
+		return nodeServices, err
 	}
-	return serviceList, err
+
+	// The cached list is shared, so the instances of the service are copied to a new list.
+	serviceList := &api.CatalogNodeServiceList{Node: nodeServices.Node}
+	for _, svc := range nodeServices.Services {
+		if svc.Meta[metaKeyKubeServiceName] == k8sServiceName && svc.Meta[constants.MetaKeyKubeNS] == k8sServiceNamespace {
+			serviceList.Services = append(serviceList.Services, svc)
+		}
+	}
+	return serviceList, nil
+}
+
+// serviceInstancesCache returns the cache of the service instances read by serviceInstancesForK8SServiceNameAndNamespace.
+func (r *Controller) serviceInstancesCache() *catalogCache {
+	r.catalogCacheOnce.Do(func() {
+		r.catalogCache = newCatalogCache(r.CatalogCacheTTL)
+	})
+	return r.catalogCache
+}
+
+// endpointSliceState returns the state of the EndpointSlices reconciled by the controller.
+func (r *Controller) endpointSliceState() *endpointSliceState {
+	r.endpointSliceStateOnce.Do(func() {
+		r.sliceState = newEndpointSliceState(r.EndpointSliceResyncInterval)
+	})
+	return r.sliceState
 }
 
 // processPreparedQueryUpstream processes an upstream in the format:
@@ -1388,3 +2036,67 @@ func getMultiPortIdx(pod corev1.Pod, serviceEndpoints corev1.Endpoints) int {
 	}
 	return -1
 }
+
+// wildcardAddress returns the unspecified address of the pod's primary IP family, which proxies bind to
+// for listeners that must be reachable on the pod IP.
+func wildcardAddress(pod corev1.Pod) string {
+	if ip := net.ParseIP(pod.Status.PodIP); ip != nil && ip.To4() == nil {
+		return "::"
+	}
+	return "0.0.0.0"
+}
+
+// podIPTaggedAddresses returns a copy of taggedAddresses with the first IPv4 and IPv6 address of the pod added
+// as lan_ipv4 and lan_ipv6 tagged addresses on the given port.
+func podIPTaggedAddresses(taggedAddresses map[string]api.ServiceAddress, pod corev1.Pod, port int) map[string]api.ServiceAddress {
+	podIPs := []string{pod.Status.PodIP}
+	for _, podIP := range pod.Status.PodIPs {
+		podIPs = append(podIPs, podIP.IP)
+	}
+
+	result := make(map[string]api.ServiceAddress, len(taggedAddresses)+2)
+	for k, v := range taggedAddresses {
+		result[k] = v
+	}
+	for _, podIP := range podIPs {
+		ip := net.ParseIP(podIP)
+		if ip == nil {
+			continue
+		}
+		name := lanIPv4TaggedAddressName
+		if ip.To4() == nil {
+			name = lanIPv6TaggedAddressName
+		}
+		if _, ok := result[name]; !ok {
+			result[name] = api.ServiceAddress{Address: podIP, Port: port}
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// multiPortHealthStatus returns the health of a single service in a multiport pod. It is critical if the
+// application container that exposes the service's port or its consul-dataplane sidecar isn't ready, and the
+// pod-level health status otherwise, so that the containers never make a service healthier than its pod, e.g.
+// while the pod is terminating.
+func multiPortHealthStatus(pod corev1.Pod, svcName string, port int, podHealthStatus string) string {
+	containers := map[string]bool{
+		fmt.Sprintf("%s-%s", sidecarContainer, svcName): true,
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if int(p.ContainerPort) == port {
+				containers[c.Name] = true
+			}
+		}
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		if containers[status.Name] && !status.Ready {
+			return api.HealthCritical
+		}
+	}
+	return podHealthStatus
+}
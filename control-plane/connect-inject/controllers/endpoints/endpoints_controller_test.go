@@ -5,6 +5,7 @@ package endpoints
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -14,12 +15,15 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/dogstatsd"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
 	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -27,6 +31,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 const (
@@ -628,6 +633,218 @@ func TestProcessUpstreams(t *testing.T) {
 	}
 }
 
+func TestProcessUpstreams_MultiPort(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		annotations map[string]string
+		expected    map[string][]api.Upstream
+	}{
+		"pod upstreams only apply to the first service": {
+			annotations: map[string]string{
+				constants.AnnotationUpstreams: "upstream1:1234",
+			},
+			expected: map[string][]api.Upstream{
+				"web": {
+					{
+						DestinationType: api.UpstreamDestTypeService,
+						DestinationName: "upstream1",
+						LocalBindPort:   1234,
+					},
+				},
+				"web-admin": {},
+			},
+		},
+		"per-service upstreams": {
+			annotations: map[string]string{
+				constants.AnnotationUpstreams:                            "upstream1:1234",
+				constants.AnnotationServiceUpstreamsPrefix + "web-admin": "upstream2:2234",
+			},
+			expected: map[string][]api.Upstream{
+				"web": {
+					{
+						DestinationType: api.UpstreamDestTypeService,
+						DestinationName: "upstream1",
+						LocalBindPort:   1234,
+					},
+				},
+				"web-admin": {
+					{
+						DestinationType: api.UpstreamDestTypeService,
+						DestinationName: "upstream2",
+						LocalBindPort:   2234,
+					},
+				},
+			},
+		},
+		"per-service upstreams override pod upstreams": {
+			annotations: map[string]string{
+				constants.AnnotationUpstreams:                      "upstream1:1234",
+				constants.AnnotationServiceUpstreamsPrefix + "web": "upstream2:2234",
+			},
+			expected: map[string][]api.Upstream{
+				"web": {
+					{
+						DestinationType: api.UpstreamDestTypeService,
+						DestinationName: "upstream2",
+						LocalBindPort:   2234,
+					},
+				},
+				"web-admin": {},
+			},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ep := &Controller{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSetWith(),
+			}
+			pod := createServicePod("pod1", "1.2.3.4", true, true)
+			pod.Annotations[constants.AnnotationService] = "web,web-admin"
+			for k, v := range c.annotations {
+				pod.Annotations[k] = v
+			}
+
+			for svc, exp := range c.expected {
+				upstreams, err := ep.processUpstreams(*pod, corev1.Endpoints{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      svc,
+						Namespace: "default",
+					},
+				})
+				require.NoError(t, err)
+				require.Equal(t, exp, upstreams, svc)
+			}
+		})
+	}
+}
+
+func TestPodIPTaggedAddresses(t *testing.T) {
+	t.Parallel()
+	virtual := api.ServiceAddress{Address: "10.96.0.10", Port: 80}
+	cases := map[string]struct {
+		podIP    string
+		podIPs   []string
+		existing map[string]api.ServiceAddress
+		expected map[string]api.ServiceAddress
+	}{
+		"no pod IPs": {
+			expected: nil,
+		},
+		"IPv4 only": {
+			podIP:  "10.0.0.1",
+			podIPs: []string{"10.0.0.1"},
+			expected: map[string]api.ServiceAddress{
+				lanIPv4TaggedAddressName: {Address: "10.0.0.1", Port: 8080},
+			},
+		},
+		"IPv6 only": {
+			podIP:  "fd00::1",
+			podIPs: []string{"fd00::1"},
+			expected: map[string]api.ServiceAddress{
+				lanIPv6TaggedAddressName: {Address: "fd00::1", Port: 8080},
+			},
+		},
+		"dual-stack keeps existing tagged addresses": {
+			podIP:    "fd00::1",
+			podIPs:   []string{"fd00::1", "10.0.0.1"},
+			existing: map[string]api.ServiceAddress{clusterIPTaggedAddressName: virtual},
+			expected: map[string]api.ServiceAddress{
+				clusterIPTaggedAddressName: virtual,
+				lanIPv4TaggedAddressName:   {Address: "10.0.0.1", Port: 8080},
+				lanIPv6TaggedAddressName:   {Address: "fd00::1", Port: 8080},
+			},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod := corev1.Pod{Status: corev1.PodStatus{PodIP: c.podIP}}
+			for _, ip := range c.podIPs {
+				pod.Status.PodIPs = append(pod.Status.PodIPs, corev1.PodIP{IP: ip})
+			}
+			actual := podIPTaggedAddresses(c.existing, pod, 8080)
+			require.Equal(t, c.expected, actual)
+			if c.existing != nil {
+				require.Len(t, c.existing, 1, "existing tagged addresses must not be modified")
+			}
+		})
+	}
+}
+
+func TestWildcardAddress(t *testing.T) {
+	t.Parallel()
+	require.Equal(t, "0.0.0.0", wildcardAddress(corev1.Pod{}))
+	require.Equal(t, "0.0.0.0", wildcardAddress(corev1.Pod{Status: corev1.PodStatus{PodIP: "10.0.0.1"}}))
+	require.Equal(t, "::", wildcardAddress(corev1.Pod{Status: corev1.PodStatus{PodIP: "fd00::1"}}))
+}
+
+func TestMultiPortHealthStatus(t *testing.T) {
+	t.Parallel()
+	pod := func(appReady, sidecarReady *bool) corev1.Pod {
+		p := corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:  "web",
+						Ports: []corev1.ContainerPort{{ContainerPort: 8080}},
+					},
+					{
+						Name:  "web-admin",
+						Ports: []corev1.ContainerPort{{ContainerPort: 9090}},
+					},
+				},
+			},
+		}
+		p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, corev1.ContainerStatus{Name: "web-admin", Ready: false})
+		if appReady != nil {
+			p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, corev1.ContainerStatus{Name: "web", Ready: *appReady})
+		}
+		if sidecarReady != nil {
+			p.Status.ContainerStatuses = append(p.Status.ContainerStatuses, corev1.ContainerStatus{Name: "consul-dataplane-web", Ready: *sidecarReady})
+		}
+		return p
+	}
+	ready, notReady := true, false
+
+	cases := map[string]struct {
+		pod             corev1.Pod
+		podHealthStatus string
+		expected        string
+	}{
+		"app and sidecar ready": {
+			pod:             pod(&ready, &ready),
+			podHealthStatus: api.HealthPassing,
+			expected:        api.HealthPassing,
+		},
+		"app and sidecar ready, pod critical": {
+			pod:             pod(&ready, &ready),
+			podHealthStatus: api.HealthCritical,
+			expected:        api.HealthCritical,
+		},
+		"app not ready": {
+			pod:             pod(&notReady, &ready),
+			podHealthStatus: api.HealthPassing,
+			expected:        api.HealthCritical,
+		},
+		"sidecar not ready": {
+			pod:             pod(&ready, &notReady),
+			podHealthStatus: api.HealthPassing,
+			expected:        api.HealthCritical,
+		},
+		"no container statuses falls back to the pod status": {
+			pod:             pod(nil, nil),
+			podHealthStatus: api.HealthWarning,
+			expected:        api.HealthWarning,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.expected, multiPortHealthStatus(c.pod, "web", 8080, c.podHealthStatus))
+		})
+	}
+}
+
 func TestGetServiceName(t *testing.T) {
 	t.Parallel()
 	cases := []struct {
@@ -2001,6 +2218,10 @@ func TestReconcileCreateEndpoint(t *testing.T) {
 					ServiceName:    "different-consul-svc-name-sidecar-proxy",
 					ServiceAddress: "1.2.3.4",
 					ServicePort:    20000,
+					ServiceLocality: &api.Locality{
+						Region: "us-west-1",
+						Zone:   "us-west-1a",
+					},
 					ServiceProxy: &api.AgentServiceConnectProxyConfig{
 						DestinationServiceName: "different-consul-svc-name",
 						DestinationServiceID:   "pod1-different-consul-svc-name",
@@ -2222,6 +2443,7 @@ func TestReconcileCreateEndpoint(t *testing.T) {
 				require.Equal(t, tt.expectedProxySvcInstances[i].ServicePort, instance.ServicePort)
 				require.Equal(t, tt.expectedProxySvcInstances[i].ServiceMeta, instance.ServiceMeta)
 				require.Equal(t, tt.expectedProxySvcInstances[i].ServiceTags, instance.ServiceTags)
+				require.Equal(t, tt.expectedProxySvcInstances[i].ServiceLocality, instance.ServiceLocality)
 				if tt.nodeMeta != nil {
 					require.Equal(t, tt.expectedProxySvcInstances[i].NodeMeta, instance.NodeMeta)
 				}
@@ -2282,6 +2504,45 @@ func TestParseLocality(t *testing.T) {
 	})
 }
 
+func TestNodeLocality(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-foo",
+			Labels: map[string]string{
+				corev1.LabelTopologyRegion: "us-west-1",
+				corev1.LabelTopologyZone:   "us-west-1a",
+			},
+		},
+	}
+	ep := &Controller{
+		Client: fake.NewClientBuilder().WithRuntimeObjects(node).Build(),
+		Log:    logrtest.New(t),
+	}
+
+	t.Run("pod on labelled node", func(t *testing.T) {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "node-foo"},
+		}
+		require.Equal(t, &api.Locality{Region: "us-west-1", Zone: "us-west-1a"}, ep.nodeLocality(pod))
+	})
+
+	t.Run("node does not exist", func(t *testing.T) {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "node-bar"},
+		}
+		require.Nil(t, ep.nodeLocality(pod))
+	})
+
+	t.Run("pod not scheduled", func(t *testing.T) {
+		pod := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "default"},
+		}
+		require.Nil(t, ep.nodeLocality(pod))
+	})
+}
+
 // Tests updating an Endpoints object.
 //   - Tests updates via the register codepath:
 //   - When an address in an Endpoint is updated, that the corresponding service instance in Consul is updated.
@@ -3794,6 +4055,125 @@ func TestReconcileUpdateEndpoint_LegacyService(t *testing.T) {
 	}
 }
 
+// TestReconcileUpdateEndpoint_ClusterNameUpgrade tests that setting a cluster name on an existing install
+// moves the service instances from the legacy virtual node of the Kubernetes node to the node named after
+// the cluster, and leaves the instances on the legacy node that consul-k8s doesn't manage alone.
+func TestReconcileUpdateEndpoint_ClusterNameUpgrade(t *testing.T) {
+	t.Parallel()
+
+	pod1 := createServicePod("pod1", "1.2.3.4", true, true)
+	endpoint := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "service-updated",
+			Namespace: "default",
+		},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{
+					{
+						IP: "1.2.3.4",
+						TargetRef: &corev1.ObjectReference{
+							Kind:      "Pod",
+							Name:      "pod1",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		},
+	}
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	fakeClient := fake.NewClientBuilder().WithRuntimeObjects(pod1, endpoint, &ns, &node).Build()
+
+	testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+	consulClient := testClient.APIClient
+
+	// Register the instances of the service the way they were registered without a cluster name,
+	// and a service that consul-k8s doesn't manage on the same node.
+	managedMeta := map[string]string{
+		metaKeyKubeServiceName:   "service-updated",
+		constants.MetaKeyKubeNS:  "default",
+		metaKeyManagedBy:         constants.ManagedByValue,
+		metaKeySyntheticNode:     "true",
+		constants.MetaKeyPodName: "pod1",
+	}
+	initialConsulSvcs := []*api.CatalogRegistration{
+		{
+			Node:    consulNodeName,
+			Address: consulNodeAddress,
+			Service: &api.AgentService{
+				ID:      "pod1-service-updated",
+				Service: "service-updated",
+				Port:    80,
+				Address: "1.2.3.4",
+				Meta:    managedMeta,
+			},
+		},
+		{
+			Node:    consulNodeName,
+			Address: consulNodeAddress,
+			Service: &api.AgentService{
+				Kind:    api.ServiceKindConnectProxy,
+				ID:      "pod1-service-updated-sidecar-proxy",
+				Service: "service-updated-sidecar-proxy",
+				Port:    20000,
+				Address: "1.2.3.4",
+				Meta:    managedMeta,
+				Proxy: &api.AgentServiceConnectProxyConfig{
+					DestinationServiceName: "service-updated",
+					DestinationServiceID:   "pod1-service-updated",
+				},
+			},
+		},
+		{
+			Node:    consulNodeName,
+			Address: consulNodeAddress,
+			Service: &api.AgentService{
+				ID:      "unmanaged",
+				Service: "unmanaged",
+				Port:    80,
+				Address: "1.2.3.5",
+			},
+		},
+	}
+	for _, svc := range initialConsulSvcs {
+		_, err := consulClient.Catalog().Register(svc, nil)
+		require.NoError(t, err)
+	}
+
+	ep := &Controller{
+		Client:                fakeClient,
+		Log:                   logrtest.New(t),
+		ConsulClientConfig:    testClient.Cfg,
+		ConsulServerConnMgr:   testClient.Watcher,
+		AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+		DenyK8sNamespacesSet:  mapset.NewSetWith(),
+		ReleaseName:           "consul",
+		ReleaseNamespace:      "default",
+		ClusterName:           "east",
+	}
+	namespacedName := types.NamespacedName{Namespace: "default", Name: "service-updated"}
+
+	resp, err := ep.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.NoError(t, err)
+	require.False(t, resp.Requeue)
+
+	// The service and its proxy are only registered on the node named after the cluster.
+	for _, svcName := range []string{"service-updated", "service-updated-sidecar-proxy"} {
+		serviceInstances, _, err := consulClient.Catalog().Service(svcName, "", nil)
+		require.NoError(t, err)
+		require.Len(t, serviceInstances, 1)
+		require.Equal(t, "test-node-east-virtual", serviceInstances[0].Node)
+	}
+
+	// The unmanaged service is still registered on the legacy node.
+	unmanagedInstances, _, err := consulClient.Catalog().Service("unmanaged", "", nil)
+	require.NoError(t, err)
+	require.Len(t, unmanagedInstances, 1)
+	require.Equal(t, consulNodeName, unmanagedInstances[0].Node)
+}
+
 // Tests deleting an Endpoints object, with and without matching Consul and K8s service names.
 // This test covers Controller.deregisterService when the map is nil (not selectively deregistered).
 func TestReconcileDeleteEndpoint(t *testing.T) {
@@ -4577,8 +4957,48 @@ func TestCreateServiceRegistrations_withTransparentProxy(t *testing.T) {
 		expTaggedAddresses  map[string]api.ServiceAddress
 		expProxyMode        api.ProxyMode
 		expExposePaths      []api.ExposePath
+		expBindAddress      interface{}
 		expErr              string
 	}{
+		"tproxy enabled with IPv6 traffic redirection": {
+			tproxyGlobalEnabled: true,
+			podContainers: []corev1.Container{
+				{
+					Name: "test",
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "tcp",
+							ContainerPort: 8081,
+						},
+					},
+				},
+			},
+			podAnnotations: map[string]string{
+				constants.AnnotationTransparentProxyIPv6: "true",
+			},
+			service: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serviceName,
+					Namespace: "default",
+				},
+				Spec: corev1.ServiceSpec{
+					ClusterIP: "10.0.0.1",
+					Ports: []corev1.ServicePort{
+						{
+							Port: 8081,
+						},
+					},
+				},
+			},
+			expProxyMode: api.ProxyModeTransparent,
+			expTaggedAddresses: map[string]api.ServiceAddress{
+				"virtual": {
+					Address: "10.0.0.1",
+					Port:    8081,
+				},
+			},
+			expBindAddress: "::",
+		},
 		"tproxy enabled globally, annotation not provided": {
 			tproxyGlobalEnabled: true,
 			podContainers: []corev1.Container{
@@ -5862,11 +6282,231 @@ func TestCreateServiceRegistrations_withTransparentProxy(t *testing.T) {
 				require.Equal(t, c.expTaggedAddresses, serviceRegistration.Service.TaggedAddresses)
 				require.Equal(t, c.expTaggedAddresses, proxyServiceRegistration.Service.TaggedAddresses)
 				require.Equal(t, c.expExposePaths, proxyServiceRegistration.Service.Proxy.Expose.Paths)
+				require.Equal(t, c.expBindAddress, proxyServiceRegistration.Service.Proxy.Config[envoyBindAddress])
+				_, hasIPv6Listener := proxyServiceRegistration.Service.Proxy.Config[envoyExtraStaticListenersJSON]
+				require.Equal(t, c.expBindAddress != nil, hasIPv6Listener)
 			}
 		})
 	}
 }
 
+func TestCreateServiceRegistrations_withDogStatsD(t *testing.T) {
+	t.Parallel()
+
+	pod := createServicePod("test-pod-1", "1.2.3.4", true, true)
+	pod.UID = "11111111-2222-3333-4444-555555555555"
+	pod.Annotations[constants.AnnotationEnvoyDogStatsDTags] = "team:payments"
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "service-created",
+			Namespace: "default",
+		},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{
+					{
+						IP: "1.2.3.4",
+						TargetRef: &corev1.ObjectReference{
+							Kind:      "Pod",
+							Name:      pod.Name,
+							Namespace: pod.Namespace,
+						},
+					},
+				},
+			},
+		},
+	}
+	ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
+
+	epCtrl := Controller{
+		Client:          fake.NewClientBuilder().WithRuntimeObjects(pod, endpoints, &ns).Build(),
+		DogStatsDConfig: dogstatsd.Config{DefaultEnabled: true, PodTags: true},
+		Log:             logrtest.New(t),
+	}
+
+	_, proxyServiceRegistration, err := epCtrl.createServiceRegistrations(*pod, *endpoints, api.HealthPassing)
+	require.NoError(t, err)
+	require.Equal(t, "unix:///var/run/datadog/dsd.socket", proxyServiceRegistration.Service.Proxy.Config["envoy_dogstatsd_url"])
+	require.Equal(t, []string{
+		"service=service-created",
+		"kube_namespace=default",
+		"pod_name=test-pod-1",
+		"dd.internal.entity_id=11111111-2222-3333-4444-555555555555",
+		"team=payments",
+	}, proxyServiceRegistration.Service.Proxy.Config["envoy_stats_tags"])
+}
+
+func TestExposePathForProbe(t *testing.T) {
+	t.Parallel()
+	originalPod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "web",
+					Ports: []corev1.ContainerPort{{Name: "https", ContainerPort: 8443}},
+				},
+			},
+		},
+	}
+	cases := map[string]struct {
+		original *corev1.Probe
+		mutated  *corev1.Probe
+		exp      *api.ExposePath
+	}{
+		"no probe": {},
+		"http probe with named port": {
+			original: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+				Port: intstr.FromString("https"),
+				Path: "/healthz",
+			}}},
+			mutated: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+				Port: intstr.FromInt(20300),
+				Path: "/healthz",
+			}}},
+			exp: &api.ExposePath{ListenerPort: 20300, LocalPathPort: 8443, Path: "/healthz"},
+		},
+		"https probe is not exposed": {
+			original: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+				Port:   intstr.FromString("https"),
+				Path:   "/healthz",
+				Scheme: corev1.URISchemeHTTPS,
+			}}},
+			mutated: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+				Port:   intstr.FromString("https"),
+				Path:   "/healthz",
+				Scheme: corev1.URISchemeHTTPS,
+			}}},
+		},
+		"grpc probe": {
+			original: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: 9090}}},
+			mutated:  &corev1.Probe{ProbeHandler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: 20400}}},
+			exp:      &api.ExposePath{ListenerPort: 20400, LocalPathPort: 9090, Path: grpcHealthCheckPath, Protocol: "http2"},
+		},
+		"tcp probe": {
+			original: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(9090)}}},
+			mutated:  &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(9090)}}},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			actual, err := exposePathForProbe(originalPod, c.original, c.mutated)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, actual)
+		})
+	}
+}
+
+func TestTLSProbePathForProbe(t *testing.T) {
+	t.Parallel()
+	originalPod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "web",
+					Ports: []corev1.ContainerPort{{Name: "https", ContainerPort: 8443}},
+				},
+			},
+		},
+	}
+	cases := map[string]struct {
+		original *corev1.Probe
+		mutated  *corev1.Probe
+		exp      *api.ExposePath
+	}{
+		"no probe": {},
+		"https probe": {
+			original: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+				Port:   intstr.FromString("https"),
+				Path:   "/healthz",
+				Scheme: corev1.URISchemeHTTPS,
+			}}},
+			mutated: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+				Port:   intstr.FromInt(20300),
+				Path:   "/healthz",
+				Scheme: corev1.URISchemeHTTPS,
+			}}},
+			exp: &api.ExposePath{ListenerPort: 20300, LocalPathPort: 8443},
+		},
+		"https probe that was not overwritten": {
+			original: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+				Port:   intstr.FromInt(8443),
+				Scheme: corev1.URISchemeHTTPS,
+			}}},
+			mutated: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+				Port:   intstr.FromInt(8443),
+				Scheme: corev1.URISchemeHTTPS,
+			}}},
+		},
+		"http probe": {
+			original: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Port: intstr.FromInt(8080)}}},
+			mutated:  &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Port: intstr.FromInt(20300)}}},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			actual, err := tlsProbePathForProbe(originalPod, c.original, c.mutated)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, actual)
+		})
+	}
+}
+
+func TestAddTLSProbeListeners(t *testing.T) {
+	t.Parallel()
+	config := map[string]interface{}{
+		envoyExtraStaticClustersJSON: `{"name":"collector"}`,
+	}
+	err := addTLSProbeListeners(config, "0.0.0.0", []api.ExposePath{
+		{ListenerPort: 20300, LocalPathPort: 8443},
+		{ListenerPort: 20400, LocalPathPort: 8443},
+	})
+	require.NoError(t, err)
+
+	var clusters []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte("["+config[envoyExtraStaticClustersJSON].(string)+"]"), &clusters))
+	require.Len(t, clusters, 3)
+	require.Equal(t, "collector", clusters[0]["name"])
+	require.Equal(t, "exposed_tls_probe_20300", clusters[1]["name"])
+	require.Equal(t, "exposed_tls_probe_20400", clusters[2]["name"])
+	endpoint := clusters[1]["load_assignment"].(map[string]interface{})["endpoints"].([]interface{})[0].(map[string]interface{})["lb_endpoints"].([]interface{})[0].(map[string]interface{})["endpoint"].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"address": "127.0.0.1", "port_value": float64(8443)},
+		endpoint["address"].(map[string]interface{})["socket_address"])
+
+	var listeners []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte("["+config[envoyExtraStaticListenersJSON].(string)+"]"), &listeners))
+	require.Len(t, listeners, 2)
+	require.Equal(t, "exposed_tls_probe_20300", listeners[0]["name"])
+	require.Equal(t, map[string]interface{}{"address": "0.0.0.0", "port_value": float64(20300)},
+		listeners[0]["address"].(map[string]interface{})["socket_address"])
+	filter := listeners[0]["filter_chains"].([]interface{})[0].(map[string]interface{})["filters"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "envoy.filters.network.tcp_proxy", filter["name"])
+	require.Equal(t, "exposed_tls_probe_20300", filter["typed_config"].(map[string]interface{})["cluster"])
+}
+
+func TestAddIPv6OutboundListener(t *testing.T) {
+	t.Parallel()
+	config := map[string]interface{}{}
+	require.NoError(t, addIPv6OutboundListener(config, 15001))
+
+	var cluster map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(config[envoyExtraStaticClustersJSON].(string)), &cluster))
+	require.Equal(t, "original-destination-ipv6", cluster["name"])
+	require.Equal(t, "ORIGINAL_DST", cluster["type"])
+	require.Equal(t, "CLUSTER_PROVIDED", cluster["lb_policy"])
+
+	var listener map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(config[envoyExtraStaticListenersJSON].(string)), &listener))
+	require.Equal(t, "outbound_listener_ipv6", listener["name"])
+	require.Equal(t, map[string]interface{}{"address": "::1", "port_value": float64(15001)},
+		listener["address"].(map[string]interface{})["socket_address"])
+	require.Equal(t, "envoy.filters.listener.original_dst",
+		listener["listener_filters"].([]interface{})[0].(map[string]interface{})["name"])
+	filter := listener["filter_chains"].([]interface{})[0].(map[string]interface{})["filters"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "original-destination-ipv6", filter["typed_config"].(map[string]interface{})["cluster"])
+}
+
 func TestGetTokenMetaFromDescription(t *testing.T) {
 	t.Parallel()
 	cases := map[string]struct {
@@ -5960,6 +6600,195 @@ func TestMapAddresses(t *testing.T) {
 	}
 }
 
+func TestServiceSlices_Merged(t *testing.T) {
+	t.Parallel()
+	ready := true
+	notReady := false
+	node := nodeName
+	podRef := func(name string) *corev1.ObjectReference {
+		return &corev1.ObjectReference{Kind: "Pod", Name: name, Namespace: "default"}
+	}
+
+	cases := map[string]struct {
+		slices   []discoveryv1.EndpointSlice
+		expected map[string]string
+	}{
+		"ready, not ready and unknown conditions": {
+			slices: []discoveryv1.EndpointSlice{
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"1.2.3.4"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}, NodeName: &node, TargetRef: podRef("pod1")},
+						{Addresses: []string{"2.2.3.4"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady}, NodeName: &node, TargetRef: podRef("pod2")},
+						{Addresses: []string{"3.2.3.4"}, NodeName: &node, TargetRef: podRef("pod3")},
+					},
+				},
+			},
+			expected: map[string]string{
+				"1.2.3.4/pod1/test-node": api.HealthPassing,
+				"2.2.3.4/pod2/test-node": api.HealthCritical,
+				"3.2.3.4/pod3/test-node": api.HealthPassing,
+			},
+		},
+		"endpoints across multiple slices": {
+			slices: []discoveryv1.EndpointSlice{
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"1.2.3.4"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}, TargetRef: podRef("pod1")},
+					},
+				},
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"2.2.3.4"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}, TargetRef: podRef("pod2")},
+					},
+				},
+			},
+			expected: map[string]string{
+				"1.2.3.4/pod1/": api.HealthPassing,
+				"2.2.3.4/pod2/": api.HealthPassing,
+			},
+		},
+		"pod in more than one slice is passing if ready in any": {
+			slices: []discoveryv1.EndpointSlice{
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"1.2.3.4"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}, TargetRef: podRef("pod1")},
+					},
+				},
+				{
+					AddressType: discoveryv1.AddressTypeIPv6,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"fd00::1"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady}, TargetRef: podRef("pod1")},
+					},
+				},
+			},
+			expected: map[string]string{
+				"1.2.3.4/pod1/": api.HealthPassing,
+			},
+		},
+		"FQDN slices and empty endpoints are skipped": {
+			slices: []discoveryv1.EndpointSlice{
+				{
+					AddressType: discoveryv1.AddressTypeFQDN,
+					Endpoints: []discoveryv1.Endpoint{
+						{Addresses: []string{"example.com"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}},
+					},
+				},
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints: []discoveryv1.Endpoint{
+						{Conditions: discoveryv1.EndpointConditions{Ready: &ready}, TargetRef: podRef("pod1")},
+					},
+				},
+			},
+			expected: map[string]string{},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Addresses hold pointers, so compare them by the fields that identify them.
+			actual := make(map[string]string)
+			// Slices are keyed by name, so name them by their index.
+			for i := range c.slices {
+				c.slices[i].Name = fmt.Sprintf("slice-%d", i)
+			}
+			for _, e := range newServiceSlices(c.slices).merged() {
+				address, status := e.address, e.health
+				var podName, node string
+				if address.TargetRef != nil {
+					podName = address.TargetRef.Name
+				}
+				if address.NodeName != nil {
+					node = *address.NodeName
+				}
+				actual[fmt.Sprintf("%s/%s/%s", address.IP, podName, node)] = status
+			}
+			require.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestEndpointsFromSlices(t *testing.T) {
+	t.Parallel()
+	ready := true
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "service-created",
+			Namespace: "default",
+			Labels:    map[string]string{constants.LabelServiceIgnore: "true"},
+		},
+	}
+	slice := func(name, svcName string, ip string) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: svcName},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{ip}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}},
+			},
+		}
+	}
+
+	fakeClient := fake.NewClientBuilder().WithObjects(
+		service,
+		slice("service-created-abc", "service-created", "1.2.3.4"),
+		slice("service-created-def", "service-created", "2.2.3.4"),
+		slice("other-abc", "other", "3.2.3.4"),
+	).Build()
+	ep := Controller{Client: fakeClient}
+
+	serviceEndpoints, slices, err := ep.endpointsFromSlices(context.Background(), types.NamespacedName{Name: "service-created", Namespace: "default"})
+	require.NoError(t, err)
+	addresses := make(map[corev1.EndpointAddress]string)
+	for _, e := range slices.merged() {
+		addresses[e.address] = e.health
+	}
+	require.Equal(t, "service-created", serviceEndpoints.Name)
+	require.Equal(t, "default", serviceEndpoints.Namespace)
+	require.Equal(t, service.Labels, serviceEndpoints.Labels)
+	require.Equal(t, map[corev1.EndpointAddress]string{
+		{IP: "1.2.3.4"}: api.HealthPassing,
+		{IP: "2.2.3.4"}: api.HealthPassing,
+	}, addresses)
+
+	_, _, err = ep.endpointsFromSlices(context.Background(), types.NamespacedName{Name: "missing", Namespace: "default"})
+	require.True(t, k8serrors.IsNotFound(err))
+
+	require.Equal(t,
+		[]reconcile.Request{{NamespacedName: types.NamespacedName{Name: "service-created", Namespace: "default"}}},
+		requestsForEndpointSlice(slice("service-created-abc", "service-created", "1.2.3.4")))
+	require.Nil(t, requestsForEndpointSlice(&discoveryv1.EndpointSlice{}))
+}
+
+func TestOwnsNamespace(t *testing.T) {
+	t.Parallel()
+	namespaces := []string{"default", "kube-system", "team-a", "team-b", "team-c", "prod"}
+
+	unsharded := Controller{}
+	for _, ns := range namespaces {
+		require.True(t, unsharded.ownsNamespace(ns))
+	}
+
+	// Every namespace must be owned by exactly one shard.
+	shardCount := 3
+	for _, ns := range namespaces {
+		owners := 0
+		for i := 0; i < shardCount; i++ {
+			if (&Controller{ShardCount: shardCount, ShardIndex: i}).ownsNamespace(ns) {
+				owners++
+			}
+		}
+		require.Equal(t, 1, owners, "namespace %s", ns)
+	}
+}
+
 func Test_GetWANData(t *testing.T) {
 	cases := map[string]struct {
 		gatewayPod      corev1.Pod
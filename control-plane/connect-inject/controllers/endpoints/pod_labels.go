@@ -0,0 +1,176 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const maxMetaKeyLength = 128
+
+var (
+	// metaKeyRegex matches the keys that Consul accepts in service metadata.
+	metaKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	// invalidMetaKeyChars matches the characters that aren't allowed in metadata keys.
+	invalidMetaKeyChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+)
+
+// ValidateMetaKey returns an error if Consul doesn't accept key as a key of service metadata.
+func ValidateMetaKey(key string) error {
+	if len(key) > maxMetaKeyLength || !metaKeyRegex.MatchString(key) {
+		return fmt.Errorf("%q is not a valid service metadata key: it must be at most %d characters long and only contain letters, digits, '_' and '-'", key, maxMetaKeyLength)
+	}
+	if strings.HasPrefix(key, "consul-") {
+		return fmt.Errorf("%q is not a valid service metadata key: the prefix \"consul-\" is reserved", key)
+	}
+	return nil
+}
+
+// labelTags appends the values of the pod labels that are registered as tags with the service to
+// tags, skipping the values that are already tags. The labels are read from the
+// AnnotationLabelsToTags annotation, or from LabelsToTags if it isn't set.
+func (r *Controller) labelTags(pod corev1.Pod, tags []string) []string {
+	labels := r.LabelsToTags
+	if raw, ok := pod.Annotations[constants.AnnotationLabelsToTags]; ok {
+		labels = splitLabelList(raw)
+	}
+	for _, label := range labels {
+		if value := pod.Labels[label]; value != "" && !slices.Contains(tags, value) {
+			tags = append(tags, value)
+		}
+	}
+	return tags
+}
+
+// labelMeta returns the metadata added to the service from the pod labels. The labels and their
+// metadata keys are read from the AnnotationLabelsToMeta annotation, or from LabelsToMeta if it
+// isn't set.
+func (r *Controller) labelMeta(pod corev1.Pod) (map[string]string, error) {
+	labelsToMeta := r.LabelsToMeta
+	if raw, ok := pod.Annotations[constants.AnnotationLabelsToMeta]; ok {
+		labelsToMeta = make(map[string]string)
+		for _, entry := range splitLabelList(raw) {
+			label, key, found := strings.Cut(entry, "=")
+			label, key = strings.TrimSpace(label), strings.TrimSpace(key)
+			if !found {
+				key = invalidMetaKeyChars.ReplaceAllString(label, "_")
+			}
+			if err := ValidateMetaKey(key); err != nil {
+				return nil, fmt.Errorf("invalid annotation %s: %w", constants.AnnotationLabelsToMeta, err)
+			}
+			labelsToMeta[label] = key
+		}
+	}
+	meta := make(map[string]string)
+	for label, key := range labelsToMeta {
+		if value, ok := pod.Labels[label]; ok {
+			meta[key] = value
+		}
+	}
+	return meta, nil
+}
+
+// splitLabelList splits a comma separated list of labels, dropping empty entries.
+func splitLabelList(raw string) []string {
+	var labels []string
+	for _, label := range strings.Split(raw, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// podLabelsChanged only lets through updates that change the labels of an injected pod, so that
+// the tags and metadata derived from them are kept in sync. Other changes of the pod are already
+// reflected in its Endpoints.
+var podLabelsChanged = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return false
+		}
+		if e.ObjectNew.GetAnnotations()[constants.KeyInjectStatus] != constants.Injected {
+			return false
+		}
+		return !reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+	},
+}
+
+// requestsForPod maps a pod to requests for the services whose endpoints include it.
+func (r *Controller) requestsForPod(object client.Object) []reconcile.Request {
+	isPod := func(ref *corev1.ObjectReference) bool {
+		return ref != nil && ref.Kind == "Pod" && ref.Name == object.GetName()
+	}
+
+	var requests []reconcile.Request
+	if r.EnableEndpointSlices {
+		var sliceList discoveryv1.EndpointSliceList
+		if err := r.Client.List(r.Context, &sliceList, client.InNamespace(object.GetNamespace())); err != nil {
+			r.Log.Error(err, "failed to list EndpointSlices", "pod", object.GetName(), "ns", object.GetNamespace())
+			return nil
+		}
+		seen := make(map[string]bool)
+		for _, slice := range sliceList.Items {
+			svcName := slice.Labels[discoveryv1.LabelServiceName]
+			if svcName == "" || seen[svcName] {
+				continue
+			}
+			for _, endpoint := range slice.Endpoints {
+				if isPod(endpoint.TargetRef) {
+					seen[svcName] = true
+					svc := types.NamespacedName{Name: svcName, Namespace: slice.Namespace}
+					// The pod's endpoints didn't change, so it has to be registered again explicitly.
+					r.endpointSliceState().markDirty(svc, types.NamespacedName{Name: object.GetName(), Namespace: object.GetNamespace()})
+					requests = append(requests, reconcile.Request{NamespacedName: svc})
+					break
+				}
+			}
+		}
+		return requests
+	}
+
+	var endpointsList corev1.EndpointsList
+	if err := r.Client.List(r.Context, &endpointsList, client.InNamespace(object.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list Endpoints", "pod", object.GetName(), "ns", object.GetNamespace())
+		return nil
+	}
+	for _, endpoints := range endpointsList.Items {
+		if endpointsIncludePod(endpoints, isPod) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: endpoints.Name, Namespace: endpoints.Namespace}})
+		}
+	}
+	return requests
+}
+
+func endpointsIncludePod(endpoints corev1.Endpoints, isPod func(*corev1.ObjectReference) bool) bool {
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if isPod(address.TargetRef) {
+				return true
+			}
+		}
+		for _, address := range subset.NotReadyAddresses {
+			if isPod(address.TargetRef) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package endpoints
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestCreateServiceRegistrations_labelsToTagsAndMeta(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		annotations  map[string]string
+		labelsToTags []string
+		labelsToMeta map[string]string
+		expTags      []string
+		expMeta      map[string]string
+		expErr       string
+	}{
+		"no labels mapped": {
+			expMeta: map[string]string{},
+		},
+		"labels mapped by the controller defaults": {
+			labelsToTags: []string{"version", "missing"},
+			labelsToMeta: map[string]string{"app.kubernetes.io/version": "version", "missing": "missing"},
+			expTags:      []string{"v2"},
+			expMeta:      map[string]string{"version": "2.1.0"},
+		},
+		"annotations override the controller defaults": {
+			annotations: map[string]string{
+				constants.AnnotationLabelsToTags: "tier",
+				constants.AnnotationLabelsToMeta: "tier, app.kubernetes.io/version",
+			},
+			labelsToTags: []string{"version"},
+			labelsToMeta: map[string]string{"version": "version"},
+			expTags:      []string{"backend"},
+			expMeta:      map[string]string{"tier": "backend", "app_kubernetes_io_version": "2.1.0"},
+		},
+		"annotations map labels to meta keys": {
+			annotations: map[string]string{
+				constants.AnnotationLabelsToMeta: "app.kubernetes.io/version=release",
+			},
+			expMeta: map[string]string{"release": "2.1.0"},
+		},
+		"service tags and meta annotations take precedence": {
+			annotations: map[string]string{
+				constants.AnnotationTags:          "abc",
+				constants.AnnotationMeta + "tier": "frontend",
+				constants.AnnotationLabelsToMeta:  "tier,version=pod-name",
+			},
+			labelsToTags: []string{"tier"},
+			expTags:      []string{"abc", "backend"},
+			expMeta:      map[string]string{"tier": "frontend"},
+		},
+		"label values that are already tags are not duplicated": {
+			annotations: map[string]string{
+				constants.AnnotationTags:         "backend,abc",
+				constants.AnnotationLabelsToTags: "tier,version,role",
+			},
+			expTags: []string{"backend", "abc", "v2"},
+			expMeta: map[string]string{},
+		},
+		"invalid meta key": {
+			annotations: map[string]string{
+				constants.AnnotationLabelsToMeta: "version=consul-version",
+			},
+			expErr: `invalid annotation consul.hashicorp.com/service-labels-to-meta: "consul-version" is not a valid service metadata key`,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			pod := createServicePod("pod1", "1.2.3.4", true, true)
+			pod.Labels["version"] = "v2"
+			pod.Labels["tier"] = "backend"
+			pod.Labels["role"] = "v2"
+			pod.Labels["app.kubernetes.io/version"] = "2.1.0"
+			for k, v := range c.annotations {
+				pod.Annotations[k] = v
+			}
+			endpoints := corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "service-created", Namespace: "default"}}
+			ns := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: pod.Namespace}}
+
+			epCtrl := Controller{
+				Client:       fake.NewClientBuilder().WithRuntimeObjects(pod, &ns).Build(),
+				LabelsToTags: c.labelsToTags,
+				LabelsToMeta: c.labelsToMeta,
+				Log:          logrtest.New(t),
+			}
+			serviceRegistration, proxyServiceRegistration, err := epCtrl.createServiceRegistrations(*pod, endpoints, api.HealthPassing)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, registration := range []*api.CatalogRegistration{serviceRegistration, proxyServiceRegistration} {
+				require.Equal(t, c.expTags, registration.Service.Tags)
+				// The metadata of the controller is always set.
+				require.Equal(t, "pod1", registration.Service.Meta[constants.MetaKeyPodName])
+				for k, v := range c.expMeta {
+					require.Equal(t, v, registration.Service.Meta[k])
+				}
+			}
+			require.Len(t, serviceRegistration.Service.Meta, 5+len(c.expMeta))
+		})
+	}
+}
+
+func TestPodLabelsChanged(t *testing.T) {
+	t.Parallel()
+	injected := createServicePod("pod1", "1.2.3.4", true, true)
+	relabeled := injected.DeepCopy()
+	relabeled.Labels["version"] = "v2"
+	notInjected := createServicePod("pod1", "1.2.3.4", false, false)
+	notInjectedRelabeled := notInjected.DeepCopy()
+	notInjectedRelabeled.Labels["version"] = "v2"
+	annotated := injected.DeepCopy()
+	annotated.Annotations["foo"] = "bar"
+
+	require.True(t, podLabelsChanged.Update(event.UpdateEvent{ObjectOld: injected, ObjectNew: relabeled}))
+	require.False(t, podLabelsChanged.Update(event.UpdateEvent{ObjectOld: injected, ObjectNew: annotated}))
+	require.False(t, podLabelsChanged.Update(event.UpdateEvent{ObjectOld: notInjected, ObjectNew: notInjectedRelabeled}))
+	require.False(t, podLabelsChanged.Create(event.CreateEvent{Object: injected}))
+	require.False(t, podLabelsChanged.Delete(event.DeleteEvent{Object: injected}))
+}
+
+func TestRequestsForPod(t *testing.T) {
+	t.Parallel()
+	pod := createServicePod("pod1", "1.2.3.4", true, true)
+	podRef := &corev1.ObjectReference{Kind: "Pod", Name: pod.Name, Namespace: pod.Namespace}
+	otherRef := &corev1.ObjectReference{Kind: "Pod", Name: "pod2", Namespace: pod.Namespace}
+
+	t.Run("Endpoints", func(t *testing.T) {
+		t.Parallel()
+		endpoints := func(name string, ready, notReady *corev1.ObjectReference) *corev1.Endpoints {
+			subset := corev1.EndpointSubset{}
+			if ready != nil {
+				subset.Addresses = []corev1.EndpointAddress{{IP: "1.2.3.4", TargetRef: ready}}
+			}
+			if notReady != nil {
+				subset.NotReadyAddresses = []corev1.EndpointAddress{{IP: "1.2.3.4", TargetRef: notReady}}
+			}
+			return &corev1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: pod.Namespace},
+				Subsets:    []corev1.EndpointSubset{subset},
+			}
+		}
+		epCtrl := Controller{
+			Client: fake.NewClientBuilder().WithRuntimeObjects(
+				endpoints("ready", podRef, nil),
+				endpoints("not-ready", otherRef, podRef),
+				endpoints("other", otherRef, nil),
+			).Build(),
+			Log:     logrtest.New(t),
+			Context: context.Background(),
+		}
+		require.ElementsMatch(t, []reconcile.Request{
+			{NamespacedName: types.NamespacedName{Name: "ready", Namespace: pod.Namespace}},
+			{NamespacedName: types.NamespacedName{Name: "not-ready", Namespace: pod.Namespace}},
+		}, epCtrl.requestsForPod(pod))
+	})
+
+	t.Run("EndpointSlices", func(t *testing.T) {
+		t.Parallel()
+		slice := func(name, svcName string, ref *corev1.ObjectReference) *discoveryv1.EndpointSlice {
+			return &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: pod.Namespace,
+					Labels:    map[string]string{discoveryv1.LabelServiceName: svcName},
+				},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"1.2.3.4"}, TargetRef: ref}},
+			}
+		}
+		epCtrl := Controller{
+			Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(
+				slice("svc-a-1", "svc-a", podRef),
+				slice("svc-a-2", "svc-a", podRef),
+				slice("svc-b-1", "svc-b", otherRef),
+			).Build(),
+			EnableEndpointSlices: true,
+			Log:                  logrtest.New(t),
+			Context:              context.Background(),
+		}
+		require.Equal(t, []reconcile.Request{
+			{NamespacedName: types.NamespacedName{Name: "svc-a", Namespace: pod.Namespace}},
+		}, epCtrl.requestsForPod(pod))
+
+		// The pod is registered again on the next reconcile of the service.
+		_, dirtyPods, _ := epCtrl.endpointSliceState().get(types.NamespacedName{Name: "svc-a", Namespace: pod.Namespace})
+		require.Equal(t, map[string]bool{pod.Namespace + "/" + pod.Name: true}, dirtyPods)
+	})
+}
@@ -0,0 +1,598 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package networkpolicy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/common"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// GeneratedLabel is set to "true" on the ServiceIntentions generated from
+	// NetworkPolicies. ServiceIntentions without it are never modified.
+	GeneratedLabel = "consul.hashicorp.com/generated-from-network-policy"
+
+	// NamespaceSelectorIndex indexes the NetworkPolicies that allow traffic
+	// from pods selected by namespace labels, which can change when Services,
+	// pods and namespaces in other namespaces do.
+	NamespaceSelectorIndex = "__networkpolicy_with_namespace_selector"
+
+	// namePrefix is the prefix of the names of the generated ServiceIntentions.
+	// They are named after their destination service, separated by a dot so
+	// that they can't collide with each other: Service names contain no dots.
+	namePrefix = "networkpolicy"
+
+	wildcard = "*"
+)
+
+// Controller generates ServiceIntentions from the ingress rules of the
+// NetworkPolicies in a namespace, so that teams that already restrict traffic
+// with NetworkPolicies get the same authorization in the mesh.
+//
+// The pods that a NetworkPolicy selects are mapped to the Services that select
+// them: a Service is selected if the labels of its selector match the pod
+// selector. Like the services registered for the pods, the destinations are
+// named after the connect-service annotation of the pods if it is set. Each
+// destination gets one ServiceIntentions that allows the sources of all the
+// policies that select it. A policy that selects all pods of a
+// namespace allows its sources to the wildcard destination of the namespace
+// instead, if the namespace is mirrored to its own Consul namespace. Ports are
+// ignored, as are IP blocks, which don't identify services. Since
+// NetworkPolicies only allow traffic, the intentions only take effect if
+// intentions deny traffic by default.
+type Controller struct {
+	client.Client
+	// Log is the logger for this controller.
+	Log logr.Logger
+
+	// NetworkPolicies in the AllowK8sNamespacesSet are translated.
+	AllowK8sNamespacesSet mapset.Set
+	// NetworkPolicies in the DenyK8sNamespacesSet are ignored.
+	DenyK8sNamespacesSet mapset.Set
+
+	// EnableConsulNamespaces indicates that a user is running Consul Enterprise
+	// with version 1.7+ which supports namespaces.
+	EnableConsulNamespaces bool
+	// ConsulDestinationNamespace is the name of the Consul namespace services
+	// are registered in if namespace mirroring is disabled.
+	ConsulDestinationNamespace string
+	// EnableNSMirroring causes Consul namespaces to be created to match the
+	// k8s namespace of services.
+	EnableNSMirroring bool
+	// NSMirroringPrefix is prepended to Consul namespaces created when mirroring.
+	NSMirroringPrefix string
+}
+
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=consul.hashicorp.com,resources=serviceintentions,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile generates the ServiceIntentions of the namespace that the request
+// is named after, and deletes the ones that are no longer needed.
+func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	namespace := req.Name
+
+	desired := make(map[string]*v1alpha1.ServiceIntentions)
+	if !shouldIgnore(namespace, r.DenyK8sNamespacesSet, r.AllowK8sNamespacesSet) {
+		var err error
+		desired, err = r.desiredIntentions(ctx, namespace)
+		if err != nil {
+			r.Log.Error(err, "failed to translate NetworkPolicies", "ns", namespace)
+			return ctrl.Result{}, err
+		}
+		if err := r.skipUserDestinations(ctx, desired); err != nil {
+			r.Log.Error(err, "failed to list ServiceIntentions")
+			return ctrl.Result{}, err
+		}
+	}
+
+	var existing v1alpha1.ServiceIntentionsList
+	if err := r.Client.List(ctx, &existing, client.InNamespace(namespace), client.MatchingLabels{GeneratedLabel: "true"}); err != nil {
+		r.Log.Error(err, "failed to list ServiceIntentions", "ns", namespace)
+		return ctrl.Result{}, err
+	}
+
+	var errs []error
+	for i := range existing.Items {
+		current := &existing.Items[i]
+		intentions, ok := desired[current.Name]
+		if !ok {
+			r.Log.Info("deleting ServiceIntentions", "name", current.Name, "ns", namespace)
+			if err := r.Client.Delete(ctx, current); err != nil && !k8serrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		delete(desired, current.Name)
+		if equality.Semantic.DeepEqual(current.Spec, intentions.Spec) {
+			continue
+		}
+		r.Log.Info("updating ServiceIntentions", "name", current.Name, "ns", namespace)
+		current.Spec = intentions.Spec
+		if err := r.Client.Update(ctx, current); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, intentions := range desired {
+		r.Log.Info("creating ServiceIntentions", "name", intentions.Name, "ns", namespace)
+		err := r.Client.Create(ctx, intentions)
+		if k8serrors.IsAlreadyExists(err) {
+			// ServiceIntentions created by users are left alone.
+			r.Log.Info("skipping ServiceIntentions that weren't generated from NetworkPolicies", "name", intentions.Name, "ns", namespace)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		err := fmt.Errorf("failed to write ServiceIntentions: %v", errs)
+		r.Log.Error(err, "failed to write ServiceIntentions", "ns", namespace)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// desiredIntentions returns the ServiceIntentions generated from the
+// NetworkPolicies in namespace, keyed by their names.
+func (r *Controller) desiredIntentions(ctx context.Context, namespace string) (map[string]*v1alpha1.ServiceIntentions, error) {
+	var policies networkingv1.NetworkPolicyList
+	if err := r.Client.List(ctx, &policies, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	// sourcesByDestination maps destination service names to their sources.
+	sourcesByDestination := make(map[string]sourceSet)
+	for _, policy := range policies.Items {
+		if !appliesToIngress(policy) {
+			continue
+		}
+		destinations, err := r.destinations(ctx, namespace, policy.Spec.PodSelector)
+		if err != nil {
+			return nil, err
+		}
+		if len(destinations) == 0 {
+			continue
+		}
+		sources := make(sourceSet)
+		for _, rule := range policy.Spec.Ingress {
+			if len(rule.From) == 0 {
+				sources.add(wildcard, r.sourceNamespace(wildcard), policy.Name)
+				continue
+			}
+			for _, peer := range rule.From {
+				if err := r.addSources(ctx, sources, namespace, peer, policy.Name); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for _, destination := range destinations {
+			if sourcesByDestination[destination] == nil {
+				sourcesByDestination[destination] = make(sourceSet)
+			}
+			sourcesByDestination[destination].merge(sources)
+		}
+	}
+
+	// Intentions of a service take precedence over the wildcard intentions,
+	// while NetworkPolicies that select the same pods are additive.
+	if wildcardSources, ok := sourcesByDestination[wildcard]; ok {
+		for destination, sources := range sourcesByDestination {
+			if destination != wildcard {
+				sources.merge(wildcardSources)
+			}
+		}
+	}
+
+	destinationNamespace := namespaces.ConsulNamespace(namespace, r.EnableConsulNamespaces, r.ConsulDestinationNamespace, r.EnableNSMirroring, r.NSMirroringPrefix)
+	intentions := make(map[string]*v1alpha1.ServiceIntentions)
+	for destination, sources := range sourcesByDestination {
+		if len(sources) == 0 {
+			continue
+		}
+		name := intentionsName(destination)
+		intentions[name] = &v1alpha1.ServiceIntentions{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{GeneratedLabel: "true"},
+			},
+			Spec: v1alpha1.ServiceIntentionsSpec{
+				Destination: v1alpha1.IntentionDestination{
+					Name:      destination,
+					Namespace: destinationNamespace,
+				},
+				Sources: sources.toSourceIntentions(),
+			},
+		}
+	}
+	return intentions, nil
+}
+
+// skipUserDestinations removes the ServiceIntentions from desired whose
+// destination already has ServiceIntentions that weren't generated from
+// NetworkPolicies. The webhook rejects a second ServiceIntentions for the same
+// destination, and the ones created by users take precedence.
+func (r *Controller) skipUserDestinations(ctx context.Context, desired map[string]*v1alpha1.ServiceIntentions) error {
+	if len(desired) == 0 {
+		return nil
+	}
+	var list v1alpha1.ServiceIntentionsList
+	if err := r.Client.List(ctx, &list); err != nil {
+		return err
+	}
+	for _, existing := range list.Items {
+		// Intentions in other partitions don't conflict with the generated
+		// ones, which are written to the partition of the controller.
+		if existing.Labels[GeneratedLabel] == "true" || common.PartitionAnnotation(&existing) != "" {
+			continue
+		}
+		for name, intentions := range desired {
+			if r.sameDestination(existing.Spec.Destination, intentions.Spec.Destination) {
+				r.Log.Info("skipping NetworkPolicies of a destination that already has ServiceIntentions",
+					"destination", intentions.Spec.Destination.Name, "ns", intentions.Namespace,
+					"intentions", existing.Name, "intentions-ns", existing.Namespace)
+				delete(desired, name)
+			}
+		}
+	}
+	return nil
+}
+
+// sameDestination returns true if a and b are the same destination, using the
+// same rules as the ServiceIntentions webhook: all ServiceIntentions are written
+// to the same Consul namespace unless namespaces are mirrored.
+func (r *Controller) sameDestination(a, b v1alpha1.IntentionDestination) bool {
+	if a.Name != b.Name {
+		return false
+	}
+	return !(r.EnableConsulNamespaces && r.EnableNSMirroring) || a.Namespace == b.Namespace
+}
+
+// destinations returns the names of the services that the pods selected by
+// podSelector in namespace belong to.
+func (r *Controller) destinations(ctx context.Context, namespace string, podSelector metav1.LabelSelector) ([]string, error) {
+	if isEmpty(&podSelector) && r.EnableNSMirroring && r.EnableConsulNamespaces {
+		return []string{wildcard}, nil
+	}
+	return r.selectedServices(ctx, namespace, &podSelector)
+}
+
+// addSources adds the services that peer allows traffic from to sources.
+func (r *Controller) addSources(ctx context.Context, sources sourceSet, namespace string, peer networkingv1.NetworkPolicyPeer, policyName string) error {
+	if peer.IPBlock != nil {
+		r.Log.Info("ignoring IP block of NetworkPolicy", "name", policyName, "ns", namespace)
+		return nil
+	}
+	if peer.NamespaceSelector != nil && isEmpty(peer.NamespaceSelector) && isEmpty(peer.PodSelector) {
+		sources.add(wildcard, r.sourceNamespace(wildcard), policyName)
+		return nil
+	}
+
+	peerNamespaces := []string{namespace}
+	if peer.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+		if err != nil {
+			return err
+		}
+		var namespaceList corev1.NamespaceList
+		if err := r.Client.List(ctx, &namespaceList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return err
+		}
+		peerNamespaces = nil
+		for _, ns := range namespaceList.Items {
+			peerNamespaces = append(peerNamespaces, ns.Name)
+		}
+	}
+
+	for _, peerNamespace := range peerNamespaces {
+		if isEmpty(peer.PodSelector) && r.EnableNSMirroring && r.EnableConsulNamespaces {
+			sources.add(wildcard, r.sourceNamespace(peerNamespace), policyName)
+			continue
+		}
+		services, err := r.selectedServices(ctx, peerNamespace, peer.PodSelector)
+		if err != nil {
+			return err
+		}
+		for _, service := range services {
+			sources.add(service, r.sourceNamespace(peerNamespace), policyName)
+		}
+	}
+	return nil
+}
+
+// selectedServices returns the names of the services of the Services in
+// namespace whose selectors match podSelector. A nil selector matches all
+// Services.
+func (r *Controller) selectedServices(ctx context.Context, namespace string, podSelector *metav1.LabelSelector) ([]string, error) {
+	selector := labels.Everything()
+	if podSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(podSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var serviceList corev1.ServiceList
+	if err := r.Client.List(ctx, &serviceList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	var podList corev1.PodList
+	if err := r.Client.List(ctx, &podList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, service := range serviceList.Items {
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+		if selector.Matches(labels.Set(service.Spec.Selector)) {
+			addServiceNames(names, service, podList.Items)
+		}
+	}
+	var services []string
+	for name := range names {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+	return services, nil
+}
+
+// addServiceNames adds the names that the pods of service are registered with
+// in Consul to names: the connect-service annotation of a pod if it names a
+// single service, or else the name of the Service, like the endpoints
+// controller does. The name of the Service is used if it has no pods.
+func addServiceNames(names map[string]bool, service corev1.Service, pods []corev1.Pod) {
+	selector := labels.SelectorFromSet(service.Spec.Selector)
+	hasPods := false
+	for _, pod := range pods {
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		hasPods = true
+		if name := pod.Annotations[constants.AnnotationService]; name != "" && !strings.Contains(name, ",") {
+			names[name] = true
+		} else {
+			names[service.Name] = true
+		}
+	}
+	if !hasPods {
+		names[service.Name] = true
+	}
+}
+
+// sourceNamespace returns the Consul namespace of the sources in the
+// Kubernetes namespace k8sNamespace.
+func (r *Controller) sourceNamespace(k8sNamespace string) string {
+	if k8sNamespace == wildcard {
+		if r.EnableConsulNamespaces {
+			return wildcard
+		}
+		return ""
+	}
+	return namespaces.ConsulNamespace(k8sNamespace, r.EnableConsulNamespaces, r.ConsulDestinationNamespace, r.EnableNSMirroring, r.NSMirroringPrefix)
+}
+
+// SetupWithManager sets up the controller with the Manager. The requests are
+// named after the namespaces whose NetworkPolicies are translated.
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &networkingv1.NetworkPolicy{}, NamespaceSelectorIndex, hasNamespaceSelector)
+	if err != nil {
+		return err
+	}
+	generated, err := predicate.LabelSelectorPredicate(metav1.LabelSelector{MatchLabels: map[string]string{GeneratedLabel: "true"}})
+	if err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("network-policy-intentions").
+		Watches(&source.Kind{Type: &networkingv1.NetworkPolicy{}}, handler.EnqueueRequestsFromMapFunc(requestForNamespace)).
+		Watches(&source.Kind{Type: &v1alpha1.ServiceIntentions{}}, handler.EnqueueRequestsFromMapFunc(requestForNamespace), builder.WithPredicates(generated)).
+		// Policies are skipped while the ServiceIntentions of users
+		// target their destinations.
+		Watches(&source.Kind{Type: &v1alpha1.ServiceIntentions{}}, handler.EnqueueRequestsFromMapFunc(r.requestsForPolicyNamespaces), builder.WithPredicates(predicate.Not(generated))).
+		// Services, the connect-service annotation of pods and namespace
+		// labels change which services policies select, also as sources in
+		// other namespaces.
+		Watches(&source.Kind{Type: &corev1.Service{}}, handler.EnqueueRequestsFromMapFunc(r.requestsForNamespaceAndSelectors)).
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.requestsForNamespaceAndSelectors), builder.WithPredicates(serviceAnnotationChanged)).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(r.requestsForNamespaceSelectors)).
+		Complete(r)
+}
+
+func requestForNamespace(object client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: object.GetNamespace()}}}
+}
+
+// requestsForNamespaceAndSelectors returns requests for the namespace of
+// object and for the namespaces with policies that select namespaces.
+func (r *Controller) requestsForNamespaceAndSelectors(object client.Object) []reconcile.Request {
+	return appendRequest(r.requestsForNamespaceSelectors(object), object.GetNamespace())
+}
+
+// requestsForNamespaceSelectors returns requests for the namespaces with
+// policies that select namespaces.
+func (r *Controller) requestsForNamespaceSelectors(_ client.Object) []reconcile.Request {
+	var policies networkingv1.NetworkPolicyList
+	if err := r.Client.List(context.Background(), &policies, client.MatchingFields{NamespaceSelectorIndex: "true"}); err != nil {
+		r.Log.Error(err, "failed to list NetworkPolicies")
+		return nil
+	}
+	return policyNamespaceRequests(policies)
+}
+
+// requestsForPolicyNamespaces returns requests for all namespaces with
+// NetworkPolicies.
+func (r *Controller) requestsForPolicyNamespaces(_ client.Object) []reconcile.Request {
+	var policies networkingv1.NetworkPolicyList
+	if err := r.Client.List(context.Background(), &policies); err != nil {
+		r.Log.Error(err, "failed to list NetworkPolicies")
+		return nil
+	}
+	return policyNamespaceRequests(policies)
+}
+
+// policyNamespaceRequests returns a request for each namespace of policies.
+func policyNamespaceRequests(policies networkingv1.NetworkPolicyList) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, policy := range policies.Items {
+		requests = appendRequest(requests, policy.Namespace)
+	}
+	return requests
+}
+
+// appendRequest appends a request for namespace unless requests has one.
+func appendRequest(requests []reconcile.Request, namespace string) []reconcile.Request {
+	for _, request := range requests {
+		if request.Name == namespace {
+			return requests
+		}
+	}
+	return append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: namespace}})
+}
+
+// hasNamespaceSelector indexes the NetworkPolicies with ingress peers that
+// select namespaces under "true".
+func hasNamespaceSelector(object client.Object) []string {
+	policy := object.(*networkingv1.NetworkPolicy)
+	for _, rule := range policy.Spec.Ingress {
+		for _, peer := range rule.From {
+			if peer.NamespaceSelector != nil {
+				return []string{"true"}
+			}
+		}
+	}
+	return nil
+}
+
+// serviceAnnotationChanged filters the events of pods down to the ones that
+// change the services named by their connect-service annotation.
+var serviceAnnotationChanged = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return e.Object.GetAnnotations()[constants.AnnotationService] != ""
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.ObjectOld.GetAnnotations()[constants.AnnotationService] != e.ObjectNew.GetAnnotations()[constants.AnnotationService]
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return e.Object.GetAnnotations()[constants.AnnotationService] != ""
+	},
+	GenericFunc: func(event.GenericEvent) bool {
+		return false
+	},
+}
+
+// sourceSet holds the sources of a destination and the names of the policies
+// that allow them.
+type sourceSet map[sourceKey]map[string]bool
+
+type sourceKey struct {
+	name      string
+	namespace string
+}
+
+func (s sourceSet) add(name, namespace, policyName string) {
+	key := sourceKey{name: name, namespace: namespace}
+	if s[key] == nil {
+		s[key] = make(map[string]bool)
+	}
+	s[key][policyName] = true
+}
+
+func (s sourceSet) merge(other sourceSet) {
+	for key, policyNames := range other {
+		for policyName := range policyNames {
+			s.add(key.name, key.namespace, policyName)
+		}
+	}
+}
+
+// toSourceIntentions returns allow intentions for the sources, sorted so that
+// the ServiceIntentions only change when the sources do.
+func (s sourceSet) toSourceIntentions() v1alpha1.SourceIntentions {
+	var intentions v1alpha1.SourceIntentions
+	for key, policyNames := range s {
+		var names []string
+		for name := range policyNames {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		intentions = append(intentions, &v1alpha1.SourceIntention{
+			Name:        key.name,
+			Namespace:   key.namespace,
+			Action:      "allow",
+			Description: fmt.Sprintf("Generated from NetworkPolicy %s", strings.Join(names, ", ")),
+		})
+	}
+	sort.Slice(intentions, func(i, j int) bool {
+		if intentions[i].Namespace != intentions[j].Namespace {
+			return intentions[i].Namespace < intentions[j].Namespace
+		}
+		return intentions[i].Name < intentions[j].Name
+	})
+	return intentions
+}
+
+// intentionsName returns the name of the ServiceIntentions of destination.
+func intentionsName(destination string) string {
+	if destination == wildcard {
+		return namePrefix
+	}
+	return namePrefix + "." + destination
+}
+
+// appliesToIngress returns true if the policy restricts ingress traffic, which
+// is the default if its policy types aren't set.
+func appliesToIngress(policy networkingv1.NetworkPolicy) bool {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return true
+	}
+	for _, policyType := range policy.Spec.PolicyTypes {
+		if policyType == networkingv1.PolicyTypeIngress {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmpty returns true if selector selects everything.
+func isEmpty(selector *metav1.LabelSelector) bool {
+	return selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0)
+}
+
+// shouldIgnore ignores namespaces where we don't connect-inject.
+func shouldIgnore(namespace string, denySet, allowSet mapset.Set) bool {
+	// Ignores system namespaces.
+	if namespace == metav1.NamespaceSystem || namespace == metav1.NamespacePublic || namespace == "local-path-storage" {
+		return true
+	}
+	if denySet.Contains(namespace) {
+		return true
+	}
+	return !allowSet.Contains(wildcard) && !allowSet.Contains(namespace)
+}
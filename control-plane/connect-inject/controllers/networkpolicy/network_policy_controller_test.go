@@ -0,0 +1,361 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package networkpolicy
+
+import (
+	"context"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		policies      []networkingv1.NetworkPolicy
+		nsMirroring   bool
+		expIntentions map[string]v1alpha1.ServiceIntentionsSpec
+	}{
+		"no policies": {
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{},
+		},
+		"pod selector sources": {
+			policies: []networkingv1.NetworkPolicy{
+				policy("web", selector("app", "web"), ingress(peer(selector("app", "frontend"), nil))),
+			},
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{
+				"networkpolicy.web": spec("web", "", allow("frontend", "", "web")),
+			},
+		},
+		"sources from all namespaces": {
+			policies: []networkingv1.NetworkPolicy{
+				policy("web", selector("app", "web"), ingress(peer(nil, &metav1.LabelSelector{}))),
+				policy("api", selector("app", "api"), networkingv1.NetworkPolicyIngressRule{}),
+			},
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{
+				"networkpolicy.web": spec("web", "", allow("*", "", "web")),
+				"networkpolicy.api": spec("api", "", allow("*", "", "api")),
+			},
+		},
+		"sources in selected namespaces": {
+			policies: []networkingv1.NetworkPolicy{
+				policy("web", selector("app", "web"),
+					ingress(peer(selector("app", "frontend"), selector("team", "a")), peer(nil, selector("team", "b")))),
+				policy("api", selector("app", "api"), ingress(peer(selector("app", "other"), &metav1.LabelSelector{}))),
+			},
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{
+				"networkpolicy.web": spec("web", "", allow("other", "", "web")),
+				"networkpolicy.api": spec("api", "", allow("other", "", "api")),
+			},
+		},
+		"policies selecting the same service are merged": {
+			policies: []networkingv1.NetworkPolicy{
+				policy("web-frontend", selector("app", "web"), ingress(peer(selector("app", "frontend"), nil))),
+				policy("web-api", selector("app", "web"), ingress(peer(selector("app", "api"), nil), peer(selector("app", "frontend"), nil))),
+			},
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{
+				"networkpolicy.web": spec("web", "",
+					allow("api", "", "web-api"),
+					allow("frontend", "", "web-api, web-frontend"),
+				),
+			},
+		},
+		"empty pod selector without namespace mirroring selects all services": {
+			policies: []networkingv1.NetworkPolicy{
+				policy("all", &metav1.LabelSelector{}, ingress(peer(&metav1.LabelSelector{}, nil))),
+			},
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{
+				"networkpolicy.api":      spec("api", "", allow("api", "", "all"), allow("frontend", "", "all"), allow("web", "", "all")),
+				"networkpolicy.frontend": spec("frontend", "", allow("api", "", "all"), allow("frontend", "", "all"), allow("web", "", "all")),
+				"networkpolicy.web":      spec("web", "", allow("api", "", "all"), allow("frontend", "", "all"), allow("web", "", "all")),
+			},
+		},
+		"empty pod selector with namespace mirroring uses wildcards": {
+			nsMirroring: true,
+			policies: []networkingv1.NetworkPolicy{
+				policy("all", &metav1.LabelSelector{}, ingress(peer(&metav1.LabelSelector{}, nil))),
+				policy("web", selector("app", "web"), ingress(peer(nil, selector("team", "b")))),
+			},
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{
+				"networkpolicy":     spec("*", "default", allow("*", "default", "all")),
+				"networkpolicy.web": spec("web", "default", allow("*", "default", "all"), allow("*", "other", "web")),
+			},
+		},
+		"egress policies and IP blocks are ignored": {
+			policies: []networkingv1.NetworkPolicy{
+				func() networkingv1.NetworkPolicy {
+					p := policy("egress", selector("app", "web"), ingress(peer(selector("app", "api"), nil)))
+					p.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}
+					return p
+				}(),
+				policy("ip-block", selector("app", "web"), ingress(networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8"}})),
+			},
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var objs []runtime.Object
+			for i := range c.policies {
+				objs = append(objs, &c.policies[i])
+			}
+			r, k8s := newController(t, objs...)
+			r.EnableConsulNamespaces = c.nsMirroring
+			r.EnableNSMirroring = c.nsMirroring
+
+			runReconcile(t, r)
+			require.Equal(t, c.expIntentions, generatedIntentions(t, k8s))
+		})
+	}
+}
+
+func TestReconcile_UpdatesAndDeletesGeneratedIntentions(t *testing.T) {
+	t.Parallel()
+	web := policy("web", selector("app", "web"), ingress(peer(selector("app", "frontend"), nil)))
+	api := policy("api", selector("app", "api"), ingress(peer(selector("app", "web"), nil)))
+	userIntentions := &v1alpha1.ServiceIntentions{
+		ObjectMeta: metav1.ObjectMeta{Name: "networkpolicy.api", Namespace: "default"},
+		Spec:       spec("api", "", allow("frontend", "", "")),
+	}
+	r, k8s := newController(t, &web, &api, userIntentions)
+
+	runReconcile(t, r)
+	require.Equal(t, map[string]v1alpha1.ServiceIntentionsSpec{
+		"networkpolicy.web": spec("web", "", allow("frontend", "", "web")),
+	}, generatedIntentions(t, k8s))
+
+	// ServiceIntentions that weren't generated are left alone.
+	var current v1alpha1.ServiceIntentions
+	require.NoError(t, k8s.Get(context.Background(), types.NamespacedName{Name: "networkpolicy.api", Namespace: "default"}, &current))
+	require.Equal(t, userIntentions.Spec, current.Spec)
+
+	web.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{ingress(peer(selector("app", "api"), nil))}
+	require.NoError(t, k8s.Update(context.Background(), &web))
+	runReconcile(t, r)
+	require.Equal(t, map[string]v1alpha1.ServiceIntentionsSpec{
+		"networkpolicy.web": spec("web", "", allow("api", "", "web")),
+	}, generatedIntentions(t, k8s))
+
+	require.NoError(t, k8s.Delete(context.Background(), &web))
+	runReconcile(t, r)
+	require.Empty(t, generatedIntentions(t, k8s))
+}
+
+func TestReconcile_IgnoredNamespace(t *testing.T) {
+	t.Parallel()
+	web := policy("web", selector("app", "web"), ingress(peer(selector("app", "frontend"), nil)))
+	r, k8s := newController(t, &web)
+	r.DenyK8sNamespacesSet = mapset.NewSetWith("default")
+
+	runReconcile(t, r)
+	require.Empty(t, generatedIntentions(t, k8s))
+}
+
+func TestReconcile_SkipsDestinationsOfUserIntentions(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		nsMirroring          bool
+		userIntentionsDestNS string
+		expIntentions        map[string]v1alpha1.ServiceIntentionsSpec
+	}{
+		"same destination": {
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{
+				"networkpolicy.api": spec("api", "", allow("web", "", "api")),
+			},
+		},
+		"same destination name in another mirrored namespace": {
+			nsMirroring:          true,
+			userIntentionsDestNS: "other",
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{
+				"networkpolicy.web": spec("web", "default", allow("frontend", "default", "web")),
+				"networkpolicy.api": spec("api", "default", allow("web", "default", "api")),
+			},
+		},
+		"same destination in a mirrored namespace": {
+			nsMirroring:          true,
+			userIntentionsDestNS: "default",
+			expIntentions: map[string]v1alpha1.ServiceIntentionsSpec{
+				"networkpolicy.api": spec("api", "default", allow("web", "default", "api")),
+			},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			web := policy("web", selector("app", "web"), ingress(peer(selector("app", "frontend"), nil)))
+			api := policy("api", selector("app", "api"), ingress(peer(selector("app", "web"), nil)))
+			// The webhook allows only one ServiceIntentions per destination,
+			// and it may be in any namespace.
+			userIntentions := &v1alpha1.ServiceIntentions{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-intentions", Namespace: "other"},
+				Spec:       spec("web", c.userIntentionsDestNS, allow("frontend", "", "")),
+			}
+			r, k8s := newController(t, &web, &api, userIntentions)
+			r.EnableConsulNamespaces = c.nsMirroring
+			r.EnableNSMirroring = c.nsMirroring
+
+			runReconcile(t, r)
+			require.Equal(t, c.expIntentions, generatedIntentions(t, k8s))
+		})
+	}
+}
+
+func TestReconcile_ConnectServiceAnnotation(t *testing.T) {
+	t.Parallel()
+	web := policy("web", selector("app", "web"), ingress(peer(selector("app", "frontend"), nil)))
+	r, k8s := newController(t, &web,
+		pod("web-1", map[string]string{"app": "web"}, "web-svc"),
+		pod("frontend-1", map[string]string{"app": "frontend"}, "frontend-svc"),
+		pod("frontend-2", map[string]string{"app": "frontend"}, ""),
+		// Pods of multi-port services are registered under the name of the Service.
+		pod("web-2", map[string]string{"app": "web"}, "web,web-admin"),
+	)
+
+	runReconcile(t, r)
+	require.Equal(t, map[string]v1alpha1.ServiceIntentionsSpec{
+		"networkpolicy.web-svc": spec("web-svc", "", allow("frontend", "", "web"), allow("frontend-svc", "", "web")),
+		"networkpolicy.web":     spec("web", "", allow("frontend", "", "web"), allow("frontend-svc", "", "web")),
+	}, generatedIntentions(t, k8s))
+}
+
+func TestRequestsForNamespaceAndSelectors(t *testing.T) {
+	t.Parallel()
+	web := policy("web", selector("app", "web"), ingress(peer(selector("app", "frontend"), nil)))
+	api := policy("api", selector("app", "api"), ingress(peer(nil, selector("team", "b"))))
+	api.Namespace = "api"
+	r, _ := newController(t, &web, &api)
+
+	requests := r.requestsForNamespaceAndSelectors(service("other", "other", nil))
+	require.ElementsMatch(t, []string{"api", "other"}, requestNames(requests))
+
+	requests = r.requestsForNamespaceAndSelectors(service("api", "api", nil))
+	require.ElementsMatch(t, []string{"api"}, requestNames(requests))
+
+	requests = r.requestsForNamespaceSelectors(namespace("other", nil))
+	require.ElementsMatch(t, []string{"api"}, requestNames(requests))
+}
+
+func requestNames(requests []reconcile.Request) []string {
+	var names []string
+	for _, request := range requests {
+		names = append(names, request.Name)
+	}
+	return names
+}
+
+func newController(t *testing.T, objs ...runtime.Object) (*Controller, client.Client) {
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.ServiceIntentions{}, &v1alpha1.ServiceIntentionsList{})
+
+	objs = append(objs,
+		namespace("default", nil),
+		namespace("other", map[string]string{"team": "b"}),
+		service("default", "web", map[string]string{"app": "web"}),
+		service("default", "api", map[string]string{"app": "api", "tier": "backend"}),
+		service("default", "frontend", map[string]string{"app": "frontend"}),
+		service("default", "external", nil),
+		service("other", "other", map[string]string{"app": "other"}),
+	)
+	k8s := fake.NewClientBuilder().
+		WithScheme(s).
+		WithRuntimeObjects(objs...).
+		WithIndex(&networkingv1.NetworkPolicy{}, NamespaceSelectorIndex, hasNamespaceSelector).
+		Build()
+	return &Controller{
+		Client:                     k8s,
+		Log:                        logrtest.New(t),
+		AllowK8sNamespacesSet:      mapset.NewSetWith("*"),
+		DenyK8sNamespacesSet:       mapset.NewSet(),
+		ConsulDestinationNamespace: "default",
+	}, k8s
+}
+
+func runReconcile(t *testing.T, r *Controller) {
+	_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "default"}})
+	require.NoError(t, err)
+}
+
+func generatedIntentions(t *testing.T, k8s client.Client) map[string]v1alpha1.ServiceIntentionsSpec {
+	var list v1alpha1.ServiceIntentionsList
+	require.NoError(t, k8s.List(context.Background(), &list, client.MatchingLabels{GeneratedLabel: "true"}))
+	specs := make(map[string]v1alpha1.ServiceIntentionsSpec)
+	for _, intentions := range list.Items {
+		specs[intentions.Name] = intentions.Spec
+	}
+	return specs
+}
+
+func policy(name string, podSelector *metav1.LabelSelector, rules ...networkingv1.NetworkPolicyIngressRule) networkingv1.NetworkPolicy {
+	return networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: *podSelector,
+			Ingress:     rules,
+		},
+	}
+}
+
+func ingress(peers ...networkingv1.NetworkPolicyPeer) networkingv1.NetworkPolicyIngressRule {
+	return networkingv1.NetworkPolicyIngressRule{From: peers}
+}
+
+func peer(podSelector, namespaceSelector *metav1.LabelSelector) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{PodSelector: podSelector, NamespaceSelector: namespaceSelector}
+}
+
+func selector(key, value string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{MatchLabels: map[string]string{key: value}}
+}
+
+func spec(destination, namespace string, sources ...*v1alpha1.SourceIntention) v1alpha1.ServiceIntentionsSpec {
+	return v1alpha1.ServiceIntentionsSpec{
+		Destination: v1alpha1.IntentionDestination{Name: destination, Namespace: namespace},
+		Sources:     sources,
+	}
+}
+
+func allow(name, namespace, policies string) *v1alpha1.SourceIntention {
+	intention := &v1alpha1.SourceIntention{Name: name, Namespace: namespace, Action: "allow"}
+	if policies != "" {
+		intention.Description = "Generated from NetworkPolicy " + policies
+	}
+	return intention
+}
+
+func namespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func pod(name string, labels map[string]string, connectService string) *corev1.Pod {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+	if connectService != "" {
+		pod.Annotations = map[string]string{constants.AnnotationService: connectService}
+	}
+	return pod
+}
+
+func service(namespace, name string, selector map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ServiceSpec{Selector: selector},
+	}
+}
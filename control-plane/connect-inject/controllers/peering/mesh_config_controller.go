@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package peering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/common"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	capi "github.com/hashicorp/consul/api"
+)
+
+// MeshConfigController periodically makes sure peering traffic is routed
+// through the mesh gateways by enabling PeerThroughMeshGateways in the Mesh
+// config entry, and checks that the mesh gateways have a WAN address the
+// peers can reach. Each peer runs it in its own cluster, so that peering
+// between clusters whose servers aren't reachable from each other works
+// without writing the Mesh config entry by hand.
+//
+// If the Mesh config entry is managed by a Mesh custom resource, it isn't
+// modified; the custom resource must enable peerThroughMeshGateways instead.
+type MeshConfigController struct {
+	// ConsulClientConfig is the config to create a Consul API client.
+	ConsulClientConfig *consul.Config
+	// ConsulServerConnMgr is the watcher for the Consul server addresses.
+	ConsulServerConnMgr consul.ServerConnectionManager
+	// MeshGatewayServiceName is the name of the mesh gateway service in Consul.
+	MeshGatewayServiceName string
+	// Interval is how often the Mesh config entry and mesh gateways are checked.
+	Interval time.Duration
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
+	// Log is the logger for this controller.
+	Log logr.Logger
+}
+
+// Start reconciles the Mesh config entry immediately and then every interval
+// until ctx is cancelled. It implements manager.Runnable and only runs on the
+// leader.
+func (c *MeshConfigController) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		if err := c.reconcile(); err != nil {
+			c.Log.Error(err, "failed to configure peering through mesh gateways")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconcile enables PeerThroughMeshGateways in the Mesh config entry and
+// validates the WAN addresses of the mesh gateways.
+func (c *MeshConfigController) reconcile() error {
+	serverState, err := c.ConsulServerConnMgr.State()
+	if err != nil {
+		return fmt.Errorf("failed to get Consul server state: %w", err)
+	}
+	apiClient, err := consul.NewClientFromConnMgrState(c.ConsulClientConfig, serverState)
+	if err != nil {
+		return fmt.Errorf("failed to create Consul API client: %w", err)
+	}
+
+	if err := c.enablePeerThroughMeshGateways(apiClient); err != nil {
+		return err
+	}
+
+	gateways, _, err := apiClient.Catalog().Service(c.MeshGatewayServiceName, "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to get mesh gateways from Consul: %w", err)
+	}
+	return validateMeshGatewayWANAddresses(c.MeshGatewayServiceName, gateways)
+}
+
+// enablePeerThroughMeshGateways sets PeerThroughMeshGateways in the Mesh
+// config entry, creating the entry if it doesn't exist. The rest of the entry
+// is left as is, and it's written with check-and-set so that concurrent
+// changes aren't overwritten.
+func (c *MeshConfigController) enablePeerThroughMeshGateways(apiClient *capi.Client) error {
+	mesh := &capi.MeshConfigEntry{}
+	entry, _, err := apiClient.ConfigEntries().Get(capi.MeshConfig, capi.MeshConfigMesh, nil)
+	if err != nil && !strings.Contains(err.Error(), "404") {
+		return fmt.Errorf("failed to get Mesh config entry from Consul: %w", err)
+	}
+	if entry != nil {
+		var ok bool
+		if mesh, ok = entry.(*capi.MeshConfigEntry); !ok {
+			return fmt.Errorf("unexpected config entry of kind %s", entry.GetKind())
+		}
+	}
+
+	if mesh.Peering != nil && mesh.Peering.PeerThroughMeshGateways {
+		return nil
+	}
+	if mesh.Meta[common.SourceKey] == common.SourceValue {
+		return errors.New("the Mesh config entry is managed by a Mesh custom resource; " +
+			"set spec.peering.peerThroughMeshGateways to true in the custom resource")
+	}
+
+	// before is the config entry as read from Consul. Peering is replaced
+	// rather than modified, so a shallow copy is enough.
+	var before *capi.MeshConfigEntry
+	op := audit.OperationCreate
+	if entry != nil {
+		copied := *mesh
+		before = &copied
+		op = audit.OperationUpdate
+	}
+	mesh.Peering = &capi.PeeringMeshConfig{PeerThroughMeshGateways: true}
+	written, _, err := apiClient.ConfigEntries().CAS(mesh, mesh.ModifyIndex, nil)
+	if err != nil || written {
+		c.Auditor.Record(audit.Event{
+			Actor:     "controller/peering-mesh-config",
+			Operation: op,
+			Resource:  audit.ResourceConfigEntry,
+			Name:      fmt.Sprintf("%s/%s", capi.MeshConfig, capi.MeshConfigMesh),
+			Before:    before,
+			After:     mesh,
+		}, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write Mesh config entry to Consul: %w", err)
+	}
+	if !written {
+		return errors.New("the Mesh config entry was modified concurrently; it will be updated on the next attempt")
+	}
+	c.Log.Info("enabled peering through mesh gateways in the Mesh config entry")
+	return nil
+}
+
+// validateMeshGatewayWANAddresses returns an error if there are no mesh
+// gateways or if any of them has a WAN address that a peer can't reach.
+func validateMeshGatewayWANAddresses(serviceName string, gateways []*capi.CatalogService) error {
+	if len(gateways) == 0 {
+		return fmt.Errorf("no %q services are registered in Consul; peering through mesh gateways requires mesh gateways", serviceName)
+	}
+	var errs []string
+	for _, gateway := range gateways {
+		wan, ok := gateway.ServiceTaggedAddresses["wan"]
+		if !ok || wan.Address == "" {
+			errs = append(errs, fmt.Sprintf("mesh gateway %s has no WAN address", gateway.ServiceID))
+			continue
+		}
+		if ip := net.ParseIP(wan.Address); ip != nil && (ip.IsLoopback() || ip.IsUnspecified()) {
+			errs = append(errs, fmt.Sprintf("mesh gateway %s has WAN address %s which is not reachable from peers", gateway.ServiceID, wan.Address))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package peering
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/common"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnablePeerThroughMeshGateways(t *testing.T) {
+	cases := map[string]struct {
+		existing *capi.MeshConfigEntry
+		casOK    bool
+		expWrite *capi.MeshConfigEntry
+		expCAS   string
+		expErr   string
+	}{
+		"creates the entry if it doesn't exist": {
+			casOK: true,
+			expWrite: &capi.MeshConfigEntry{
+				Peering: &capi.PeeringMeshConfig{PeerThroughMeshGateways: true},
+			},
+			expCAS: "0",
+		},
+		"updates the entry and keeps the other fields": {
+			existing: &capi.MeshConfigEntry{
+				TransparentProxy: capi.TransparentProxyMeshConfig{MeshDestinationsOnly: true},
+				ModifyIndex:      7,
+			},
+			casOK: true,
+			expWrite: &capi.MeshConfigEntry{
+				TransparentProxy: capi.TransparentProxyMeshConfig{MeshDestinationsOnly: true},
+				Peering:          &capi.PeeringMeshConfig{PeerThroughMeshGateways: true},
+				ModifyIndex:      7,
+			},
+			expCAS: "7",
+		},
+		"does nothing if already enabled": {
+			existing: &capi.MeshConfigEntry{
+				Peering:     &capi.PeeringMeshConfig{PeerThroughMeshGateways: true},
+				ModifyIndex: 7,
+			},
+		},
+		"does not modify an entry managed by a Mesh custom resource": {
+			existing: &capi.MeshConfigEntry{
+				Meta:        map[string]string{common.SourceKey: common.SourceValue},
+				ModifyIndex: 7,
+			},
+			expErr: "the Mesh config entry is managed by a Mesh custom resource",
+		},
+		"fails if the entry was modified concurrently": {
+			existing: &capi.MeshConfigEntry{ModifyIndex: 7},
+			casOK:    false,
+			expWrite: &capi.MeshConfigEntry{
+				Peering:     &capi.PeeringMeshConfig{PeerThroughMeshGateways: true},
+				ModifyIndex: 7,
+			},
+			expCAS: "7",
+			expErr: "the Mesh config entry was modified concurrently",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var written *capi.MeshConfigEntry
+			var cas string
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/config/mesh/mesh":
+					if c.existing == nil {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					require.NoError(t, json.NewEncoder(w).Encode(c.existing))
+				case r.Method == http.MethodPut && r.URL.Path == "/v1/config":
+					cas = r.URL.Query().Get("cas")
+					written = &capi.MeshConfigEntry{}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(written))
+					require.NoError(t, json.NewEncoder(w).Encode(c.casOK))
+				default:
+					t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+				}
+			}))
+			t.Cleanup(consulServer.Close)
+			apiClient, err := capi.NewClient(&capi.Config{Address: consulServer.URL})
+			require.NoError(t, err)
+
+			controller := &MeshConfigController{Log: logrtest.New(t)}
+			err = controller.enablePeerThroughMeshGateways(apiClient)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if c.expWrite == nil {
+				require.Nil(t, written)
+				return
+			}
+			require.NotNil(t, written)
+			require.Equal(t, c.expCAS, cas)
+			require.Equal(t, c.expWrite.Peering, written.Peering)
+			require.Equal(t, c.expWrite.TransparentProxy, written.TransparentProxy)
+		})
+	}
+}
+
+func TestValidateMeshGatewayWANAddresses(t *testing.T) {
+	gateway := func(id, wanAddress string) *capi.CatalogService {
+		svc := &capi.CatalogService{ServiceID: id, ServiceTaggedAddresses: map[string]capi.ServiceAddress{}}
+		if wanAddress != "" {
+			svc.ServiceTaggedAddresses["wan"] = capi.ServiceAddress{Address: wanAddress, Port: 443}
+		}
+		return svc
+	}
+	cases := map[string]struct {
+		gateways []*capi.CatalogService
+		expErr   string
+	}{
+		"no mesh gateways": {
+			expErr: `no "mesh-gateway" services are registered in Consul; peering through mesh gateways requires mesh gateways`,
+		},
+		"reachable WAN addresses": {
+			gateways: []*capi.CatalogService{gateway("mgw-1", "203.0.113.10"), gateway("mgw-2", "gateway.example.com")},
+		},
+		"missing WAN address": {
+			gateways: []*capi.CatalogService{gateway("mgw-1", "203.0.113.10"), gateway("mgw-2", "")},
+			expErr:   "mesh gateway mgw-2 has no WAN address",
+		},
+		"unreachable WAN addresses": {
+			gateways: []*capi.CatalogService{gateway("mgw-1", "127.0.0.1"), gateway("mgw-2", "0.0.0.0")},
+			expErr: "mesh gateway mgw-1 has WAN address 127.0.0.1 which is not reachable from peers; " +
+				"mesh gateway mgw-2 has WAN address 0.0.0.0 which is not reachable from peers",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validateMeshGatewayWANAddresses("mesh-gateway", c.gateways)
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
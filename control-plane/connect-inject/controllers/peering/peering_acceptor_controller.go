@@ -12,6 +12,7 @@ import (
 
 	"github.com/go-logr/logr"
 	consulv1alpha1 "github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul/api"
@@ -24,6 +25,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -46,6 +48,11 @@ type AcceptorController struct {
 	Log logr.Logger
 	// Scheme is the API scheme that this controller should have.
 	Scheme *runtime.Scheme
+	// ControllerOptions configures the concurrency and rate limiting of this controller's workqueue.
+	// The zero value uses the controller-runtime defaults.
+	ControllerOptions controller.Options
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
 	context.Context
 }
 
@@ -54,6 +61,11 @@ const (
 	consulAgentError = "consulAgentError"
 	internalError    = "internalError"
 	kubernetesError  = "kubernetesError"
+	peeringFailing   = "peeringFailing"
+
+	// failedPeeringRotationInterval is how often the state of a peering is checked when its acceptor rotates
+	// the token on failure, and the minimum time between two rotations of a failing peering's token.
+	failedPeeringRotationInterval = 5 * time.Minute
 )
 
 //+kubebuilder:rbac:groups=consul.hashicorp.com,resources=peeringacceptors,verbs=get;list;watch;create;update;patch;delete
@@ -117,7 +129,7 @@ func (r *AcceptorController) Reconcile(ctx context.Context, req ctrl.Request) (c
 	} else {
 		if containsString(acceptor.Finalizers, finalizerName) {
 			r.Log.Info("PeeringAcceptor was deleted, deleting from Consul", "name", req.Name, "ns", req.Namespace)
-			err := r.deletePeering(ctx, apiClient, req.Name)
+			err := r.deletePeering(ctx, apiClient, acceptor)
 			if acceptor.Secret().Backend == "kubernetes" {
 				err = r.deleteK8sSecret(ctx, acceptor.Secret().Name, acceptor.Namespace)
 			}
@@ -159,7 +171,7 @@ func (r *AcceptorController) Reconcile(ctx context.Context, req ctrl.Request) (c
 		}
 		// Generate and store the peering token.
 		var resp *api.PeeringGenerateTokenResponse
-		if resp, err = r.generateToken(ctx, apiClient, acceptor.Name); err != nil {
+		if resp, err = r.generateToken(ctx, apiClient, acceptor); err != nil {
 			r.updateStatusError(ctx, acceptor, consulAgentError, err)
 			return ctrl.Result{}, err
 		}
@@ -185,11 +197,18 @@ func (r *AcceptorController) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
+	// If the token doesn't need to be regenerated because of a spec change, check whether it's due for rotation.
+	rotate, rotationReason, requeueAfter := tokenRotationDue(acceptor, peering, time.Now())
+	if !shouldGenerate && rotate {
+		r.Log.Info("rotating peering token", "name", acceptor.Name, "reason", rotationReason)
+		shouldGenerate = true
+	}
+
 	if shouldGenerate {
 		// Generate and store the peering token.
 		var resp *api.PeeringGenerateTokenResponse
 		r.Log.Info("generating new token for an existing peering")
-		if resp, err = r.generateToken(ctx, apiClient, acceptor.Name); err != nil {
+		if resp, err = r.generateToken(ctx, apiClient, acceptor); err != nil {
 			return ctrl.Result{}, err
 		}
 		if acceptor.Secret().Backend == "kubernetes" {
@@ -211,7 +230,48 @@ func (r *AcceptorController) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// tokenRotationDue returns whether the acceptor's peering token should be rotated according to its
+// spec.tokenRotation, and why. If it isn't due, it returns how long until the rotation needs to be checked
+// again, since neither the passing of time nor changes to the peering state in Consul trigger a reconcile.
+func tokenRotationDue(acceptor *consulv1alpha1.PeeringAcceptor, peering *api.Peering, now time.Time) (due bool, reason string, requeueAfter time.Duration) {
+	rotation := acceptor.Spec.TokenRotation
+	if rotation == nil {
+		return false, "", 0
+	}
+	// Acceptors that synced before token rotation was supported only have the last synced time.
+	lastGenerated := acceptor.Status.LastTokenGenerationTime
+	if lastGenerated == nil {
+		lastGenerated = acceptor.Status.LastSyncedTime
+	}
+	if lastGenerated == nil {
+		return false, "", 0
+	}
+	sinceGenerated := now.Sub(lastGenerated.Time)
+
+	if rotation.OnFailure {
+		failed := peering.State == api.PeeringStateFailing || peering.State == api.PeeringStateTerminated
+		// Back off between rotations so that a peering that keeps failing doesn't regenerate tokens in a loop.
+		if failed && sinceGenerated >= failedPeeringRotationInterval {
+			return true, fmt.Sprintf("peering is %s", peering.State), 0
+		}
+		requeueAfter = failedPeeringRotationInterval
+		if failed {
+			requeueAfter = failedPeeringRotationInterval - sinceGenerated
+		}
+	}
+
+	if interval := rotation.Interval.Duration; interval > 0 {
+		if sinceGenerated >= interval {
+			return true, fmt.Sprintf("token is older than %s", interval), 0
+		}
+		if requeueAfter == 0 || interval-sinceGenerated < requeueAfter {
+			requeueAfter = interval - sinceGenerated
+		}
+	}
+	return false, "", requeueAfter
 }
 
 // shouldGenerateToken returns whether a token should be generated, and whether the name of the secret has changed. It
@@ -257,7 +317,10 @@ func (r *AcceptorController) updateStatus(ctx context.Context, acceptorObjKey ty
 	acceptor.Status.SecretRef = &consulv1alpha1.SecretRefStatus{
 		Secret: *acceptor.Secret(),
 	}
-	acceptor.Status.LastSyncedTime = &metav1.Time{Time: time.Now()}
+	// The status is only updated after a new token was generated and stored.
+	now := metav1.Time{Time: time.Now()}
+	acceptor.Status.LastSyncedTime = &now
+	acceptor.Status.LastTokenGenerationTime = &now
 	acceptor.SetSyncedCondition(corev1.ConditionTrue, "", "")
 	if peeringVersionString, ok := acceptor.Annotations[constants.AnnotationPeeringVersion]; ok {
 		peeringVersion, err := strconv.ParseUint(peeringVersionString, 10, 64)
@@ -340,6 +403,7 @@ func (r *AcceptorController) deleteK8sSecret(ctx context.Context, name, namespac
 func (r *AcceptorController) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&consulv1alpha1.PeeringAcceptor{}).
+		WithOptions(r.ControllerOptions).
 		Watches(
 			&source.Kind{Type: &corev1.Secret{}},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForPeeringTokens),
@@ -348,11 +412,12 @@ func (r *AcceptorController) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 // generateToken is a helper function that calls the Consul api to generate a token for the peer.
-func (r *AcceptorController) generateToken(ctx context.Context, apiClient *api.Client, peerName string) (*api.PeeringGenerateTokenResponse, error) {
+func (r *AcceptorController) generateToken(ctx context.Context, apiClient *api.Client, acceptor *consulv1alpha1.PeeringAcceptor) (*api.PeeringGenerateTokenResponse, error) {
 	req := api.PeeringGenerateTokenRequest{
-		PeerName: peerName,
+		PeerName: acceptor.Name,
 	}
 	resp, _, err := apiClient.Peerings().GenerateToken(ctx, req, nil)
+	r.recordWrite(acceptor, audit.OperationUpsert, err)
 	if err != nil {
 		r.Log.Error(err, "failed to get generate token", "err", err)
 		return nil, err
@@ -361,15 +426,28 @@ func (r *AcceptorController) generateToken(ctx context.Context, apiClient *api.C
 }
 
 // deletePeering is a helper function that calls the Consul api to delete a peering.
-func (r *AcceptorController) deletePeering(ctx context.Context, apiClient *api.Client, peerName string) error {
-	_, err := apiClient.Peerings().Delete(ctx, peerName, nil)
+func (r *AcceptorController) deletePeering(ctx context.Context, apiClient *api.Client, acceptor *consulv1alpha1.PeeringAcceptor) error {
+	_, err := apiClient.Peerings().Delete(ctx, acceptor.Name, nil)
+	r.recordWrite(acceptor, audit.OperationDelete, err)
 	if err != nil {
-		r.Log.Error(err, "failed to delete Peering from Consul", "name", peerName)
+		r.Log.Error(err, "failed to delete Peering from Consul", "name", acceptor.Name)
 		return err
 	}
 	return nil
 }
 
+// recordWrite records a write of the peering of the PeeringAcceptor in the audit log.
+// The peering token is not recorded since it is a secret.
+func (r *AcceptorController) recordWrite(acceptor *consulv1alpha1.PeeringAcceptor, op audit.Operation, err error) {
+	r.Auditor.Record(audit.Event{
+		Actor:     "controller/peering-acceptor",
+		Operation: op,
+		Resource:  audit.ResourcePeering,
+		Name:      acceptor.Name,
+		Source:    &audit.Source{Kind: "PeeringAcceptor", Namespace: acceptor.Namespace, Name: acceptor.Name},
+	}, err)
+}
+
 // requestsForPeeringTokens creates a slice of requests for the peering acceptor controller.
 // It enqueues a request for each acceptor that needs to be reconciled. It iterates through
 // the list of acceptors and creates a request for the acceptor that has the same secret as it's
@@ -986,6 +986,105 @@ func TestShouldGenerateToken(t *testing.T) {
 	}
 }
 
+func TestTokenRotationDue(t *testing.T) {
+	now := time.Now()
+	ago := func(d time.Duration) *metav1.Time {
+		return &metav1.Time{Time: now.Add(-d)}
+	}
+	cases := map[string]struct {
+		rotation        *v1alpha1.TokenRotation
+		lastGenerated   *metav1.Time
+		lastSynced      *metav1.Time
+		state           api.PeeringState
+		expDue          bool
+		expReason       string
+		expRequeueAfter time.Duration
+	}{
+		"no rotation configured": {
+			lastGenerated: ago(1000 * time.Hour),
+			state:         api.PeeringStateFailing,
+		},
+		"interval not elapsed": {
+			rotation:        &v1alpha1.TokenRotation{Interval: metav1.Duration{Duration: 24 * time.Hour}},
+			lastGenerated:   ago(20 * time.Hour),
+			state:           api.PeeringStateActive,
+			expRequeueAfter: 4 * time.Hour,
+		},
+		"interval elapsed": {
+			rotation:      &v1alpha1.TokenRotation{Interval: metav1.Duration{Duration: 24 * time.Hour}},
+			lastGenerated: ago(25 * time.Hour),
+			state:         api.PeeringStateActive,
+			expDue:        true,
+			expReason:     "token is older than 24h0m0s",
+		},
+		"interval elapsed since last sync of an acceptor without generation time": {
+			rotation:   &v1alpha1.TokenRotation{Interval: metav1.Duration{Duration: 24 * time.Hour}},
+			lastSynced: ago(25 * time.Hour),
+			state:      api.PeeringStateActive,
+			expDue:     true,
+			expReason:  "token is older than 24h0m0s",
+		},
+		"never synced": {
+			rotation: &v1alpha1.TokenRotation{Interval: metav1.Duration{Duration: 24 * time.Hour}},
+			state:    api.PeeringStateActive,
+		},
+		"on failure with active peering": {
+			rotation:        &v1alpha1.TokenRotation{OnFailure: true},
+			lastGenerated:   ago(time.Hour),
+			state:           api.PeeringStateActive,
+			expRequeueAfter: failedPeeringRotationInterval,
+		},
+		"on failure with failing peering": {
+			rotation:      &v1alpha1.TokenRotation{OnFailure: true},
+			lastGenerated: ago(time.Hour),
+			state:         api.PeeringStateFailing,
+			expDue:        true,
+			expReason:     "peering is FAILING",
+		},
+		"on failure with terminated peering": {
+			rotation:      &v1alpha1.TokenRotation{OnFailure: true},
+			lastGenerated: ago(time.Hour),
+			state:         api.PeeringStateTerminated,
+			expDue:        true,
+			expReason:     "peering is TERMINATED",
+		},
+		"on failure with failing peering backs off after a rotation": {
+			rotation:        &v1alpha1.TokenRotation{OnFailure: true},
+			lastGenerated:   ago(2 * time.Minute),
+			state:           api.PeeringStateFailing,
+			expRequeueAfter: 3 * time.Minute,
+		},
+		"failing peering is ignored without on failure": {
+			rotation:        &v1alpha1.TokenRotation{Interval: metav1.Duration{Duration: 24 * time.Hour}},
+			lastGenerated:   ago(time.Hour),
+			state:           api.PeeringStateFailing,
+			expRequeueAfter: 23 * time.Hour,
+		},
+		"requeues for the earlier of interval and failure check": {
+			rotation:        &v1alpha1.TokenRotation{Interval: metav1.Duration{Duration: time.Hour}, OnFailure: true},
+			lastGenerated:   ago(58 * time.Minute),
+			state:           api.PeeringStateActive,
+			expRequeueAfter: 2 * time.Minute,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			acceptor := &v1alpha1.PeeringAcceptor{
+				Spec: v1alpha1.PeeringAcceptorSpec{TokenRotation: c.rotation},
+				Status: v1alpha1.PeeringAcceptorStatus{
+					LastTokenGenerationTime: c.lastGenerated,
+					LastSyncedTime:          c.lastSynced,
+				},
+			}
+			due, reason, requeueAfter := tokenRotationDue(acceptor, &api.Peering{State: c.state}, now)
+			require.Equal(t, c.expDue, due)
+			require.Equal(t, c.expReason, reason)
+			require.Equal(t, c.expRequeueAfter, requeueAfter)
+		})
+	}
+}
+
 func TestAcceptorUpdateStatus(t *testing.T) {
 	cases := []struct {
 		name            string
@@ -1105,6 +1204,8 @@ func TestAcceptorUpdateStatus(t *testing.T) {
 			require.Equal(t, tt.expStatus.SecretRef.Backend, acceptor.SecretRef().Backend)
 			require.Equal(t, tt.expStatus.SecretRef.ResourceVersion, acceptor.SecretRef().ResourceVersion)
 			require.Equal(t, tt.expStatus.Conditions[0].Message, acceptor.Status.Conditions[0].Message)
+			require.NotNil(t, acceptor.Status.LastTokenGenerationTime)
+			require.Equal(t, acceptor.Status.LastSyncedTime, acceptor.Status.LastTokenGenerationTime)
 		})
 	}
 }
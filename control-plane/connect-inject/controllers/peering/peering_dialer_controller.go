@@ -12,6 +12,7 @@ import (
 
 	"github.com/go-logr/logr"
 	consulv1alpha1 "github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul/api"
@@ -24,6 +25,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -42,6 +44,11 @@ type PeeringDialerController struct {
 	Log logr.Logger
 	// Scheme is the API scheme that this controller should have.
 	Scheme *runtime.Scheme
+	// ControllerOptions configures the concurrency and rate limiting of this controller's workqueue.
+	// The zero value uses the controller-runtime defaults.
+	ControllerOptions controller.Options
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
 	context.Context
 }
 
@@ -92,7 +99,7 @@ func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Reques
 	} else {
 		if containsString(dialer.Finalizers, finalizerName) {
 			r.Log.Info("PeeringDialer was deleted, deleting from Consul", "name", req.Name, "ns", req.Namespace)
-			if err := r.deletePeering(ctx, apiClient, req.Name); err != nil {
+			if err := r.deletePeering(ctx, apiClient, dialer); err != nil {
 				return ctrl.Result{}, err
 			}
 			controllerutil.RemoveFinalizer(dialer, finalizerName)
@@ -139,7 +146,7 @@ func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Reques
 		// correct secret specified in the spec.
 		r.Log.Info("the secret in status.secretRef doesn't exist or wasn't set, establishing peering with the existing spec.peer.secret", "secret-name", dialer.Secret().Name, "secret-namespace", dialer.Namespace)
 		peeringToken := specSecret.Data[dialer.Secret().Key]
-		if err := r.establishPeering(ctx, apiClient, dialer.Name, string(peeringToken)); err != nil {
+		if err := r.establishPeering(ctx, apiClient, dialer, string(peeringToken)); err != nil {
 			r.updateStatusError(ctx, dialer, consulAgentError, err)
 			return ctrl.Result{}, err
 		} else {
@@ -162,7 +169,7 @@ func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Reques
 		if peering == nil {
 			r.Log.Info("status.secret exists, but the peering doesn't exist in Consul; establishing peering with the existing spec.peer.secret", "secret-name", dialer.Secret().Name, "secret-namespace", dialer.Namespace)
 			peeringToken := specSecret.Data[dialer.Secret().Key]
-			if err := r.establishPeering(ctx, apiClient, dialer.Name, string(peeringToken)); err != nil {
+			if err := r.establishPeering(ctx, apiClient, dialer, string(peeringToken)); err != nil {
 				r.updateStatusError(ctx, dialer, consulAgentError, err)
 				return ctrl.Result{}, err
 			} else {
@@ -176,7 +183,7 @@ func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Reques
 		if r.specStatusSecretsDifferent(dialer, specSecret) {
 			r.Log.Info("the spec.peer.secret is different from the status secret, re-establishing peering", "secret-name", dialer.Secret().Name, "secret-namespace", dialer.Namespace)
 			peeringToken := specSecret.Data[dialer.Secret().Key]
-			if err := r.establishPeering(ctx, apiClient, dialer.Name, string(peeringToken)); err != nil {
+			if err := r.establishPeering(ctx, apiClient, dialer, string(peeringToken)); err != nil {
 				r.updateStatusError(ctx, dialer, consulAgentError, err)
 				return ctrl.Result{}, err
 			} else {
@@ -188,7 +195,7 @@ func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Reques
 		if updated, err := r.versionAnnotationUpdated(dialer); err == nil && updated {
 			r.Log.Info("the version annotation was incremented; re-establishing peering with spec.peer.secret", "secret-name", dialer.Secret().Name, "secret-namespace", dialer.Namespace)
 			peeringToken := specSecret.Data[dialer.Secret().Key]
-			if err := r.establishPeering(ctx, apiClient, dialer.Name, string(peeringToken)); err != nil {
+			if err := r.establishPeering(ctx, apiClient, dialer, string(peeringToken)); err != nil {
 				r.updateStatusError(ctx, dialer, consulAgentError, err)
 				return ctrl.Result{}, err
 			} else {
@@ -199,11 +206,35 @@ func (r *PeeringDialerController) Reconcile(ctx context.Context, req ctrl.Reques
 			r.updateStatusError(ctx, dialer, internalError, err)
 			return ctrl.Result{}, err
 		}
+
+		// A failing or terminated peering can't be re-established with the token it was established with. Report it
+		// until the acceptor's new token is stored in spec.peer.secret, which re-establishes the peering above. Since
+		// changes to the peering state in Consul don't trigger a reconcile, check it again periodically.
+		if peering.State == api.PeeringStateFailing || peering.State == api.PeeringStateTerminated {
+			r.Log.Info("peering is failing; waiting for a new peering token in spec.peer.secret", "name", dialer.Name, "state", peering.State)
+			r.updateStatusError(ctx, dialer, peeringFailing, fmt.Errorf("peering is %s", peering.State))
+			return ctrl.Result{RequeueAfter: failedPeeringRotationInterval}, nil
+		}
+		if peeringFailureReported(dialer) {
+			r.Log.Info("peering recovered", "name", dialer.Name, "state", peering.State)
+			err := r.updateStatus(ctx, req.NamespacedName, specSecret.ResourceVersion)
+			return ctrl.Result{}, err
+		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// peeringFailureReported returns true if the dialer's Synced condition reports that the peering is failing.
+func peeringFailureReported(dialer *consulv1alpha1.PeeringDialer) bool {
+	for _, cond := range dialer.Status.Conditions {
+		if cond.Type == consulv1alpha1.ConditionSynced {
+			return cond.IsFalse() && cond.Reason == peeringFailing
+		}
+	}
+	return false
+}
+
 func (r *PeeringDialerController) specStatusSecretsDifferent(dialer *consulv1alpha1.PeeringDialer, existingSpecSecret *corev1.Secret) bool {
 	if dialer.SecretRef().Name != dialer.Secret().Name {
 		return true
@@ -271,6 +302,7 @@ func (r *PeeringDialerController) getSecret(ctx context.Context, name string, na
 func (r *PeeringDialerController) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&consulv1alpha1.PeeringDialer{}).
+		WithOptions(r.ControllerOptions).
 		Watches(
 			&source.Kind{Type: &corev1.Secret{}},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForPeeringTokens),
@@ -279,12 +311,13 @@ func (r *PeeringDialerController) SetupWithManager(mgr ctrl.Manager) error {
 }
 
 // establishPeering is a helper function that calls the Consul api to generate a token for the peer.
-func (r *PeeringDialerController) establishPeering(ctx context.Context, apiClient *api.Client, peerName string, peeringToken string) error {
+func (r *PeeringDialerController) establishPeering(ctx context.Context, apiClient *api.Client, dialer *consulv1alpha1.PeeringDialer, peeringToken string) error {
 	req := api.PeeringEstablishRequest{
-		PeerName:     peerName,
+		PeerName:     dialer.Name,
 		PeeringToken: peeringToken,
 	}
 	_, _, err := apiClient.Peerings().Establish(ctx, req, nil)
+	r.recordWrite(dialer, audit.OperationCreate, err)
 	if err != nil {
 		r.Log.Error(err, "failed to initiate peering", "err", err)
 		return err
@@ -293,15 +326,28 @@ func (r *PeeringDialerController) establishPeering(ctx context.Context, apiClien
 }
 
 // deletePeering is a helper function that calls the Consul api to delete a peering.
-func (r *PeeringDialerController) deletePeering(ctx context.Context, apiClient *api.Client, peerName string) error {
-	_, err := apiClient.Peerings().Delete(ctx, peerName, nil)
+func (r *PeeringDialerController) deletePeering(ctx context.Context, apiClient *api.Client, dialer *consulv1alpha1.PeeringDialer) error {
+	_, err := apiClient.Peerings().Delete(ctx, dialer.Name, nil)
+	r.recordWrite(dialer, audit.OperationDelete, err)
 	if err != nil {
-		r.Log.Error(err, "failed to delete Peering from Consul", "name", peerName)
+		r.Log.Error(err, "failed to delete Peering from Consul", "name", dialer.Name)
 		return err
 	}
 	return nil
 }
 
+// recordWrite records a write of the peering of the PeeringDialer in the audit log.
+// The peering token is not recorded since it is a secret.
+func (r *PeeringDialerController) recordWrite(dialer *consulv1alpha1.PeeringDialer, op audit.Operation, err error) {
+	r.Auditor.Record(audit.Event{
+		Actor:     "controller/peering-dialer",
+		Operation: op,
+		Resource:  audit.ResourcePeering,
+		Name:      dialer.Name,
+		Source:    &audit.Source{Kind: "PeeringDialer", Namespace: dialer.Namespace, Name: dialer.Name},
+	}, err)
+}
+
 func (r *PeeringDialerController) versionAnnotationUpdated(dialer *consulv1alpha1.PeeringDialer) (bool, error) {
 	if peeringVersionString, ok := dialer.Annotations[constants.AnnotationPeeringVersion]; ok {
 		peeringVersion, err := strconv.ParseUint(peeringVersionString, 10, 64)
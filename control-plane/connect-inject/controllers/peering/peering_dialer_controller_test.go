@@ -1290,3 +1290,28 @@ func TestDialer_RequestsForPeeringTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestPeeringFailureReported(t *testing.T) {
+	cases := map[string]struct {
+		conditions v1alpha1.Conditions
+		exp        bool
+	}{
+		"no conditions": {},
+		"synced": {
+			conditions: v1alpha1.Conditions{{Type: v1alpha1.ConditionSynced, Status: corev1.ConditionTrue}},
+		},
+		"failed to sync": {
+			conditions: v1alpha1.Conditions{{Type: v1alpha1.ConditionSynced, Status: corev1.ConditionFalse, Reason: consulAgentError}},
+		},
+		"peering failing": {
+			conditions: v1alpha1.Conditions{{Type: v1alpha1.ConditionSynced, Status: corev1.ConditionFalse, Reason: peeringFailing}},
+			exp:        true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			dialer := &v1alpha1.PeeringDialer{Status: v1alpha1.PeeringDialerStatus{Conditions: c.conditions}}
+			require.Equal(t, c.exp, peeringFailureReported(dialer))
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package registration
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	consulv1alpha1 "github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// GeneratedLabel is set to "true" on the Registrations generated from annotated
+// Services. Registrations without it are never modified.
+const GeneratedLabel = "consul.hashicorp.com/generated-from-service"
+
+// ExternalServiceController generates a Registration for each Service of type
+// ExternalName with the terminating-gateway annotation. The RegistrationController
+// then registers the external host in Consul and links it to the terminating
+// gateway, so that services can be linked without writing a Registration.
+//
+// The Registrations are named after the Service and owned by it, so they're
+// deleted with it. Registrations created by users are never modified.
+type ExternalServiceController struct {
+	client.Client
+	// Log is the logger for this controller.
+	Log logr.Logger
+	// ControllerOptions configures the concurrency and rate limiting of this controller's workqueue.
+	// The zero value uses the controller-runtime defaults.
+	ControllerOptions controller.Options
+}
+
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
+//+kubebuilder:rbac:groups=consul.hashicorp.com,resources=registrations,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile creates, updates or deletes the Registration generated for the
+// Service in the request.
+func (r *ExternalServiceController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var service corev1.Service
+	err := r.Client.Get(ctx, req.NamespacedName, &service)
+	if k8serrors.IsNotFound(err) {
+		// The Registration is garbage collected with the Service.
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		r.Log.Error(err, "failed to get Service", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	gateway := service.Annotations[constants.AnnotationTerminatingGateway]
+	if gateway != "" && service.Spec.Type != corev1.ServiceTypeExternalName {
+		r.Log.Info("ignoring terminating-gateway annotation of Service that isn't of type ExternalName", "name", req.Name, "ns", req.Namespace)
+		gateway = ""
+	}
+
+	var existing consulv1alpha1.Registration
+	err = r.Client.Get(ctx, req.NamespacedName, &existing)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		r.Log.Error(err, "failed to get Registration", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	found := err == nil
+	if found && (existing.Labels[GeneratedLabel] != "true" || !metav1.IsControlledBy(&existing, &service)) {
+		if gateway != "" {
+			r.Log.Info("skipping Registration that wasn't generated from Service", "name", req.Name, "ns", req.Namespace)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	switch {
+	case gateway == "" && found:
+		r.Log.Info("deleting Registration", "name", req.Name, "ns", req.Namespace)
+		if err := r.Client.Delete(ctx, &existing); err != nil && !k8serrors.IsNotFound(err) {
+			r.Log.Error(err, "failed to delete Registration", "name", req.Name, "ns", req.Namespace)
+			return ctrl.Result{}, err
+		}
+	case gateway != "" && found:
+		spec := registrationSpecForService(service, gateway)
+		if reflect.DeepEqual(existing.Spec, spec) {
+			return ctrl.Result{}, nil
+		}
+		r.Log.Info("updating Registration", "name", req.Name, "ns", req.Namespace, "gateway", gateway)
+		existing.Spec = spec
+		if err := r.Client.Update(ctx, &existing); err != nil {
+			r.Log.Error(err, "failed to update Registration", "name", req.Name, "ns", req.Namespace)
+			return ctrl.Result{}, err
+		}
+	case gateway != "":
+		registration := &consulv1alpha1.Registration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      service.Name,
+				Namespace: service.Namespace,
+				Labels:    map[string]string{GeneratedLabel: "true"},
+			},
+			Spec: registrationSpecForService(service, gateway),
+		}
+		if err := controllerutil.SetControllerReference(&service, registration, r.Client.Scheme()); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.Log.Info("creating Registration", "name", req.Name, "ns", req.Namespace, "gateway", gateway)
+		err := r.Client.Create(ctx, registration)
+		if k8serrors.IsAlreadyExists(err) {
+			// A user created the Registration in the meantime.
+			return ctrl.Result{}, nil
+		}
+		if err != nil {
+			r.Log.Error(err, "failed to create Registration", "name", req.Name, "ns", req.Namespace)
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// registrationSpecForService returns the Registration of the external host of
+// service. The host is registered as a node marked as external, so that
+// consul-esm can monitor it, with the service on the first port of the Service.
+func registrationSpecForService(service corev1.Service, gateway string) consulv1alpha1.RegistrationSpec {
+	spec := consulv1alpha1.RegistrationSpec{
+		Node:     service.Spec.ExternalName,
+		Address:  service.Spec.ExternalName,
+		NodeMeta: map[string]string{"external-node": "true"},
+		Service: consulv1alpha1.RegistrationService{
+			Name: service.Name,
+		},
+		TerminatingGateway: gateway,
+	}
+	if len(service.Spec.Ports) > 0 {
+		spec.Service.Port = int(service.Spec.Ports[0].Port)
+	}
+	return spec
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ExternalServiceController) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("terminating-gateway-external-service").
+		For(&corev1.Service{}).
+		Owns(&consulv1alpha1.Registration{}).
+		WithOptions(r.ControllerOptions).
+		Complete(r)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package registration
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExternalServiceReconcile(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		serviceType corev1.ServiceType
+		gateway     string
+		// expSpec is nil if no Registration is expected.
+		expSpec *v1alpha1.RegistrationSpec
+	}{
+		"annotated ExternalName service": {
+			serviceType: corev1.ServiceTypeExternalName,
+			gateway:     "terminating-gateway",
+			expSpec: &v1alpha1.RegistrationSpec{
+				Node:               "db.example.com",
+				Address:            "db.example.com",
+				NodeMeta:           map[string]string{"external-node": "true"},
+				Service:            v1alpha1.RegistrationService{Name: "db", Port: 5432},
+				TerminatingGateway: "terminating-gateway",
+			},
+		},
+		"ExternalName service without annotation": {
+			serviceType: corev1.ServiceTypeExternalName,
+		},
+		"annotated ClusterIP service": {
+			serviceType: corev1.ServiceTypeClusterIP,
+			gateway:     "terminating-gateway",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			svc := externalService(c.serviceType, c.gateway)
+			r, k8s := newExternalServiceController(t, svc)
+
+			runExternalServiceReconcile(t, r)
+			registrations := generatedRegistrations(t, k8s)
+			if c.expSpec == nil {
+				require.Empty(t, registrations)
+				return
+			}
+			require.Len(t, registrations, 1)
+			require.Equal(t, "db", registrations[0].Name)
+			require.Equal(t, *c.expSpec, registrations[0].Spec)
+			require.True(t, metav1.IsControlledBy(&registrations[0], svc))
+		})
+	}
+}
+
+func TestExternalServiceReconcile_UpdatesAndDeletesRegistration(t *testing.T) {
+	t.Parallel()
+	svc := externalService(corev1.ServiceTypeExternalName, "terminating-gateway")
+	r, k8s := newExternalServiceController(t, svc)
+	runExternalServiceReconcile(t, r)
+
+	// Changing the gateway updates the Registration.
+	svc.Annotations[constants.AnnotationTerminatingGateway] = "other-gateway"
+	require.NoError(t, k8s.Update(context.Background(), svc))
+	runExternalServiceReconcile(t, r)
+	registrations := generatedRegistrations(t, k8s)
+	require.Len(t, registrations, 1)
+	require.Equal(t, "other-gateway", registrations[0].Spec.TerminatingGateway)
+
+	// Removing the annotation deletes the Registration.
+	delete(svc.Annotations, constants.AnnotationTerminatingGateway)
+	require.NoError(t, k8s.Update(context.Background(), svc))
+	runExternalServiceReconcile(t, r)
+	require.Empty(t, generatedRegistrations(t, k8s))
+}
+
+func TestExternalServiceReconcile_KeepsUserRegistration(t *testing.T) {
+	t.Parallel()
+	svc := externalService(corev1.ServiceTypeExternalName, "terminating-gateway")
+	userRegistration := &v1alpha1.Registration{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: v1alpha1.RegistrationSpec{
+			Node:    "db-host",
+			Address: "10.0.0.1",
+			Service: v1alpha1.RegistrationService{Name: "db"},
+		},
+	}
+	r, k8s := newExternalServiceController(t, svc, userRegistration)
+	runExternalServiceReconcile(t, r)
+
+	var actual v1alpha1.Registration
+	require.NoError(t, k8s.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "db"}, &actual))
+	require.Equal(t, userRegistration.Spec, actual.Spec)
+	require.Empty(t, actual.OwnerReferences)
+}
+
+func externalService(serviceType corev1.ServiceType, gateway string) *corev1.Service {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "db",
+			Namespace:   "default",
+			UID:         "db-uid",
+			Annotations: map[string]string{},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:  serviceType,
+			Ports: []corev1.ServicePort{{Port: 5432}},
+		},
+	}
+	if serviceType == corev1.ServiceTypeExternalName {
+		svc.Spec.ExternalName = "db.example.com"
+	}
+	if gateway != "" {
+		svc.Annotations[constants.AnnotationTerminatingGateway] = gateway
+	}
+	return svc
+}
+
+func newExternalServiceController(t *testing.T, objs ...client.Object) (*ExternalServiceController, client.Client) {
+	t.Helper()
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.Registration{}, &v1alpha1.RegistrationList{})
+	k8s := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+	return &ExternalServiceController{Client: k8s, Log: logrtest.New(t)}, k8s
+}
+
+func runExternalServiceReconcile(t *testing.T, r *ExternalServiceController) {
+	t.Helper()
+	_, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "default", Name: "db"},
+	})
+	require.NoError(t, err)
+}
+
+func generatedRegistrations(t *testing.T, k8s client.Client) []v1alpha1.Registration {
+	t.Helper()
+	var list v1alpha1.RegistrationList
+	require.NoError(t, k8s.List(context.Background(), &list, client.MatchingLabels{GeneratedLabel: "true"}))
+	return list.Items
+}
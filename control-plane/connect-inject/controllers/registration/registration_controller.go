@@ -0,0 +1,531 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package registration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	consulv1alpha1 "github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	capi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	finalizerName = "finalizers.consul.hashicorp.com"
+
+	consulAgentError = "consulAgentError"
+)
+
+// errTerminatingGatewayModified is returned when the terminating gateway config entry
+// was modified between reading and writing it. The request is retried.
+var errTerminatingGatewayModified = errors.New("terminating gateway config entry was modified concurrently")
+
+// RegistrationController reconciles a Registration object.
+type RegistrationController struct {
+	client.Client
+	// ConsulClientConfig is the config to create a Consul API client.
+	ConsulClientConfig *consul.Config
+	// ConsulServerConnMgr is the watcher for the Consul server addresses.
+	ConsulServerConnMgr consul.ServerConnectionManager
+	// Log is the logger for this controller.
+	Log logr.Logger
+	// Scheme is the API scheme that this controller should have.
+	Scheme *runtime.Scheme
+	// ACLsEnabled is true when consul-k8s manages the Consul ACL system. The ACL role
+	// of a terminating gateway is then granted write access to the services linked to it.
+	ACLsEnabled bool
+	// ResourcePrefix is the prefix of the ACL role names created by server-acl-init.
+	ResourcePrefix string
+	// ClusterName is the -cluster-name of server-acl-init, which it adds to the names of
+	// the ACL roles and policies so that clusters sharing the Consul servers don't clash.
+	ClusterName string
+	// ControllerOptions configures the concurrency and rate limiting of this controller's workqueue.
+	// The zero value uses the controller-runtime defaults.
+	ControllerOptions controller.Options
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
+	context.Context
+}
+
+//+kubebuilder:rbac:groups=consul.hashicorp.com,resources=registrations,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=consul.hashicorp.com,resources=registrations/status,verbs=get;update;patch
+
+// Reconcile registers the service of a Registration in the Consul catalog and
+// deregisters it when the Registration is deleted.
+func (r *RegistrationController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Log.Info("received request for Registration", "name", req.Name, "ns", req.Namespace)
+
+	registration := &consulv1alpha1.Registration{}
+	err := r.Client.Get(ctx, req.NamespacedName, registration)
+	// This can be safely ignored as a resource will only ever be not found if it has never been reconciled
+	// since we add finalizers to our resources.
+	if k8serrors.IsNotFound(err) {
+		r.Log.Info("Registration resource not found. Ignoring resource", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		r.Log.Error(err, "failed to get Registration", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	// Create Consul client for this reconcile.
+	serverState, err := r.ConsulServerConnMgr.State()
+	if err != nil {
+		r.Log.Error(err, "failed to get Consul server state", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	apiClient, err := consul.NewClientFromConnMgrState(r.ConsulClientConfig, serverState)
+	if err != nil {
+		r.Log.Error(err, "failed to create Consul API client", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	// The DeletionTimestamp is zero when the object has not been marked for deletion. The finalizer is added
+	// in case it does not exist to all resources. If the DeletionTimestamp is non-zero, the object has been
+	// marked for deletion and goes into the deletion workflow.
+	if registration.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(registration, finalizerName) {
+			controllerutil.AddFinalizer(registration, finalizerName)
+			if err := r.Update(ctx, registration); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if controllerutil.ContainsFinalizer(registration, finalizerName) {
+			r.Log.Info("Registration was deleted, deregistering from Consul", "name", req.Name, "ns", req.Namespace)
+			if err := r.deregister(ctx, apiClient, registration); err != nil {
+				r.updateStatusError(ctx, registration, consulAgentError, err)
+				return ctrl.Result{}, err
+			}
+			controllerutil.RemoveFinalizer(registration, finalizerName)
+			err := r.Update(ctx, registration)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.register(apiClient, registration); err != nil {
+		r.updateStatusError(ctx, registration, consulAgentError, err)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.updateStatus(ctx, registration)
+}
+
+// register registers the service in the Consul catalog and links it to the
+// terminating gateway.
+func (r *RegistrationController) register(apiClient *capi.Client, registration *consulv1alpha1.Registration) error {
+	catalogRegistration := registration.ToCatalogRegistration()
+
+	// The status of the health check of an external service is updated by consul-esm,
+	// so re-registering the service must not reset it to the initial status.
+	if catalogRegistration.Check != nil {
+		checks, _, err := apiClient.Health().Checks(registration.Spec.Service.Name, &capi.QueryOptions{
+			Datacenter: registration.Spec.Datacenter,
+			Namespace:  registration.Spec.Service.Namespace,
+			Partition:  registration.Spec.Partition,
+		})
+		if err != nil {
+			return err
+		}
+		for _, check := range checks {
+			if check.Node == catalogRegistration.Check.Node && check.CheckID == catalogRegistration.Check.CheckID {
+				catalogRegistration.Check.Status = check.Status
+				catalogRegistration.Check.Output = check.Output
+			}
+		}
+	}
+
+	r.Log.Info("registering service with Consul", "service", registration.Spec.Service.Name, "node", registration.Spec.Node)
+	_, err := apiClient.Catalog().Register(catalogRegistration, nil)
+	r.recordWrite(registration, audit.Event{
+		Operation: audit.OperationUpsert,
+		Resource:  audit.ResourceCatalogService,
+		Name:      fmt.Sprintf("%s/%s", catalogRegistration.Node, catalogRegistration.Service.ID),
+		Namespace: catalogRegistration.Service.Namespace,
+		After:     catalogRegistration,
+	}, err)
+	if err != nil {
+		return err
+	}
+
+	if registration.Spec.TerminatingGateway != "" {
+		// The gateway must be allowed to access the service before it is linked to it.
+		if r.ACLsEnabled {
+			if err := r.updateTerminatingGatewayACLs(apiClient, registration, true); err != nil {
+				return err
+			}
+		}
+		return r.updateTerminatingGateway(apiClient, registration, true)
+	}
+	return nil
+}
+
+// deregister removes the service from the terminating gateway and deregisters
+// it from the Consul catalog. The service stays linked to the gateway while
+// other Registrations, e.g. for other instances of the service, still link it.
+// The config entry of the gateway is per datacenter, but its ACL role and
+// policies are global, so the policy is kept while Registrations in any
+// datacenter link the service.
+func (r *RegistrationController) deregister(ctx context.Context, apiClient *capi.Client, registration *consulv1alpha1.Registration) error {
+	linkedByOthers, err := r.linkedByOtherRegistrations(ctx, registration, false)
+	if err != nil {
+		return err
+	}
+	if registration.Spec.TerminatingGateway != "" && !linkedByOthers {
+		if err := r.updateTerminatingGateway(apiClient, registration, false); err != nil {
+			return err
+		}
+	}
+	if registration.Spec.TerminatingGateway != "" && r.ACLsEnabled {
+		linkedInOtherDatacenters, err := r.linkedByOtherRegistrations(ctx, registration, true)
+		if err != nil {
+			return err
+		}
+		if !linkedInOtherDatacenters {
+			if err := r.updateTerminatingGatewayACLs(apiClient, registration, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.Log.Info("deregistering service from Consul", "service", registration.Spec.Service.Name, "node", registration.Spec.Node)
+	deregistration := registration.ToCatalogDeregistration()
+	_, err = apiClient.Catalog().Deregister(deregistration, nil)
+	if err != nil && isNotFoundErr(err) {
+		return nil
+	}
+	r.recordWrite(registration, audit.Event{
+		Operation: audit.OperationDelete,
+		Resource:  audit.ResourceCatalogService,
+		Name:      fmt.Sprintf("%s/%s", deregistration.Node, deregistration.ServiceID),
+		Namespace: deregistration.Namespace,
+	}, err)
+	return err
+}
+
+// linkedByOtherRegistrations returns true if a Registration other than registration
+// links the same service to the same terminating gateway in the same datacenter, or
+// in any datacenter if anyDatacenter is true. Registrations that are being deleted
+// don't count.
+func (r *RegistrationController) linkedByOtherRegistrations(ctx context.Context, registration *consulv1alpha1.Registration, anyDatacenter bool) (bool, error) {
+	if registration.Spec.TerminatingGateway == "" {
+		return false, nil
+	}
+	var registrations consulv1alpha1.RegistrationList
+	if err := r.Client.List(ctx, &registrations); err != nil {
+		return false, err
+	}
+	for _, other := range registrations.Items {
+		if other.Namespace == registration.Namespace && other.Name == registration.Name {
+			continue
+		}
+		if !other.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		if other.Spec.TerminatingGateway == registration.Spec.TerminatingGateway &&
+			other.Spec.Service.Name == registration.Spec.Service.Name &&
+			other.Spec.Service.Namespace == registration.Spec.Service.Namespace &&
+			other.Spec.Partition == registration.Spec.Partition &&
+			(anyDatacenter || other.Spec.Datacenter == registration.Spec.Datacenter) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// updateTerminatingGateway adds the service to, or removes it from, the
+// services of the terminating gateway config entry. The config entry is
+// written with a check-and-set so that concurrent updates for other services
+// aren't lost.
+func (r *RegistrationController) updateTerminatingGateway(apiClient *capi.Client, registration *consulv1alpha1.Registration, link bool) error {
+	gatewayName := registration.Spec.TerminatingGateway
+	queryOpts := &capi.QueryOptions{
+		Datacenter: registration.Spec.Datacenter,
+		Partition:  registration.Spec.Partition,
+	}
+	entry := &capi.TerminatingGatewayConfigEntry{
+		Kind:      capi.TerminatingGateway,
+		Name:      gatewayName,
+		Partition: registration.Spec.Partition,
+	}
+	existing, _, err := apiClient.ConfigEntries().Get(capi.TerminatingGateway, gatewayName, queryOpts)
+	if err != nil && !isNotFoundErr(err) {
+		return err
+	}
+	// before is the config entry as read from Consul. Services is replaced
+	// rather than modified below, so a shallow copy is enough.
+	var before *capi.TerminatingGatewayConfigEntry
+	if existing != nil {
+		entry = existing.(*capi.TerminatingGatewayConfigEntry)
+		copied := *entry
+		before = &copied
+	} else if !link {
+		return nil
+	}
+
+	service := registration.Spec.Service
+	var services []capi.LinkedService
+	linked := false
+	for _, svc := range entry.Services {
+		if svc.Name == service.Name && svc.Namespace == service.Namespace {
+			linked = true
+			if !link {
+				continue
+			}
+		}
+		services = append(services, svc)
+	}
+	if linked == link {
+		return nil
+	}
+	if link {
+		services = append(services, capi.LinkedService{Name: service.Name, Namespace: service.Namespace})
+	}
+	entry.Services = services
+
+	r.Log.Info("updating terminating gateway config entry", "name", gatewayName, "service", service.Name, "linked", link)
+	ok, _, err := apiClient.ConfigEntries().CAS(entry, entry.ModifyIndex, &capi.WriteOptions{
+		Datacenter: registration.Spec.Datacenter,
+		Partition:  registration.Spec.Partition,
+	})
+	if err != nil || ok {
+		op := audit.OperationUpdate
+		if before == nil {
+			op = audit.OperationCreate
+		}
+		r.recordWrite(registration, audit.Event{
+			Operation: op,
+			Resource:  audit.ResourceConfigEntry,
+			Name:      fmt.Sprintf("%s/%s", capi.TerminatingGateway, gatewayName),
+			Before:    before,
+			After:     entry,
+		}, err)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errTerminatingGatewayModified
+	}
+	return nil
+}
+
+// updateTerminatingGatewayACLs creates a policy that grants write access to the
+// service and attaches it to the ACL role of the terminating gateway, or detaches
+// and deletes that policy again.
+func (r *RegistrationController) updateTerminatingGatewayACLs(apiClient *capi.Client, registration *consulv1alpha1.Registration, link bool) error {
+	queryOpts := &capi.QueryOptions{
+		Datacenter: registration.Spec.Datacenter,
+		Partition:  registration.Spec.Partition,
+	}
+	writeOpts := &capi.WriteOptions{
+		Datacenter: registration.Spec.Datacenter,
+		Partition:  registration.Spec.Partition,
+	}
+
+	roleName := r.terminatingGatewayRoleName(registration.Spec.TerminatingGateway)
+	role, _, err := apiClient.ACL().RoleReadByName(roleName, queryOpts)
+	if err != nil {
+		return err
+	}
+	if role == nil && link {
+		return fmt.Errorf("ACL role %q of terminating gateway %q not found", roleName, registration.Spec.TerminatingGateway)
+	}
+
+	policyName := r.terminatingGatewayPolicyName(registration)
+	policy, _, err := apiClient.ACL().PolicyReadByName(policyName, queryOpts)
+	if err != nil && !isNotFoundErr(err) {
+		return err
+	}
+
+	if link {
+		rules := terminatingGatewayPolicyRules(registration)
+		if policy == nil {
+			r.Log.Info("creating terminating gateway ACL policy", "name", policyName)
+			newPolicy := &capi.ACLPolicy{
+				Name:        policyName,
+				Description: fmt.Sprintf("Write policy for service %q linked to terminating gateway %q", registration.Spec.Service.Name, registration.Spec.TerminatingGateway),
+				Rules:       rules,
+			}
+			policy, _, err = apiClient.ACL().PolicyCreate(newPolicy, writeOpts)
+			r.recordWrite(registration, audit.Event{
+				Operation: audit.OperationCreate,
+				Resource:  audit.ResourceACLPolicy,
+				Name:      policyName,
+				After:     newPolicy,
+			}, err)
+			if err != nil {
+				return err
+			}
+		} else if policy.Rules != rules {
+			before := *policy
+			policy.Rules = rules
+			_, _, err := apiClient.ACL().PolicyUpdate(policy, writeOpts)
+			r.recordWrite(registration, audit.Event{
+				Operation: audit.OperationUpdate,
+				Resource:  audit.ResourceACLPolicy,
+				Name:      policyName,
+				Before:    &before,
+				After:     policy,
+			}, err)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, rolePolicy := range role.Policies {
+			if rolePolicy.ID == policy.ID || rolePolicy.Name == policyName {
+				return nil
+			}
+		}
+		r.Log.Info("attaching ACL policy to terminating gateway role", "policy", policyName, "role", roleName)
+		before := *role
+		role.Policies = append(role.Policies, &capi.ACLRolePolicyLink{ID: policy.ID, Name: policyName})
+		_, _, err = apiClient.ACL().RoleUpdate(role, writeOpts)
+		r.recordRoleUpdate(registration, &before, role, err)
+		return err
+	}
+
+	if role != nil {
+		var policies []*capi.ACLRolePolicyLink
+		for _, rolePolicy := range role.Policies {
+			if rolePolicy.Name == policyName || (policy != nil && rolePolicy.ID == policy.ID) {
+				continue
+			}
+			policies = append(policies, rolePolicy)
+		}
+		if len(policies) != len(role.Policies) {
+			r.Log.Info("detaching ACL policy from terminating gateway role", "policy", policyName, "role", roleName)
+			before := *role
+			role.Policies = policies
+			_, _, err := apiClient.ACL().RoleUpdate(role, writeOpts)
+			r.recordRoleUpdate(registration, &before, role, err)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if policy != nil {
+		r.Log.Info("deleting terminating gateway ACL policy", "name", policyName)
+		_, err := apiClient.ACL().PolicyDelete(policy.ID, writeOpts)
+		if err != nil && isNotFoundErr(err) {
+			return nil
+		}
+		r.recordWrite(registration, audit.Event{
+			Operation: audit.OperationDelete,
+			Resource:  audit.ResourceACLPolicy,
+			Name:      policyName,
+			Before:    policy,
+		}, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RegistrationController) recordRoleUpdate(registration *consulv1alpha1.Registration, before, after *capi.ACLRole, err error) {
+	r.recordWrite(registration, audit.Event{
+		Operation: audit.OperationUpdate,
+		Resource:  audit.ResourceACLRole,
+		Name:      after.Name,
+		Before:    before,
+		After:     after,
+	}, err)
+}
+
+// recordWrite records a write to Consul made for the Registration in the audit log.
+func (r *RegistrationController) recordWrite(registration *consulv1alpha1.Registration, event audit.Event, err error) {
+	event.Actor = "controller/registration"
+	event.Source = &audit.Source{Kind: "Registration", Namespace: registration.Namespace, Name: registration.Name}
+	event.Partition = registration.Spec.Partition
+	r.Auditor.Record(event, err)
+}
+
+// terminatingGatewayRoleName returns the name of the ACL role that server-acl-init
+// creates for the terminating gateway.
+func (r *RegistrationController) terminatingGatewayRoleName(gatewayName string) string {
+	if r.ClusterName != "" {
+		return fmt.Sprintf("%s-%s-%s-acl-role", r.ResourcePrefix, r.ClusterName, gatewayName)
+	}
+	return fmt.Sprintf("%s-%s-acl-role", r.ResourcePrefix, gatewayName)
+}
+
+// terminatingGatewayPolicyName returns the name of the ACL policy that grants the
+// terminating gateway write access to the service of the Registration. Like the
+// policies of server-acl-init, it ends with the cluster name if there is one.
+func (r *RegistrationController) terminatingGatewayPolicyName(registration *consulv1alpha1.Registration) string {
+	service := registration.Spec.Service
+	name := fmt.Sprintf("%s-%s-write-policy", registration.Spec.TerminatingGateway, service.Name)
+	if service.Namespace != "" {
+		name = fmt.Sprintf("%s-%s-%s-write-policy", registration.Spec.TerminatingGateway, service.Namespace, service.Name)
+	}
+	if r.ClusterName != "" {
+		name = fmt.Sprintf("%s-%s", name, r.ClusterName)
+	}
+	return name
+}
+
+func terminatingGatewayPolicyRules(registration *consulv1alpha1.Registration) string {
+	rules := fmt.Sprintf("service %q {\n  policy = \"write\"\n}", registration.Spec.Service.Name)
+	if registration.Spec.Service.Namespace != "" {
+		rules = fmt.Sprintf("namespace %q {\n%s\n}", registration.Spec.Service.Namespace, rules)
+	}
+	if registration.Spec.Partition != "" {
+		rules = fmt.Sprintf("partition %q {\n%s\n}", registration.Spec.Partition, rules)
+	}
+	return rules
+}
+
+// updateStatus sets the Synced condition to true. The status is only updated
+// when the condition changes or a new generation was synced so that
+// reconciles don't keep updating it.
+func (r *RegistrationController) updateStatus(ctx context.Context, registration *consulv1alpha1.Registration) error {
+	if registration.Status.GetCondition(consulv1alpha1.ConditionSynced).IsTrue() &&
+		registration.Status.ObservedGeneration == registration.Generation {
+		return nil
+	}
+	registration.SetSyncedCondition(corev1.ConditionTrue, "", "")
+	timeNow := metav1.NewTime(time.Now())
+	registration.SetLastSyncedTime(&timeNow)
+	if err := r.Status().Update(ctx, registration); err != nil {
+		r.Log.Error(err, "failed to update Registration status", "name", registration.Name, "namespace", registration.Namespace)
+		return err
+	}
+	return nil
+}
+
+func (r *RegistrationController) updateStatusError(ctx context.Context, registration *consulv1alpha1.Registration, reason string, reconcileErr error) {
+	registration.SetSyncedCondition(corev1.ConditionFalse, reason, reconcileErr.Error())
+	if err := r.Status().Update(ctx, registration); err != nil {
+		r.Log.Error(err, "failed to update Registration status", "name", registration.Name, "namespace", registration.Namespace)
+	}
+}
+
+func (r *RegistrationController) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Status updates must not trigger a reconcile.
+		For(&consulv1alpha1.Registration{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		WithOptions(r.ControllerOptions).
+		Complete(r)
+}
+
+func isNotFoundErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "404")
+}
@@ -0,0 +1,445 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package registration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcile_CreateRegistration(t *testing.T) {
+	t.Parallel()
+	registration := &v1alpha1.Registration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "external",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RegistrationSpec{
+			Node:    "external-node",
+			Address: "10.0.0.1",
+			Service: v1alpha1.RegistrationService{
+				Name: "external",
+				Port: 443,
+			},
+			HealthCheck: &v1alpha1.RegistrationHealthCheck{
+				Name:   "external-check",
+				Status: api.HealthPassing,
+			},
+			TerminatingGateway: "terminating-gateway",
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.Registration{}, &v1alpha1.RegistrationList{})
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(registration).Build()
+
+	testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+	consulClient := testClient.APIClient
+
+	// An existing linked service of the terminating gateway must be kept.
+	_, _, err := consulClient.ConfigEntries().Set(&api.TerminatingGatewayConfigEntry{
+		Kind:     api.TerminatingGateway,
+		Name:     "terminating-gateway",
+		Services: []api.LinkedService{{Name: "other"}},
+	}, nil)
+	require.NoError(t, err)
+
+	controller := &RegistrationController{
+		Client:              fakeClient,
+		Log:                 logrtest.New(t),
+		ConsulClientConfig:  testClient.Cfg,
+		ConsulServerConnMgr: testClient.Watcher,
+		Scheme:              s,
+	}
+	namespacedName := types.NamespacedName{Name: "external", Namespace: "default"}
+	resp, err := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.NoError(t, err)
+	require.False(t, resp.Requeue)
+
+	services, _, err := consulClient.Catalog().Service("external", "", nil)
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	require.Equal(t, "external-node", services[0].Node)
+	require.Equal(t, "10.0.0.1", services[0].Address)
+	require.Equal(t, 443, services[0].ServicePort)
+
+	checks, _, err := consulClient.Health().Checks("external", nil)
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	require.Equal(t, api.HealthPassing, checks[0].Status)
+
+	entry, _, err := consulClient.ConfigEntries().Get(api.TerminatingGateway, "terminating-gateway", nil)
+	require.NoError(t, err)
+	require.Equal(t, []api.LinkedService{{Name: "other"}, {Name: "external"}}, entry.(*api.TerminatingGatewayConfigEntry).Services)
+
+	err = fakeClient.Get(context.Background(), namespacedName, registration)
+	require.NoError(t, err)
+	require.Contains(t, registration.Finalizers, finalizerName)
+	require.Equal(t, corev1.ConditionTrue, registration.Status.GetCondition(v1alpha1.ConditionSynced).Status)
+	require.NotNil(t, registration.Status.LastSyncedTime)
+
+	// The status updated by consul-esm must not be reset by another reconcile.
+	_, err = consulClient.Catalog().Register(&api.CatalogRegistration{
+		Node:    "external-node",
+		Address: "10.0.0.1",
+		Check: &api.AgentCheck{
+			Node:      "external-node",
+			CheckID:   "external-check",
+			Name:      "external-check",
+			Status:    api.HealthCritical,
+			ServiceID: "external",
+		},
+		SkipNodeUpdate: true,
+	}, nil)
+	require.NoError(t, err)
+	_, err = controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.NoError(t, err)
+	checks, _, err = consulClient.Health().Checks("external", nil)
+	require.NoError(t, err)
+	require.Len(t, checks, 1)
+	require.Equal(t, api.HealthCritical, checks[0].Status)
+}
+
+// Test that the status is only updated when the Synced condition changes or
+// a new generation was synced.
+func TestUpdateStatus(t *testing.T) {
+	t.Parallel()
+	lastSynced := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	registration := &v1alpha1.Registration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "external",
+			Namespace:  "default",
+			Generation: 1,
+		},
+	}
+	registration.SetSyncedCondition(corev1.ConditionTrue, "", "")
+	registration.SetLastSyncedTime(&lastSynced)
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.Registration{}, &v1alpha1.RegistrationList{})
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(registration).Build()
+	controller := &RegistrationController{
+		Client: fakeClient,
+		Log:    logrtest.New(t),
+		Scheme: s,
+	}
+	namespacedName := types.NamespacedName{Name: "external", Namespace: "default"}
+
+	// The generation was already synced.
+	require.NoError(t, fakeClient.Get(context.Background(), namespacedName, registration))
+	require.NoError(t, controller.updateStatus(context.Background(), registration))
+	require.NoError(t, fakeClient.Get(context.Background(), namespacedName, registration))
+	require.True(t, lastSynced.Equal(registration.Status.LastSyncedTime))
+
+	// A new generation was synced.
+	registration.Generation = 2
+	require.NoError(t, controller.updateStatus(context.Background(), registration))
+	require.NoError(t, fakeClient.Get(context.Background(), namespacedName, registration))
+	require.True(t, registration.Status.LastSyncedTime.After(lastSynced.Time))
+	require.Equal(t, int64(2), registration.Status.ObservedGeneration)
+}
+
+func TestReconcile_DeleteRegistration(t *testing.T) {
+	t.Parallel()
+	registration := &v1alpha1.Registration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "external",
+			Namespace:         "default",
+			DeletionTimestamp: &metav1.Time{Time: time.Now()},
+			Finalizers:        []string{finalizerName},
+		},
+		Spec: v1alpha1.RegistrationSpec{
+			Node:               "external-node",
+			Address:            "10.0.0.1",
+			Service:            v1alpha1.RegistrationService{Name: "external"},
+			TerminatingGateway: "terminating-gateway",
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.Registration{}, &v1alpha1.RegistrationList{})
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(registration).Build()
+
+	testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+	consulClient := testClient.APIClient
+
+	_, err := consulClient.Catalog().Register(registration.ToCatalogRegistration(), nil)
+	require.NoError(t, err)
+	_, _, err = consulClient.ConfigEntries().Set(&api.TerminatingGatewayConfigEntry{
+		Kind:     api.TerminatingGateway,
+		Name:     "terminating-gateway",
+		Services: []api.LinkedService{{Name: "external"}, {Name: "other"}},
+	}, nil)
+	require.NoError(t, err)
+
+	controller := &RegistrationController{
+		Client:              fakeClient,
+		Log:                 logrtest.New(t),
+		ConsulClientConfig:  testClient.Cfg,
+		ConsulServerConnMgr: testClient.Watcher,
+		Scheme:              s,
+	}
+	namespacedName := types.NamespacedName{Name: "external", Namespace: "default"}
+	resp, err := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.NoError(t, err)
+	require.False(t, resp.Requeue)
+
+	services, _, err := consulClient.Catalog().Service("external", "", nil)
+	require.NoError(t, err)
+	require.Empty(t, services)
+
+	entry, _, err := consulClient.ConfigEntries().Get(api.TerminatingGateway, "terminating-gateway", nil)
+	require.NoError(t, err)
+	require.Equal(t, []api.LinkedService{{Name: "other"}}, entry.(*api.TerminatingGatewayConfigEntry).Services)
+
+	err = fakeClient.Get(context.Background(), namespacedName, registration)
+	require.EqualError(t, err, `registrations.consul.hashicorp.com "external" not found`)
+}
+
+func TestLinkedByOtherRegistrations(t *testing.T) {
+	t.Parallel()
+	newRegistration := func(name, service, gateway string) *v1alpha1.Registration {
+		return &v1alpha1.Registration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: v1alpha1.RegistrationSpec{
+				Node:               name,
+				Address:            "10.0.0.1",
+				Service:            v1alpha1.RegistrationService{Name: service},
+				TerminatingGateway: gateway,
+			},
+		}
+	}
+	deleted := newRegistration("external-deleted", "external", "terminating-gateway")
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	deleted.Finalizers = []string{finalizerName}
+	otherNamespace := newRegistration("external-ns", "external", "terminating-gateway")
+	otherNamespace.Spec.Service.Namespace = "ns1"
+	otherDatacenter := newRegistration("external-dc2", "external", "terminating-gateway")
+	otherDatacenter.Spec.Datacenter = "dc2"
+
+	cases := map[string]struct {
+		others           []*v1alpha1.Registration
+		exp              bool
+		expAnyDatacenter bool
+	}{
+		"no other registrations": {},
+		"other instance of the service": {
+			others:           []*v1alpha1.Registration{newRegistration("external-2", "external", "terminating-gateway")},
+			exp:              true,
+			expAnyDatacenter: true,
+		},
+		"other datacenter": {
+			others:           []*v1alpha1.Registration{otherDatacenter},
+			expAnyDatacenter: true,
+		},
+		"other service": {
+			others: []*v1alpha1.Registration{newRegistration("other", "other", "terminating-gateway")},
+		},
+		"other gateway": {
+			others: []*v1alpha1.Registration{newRegistration("external-2", "external", "other-gateway")},
+		},
+		"other Consul namespace": {
+			others: []*v1alpha1.Registration{otherNamespace},
+		},
+		"other registration is being deleted": {
+			others: []*v1alpha1.Registration{deleted},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			registration := newRegistration("external", "external", "terminating-gateway")
+			objs := []runtime.Object{registration}
+			for _, other := range c.others {
+				objs = append(objs, other)
+			}
+			s := runtime.NewScheme()
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.Registration{}, &v1alpha1.RegistrationList{})
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).Build()
+
+			controller := &RegistrationController{Client: fakeClient, Log: logrtest.New(t)}
+			actual, err := controller.linkedByOtherRegistrations(context.Background(), registration, false)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, actual)
+			actual, err = controller.linkedByOtherRegistrations(context.Background(), registration, true)
+			require.NoError(t, err)
+			require.Equal(t, c.expAnyDatacenter, actual)
+		})
+	}
+}
+
+func TestReconcile_TerminatingGatewayACLs(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		clusterName string
+		roleName    string
+		policyName  string
+	}{
+		"without cluster name": {
+			roleName:   "consul-terminating-gateway-acl-role",
+			policyName: "terminating-gateway-external-write-policy",
+		},
+		"with cluster name": {
+			clusterName: "cluster2",
+			roleName:    "consul-cluster2-terminating-gateway-acl-role",
+			policyName:  "terminating-gateway-external-write-policy-cluster2",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			testTerminatingGatewayACLs(t, c.clusterName, c.roleName, c.policyName)
+		})
+	}
+}
+
+func testTerminatingGatewayACLs(t *testing.T, clusterName, roleName, policyName string) {
+	registration := &v1alpha1.Registration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "external",
+			Namespace: "default",
+		},
+		Spec: v1alpha1.RegistrationSpec{
+			Node:               "external-node",
+			Address:            "10.0.0.1",
+			Service:            v1alpha1.RegistrationService{Name: "external"},
+			TerminatingGateway: "terminating-gateway",
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.Registration{}, &v1alpha1.RegistrationList{})
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(registration).Build()
+
+	adminToken := "123e4567-e89b-12d3-a456-426614174000"
+	testClient := test.TestServerWithMockConnMgrWatcher(t, func(c *testutil.TestServerConfig) {
+		c.ACL.Enabled = true
+		c.ACL.Tokens.InitialManagement = adminToken
+	})
+	consulClient := testClient.APIClient
+
+	// The role of the terminating gateway is created by server-acl-init.
+	gatewayPolicy, _, err := consulClient.ACL().PolicyCreate(&api.ACLPolicy{
+		Name:  "terminating-gateway-policy",
+		Rules: `service "terminating-gateway" { policy = "write" }`,
+	}, nil)
+	require.NoError(t, err)
+	_, _, err = consulClient.ACL().RoleCreate(&api.ACLRole{
+		Name:     roleName,
+		Policies: []*api.ACLRolePolicyLink{{ID: gatewayPolicy.ID}},
+	}, nil)
+	require.NoError(t, err)
+
+	controller := &RegistrationController{
+		Client:              fakeClient,
+		Log:                 logrtest.New(t),
+		ConsulClientConfig:  testClient.Cfg,
+		ConsulServerConnMgr: testClient.Watcher,
+		Scheme:              s,
+		ACLsEnabled:         true,
+		ResourcePrefix:      "consul",
+		ClusterName:         clusterName,
+	}
+	namespacedName := types.NamespacedName{Name: "external", Namespace: "default"}
+	_, err = controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.NoError(t, err)
+
+	policy, _, err := consulClient.ACL().PolicyReadByName(policyName, nil)
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	require.Equal(t, "service \"external\" {\n  policy = \"write\"\n}", policy.Rules)
+
+	role, _, err := consulClient.ACL().RoleReadByName(roleName, nil)
+	require.NoError(t, err)
+	require.Len(t, role.Policies, 2)
+	require.Equal(t, gatewayPolicy.ID, role.Policies[0].ID)
+	require.Equal(t, policy.ID, role.Policies[1].ID)
+
+	// Reconciling again must not attach the policy twice.
+	_, err = controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.NoError(t, err)
+	role, _, err = consulClient.ACL().RoleReadByName(roleName, nil)
+	require.NoError(t, err)
+	require.Len(t, role.Policies, 2)
+
+	// Deleting the Registration detaches and deletes the policy.
+	require.NoError(t, fakeClient.Get(context.Background(), namespacedName, registration))
+	require.NoError(t, fakeClient.Delete(context.Background(), registration))
+	_, err = controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.NoError(t, err)
+
+	role, _, err = consulClient.ACL().RoleReadByName(roleName, nil)
+	require.NoError(t, err)
+	require.Len(t, role.Policies, 1)
+	require.Equal(t, gatewayPolicy.ID, role.Policies[0].ID)
+	policy, _, err = consulClient.ACL().PolicyReadByName(policyName, nil)
+	require.NoError(t, err)
+	require.Nil(t, policy)
+}
+
+func TestTerminatingGatewayACLNames(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		clusterName string
+		namespace   string
+		expRole     string
+		expPolicy   string
+	}{
+		"default": {
+			expRole:   "consul-terminating-gateway-acl-role",
+			expPolicy: "terminating-gateway-external-write-policy",
+		},
+		"with namespace": {
+			namespace: "ns1",
+			expRole:   "consul-terminating-gateway-acl-role",
+			expPolicy: "terminating-gateway-ns1-external-write-policy",
+		},
+		"with cluster name": {
+			clusterName: "cluster2",
+			expRole:     "consul-cluster2-terminating-gateway-acl-role",
+			expPolicy:   "terminating-gateway-external-write-policy-cluster2",
+		},
+		"with cluster name and namespace": {
+			clusterName: "cluster2",
+			namespace:   "ns1",
+			expRole:     "consul-cluster2-terminating-gateway-acl-role",
+			expPolicy:   "terminating-gateway-ns1-external-write-policy-cluster2",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			controller := &RegistrationController{ResourcePrefix: "consul", ClusterName: c.clusterName}
+			registration := &v1alpha1.Registration{
+				Spec: v1alpha1.RegistrationSpec{
+					Service:            v1alpha1.RegistrationService{Name: "external", Namespace: c.namespace},
+					TerminatingGateway: "terminating-gateway",
+				},
+			}
+			require.Equal(t, c.expRole, controller.terminatingGatewayRoleName(registration.Spec.TerminatingGateway))
+			require.Equal(t, c.expPolicy, controller.terminatingGatewayPolicyName(registration))
+		})
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serverupgrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	capi "github.com/hashicorp/consul/api"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ReasonUpgradePaused is the reason of the events recorded when the
+	// rollout waits for the servers to become healthy.
+	ReasonUpgradePaused = "UpgradePaused"
+	// ReasonLeadershipTransferred is the reason of the events recorded when
+	// leadership is transferred away from the server that is restarted next.
+	ReasonLeadershipTransferred = "LeadershipTransferred"
+	// ReasonServerRestarted is the reason of the events recorded when the pod
+	// of a server is deleted to update it.
+	ReasonServerRestarted = "ServerRestarted"
+
+	// waitInterval is how long to wait before checking again whether the
+	// servers are ready for the next restart.
+	waitInterval = 10 * time.Second
+)
+
+// Controller rolls out updates of the Consul server StatefulSet.
+//
+// The StatefulSet uses the OnDelete update strategy, so Kubernetes only
+// updates a server when its pod is deleted. The controller deletes the pods
+// of outdated servers one at a time, only while all pods are ready and
+// autopilot reports all servers as healthy, so that a restart never costs the
+// cluster its quorum. Followers are restarted first. The leader is restarted
+// last, after it transferred leadership to another server.
+type Controller struct {
+	client.Client
+	// ConsulClientConfig is the config to create a Consul API client.
+	ConsulClientConfig *consul.Config
+	// ConsulServerConnMgr is the watcher for the Consul server addresses.
+	ConsulServerConnMgr consul.ServerConnectionManager
+	// Log is the logger for this controller.
+	Log logr.Logger
+	// EventRecorder records events on the StatefulSet as the rollout
+	// progresses. Events are not recorded if it is nil.
+	EventRecorder record.EventRecorder
+	// Namespace is the namespace of the StatefulSet, i.e. the namespace of
+	// the Helm release.
+	Namespace string
+	// StatefulSetName is the name of the Consul server StatefulSet.
+	StatefulSetName string
+
+	// cache reads the StatefulSet and its pods from Namespace only. It is
+	// set by SetupWithManager; the Client is used if it is nil.
+	cache client.Reader
+}
+
+// The StatefulSet and the pods are read and deleted with a Role in Namespace,
+// which the Helm chart creates, rather than with the manager's ClusterRole.
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile restarts the next outdated Consul server if the cluster is healthy
+// enough to lose it, and requeues the StatefulSet until all servers are
+// updated.
+func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Pods of other StatefulSets are watched too and enqueue their owner.
+	if req.Namespace != r.Namespace || req.Name != r.StatefulSetName {
+		return ctrl.Result{}, nil
+	}
+	var statefulSet appsv1.StatefulSet
+	err := r.reader().Get(ctx, req.NamespacedName, &statefulSet)
+	if k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		r.Log.Error(err, "failed to get StatefulSet", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	if statefulSet.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType {
+		r.Log.Info("StatefulSet does not use the OnDelete update strategy, leaving its rollout to Kubernetes", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, nil
+	}
+	// The update revision is stale until the StatefulSet controller has
+	// observed the latest spec.
+	if statefulSet.Status.ObservedGeneration < statefulSet.Generation || statefulSet.Status.UpdateRevision == "" {
+		return ctrl.Result{RequeueAfter: waitInterval}, nil
+	}
+
+	pods, err := r.serverPods(ctx, &statefulSet)
+	if err != nil {
+		r.Log.Error(err, "failed to list server pods", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	outdated := outdatedPods(pods, statefulSet.Status.UpdateRevision)
+	if len(outdated) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	// Only restart a server while every other one is up.
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if int32(len(pods)) < replicas {
+		r.Log.Info("waiting for all server pods to be created", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{RequeueAfter: waitInterval}, nil
+	}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || !isReady(pod) {
+			r.Log.Info("waiting for server pod to be ready", "pod", pod.Name, "ns", pod.Namespace)
+			return ctrl.Result{RequeueAfter: waitInterval}, nil
+		}
+	}
+
+	apiClient, err := consul.NewClientFromConnMgr(r.ConsulClientConfig, r.ConsulServerConnMgr)
+	if err != nil {
+		r.Log.Error(err, "failed to create Consul API client", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	if msg := r.checkHealth(apiClient, int(replicas)); msg != "" {
+		r.Log.Info("pausing server rollout", "reason", msg, "name", req.Name, "ns", req.Namespace)
+		r.recordEvent(&statefulSet, corev1.EventTypeWarning, ReasonUpgradePaused, msg)
+		return ctrl.Result{RequeueAfter: waitInterval}, nil
+	}
+
+	raftConfig, err := apiClient.Operator().RaftGetConfiguration(nil)
+	if err != nil {
+		r.Log.Error(err, "failed to get raft configuration", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	var leader string
+	for _, server := range raftConfig.Servers {
+		if server.Leader {
+			leader = server.Node
+		}
+	}
+	if leader == "" {
+		r.recordEvent(&statefulSet, corev1.EventTypeWarning, ReasonUpgradePaused, "the Consul servers have no leader")
+		return ctrl.Result{RequeueAfter: waitInterval}, nil
+	}
+
+	// Restart the followers first, so that leadership changes only once.
+	next := outdated[0]
+	for _, pod := range outdated {
+		if pod.Name != leader {
+			next = pod
+			break
+		}
+	}
+	if next.Name == leader && len(raftConfig.Servers) > 1 {
+		// Restart the leader once another server has taken over, which the
+		// next reconcile verifies.
+		if _, err := apiClient.Operator().RaftLeaderTransfer("", nil); err != nil {
+			r.Log.Error(err, "failed to transfer raft leadership", "pod", next.Name, "ns", next.Namespace)
+			return ctrl.Result{}, err
+		}
+		r.recordEvent(&statefulSet, corev1.EventTypeNormal, ReasonLeadershipTransferred,
+			fmt.Sprintf("Transferred raft leadership away from %s before restarting it", next.Name))
+		return ctrl.Result{RequeueAfter: waitInterval}, nil
+	}
+
+	r.Log.Info("restarting outdated server", "pod", next.Name, "ns", next.Namespace)
+	if err := r.Client.Delete(ctx, next, client.Preconditions{UID: &next.UID}); err != nil && !k8serrors.IsNotFound(err) {
+		r.Log.Error(err, "failed to delete server pod", "pod", next.Name, "ns", next.Namespace)
+		return ctrl.Result{}, err
+	}
+	r.recordEvent(&statefulSet, corev1.EventTypeNormal, ReasonServerRestarted,
+		fmt.Sprintf("Restarted %s to update it to revision %s", next.Name, statefulSet.Status.UpdateRevision))
+	return ctrl.Result{RequeueAfter: waitInterval}, nil
+}
+
+// checkHealth returns why the servers can't lose a member right now, or an
+// empty string if one can be restarted.
+func (r *Controller) checkHealth(apiClient *capi.Client, replicas int) string {
+	health, err := apiClient.Operator().AutopilotServerHealth(nil)
+	if err != nil {
+		return fmt.Sprintf("failed to get autopilot health: %s", err)
+	}
+	var unhealthy []string
+	for _, server := range health.Servers {
+		if !server.Healthy {
+			unhealthy = append(unhealthy, server.Name)
+		}
+	}
+	if len(unhealthy) > 0 {
+		return fmt.Sprintf("autopilot reports unhealthy servers: %s", strings.Join(unhealthy, ", "))
+	}
+	if !health.Healthy {
+		return "autopilot reports the servers as unhealthy"
+	}
+	if len(health.Servers) < replicas {
+		return fmt.Sprintf("autopilot reports %d of %d servers", len(health.Servers), replicas)
+	}
+	return ""
+}
+
+// serverPods returns the pods of the StatefulSet.
+func (r *Controller) serverPods(ctx context.Context, statefulSet *appsv1.StatefulSet) ([]*corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	var podList corev1.PodList
+	if err := r.reader().List(ctx, &podList, client.InNamespace(statefulSet.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	var pods []*corev1.Pod
+	for i := range podList.Items {
+		if metav1.IsControlledBy(&podList.Items[i], statefulSet) {
+			pods = append(pods, &podList.Items[i])
+		}
+	}
+	return pods, nil
+}
+
+// outdatedPods returns the pods that don't run the update revision, highest
+// ordinal first like the StatefulSet RollingUpdate strategy.
+func outdatedPods(pods []*corev1.Pod, updateRevision string) []*corev1.Pod {
+	var outdated []*corev1.Pod
+	for _, pod := range pods {
+		if pod.Labels[appsv1.StatefulSetRevisionLabel] != updateRevision {
+			outdated = append(outdated, pod)
+		}
+	}
+	sort.Slice(outdated, func(i, j int) bool {
+		return ordinal(outdated[i]) > ordinal(outdated[j])
+	})
+	return outdated
+}
+
+// ordinal returns the ordinal of a StatefulSet pod, which is the suffix of its name.
+func ordinal(pod *corev1.Pod) int {
+	i := strings.LastIndex(pod.Name, "-")
+	n, err := strconv.Atoi(pod.Name[i+1:])
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func isReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (r *Controller) recordEvent(statefulSet *appsv1.StatefulSet, eventType, reason, message string) {
+	if r.EventRecorder != nil {
+		r.EventRecorder.Event(statefulSet, eventType, reason, message)
+	}
+}
+
+// reader returns the reader of the StatefulSet and its pods.
+func (r *Controller) reader() client.Reader {
+	if r.cache != nil {
+		return r.cache
+	}
+	return r.Client
+}
+
+// SetupWithManager watches the StatefulSet and its pods with a cache limited to
+// Namespace, since the controller is only allowed to read them there.
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	namespaceCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:    mgr.GetScheme(),
+		Mapper:    mgr.GetRESTMapper(),
+		Namespace: r.Namespace,
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(namespaceCache); err != nil {
+		return err
+	}
+	r.cache = namespaceCache
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("server-upgrade").
+		Watches(source.NewKindWithCache(&appsv1.StatefulSet{}, namespaceCache), &handler.EnqueueRequestForObject{},
+			builder.WithPredicates(predicate.NewPredicateFuncs(r.isServerStatefulSet))).
+		Watches(source.NewKindWithCache(&corev1.Pod{}, namespaceCache), &handler.EnqueueRequestForOwner{
+			OwnerType:    &appsv1.StatefulSet{},
+			IsController: true,
+		}).
+		Complete(r)
+}
+
+// isServerStatefulSet returns true if obj is the Consul server StatefulSet.
+func (r *Controller) isServerStatefulSet(obj client.Object) bool {
+	return obj.GetNamespace() == r.Namespace && obj.GetName() == r.StatefulSetName
+}
@@ -0,0 +1,277 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serverupgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	namespace       = "consul"
+	statefulSetName = "consul-server"
+	oldRevision     = "consul-server-1"
+	newRevision     = "consul-server-2"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		updateStrategy appsv1.StatefulSetUpdateStrategyType
+		// revisions are the revisions of the pods, by ordinal.
+		revisions []string
+		notReady  string
+		unhealthy bool
+		// autopilotServers is the number of servers autopilot reports.
+		// Defaults to the number of pods.
+		autopilotServers int
+		leader           int
+
+		expDeleted     string
+		expTransferred bool
+		expEvent       string
+		expRequeue     bool
+	}{
+		"all servers updated": {
+			revisions: []string{newRevision, newRevision, newRevision},
+		},
+		"not using the OnDelete update strategy": {
+			updateStrategy: appsv1.RollingUpdateStatefulSetStrategyType,
+			revisions:      []string{oldRevision, oldRevision, oldRevision},
+		},
+		"pod not ready": {
+			revisions:  []string{oldRevision, oldRevision, oldRevision},
+			notReady:   "consul-server-1",
+			expRequeue: true,
+		},
+		"autopilot reports unhealthy servers": {
+			revisions:  []string{oldRevision, oldRevision, oldRevision},
+			unhealthy:  true,
+			expEvent:   ReasonUpgradePaused,
+			expRequeue: true,
+		},
+		"autopilot reports too few servers": {
+			revisions:        []string{oldRevision, oldRevision, oldRevision},
+			autopilotServers: 2,
+			expEvent:         ReasonUpgradePaused,
+			expRequeue:       true,
+		},
+		"restarts the follower with the highest ordinal first": {
+			revisions:  []string{oldRevision, oldRevision, oldRevision},
+			leader:     2,
+			expDeleted: "consul-server-1",
+			expEvent:   ReasonServerRestarted,
+			expRequeue: true,
+		},
+		"transfers leadership before restarting the leader": {
+			revisions:      []string{newRevision, newRevision, oldRevision},
+			leader:         2,
+			expTransferred: true,
+			expEvent:       ReasonLeadershipTransferred,
+			expRequeue:     true,
+		},
+		"restarts the former leader": {
+			revisions:  []string{newRevision, newRevision, oldRevision},
+			leader:     0,
+			expDeleted: "consul-server-2",
+			expEvent:   ReasonServerRestarted,
+			expRequeue: true,
+		},
+		"restarts a single server": {
+			revisions:  []string{oldRevision},
+			leader:     0,
+			expDeleted: "consul-server-0",
+			expEvent:   ReasonServerRestarted,
+			expRequeue: true,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			updateStrategy := c.updateStrategy
+			if updateStrategy == "" {
+				updateStrategy = appsv1.OnDeleteStatefulSetStrategyType
+			}
+			replicas := int32(len(c.revisions))
+			statefulSet := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      statefulSetName,
+					Namespace: namespace,
+					UID:       "statefulset-uid",
+				},
+				Spec: appsv1.StatefulSetSpec{
+					Replicas:       &replicas,
+					Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"component": "server"}},
+					UpdateStrategy: appsv1.StatefulSetUpdateStrategy{Type: updateStrategy},
+				},
+				Status: appsv1.StatefulSetStatus{UpdateRevision: newRevision},
+			}
+			objs := []runtime.Object{statefulSet}
+			for i, revision := range c.revisions {
+				pod := serverPod(statefulSet, i, revision)
+				if pod.Name == c.notReady {
+					pod.Status.Conditions[0].Status = corev1.ConditionFalse
+				}
+				objs = append(objs, pod)
+			}
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objs...).Build()
+
+			autopilotServers := c.autopilotServers
+			if autopilotServers == 0 {
+				autopilotServers = len(c.revisions)
+			}
+			servers := &fakeServers{count: autopilotServers, leader: c.leader, unhealthy: c.unhealthy}
+			consulConfig, watcher := servers.start(t)
+
+			recorder := record.NewFakeRecorder(10)
+			controller := &Controller{
+				Client:              fakeClient,
+				ConsulClientConfig:  consulConfig,
+				ConsulServerConnMgr: watcher,
+				Log:                 logrtest.New(t),
+				EventRecorder:       recorder,
+				Namespace:           namespace,
+				StatefulSetName:     statefulSetName,
+			}
+			resp, err := controller.Reconcile(context.Background(), reconcileRequest(statefulSetName))
+			require.NoError(t, err)
+			require.Equal(t, c.expRequeue, resp.RequeueAfter > 0)
+			require.Equal(t, c.expTransferred, servers.transferred.Load())
+
+			for i := range c.revisions {
+				podName := fmt.Sprintf("consul-server-%d", i)
+				err := fakeClient.Get(context.Background(), types.NamespacedName{Name: podName, Namespace: namespace}, &corev1.Pod{})
+				if podName == c.expDeleted {
+					require.True(t, k8serrors.IsNotFound(err), "expected %s to be deleted", podName)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+
+			if c.expEvent == "" {
+				require.Empty(t, recorder.Events)
+			} else {
+				require.Len(t, recorder.Events, 1)
+				require.Contains(t, <-recorder.Events, c.expEvent)
+			}
+		})
+	}
+}
+
+func TestReconcile_IgnoresOtherStatefulSets(t *testing.T) {
+	t.Parallel()
+	controller := &Controller{
+		Client:          fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		Log:             logrtest.New(t),
+		Namespace:       namespace,
+		StatefulSetName: statefulSetName,
+	}
+	resp, err := controller.Reconcile(context.Background(), reconcileRequest("other"))
+	require.NoError(t, err)
+	require.Equal(t, ctrl.Result{}, resp)
+}
+
+func reconcileRequest(name string) ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+}
+
+func serverPod(statefulSet *appsv1.StatefulSet, ordinal int, revision string) *corev1.Pod {
+	isController := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%d", statefulSet.Name, ordinal),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"component":                     "server",
+				appsv1.StatefulSetRevisionLabel: revision,
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "StatefulSet",
+				Name:       statefulSet.Name,
+				UID:        statefulSet.UID,
+				Controller: &isController,
+			}},
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+// fakeServers implements the autopilot and raft endpoints of the Consul HTTP API.
+type fakeServers struct {
+	count       int
+	leader      int
+	unhealthy   bool
+	transferred atomic.Bool
+}
+
+func (s *fakeServers) start(t *testing.T) (*consul.Config, consul.ServerConnectionManager) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/operator/autopilot/health":
+			reply := capi.OperatorHealthReply{Healthy: !s.unhealthy}
+			for i := 0; i < s.count; i++ {
+				reply.Servers = append(reply.Servers, capi.ServerHealth{
+					Name:    fmt.Sprintf("consul-server-%d", i),
+					Healthy: !s.unhealthy,
+					Leader:  i == s.leader,
+					Voter:   true,
+				})
+			}
+			if s.unhealthy {
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(reply))
+		case "/v1/operator/raft/configuration":
+			var config capi.RaftConfiguration
+			for i := 0; i < s.count; i++ {
+				config.Servers = append(config.Servers, &capi.RaftServer{
+					Node:   fmt.Sprintf("consul-server-%d", i),
+					Leader: i == s.leader,
+					Voter:  true,
+				})
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(config))
+		case "/v1/operator/raft/transfer-leader":
+			s.transferred.Store(true)
+			require.NoError(t, json.NewEncoder(w).Encode(capi.TransferLeaderResponse{Success: true}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+	return &consul.Config{APIClientConfig: &capi.Config{}, HTTPPort: port},
+		test.MockConnMgrForIPAndPort(serverURL.Hostname(), port)
+}
@@ -0,0 +1,427 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshotschedule
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	consulv1alpha1 "github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// ConfigKey is the key of the snapshot agent config in the config secret.
+	ConfigKey = "snapshot-config.json"
+	// LicenseKey is the key of the Consul Enterprise license in the config secret.
+	// It doesn't have a .json or .hcl extension so that the snapshot agent doesn't
+	// load it as config.
+	LicenseKey = "license.hclic"
+	// GCSCredentialsKey is the key of the Google Cloud service account key in the
+	// config secret.
+	GCSCredentialsKey = "gcs-credentials"
+
+	// snapshotAgentContainer is the name of the snapshot agent container in the
+	// Consul server pods.
+	snapshotAgentContainer = "consul-snapshot-agent"
+	// savedSnapshotLog is logged by the snapshot agent when it saved a snapshot.
+	savedSnapshotLog = "Saved snapshot"
+	// maxSnapshotLogLines bounds the lines of logs read from each snapshot agent
+	// on every poll. A snapshot agent logs a few lines per snapshot, so this
+	// covers far more than a poll interval.
+	maxSnapshotLogLines = int64(1000)
+	// defaultRetain is the number of snapshots kept if the schedule doesn't set it.
+	defaultRetain = 30
+	// statusPollInterval is how often the snapshot agent logs are checked for
+	// new snapshots.
+	statusPollInterval = time.Minute
+
+	invalidScheduleReason     = "InvalidSnapshotSchedule"
+	conflictingScheduleReason = "ConflictingSnapshotSchedule"
+	unsupportedNSReason       = "UnsupportedNamespace"
+	secretErrorReason         = "SecretError"
+)
+
+// Controller renders the snapshot agent configuration from a SnapshotSchedule
+// into a secret that is mounted by the snapshot agents running alongside the
+// Consul servers.
+//
+// The snapshot agents share a single configuration, so only SnapshotSchedules
+// in the namespace of the Helm release are supported and only the oldest of
+// them is applied.
+type Controller struct {
+	client.Client
+	// Clientset is used to read the logs of the snapshot agents.
+	Clientset kubernetes.Interface
+	// Log is the logger for this controller.
+	Log logr.Logger
+	// Scheme is the API scheme that this controller should have.
+	Scheme *runtime.Scheme
+	// Namespace is the namespace of the Consul servers, i.e. the namespace of
+	// the Helm release.
+	Namespace string
+	// ConfigSecretName is the name of the secret the snapshot agent config is
+	// written to.
+	ConfigSecretName string
+	// ServerPodSelector is the label selector of the Consul server pods.
+	ServerPodSelector string
+}
+
+//+kubebuilder:rbac:groups=consul.hashicorp.com,resources=snapshotschedules,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=consul.hashicorp.com,resources=snapshotschedules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+
+// Reconcile writes the snapshot agent config of the active SnapshotSchedule
+// and updates its status with the time of the last snapshot saved by the
+// snapshot agents. It's requeued periodically to keep the status up to date.
+func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if req.Namespace != r.Namespace {
+		schedule := &consulv1alpha1.SnapshotSchedule{}
+		if err := r.Client.Get(ctx, req.NamespacedName, schedule); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+		r.updateStatusError(ctx, schedule, unsupportedNSReason,
+			fmt.Errorf("SnapshotSchedules are only supported in namespace %q", r.Namespace))
+		return ctrl.Result{}, nil
+	}
+
+	var schedules consulv1alpha1.SnapshotScheduleList
+	if err := r.Client.List(ctx, &schedules, client.InNamespace(r.Namespace)); err != nil {
+		r.Log.Error(err, "failed to list SnapshotSchedules", "ns", r.Namespace)
+		return ctrl.Result{}, err
+	}
+	active := activeSchedule(schedules.Items)
+	if active == nil {
+		// The config secret is garbage collected together with the last
+		// SnapshotSchedule that owned it.
+		return ctrl.Result{}, nil
+	}
+
+	if req.Name != active.Name {
+		for i := range schedules.Items {
+			if schedule := &schedules.Items[i]; schedule.Name == req.Name {
+				r.updateStatusError(ctx, schedule, conflictingScheduleReason,
+					fmt.Errorf("only the oldest SnapshotSchedule in the namespace is applied, which is %q", active.Name))
+			}
+		}
+	}
+
+	if err := active.Validate(); err != nil {
+		r.updateStatusError(ctx, active, invalidScheduleReason, err)
+		// The SnapshotSchedule is reconciled again once it's updated.
+		return ctrl.Result{}, nil
+	}
+
+	data, err := r.configData(ctx, active)
+	if err != nil {
+		r.updateStatusError(ctx, active, secretErrorReason, err)
+		return ctrl.Result{}, err
+	}
+	if err := r.writeConfigSecret(ctx, active, data); err != nil {
+		r.updateStatusError(ctx, active, secretErrorReason, err)
+		return ctrl.Result{}, err
+	}
+
+	lastSnapshot := r.lastSuccessfulSnapshot(ctx, active)
+	return ctrl.Result{RequeueAfter: statusPollInterval}, r.updateStatus(ctx, active, lastSnapshot)
+}
+
+// activeSchedule returns the oldest SnapshotSchedule that isn't being deleted.
+func activeSchedule(schedules []consulv1alpha1.SnapshotSchedule) *consulv1alpha1.SnapshotSchedule {
+	var candidates []*consulv1alpha1.SnapshotSchedule
+	for i := range schedules {
+		if schedules[i].DeletionTimestamp.IsZero() {
+			candidates = append(candidates, &schedules[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return a.CreationTimestamp.Before(&b.CreationTimestamp)
+		}
+		return a.Name < b.Name
+	})
+	return candidates[0]
+}
+
+// agentConfig is the snapshot agent config file.
+// See https://developer.hashicorp.com/consul/commands/snapshot/agent#config-file-options.
+type agentConfig struct {
+	SnapshotAgent snapshotAgentConfig `json:"snapshot_agent"`
+}
+
+type snapshotAgentConfig struct {
+	Token            string                  `json:"token,omitempty"`
+	Snapshot         snapshotConfig          `json:"snapshot"`
+	AWSStorage       *awsStorageConfig       `json:"aws_storage,omitempty"`
+	GoogleStorage    *googleStorageConfig    `json:"google_storage,omitempty"`
+	AzureBlobStorage *azureBlobStorageConfig `json:"azure_blob_storage,omitempty"`
+}
+
+type snapshotConfig struct {
+	Interval string `json:"interval"`
+	Retain   int    `json:"retain"`
+}
+
+type awsStorageConfig struct {
+	AccessKeyID            string `json:"access_key_id,omitempty"`
+	SecretAccessKey        string `json:"secret_access_key,omitempty"`
+	S3Region               string `json:"s3_region"`
+	S3Bucket               string `json:"s3_bucket"`
+	S3KeyPrefix            string `json:"s3_key_prefix,omitempty"`
+	S3Endpoint             string `json:"s3_endpoint,omitempty"`
+	S3ForcePathStyle       bool   `json:"s3_force_path_style,omitempty"`
+	S3ServerSideEncryption bool   `json:"s3_server_side_encryption,omitempty"`
+}
+
+type googleStorageConfig struct {
+	Bucket string `json:"bucket"`
+}
+
+type azureBlobStorageConfig struct {
+	AccountName   string `json:"account_name"`
+	AccountKey    string `json:"account_key"`
+	ContainerName string `json:"container_name"`
+	Environment   string `json:"environment,omitempty"`
+}
+
+// configData returns the data of the config secret for the schedule. Values
+// referenced from other secrets are copied into it since the snapshot agents
+// run in the Consul server pods and can't mount arbitrary secrets.
+func (r *Controller) configData(ctx context.Context, schedule *consulv1alpha1.SnapshotSchedule) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	retain := defaultRetain
+	if schedule.Spec.Retain != nil {
+		retain = *schedule.Spec.Retain
+	}
+	config := agentConfig{SnapshotAgent: snapshotAgentConfig{
+		Snapshot: snapshotConfig{
+			Interval: schedule.Spec.Interval.Duration.String(),
+			Retain:   retain,
+		},
+	}}
+
+	var err error
+	if schedule.Spec.ACLToken != nil {
+		if config.SnapshotAgent.Token, err = r.secretValue(ctx, schedule.Namespace, schedule.Spec.ACLToken); err != nil {
+			return nil, err
+		}
+	}
+	if schedule.Spec.License != nil {
+		license, err := r.secretValue(ctx, schedule.Namespace, schedule.Spec.License)
+		if err != nil {
+			return nil, err
+		}
+		data[LicenseKey] = []byte(license)
+	}
+
+	dest := schedule.Spec.Destination
+	switch {
+	case dest.S3 != nil:
+		aws := &awsStorageConfig{
+			S3Region:               dest.S3.Region,
+			S3Bucket:               dest.S3.Bucket,
+			S3KeyPrefix:            dest.S3.KeyPrefix,
+			S3Endpoint:             dest.S3.Endpoint,
+			S3ForcePathStyle:       dest.S3.ForcePathStyle,
+			S3ServerSideEncryption: dest.S3.ServerSideEncryption,
+		}
+		if dest.S3.AccessKeyID != nil {
+			if aws.AccessKeyID, err = r.secretValue(ctx, schedule.Namespace, dest.S3.AccessKeyID); err != nil {
+				return nil, err
+			}
+			if aws.SecretAccessKey, err = r.secretValue(ctx, schedule.Namespace, dest.S3.SecretAccessKey); err != nil {
+				return nil, err
+			}
+		}
+		config.SnapshotAgent.AWSStorage = aws
+	case dest.GCS != nil:
+		config.SnapshotAgent.GoogleStorage = &googleStorageConfig{Bucket: dest.GCS.Bucket}
+		if dest.GCS.Credentials != nil {
+			credentials, err := r.secretValue(ctx, schedule.Namespace, dest.GCS.Credentials)
+			if err != nil {
+				return nil, err
+			}
+			data[GCSCredentialsKey] = []byte(credentials)
+		}
+	case dest.Azure != nil:
+		accountKey, err := r.secretValue(ctx, schedule.Namespace, &dest.Azure.AccountKey)
+		if err != nil {
+			return nil, err
+		}
+		config.SnapshotAgent.AzureBlobStorage = &azureBlobStorageConfig{
+			AccountName:   dest.Azure.AccountName,
+			AccountKey:    accountKey,
+			ContainerName: dest.Azure.ContainerName,
+			Environment:   dest.Azure.Environment,
+		}
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	data[ConfigKey] = configJSON
+	return data, nil
+}
+
+func (r *Controller) secretValue(ctx context.Context, namespace string, ref *corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+		return "", fmt.Errorf("failed to get secret %q: %w", ref.Name, err)
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("secret %q has no key %q", ref.Name, ref.Key)
+	}
+	return string(value), nil
+}
+
+// writeConfigSecret creates or updates the config secret. It's owned by the
+// schedule so that it's deleted together with it.
+func (r *Controller) writeConfigSecret(ctx context.Context, schedule *consulv1alpha1.SnapshotSchedule, data map[string][]byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.ConfigSecretName,
+			Namespace: r.Namespace,
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		secret.Data = data
+		// The secret is handed over when the active schedule changes.
+		secret.OwnerReferences = nil
+		return controllerutil.SetControllerReference(schedule, secret, r.Scheme)
+	})
+	if err != nil {
+		r.Log.Error(err, "failed to write snapshot agent config", "secret", r.ConfigSecretName, "ns", r.Namespace)
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		r.Log.Info("wrote snapshot agent config", "secret", r.ConfigSecretName, "ns", r.Namespace,
+			"schedule", schedule.Name, "operation", op)
+	}
+	return nil
+}
+
+// lastSuccessfulSnapshot returns the time of the last snapshot logged as saved
+// by the snapshot agent of any Consul server. Only one snapshot agent takes
+// snapshots at a time, but the leader can change. Errors reading the logs are
+// logged and otherwise ignored since the snapshot agents may not be running yet.
+func (r *Controller) lastSuccessfulSnapshot(ctx context.Context, schedule *consulv1alpha1.SnapshotSchedule) *metav1.Time {
+	last := schedule.Status.LastSuccessfulSnapshotTime
+	since := schedule.CreationTimestamp
+	if last != nil {
+		since = *last
+	}
+
+	pods, err := r.Clientset.CoreV1().Pods(r.Namespace).List(ctx, metav1.ListOptions{LabelSelector: r.ServerPodSelector})
+	if err != nil {
+		r.Log.Error(err, "failed to list Consul server pods", "ns", r.Namespace)
+		return last
+	}
+	for _, pod := range pods.Items {
+		for _, opts := range snapshotAgentLogOptions(pod, since) {
+			logs, err := r.Clientset.CoreV1().Pods(r.Namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+			if err != nil {
+				r.Log.V(1).Info("failed to read snapshot agent logs", "pod", pod.Name, "ns", r.Namespace, "previous", opts.Previous, "err", err)
+				continue
+			}
+			if t := lastSnapshotTime(logs); t != nil && (last == nil || t.After(last.Time)) {
+				last = t
+			}
+		}
+	}
+	return last
+}
+
+// snapshotAgentLogOptions returns the options to read the logs of the snapshot
+// agent container of pod since the given time. If the container restarted,
+// the logs of its previous instance are read too so that snapshots saved
+// shortly before the restart aren't missed.
+func snapshotAgentLogOptions(pod corev1.Pod, since metav1.Time) []*corev1.PodLogOptions {
+	tailLines := maxSnapshotLogLines
+	opts := []*corev1.PodLogOptions{{
+		Container:  snapshotAgentContainer,
+		Timestamps: true,
+		SinceTime:  &since,
+		TailLines:  &tailLines,
+	}}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == snapshotAgentContainer && status.RestartCount > 0 {
+			previous := *opts[0]
+			previous.Previous = true
+			opts = append(opts, &previous)
+		}
+	}
+	return opts
+}
+
+// lastSnapshotTime returns the timestamp of the last line of logs, read with
+// timestamps, that reports a saved snapshot.
+func lastSnapshotTime(logs []byte) *metav1.Time {
+	var last *metav1.Time
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	for scanner.Scan() {
+		timestamp, line, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || !strings.Contains(line, savedSnapshotLog) {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, timestamp)
+		if err != nil {
+			continue
+		}
+		if last == nil || t.After(last.Time) {
+			last = &metav1.Time{Time: t}
+		}
+	}
+	return last
+}
+
+func (r *Controller) updateStatus(ctx context.Context, schedule *consulv1alpha1.SnapshotSchedule, lastSnapshot *metav1.Time) error {
+	schedule.SetSyncedCondition(corev1.ConditionTrue, "", "")
+	timeNow := metav1.NewTime(time.Now())
+	schedule.Status.LastSyncedTime = &timeNow
+	schedule.Status.LastSuccessfulSnapshotTime = lastSnapshot
+	if err := r.Status().Update(ctx, schedule); err != nil {
+		r.Log.Error(err, "failed to update SnapshotSchedule status", "name", schedule.Name, "ns", schedule.Namespace)
+		return err
+	}
+	return nil
+}
+
+func (r *Controller) updateStatusError(ctx context.Context, schedule *consulv1alpha1.SnapshotSchedule, reason string, reconcileErr error) {
+	schedule.SetSyncedCondition(corev1.ConditionFalse, reason, reconcileErr.Error())
+	if err := r.Status().Update(ctx, schedule); err != nil {
+		r.Log.Error(err, "failed to update SnapshotSchedule status", "name", schedule.Name, "ns", schedule.Namespace)
+	}
+}
+
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Status updates must not trigger a reconcile since every reconcile
+		// updates the status.
+		For(&consulv1alpha1.SnapshotSchedule{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
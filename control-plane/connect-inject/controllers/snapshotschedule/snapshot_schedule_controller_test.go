@@ -0,0 +1,298 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshotschedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	namespace        = "consul"
+	configSecretName = "consul-snapshot-agent-schedule"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	retainAll := 0
+	cases := map[string]struct {
+		spec      v1alpha1.SnapshotScheduleSpec
+		expData   map[string]string
+		expReason string
+		expErr    string
+		noSecret  bool
+	}{
+		"s3 with credentials and ACL token": {
+			spec: v1alpha1.SnapshotScheduleSpec{
+				Interval: metav1.Duration{Duration: time.Hour},
+				Destination: v1alpha1.SnapshotDestination{S3: &v1alpha1.S3SnapshotDestination{
+					Bucket:          "snapshots",
+					Region:          "us-east-1",
+					KeyPrefix:       "dc1",
+					AccessKeyID:     secretRef("aws", "id"),
+					SecretAccessKey: secretRef("aws", "secret"),
+				}},
+				ACLToken: secretRef("snapshot-token", "token"),
+			},
+			expData: map[string]string{
+				ConfigKey: `{"snapshot_agent":{"token":"acl-token","snapshot":{"interval":"1h0m0s","retain":30},` +
+					`"aws_storage":{"access_key_id":"aws-id","secret_access_key":"aws-secret","s3_region":"us-east-1","s3_bucket":"snapshots","s3_key_prefix":"dc1"}}}`,
+			},
+		},
+		"gcs with credentials and license": {
+			spec: v1alpha1.SnapshotScheduleSpec{
+				Interval:    metav1.Duration{Duration: 30 * time.Minute},
+				Retain:      &retainAll,
+				Destination: v1alpha1.SnapshotDestination{GCS: &v1alpha1.GCSSnapshotDestination{Bucket: "snapshots", Credentials: secretRef("gcs", "key.json")}},
+				License:     secretRef("license", "key"),
+			},
+			expData: map[string]string{
+				ConfigKey:         `{"snapshot_agent":{"snapshot":{"interval":"30m0s","retain":0},"google_storage":{"bucket":"snapshots"}}}`,
+				GCSCredentialsKey: "gcs-credentials",
+				LicenseKey:        "license",
+			},
+		},
+		"azure": {
+			spec: v1alpha1.SnapshotScheduleSpec{
+				Interval: metav1.Duration{Duration: time.Hour},
+				Destination: v1alpha1.SnapshotDestination{Azure: &v1alpha1.AzureSnapshotDestination{
+					AccountName:   "account",
+					AccountKey:    *secretRef("azure", "key"),
+					ContainerName: "snapshots",
+				}},
+			},
+			expData: map[string]string{
+				ConfigKey: `{"snapshot_agent":{"snapshot":{"interval":"1h0m0s","retain":30},` +
+					`"azure_blob_storage":{"account_name":"account","account_key":"azure-key","container_name":"snapshots"}}}`,
+			},
+		},
+		"invalid schedule": {
+			spec: v1alpha1.SnapshotScheduleSpec{
+				Interval: metav1.Duration{Duration: time.Hour},
+			},
+			expReason: invalidScheduleReason,
+			noSecret:  true,
+		},
+		"missing secret": {
+			spec: v1alpha1.SnapshotScheduleSpec{
+				Interval:    metav1.Duration{Duration: time.Hour},
+				Destination: v1alpha1.SnapshotDestination{GCS: &v1alpha1.GCSSnapshotDestination{Bucket: "snapshots", Credentials: secretRef("missing", "key")}},
+			},
+			expReason: secretErrorReason,
+			expErr:    `failed to get secret "missing"`,
+			noSecret:  true,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			schedule := &v1alpha1.SnapshotSchedule{
+				ObjectMeta: metav1.ObjectMeta{Name: "snapshots", Namespace: namespace},
+				Spec:       c.spec,
+			}
+			ctrlr, k8s := newController(t, schedule)
+
+			result, err := ctrlr.Reconcile(context.Background(), request(schedule))
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var secret corev1.Secret
+			err = k8s.Get(context.Background(), types.NamespacedName{Name: configSecretName, Namespace: namespace}, &secret)
+			if c.noSecret {
+				require.True(t, k8serrors.IsNotFound(err))
+			} else {
+				require.NoError(t, err)
+				data := make(map[string]string)
+				for k, v := range secret.Data {
+					data[k] = string(v)
+				}
+				require.Equal(t, c.expData, data)
+				require.Len(t, secret.OwnerReferences, 1)
+				require.Equal(t, schedule.Name, secret.OwnerReferences[0].Name)
+				require.Equal(t, statusPollInterval, result.RequeueAfter)
+			}
+
+			require.NoError(t, k8s.Get(context.Background(), client.ObjectKeyFromObject(schedule), schedule))
+			synced := syncedCondition(schedule)
+			require.NotNil(t, synced)
+			if c.expReason != "" {
+				require.Equal(t, corev1.ConditionFalse, synced.Status)
+				require.Equal(t, c.expReason, synced.Reason)
+			} else {
+				require.Equal(t, corev1.ConditionTrue, synced.Status)
+				require.NotNil(t, schedule.Status.LastSyncedTime)
+			}
+		})
+	}
+}
+
+func TestReconcile_OnlyOldestScheduleIsApplied(t *testing.T) {
+	t.Parallel()
+	older := newSchedule("older", namespace, "older-bucket")
+	older.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+	newer := newSchedule("newer", namespace, "newer-bucket")
+	newer.CreationTimestamp = metav1.NewTime(time.Now())
+	ctrlr, k8s := newController(t, older, newer)
+
+	_, err := ctrlr.Reconcile(context.Background(), request(newer))
+	require.NoError(t, err)
+
+	var secret corev1.Secret
+	require.NoError(t, k8s.Get(context.Background(), types.NamespacedName{Name: configSecretName, Namespace: namespace}, &secret))
+	require.Contains(t, string(secret.Data[ConfigKey]), "older-bucket")
+	require.Equal(t, "older", secret.OwnerReferences[0].Name)
+
+	require.NoError(t, k8s.Get(context.Background(), client.ObjectKeyFromObject(newer), newer))
+	synced := syncedCondition(newer)
+	require.Equal(t, corev1.ConditionFalse, synced.Status)
+	require.Equal(t, conflictingScheduleReason, synced.Reason)
+
+	// Once the older schedule is deleted, the newer one takes over the config secret.
+	require.NoError(t, k8s.Delete(context.Background(), older))
+	_, err = ctrlr.Reconcile(context.Background(), request(older))
+	require.NoError(t, err)
+	require.NoError(t, k8s.Get(context.Background(), types.NamespacedName{Name: configSecretName, Namespace: namespace}, &secret))
+	require.Contains(t, string(secret.Data[ConfigKey]), "newer-bucket")
+	require.Len(t, secret.OwnerReferences, 1)
+	require.Equal(t, "newer", secret.OwnerReferences[0].Name)
+}
+
+func TestReconcile_ScheduleInOtherNamespace(t *testing.T) {
+	t.Parallel()
+	schedule := newSchedule("snapshots", "default", "snapshots")
+	ctrlr, k8s := newController(t, schedule)
+
+	_, err := ctrlr.Reconcile(context.Background(), request(schedule))
+	require.NoError(t, err)
+
+	var secret corev1.Secret
+	err = k8s.Get(context.Background(), types.NamespacedName{Name: configSecretName, Namespace: namespace}, &secret)
+	require.True(t, k8serrors.IsNotFound(err))
+	require.NoError(t, k8s.Get(context.Background(), client.ObjectKeyFromObject(schedule), schedule))
+	synced := syncedCondition(schedule)
+	require.Equal(t, corev1.ConditionFalse, synced.Status)
+	require.Equal(t, unsupportedNSReason, synced.Reason)
+}
+
+func TestLastSnapshotTime(t *testing.T) {
+	t.Parallel()
+	logs := `2024-01-02T03:00:00.000000001Z 2024-01-02T03:00:00.000Z [INFO]  snapshot.agent: Snapshot agent running
+2024-01-02T03:00:01.5Z 2024-01-02T03:00:01.500Z [INFO]  snapshot.agent: Saved snapshot: id=1704164401
+2024-01-02T04:00:02Z 2024-01-02T04:00:02.000Z [INFO]  snapshot.agent: Saved snapshot: id=1704168002
+2024-01-02T05:00:00Z 2024-01-02T05:00:00.000Z [ERROR] snapshot.agent: Snapshot failed (will retry at next interval): error="permission denied"
+not-a-timestamp Saved snapshot
+`
+	exp := time.Date(2024, 1, 2, 4, 0, 2, 0, time.UTC)
+	last := lastSnapshotTime([]byte(logs))
+	require.NotNil(t, last)
+	require.True(t, exp.Equal(last.Time), last.Time)
+
+	require.Nil(t, lastSnapshotTime([]byte("fake logs")))
+}
+
+func TestSnapshotAgentLogOptions(t *testing.T) {
+	t.Parallel()
+	since := metav1.NewTime(time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC))
+	pod := corev1.Pod{}
+
+	opts := snapshotAgentLogOptions(pod, since)
+	require.Len(t, opts, 1)
+	require.Equal(t, snapshotAgentContainer, opts[0].Container)
+	require.True(t, opts[0].Timestamps)
+	require.Equal(t, &since, opts[0].SinceTime)
+	require.Equal(t, maxSnapshotLogLines, *opts[0].TailLines)
+	require.False(t, opts[0].Previous)
+
+	// The logs of the previous instance are read once the container restarted.
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "consul", RestartCount: 2},
+		{Name: snapshotAgentContainer, RestartCount: 1},
+	}
+	opts = snapshotAgentLogOptions(pod, since)
+	require.Len(t, opts, 2)
+	require.False(t, opts[0].Previous)
+	require.True(t, opts[1].Previous)
+	require.Equal(t, snapshotAgentContainer, opts[1].Container)
+}
+
+func newController(t *testing.T, objs ...runtime.Object) (*Controller, client.Client) {
+	s := runtime.NewScheme()
+	require.NoError(t, scheme.AddToScheme(s))
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.SnapshotSchedule{}, &v1alpha1.SnapshotScheduleList{})
+
+	secrets := []runtime.Object{
+		secret("aws", map[string]string{"id": "aws-id", "secret": "aws-secret"}),
+		secret("snapshot-token", map[string]string{"token": "acl-token"}),
+		secret("gcs", map[string]string{"key.json": "gcs-credentials"}),
+		secret("license", map[string]string{"key": "license"}),
+		secret("azure", map[string]string{"key": "azure-key"}),
+	}
+	k8s := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(append(secrets, objs...)...).Build()
+	return &Controller{
+		Client:            k8s,
+		Clientset:         k8sfake.NewSimpleClientset(),
+		Log:               logrtest.New(t),
+		Scheme:            s,
+		Namespace:         namespace,
+		ConfigSecretName:  configSecretName,
+		ServerPodSelector: "app=consul,component=server",
+	}, k8s
+}
+
+func syncedCondition(schedule *v1alpha1.SnapshotSchedule) *v1alpha1.Condition {
+	for i, cond := range schedule.Status.Conditions {
+		if cond.Type == v1alpha1.ConditionSynced {
+			return &schedule.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func newSchedule(name, ns, bucket string) *v1alpha1.SnapshotSchedule {
+	return &v1alpha1.SnapshotSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec: v1alpha1.SnapshotScheduleSpec{
+			Interval:    metav1.Duration{Duration: time.Hour},
+			Destination: v1alpha1.SnapshotDestination{GCS: &v1alpha1.GCSSnapshotDestination{Bucket: bucket}},
+		},
+	}
+}
+
+func request(schedule *v1alpha1.SnapshotSchedule) ctrl.Request {
+	return ctrl.Request{NamespacedName: client.ObjectKeyFromObject(schedule)}
+}
+
+func secret(name string, data map[string]string) *corev1.Secret {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       make(map[string][]byte),
+	}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func secretRef(name, key string) *corev1.SecretKeySelector {
+	return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tokencleanup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	capi "github.com/hashicorp/consul/api"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	tokenMetaPodNameKey = "pod"
+
+	// gracePeriod is how old a token must be before it is deleted, so that
+	// tokens of pods that were just created aren't deleted before the pod is
+	// in the cache, and to allow for clock skew between Consul and Kubernetes.
+	gracePeriod = 5 * time.Minute
+)
+
+var tokenMetaRegex = regexp.MustCompile(`.*({.+})`)
+
+// Controller periodically deletes the ACL tokens created by logging in with
+// the Kubernetes auth methods whose pods no longer exist.
+//
+// Tokens are usually deleted by the endpoints controller when a pod is
+// removed from its service, or by the pod logging out when it stops, but pods
+// that crash or are on nodes that disappear can leave orphaned tokens behind.
+type Controller struct {
+	client.Client
+	// ConsulClientConfig is the config to create a Consul API client.
+	ConsulClientConfig *consul.Config
+	// ConsulServerConnMgr is the watcher for the Consul server addresses.
+	ConsulServerConnMgr consul.ServerConnectionManager
+	// AuthMethods are the names of the Kubernetes auth methods whose tokens
+	// are cleaned up.
+	AuthMethods []string
+	// EnableConsulNamespaces indicates that Consul namespaces are enabled, in
+	// which case tokens are cleaned up in all namespaces.
+	EnableConsulNamespaces bool
+	// Interval is how often orphaned tokens are cleaned up.
+	Interval time.Duration
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
+	// Log is the logger for this controller.
+	Log logr.Logger
+}
+
+// Start cleans up orphaned tokens every interval until ctx is cancelled.
+// It implements manager.Runnable and only runs on the leader.
+func (c *Controller) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.cleanup(ctx); err != nil {
+				c.Log.Error(err, "failed to clean up orphaned ACL tokens")
+			}
+		}
+	}
+}
+
+// cleanup deletes the tokens of the auth methods whose pod no longer exists.
+func (c *Controller) cleanup(ctx context.Context) error {
+	serverState, err := c.ConsulServerConnMgr.State()
+	if err != nil {
+		return fmt.Errorf("failed to get Consul server state: %w", err)
+	}
+	apiClient, err := consul.NewClientFromConnMgrState(c.ConsulClientConfig, serverState)
+	if err != nil {
+		return fmt.Errorf("failed to create Consul API client: %w", err)
+	}
+
+	var opts capi.QueryOptions
+	if c.EnableConsulNamespaces {
+		opts.Namespace = namespaces.WildcardNamespace
+	}
+	tokens, _, err := apiClient.ACL().TokenList(&opts)
+	if err != nil {
+		return fmt.Errorf("failed to get a list of tokens from Consul: %w", err)
+	}
+
+	for _, token := range tokens {
+		if !c.isAuthMethod(token.AuthMethod) || time.Since(token.CreateTime) < gracePeriod {
+			continue
+		}
+		orphaned, err := c.isOrphaned(ctx, token)
+		if err != nil {
+			return err
+		}
+		if !orphaned {
+			continue
+		}
+		c.Log.Info("deleting orphaned ACL token", "accessor-id", token.AccessorID, "auth-method", token.AuthMethod)
+		_, err = apiClient.ACL().TokenDelete(token.AccessorID, &capi.WriteOptions{Namespace: token.Namespace})
+		c.Auditor.Record(audit.Event{
+			Actor:     "controller/acl-token-cleanup",
+			Operation: audit.OperationDelete,
+			Resource:  audit.ResourceACLToken,
+			Name:      token.AccessorID,
+			Namespace: token.Namespace,
+			Partition: token.Partition,
+		}, err)
+		if err != nil {
+			return fmt.Errorf("failed to delete token from Consul: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) isAuthMethod(authMethod string) bool {
+	for _, name := range c.AuthMethods {
+		if name != "" && name == authMethod {
+			return true
+		}
+	}
+	return false
+}
+
+// isOrphaned returns true if the pod the token was created for doesn't exist,
+// or was created after the token, i.e. a pod with the same name replaced it.
+// Tokens without pod metadata are never orphaned.
+func (c *Controller) isOrphaned(ctx context.Context, token *capi.ACLTokenListEntry) (bool, error) {
+	pod, ok := tokenPod(token.Description)
+	if !ok {
+		return false, nil
+	}
+	var p corev1.Pod
+	err := c.Client.Get(ctx, pod, &p)
+	if k8serrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get pod %s: %w", pod, err)
+	}
+	return token.CreateTime.Add(gracePeriod).Before(p.CreationTimestamp.Time), nil
+}
+
+// tokenPod returns the pod from the metadata in the description of a token
+// created by logging in, e.g. `token created via login: {"pod":"default/foo"}`.
+func tokenPod(description string) (types.NamespacedName, bool) {
+	matches := tokenMetaRegex.FindStringSubmatch(description)
+	if len(matches) != 2 {
+		return types.NamespacedName{}, false
+	}
+	var tokenMeta map[string]string
+	if err := json.Unmarshal([]byte(matches[1]), &tokenMeta); err != nil {
+		return types.NamespacedName{}, false
+	}
+	parts := strings.SplitN(tokenMeta[tokenMetaPodNameKey], "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: parts[0], Name: parts[1]}, true
+}
@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tokencleanup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTokenPod(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		description string
+		expPod      types.NamespacedName
+		expOK       bool
+	}{
+		"token created via login": {
+			description: `token created via login: {"pod":"default/foo"}`,
+			expPod:      types.NamespacedName{Namespace: "default", Name: "foo"},
+			expOK:       true,
+		},
+		"no metadata": {
+			description: "token created via login",
+		},
+		"invalid metadata": {
+			description: `token created via login: {"pod":}`,
+		},
+		"metadata without a pod": {
+			description: `token created via login: {"component":"sync-catalog"}`,
+		},
+		"pod without a namespace": {
+			description: `token created via login: {"pod":"foo"}`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			pod, ok := tokenPod(c.description)
+			require.Equal(t, c.expOK, ok)
+			require.Equal(t, c.expPod, pod)
+		})
+	}
+}
+
+func TestIsOrphaned(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	description := `token created via login: {"pod":"default/foo"}`
+
+	cases := map[string]struct {
+		description string
+		pod         *corev1.Pod
+		tokenCreate time.Time
+		exp         bool
+	}{
+		"pod exists": {
+			description: description,
+			pod:         pod(now.Add(-time.Hour)),
+			tokenCreate: now.Add(-50 * time.Minute),
+		},
+		"pod deleted": {
+			description: description,
+			tokenCreate: now.Add(-50 * time.Minute),
+			exp:         true,
+		},
+		"pod recreated after the token": {
+			description: description,
+			pod:         pod(now.Add(-10 * time.Minute)),
+			tokenCreate: now.Add(-50 * time.Minute),
+			exp:         true,
+		},
+		"pod created just after the token": {
+			description: description,
+			pod:         pod(now.Add(-49 * time.Minute)),
+			tokenCreate: now.Add(-50 * time.Minute),
+		},
+		"token without pod metadata": {
+			description: "token created via login",
+			tokenCreate: now.Add(-50 * time.Minute),
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var objs []runtime.Object
+			if c.pod != nil {
+				objs = append(objs, c.pod)
+			}
+			controller := &Controller{
+				Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objs...).Build(),
+				Log:    logrtest.New(t),
+			}
+			token := &capi.ACLTokenListEntry{
+				AccessorID:  "accessor-id",
+				Description: c.description,
+				CreateTime:  c.tokenCreate,
+			}
+			orphaned, err := controller.isOrphaned(context.Background(), token)
+			require.NoError(t, err)
+			require.Equal(t, c.exp, orphaned)
+		})
+	}
+}
+
+func TestIsAuthMethod(t *testing.T) {
+	t.Parallel()
+	controller := &Controller{AuthMethods: []string{"consul-k8s-auth-method", ""}}
+	require.True(t, controller.isAuthMethod("consul-k8s-auth-method"))
+	require.False(t, controller.isAuthMethod("other-auth-method"))
+	require.False(t, controller.isAuthMethod(""))
+}
+
+func pod(created time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "foo",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tokenrotation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	capi "github.com/hashicorp/consul/api"
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// RotateTokenLabel is set to "true" on the Secrets that hold the ACL
+	// tokens of the components whose tokens are rotated.
+	RotateTokenLabel = "consul.hashicorp.com/rotate-acl-token"
+
+	// ServiceAccountAnnotation is set on a token Secret to the service account
+	// of the component, which is the identity the component's token is created for.
+	ServiceAccountAnnotation = "consul.hashicorp.com/service-account"
+
+	// ComponentAnnotation is set on a token Secret to the name of the
+	// component, which is added to the metadata of its tokens.
+	ComponentAnnotation = "consul.hashicorp.com/component"
+
+	// AuthMethodAnnotation is set on a token Secret to the auth method the
+	// component logs in with, if it's not the controller's auth method.
+	AuthMethodAnnotation = "consul.hashicorp.com/auth-method"
+
+	// DatacenterAnnotation is set on a token Secret to the datacenter of the
+	// auth method the component logs in with, if it's not the controller's.
+	DatacenterAnnotation = "consul.hashicorp.com/auth-method-datacenter"
+
+	// TokenRotatedAtAnnotation is set on a token Secret to the time its ACL
+	// token was last rotated.
+	TokenRotatedAtAnnotation = "consul.hashicorp.com/acl-token-rotated-at"
+
+	// RestartDeploymentAnnotation is set on a token Secret to the name of the
+	// Deployment of the component if the component only reads its token when
+	// it starts. The Deployment is restarted whenever the token is rotated.
+	RestartDeploymentAnnotation = "consul.hashicorp.com/restart-deployment"
+
+	// TokenKey is the key of the current ACL token in a token Secret.
+	TokenKey = "token"
+
+	// previousTokenKey is the key of the token the current token replaced.
+	// It's kept until the next rotation so that components have a full
+	// interval to pick up the new token before the old one is deleted.
+	previousTokenKey = "previous-token"
+
+	// bearerTokenExpiration is how long the service account tokens used to
+	// log in are valid for. They are only used once.
+	bearerTokenExpiration = 10 * time.Minute
+)
+
+// Controller periodically rotates the ACL tokens of long-lived components
+// such as the sync catalog, the snapshot agent and the gateways.
+//
+// The token of each component is kept in a Secret that the component reads
+// its token from. When the token is due to be rotated, the controller logs in
+// with the component auth method as the component's service account and
+// writes the new token to the Secret. Components that only read their token
+// when they start are restarted. The token it replaced is logged out at the
+// next rotation, so no token can be used for longer than two intervals.
+type Controller struct {
+	client.Client
+	// Clientset is used to create the service account tokens to log in with,
+	// which the controller-runtime client doesn't support.
+	Clientset kubernetes.Interface
+	// ConsulClientConfig is the config to create a Consul API client.
+	ConsulClientConfig *consul.Config
+	// ConsulServerConnMgr is the watcher for the Consul server addresses.
+	ConsulServerConnMgr consul.ServerConnectionManager
+	// AuthMethod is the name of the component auth method, which is used
+	// unless a token Secret has the auth method annotation.
+	AuthMethod string
+	// Datacenter is the datacenter of the component auth method, if it's not
+	// the local datacenter. It's used unless a token Secret has the datacenter
+	// annotation.
+	Datacenter string
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
+	// Log is the logger for this controller.
+	Log logr.Logger
+	// Namespace is the namespace of the token Secrets, i.e. the namespace of
+	// the Helm release.
+	Namespace string
+	// RotationInterval is how often the ACL tokens are rotated.
+	RotationInterval time.Duration
+}
+
+// The token Secrets are updated, the service account tokens are created and
+// the Deployments are restarted with a Role in Namespace, which the Helm chart
+// creates, rather than with the manager's ClusterRole.
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile rotates the ACL token in a token Secret if it is missing or was
+// last rotated longer than the rotation interval ago, and requeues the Secret
+// for when its token needs to be rotated again.
+func (r *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var secret corev1.Secret
+	err := r.Client.Get(ctx, req.NamespacedName, &secret)
+	if k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		r.Log.Error(err, "failed to get Secret", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+
+	now := time.Now()
+	if len(secret.Data[TokenKey]) > 0 {
+		nextRotation := lastRotated(&secret).Add(r.RotationInterval)
+		if now.Before(nextRotation) {
+			return ctrl.Result{RequeueAfter: nextRotation.Sub(now)}, nil
+		}
+	}
+
+	r.Log.Info("rotating ACL token", "name", req.Name, "ns", req.Namespace)
+	if err := r.rotate(ctx, &secret, now); err != nil {
+		r.Log.Error(err, "failed to rotate ACL token", "name", req.Name, "ns", req.Namespace)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.RotationInterval}, nil
+}
+
+// rotate logs in as the component of the Secret, writes the new token to the
+// Secret and logs out the token that the previous rotation replaced.
+func (r *Controller) rotate(ctx context.Context, secret *corev1.Secret, now time.Time) error {
+	serviceAccount := secret.Annotations[ServiceAccountAnnotation]
+	if serviceAccount == "" {
+		return fmt.Errorf("the %s annotation is not set", ServiceAccountAnnotation)
+	}
+	expiration := int64(bearerTokenExpiration.Seconds())
+	tokenRequest, err := r.Clientset.CoreV1().ServiceAccounts(secret.Namespace).CreateToken(ctx, serviceAccount,
+		&authenticationv1.TokenRequest{Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expiration}},
+		metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create a token for service account %s: %w", serviceAccount, err)
+	}
+
+	apiClient, err := consul.NewClientFromConnMgr(r.ConsulClientConfig, r.ConsulServerConnMgr)
+	if err != nil {
+		return fmt.Errorf("failed to create Consul API client: %w", err)
+	}
+	authMethod, datacenter := r.AuthMethod, r.Datacenter
+	if v, ok := secret.Annotations[AuthMethodAnnotation]; ok {
+		authMethod = v
+	}
+	if v, ok := secret.Annotations[DatacenterAnnotation]; ok {
+		datacenter = v
+	}
+	token, _, err := apiClient.ACL().Login(&capi.ACLLoginParams{
+		AuthMethod:  authMethod,
+		BearerToken: tokenRequest.Status.Token,
+		Meta:        map[string]string{"component": secret.Annotations[ComponentAnnotation]},
+	}, &capi.WriteOptions{Datacenter: datacenter})
+	r.Auditor.Record(audit.Event{
+		Actor:     "controller/acl-token-rotation",
+		Operation: audit.OperationCreate,
+		Resource:  audit.ResourceACLToken,
+		Name:      accessorID(token),
+	}, err)
+	if err != nil {
+		return fmt.Errorf("failed to log in with auth method %s: %w", authMethod, err)
+	}
+
+	staleToken := string(secret.Data[previousTokenKey])
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[previousTokenKey] = secret.Data[TokenKey]
+	secret.Data[TokenKey] = []byte(token.SecretID)
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[TokenRotatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+	if err := r.Client.Update(ctx, secret); err != nil {
+		// The new token is logged out since nothing else knows about it.
+		if logoutErr := r.logout(apiClient, token.SecretID, datacenter); logoutErr != nil {
+			r.Log.Error(logoutErr, "failed to log out ACL token", "name", secret.Name, "ns", secret.Namespace)
+		}
+		return fmt.Errorf("failed to update Secret: %w", err)
+	}
+
+	if deployment := secret.Annotations[RestartDeploymentAnnotation]; deployment != "" {
+		if err := r.restart(ctx, secret.Namespace, deployment, now); err != nil {
+			// The rotation isn't retried since the Secret already has the
+			// new token. The previous token stays valid until the next
+			// rotation, so the component keeps working until then.
+			r.Log.Error(err, "failed to restart Deployment", "name", deployment, "ns", secret.Namespace)
+		}
+	}
+
+	if staleToken != "" {
+		if err := r.logout(apiClient, staleToken, datacenter); err != nil {
+			// The rotation isn't retried since the Secret already has the
+			// new token.
+			r.Log.Error(err, "failed to log out ACL token", "name", secret.Name, "ns", secret.Namespace)
+		}
+	}
+	return nil
+}
+
+// restart restarts a Deployment by setting the time of the rotation on its pod
+// template, so that its pods read the new token.
+func (r *Controller) restart(ctx context.Context, namespace, name string, now time.Time) error {
+	patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		TokenRotatedAtAnnotation, now.UTC().Format(time.RFC3339))
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	return r.Client.Patch(ctx, deployment, client.RawPatch(types.MergePatchType, []byte(patch)))
+}
+
+// logout deletes the token with the given secret ID. Tokens that no longer
+// exist are ignored.
+func (r *Controller) logout(apiClient *capi.Client, secretID, datacenter string) error {
+	token, _, err := apiClient.ACL().TokenReadSelf(&capi.QueryOptions{Token: secretID, Datacenter: datacenter})
+	if isTokenNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	_, err = apiClient.ACL().Logout(&capi.WriteOptions{Token: secretID, Datacenter: datacenter})
+	r.Auditor.Record(audit.Event{
+		Actor:     "controller/acl-token-rotation",
+		Operation: audit.OperationDelete,
+		Resource:  audit.ResourceACLToken,
+		Name:      token.AccessorID,
+	}, err)
+	if isTokenNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// isTokenNotFound returns true if err is the error Consul returns for a token
+// that doesn't exist, e.g. because it was already deleted by the token cleanup.
+func isTokenNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "ACL not found")
+}
+
+func accessorID(token *capi.ACLToken) string {
+	if token == nil {
+		return ""
+	}
+	return token.AccessorID
+}
+
+// lastRotated returns the time the ACL token of the component was last
+// rotated, or the time the Secret was created if it's never been.
+func lastRotated(secret *corev1.Secret) time.Time {
+	if rotatedAt, ok := secret.Annotations[TokenRotatedAtAnnotation]; ok {
+		if t, err := time.Parse(time.RFC3339, rotatedAt); err == nil {
+			return t
+		}
+	}
+	return secret.CreationTimestamp.Time
+}
+
+func (r *Controller) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("acl-token-rotation").
+		For(&corev1.Secret{}, builder.WithPredicates(predicate.NewPredicateFuncs(r.isTokenSecret))).
+		Complete(r)
+}
+
+// isTokenSecret returns true if obj is the token Secret of a component whose
+// ACL token is rotated.
+func (r *Controller) isTokenSecret(obj client.Object) bool {
+	return obj.GetNamespace() == r.Namespace && obj.GetLabels()[RotateTokenLabel] == "true"
+}
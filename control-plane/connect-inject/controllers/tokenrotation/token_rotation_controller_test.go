@@ -0,0 +1,367 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tokenrotation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	interval := time.Hour
+	now := time.Now()
+
+	cases := map[string]struct {
+		created       time.Time
+		rotatedAt     string
+		token         string
+		previousToken string
+		authMethod    string
+		expRotated    bool
+		expLoggedOut  []string
+		maxRequeueDur time.Duration
+	}{
+		"new secret without a token": {
+			created:       now.Add(-time.Minute),
+			expRotated:    true,
+			maxRequeueDur: interval,
+		},
+		"token rotated recently": {
+			created:       now.Add(-2 * time.Hour),
+			rotatedAt:     now.Add(-30 * time.Minute).UTC().Format(time.RFC3339),
+			token:         "current",
+			maxRequeueDur: 30 * time.Minute,
+		},
+		"token rotated longer than the interval ago": {
+			created:       now.Add(-3 * time.Hour),
+			rotatedAt:     now.Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			token:         "current",
+			previousToken: "previous",
+			expRotated:    true,
+			expLoggedOut:  []string{"previous"},
+			maxRequeueDur: interval,
+		},
+		"previous token already deleted": {
+			created:       now.Add(-3 * time.Hour),
+			rotatedAt:     now.Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+			token:         "current",
+			previousToken: "deleted",
+			expRotated:    true,
+			maxRequeueDur: interval,
+		},
+		"auth method annotation": {
+			created:       now.Add(-time.Minute),
+			authMethod:    "consul-k8s-component-auth-method-dc2",
+			expRotated:    true,
+			maxRequeueDur: interval,
+		},
+		"invalid rotation time": {
+			created:       now.Add(-2 * time.Hour),
+			rotatedAt:     "yesterday",
+			token:         "current",
+			expRotated:    true,
+			maxRequeueDur: interval,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "consul-sync-catalog-acl-token",
+					Namespace:         "consul",
+					CreationTimestamp: metav1.NewTime(c.created),
+					Labels:            map[string]string{RotateTokenLabel: "true"},
+					Annotations: map[string]string{
+						ServiceAccountAnnotation: "consul-sync-catalog",
+						ComponentAnnotation:      "sync-catalog",
+					},
+				},
+				Data: map[string][]byte{},
+			}
+			if c.rotatedAt != "" {
+				secret.Annotations[TokenRotatedAtAnnotation] = c.rotatedAt
+			}
+			expAuthMethod := "consul-k8s-component-auth-method"
+			if c.authMethod != "" {
+				secret.Annotations[AuthMethodAnnotation] = c.authMethod
+				expAuthMethod = c.authMethod
+			}
+			if c.token != "" {
+				secret.Data[TokenKey] = []byte(c.token)
+			}
+			if c.previousToken != "" {
+				secret.Data[previousTokenKey] = []byte(c.previousToken)
+			}
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
+			consulServer := newTestConsulServer(t, "deleted")
+			consulConfig, connMgr := consulConfig(t, consulServer.URL)
+
+			controller := &Controller{
+				Client:              fakeClient,
+				Clientset:           fakeClientset("consul-sync-catalog"),
+				ConsulClientConfig:  consulConfig,
+				ConsulServerConnMgr: connMgr,
+				AuthMethod:          "consul-k8s-component-auth-method",
+				Log:                 logrtest.New(t),
+				Namespace:           "consul",
+				RotationInterval:    interval,
+			}
+			namespacedName := types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}
+			resp, err := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+			require.NoError(t, err)
+			require.Greater(t, resp.RequeueAfter, time.Duration(0))
+			require.LessOrEqual(t, resp.RequeueAfter, c.maxRequeueDur)
+
+			var updated corev1.Secret
+			require.NoError(t, fakeClient.Get(context.Background(), namespacedName, &updated))
+			rotatedAt := updated.Annotations[TokenRotatedAtAnnotation]
+			if c.expRotated {
+				require.Equal(t, "secret-1", string(updated.Data[TokenKey]))
+				require.Equal(t, c.token, string(updated.Data[previousTokenKey]))
+				rotated, err := time.Parse(time.RFC3339, rotatedAt)
+				require.NoError(t, err)
+				require.WithinDuration(t, now, rotated, time.Minute)
+				require.Equal(t, []capi.ACLLoginParams{{
+					AuthMethod:  expAuthMethod,
+					BearerToken: "jwt-consul-sync-catalog",
+					Meta:        map[string]string{"component": "sync-catalog"},
+				}}, consulServer.logins)
+			} else {
+				require.Equal(t, c.rotatedAt, rotatedAt)
+				require.Equal(t, c.token, string(updated.Data[TokenKey]))
+				require.Empty(t, consulServer.logins)
+			}
+			require.Equal(t, c.expLoggedOut, consulServer.logouts)
+		})
+	}
+}
+
+func TestReconcile_RestartDeployment(t *testing.T) {
+	t.Parallel()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "consul-mesh-gateway-acl-token",
+			Namespace: "consul",
+			Labels:    map[string]string{RotateTokenLabel: "true"},
+			Annotations: map[string]string{
+				ServiceAccountAnnotation:    "consul-mesh-gateway",
+				ComponentAnnotation:         "mesh-gateway",
+				RestartDeploymentAnnotation: "consul-mesh-gateway",
+			},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "consul-mesh-gateway", Namespace: "consul"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"consul.hashicorp.com/gateway-kind": "mesh-gateway"}},
+			},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret, deployment).Build()
+	consulServer := newTestConsulServer(t)
+	consulConfig, connMgr := consulConfig(t, consulServer.URL)
+
+	controller := &Controller{
+		Client:              fakeClient,
+		Clientset:           fakeClientset("consul-mesh-gateway"),
+		ConsulClientConfig:  consulConfig,
+		ConsulServerConnMgr: connMgr,
+		AuthMethod:          "consul-k8s-component-auth-method",
+		Log:                 logrtest.New(t),
+		Namespace:           "consul",
+		RotationInterval:    time.Hour,
+	}
+	namespacedName := types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}
+	_, err := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.NoError(t, err)
+
+	var updatedSecret corev1.Secret
+	require.NoError(t, fakeClient.Get(context.Background(), namespacedName, &updatedSecret))
+	var updated appsv1.Deployment
+	require.NoError(t, fakeClient.Get(context.Background(), types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, &updated))
+	require.Equal(t, map[string]string{
+		"consul.hashicorp.com/gateway-kind": "mesh-gateway",
+		TokenRotatedAtAnnotation:            updatedSecret.Annotations[TokenRotatedAtAnnotation],
+	}, updated.Spec.Template.Annotations)
+}
+
+func TestReconcile_MissingServiceAccount(t *testing.T) {
+	t.Parallel()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "consul-sync-catalog-acl-token",
+			Namespace: "consul",
+			Labels:    map[string]string{RotateTokenLabel: "true"},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
+	consulServer := newTestConsulServer(t)
+	consulConfig, connMgr := consulConfig(t, consulServer.URL)
+
+	controller := &Controller{
+		Client:              fakeClient,
+		Clientset:           fakeClientset("consul-sync-catalog"),
+		ConsulClientConfig:  consulConfig,
+		ConsulServerConnMgr: connMgr,
+		Log:                 logrtest.New(t),
+		Namespace:           "consul",
+		RotationInterval:    time.Hour,
+	}
+	namespacedName := types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}
+	_, err := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: namespacedName})
+	require.EqualError(t, err, "the consul.hashicorp.com/service-account annotation is not set")
+	require.Empty(t, consulServer.logins)
+}
+
+func TestIsTokenSecret(t *testing.T) {
+	t.Parallel()
+	controller := &Controller{Namespace: "consul"}
+
+	cases := map[string]struct {
+		namespace string
+		labels    map[string]string
+		exp       bool
+	}{
+		"token secret": {
+			namespace: "consul",
+			labels:    map[string]string{RotateTokenLabel: "true"},
+			exp:       true,
+		},
+		"without label": {
+			namespace: "consul",
+		},
+		"label set to false": {
+			namespace: "consul",
+			labels:    map[string]string{RotateTokenLabel: "false"},
+		},
+		"other namespace": {
+			namespace: "default",
+			labels:    map[string]string{RotateTokenLabel: "true"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: c.namespace,
+					Labels:    c.labels,
+				},
+			}
+			require.Equal(t, c.exp, controller.isTokenSecret(secret))
+		})
+	}
+}
+
+// fakeClientset returns a clientset that creates the token "jwt-<name>" for
+// the given service account.
+func fakeClientset(serviceAccount string) *k8sfake.Clientset {
+	clientset := k8sfake.NewSimpleClientset()
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		create := action.(k8stesting.CreateAction)
+		if create.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		tokenRequest := create.GetObject().(*authenticationv1.TokenRequest).DeepCopy()
+		if create.(k8stesting.CreateActionImpl).Name != serviceAccount {
+			return true, nil, fmt.Errorf("service account %q not found", create.(k8stesting.CreateActionImpl).Name)
+		}
+		tokenRequest.Status.Token = "jwt-" + serviceAccount
+		return true, tokenRequest, nil
+	})
+	return clientset
+}
+
+// testConsulServer is a Consul server that records the logins and logouts.
+// The tokens it issues are "secret-1", "secret-2" and so on.
+type testConsulServer struct {
+	*httptest.Server
+
+	lock    sync.Mutex
+	logins  []capi.ACLLoginParams
+	logouts []string
+	deleted map[string]bool
+}
+
+func newTestConsulServer(t *testing.T, deletedTokens ...string) *testConsulServer {
+	t.Helper()
+	s := &testConsulServer{deleted: make(map[string]bool)}
+	for _, token := range deletedTokens {
+		s.deleted[token] = true
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		token := r.Header.Get("X-Consul-Token")
+		switch r.URL.Path {
+		case "/v1/acl/login":
+			var params capi.ACLLoginParams
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+			s.logins = append(s.logins, params)
+			n := len(s.logins)
+			_ = json.NewEncoder(w).Encode(capi.ACLToken{
+				AccessorID: fmt.Sprintf("accessor-%d", n),
+				SecretID:   fmt.Sprintf("secret-%d", n),
+			})
+		case "/v1/acl/token/self":
+			if s.deleted[token] {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte("ACL not found"))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(capi.ACLToken{AccessorID: "accessor-" + token, SecretID: token})
+		case "/v1/acl/logout":
+			s.logouts = append(s.logouts, token)
+			s.deleted[token] = true
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// consulConfig returns the config and connection manager for the test Consul
+// server.
+func consulConfig(t *testing.T, serverURL string) (*consul.Config, consul.ServerConnectionManager) {
+	t.Helper()
+	u, err := url.Parse(serverURL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	addr, err := discovery.MakeAddr(u.Hostname(), port)
+	require.NoError(t, err)
+	connMgr := &consul.MockServerConnectionManager{}
+	connMgr.On("State").Return(discovery.State{Address: addr}, nil)
+	return &consul.Config{APIClientConfig: &capi.Config{}, HTTPPort: port}, connMgr
+}
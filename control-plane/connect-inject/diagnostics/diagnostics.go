@@ -0,0 +1,267 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package diagnostics reports the health of the connect injector beyond
+// whether it can serve its webhooks, so that a degraded injector can be
+// alerted on before it starts failing pods.
+package diagnostics
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/cert"
+	capi "github.com/hashicorp/consul/api"
+)
+
+// Checker checks the webhook certificate, the connection to the Consul
+// servers and the ACL login of the injector. Its check methods have the
+// signature of controller-runtime's healthz.Checker so that they can be
+// served on /healthz, and it serves the full Report on /diagz.
+type Checker struct {
+	// CertDir is the directory of the webhook's tls.crt.
+	CertDir string
+
+	ConsulConfig        *consul.Config
+	ConsulServerConnMgr consul.ServerConnectionManager
+	// AuthMethod is the auth method the injector logs in to Consul with. The
+	// ACL login isn't checked if it is empty.
+	AuthMethod string
+
+	// Reconciles tracks the last successful reconcile of the controllers. It
+	// is optional.
+	Reconciles *ReconcileTracker
+
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+// Report is the diagnostics of the injector served on /diagz.
+type Report struct {
+	// Healthy is false if any of the checks failed.
+	Healthy     bool               `json:"healthy"`
+	WebhookCert CertStatus         `json:"webhookCert"`
+	Consul      ConsulStatus       `json:"consul"`
+	ACLLogin    *ACLLoginStatus    `json:"aclLogin,omitempty"`
+	Controllers []ControllerStatus `json:"controllers"`
+}
+
+// CertStatus is the validity window of the webhook certificate.
+type CertStatus struct {
+	NotBefore       *time.Time `json:"notBefore,omitempty"`
+	NotAfter        *time.Time `json:"notAfter,omitempty"`
+	SecondsToExpiry int64      `json:"secondsToExpiry"`
+	Error           string     `json:"error,omitempty"`
+}
+
+// ConsulStatus is the connection to the Consul servers.
+type ConsulStatus struct {
+	Address string `json:"address,omitempty"`
+	Leader  string `json:"leader,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ACLLoginStatus is the ACL login of the injector.
+type ACLLoginStatus struct {
+	AuthMethod string `json:"authMethod"`
+	LoggedIn   bool   `json:"loggedIn"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ControllerStatus is when a controller last reconciled successfully.
+type ControllerStatus struct {
+	Name                      string    `json:"name"`
+	LastSuccessfulReconcile   time.Time `json:"lastSuccessfulReconcile"`
+	SecondsSinceLastReconcile int64     `json:"secondsSinceLastReconcile"`
+}
+
+// CertCheck fails if the webhook certificate can't be read or is outside of
+// its validity window.
+func (c *Checker) CertCheck(_ *http.Request) error {
+	_, err := c.certificate()
+	return err
+}
+
+// ConsulCheck fails if the Consul servers can't be reached or have no leader.
+func (c *Checker) ConsulCheck(_ *http.Request) error {
+	return c.consulStatus().err()
+}
+
+// ACLLoginCheck fails if the injector logs in with an auth method and isn't
+// logged in or its token is no longer valid.
+func (c *Checker) ACLLoginCheck(_ *http.Request) error {
+	status := c.aclLoginStatus()
+	if status == nil || status.Error == "" {
+		return nil
+	}
+	return errors.New(status.Error)
+}
+
+// Report runs all the checks.
+func (c *Checker) Report() Report {
+	report := Report{Healthy: true, Controllers: []ControllerStatus{}}
+
+	now := c.clock()
+	crt, err := c.certificate()
+	if crt != nil {
+		notBefore, notAfter := crt.NotBefore, crt.NotAfter
+		report.WebhookCert.NotBefore = &notBefore
+		report.WebhookCert.NotAfter = &notAfter
+		report.WebhookCert.SecondsToExpiry = int64(notAfter.Sub(now).Seconds())
+	}
+	if err != nil {
+		report.WebhookCert.Error = err.Error()
+		report.Healthy = false
+	}
+
+	report.Consul = c.consulStatus()
+	if report.Consul.Error != "" {
+		report.Healthy = false
+	}
+
+	report.ACLLogin = c.aclLoginStatus()
+	if report.ACLLogin != nil && report.ACLLogin.Error != "" {
+		report.Healthy = false
+	}
+
+	if c.Reconciles != nil {
+		for name, last := range c.Reconciles.LastSuccessfulReconciles() {
+			report.Controllers = append(report.Controllers, ControllerStatus{
+				Name:                      name,
+				LastSuccessfulReconcile:   last,
+				SecondsSinceLastReconcile: int64(now.Sub(last).Seconds()),
+			})
+		}
+		sort.Slice(report.Controllers, func(i, j int) bool {
+			return report.Controllers[i].Name < report.Controllers[j].Name
+		})
+	}
+	return report
+}
+
+// ServeHTTP serves the Report as JSON. The status code is 503 if any of the
+// checks failed so that the endpoint can be probed directly.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	report := c.Report()
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+func (c *Checker) certificate() (*x509.Certificate, error) {
+	pemValue, err := os.ReadFile(filepath.Join(c.CertDir, "tls.crt"))
+	if err != nil {
+		return nil, err
+	}
+	crt, err := cert.ParseCert(pemValue)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the webhook certificate: %w", err)
+	}
+	now := c.clock()
+	if now.Before(crt.NotBefore) {
+		return crt, fmt.Errorf("webhook certificate is not valid until %s", crt.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(crt.NotAfter) {
+		return crt, fmt.Errorf("webhook certificate expired at %s", crt.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return crt, nil
+}
+
+func (c *Checker) consulStatus() ConsulStatus {
+	var status ConsulStatus
+	client, address, err := c.consulClient()
+	status.Address = address
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	leader, err := client.Status().Leader()
+	if err != nil {
+		status.Error = fmt.Sprintf("unable to reach the Consul servers: %s", err)
+		return status
+	}
+	if leader == "" {
+		status.Error = "the Consul servers have no leader"
+		return status
+	}
+	status.Leader = leader
+	return status
+}
+
+func (s ConsulStatus) err() error {
+	if s.Error == "" {
+		return nil
+	}
+	return errors.New(s.Error)
+}
+
+func (c *Checker) aclLoginStatus() *ACLLoginStatus {
+	if c.AuthMethod == "" {
+		return nil
+	}
+	status := &ACLLoginStatus{AuthMethod: c.AuthMethod}
+	state, err := c.ConsulServerConnMgr.State()
+	if err != nil {
+		status.Error = fmt.Sprintf("unable to get the state of the Consul server connection: %s", err)
+		return status
+	}
+	if state.Token == "" {
+		status.Error = fmt.Sprintf("not logged in with auth method %q", c.AuthMethod)
+		return status
+	}
+	client, _, err := c.consulClient()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if _, _, err := client.ACL().TokenReadSelf(nil); err != nil {
+		status.Error = fmt.Sprintf("unable to read the ACL token from the login: %s", err)
+		return status
+	}
+	status.LoggedIn = true
+	return status
+}
+
+// consulClient returns a client for the current Consul server and its
+// address. The client doesn't share the rate limiter and request policy of
+// the controllers so that the checks report the state of the connection
+// rather than waiting on or retrying it, and it doesn't keep connections
+// open between checks.
+func (c *Checker) consulClient() (*capi.Client, string, error) {
+	state, err := c.ConsulServerConnMgr.State()
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to get the state of the Consul server connection: %w", err)
+	}
+	address := fmt.Sprintf("%s:%d", state.Address.IP.String(), c.ConsulConfig.HTTPPort)
+	apiConfig := *c.ConsulConfig.APIClientConfig
+	apiConfig.HttpClient = nil
+	apiConfig.Transport = &http.Transport{DisableKeepAlives: true}
+	config := &consul.Config{
+		APIClientConfig: &apiConfig,
+		HTTPPort:        c.ConsulConfig.HTTPPort,
+		GRPCPort:        c.ConsulConfig.GRPCPort,
+		APITimeout:      c.ConsulConfig.APITimeout,
+	}
+	client, err := consul.NewClientFromConnMgrState(config, state)
+	if err != nil {
+		return nil, address, fmt.Errorf("unable to create the Consul client: %w", err)
+	}
+	return client, address, nil
+}
+
+func (c *Checker) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
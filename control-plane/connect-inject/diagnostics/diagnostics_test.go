@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package diagnostics
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/cert"
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_CertCheck(t *testing.T) {
+	cases := map[string]struct {
+		certPEM func(t *testing.T) string
+		expErr  string
+	}{
+		"valid certificate": {
+			certPEM: func(t *testing.T) string { return generateCert(t, time.Hour) },
+		},
+		"expired certificate": {
+			certPEM: func(t *testing.T) string { return generateCert(t, -time.Second) },
+			expErr:  "webhook certificate expired at",
+		},
+		"not a certificate": {
+			certPEM: func(t *testing.T) string { return "not a certificate" },
+			expErr:  "unable to parse the webhook certificate",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			certDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(certDir, "tls.crt"), []byte(c.certPEM(t)), 0600))
+
+			checker := &Checker{CertDir: certDir}
+			err := checker.CertCheck(nil)
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, c.expErr)
+			}
+		})
+	}
+}
+
+func TestChecker_CertCheck_MissingCertificate(t *testing.T) {
+	checker := &Checker{CertDir: t.TempDir()}
+	require.Error(t, checker.CertCheck(nil))
+}
+
+func TestChecker_ConsulCheck(t *testing.T) {
+	cases := map[string]struct {
+		leader string
+		expErr string
+	}{
+		"servers have a leader": {
+			leader: `"10.0.0.1:8300"`,
+		},
+		"servers have no leader": {
+			leader: `""`,
+			expErr: "the Consul servers have no leader",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/status/leader", r.URL.Path)
+				_, _ = w.Write([]byte(c.leader))
+			}))
+			defer server.Close()
+
+			checker := testChecker(t, server.URL, "", "")
+			err := checker.ConsulCheck(nil)
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
+
+func TestChecker_ConsulCheck_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	serverURL := server.URL
+	server.Close()
+
+	checker := testChecker(t, serverURL, "", "")
+	require.ErrorContains(t, checker.ConsulCheck(nil), "unable to reach the Consul servers")
+}
+
+func TestChecker_ACLLoginCheck(t *testing.T) {
+	cases := map[string]struct {
+		authMethod string
+		token      string
+		tokenCode  int
+		expErr     string
+	}{
+		"no auth method": {},
+		"not logged in": {
+			authMethod: "consul-k8s-component-auth-method",
+			expErr:     `not logged in with auth method "consul-k8s-component-auth-method"`,
+		},
+		"valid token": {
+			authMethod: "consul-k8s-component-auth-method",
+			token:      "token",
+			tokenCode:  http.StatusOK,
+		},
+		"invalid token": {
+			authMethod: "consul-k8s-component-auth-method",
+			token:      "token",
+			tokenCode:  http.StatusForbidden,
+			expErr:     "unable to read the ACL token from the login",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v1/acl/token/self", r.URL.Path)
+				require.Equal(t, c.token, r.Header.Get("X-Consul-Token"))
+				w.WriteHeader(c.tokenCode)
+				if c.tokenCode == http.StatusOK {
+					_ = json.NewEncoder(w).Encode(capi.ACLToken{AccessorID: "accessor-id"})
+				}
+			}))
+			defer server.Close()
+
+			checker := testChecker(t, server.URL, c.authMethod, c.token)
+			err := checker.ACLLoginCheck(nil)
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, c.expErr)
+			}
+		})
+	}
+}
+
+func TestChecker_ServeHTTP(t *testing.T) {
+	healthyConsul := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthyConsul {
+			_, _ = w.Write([]byte(`"10.0.0.1:8300"`))
+		} else {
+			_, _ = w.Write([]byte(`""`))
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	certDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(certDir, "tls.crt"), []byte(generateCert(t, time.Hour)), 0600))
+	checker := testChecker(t, server.URL, "", "")
+	checker.CertDir = certDir
+	checker.now = func() time.Time { return now }
+	checker.Reconciles = &ReconcileTracker{
+		last: map[string]time.Time{
+			"endpoints":         now.Add(-30 * time.Second),
+			"peering-acceptor":  now.Add(-time.Minute),
+			"acl-token-cleanup": now.Add(-2 * time.Minute),
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	checker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/diagz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	require.True(t, report.Healthy)
+	require.Empty(t, report.WebhookCert.Error)
+	require.InDelta(t, time.Hour.Seconds(), report.WebhookCert.SecondsToExpiry, 5)
+	require.Equal(t, "10.0.0.1:8300", report.Consul.Leader)
+	require.Nil(t, report.ACLLogin)
+	require.Len(t, report.Controllers, 3)
+	require.Equal(t, "acl-token-cleanup", report.Controllers[0].Name)
+	require.Equal(t, int64(120), report.Controllers[0].SecondsSinceLastReconcile)
+	require.Equal(t, "endpoints", report.Controllers[1].Name)
+	require.Equal(t, int64(30), report.Controllers[1].SecondsSinceLastReconcile)
+	require.Equal(t, "peering-acceptor", report.Controllers[2].Name)
+
+	healthyConsul = false
+	rec = httptest.NewRecorder()
+	checker.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/diagz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	require.False(t, report.Healthy)
+	require.Equal(t, "the Consul servers have no leader", report.Consul.Error)
+}
+
+// testChecker returns a Checker whose Consul server is at serverURL.
+func testChecker(t *testing.T, serverURL, authMethod, token string) *Checker {
+	t.Helper()
+	parsedURL, err := url.Parse(serverURL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(parsedURL.Port())
+	require.NoError(t, err)
+
+	connMgr := &consul.MockServerConnectionManager{}
+	connMgr.On("State").Return(discovery.State{
+		Address: discovery.Addr{
+			TCPAddr: net.TCPAddr{IP: net.ParseIP(parsedURL.Hostname()), Port: port},
+		},
+		Token: token,
+	}, nil)
+
+	return &Checker{
+		ConsulConfig:        &consul.Config{APIClientConfig: &capi.Config{}, HTTPPort: port, APITimeout: time.Second},
+		ConsulServerConnMgr: connMgr,
+		AuthMethod:          authMethod,
+	}
+}
+
+func generateCert(t *testing.T, expiry time.Duration) string {
+	t.Helper()
+	signer, _, _, caCert, err := cert.GenerateCA("Consul Agent CA - Test")
+	require.NoError(t, err)
+	certPEM, _, err := cert.GenerateCert("consul-connect-injector", expiry, caCert, signer, []string{"localhost"})
+	require.NoError(t, err)
+	return certPEM
+}
@@ -0,0 +1,122 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package diagnostics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultSampleInterval is how often the reconcile metrics are sampled if
+	// SampleInterval is not set.
+	DefaultSampleInterval = 5 * time.Second
+
+	// reconcileTotalMetric is the counter of reconciles that controller-runtime
+	// keeps for each controller, labeled by the result of the reconcile.
+	reconcileTotalMetric = "controller_runtime_reconcile_total"
+)
+
+// ReconcileTracker tracks when each controller last reconciled successfully,
+// i.e. without returning an error. Rather than wrapping every reconciler, it
+// samples the reconcile counters that controller-runtime already keeps, so
+// the times are accurate to SampleInterval.
+type ReconcileTracker struct {
+	// Gatherer gathers controller-runtime's metrics, i.e. its metrics.Registry.
+	Gatherer       prometheus.Gatherer
+	SampleInterval time.Duration
+	Log            logr.Logger
+
+	mu        sync.Mutex
+	successes map[string]float64
+	last      map[string]time.Time
+}
+
+// Start samples the reconcile counters until ctx is cancelled. It implements
+// controller-runtime's manager.Runnable.
+func (t *ReconcileTracker) Start(ctx context.Context) error {
+	interval := t.SampleInterval
+	if interval == 0 {
+		interval = DefaultSampleInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.sample(time.Now()); err != nil {
+			t.Log.Error(err, "unable to sample the reconcile metrics")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection returns false so that the tracker runs on every replica.
+// Replicas that aren't the leader report no reconciles.
+func (t *ReconcileTracker) NeedLeaderElection() bool {
+	return false
+}
+
+// LastSuccessfulReconciles returns the time of the last successful reconcile
+// of each controller that has reconciled successfully at least once.
+func (t *ReconcileTracker) LastSuccessfulReconciles() map[string]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	last := make(map[string]time.Time, len(t.last))
+	for name, at := range t.last {
+		last[name] = at
+	}
+	return last
+}
+
+// sample records now as the last successful reconcile of the controllers
+// whose count of successful reconciles went up since the previous sample.
+func (t *ReconcileTracker) sample(now time.Time) error {
+	families, err := t.Gatherer.Gather()
+	if err != nil {
+		return err
+	}
+	successes := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != reconcileTotalMetric {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var controller, result string
+			for _, label := range metric.GetLabel() {
+				switch label.GetName() {
+				case "controller":
+					controller = label.GetValue()
+				case "result":
+					result = label.GetValue()
+				}
+			}
+			// Requeues are successful reconciles that asked to be run again.
+			if controller == "" || result == "error" {
+				continue
+			}
+			successes[controller] += metric.GetCounter().GetValue()
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last == nil {
+		t.last = make(map[string]time.Time)
+	}
+	for controller, count := range successes {
+		if count > t.successes[controller] {
+			t.last[controller] = now
+		}
+	}
+	t.successes = successes
+	return nil
+}
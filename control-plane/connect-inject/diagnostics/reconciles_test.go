@@ -0,0 +1,47 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package diagnostics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileTracker_Sample(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	reconciles := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: reconcileTotalMetric,
+		Help: "Total number of reconciliations per controller",
+	}, []string{"controller", "result"})
+	registry.MustRegister(reconciles)
+
+	tracker := &ReconcileTracker{Gatherer: registry}
+	start := time.Now()
+
+	// Controllers that haven't reconciled successfully are not reported.
+	reconciles.WithLabelValues("endpoints", "error").Inc()
+	require.NoError(t, tracker.sample(start))
+	require.Empty(t, tracker.LastSuccessfulReconciles())
+
+	reconciles.WithLabelValues("endpoints", "success").Inc()
+	reconciles.WithLabelValues("peering-acceptor", "requeue_after").Inc()
+	require.NoError(t, tracker.sample(start.Add(time.Second)))
+	require.Equal(t, map[string]time.Time{
+		"endpoints":        start.Add(time.Second),
+		"peering-acceptor": start.Add(time.Second),
+	}, tracker.LastSuccessfulReconciles())
+
+	// Only the controllers that reconciled successfully since the previous
+	// sample are updated.
+	reconciles.WithLabelValues("endpoints", "success").Inc()
+	reconciles.WithLabelValues("peering-acceptor", "error").Inc()
+	require.NoError(t, tracker.sample(start.Add(2*time.Second)))
+	require.Equal(t, map[string]time.Time{
+		"endpoints":        start.Add(2 * time.Second),
+		"peering-acceptor": start.Add(time.Second),
+	}, tracker.LastSuccessfulReconciles())
+}
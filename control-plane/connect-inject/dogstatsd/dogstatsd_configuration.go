@@ -0,0 +1,190 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package dogstatsd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultSocketPath is the default path of the DogStatsD socket of the Datadog agent.
+	DefaultSocketPath = "/var/run/datadog/dsd.socket"
+
+	// Proxy config keys understood by Consul when generating Envoy configuration.
+	envoyDogStatsDURL = "envoy_dogstatsd_url"
+	envoyStatsTags    = "envoy_stats_tags"
+
+	// entityIDTag is the tag the Datadog agent uses to detect the pod that metrics originate from
+	// and to add the pod's tags to them.
+	entityIDTag = "dd.internal.entity_id"
+)
+
+// Config represents configuration common to connect-inject components related to sending Envoy
+// metrics to DogStatsD.
+type Config struct {
+	DefaultEnabled bool
+	// SocketPath is the path of the DogStatsD Unix domain socket of the Datadog agent on the node.
+	// The directory that contains it is mounted into the sidecar proxy with a hostPath volume.
+	SocketPath string
+	// HostPort is the UDP port of DogStatsD on the node. If it is set, metrics are sent to this
+	// port on the pod's host IP instead of the socket, so no hostPath volume is needed.
+	HostPort int
+	// PodTags adds the pod name and UID tags to the metrics. They're off by default since every
+	// new pod creates new metric contexts in Datadog.
+	PodTags bool
+	// DefaultTags are Datadog "key:value" tags added to the metrics of every sidecar proxy.
+	DefaultTags []string
+}
+
+// Enabled returns whether the pod's sidecar proxy sends its metrics to DogStatsD, either via the
+// default value or if it's been overridden via the annotation.
+func (c Config) Enabled(pod corev1.Pod) (bool, error) {
+	enabled := c.DefaultEnabled
+	if raw, ok := pod.Annotations[constants.AnnotationEnvoyDogStatsDEnabled]; ok && raw != "" {
+		val, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, fmt.Errorf("%s annotation value of %s was invalid: %s", constants.AnnotationEnvoyDogStatsDEnabled, raw, err)
+		}
+		enabled = val
+	}
+	return enabled, nil
+}
+
+// UsesSocket returns whether metrics are sent to the DogStatsD socket, which
+// is mounted into the sidecar proxies.
+func (c Config) UsesSocket() bool {
+	return c.HostPort == 0
+}
+
+// SocketDir returns the directory of the DogStatsD socket.
+func (c Config) SocketDir() string {
+	return filepath.Dir(c.socketPath())
+}
+
+func (c Config) socketPath() string {
+	if c.SocketPath == "" {
+		return DefaultSocketPath
+	}
+	return c.SocketPath
+}
+
+// ProxyConfig returns the opaque proxy configuration that makes the pod's sidecar proxy send its
+// metrics to DogStatsD. It returns nil if DogStatsD is not enabled for the pod.
+//
+// The metrics are tagged with the service, the pod's namespace and the unified service tags from
+// the pod's tags.datadoghq.com labels. serviceName is used as the service tag unless the pod has
+// the tags.datadoghq.com/service label. If PodTags is set, the pod's name and UID are added too,
+// so that the Datadog agent adds the pod's tags to them. Tags from the defaults and the annotation
+// are added last and override the tags with the same key.
+func (c Config) ProxyConfig(pod corev1.Pod, serviceName string) (map[string]interface{}, error) {
+	enabled, err := c.Enabled(pod)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+	tags, err := c.tags(pod, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	url := "unix://" + c.socketPath()
+	if !c.UsesSocket() {
+		if pod.Status.HostIP == "" {
+			return nil, errors.New("the pod has no host IP to send DogStatsD metrics to")
+		}
+		url = "udp://" + net.JoinHostPort(pod.Status.HostIP, strconv.Itoa(c.HostPort))
+	}
+	return map[string]interface{}{
+		envoyDogStatsDURL: url,
+		envoyStatsTags:    tags.envoyStatsTags(),
+	}, nil
+}
+
+// Validate returns whether DogStatsD is enabled for the pod and an error if
+// its DogStatsD annotations are invalid. Unlike ProxyConfig, it doesn't need
+// the pod to be scheduled.
+func (c Config) Validate(pod corev1.Pod) (bool, error) {
+	enabled, err := c.Enabled(pod)
+	if err != nil || !enabled {
+		return false, err
+	}
+	if _, err := c.tags(pod, ""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// tags returns the tags of the pod's metrics.
+func (c Config) tags(pod corev1.Pod, serviceName string) (*tagList, error) {
+	if raw, ok := pod.Labels[constants.LabelDatadogService]; ok && raw != "" {
+		serviceName = raw
+	}
+	tags := newTagList()
+	tags.set("service", serviceName)
+	tags.set("env", pod.Labels[constants.LabelDatadogEnv])
+	tags.set("version", pod.Labels[constants.LabelDatadogVersion])
+	tags.set("kube_namespace", pod.Namespace)
+	if c.PodTags {
+		tags.set("pod_name", pod.Name)
+		tags.set(entityIDTag, string(pod.UID))
+	}
+
+	userTags := append([]string(nil), c.DefaultTags...)
+	if raw, ok := pod.Annotations[constants.AnnotationEnvoyDogStatsDTags]; ok && raw != "" {
+		userTags = append(userTags, strings.Split(raw, ",")...)
+	}
+	for _, tag := range userTags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(tag, ":")
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("%s tag %q was invalid: must be in the format key:value", constants.AnnotationEnvoyDogStatsDTags, tag)
+		}
+		tags.set(key, value)
+	}
+	return tags, nil
+}
+
+// tagList is a list of tags that keeps the order in which keys are first set.
+type tagList struct {
+	keys   []string
+	values map[string]string
+}
+
+func newTagList() *tagList {
+	return &tagList{values: make(map[string]string)}
+}
+
+// set sets the tag, replacing the value of an existing tag with the same key. Empty values are
+// ignored.
+func (t *tagList) set(key, value string) {
+	if value == "" {
+		return
+	}
+	if _, ok := t.values[key]; !ok {
+		t.keys = append(t.keys, key)
+	}
+	t.values[key] = value
+}
+
+// envoyStatsTags returns the tags in the "key=value" format of Consul's envoy_stats_tags.
+func (t *tagList) envoyStatsTags() []string {
+	tags := make([]string, 0, len(t.keys))
+	for _, key := range t.keys {
+		tags = append(tags, key+"="+t.values[key])
+	}
+	return tags
+}
@@ -0,0 +1,214 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package dogstatsd
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDogStatsDConfig_ProxyConfig(t *testing.T) {
+	cases := []struct {
+		Name            string
+		Pod             func(*corev1.Pod) *corev1.Pod
+		DogStatsDConfig Config
+		ExpDisabled     bool
+		ExpURL          string
+		ExpTags         []string
+		Err             string
+	}{
+		{
+			Name: "DogStatsD disabled by default",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			DogStatsDConfig: Config{},
+			ExpDisabled:     true,
+		},
+		{
+			Name: "DogStatsD enabled via meshWebhook",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			DogStatsDConfig: Config{DefaultEnabled: true},
+			ExpURL:          "unix:///var/run/datadog/dsd.socket",
+			ExpTags: []string{
+				"service=web",
+				"kube_namespace=default",
+			},
+		},
+		{
+			Name: "DogStatsD with pod tags",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			DogStatsDConfig: Config{DefaultEnabled: true, PodTags: true},
+			ExpURL:          "unix:///var/run/datadog/dsd.socket",
+			ExpTags: []string{
+				"service=web",
+				"kube_namespace=default",
+				"pod_name=minimal",
+				"dd.internal.entity_id=11111111-2222-3333-4444-555555555555",
+			},
+		},
+		{
+			Name: "DogStatsD on the host port",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Status.HostIP = "10.0.0.1"
+				return pod
+			},
+			DogStatsDConfig: Config{DefaultEnabled: true, HostPort: 8125},
+			ExpURL:          "udp://10.0.0.1:8125",
+			ExpTags: []string{
+				"service=web",
+				"kube_namespace=default",
+			},
+		},
+		{
+			Name: "DogStatsD on the host port of an IPv6 node",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Status.HostIP = "fd00::1"
+				return pod
+			},
+			DogStatsDConfig: Config{DefaultEnabled: true, HostPort: 8125},
+			ExpURL:          "udp://[fd00::1]:8125",
+			ExpTags: []string{
+				"service=web",
+				"kube_namespace=default",
+			},
+		},
+		{
+			Name: "DogStatsD on the host port of an unscheduled pod",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			DogStatsDConfig: Config{DefaultEnabled: true, HostPort: 8125},
+			Err:             "the pod has no host IP to send DogStatsD metrics to",
+		},
+		{
+			Name: "DogStatsD enabled via annotation with unified service tags and custom tags",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyDogStatsDEnabled] = "true"
+				pod.Annotations[constants.AnnotationEnvoyDogStatsDTags] = "team:payments, env:staging"
+				pod.Labels = map[string]string{
+					constants.LabelDatadogService: "frontend",
+					constants.LabelDatadogEnv:     "prod",
+					constants.LabelDatadogVersion: "1.2.3",
+				}
+				return pod
+			},
+			DogStatsDConfig: Config{
+				SocketPath:  "/run/datadog/apm/dsd.socket",
+				DefaultTags: []string{"cluster:east"},
+			},
+			ExpURL: "unix:///run/datadog/apm/dsd.socket",
+			ExpTags: []string{
+				"service=frontend",
+				"env=staging",
+				"version=1.2.3",
+				"kube_namespace=default",
+				"cluster=east",
+				"team=payments",
+			},
+		},
+		{
+			Name: "DogStatsD disabled via annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyDogStatsDEnabled] = "false"
+				return pod
+			},
+			DogStatsDConfig: Config{DefaultEnabled: true},
+			ExpDisabled:     true,
+		},
+		{
+			Name: "Invalid enabled annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyDogStatsDEnabled] = "not-a-bool"
+				return pod
+			},
+			Err: "consul.hashicorp.com/envoy-dogstatsd-enabled annotation value of not-a-bool was invalid: strconv.ParseBool: parsing \"not-a-bool\": invalid syntax",
+		},
+		{
+			Name: "Invalid tag",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyDogStatsDTags] = "team"
+				return pod
+			},
+			DogStatsDConfig: Config{DefaultEnabled: true},
+			Err:             "consul.hashicorp.com/envoy-dogstatsd-tags tag \"team\" was invalid: must be in the format key:value",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+
+			actual, err := tt.DogStatsDConfig.ProxyConfig(*tt.Pod(minimal()), "web")
+			if tt.Err != "" {
+				require.EqualError(err, tt.Err)
+				return
+			}
+			require.NoError(err)
+			if tt.ExpDisabled {
+				require.Nil(actual)
+				return
+			}
+			require.Equal(map[string]interface{}{
+				envoyDogStatsDURL: tt.ExpURL,
+				envoyStatsTags:    tt.ExpTags,
+			}, actual)
+		})
+	}
+}
+
+func TestDogStatsDConfig_Validate(t *testing.T) {
+	config := Config{DefaultEnabled: true, HostPort: 8125}
+
+	// The host IP is only needed for the proxy config.
+	enabled, err := config.Validate(*minimal())
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	pod := minimal()
+	pod.Annotations[constants.AnnotationEnvoyDogStatsDTags] = "team"
+	_, err = config.Validate(*pod)
+	require.EqualError(t, err, "consul.hashicorp.com/envoy-dogstatsd-tags tag \"team\" was invalid: must be in the format key:value")
+
+	pod = minimal()
+	pod.Annotations[constants.AnnotationEnvoyDogStatsDEnabled] = "false"
+	enabled, err = config.Validate(*pod)
+	require.NoError(t, err)
+	require.False(t, enabled)
+}
+
+func TestDogStatsDConfig_SocketDir(t *testing.T) {
+	require.Equal(t, "/var/run/datadog", Config{}.SocketDir())
+	require.Equal(t, "/run/datadog/apm", Config{SocketPath: "/run/datadog/apm/dsd.socket"}.SocketDir())
+}
+
+func minimal() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespaces.DefaultNamespace,
+			Name:      "minimal",
+			UID:       "11111111-2222-3333-4444-555555555555",
+			Annotations: map[string]string{
+				constants.AnnotationService: "foo",
+			},
+		},
+
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "web",
+				},
+			},
+		},
+	}
+}
@@ -17,6 +17,7 @@ type Config struct {
 	DefaultEnableProxyLifecycle         bool
 	DefaultEnableShutdownDrainListeners bool
 	DefaultShutdownGracePeriodSeconds   int
+	DefaultEnablePreStopDrain           bool
 	DefaultGracefulPort                 string
 	DefaultGracefulShutdownPath         string
 }
@@ -63,6 +64,20 @@ func (lc Config) ShutdownGracePeriodSeconds(pod corev1.Pod) (int, error) {
 	return shutdownGracePeriodSeconds, nil
 }
 
+// EnablePreStopDrain returns whether the sidecar proxy should start its graceful shutdown from a preStop hook, either
+// via the default value in the meshWebhook, or if it's been overridden via the annotation.
+func (lc Config) EnablePreStopDrain(pod corev1.Pod) (bool, error) {
+	enabled := lc.DefaultEnablePreStopDrain
+	if raw, ok := pod.Annotations[constants.AnnotationEnableSidecarProxyLifecyclePreStopDrain]; ok && raw != "" {
+		enablePreStopDrain, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, fmt.Errorf("%s annotation value of %s was invalid: %s", constants.AnnotationEnableSidecarProxyLifecyclePreStopDrain, raw, err)
+		}
+		enabled = enablePreStopDrain
+	}
+	return enabled, nil
+}
+
 // GracefulPort returns the port on which consul-dataplane should serve the proxy lifecycle management HTTP endpoints, either via the default value in the meshWebhook, or
 // if it's been overridden via the annotation. It also validates the port is in the unprivileged port range.
 func (lc Config) GracefulPort(pod corev1.Pod) (int, error) {
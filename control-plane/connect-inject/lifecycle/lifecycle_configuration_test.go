@@ -200,6 +200,69 @@ func TestLifecycleConfig_ShutdownGracePeriodSeconds(t *testing.T) {
 	}
 }
 
+func TestLifecycleConfig_EnablePreStopDrain(t *testing.T) {
+	cases := []struct {
+		Name            string
+		Pod             func(*corev1.Pod) *corev1.Pod
+		LifecycleConfig Config
+		Expected        bool
+		Err             string
+	}{
+		{
+			Name: "Sidecar proxy preStop drain not set",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			Expected: false,
+		},
+		{
+			Name: "Sidecar proxy preStop drain enabled via meshWebhook",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			LifecycleConfig: Config{
+				DefaultEnablePreStopDrain: true,
+			},
+			Expected: true,
+		},
+		{
+			Name: "Sidecar proxy preStop drain disabled via annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnableSidecarProxyLifecyclePreStopDrain] = "false"
+				return pod
+			},
+			LifecycleConfig: Config{
+				DefaultEnablePreStopDrain: true,
+			},
+			Expected: false,
+		},
+		{
+			Name: "Sidecar proxy preStop drain configured via invalid annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnableSidecarProxyLifecyclePreStopDrain] = "not-a-bool"
+				return pod
+			},
+			Err: "consul.hashicorp.com/enable-sidecar-proxy-lifecycle-prestop-drain annotation value of not-a-bool was invalid: strconv.ParseBool: parsing \"not-a-bool\": invalid syntax",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+			lc := tt.LifecycleConfig
+
+			actual, err := lc.EnablePreStopDrain(*tt.Pod(minimal()))
+
+			if tt.Err == "" {
+				require.Equal(tt.Expected, actual)
+				require.NoError(err)
+			} else {
+				require.EqualError(err, tt.Err)
+			}
+		})
+	}
+}
+
 func TestLifecycleConfig_GracefulPort(t *testing.T) {
 	cases := []struct {
 		Name            string
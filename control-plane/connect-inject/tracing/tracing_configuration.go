@@ -0,0 +1,176 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// CollectorClusterName is the name of the static Envoy cluster that points at the OTLP collector.
+	CollectorClusterName = "opentelemetry_collector"
+
+	// Proxy config keys understood by Consul when generating Envoy configuration.
+	envoyListenerTracingJSON       = "envoy_listener_tracing_json"
+	envoyExtraStaticClustersJSON   = "envoy_extra_static_clusters_json"
+	defaultSamplingRatePercentage  = "100"
+	collectorConnectTimeout        = "5s"
+	openTelemetryTracerName        = "envoy.tracers.opentelemetry"
+	openTelemetryConfigType        = "type.googleapis.com/envoy.config.trace.v3.OpenTelemetryConfig"
+	httpConnectionManagerTraceType = "type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager.Tracing"
+	http2ProtocolOptionsType       = "type.googleapis.com/envoy.extensions.upstreams.http.v3.HttpProtocolOptions"
+)
+
+// Config represents configuration common to connect-inject components related to Envoy tracing.
+type Config struct {
+	DefaultEnabled          bool
+	DefaultCollectorAddress string
+	DefaultSamplingRate     string
+}
+
+// Enabled returns whether tracing is enabled for the pod's sidecar proxy, either via the default value
+// or if it's been overridden via the annotation.
+func (c Config) Enabled(pod corev1.Pod) (bool, error) {
+	enabled := c.DefaultEnabled
+	if raw, ok := pod.Annotations[constants.AnnotationEnvoyTracingEnabled]; ok && raw != "" {
+		val, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, fmt.Errorf("%s annotation value of %s was invalid: %s", constants.AnnotationEnvoyTracingEnabled, raw, err)
+		}
+		enabled = val
+	}
+	return enabled, nil
+}
+
+// CollectorAddress returns the host:port of the OTLP gRPC collector, either via the default value or
+// if it's been overridden via the annotation.
+func (c Config) CollectorAddress(pod corev1.Pod) (string, int, error) {
+	addr := c.DefaultCollectorAddress
+	if raw, ok := pod.Annotations[constants.AnnotationEnvoyTracingCollectorAddress]; ok && raw != "" {
+		addr = raw
+	}
+	if addr == "" {
+		return "", 0, fmt.Errorf("%s must be set when tracing is enabled", constants.AnnotationEnvoyTracingCollectorAddress)
+	}
+	host, rawPort, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, fmt.Errorf("%s value of %s was invalid: %s", constants.AnnotationEnvoyTracingCollectorAddress, addr, err)
+	}
+	port, err := strconv.Atoi(rawPort)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("%s value of %s was invalid: port must be between 1 and 65535", constants.AnnotationEnvoyTracingCollectorAddress, addr)
+	}
+	return host, port, nil
+}
+
+// SamplingRate returns the percentage of requests that should be sampled, either via the default value
+// or if it's been overridden via the annotation.
+func (c Config) SamplingRate(pod corev1.Pod) (float64, error) {
+	raw := c.DefaultSamplingRate
+	if anno, ok := pod.Annotations[constants.AnnotationEnvoyTracingSamplingRate]; ok && anno != "" {
+		raw = anno
+	}
+	if raw == "" {
+		raw = defaultSamplingRatePercentage
+	}
+	rate, err := strconv.ParseFloat(raw, 64)
+	if err != nil || rate < 0 || rate > 100 {
+		return 0, fmt.Errorf("%s value of %s was invalid: must be a percentage between 0 and 100", constants.AnnotationEnvoyTracingSamplingRate, raw)
+	}
+	return rate, nil
+}
+
+// ProxyConfig returns the opaque proxy configuration that enables OpenTelemetry tracing on the pod's sidecar proxy.
+// It returns nil if tracing is not enabled for the pod. serviceName is used as the tracing service name unless it's
+// overridden via the annotation.
+func (c Config) ProxyConfig(pod corev1.Pod, serviceName string) (map[string]interface{}, error) {
+	enabled, err := c.Enabled(pod)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	host, port, err := c.CollectorAddress(pod)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := c.SamplingRate(pod)
+	if err != nil {
+		return nil, err
+	}
+	if raw, ok := pod.Annotations[constants.AnnotationEnvoyTracingServiceName]; ok && raw != "" {
+		serviceName = raw
+	}
+
+	tracingJSON, err := json.Marshal(map[string]interface{}{
+		"@type": httpConnectionManagerTraceType,
+		"provider": map[string]interface{}{
+			"name": openTelemetryTracerName,
+			"typed_config": map[string]interface{}{
+				"@type": openTelemetryConfigType,
+				"grpc_service": map[string]interface{}{
+					"envoy_grpc": map[string]interface{}{
+						"cluster_name": CollectorClusterName,
+					},
+				},
+				"service_name": serviceName,
+			},
+		},
+		"random_sampling": map[string]interface{}{
+			"value": rate,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clusterJSON, err := json.Marshal(map[string]interface{}{
+		"name":            CollectorClusterName,
+		"type":            "STRICT_DNS",
+		"connect_timeout": collectorConnectTimeout,
+		"typed_extension_protocol_options": map[string]interface{}{
+			"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": map[string]interface{}{
+				"@type": http2ProtocolOptionsType,
+				"explicit_http_config": map[string]interface{}{
+					"http2_protocol_options": map[string]interface{}{},
+				},
+			},
+		},
+		"load_assignment": map[string]interface{}{
+			"cluster_name": CollectorClusterName,
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"lb_endpoints": []interface{}{
+						map[string]interface{}{
+							"endpoint": map[string]interface{}{
+								"address": map[string]interface{}{
+									"socket_address": map[string]interface{}{
+										"address":    host,
+										"port_value": port,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		envoyListenerTracingJSON:     string(tracingJSON),
+		envoyExtraStaticClustersJSON: string(clusterJSON),
+	}, nil
+}
@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package tracing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTracingConfig_ProxyConfig(t *testing.T) {
+	cases := []struct {
+		Name             string
+		Pod              func(*corev1.Pod) *corev1.Pod
+		TracingConfig    Config
+		ExpDisabled      bool
+		ExpServiceName   string
+		ExpSamplingRate  float64
+		ExpCollectorHost string
+		ExpCollectorPort float64
+		Err              string
+	}{
+		{
+			Name: "Tracing disabled by default",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			TracingConfig: Config{},
+			ExpDisabled:   true,
+		},
+		{
+			Name: "Tracing enabled via meshWebhook",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				return pod
+			},
+			TracingConfig: Config{
+				DefaultEnabled:          true,
+				DefaultCollectorAddress: "otel-collector.observability:4317",
+				DefaultSamplingRate:     "10",
+			},
+			ExpServiceName:   "web",
+			ExpSamplingRate:  10,
+			ExpCollectorHost: "otel-collector.observability",
+			ExpCollectorPort: 4317,
+		},
+		{
+			Name: "Tracing enabled and overridden via annotations",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyTracingEnabled] = "true"
+				pod.Annotations[constants.AnnotationEnvoyTracingCollectorAddress] = "10.0.0.1:4318"
+				pod.Annotations[constants.AnnotationEnvoyTracingSamplingRate] = "0.5"
+				pod.Annotations[constants.AnnotationEnvoyTracingServiceName] = "frontend"
+				return pod
+			},
+			TracingConfig: Config{
+				DefaultCollectorAddress: "otel-collector.observability:4317",
+			},
+			ExpServiceName:   "frontend",
+			ExpSamplingRate:  0.5,
+			ExpCollectorHost: "10.0.0.1",
+			ExpCollectorPort: 4318,
+		},
+		{
+			Name: "Tracing disabled via annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyTracingEnabled] = "false"
+				return pod
+			},
+			TracingConfig: Config{
+				DefaultEnabled:          true,
+				DefaultCollectorAddress: "otel-collector.observability:4317",
+			},
+			ExpDisabled: true,
+		},
+		{
+			Name: "Invalid enabled annotation",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyTracingEnabled] = "not-a-bool"
+				return pod
+			},
+			Err: "consul.hashicorp.com/envoy-tracing-enabled annotation value of not-a-bool was invalid: strconv.ParseBool: parsing \"not-a-bool\": invalid syntax",
+		},
+		{
+			Name: "Missing collector address",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyTracingEnabled] = "true"
+				return pod
+			},
+			Err: "consul.hashicorp.com/envoy-tracing-collector-address must be set when tracing is enabled",
+		},
+		{
+			Name: "Collector address without port",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyTracingCollectorAddress] = "otel-collector"
+				return pod
+			},
+			TracingConfig: Config{DefaultEnabled: true},
+			Err:           "consul.hashicorp.com/envoy-tracing-collector-address value of otel-collector was invalid: address otel-collector: missing port in address",
+		},
+		{
+			Name: "Sampling rate out of range",
+			Pod: func(pod *corev1.Pod) *corev1.Pod {
+				pod.Annotations[constants.AnnotationEnvoyTracingSamplingRate] = "101"
+				return pod
+			},
+			TracingConfig: Config{DefaultEnabled: true, DefaultCollectorAddress: "otel-collector:4317"},
+			Err:           "consul.hashicorp.com/envoy-tracing-sampling-rate value of 101 was invalid: must be a percentage between 0 and 100",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+
+			actual, err := tt.TracingConfig.ProxyConfig(*tt.Pod(minimal()), "web")
+			if tt.Err != "" {
+				require.EqualError(err, tt.Err)
+				return
+			}
+			require.NoError(err)
+			if tt.ExpDisabled {
+				require.Nil(actual)
+				return
+			}
+
+			var tracingCfg map[string]interface{}
+			require.NoError(json.Unmarshal([]byte(actual[envoyListenerTracingJSON].(string)), &tracingCfg))
+			provider := tracingCfg["provider"].(map[string]interface{})
+			require.Equal(openTelemetryTracerName, provider["name"])
+			require.Equal(tt.ExpServiceName, provider["typed_config"].(map[string]interface{})["service_name"])
+			require.Equal(tt.ExpSamplingRate, tracingCfg["random_sampling"].(map[string]interface{})["value"])
+
+			var clusterCfg map[string]interface{}
+			require.NoError(json.Unmarshal([]byte(actual[envoyExtraStaticClustersJSON].(string)), &clusterCfg))
+			require.Equal(CollectorClusterName, clusterCfg["name"])
+			endpoint := clusterCfg["load_assignment"].(map[string]interface{})["endpoints"].([]interface{})[0].(map[string]interface{})["lb_endpoints"].([]interface{})[0].(map[string]interface{})["endpoint"].(map[string]interface{})
+			socketAddress := endpoint["address"].(map[string]interface{})["socket_address"].(map[string]interface{})
+			require.Equal(tt.ExpCollectorHost, socketAddress["address"])
+			require.Equal(tt.ExpCollectorPort, socketAddress["port_value"])
+		})
+	}
+}
+
+func minimal() *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespaces.DefaultNamespace,
+			Name:      "minimal",
+			Annotations: map[string]string{
+				constants.AnnotationService: "foo",
+			},
+		},
+
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "web",
+				},
+			},
+		},
+	}
+}
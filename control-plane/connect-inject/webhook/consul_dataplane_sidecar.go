@@ -21,6 +21,9 @@ import (
 const (
 	consulDataplaneDNSBindHost = "127.0.0.1"
 	consulDataplaneDNSBindPort = 8600
+
+	// prometheusPortName is the name of the sidecar port that Prometheus scrapes.
+	prometheusPortName = "prometheus"
 )
 
 func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod corev1.Pod, mpi multiPortInfo) (corev1.Container, error) {
@@ -33,7 +36,7 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 	var bearerTokenFile string
 	var saTokenVolumeMount corev1.VolumeMount
 	if w.AuthMethod != "" {
-		saTokenVolumeMount, bearerTokenFile, err = findServiceAccountVolumeMount(pod, mpi.serviceName)
+		saTokenVolumeMount, bearerTokenFile, err = w.findServiceAccountVolumeMount(pod, mpi.serviceName)
 		if err != nil {
 			return corev1.Container{}, err
 		}
@@ -76,7 +79,7 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 
 	container := corev1.Container{
 		Name:      containerName,
-		Image:     w.ImageConsulDataplane,
+		Image:     w.sidecarImage(pod),
 		Resources: resources,
 		// We need to set tmp dir to an ephemeral volume that we're mounting so that
 		// consul-dataplane can write files to it. Otherwise, it wouldn't be able to
@@ -84,7 +87,7 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 		Env: []corev1.EnvVar{
 			{
 				Name:  "TMPDIR",
-				Value: "/consul/connect-inject",
+				Value: injectDir(pod),
 			},
 			{
 				Name: "NODE_NAME",
@@ -96,13 +99,13 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 			},
 			{
 				Name:  "DP_SERVICE_NODE_NAME",
-				Value: "$(NODE_NAME)-virtual",
+				Value: common.ConsulNodeNameFromK8sNode("$(NODE_NAME)", w.ClusterName),
 			},
 		},
 		VolumeMounts: []corev1.VolumeMount{
 			{
 				Name:      volumeName,
-				MountPath: "/consul/connect-inject",
+				MountPath: injectDir(pod),
 			},
 		},
 		Args:           args,
@@ -113,6 +116,48 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 		container.VolumeMounts = append(container.VolumeMounts, saTokenVolumeMount)
 	}
 
+	dogStatsDEnabled, err := w.DogStatsDConfig.Enabled(pod)
+	if err != nil {
+		return corev1.Container{}, fmt.Errorf("unable to determine if dogstatsd is enabled: %w", err)
+	}
+	if dogStatsDEnabled && w.DogStatsDConfig.UsesSocket() {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      dogStatsDSocketVolumeName,
+			MountPath: w.DogStatsDConfig.SocketDir(),
+			ReadOnly:  true,
+		})
+	}
+
+	// Start the graceful shutdown of consul-dataplane from a preStop hook so that the proxy drains
+	// and keeps serving for its shutdown grace period while the pod is removed from its endpoints.
+	// consul-dataplane only serves its lifecycle endpoint on localhost and its image has no shell, so
+	// the hook runs the consul-k8s-control-plane binary that the init container copied into the pod.
+	enablePreStopDrain, err := w.preStopDrainEnabled(pod)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+	if enablePreStopDrain {
+		gracefulPort, err := w.LifecycleConfig.GracefulPort(pod)
+		if err != nil {
+			return corev1.Container{}, fmt.Errorf("unable to determine proxy lifecycle graceful port: %w", err)
+		}
+		if mpi.serviceName != "" {
+			gracefulPort = gracefulPort + mpi.serviceIndex
+		}
+		container.Lifecycle = &corev1.Lifecycle{
+			PreStop: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{
+					Command: []string{
+						injectDirFile(pod, gracefulShutdownBinary),
+						"graceful-shutdown",
+						fmt.Sprintf("-port=%d", gracefulPort),
+						fmt.Sprintf("-path=%s", w.LifecycleConfig.GracefulShutdownPath(pod)),
+					},
+				},
+			},
+		}
+	}
+
 	if useProxyHealthCheck(pod) {
 		// Configure the Readiness Address for the proxy's health check to be the Pod IP.
 		container.Env = append(container.Env, corev1.EnvVar{
@@ -128,6 +173,32 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 		})
 	}
 
+	// Name the port Prometheus scrapes so that it can be selected by PodMonitors.
+	// Multi-port pods run one proxy per service, so the port is only named for
+	// single-port pods.
+	if !multiPort {
+		enableMetrics, err := w.MetricsConfig.EnableMetrics(pod)
+		if err != nil {
+			return corev1.Container{}, fmt.Errorf("unable to determine if metrics are enabled: %w", err)
+		}
+		if enableMetrics {
+			prometheusScrapePort, err := w.MetricsConfig.PrometheusScrapePort(pod)
+			if err != nil {
+				return corev1.Container{}, fmt.Errorf("unable to determine prometheus scrape port: %w", err)
+			}
+			if prometheusScrapePort != "" {
+				port, err := strconv.Atoi(prometheusScrapePort)
+				if err != nil {
+					return corev1.Container{}, fmt.Errorf("unable to parse prometheus scrape port: %w", err)
+				}
+				container.Ports = append(container.Ports, corev1.ContainerPort{
+					Name:          prometheusPortName,
+					ContainerPort: int32(port),
+				})
+			}
+		}
+	}
+
 	// Add any extra VolumeMounts.
 	if userVolMount, ok := pod.Annotations[constants.AnnotationConsulSidecarUserVolumeMount]; ok {
 		var volumeMounts []corev1.VolumeMount
@@ -147,7 +218,9 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 	// skip setting the security context and let OpenShift set it for us.
 	// When transparent proxy is enabled, then consul-dataplane needs to run as our specific user
 	// so that traffic redirection will work.
-	if tproxyEnabled || !w.EnableOpenShift {
+	if isWindowsPod(pod) {
+		container.SecurityContext = windowsSecurityContext()
+	} else if tproxyEnabled || !w.EnableOpenShift {
 		if pod.Spec.SecurityContext != nil {
 			// User container and consul-dataplane container cannot have the same UID.
 			if pod.Spec.SecurityContext.RunAsUser != nil && *pod.Spec.SecurityContext.RunAsUser == sidecarUserAndGroupID {
@@ -158,7 +231,7 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 		// has only injected init containers so all containers defined in pod.Spec.Containers are from the user.
 		for _, c := range pod.Spec.Containers {
 			// User container and consul-dataplane container cannot have the same UID.
-			if c.SecurityContext != nil && c.SecurityContext.RunAsUser != nil && *c.SecurityContext.RunAsUser == sidecarUserAndGroupID && c.Image != w.ImageConsulDataplane {
+			if c.SecurityContext != nil && c.SecurityContext.RunAsUser != nil && *c.SecurityContext.RunAsUser == sidecarUserAndGroupID && c.Image != container.Image {
 				return corev1.Container{}, fmt.Errorf("container %q has runAsUser set to the same UID \"%d\" as consul-dataplane which is not allowed", c.Name, sidecarUserAndGroupID)
 			}
 		}
@@ -174,9 +247,9 @@ func (w *MeshWebhook) consulDataplaneSidecar(namespace corev1.Namespace, pod cor
 }
 
 func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi multiPortInfo, bearerTokenFile string, pod corev1.Pod) ([]string, error) {
-	proxyIDFileName := "/consul/connect-inject/proxyid"
+	proxyIDFileName := injectDirFile(pod, "proxyid")
 	if mpi.serviceName != "" {
-		proxyIDFileName = fmt.Sprintf("/consul/connect-inject/proxyid-%s", mpi.serviceName)
+		proxyIDFileName = injectDirFile(pod, fmt.Sprintf("proxyid-%s", mpi.serviceName))
 	}
 
 	envoyConcurrency := w.DefaultEnvoyProxyConcurrency
@@ -398,6 +471,73 @@ func (w *MeshWebhook) getContainerSidecarArgs(namespace corev1.Namespace, mpi mu
 	return args, nil
 }
 
+// gracefulShutdownBinary is the name of the consul-k8s-control-plane binary that the init container copies
+// into the shared volume so that the sidecar's preStop hook can run it.
+const gracefulShutdownBinary = "consul-k8s-control-plane"
+
+// preStopDrainEnabled returns true if the sidecar should start its graceful shutdown from a preStop hook. The
+// shutdown grace period is only passed to consul-dataplane when proxy lifecycle management is enabled, so the
+// hook is only added then. Windows pods never get the hook since the init container can only copy the Linux
+// binary into the pod.
+func (w *MeshWebhook) preStopDrainEnabled(pod corev1.Pod) (bool, error) {
+	if isWindowsPod(pod) {
+		return false, nil
+	}
+	enableProxyLifecycle, err := w.LifecycleConfig.EnableProxyLifecycle(pod)
+	if err != nil {
+		return false, fmt.Errorf("unable to determine if proxy lifecycle management is enabled: %w", err)
+	}
+	if !enableProxyLifecycle {
+		return false, nil
+	}
+	enablePreStopDrain, err := w.LifecycleConfig.EnablePreStopDrain(pod)
+	if err != nil {
+		return false, fmt.Errorf("unable to determine if proxy lifecycle preStop drain is enabled: %w", err)
+	}
+	return enablePreStopDrain, nil
+}
+
+// alignTerminationGracePeriod raises the pod's terminationGracePeriodSeconds to the sidecar's shutdown grace period
+// when proxy lifecycle management is enabled, so that the kubelet doesn't kill consul-dataplane while it is still
+// draining connections. A grace period that is already long enough is left unchanged.
+func (w *MeshWebhook) alignTerminationGracePeriod(pod *corev1.Pod) error {
+	enableProxyLifecycle, err := w.LifecycleConfig.EnableProxyLifecycle(*pod)
+	if err != nil {
+		return fmt.Errorf("unable to determine if proxy lifecycle management is enabled: %w", err)
+	}
+	if !enableProxyLifecycle {
+		return nil
+	}
+	shutdownGracePeriodSeconds, err := w.LifecycleConfig.ShutdownGracePeriodSeconds(*pod)
+	if err != nil {
+		return fmt.Errorf("unable to determine proxy lifecycle shutdown grace period: %w", err)
+	}
+
+	terminationGracePeriodSeconds := int64(corev1.DefaultTerminationGracePeriodSeconds)
+	if pod.Spec.TerminationGracePeriodSeconds != nil {
+		terminationGracePeriodSeconds = *pod.Spec.TerminationGracePeriodSeconds
+	}
+	if terminationGracePeriodSeconds < int64(shutdownGracePeriodSeconds) {
+		pod.Spec.TerminationGracePeriodSeconds = pointer.Int64(int64(shutdownGracePeriodSeconds))
+	}
+	return nil
+}
+
+// sidecarImage returns the consul-dataplane image for the pod's sidecar, which is the default image
+// unless it's been overridden via the sidecar proxy image or consul-dataplane image annotations. Empty
+// annotations are ignored.
+func (w *MeshWebhook) sidecarImage(pod corev1.Pod) string {
+	for _, annotation := range []string{constants.AnnotationSidecarProxyImage, constants.AnnotationConsulDataplaneImage} {
+		if image := strings.TrimSpace(pod.Annotations[annotation]); image != "" {
+			return image
+		}
+	}
+	if isWindowsPod(pod) {
+		return w.ImageConsulDataplaneWindows
+	}
+	return w.ImageConsulDataplane
+}
+
 func (w *MeshWebhook) sidecarResources(pod corev1.Pod) (corev1.ResourceRequirements, error) {
 	resources := corev1.ResourceRequirements{
 		Limits:   corev1.ResourceList{},
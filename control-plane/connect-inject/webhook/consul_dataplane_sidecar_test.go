@@ -9,8 +9,11 @@ import (
 	"strings"
 	"testing"
 
+	logrtest "github.com/go-logr/logr/testr"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/dogstatsd"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/lifecycle"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
@@ -228,6 +231,72 @@ func TestHandlerConsulDataplaneSidecar(t *testing.T) {
 	}
 }
 
+func TestHandlerConsulDataplaneSidecar_ProjectedServiceAccountToken(t *testing.T) {
+	w := &MeshWebhook{
+		ConsulAddress:                      "1.1.1.1",
+		ConsulConfig:                       &consul.Config{GRPCPort: 8502},
+		AuthMethod:                         "test-auth-method",
+		EnableProjectedServiceAccountToken: true,
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-pod",
+			Annotations: map[string]string{
+				constants.AnnotationService: "foo",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "web",
+				},
+			},
+		},
+	}
+
+	container, err := w.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+	require.NoError(t, err)
+	require.Contains(t, container.Args, "-login-bearer-token-path=/consul/service-account-token/token")
+	require.Equal(t, []corev1.VolumeMount{
+		{
+			Name:      volumeName,
+			MountPath: "/consul/connect-inject",
+		},
+		{
+			Name:      "consul-service-account-token",
+			ReadOnly:  true,
+			MountPath: "/consul/service-account-token",
+		},
+	}, container.VolumeMounts)
+}
+
+func TestHandlerConsulDataplaneSidecar_ClusterName(t *testing.T) {
+	w := &MeshWebhook{
+		ConsulAddress: "1.1.1.1",
+		ConsulConfig:  &consul.Config{GRPCPort: 8502},
+		ClusterName:   "east",
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-pod",
+			Annotations: map[string]string{
+				constants.AnnotationService: "foo",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "web",
+				},
+			},
+		},
+	}
+
+	container, err := w.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+	require.NoError(t, err)
+	require.Contains(t, container.Env, corev1.EnvVar{Name: "DP_SERVICE_NODE_NAME", Value: "$(NODE_NAME)-east-virtual"})
+}
+
 func TestHandlerConsulDataplaneSidecar_Concurrency(t *testing.T) {
 	cases := map[string]struct {
 		annotations map[string]string
@@ -292,6 +361,81 @@ func TestHandlerConsulDataplaneSidecar_Concurrency(t *testing.T) {
 }
 
 // Test that we pass the dns proxy flag to dataplane correctly.
+func TestHandlerConsulDataplaneSidecar_ImageOverride(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		expImage    string
+	}{
+		"no annotation": {
+			annotations: map[string]string{
+				constants.AnnotationService: "foo",
+			},
+			expImage: "hashicorp/consul-dataplane:1.1.0",
+		},
+		"annotation override": {
+			annotations: map[string]string{
+				constants.AnnotationService:              "foo",
+				constants.AnnotationConsulDataplaneImage: "hashicorp/consul-dataplane:1.2.0-rc1",
+			},
+			expImage: "hashicorp/consul-dataplane:1.2.0-rc1",
+		},
+		"empty annotation": {
+			annotations: map[string]string{
+				constants.AnnotationService:              "foo",
+				constants.AnnotationConsulDataplaneImage: " ",
+			},
+			expImage: "hashicorp/consul-dataplane:1.1.0",
+		},
+		"sidecar proxy image annotation override": {
+			annotations: map[string]string{
+				constants.AnnotationService:           "foo",
+				constants.AnnotationSidecarProxyImage: "hashicorp/consul-dataplane:1.2.0-rc1",
+			},
+			expImage: "hashicorp/consul-dataplane:1.2.0-rc1",
+		},
+		"sidecar proxy image annotation takes precedence": {
+			annotations: map[string]string{
+				constants.AnnotationService:              "foo",
+				constants.AnnotationConsulDataplaneImage: "hashicorp/consul-dataplane:1.0.0",
+				constants.AnnotationSidecarProxyImage:    "hashicorp/consul-dataplane:1.2.0-rc1",
+			},
+			expImage: "hashicorp/consul-dataplane:1.2.0-rc1",
+		},
+		"empty sidecar proxy image annotation": {
+			annotations: map[string]string{
+				constants.AnnotationService:              "foo",
+				constants.AnnotationConsulDataplaneImage: "hashicorp/consul-dataplane:1.0.0",
+				constants.AnnotationSidecarProxyImage:    " ",
+			},
+			expImage: "hashicorp/consul-dataplane:1.0.0",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := MeshWebhook{
+				ConsulConfig:         &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+				ImageConsulDataplane: "hashicorp/consul-dataplane:1.1.0",
+			}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: c.annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "web",
+						},
+					},
+				},
+			}
+			container, err := h.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+			require.NoError(t, err)
+			require.Equal(t, c.expImage, container.Image)
+		})
+	}
+}
+
 func TestHandlerConsulDataplaneSidecar_DNSProxy(t *testing.T) {
 
 	// We only want the flag passed when DNS and tproxy are both enabled. DNS/tproxy can
@@ -1300,6 +1444,87 @@ func TestHandlerConsulDataplaneSidecar_Metrics(t *testing.T) {
 	}
 }
 
+func TestHandlerConsulDataplaneSidecar_PrometheusPort(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		mpi         multiPortInfo
+		expPorts    []corev1.ContainerPort
+	}{
+		"metrics disabled": {
+			annotations: map[string]string{},
+		},
+		"metrics enabled": {
+			annotations: map[string]string{
+				constants.AnnotationEnableMetrics: "true",
+			},
+			expPorts: []corev1.ContainerPort{{Name: "prometheus", ContainerPort: 20200}},
+		},
+		"metrics enabled with scrape port annotation": {
+			annotations: map[string]string{
+				constants.AnnotationEnableMetrics:        "true",
+				constants.AnnotationPrometheusScrapePort: "22222",
+			},
+			expPorts: []corev1.ContainerPort{{Name: "prometheus", ContainerPort: 22222}},
+		},
+		"metrics enabled in a multi-port pod": {
+			annotations: map[string]string{
+				constants.AnnotationService:       "web,web-admin",
+				constants.AnnotationEnableMetrics: "true",
+			},
+			mpi: multiPortInfo{serviceIndex: 0, serviceName: "web"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			h := MeshWebhook{
+				ConsulConfig: &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+				MetricsConfig: metrics.Config{
+					DefaultPrometheusScrapePort: "20200",
+					DefaultPrometheusScrapePath: "/metrics",
+				},
+			}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-pod",
+					Annotations: c.annotations,
+				},
+			}
+			container, err := h.consulDataplaneSidecar(testNS, pod, c.mpi)
+			require.NoError(t, err)
+			require.Equal(t, c.expPorts, container.Ports)
+		})
+	}
+}
+
+func TestHandlerConsulDataplaneSidecar_DogStatsD(t *testing.T) {
+	h := MeshWebhook{
+		ConsulConfig:    &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+		DogStatsDConfig: dogstatsd.Config{SocketPath: "/run/datadog/dsd.socket"},
+	}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				constants.AnnotationEnvoyDogStatsDEnabled: "true",
+			},
+		},
+	}
+	container, err := h.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+	require.NoError(t, err)
+	require.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+		Name:      "consul-dogstatsd-socket",
+		MountPath: "/run/datadog",
+		ReadOnly:  true,
+	})
+
+	pod.Annotations[constants.AnnotationEnvoyDogStatsDEnabled] = "false"
+	container, err = h.consulDataplaneSidecar(testNS, pod, multiPortInfo{})
+	require.NoError(t, err)
+	for _, mount := range container.VolumeMounts {
+		require.NotEqual(t, "consul-dogstatsd-socket", mount.Name)
+	}
+}
+
 func TestHandlerConsulDataplaneSidecar_Lifecycle(t *testing.T) {
 	gracefulShutdownSeconds := 10
 	gracefulPort := "20307"
@@ -1450,6 +1675,184 @@ func TestHandlerConsulDataplaneSidecar_Lifecycle(t *testing.T) {
 	}
 }
 
+func TestHandlerConsulDataplaneSidecar_PreStopDrain(t *testing.T) {
+	cases := map[string]struct {
+		config       lifecycle.Config
+		annotations  map[string]string
+		nodeSelector map[string]string
+		mpi          multiPortInfo
+		expLifecycle *corev1.Lifecycle
+	}{
+		"not set": {
+			config: lifecycle.Config{DefaultEnableProxyLifecycle: true},
+		},
+		"set via default": {
+			config: lifecycle.Config{
+				DefaultEnableProxyLifecycle: true,
+				DefaultEnablePreStopDrain:   true,
+			},
+			expLifecycle: &corev1.Lifecycle{
+				PreStop: &corev1.LifecycleHandler{
+					Exec: &corev1.ExecAction{
+						Command: []string{
+							"/consul/connect-inject/consul-k8s-control-plane",
+							"graceful-shutdown",
+							"-port=20600",
+							"-path=/graceful_shutdown",
+						},
+					},
+				},
+			},
+		},
+		"set via annotation with a custom port and path": {
+			config: lifecycle.Config{DefaultEnableProxyLifecycle: true},
+			annotations: map[string]string{
+				constants.AnnotationEnableSidecarProxyLifecyclePreStopDrain:   "true",
+				constants.AnnotationSidecarProxyLifecycleGracefulPort:         "20700",
+				constants.AnnotationSidecarProxyLifecycleGracefulShutdownPath: "/shutdown",
+			},
+			expLifecycle: &corev1.Lifecycle{
+				PreStop: &corev1.LifecycleHandler{
+					Exec: &corev1.ExecAction{
+						Command: []string{
+							"/consul/connect-inject/consul-k8s-control-plane",
+							"graceful-shutdown",
+							"-port=20700",
+							"-path=/shutdown",
+						},
+					},
+				},
+			},
+		},
+		"multiport uses the port of the service": {
+			config: lifecycle.Config{
+				DefaultEnableProxyLifecycle: true,
+				DefaultEnablePreStopDrain:   true,
+			},
+			mpi: multiPortInfo{serviceName: "web-admin", serviceIndex: 1},
+			expLifecycle: &corev1.Lifecycle{
+				PreStop: &corev1.LifecycleHandler{
+					Exec: &corev1.ExecAction{
+						Command: []string{
+							"/consul/connect-inject/consul-k8s-control-plane",
+							"graceful-shutdown",
+							"-port=20601",
+							"-path=/graceful_shutdown",
+						},
+					},
+				},
+			},
+		},
+		"annotation disables default": {
+			config: lifecycle.Config{
+				DefaultEnableProxyLifecycle: true,
+				DefaultEnablePreStopDrain:   true,
+			},
+			annotations: map[string]string{
+				constants.AnnotationEnableSidecarProxyLifecyclePreStopDrain: "false",
+			},
+		},
+		"not set without lifecycle management": {
+			config: lifecycle.Config{DefaultEnablePreStopDrain: true},
+		},
+		"not set on windows pods": {
+			config: lifecycle.Config{
+				DefaultEnableProxyLifecycle: true,
+				DefaultEnablePreStopDrain:   true,
+			},
+			nodeSelector: map[string]string{corev1.LabelOSStable: "windows"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				ConsulConfig:    &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+				LifecycleConfig: c.config,
+			}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: c.annotations,
+				},
+				Spec: corev1.PodSpec{
+					NodeSelector: c.nodeSelector,
+					Containers: []corev1.Container{
+						{
+							Name: "web",
+						},
+					},
+				},
+			}
+			container, err := w.consulDataplaneSidecar(testNS, pod, c.mpi)
+			require.NoError(t, err)
+			require.Equal(t, c.expLifecycle, container.Lifecycle)
+		})
+	}
+}
+
+func TestAlignTerminationGracePeriod(t *testing.T) {
+	cases := map[string]struct {
+		config      lifecycle.Config
+		annotations map[string]string
+		podValue    *int64
+		expValue    *int64
+	}{
+		"lifecycle disabled": {
+			config: lifecycle.Config{DefaultShutdownGracePeriodSeconds: 60},
+		},
+		"shutdown grace period within the default": {
+			config: lifecycle.Config{
+				DefaultEnableProxyLifecycle:       true,
+				DefaultShutdownGracePeriodSeconds: 30,
+			},
+		},
+		"shutdown grace period exceeds the default": {
+			config: lifecycle.Config{
+				DefaultEnableProxyLifecycle:       true,
+				DefaultShutdownGracePeriodSeconds: 45,
+			},
+			expValue: pointer.Int64(45),
+		},
+		"shutdown grace period set via annotation": {
+			config: lifecycle.Config{DefaultEnableProxyLifecycle: true},
+			annotations: map[string]string{
+				constants.AnnotationSidecarProxyLifecycleShutdownGracePeriodSeconds: "60",
+			},
+			expValue: pointer.Int64(60),
+		},
+		"shorter pod value is raised": {
+			config: lifecycle.Config{
+				DefaultEnableProxyLifecycle:       true,
+				DefaultShutdownGracePeriodSeconds: 20,
+			},
+			podValue: pointer.Int64(10),
+			expValue: pointer.Int64(20),
+		},
+		"longer pod value is kept": {
+			config: lifecycle.Config{
+				DefaultEnableProxyLifecycle:       true,
+				DefaultShutdownGracePeriodSeconds: 20,
+			},
+			podValue: pointer.Int64(120),
+			expValue: pointer.Int64(120),
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{LifecycleConfig: c.config, Log: logrtest.New(t)}
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: c.annotations,
+				},
+				Spec: corev1.PodSpec{
+					TerminationGracePeriodSeconds: c.podValue,
+				},
+			}
+			require.NoError(t, w.alignTerminationGracePeriod(&pod))
+			require.Equal(t, c.expValue, pod.Spec.TerminationGracePeriodSeconds)
+		})
+	}
+}
+
 // boolPtr returns pointer to b.
 func boolPtr(b bool) *bool {
 	return &b
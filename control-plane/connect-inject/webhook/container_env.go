@@ -13,14 +13,58 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
+// upstreamLocalBindAddress is the address Consul binds upstream listeners to. It is also reachable in IPv6-only
+// pods since the loopback interface always has the IPv4 loopback address.
+const upstreamLocalBindAddress = "127.0.0.1"
+
+// podUpstreams returns the raw upstreams of the pod from the upstreams annotation and the per-service upstreams
+// annotations of multiport pods.
+func podUpstreams(pod corev1.Pod) []string {
+	var upstreams []string
+	if raw, ok := pod.Annotations[constants.AnnotationUpstreams]; ok && raw != "" {
+		upstreams = append(upstreams, strings.Split(raw, ",")...)
+	}
+	// Multiport pods may also set upstreams per service.
+	for _, svc := range strings.Split(pod.Annotations[constants.AnnotationService], ",") {
+		if raw, ok := pod.Annotations[constants.AnnotationServiceUpstreamsPrefix+svc]; ok && svc != "" && raw != "" {
+			upstreams = append(upstreams, strings.Split(raw, ",")...)
+		}
+	}
+	return upstreams
+}
+
+// validateUpstreams returns an error if an upstream of the pod can't be rendered into an upstream listener, i.e.
+// it has no service or prepared query name, or its port isn't a valid port or named port of the pod.
+func validateUpstreams(pod corev1.Pod) error {
+	for _, raw := range podUpstreams(pod) {
+		parts := strings.SplitN(strings.TrimSpace(raw), ":", 3)
+		name, port := strings.TrimSpace(parts[0]), ""
+		if name == "prepared_query" {
+			if len(parts) < 3 {
+				return fmt.Errorf("upstream %q must be in the format prepared_query:[query name]:[port]", raw)
+			}
+			name, port = strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
+		} else if len(parts) > 1 {
+			port = strings.TrimSpace(parts[1])
+		}
+		if name == "" {
+			return fmt.Errorf("upstream %q has no service name", raw)
+		}
+		if value, err := common.PortValue(pod, port); err != nil || value < 1 || value > 65535 {
+			return fmt.Errorf("upstream %q has an invalid port %q", raw, port)
+		}
+	}
+	return nil
+}
+
 func (w *MeshWebhook) containerEnvVars(pod corev1.Pod) []corev1.EnvVar {
-	raw, ok := pod.Annotations[constants.AnnotationUpstreams]
-	if !ok || raw == "" {
+	upstreams := podUpstreams(pod)
+	if len(upstreams) == 0 {
 		return []corev1.EnvVar{}
 	}
 
 	var result []corev1.EnvVar
-	for _, raw := range strings.Split(raw, ",") {
+	for _, raw := range upstreams {
 		parts := strings.SplitN(raw, ":", 3)
 		port, _ := common.PortValue(pod, strings.TrimSpace(parts[1]))
 		if port > 0 {
@@ -30,7 +74,7 @@ func (w *MeshWebhook) containerEnvVars(pod corev1.Pod) []corev1.EnvVar {
 
 			result = append(result, corev1.EnvVar{
 				Name:  fmt.Sprintf("%s_CONNECT_SERVICE_HOST", name),
-				Value: "127.0.0.1",
+				Value: upstreamLocalBindAddress,
 			}, corev1.EnvVar{
 				Name:  fmt.Sprintf("%s_CONNECT_SERVICE_PORT", name),
 				Value: portStr,
@@ -54,3 +54,98 @@ func TestContainerEnvVars(t *testing.T) {
 		})
 	}
 }
+
+func TestContainerEnvVars_MultiPort(t *testing.T) {
+	var w MeshWebhook
+	envVars := w.containerEnvVars(corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				constants.AnnotationService:                              "web,web-admin",
+				constants.AnnotationUpstreams:                            "static-server:7890",
+				constants.AnnotationServiceUpstreamsPrefix + "web-admin": "db:5432",
+			},
+		},
+	})
+
+	require.ElementsMatch(t, envVars, []corev1.EnvVar{
+		{
+			Name:  "STATIC_SERVER_CONNECT_SERVICE_HOST",
+			Value: "127.0.0.1",
+		}, {
+			Name:  "STATIC_SERVER_CONNECT_SERVICE_PORT",
+			Value: "7890",
+		}, {
+			Name:  "DB_CONNECT_SERVICE_HOST",
+			Value: "127.0.0.1",
+		}, {
+			Name:  "DB_CONNECT_SERVICE_PORT",
+			Value: "5432",
+		},
+	})
+}
+
+func TestValidateUpstreams(t *testing.T) {
+	cases := map[string]struct {
+		upstreams string
+		expErr    string
+	}{
+		"service upstreams": {
+			upstreams: "static-server:7890:dc1, db.svc.ns1.ns.peer1.peer:1234",
+		},
+		"prepared query upstream": {
+			upstreams: "prepared_query:query-name:1234",
+		},
+		"named port": {
+			upstreams: "static-server:http",
+		},
+		"missing port": {
+			upstreams: "static-server",
+			expErr:    `upstream "static-server" has an invalid port ""`,
+		},
+		"port out of range": {
+			upstreams: "static-server:70000",
+			expErr:    `upstream "static-server:70000" has an invalid port "70000"`,
+		},
+		"unknown named port": {
+			upstreams: "static-server:admin",
+			expErr:    `upstream "static-server:admin" has an invalid port "admin"`,
+		},
+		"empty entry": {
+			upstreams: "static-server:7890,",
+			expErr:    `upstream "" has no service name`,
+		},
+		"IPv6 address": {
+			upstreams: "[::1]:7890",
+			expErr:    `upstream "[::1]:7890" has an invalid port ""`,
+		},
+		"prepared query without port": {
+			upstreams: "prepared_query:query-name",
+			expErr:    `upstream "prepared_query:query-name" must be in the format prepared_query:[query name]:[port]`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := validateUpstreams(corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						constants.AnnotationService:   "foo",
+						constants.AnnotationUpstreams: c.upstreams,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "foo",
+							Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+						},
+					},
+				},
+			})
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ package webhook
 import (
 	"bytes"
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 	"text/template"
@@ -33,6 +34,14 @@ type initContainerCommandData struct {
 	// of the services on the multi port Pod.
 	MultiPort bool
 
+	// ProxyIDFile is the file connect-init writes the proxy ID to. It is left empty for single port
+	// Linux pods so that connect-init uses its default.
+	ProxyIDFile string
+
+	// GracefulShutdownBinary is the path in the shared volume that the consul-k8s-control-plane binary is
+	// copied to so that the sidecar's preStop hook can run it. It is left empty when the hook is disabled.
+	GracefulShutdownBinary string
+
 	// Log settings for the connect-init command.
 	LogLevel string
 	LogJSON  bool
@@ -60,13 +69,17 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 	volMounts := []corev1.VolumeMount{
 		{
 			Name:      volumeName,
-			MountPath: "/consul/connect-inject",
+			MountPath: injectDir(pod),
 		},
 	}
 
 	if multiPort {
 		data.ServiceName = mpi.serviceName
+		data.ProxyIDFile = injectDirFile(pod, fmt.Sprintf("proxyid-%s", mpi.serviceName))
 	} else {
+		if isWindowsPod(pod) {
+			data.ProxyIDFile = injectDirFile(pod, "proxyid")
+		}
 		data.ServiceName = pod.Annotations[constants.AnnotationService]
 	}
 	var bearerTokenFile string
@@ -80,7 +93,7 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 		}
 		// Extract the service account token's volume mount
 		var saTokenVolumeMount corev1.VolumeMount
-		saTokenVolumeMount, bearerTokenFile, err = findServiceAccountVolumeMount(pod, mpi.serviceName)
+		saTokenVolumeMount, bearerTokenFile, err = w.findServiceAccountVolumeMount(pod, mpi.serviceName)
 		if err != nil {
 			return corev1.Container{}, err
 		}
@@ -89,11 +102,16 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 		volMounts = append(volMounts, saTokenVolumeMount)
 	}
 
+	enablePreStopDrain, err := w.preStopDrainEnabled(pod)
+	if err != nil {
+		return corev1.Container{}, err
+	}
+	if enablePreStopDrain {
+		data.GracefulShutdownBinary = injectDirFile(pod, gracefulShutdownBinary)
+	}
+
 	// Render the command
-	var buf bytes.Buffer
-	tpl := template.Must(template.New("root").Parse(strings.TrimSpace(
-		initContainerCommandTpl)))
-	err = tpl.Execute(&buf, &data)
+	command, err := renderInitContainerCommand(data)
 	if err != nil {
 		return corev1.Container{}, err
 	}
@@ -144,12 +162,12 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 			},
 			{
 				Name:  "CONSUL_NODE_NAME",
-				Value: "$(NODE_NAME)-virtual",
+				Value: common.ConsulNodeNameFromK8sNode("$(NODE_NAME)", w.ClusterName),
 			},
 		},
 		Resources:    w.InitContainerResources,
 		VolumeMounts: volMounts,
-		Command:      []string{"/bin/sh", "-ec", buf.String()},
+		Command:      []string{"/bin/sh", "-ec", command},
 	}
 
 	if w.TLSEnabled {
@@ -223,6 +241,12 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 			})
 	}
 
+	if isWindowsPod(pod) {
+		container.Image = w.ImageConsulK8SWindows
+		container.Command = windowsInitContainerCommand(data)
+		container.SecurityContext = windowsSecurityContext()
+	}
+
 	if tproxyEnabled {
 		if !w.EnableCNI {
 			// Set redirect traffic config for the container so that we can apply iptables rules.
@@ -235,6 +259,17 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 					Name:  "CONSUL_REDIRECT_TRAFFIC_CONFIG",
 					Value: redirectTrafficConfig,
 				})
+			ipv6Enabled, err := common.TransparentProxyIPv6Enabled(pod, w.TProxyEnableIPv6)
+			if err != nil {
+				return corev1.Container{}, err
+			}
+			if ipv6Enabled {
+				container.Env = append(container.Env,
+					corev1.EnvVar{
+						Name:  "CONSUL_REDIRECT_TRAFFIC_IPV6",
+						Value: "true",
+					})
+			}
 
 			// Running consul connect redirect-traffic with iptables
 			// requires both being a root user and having NET_ADMIN capability.
@@ -249,6 +284,18 @@ func (w *MeshWebhook) containerInit(namespace corev1.Namespace, pod corev1.Pod,
 				},
 			}
 		} else {
+			if w.CNIRequireRedirection {
+				container.Env = append(container.Env,
+					corev1.EnvVar{
+						Name:  "CONSUL_CNI_STATUS_FILE",
+						Value: path.Join(cniStatusMountPath, cniStatusFile),
+					})
+				container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+					Name:      cniStatusVolumeName,
+					MountPath: cniStatusMountPath,
+					ReadOnly:  true,
+				})
+			}
 			container.SecurityContext = &corev1.SecurityContext{
 				RunAsUser:    pointer.Int64(initContainersUserAndGroupID),
 				RunAsGroup:   pointer.Int64(initContainersUserAndGroupID),
@@ -295,15 +342,34 @@ func consulDNSEnabled(namespace corev1.Namespace, pod corev1.Pod, globalDNSEnabl
 func splitCommaSeparatedItemsFromAnnotation(annotation string, pod corev1.Pod) []string {
 	var items []string
 	if raw, ok := pod.Annotations[annotation]; ok {
-		items = append(items, strings.Split(raw, ",")...)
+		for _, item := range strings.Split(raw, ",") {
+			// Tolerate whitespace around items, e.g. "10.0.0.0/8, 192.168.0.0/16".
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
 	}
 
 	return items
 }
 
+// renderInitContainerCommand renders initContainerCommandTpl for the shell of Linux images.
+func renderInitContainerCommand(data initContainerCommandData) (string, error) {
+	var buf bytes.Buffer
+	tpl := template.Must(template.New("root").Parse(strings.TrimSpace(
+		initContainerCommandTpl)))
+	if err := tpl.Execute(&buf, &data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
 // initContainerCommandTpl is the template for the command executed by
 // the init container.
 const initContainerCommandTpl = `
+{{- if .GracefulShutdownBinary -}}
+cp "$(command -v consul-k8s-control-plane)" {{ .GracefulShutdownBinary }}
+{{ end -}}
 consul-k8s-control-plane connect-init -pod-name=${POD_NAME} -pod-namespace=${POD_NAMESPACE} \
   -log-level={{ .LogLevel }} \
   -log-json={{ .LogJSON }} \
@@ -313,9 +379,11 @@ consul-k8s-control-plane connect-init -pod-name=${POD_NAME} -pod-namespace=${POD
   {{- end }}
   {{- if .MultiPort }}
   -multiport=true \
-  -proxy-id-file=/consul/connect-inject/proxyid-{{ .ServiceName }} \
-  {{- if not .AuthMethod }}
-  -service-name="{{ .ServiceName }}" \
   {{- end }}
+  {{- if .ProxyIDFile }}
+  -proxy-id-file={{ .ProxyIDFile }} \
+  {{- end }}
+  {{- if and .MultiPort (not .AuthMethod) }}
+  -service-name="{{ .ServiceName }}" \
   {{- end }}
 `
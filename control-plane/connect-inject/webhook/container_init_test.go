@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/lifecycle"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 	"github.com/stretchr/testify/require"
@@ -300,6 +301,154 @@ func TestHandlerContainerInit_transparentProxy(t *testing.T) {
 	}
 }
 
+func TestHandlerContainerInit_cniRequireRedirection(t *testing.T) {
+	cases := map[string]struct {
+		requireRedirection bool
+		annotations        map[string]string
+		expStatusFile      bool
+	}{
+		"not required": {},
+		"required": {
+			requireRedirection: true,
+			expStatusFile:      true,
+		},
+		"required, tproxy disabled on the pod": {
+			requireRedirection: true,
+			annotations:        map[string]string{constants.KeyTransparentProxy: "false"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				EnableTransparentProxy: true,
+				EnableCNI:              true,
+				CNIRequireRedirection:  c.requireRedirection,
+				ConsulConfig:           &consul.Config{HTTPPort: 8500},
+			}
+			pod := minimal()
+			for k, v := range c.annotations {
+				pod.Annotations[k] = v
+			}
+			container, err := w.containerInit(testNS, *pod, multiPortInfo{})
+			require.NoError(t, err)
+
+			var statusFile string
+			for _, ev := range container.Env {
+				if ev.Name == "CONSUL_CNI_STATUS_FILE" {
+					statusFile = ev.Value
+				}
+			}
+			var statusMount *corev1.VolumeMount
+			for i, m := range container.VolumeMounts {
+				if m.Name == cniStatusVolumeName {
+					statusMount = &container.VolumeMounts[i]
+				}
+			}
+			if !c.expStatusFile {
+				require.Empty(t, statusFile)
+				require.Nil(t, statusMount)
+				return
+			}
+			require.Equal(t, "/consul/cni-status/status", statusFile)
+			require.Equal(t, &corev1.VolumeMount{Name: cniStatusVolumeName, MountPath: "/consul/cni-status", ReadOnly: true}, statusMount)
+			// The init container still runs unprivileged since the CNI plugin applies the rules.
+			require.False(t, *container.SecurityContext.Privileged)
+		})
+	}
+}
+
+func TestHandlerContainerInit_preStopDrain(t *testing.T) {
+	cases := map[string]struct {
+		config  lifecycle.Config
+		expCopy bool
+	}{
+		"disabled": {
+			config: lifecycle.Config{DefaultEnableProxyLifecycle: true},
+		},
+		"enabled": {
+			config: lifecycle.Config{
+				DefaultEnableProxyLifecycle: true,
+				DefaultEnablePreStopDrain:   true,
+			},
+			expCopy: true,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				ConsulConfig:    &consul.Config{HTTPPort: 8500},
+				LifecycleConfig: c.config,
+			}
+			container, err := w.containerInit(testNS, *minimal(), multiPortInfo{})
+			require.NoError(t, err)
+			command := container.Command[2]
+			if !c.expCopy {
+				require.True(t, strings.HasPrefix(command, "consul-k8s-control-plane connect-init"), command)
+				return
+			}
+			require.True(t, strings.HasPrefix(command, `cp "$(command -v consul-k8s-control-plane)" /consul/connect-inject/consul-k8s-control-plane
+consul-k8s-control-plane connect-init`), command)
+		})
+	}
+}
+
+func TestCNIStatusVolume(t *testing.T) {
+	w := MeshWebhook{}
+	volume := w.cniStatusVolume()
+	require.Equal(t, cniStatusVolumeName, volume.Name)
+	require.Equal(t, []corev1.DownwardAPIVolumeFile{
+		{
+			Path:     "status",
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.annotations['consul.hashicorp.com/transparent-proxy-status']"},
+		},
+	}, volume.DownwardAPI.Items)
+}
+
+func TestHandlerContainerInit_transparentProxyIPv6(t *testing.T) {
+	cases := map[string]struct {
+		globalEnabled bool
+		annotations   map[string]string
+		expEnabled    bool
+	}{
+		"disabled by default": {},
+		"enabled globally": {
+			globalEnabled: true,
+			expEnabled:    true,
+		},
+		"enabled via annotation": {
+			annotations: map[string]string{constants.AnnotationTransparentProxyIPv6: "true"},
+			expEnabled:  true,
+		},
+		"disabled via annotation": {
+			globalEnabled: true,
+			annotations:   map[string]string{constants.AnnotationTransparentProxyIPv6: "false"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				EnableTransparentProxy: true,
+				TProxyEnableIPv6:       c.globalEnabled,
+				ConsulConfig:           &consul.Config{HTTPPort: 8500},
+			}
+			pod := minimal()
+			pod.Annotations = c.annotations
+
+			container, err := w.containerInit(testNS, *pod, multiPortInfo{})
+			require.NoError(t, err)
+
+			ipv6EnvVarFound := false
+			for _, ev := range container.Env {
+				if ev.Name == "CONSUL_REDIRECT_TRAFFIC_IPV6" {
+					ipv6EnvVarFound = true
+					require.Equal(t, "true", ev.Value)
+				}
+			}
+			require.Equal(t, c.expEnabled, ipv6EnvVarFound)
+		})
+	}
+}
+
 func TestHandlerContainerInit_namespacesAndPartitionsEnabled(t *testing.T) {
 	minimal := func() *corev1.Pod {
 		return &corev1.Pod{
@@ -935,6 +1084,55 @@ func TestHandlerContainerInit_Resources(t *testing.T) {
 	}, container.Resources)
 }
 
+func TestHandlerContainerInit_ProjectedServiceAccountToken(t *testing.T) {
+	w := MeshWebhook{
+		AuthMethod:                         "test-auth-method",
+		EnableProjectedServiceAccountToken: true,
+		ConsulConfig:                       &consul.Config{HTTPPort: 8500, APITimeout: 5 * time.Second},
+	}
+	// The pod doesn't need the automounted service account token.
+	pod := minimal()
+	container, err := w.containerInit(testNS, *pod, multiPortInfo{})
+	require.NoError(t, err)
+	require.Contains(t, container.VolumeMounts, corev1.VolumeMount{
+		Name:      "consul-service-account-token",
+		ReadOnly:  true,
+		MountPath: "/consul/service-account-token",
+	})
+	require.Contains(t, container.Env, corev1.EnvVar{
+		Name:  "CONSUL_LOGIN_BEARER_TOKEN_FILE",
+		Value: "/consul/service-account-token/token",
+	})
+}
+
+func TestHandlerServiceAccountTokenVolume(t *testing.T) {
+	w := MeshWebhook{
+		ServiceAccountTokenAudience:          "consul",
+		ServiceAccountTokenExpirationSeconds: 600,
+	}
+	expirationSeconds := int64(600)
+	require.Equal(t, corev1.Volume{
+		Name: "consul-service-account-token",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+							Audience:          "consul",
+							ExpirationSeconds: &expirationSeconds,
+							Path:              "token",
+						},
+					},
+				},
+			},
+		},
+	}, w.serviceAccountTokenVolume())
+
+	// The expiration defaults to the Kubernetes default.
+	w = MeshWebhook{}
+	require.Nil(t, w.serviceAccountTokenVolume().Projected.Sources[0].ServiceAccountToken.ExpirationSeconds)
+}
+
 var testNS = corev1.Namespace{
 	ObjectMeta: metav1.ObjectMeta{
 		Name:   k8sNamespace,
@@ -4,6 +4,9 @@
 package webhook
 
 import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -11,6 +14,31 @@ import (
 // Consul Connect injection data.
 const volumeName = "consul-connect-inject-data"
 
+const (
+	// serviceAccountTokenVolumeName is the name of the volume with the
+	// projected service account token used to log in with the auth method.
+	serviceAccountTokenVolumeName = "consul-service-account-token"
+
+	// serviceAccountTokenMountPath is where the projected service account
+	// token volume is mounted.
+	serviceAccountTokenMountPath = "/consul/service-account-token"
+
+	// dogStatsDSocketVolumeName is the name of the volume with the DogStatsD
+	// socket of the Datadog agent on the node.
+	dogStatsDSocketVolumeName = "consul-dogstatsd-socket"
+
+	// cniStatusVolumeName is the name of the volume with the status of the
+	// traffic redirection applied by the CNI plugin.
+	cniStatusVolumeName = "consul-cni-status"
+
+	// cniStatusMountPath is where the CNI status volume is mounted.
+	cniStatusMountPath = "/consul/cni-status"
+
+	// cniStatusFile is the file in the CNI status volume that holds the
+	// transparent proxy status annotation of the pod.
+	cniStatusFile = "status"
+)
+
 // containerVolume returns the volume data to add to the pod. This volume
 // is used for shared data between containers.
 func (w *MeshWebhook) containerVolume() corev1.Volume {
@@ -21,3 +49,60 @@ func (w *MeshWebhook) containerVolume() corev1.Volume {
 		},
 	}
 }
+
+// serviceAccountTokenVolume returns a volume with a service account token
+// requested for the pod with the TokenRequest API. Unlike the token in a
+// service account secret, it is bound to the pod, expires and is rotated by
+// the kubelet, and can be bound to an audience.
+func (w *MeshWebhook) serviceAccountTokenVolume() corev1.Volume {
+	tokenProjection := &corev1.ServiceAccountTokenProjection{
+		Audience: w.ServiceAccountTokenAudience,
+		Path:     "token",
+	}
+	if w.ServiceAccountTokenExpirationSeconds > 0 {
+		tokenProjection.ExpirationSeconds = &w.ServiceAccountTokenExpirationSeconds
+	}
+	return corev1.Volume{
+		Name: serviceAccountTokenVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{ServiceAccountToken: tokenProjection},
+				},
+			},
+		},
+	}
+}
+
+// dogStatsDSocketVolume returns a volume with the directory of the DogStatsD
+// socket on the node. It's mounted into the sidecar proxies at the same path.
+func (w *MeshWebhook) dogStatsDSocketVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: dogStatsDSocketVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: w.DogStatsDConfig.SocketDir()},
+		},
+	}
+}
+
+// cniStatusVolume returns a volume with the transparent proxy status annotation
+// of the pod. The CNI plugin sets the annotation to "complete" once it has applied
+// the traffic redirection rules, and the kubelet updates the file when the
+// annotation changes.
+func (w *MeshWebhook) cniStatusVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: cniStatusVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{
+				Items: []corev1.DownwardAPIVolumeFile{
+					{
+						Path: cniStatusFile,
+						FieldRef: &corev1.ObjectFieldSelector{
+							FieldPath: fmt.Sprintf("metadata.annotations['%s']", constants.KeyTransparentProxyStatus),
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/prometheus/client_golang/prometheus"
+	"gomodules.xyz/jsonpatch/v2"
+	corev1 "k8s.io/api/core/v1"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	dryRunResultInjected = "injected"
+	dryRunResultRejected = "rejected"
+)
+
+var (
+	dryRunPods = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_k8s_connect_inject_dry_run_pods_total",
+		Help: "Number of pods evaluated in dry-run mode, by whether they would have been injected or rejected.",
+	}, []string{"namespace", "result"})
+	dryRunCPURequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_k8s_connect_inject_dry_run_cpu_requests_cores_total",
+		Help: "Sum of the CPU requests of the containers that would have been added to the pods evaluated in dry-run mode.",
+	}, []string{"namespace"})
+	dryRunMemoryRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_k8s_connect_inject_dry_run_memory_requests_bytes_total",
+		Help: "Sum of the memory requests of the containers that would have been added to the pods evaluated in dry-run mode.",
+	}, []string{"namespace"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(dryRunPods, dryRunCPURequests, dryRunMemoryRequests)
+}
+
+// dryRunReport is what the webhook would have injected into a pod in dry-run mode. It is added to the
+// pod as the AnnotationDryRunReport annotation.
+type dryRunReport struct {
+	// Error is why the pod would have been rejected. The other fields are empty if it is set.
+	Error string `json:"error,omitempty"`
+	// InitContainers, Containers and Volumes are the names of the ones that would have been added.
+	InitContainers []string `json:"initContainers,omitempty"`
+	Containers     []string `json:"containers,omitempty"`
+	Volumes        []string `json:"volumes,omitempty"`
+	// Requests and Limits are the sum of the resources of the containers that would have been added. They
+	// leave out the init containers since those only run before the pod starts.
+	Requests         corev1.ResourceList `json:"requests,omitempty"`
+	Limits           corev1.ResourceList `json:"limits,omitempty"`
+	TransparentProxy bool                `json:"transparentProxy,omitempty"`
+}
+
+// dryRunEnabled returns whether the pods of the namespace are only evaluated rather than injected. The
+// namespace label takes precedence over the -dry-run flag.
+func (w *MeshWebhook) dryRunEnabled(ns corev1.Namespace) (bool, error) {
+	if raw, ok := ns.Labels[constants.LabelDryRun]; ok {
+		return strconv.ParseBool(raw)
+	}
+	return w.DryRun, nil
+}
+
+// dryRunResponse returns a response that only adds the dry-run report to the pod as received. The report
+// compares the pod to injectedPod, which is what the pod would have been injected as, or contains rejection
+// if the pod would have been rejected.
+func (w *MeshWebhook) dryRunResponse(req admission.Request, origPodJson []byte, injectedPod *corev1.Pod, rejection string) admission.Response {
+	var pod corev1.Pod
+	if err := json.Unmarshal(origPodJson, &pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	report := dryRunReport{Error: rejection}
+	result := dryRunResultRejected
+	if injectedPod != nil {
+		report = newDryRunReport(pod, *injectedPod)
+		result = dryRunResultInjected
+		dryRunCPURequests.WithLabelValues(req.Namespace).Add(report.Requests.Cpu().AsApproximateFloat64())
+		dryRunMemoryRequests.WithLabelValues(req.Namespace).Add(report.Requests.Memory().AsApproximateFloat64())
+	}
+	dryRunPods.WithLabelValues(req.Namespace, result).Inc()
+	w.Log.Info("evaluated pod in dry-run mode", "name", req.Name, "ns", req.Namespace, "result", result)
+
+	reportJson, err := json.Marshal(report)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[constants.AnnotationDryRunReport] = string(reportJson)
+
+	updatedPodJson, err := json.Marshal(pod)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	patches, err := jsonpatch.CreatePatch(origPodJson, updatedPodJson)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return admission.Patched(fmt.Sprintf("dry run of %s request", pod.Kind), patches...)
+}
+
+// newDryRunReport returns the report of what was added to pod to inject it as injectedPod.
+func newDryRunReport(pod, injectedPod corev1.Pod) dryRunReport {
+	report := dryRunReport{
+		Requests:         corev1.ResourceList{},
+		Limits:           corev1.ResourceList{},
+		TransparentProxy: injectedPod.Annotations[constants.KeyTransparentProxyStatus] == constants.Enabled,
+	}
+
+	initContainers := make(map[string]bool)
+	for _, c := range pod.Spec.InitContainers {
+		initContainers[c.Name] = true
+	}
+	for _, c := range injectedPod.Spec.InitContainers {
+		if !initContainers[c.Name] {
+			report.InitContainers = append(report.InitContainers, c.Name)
+		}
+	}
+
+	containers := make(map[string]bool)
+	for _, c := range pod.Spec.Containers {
+		containers[c.Name] = true
+	}
+	for _, c := range injectedPod.Spec.Containers {
+		if containers[c.Name] {
+			continue
+		}
+		report.Containers = append(report.Containers, c.Name)
+		addResources(report.Requests, c.Resources.Requests)
+		addResources(report.Limits, c.Resources.Limits)
+	}
+
+	volumes := make(map[string]bool)
+	for _, v := range pod.Spec.Volumes {
+		volumes[v.Name] = true
+	}
+	for _, v := range injectedPod.Spec.Volumes {
+		if !volumes[v.Name] {
+			report.Volumes = append(report.Volumes, v.Name)
+		}
+	}
+	return report
+}
+
+func addResources(total, resources corev1.ResourceList) {
+	for name, quantity := range resources {
+		sum := total[name]
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
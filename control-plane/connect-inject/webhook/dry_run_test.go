@@ -0,0 +1,212 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	mapset "github.com/deckarep/golang-set"
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestHandlerDryRun(t *testing.T) {
+	t.Parallel()
+	s := runtime.NewScheme()
+	s.AddKnownTypes(schema.GroupVersion{Group: "", Version: "v1"}, &corev1.Pod{})
+	decoder, err := admission.NewDecoder(s)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		dryRun         bool
+		namespaceLabel map[string]string
+		annotations    map[string]string
+		// expReport is nil if the pod is expected to be injected.
+		expReport *dryRunReport
+	}{
+		"dry-run disabled": {},
+		"dry-run enabled by flag": {
+			dryRun: true,
+			expReport: &dryRunReport{
+				InitContainers: []string{"consul-connect-inject-init"},
+				Containers:     []string{sidecarContainer},
+				Volumes:        []string{"consul-connect-inject-data"},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("100Mi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("100Mi"),
+				},
+			},
+		},
+		"dry-run enabled by namespace label": {
+			namespaceLabel: map[string]string{constants.LabelDryRun: "true"},
+			expReport: &dryRunReport{
+				InitContainers: []string{"consul-connect-inject-init"},
+				Containers:     []string{sidecarContainer},
+				Volumes:        []string{"consul-connect-inject-data"},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("100Mi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("100Mi"),
+				},
+			},
+		},
+		"dry-run disabled by namespace label": {
+			dryRun:         true,
+			namespaceLabel: map[string]string{constants.LabelDryRun: "false"},
+		},
+		"pod that would be rejected": {
+			dryRun:      true,
+			annotations: map[string]string{constants.KeyTransparentProxy: "not-a-bool"},
+			expReport: &dryRunReport{
+				Error: `error configuring injection init container: strconv.ParseBool: parsing "not-a-bool": invalid syntax`,
+			},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: c.namespaceLabel}}
+			w := MeshWebhook{
+				Log:                       logrtest.New(t),
+				AllowK8sNamespacesSet:     mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:      mapset.NewSet(),
+				Clientset:                 fake.NewSimpleClientset(ns),
+				ConsulConfig:              &consul.Config{HTTPPort: 8500},
+				DefaultProxyCPURequest:    resource.MustParse("100m"),
+				DefaultProxyCPULimit:      resource.MustParse("100m"),
+				DefaultProxyMemoryRequest: resource.MustParse("100Mi"),
+				DefaultProxyMemoryLimit:   resource.MustParse("100Mi"),
+				DryRun:                    c.dryRun,
+				decoder:                   decoder,
+			}
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Annotations: c.annotations},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}},
+			}
+			resp := w.Handle(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: "default",
+					Object:    encodeRaw(t, pod),
+				},
+			})
+			require.True(t, resp.Allowed)
+
+			if c.expReport == nil {
+				// The pod is injected.
+				require.Greater(t, len(resp.Patches), 1)
+				return
+			}
+
+			// Only the report is added to the pod.
+			require.Len(t, resp.Patches, 1)
+			patch := resp.Patches[0]
+			require.Equal(t, "add", patch.Operation)
+			var reportJson string
+			if c.annotations == nil {
+				require.Equal(t, "/metadata/annotations", patch.Path)
+				annotations := patch.Value.(map[string]interface{})
+				require.Len(t, annotations, 1)
+				reportJson = annotations[constants.AnnotationDryRunReport].(string)
+			} else {
+				require.Equal(t, "/metadata/annotations/consul.hashicorp.com~1connect-inject-dry-run-report", patch.Path)
+				reportJson = patch.Value.(string)
+			}
+			var report dryRunReport
+			require.NoError(t, json.Unmarshal([]byte(reportJson), &report))
+			require.Equal(t, c.expReport.Error, report.Error)
+			require.Equal(t, c.expReport.InitContainers, report.InitContainers)
+			require.Equal(t, c.expReport.Containers, report.Containers)
+			require.Equal(t, c.expReport.Volumes, report.Volumes)
+			requireResourcesEqual(t, c.expReport.Requests, report.Requests)
+			requireResourcesEqual(t, c.expReport.Limits, report.Limits)
+		})
+	}
+}
+
+func TestHandlerDryRun_InvalidNamespaceLabel(t *testing.T) {
+	w := MeshWebhook{}
+	_, err := w.dryRunEnabled(corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{constants.LabelDryRun: "maybe"}},
+	})
+	require.Error(t, err)
+}
+
+func TestNewDryRunReport(t *testing.T) {
+	pod := corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "setup"}},
+			Containers: []corev1.Container{{
+				Name: "web",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				},
+			}},
+			Volumes: []corev1.Volume{{Name: "data"}},
+		},
+	}
+	injectedPod := *pod.DeepCopy()
+	injectedPod.Annotations = map[string]string{constants.KeyTransparentProxyStatus: constants.Enabled}
+	injectedPod.Spec.InitContainers = append(injectedPod.Spec.InitContainers, corev1.Container{
+		Name: "consul-connect-inject-init-web",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")},
+		},
+	})
+	for _, name := range []string{"consul-dataplane-web", "consul-dataplane-admin"} {
+		injectedPod.Spec.Containers = append(injectedPod.Spec.Containers, corev1.Container{
+			Name: name,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("64Mi"),
+				},
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			},
+		})
+	}
+	injectedPod.Spec.Volumes = append(injectedPod.Spec.Volumes, corev1.Volume{Name: "consul-connect-inject-data"})
+
+	report := newDryRunReport(pod, injectedPod)
+	require.Equal(t, []string{"consul-connect-inject-init-web"}, report.InitContainers)
+	require.Equal(t, []string{"consul-dataplane-web", "consul-dataplane-admin"}, report.Containers)
+	require.Equal(t, []string{"consul-connect-inject-data"}, report.Volumes)
+	require.True(t, report.TransparentProxy)
+	// The init containers and the existing containers are left out of the resources.
+	requireResourcesEqual(t, corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("200m"),
+		corev1.ResourceMemory: resource.MustParse("128Mi"),
+	}, report.Requests)
+	requireResourcesEqual(t, corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("256Mi"),
+	}, report.Limits)
+}
+
+func requireResourcesEqual(t *testing.T, exp, actual corev1.ResourceList) {
+	t.Helper()
+	require.Len(t, actual, len(exp))
+	for name, quantity := range exp {
+		actualQuantity, ok := actual[name]
+		require.True(t, ok, "missing resource %s", name)
+		require.Zero(t, quantity.Cmp(actualQuantity), "expected %s to be %s, got %s", name, quantity.String(), actualQuantity.String())
+	}
+}
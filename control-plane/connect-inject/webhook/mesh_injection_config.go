@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=consul.hashicorp.com,resources=meshinjectionconfigs,verbs=get;list;watch
+
+// applyMeshInjectionConfig sets the annotations of the pod that are defaulted by the
+// MeshInjectionConfig of its namespace, unless the pod already has them. It must be
+// called before the annotations are read so that the defaults of the config take
+// precedence over the defaults of the webhook.
+func (w *MeshWebhook) applyMeshInjectionConfig(ctx context.Context, pod *corev1.Pod, namespace string) error {
+	if w.Client == nil {
+		return nil
+	}
+	var configs v1alpha1.MeshInjectionConfigList
+	if err := w.Client.List(ctx, &configs, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	// The MeshInjectionConfig webhook only allows one config per namespace.
+	if len(configs.Items) == 0 {
+		return nil
+	}
+	config := configs.Items[0]
+	spec := config.Spec
+
+	setDefault := func(key, value string) {
+		if _, ok := pod.Annotations[key]; !ok {
+			pod.Annotations[key] = value
+		}
+	}
+	setBool := func(key string, value *bool) {
+		if value != nil {
+			setDefault(key, strconv.FormatBool(*value))
+		}
+	}
+	setInt := func(key string, value *int32) {
+		if value != nil {
+			setDefault(key, strconv.Itoa(int(*value)))
+		}
+	}
+
+	if tproxy := spec.TransparentProxy; tproxy != nil {
+		if tproxy.Enabled != nil {
+			// The namespace label takes precedence over the config, so it
+			// must not be shadowed by a pod annotation.
+			ns, err := w.Clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if _, ok := ns.Labels[constants.KeyTransparentProxy]; !ok {
+				setBool(constants.KeyTransparentProxy, tproxy.Enabled)
+			}
+		}
+		setBool(constants.AnnotationTransparentProxyOverwriteProbes, tproxy.OverwriteProbes)
+		if len(tproxy.ExcludeOutboundCIDRs) > 0 {
+			setDefault(constants.AnnotationTProxyExcludeOutboundCIDRs, strings.Join(tproxy.ExcludeOutboundCIDRs, ","))
+		}
+	}
+	if len(spec.Upstreams) > 0 {
+		setDefault(constants.AnnotationUpstreams, strings.Join(spec.Upstreams, ","))
+	}
+	if metrics := spec.Metrics; metrics != nil {
+		setBool(constants.AnnotationEnableMetrics, metrics.Enabled)
+		setBool(constants.AnnotationEnableMetricsMerging, metrics.MergingEnabled)
+	}
+	if lifecycle := spec.SidecarProxyLifecycle; lifecycle != nil {
+		setBool(constants.AnnotationEnableSidecarProxyLifecycle, lifecycle.Enabled)
+		setBool(constants.AnnotationEnableSidecarProxyLifecycleShutdownDrainListeners, lifecycle.ShutdownDrainListeners)
+		setInt(constants.AnnotationSidecarProxyLifecycleShutdownGracePeriodSeconds, lifecycle.ShutdownGracePeriodSeconds)
+		setInt(constants.AnnotationSidecarProxyLifecycleGracefulPort, lifecycle.GracefulPort)
+		if lifecycle.GracefulShutdownPath != "" {
+			setDefault(constants.AnnotationSidecarProxyLifecycleGracefulShutdownPath, lifecycle.GracefulShutdownPath)
+		}
+	}
+	pod.Annotations[constants.AnnotationMeshInjectionConfig] = config.Name
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHandlerApplyMeshInjectionConfig(t *testing.T) {
+	t.Parallel()
+	enabled := true
+	disabled := false
+	gracePeriod := int32(30)
+	config := &v1alpha1.MeshInjectionConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "default"},
+		Spec: v1alpha1.MeshInjectionConfigSpec{
+			TransparentProxy: &v1alpha1.InjectionTransparentProxy{
+				Enabled:              &enabled,
+				OverwriteProbes:      &disabled,
+				ExcludeOutboundCIDRs: []string{"10.0.0.0/8", "1.1.1.1"},
+			},
+			Upstreams: []string{"db:1234", "cache:6379"},
+			Metrics:   &v1alpha1.InjectionMetrics{MergingEnabled: &enabled},
+			SidecarProxyLifecycle: &v1alpha1.InjectionSidecarProxyLifecycle{
+				ShutdownGracePeriodSeconds: &gracePeriod,
+				GracefulShutdownPath:       "/shutdown",
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		configs        []runtime.Object
+		namespaceLabel map[string]string
+		annotations    map[string]string
+		expAnnotations map[string]string
+	}{
+		"no config": {
+			expAnnotations: map[string]string{},
+		},
+		"config in another namespace": {
+			configs: []runtime.Object{func() *v1alpha1.MeshInjectionConfig {
+				c := config.DeepCopy()
+				c.Namespace = "other"
+				return c
+			}()},
+			expAnnotations: map[string]string{},
+		},
+		"config defaults the annotations": {
+			configs: []runtime.Object{config},
+			expAnnotations: map[string]string{
+				constants.KeyTransparentProxy:                                       "true",
+				constants.AnnotationTransparentProxyOverwriteProbes:                 "false",
+				constants.AnnotationTProxyExcludeOutboundCIDRs:                      "10.0.0.0/8,1.1.1.1",
+				constants.AnnotationUpstreams:                                       "db:1234,cache:6379",
+				constants.AnnotationEnableMetricsMerging:                            "true",
+				constants.AnnotationSidecarProxyLifecycleShutdownGracePeriodSeconds: "30",
+				constants.AnnotationSidecarProxyLifecycleGracefulShutdownPath:       "/shutdown",
+				constants.AnnotationMeshInjectionConfig:                             "defaults",
+			},
+		},
+		"pod annotations and namespace labels take precedence": {
+			configs:        []runtime.Object{config},
+			namespaceLabel: map[string]string{constants.KeyTransparentProxy: "false"},
+			annotations: map[string]string{
+				constants.AnnotationUpstreams:                                       "api:8080",
+				constants.AnnotationSidecarProxyLifecycleShutdownGracePeriodSeconds: "5",
+			},
+			expAnnotations: map[string]string{
+				constants.AnnotationTransparentProxyOverwriteProbes:                 "false",
+				constants.AnnotationTProxyExcludeOutboundCIDRs:                      "10.0.0.0/8,1.1.1.1",
+				constants.AnnotationUpstreams:                                       "api:8080",
+				constants.AnnotationEnableMetricsMerging:                            "true",
+				constants.AnnotationSidecarProxyLifecycleShutdownGracePeriodSeconds: "5",
+				constants.AnnotationSidecarProxyLifecycleGracefulShutdownPath:       "/shutdown",
+				constants.AnnotationMeshInjectionConfig:                             "defaults",
+			},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := runtime.NewScheme()
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.MeshInjectionConfig{}, &v1alpha1.MeshInjectionConfigList{})
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: c.namespaceLabel}}
+			w := MeshWebhook{
+				Clientset: fake.NewSimpleClientset(ns),
+				Client:    ctrlfake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(c.configs...).Build(),
+			}
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			for k, v := range c.annotations {
+				pod.Annotations[k] = v
+			}
+
+			require.NoError(t, w.applyMeshInjectionConfig(context.Background(), pod, "default"))
+			require.Equal(t, c.expAnnotations, pod.Annotations)
+		})
+	}
+}
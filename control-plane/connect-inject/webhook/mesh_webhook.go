@@ -15,10 +15,14 @@ import (
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/accesslogs"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/dogstatsd"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/lifecycle"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/tracing"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 	"github.com/hashicorp/consul-k8s/control-plane/version"
@@ -29,6 +33,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -56,6 +61,10 @@ var kubeSystemNamespaces = mapset.NewSetWith(metav1.NamespaceSystem, metav1.Name
 type MeshWebhook struct {
 	Clientset kubernetes.Interface
 
+	// Client reads the MeshInjectionConfigs that default the annotations of the
+	// pods in their namespace. If it is nil, no MeshInjectionConfig is applied.
+	Client client.Client
+
 	// ConsulClientConfig is the config to create a Consul API client.
 	ConsulConfig *consul.Config
 
@@ -73,6 +82,12 @@ type MeshWebhook struct {
 	// This image is used for the consul-sidecar container.
 	ImageConsulK8S string
 
+	// ImageConsulDataplaneWindows and ImageConsulK8SWindows are the images used in place of
+	// ImageConsulDataplane and ImageConsulK8S for pods that run on Windows nodes.
+	// Windows pods are rejected if they are not set.
+	ImageConsulDataplaneWindows string
+	ImageConsulK8SWindows       string
+
 	// Optional: set when you need extra options to be set when running envoy
 	// See a list of args here: https://www.envoyproxy.io/docs/envoy/latest/operations/cli
 	EnvoyExtraArgs string
@@ -85,6 +100,21 @@ type MeshWebhook struct {
 	// use for identity with connectInjection if ACLs are enabled.
 	AuthMethod string
 
+	// EnableProjectedServiceAccountToken mounts a projected service account
+	// token into the injected containers to log in with the auth method,
+	// instead of the token automounted into the pod.
+	EnableProjectedServiceAccountToken bool
+
+	// ServiceAccountTokenAudience is the audience of the projected service
+	// account token. If empty, it defaults to the audience of the Kubernetes
+	// API server. It must be one of the API server's audiences so that the
+	// auth method can validate the token with the TokenReview API.
+	ServiceAccountTokenAudience string
+
+	// ServiceAccountTokenExpirationSeconds is the requested lifetime of the
+	// projected service account token. If 0, the Kubernetes default is used.
+	ServiceAccountTokenExpirationSeconds int64
+
 	// The PEM-encoded CA certificate string
 	// to use when communicating with Consul clients over HTTPS.
 	// If not set, will use HTTP.
@@ -145,6 +175,10 @@ type MeshWebhook struct {
 	// Only necessary if ACLs are enabled.
 	CrossNamespaceACLPolicy string
 
+	// Auditor records the Consul namespaces the webhook creates. Writes are
+	// not recorded if it is nil.
+	Auditor *audit.Auditor
+
 	// Default resource settings for sidecar proxies. Some of these
 	// fields may be empty.
 	DefaultProxyCPURequest    resource.Quantity
@@ -164,6 +198,20 @@ type MeshWebhook struct {
 	// annotations and the merged metrics server.
 	MetricsConfig metrics.Config
 
+	// AccessLogsConfig contains Envoy access logging configuration from the inject-connect command and has methods to
+	// determine whether configuration should come from the default flags or annotations. The meshWebhook uses this to
+	// validate access log annotations; the endpoints controller applies them to the proxy service registration.
+	AccessLogsConfig accesslogs.Config
+
+	// TracingConfig contains Envoy tracing configuration from the inject-connect command. The meshWebhook uses this to
+	// validate tracing annotations; the endpoints controller applies them to the proxy service registration.
+	TracingConfig tracing.Config
+
+	// DogStatsDConfig contains the configuration for sending Envoy metrics to DogStatsD from the inject-connect
+	// command. The meshWebhook uses this to mount the DogStatsD socket into the sidecar proxies and to label pods
+	// for Datadog; the endpoints controller applies it to the proxy service registration.
+	DogStatsDConfig dogstatsd.Config
+
 	// Resource settings for init container. All of these fields
 	// will be populated by the defaults provided in the initial flags.
 	InitContainerResources corev1.ResourceRequirements
@@ -182,10 +230,20 @@ type MeshWebhook struct {
 	// redirection
 	EnableCNI bool
 
+	// CNIRequireRedirection makes the connect-inject init container of pods with transparent proxy enabled
+	// wait for the CNI plugin to apply the traffic redirection rules and fail if it doesn't, so that pods on
+	// nodes where the plugin is not installed don't start with traffic that bypasses the proxy.
+	// Only used if EnableCNI is set.
+	CNIRequireRedirection bool
+
 	// TProxyOverwriteProbes controls whether the webhook should mutate pod's HTTP probes
 	// to point them to the Envoy proxy.
 	TProxyOverwriteProbes bool
 
+	// TProxyEnableIPv6 controls whether IPv6 traffic is redirected to the proxy by default
+	// in Transparent Proxy mode. Pods can override it with the transparent-proxy-ipv6 annotation.
+	TProxyEnableIPv6 bool
+
 	// EnableConsulDNS enables traffic redirection so that DNS requests are directed to Consul
 	// from mesh services.
 	EnableConsulDNS bool
@@ -202,6 +260,15 @@ type MeshWebhook struct {
 	// ReleaseNamespace is the Kubernetes namespace where this webhook is running.
 	ReleaseNamespace string
 
+	// DryRun evaluates pods without injecting them. Instead, a report of what would have been injected is added
+	// to the pods as an annotation and counted in metrics. It can be overridden per namespace with a label.
+	DryRun bool
+
+	// ClusterName distinguishes this Kubernetes cluster from the other Kubernetes clusters that are
+	// registered with the same Consul datacenter. It is added to the names of the virtual nodes that
+	// injected pods register their services on.
+	ClusterName string
+
 	// Log
 	Log logr.Logger
 	// Log settings for consul-dataplane and connect-init containers.
@@ -255,13 +322,52 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 
 	w.Log.Info("received pod", "name", req.Name, "ns", req.Namespace)
 
+	// A user can enable/disable tproxy and dry-run mode for an entire namespace via a label.
+	ns, err := w.Clientset.CoreV1().Namespaces().Get(ctx, req.Namespace, metav1.GetOptions{})
+	if err != nil {
+		w.Log.Error(err, "error fetching namespace metadata for container", "request name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error getting namespace metadata for container: %s", err))
+	}
+
+	dryRun, err := w.dryRunEnabled(*ns)
+	if err != nil {
+		w.Log.Error(err, "error determining if dry-run mode is enabled", "request name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error determining if dry-run mode is enabled: %s", err))
+	}
+	resp := w.injectPod(ctx, req, pod, ns, origPodJson, dryRun)
+	if dryRun && !resp.Allowed {
+		// Pods are never rejected in dry-run mode. The rejection is reported instead.
+		return w.dryRunResponse(req, origPodJson, nil, resp.Result.Message)
+	}
+	return resp
+}
+
+// injectPod adds the init container, sidecar proxy and the rest of the configuration of the mesh
+// to the pod. In dry-run mode, it reports the pod it would have injected instead of patching it.
+func (w *MeshWebhook) injectPod(ctx context.Context, req admission.Request, pod corev1.Pod, ns *corev1.Namespace, origPodJson []byte, dryRun bool) admission.Response {
+	// Default the annotations of the pod with the MeshInjectionConfig of its namespace.
+	// This MUST be done before the annotations are read below.
+	if err := w.applyMeshInjectionConfig(ctx, &pod, req.Namespace); err != nil {
+		w.Log.Error(err, "error applying mesh injection config", "request name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error applying mesh injection config: %s", err))
+	}
+
 	// Add our volume that will be shared by the init container and
 	// the sidecar for passing data in the pod.
 	pod.Spec.Volumes = append(pod.Spec.Volumes, w.containerVolume())
+	if w.AuthMethod != "" && w.EnableProjectedServiceAccountToken {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, w.serviceAccountTokenVolume())
+	}
 
 	// Optionally mount data volume to other containers
 	w.injectVolumeMount(pod)
 
+	// Mount the DogStatsD socket if the sidecar proxy sends its metrics to the socket.
+	// Invalid DogStatsD annotations are rejected below.
+	if enabled, err := w.DogStatsDConfig.Enabled(pod); err == nil && enabled && w.DogStatsDConfig.UsesSocket() {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, w.dogStatsDSocketVolume())
+	}
+
 	// Optionally add any volumes that are to be used by the envoy sidecar.
 	if _, ok := pod.Annotations[constants.AnnotationConsulSidecarUserVolume]; ok {
 		var userVolumes []corev1.Volume
@@ -274,6 +380,14 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 
 	// Add the upstream services as environment variables for easy
 	// service discovery.
+	if err := validateUpstreams(pod); err != nil {
+		w.Log.Error(err, "invalid upstreams annotation", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, fmt.Errorf("invalid upstreams annotation: %s", err))
+	}
+	if _, err := common.MeshGatewayModeFromPod(pod); err != nil {
+		w.Log.Error(err, "invalid mesh gateway mode annotation", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
 	containerEnvVars := w.containerEnvVars(pod)
 	for i := range pod.Spec.InitContainers {
 		pod.Spec.InitContainers[i].Env = append(pod.Spec.InitContainers[i].Env, containerEnvVars...)
@@ -283,11 +397,12 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 		pod.Spec.Containers[i].Env = append(pod.Spec.Containers[i].Env, containerEnvVars...)
 	}
 
-	// A user can enable/disable tproxy for an entire namespace via a label.
-	ns, err := w.Clientset.CoreV1().Namespaces().Get(ctx, req.Namespace, metav1.GetOptions{})
-	if err != nil {
-		w.Log.Error(err, "error fetching namespace metadata for container", "request name", req.Name)
-		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error getting namespace metadata for container: %s", err))
+	// Windows pods use Windows images and do not support transparent proxy.
+	if isWindowsPod(pod) {
+		if err := w.prepareWindowsPod(&pod); err != nil {
+			w.Log.Error(err, "error preparing Windows pod for injection", "request name", req.Name)
+			return admission.Errored(http.StatusBadRequest, err)
+		}
 	}
 
 	// Get service names from the annotation. If theres 0-1 service names, it's a single port pod, otherwise it's multi
@@ -388,6 +503,12 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 		}
 	}
 
+	// Make sure the sidecar has enough time to drain connections before it is killed.
+	if err := w.alignTerminationGracePeriod(&pod); err != nil {
+		w.Log.Error(err, "error configuring termination grace period", "request name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error configuring termination grace period: %s", err))
+	}
+
 	// pod.Annotations has already been initialized by h.defaultAnnotations()
 	// and does not need to be checked for being a nil value.
 	pod.Annotations[constants.KeyInjectStatus] = constants.Injected
@@ -402,6 +523,17 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 	// to determine if it should traffic redirect or not.
 	if tproxyEnabled {
 		pod.Annotations[constants.KeyTransparentProxyStatus] = constants.Enabled
+
+		// Record that IPv6 traffic is redirected so that the CNI plugin and the endpoints controller
+		// don't need to know the default. A missing annotation means IPv6 traffic is not redirected.
+		ipv6Enabled, err := common.TransparentProxyIPv6Enabled(pod, w.TProxyEnableIPv6)
+		if err != nil {
+			w.Log.Error(err, "error determining if IPv6 traffic redirection is enabled", "request name", req.Name)
+			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error determining if IPv6 traffic redirection is enabled: %s", err))
+		}
+		if ipv6Enabled {
+			pod.Annotations[constants.AnnotationTransparentProxyIPv6] = "true"
+		}
 	}
 
 	// If DNS redirection is enabled, we want to configure dns on the pod.
@@ -423,9 +555,40 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 		return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error configuring prometheus annotations: %s", err))
 	}
 
+	// Validate access log annotations so that invalid configuration is rejected here rather than
+	// failing the proxy service registration later on.
+	if _, err = w.AccessLogsConfig.AccessLogs(pod); err != nil {
+		w.Log.Error(err, "error configuring envoy access logs", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, fmt.Errorf("error configuring envoy access logs: %s", err))
+	}
+
+	// Validate tracing annotations for the same reason.
+	if _, err = w.TracingConfig.ProxyConfig(pod, ""); err != nil {
+		w.Log.Error(err, "error configuring envoy tracing", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, fmt.Errorf("error configuring envoy tracing: %s", err))
+	}
+
+	// Validate DogStatsD annotations for the same reason.
+	dogStatsDEnabled, err := w.DogStatsDConfig.Validate(pod)
+	if err != nil {
+		w.Log.Error(err, "error configuring envoy dogstatsd", "request name", req.Name)
+		return admission.Errored(http.StatusBadRequest, fmt.Errorf("error configuring envoy dogstatsd: %s", err))
+	}
+
 	if pod.Labels == nil {
 		pod.Labels = make(map[string]string)
 	}
+
+	// Label the pod with its service for Datadog's unified service tagging so that Datadog attributes the
+	// metrics of the pod's containers to the same service as the sidecar proxy's metrics. The service is only
+	// known here if it's set by the annotation, otherwise it's the name of the Kubernetes service.
+	if dogStatsDEnabled {
+		if svcNames := w.annotatedServiceNames(pod); len(svcNames) == 1 {
+			if _, ok := pod.Labels[constants.LabelDatadogService]; !ok {
+				pod.Labels[constants.LabelDatadogService] = svcNames[0]
+			}
+		}
+	}
 	pod.Labels[constants.KeyInjectStatus] = constants.Injected
 
 	// Add the managed-by label since services are now managed by endpoints controller. This is to support upgrading
@@ -451,6 +614,13 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 			w.Log.Error(err, "error configuring annotation for CNI traffic redirection", "request name", req.Name)
 			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error configuring annotation for CNI traffic redirection: %s", err))
 		}
+		if w.CNIRequireRedirection {
+			pod.Spec.Volumes = append(pod.Spec.Volumes, w.cniStatusVolume())
+		}
+	}
+
+	if dryRun {
+		return w.dryRunResponse(req, origPodJson, &pod, "")
 	}
 
 	// Marshall the pod into JSON after it has the desired envs, annotations, labels,
@@ -483,7 +653,17 @@ func (w *MeshWebhook) Handle(ctx context.Context, req admission.Request) admissi
 				"ns", w.consulNamespace(req.Namespace), "request name", req.Name)
 			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error checking or creating namespace: %s", err))
 		}
-		if _, err := namespaces.EnsureExists(apiClient, w.consulNamespace(req.Namespace), w.CrossNamespaceACLPolicy); err != nil {
+		created, err := namespaces.EnsureExists(apiClient, w.consulNamespace(req.Namespace), w.CrossNamespaceACLPolicy)
+		if created || err != nil {
+			w.Auditor.Record(audit.Event{
+				Actor:     "webhook/connect-inject",
+				Operation: audit.OperationCreate,
+				Resource:  audit.ResourceNamespace,
+				Name:      w.consulNamespace(req.Namespace),
+				Source:    &audit.Source{Kind: "Pod", Namespace: req.Namespace, Name: req.Name},
+			}, err)
+		}
+		if err != nil {
 			w.Log.Error(err, "error checking or creating namespace",
 				"ns", w.consulNamespace(req.Namespace), "request name", req.Name)
 			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("error checking or creating namespace: %s", err))
@@ -514,20 +694,35 @@ func (w *MeshWebhook) overwriteProbes(ns corev1.Namespace, pod *corev1.Pod) erro
 			if container.Name == sidecarContainer {
 				continue
 			}
-			if container.LivenessProbe != nil && container.LivenessProbe.HTTPGet != nil {
-				container.LivenessProbe.HTTPGet.Port = intstr.FromInt(exposedPathsLivenessPortsRangeStart + i)
-			}
-			if container.ReadinessProbe != nil && container.ReadinessProbe.HTTPGet != nil {
-				container.ReadinessProbe.HTTPGet.Port = intstr.FromInt(exposedPathsReadinessPortsRangeStart + i)
-			}
-			if container.StartupProbe != nil && container.StartupProbe.HTTPGet != nil {
-				container.StartupProbe.HTTPGet.Port = intstr.FromInt(exposedPathsStartupPortsRangeStart + i)
-			}
+			overwriteProbe(container.LivenessProbe, exposedPathsLivenessPortsRangeStart+i)
+			overwriteProbe(container.ReadinessProbe, exposedPathsReadinessPortsRangeStart+i)
+			overwriteProbe(container.StartupProbe, exposedPathsStartupPortsRangeStart+i)
 		}
 	}
 	return nil
 }
 
+// probeCanBeOverwritten returns true if the probe is served over HTTP, HTTPS or gRPC and so can be
+// routed through a listener on the proxy.
+func probeCanBeOverwritten(probe *corev1.Probe) bool {
+	return probe != nil && (probe.HTTPGet != nil || probe.GRPC != nil)
+}
+
+// overwriteProbe points the probe at the given listener port on the proxy. Only the port is changed, so HTTP
+// probes keep their scheme and path, and gRPC probes keep their service. The endpoints controller exposes
+// HTTP and gRPC probes through exposed path listeners, and forwards HTTPS probes to the application without
+// terminating TLS.
+func overwriteProbe(probe *corev1.Probe, port int) {
+	if !probeCanBeOverwritten(probe) {
+		return
+	}
+	if probe.HTTPGet != nil {
+		probe.HTTPGet.Port = intstr.FromInt(port)
+		return
+	}
+	probe.GRPC.Port = int32(port)
+}
+
 func (w *MeshWebhook) injectVolumeMount(pod corev1.Pod) {
 	containersToInject := splitCommaSeparatedItemsFromAnnotation(constants.AnnotationInjectMountVolumes, pod)
 
@@ -535,7 +730,7 @@ func (w *MeshWebhook) injectVolumeMount(pod corev1.Pod) {
 		if sliceContains(containersToInject, container.Name) {
 			pod.Spec.Containers[index].VolumeMounts = append(pod.Spec.Containers[index].VolumeMounts, corev1.VolumeMount{
 				Name:      volumeName,
-				MountPath: "/consul/connect-inject",
+				MountPath: injectDir(pod),
 			})
 		}
 	}
@@ -624,7 +819,7 @@ func (w *MeshWebhook) consulNamespace(ns string) string {
 	return namespaces.ConsulNamespace(ns, w.EnableNamespaces, w.ConsulDestinationNamespace, w.EnableK8SNSMirroring, w.K8SNSMirroringPrefix)
 }
 
-func findServiceAccountVolumeMount(pod corev1.Pod, multiPortSvcName string) (corev1.VolumeMount, string, error) {
+func (w *MeshWebhook) findServiceAccountVolumeMount(pod corev1.Pod, multiPortSvcName string) (corev1.VolumeMount, string, error) {
 	// In the case of a multiPort pod, there may be another service account
 	// token mounted as a different volume. Its name must be <svc>-serviceaccount.
 	// If not we'll fall back to the service account for the pod.
@@ -641,6 +836,14 @@ func findServiceAccountVolumeMount(pod corev1.Pod, multiPortSvcName string) (cor
 		}
 	}
 
+	if w.EnableProjectedServiceAccountToken {
+		return corev1.VolumeMount{
+			Name:      serviceAccountTokenVolumeName,
+			ReadOnly:  true,
+			MountPath: serviceAccountTokenMountPath,
+		}, filepath.Join(serviceAccountTokenMountPath, "token"), nil
+	}
+
 	// Find the volume mount that is mounted at the known
 	// service account token location
 	var volumeMount corev1.VolumeMount
@@ -6,12 +6,14 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"strings"
 	"testing"
 
 	mapset "github.com/deckarep/golang-set"
 	logrtest "github.com/go-logr/logr/testr"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/dogstatsd"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
@@ -1171,6 +1173,173 @@ func TestHandlerPrometheusAnnotations(t *testing.T) {
 	}
 }
 
+func TestHandlerHandle_DogStatsD(t *testing.T) {
+	s := runtime.NewScheme()
+	s.AddKnownTypes(schema.GroupVersion{Group: "", Version: "v1"}, &corev1.Pod{})
+	decoder, err := admission.NewDecoder(s)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		annotations map[string]string
+		labels      map[string]string
+		hostPort    int
+		expLabel    string
+		expVolume   bool
+		expErr      string
+	}{
+		"disabled": {
+			annotations: map[string]string{constants.AnnotationService: "web"},
+		},
+		"enabled via annotation": {
+			annotations: map[string]string{
+				constants.AnnotationService:               "web",
+				constants.AnnotationEnvoyDogStatsDEnabled: "true",
+			},
+			expLabel:  "web",
+			expVolume: true,
+		},
+		"enabled via annotation keeps the service label": {
+			annotations: map[string]string{
+				constants.AnnotationService:               "web",
+				constants.AnnotationEnvoyDogStatsDEnabled: "true",
+			},
+			labels:    map[string]string{constants.LabelDatadogService: "frontend"},
+			expVolume: true,
+		},
+		"enabled via annotation without the service annotation": {
+			annotations: map[string]string{constants.AnnotationEnvoyDogStatsDEnabled: "true"},
+			expVolume:   true,
+		},
+		"enabled on the host port without the socket volume": {
+			annotations: map[string]string{
+				constants.AnnotationService:               "web",
+				constants.AnnotationEnvoyDogStatsDEnabled: "true",
+			},
+			hostPort: 8125,
+			expLabel: "web",
+		},
+		"invalid tags": {
+			annotations: map[string]string{
+				constants.AnnotationEnvoyDogStatsDEnabled: "true",
+				constants.AnnotationEnvoyDogStatsDTags:    "team",
+			},
+			expErr: "error configuring envoy dogstatsd",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+				ConsulConfig:          &consul.Config{HTTPPort: 8500},
+				DogStatsDConfig:       dogstatsd.Config{SocketPath: "/var/run/datadog/dsd.socket", HostPort: c.hostPort},
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: c.annotations,
+							Labels:      c.labels,
+						},
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}},
+					}),
+				},
+			}
+			resp := w.Handle(context.Background(), req)
+			if c.expErr != "" {
+				require.False(t, resp.Allowed)
+				require.Contains(t, resp.Result.Message, c.expErr)
+				return
+			}
+			require.True(t, resp.Allowed)
+
+			var label interface{}
+			var volumes []interface{}
+			for _, patch := range resp.Patches {
+				switch patch.Path {
+				case "/metadata/labels":
+					label = patch.Value.(map[string]interface{})[constants.LabelDatadogService]
+				case "/metadata/labels/" + escapeJSONPointer(constants.LabelDatadogService):
+					label = patch.Value
+				case "/spec/volumes":
+					volumes = patch.Value.([]interface{})
+				}
+			}
+			if c.expLabel == "" {
+				require.Nil(t, label)
+			} else {
+				require.Equal(t, c.expLabel, label)
+			}
+
+			var hasVolume bool
+			for _, v := range volumes {
+				if v.(map[string]interface{})["name"] == dogStatsDSocketVolumeName {
+					hasVolume = true
+					require.Equal(t, map[string]interface{}{"path": "/var/run/datadog"}, v.(map[string]interface{})["hostPath"])
+				}
+			}
+			require.Equal(t, c.expVolume, hasVolume)
+		})
+	}
+}
+
+func TestHandlerHandle_MeshGatewayMode(t *testing.T) {
+	s := runtime.NewScheme()
+	s.AddKnownTypes(schema.GroupVersion{Group: "", Version: "v1"}, &corev1.Pod{})
+	decoder, err := admission.NewDecoder(s)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		mode   string
+		expErr string
+	}{
+		"valid mode": {
+			mode: "local",
+		},
+		"invalid mode": {
+			mode:   "nearby",
+			expErr: `consul.hashicorp.com/mesh-gateway-mode annotation value of "nearby" must be one of "local", "remote" or "none"`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+				Clientset:             defaultTestClientWithNamespace(),
+				ConsulConfig:          &consul.Config{HTTPPort: 8500},
+			}
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Namespace: namespaces.DefaultNamespace,
+					Object: encodeRaw(t, &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{constants.AnnotationMeshGatewayMode: c.mode},
+						},
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}},
+					}),
+				},
+			}
+			resp := w.Handle(context.Background(), req)
+			if c.expErr != "" {
+				require.False(t, resp.Allowed)
+				require.Equal(t, int32(http.StatusBadRequest), resp.Result.Code)
+				require.Contains(t, resp.Result.Message, c.expErr)
+				return
+			}
+			require.True(t, resp.Allowed)
+		})
+	}
+}
+
 // Test consulNamespace function.
 func TestConsulNamespace(t *testing.T) {
 	cases := []struct {
@@ -1886,6 +2055,58 @@ func TestOverwriteProbes(t *testing.T) {
 	}
 }
 
+func TestOverwriteProbes_HTTPSAndGRPC(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "test",
+					LivenessProbe: &corev1.Probe{
+						ProbeHandler: corev1.ProbeHandler{
+							HTTPGet: &corev1.HTTPGetAction{
+								Port:   intstr.FromInt(8443),
+								Scheme: corev1.URISchemeHTTPS,
+							},
+						},
+					},
+					ReadinessProbe: &corev1.Probe{
+						ProbeHandler: corev1.ProbeHandler{
+							GRPC: &corev1.GRPCAction{
+								Port: 9090,
+							},
+						},
+					},
+					StartupProbe: &corev1.Probe{
+						ProbeHandler: corev1.ProbeHandler{
+							TCPSocket: &corev1.TCPSocketAction{
+								Port: intstr.FromInt(9091),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	w := MeshWebhook{
+		EnableTransparentProxy: true,
+		TProxyOverwriteProbes:  true,
+	}
+	require.NoError(t, w.overwriteProbes(corev1.Namespace{}, pod))
+
+	container := pod.Spec.Containers[0]
+	// HTTPS probes keep their scheme so that TLS is still terminated by the application.
+	require.Equal(t, exposedPathsLivenessPortsRangeStart, container.LivenessProbe.HTTPGet.Port.IntValue())
+	require.Equal(t, corev1.URISchemeHTTPS, container.LivenessProbe.HTTPGet.Scheme)
+	require.Equal(t, int32(exposedPathsReadinessPortsRangeStart), container.ReadinessProbe.GRPC.Port)
+	// TCP probes are not overwritten.
+	require.Equal(t, 9091, container.StartupProbe.TCPSocket.Port.IntValue())
+}
+
 func TestHandler_checkUnsupportedMultiPortCases(t *testing.T) {
 	cases := []struct {
 		name        string
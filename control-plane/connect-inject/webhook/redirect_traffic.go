@@ -6,6 +6,7 @@ package webhook
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"strconv"
 
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
@@ -67,13 +68,13 @@ func (w *MeshWebhook) iptablesConfigJSON(pod corev1.Pod, ns corev1.Namespace) (s
 			if container.Name == sidecarContainer {
 				continue
 			}
-			if container.LivenessProbe != nil && container.LivenessProbe.HTTPGet != nil {
+			if probeCanBeOverwritten(container.LivenessProbe) {
 				cfg.ExcludeInboundPorts = append(cfg.ExcludeInboundPorts, strconv.Itoa(exposedPathsLivenessPortsRangeStart+i))
 			}
-			if container.ReadinessProbe != nil && container.ReadinessProbe.HTTPGet != nil {
+			if probeCanBeOverwritten(container.ReadinessProbe) {
 				cfg.ExcludeInboundPorts = append(cfg.ExcludeInboundPorts, strconv.Itoa(exposedPathsReadinessPortsRangeStart+i))
 			}
-			if container.StartupProbe != nil && container.StartupProbe.HTTPGet != nil {
+			if probeCanBeOverwritten(container.StartupProbe) {
 				cfg.ExcludeInboundPorts = append(cfg.ExcludeInboundPorts, strconv.Itoa(exposedPathsStartupPortsRangeStart+i))
 			}
 		}
@@ -89,6 +90,13 @@ func (w *MeshWebhook) iptablesConfigJSON(pod corev1.Pod, ns corev1.Namespace) (s
 
 	// Outbound CIDRs
 	excludeOutboundCIDRs := splitCommaSeparatedItemsFromAnnotation(constants.AnnotationTProxyExcludeOutboundCIDRs, pod)
+	for _, cidr := range excludeOutboundCIDRs {
+		// Validate here so that a bad value is rejected at admission time rather than
+		// failing iptables setup in the init container or CNI plugin after scheduling.
+		if err := validateExcludedCIDR(cidr); err != nil {
+			return "", err
+		}
+	}
 	cfg.ExcludeOutboundCIDRs = append(cfg.ExcludeOutboundCIDRs, excludeOutboundCIDRs...)
 
 	// UIDs
@@ -130,3 +138,18 @@ func (w *MeshWebhook) addRedirectTrafficConfigAnnotation(pod *corev1.Pod, ns cor
 
 	return nil
 }
+
+// validateExcludedCIDR returns an error if value is neither a valid IP address
+// nor a valid CIDR block. Both forms are accepted by iptables as a destination.
+// IPv6 values are only applied by ip6tables, as connect-init and the CNI plugin
+// split the excluded CIDRs by address family before applying the rules.
+func validateExcludedCIDR(value string) error {
+	if net.ParseIP(value) != nil {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		return fmt.Errorf("invalid value %q in annotation %s: must be an IP address or CIDR block",
+			value, constants.AnnotationTProxyExcludeOutboundCIDRs)
+	}
+	return nil
+}
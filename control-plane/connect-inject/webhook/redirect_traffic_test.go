@@ -218,6 +218,49 @@ func TestAddRedirectTrafficConfig(t *testing.T) {
 				ExcludeInboundPorts: []string{strconv.Itoa(exposedPathsLivenessPortsRangeStart)},
 			},
 		},
+		{
+			name: "overwrite probes, HTTPS probe listener port is excluded instead of the application port",
+			webhook: MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+			},
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: defaultNamespace,
+					Name:      defaultPodName,
+					Annotations: map[string]string{
+						constants.AnnotationTransparentProxyOverwriteProbes: "true",
+						constants.KeyTransparentProxy:                       "true",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "test",
+							Ports: []corev1.ContainerPort{{Name: "health", ContainerPort: 8443}},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Port:   intstr.FromString("health"),
+										Scheme: corev1.URISchemeHTTPS,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expCfg: iptables.Config{
+				ConsulDNSIP:         "",
+				ProxyUserID:         strconv.Itoa(sidecarUserAndGroupID),
+				ProxyInboundPort:    constants.ProxyDefaultInboundPort,
+				ProxyOutboundPort:   iptables.DefaultTProxyOutboundPort,
+				ExcludeUIDs:         []string{"5996"},
+				ExcludeInboundPorts: []string{strconv.Itoa(exposedPathsLivenessPortsRangeStart)},
+			},
+		},
 		{
 			name: "exclude inbound ports",
 			webhook: MeshWebhook{
@@ -317,6 +360,66 @@ func TestAddRedirectTrafficConfig(t *testing.T) {
 				ExcludeOutboundCIDRs: []string{"3.3.3.3", "3.3.3.3/24"},
 			},
 		},
+		{
+			name: "exclude outbound CIDRs with whitespace",
+			webhook: MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+			},
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: defaultNamespace,
+					Name:      defaultPodName,
+					Annotations: map[string]string{
+						constants.AnnotationTProxyExcludeOutboundCIDRs: " 10.0.0.0/8, 3.3.3.3/24 ,",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "test",
+						},
+					},
+				},
+			},
+			expCfg: iptables.Config{
+				ConsulDNSIP:          "",
+				ProxyUserID:          strconv.Itoa(sidecarUserAndGroupID),
+				ProxyInboundPort:     constants.ProxyDefaultInboundPort,
+				ProxyOutboundPort:    iptables.DefaultTProxyOutboundPort,
+				ExcludeUIDs:          []string{strconv.Itoa(initContainersUserAndGroupID)},
+				ExcludeOutboundCIDRs: []string{"10.0.0.0/8", "3.3.3.3/24"},
+			},
+		},
+		{
+			name: "invalid outbound CIDR",
+			webhook: MeshWebhook{
+				Log:                   logrtest.New(t),
+				AllowK8sNamespacesSet: mapset.NewSetWith("*"),
+				DenyK8sNamespacesSet:  mapset.NewSet(),
+				decoder:               decoder,
+			},
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: defaultNamespace,
+					Name:      defaultPodName,
+					Annotations: map[string]string{
+						constants.AnnotationTProxyExcludeOutboundCIDRs: "3.3.3.3/24,db.example.com",
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "test",
+						},
+					},
+				},
+			},
+			expErr: fmt.Errorf("invalid value %q in annotation %s: must be an IP address or CIDR block",
+				"db.example.com", constants.AnnotationTProxyExcludeOutboundCIDRs),
+		},
 		{
 			name: "exclude UIDs",
 			webhook: MeshWebhook{
@@ -0,0 +1,113 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	// windowsContainerUser is the built-in non-administrator user of Windows container images.
+	windowsContainerUser = "ContainerUser"
+
+	// linuxInjectDir and windowsInjectDir are where the volume shared by the injected
+	// containers is mounted.
+	linuxInjectDir   = "/consul/connect-inject"
+	windowsInjectDir = `C:\consul\connect-inject`
+)
+
+// isWindowsPod returns true if the pod will be scheduled on a Windows node, either because
+// it sets the pod OS or because it selects Windows nodes.
+func isWindowsPod(pod corev1.Pod) bool {
+	if pod.Spec.OS != nil {
+		return pod.Spec.OS.Name == corev1.Windows
+	}
+	return pod.Spec.NodeSelector[corev1.LabelOSStable] == string(corev1.Windows)
+}
+
+// injectDir returns the path the volume shared by the injected containers is mounted at
+// in the containers of the pod.
+func injectDir(pod corev1.Pod) string {
+	if isWindowsPod(pod) {
+		return windowsInjectDir
+	}
+	return linuxInjectDir
+}
+
+// injectDirFile returns the path of the file in the volume shared by the injected containers.
+func injectDirFile(pod corev1.Pod, name string) string {
+	if isWindowsPod(pod) {
+		return windowsInjectDir + `\` + name
+	}
+	return linuxInjectDir + "/" + name
+}
+
+// prepareWindowsPod validates that a Windows pod can be injected and disables the features
+// that are not available on Windows. Transparent proxy relies on iptables, so it is turned off
+// unless the pod explicitly asks for it, in which case an error is returned.
+func (w *MeshWebhook) prepareWindowsPod(pod *corev1.Pod) error {
+	if w.ImageConsulK8SWindows == "" || w.ImageConsulDataplaneWindows == "" {
+		return errors.New("cannot inject Windows pod: Windows images for consul-k8s and consul-dataplane are not configured")
+	}
+
+	if raw, ok := pod.Annotations[constants.KeyTransparentProxy]; ok {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s annotation value of %s was invalid: %s", constants.KeyTransparentProxy, raw, err)
+		}
+		if enabled {
+			return fmt.Errorf("transparent proxy is not supported on Windows pods; set the %s annotation to \"false\"", constants.KeyTransparentProxy)
+		}
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[constants.KeyTransparentProxy] = "false"
+	return nil
+}
+
+// windowsInitContainerCommand returns the connect-init command for Windows pods. Windows
+// images have no /bin/sh, so the command is built as a list of arguments with the same flags
+// as initContainerCommandTpl, relying on Kubernetes to expand the environment variables.
+// Values are passed as is since there is no shell to quote them for.
+func windowsInitContainerCommand(data initContainerCommandData) []string {
+	args := []string{
+		"consul-k8s-control-plane", "connect-init",
+		"-pod-name=$(POD_NAME)",
+		"-pod-namespace=$(POD_NAMESPACE)",
+		"-log-level=" + data.LogLevel,
+		"-log-json=" + strconv.FormatBool(data.LogJSON),
+	}
+	if data.AuthMethod != "" {
+		args = append(args,
+			"-service-account-name="+data.ServiceAccountName,
+			"-service-name="+data.ServiceName)
+	}
+	if data.MultiPort {
+		args = append(args, "-multiport=true")
+	}
+	if data.ProxyIDFile != "" {
+		args = append(args, "-proxy-id-file="+data.ProxyIDFile)
+	}
+	if data.MultiPort && data.AuthMethod == "" {
+		args = append(args, "-service-name="+data.ServiceName)
+	}
+	return args
+}
+
+// windowsSecurityContext returns the security context for containers injected into Windows pods.
+// Linux-only fields such as RunAsUser are rejected by the API server for Windows pods.
+func windowsSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		WindowsOptions: &corev1.WindowsSecurityContextOptions{
+			RunAsUserName: pointer.String(windowsContainerUser),
+		},
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestIsWindowsPod(t *testing.T) {
+	cases := map[string]struct {
+		spec corev1.PodSpec
+		exp  bool
+	}{
+		"no OS or node selector": {
+			spec: corev1.PodSpec{},
+			exp:  false,
+		},
+		"pod OS windows": {
+			spec: corev1.PodSpec{OS: &corev1.PodOS{Name: corev1.Windows}},
+			exp:  true,
+		},
+		"pod OS linux": {
+			spec: corev1.PodSpec{OS: &corev1.PodOS{Name: corev1.Linux}},
+			exp:  false,
+		},
+		"node selector windows": {
+			spec: corev1.PodSpec{NodeSelector: map[string]string{corev1.LabelOSStable: "windows"}},
+			exp:  true,
+		},
+		"pod OS takes precedence over node selector": {
+			spec: corev1.PodSpec{
+				OS:           &corev1.PodOS{Name: corev1.Linux},
+				NodeSelector: map[string]string{corev1.LabelOSStable: "windows"},
+			},
+			exp: false,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.exp, isWindowsPod(corev1.Pod{Spec: c.spec}))
+		})
+	}
+}
+
+func TestPrepareWindowsPod(t *testing.T) {
+	cases := map[string]struct {
+		images      bool
+		annotations map[string]string
+		expErr      string
+	}{
+		"images not configured": {
+			images: false,
+			expErr: "cannot inject Windows pod: Windows images for consul-k8s and consul-dataplane are not configured",
+		},
+		"transparent proxy not set": {
+			images:      true,
+			annotations: map[string]string{},
+		},
+		"transparent proxy disabled": {
+			images:      true,
+			annotations: map[string]string{constants.KeyTransparentProxy: "false"},
+		},
+		"transparent proxy enabled": {
+			images:      true,
+			annotations: map[string]string{constants.KeyTransparentProxy: "true"},
+			expErr:      "transparent proxy is not supported on Windows pods; set the consul.hashicorp.com/transparent-proxy annotation to \"false\"",
+		},
+		"transparent proxy invalid": {
+			images:      true,
+			annotations: map[string]string{constants.KeyTransparentProxy: "foo"},
+			expErr:      "consul.hashicorp.com/transparent-proxy annotation value of foo was invalid",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			w := MeshWebhook{EnableTransparentProxy: true}
+			if c.images {
+				w.ImageConsulK8SWindows = "hashicorp/consul-k8s-control-plane:windows"
+				w.ImageConsulDataplaneWindows = "hashicorp/consul-dataplane:windows"
+			}
+			pod := minimal()
+			pod.Annotations = c.annotations
+			err := w.prepareWindowsPod(pod)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "false", pod.Annotations[constants.KeyTransparentProxy])
+		})
+	}
+}
+
+func TestHandlerContainers_Windows(t *testing.T) {
+	w := MeshWebhook{
+		ConsulConfig:                &consul.Config{HTTPPort: 8500, GRPCPort: 8502},
+		EnableTransparentProxy:      true,
+		AuthMethod:                  "an-auth-method",
+		LogLevel:                    "info",
+		ImageConsulK8S:              "hashicorp/consul-k8s-control-plane:linux",
+		ImageConsulK8SWindows:       "hashicorp/consul-k8s-control-plane:windows",
+		ImageConsulDataplane:        "hashicorp/consul-dataplane:linux",
+		ImageConsulDataplaneWindows: "hashicorp/consul-dataplane:windows",
+	}
+	pod := minimal()
+	pod.Spec.OS = &corev1.PodOS{Name: corev1.Windows}
+	pod.Spec.ServiceAccountName = "web"
+	pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
+		{
+			Name:      "sa",
+			MountPath: "/var/run/secrets/kubernetes.io/serviceaccount",
+		},
+	}
+	require.NoError(t, w.prepareWindowsPod(pod))
+
+	initContainer, err := w.containerInit(testNS, *pod, multiPortInfo{})
+	require.NoError(t, err)
+	require.Equal(t, "hashicorp/consul-k8s-control-plane:windows", initContainer.Image)
+	require.Equal(t, []string{
+		"consul-k8s-control-plane", "connect-init",
+		"-pod-name=$(POD_NAME)",
+		"-pod-namespace=$(POD_NAMESPACE)",
+		"-log-level=info",
+		"-log-json=false",
+		"-service-account-name=web",
+		"-service-name=foo",
+		`-proxy-id-file=C:\consul\connect-inject\proxyid`,
+	}, initContainer.Command)
+	require.Equal(t, `C:\consul\connect-inject`, initContainer.VolumeMounts[0].MountPath)
+	require.Equal(t, windowsSecurityContext(), initContainer.SecurityContext)
+	for _, env := range initContainer.Env {
+		require.NotEqual(t, "CONSUL_REDIRECT_TRAFFIC_CONFIG", env.Name)
+	}
+
+	sidecar, err := w.consulDataplaneSidecar(testNS, *pod, multiPortInfo{})
+	require.NoError(t, err)
+	require.Equal(t, "hashicorp/consul-dataplane:windows", sidecar.Image)
+	require.Equal(t, &corev1.SecurityContext{
+		WindowsOptions: &corev1.WindowsSecurityContextOptions{
+			RunAsUserName: pointer.String("ContainerUser"),
+		},
+	}, sidecar.SecurityContext)
+	require.Equal(t, `C:\consul\connect-inject`, sidecar.VolumeMounts[0].MountPath)
+	require.Contains(t, sidecar.Args, `-proxy-service-id-path=C:\consul\connect-inject\proxyid`)
+	require.Contains(t, sidecar.Env, corev1.EnvVar{Name: "TMPDIR", Value: `C:\consul\connect-inject`})
+}
+
+func TestWindowsInitContainerCommand_MultiPort(t *testing.T) {
+	cmd := windowsInitContainerCommand(initContainerCommandData{
+		ServiceName: "web-admin",
+		MultiPort:   true,
+		ProxyIDFile: `C:\consul\connect-inject\proxyid-web-admin`,
+		LogLevel:    "debug",
+		LogJSON:     true,
+	})
+	require.Equal(t, []string{
+		"consul-k8s-control-plane", "connect-init",
+		"-pod-name=$(POD_NAME)",
+		"-pod-namespace=$(POD_NAMESPACE)",
+		"-log-level=debug",
+		"-log-json=true",
+		"-multiport=true",
+		`-proxy-id-file=C:\consul\connect-inject\proxyid-web-admin`,
+		"-service-name=web-admin",
+	}, cmd)
+}
+
+func TestWindowsInitContainerCommand_ACLs(t *testing.T) {
+	cmd := windowsInitContainerCommand(initContainerCommandData{
+		ServiceName:        "web",
+		ServiceAccountName: "web",
+		AuthMethod:         "auth-method",
+		ProxyIDFile:        `C:\Program Files\consul\proxyid`,
+		LogLevel:           "info",
+	})
+	// Each flag is a single argument even if its value contains spaces.
+	require.Equal(t, []string{
+		"consul-k8s-control-plane", "connect-init",
+		"-pod-name=$(POD_NAME)",
+		"-pod-namespace=$(POD_NAMESPACE)",
+		"-log-level=info",
+		"-log-json=false",
+		"-service-account-name=web",
+		"-service-name=web",
+		`-proxy-id-file=C:\Program Files\consul\proxyid`,
+	}, cmd)
+}
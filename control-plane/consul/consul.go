@@ -4,13 +4,18 @@
 package consul
 
 import (
+	"bytes"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/consul-k8s/control-plane/version"
 	"github.com/hashicorp/consul-server-connection-manager/discovery"
 	capi "github.com/hashicorp/consul/api"
+	"golang.org/x/time/rate"
 )
 
 //go:generate mockery --name ServerConnectionManager --inpkg
@@ -23,6 +28,10 @@ type ServerConnectionManager interface {
 // NewClient returns a Consul API client. It adds a required User-Agent
 // header that describes the version of consul-k8s making the call.
 func NewClient(config *capi.Config, consulAPITimeout time.Duration) (*capi.Client, error) {
+	return newClient(config, consulAPITimeout, nil, nil)
+}
+
+func newClient(config *capi.Config, consulAPITimeout time.Duration, limiter *rate.Limiter, policy *RequestPolicy) (*capi.Client, error) {
 	if consulAPITimeout <= 0 {
 		// This is only here as a last resort scenario.  This should not get
 		// triggered because all components should pass the value.
@@ -52,6 +61,12 @@ func NewClient(config *capi.Config, consulAPITimeout time.Duration) (*capi.Clien
 		config.Transport.TLSClientConfig = tlsClientConfig
 	}
 	config.HttpClient.Transport = config.Transport
+	if limiter != nil {
+		config.HttpClient.Transport = &rateLimitedTransport{limiter: limiter, transport: config.Transport}
+	}
+	if policy != nil {
+		config.HttpClient.Transport = &policyTransport{policy: policy, transport: config.HttpClient.Transport}
+	}
 
 	client, err := capi.NewClient(config)
 	if err != nil {
@@ -66,18 +81,39 @@ type Config struct {
 	HTTPPort        int
 	GRPCPort        int
 	APITimeout      time.Duration
+	// RateLimiter, if set, limits the rate of requests made by every client created from this
+	// config. It is shared between clients so that it applies to the process as a whole.
+	RateLimiter *rate.Limiter
+	// RequestPolicy, if set, retries requests that failed because of a server failure and
+	// rejects requests to endpoints whose circuit breaker is open. Like RateLimiter, it is
+	// shared between clients.
+	RequestPolicy *RequestPolicy
 }
 
 // todo (ishustava): replace all usages of this one.
 // NewClientFromConnMgrState creates a new API client with an IP address from the state
-// of the consul-server-connection-manager.
+// of the consul-server-connection-manager. The config is shared by every controller and
+// webhook, so the client is created from a copy of it rather than by setting the address
+// and token of the state on the config itself.
 func NewClientFromConnMgrState(config *Config, state discovery.State) (*capi.Client, error) {
-	ipAddress := state.Address.IP
-	config.APIClientConfig.Address = fmt.Sprintf("%s:%d", ipAddress.String(), config.HTTPPort)
+	apiConfig := *config.APIClientConfig
+	apiConfig.Address = fmt.Sprintf("%s:%d", state.Address.IP.String(), config.HTTPPort)
 	if state.Token != "" {
-		config.APIClientConfig.Token = state.Token
+		apiConfig.Token = state.Token
+	}
+	if apiConfig.HttpClient != nil {
+		httpClient := *apiConfig.HttpClient
+		if httpClient.Timeout == 0 {
+			httpClient.Timeout = config.APITimeout
+		}
+		apiConfig.HttpClient = &httpClient
 	}
-	return NewClient(config.APIClientConfig, config.APITimeout)
+	transport, err := clientTransport(&apiConfig)
+	if err != nil {
+		return nil, err
+	}
+	apiConfig.Transport = transport
+	return newClient(&apiConfig, config.APITimeout, config.RateLimiter, config.RequestPolicy)
 }
 
 // NewClientFromConnMgr creates a new API client by first getting the state of the passed watcher.
@@ -93,3 +129,88 @@ func NewClientFromConnMgr(config *Config, watcher ServerConnectionManager) (*cap
 	}
 	return consulClient, nil
 }
+
+const (
+	// dialTimeout and tlsHandshakeTimeout bound how long the shared transports wait for
+	// a connection to a Consul server. The time to wait for a response is bounded by the
+	// timeout of the HTTP client, which is the API timeout.
+	dialTimeout         = 10 * time.Second
+	tlsHandshakeTimeout = 10 * time.Second
+)
+
+// sharedTransport is a transport shared by the clients created from configs with the
+// same TLS settings, along with the CA cert it was created with.
+type sharedTransport struct {
+	caPem     []byte
+	transport *http.Transport
+}
+
+var (
+	sharedTransportsLock sync.Mutex
+	// sharedTransports are keyed by the TLS settings of the configs they were created for.
+	sharedTransports = make(map[string]*sharedTransport)
+)
+
+// clientTransport returns the transport for a client created from config without modifying
+// the transport of config. Configs without a transport share one per TLS settings so that
+// their clients reuse connections. The shared transport is replaced when the contents of
+// the CA cert file change.
+func clientTransport(config *capi.Config) (*http.Transport, error) {
+	if config.Transport != nil {
+		if config.Transport.TLSClientConfig != nil {
+			return config.Transport, nil
+		}
+		tlsClientConfig, err := capi.SetupTLSConfig(&config.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		transport := config.Transport.Clone()
+		transport.TLSClientConfig = tlsClientConfig
+		return transport, nil
+	}
+
+	var caPem []byte
+	if config.TLSConfig.CAFile != "" {
+		var err error
+		caPem, err = os.ReadFile(config.TLSConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file: %w", err)
+		}
+	}
+	key := fmt.Sprintf("%#v", config.TLSConfig)
+
+	sharedTransportsLock.Lock()
+	defer sharedTransportsLock.Unlock()
+	shared, ok := sharedTransports[key]
+	if ok && bytes.Equal(shared.caPem, caPem) {
+		return shared.transport, nil
+	}
+	tlsClientConfig, err := capi.SetupTLSConfig(&config.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		shared.transport.CloseIdleConnections()
+	}
+	transport := &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout: tlsHandshakeTimeout,
+		TLSClientConfig:     tlsClientConfig,
+	}
+	sharedTransports[key] = &sharedTransport{caPem: caPem, transport: transport}
+	return transport, nil
+}
+
+// rateLimitedTransport is an http.RoundTripper that waits on a rate limiter
+// before sending each request.
+type rateLimitedTransport struct {
+	limiter   *rate.Limiter
+	transport http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.transport.RoundTrip(req)
+}
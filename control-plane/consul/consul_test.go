@@ -4,14 +4,20 @@
 package consul
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul-k8s/control-plane/version"
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
 	capi "github.com/hashicorp/consul/api"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 func TestNewClient(t *testing.T) {
@@ -68,3 +74,106 @@ func TestNewClient_httpClientDefaultTimeout(t *testing.T) {
 	require.Error(t, err, "Get \"http://126.0.0.1/v1/agent/checks\": context deadline exceeded (Client.Timeout exceeded while awaiting headers)")
 
 }
+
+func TestNewClientFromConnMgrState_RateLimiter(t *testing.T) {
+	var calls int
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintln(w, "\"leader\"")
+	}))
+	defer consulServer.Close()
+	serverURL, err := url.Parse(consulServer.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+	addr, err := discovery.MakeAddr(serverURL.Hostname(), port)
+	require.NoError(t, err)
+
+	// Allow a single request and then block all further requests.
+	cfg := &Config{
+		APIClientConfig: capi.DefaultConfig(),
+		HTTPPort:        port,
+		APITimeout:      time.Second,
+		RateLimiter:     rate.NewLimiter(rate.Every(time.Hour), 1),
+	}
+	client, err := NewClientFromConnMgrState(cfg, discovery.State{Address: addr})
+	require.NoError(t, err)
+
+	_, err = client.Status().Leader()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = client.Status().LeaderWithQueryOptions((&capi.QueryOptions{}).WithContext(ctx))
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestNewClientFromConnMgrState_DoesNotModifyConfig(t *testing.T) {
+	var tokens []string
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("X-Consul-Token"))
+		fmt.Fprintln(w, "\"leader\"")
+	}))
+	defer consulServer.Close()
+	serverURL, err := url.Parse(consulServer.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+	addr, err := discovery.MakeAddr(serverURL.Hostname(), port)
+	require.NoError(t, err)
+
+	cfg := &Config{
+		APIClientConfig: &capi.Config{Scheme: "http"},
+		HTTPPort:        port,
+		APITimeout:      time.Second,
+	}
+	clientOne, err := NewClientFromConnMgrState(cfg, discovery.State{Address: addr, Token: "token-one"})
+	require.NoError(t, err)
+	clientTwo, err := NewClientFromConnMgrState(cfg, discovery.State{Address: addr, Token: "token-two"})
+	require.NoError(t, err)
+
+	_, err = clientOne.Status().Leader()
+	require.NoError(t, err)
+	_, err = clientTwo.Status().Leader()
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"token-one", "token-two"}, tokens)
+	require.Equal(t, &capi.Config{Scheme: "http"}, cfg.APIClientConfig)
+}
+
+func TestNewClientFromConnMgrState_Timeouts(t *testing.T) {
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+		fmt.Fprintln(w, "\"leader\"")
+	}))
+	defer consulServer.Close()
+	serverURL, err := url.Parse(consulServer.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+	addr, err := discovery.MakeAddr(serverURL.Hostname(), port)
+	require.NoError(t, err)
+
+	for name, apiConfig := range map[string]*capi.Config{
+		"without HTTP client": {Scheme: "http"},
+		"with HTTP client":    {Scheme: "http", HttpClient: &http.Client{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := &Config{
+				APIClientConfig: apiConfig,
+				HTTPPort:        port,
+				APITimeout:      100 * time.Millisecond,
+			}
+			client, err := NewClientFromConnMgrState(cfg, discovery.State{Address: addr})
+			require.NoError(t, err)
+			_, err = client.Status().Leader()
+			require.ErrorContains(t, err, "Client.Timeout exceeded")
+		})
+	}
+
+	transport, err := clientTransport(&capi.Config{})
+	require.NoError(t, err)
+	require.NotNil(t, transport.DialContext)
+	require.Equal(t, tlsHandshakeTimeout, transport.TLSHandshakeTimeout)
+}
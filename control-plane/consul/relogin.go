@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"time"
+
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ReloginConnMgr is a ServerConnectionManager that logs in to Consul again
+// periodically, so that its ACL token is rotated.
+//
+// Each time, a new server connection manager is started, which logs in with a
+// new token, and replaces the old one once it's connected to the servers. The
+// old one logs out when it's stopped, which deletes its token.
+type ReloginConnMgr struct {
+	connMgrSwapper
+
+	// Interval is how often to log in again.
+	Interval time.Duration
+	// NewConnMgr creates a server connection manager that logs in to Consul.
+	NewConnMgr func() (ServerConnectionManager, error)
+	// Log is the logger for the logins.
+	Log hclog.Logger
+}
+
+// State returns the state of the current connection manager. It blocks until
+// there is one.
+func (m *ReloginConnMgr) State() (discovery.State, error) {
+	return m.connMgrSwapper.State()
+}
+
+// Run starts a connection manager and replaces it every interval, until Stop
+// is called.
+func (m *ReloginConnMgr) Run() {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for first := true; ; first = false {
+		m.relogin(first)
+		select {
+		case <-m.stopped():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *ReloginConnMgr) relogin(first bool) {
+	connMgr, err := m.NewConnMgr()
+	if err != nil {
+		m.Log.Error("unable to create Consul server watcher", "error", err)
+		return
+	}
+	if err := m.swap(connMgr); err != nil {
+		m.Log.Error("unable to log in to Consul again, keeping the current ACL token", "error", err)
+		return
+	}
+	if !first {
+		m.Log.Info("logged in to Consul again, the ACL token was rotated")
+	}
+}
+
+// Stop stops the current connection manager.
+func (m *ReloginConnMgr) Stop() {
+	m.connMgrSwapper.Stop()
+}
@@ -0,0 +1,254 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// maxRetryBackoff caps the exponential backoff between retries of a request.
+	maxRetryBackoff = 5 * time.Second
+
+	rejectReasonCircuitOpen = "circuit_open"
+	rejectReasonRetryBudget = "retry_budget"
+)
+
+var (
+	retriedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_k8s_consul_api_retried_requests_total",
+		Help: "Number of requests to the Consul HTTP API that were retried after a server failure.",
+	}, []string{"endpoint"})
+	rejectedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "consul_k8s_consul_api_rejected_requests_total",
+		Help: "Number of requests or retries to the Consul HTTP API that were not sent because the endpoint's " +
+			"circuit breaker was open or the retry budget was exhausted.",
+	}, []string{"endpoint", "reason"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(retriedRequests, rejectedRequests)
+}
+
+// RequestPolicy configures how requests to the Consul HTTP API are retried and when they are
+// rejected without being sent so that controllers do not bury a degraded Consul server under retries.
+// A policy is shared between all clients created from a Config so that the retry budget and the
+// circuit breakers apply to the process as a whole.
+//
+// Only connection errors and 429, 502, 503 and 504 responses are considered server failures.
+// Other errors, such as a 500 returned for an invalid config entry, are returned as is.
+//
+// Requests that are not idempotent, such as a PUT that creates an ACL token, may have been
+// applied by the server even though they failed with a connection error, 502 or 504, so they
+// are only retried if the connection could not be established or the server rejected them
+// with a 429 or 503 without processing them.
+//
+// The retries of a request, including the backoff between them, count towards the timeout
+// of the client's http.Client (the Consul API timeout), so a request is never retried past it.
+type RequestPolicy struct {
+	// MaxRetries is the number of times a request that failed with a server failure is retried.
+	// Defaults to 0 which does not retry requests.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry of a request. It doubles on each
+	// further retry up to 5s, or until the Consul API timeout expires.
+	RetryBackoff time.Duration
+	// RetryBudget, if set, limits the rate of retries across all requests. Retries above
+	// the budget are not made and the failure is returned to the caller instead.
+	RetryBudget *rate.Limiter
+	// CircuitBreakerThreshold is the number of consecutive server failures of an endpoint
+	// after which requests to that endpoint are rejected. Defaults to 0 which disables circuit breaking.
+	CircuitBreakerThreshold int
+	// CircuitBreakerOpenDuration is how long requests to an endpoint are rejected once its
+	// circuit breaker is open. After that, a single request is let through and the circuit
+	// breaker closes again if it succeeds.
+	CircuitBreakerOpenDuration time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// CircuitOpenError is returned for requests rejected because the circuit breaker
+// of their endpoint is open.
+type CircuitOpenError struct {
+	Endpoint string
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker for Consul API endpoint %q is open", e.Endpoint)
+}
+
+func (p *RequestPolicy) breaker(endpoint string) *circuitBreaker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.breakers == nil {
+		p.breakers = make(map[string]*circuitBreaker)
+	}
+	b, ok := p.breakers[endpoint]
+	if !ok {
+		b = &circuitBreaker{threshold: p.CircuitBreakerThreshold, openDuration: p.CircuitBreakerOpenDuration}
+		p.breakers[endpoint] = b
+	}
+	return b
+}
+
+// circuitBreaker tracks consecutive server failures of a single endpoint.
+type circuitBreaker struct {
+	threshold    int
+	openDuration time.Duration
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	openedAt time.Time
+	probing  bool
+}
+
+// allow returns whether a request may be sent. Once the breaker has been open for
+// openDuration, a single probe request is allowed until its result is recorded.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true
+	}
+	if b.probing || now.Sub(b.openedAt) < b.openDuration {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *circuitBreaker) record(success bool, now time.Time) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if success {
+		b.failures = 0
+		b.open = false
+		b.probing = false
+		return
+	}
+	b.failures++
+	if b.probing || b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = now
+		b.probing = false
+	}
+}
+
+// endpointKey groups requests by method and the first three segments of their path,
+// e.g. "GET /v1/catalog/service", so that the name of the resource being requested
+// does not create a separate circuit breaker.
+func endpointKey(req *http.Request) string {
+	segments := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 4)
+	if len(segments) > 3 {
+		segments = segments[:3]
+	}
+	return req.Method + " /" + strings.Join(segments, "/")
+}
+
+// isServerFailure returns whether a request failed because the Consul server
+// was unavailable or overloaded.
+func isServerFailure(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// A request cancelled by its caller does not say anything about the server.
+		return req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isRetryable returns whether a request that failed with a server failure can be sent again
+// without applying it twice.
+func isRetryable(req *http.Request, resp *http.Response, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	if err != nil {
+		// The request was not sent if the connection could not be established.
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// policyTransport is an http.RoundTripper that applies a RequestPolicy to each request.
+// It wraps the rate limited transport so that retries count towards the rate limit too.
+type policyTransport struct {
+	policy    *RequestPolicy
+	transport http.RoundTripper
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := endpointKey(req)
+	breaker := t.policy.breaker(endpoint)
+	backoff := t.policy.RetryBackoff
+
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		if !breaker.allow(time.Now()) {
+			rejectedRequests.WithLabelValues(endpoint, rejectReasonCircuitOpen).Inc()
+			return nil, &CircuitOpenError{Endpoint: endpoint}
+		}
+
+		resp, err := t.transport.RoundTrip(attemptReq)
+		failed := isServerFailure(req, resp, err)
+		breaker.record(!failed, time.Now())
+
+		// Requests with a body can only be retried if the body can be read again.
+		canRetry := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if !failed || attempt >= t.policy.MaxRetries || !canRetry || !isRetryable(req, resp, err) {
+			return resp, err
+		}
+		if t.policy.RetryBudget != nil && !t.policy.RetryBudget.Allow() {
+			rejectedRequests.WithLabelValues(endpoint, rejectReasonRetryBudget).Inc()
+			return resp, err
+		}
+
+		if resp != nil {
+			// Drain the body so that the connection can be reused.
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(backoff):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+
+		attemptReq = req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+		retriedRequests.WithLabelValues(endpoint).Inc()
+	}
+}
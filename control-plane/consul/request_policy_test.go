@@ -0,0 +1,275 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	capi "github.com/hashicorp/consul/api"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// newPolicyTestServer returns a server that responds with the given status codes in order
+// and then with 200, and a func returning the number of requests it received.
+func newPolicyTestServer(t *testing.T, statusCodes ...int) (*httptest.Server, func() int) {
+	var mu sync.Mutex
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls <= len(statusCodes) {
+			w.WriteHeader(statusCodes[calls-1])
+			return
+		}
+		fmt.Fprintln(w, "\"leader\"")
+	}))
+	t.Cleanup(server.Close)
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls
+	}
+}
+
+func newPolicyTestClient(t *testing.T, address string, policy *RequestPolicy) *capi.Client {
+	cfg := capi.DefaultConfig()
+	cfg.Address = address
+	client, err := newClient(cfg, time.Second, nil, policy)
+	require.NoError(t, err)
+	return client
+}
+
+func TestRequestPolicy_Retries(t *testing.T) {
+	cases := map[string]struct {
+		statusCodes []int
+		maxRetries  int
+		budget      *rate.Limiter
+		expErr      bool
+		expCalls    int
+	}{
+		"retries server failures until the request succeeds": {
+			statusCodes: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			maxRetries:  2,
+			expCalls:    3,
+		},
+		"returns the failure after max retries": {
+			statusCodes: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			maxRetries:  2,
+			expErr:      true,
+			expCalls:    3,
+		},
+		"does not retry other errors": {
+			statusCodes: []int{http.StatusInternalServerError},
+			maxRetries:  2,
+			expErr:      true,
+			expCalls:    1,
+		},
+		"does not retry when retries are disabled": {
+			statusCodes: []int{http.StatusServiceUnavailable},
+			expErr:      true,
+			expCalls:    1,
+		},
+		"does not retry once the retry budget is exhausted": {
+			statusCodes: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			maxRetries:  2,
+			budget:      rate.NewLimiter(rate.Every(time.Hour), 1),
+			expErr:      true,
+			expCalls:    2,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server, calls := newPolicyTestServer(t, c.statusCodes...)
+			client := newPolicyTestClient(t, server.URL, &RequestPolicy{
+				MaxRetries:   c.maxRetries,
+				RetryBackoff: time.Millisecond,
+				RetryBudget:  c.budget,
+			})
+
+			_, err := client.Status().Leader()
+			if c.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, c.expCalls, calls())
+		})
+	}
+}
+
+func TestRequestPolicy_RetriesReplayBody(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "true")
+	}))
+	t.Cleanup(server.Close)
+	client := newPolicyTestClient(t, server.URL, &RequestPolicy{MaxRetries: 1, RetryBackoff: time.Millisecond})
+
+	retriedBefore := testutil.ToFloat64(retriedRequests.WithLabelValues("PUT /v1/kv/key"))
+	_, err := client.KV().Put(&capi.KVPair{Key: "key", Value: []byte("value")}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"value", "value"}, bodies)
+	require.Equal(t, retriedBefore+1, testutil.ToFloat64(retriedRequests.WithLabelValues("PUT /v1/kv/key")))
+}
+
+func TestRequestPolicy_NonIdempotentRequests(t *testing.T) {
+	cases := map[string]struct {
+		statusCode int
+		expCalls   int
+	}{
+		"retries 429": {
+			statusCode: http.StatusTooManyRequests,
+			expCalls:   2,
+		},
+		"retries 503": {
+			statusCode: http.StatusServiceUnavailable,
+			expCalls:   2,
+		},
+		"does not retry 502": {
+			statusCode: http.StatusBadGateway,
+			expCalls:   1,
+		},
+		"does not retry 504": {
+			statusCode: http.StatusGatewayTimeout,
+			expCalls:   1,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			server, calls := newPolicyTestServer(t, c.statusCode)
+			client := newPolicyTestClient(t, server.URL, &RequestPolicy{MaxRetries: 1, RetryBackoff: time.Millisecond})
+
+			_, _ = client.KV().Put(&capi.KVPair{Key: "key", Value: []byte("value")}, nil)
+			require.Equal(t, c.expCalls, calls())
+		})
+	}
+}
+
+func TestRequestPolicy_ConnectionErrors(t *testing.T) {
+	// The server closes the connection after reading the request, so it may have been applied.
+	var mu sync.Mutex
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	t.Cleanup(server.Close)
+	client := newPolicyTestClient(t, server.URL, &RequestPolicy{MaxRetries: 1, RetryBackoff: time.Millisecond})
+
+	_, err := client.Status().Leader()
+	require.Error(t, err)
+	mu.Lock()
+	require.Equal(t, 2, calls)
+	calls = 0
+	mu.Unlock()
+
+	_, err = client.KV().Put(&capi.KVPair{Key: "key", Value: []byte("value")}, nil)
+	require.Error(t, err)
+	mu.Lock()
+	require.Equal(t, 1, calls)
+	mu.Unlock()
+}
+
+func TestIsRetryable_DialErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/v1/acl/token", nil)
+	dialErr := &url.Error{Op: "Put", URL: "/v1/acl/token", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	require.True(t, isRetryable(req, nil, dialErr))
+
+	readErr := &url.Error{Op: "Put", URL: "/v1/acl/token", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}}
+	require.False(t, isRetryable(req, nil, readErr))
+	require.False(t, isRetryable(req, nil, io.EOF))
+}
+
+func TestRequestPolicy_CircuitBreaker(t *testing.T) {
+	server, calls := newPolicyTestServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	client := newPolicyTestClient(t, server.URL, &RequestPolicy{
+		CircuitBreakerThreshold:    2,
+		CircuitBreakerOpenDuration: 100 * time.Millisecond,
+	})
+	endpoint := "GET /v1/status/leader"
+	rejectedBefore := testutil.ToFloat64(rejectedRequests.WithLabelValues(endpoint, rejectReasonCircuitOpen))
+
+	// The circuit breaker opens after two consecutive failures.
+	for i := 0; i < 2; i++ {
+		_, err := client.Status().Leader()
+		require.Error(t, err)
+	}
+	_, err := client.Status().Leader()
+	var circuitErr *CircuitOpenError
+	require.True(t, errors.As(err, &circuitErr), "unexpected error: %v", err)
+	require.Equal(t, endpoint, circuitErr.Endpoint)
+	require.Equal(t, 2, calls())
+	require.Equal(t, rejectedBefore+1, testutil.ToFloat64(rejectedRequests.WithLabelValues(endpoint, rejectReasonCircuitOpen)))
+
+	// Other endpoints are not affected.
+	_, _, err = client.KV().Get("key", nil)
+	require.False(t, errors.As(err, &circuitErr), "unexpected error: %v", err)
+	require.Equal(t, 3, calls())
+
+	// Once the open duration has passed, a request is let through and closes the circuit breaker.
+	time.Sleep(100 * time.Millisecond)
+	leader, err := client.Status().Leader()
+	require.NoError(t, err)
+	require.Equal(t, "leader", leader)
+}
+
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	b := &circuitBreaker{threshold: 1, openDuration: time.Minute}
+	now := time.Now()
+
+	require.True(t, b.allow(now))
+	b.record(false, now)
+	require.False(t, b.allow(now))
+
+	// Only a single probe is allowed once the open duration has passed.
+	later := now.Add(time.Minute)
+	require.True(t, b.allow(later))
+	require.False(t, b.allow(later))
+
+	// A failed probe opens the circuit breaker for another open duration.
+	b.record(false, later)
+	require.False(t, b.allow(later.Add(time.Second)))
+	require.True(t, b.allow(later.Add(time.Minute)))
+}
+
+func TestEndpointKey(t *testing.T) {
+	cases := map[string]string{
+		"/v1/status/leader":                  "GET /v1/status/leader",
+		"/v1/config/service-defaults/foo":    "GET /v1/config/service-defaults",
+		"/v1/catalog/node-services/node/abc": "GET /v1/catalog/node-services",
+		"/v1/agent":                          "GET /v1/agent",
+	}
+	for path, exp := range cases {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			require.Equal(t, exp, endpointKey(req))
+		})
+	}
+}
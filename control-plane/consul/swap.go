@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+)
+
+// connMgrSwapper holds the current connection manager of a server connection
+// manager that replaces it over time, e.g. to change its ACL token.
+type connMgrSwapper struct {
+	lock    sync.Mutex
+	connMgr ServerConnectionManager
+	// ready is closed once the first connection manager is set.
+	ready    chan struct{}
+	stopCh   chan struct{}
+	initOnce sync.Once
+	stopOnce sync.Once
+}
+
+func (s *connMgrSwapper) init() {
+	s.initOnce.Do(func() {
+		s.ready = make(chan struct{})
+		s.stopCh = make(chan struct{})
+	})
+}
+
+// State returns the state of the current connection manager. It blocks until
+// there is one.
+func (s *connMgrSwapper) State() (discovery.State, error) {
+	s.init()
+	select {
+	case <-s.ready:
+	case <-s.stopCh:
+		return discovery.State{}, errors.New("the server connection manager was stopped")
+	}
+	s.lock.Lock()
+	connMgr := s.connMgr
+	s.lock.Unlock()
+	return connMgr.State()
+}
+
+// swap starts connMgr and makes it the current connection manager. If there
+// already is one, it's kept until connMgr is connected so that State doesn't
+// fail in the meantime, and then stopped.
+func (s *connMgrSwapper) swap(connMgr ServerConnectionManager) error {
+	s.init()
+	go connMgr.Run()
+	s.lock.Lock()
+	hasCurrent := s.connMgr != nil
+	s.lock.Unlock()
+	if hasCurrent {
+		if _, err := connMgr.State(); err != nil {
+			connMgr.Stop()
+			return err
+		}
+	}
+
+	s.lock.Lock()
+	select {
+	case <-s.stopCh:
+		s.lock.Unlock()
+		connMgr.Stop()
+		return errors.New("the server connection manager was stopped")
+	default:
+	}
+	old := s.connMgr
+	s.connMgr = connMgr
+	s.lock.Unlock()
+	if old == nil {
+		close(s.ready)
+	} else {
+		old.Stop()
+	}
+	return nil
+}
+
+// stopped returns a channel that is closed when Stop is called.
+func (s *connMgrSwapper) stopped() <-chan struct{} {
+	s.init()
+	return s.stopCh
+}
+
+// Stop stops the current connection manager.
+func (s *connMgrSwapper) Stop() {
+	s.init()
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if s.connMgr != nil {
+			s.connMgr.Stop()
+		}
+	})
+}
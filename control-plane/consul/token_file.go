@@ -0,0 +1,89 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	"github.com/hashicorp/go-hclog"
+)
+
+// TokenFileConnMgr is a ServerConnectionManager that uses the ACL token in a
+// file, such as a mounted Secret whose token is rotated.
+//
+// A server connection manager can't change its token once it's started, so
+// whenever the token in the file changes, a new one is started with the new
+// token and replaces the old one once it's connected to the servers.
+type TokenFileConnMgr struct {
+	connMgrSwapper
+
+	// TokenFile is the path to the file with the ACL token.
+	TokenFile string
+	// Interval is how often the file is checked for a new token.
+	Interval time.Duration
+	// NewConnMgr creates a server connection manager with the given token.
+	NewConnMgr func(token string) (ServerConnectionManager, error)
+	// Log is the logger for the token changes.
+	Log hclog.Logger
+
+	// token is the token of the current connection manager. It's only used
+	// by Run.
+	token string
+}
+
+// State returns the state of the current connection manager. It blocks until
+// the file has a token.
+func (m *TokenFileConnMgr) State() (discovery.State, error) {
+	return m.connMgrSwapper.State()
+}
+
+// Run starts a connection manager with the token in the file and replaces it
+// whenever the token changes, until Stop is called.
+func (m *TokenFileConnMgr) Run() {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		m.reload()
+		select {
+		case <-m.stopped():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reload starts a connection manager with the token in the file if it changed.
+func (m *TokenFileConnMgr) reload() {
+	contents, err := os.ReadFile(m.TokenFile)
+	if err != nil {
+		m.Log.Error("unable to read ACL token file", "file", m.TokenFile, "error", err)
+		return
+	}
+	token := strings.TrimSpace(string(contents))
+	if token == "" || token == m.token {
+		return
+	}
+
+	connMgr, err := m.NewConnMgr(token)
+	if err != nil {
+		m.Log.Error("unable to create Consul server watcher", "error", err)
+		return
+	}
+	if err := m.swap(connMgr); err != nil {
+		m.Log.Error("unable to connect to Consul servers with the new ACL token", "error", err)
+		return
+	}
+	if m.token != "" {
+		m.Log.Info("the ACL token changed, replaced the Consul server watcher")
+	}
+	m.token = token
+}
+
+// Stop stops the current connection manager.
+func (m *TokenFileConnMgr) Stop() {
+	m.connMgrSwapper.Stop()
+}
@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consul
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenConnMgr is a connection manager whose state has the token it was
+// created with.
+type tokenConnMgr struct {
+	token   string
+	lock    sync.Mutex
+	stopped bool
+}
+
+func (c *tokenConnMgr) State() (discovery.State, error) {
+	return discovery.State{Token: c.token}, nil
+}
+
+func (c *tokenConnMgr) Run() {}
+
+func (c *tokenConnMgr) Stop() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stopped = true
+}
+
+func (c *tokenConnMgr) isStopped() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.stopped
+}
+
+func TestTokenFileConnMgr(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, nil, 0600))
+
+	var lock sync.Mutex
+	var created []*tokenConnMgr
+	connMgr := &TokenFileConnMgr{
+		TokenFile: tokenFile,
+		Interval:  10 * time.Millisecond,
+		NewConnMgr: func(token string) (ServerConnectionManager, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			c := &tokenConnMgr{token: token}
+			created = append(created, c)
+			return c, nil
+		},
+		Log: hclog.NewNullLogger(),
+	}
+	go connMgr.Run()
+	defer connMgr.Stop()
+
+	// State blocks until the file has a token.
+	stateCh := make(chan discovery.State)
+	go func() {
+		state, err := connMgr.State()
+		require.NoError(t, err)
+		stateCh <- state
+	}()
+	select {
+	case <-stateCh:
+		t.Fatal("State returned before the file had a token")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-one\n"), 0600))
+	require.Equal(t, "token-one", (<-stateCh).Token)
+
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-two"), 0600))
+	require.Eventually(t, func() bool {
+		state, err := connMgr.State()
+		return err == nil && state.Token == "token-two"
+	}, time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	require.Len(t, created, 2)
+	first, second := created[0], created[1]
+	lock.Unlock()
+	require.True(t, first.isStopped())
+	require.False(t, second.isStopped())
+
+	connMgr.Stop()
+	require.True(t, second.isStopped())
+}
+
+func TestReloginConnMgr(t *testing.T) {
+	var lock sync.Mutex
+	var created []*tokenConnMgr
+	connMgr := &ReloginConnMgr{
+		Interval: 20 * time.Millisecond,
+		NewConnMgr: func() (ServerConnectionManager, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			c := &tokenConnMgr{token: fmt.Sprintf("token-%d", len(created))}
+			created = append(created, c)
+			return c, nil
+		},
+		Log: hclog.NewNullLogger(),
+	}
+	go connMgr.Run()
+	defer connMgr.Stop()
+
+	state, err := connMgr.State()
+	require.NoError(t, err)
+	require.Equal(t, "token-0", state.Token)
+
+	require.Eventually(t, func() bool {
+		state, err := connMgr.State()
+		return err == nil && state.Token != "token-0"
+	}, time.Second, 10*time.Millisecond)
+	lock.Lock()
+	first := created[0]
+	lock.Unlock()
+	require.True(t, first.isStopped())
+
+	connMgr.Stop()
+	lock.Lock()
+	last := created[len(created)-1]
+	lock.Unlock()
+	require.True(t, last.isStopped())
+}
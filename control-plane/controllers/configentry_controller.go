@@ -6,12 +6,16 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/hashicorp/consul-k8s/control-plane/api/common"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 	capi "github.com/hashicorp/consul/api"
@@ -20,6 +24,7 @@ import (
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,6 +37,19 @@ const (
 	ConsulAgentError             = "ConsulAgentError"
 	ExternallyManagedConfigError = "ExternallyManagedConfigError"
 	MigrationFailedError         = "MigrationFailedError"
+
+	// ConsulValidationError is the reason of the Validated condition when
+	// Consul rejects the config entry as invalid.
+	ConsulValidationError = "ConsulValidationError"
+
+	// DriftCorrected is the reason of the event recorded when a config entry
+	// that was modified or deleted outside of Kubernetes is rewritten to
+	// match its resource.
+	DriftCorrected = "DriftCorrected"
+
+	// KubernetesError is the reason of the Synced condition when resolving
+	// the resource's references to other Kubernetes resources fails.
+	KubernetesError = "KubernetesError"
 )
 
 // Controller is implemented by CRD-specific controllers. It is used by
@@ -50,6 +68,14 @@ type Controller interface {
 	Logger(types.NamespacedName) logr.Logger
 }
 
+// Resolver is implemented by CRD-specific controllers whose config entries
+// depend on other Kubernetes resources, e.g. services selected by labels.
+// Resolve is called before the config entry is built so that it can look up
+// those resources and set them on the resource.
+type Resolver interface {
+	Resolve(ctx context.Context, configEntry common.ConfigEntryResource) error
+}
+
 // ConfigEntryController is a generic controller that is used to reconcile
 // all config entry types, e.g. ServiceDefaults, ServiceResolver, etc, since
 // they share the same reconcile behaviour.
@@ -87,8 +113,22 @@ type ConfigEntryController struct {
 	// any created Consul namespaces to allow cross namespace service discovery.
 	// Only necessary if ACLs are enabled.
 	CrossNSACLPolicy string
+
+	// ResyncInterval is the interval at which synced resources are reconciled
+	// again so that config entries modified or deleted directly in Consul are
+	// rewritten to match their resource. Resources are not resynced if it is 0.
+	ResyncInterval time.Duration
+
+	// EventRecorder records events on the resources, e.g. when they fail to
+	// sync. Events are not recorded if it is nil.
+	EventRecorder record.EventRecorder
+
+	// Auditor records the writes to Consul. Writes are not recorded if it is nil.
+	Auditor *audit.Auditor
 }
 
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
 // ReconcileEntry reconciles an update to a resource. CRD-specific controller's
 // call this function because it handles reconciliation of config entries
 // generically.
@@ -111,12 +151,18 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 		logger.Error(err, "failed to get Consul server state", "name", req.Name, "ns", req.Namespace)
 		return ctrl.Result{}, err
 	}
-	consulClient, err := consul.NewClientFromConnMgrState(r.ConsulClientConfig, serverState)
+	consulClient, err := consul.NewClientFromConnMgrState(r.consulClientConfig(configEntry), serverState)
 	if err != nil {
 		logger.Error(err, "failed to create Consul API client", "name", req.Name, "ns", req.Namespace)
 		return ctrl.Result{}, err
 	}
 
+	if resolver, ok := crdCtrl.(Resolver); ok && configEntry.GetDeletionTimestamp().IsZero() {
+		if err := resolver.Resolve(ctx, configEntry); err != nil {
+			return r.syncFailed(ctx, logger, crdCtrl, configEntry, KubernetesError, err)
+		}
+	}
+
 	consulEntry := configEntry.ToConsul(r.DatacenterName)
 
 	if configEntry.GetDeletionTimestamp().IsZero() {
@@ -148,6 +194,7 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 					_, err := consulClient.ConfigEntries().Delete(configEntry.ConsulKind(), configEntry.ConsulName(), &capi.WriteOptions{
 						Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
 					})
+					r.recordWrite(configEntry, audit.OperationDelete, entry, nil, err)
 					if err != nil {
 						return r.syncFailed(ctx, logger, crdCtrl, configEntry, ConsulAgentError,
 							fmt.Errorf("deleting config entry from consul: %w", err))
@@ -182,6 +229,15 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 		if r.EnableConsulNamespaces {
 			consulNS := r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource())
 			created, err := namespaces.EnsureExists(consulClient, consulNS, r.CrossNSACLPolicy)
+			if created {
+				r.Auditor.Record(audit.Event{
+					Actor:     r.auditActor(configEntry),
+					Operation: audit.OperationCreate,
+					Resource:  audit.ResourceNamespace,
+					Name:      consulNS,
+					Source:    auditSource(configEntry),
+				}, err)
+			}
 			if err != nil {
 				return r.syncFailed(ctx, logger, crdCtrl, configEntry, ConsulAgentError,
 					fmt.Errorf("creating consul namespace %q: %w", consulNS, err))
@@ -195,12 +251,16 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 		_, writeMeta, err := consulClient.ConfigEntries().Set(consulEntry, &capi.WriteOptions{
 			Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
 		})
+		r.recordWrite(configEntry, audit.OperationCreate, nil, consulEntry, err)
 		if err != nil {
 			return r.syncFailed(ctx, logger, crdCtrl, configEntry, ConsulAgentError,
 				fmt.Errorf("writing config entry to consul: %w", err))
 		}
 
 		logger.Info("config entry created", "request-time", writeMeta.RequestTime)
+		if hasDrifted(configEntry) {
+			r.recordDriftCorrected(logger, configEntry, "config entry was deleted from Consul and has been recreated")
+		}
 		return r.syncSuccessful(ctx, crdCtrl, configEntry)
 	}
 
@@ -244,11 +304,15 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 		_, writeMeta, err := consulClient.ConfigEntries().Set(consulEntry, &capi.WriteOptions{
 			Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
 		})
+		r.recordWrite(configEntry, audit.OperationUpdate, entry, consulEntry, err)
 		if err != nil {
 			return r.syncUnknownWithError(ctx, logger, crdCtrl, configEntry, ConsulAgentError,
 				fmt.Errorf("updating config entry in consul: %w", err))
 		}
 		logger.Info("config entry updated", "request-time", writeMeta.RequestTime)
+		if hasDrifted(configEntry) {
+			r.recordDriftCorrected(logger, configEntry, "config entry was modified in Consul and has been rewritten to match the resource")
+		}
 		return r.syncSuccessful(ctx, crdCtrl, configEntry)
 	} else if requiresMigration && entry.GetMeta()[common.DatacenterKey] != r.DatacenterName {
 		// If we get here then we're doing a migration and the entry in Consul
@@ -258,13 +322,15 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 		_, writeMeta, err := consulClient.ConfigEntries().Set(consulEntry, &capi.WriteOptions{
 			Namespace: r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource()),
 		})
+		r.recordWrite(configEntry, audit.OperationUpdate, entry, consulEntry, err)
 		if err != nil {
 			return r.syncUnknownWithError(ctx, logger, crdCtrl, configEntry, ConsulAgentError,
 				fmt.Errorf("updating config entry in consul: %w", err))
 		}
 		logger.Info("config entry migrated", "request-time", writeMeta.RequestTime)
 		return r.syncSuccessful(ctx, crdCtrl, configEntry)
-	} else if configEntry.SyncedConditionStatus() != corev1.ConditionTrue {
+	} else if configEntry.SyncedConditionStatus() != corev1.ConditionTrue ||
+		configEntry.ConditionStatus(string(v1alpha1.ConditionInSync)) != corev1.ConditionTrue {
 		return r.syncSuccessful(ctx, crdCtrl, configEntry)
 	}
 
@@ -277,13 +343,21 @@ func (r *ConfigEntryController) ReconcileEntry(ctx context.Context, crdCtrl Cont
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: r.ResyncInterval}, nil
 }
 
 // setupWithManager sets up the controller manager for the given resource
 // with our default options.
 func setupWithManager(mgr ctrl.Manager, resource client.Object, reconciler reconcile.Reconciler) error {
-	options := controller.Options{
+	return ctrl.NewControllerManagedBy(mgr).
+		For(resource).
+		WithOptions(controllerOptions()).
+		Complete(reconciler)
+}
+
+// controllerOptions returns our default controller options.
+func controllerOptions() controller.Options {
+	return controller.Options{
 		// Taken from https://github.com/kubernetes/client-go/blob/master/util/workqueue/default_rate_limiters.go#L39
 		// and modified from a starting backoff of 5ms and max of 1000s to a
 		// starting backoff of 200ms and a max of 5s to better fit our most
@@ -304,11 +378,21 @@ func setupWithManager(mgr ctrl.Manager, resource client.Object, reconciler recon
 			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
 		),
 	}
+}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(resource).
-		WithOptions(options).
-		Complete(reconciler)
+// consulClientConfig returns the config of the Consul API client for
+// configEntry. Config entries with the partition annotation are written to
+// that partition instead of the partition the controller runs in.
+func (r *ConfigEntryController) consulClientConfig(configEntry common.ConfigEntryResource) *consul.Config {
+	partition := common.PartitionAnnotation(configEntry)
+	if partition == "" {
+		return r.ConsulClientConfig
+	}
+	cfg := *r.ConsulClientConfig
+	apiCfg := *cfg.APIClientConfig
+	apiCfg.Partition = partition
+	cfg.APIClientConfig = &apiCfg
+	return &cfg
 }
 
 func (r *ConfigEntryController) consulNamespace(configEntry capi.ConfigEntry, namespace string, globalResource bool) string {
@@ -336,6 +420,7 @@ func (r *ConfigEntryController) consulNamespace(configEntry capi.ConfigEntry, na
 
 func (r *ConfigEntryController) syncFailed(ctx context.Context, logger logr.Logger, updater Controller, configEntry common.ConfigEntryResource, errType string, err error) (ctrl.Result, error) {
 	configEntry.SetSyncedCondition(corev1.ConditionFalse, errType, err.Error())
+	r.setFailedConditions(configEntry, errType, err)
 	if updateErr := updater.UpdateStatus(ctx, configEntry); updateErr != nil {
 		// Log the original error here because we are returning the updateErr.
 		// Otherwise the original error would be lost.
@@ -347,9 +432,86 @@ func (r *ConfigEntryController) syncFailed(ctx context.Context, logger logr.Logg
 
 func (r *ConfigEntryController) syncSuccessful(ctx context.Context, updater Controller, configEntry common.ConfigEntryResource) (ctrl.Result, error) {
 	configEntry.SetSyncedCondition(corev1.ConditionTrue, "", "")
+	configEntry.SetCondition(string(v1alpha1.ConditionValidated), corev1.ConditionTrue, "", "")
+	configEntry.SetCondition(string(v1alpha1.ConditionApplied), corev1.ConditionTrue, "", "")
+	configEntry.SetCondition(string(v1alpha1.ConditionInSync), corev1.ConditionTrue, "",
+		fmt.Sprintf("config entry in Consul matches generation %d", configEntry.GetGeneration()))
 	timeNow := metav1.NewTime(time.Now())
 	configEntry.SetLastSyncedTime(&timeNow)
-	return ctrl.Result{}, updater.UpdateStatus(ctx, configEntry)
+	configEntry.SetLastSyncedGeneration(configEntry.GetGeneration())
+	return ctrl.Result{RequeueAfter: r.ResyncInterval}, updater.UpdateStatus(ctx, configEntry)
+}
+
+// setFailedConditions sets the Validated, Applied and InSync conditions of a
+// resource that failed to sync and records a warning event with the error.
+// Validated is only False if Consul rejected the config entry, otherwise it
+// isn't known whether the config entry is valid.
+func (r *ConfigEntryController) setFailedConditions(configEntry common.ConfigEntryResource, errType string, err error) {
+	if isValidationErr(err) {
+		configEntry.SetCondition(string(v1alpha1.ConditionValidated), corev1.ConditionFalse, ConsulValidationError, err.Error())
+	} else {
+		configEntry.SetCondition(string(v1alpha1.ConditionValidated), corev1.ConditionUnknown, "", "")
+	}
+	configEntry.SetCondition(string(v1alpha1.ConditionApplied), corev1.ConditionFalse, errType, err.Error())
+	inSyncMessage := fmt.Sprintf("generation %d has not been synced to Consul", configEntry.GetGeneration())
+	if generation := configEntry.GetLastSyncedGeneration(); generation != 0 {
+		inSyncMessage = fmt.Sprintf("%s, the last synced generation is %d", inSyncMessage, generation)
+	}
+	configEntry.SetCondition(string(v1alpha1.ConditionInSync), corev1.ConditionFalse, errType, inSyncMessage)
+	r.recordEvent(configEntry, corev1.EventTypeWarning, errType, err.Error())
+}
+
+// recordDriftCorrected records an event on the resource when its config entry
+// was changed outside of Kubernetes and has been rewritten.
+func (r *ConfigEntryController) recordDriftCorrected(logger logr.Logger, configEntry common.ConfigEntryResource, message string) {
+	logger.Info("corrected drift of config entry in Consul")
+	r.recordEvent(configEntry, corev1.EventTypeNormal, DriftCorrected, message)
+}
+
+// recordWrite records a write of the config entry of the resource in the audit log.
+func (r *ConfigEntryController) recordWrite(configEntry common.ConfigEntryResource, op audit.Operation, before, after capi.ConfigEntry, err error) {
+	if r.Auditor == nil {
+		return
+	}
+	event := audit.Event{
+		Actor:     r.auditActor(configEntry),
+		Operation: op,
+		Resource:  audit.ResourceConfigEntry,
+		Name:      fmt.Sprintf("%s/%s", configEntry.ConsulKind(), configEntry.ConsulName()),
+		Source:    auditSource(configEntry),
+		Before:    before,
+		After:     after,
+	}
+	consulEntry := configEntry.ToConsul(r.DatacenterName)
+	event.Namespace = r.consulNamespace(consulEntry, configEntry.ConsulMirroringNS(), configEntry.ConsulGlobalResource())
+	event.Partition = consulEntry.GetPartition()
+	r.Auditor.Record(event, err)
+}
+
+func (r *ConfigEntryController) auditActor(configEntry common.ConfigEntryResource) string {
+	return "controller/" + configEntry.KubeKind()
+}
+
+func auditSource(configEntry common.ConfigEntryResource) *audit.Source {
+	return &audit.Source{
+		Kind:      configEntry.KubeKind(),
+		Namespace: configEntry.GetNamespace(),
+		Name:      configEntry.KubernetesName(),
+	}
+}
+
+func (r *ConfigEntryController) recordEvent(configEntry common.ConfigEntryResource, eventType, reason, message string) {
+	if r.EventRecorder != nil {
+		r.EventRecorder.Event(configEntry, eventType, reason, message)
+	}
+}
+
+// hasDrifted returns true if the resource hasn't changed since it was last
+// synced with Consul. If the config entry in Consul doesn't match such a
+// resource, it must have been changed outside of Kubernetes.
+func hasDrifted(configEntry common.ConfigEntryResource) bool {
+	return configEntry.SyncedConditionStatus() == corev1.ConditionTrue &&
+		configEntry.GetLastSyncedGeneration() == configEntry.GetGeneration()
 }
 
 func (r *ConfigEntryController) syncUnknown(ctx context.Context, updater Controller, configEntry common.ConfigEntryResource) error {
@@ -365,6 +527,7 @@ func (r *ConfigEntryController) syncUnknownWithError(ctx context.Context,
 	err error) (ctrl.Result, error) {
 
 	configEntry.SetSyncedCondition(corev1.ConditionUnknown, errType, err.Error())
+	r.setFailedConditions(configEntry, errType, err)
 	if updateErr := updater.UpdateStatus(ctx, configEntry); updateErr != nil {
 		// Log the original error here because we are returning the updateErr.
 		// Otherwise the original error would be lost.
@@ -393,6 +556,11 @@ func (r *ConfigEntryController) nonMatchingMigrationError(kubeEntry common.Confi
 
 // needsVirtualIPAssignment checks to see if a configEntry type needs to be assigned a virtual IP.
 func needsVirtualIPAssignment(datacenterName string, configEntry common.ConfigEntryResource) bool {
+	// The services of config entries in other partitions don't run in
+	// this cluster.
+	if common.PartitionAnnotation(configEntry) != "" {
+		return false
+	}
 	switch configEntry.KubeKind() {
 	case common.ServiceResolver:
 		return true
@@ -464,6 +632,23 @@ func isNotFoundErr(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "404")
 }
 
+// isValidationErr returns true if Consul rejected a config entry, rather than
+// the request failing, e.g. because Consul is unavailable. Only client errors
+// are validation errors. Server errors aren't since a 500 may just as well be
+// caused by the servers being unhealthy, and neither are ACL or rate limit
+// errors.
+func isValidationErr(err error) bool {
+	var statusErr capi.StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	switch statusErr.Code {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		return false
+	}
+	return statusErr.Code >= 400 && statusErr.Code < 500
+}
+
 // containsString returns true if s is in slice.
 func containsString(slice []string, s string) bool {
 	for _, item := range slice {
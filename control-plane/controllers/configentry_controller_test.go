@@ -5,7 +5,9 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
@@ -24,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -1547,6 +1550,73 @@ func TestConfigEntryControllers_errorUpdatesSyncStatus(t *testing.T) {
 	req.Equal(corev1.ConditionFalse, status)
 	req.Equal("ConsulAgentError", reason)
 	req.Contains(errMsg, expErr)
+
+	// Consul being unavailable doesn't mean that the config entry is invalid.
+	req.Equal(corev1.ConditionUnknown, svcDefaults.ConditionStatus(string(v1alpha1.ConditionValidated)))
+	req.Equal(corev1.ConditionFalse, svcDefaults.ConditionStatus(string(v1alpha1.ConditionApplied)))
+	req.Equal(corev1.ConditionFalse, svcDefaults.ConditionStatus(string(v1alpha1.ConditionInSync)))
+}
+
+// Test that if Consul responds to a config entry with a 500, the Validated
+// condition is unknown, since the error can't be told apart from the servers
+// being unhealthy, and a warning event is recorded with the error.
+func TestConfigEntryControllers_setsValidatedToUnknownOnServerError(t *testing.T) {
+	t.Parallel()
+	kubeNS := "default"
+	req := require.New(t)
+	ctx := context.Background()
+	// A router requires the service to use an L7 protocol but the protocol
+	// of the service defaults to tcp.
+	svcRouter := &v1alpha1.ServiceRouter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "foo",
+			Namespace:  kubeNS,
+			Generation: 1,
+			Finalizers: []string{FinalizerName},
+		},
+		Spec: v1alpha1.ServiceRouterSpec{
+			Routes: []v1alpha1.ServiceRoute{
+				{
+					Match: &v1alpha1.ServiceRouteMatch{
+						HTTP: &v1alpha1.ServiceRouteHTTPMatch{PathPrefix: "/admin"},
+					},
+				},
+			},
+		},
+	}
+	s := runtime.NewScheme()
+	s.AddKnownTypes(v1alpha1.GroupVersion, svcRouter)
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(svcRouter).Build()
+
+	testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+	testClient.TestServer.WaitForServiceIntentions(t)
+
+	recorder := record.NewFakeRecorder(1)
+	reconciler := &ServiceRouterController{
+		Client: fakeClient,
+		Log:    logrtest.New(t),
+		ConfigEntryController: &ConfigEntryController{
+			ConsulClientConfig:  testClient.Cfg,
+			ConsulServerConnMgr: testClient.Watcher,
+			DatacenterName:      datacenterName,
+			EventRecorder:       recorder,
+		},
+	}
+	namespacedName := types.NamespacedName{Namespace: kubeNS, Name: svcRouter.KubernetesName()}
+	_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: namespacedName})
+	req.Error(err)
+	req.Contains(err.Error(), "does not permit advanced routing or splitting behavior")
+
+	err = fakeClient.Get(ctx, namespacedName, svcRouter)
+	req.NoError(err)
+	req.Equal(corev1.ConditionFalse, svcRouter.SyncedConditionStatus())
+	validated := svcRouter.Status.GetCondition(v1alpha1.ConditionValidated)
+	req.Equal(corev1.ConditionUnknown, validated.Status)
+	req.Equal(corev1.ConditionFalse, svcRouter.ConditionStatus(string(v1alpha1.ConditionApplied)))
+	inSync := svcRouter.Status.GetCondition(v1alpha1.ConditionInSync)
+	req.Equal(corev1.ConditionFalse, inSync.Status)
+	req.Equal("generation 1 has not been synced to Consul", inSync.Message)
+	req.Equal(fmt.Sprintf("Warning %s %s", ConsulAgentError, err.Error()), <-recorder.Events)
 }
 
 // Test that if the config entry hasn't changed in Consul but our resource
@@ -1611,6 +1681,98 @@ func TestConfigEntryControllers_setsSyncedToTrue(t *testing.T) {
 	err = fakeClient.Get(ctx, namespacedName, svcDefaults)
 	req.NoError(err)
 	req.Equal(corev1.ConditionTrue, svcDefaults.SyncedConditionStatus())
+	req.Equal(corev1.ConditionTrue, svcDefaults.ConditionStatus(string(v1alpha1.ConditionValidated)))
+	req.Equal(corev1.ConditionTrue, svcDefaults.ConditionStatus(string(v1alpha1.ConditionApplied)))
+	req.Equal(corev1.ConditionTrue, svcDefaults.ConditionStatus(string(v1alpha1.ConditionInSync)))
+}
+
+// Test that config entries modified or deleted directly in Consul are
+// rewritten to match the resource and that a DriftCorrected event is recorded.
+func TestConfigEntryControllers_correctsDrift(t *testing.T) {
+	t.Parallel()
+	kubeNS := "default"
+
+	cases := map[string]struct {
+		consulEntry *capi.ServiceConfigEntry
+		expEvent    string
+	}{
+		"modified in consul": {
+			consulEntry: &capi.ServiceConfigEntry{
+				Kind:     capi.ServiceDefaults,
+				Name:     "foo",
+				Protocol: "tcp",
+				Meta: map[string]string{
+					common.SourceKey:     common.SourceValue,
+					common.DatacenterKey: datacenterName,
+				},
+			},
+			expEvent: "Normal DriftCorrected config entry was modified in Consul and has been rewritten to match the resource",
+		},
+		"deleted from consul": {
+			expEvent: "Normal DriftCorrected config entry was deleted from Consul and has been recreated",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			s := runtime.NewScheme()
+			svcDefaults := &v1alpha1.ServiceDefaults{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "foo",
+					Namespace:  kubeNS,
+					Generation: 2,
+					Finalizers: []string{FinalizerName},
+				},
+				Spec: v1alpha1.ServiceDefaultsSpec{
+					Protocol: "http",
+				},
+				Status: v1alpha1.Status{
+					Conditions: v1alpha1.Conditions{
+						{
+							Type:   v1alpha1.ConditionSynced,
+							Status: corev1.ConditionTrue,
+						},
+					},
+					LastSyncedGeneration: 2,
+				},
+			}
+			s.AddKnownTypes(v1alpha1.GroupVersion, svcDefaults)
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(svcDefaults).Build()
+
+			testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+			testClient.TestServer.WaitForServiceIntentions(t)
+			consulClient := testClient.APIClient
+			if c.consulEntry != nil {
+				_, _, err := consulClient.ConfigEntries().Set(c.consulEntry, nil)
+				require.NoError(t, err)
+			}
+
+			recorder := record.NewFakeRecorder(1)
+			reconciler := &ServiceDefaultsController{
+				Client: fakeClient,
+				Log:    logrtest.New(t),
+				ConfigEntryController: &ConfigEntryController{
+					ConsulClientConfig:  testClient.Cfg,
+					ConsulServerConnMgr: testClient.Watcher,
+					DatacenterName:      datacenterName,
+					ResyncInterval:      time.Minute,
+					EventRecorder:       recorder,
+				},
+			}
+			resp, err := reconciler.Reconcile(ctx, ctrl.Request{
+				NamespacedName: types.NamespacedName{Namespace: kubeNS, Name: svcDefaults.KubernetesName()},
+			})
+			require.NoError(t, err)
+			require.Equal(t, time.Minute, resp.RequeueAfter)
+
+			entry, _, err := consulClient.ConfigEntries().Get(capi.ServiceDefaults, "foo", nil)
+			require.NoError(t, err)
+			require.Equal(t, "http", entry.(*capi.ServiceConfigEntry).Protocol)
+			require.Equal(t, c.expEvent, <-recorder.Events)
+		})
+	}
 }
 
 // Test that if the config entry exists in Consul but is not managed by the
@@ -2249,3 +2411,44 @@ func TestConfigEntryControllers_assignServiceVirtualIP(t *testing.T) {
 		})
 	}
 }
+
+func TestIsValidationErr(t *testing.T) {
+	cases := map[string]struct {
+		err error
+		exp bool
+	}{
+		"bad request": {
+			err: fmt.Errorf("writing config entry to consul: %w", capi.StatusError{Code: http.StatusBadRequest, Body: "invalid"}),
+			exp: true,
+		},
+		"conflict": {
+			err: capi.StatusError{Code: http.StatusConflict},
+			exp: true,
+		},
+		"permission denied": {
+			err: capi.StatusError{Code: http.StatusForbidden, Body: "Permission denied"},
+			exp: false,
+		},
+		"rate limited": {
+			err: capi.StatusError{Code: http.StatusTooManyRequests},
+			exp: false,
+		},
+		"server error": {
+			err: capi.StatusError{Code: http.StatusInternalServerError, Body: "rpc error"},
+			exp: false,
+		},
+		"unavailable": {
+			err: capi.StatusError{Code: http.StatusServiceUnavailable},
+			exp: false,
+		},
+		"not a status error": {
+			err: errors.New("connection refused"),
+			exp: false,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.exp, isValidationErr(c.err))
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/common"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	capi "github.com/hashicorp/consul/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConsulValidator validates config entries against the config entries that
+// are already written to Consul, so that entries Consul would reject are
+// rejected by the webhooks at apply time rather than failing to sync.
+//
+// Resources that are defined in Kubernetes take precedence over the entries
+// in Consul because they may not have been synced yet, e.g. when a
+// ServiceDefaults and a ServiceRouter for the same service are applied
+// together.
+//
+// If Consul can't be reached, validation is skipped so that Consul being
+// unavailable doesn't block changes to the resources.
+type ConsulValidator struct {
+	client.Client
+
+	// ConsulClientConfig is the config for the Consul API client.
+	ConsulClientConfig *consul.Config
+
+	// ConsulServerConnMgr is the watcher for the Consul server addresses.
+	ConsulServerConnMgr consul.ServerConnectionManager
+
+	Log logr.Logger
+}
+
+// ValidateWithConsul implements common.ConsulValidator.
+func (v *ConsulValidator) ValidateWithConsul(ctx context.Context, cfgEntry common.ConfigEntryResource, consulMeta common.ConsulMeta) error {
+	switch cfgEntry.ConsulKind() {
+	case capi.ServiceRouter, capi.ServiceSplitter, capi.ServiceDefaults:
+	default:
+		return nil
+	}
+
+	serverState, err := v.ConsulServerConnMgr.State()
+	if err != nil {
+		v.Log.Error(err, "failed to get Consul server state, skipping validation", "name", cfgEntry.KubernetesName())
+		return nil
+	}
+	consulClient, err := consul.NewClientFromConnMgrState(v.ConsulClientConfig, serverState)
+	if err != nil {
+		v.Log.Error(err, "failed to create Consul API client, skipping validation", "name", cfgEntry.KubernetesName())
+		return nil
+	}
+
+	err = v.validateProtocol(ctx, consulClient, cfgEntry, consulMeta)
+	if err != nil {
+		if _, ok := err.(protocolError); ok {
+			return err
+		}
+		v.Log.Error(err, "failed to validate with Consul, skipping validation", "name", cfgEntry.KubernetesName())
+	}
+	return nil
+}
+
+// protocolError is returned when a config entry requires a protocol that
+// doesn't match the protocol of the service.
+type protocolError struct {
+	msg string
+}
+
+func (e protocolError) Error() string {
+	return e.msg
+}
+
+// validateProtocol checks that routers and splitters are only defined for
+// services with an L7 protocol, and that the protocol of a service can't be
+// changed to an L4 protocol while a router or splitter is defined for it.
+func (v *ConsulValidator) validateProtocol(ctx context.Context, consulClient *capi.Client, cfgEntry common.ConfigEntryResource, consulMeta common.ConsulMeta) error {
+	opts := &capi.QueryOptions{
+		Namespace: consulNamespace(cfgEntry, consulMeta),
+	}
+	if consulMeta.PartitionsEnabled {
+		opts.Partition = consulMeta.Partition
+	}
+	service := cfgEntry.ConsulName()
+
+	if serviceDefaults, ok := cfgEntry.(*v1alpha1.ServiceDefaults); ok {
+		if serviceDefaults.Spec.Protocol == "" || isL7Protocol(serviceDefaults.Spec.Protocol) {
+			return nil
+		}
+		for _, kind := range []string{capi.ServiceRouter, capi.ServiceSplitter} {
+			_, _, err := consulClient.ConfigEntries().Get(kind, service, opts)
+			if isNotFoundErr(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			return protocolError{msg: fmt.Sprintf("service %q has a %s config entry in Consul which requires an L7 protocol, so its protocol cannot be %q",
+				service, kind, serviceDefaults.Spec.Protocol)}
+		}
+		return nil
+	}
+
+	protocol, err := v.serviceProtocol(ctx, consulClient, service, opts, consulMeta)
+	if err != nil {
+		return err
+	}
+	if !isL7Protocol(protocol) {
+		return protocolError{msg: fmt.Sprintf("%s for service %q requires the service to use an L7 protocol (\"http\", \"http2\" or \"grpc\") but its protocol is %q - set the protocol with a ServiceDefaults or ProxyDefaults resource",
+			cfgEntry.KubeKind(), service, protocol)}
+	}
+	return nil
+}
+
+// serviceProtocol returns the protocol of service from its service-defaults
+// or, if it isn't set there, the global proxy-defaults.
+func (v *ConsulValidator) serviceProtocol(ctx context.Context, consulClient *capi.Client, service string, opts *capi.QueryOptions, consulMeta common.ConsulMeta) (string, error) {
+	var serviceDefaultsList v1alpha1.ServiceDefaultsList
+	if err := v.Client.List(ctx, &serviceDefaultsList); err != nil {
+		return "", err
+	}
+	for _, item := range serviceDefaultsList.Items {
+		if item.ConsulName() == service && consulNamespace(&item, consulMeta) == opts.Namespace && item.Spec.Protocol != "" {
+			return item.Spec.Protocol, nil
+		}
+	}
+	entry, _, err := consulClient.ConfigEntries().Get(capi.ServiceDefaults, service, opts)
+	if err != nil && !isNotFoundErr(err) {
+		return "", err
+	}
+	if entry != nil && entry.(*capi.ServiceConfigEntry).Protocol != "" {
+		return entry.(*capi.ServiceConfigEntry).Protocol, nil
+	}
+
+	var config map[string]interface{}
+	var proxyDefaultsList v1alpha1.ProxyDefaultsList
+	if err := v.Client.List(ctx, &proxyDefaultsList); err != nil {
+		return "", err
+	}
+	if len(proxyDefaultsList.Items) > 0 {
+		// The webhook only allows a single ProxyDefaults resource named global.
+		config = proxyDefaultsList.Items[0].ToConsul("").(*capi.ProxyConfigEntry).Config
+	} else {
+		entry, _, err := consulClient.ConfigEntries().Get(capi.ProxyDefaults, common.Global, &capi.QueryOptions{Partition: opts.Partition})
+		if err != nil && !isNotFoundErr(err) {
+			return "", err
+		}
+		if entry != nil {
+			config = entry.(*capi.ProxyConfigEntry).Config
+		}
+	}
+	if protocol, ok := config["protocol"].(string); ok && protocol != "" {
+		return protocol, nil
+	}
+	return "tcp", nil
+}
+
+// consulNamespace returns the Consul namespace that cfgEntry is written to.
+func consulNamespace(cfgEntry common.ConfigEntryResource, consulMeta common.ConsulMeta) string {
+	return namespaces.ConsulNamespace(cfgEntry.ConsulMirroringNS(), consulMeta.NamespacesEnabled, consulMeta.DestinationNamespace, consulMeta.Mirroring, consulMeta.Prefix)
+}
+
+func isL7Protocol(protocol string) bool {
+	switch protocol {
+	case "http", "http2", "grpc":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/common"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConsulValidator_ValidateWithConsul(t *testing.T) {
+	t.Parallel()
+	kubeNS := "default"
+
+	router := &v1alpha1.ServiceRouter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: kubeNS,
+		},
+	}
+
+	cases := map[string]struct {
+		kubeResources []runtime.Object
+		consulEntries []capi.ConfigEntry
+		cfgEntry      common.ConfigEntryResource
+		expErr        string
+	}{
+		"router for a tcp service": {
+			cfgEntry: router,
+			expErr:   `servicerouter for service "foo" requires the service to use an L7 protocol ("http", "http2" or "grpc") but its protocol is "tcp" - set the protocol with a ServiceDefaults or ProxyDefaults resource`,
+		},
+		"router for an http service in Consul": {
+			consulEntries: []capi.ConfigEntry{
+				&capi.ServiceConfigEntry{Kind: capi.ServiceDefaults, Name: "foo", Protocol: "http"},
+			},
+			cfgEntry: router,
+		},
+		"router for an http service in Kubernetes": {
+			kubeResources: []runtime.Object{
+				&v1alpha1.ServiceDefaults{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: kubeNS},
+					Spec:       v1alpha1.ServiceDefaultsSpec{Protocol: "http"},
+				},
+			},
+			cfgEntry: router,
+		},
+		"router with a global grpc protocol": {
+			consulEntries: []capi.ConfigEntry{
+				&capi.ProxyConfigEntry{Kind: capi.ProxyDefaults, Name: common.Global, Config: map[string]interface{}{"protocol": "grpc"}},
+			},
+			cfgEntry: router,
+		},
+		"service defaults changing protocol to tcp with a router": {
+			consulEntries: []capi.ConfigEntry{
+				&capi.ServiceConfigEntry{Kind: capi.ServiceDefaults, Name: "foo", Protocol: "http"},
+				&capi.ServiceRouterConfigEntry{Kind: capi.ServiceRouter, Name: "foo"},
+			},
+			cfgEntry: &v1alpha1.ServiceDefaults{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: kubeNS},
+				Spec:       v1alpha1.ServiceDefaultsSpec{Protocol: "tcp"},
+			},
+			expErr: `service "foo" has a service-router config entry in Consul which requires an L7 protocol, so its protocol cannot be "tcp"`,
+		},
+		"service defaults with tcp protocol": {
+			cfgEntry: &v1alpha1.ServiceDefaults{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: kubeNS},
+				Spec:       v1alpha1.ServiceDefaultsSpec{Protocol: "tcp"},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := runtime.NewScheme()
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.ServiceDefaults{}, &v1alpha1.ServiceDefaultsList{}, &v1alpha1.ProxyDefaults{}, &v1alpha1.ProxyDefaultsList{})
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(c.kubeResources...).Build()
+
+			testClient := test.TestServerWithMockConnMgrWatcher(t, nil)
+			for _, entry := range c.consulEntries {
+				_, _, err := testClient.APIClient.ConfigEntries().Set(entry, nil)
+				require.NoError(t, err)
+			}
+
+			validator := &ConsulValidator{
+				Client:              fakeClient,
+				ConsulClientConfig:  testClient.Cfg,
+				ConsulServerConnMgr: testClient.Watcher,
+				Log:                 logrtest.New(t),
+			}
+			err := validator.ValidateWithConsul(context.Background(), c.cfgEntry, common.ConsulMeta{})
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
@@ -5,14 +5,29 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/hashicorp/consul-k8s/control-plane/api/common"
 	consulv1alpha1 "github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
 )
 
 // ExportedServicesController reconciles a ExportedServices object.
@@ -25,6 +40,7 @@ type ExportedServicesController struct {
 
 // +kubebuilder:rbac:groups=consul.hashicorp.com,resources=exportedservices,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=consul.hashicorp.com,resources=exportedservices/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=services;namespaces;pods,verbs=get;list;watch
 
 func (r *ExportedServicesController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	return r.ConfigEntryController.ReconcileEntry(ctx, r, req, &consulv1alpha1.ExportedServices{})
@@ -38,6 +54,245 @@ func (r *ExportedServicesController) UpdateStatus(ctx context.Context, obj clien
 	return r.Status().Update(ctx, obj, opts...)
 }
 
+// Resolve sets the services selected by the service selectors and the
+// namespace selectors of the ExportedServices resource so that they're
+// included in its config entry.
+func (r *ExportedServicesController) Resolve(ctx context.Context, configEntry common.ConfigEntryResource) error {
+	exportedServices, ok := configEntry.(*consulv1alpha1.ExportedServices)
+	if !ok || !hasSelectors(exportedServices) {
+		return nil
+	}
+
+	var selected []consulv1alpha1.ExportedService
+	for _, service := range exportedServices.Spec.Services {
+		if service.NamespaceSelector == nil {
+			continue
+		}
+		consulNamespaces, err := r.selectConsulNamespaces(ctx, service.NamespaceSelector)
+		if err != nil {
+			return err
+		}
+		for _, ns := range consulNamespaces {
+			selected = append(selected, consulv1alpha1.ExportedService{
+				Name:      consulv1alpha1.WildcardSpecifier,
+				Namespace: ns,
+				Consumers: service.Consumers,
+			})
+		}
+	}
+	for _, serviceSelector := range exportedServices.Spec.ServiceSelectors {
+		services, err := r.selectServices(ctx, serviceSelector)
+		if err != nil {
+			return err
+		}
+		for _, svc := range services {
+			names, err := r.consulServiceNames(ctx, svc)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				selected = append(selected, consulv1alpha1.ExportedService{
+					Name:      name,
+					Namespace: r.consulNamespace(svc.Namespace),
+					Consumers: serviceSelector.Consumers,
+				})
+			}
+		}
+	}
+	exportedServices.SetSelectedServices(selected)
+	return nil
+}
+
+// selectServices returns the Kubernetes services matching the selector,
+// sorted by namespace and name so that the config entry doesn't change when
+// nothing else has. An empty selector is an error rather than selecting every
+// service, since that would export services by accident.
+func (r *ExportedServicesController) selectServices(ctx context.Context, serviceSelector consulv1alpha1.ExportedServiceSelector) ([]corev1.Service, error) {
+	if serviceSelector.Selector == nil || (len(serviceSelector.Selector.MatchLabels) == 0 && len(serviceSelector.Selector.MatchExpressions) == 0) {
+		return nil, errors.New("service selector must not be empty")
+	}
+	selector, err := metav1.LabelSelectorAsSelector(serviceSelector.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+	var serviceList corev1.ServiceList
+	if err := r.Client.List(ctx, &serviceList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("listing services: %w", err)
+	}
+
+	var selectedNamespaces map[string]bool
+	if serviceSelector.NamespaceSelector != nil {
+		namespaceList, err := r.selectNamespaces(ctx, serviceSelector.NamespaceSelector)
+		if err != nil {
+			return nil, err
+		}
+		selectedNamespaces = make(map[string]bool, len(namespaceList))
+		for _, ns := range namespaceList {
+			selectedNamespaces[ns.Name] = true
+		}
+	}
+
+	var services []corev1.Service
+	for _, svc := range serviceList.Items {
+		if selectedNamespaces == nil || selectedNamespaces[svc.Namespace] {
+			services = append(services, svc)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Namespace != services[j].Namespace {
+			return services[i].Namespace < services[j].Namespace
+		}
+		return services[i].Name < services[j].Name
+	})
+	return services, nil
+}
+
+// consulServiceNames returns the sorted names that the pods of the Kubernetes
+// service are registered with in the service mesh. Only connect-injected pods
+// are registered by the endpoints controller, so no names are returned for
+// services without injected pods, which keeps services that aren't in the mesh
+// from being exported. Like in the endpoints controller, the name is the
+// connect-service annotation of a pod if it's set to a single name, and the
+// name of the Kubernetes service otherwise.
+func (r *ExportedServicesController) consulServiceNames(ctx context.Context, svc corev1.Service) ([]string, error) {
+	if len(svc.Spec.Selector) == 0 {
+		return nil, nil
+	}
+	var podList corev1.PodList
+	err := r.Client.List(ctx, &podList, client.InNamespace(svc.Namespace), client.MatchingLabels(svc.Spec.Selector))
+	if err != nil {
+		return nil, fmt.Errorf("listing pods of service %s/%s: %w", svc.Namespace, svc.Name, err)
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, pod := range podList.Items {
+		if !isInjectedPod(&pod) {
+			continue
+		}
+		name := svc.Name
+		if annotation := pod.Annotations[constants.AnnotationService]; annotation != "" && !strings.Contains(annotation, ",") {
+			name = annotation
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// selectNamespaces returns the Kubernetes namespaces matching the selector.
+func (r *ExportedServicesController) selectNamespaces(ctx context.Context, namespaceSelector *metav1.LabelSelector) ([]corev1.Namespace, error) {
+	selector, err := labelSelector(namespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace selector: %w", err)
+	}
+	var namespaceList corev1.NamespaceList
+	if err := r.Client.List(ctx, &namespaceList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("listing namespaces: %w", err)
+	}
+	return namespaceList.Items, nil
+}
+
+// selectConsulNamespaces returns the sorted Consul namespaces that the
+// Kubernetes namespaces matching the selector map to. Several Kubernetes
+// namespaces map to the same Consul namespace unless namespaces are mirrored.
+func (r *ExportedServicesController) selectConsulNamespaces(ctx context.Context, namespaceSelector *metav1.LabelSelector) ([]string, error) {
+	namespaceList, err := r.selectNamespaces(ctx, namespaceSelector)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var consulNamespaces []string
+	for _, ns := range namespaceList {
+		consulNS := r.consulNamespace(ns.Name)
+		if seen[consulNS] {
+			continue
+		}
+		seen[consulNS] = true
+		consulNamespaces = append(consulNamespaces, consulNS)
+	}
+	sort.Strings(consulNamespaces)
+	return consulNamespaces, nil
+}
+
+// consulNamespace returns the Consul namespace the services in the Kubernetes
+// namespace are registered in. It's empty if Consul namespaces are disabled.
+func (r *ExportedServicesController) consulNamespace(k8sNamespace string) string {
+	cfg := r.ConfigEntryController
+	if !cfg.EnableConsulNamespaces {
+		return ""
+	}
+	return namespaces.ConsulNamespace(k8sNamespace, cfg.EnableConsulNamespaces, cfg.ConsulDestinationNamespace, cfg.EnableNSMirroring, cfg.NSMirroringPrefix)
+}
+
+// labelSelector converts a label selector to a selector. Unlike
+// metav1.LabelSelectorAsSelector, a nil selector selects everything.
+func labelSelector(selector *metav1.LabelSelector) (labels.Selector, error) {
+	if selector == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(selector)
+}
+
 func (r *ExportedServicesController) SetupWithManager(mgr ctrl.Manager) error {
-	return setupWithManager(mgr, &consulv1alpha1.ExportedServices{}, r)
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&consulv1alpha1.ExportedServices{}).
+		WithOptions(controllerOptions()).
+		Watches(
+			&source.Kind{Type: &corev1.Service{}},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForServiceSelectors),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Namespace{}},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForServiceSelectors),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.Pod{}},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForServiceSelectors),
+			builder.WithPredicates(predicate.NewPredicateFuncs(isInjectedPod)),
+		).
+		Complete(r)
+}
+
+// requestsForServiceSelectors enqueues every ExportedServices resource with
+// service or namespace selectors when a service, namespace or injected pod
+// changes, since the change may add or remove a selected service.
+func (r *ExportedServicesController) requestsForServiceSelectors(_ client.Object) []reconcile.Request {
+	var exportedServicesList consulv1alpha1.ExportedServicesList
+	if err := r.Client.List(context.Background(), &exportedServicesList); err != nil {
+		r.Log.Error(err, "failed to list ExportedServices")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, exportedServices := range exportedServicesList.Items {
+		if !hasSelectors(&exportedServices) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: exportedServices.Name, Namespace: exportedServices.Namespace},
+		})
+	}
+	return requests
+}
+
+// isInjectedPod returns true if the pod is connect-injected, so that it's
+// registered in the service mesh by the endpoints controller.
+func isInjectedPod(obj client.Object) bool {
+	return obj.GetAnnotations()[constants.KeyInjectStatus] == constants.Injected
+}
+
+// hasSelectors returns true if the ExportedServices resource selects services
+// with service selectors or namespace selectors.
+func hasSelectors(exportedServices *consulv1alpha1.ExportedServices) bool {
+	if len(exportedServices.Spec.ServiceSelectors) > 0 {
+		return true
+	}
+	for _, service := range exportedServices.Spec.Services {
+		if service.NamespaceSelector != nil {
+			return true
+		}
+	}
+	return false
 }
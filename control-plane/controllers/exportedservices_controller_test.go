@@ -0,0 +1,260 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	logrtest "github.com/go-logr/logr/testr"
+	"github.com/hashicorp/consul-k8s/control-plane/api/common"
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	capi "github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestExportedServicesController_Resolve(t *testing.T) {
+	t.Parallel()
+
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	service := func(namespace, name string, labels map[string]string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": name}},
+		}
+	}
+	pod := func(namespace, name string, labels map[string]string, serviceName string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				constants.KeyInjectStatus:   constants.Injected,
+				constants.AnnotationService: serviceName,
+			},
+		}}
+	}
+	objs := []client.Object{
+		namespace("default", nil),
+		namespace("team-a", map[string]string{"team": "a"}),
+		namespace("team-b", map[string]string{"team": "b"}),
+		service("default", "api", map[string]string{"export": "true"}),
+		pod("default", "api-1", map[string]string{"app": "api"}, ""),
+		service("team-a", "web", map[string]string{"export": "true"}),
+		pod("team-a", "web-1", map[string]string{"app": "web"}, ""),
+		service("team-a", "db", nil),
+		pod("team-a", "db-1", map[string]string{"app": "db"}, ""),
+		service("team-b", "api", map[string]string{"export": "true"}),
+		pod("team-b", "api-1", map[string]string{"app": "api"}, ""),
+		// Services that aren't in the mesh are never selected.
+		service("team-a", "legacy", map[string]string{"export": "true"}),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "legacy-1", Namespace: "team-a", Labels: map[string]string{"app": "legacy"}}},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "team-a", Labels: map[string]string{"export": "true"}},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "example.com"},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "team-a", Labels: map[string]string{"renamed": "true"}},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "frontend"}},
+		},
+		pod("team-a", "frontend-1", map[string]string{"app": "frontend"}, "frontend-v2"),
+		pod("team-a", "frontend-2", map[string]string{"app": "frontend"}, "frontend-v2"),
+		// Multi port pods are registered with the name of the service.
+		pod("team-a", "frontend-3", map[string]string{"app": "frontend"}, "frontend,admin"),
+		pod("team-a", "other", map[string]string{"app": "other"}, "other"),
+	}
+	exportSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"export": "true"}}
+	peer1 := []v1alpha1.ServiceConsumer{{Peer: "peer-1"}}
+
+	cases := map[string]struct {
+		enableNamespaces bool
+		services         []v1alpha1.ExportedService
+		selectors        []v1alpha1.ExportedServiceSelector
+		expServices      []capi.ExportedService
+		expErr           string
+	}{
+		"selects services by label": {
+			selectors: []v1alpha1.ExportedServiceSelector{
+				{Selector: exportSelector, Consumers: peer1},
+			},
+			// Without namespaces, the services named api are the same service.
+			expServices: []capi.ExportedService{
+				{Name: "api", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+				{Name: "web", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+			},
+		},
+		"selects services by label and namespace label": {
+			enableNamespaces: true,
+			selectors: []v1alpha1.ExportedServiceSelector{
+				{
+					Selector:          exportSelector,
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Consumers:         peer1,
+				},
+			},
+			expServices: []capi.ExportedService{
+				{Name: "web", Namespace: "team-a", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+			},
+		},
+		"selects all services in the selected namespaces": {
+			enableNamespaces: true,
+			selectors: []v1alpha1.ExportedServiceSelector{
+				{
+					Selector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "export", Operator: metav1.LabelSelectorOpDoesNotExist}},
+					},
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Consumers:         peer1,
+				},
+			},
+			expServices: []capi.ExportedService{
+				{Name: "db", Namespace: "team-a", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+				{Name: "frontend", Namespace: "team-a", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+				{Name: "frontend-v2", Namespace: "team-a", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+			},
+		},
+		"rejects an empty selector": {
+			enableNamespaces: true,
+			selectors: []v1alpha1.ExportedServiceSelector{
+				{Selector: &metav1.LabelSelector{}, Consumers: peer1},
+			},
+			expErr: "service selector must not be empty",
+		},
+		"uses the connect-service annotation of the selected pods": {
+			enableNamespaces: true,
+			selectors: []v1alpha1.ExportedServiceSelector{
+				{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"renamed": "true"}}, Consumers: peer1},
+			},
+			expServices: []capi.ExportedService{
+				{Name: "frontend", Namespace: "team-a", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+				{Name: "frontend-v2", Namespace: "team-a", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+			},
+		},
+		"merges the consumers of services selected more than once": {
+			enableNamespaces: true,
+			selectors: []v1alpha1.ExportedServiceSelector{
+				{Selector: exportSelector, Consumers: peer1},
+				{
+					Selector:          exportSelector,
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+					Consumers:         []v1alpha1.ServiceConsumer{{Peer: "peer-2"}},
+				},
+			},
+			expServices: []capi.ExportedService{
+				{Name: "api", Namespace: "default", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+				{Name: "web", Namespace: "team-a", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+				{Name: "api", Namespace: "team-b", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}, {Peer: "peer-2"}}},
+			},
+		},
+		"exports all services from namespaces selected by label": {
+			enableNamespaces: true,
+			services: []v1alpha1.ExportedService{
+				{
+					Name: "*",
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: metav1.LabelSelectorOpExists}},
+					},
+					Consumers: peer1,
+				},
+				{Name: "api", Namespace: "default", Consumers: peer1},
+			},
+			expServices: []capi.ExportedService{
+				{Name: "api", Namespace: "default", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+				{Name: "*", Namespace: "team-a", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+				{Name: "*", Namespace: "team-b", Consumers: []capi.ServiceConsumer{{Peer: "peer-1"}}},
+			},
+		},
+		"selects no services": {
+			selectors: []v1alpha1.ExportedServiceSelector{
+				{
+					Selector:  &metav1.LabelSelector{MatchLabels: map[string]string{"export": "false"}},
+					Consumers: peer1,
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := runtime.NewScheme()
+			require.NoError(t, corev1.AddToScheme(s))
+			s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.ExportedServices{}, &v1alpha1.ExportedServicesList{})
+			fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+
+			controller := &ExportedServicesController{
+				Client: fakeClient,
+				Log:    logrtest.New(t),
+				ConfigEntryController: &ConfigEntryController{
+					EnableConsulNamespaces:     c.enableNamespaces,
+					ConsulDestinationNamespace: "default",
+					EnableNSMirroring:          true,
+				},
+			}
+			exportedServices := &v1alpha1.ExportedServices{
+				ObjectMeta: metav1.ObjectMeta{Name: common.DefaultConsulPartition},
+				Spec:       v1alpha1.ExportedServicesSpec{Services: c.services, ServiceSelectors: c.selectors},
+			}
+			err := controller.Resolve(context.Background(), exportedServices)
+			if c.expErr != "" {
+				require.EqualError(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+
+			entry := exportedServices.ToConsul("datacenter").(*capi.ExportedServicesConfigEntry)
+			require.Equal(t, c.expServices, entry.Services)
+		})
+	}
+}
+
+func TestExportedServicesController_requestsForServiceSelectors(t *testing.T) {
+	t.Parallel()
+
+	s := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(s))
+	s.AddKnownTypes(v1alpha1.GroupVersion, &v1alpha1.ExportedServices{}, &v1alpha1.ExportedServicesList{})
+	fakeClient := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		&v1alpha1.ExportedServices{
+			ObjectMeta: metav1.ObjectMeta{Name: "with-selectors", Namespace: "default"},
+			Spec: v1alpha1.ExportedServicesSpec{
+				ServiceSelectors: []v1alpha1.ExportedServiceSelector{{Consumers: []v1alpha1.ServiceConsumer{{Peer: "peer-1"}}}},
+			},
+		},
+		&v1alpha1.ExportedServices{
+			ObjectMeta: metav1.ObjectMeta{Name: "with-namespace-selector", Namespace: "default"},
+			Spec: v1alpha1.ExportedServicesSpec{
+				Services: []v1alpha1.ExportedService{{
+					Name:              "*",
+					NamespaceSelector: &metav1.LabelSelector{},
+					Consumers:         []v1alpha1.ServiceConsumer{{Peer: "peer-1"}},
+				}},
+			},
+		},
+		&v1alpha1.ExportedServices{
+			ObjectMeta: metav1.ObjectMeta{Name: "without-selectors", Namespace: "default"},
+			Spec: v1alpha1.ExportedServicesSpec{
+				Services: []v1alpha1.ExportedService{{Name: "api", Consumers: []v1alpha1.ServiceConsumer{{Peer: "peer-1"}}}},
+			},
+		},
+	).Build()
+
+	controller := &ExportedServicesController{Client: fakeClient, Log: logrtest.New(t)}
+	requests := controller.requestsForServiceSelectors(&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"}})
+	require.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: "with-namespace-selector", Namespace: "default"}},
+		{NamespacedName: types.NamespacedName{Name: "with-selectors", Namespace: "default"}},
+	}, requests)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package loglevel changes the log level of long-running components at runtime
+// from a ConfigMap so that they can be debugged without restarting their pods.
+package loglevel
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultPollInterval is how often the ConfigMap is read if PollInterval is not set.
+const DefaultPollInterval = 10 * time.Second
+
+// Watcher polls a ConfigMap for the log level of a component. The ConfigMap's
+// data is keyed by component name, e.g. "connect-injector: debug". When the
+// component's key is removed or the ConfigMap is deleted, the component goes
+// back to DefaultLevel.
+//
+// Access to the ConfigMap is controlled by Kubernetes RBAC, so only users who
+// can edit ConfigMaps in the component's namespace can change its log level.
+type Watcher struct {
+	Client        kubernetes.Interface
+	Namespace     string
+	ConfigMapName string
+	// Component is the key of the component's log level in the ConfigMap.
+	Component string
+	// DefaultLevel is the level the component was started with.
+	DefaultLevel string
+	// SetLevel sets the level of all the component's loggers. It must return
+	// an error if the level is invalid.
+	SetLevel     func(level string) error
+	PollInterval time.Duration
+	Log          hclog.Logger
+
+	currentLevel string
+}
+
+// Run polls the ConfigMap until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	interval := w.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+	w.currentLevel = w.DefaultLevel
+
+	for {
+		w.sync(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// sync reads the ConfigMap once and applies the component's log level if it changed.
+func (w *Watcher) sync(ctx context.Context) {
+	level := w.DefaultLevel
+	configMap, err := w.Client.CoreV1().ConfigMaps(w.Namespace).Get(ctx, w.ConfigMapName, metav1.GetOptions{})
+	switch {
+	case k8serrors.IsNotFound(err):
+	case err != nil:
+		if ctx.Err() == nil {
+			w.Log.Error("unable to read log level ConfigMap", "name", w.ConfigMapName, "err", err)
+		}
+		return
+	default:
+		if raw := strings.TrimSpace(configMap.Data[w.Component]); raw != "" {
+			level = raw
+		}
+	}
+
+	if strings.EqualFold(level, w.currentLevel) {
+		return
+	}
+	// Log before changing the level so that the message isn't dropped when the level is raised.
+	w.Log.Info("changing log level", "from", w.currentLevel, "to", level)
+	if err := w.SetLevel(level); err != nil {
+		w.Log.Error("invalid log level in ConfigMap", "name", w.ConfigMapName, "key", w.Component, "err", err)
+		return
+	}
+	w.currentLevel = level
+}
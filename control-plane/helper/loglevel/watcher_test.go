@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package loglevel
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWatcher_sync(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	var levels []string
+	w := &Watcher{
+		Client:        client,
+		Namespace:     "default",
+		ConfigMapName: "consul-log-levels",
+		Component:     "connect-injector",
+		DefaultLevel:  "info",
+		SetLevel: func(level string) error {
+			if level == "invalid" {
+				return fmt.Errorf("unknown log level: %s", level)
+			}
+			levels = append(levels, level)
+			return nil
+		},
+		Log:          hclog.NewNullLogger(),
+		currentLevel: "info",
+	}
+
+	// No ConfigMap keeps the default level.
+	w.sync(ctx)
+	require.Empty(t, levels)
+
+	// The component's key changes the level.
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "consul-log-levels", Namespace: "default"},
+		Data:       map[string]string{"connect-injector": "debug", "sync-catalog": "trace"},
+	}
+	_, err := client.CoreV1().ConfigMaps("default").Create(ctx, configMap, metav1.CreateOptions{})
+	require.NoError(t, err)
+	w.sync(ctx)
+	require.Equal(t, []string{"debug"}, levels)
+
+	// An unchanged level isn't set again.
+	w.sync(ctx)
+	require.Equal(t, []string{"debug"}, levels)
+
+	// An invalid level keeps the current level.
+	configMap.Data["connect-injector"] = "invalid"
+	_, err = client.CoreV1().ConfigMaps("default").Update(ctx, configMap, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	w.sync(ctx)
+	require.Equal(t, []string{"debug"}, levels)
+	require.Equal(t, "debug", w.currentLevel)
+
+	// Deleting the ConfigMap goes back to the default level.
+	require.NoError(t, client.CoreV1().ConfigMaps("default").Delete(ctx, "consul-log-levels", metav1.DeleteOptions{}))
+	w.sync(ctx)
+	require.Equal(t, []string{"debug", "info"}, levels)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package secretreload
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+	"sync/atomic"
+)
+
+// RootCAs are the CA certificates that TLS clients verify servers against.
+// Unlike tls.Config.RootCAs, they can be replaced while the clients are in
+// use, e.g. when the Consul CA rendered by the Vault agent changes.
+type RootCAs struct {
+	pool atomic.Pointer[x509.CertPool]
+}
+
+// NewRootCAs returns the CA certificates PEM-encoded in caPEM.
+func NewRootCAs(caPEM []byte) (*RootCAs, error) {
+	r := &RootCAs{}
+	if err := r.Update(caPEM); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update replaces the CA certificates with the ones PEM-encoded in caPEM.
+// New connections are verified against them, existing ones are kept.
+func (r *RootCAs) Update(caPEM []byte) error {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return errors.New("no CA certificates found")
+	}
+	r.pool.Store(pool)
+	return nil
+}
+
+// ConfigureTLS makes connections with cfg verify that the server has a
+// certificate for serverName that is signed by the current CA certificates.
+//
+// The TLS library only verifies servers against the RootCAs of the config,
+// which can't be changed once the config is in use. The server is verified in
+// VerifyConnection instead, which is why InsecureSkipVerify is set.
+func (r *RootCAs) ConfigureTLS(cfg *tls.Config, serverName string) error {
+	if serverName == "" {
+		return errors.New("a server name is needed to verify the server")
+	}
+	cfg.ServerName = serverName
+	cfg.RootCAs = nil
+	cfg.InsecureSkipVerify = true
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("server presented no certificates")
+		}
+		opts := x509.VerifyOptions{
+			Roots:         r.pool.Load(),
+			DNSName:       serverName,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+	return nil
+}
+
+// WatchRootCAs makes connections with cfg verify that the server has a
+// certificate for serverName that is signed by the CA certificates in the file
+// at path, and reloads them whenever the file changes.
+func (w *Watcher) WatchRootCAs(path string, cfg *tls.Config, serverName string) error {
+	caPEM, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	rootCAs, err := NewRootCAs(caPEM)
+	if err != nil {
+		return err
+	}
+	if err := rootCAs.ConfigureTLS(cfg, serverName); err != nil {
+		return err
+	}
+	return w.Watch(path, rootCAs.Update)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package secretreload
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/helper/cert"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRootCAs_ConfigureTLS(t *testing.T) {
+	oldCA, _ := generateCA(t)
+	newCA, serverCert := generateCA(t)
+
+	dial := startTLSServer(t, serverCert)
+
+	rootCAs, err := NewRootCAs([]byte(oldCA))
+	require.NoError(t, err)
+	cfg := &tls.Config{}
+	require.NoError(t, rootCAs.ConfigureTLS(cfg, "server.dc1.consul"))
+
+	// The server's certificate isn't signed by the old CA.
+	require.Error(t, dial(cfg))
+
+	require.NoError(t, rootCAs.Update([]byte(newCA)))
+	require.NoError(t, dial(cfg))
+
+	// The server name is still verified.
+	wrongName := &tls.Config{}
+	require.NoError(t, rootCAs.ConfigureTLS(wrongName, "server.dc2.consul"))
+	require.Error(t, dial(wrongName))
+}
+
+func TestRootCAs_Errors(t *testing.T) {
+	_, err := NewRootCAs([]byte("not a certificate"))
+	require.EqualError(t, err, "no CA certificates found")
+
+	caPEM, _ := generateCA(t)
+	rootCAs, err := NewRootCAs([]byte(caPEM))
+	require.NoError(t, err)
+	require.EqualError(t, rootCAs.ConfigureTLS(&tls.Config{}, ""), "a server name is needed to verify the server")
+}
+
+func TestWatcher_WatchRootCAs(t *testing.T) {
+	oldCA, _ := generateCA(t)
+	newCA, serverCert := generateCA(t)
+	dial := startTLSServer(t, serverCert)
+
+	path := filepath.Join(t.TempDir(), "ca.crt")
+	require.NoError(t, os.WriteFile(path, []byte(oldCA), 0600))
+	w := &Watcher{Log: hclog.NewNullLogger()}
+	cfg := &tls.Config{}
+	require.NoError(t, w.WatchRootCAs(path, cfg, "server.dc1.consul"))
+	require.Error(t, dial(cfg))
+
+	// The CA is reloaded once the file changes.
+	require.NoError(t, os.WriteFile(path, []byte(newCA), 0600))
+	w.sync(time.Now())
+	require.NoError(t, dial(cfg))
+}
+
+// startTLSServer starts a TLS server with serverCert and returns a function
+// that dials it with a config.
+func startTLSServer(t *testing.T, serverCert tls.Certificate) func(cfg *tls.Config) error {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{serverCert}})
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			// Complete the handshake before closing the connection.
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	return func(cfg *tls.Config) error {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", listener.Addr().String(), cfg)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// generateCA returns a PEM-encoded CA certificate and a server certificate
+// for server.dc1.consul signed by it.
+func generateCA(t *testing.T) (string, tls.Certificate) {
+	signer, _, caPEM, caTemplate, err := cert.GenerateCA("Consul CA")
+	require.NoError(t, err)
+	certPEM, keyPEM, err := cert.GenerateCert("server.dc1.consul", time.Hour, caTemplate, signer, []string{"server.dc1.consul"})
+	require.NoError(t, err)
+	serverCert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	require.NoError(t, err)
+	return caPEM, serverCert
+}
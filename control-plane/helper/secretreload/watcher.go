@@ -0,0 +1,162 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package secretreload reloads the secrets that the Vault agent renders into
+// files for long-running components, so that they pick up renewed TLS
+// material without restarting their pods.
+//
+// The Vault agent sidecar renews the leases of the secrets it renders, or
+// fetches them again when they can't be renewed, and rewrites their files.
+// Watcher notices the rewritten files and hands them to the component. It also
+// reports certificates that are about to expire without having been renewed,
+// e.g. because the Vault agent lost access to Vault.
+package secretreload
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	// DefaultPollInterval is how often the files are read if PollInterval is not set.
+	DefaultPollInterval = 30 * time.Second
+
+	// expiryWarningFraction is the fraction of a certificate's lifetime left
+	// when it is reported as not renewed. The Vault agent renews
+	// certificates well before that.
+	expiryWarningFraction = 0.1
+)
+
+// Watcher polls files for changes and calls a function with their new
+// contents. Polling instead of watching for file system events works with any
+// volume type and with both in-place writes and atomic renames.
+type Watcher struct {
+	PollInterval time.Duration
+	Log          hclog.Logger
+
+	mu    sync.Mutex
+	files []*watchedFile
+}
+
+type watchedFile struct {
+	path     string
+	onChange func(contents []byte) error
+	checksum [sha256.Size]byte
+	// warnedExpiry is true if the expiry of the certificate with the current
+	// checksum was reported, so that it is only reported once.
+	warnedExpiry bool
+}
+
+// Watch reads the file at path and calls onChange with its contents whenever
+// they change afterwards. onChange is called again on the next poll if it
+// returns an error. It can be nil to only report the expiry of the
+// certificate in the file.
+func (w *Watcher) Watch(path string, onChange func(contents []byte) error) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files = append(w.files, &watchedFile{
+		path:     path,
+		onChange: onChange,
+		checksum: sha256.Sum256(contents),
+	})
+	return nil
+}
+
+// Run polls the files until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	interval := w.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+
+	for {
+		w.sync(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// sync reads every file once and reloads the ones that changed.
+func (w *Watcher) sync(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, f := range w.files {
+		contents, err := os.ReadFile(f.path)
+		if err != nil {
+			w.Log.Error("unable to read secret file", "path", f.path, "err", err)
+			continue
+		}
+		if checksum := sha256.Sum256(contents); checksum != f.checksum {
+			if f.onChange != nil {
+				if err := f.onChange(contents); err != nil {
+					w.Log.Error("unable to reload secret file", "path", f.path, "err", err)
+					continue
+				}
+			}
+			w.Log.Info("reloaded secret file", "path", f.path)
+			f.checksum = checksum
+			f.warnedExpiry = false
+		}
+		if !f.warnedExpiry {
+			f.warnedExpiry = w.checkExpiry(f.path, contents, now)
+		}
+	}
+}
+
+// checkExpiry reports the first certificate in contents if it has expired or
+// is about to, and returns whether it was reported.
+func (w *Watcher) checkExpiry(path string, contents []byte, now time.Time) bool {
+	cert := firstCertificate(contents)
+	if cert == nil {
+		return false
+	}
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	remaining := cert.NotAfter.Sub(now)
+	switch {
+	case remaining <= 0:
+		w.Log.Error("certificate has expired and was not renewed; check that the Vault agent can reach Vault",
+			"path", path, "expiry", cert.NotAfter)
+	case remaining < time.Duration(float64(lifetime)*expiryWarningFraction):
+		w.Log.Warn("certificate expires soon and was not renewed; check that the Vault agent can reach Vault",
+			"path", path, "expiry", cert.NotAfter)
+	default:
+		return false
+	}
+	return true
+}
+
+// firstCertificate returns the first certificate PEM-encoded in contents, or
+// nil if there is none.
+func firstCertificate(contents []byte) *x509.Certificate {
+	for {
+		var block *pem.Block
+		block, contents = pem.Decode(contents)
+		if block == nil {
+			return nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil
+		}
+		return cert
+	}
+}
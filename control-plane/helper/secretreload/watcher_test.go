@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package secretreload
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/helper/cert"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_sync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0600))
+
+	var reloaded []string
+	var failReload bool
+	w := &Watcher{Log: hclog.NewNullLogger()}
+	require.NoError(t, w.Watch(path, func(contents []byte) error {
+		if failReload {
+			return errors.New("reload failed")
+		}
+		reloaded = append(reloaded, string(contents))
+		return nil
+	}))
+
+	// The initial contents are not reloaded.
+	w.sync(time.Now())
+	require.Empty(t, reloaded)
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0600))
+	w.sync(time.Now())
+	w.sync(time.Now())
+	require.Equal(t, []string{"second"}, reloaded)
+
+	// A failed reload is retried on the next poll.
+	require.NoError(t, os.WriteFile(path, []byte("third"), 0600))
+	failReload = true
+	w.sync(time.Now())
+	require.Equal(t, []string{"second"}, reloaded)
+	failReload = false
+	w.sync(time.Now())
+	require.Equal(t, []string{"second", "third"}, reloaded)
+
+	// A missing file is reported and the last contents are kept.
+	require.NoError(t, os.Remove(path))
+	w.sync(time.Now())
+	require.Equal(t, []string{"second", "third"}, reloaded)
+}
+
+func TestWatcher_Watch_MissingFile(t *testing.T) {
+	w := &Watcher{Log: hclog.NewNullLogger()}
+	require.Error(t, w.Watch(filepath.Join(t.TempDir(), "missing"), nil))
+}
+
+func TestWatcher_ReportsExpiringCertificates(t *testing.T) {
+	signer, _, _, caTemplate, err := cert.GenerateCA("Consul CA")
+	require.NoError(t, err)
+	certPEM, _, err := cert.GenerateCert("server.dc1.consul", time.Hour, caTemplate, signer, nil)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "tls.crt")
+	require.NoError(t, os.WriteFile(path, []byte(certPEM), 0600))
+
+	var logs bytes.Buffer
+	w := &Watcher{Log: hclog.New(&hclog.LoggerOptions{Output: &logs})}
+	require.NoError(t, w.Watch(path, nil))
+
+	w.sync(time.Now())
+	require.Empty(t, logs.String())
+
+	// Less than a tenth of the lifetime is left, which is reported once.
+	w.sync(time.Now().Add(55 * time.Minute))
+	require.Contains(t, logs.String(), "certificate expires soon and was not renewed")
+	logs.Reset()
+	w.sync(time.Now().Add(56 * time.Minute))
+	require.Empty(t, logs.String())
+
+	// A renewed certificate is reported again once it is about to expire.
+	certPEM, _, err = cert.GenerateCert("server.dc1.consul", time.Hour, caTemplate, signer, nil)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(certPEM), 0600))
+	w.sync(time.Now().Add(2 * time.Hour))
+	require.Contains(t, logs.String(), "certificate has expired and was not renewed")
+}
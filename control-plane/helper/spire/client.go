@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package spire is a minimal client for the SPIRE agent's Workload API and the
+// SPIRE server API. It only implements the calls needed to obtain a
+// downstream X.509 CA from SPIRE so that Consul's Connect CA can be chained to
+// a SPIFFE trust domain.
+package spire
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// workloadAPIHeader must be set on Workload API requests so that the
+	// agent can tell them apart from requests proxied by mistake.
+	workloadAPIHeader = "workload.spiffe.io"
+)
+
+// X509SVID is an X.509 SVID of this workload and the X.509 authorities of its
+// trust domain.
+type X509SVID struct {
+	// ID is the SPIFFE ID of the SVID.
+	ID *url.URL
+	// Certificates is the certificate chain of the SVID, leaf first.
+	Certificates []*x509.Certificate
+	// PrivateKey is the private key of the SVID.
+	PrivateKey crypto.Signer
+	// Bundle is the X.509 authorities of the trust domain.
+	Bundle []*x509.Certificate
+}
+
+// TrustDomain returns the trust domain of the SVID, e.g. "example.org".
+func (s *X509SVID) TrustDomain() string {
+	return s.ID.Host
+}
+
+// DownstreamCA is an X.509 CA signed by SPIRE.
+type DownstreamCA struct {
+	// CertChain is the CA certificate followed by the SPIRE CA certificates
+	// that signed it.
+	CertChain []*x509.Certificate
+	// Authorities is the X.509 authorities of the trust domain.
+	Authorities []*x509.Certificate
+}
+
+// Client talks to the SPIRE agent over its Workload API socket and to the
+// SPIRE server with the X.509 SVID obtained from the agent.
+type Client struct {
+	// AgentSocketPath is the path of the SPIRE agent's Workload API socket.
+	AgentSocketPath string
+	// ServerAddress is the host:port of the SPIRE server API.
+	ServerAddress string
+}
+
+// FetchX509SVID fetches the X.509 SVID of this workload from the SPIRE agent.
+// The workload must be registered with SPIRE.
+func (c *Client) FetchX509SVID(ctx context.Context) (*X509SVID, error) {
+	conn, err := grpc.DialContext(ctx, "unix://"+c.AgentSocketPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the SPIRE agent: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(ctx, workloadAPIHeader, "true"))
+	// Cancelling closes the stream; only the first response is needed.
+	defer cancel()
+	stream, err := workload.NewSpiffeWorkloadAPIClient(conn).FetchX509SVID(ctx, &workload.X509SVIDRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch X.509 SVID from the SPIRE agent: %w", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch X.509 SVID from the SPIRE agent: %w", err)
+	}
+	return parseX509SVIDResponse(resp)
+}
+
+// NewDownstreamCA asks the SPIRE server to sign a CA certificate for the
+// public key of the CSR. The workload must be registered as a downstream
+// workload. ttl is the preferred lifetime of the CA certificate; the server
+// default is used if it's zero.
+func (c *Client) NewDownstreamCA(ctx context.Context, csr []byte, ttl time.Duration) (*DownstreamCA, error) {
+	svid, err := c.FetchX509SVID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.DialContext(ctx, c.ServerAddress,
+		grpc.WithTransportCredentials(credentials.NewTLS(serverTLSConfig(svid))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the SPIRE server: %w", err)
+	}
+	defer conn.Close()
+
+	resp, err := svidv1.NewSVIDClient(conn).NewDownstreamX509CA(ctx, newNewDownstreamX509CARequest(csr, ttl))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get downstream CA from the SPIRE server: %w", err)
+	}
+	return parseNewDownstreamX509CAResponse(resp)
+}
+
+// serverTLSConfig returns the TLS config to authenticate to the SPIRE server
+// with the SVID. The server certificate is an SVID without DNS names, so
+// instead of the hostname its SPIFFE ID is verified.
+func serverTLSConfig(svid *X509SVID) *tls.Config {
+	cert := tls.Certificate{PrivateKey: svid.PrivateKey, Leaf: svid.Certificates[0]}
+	for _, c := range svid.Certificates {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	serverID := fmt.Sprintf("spiffe://%s/spire/server", svid.TrustDomain())
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		// The server certificate is verified in VerifyPeerCertificate.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyServerCertificate(rawCerts, svid.Bundle, serverID)
+		},
+	}
+}
+
+// verifyServerCertificate verifies that the certificate chain is signed by one
+// of the authorities and that the leaf has the given SPIFFE ID.
+func verifyServerCertificate(rawCerts [][]byte, authorities []*x509.Certificate, id string) error {
+	if len(rawCerts) == 0 {
+		return errors.New("SPIRE server presented no certificate")
+	}
+	var certs []*x509.Certificate
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse SPIRE server certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if err := VerifyChain(certs, authorities, x509.ExtKeyUsageServerAuth); err != nil {
+		return fmt.Errorf("failed to verify SPIRE server certificate: %w", err)
+	}
+	for _, uri := range certs[0].URIs {
+		if uri.String() == id {
+			return nil
+		}
+	}
+	return fmt.Errorf("SPIRE server certificate does not have SPIFFE ID %s", id)
+}
+
+// VerifyChain verifies that the first certificate is signed by one of the
+// authorities, using the other certificates as intermediates.
+func VerifyChain(certs []*x509.Certificate, authorities []*x509.Certificate, usage x509.ExtKeyUsage) error {
+	roots := x509.NewCertPool()
+	for _, authority := range authorities {
+		roots.AddCert(authority)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	})
+	return err
+}
@@ -0,0 +1,289 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package spire
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestClient_NewDownstreamCA(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t, "example.org")
+	workloadCert, workloadKey := ca.issue(t, "spiffe://example.org/consul-k8s", false)
+	serverCert, serverKey := ca.issue(t, "spiffe://example.org/spire/server", false)
+	downstreamCert, _ := ca.issue(t, "spiffe://example.org", true)
+
+	socketPath := startAgent(t, newTestX509SVIDResponse(t, "spiffe://example.org/consul-k8s", workloadCert, workloadKey, ca.cert))
+
+	var gotCSR []byte
+	var gotTTL int32
+	serverAddress := startServer(t, tls.Certificate{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey},
+		func(req *svidv1.NewDownstreamX509CARequest) *svidv1.NewDownstreamX509CAResponse {
+			gotCSR = req.GetCsr()
+			gotTTL = req.GetPreferredTtl()
+			return &svidv1.NewDownstreamX509CAResponse{
+				CaCertChain:     [][]byte{downstreamCert.Raw},
+				X509Authorities: [][]byte{ca.cert.Raw},
+			}
+		})
+
+	client := &Client{AgentSocketPath: socketPath, ServerAddress: serverAddress}
+	downstream, err := client.NewDownstreamCA(context.Background(), []byte("csr"), time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []byte("csr"), gotCSR)
+	require.Equal(t, int32(3600), gotTTL)
+	require.Len(t, downstream.CertChain, 1)
+	require.Equal(t, downstreamCert.Raw, downstream.CertChain[0].Raw)
+	require.Len(t, downstream.Authorities, 1)
+	require.Equal(t, ca.cert.Raw, downstream.Authorities[0].Raw)
+}
+
+func TestClient_NewDownstreamCA_RejectsServerWithWrongID(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t, "example.org")
+	workloadCert, workloadKey := ca.issue(t, "spiffe://example.org/consul-k8s", false)
+	serverCert, serverKey := ca.issue(t, "spiffe://example.org/not-spire", false)
+
+	socketPath := startAgent(t, newTestX509SVIDResponse(t, "spiffe://example.org/consul-k8s", workloadCert, workloadKey, ca.cert))
+	serverAddress := startServer(t, tls.Certificate{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey},
+		func(*svidv1.NewDownstreamX509CARequest) *svidv1.NewDownstreamX509CAResponse {
+			t.Error("unexpected request to the SPIRE server")
+			return nil
+		})
+
+	client := &Client{AgentSocketPath: socketPath, ServerAddress: serverAddress}
+	_, err := client.NewDownstreamCA(context.Background(), []byte("csr"), 0)
+	require.ErrorContains(t, err, "failed to get downstream CA from the SPIRE server")
+}
+
+func TestClient_FetchX509SVID(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t, "example.org")
+	cert, key := ca.issue(t, "spiffe://example.org/consul-k8s", false)
+	socketPath := startAgent(t, newTestX509SVIDResponse(t, "spiffe://example.org/consul-k8s", cert, key, ca.cert))
+
+	client := &Client{AgentSocketPath: socketPath}
+	svid, err := client.FetchX509SVID(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "spiffe://example.org/consul-k8s", svid.ID.String())
+	require.Equal(t, "example.org", svid.TrustDomain())
+	require.Equal(t, cert.Raw, svid.Certificates[0].Raw)
+	require.Equal(t, ca.cert.Raw, svid.Bundle[0].Raw)
+	require.Equal(t, key.Public(), svid.PrivateKey.Public())
+}
+
+func TestClient_FetchX509SVID_NotRegistered(t *testing.T) {
+	t.Parallel()
+
+	socketPath := startAgent(t, &workload.X509SVIDResponse{})
+	client := &Client{AgentSocketPath: socketPath}
+	_, err := client.FetchX509SVID(context.Background())
+	require.EqualError(t, err, "invalid X.509 SVID response: no SVIDs; the workload may not be registered with SPIRE")
+}
+
+func TestParseNewDownstreamX509CAResponse(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t, "example.org")
+	cases := map[string]struct {
+		resp   *svidv1.NewDownstreamX509CAResponse
+		expErr string
+	}{
+		"valid": {
+			resp: &svidv1.NewDownstreamX509CAResponse{CaCertChain: [][]byte{ca.cert.Raw}, X509Authorities: [][]byte{ca.cert.Raw}},
+		},
+		"no CA certificate": {
+			resp:   &svidv1.NewDownstreamX509CAResponse{X509Authorities: [][]byte{ca.cert.Raw}},
+			expErr: "invalid NewDownstreamX509CA response: no CA certificate",
+		},
+		"no authorities": {
+			resp:   &svidv1.NewDownstreamX509CAResponse{CaCertChain: [][]byte{ca.cert.Raw}},
+			expErr: "invalid NewDownstreamX509CA response: no X.509 authorities",
+		},
+		"invalid certificate": {
+			resp:   &svidv1.NewDownstreamX509CAResponse{CaCertChain: [][]byte{[]byte("not a certificate")}, X509Authorities: [][]byte{ca.cert.Raw}},
+			expErr: "invalid NewDownstreamX509CA response: failed to parse certificate",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			downstream, err := parseNewDownstreamX509CAResponse(c.resp)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, ca.cert.Raw, downstream.CertChain[0].Raw)
+		})
+	}
+}
+
+func TestNewNewDownstreamX509CARequest(t *testing.T) {
+	t.Parallel()
+
+	req := newNewDownstreamX509CARequest([]byte("csr"), time.Hour)
+	require.Equal(t, []byte("csr"), req.GetCsr())
+	require.Equal(t, int32(3600), req.GetPreferredTtl())
+
+	// The server default is used for TTLs below a second.
+	require.Zero(t, newNewDownstreamX509CARequest([]byte("csr"), time.Millisecond).GetPreferredTtl())
+}
+
+func TestVerifyChain(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t, "example.org")
+	otherCA := newTestCA(t, "example.com")
+	cert, _ := ca.issue(t, "spiffe://example.org/spire/server", false)
+
+	require.NoError(t, VerifyChain([]*x509.Certificate{cert}, []*x509.Certificate{ca.cert}, x509.ExtKeyUsageServerAuth))
+	require.Error(t, VerifyChain([]*x509.Certificate{cert}, []*x509.Certificate{otherCA.cert}, x509.ExtKeyUsageServerAuth))
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, trustDomain string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: trustDomain},
+		URIs:                  []*url.URL{{Scheme: "spiffe", Host: trustDomain}},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, id string, isCA bool) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		URIs:                  []*url.URL{uri},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	if isCA {
+		template.IsCA = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		template.ExtKeyUsage = nil
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// newTestX509SVIDResponse returns an X509SVIDResponse with one SVID.
+func newTestX509SVIDResponse(t *testing.T, id string, cert *x509.Certificate, key *ecdsa.PrivateKey, bundle *x509.Certificate) *workload.X509SVIDResponse {
+	t.Helper()
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return &workload.X509SVIDResponse{
+		Svids: []*workload.X509SVID{{
+			SpiffeId:    id,
+			X509Svid:    cert.Raw,
+			X509SvidKey: keyDER,
+			Bundle:      bundle.Raw,
+		}},
+	}
+}
+
+// fakeAgent is a SPIRE agent that responds to FetchX509SVID with resp.
+type fakeAgent struct {
+	workload.UnimplementedSpiffeWorkloadAPIServer
+	t    *testing.T
+	resp *workload.X509SVIDResponse
+}
+
+func (a *fakeAgent) FetchX509SVID(_ *workload.X509SVIDRequest, stream workload.SpiffeWorkloadAPI_FetchX509SVIDServer) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	require.Equal(a.t, []string{"true"}, md.Get(workloadAPIHeader))
+	return stream.Send(a.resp)
+}
+
+// startAgent starts a fake SPIRE agent that responds to FetchX509SVID with
+// resp and returns the path of its socket.
+func startAgent(t *testing.T, resp *workload.X509SVIDResponse) string {
+	t.Helper()
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	lis, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	workload.RegisterSpiffeWorkloadAPIServer(server, &fakeAgent{t: t, resp: resp})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return socketPath
+}
+
+// fakeServer is a SPIRE server that responds to NewDownstreamX509CA with
+// handle.
+type fakeServer struct {
+	svidv1.UnimplementedSVIDServer
+	handle func(*svidv1.NewDownstreamX509CARequest) *svidv1.NewDownstreamX509CAResponse
+}
+
+func (s *fakeServer) NewDownstreamX509CA(_ context.Context, req *svidv1.NewDownstreamX509CARequest) (*svidv1.NewDownstreamX509CAResponse, error) {
+	return s.handle(req), nil
+}
+
+// startServer starts a fake SPIRE server that requires a client certificate,
+// responds to NewDownstreamX509CA with handle and returns its address.
+func startServer(t *testing.T, cert tls.Certificate, handle func(*svidv1.NewDownstreamX509CARequest) *svidv1.NewDownstreamX509CAResponse) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+	})
+	server := grpc.NewServer(grpc.Creds(creds))
+	svidv1.RegisterSVIDServer(server, &fakeServer{handle: handle})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package spire
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
+)
+
+// newNewDownstreamX509CARequest returns a NewDownstreamX509CARequest for the
+// CSR. The preferred TTL is only set if ttl is at least a second.
+func newNewDownstreamX509CARequest(csr []byte, ttl time.Duration) *svidv1.NewDownstreamX509CARequest {
+	req := &svidv1.NewDownstreamX509CARequest{Csr: csr}
+	if seconds := int32(ttl / time.Second); seconds > 0 {
+		req.PreferredTtl = seconds
+	}
+	return req
+}
+
+// parseNewDownstreamX509CAResponse returns the CA of a
+// NewDownstreamX509CAResponse.
+func parseNewDownstreamX509CAResponse(resp *svidv1.NewDownstreamX509CAResponse) (*DownstreamCA, error) {
+	chain, err := parseCertificateList(resp.GetCaCertChain())
+	if err != nil {
+		return nil, fmt.Errorf("invalid NewDownstreamX509CA response: %w", err)
+	}
+	authorities, err := parseCertificateList(resp.GetX509Authorities())
+	if err != nil {
+		return nil, fmt.Errorf("invalid NewDownstreamX509CA response: %w", err)
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("invalid NewDownstreamX509CA response: no CA certificate")
+	}
+	if len(authorities) == 0 {
+		return nil, errors.New("invalid NewDownstreamX509CA response: no X.509 authorities")
+	}
+	return &DownstreamCA{CertChain: chain, Authorities: authorities}, nil
+}
+
+// parseX509SVIDResponse returns the first SVID of an X509SVIDResponse.
+func parseX509SVIDResponse(resp *workload.X509SVIDResponse) (*X509SVID, error) {
+	svids := resp.GetSvids()
+	if len(svids) == 0 {
+		return nil, errors.New("invalid X.509 SVID response: no SVIDs; the workload may not be registered with SPIRE")
+	}
+	svid, err := parseX509SVID(svids[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid X.509 SVID response: %w", err)
+	}
+	return svid, nil
+}
+
+// parseX509SVID parses an X509SVID, whose certificates and bundle are ASN.1
+// DER certificates, leaf first, and whose key is an ASN.1 DER PKCS#8 private
+// key.
+func parseX509SVID(msg *workload.X509SVID) (*X509SVID, error) {
+	rawID := msg.GetSpiffeId()
+	rawCerts := msg.GetX509Svid()
+	rawKey := msg.GetX509SvidKey()
+	rawBundle := msg.GetBundle()
+	if rawID == "" || len(rawCerts) == 0 || len(rawKey) == 0 || len(rawBundle) == 0 {
+		return nil, errors.New("SVID is missing the SPIFFE ID, certificates, private key or bundle")
+	}
+
+	svid := &X509SVID{}
+	var err error
+	svid.ID, err = url.Parse(rawID)
+	if err != nil {
+		return nil, err
+	}
+	if svid.ID.Scheme != "spiffe" || svid.ID.Host == "" {
+		return nil, fmt.Errorf("%q is not a SPIFFE ID", rawID)
+	}
+	if svid.Certificates, err = x509.ParseCertificates(rawCerts); err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(rawKey)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	if svid.PrivateKey, ok = key.(crypto.Signer); !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if svid.Bundle, err = x509.ParseCertificates(rawBundle); err != nil {
+		return nil, err
+	}
+	return svid, nil
+}
+
+// parseCertificateList parses a list of ASN.1 DER certificates.
+func parseCertificateList(list [][]byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, raw := range list {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
@@ -5,8 +5,11 @@
 package common
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +20,7 @@ import (
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-discover"
 	"github.com/hashicorp/go-hclog"
+	uberzap "go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
@@ -36,11 +40,19 @@ const (
 	CLILabelKey   = "managed-by"
 	CLILabelValue = "consul-k8s"
 
+	// PartitionDescription is the description of the Admin Partitions created
+	// by partition-init. It is how uninstall-cleanup tells them apart from
+	// partitions created by other means.
+	PartitionDescription = "Created by Helm installation"
+
 	// The number of times to attempt ACL Login.
 	numLoginRetries = 100
 
 	raftReplicationTimeout   = 2 * time.Second
 	tokenReadPollingInterval = 100 * time.Millisecond
+
+	// hclogTimeFormat is the format of timestamps in hclog's JSON logs.
+	hclogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
 )
 
 // Logger returns an hclog instance with log level set and JSON logging enabled/disabled, or an error if level is invalid.
@@ -63,19 +75,68 @@ func NamedLogger(level string, jsonLogging bool, name string) (hclog.Logger, err
 
 // ZapLogger returns a logr.Logger instance with log level set and JSON logging enabled/disabled, or an error if the level is invalid.
 func ZapLogger(level string, jsonLogging bool) (logr.Logger, error) {
+	logger, _, err := AtomicZapLogger(level, jsonLogging, false)
+	return logger, err
+}
+
+// AtomicZapLogger returns a logr.Logger instance like ZapLogger along with its level, which can be
+// changed with SetZapLevel while the logger is in use.
+//
+// If hclogJSONKeys is true, JSON logs use the same keys as hclog's JSON logs (@timestamp, @level,
+// @message, ...) so that all components log consistent fields. Otherwise they use zap's keys (ts,
+// level, msg, ...) so that existing log pipelines keep working.
+func AtomicZapLogger(level string, jsonLogging, hclogJSONKeys bool) (logr.Logger, uberzap.AtomicLevel, error) {
+	atomicLevel := uberzap.NewAtomicLevel()
+	if err := SetZapLevel(atomicLevel, level); err != nil {
+		return logr.Logger{}, atomicLevel, err
+	}
+	encoder := zap.ConsoleEncoder()
+	if jsonLogging {
+		encoder = zapJSONEncoder(hclogJSONKeys)
+	}
+	return zap.New(zap.UseDevMode(false), zap.Level(atomicLevel), encoder), atomicLevel, nil
+}
+
+// zapJSONEncoder returns the JSON encoder of AtomicZapLogger.
+func zapJSONEncoder(hclogJSONKeys bool) zap.Opts {
+	if !hclogJSONKeys {
+		return zap.JSONEncoder()
+	}
+	return zap.JSONEncoder(func(cfg *zapcore.EncoderConfig) {
+		cfg.TimeKey = "@timestamp"
+		cfg.LevelKey = "@level"
+		cfg.MessageKey = "@message"
+		cfg.NameKey = "@module"
+		cfg.CallerKey = "@caller"
+		cfg.EncodeTime = zapcore.TimeEncoderOfLayout(hclogTimeFormat)
+		cfg.EncodeLevel = zapcore.LowercaseLevelEncoder
+	})
+}
+
+// SetZapLevel sets the level of a logger returned by AtomicZapLogger, or returns an error if the level is invalid.
+func SetZapLevel(atomicLevel uberzap.AtomicLevel, level string) error {
 	var zapLevel zapcore.Level
 	// It is possible that a user passes in "trace" from global.logLevel, until we standardize on one logging framework
 	// we will assume they meant debug here and not fail.
-	if level == "trace" || level == "TRACE" {
+	if strings.EqualFold(level, "trace") {
 		level = "debug"
 	}
 	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
-		return logr.Logger{}, fmt.Errorf("unknown log level %q: %s", level, err.Error())
+		return fmt.Errorf("unknown log level %q: %s", level, err.Error())
 	}
-	if jsonLogging {
-		return zap.New(zap.UseDevMode(false), zap.Level(zapLevel), zap.JSONEncoder()), nil
+	atomicLevel.SetLevel(zapLevel)
+	return nil
+}
+
+// SetLoggerLevel sets the level of an hclog instance and the loggers created from it with Named or With,
+// or returns an error if the level is invalid.
+func SetLoggerLevel(logger hclog.Logger, level string) error {
+	parsedLevel := hclog.LevelFromString(level)
+	if parsedLevel == hclog.NoLevel {
+		return fmt.Errorf("unknown log level: %s", level)
 	}
-	return zap.New(zap.UseDevMode(false), zap.Level(zapLevel), zap.ConsoleEncoder()), nil
+	logger.SetLevel(parsedLevel)
+	return nil
 }
 
 // ValidateUnprivilegedPort converts flags representing ports into integer and validates
@@ -92,6 +153,18 @@ func ValidateUnprivilegedPort(flagName, flagValue string) error {
 	return nil
 }
 
+// clusterNameRegex matches cluster names that can be used in Consul node names and ACL resource names.
+var clusterNameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidateClusterName validates the name that distinguishes a Kubernetes cluster from the other
+// Kubernetes clusters that are registered with the same Consul datacenter.
+func ValidateClusterName(flagName, flagValue string) error {
+	if !clusterNameRegex.MatchString(flagValue) {
+		return fmt.Errorf("%s value of %q must be at most 63 characters long and only contain lowercase letters, digits and '-', starting and ending with a letter or digit", flagName, flagValue)
+	}
+	return nil
+}
+
 // LoginParams are parameters used to log in to consul.
 type LoginParams struct {
 	// AuthMethod is the name of the auth method.
@@ -228,3 +301,21 @@ func GetResolvedServerAddresses(serverAddresses []string, providers map[string]d
 	}
 	return godiscover.ConsulServerAddresses(serverAddresses[0], providers, logger)
 }
+
+// GenerateGossipKey generates a random 32 byte secret returned as a base64 encoded string.
+func GenerateGossipKey() (string, error) {
+	// This code was copied from Consul's Keygen command:
+	// https://github.com/hashicorp/consul/blob/d652cc86e3d0322102c2b5e9026c6a60f36c17a5/command/keygen/keygen.go
+
+	key := make([]byte, 32)
+	n, err := rand.Reader.Read(key)
+
+	if err != nil {
+		return "", fmt.Errorf("error reading random data: %s", err)
+	}
+	if n != 32 {
+		return "", fmt.Errorf("couldn't read enough entropy")
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
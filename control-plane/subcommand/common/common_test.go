@@ -4,6 +4,8 @@
 package common
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -18,6 +20,7 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
 func TestLogger_InvalidLogLevel(t *testing.T) {
@@ -36,6 +39,62 @@ func TestZapLogger_TraceLogLevel(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAtomicZapLogger_SetZapLevel(t *testing.T) {
+	lgr, level, err := AtomicZapLogger("info", true, false)
+	require.NoError(t, err)
+	require.False(t, lgr.V(1).Enabled())
+
+	require.NoError(t, SetZapLevel(level, "debug"))
+	require.True(t, lgr.V(1).Enabled())
+
+	require.NoError(t, SetZapLevel(level, "error"))
+	require.False(t, lgr.V(0).Enabled())
+
+	require.EqualError(t, SetZapLevel(level, "invalid"), "unknown log level \"invalid\": unrecognized level: \"invalid\"")
+	require.False(t, lgr.V(0).Enabled())
+}
+
+func TestZapJSONEncoder(t *testing.T) {
+	cases := map[string]struct {
+		hclogJSONKeys bool
+		expKeys       []string
+	}{
+		"zap keys by default": {
+			expKeys: []string{"ts", "level", "logger", "msg"},
+		},
+		"hclog keys": {
+			hclogJSONKeys: true,
+			expKeys:       []string{"@timestamp", "@level", "@module", "@message"},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			lgr := zap.New(zap.WriteTo(&buf), zapJSONEncoder(c.hclogJSONKeys))
+			lgr.WithName("test").Info("hello")
+
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			for _, key := range c.expKeys {
+				require.Contains(t, entry, key)
+			}
+			require.Len(t, entry, len(c.expKeys))
+		})
+	}
+}
+
+func TestSetLoggerLevel(t *testing.T) {
+	lgr, err := Logger("info", false)
+	require.NoError(t, err)
+	named := lgr.Named("child")
+
+	require.NoError(t, SetLoggerLevel(lgr, "trace"))
+	require.True(t, named.IsTrace())
+
+	require.EqualError(t, SetLoggerLevel(lgr, "invalid"), "unknown log level: invalid")
+	require.True(t, named.IsTrace())
+}
+
 func TestLogger(t *testing.T) {
 	lgr, err := Logger("debug", false)
 	require.NoError(t, err)
@@ -0,0 +1,274 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package configurecoredns
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+type Command struct {
+	UI cli.Ui
+
+	flags *flag.FlagSet
+	k8s   *flags.K8SFlags
+
+	flagNamespace        string
+	flagDNSServiceName   string
+	flagCoreDNSNamespace string
+	flagCoreDNSConfigMap string
+	flagCorefileKey      string
+	flagDomain           string
+	flagRemove           bool
+	flagTimeout          time.Duration
+
+	flagLogLevel string
+	flagLogJSON  bool
+
+	k8sClient kubernetes.Interface
+
+	// retryInterval is how often the DNS Service and the CoreDNS ConfigMap are retried.
+	retryInterval time.Duration
+
+	log  hclog.Logger
+	once sync.Once
+	ctx  context.Context
+	help string
+}
+
+// init is run once to set up usage documentation for flags.
+func (c *Command) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+
+	c.flags.StringVar(&c.flagLogLevel, "log-level", "info",
+		"Log verbosity level. Supported values (in order of detail) are \"trace\", "+
+			"\"debug\", \"info\", \"warn\", and \"error\".")
+	c.flags.BoolVar(&c.flagLogJSON, "log-json", false, "Enable or disable JSON output format for logging.")
+	c.flags.StringVar(&c.flagNamespace, "namespace", "", "Name of Kubernetes namespace of the Consul DNS proxy Service.")
+	c.flags.StringVar(&c.flagDNSServiceName, "dns-service-name", "", "Name of the Consul DNS proxy Service that CoreDNS forwards queries to.")
+	c.flags.StringVar(&c.flagCoreDNSNamespace, "coredns-namespace", "kube-system", "Name of Kubernetes namespace of the CoreDNS ConfigMap.")
+	c.flags.StringVar(&c.flagCoreDNSConfigMap, "coredns-configmap", "coredns", "Name of the CoreDNS ConfigMap.")
+	c.flags.StringVar(&c.flagCorefileKey, "corefile-key", "Corefile", "Key of the Corefile in the CoreDNS ConfigMap.")
+	c.flags.StringVar(&c.flagDomain, "domain", "consul", "Consul DNS domain that CoreDNS forwards to the Consul DNS proxy.")
+	c.flags.BoolVar(&c.flagRemove, "remove", false,
+		"Remove the server block previously added to the Corefile instead of adding or updating it.")
+	c.flags.DurationVar(&c.flagTimeout, "timeout", 2*time.Minute,
+		"How long to wait for the Consul DNS proxy Service to be assigned a cluster IP.")
+
+	c.k8s = &flags.K8SFlags{}
+	flags.Merge(c.flags, c.k8s.Flags())
+
+	c.help = flags.Usage(help, c.flags)
+}
+
+// Run adds a server block forwarding the Consul DNS domain to the Consul DNS proxy Service
+// to the CoreDNS Corefile, or removes it if -remove is set.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+
+	if err := c.flags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+
+	if err := c.validateFlags(); err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to validate flags: %v", err))
+		return 1
+	}
+
+	var err error
+	c.log, err = common.Logger(c.flagLogLevel, c.flagLogJSON)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if c.ctx == nil {
+		c.ctx = context.Background()
+	}
+	if c.retryInterval == 0 {
+		c.retryInterval = 2 * time.Second
+	}
+
+	if c.k8sClient == nil {
+		config, err := subcommand.K8SConfig(c.k8s.KubeConfig())
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to create Kubernetes config: %v", err))
+			return 1
+		}
+		c.k8sClient, err = kubernetes.NewForConfig(config)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to create Kubernetes client: %v", err))
+			return 1
+		}
+	}
+
+	var block string
+	if !c.flagRemove {
+		clusterIP, err := c.dnsServiceClusterIP()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to get cluster IP of Service %s/%s: %v", c.flagNamespace, c.flagDNSServiceName, err))
+			return 1
+		}
+		block = c.serverBlock(clusterIP)
+	}
+
+	// Retry on conflicts since other controllers, e.g. managed Kubernetes add-on managers,
+	// may update the CoreDNS ConfigMap at the same time.
+	err = backoff.Retry(func() error {
+		err := c.updateCorefile(block)
+		if err != nil && !apierrors.IsConflict(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}, backoff.WithMaxRetries(backoff.NewConstantBackOff(c.retryInterval), 10))
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to update ConfigMap %s/%s: %v", c.flagCoreDNSNamespace, c.flagCoreDNSConfigMap, err))
+		return 1
+	}
+
+	if c.flagRemove {
+		c.UI.Info(fmt.Sprintf("Removed the %q server block from ConfigMap %s/%s.", c.flagDomain, c.flagCoreDNSNamespace, c.flagCoreDNSConfigMap))
+	} else {
+		c.UI.Info(fmt.Sprintf("Configured ConfigMap %s/%s to forward %q queries to Service %s/%s.",
+			c.flagCoreDNSNamespace, c.flagCoreDNSConfigMap, c.flagDomain, c.flagNamespace, c.flagDNSServiceName))
+	}
+	return 0
+}
+
+// dnsServiceClusterIP waits until the DNS proxy Service has a cluster IP and returns it.
+func (c *Command) dnsServiceClusterIP() (string, error) {
+	var clusterIP string
+	err := backoff.Retry(func() error {
+		svc, err := c.k8sClient.CoreV1().Services(c.flagNamespace).Get(c.ctx, c.flagDNSServiceName, metav1.GetOptions{})
+		if err != nil {
+			c.log.Info("waiting for Consul DNS proxy Service", "err", err)
+			return err
+		}
+		if svc.Spec.ClusterIP == "" || svc.Spec.ClusterIP == corev1.ClusterIPNone {
+			return backoff.Permanent(errors.New("the Service must have a cluster IP"))
+		}
+		clusterIP = svc.Spec.ClusterIP
+		return nil
+	}, backoff.WithMaxRetries(backoff.NewConstantBackOff(c.retryInterval), uint64(c.flagTimeout/c.retryInterval)))
+	return clusterIP, err
+}
+
+// updateCorefile replaces the server block managed by this command in the Corefile with block.
+// If block is empty, the managed server block is removed.
+func (c *Command) updateCorefile(block string) error {
+	configMaps := c.k8sClient.CoreV1().ConfigMaps(c.flagCoreDNSNamespace)
+	cm, err := configMaps.Get(c.ctx, c.flagCoreDNSConfigMap, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) && c.flagRemove {
+			return nil
+		}
+		return err
+	}
+	corefile, ok := cm.Data[c.flagCorefileKey]
+	if !ok && !c.flagRemove {
+		return fmt.Errorf("ConfigMap has no %q key", c.flagCorefileKey)
+	}
+
+	updated := replaceServerBlock(corefile, c.beginMarker(), c.endMarker(), block)
+	if updated == corefile {
+		c.log.Info("Corefile is already up to date")
+		return nil
+	}
+	cm.Data[c.flagCorefileKey] = updated
+	_, err = configMaps.Update(c.ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// serverBlock returns the Corefile server block forwarding the Consul DNS domain to clusterIP.
+// The markers allow the block to be updated or removed without touching the rest of the Corefile.
+func (c *Command) serverBlock(clusterIP string) string {
+	return fmt.Sprintf(`%s
+%s:53 {
+    errors
+    cache 30
+    forward . %s
+}
+%s
+`, c.beginMarker(), c.flagDomain, clusterIP, c.endMarker())
+}
+
+func (c *Command) beginMarker() string {
+	return fmt.Sprintf("# BEGIN consul-k8s %s/%s", c.flagNamespace, c.flagDNSServiceName)
+}
+
+func (c *Command) endMarker() string {
+	return fmt.Sprintf("# END consul-k8s %s/%s", c.flagNamespace, c.flagDNSServiceName)
+}
+
+// replaceServerBlock replaces the lines between begin and end (inclusive) in corefile with block,
+// or appends block if the markers are not found.
+func replaceServerBlock(corefile, begin, end, block string) string {
+	start := strings.Index(corefile, begin+"\n")
+	if start >= 0 {
+		if stop := strings.Index(corefile[start:], end+"\n"); stop >= 0 {
+			return corefile[:start] + block + corefile[start+stop+len(end)+1:]
+		}
+	}
+	if block == "" {
+		return corefile
+	}
+	if corefile != "" && !strings.HasSuffix(corefile, "\n") {
+		corefile += "\n"
+	}
+	return corefile + block
+}
+
+// Help returns the command's help text.
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return c.help
+}
+
+// Synopsis returns a one-line synopsis of the command.
+func (c *Command) Synopsis() string {
+	return synopsis
+}
+
+// validateFlags ensures that all required flags are set.
+func (c *Command) validateFlags() error {
+	if c.flagNamespace == "" {
+		return errors.New("-namespace must be set")
+	}
+	if c.flagDNSServiceName == "" {
+		return errors.New("-dns-service-name must be set")
+	}
+	if c.flagCoreDNSNamespace == "" || c.flagCoreDNSConfigMap == "" || c.flagCorefileKey == "" {
+		return errors.New("-coredns-namespace, -coredns-configmap and -corefile-key must be set")
+	}
+	if c.flagDomain == "" {
+		return errors.New("-domain must be set")
+	}
+	return nil
+}
+
+const synopsis = "Configure CoreDNS to forward Consul DNS queries to the Consul DNS proxy."
+const help = `
+Usage: consul-k8s-control-plane configure-coredns [options]
+
+  Adds a server block to the CoreDNS Corefile that forwards queries for the
+  Consul DNS domain to the Consul DNS proxy Service, or removes it with -remove.
+  CoreDNS picks up the change if its reload plugin is enabled.
+`
@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package configurecoredns
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const defaultCorefile = `.:53 {
+    errors
+    health
+    kubernetes cluster.local in-addr.arpa ip6.arpa
+    forward . /etc/resolv.conf
+    cache 30
+    reload
+}
+`
+
+func TestRun_FlagValidation(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		flags  []string
+		expErr string
+	}{
+		{
+			flags:  []string{},
+			expErr: "-namespace must be set",
+		},
+		{
+			flags:  []string{"-namespace", "default"},
+			expErr: "-dns-service-name must be set",
+		},
+		{
+			flags:  []string{"-namespace", "default", "-dns-service-name", "consul-dns-proxy", "-coredns-configmap", ""},
+			expErr: "-coredns-namespace, -coredns-configmap and -corefile-key must be set",
+		},
+		{
+			flags:  []string{"-namespace", "default", "-dns-service-name", "consul-dns-proxy", "-domain", ""},
+			expErr: "-domain must be set",
+		},
+		{
+			flags:  []string{"-namespace", "default", "-dns-service-name", "consul-dns-proxy", "-log-level", "oak"},
+			expErr: "unknown log level",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := Command{
+				UI: ui,
+			}
+			code := cmd.Run(c.flags)
+			require.Equal(t, 1, code)
+			require.Contains(t, ui.ErrorWriter.String(), c.expErr)
+		})
+	}
+}
+
+func TestRun_AddsUpdatesAndRemovesServerBlock(t *testing.T) {
+	t.Parallel()
+	k8s := fake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-dns-proxy", Namespace: "consul"},
+			Spec:       corev1.ServiceSpec{ClusterIP: "10.0.0.53"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Data:       map[string]string{"Corefile": defaultCorefile},
+		},
+	)
+	run := func(args ...string) string {
+		ui := cli.NewMockUi()
+		cmd := Command{UI: ui, k8sClient: k8s, retryInterval: 10 * time.Millisecond}
+		code := cmd.Run(append([]string{"-namespace=consul", "-dns-service-name=consul-dns-proxy"}, args...))
+		require.Equal(t, 0, code, ui.ErrorWriter.String())
+		cm, err := k8s.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
+		require.NoError(t, err)
+		return cm.Data["Corefile"]
+	}
+
+	expBlock := `# BEGIN consul-k8s consul/consul-dns-proxy
+consul:53 {
+    errors
+    cache 30
+    forward . 10.0.0.53
+}
+# END consul-k8s consul/consul-dns-proxy
+`
+	require.Equal(t, defaultCorefile+expBlock, run())
+
+	// Running it again does not add the server block twice.
+	require.Equal(t, defaultCorefile+expBlock, run())
+
+	// The server block is updated in place when the Service's cluster IP changes.
+	svc, err := k8s.CoreV1().Services("consul").Get(context.Background(), "consul-dns-proxy", metav1.GetOptions{})
+	require.NoError(t, err)
+	svc.Spec.ClusterIP = "10.0.0.54"
+	_, err = k8s.CoreV1().Services("consul").Update(context.Background(), svc, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	corefile := run("-domain=example")
+	require.Contains(t, corefile, "example:53 {\n")
+	require.Contains(t, corefile, "forward . 10.0.0.54\n")
+	require.NotContains(t, corefile, "10.0.0.53")
+
+	require.Equal(t, defaultCorefile, run("-remove"))
+}
+
+func TestRun_RemoveWithoutConfigMap(t *testing.T) {
+	t.Parallel()
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, k8sClient: fake.NewSimpleClientset(), retryInterval: 10 * time.Millisecond}
+	code := cmd.Run([]string{"-namespace=consul", "-dns-service-name=consul-dns-proxy", "-remove"})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+}
+
+func TestRun_ServiceWithoutClusterIP(t *testing.T) {
+	t.Parallel()
+	k8s := fake.NewSimpleClientset(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "consul-dns-proxy", Namespace: "consul"},
+		Spec:       corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone},
+	})
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, k8sClient: k8s, retryInterval: 10 * time.Millisecond}
+	code := cmd.Run([]string{"-namespace=consul", "-dns-service-name=consul-dns-proxy"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "the Service must have a cluster IP")
+}
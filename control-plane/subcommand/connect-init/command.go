@@ -12,11 +12,13 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/cenkalti/backoff"
+	"github.com/hashicorp/consul-k8s/control-plane/cni/ip6tables"
 	"github.com/hashicorp/consul-server-connection-manager/discovery"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/iptables"
@@ -37,6 +39,10 @@ const (
 
 	// The number of times to attempt to read this service (120s).
 	defaultServicePollingRetries = 120
+
+	// cniStatusComplete is the transparent proxy status the consul-cni plugin sets on a pod once it
+	// has applied the traffic redirection rules.
+	cniStatusComplete = "complete"
 )
 
 type Command struct {
@@ -49,6 +55,8 @@ type Command struct {
 	flagServiceName           string // Service name.
 	flagGatewayKind           string
 	flagRedirectTrafficConfig string
+	flagRedirectTrafficIPv6   bool
+	flagCNIStatusFile         string
 	flagLogLevel              string
 	flagLogJSON               bool
 
@@ -71,6 +79,9 @@ type Command struct {
 	// Only used in tests.
 	iptablesProvider iptables.Provider
 	iptablesConfig   iptables.Config
+	// ip6tablesProvider is only used in tests. When set, IPv6 rules are applied with it if IPv6 traffic
+	// redirection is enabled, whether or not the pod has an IPv6 address.
+	ip6tablesProvider iptables.Provider
 }
 
 func (c *Command) init() {
@@ -84,6 +95,10 @@ func (c *Command) init() {
 	c.flagSet.BoolVar(&c.flagMultiPort, "multiport", false, "If the pod is a multi port pod.")
 	c.flagSet.StringVar(&c.flagGatewayKind, "gateway-kind", "", "Kind of gateway that is being registered: ingress-gateway, terminating-gateway, or mesh-gateway.")
 	c.flagSet.StringVar(&c.flagRedirectTrafficConfig, "redirect-traffic-config", os.Getenv("CONSUL_REDIRECT_TRAFFIC_CONFIG"), "Config (in JSON format) to configure iptables for this pod.")
+	c.flagSet.BoolVar(&c.flagRedirectTrafficIPv6, "redirect-traffic-ipv6", os.Getenv("CONSUL_REDIRECT_TRAFFIC_IPV6") == "true", "Also redirect IPv6 traffic with ip6tables when the pod has an IPv6 address.")
+	c.flagSet.StringVar(&c.flagCNIStatusFile, "cni-status-file", os.Getenv("CONSUL_CNI_STATUS_FILE"),
+		"File with the transparent proxy status annotation of the pod. If set, initialization fails unless the consul-cni plugin "+
+			"reports that it applied the traffic redirection rules.")
 	c.flagSet.StringVar(&c.flagLogLevel, "log-level", "info",
 		"Log verbosity level. Supported values (in order of detail) are \"trace\", "+
 			"\"debug\", \"info\", \"warn\", and \"error\".")
@@ -213,6 +228,14 @@ func (c *Command) Run(args []string) int {
 		}
 	}
 
+	if c.flagCNIStatusFile != "" {
+		err = backoff.Retry(c.checkCNIStatus, backoff.WithMaxRetries(backoff.NewConstantBackOff(1*time.Second), c.serviceRegistrationPollingAttempts))
+		if err != nil {
+			c.logger.Error("Timed out waiting for the consul-cni plugin to apply traffic redirection rules", "error", err)
+			return 1
+		}
+	}
+
 	c.logger.Info("Connect initialization completed")
 	return 0
 }
@@ -352,6 +375,19 @@ func (c *Command) getGatewayRegistration(client *api.Client) backoff.Operation {
 	}
 }
 
+// checkCNIStatus returns an error unless the consul-cni plugin has applied the traffic redirection rules of the pod.
+// The kubelet updates the status file when the plugin updates the annotation, so the pod may start before it does.
+func (c *Command) checkCNIStatus() error {
+	status, err := os.ReadFile(c.flagCNIStatusFile)
+	if err != nil {
+		return err
+	}
+	if s := strings.TrimSpace(string(status)); s != cniStatusComplete {
+		return fmt.Errorf("transparent proxy status of the pod is %q, expected %q; check that the consul-cni plugin is installed on the node", s, cniStatusComplete)
+	}
+	return nil
+}
+
 func (c *Command) validateFlags() error {
 	if c.flagPodName == "" {
 		return errors.New("-pod-name must be set")
@@ -419,11 +455,30 @@ func (c *Command) applyTrafficRedirectionRules(svc *api.AgentService) error {
 	}
 
 	// Configure any relevant information from the proxy service
-	err = iptables.Setup(c.iptablesConfig)
+	ipv4Config, ipv6Config := ip6tables.SplitConfigByFamily(c.iptablesConfig)
+	err = iptables.Setup(ipv4Config)
 	if err != nil {
 		return err
 	}
-	c.logger.Info("Successfully applied traffic redirection rules")
+
+	// In IPv6 and dual-stack clusters, IPv6 traffic is redirected as well when enabled.
+	ipv6Enabled := c.flagRedirectTrafficIPv6
+	if ipv6Enabled && c.ip6tablesProvider == nil {
+		ipv6Enabled, err = hasIPv6Address()
+		if err != nil {
+			return fmt.Errorf("failed to read pod IP addresses: %s", err)
+		}
+	}
+	if ipv6Enabled {
+		ipv6Config.IptablesProvider = c.ip6tablesProvider
+		if ipv6Config.IptablesProvider == nil {
+			ipv6Config.IptablesProvider = &ip6tables.Executor{}
+		}
+		if err = iptables.Setup(ipv6Config); err != nil {
+			return fmt.Errorf("failed to apply IPv6 traffic redirection rules: %s", err)
+		}
+	}
+	c.logger.Info("Successfully applied traffic redirection rules", "ipv6", ipv6Enabled)
 	return nil
 }
 
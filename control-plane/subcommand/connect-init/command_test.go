@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -737,14 +738,57 @@ func TestRun_InvalidProxyFile(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestCheckCNIStatus(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		status string
+		expErr string
+	}{
+		"complete": {
+			status: "complete",
+		},
+		"waiting": {
+			status: "waiting",
+			expErr: `transparent proxy status of the pod is "waiting", expected "complete"`,
+		},
+		"not applied by the plugin": {
+			status: "enabled",
+			expErr: `transparent proxy status of the pod is "enabled", expected "complete"`,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			statusFile := filepath.Join(t.TempDir(), "status")
+			require.NoError(t, os.WriteFile(statusFile, []byte(c.status), 0444))
+			cmd := Command{flagCNIStatusFile: statusFile}
+			err := cmd.checkCNIStatus()
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, c.expErr)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		cmd := Command{flagCNIStatusFile: filepath.Join(t.TempDir(), "status")}
+		require.Error(t, cmd.checkCNIStatus())
+	})
+}
+
 func TestRun_TrafficRedirection(t *testing.T) {
 	cases := map[string]struct {
 		proxyConfig           map[string]interface{}
 		tproxyConfig          api.TransparentProxyConfig
 		registerProxyDefaults bool
+		redirectIPv6          bool
 		expIptablesParamsFunc func(actual iptables.Config) (bool, string)
 	}{
 		"no extra proxy config provided": {},
+		"IPv6 traffic redirection is enabled": {
+			redirectIPv6: true,
+		},
 		"envoy bind port is provided in service proxy config": {
 			proxyConfig: map[string]interface{}{"bind_port": "21000"},
 			expIptablesParamsFunc: func(actual iptables.Config) (bool, string) {
@@ -859,6 +903,7 @@ func TestRun_TrafficRedirection(t *testing.T) {
 			ui := cli.NewMockUi()
 
 			iptablesProvider := &fakeIptablesProvider{}
+			ip6tablesProvider := &fakeIptablesProvider{}
 			iptablesCfg := iptables.Config{
 				ProxyUserID:      "5995",
 				ProxyInboundPort: 20000,
@@ -867,6 +912,7 @@ func TestRun_TrafficRedirection(t *testing.T) {
 				UI:                                 ui,
 				serviceRegistrationPollingAttempts: 3,
 				iptablesProvider:                   iptablesProvider,
+				ip6tablesProvider:                  ip6tablesProvider,
 			}
 			iptablesCfgJSON, err := json.Marshal(iptablesCfg)
 			require.NoError(t, err)
@@ -880,9 +926,13 @@ func TestRun_TrafficRedirection(t *testing.T) {
 				"-proxy-id-file", proxyFile,
 				"-redirect-traffic-config", string(iptablesCfgJSON),
 			}
+			if c.redirectIPv6 {
+				flags = append(flags, "-redirect-traffic-ipv6")
+			}
 			code := cmd.Run(flags)
 			require.Equal(t, 0, code, ui.ErrorWriter.String())
 			require.Truef(t, iptablesProvider.applyCalled, "redirect traffic rules were not applied")
+			require.Equal(t, c.redirectIPv6, ip6tablesProvider.applyCalled)
 			if c.expIptablesParamsFunc != nil {
 				actualIptablesConfigParamsEqualExpected, errMsg := c.expIptablesParamsFunc(cmd.iptablesConfig)
 				require.Truef(t, actualIptablesConfigParamsEqualExpected, errMsg)
@@ -0,0 +1,24 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package connectinit
+
+import (
+	"net"
+)
+
+// hasIPv6Address returns true if the pod's network namespace has a global unicast IPv6 address,
+// i.e. the pod runs in an IPv6 or dual-stack cluster.
+func hasIPv6Address() (bool, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && ipNet.IP.To4() == nil && ipNet.IP.IsGlobalUnicast() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
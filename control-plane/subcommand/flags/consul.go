@@ -6,6 +6,8 @@ package flags
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -43,6 +45,12 @@ const (
 
 	SkipServerWatchEnvVar = "CONSUL_SKIP_SERVER_WATCH"
 
+	ServerWatchDisabledIntervalEnvVar = "CONSUL_SERVER_WATCH_DISABLED_INTERVAL"
+	ServerCIDRsEnvVar                 = "CONSUL_SERVER_CIDRS"
+	BackOffInitialIntervalEnvVar      = "CONSUL_BACKOFF_INITIAL_INTERVAL"
+	BackOffMaxIntervalEnvVar          = "CONSUL_BACKOFF_MAX_INTERVAL"
+	BackOffResetIntervalEnvVar        = "CONSUL_BACKOFF_RESET_INTERVAL"
+
 	APITimeoutEnvVar = "CONSUL_API_TIMEOUT"
 )
 
@@ -59,10 +67,23 @@ type ConsulFlags struct {
 
 	SkipServerWatch bool
 
+	ConsulServerConnMgrFlags
 	ConsulTLSFlags
 	ConsulACLFlags
 }
 
+// ConsulServerConnMgrFlags tune how the consul-server-connection-manager
+// discovers and switches between Consul servers. Zero values use the
+// defaults of the connection manager.
+type ConsulServerConnMgrFlags struct {
+	ServerWatchDisabledInterval time.Duration
+	ServerCIDRs                 string
+
+	BackOffInitialInterval time.Duration
+	BackOffMaxInterval     time.Duration
+	BackOffResetInterval   time.Duration
+}
+
 type ConsulTLSFlags struct {
 	UseTLS        bool
 	CACertFile    string
@@ -178,9 +199,34 @@ func (f *ConsulFlags) Flags() *flag.FlagSet {
 		"The time in seconds that the consul API client will wait for a response from the API before cancelling the request.")
 	fs.BoolVar(&f.SkipServerWatch, "skip-server-watch", skipServerWatch, "If true, skip watching server upstream."+
 		"This can also be specified via the CONSUL_SKIP_SERVER_WATCH environment variable.")
+	fs.DurationVar(&f.ServerWatchDisabledInterval, "server-watch-disabled-interval", durationFromEnv(ServerWatchDisabledIntervalEnvVar),
+		"How often to check that the current Consul server is healthy and switch to another one if it isn't, "+
+			"when the server watch is skipped or not supported by the servers. Defaults to 1m. "+
+			"This can also be specified via the CONSUL_SERVER_WATCH_DISABLED_INTERVAL environment variable.")
+	fs.StringVar(&f.ServerCIDRs, "server-cidrs", os.Getenv(ServerCIDRsEnvVar),
+		"Comma-separated CIDRs of the Consul servers to connect to. Servers with addresses outside of them are skipped, "+
+			"e.g. to only connect to servers in the local network. If unset, all servers are used. "+
+			"This can also be specified via the CONSUL_SERVER_CIDRS environment variable.")
+	fs.DurationVar(&f.BackOffInitialInterval, "backoff-initial-interval", durationFromEnv(BackOffInitialIntervalEnvVar),
+		"Initial interval between attempts to connect to a Consul server. Defaults to 500ms. "+
+			"This can also be specified via the CONSUL_BACKOFF_INITIAL_INTERVAL environment variable.")
+	fs.DurationVar(&f.BackOffMaxInterval, "backoff-max-interval", durationFromEnv(BackOffMaxIntervalEnvVar),
+		"Maximum interval between attempts to connect to a Consul server. Defaults to 1m. "+
+			"This can also be specified via the CONSUL_BACKOFF_MAX_INTERVAL environment variable.")
+	fs.DurationVar(&f.BackOffResetInterval, "backoff-reset-interval", durationFromEnv(BackOffResetIntervalEnvVar),
+		"How long the connection to a Consul server must be healthy for the interval between attempts to "+
+			"connect to be reset. Defaults to 3m. "+
+			"This can also be specified via the CONSUL_BACKOFF_RESET_INTERVAL environment variable.")
 	return fs
 }
 
+// durationFromEnv returns the duration in the environment variable, or 0 if
+// it's not set or can't be parsed.
+func durationFromEnv(name string) time.Duration {
+	d, _ := time.ParseDuration(os.Getenv(name))
+	return d
+}
+
 func (f *ConsulFlags) ConsulServerConnMgrConfig() (discovery.Config, error) {
 	cfg := discovery.Config{
 		Addresses: f.Addresses,
@@ -236,10 +282,45 @@ func (f *ConsulFlags) ConsulServerConnMgrConfig() (discovery.Config, error) {
 	if f.SkipServerWatch {
 		cfg.ServerWatchDisabled = true
 	}
+	cfg.ServerWatchDisabledInterval = f.ServerWatchDisabledInterval
+	cfg.BackOff = discovery.BackOffConfig{
+		InitialInterval: f.BackOffInitialInterval,
+		MaxInterval:     f.BackOffMaxInterval,
+		ResetInterval:   f.BackOffResetInterval,
+	}
+
+	if f.ServerCIDRs != "" {
+		var cidrs []*net.IPNet
+		for _, raw := range strings.Split(f.ServerCIDRs, ",") {
+			_, cidr, err := net.ParseCIDR(strings.TrimSpace(raw))
+			if err != nil {
+				return discovery.Config{}, fmt.Errorf("invalid Consul server CIDR %q: %w", raw, err)
+			}
+			cidrs = append(cidrs, cidr)
+		}
+		cfg.ServerEvalFn = func(s discovery.State) bool {
+			for _, cidr := range cidrs {
+				if cidr.Contains(s.Address.IP) {
+					return true
+				}
+			}
+			return false
+		}
+	}
 
 	return cfg, nil
 }
 
+// ServerName returns the name that the certificates of the Consul servers are
+// verified against: the TLS server name, or else the addresses unless they are
+// an exec command.
+func (f *ConsulFlags) ServerName() string {
+	if f.TLSServerName == "" && !strings.HasPrefix(f.Addresses, "exec=") {
+		return f.Addresses
+	}
+	return f.TLSServerName
+}
+
 func (f *ConsulFlags) ConsulClientConfig() *consul.Config {
 	cfg := &api.Config{
 		Namespace:  f.Namespace,
@@ -256,12 +337,7 @@ func (f *ConsulFlags) ConsulClientConfig() *consul.Config {
 			cfg.TLSConfig.CAPem = []byte(f.CACertPEM)
 		}
 
-		// Infer TLS server name from addresses.
-		if f.TLSServerName == "" && !strings.HasPrefix(f.Addresses, "exec=") {
-			cfg.TLSConfig.Address = f.Addresses
-		} else if f.TLSServerName != "" {
-			cfg.TLSConfig.Address = f.TLSServerName
-		}
+		cfg.TLSConfig.Address = f.ServerName()
 	}
 
 	if f.Token != "" {
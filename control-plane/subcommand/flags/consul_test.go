@@ -43,6 +43,12 @@ func TestConsulFlags_Flags(t *testing.T) {
 				LoginNamespaceEnvVar:       "other-test-ns",
 				LoginMetaEnvVar:            "key1=value1,key2=value2",
 				SkipServerWatchEnvVar:      "true",
+
+				ServerWatchDisabledIntervalEnvVar: "30s",
+				ServerCIDRsEnvVar:                 "10.0.0.0/8",
+				BackOffInitialIntervalEnvVar:      "1s",
+				BackOffMaxIntervalEnvVar:          "2m",
+				BackOffResetIntervalEnvVar:        "10m",
 			},
 			expFlags: &ConsulFlags{
 				Addresses:  "consul.address",
@@ -71,6 +77,13 @@ func TestConsulFlags_Flags(t *testing.T) {
 					},
 				},
 				SkipServerWatch: true,
+				ConsulServerConnMgrFlags: ConsulServerConnMgrFlags{
+					ServerWatchDisabledInterval: 30 * time.Second,
+					ServerCIDRs:                 "10.0.0.0/8",
+					BackOffInitialInterval:      time.Second,
+					BackOffMaxInterval:          2 * time.Minute,
+					BackOffResetInterval:        10 * time.Minute,
+				},
 			},
 		},
 		"defaults": {
@@ -89,6 +102,8 @@ func TestConsulFlags_Flags(t *testing.T) {
 				HTTPPortEnvVar:   "not-int-http-port",
 				APITimeoutEnvVar: "10sec",
 
+				BackOffMaxIntervalEnvVar: "not-a-duration",
+
 				UseTLSEnvVar: "not-a-bool",
 
 				LoginMetaEnvVar: "key1:value1;key2:value2",
@@ -228,6 +243,26 @@ func TestConsulFlags_ConsulServerConnMgrConfig(t *testing.T) {
 				ServerWatchDisabled: true,
 			},
 		},
+		"server watch disabled interval and backoff": {
+			flags: ConsulFlags{
+				Addresses: "consul.address",
+				ConsulServerConnMgrFlags: ConsulServerConnMgrFlags{
+					ServerWatchDisabledInterval: 30 * time.Second,
+					BackOffInitialInterval:      time.Second,
+					BackOffMaxInterval:          2 * time.Minute,
+					BackOffResetInterval:        10 * time.Minute,
+				},
+			},
+			expConfig: discovery.Config{
+				Addresses:                   "consul.address",
+				ServerWatchDisabledInterval: 30 * time.Second,
+				BackOff: discovery.BackOffConfig{
+					InitialInterval: time.Second,
+					MaxInterval:     2 * time.Minute,
+					ResetInterval:   10 * time.Minute,
+				},
+			},
+		},
 	}
 
 	for name, c := range cases {
@@ -258,6 +293,33 @@ func TestConsulFlags_ConsulServerConnMgrConfig(t *testing.T) {
 	}
 }
 
+func TestConsulFlags_ConsulServerConnMgrConfig_ServerCIDRs(t *testing.T) {
+	flags := ConsulFlags{
+		Addresses: "consul.address",
+		ConsulServerConnMgrFlags: ConsulServerConnMgrFlags{
+			ServerCIDRs: "10.0.0.0/16, fd00::/8",
+		},
+	}
+	cfg, err := flags.ConsulServerConnMgrConfig()
+	require.NoError(t, err)
+	require.NotNil(t, cfg.ServerEvalFn)
+
+	for addr, exp := range map[string]bool{
+		"10.0.1.2": true,
+		"10.1.1.2": false,
+		"fd00::1":  true,
+		"fe80::1":  false,
+	} {
+		a, err := discovery.MakeAddr(addr, 8502)
+		require.NoError(t, err)
+		require.Equal(t, exp, cfg.ServerEvalFn(discovery.State{Address: a}), addr)
+	}
+
+	flags.ServerCIDRs = "10.0.0.0"
+	_, err = flags.ConsulServerConnMgrConfig()
+	require.EqualError(t, err, "invalid Consul server CIDR \"10.0.0.0\": invalid CIDR address: 10.0.0.0")
+}
+
 func TestConsulFlags_ConsulServerConnMgrConfig_TLS(t *testing.T) {
 	caFile, err := os.CreateTemp("", "")
 	t.Cleanup(func() {
@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package flags
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+)
+
+const (
+	TLSVersion12 = "TLSv1_2"
+	TLSVersion13 = "TLSv1_3"
+)
+
+// tlsVersions maps the TLS version names used by Consul to their Go values.
+var tlsVersions = map[string]uint16{
+	TLSVersion12: tls.VersionTLS12,
+	TLSVersion13: tls.VersionTLS13,
+}
+
+// TLSListenerFlags restrict the TLS versions and cipher suites that the
+// listeners of a component accept, e.g. to only allow FIPS-approved ones.
+// The values use the names Consul uses, so that they can be passed on to the
+// listeners configured in Consul as well.
+type TLSListenerFlags struct {
+	// MinVersion is the minimum TLS version, "TLSv1_2" or "TLSv1_3". If
+	// empty, the defaults of the listeners are used.
+	MinVersion string
+	// CipherSuites are the IANA names of the TLS 1.2 cipher suites, e.g.
+	// "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256". If empty, the defaults of
+	// the listeners are used.
+	CipherSuites []string
+}
+
+func (f *TLSListenerFlags) Flags() *flag.FlagSet {
+	fs := flag.NewFlagSet("tls-listener", flag.ContinueOnError)
+	fs.StringVar(&f.MinVersion, "tls-min-version", "",
+		fmt.Sprintf("Minimum TLS version that the listeners accept: %q or %q. Defaults to the listeners' defaults.",
+			TLSVersion12, TLSVersion13))
+	fs.Var((*AppendSliceValue)(&f.CipherSuites), "tls-cipher-suite",
+		"IANA name of a TLS 1.2 cipher suite that the listeners accept, e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256. "+
+			"May be specified multiple times. Only secure cipher suites are supported. The cipher suites of TLS 1.3 "+
+			"can't be configured. Defaults to the listeners' defaults.")
+	return fs
+}
+
+// Validate returns an error if the TLS version or a cipher suite is unknown,
+// or if cipher suites are set even though TLS 1.2 isn't accepted.
+func (f *TLSListenerFlags) Validate() error {
+	if f.MinVersion != "" {
+		if _, ok := tlsVersions[f.MinVersion]; !ok {
+			return fmt.Errorf("-tls-min-version must be %q or %q", TLSVersion12, TLSVersion13)
+		}
+	}
+	if len(f.CipherSuites) > 0 && f.MinVersion == TLSVersion13 {
+		return fmt.Errorf("-tls-cipher-suite can't be set if -tls-min-version is %q", TLSVersion13)
+	}
+	_, err := cipherSuiteIDs(f.CipherSuites)
+	return err
+}
+
+// ConfigureTLS applies the TLS version and cipher suites to cfg. The flags
+// must be valid.
+func (f *TLSListenerFlags) ConfigureTLS(cfg *tls.Config) {
+	if f.MinVersion != "" {
+		cfg.MinVersion = tlsVersions[f.MinVersion]
+	}
+	if len(f.CipherSuites) > 0 {
+		cfg.CipherSuites, _ = cipherSuiteIDs(f.CipherSuites)
+	}
+}
+
+// cipherSuiteIDs returns the IDs of the named cipher suites. Only the
+// secure cipher suites that Go implements are allowed.
+func cipherSuiteIDs(names []string) ([]uint16, error) {
+	supported := make(map[string]*tls.CipherSuite)
+	for _, suite := range tls.CipherSuites() {
+		supported[suite.Name] = suite
+	}
+	var ids []uint16
+	for _, name := range names {
+		suite, ok := supported[name]
+		if !ok {
+			return nil, fmt.Errorf("-tls-cipher-suite %q is not a supported cipher suite", name)
+		}
+		if !supportsTLS12(suite) {
+			return nil, fmt.Errorf("-tls-cipher-suite %q is a TLS 1.3 cipher suite, which can't be configured", name)
+		}
+		ids = append(ids, suite.ID)
+	}
+	return ids, nil
+}
+
+func supportsTLS12(suite *tls.CipherSuite) bool {
+	for _, v := range suite.SupportedVersions {
+		if v == tls.VersionTLS12 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package flags
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSListenerFlags_Validate(t *testing.T) {
+	cases := map[string]struct {
+		args   []string
+		expErr string
+	}{
+		"defaults": {},
+		"TLS 1.2 with cipher suites": {
+			args: []string{"-tls-min-version=TLSv1_2",
+				"-tls-cipher-suite=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+				"-tls-cipher-suite=TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+		},
+		"TLS 1.3": {
+			args: []string{"-tls-min-version=TLSv1_3"},
+		},
+		"unknown version": {
+			args:   []string{"-tls-min-version=1.2"},
+			expErr: `-tls-min-version must be "TLSv1_2" or "TLSv1_3"`,
+		},
+		"TLS 1.0": {
+			args:   []string{"-tls-min-version=TLSv1_0"},
+			expErr: `-tls-min-version must be "TLSv1_2" or "TLSv1_3"`,
+		},
+		"cipher suites with TLS 1.3": {
+			args:   []string{"-tls-min-version=TLSv1_3", "-tls-cipher-suite=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+			expErr: `-tls-cipher-suite can't be set if -tls-min-version is "TLSv1_3"`,
+		},
+		"unknown cipher suite": {
+			args:   []string{"-tls-cipher-suite=ECDHE-ECDSA-AES128-GCM-SHA256"},
+			expErr: `-tls-cipher-suite "ECDHE-ECDSA-AES128-GCM-SHA256" is not a supported cipher suite`,
+		},
+		"insecure cipher suite": {
+			args:   []string{"-tls-cipher-suite=TLS_RSA_WITH_RC4_128_SHA"},
+			expErr: `-tls-cipher-suite "TLS_RSA_WITH_RC4_128_SHA" is not a supported cipher suite`,
+		},
+		"TLS 1.3 cipher suite": {
+			args:   []string{"-tls-cipher-suite=TLS_AES_128_GCM_SHA256"},
+			expErr: `-tls-cipher-suite "TLS_AES_128_GCM_SHA256" is a TLS 1.3 cipher suite, which can't be configured`,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			var f TLSListenerFlags
+			require.NoError(t, f.Flags().Parse(c.args))
+			err := f.Validate()
+			if c.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, c.expErr)
+			}
+		})
+	}
+}
+
+func TestTLSListenerFlags_ConfigureTLS(t *testing.T) {
+	f := TLSListenerFlags{
+		MinVersion:   TLSVersion12,
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+	}
+	cfg := &tls.Config{}
+	f.ConfigureTLS(cfg)
+	require.Equal(t, uint16(tls.VersionTLS12), cfg.MinVersion)
+	require.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}, cfg.CipherSuites)
+
+	// The defaults of the listener are kept if the flags aren't set.
+	cfg = &tls.Config{MinVersion: tls.VersionTLS13}
+	(&TLSListenerFlags{}).ConfigureTLS(cfg)
+	require.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	require.Nil(t, cfg.CipherSuites)
+}
@@ -25,6 +25,7 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+	k8syaml "sigs.k8s.io/yaml"
 )
 
 // this dupes the Kubernetes tolerations
@@ -67,18 +68,23 @@ type Command struct {
 	flagDeploymentMaxInstances     int
 	flagDeploymentMinInstances     int
 
-	flagNodeSelector       string // this is a yaml multiline string map
-	flagTolerations        string // this is a multiline yaml string matching the tolerations array
-	flagServiceAnnotations string // this is a multiline yaml string array of annotations to allow
+	flagNodeSelector              string // this is a yaml multiline string map
+	flagTolerations               string // this is a multiline yaml string matching the tolerations array
+	flagAffinity                  string // this is a multiline yaml string matching the pod affinity object
+	flagTopologySpreadConstraints string // this is a multiline yaml string matching the topologySpreadConstraints array
+	flagPriorityClassName         string
+	flagServiceAnnotations        string // this is a multiline yaml string array of annotations to allow
 
 	k8sClient client.Client
 
 	once sync.Once
 	help string
 
-	nodeSelector       map[string]string
-	tolerations        []corev1.Toleration
-	serviceAnnotations []string
+	nodeSelector              map[string]string
+	tolerations               []corev1.Toleration
+	affinity                  *corev1.Affinity
+	topologySpreadConstraints []corev1.TopologySpreadConstraint
+	serviceAnnotations        []string
 
 	ctx context.Context
 }
@@ -120,6 +126,15 @@ func (c *Command) init() {
 	c.flags.StringVar(&c.flagTolerations, "tolerations", "",
 		"The tolerations to use in a deployed gateway.",
 	)
+	c.flags.StringVar(&c.flagAffinity, "affinity", "",
+		"The affinity to use in scheduling a gateway.",
+	)
+	c.flags.StringVar(&c.flagTopologySpreadConstraints, "topology-spread-constraints", "",
+		"The topology spread constraints to use in scheduling a gateway.",
+	)
+	c.flags.StringVar(&c.flagPriorityClassName, "priority-class-name", "",
+		"The priority class name to use in a deployed gateway.",
+	)
 	c.flags.StringVar(&c.flagServiceAnnotations, "service-annotations", "",
 		"The annotations to copy over from a gateway to its service.",
 	)
@@ -191,7 +206,10 @@ func (c *Command) Run(args []string) int {
 			CopyAnnotations: v1alpha1.CopyAnnotationsSpec{
 				Service: c.serviceAnnotations,
 			},
-			Tolerations: c.tolerations,
+			Tolerations:               c.tolerations,
+			Affinity:                  c.affinity,
+			TopologySpreadConstraints: c.topologySpreadConstraints,
+			PriorityClassName:         c.flagPriorityClassName,
 			DeploymentSpec: v1alpha1.DeploymentSpec{
 				DefaultInstances: nonZeroOrNil(c.flagDeploymentDefaultInstances),
 				MaxInstances:     nonZeroOrNil(c.flagDeploymentMaxInstances),
@@ -266,6 +284,17 @@ func (c *Command) validateFlags() error {
 			return fmt.Errorf("error decoding node selector: %w", err)
 		}
 	}
+	if c.flagAffinity != "" {
+		c.affinity = &corev1.Affinity{}
+		if err := k8syaml.Unmarshal([]byte(c.flagAffinity), c.affinity); err != nil {
+			return fmt.Errorf("error decoding affinity: %w", err)
+		}
+	}
+	if c.flagTopologySpreadConstraints != "" {
+		if err := k8syaml.Unmarshal([]byte(c.flagTopologySpreadConstraints), &c.topologySpreadConstraints); err != nil {
+			return fmt.Errorf("error decoding topology spread constraints: %w", err)
+		}
+	}
 	if c.flagServiceAnnotations != "" {
 		if err := yaml.Unmarshal([]byte(c.flagServiceAnnotations), &c.serviceAnnotations); err != nil {
 			return fmt.Errorf("error decoding service annotations: %w", err)
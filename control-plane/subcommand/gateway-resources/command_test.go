@@ -118,6 +118,34 @@ func TestRun_flagValidation(t *testing.T) {
 			},
 			expectedErr: "error decoding node selector: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `foo` into map[string]string",
 		},
+		"required valid affinity": {
+			cmd: &Command{
+				flagGatewayClassConfigName: "test",
+				flagGatewayClassName:       "test",
+				flagHeritage:               "test",
+				flagChart:                  "test",
+				flagApp:                    "test",
+				flagRelease:                "test",
+				flagComponent:              "test",
+				flagControllerName:         "test",
+				flagAffinity:               "foo",
+			},
+			expectedErr: "error decoding affinity: error unmarshaling JSON: while decoding JSON: json: cannot unmarshal string into Go value of type v1.Affinity",
+		},
+		"required valid topology spread constraints": {
+			cmd: &Command{
+				flagGatewayClassConfigName:    "test",
+				flagGatewayClassName:          "test",
+				flagHeritage:                  "test",
+				flagChart:                     "test",
+				flagApp:                       "test",
+				flagRelease:                   "test",
+				flagComponent:                 "test",
+				flagControllerName:            "test",
+				flagTopologySpreadConstraints: "foo",
+			},
+			expectedErr: "error decoding topology spread constraints: error unmarshaling JSON: while decoding JSON: json: cannot unmarshal string into Go value of type []v1.TopologySpreadConstraint",
+		},
 		"required valid service annotations": {
 			cmd: &Command{
 				flagGatewayClassConfigName: "test",
@@ -160,6 +188,20 @@ bar: 2`,
 				flagTolerations: `
 - value: foo
 - value: bar`,
+				flagAffinity: `
+nodeAffinity:
+  requiredDuringSchedulingIgnoredDuringExecution:
+    nodeSelectorTerms:
+    - matchExpressions:
+      - key: pool
+        operator: In
+        values:
+        - edge`,
+				flagTopologySpreadConstraints: `
+- maxSkew: 1
+  topologyKey: topology.kubernetes.io/zone
+  whenUnsatisfiable: ScheduleAnyway`,
+				flagPriorityClassName: "gateway-critical",
 				flagServiceAnnotations: `
 - foo
 - bar`,
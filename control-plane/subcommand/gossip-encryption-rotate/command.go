@@ -0,0 +1,338 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package gossipencryptionrotate
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// rotationIDAnnotation records the ID of the last completed rotation on the gossip key secret
+	// so that rotating again with the same ID is a no-op.
+	rotationIDAnnotation = "consul.hashicorp.com/gossip-key-rotation-id"
+
+	// pendingKeySuffix is appended to -secret-key to get the key within the secret that holds the
+	// key of a rotation in progress, so that running a failed rotation again reuses its key.
+	pendingKeySuffix = "-pending"
+)
+
+type Command struct {
+	UI cli.Ui
+
+	flags  *flag.FlagSet
+	k8s    *flags.K8SFlags
+	consul *flags.ConsulFlags
+
+	flagNamespace  string
+	flagSecretName string
+	flagSecretKey  string
+	flagRotationID string
+	flagTimeout    time.Duration
+
+	flagLogLevel string
+	flagLogJSON  bool
+
+	k8sClient    kubernetes.Interface
+	consulClient *api.Client
+
+	// ctx is cancelled when the command timeout is reached.
+	ctx           context.Context
+	retryDuration time.Duration
+
+	log  hclog.Logger
+	once sync.Once
+	help string
+}
+
+func (c *Command) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+
+	c.flags.StringVar(&c.flagLogLevel, "log-level", "info",
+		"Log verbosity level. Supported values (in order of detail) are \"trace\", "+
+			"\"debug\", \"info\", \"warn\", and \"error\".")
+	c.flags.BoolVar(&c.flagLogJSON, "log-json", false, "Enable or disable JSON output format for logging.")
+	c.flags.StringVar(&c.flagNamespace, "k8s-namespace", "", "Name of Kubernetes namespace of the gossip encryption key secret.")
+	c.flags.StringVar(&c.flagSecretName, "secret-name", "", "Name of the Kubernetes secret holding the gossip encryption key.")
+	c.flags.StringVar(&c.flagSecretKey, "secret-key", "key", "Key within the Kubernetes secret holding the gossip encryption key.")
+	c.flags.StringVar(&c.flagRotationID, "rotation-id", "",
+		"ID of this rotation. The key is only rotated if the last rotation recorded on the secret has a different ID.")
+	c.flags.DurationVar(&c.flagTimeout, "timeout", 10*time.Minute,
+		"How long we'll try to rotate the gossip encryption key for before timing out, e.g. 1ms, 2s, 3m")
+
+	c.k8s = &flags.K8SFlags{}
+	c.consul = &flags.ConsulFlags{}
+	flags.Merge(c.flags, c.k8s.Flags())
+	flags.Merge(c.flags, c.consul.Flags())
+	c.help = flags.Usage(help, c.flags)
+
+	// Default retry to 1s. This is exposed for setting in tests.
+	if c.retryDuration == 0 {
+		c.retryDuration = 1 * time.Second
+	}
+}
+
+// Run rotates the gossip encryption key of the local datacenter. It stores a new key in the
+// Kubernetes secret as the pending key, installs it on all agents, makes it the primary key,
+// stores it as the key in the secret so that restarted agents use it too, and finally removes
+// all other keys. Each step is idempotent and a failed rotation reuses the pending key, so it
+// can be run again and converges on a single key.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+	if err := c.flags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+	if err := c.validateFlags(); err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to validate flags: %v", err))
+		return 1
+	}
+
+	var err error
+	c.log, err = common.Logger(c.flagLogLevel, c.flagLogJSON)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	var cancel context.CancelFunc
+	c.ctx, cancel = context.WithTimeout(context.Background(), c.flagTimeout)
+	// The context will only ever be intentionally ended by the timeout.
+	defer cancel()
+
+	if c.k8sClient == nil {
+		config, err := subcommand.K8SConfig(c.k8s.KubeConfig())
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to create Kubernetes config: %v", err))
+			return 1
+		}
+		c.k8sClient, err = kubernetes.NewForConfig(config)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to create Kubernetes client: %v", err))
+			return 1
+		}
+	}
+
+	secret, err := c.k8sClient.CoreV1().Secrets(c.flagNamespace).Get(c.ctx, c.flagSecretName, metav1.GetOptions{})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to get Kubernetes secret: %v", err))
+		return 1
+	}
+	if secret.Annotations[rotationIDAnnotation] == c.flagRotationID {
+		c.UI.Info(fmt.Sprintf("The gossip encryption key has already been rotated for rotation ID %q.", c.flagRotationID))
+		return 0
+	}
+
+	if c.consulClient == nil {
+		serverConnMgrCfg, err := c.consul.ConsulServerConnMgrConfig()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("unable to create config for consul-server-connection-manager: %s", err))
+			return 1
+		}
+		serverConnMgrCfg.ServerWatchDisabled = true
+		watcher, err := discovery.NewWatcher(c.ctx, serverConnMgrCfg, c.log.Named("consul-server-connection-manager"))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("unable to create Consul server watcher: %s", err))
+			return 1
+		}
+		go watcher.Run()
+		defer watcher.Stop()
+
+		c.consulClient, err = consul.NewClientFromConnMgr(c.consul.ConsulClientConfig(), watcher)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("unable to create Consul client: %s", err))
+			return 1
+		}
+	}
+
+	var newKey string
+	err = c.retry("store the new key", func() error {
+		var err error
+		newKey, err = c.pendingKey()
+		return err
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to store the new gossip encryption key: %v", err))
+		return 1
+	}
+
+	// The Kubernetes secret is only updated once all agents use the new key so that agents
+	// restarted during the rotation still join with a key that is installed, and the old keys
+	// are only removed once the secret holds the new key so that agents restarted after the
+	// removal don't join with a removed key.
+	writeOpts := &api.WriteOptions{Datacenter: c.consul.Datacenter}
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"install the new key", func() error { return c.consulClient.Operator().KeyringInstall(newKey, writeOpts) }},
+		{"make the new key primary", func() error { return c.consulClient.Operator().KeyringUse(newKey, writeOpts) }},
+		{"update the Kubernetes secret", func() error {
+			return c.updateSecret(func(secret *corev1.Secret) {
+				secret.Data[c.flagSecretKey] = []byte(newKey)
+			})
+		}},
+		{"remove old keys", func() error { return c.removeKeysExcept(newKey) }},
+		{"record the rotation", func() error {
+			return c.updateSecret(func(secret *corev1.Secret) {
+				delete(secret.Data, c.flagSecretKey+pendingKeySuffix)
+				secret.Annotations[rotationIDAnnotation] = c.flagRotationID
+			})
+		}},
+	}
+	for _, step := range steps {
+		if err := c.retry(step.name, step.fn); err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to %s: %v", step.name, err))
+			return 1
+		}
+		c.log.Info("Gossip encryption key rotation step completed", "step", step.name)
+	}
+
+	c.UI.Info(fmt.Sprintf("Successfully rotated the gossip encryption key in Kubernetes secret `%s` in namespace `%s`.",
+		c.flagSecretName, c.flagNamespace))
+	return 0
+}
+
+// pendingKey returns the pending key stored in the secret by a previous, failed run of this
+// rotation, or generates a new key and stores it as the pending key.
+func (c *Command) pendingKey() (string, error) {
+	secret, err := c.k8sClient.CoreV1().Secrets(c.flagNamespace).Get(c.ctx, c.flagSecretName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if key := string(secret.Data[c.flagSecretKey+pendingKeySuffix]); key != "" {
+		c.log.Info("Resuming the rotation of the pending gossip encryption key")
+		return key, nil
+	}
+	key, err := common.GenerateGossipKey()
+	if err != nil {
+		return "", err
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[c.flagSecretKey+pendingKeySuffix] = []byte(key)
+	if _, err := c.k8sClient.CoreV1().Secrets(c.flagNamespace).Update(c.ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// removeKeysExcept removes every key other than key that is installed on the agents of the
+// local datacenter. This also removes keys left behind by previously failed rotations.
+func (c *Command) removeKeysExcept(key string) error {
+	keyrings, err := c.consulClient.Operator().KeyringList(&api.QueryOptions{Datacenter: c.consul.Datacenter})
+	if err != nil {
+		return err
+	}
+	oldKeys := make(map[string]struct{})
+	for _, keyring := range keyrings {
+		if keyring.Datacenter != c.consul.Datacenter {
+			continue
+		}
+		for k := range keyring.Keys {
+			if k != key {
+				oldKeys[k] = struct{}{}
+			}
+		}
+	}
+	for k := range oldKeys {
+		if err := c.consulClient.Operator().KeyringRemove(k, &api.WriteOptions{Datacenter: c.consul.Datacenter}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateSecret applies update to the gossip key secret.
+func (c *Command) updateSecret(update func(*corev1.Secret)) error {
+	secret, err := c.k8sClient.CoreV1().Secrets(c.flagNamespace).Get(c.ctx, c.flagSecretName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	update(secret)
+	_, err = c.k8sClient.CoreV1().Secrets(c.flagNamespace).Update(c.ctx, secret, metav1.UpdateOptions{})
+	return err
+}
+
+// retry runs fn until it succeeds or the command times out.
+func (c *Command) retry(name string, fn func() error) error {
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		c.log.Error("Error during gossip encryption key rotation", "step", name, "error", err.Error())
+		c.log.Info("Retrying in " + c.retryDuration.String())
+		select {
+		case <-time.After(c.retryDuration):
+		case <-c.ctx.Done():
+			return err
+		}
+	}
+}
+
+func (c *Command) Synopsis() string { return synopsis }
+
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return c.help
+}
+
+func (c *Command) validateFlags() error {
+	if c.flagNamespace == "" {
+		return errors.New("-k8s-namespace must be set")
+	}
+	if c.flagSecretName == "" {
+		return errors.New("-secret-name must be set")
+	}
+	if c.flagSecretKey == "" {
+		return errors.New("-secret-key must be set")
+	}
+	if c.flagRotationID == "" {
+		return errors.New("-rotation-id must be set")
+	}
+	if c.consul.Datacenter == "" {
+		return errors.New("-datacenter must be set")
+	}
+	if c.consulClient == nil && len(c.consul.Addresses) == 0 {
+		return errors.New("-addresses must be set")
+	}
+	return nil
+}
+
+const synopsis = "Rotate the gossip encryption key."
+const help = `
+Usage: consul-k8s-control-plane gossip-encryption-rotate [options]
+
+  Generates a new gossip encryption key, installs it on all Consul agents of
+  the datacenter, makes it the primary key, stores it in the Kubernetes secret
+  and removes all other keys. The rotation only runs if -rotation-id differs
+  from the ID of the last rotation recorded on the secret, so it is safe to run
+  multiple times. The key of a rotation in progress is stored in the secret
+  under -secret-key with the suffix "-pending", so a failed rotation reuses it.
+
+`
@@ -0,0 +1,256 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package gossipencryptionrotate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	namespace  = "default"
+	secretName = "consul-gossip-encryption-key"
+	oldKey     = "H1dfkSZOVnP/JUnaBfTzXg=="
+	otherDCKey = "Wb4MXYzPDuRJfvTnVEWoUw=="
+)
+
+func TestRun_FlagValidation(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		flags  []string
+		expErr string
+	}{
+		{
+			flags:  []string{},
+			expErr: "-k8s-namespace must be set",
+		},
+		{
+			flags:  []string{"-k8s-namespace", namespace},
+			expErr: "-secret-name must be set",
+		},
+		{
+			flags:  []string{"-k8s-namespace", namespace, "-secret-name", secretName, "-secret-key", ""},
+			expErr: "-secret-key must be set",
+		},
+		{
+			flags:  []string{"-k8s-namespace", namespace, "-secret-name", secretName},
+			expErr: "-rotation-id must be set",
+		},
+		{
+			flags:  []string{"-k8s-namespace", namespace, "-secret-name", secretName, "-rotation-id", "1"},
+			expErr: "-datacenter must be set",
+		},
+		{
+			flags:  []string{"-k8s-namespace", namespace, "-secret-name", secretName, "-rotation-id", "1", "-datacenter", "dc1"},
+			expErr: "-addresses must be set",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := Command{
+				UI: ui,
+			}
+			code := cmd.Run(c.flags)
+			require.Equal(t, 1, code)
+			require.Contains(t, ui.ErrorWriter.String(), c.expErr)
+		})
+	}
+}
+
+func TestRun_RotatesKey(t *testing.T) {
+	t.Parallel()
+	keyring := newFakeKeyring(oldKey, "leftover-key-from-a-failed-rotation")
+	k8s := fake.NewSimpleClientset(gossipSecret(nil))
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, k8sClient: k8s, consulClient: keyring.client(t)}
+	code := cmd.Run([]string{"-k8s-namespace", namespace, "-secret-name", secretName, "-rotation-id", "2023-10-01", "-datacenter", "dc1"})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+
+	secret, err := k8s.CoreV1().Secrets(namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	newKey := string(secret.Data["key"])
+	require.NotEqual(t, oldKey, newKey)
+	require.Equal(t, "2023-10-01", secret.Annotations[rotationIDAnnotation])
+	require.NotContains(t, secret.Data, "key-pending")
+
+	// Only the new key is left on the agents and it is the primary key. The keys of other
+	// datacenters are not removed.
+	require.Equal(t, map[string]bool{newKey: true, otherDCKey: true}, keyring.installed())
+	require.Equal(t, newKey, keyring.primaryKey())
+}
+
+func TestRun_SkipsCompletedRotation(t *testing.T) {
+	t.Parallel()
+	keyring := newFakeKeyring(oldKey)
+	k8s := fake.NewSimpleClientset(gossipSecret(map[string]string{rotationIDAnnotation: "1"}))
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, k8sClient: k8s, consulClient: keyring.client(t)}
+	code := cmd.Run([]string{"-k8s-namespace", namespace, "-secret-name", secretName, "-rotation-id", "1", "-datacenter", "dc1"})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+
+	secret, err := k8s.CoreV1().Secrets(namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, oldKey, string(secret.Data["key"]))
+	require.Equal(t, oldKey, keyring.primaryKey())
+}
+
+func TestRun_DoesNotUpdateSecretIfKeyringFails(t *testing.T) {
+	t.Parallel()
+	keyring := newFakeKeyring(oldKey)
+	keyring.failUse = true
+	k8s := fake.NewSimpleClientset(gossipSecret(nil))
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, k8sClient: k8s, consulClient: keyring.client(t), retryDuration: 10 * time.Millisecond}
+	args := []string{"-k8s-namespace", namespace, "-secret-name", secretName, "-rotation-id", "1", "-datacenter", "dc1", "-timeout", "100ms"}
+	code := cmd.Run(args)
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "Failed to make the new key primary")
+
+	secret, err := k8s.CoreV1().Secrets(namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, oldKey, string(secret.Data["key"]))
+	require.Empty(t, secret.Annotations[rotationIDAnnotation])
+	require.Equal(t, oldKey, keyring.primaryKey())
+	pendingKey := string(secret.Data["key-pending"])
+	require.NotEmpty(t, pendingKey)
+
+	// Running the rotation again reuses the pending key.
+	keyring.mu.Lock()
+	keyring.failUse = false
+	keyring.mu.Unlock()
+	ui = cli.NewMockUi()
+	cmd = Command{UI: ui, k8sClient: k8s, consulClient: keyring.client(t), retryDuration: 10 * time.Millisecond}
+	code = cmd.Run(args)
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+
+	secret, err = k8s.CoreV1().Secrets(namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, pendingKey, string(secret.Data["key"]))
+	require.NotContains(t, secret.Data, "key-pending")
+	require.Equal(t, "1", secret.Annotations[rotationIDAnnotation])
+	require.Equal(t, pendingKey, keyring.primaryKey())
+}
+
+func TestRun_UpdatesSecretBeforeRemovingOldKeys(t *testing.T) {
+	t.Parallel()
+	keyring := newFakeKeyring(oldKey)
+	keyring.failRemove = true
+	k8s := fake.NewSimpleClientset(gossipSecret(nil))
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, k8sClient: k8s, consulClient: keyring.client(t), retryDuration: 10 * time.Millisecond}
+	code := cmd.Run([]string{"-k8s-namespace", namespace, "-secret-name", secretName, "-rotation-id", "1", "-datacenter", "dc1", "-timeout", "100ms"})
+	require.Equal(t, 1, code)
+	require.Contains(t, ui.ErrorWriter.String(), "Failed to remove old keys")
+
+	// Restarted agents join with the new key, which is installed, while the old key is still installed.
+	secret, err := k8s.CoreV1().Secrets(namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, keyring.primaryKey(), string(secret.Data["key"]))
+	require.Equal(t, keyring.primaryKey(), string(secret.Data["key-pending"]))
+	require.True(t, keyring.installed()[oldKey])
+	require.Empty(t, secret.Annotations[rotationIDAnnotation])
+}
+
+func gossipSecret(annotations map[string]string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secretName,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Data: map[string][]byte{"key": []byte(oldKey)},
+	}
+}
+
+// fakeKeyring implements the keyring endpoints of the Consul HTTP API.
+// The keys of dc1 are managed, and dc2 has otherDCKey installed.
+type fakeKeyring struct {
+	mu         sync.Mutex
+	keys       map[string]bool
+	primary    string
+	failUse    bool
+	failRemove bool
+}
+
+func newFakeKeyring(primary string, otherKeys ...string) *fakeKeyring {
+	k := &fakeKeyring{keys: map[string]bool{primary: true, otherDCKey: true}, primary: primary}
+	for _, key := range otherKeys {
+		k.keys[key] = true
+	}
+	return k
+}
+
+func (k *fakeKeyring) client(t *testing.T) *api.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		require.Equal(t, "dc1", r.URL.Query().Get("dc"))
+		var req struct{ Key string }
+		if r.Method != http.MethodGet {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		}
+		switch r.Method {
+		case http.MethodGet:
+			keys := make(map[string]int)
+			for key := range k.keys {
+				if key != otherDCKey {
+					keys[key] = 3
+				}
+			}
+			require.NoError(t, json.NewEncoder(w).Encode([]*api.KeyringResponse{
+				{Datacenter: "dc1", Keys: keys, PrimaryKeys: map[string]int{k.primary: 3}, NumNodes: 3},
+				{Datacenter: "dc2", Keys: map[string]int{otherDCKey: 3}, PrimaryKeys: map[string]int{otherDCKey: 3}, NumNodes: 3},
+			}))
+		case http.MethodPost:
+			k.keys[req.Key] = true
+		case http.MethodPut:
+			if k.failUse || !k.keys[req.Key] {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			k.primary = req.Key
+		case http.MethodDelete:
+			if k.failRemove || req.Key == k.primary {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			delete(k.keys, req.Key)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	require.NoError(t, err)
+	return client
+}
+
+func (k *fakeKeyring) installed() map[string]bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.keys
+}
+
+func (k *fakeKeyring) primaryKey() string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.primary
+}
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package gracefulshutdown
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
+	"github.com/mitchellh/cli"
+)
+
+// The graceful-shutdown command starts the graceful shutdown of consul-dataplane from the preStop hook of
+// its container. consul-dataplane only serves its lifecycle endpoint on 127.0.0.1 and its image has no
+// shell, so the mesh webhook copies this binary into the pod and runs it in the consul-dataplane container.
+type Command struct {
+	UI cli.Ui
+
+	flagPort    int
+	flagPath    string
+	flagTimeout time.Duration
+
+	flagSet *flag.FlagSet
+
+	once sync.Once
+	help string
+}
+
+func (c *Command) init() {
+	c.flagSet = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flagSet.IntVar(&c.flagPort, "port", 20600,
+		"Port of the consul-dataplane lifecycle endpoint.")
+	c.flagSet.StringVar(&c.flagPath, "path", "/graceful_shutdown",
+		"Path of the consul-dataplane graceful shutdown endpoint.")
+	c.flagSet.DurationVar(&c.flagTimeout, "timeout", 5*time.Second,
+		"Timeout for the request to the graceful shutdown endpoint.")
+	c.help = flags.Usage(help, c.flagSet)
+}
+
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+
+	if err := c.flagSet.Parse(args); err != nil {
+		return 1
+	}
+	if c.flagPort <= 0 || c.flagPort > 65535 {
+		c.UI.Error("-port must be between 1 and 65535")
+		return 1
+	}
+	if c.flagTimeout <= 0 {
+		c.UI.Error("-timeout must be greater than 0")
+		return 1
+	}
+
+	if err := c.shutdown(); err != nil {
+		c.UI.Error(fmt.Sprintf("Unable to start the graceful shutdown of consul-dataplane: %s", err))
+		return 1
+	}
+	return 0
+}
+
+// shutdown calls the graceful shutdown endpoint. consul-dataplane responds once the shutdown has
+// started and keeps running for its shutdown grace period.
+func (c *Command) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.flagTimeout)
+	defer cancel()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", c.flagPort, c.flagPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+	return nil
+}
+
+func (c *Command) Synopsis() string { return synopsis }
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return c.help
+}
+
+const synopsis = "Start the graceful shutdown of consul-dataplane."
+const help = `
+Usage: consul-k8s-control-plane graceful-shutdown [options]
+
+  Calls the graceful shutdown endpoint of the consul-dataplane container
+  it runs in. Not intended for stand-alone use.
+`
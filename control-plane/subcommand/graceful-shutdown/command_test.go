@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package gracefulshutdown
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_FlagValidation(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		flags  []string
+		expErr string
+	}{
+		{
+			flags:  []string{"-port=0"},
+			expErr: "-port must be between 1 and 65535",
+		},
+		{
+			flags:  []string{"-timeout=0s"},
+			expErr: "-timeout must be greater than 0",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expErr, func(tt *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := Command{
+				UI: ui,
+			}
+			exitCode := cmd.Run(c.flags)
+			require.Equal(tt, 1, exitCode, ui.ErrorWriter.String())
+			require.Contains(tt, ui.ErrorWriter.String(), c.expErr)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		status   int
+		exitCode int
+	}{
+		"shutdown started": {
+			status:   http.StatusOK,
+			exitCode: 0,
+		},
+		"shutdown failed": {
+			status:   http.StatusInternalServerError,
+			exitCode: 1,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			var called bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/shutdown", r.URL.Path)
+				called = true
+				w.WriteHeader(c.status)
+			}))
+			defer server.Close()
+			_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+			require.NoError(t, err)
+			portNum, err := strconv.Atoi(port)
+			require.NoError(t, err)
+
+			ui := cli.NewMockUi()
+			cmd := Command{UI: ui}
+			exitCode := cmd.Run([]string{"-port=" + strconv.Itoa(portNum), "-path=/shutdown"})
+			require.Equal(t, c.exitCode, exitCode, ui.ErrorWriter.String())
+			require.True(t, called)
+		})
+	}
+}
+
+func TestRun_Unreachable(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui}
+	require.Equal(t, 1, cmd.Run([]string{"-port=" + strconv.Itoa(port)}))
+	require.Contains(t, ui.ErrorWriter.String(), "Unable to start the graceful shutdown of consul-dataplane")
+}
@@ -8,30 +8,52 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"math"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	gatewaycommon "github.com/hashicorp/consul-k8s/control-plane/api-gateway/common"
 	gatewaycontrollers "github.com/hashicorp/consul-k8s/control-plane/api-gateway/controllers"
 	apicommon "github.com/hashicorp/consul-k8s/control-plane/api/common"
 	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/audit"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/accesslogs"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/appprotocol"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/connectca"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/endpoints"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/networkpolicy"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/peering"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/registration"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/serverupgrade"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/snapshotschedule"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/tokencleanup"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/controllers/tokenrotation"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/diagnostics"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/dogstatsd"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/lifecycle"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/metrics"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/tracing"
 	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/webhook"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/controllers"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/loglevel"
 	mutatingwebhookconfiguration "github.com/hashicorp/consul-k8s/control-plane/helper/mutating-webhook-configuration"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/secretreload"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/spire"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
 	"github.com/hashicorp/consul-server-connection-manager/discovery"
 	"github.com/mitchellh/cli"
 	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -39,8 +61,12 @@ import (
 	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	ctrlRuntimeWebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	gwv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
@@ -48,6 +74,14 @@ import (
 
 const (
 	WebhookCAFilename = "ca.crt"
+
+	// meshConfigSyncInterval is how often the Mesh config entry is checked
+	// when peering through mesh gateways is enabled.
+	meshConfigSyncInterval = time.Minute
+
+	// spireCASyncInterval is how often the Connect CA is checked when it's
+	// issued by SPIRE.
+	spireCASyncInterval = time.Minute
 )
 
 type Command struct {
@@ -62,14 +96,21 @@ type Command struct {
 	flagACLAuthMethod         string // Auth Method to use for ACLs, if enabled
 	flagEnvoyExtraArgs        string // Extra envoy args when starting envoy
 	flagEnableWebhookCAUpdate bool
+	flagSecretReloadInterval  time.Duration // How often to check the secret files for changes
 	flagLogLevel              string
 	flagLogJSON               bool
+	flagLogJSONHCLogKeys      bool
+	flagLogLevelConfigMap     string // Name of the ConfigMap to change the log level from at runtime
 
 	flagAllowK8sNamespacesList []string // K8s namespaces to explicitly inject
 	flagDenyK8sNamespacesList  []string // K8s namespaces to deny injection (has precedence)
 
 	flagEnablePartitions bool // Use Admin Partitions on all components
 
+	// flagConfigEntryPartitions are the partitions that config entries can be
+	// written to with the partition annotation.
+	flagConfigEntryPartitions []string
+
 	// Flags to support Consul namespaces
 	flagEnableNamespaces           bool   // Use namespacing on all components
 	flagConsulDestinationNamespace string // Consul namespace to register everything if not mirroring
@@ -92,6 +133,7 @@ type Command struct {
 	flagDefaultEnableSidecarProxyLifecycle                       bool
 	flagDefaultEnableSidecarProxyLifecycleShutdownDrainListeners bool
 	flagDefaultSidecarProxyLifecycleShutdownGracePeriodSeconds   int
+	flagDefaultEnableSidecarProxyLifecyclePreStopDrain           bool
 	flagDefaultSidecarProxyLifecycleGracefulPort                 string
 	flagDefaultSidecarProxyLifecycleGracefulShutdownPath         string
 
@@ -103,6 +145,25 @@ type Command struct {
 	flagDefaultPrometheusScrapePort string
 	flagDefaultPrometheusScrapePath string
 
+	// Envoy access log settings.
+	flagDefaultEnableEnvoyAccessLogs     bool
+	flagDefaultEnvoyAccessLogsType       string
+	flagDefaultEnvoyAccessLogsPath       string
+	flagDefaultEnvoyAccessLogsJSONFormat string
+	flagDefaultEnvoyAccessLogsTextFormat string
+
+	// Envoy tracing settings.
+	flagDefaultEnableEnvoyTracing           bool
+	flagDefaultEnvoyTracingCollectorAddress string
+	flagDefaultEnvoyTracingSamplingRate     string
+
+	// Envoy DogStatsD settings.
+	flagDefaultEnableEnvoyDogStatsD bool
+	flagEnvoyDogStatsDSocketPath    string
+	flagEnvoyDogStatsDHostPort      int
+	flagEnvoyDogStatsDPodTags       bool
+	flagDefaultEnvoyDogStatsDTags   []string
+
 	// Init container resource settings.
 	flagInitContainerCPULimit      string
 	flagInitContainerCPURequest    string
@@ -112,32 +173,112 @@ type Command struct {
 	// Transparent proxy flags.
 	flagDefaultEnableTransparentProxy          bool
 	flagTransparentProxyDefaultOverwriteProbes bool
+	flagTransparentProxyDefaultEnableIPv6      bool
 
 	// CNI flag.
-	flagEnableCNI bool
+	flagEnableCNI             bool
+	flagCNIRequireRedirection bool
 
 	// Additional metadata to get applied to nodes.
 	flagNodeMeta map[string]string
 
 	// Peering flags.
-	flagEnablePeering bool
+	flagEnablePeering                bool
+	flagPeerThroughMeshGateways      bool
+	flagMeshGatewayConsulServiceName string
 
 	// WAN Federation flags.
 	flagEnableFederation bool
 
+	// SPIRE Connect CA flags.
+	flagEnableSpireConnectCA bool
+	flagSpireAgentSocketPath string
+	flagSpireServerAddress   string
+	flagSpireCATTL           time.Duration
+
 	flagEnableAutoEncrypt bool
 
 	// Consul telemetry collector
 	flagEnableTelemetryCollector bool
 
+	flagEnableEndpointSlices bool
+
+	flagEndpointsCatalogCacheTTL time.Duration
+
+	flagEndpointSliceResyncInterval time.Duration
+
+	flagEnableDualStackAddresses bool
+
+	// Pod labels registered as service tags and metadata.
+	flagLabelsToTags []string
+	flagLabelsToMeta map[string]string
+
+	flagEnableConfigEntryConsulValidation bool
+	flagConfigEntryResyncInterval         time.Duration
+
+	flagACLTokenRotationInterval time.Duration
+	flagACLTokenCleanupInterval  time.Duration
+
+	// Flags for the snapshot agent config rendered from SnapshotSchedules.
+	flagSnapshotAgentConfigSecretName string
+	flagSnapshotAgentPodSelector      string
+
+	// Flags for the rollout of the Consul servers.
+	flagServerStatefulSetName string
+
+	flagEnableNetworkPolicyIntentions bool
+
+	flagEnableAppProtocolServiceDefaults bool
+
+	flagDryRun bool
+
+	// Flags for the audit log of the writes to Consul.
+	flagEnableAuditLog     bool
+	flagAuditLogWebhookURL string
+
+	// Flags for the projected service account tokens used to log in.
+	flagEnableProjectedServiceAccountToken bool
+	flagServiceAccountTokenAudience        string
+	flagServiceAccountTokenExpiration      time.Duration
+
+	// Images used for pods on Windows nodes.
+	flagConsulDataplaneImageWindows string
+	flagConsulK8sImageWindows       string
+
+	// Sharding flags.
+	flagShardCount int
+	flagShardIndex int
+
+	// Controller tuning flags.
+	flagEndpointsMaxConcurrentReconciles int
+	flagPeeringMaxConcurrentReconciles   int
+	flagReconcileBaseDelay               time.Duration
+	flagReconcileMaxDelay                time.Duration
+	flagReconcileQPS                     float64
+	flagReconcileBurst                   int
+	flagKubeAPIQPS                       float64
+	flagKubeAPIBurst                     int
+	flagConsulAPIQPS                     float64
+	flagConsulAPIBurst                   int
+	flagConsulAPIMaxRetries              int
+	flagConsulAPIRetryBackoff            time.Duration
+	flagConsulAPIRetryBudget             float64
+	flagConsulAPICircuitBreakerThreshold int
+	flagConsulAPICircuitBreakerOpen      time.Duration
+
 	// Consul DNS flags.
 	flagEnableConsulDNS bool
 	flagResourcePrefix  string
 
+	// flagClusterName distinguishes this Kubernetes cluster from the others
+	// that are registered with the same Consul datacenter.
+	flagClusterName string
+
 	flagEnableOpenShift bool
 
-	flagSet *flag.FlagSet
-	consul  *flags.ConsulFlags
+	flagSet     *flag.FlagSet
+	consul      *flags.ConsulFlags
+	tlsListener *flags.TLSListenerFlags
 
 	clientset kubernetes.Interface
 
@@ -173,12 +314,39 @@ func (c *Command) init() {
 		"Docker image for Consul Dataplane.")
 	c.flagSet.StringVar(&c.flagConsulK8sImage, "consul-k8s-image", "",
 		"Docker image for consul-k8s. Used for the connect sidecar.")
+	c.flagSet.StringVar(&c.flagConsulDataplaneImageWindows, "consul-dataplane-image-windows", "",
+		"Docker image for Consul Dataplane used for pods on Windows nodes. Windows pods are not injected if it is not set.")
+	c.flagSet.StringVar(&c.flagConsulK8sImageWindows, "consul-k8s-image-windows", "",
+		"Docker image for consul-k8s used for pods on Windows nodes. Windows pods are not injected if it is not set.")
 	c.flagSet.BoolVar(&c.flagEnablePeering, "enable-peering", false, "Enable cluster peering controllers.")
+	c.flagSet.BoolVar(&c.flagPeerThroughMeshGateways, "peer-through-mesh-gateways", false,
+		"Enable peering through mesh gateways in the Mesh config entry and validate the WAN addresses of the mesh gateways. "+
+			"Requires -enable-peering.")
+	c.flagSet.StringVar(&c.flagMeshGatewayConsulServiceName, "mesh-gateway-consul-service-name", "mesh-gateway",
+		"Name of the mesh gateway service in Consul whose WAN addresses are validated if -peer-through-mesh-gateways is set.")
 	c.flagSet.BoolVar(&c.flagEnableFederation, "enable-federation", false, "Enable Consul WAN Federation.")
+	c.flagSet.BoolVar(&c.flagEnableSpireConnectCA, "enable-spire-connect-ca", false,
+		"Configure Consul's Connect CA with a CA certificate from SPIRE so that it's chained to SPIRE's trust domain. "+
+			"This workload must be registered with SPIRE as a downstream workload.")
+	c.flagSet.StringVar(&c.flagSpireAgentSocketPath, "spire-agent-socket-path", "/run/spire/sockets/agent.sock",
+		"Path of the SPIRE agent's Workload API socket.")
+	c.flagSet.StringVar(&c.flagSpireServerAddress, "spire-server-address", "",
+		"Address of the SPIRE server API in the form host:port. Required if -enable-spire-connect-ca is set.")
+	c.flagSet.DurationVar(&c.flagSpireCATTL, "spire-ca-ttl", 0,
+		"Preferred lifetime of the Connect CA certificates from SPIRE. The SPIRE server decides the lifetime if it is not set.")
 	c.flagSet.StringVar(&c.flagEnvoyExtraArgs, "envoy-extra-args", "",
 		"Extra envoy command line args to be set when starting envoy (e.g \"--log-level debug --disable-hot-restart\").")
 	c.flagSet.StringVar(&c.flagACLAuthMethod, "acl-auth-method", "",
 		"The name of the Kubernetes Auth Method to use for connectInjection if ACLs are enabled.")
+	c.flagSet.BoolVar(&c.flagEnableProjectedServiceAccountToken, "enable-projected-service-account-token", false,
+		"Log in with a projected service account token requested for each injected pod instead of the token "+
+			"automounted into the pod. Multi-port pods still use the service account secrets of their services.")
+	c.flagSet.StringVar(&c.flagServiceAccountTokenAudience, "service-account-token-audience", "",
+		"Audience of the projected service account token. It must be one of the audiences of the Kubernetes API server. "+
+			"Defaults to the audience of the API server.")
+	c.flagSet.DurationVar(&c.flagServiceAccountTokenExpiration, "service-account-token-expiration", 0,
+		"Requested lifetime of the projected service account token. Must be at least 10m. "+
+			"Defaults to 0 which uses the Kubernetes default of 1h.")
 	c.flagSet.Var((*flags.AppendSliceValue)(&c.flagAllowK8sNamespacesList), "allow-k8s-namespace",
 		"K8s namespaces to explicitly allow. May be specified multiple times.")
 	c.flagSet.Var((*flags.AppendSliceValue)(&c.flagDenyK8sNamespacesList), "deny-k8s-namespace",
@@ -187,6 +355,9 @@ func (c *Command) init() {
 	c.flagSet.StringVar(&c.flagReleaseNamespace, "release-namespace", "default", "The Consul Helm installation namespace, e.g 'helm install <RELEASE-NAME> --namespace <RELEASE-NAMESPACE>'")
 	c.flagSet.BoolVar(&c.flagEnablePartitions, "enable-partitions", false,
 		"[Enterprise Only] Enables Admin Partitions.")
+	c.flagSet.Var((*flags.AppendSliceValue)(&c.flagConfigEntryPartitions), "config-entry-partition",
+		"[Enterprise Only] Partition that config entry resources can be written to with the "+
+			"consul.hashicorp.com/partition annotation. May be specified multiple times. Requires -partition to be 'default'.")
 	c.flagSet.BoolVar(&c.flagEnableNamespaces, "enable-namespaces", false,
 		"[Enterprise Only] Enables namespaces, in either a single Consul namespace or mirrored.")
 	c.flagSet.StringVar(&c.flagConsulDestinationNamespace, "consul-destination-namespace", "default",
@@ -203,25 +374,141 @@ func (c *Command) init() {
 		"Enable transparent proxy mode for all Consul service mesh applications by default.")
 	c.flagSet.BoolVar(&c.flagEnableCNI, "enable-cni", false,
 		"Enable CNI traffic redirection for all Consul service mesh applications.")
+	c.flagSet.BoolVar(&c.flagCNIRequireRedirection, "cni-require-redirection", false,
+		"Make the connect-inject init container of transparent proxy pods wait for the CNI plugin to apply the traffic "+
+			"redirection rules, and fail if it doesn't. Only used if -enable-cni is set.")
 	c.flagSet.BoolVar(&c.flagTransparentProxyDefaultOverwriteProbes, "transparent-proxy-default-overwrite-probes", true,
 		"Overwrite Kubernetes probes to point to Envoy by default when in Transparent Proxy mode.")
+	c.flagSet.BoolVar(&c.flagTransparentProxyDefaultEnableIPv6, "transparent-proxy-default-enable-ipv6", false,
+		"Redirect IPv6 traffic to Envoy by default when in Transparent Proxy mode and bind Envoy's public listener to '::'.")
 	c.flagSet.BoolVar(&c.flagEnableConsulDNS, "enable-consul-dns", false,
 		"Enables Consul DNS lookup for services in the mesh.")
 	c.flagSet.StringVar(&c.flagResourcePrefix, "resource-prefix", "",
 		"Release prefix of the Consul installation used to determine Consul DNS Service name.")
+	c.flagSet.StringVar(&c.flagClusterName, "cluster-name", "",
+		"Name that distinguishes this Kubernetes cluster from the other Kubernetes clusters that are registered with the same "+
+			"Consul datacenter. It is added to the names of the virtual Consul nodes that services are registered on and "+
+			"of the ACL roles created by server-acl-init.")
 	c.flagSet.BoolVar(&c.flagEnableOpenShift, "enable-openshift", false,
 		"Indicates that the command runs in an OpenShift cluster.")
 	c.flagSet.BoolVar(&c.flagEnableWebhookCAUpdate, "enable-webhook-ca-update", false,
 		"Enables updating the CABundle on the webhook within this controller rather than using the web cert manager.")
+	c.flagSet.DurationVar(&c.flagSecretReloadInterval, "secret-reload-interval", 0,
+		"How often the Consul CA certificate and the webhook certificates are checked for changes, e.g. when the "+
+			"Vault agent renews them. Changes are reloaded without restarting. Defaults to 0 which disables reloading.")
 	c.flagSet.BoolVar(&c.flagEnableAutoEncrypt, "enable-auto-encrypt", false,
 		"Indicates whether TLS with auto-encrypt should be used when talking to Consul clients.")
 	c.flagSet.BoolVar(&c.flagEnableTelemetryCollector, "enable-telemetry-collector", false,
 		"Indicates whether proxies should be registered with configuration to enable forwarding metrics to consul-telemetry-collector")
+	c.flagSet.BoolVar(&c.flagEnableEndpointSlices, "enable-endpoint-slices", false,
+		"Indicates whether the endpoints controller should watch discovery.k8s.io/v1 EndpointSlices instead of v1 Endpoints.")
+	c.flagSet.DurationVar(&c.flagEndpointSliceResyncInterval, "endpoint-slice-resync-interval", 10*time.Minute,
+		"Interval at which the endpoints controller compares all addresses of a service against Consul when -enable-endpoint-slices "+
+			"is set. In between, only the endpoints of the EndpointSlices that changed are synced. Set to 0 to only compare "+
+			"addresses in full on the first reconcile of each service.")
+	c.flagSet.DurationVar(&c.flagEndpointsCatalogCacheTTL, "endpoints-catalog-cache-ttl", 0,
+		"How long the endpoints controller reuses the service instances it reads from a Consul node for later "+
+			"reconciles of the services on the node. Concurrent reads of a node are always coalesced. Defaults to 0 which disables caching.")
+	c.flagSet.BoolVar(&c.flagEnableDualStackAddresses, "enable-dual-stack-addresses", false,
+		"Indicates whether the endpoints controller should register the IPv4 and IPv6 addresses of dual-stack pods as tagged addresses.")
+	c.flagSet.Var((*flags.AppendSliceValue)(&c.flagLabelsToTags), "label-to-tag",
+		"Pod label whose value is registered as a tag with the pod's services. This flag may be specified multiple times. "+
+			"It is overridden by the consul.hashicorp.com/service-labels-to-tags annotation of a pod.")
+	c.flagSet.Var((*flags.FlagMapValue)(&c.flagLabelsToMeta), "label-to-meta",
+		"Pod label whose value is added to the metadata of the pod's services, formatted as label=key. This flag may be specified "+
+			"multiple times. It is overridden by the consul.hashicorp.com/service-labels-to-meta annotation of a pod.")
+	c.flagSet.BoolVar(&c.flagEnableConfigEntryConsulValidation, "enable-config-entry-consul-validation", false,
+		"Indicates whether the config entry webhooks should validate resources against the config entries in Consul.")
+	c.flagSet.DurationVar(&c.flagConfigEntryResyncInterval, "config-entry-resync-interval", 0,
+		"Interval at which config entries are re-read from Consul and rewritten if they were changed outside of Kubernetes. "+
+			"Defaults to 0 which only syncs config entries when their resource changes.")
+	c.flagSet.DurationVar(&c.flagACLTokenRotationInterval, "acl-token-rotation-interval", 0,
+		"Interval at which the ACL tokens of the components are rotated by logging in again and writing the new token to "+
+			"their token Secrets. Only Secrets in the release namespace with the consul.hashicorp.com/rotate-acl-token label "+
+			"are rotated. The injector's own token is rotated too if it logs in with -auth-method-name. "+
+			"Defaults to 0 which disables rotation.")
+	c.flagSet.DurationVar(&c.flagACLTokenCleanupInterval, "acl-token-cleanup-interval", 0,
+		"Interval at which ACL tokens created by logging in with the auth methods are deleted if their pod no longer exists. "+
+			"Defaults to 0 which disables the cleanup.")
+	c.flagSet.StringVar(&c.flagSnapshotAgentConfigSecretName, "snapshot-agent-config-secret-name", "",
+		"Name of the secret in the release namespace that the snapshot agent config of the SnapshotSchedule is written to. "+
+			"SnapshotSchedules are only reconciled if set.")
+	c.flagSet.StringVar(&c.flagSnapshotAgentPodSelector, "snapshot-agent-pod-selector", "",
+		"Label selector of the Consul server pods running the snapshot agents. Their logs are read to report the time "+
+			"of the last successful snapshot in the SnapshotSchedule status.")
+	c.flagSet.StringVar(&c.flagServerStatefulSetName, "server-statefulset-name", "",
+		"Name of the Consul server StatefulSet in the release namespace. If set, its pods are restarted one at a time "+
+			"to roll out updates, only while autopilot reports all servers as healthy. The StatefulSet must use the "+
+			"OnDelete update strategy.")
+	c.flagSet.BoolVar(&c.flagEnableNetworkPolicyIntentions, "enable-network-policy-intentions", false,
+		"Generate ServiceIntentions from the ingress rules of the NetworkPolicies in the namespaces that are injected. "+
+			"The intentions only allow traffic, so they only take effect if intentions deny traffic by default.")
+	c.flagSet.BoolVar(&c.flagEnableAppProtocolServiceDefaults, "enable-app-protocol-service-defaults", false,
+		"Generate a ServiceDefaults with the protocol of each Service in the namespaces that are injected whose "+
+			"ports set an appProtocol of http, http2, grpc or tcp. ServiceDefaults created by users take precedence.")
+	c.flagSet.BoolVar(&c.flagDryRun, "dry-run", false,
+		"Evaluate pods without injecting them. Instead, a report of what would have been injected, or why the pod "+
+			"would have been rejected, is added to the pods as an annotation and counted in metrics. It can be "+
+			"overridden per namespace with the \"consul.hashicorp.com/connect-inject-dry-run\" label.")
+	c.flagSet.BoolVar(&c.flagEnableAuditLog, "enable-audit-log", false,
+		"Log every write the controllers make to Consul, with the controller, the Kubernetes object it was made for "+
+			"and the diff of the Consul object.")
+	c.flagSet.StringVar(&c.flagAuditLogWebhookURL, "audit-log-webhook-url", "",
+		"URL that each audit log event is sent to as JSON in a POST request, in addition to being logged. "+
+			"Requires -enable-audit-log.")
+	c.flagSet.IntVar(&c.flagShardCount, "shard-count", 1,
+		"Number of shards that endpoints reconciliation is split across. Each shard elects its own leader "+
+			"and only reconciles services in the Kubernetes namespaces it owns.")
+	c.flagSet.IntVar(&c.flagShardIndex, "shard-index", 0,
+		"Index of the shard this replica belongs to, from 0 to -shard-count minus 1. Controllers other than "+
+			"the endpoints controller only run on shard 0.")
+	c.flagSet.IntVar(&c.flagEndpointsMaxConcurrentReconciles, "endpoints-controller-max-concurrent-reconciles", 1,
+		"Maximum number of services the endpoints controller reconciles concurrently.")
+	c.flagSet.IntVar(&c.flagPeeringMaxConcurrentReconciles, "peering-controller-max-concurrent-reconciles", 1,
+		"Maximum number of resources the peering acceptor and dialer controllers each reconcile concurrently.")
+	c.flagSet.DurationVar(&c.flagReconcileBaseDelay, "reconcile-base-delay", 5*time.Millisecond,
+		"Initial delay before a failed reconcile is retried. The delay doubles on each failure up to -reconcile-max-delay.")
+	c.flagSet.DurationVar(&c.flagReconcileMaxDelay, "reconcile-max-delay", 1000*time.Second,
+		"Maximum delay before a failed reconcile is retried.")
+	c.flagSet.Float64Var(&c.flagReconcileQPS, "reconcile-qps", 10,
+		"Overall rate per second at which each controller's workqueue releases items to be reconciled.")
+	c.flagSet.IntVar(&c.flagReconcileBurst, "reconcile-burst", 100,
+		"Burst size of each controller's workqueue rate limit.")
+	c.flagSet.Float64Var(&c.flagKubeAPIQPS, "kube-api-qps", 20,
+		"Maximum queries per second to the Kubernetes API.")
+	c.flagSet.IntVar(&c.flagKubeAPIBurst, "kube-api-burst", 30,
+		"Burst size of queries to the Kubernetes API.")
+	c.flagSet.Float64Var(&c.flagConsulAPIQPS, "consul-api-qps", 0,
+		"Maximum queries per second to the Consul HTTP API. Defaults to 0 which does not limit requests.")
+	c.flagSet.IntVar(&c.flagConsulAPIBurst, "consul-api-burst", 100,
+		"Burst size of queries to the Consul HTTP API. Only used if -consul-api-qps is set.")
+	c.flagSet.IntVar(&c.flagConsulAPIMaxRetries, "consul-api-max-retries", 0,
+		"Number of times a Consul HTTP API request is retried when the server is unavailable or overloaded. "+
+			"Requests other than GET are only retried if they were not processed, i.e. if the connection failed or "+
+			"the server responded with 429 or 503. Retries stop when -api-timeout expires. "+
+			"Defaults to 0 which does not retry requests.")
+	c.flagSet.DurationVar(&c.flagConsulAPIRetryBackoff, "consul-api-retry-backoff", 100*time.Millisecond,
+		"Delay before the first retry of a Consul HTTP API request. The delay doubles on each further retry up to 5s.")
+	c.flagSet.Float64Var(&c.flagConsulAPIRetryBudget, "consul-api-retry-budget", 10,
+		"Maximum retries per second of Consul HTTP API requests across all controllers. "+
+			"Only used if -consul-api-max-retries is set.")
+	c.flagSet.IntVar(&c.flagConsulAPICircuitBreakerThreshold, "consul-api-circuit-breaker-threshold", 0,
+		"Number of consecutive failures of a Consul HTTP API endpoint after which requests to it are rejected "+
+			"for -consul-api-circuit-breaker-open-duration. Defaults to 0 which disables circuit breaking.")
+	c.flagSet.DurationVar(&c.flagConsulAPICircuitBreakerOpen, "consul-api-circuit-breaker-open-duration", 30*time.Second,
+		"How long requests to a Consul HTTP API endpoint are rejected once its circuit breaker is open.")
 	c.flagSet.StringVar(&c.flagLogLevel, "log-level", zapcore.InfoLevel.String(),
 		fmt.Sprintf("Log verbosity level. Supported values (in order of detail) are "+
 			"%q, %q, %q, and %q.", zapcore.DebugLevel.String(), zapcore.InfoLevel.String(), zapcore.WarnLevel.String(), zapcore.ErrorLevel.String()))
 	c.flagSet.BoolVar(&c.flagLogJSON, "log-json", false,
 		"Enable or disable JSON output format for logging.")
+	c.flagSet.BoolVar(&c.flagLogJSONHCLogKeys, "log-json-hclog-keys", false,
+		"Use the same keys in JSON logs as the other Consul components, e.g. @timestamp, @level and @message, "+
+			"instead of ts, level and msg.")
+	c.flagSet.StringVar(&c.flagLogLevelConfigMap, "log-level-configmap", "",
+		"Name of a ConfigMap in the release namespace to change the log level from at runtime. "+
+			"The log level is read from its \"connect-injector\" key. If the key or the ConfigMap is removed, "+
+			"the log level set with -log-level is used again.")
 
 	// Proxy sidecar resource setting flags.
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyCPURequest, "default-sidecar-proxy-cpu-request", "", "Default sidecar proxy CPU request.")
@@ -233,6 +520,7 @@ func (c *Command) init() {
 	c.flagSet.BoolVar(&c.flagDefaultEnableSidecarProxyLifecycle, "default-enable-sidecar-proxy-lifecycle", false, "Default for enabling sidecar proxy lifecycle management.")
 	c.flagSet.BoolVar(&c.flagDefaultEnableSidecarProxyLifecycleShutdownDrainListeners, "default-enable-sidecar-proxy-lifecycle-shutdown-drain-listeners", false, "Default for enabling sidecar proxy listener draining of inbound connections during shutdown.")
 	c.flagSet.IntVar(&c.flagDefaultSidecarProxyLifecycleShutdownGracePeriodSeconds, "default-sidecar-proxy-lifecycle-shutdown-grace-period-seconds", 0, "Default sidecar proxy shutdown grace period in seconds.")
+	c.flagSet.BoolVar(&c.flagDefaultEnableSidecarProxyLifecyclePreStopDrain, "default-enable-sidecar-proxy-lifecycle-prestop-drain", false, "Default for starting the graceful shutdown of the sidecar proxy from a preStop hook when sidecar proxy lifecycle management is enabled.")
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyLifecycleGracefulPort, "default-sidecar-proxy-lifecycle-graceful-port", strconv.Itoa(constants.DefaultGracefulPort), "Default port for sidecar proxy lifecycle management HTTP endpoints.")
 	c.flagSet.StringVar(&c.flagDefaultSidecarProxyLifecycleGracefulShutdownPath, "default-sidecar-proxy-lifecycle-graceful-shutdown-path", "/graceful_shutdown", "Default sidecar proxy lifecycle management graceful shutdown path.")
 
@@ -244,6 +532,28 @@ func (c *Command) init() {
 	c.flagSet.StringVar(&c.flagDefaultPrometheusScrapePort, "default-prometheus-scrape-port", "20200", "Default port where Prometheus scrapes connect metrics from.")
 	c.flagSet.StringVar(&c.flagDefaultPrometheusScrapePath, "default-prometheus-scrape-path", "/metrics", "Default path where Prometheus scrapes connect metrics from.")
 
+	// Envoy access log setting flags.
+	c.flagSet.BoolVar(&c.flagDefaultEnableEnvoyAccessLogs, "default-enable-envoy-access-logs", false, "Default for enabling Envoy access logs on sidecar proxies.")
+	c.flagSet.StringVar(&c.flagDefaultEnvoyAccessLogsType, "default-envoy-access-logs-type", "stdout", "Default output for Envoy access logs. One of \"stdout\", \"stderr\" or \"file\".")
+	c.flagSet.StringVar(&c.flagDefaultEnvoyAccessLogsPath, "default-envoy-access-logs-path", "", "Default file path for Envoy access logs when the type is \"file\".")
+	c.flagSet.StringVar(&c.flagDefaultEnvoyAccessLogsJSONFormat, "default-envoy-access-logs-json-format", "", "Default JSON format dictionary for Envoy access logs.")
+	c.flagSet.StringVar(&c.flagDefaultEnvoyAccessLogsTextFormat, "default-envoy-access-logs-text-format", "", "Default text format string for Envoy access logs.")
+
+	// Envoy tracing setting flags.
+	c.flagSet.BoolVar(&c.flagDefaultEnableEnvoyTracing, "default-enable-envoy-tracing", false, "Default for enabling OpenTelemetry tracing on sidecar proxies.")
+	c.flagSet.StringVar(&c.flagDefaultEnvoyTracingCollectorAddress, "default-envoy-tracing-collector-address", "", "Default host:port of the OTLP gRPC collector that sidecar proxies send traces to.")
+	c.flagSet.StringVar(&c.flagDefaultEnvoyTracingSamplingRate, "default-envoy-tracing-sampling-rate", "100", "Default percentage of requests traced by sidecar proxies.")
+
+	// Envoy DogStatsD setting flags.
+	c.flagSet.BoolVar(&c.flagDefaultEnableEnvoyDogStatsD, "default-enable-envoy-dogstatsd", false, "Default for sending the metrics of sidecar proxies to DogStatsD.")
+	c.flagSet.StringVar(&c.flagEnvoyDogStatsDSocketPath, "envoy-dogstatsd-socket-path", dogstatsd.DefaultSocketPath, "Path of the DogStatsD Unix domain socket of the Datadog agent on the node.")
+	c.flagSet.IntVar(&c.flagEnvoyDogStatsDHostPort, "envoy-dogstatsd-host-port", 0,
+		"UDP port of the Datadog agent on the node's host IP. If set, sidecar proxies send their metrics to it instead of the socket, and no hostPath volume is mounted.")
+	c.flagSet.BoolVar(&c.flagEnvoyDogStatsDPodTags, "envoy-dogstatsd-pod-tags", false,
+		"Tag the DogStatsD metrics of sidecar proxies with the pod's name and UID. This creates a time series per pod.")
+	c.flagSet.Var((*flags.AppendSliceValue)(&c.flagDefaultEnvoyDogStatsDTags), "default-envoy-dogstatsd-tag",
+		"Datadog tag in the form key:value that is added to the DogStatsD metrics of sidecar proxies. May be specified multiple times.")
+
 	// Init container resource setting flags.
 	c.flagSet.StringVar(&c.flagInitContainerCPURequest, "init-container-cpu-request", "50m", "Init container CPU request.")
 	c.flagSet.StringVar(&c.flagInitContainerCPULimit, "init-container-cpu-limit", "50m", "Init container CPU limit.")
@@ -253,8 +563,10 @@ func (c *Command) init() {
 	c.flagSet.IntVar(&c.flagDefaultEnvoyProxyConcurrency, "default-envoy-proxy-concurrency", 2, "Default Envoy proxy concurrency.")
 
 	c.consul = &flags.ConsulFlags{}
+	c.tlsListener = &flags.TLSListenerFlags{}
 
 	flags.Merge(c.flagSet, c.consul.Flags())
+	flags.Merge(c.flagSet, c.tlsListener.Flags())
 	// flag.CommandLine is a package level variable representing the default flagSet. The init() function in
 	// "sigs.k8s.io/controller-runtime/pkg/client/config", which is imported by ctrl, registers the flag --kubeconfig to
 	// the default flagSet. That's why we need to merge it to have access with our flagSet.
@@ -346,6 +658,8 @@ func (c *Command) Run(args []string) int {
 			c.UI.Error(fmt.Sprintf("error loading in-cluster K8S config: %s", err))
 			return 1
 		}
+		config.QPS = float32(c.flagKubeAPIQPS)
+		config.Burst = c.flagKubeAPIBurst
 		c.clientset, err = kubernetes.NewForConfig(config)
 		if err != nil {
 			c.UI.Error(fmt.Sprintf("error creating K8S client: %s", err))
@@ -357,7 +671,7 @@ func (c *Command) Run(args []string) int {
 	allowK8sNamespaces := flags.ToSet(c.flagAllowK8sNamespacesList)
 	denyK8sNamespaces := flags.ToSet(c.flagDenyK8sNamespacesList)
 
-	zapLogger, err := common.ZapLogger(c.flagLogLevel, c.flagLogJSON)
+	zapLogger, zapLevel, err := common.AtomicZapLogger(c.flagLogLevel, c.flagLogJSON, c.flagLogJSONHCLogKeys)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error setting up logging: %s", err.Error()))
 		return 1
@@ -366,7 +680,7 @@ func (c *Command) Run(args []string) int {
 	klog.SetLogger(zapLogger)
 
 	// TODO (agentless): find a way to integrate zap logger (via having a generic logger interface in connection manager).
-	hcLog, err := common.NamedLogger(c.flagLogLevel, c.flagLogJSON, "consul-server-connection-manager")
+	hcLog, err := common.Logger(c.flagLogLevel, c.flagLogJSON)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error setting up logging: %s", err.Error()))
 		return 1
@@ -385,6 +699,18 @@ func (c *Command) Run(args []string) int {
 
 	// Create Consul API config object.
 	consulConfig := c.consul.ConsulClientConfig()
+	if c.flagConsulAPIQPS > 0 {
+		consulConfig.RateLimiter = rate.NewLimiter(rate.Limit(c.flagConsulAPIQPS), c.flagConsulAPIBurst)
+	}
+	if c.flagConsulAPIMaxRetries > 0 || c.flagConsulAPICircuitBreakerThreshold > 0 {
+		consulConfig.RequestPolicy = &consul.RequestPolicy{
+			MaxRetries:                 c.flagConsulAPIMaxRetries,
+			RetryBackoff:               c.flagConsulAPIRetryBackoff,
+			RetryBudget:                rate.NewLimiter(rate.Limit(c.flagConsulAPIRetryBudget), int(math.Ceil(c.flagConsulAPIRetryBudget))),
+			CircuitBreakerThreshold:    c.flagConsulAPICircuitBreakerThreshold,
+			CircuitBreakerOpenDuration: c.flagConsulAPICircuitBreakerOpen,
+		}
+	}
 
 	var caCertPem []byte
 	if c.consul.CACertFile != "" {
@@ -406,15 +732,62 @@ func (c *Command) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("unable to create config for consul-server-connection-manager: %s", err))
 		return 1
 	}
-	watcher, err := discovery.NewWatcher(ctx, serverConnMgrCfg, hcLog)
-	if err != nil {
-		c.UI.Error(fmt.Sprintf("unable to create Consul server watcher: %s", err))
-		return 1
+	var secretWatcher *secretreload.Watcher
+	if c.flagSecretReloadInterval > 0 {
+		secretWatcher = &secretreload.Watcher{
+			PollInterval: c.flagSecretReloadInterval,
+			Log:          hcLog.Named("secret-reload"),
+		}
+		if serverConnMgrCfg.TLS != nil && c.consul.CACertFile != "" {
+			// The HTTP API clients read the CA cert file whenever they are
+			// created, so only the gRPC connections need to reload it.
+			if serverName := c.consul.ServerName(); serverName == "" {
+				hcLog.Warn("Consul's CA cert is not reloaded for gRPC connections because -tls-server-name is not set")
+			} else if err := secretWatcher.WatchRootCAs(c.consul.CACertFile, serverConnMgrCfg.TLS, serverName); err != nil {
+				c.UI.Error(fmt.Sprintf("unable to reload Consul's CA cert file %q: %s", c.consul.CACertFile, err))
+				return 1
+			}
+		}
+	}
+	var watcher consul.ServerConnectionManager
+	if c.flagACLTokenRotationInterval > 0 && c.consul.ConsulLogin.AuthMethod != "" {
+		// The injector rotates its own token too, by logging in again.
+		watcher = &consul.ReloginConnMgr{
+			Interval: c.flagACLTokenRotationInterval,
+			NewConnMgr: func() (consul.ServerConnectionManager, error) {
+				return discovery.NewWatcher(ctx, serverConnMgrCfg, hcLog.Named("consul-server-connection-manager"))
+			},
+			Log: hcLog.Named("consul-server-connection-manager"),
+		}
+	} else {
+		watcher, err = discovery.NewWatcher(ctx, serverConnMgrCfg, hcLog.Named("consul-server-connection-manager"))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("unable to create Consul server watcher: %s", err))
+			return 1
+		}
 	}
 
 	go watcher.Run()
 	defer watcher.Stop()
 
+	if c.flagLogLevelConfigMap != "" {
+		logLevelWatcher := &loglevel.Watcher{
+			Client:        c.clientset,
+			Namespace:     c.flagReleaseNamespace,
+			ConfigMapName: c.flagLogLevelConfigMap,
+			Component:     "connect-injector",
+			DefaultLevel:  c.flagLogLevel,
+			SetLevel: func(level string) error {
+				if err := common.SetZapLevel(zapLevel, level); err != nil {
+					return err
+				}
+				return common.SetLoggerLevel(hcLog, level)
+			},
+			Log: hcLog.Named("log-level"),
+		}
+		go logLevelWatcher.Run(ctx)
+	}
+
 	// This is a blocking command that is run in order to ensure we only start the
 	// connect-inject controllers only after we have access to the Consul server.
 	_, err = watcher.State()
@@ -423,10 +796,20 @@ func (c *Command) Run(args []string) int {
 		return 1
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	// Each shard elects its own leader so that shards reconcile in parallel.
+	leaderElectionID := "consul-controller-lock"
+	if c.flagShardCount > 1 {
+		leaderElectionID = fmt.Sprintf("%s-shard-%d", leaderElectionID, c.flagShardIndex)
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = float32(c.flagKubeAPIQPS)
+	restConfig.Burst = c.flagKubeAPIBurst
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
 		LeaderElection:         true,
-		LeaderElectionID:       "consul-controller-lock",
+		LeaderElectionID:       leaderElectionID,
 		Host:                   listenSplits[0],
 		Port:                   port,
 		Logger:                 zapLogger,
@@ -438,10 +821,26 @@ func (c *Command) Run(args []string) int {
 		return 1
 	}
 
+	var auditor *audit.Auditor
+	if c.flagEnableAuditLog {
+		auditLog := ctrl.Log.WithName("audit")
+		sinks := []audit.Sink{&audit.LogSink{Log: auditLog}}
+		if c.flagAuditLogWebhookURL != "" {
+			webhookSink := audit.NewWebhookSink(c.flagAuditLogWebhookURL, auditLog.WithName("webhook"))
+			if err = mgr.Add(webhookSink); err != nil {
+				setupLog.Error(err, "unable to add audit log webhook")
+				return 1
+			}
+			sinks = append(sinks, webhookSink)
+		}
+		auditor = audit.New(auditLog, sinks...)
+	}
+
 	lifecycleConfig := lifecycle.Config{
 		DefaultEnableProxyLifecycle:         c.flagDefaultEnableSidecarProxyLifecycle,
 		DefaultEnableShutdownDrainListeners: c.flagDefaultEnableSidecarProxyLifecycleShutdownDrainListeners,
 		DefaultShutdownGracePeriodSeconds:   c.flagDefaultSidecarProxyLifecycleShutdownGracePeriodSeconds,
+		DefaultEnablePreStopDrain:           c.flagDefaultEnableSidecarProxyLifecyclePreStopDrain,
 		DefaultGracefulPort:                 c.flagDefaultSidecarProxyLifecycleGracefulPort,
 		DefaultGracefulShutdownPath:         c.flagDefaultSidecarProxyLifecycleGracefulShutdownPath,
 	}
@@ -455,262 +854,478 @@ func (c *Command) Run(args []string) int {
 		DefaultPrometheusScrapePath: c.flagDefaultPrometheusScrapePath,
 	}
 
-	if err = (&endpoints.Controller{
-		Client:                     mgr.GetClient(),
-		ConsulClientConfig:         consulConfig,
-		ConsulServerConnMgr:        watcher,
-		AllowK8sNamespacesSet:      allowK8sNamespaces,
-		DenyK8sNamespacesSet:       denyK8sNamespaces,
-		MetricsConfig:              metricsConfig,
-		EnableConsulPartitions:     c.flagEnablePartitions,
-		EnableConsulNamespaces:     c.flagEnableNamespaces,
-		ConsulDestinationNamespace: c.flagConsulDestinationNamespace,
-		EnableNSMirroring:          c.flagEnableK8SNSMirroring,
-		NSMirroringPrefix:          c.flagK8SNSMirroringPrefix,
-		CrossNSACLPolicy:           c.flagCrossNamespaceACLPolicy,
-		EnableTransparentProxy:     c.flagDefaultEnableTransparentProxy,
-		EnableWANFederation:        c.flagEnableFederation,
-		TProxyOverwriteProbes:      c.flagTransparentProxyDefaultOverwriteProbes,
-		AuthMethod:                 c.flagACLAuthMethod,
-		NodeMeta:                   c.flagNodeMeta,
-		Log:                        ctrl.Log.WithName("controller").WithName("endpoints"),
-		Scheme:                     mgr.GetScheme(),
-		ReleaseName:                c.flagReleaseName,
-		ReleaseNamespace:           c.flagReleaseNamespace,
-		EnableAutoEncrypt:          c.flagEnableAutoEncrypt,
-		EnableTelemetryCollector:   c.flagEnableTelemetryCollector,
-		Context:                    ctx,
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", endpoints.Controller{})
-		return 1
+	accessLogsConfig := accesslogs.Config{
+		DefaultEnabled:    c.flagDefaultEnableEnvoyAccessLogs,
+		DefaultType:       c.flagDefaultEnvoyAccessLogsType,
+		DefaultPath:       c.flagDefaultEnvoyAccessLogsPath,
+		DefaultJSONFormat: c.flagDefaultEnvoyAccessLogsJSONFormat,
+		DefaultTextFormat: c.flagDefaultEnvoyAccessLogsTextFormat,
 	}
 
-	// API Gateway Controllers
-	if err := gatewaycontrollers.RegisterFieldIndexes(ctx, mgr); err != nil {
-		setupLog.Error(err, "unable to register field indexes")
-		return 1
+	tracingConfig := tracing.Config{
+		DefaultEnabled:          c.flagDefaultEnableEnvoyTracing,
+		DefaultCollectorAddress: c.flagDefaultEnvoyTracingCollectorAddress,
+		DefaultSamplingRate:     c.flagDefaultEnvoyTracingSamplingRate,
 	}
 
-	if err = (&gatewaycontrollers.GatewayClassConfigController{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controller").WithName("gateways"),
-	}).SetupWithManager(ctx, mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", gatewaycontrollers.GatewayClassConfigController{})
-		return 1
+	dogStatsDConfig := dogstatsd.Config{
+		DefaultEnabled: c.flagDefaultEnableEnvoyDogStatsD,
+		SocketPath:     c.flagEnvoyDogStatsDSocketPath,
+		HostPort:       c.flagEnvoyDogStatsDHostPort,
+		PodTags:        c.flagEnvoyDogStatsDPodTags,
+		DefaultTags:    c.flagDefaultEnvoyDogStatsDTags,
 	}
 
-	if err := (&gatewaycontrollers.GatewayClassController{
-		ControllerName: gatewaycommon.GatewayClassControllerName,
-		Client:         mgr.GetClient(),
-		Log:            ctrl.Log.WithName("controllers").WithName("GatewayClass"),
-	}).SetupWithManager(ctx, mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "GatewayClass")
+	if err = (&endpoints.Controller{
+		Client:                      mgr.GetClient(),
+		ConsulClientConfig:          consulConfig,
+		ConsulServerConnMgr:         watcher,
+		AllowK8sNamespacesSet:       allowK8sNamespaces,
+		DenyK8sNamespacesSet:        denyK8sNamespaces,
+		MetricsConfig:               metricsConfig,
+		AccessLogsConfig:            accessLogsConfig,
+		TracingConfig:               tracingConfig,
+		DogStatsDConfig:             dogStatsDConfig,
+		EnableConsulPartitions:      c.flagEnablePartitions,
+		EnableConsulNamespaces:      c.flagEnableNamespaces,
+		ConsulDestinationNamespace:  c.flagConsulDestinationNamespace,
+		EnableNSMirroring:           c.flagEnableK8SNSMirroring,
+		NSMirroringPrefix:           c.flagK8SNSMirroringPrefix,
+		CrossNSACLPolicy:            c.flagCrossNamespaceACLPolicy,
+		EnableTransparentProxy:      c.flagDefaultEnableTransparentProxy,
+		EnableWANFederation:         c.flagEnableFederation,
+		TProxyOverwriteProbes:       c.flagTransparentProxyDefaultOverwriteProbes,
+		AuthMethod:                  c.flagACLAuthMethod,
+		NodeMeta:                    c.flagNodeMeta,
+		Log:                         ctrl.Log.WithName("controller").WithName("endpoints"),
+		Scheme:                      mgr.GetScheme(),
+		ReleaseName:                 c.flagReleaseName,
+		ReleaseNamespace:            c.flagReleaseNamespace,
+		ClusterName:                 c.flagClusterName,
+		EnableAutoEncrypt:           c.flagEnableAutoEncrypt,
+		EnableTelemetryCollector:    c.flagEnableTelemetryCollector,
+		EnableEndpointSlices:        c.flagEnableEndpointSlices,
+		EndpointSliceResyncInterval: c.flagEndpointSliceResyncInterval,
+		CatalogCacheTTL:             c.flagEndpointsCatalogCacheTTL,
+		EnableDualStackAddresses:    c.flagEnableDualStackAddresses,
+		LabelsToTags:                c.flagLabelsToTags,
+		LabelsToMeta:                c.flagLabelsToMeta,
+		ShardCount:                  c.flagShardCount,
+		ShardIndex:                  c.flagShardIndex,
+		ControllerOptions:           c.controllerOptions(c.flagEndpointsMaxConcurrentReconciles),
+		Auditor:                     auditor,
+		Context:                     ctx,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", endpoints.Controller{})
 		return 1
 	}
 
-	cache, err := gatewaycontrollers.SetupGatewayControllerWithManager(ctx, mgr, gatewaycontrollers.GatewayControllerConfig{
-		HelmConfig: gatewaycommon.HelmConfig{
-			ConsulConfig: gatewaycommon.ConsulConfig{
-				Address:    c.consul.Addresses,
-				GRPCPort:   consulConfig.GRPCPort,
-				HTTPPort:   consulConfig.HTTPPort,
-				APITimeout: consulConfig.APITimeout,
+	// Only the first shard runs the controllers that are not partitioned by namespace, so that
+	// they are never reconciled by more than one leader at a time.
+	if c.flagShardIndex == 0 {
+		// API Gateway Controllers
+		if err := gatewaycontrollers.RegisterFieldIndexes(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to register field indexes")
+			return 1
+		}
+
+		if err = (&gatewaycontrollers.GatewayClassConfigController{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controller").WithName("gateways"),
+		}).SetupWithManager(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", gatewaycontrollers.GatewayClassConfigController{})
+			return 1
+		}
+
+		if err := (&gatewaycontrollers.GatewayClassController{
+			ControllerName: gatewaycommon.GatewayClassControllerName,
+			Client:         mgr.GetClient(),
+			Log:            ctrl.Log.WithName("controllers").WithName("GatewayClass"),
+		}).SetupWithManager(ctx, mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "GatewayClass")
+			return 1
+		}
+
+		cache, err := gatewaycontrollers.SetupGatewayControllerWithManager(ctx, mgr, gatewaycontrollers.GatewayControllerConfig{
+			HelmConfig: gatewaycommon.HelmConfig{
+				ConsulConfig: gatewaycommon.ConsulConfig{
+					Address:    c.consul.Addresses,
+					GRPCPort:   consulConfig.GRPCPort,
+					HTTPPort:   consulConfig.HTTPPort,
+					APITimeout: consulConfig.APITimeout,
+				},
+				ImageDataplane:             c.flagConsulDataplaneImage,
+				ImageConsulK8S:             c.flagConsulK8sImage,
+				ConsulDestinationNamespace: c.flagConsulDestinationNamespace,
+				NamespaceMirroringPrefix:   c.flagK8SNSMirroringPrefix,
+				EnableNamespaces:           c.flagEnableNamespaces,
+				PeeringEnabled:             c.flagEnablePeering,
+				EnableOpenShift:            c.flagEnableOpenShift,
+				EnableNamespaceMirroring:   c.flagEnableK8SNSMirroring,
+				AuthMethod:                 c.consul.ConsulLogin.AuthMethod,
+				LogLevel:                   c.flagLogLevel,
+				LogJSON:                    c.flagLogJSON,
+				TLSEnabled:                 c.consul.UseTLS,
+				ConsulTLSServerName:        c.consul.TLSServerName,
+				ConsulPartition:            c.consul.Partition,
+				ConsulCACert:               string(caCertPem),
+				ListenerTLSMinVersion:      c.tlsListener.MinVersion,
+				ListenerTLSCipherSuites:    c.tlsListener.CipherSuites,
+				ClusterName:                c.flagClusterName,
 			},
-			ImageDataplane:             c.flagConsulDataplaneImage,
-			ImageConsulK8S:             c.flagConsulK8sImage,
+			AllowK8sNamespacesSet:   allowK8sNamespaces,
+			DenyK8sNamespacesSet:    denyK8sNamespaces,
+			ConsulClientConfig:      consulConfig,
+			ConsulServerConnMgr:     watcher,
+			NamespacesEnabled:       c.flagEnableNamespaces,
+			CrossNamespaceACLPolicy: c.flagCrossNamespaceACLPolicy,
+			Partition:               c.consul.Partition,
+			Datacenter:              c.consul.Datacenter,
+			Auditor:                 auditor,
+		})
+
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Gateway")
+			return 1
+		}
+
+		go cache.Run(ctx)
+
+		// wait for the cache to fill
+		setupLog.Info("waiting for Consul cache sync")
+		cache.WaitSynced(ctx)
+		setupLog.Info("Consul cache synced")
+
+		configEntryReconciler := &controllers.ConfigEntryController{
+			ConsulClientConfig:         consulConfig,
+			ConsulServerConnMgr:        watcher,
+			DatacenterName:             c.consul.Datacenter,
+			EnableConsulNamespaces:     c.flagEnableNamespaces,
 			ConsulDestinationNamespace: c.flagConsulDestinationNamespace,
-			NamespaceMirroringPrefix:   c.flagK8SNSMirroringPrefix,
-			EnableNamespaces:           c.flagEnableNamespaces,
-			PeeringEnabled:             c.flagEnablePeering,
-			EnableOpenShift:            c.flagEnableOpenShift,
-			EnableNamespaceMirroring:   c.flagEnableK8SNSMirroring,
-			AuthMethod:                 c.consul.ConsulLogin.AuthMethod,
-			LogLevel:                   c.flagLogLevel,
-			LogJSON:                    c.flagLogJSON,
-			TLSEnabled:                 c.consul.UseTLS,
-			ConsulTLSServerName:        c.consul.TLSServerName,
-			ConsulPartition:            c.consul.Partition,
-			ConsulCACert:               string(caCertPem),
-		},
-		AllowK8sNamespacesSet:   allowK8sNamespaces,
-		DenyK8sNamespacesSet:    denyK8sNamespaces,
-		ConsulClientConfig:      consulConfig,
-		ConsulServerConnMgr:     watcher,
-		NamespacesEnabled:       c.flagEnableNamespaces,
-		CrossNamespaceACLPolicy: c.flagCrossNamespaceACLPolicy,
-		Partition:               c.consul.Partition,
-		Datacenter:              c.consul.Datacenter,
-	})
+			EnableNSMirroring:          c.flagEnableK8SNSMirroring,
+			NSMirroringPrefix:          c.flagK8SNSMirroringPrefix,
+			CrossNSACLPolicy:           c.flagCrossNamespaceACLPolicy,
+			ResyncInterval:             c.flagConfigEntryResyncInterval,
+			EventRecorder:              mgr.GetEventRecorderFor("consul-k8s-config-entries"),
+			Auditor:                    auditor,
+		}
+		if err = (&controllers.ServiceDefaultsController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceDefaults),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceDefaults)
+			return 1
+		}
+		if err = (&controllers.ServiceResolverController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceResolver),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceResolver)
+			return 1
+		}
+		if err = (&controllers.ProxyDefaultsController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ProxyDefaults),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.ProxyDefaults)
+			return 1
+		}
+		if err = (&controllers.MeshController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.Mesh),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.Mesh)
+			return 1
+		}
+		if err = (&controllers.ExportedServicesController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ExportedServices),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.ExportedServices)
+			return 1
+		}
+		if err = (&controllers.ServiceRouterController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceRouter),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceRouter)
+			return 1
+		}
+		if err = (&controllers.ServiceSplitterController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceSplitter),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceSplitter)
+			return 1
+		}
+		if err = (&controllers.ServiceIntentionsController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceIntentions),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceIntentions)
+			return 1
+		}
+		if err = (&controllers.IngressGatewayController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.IngressGateway),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.IngressGateway)
+			return 1
+		}
+		if err = (&controllers.TerminatingGatewayController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.TerminatingGateway),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.TerminatingGateway)
+			return 1
+		}
+		if err = (&controllers.SamenessGroupController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.SamenessGroup),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.SamenessGroup)
+			return 1
+		}
+		if err = (&controllers.JWTProviderController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.JWTProvider),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.JWTProvider)
+			return 1
+		}
+		if err = (&controllers.ControlPlaneRequestLimitController{
+			ConfigEntryController: configEntryReconciler,
+			Client:                mgr.GetClient(),
+			Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ControlPlaneRequestLimit),
+			Scheme:                mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", apicommon.ControlPlaneRequestLimit)
+			return 1
+		}
+		if err = (&registration.RegistrationController{
+			Client:              mgr.GetClient(),
+			ConsulClientConfig:  consulConfig,
+			ConsulServerConnMgr: watcher,
+			ACLsEnabled:         c.flagACLAuthMethod != "",
+			ResourcePrefix:      c.flagResourcePrefix,
+			ClusterName:         c.flagClusterName,
+			Auditor:             auditor,
+			ControllerOptions:   c.controllerOptions(1),
+			Log:                 ctrl.Log.WithName("controller").WithName("registration"),
+			Scheme:              mgr.GetScheme(),
+			Context:             ctx,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "registration")
+			return 1
+		}
+		if err = (&registration.ExternalServiceController{
+			Client:            mgr.GetClient(),
+			ControllerOptions: c.controllerOptions(1),
+			Log:               ctrl.Log.WithName("controller").WithName("terminating-gateway-external-service"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "terminating-gateway-external-service")
+			return 1
+		}
 
-	if err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Gateway")
-		return 1
-	}
+		if c.flagSnapshotAgentConfigSecretName != "" {
+			if err = (&snapshotschedule.Controller{
+				Client:            mgr.GetClient(),
+				Clientset:         c.clientset,
+				Log:               ctrl.Log.WithName("controller").WithName("snapshot-schedule"),
+				Scheme:            mgr.GetScheme(),
+				Namespace:         c.flagReleaseNamespace,
+				ConfigSecretName:  c.flagSnapshotAgentConfigSecretName,
+				ServerPodSelector: c.flagSnapshotAgentPodSelector,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "snapshot-schedule")
+				return 1
+			}
+		}
 
-	go cache.Run(ctx)
-
-	// wait for the cache to fill
-	setupLog.Info("waiting for Consul cache sync")
-	cache.WaitSynced(ctx)
-	setupLog.Info("Consul cache synced")
-
-	configEntryReconciler := &controllers.ConfigEntryController{
-		ConsulClientConfig:         c.consul.ConsulClientConfig(),
-		ConsulServerConnMgr:        watcher,
-		DatacenterName:             c.consul.Datacenter,
-		EnableConsulNamespaces:     c.flagEnableNamespaces,
-		ConsulDestinationNamespace: c.flagConsulDestinationNamespace,
-		EnableNSMirroring:          c.flagEnableK8SNSMirroring,
-		NSMirroringPrefix:          c.flagK8SNSMirroringPrefix,
-		CrossNSACLPolicy:           c.flagCrossNamespaceACLPolicy,
-	}
-	if err = (&controllers.ServiceDefaultsController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceDefaults),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceDefaults)
-		return 1
-	}
-	if err = (&controllers.ServiceResolverController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceResolver),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceResolver)
-		return 1
-	}
-	if err = (&controllers.ProxyDefaultsController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ProxyDefaults),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.ProxyDefaults)
-		return 1
-	}
-	if err = (&controllers.MeshController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.Mesh),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.Mesh)
-		return 1
-	}
-	if err = (&controllers.ExportedServicesController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ExportedServices),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.ExportedServices)
-		return 1
-	}
-	if err = (&controllers.ServiceRouterController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceRouter),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceRouter)
-		return 1
-	}
-	if err = (&controllers.ServiceSplitterController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceSplitter),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceSplitter)
-		return 1
-	}
-	if err = (&controllers.ServiceIntentionsController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ServiceIntentions),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.ServiceIntentions)
-		return 1
+		if c.flagServerStatefulSetName != "" {
+			if err = (&serverupgrade.Controller{
+				Client:              mgr.GetClient(),
+				ConsulClientConfig:  consulConfig,
+				ConsulServerConnMgr: watcher,
+				Log:                 ctrl.Log.WithName("controller").WithName("server-upgrade"),
+				EventRecorder:       mgr.GetEventRecorderFor("consul-k8s-server-upgrade"),
+				Namespace:           c.flagReleaseNamespace,
+				StatefulSetName:     c.flagServerStatefulSetName,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "server-upgrade")
+				return 1
+			}
+		}
+
+		if c.flagACLTokenRotationInterval > 0 {
+			if err = (&tokenrotation.Controller{
+				Client:              mgr.GetClient(),
+				Clientset:           c.clientset,
+				ConsulClientConfig:  consulConfig,
+				ConsulServerConnMgr: watcher,
+				AuthMethod:          c.consul.ConsulLogin.AuthMethod,
+				Datacenter:          c.consul.ConsulLogin.Datacenter,
+				Auditor:             auditor,
+				Log:                 ctrl.Log.WithName("controller").WithName("acl-token-rotation"),
+				Namespace:           c.flagReleaseNamespace,
+				RotationInterval:    c.flagACLTokenRotationInterval,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "acl-token-rotation")
+				return 1
+			}
+		}
+
+		if c.flagACLTokenCleanupInterval > 0 && c.flagACLAuthMethod != "" {
+			if err = mgr.Add(&tokencleanup.Controller{
+				Client:                 mgr.GetClient(),
+				ConsulClientConfig:     consulConfig,
+				ConsulServerConnMgr:    watcher,
+				AuthMethods:            []string{c.flagACLAuthMethod, c.consul.ConsulLogin.AuthMethod},
+				EnableConsulNamespaces: c.flagEnableNamespaces,
+				Interval:               c.flagACLTokenCleanupInterval,
+				Auditor:                auditor,
+				Log:                    ctrl.Log.WithName("controller").WithName("acl-token-cleanup"),
+			}); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "acl-token-cleanup")
+				return 1
+			}
+		}
+
+		if c.flagEnableNetworkPolicyIntentions {
+			if err = (&networkpolicy.Controller{
+				Client:                     mgr.GetClient(),
+				Log:                        ctrl.Log.WithName("controller").WithName("network-policy-intentions"),
+				AllowK8sNamespacesSet:      allowK8sNamespaces,
+				DenyK8sNamespacesSet:       denyK8sNamespaces,
+				EnableConsulNamespaces:     c.flagEnableNamespaces,
+				ConsulDestinationNamespace: c.flagConsulDestinationNamespace,
+				EnableNSMirroring:          c.flagEnableK8SNSMirroring,
+				NSMirroringPrefix:          c.flagK8SNSMirroringPrefix,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "network-policy-intentions")
+				return 1
+			}
+		}
+
+		if c.flagEnableAppProtocolServiceDefaults {
+			if err = (&appprotocol.Controller{
+				Client:                mgr.GetClient(),
+				Log:                   ctrl.Log.WithName("controller").WithName("app-protocol-service-defaults"),
+				AllowK8sNamespacesSet: allowK8sNamespaces,
+				DenyK8sNamespacesSet:  denyK8sNamespaces,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "app-protocol-service-defaults")
+				return 1
+			}
+		}
 	}
-	if err = (&controllers.IngressGatewayController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.IngressGateway),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.IngressGateway)
+
+	if err = mgr.AddReadyzCheck("ready", webhook.ReadinessCheck{CertDir: c.flagCertDir}.Ready); err != nil {
+		setupLog.Error(err, "unable to create readiness check", "controller", endpoints.Controller{})
 		return 1
 	}
-	if err = (&controllers.TerminatingGatewayController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.TerminatingGateway),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.TerminatingGateway)
-		return 1
+
+	// The health checks are served on /healthz of the health probe port and
+	// the full diagnostics on /diagz of the metrics port. They aren't used by
+	// the liveness probe so that an outage of the Consul servers doesn't
+	// restart the injector.
+	reconcileTracker := &diagnostics.ReconcileTracker{
+		Gatherer: ctrlmetrics.Registry,
+		Log:      ctrl.Log.WithName("diagnostics"),
 	}
-	if err = (&controllers.SamenessGroupController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.SamenessGroup),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.SamenessGroup)
+	if err = mgr.Add(reconcileTracker); err != nil {
+		setupLog.Error(err, "unable to add the reconcile tracker")
 		return 1
 	}
-	if err = (&controllers.JWTProviderController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.JWTProvider),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.JWTProvider)
-		return 1
+	diagnosticsChecker := &diagnostics.Checker{
+		CertDir:             c.flagCertDir,
+		ConsulConfig:        consulConfig,
+		ConsulServerConnMgr: watcher,
+		AuthMethod:          c.consul.ConsulLogin.AuthMethod,
+		Reconciles:          reconcileTracker,
 	}
-	if err = (&controllers.ControlPlaneRequestLimitController{
-		ConfigEntryController: configEntryReconciler,
-		Client:                mgr.GetClient(),
-		Log:                   ctrl.Log.WithName("controller").WithName(apicommon.ControlPlaneRequestLimit),
-		Scheme:                mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", apicommon.ControlPlaneRequestLimit)
-		return 1
+	for name, check := range map[string]healthz.Checker{
+		"webhook-cert": diagnosticsChecker.CertCheck,
+		"consul":       diagnosticsChecker.ConsulCheck,
+		"acl-login":    diagnosticsChecker.ACLLoginCheck,
+	} {
+		if err = mgr.AddHealthzCheck(name, check); err != nil {
+			setupLog.Error(err, "unable to create health check", "check", name)
+			return 1
+		}
 	}
-
-	if err = mgr.AddReadyzCheck("ready", webhook.ReadinessCheck{CertDir: c.flagCertDir}.Ready); err != nil {
-		setupLog.Error(err, "unable to create readiness check", "controller", endpoints.Controller{})
+	if err = mgr.AddMetricsExtraHandler("/diagz", diagnosticsChecker); err != nil {
+		setupLog.Error(err, "unable to add the diagnostics handler")
 		return 1
 	}
 
 	if c.flagEnablePeering {
-		if err = (&peering.AcceptorController{
-			Client:                   mgr.GetClient(),
-			ConsulClientConfig:       consulConfig,
-			ConsulServerConnMgr:      watcher,
-			ExposeServersServiceName: c.flagResourcePrefix + "-expose-servers",
-			ReleaseNamespace:         c.flagReleaseNamespace,
-			Log:                      ctrl.Log.WithName("controller").WithName("peering-acceptor"),
-			Scheme:                   mgr.GetScheme(),
-			Context:                  ctx,
-		}).SetupWithManager(mgr); err != nil {
-			setupLog.Error(err, "unable to create controller", "controller", "peering-acceptor")
-			return 1
-		}
-		if err = (&peering.PeeringDialerController{
-			Client:              mgr.GetClient(),
-			ConsulClientConfig:  consulConfig,
-			ConsulServerConnMgr: watcher,
-			Log:                 ctrl.Log.WithName("controller").WithName("peering-dialer"),
-			Scheme:              mgr.GetScheme(),
-			Context:             ctx,
-		}).SetupWithManager(mgr); err != nil {
-			setupLog.Error(err, "unable to create controller", "controller", "peering-dialer")
-			return 1
+		if c.flagShardIndex == 0 {
+			if err = (&peering.AcceptorController{
+				Client:                   mgr.GetClient(),
+				ConsulClientConfig:       consulConfig,
+				ConsulServerConnMgr:      watcher,
+				ExposeServersServiceName: c.flagResourcePrefix + "-expose-servers",
+				ReleaseNamespace:         c.flagReleaseNamespace,
+				ControllerOptions:        c.controllerOptions(c.flagPeeringMaxConcurrentReconciles),
+				Auditor:                  auditor,
+				Log:                      ctrl.Log.WithName("controller").WithName("peering-acceptor"),
+				Scheme:                   mgr.GetScheme(),
+				Context:                  ctx,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "peering-acceptor")
+				return 1
+			}
+			if err = (&peering.PeeringDialerController{
+				Client:              mgr.GetClient(),
+				ConsulClientConfig:  consulConfig,
+				ConsulServerConnMgr: watcher,
+				ControllerOptions:   c.controllerOptions(c.flagPeeringMaxConcurrentReconciles),
+				Auditor:             auditor,
+				Log:                 ctrl.Log.WithName("controller").WithName("peering-dialer"),
+				Scheme:              mgr.GetScheme(),
+				Context:             ctx,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "peering-dialer")
+				return 1
+			}
+			if c.flagPeerThroughMeshGateways {
+				if err = mgr.Add(&peering.MeshConfigController{
+					ConsulClientConfig:     consulConfig,
+					ConsulServerConnMgr:    watcher,
+					MeshGatewayServiceName: c.flagMeshGatewayConsulServiceName,
+					Interval:               meshConfigSyncInterval,
+					Auditor:                auditor,
+					Log:                    ctrl.Log.WithName("controller").WithName("peering-mesh-config"),
+				}); err != nil {
+					setupLog.Error(err, "unable to create controller", "controller", "peering-mesh-config")
+					return 1
+				}
+			}
 		}
 
 		mgr.GetWebhookServer().Register("/mutate-v1alpha1-peeringacceptors",
@@ -725,58 +1340,100 @@ func (c *Command) Run(args []string) int {
 			}})
 	}
 
+	if c.flagEnableSpireConnectCA && c.flagShardIndex == 0 {
+		if err = mgr.Add(&connectca.SpireCAController{
+			ConsulClientConfig:  consulConfig,
+			ConsulServerConnMgr: watcher,
+			Spire: &spire.Client{
+				AgentSocketPath: c.flagSpireAgentSocketPath,
+				ServerAddress:   c.flagSpireServerAddress,
+			},
+			CATTL:    c.flagSpireCATTL,
+			Interval: spireCASyncInterval,
+			Log:      ctrl.Log.WithName("controller").WithName("spire-connect-ca"),
+			Auditor:  auditor,
+		}); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "spire-connect-ca")
+			return 1
+		}
+	}
+
 	mgr.GetWebhookServer().CertDir = c.flagCertDir
+	mgr.GetWebhookServer().TLSOpts = append(mgr.GetWebhookServer().TLSOpts, c.tlsListener.ConfigureTLS)
 
 	mgr.GetWebhookServer().Register("/mutate",
 		&ctrlRuntimeWebhook.Admission{Handler: &webhook.MeshWebhook{
-			Clientset:                    c.clientset,
-			ReleaseNamespace:             c.flagReleaseNamespace,
-			ConsulConfig:                 consulConfig,
-			ConsulServerConnMgr:          watcher,
-			ImageConsul:                  c.flagConsulImage,
-			ImageConsulDataplane:         c.flagConsulDataplaneImage,
-			EnvoyExtraArgs:               c.flagEnvoyExtraArgs,
-			ImageConsulK8S:               c.flagConsulK8sImage,
-			RequireAnnotation:            !c.flagDefaultInject,
-			AuthMethod:                   c.flagACLAuthMethod,
-			ConsulCACert:                 string(caCertPem),
-			TLSEnabled:                   c.consul.UseTLS,
-			ConsulAddress:                c.consul.Addresses,
-			SkipServerWatch:              c.consul.SkipServerWatch,
-			ConsulTLSServerName:          c.consul.TLSServerName,
-			DefaultProxyCPURequest:       sidecarProxyCPURequest,
-			DefaultProxyCPULimit:         sidecarProxyCPULimit,
-			DefaultProxyMemoryRequest:    sidecarProxyMemoryRequest,
-			DefaultProxyMemoryLimit:      sidecarProxyMemoryLimit,
-			DefaultEnvoyProxyConcurrency: c.flagDefaultEnvoyProxyConcurrency,
-			LifecycleConfig:              lifecycleConfig,
-			MetricsConfig:                metricsConfig,
-			InitContainerResources:       initResources,
-			ConsulPartition:              c.consul.Partition,
-			AllowK8sNamespacesSet:        allowK8sNamespaces,
-			DenyK8sNamespacesSet:         denyK8sNamespaces,
-			EnableNamespaces:             c.flagEnableNamespaces,
-			ConsulDestinationNamespace:   c.flagConsulDestinationNamespace,
-			EnableK8SNSMirroring:         c.flagEnableK8SNSMirroring,
-			K8SNSMirroringPrefix:         c.flagK8SNSMirroringPrefix,
-			CrossNamespaceACLPolicy:      c.flagCrossNamespaceACLPolicy,
-			EnableTransparentProxy:       c.flagDefaultEnableTransparentProxy,
-			EnableCNI:                    c.flagEnableCNI,
-			TProxyOverwriteProbes:        c.flagTransparentProxyDefaultOverwriteProbes,
-			EnableConsulDNS:              c.flagEnableConsulDNS,
-			EnableOpenShift:              c.flagEnableOpenShift,
-			Log:                          ctrl.Log.WithName("handler").WithName("connect"),
-			LogLevel:                     c.flagLogLevel,
-			LogJSON:                      c.flagLogJSON,
+			Clientset:                            c.clientset,
+			Client:                               mgr.GetClient(),
+			ReleaseNamespace:                     c.flagReleaseNamespace,
+			ClusterName:                          c.flagClusterName,
+			DryRun:                               c.flagDryRun,
+			ConsulConfig:                         consulConfig,
+			ConsulServerConnMgr:                  watcher,
+			ImageConsul:                          c.flagConsulImage,
+			ImageConsulDataplane:                 c.flagConsulDataplaneImage,
+			EnvoyExtraArgs:                       c.flagEnvoyExtraArgs,
+			ImageConsulK8S:                       c.flagConsulK8sImage,
+			ImageConsulDataplaneWindows:          c.flagConsulDataplaneImageWindows,
+			ImageConsulK8SWindows:                c.flagConsulK8sImageWindows,
+			RequireAnnotation:                    !c.flagDefaultInject,
+			AuthMethod:                           c.flagACLAuthMethod,
+			EnableProjectedServiceAccountToken:   c.flagEnableProjectedServiceAccountToken,
+			ServiceAccountTokenAudience:          c.flagServiceAccountTokenAudience,
+			ServiceAccountTokenExpirationSeconds: int64(c.flagServiceAccountTokenExpiration.Seconds()),
+			ConsulCACert:                         string(caCertPem),
+			TLSEnabled:                           c.consul.UseTLS,
+			ConsulAddress:                        c.consul.Addresses,
+			SkipServerWatch:                      c.consul.SkipServerWatch,
+			ConsulTLSServerName:                  c.consul.TLSServerName,
+			DefaultProxyCPURequest:               sidecarProxyCPURequest,
+			DefaultProxyCPULimit:                 sidecarProxyCPULimit,
+			DefaultProxyMemoryRequest:            sidecarProxyMemoryRequest,
+			DefaultProxyMemoryLimit:              sidecarProxyMemoryLimit,
+			DefaultEnvoyProxyConcurrency:         c.flagDefaultEnvoyProxyConcurrency,
+			LifecycleConfig:                      lifecycleConfig,
+			MetricsConfig:                        metricsConfig,
+			AccessLogsConfig:                     accessLogsConfig,
+			TracingConfig:                        tracingConfig,
+			DogStatsDConfig:                      dogStatsDConfig,
+			InitContainerResources:               initResources,
+			ConsulPartition:                      c.consul.Partition,
+			AllowK8sNamespacesSet:                allowK8sNamespaces,
+			DenyK8sNamespacesSet:                 denyK8sNamespaces,
+			EnableNamespaces:                     c.flagEnableNamespaces,
+			ConsulDestinationNamespace:           c.flagConsulDestinationNamespace,
+			EnableK8SNSMirroring:                 c.flagEnableK8SNSMirroring,
+			K8SNSMirroringPrefix:                 c.flagK8SNSMirroringPrefix,
+			CrossNamespaceACLPolicy:              c.flagCrossNamespaceACLPolicy,
+			Auditor:                              auditor,
+			EnableTransparentProxy:               c.flagDefaultEnableTransparentProxy,
+			EnableCNI:                            c.flagEnableCNI,
+			CNIRequireRedirection:                c.flagCNIRequireRedirection,
+			TProxyOverwriteProbes:                c.flagTransparentProxyDefaultOverwriteProbes,
+			TProxyEnableIPv6:                     c.flagTransparentProxyDefaultEnableIPv6,
+			EnableConsulDNS:                      c.flagEnableConsulDNS,
+			EnableOpenShift:                      c.flagEnableOpenShift,
+			Log:                                  ctrl.Log.WithName("handler").WithName("connect"),
+			LogLevel:                             c.flagLogLevel,
+			LogJSON:                              c.flagLogJSON,
 		}})
 
 	consulMeta := apicommon.ConsulMeta{
-		PartitionsEnabled:    c.flagEnablePartitions,
-		Partition:            c.consul.Partition,
-		NamespacesEnabled:    c.flagEnableNamespaces,
-		DestinationNamespace: c.flagConsulDestinationNamespace,
-		Mirroring:            c.flagEnableK8SNSMirroring,
-		Prefix:               c.flagK8SNSMirroringPrefix,
+		PartitionsEnabled:     c.flagEnablePartitions,
+		ConfigEntryPartitions: c.flagConfigEntryPartitions,
+		Partition:             c.consul.Partition,
+		NamespacesEnabled:     c.flagEnableNamespaces,
+		DestinationNamespace:  c.flagConsulDestinationNamespace,
+		Mirroring:             c.flagEnableK8SNSMirroring,
+		Prefix:                c.flagK8SNSMirroringPrefix,
+	}
+	if c.flagEnableConfigEntryConsulValidation {
+		consulMeta.ConsulValidator = &controllers.ConsulValidator{
+			Client:              mgr.GetClient(),
+			ConsulClientConfig:  consulConfig,
+			ConsulServerConnMgr: watcher,
+			Log:                 ctrl.Log.WithName("webhooks").WithName("consul-validator"),
+		}
 	}
 
 	// Note: The path here should be identical to the one on the kubebuilder
@@ -859,6 +1516,16 @@ func (c *Command) Run(args []string) int {
 			Logger:     ctrl.Log.WithName("webhooks").WithName(apicommon.ControlPlaneRequestLimit),
 			ConsulMeta: consulMeta,
 		}})
+	mgr.GetWebhookServer().Register("/mutate-v1alpha1-registrations",
+		&ctrlRuntimeWebhook.Admission{Handler: &v1alpha1.RegistrationWebhook{
+			Client: mgr.GetClient(),
+			Logger: ctrl.Log.WithName("webhooks").WithName("registration"),
+		}})
+	mgr.GetWebhookServer().Register("/mutate-v1alpha1-meshinjectionconfigs",
+		&ctrlRuntimeWebhook.Admission{Handler: &v1alpha1.MeshInjectionConfigWebhook{
+			Client: mgr.GetClient(),
+			Logger: ctrl.Log.WithName("webhooks").WithName("mesh-injection-config"),
+		}})
 
 	if c.flagEnableWebhookCAUpdate {
 		err = c.updateWebhookCABundle(ctx)
@@ -868,6 +1535,27 @@ func (c *Command) Run(args []string) int {
 		}
 	}
 
+	if secretWatcher != nil {
+		if c.flagCertDir != "" {
+			if c.flagEnableWebhookCAUpdate {
+				err = secretWatcher.Watch(filepath.Join(c.flagCertDir, WebhookCAFilename), func([]byte) error {
+					return c.updateWebhookCABundle(ctx)
+				})
+				if err != nil {
+					setupLog.Error(err, "unable to watch the webhook CA cert")
+					return 1
+				}
+			}
+			// The webhook server reloads its certificate by itself. It is
+			// only watched to report it if it isn't renewed.
+			if err = secretWatcher.Watch(filepath.Join(c.flagCertDir, "tls.crt"), nil); err != nil {
+				setupLog.Error(err, "unable to watch the webhook cert")
+				return 1
+			}
+		}
+		go secretWatcher.Run(ctx)
+	}
+
 	if err = mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		return 1
@@ -909,13 +1597,158 @@ func (c *Command) validateFlags() error {
 		return errors.New("-enable-partitions must be set to 'true' if -partition is set")
 	}
 
+	if len(c.flagConfigEntryPartitions) > 0 && c.consul.Partition != apicommon.DefaultConsulPartition {
+		return errors.New("-config-entry-partition can only be set if -partition is 'default'")
+	}
+
 	if c.flagDefaultEnvoyProxyConcurrency < 0 {
 		return errors.New("-default-envoy-proxy-concurrency must be >= 0 if set")
 	}
 
+	switch c.flagDefaultEnvoyAccessLogsType {
+	case "stdout", "stderr":
+	case "file":
+		if c.flagDefaultEnvoyAccessLogsPath == "" {
+			return errors.New("-default-envoy-access-logs-path must be set if -default-envoy-access-logs-type is 'file'")
+		}
+	default:
+		return fmt.Errorf("-default-envoy-access-logs-type must be one of 'stdout', 'stderr' or 'file', got %q", c.flagDefaultEnvoyAccessLogsType)
+	}
+
+	if c.flagDefaultEnvoyAccessLogsJSONFormat != "" && c.flagDefaultEnvoyAccessLogsTextFormat != "" {
+		return errors.New("-default-envoy-access-logs-json-format and -default-envoy-access-logs-text-format cannot both be set")
+	}
+
+	if c.flagDefaultEnableEnvoyTracing && c.flagDefaultEnvoyTracingCollectorAddress == "" {
+		return errors.New("-default-envoy-tracing-collector-address must be set if -default-enable-envoy-tracing is 'true'")
+	}
+
+	if !filepath.IsAbs(c.flagEnvoyDogStatsDSocketPath) {
+		return fmt.Errorf("-envoy-dogstatsd-socket-path must be an absolute path, got %q", c.flagEnvoyDogStatsDSocketPath)
+	}
+	if c.flagEnvoyDogStatsDHostPort < 0 || c.flagEnvoyDogStatsDHostPort > 65535 {
+		return fmt.Errorf("-envoy-dogstatsd-host-port must be between 0 and 65535, got %d", c.flagEnvoyDogStatsDHostPort)
+	}
+	for _, tag := range c.flagDefaultEnvoyDogStatsDTags {
+		if key, value, ok := strings.Cut(tag, ":"); !ok || key == "" || value == "" {
+			return fmt.Errorf("-default-envoy-dogstatsd-tag must be in the form key:value, got %q", tag)
+		}
+	}
+
+	if c.flagEndpointsMaxConcurrentReconciles < 1 {
+		return errors.New("-endpoints-controller-max-concurrent-reconciles must be >= 1")
+	}
+	if c.flagEndpointSliceResyncInterval < 0 {
+		return errors.New("-endpoint-slice-resync-interval must be >= 0")
+	}
+	if c.flagEndpointsCatalogCacheTTL < 0 {
+		return errors.New("-endpoints-catalog-cache-ttl must be >= 0")
+	}
+	if c.flagPeeringMaxConcurrentReconciles < 1 {
+		return errors.New("-peering-controller-max-concurrent-reconciles must be >= 1")
+	}
+	if c.flagPeerThroughMeshGateways && !c.flagEnablePeering {
+		return errors.New("-peer-through-mesh-gateways requires -enable-peering")
+	}
+	if c.flagPeerThroughMeshGateways && c.consul.Partition != "" && c.consul.Partition != apicommon.DefaultConsulPartition {
+		return errors.New("-peer-through-mesh-gateways can only be set if -partition is 'default'")
+	}
+	if c.flagEnableSpireConnectCA && c.flagSpireServerAddress == "" {
+		return errors.New("-spire-server-address must be set if -enable-spire-connect-ca is set")
+	}
+	if c.flagEnableSpireConnectCA && c.consul.Partition != "" && c.consul.Partition != apicommon.DefaultConsulPartition {
+		return errors.New("-enable-spire-connect-ca can only be set if -partition is 'default'")
+	}
+	if c.flagSpireCATTL < 0 {
+		return errors.New("-spire-ca-ttl must be >= 0")
+	}
+	if c.flagConfigEntryResyncInterval < 0 {
+		return errors.New("-config-entry-resync-interval must be >= 0")
+	}
+	if c.flagACLTokenRotationInterval < 0 {
+		return errors.New("-acl-token-rotation-interval must be >= 0")
+	}
+	if c.flagACLTokenCleanupInterval < 0 {
+		return errors.New("-acl-token-cleanup-interval must be >= 0")
+	}
+	if c.flagSnapshotAgentConfigSecretName != "" && c.flagSnapshotAgentPodSelector == "" {
+		return errors.New("-snapshot-agent-pod-selector must be set if -snapshot-agent-config-secret-name is set")
+	}
+	if c.flagAuditLogWebhookURL != "" && !c.flagEnableAuditLog {
+		return errors.New("-enable-audit-log must be set if -audit-log-webhook-url is set")
+	}
+	if c.flagAuditLogWebhookURL != "" {
+		if u, err := url.Parse(c.flagAuditLogWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("-audit-log-webhook-url must be an http or https URL, got %q", c.flagAuditLogWebhookURL)
+		}
+	}
+	if c.flagServiceAccountTokenExpiration != 0 && c.flagServiceAccountTokenExpiration < 10*time.Minute {
+		return errors.New("-service-account-token-expiration must be 0 or at least 10m")
+	}
+	if c.flagReconcileBaseDelay <= 0 || c.flagReconcileMaxDelay < c.flagReconcileBaseDelay {
+		return errors.New("-reconcile-base-delay must be > 0 and not greater than -reconcile-max-delay")
+	}
+	if c.flagReconcileQPS <= 0 || c.flagReconcileBurst < 1 {
+		return errors.New("-reconcile-qps must be > 0 and -reconcile-burst must be >= 1")
+	}
+	if c.flagKubeAPIQPS <= 0 || c.flagKubeAPIBurst < 1 {
+		return errors.New("-kube-api-qps must be > 0 and -kube-api-burst must be >= 1")
+	}
+	if c.flagConsulAPIQPS < 0 || (c.flagConsulAPIQPS > 0 && c.flagConsulAPIBurst < 1) {
+		return errors.New("-consul-api-qps must be >= 0 and -consul-api-burst must be >= 1 if it is set")
+	}
+	if c.flagConsulAPIMaxRetries < 0 {
+		return errors.New("-consul-api-max-retries must be >= 0")
+	}
+	if c.flagConsulAPIMaxRetries > 0 && (c.flagConsulAPIRetryBackoff <= 0 || c.flagConsulAPIRetryBudget <= 0) {
+		return errors.New("-consul-api-retry-backoff and -consul-api-retry-budget must be > 0 if -consul-api-max-retries is set")
+	}
+	if c.flagConsulAPICircuitBreakerThreshold < 0 {
+		return errors.New("-consul-api-circuit-breaker-threshold must be >= 0")
+	}
+	if c.flagConsulAPICircuitBreakerThreshold > 0 && c.flagConsulAPICircuitBreakerOpen <= 0 {
+		return errors.New("-consul-api-circuit-breaker-open-duration must be > 0 if -consul-api-circuit-breaker-threshold is set")
+	}
+
+	if c.flagShardCount < 1 {
+		return errors.New("-shard-count must be >= 1")
+	}
+	if c.flagShardIndex < 0 || c.flagShardIndex >= c.flagShardCount {
+		return fmt.Errorf("-shard-index must be between 0 and %d", c.flagShardCount-1)
+	}
+
+	if c.flagClusterName != "" {
+		if err := common.ValidateClusterName("-cluster-name", c.flagClusterName); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range c.flagLabelsToMeta {
+		if err := endpoints.ValidateMetaKey(key); err != nil {
+			return fmt.Errorf("-label-to-meta: %w", err)
+		}
+	}
+
+	if err := c.tlsListener.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// controllerOptions returns the options for a controller that reconciles up to maxConcurrentReconciles
+// items at once. Each controller gets its own rate limiter so that failures in one controller do not
+// delay another.
+func (c *Command) controllerOptions(maxConcurrentReconciles int) controller.Options {
+	return controller.Options{
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		RateLimiter: workqueue.NewMaxOfRateLimiter(
+			workqueue.NewItemExponentialFailureRateLimiter(c.flagReconcileBaseDelay, c.flagReconcileMaxDelay),
+			&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(c.flagReconcileQPS), c.flagReconcileBurst)},
+		),
+	}
+}
+
 func (c *Command) parseAndValidateResourceFlags() (corev1.ResourceRequirements, error) {
 	// Init container
 	var initContainerCPULimit, initContainerCPURequest, initContainerMemoryLimit, initContainerMemoryRequest resource.Quantity
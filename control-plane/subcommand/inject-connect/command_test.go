@@ -5,6 +5,7 @@ package connectinject
 
 import (
 	"testing"
+	"time"
 
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/require"
@@ -48,6 +49,11 @@ func TestRun_FlagValidation(t *testing.T) {
 				"-partition", "default"},
 			expErr: "-enable-partitions must be set to 'true' if -partition is set",
 		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-enable-partitions", "-partition", "foo", "-config-entry-partition", "ap1"},
+			expErr: "-config-entry-partition can only be set if -partition is 'default'",
+		},
 		{
 			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
 				"-default-sidecar-proxy-cpu-limit=unparseable"},
@@ -132,6 +138,216 @@ func TestRun_FlagValidation(t *testing.T) {
 			},
 			expErr: "-default-envoy-proxy-concurrency must be >= 0 if set",
 		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-default-envoy-access-logs-type=syslog",
+			},
+			expErr: "-default-envoy-access-logs-type must be one of 'stdout', 'stderr' or 'file', got \"syslog\"",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-default-envoy-access-logs-type=file",
+			},
+			expErr: "-default-envoy-access-logs-path must be set if -default-envoy-access-logs-type is 'file'",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-default-enable-envoy-tracing",
+			},
+			expErr: "-default-envoy-tracing-collector-address must be set if -default-enable-envoy-tracing is 'true'",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-envoy-dogstatsd-socket-path", "dsd.socket",
+			},
+			expErr: `-envoy-dogstatsd-socket-path must be an absolute path, got "dsd.socket"`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-envoy-dogstatsd-host-port", "70000",
+			},
+			expErr: "-envoy-dogstatsd-host-port must be between 0 and 65535, got 70000",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-default-envoy-dogstatsd-tag", "env:prod", "-default-envoy-dogstatsd-tag", "team",
+			},
+			expErr: `-default-envoy-dogstatsd-tag must be in the form key:value, got "team"`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-shard-count=0",
+			},
+			expErr: "-shard-count must be >= 1",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-shard-count=3", "-shard-index=3",
+			},
+			expErr: "-shard-index must be between 0 and 2",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-endpoints-controller-max-concurrent-reconciles=0",
+			},
+			expErr: "-endpoints-controller-max-concurrent-reconciles must be >= 1",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-endpoints-catalog-cache-ttl=-1s",
+			},
+			expErr: "-endpoints-catalog-cache-ttl must be >= 0",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-peering-controller-max-concurrent-reconciles=0",
+			},
+			expErr: "-peering-controller-max-concurrent-reconciles must be >= 1",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-endpoint-slice-resync-interval=-1s",
+			},
+			expErr: "-endpoint-slice-resync-interval must be >= 0",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-config-entry-resync-interval=-1s",
+			},
+			expErr: "-config-entry-resync-interval must be >= 0",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-acl-token-rotation-interval=-1s",
+			},
+			expErr: "-acl-token-rotation-interval must be >= 0",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-acl-token-cleanup-interval=-1s",
+			},
+			expErr: "-acl-token-cleanup-interval must be >= 0",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-snapshot-agent-config-secret-name=consul-snapshot-agent-schedule",
+			},
+			expErr: "-snapshot-agent-pod-selector must be set if -snapshot-agent-config-secret-name is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-peer-through-mesh-gateways",
+			},
+			expErr: "-peer-through-mesh-gateways requires -enable-peering",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-enable-peering", "-peer-through-mesh-gateways", "-enable-partitions", "-partition", "foo",
+			},
+			expErr: "-peer-through-mesh-gateways can only be set if -partition is 'default'",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-enable-spire-connect-ca",
+			},
+			expErr: "-spire-server-address must be set if -enable-spire-connect-ca is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-enable-spire-connect-ca", "-spire-server-address", "spire-server.spire:8081", "-enable-partitions", "-partition", "foo",
+			},
+			expErr: "-enable-spire-connect-ca can only be set if -partition is 'default'",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-spire-ca-ttl=-1h",
+			},
+			expErr: "-spire-ca-ttl must be >= 0",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-audit-log-webhook-url=https://audit.example.com",
+			},
+			expErr: "-enable-audit-log must be set if -audit-log-webhook-url is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-enable-audit-log", "-audit-log-webhook-url=audit.example.com",
+			},
+			expErr: `-audit-log-webhook-url must be an http or https URL, got "audit.example.com"`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-service-account-token-expiration=5m",
+			},
+			expErr: "-service-account-token-expiration must be 0 or at least 10m",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-reconcile-base-delay=10s", "-reconcile-max-delay=1s",
+			},
+			expErr: "-reconcile-base-delay must be > 0 and not greater than -reconcile-max-delay",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-reconcile-qps=0",
+			},
+			expErr: "-reconcile-qps must be > 0 and -reconcile-burst must be >= 1",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-kube-api-qps=0",
+			},
+			expErr: "-kube-api-qps must be > 0 and -kube-api-burst must be >= 1",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-consul-api-qps=10", "-consul-api-burst=0",
+			},
+			expErr: "-consul-api-qps must be >= 0 and -consul-api-burst must be >= 1 if it is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-consul-api-max-retries=-1",
+			},
+			expErr: "-consul-api-max-retries must be >= 0",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-consul-api-max-retries=3", "-consul-api-retry-budget=0",
+			},
+			expErr: "-consul-api-retry-backoff and -consul-api-retry-budget must be > 0 if -consul-api-max-retries is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-consul-api-circuit-breaker-threshold=5", "-consul-api-circuit-breaker-open-duration=0s",
+			},
+			expErr: "-consul-api-circuit-breaker-open-duration must be > 0 if -consul-api-circuit-breaker-threshold is set",
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-tls-min-version=TLSv1_0",
+			},
+			expErr: `-tls-min-version must be "TLSv1_2" or "TLSv1_3"`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-tls-min-version=TLSv1_3", "-tls-cipher-suite=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			},
+			expErr: `-tls-cipher-suite can't be set if -tls-min-version is "TLSv1_3"`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-label-to-meta=app.kubernetes.io/version=app.kubernetes.io/version",
+			},
+			expErr: `-label-to-meta: "app.kubernetes.io/version" is not a valid service metadata key`,
+		},
+		{
+			flags: []string{"-consul-k8s-image", "foo", "-consul-image", "foo", "-consul-dataplane-image", "consul-dataplane:1.14.0",
+				"-cluster-name=East",
+			},
+			expErr: `-cluster-name value of "East" must be at most 63 characters long`,
+		},
 	}
 
 	for _, c := range cases {
@@ -159,3 +375,20 @@ func TestRun_ResourceLimitDefaults(t *testing.T) {
 	require.Equal(t, cmd.flagInitContainerMemoryRequest, "25Mi")
 	require.Equal(t, cmd.flagInitContainerMemoryLimit, "150Mi")
 }
+
+func TestRun_ControllerOptions(t *testing.T) {
+	cmd := Command{}
+	cmd.init()
+	require.NoError(t, cmd.flagSet.Parse([]string{
+		"-endpoints-controller-max-concurrent-reconciles=4",
+		"-reconcile-base-delay=1s",
+		"-reconcile-max-delay=10s",
+	}))
+
+	opts := cmd.controllerOptions(cmd.flagEndpointsMaxConcurrentReconciles)
+	require.Equal(t, 4, opts.MaxConcurrentReconciles)
+	require.Equal(t, time.Second, opts.RateLimiter.When("item"))
+	require.Equal(t, 2*time.Second, opts.RateLimiter.When("item"))
+	opts.RateLimiter.Forget("item")
+	require.Equal(t, time.Second, opts.RateLimiter.When("item"))
+}
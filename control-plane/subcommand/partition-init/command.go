@@ -135,7 +135,7 @@ func (c *Command) Run(args []string) int {
 			// Retry Admin Partition creation until it succeeds, or we reach the command timeout.
 			_, _, err = consulClient.Partitions().Create(c.ctx, &api.Partition{
 				Name:        c.consul.Partition,
-				Description: "Created by Helm installation",
+				Description: common.PartitionDescription,
 			}, nil)
 			if err == nil {
 				c.log.Info("Successfully created Admin Partition", "name", c.consul.Partition)
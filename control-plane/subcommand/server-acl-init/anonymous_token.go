@@ -4,12 +4,25 @@
 package serveraclinit
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/consul/api"
 )
 
+const (
+	// anonymousTokenID is the accessor ID of the anonymous token.
+	anonymousTokenID = "00000000-0000-0000-0000-000000000002"
+	// anonymousPolicyName is the name of the policy of the anonymous token.
+	anonymousPolicyName = "anonymous-token-policy"
+)
+
 // configureAnonymousPolicy sets up policies and tokens so that Consul DNS and
 // cross-datacenter Consul connect calls will work.
 func (c *Command) configureAnonymousPolicy(consulClient *api.Client) error {
+	if c.flagUseTemplatedPolicies {
+		return c.configureAnonymousTemplatedPolicy(consulClient)
+	}
+
 	anonRules, err := c.anonymousTokenRules()
 	if err != nil {
 		c.log.Error("Error templating anonymous token rules", "err", err)
@@ -18,7 +31,7 @@ func (c *Command) configureAnonymousPolicy(consulClient *api.Client) error {
 
 	// Create policy for the anonymous token
 	anonPolicy := api.ACLPolicy{
-		Name:        "anonymous-token-policy",
+		Name:        anonymousPolicyName,
 		Description: "Anonymous token Policy",
 		Rules:       anonRules,
 	}
@@ -33,7 +46,7 @@ func (c *Command) configureAnonymousPolicy(consulClient *api.Client) error {
 
 	// Create token to get sent to TokenUpdate
 	aToken := api.ACLToken{
-		AccessorID: "00000000-0000-0000-0000-000000000002",
+		AccessorID: anonymousTokenID,
 		Policies:   []*api.ACLTokenPolicyLink{{Name: anonPolicy.Name}},
 	}
 
@@ -44,3 +57,39 @@ func (c *Command) configureAnonymousPolicy(consulClient *api.Client) error {
 			return err
 		})
 }
+
+// configureAnonymousTemplatedPolicy links the anonymous token to the
+// builtin/dns templated policy instead of the anonymous token policy. The
+// token is updated in place: the anonymous token policy is replaced and then
+// deleted, and the other links of the token are kept.
+func (c *Command) configureAnonymousTemplatedPolicy(consulClient *api.Client) error {
+	if err := c.requireTemplatedPolicies(consulClient); err != nil {
+		return err
+	}
+
+	err := c.untilSucceeds("updating anonymous token with templated policy",
+		func() error {
+			token, _, err := consulClient.ACL().TokenRead(anonymousTokenID, &api.QueryOptions{})
+			if err != nil {
+				return err
+			}
+			var policies []*api.ACLTokenPolicyLink
+			for _, p := range token.Policies {
+				if p.Name != anonymousPolicyName {
+					policies = append(policies, p)
+				}
+			}
+			token.Policies = policies
+			token.TemplatedPolicies = withTemplatedPolicy(token.TemplatedPolicies, &api.ACLTemplatedPolicy{TemplateName: api.ACLTemplatedPolicyDNSName})
+			_, _, err = consulClient.ACL().TokenUpdate(token, &api.WriteOptions{})
+			return err
+		})
+	if err != nil {
+		return err
+	}
+
+	return c.untilSucceeds(fmt.Sprintf("deleting policy %s", anonymousPolicyName),
+		func() error {
+			return c.deleteACLPolicy(anonymousPolicyName, consulClient)
+		})
+}
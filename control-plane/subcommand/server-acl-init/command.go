@@ -42,15 +42,26 @@ type Command struct {
 
 	flagResourcePrefix string
 	flagK8sNamespace   string
+	flagClusterName    string
 
 	flagAllowDNS bool
 
+	// flagUseTemplatedPolicies links tokens to builtin templated policies
+	// instead of creating policies for them, where a templated policy grants
+	// the permissions they need.
+	flagUseTemplatedPolicies bool
+	// templatedPoliciesChecked and templatedPoliciesSupported cache whether
+	// the Consul servers support templated policies.
+	templatedPoliciesChecked   bool
+	templatedPoliciesSupported bool
+
 	flagSetServerTokens bool
 
 	flagClient bool
 
 	flagSyncCatalog        bool
 	flagSyncConsulNodeName string
+	flagSyncNodeTopology   bool
 
 	flagConnectInject       bool
 	flagAuthMethodHost      string
@@ -66,6 +77,17 @@ type Command struct {
 
 	flagAPIGatewayController bool
 
+	// Flags to configure a JWT auth method for operators and CI systems.
+	flagJWTAuthMethod        bool
+	flagJWTOIDCDiscoveryURL  string
+	flagJWTJWKSURL           string
+	flagJWTBoundIssuer       string
+	flagJWTBoundAudiences    []string
+	flagJWTClaimMappings     map[string]string
+	flagJWTListClaimMappings map[string]string
+	flagJWTMaxTokenTTL       time.Duration
+	flagJWTBindingRules      []string
+
 	// Flags to configure Consul connection.
 	flagServerPort uint
 
@@ -74,11 +96,24 @@ type Command struct {
 	flagACLReplicationTokenFile   string
 
 	// Flags to support partitions.
-	flagPartitionTokenFile string
+	flagPartitionTokenFile         string
+	flagDefaultPartitionAuthMethod string
 
 	// Flags to support peering.
 	flagEnablePeering bool // true if Cluster Peering is enabled
 
+	// flagConfigEntryPartitions are the partitions that the connect injector in
+	// the default partition manages config entries in.
+	flagConfigEntryPartitions []string
+
+	// flagEnableSpireConnectCA allows the connect injector to configure the
+	// Connect CA with a CA from SPIRE.
+	flagEnableSpireConnectCA bool
+
+	// flagEnableServerUpgradeController allows the connect injector to
+	// transfer raft leadership while it rolls out the Consul servers.
+	flagEnableServerUpgradeController bool
+
 	// Flags to support namespaces.
 	flagEnableNamespaces                 bool   // Use namespacing on all components
 	flagConsulSyncDestinationNamespace   string // Consul namespace to register all catalog sync services into if not mirroring
@@ -125,6 +160,10 @@ func (c *Command) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.StringVar(&c.flagResourcePrefix, "resource-prefix", "",
 		"Prefix to use for Kubernetes resources.")
+	c.flags.StringVar(&c.flagClusterName, "cluster-name", "",
+		"Name that distinguishes this Kubernetes cluster from the other Kubernetes clusters that are registered with "+
+			"the same Consul datacenter. It is added to the names of the auth methods, ACL roles and policies that are "+
+			"created, so that the clusters don't overwrite each other's.")
 	c.flags.StringVar(&c.flagK8sNamespace, "k8s-namespace", "",
 		"Name of Kubernetes namespace where Consul and consul-k8s components are deployed.")
 
@@ -132,6 +171,10 @@ func (c *Command) init() {
 
 	c.flags.BoolVar(&c.flagAllowDNS, "allow-dns", false,
 		"Toggle for updating the anonymous token to allow DNS queries to work")
+	c.flags.BoolVar(&c.flagUseTemplatedPolicies, "use-templated-policies", false,
+		"Toggle for linking the anonymous token and the ACL roles of gateways, the snapshot agent and the catalog sync to "+
+			"builtin templated policies, instead of creating policies with the rules that they grant. "+
+			"Existing tokens and roles are updated in place. Requires Consul 1.17 or later.")
 	c.flags.BoolVar(&c.flagClient, "client", true,
 		"Toggle for creating a client agent token. Default is true.")
 
@@ -140,6 +183,9 @@ func (c *Command) init() {
 	c.flags.StringVar(&c.flagSyncConsulNodeName, "sync-consul-node-name", "k8s-sync",
 		"The Consul node name to register for catalog sync. Defaults to k8s-sync. To be discoverable "+
 			"via DNS, the name should only contain alpha-numerics and dashes.")
+	c.flags.BoolVar(&c.flagSyncNodeTopology, "sync-node-topology", false,
+		"Toggle for allowing catalog sync to register a Consul node per Kubernetes node, named "+
+			"<sync-consul-node-name>-<Kubernetes node name>, when it syncs the node topology.")
 
 	c.flags.BoolVar(&c.flagConnectInject, "connect-inject", false,
 		"Toggle for configuring ACL login for Connect inject.")
@@ -166,13 +212,49 @@ func (c *Command) init() {
 	c.flags.BoolVar(&c.flagAPIGatewayController, "api-gateway-controller", false,
 		"Toggle for configuring ACL login for the API gateway controller.")
 
+	c.flags.BoolVar(&c.flagJWTAuthMethod, "jwt-auth-method", false,
+		"Toggle for creating a JWT auth method that operators and CI systems can log in with using JWTs "+
+			"from an external identity provider.")
+	c.flags.StringVar(&c.flagJWTOIDCDiscoveryURL, "jwt-auth-method-oidc-discovery-url", "",
+		"OIDC discovery URL of the issuer, used to fetch the keys to validate JWTs with. "+
+			"Exactly one of this or -jwt-auth-method-jwks-url must be set.")
+	c.flags.StringVar(&c.flagJWTJWKSURL, "jwt-auth-method-jwks-url", "",
+		"JWKS URL to fetch the keys to validate JWTs with.")
+	c.flags.StringVar(&c.flagJWTBoundIssuer, "jwt-auth-method-bound-issuer", "",
+		"Value that the iss claim of JWTs must match.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagJWTBoundAudiences), "jwt-auth-method-bound-audience",
+		"Value that the aud claim of JWTs must match. May be specified multiple times.")
+	c.flags.Var((*flags.FlagMapValue)(&c.flagJWTClaimMappings), "jwt-auth-method-claim-mapping",
+		"Mapping of a JWT claim to a binding rule attribute, in the form <claim>=<attribute>. "+
+			"May be specified multiple times.")
+	c.flags.Var((*flags.FlagMapValue)(&c.flagJWTListClaimMappings), "jwt-auth-method-list-claim-mapping",
+		"Mapping of a list JWT claim, e.g. groups, to a binding rule attribute, in the form <claim>=<attribute>. "+
+			"May be specified multiple times.")
+	c.flags.DurationVar(&c.flagJWTMaxTokenTTL, "jwt-auth-method-max-token-ttl", 0,
+		"Maximum lifetime of tokens created by logging in with the JWT auth method. Defaults to the Consul default.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagJWTBindingRules), "jwt-auth-method-binding-rule",
+		"Binding rule mapping JWT claims to a Consul identity, in the form <bind-type>:<bind-name>[:<selector>] "+
+			"where bind-type is one of service, node or role. May be specified multiple times. Binding rules "+
+			"previously created from this flag that are no longer set are deleted.")
+
 	c.flags.UintVar(&c.flagServerPort, "server-port", 8500, "The HTTP or HTTPS port of the Consul server. Defaults to 8500.")
 
 	c.flags.StringVar(&c.flagPartitionTokenFile, "partition-token-file", "",
 		"[Enterprise Only] Path to file containing ACL token to be used in non-default partitions.")
+	c.flags.StringVar(&c.flagDefaultPartitionAuthMethod, "default-partition-auth-method", "",
+		"[Enterprise Only] Name of the auth method in the default partition whose tokens are allowed to read the "+
+			"services of this non-default partition, e.g. the connect inject auth method of the default partition. "+
+			"If not set, no tokens in the default partition are allowed to.")
 
 	c.flags.BoolVar(&c.flagEnablePeering, "enable-peering", false,
 		"Enables Cluster Peering.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagConfigEntryPartitions), "config-entry-partition",
+		"[Enterprise Only] Partition that the connect injector in the default partition manages config entries in. "+
+			"May be specified multiple times.")
+	c.flags.BoolVar(&c.flagEnableSpireConnectCA, "enable-spire-connect-ca", false,
+		"Allows the connect injector to configure the Connect CA with a CA from SPIRE.")
+	c.flags.BoolVar(&c.flagEnableServerUpgradeController, "enable-server-upgrade-controller", false,
+		"Allows the connect injector to transfer raft leadership while it rolls out the Consul servers.")
 
 	c.flags.BoolVar(&c.flagEnableNamespaces, "enable-namespaces", false,
 		"[Enterprise Only] Enables namespaces, in either a single Consul namespace or mirrored [Enterprise only feature]")
@@ -432,9 +514,28 @@ func (c *Command) Run(args []string) int {
 		}
 	}
 
+	if err := c.configurePartitionNamespaces(consulClient); err != nil {
+		c.log.Error(err.Error())
+		return 1
+	}
+
+	if c.isNonDefaultPartition() {
+		defaultPartitionConfig := c.consulFlags.ConsulClientConfig()
+		defaultPartitionConfig.APIClientConfig.Partition = consulDefaultPartition
+		defaultPartitionClient, err := consul.NewClientFromConnMgrState(defaultPartitionConfig, c.state)
+		if err != nil {
+			c.log.Error(err.Error())
+			return 1
+		}
+		if err := c.configurePartitionACLs(consulClient, defaultPartitionClient); err != nil {
+			c.log.Error(err.Error())
+			return 1
+		}
+	}
+
 	// Create the component auth method, this is the auth method that Consul components will use
 	// to issue an `ACL().Login()` against at startup, for local tokens.
-	localComponentAuthMethodName := c.withPrefix("k8s-component-auth-method")
+	localComponentAuthMethodName := c.withACLPrefix("k8s-component-auth-method")
 	err = c.configureLocalComponentAuthMethod(consulClient, localComponentAuthMethodName)
 	if err != nil {
 		c.log.Error(err.Error())
@@ -450,6 +551,14 @@ func (c *Command) Run(args []string) int {
 		}
 	}
 
+	if c.flagJWTAuthMethod {
+		err = c.configureJWTAuthMethod(consulClient, c.withPrefix("jwt-auth-method"))
+		if err != nil {
+			c.log.Error(err.Error())
+			return 1
+		}
+	}
+
 	if c.flagClient {
 		agentRules, err := c.agentRules()
 		if err != nil {
@@ -496,6 +605,7 @@ func (c *Command) Run(args []string) int {
 
 		serviceAccountName := c.withPrefix("sync-catalog")
 		componentAuthMethodName := localComponentAuthMethodName
+		policyScope := localPolicy
 
 		// If namespaces are enabled, the policy and token need to be global to be allowed to create namespaces.
 		if c.flagEnableNamespaces {
@@ -505,9 +615,12 @@ func (c *Command) Run(args []string) int {
 			if !primary {
 				componentAuthMethodName = globalComponentAuthMethodName
 			}
-			err = c.createACLPolicyRoleAndBindingRule("sync-catalog", syncRules, consulDC, primaryDC, globalPolicy, primary, componentAuthMethodName, serviceAccountName, consulClient)
+			policyScope = globalPolicy
+		}
+		if c.flagUseTemplatedPolicies {
+			err = c.createTemplatedPolicyRoleAndBindingRule("sync-catalog", nodeTemplatedPolicy(c.flagSyncConsulNodeName), syncRules, consulDC, primaryDC, policyScope, primary, componentAuthMethodName, serviceAccountName, consulClient)
 		} else {
-			err = c.createACLPolicyRoleAndBindingRule("sync-catalog", syncRules, consulDC, primaryDC, localPolicy, primary, componentAuthMethodName, serviceAccountName, consulClient)
+			err = c.createACLPolicyRoleAndBindingRule("sync-catalog", syncRules, consulDC, primaryDC, policyScope, primary, componentAuthMethodName, serviceAccountName, consulClient)
 		}
 		if err != nil {
 			c.log.Error(err.Error())
@@ -516,12 +629,16 @@ func (c *Command) Run(args []string) int {
 	}
 
 	if c.flagConnectInject {
-		connectAuthMethodName := c.withPrefix("k8s-auth-method")
+		connectAuthMethodName := c.withACLPrefix("k8s-auth-method")
 		err := c.configureConnectInjectAuthMethod(consulClient, connectAuthMethodName)
 		if err != nil {
 			c.log.Error(err.Error())
 			return 1
 		}
+		if err := c.addPartitionReadBindingRule(consulClient, connectAuthMethodName); err != nil {
+			c.log.Error(err.Error())
+			return 1
+		}
 
 		// The endpoints controller needs an ACL token always.
 		injectRules, err := c.injectRules()
@@ -560,8 +677,18 @@ func (c *Command) Run(args []string) int {
 	}
 
 	if c.flagSnapshotAgent {
+		rules, err := c.snapshotAgentRules()
+		if err != nil {
+			c.log.Error("Error templating snapshot agent rules", "err", err)
+			return 1
+		}
 		serviceAccountName := c.withPrefix("server")
-		if err := c.createACLPolicyRoleAndBindingRule("snapshot-agent", snapshotAgentRules, consulDC, primaryDC, localPolicy, primary, localComponentAuthMethodName, serviceAccountName, consulClient); err != nil {
+		if c.flagUseTemplatedPolicies {
+			err = c.createTemplatedPolicyRoleAndBindingRule("snapshot-agent", serviceTemplatedPolicy("consul-snapshot"), rules, consulDC, primaryDC, localPolicy, primary, localComponentAuthMethodName, serviceAccountName, consulClient)
+		} else {
+			err = c.createACLPolicyRoleAndBindingRule("snapshot-agent", rules, consulDC, primaryDC, localPolicy, primary, localComponentAuthMethodName, serviceAccountName, consulClient)
+		}
+		if err != nil {
 			c.log.Error(err.Error())
 			return 1
 		}
@@ -603,7 +730,11 @@ func (c *Command) Run(args []string) int {
 		if !primary {
 			authMethodName = globalComponentAuthMethodName
 		}
-		err = c.createACLPolicyRoleAndBindingRule("mesh-gateway", rules, consulDC, primaryDC, globalPolicy, primary, authMethodName, serviceAccountName, consulClient)
+		if c.flagUseTemplatedPolicies {
+			err = c.createTemplatedPolicyRoleAndBindingRule("mesh-gateway", serviceTemplatedPolicy("mesh-gateway"), rules, consulDC, primaryDC, globalPolicy, primary, authMethodName, serviceAccountName, consulClient)
+		} else {
+			err = c.createACLPolicyRoleAndBindingRule("mesh-gateway", rules, consulDC, primaryDC, globalPolicy, primary, authMethodName, serviceAccountName, consulClient)
+		}
 		if err != nil {
 			c.log.Error(err.Error())
 			return 1
@@ -772,6 +903,20 @@ func (c *Command) configureGateway(gatewayParams ConfigureGatewayParams, consulC
 			return errors.New(errMessage)
 		}
 
+		// The ACL role is in the default namespace, so builtin/service only grants
+		// the gateway access to itself if it's in that namespace too.
+		serviceAccountName := c.withPrefix(name)
+		if c.flagUseTemplatedPolicies && (namespace == "" || namespace == consulDefaultNamespace) {
+			err := c.createTemplatedPolicyRoleAndBindingRule(name, serviceTemplatedPolicy(name), "",
+				gatewayParams.ConsulDC, gatewayParams.PrimaryDC, localPolicy,
+				gatewayParams.Primary, gatewayParams.AuthMethodName, serviceAccountName, consulClient)
+			if err != nil {
+				c.log.Error(err.Error())
+				return err
+			}
+			continue
+		}
+
 		// Define the gateway rules
 		rules, err := gatewayParams.RulesGenerator(name, namespace)
 		if err != nil {
@@ -786,7 +931,6 @@ func (c *Command) configureGateway(gatewayParams ConfigureGatewayParams, consulC
 		// The names in the Helm chart are specified by users and so may not contain
 		// the words "ingress-gateway" or "terminating-gateway". We need to create unique names for tokens
 		// across all gateway types and so must suffix with either `-ingress-gateway` of `-terminating-gateway`.
-		serviceAccountName := c.withPrefix(name)
 		err = c.createACLPolicyRoleAndBindingRule(name, rules,
 			gatewayParams.ConsulDC, gatewayParams.PrimaryDC, localPolicy,
 			gatewayParams.Primary, gatewayParams.AuthMethodName, serviceAccountName, consulClient)
@@ -888,6 +1032,24 @@ func (c *Command) withPrefix(resource string) string {
 	return fmt.Sprintf("%s-%s", c.flagResourcePrefix, resource)
 }
 
+// withACLPrefix returns the name of a Consul auth method or ACL role. It is
+// prefixed like Kubernetes resources, followed by the -cluster-name if it is set.
+func (c *Command) withACLPrefix(resource string) string {
+	if c.flagClusterName != "" {
+		return fmt.Sprintf("%s-%s-%s", c.flagResourcePrefix, c.flagClusterName, resource)
+	}
+	return c.withPrefix(resource)
+}
+
+// withClusterSuffix appends the -cluster-name, if it is set, to the name of a
+// Consul ACL policy.
+func (c *Command) withClusterSuffix(name string) string {
+	if c.flagClusterName != "" {
+		return fmt.Sprintf("%s-%s", name, c.flagClusterName)
+	}
+	return name
+}
+
 // consulDatacenterList returns the current datacenter name and the primary datacenter using the
 // /agent/self API endpoint.
 func (c *Command) consulDatacenterList(client *api.Client) (string, string, error) {
@@ -960,6 +1122,12 @@ func (c *Command) validateFlags() error {
 		return errors.New("-resource-prefix must be set")
 	}
 
+	if c.flagClusterName != "" {
+		if err := common.ValidateClusterName("-cluster-name", c.flagClusterName); err != nil {
+			return err
+		}
+	}
+
 	// For the Consul node name to be discoverable via DNS, it must contain only
 	// dashes and alphanumeric characters. Length is also constrained.
 	// These restrictions match those defined in Consul's agent definition.
@@ -985,6 +1153,20 @@ func (c *Command) validateFlags() error {
 		return errors.New("-consul-api-timeout must be set to a value greater than 0")
 	}
 
+	if c.flagJWTAuthMethod {
+		if (c.flagJWTOIDCDiscoveryURL == "") == (c.flagJWTJWKSURL == "") {
+			return errors.New("exactly one of -jwt-auth-method-oidc-discovery-url or -jwt-auth-method-jwks-url must be set when -jwt-auth-method is true")
+		}
+		if c.flagJWTMaxTokenTTL < 0 {
+			return errors.New("-jwt-auth-method-max-token-ttl must be >= 0")
+		}
+		for _, rule := range c.flagJWTBindingRules {
+			if _, err := parseJWTBindingRule(rule); err != nil {
+				return err
+			}
+		}
+	}
+
 	//if c.flagVaultNamespace != "" && c.flagSecretsBackend != SecretsBackendTypeVault {
 	//	return fmt.Errorf("-vault-namespace not supported for -secrets-backend=%q", c.flagSecretsBackend)
 	//}
@@ -267,6 +267,54 @@ func TestRun_AnonymousToken_CreatedFromNonDefaultPartition(t *testing.T) {
 	require.Equal(t, anonPolicyName, tokenData.Policies[0].Name)
 }
 
+// Test that the destination namespaces are created in a non-default partition
+// with the cross-namespace policy as a default.
+func TestRun_DestinationNamespaces_CreatedInNonDefaultPartition(t *testing.T) {
+	bootToken := "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
+	server := partitionedSetup(t, bootToken, "test")
+	k8s := fake.NewSimpleClientset()
+	setUpK8sServiceAccount(t, k8s, ns)
+
+	ui := cli.NewMockUi()
+	cmd := Command{
+		UI:        ui,
+		clientset: k8s,
+		backend:   &FakeSecretsBackend{bootstrapToken: bootToken},
+	}
+	cmd.init()
+	args := []string{
+		"-addresses=" + strings.Split(server.HTTPAddr, ":")[0],
+		"-http-port=" + strings.Split(server.HTTPAddr, ":")[1],
+		"-grpc-port=" + strings.Split(server.GRPCAddr, ":")[1],
+		"-resource-prefix=" + resourcePrefix,
+		"-k8s-namespace=" + ns,
+		"-partition=test",
+		"-enable-namespaces",
+		"-sync-catalog",
+		"-consul-sync-destination-namespace=sync",
+		"-connect-inject",
+		"-consul-inject-destination-namespace=inject",
+	}
+	responseCode := cmd.Run(args)
+	require.Equal(t, 0, responseCode, ui.ErrorWriter.String())
+
+	consul, err := api.NewClient(&api.Config{
+		Address:   server.HTTPAddr,
+		Token:     bootToken,
+		Partition: "test",
+	})
+	require.NoError(t, err)
+
+	for _, name := range []string{"sync", "inject"} {
+		namespace, _, err := consul.Namespaces().Read(name, nil)
+		require.NoError(t, err)
+		require.NotNil(t, namespace, name)
+		require.Equal(t, "test", namespace.Partition)
+		require.Len(t, namespace.ACLs.PolicyDefaults, 1)
+		require.Equal(t, "cross-namespace-policy", namespace.ACLs.PolicyDefaults[0].Name)
+	}
+}
+
 // Test that ACL policies get updated if namespaces/partition config changes.
 func TestRun_ACLPolicyUpdates(t *testing.T) {
 	t.Parallel()
@@ -21,6 +21,7 @@ import (
 	"github.com/hashicorp/consul-k8s/control-plane/helper/cert"
 	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/freeport"
 	"github.com/hashicorp/consul/sdk/testutil"
@@ -81,6 +82,52 @@ func TestRun_FlagValidation(t *testing.T) {
 			ExpErr: "-sync-consul-node-name=5r9OPGfSRXUdGzNjBdAwmhCBrzHDNYs4XjZVR4wp7lSLIzqwS0ta51nBLIN0TMPV-too-long is invalid: node name will not be discoverable " +
 				"via DNS due to it being too long. Valid lengths are between 1 and 63 bytes",
 		},
+		{
+			Flags: []string{
+				"-addresses=localhost",
+				"-resource-prefix=prefix",
+				"-cluster-name=East_1",
+			},
+			ExpErr: `-cluster-name value of "East_1" must be at most 63 characters long and only contain lowercase letters, digits and '-', starting and ending with a letter or digit`,
+		},
+		{
+			Flags: []string{
+				"-addresses=localhost",
+				"-resource-prefix=prefix",
+				"-jwt-auth-method",
+			},
+			ExpErr: "exactly one of -jwt-auth-method-oidc-discovery-url or -jwt-auth-method-jwks-url must be set when -jwt-auth-method is true",
+		},
+		{
+			Flags: []string{
+				"-addresses=localhost",
+				"-resource-prefix=prefix",
+				"-jwt-auth-method",
+				"-jwt-auth-method-oidc-discovery-url=https://issuer.example.com",
+				"-jwt-auth-method-jwks-url=https://issuer.example.com/keys",
+			},
+			ExpErr: "exactly one of -jwt-auth-method-oidc-discovery-url or -jwt-auth-method-jwks-url must be set when -jwt-auth-method is true",
+		},
+		{
+			Flags: []string{
+				"-addresses=localhost",
+				"-resource-prefix=prefix",
+				"-jwt-auth-method",
+				"-jwt-auth-method-oidc-discovery-url=https://issuer.example.com",
+				"-jwt-auth-method-binding-rule=policy:admin",
+			},
+			ExpErr: "-jwt-auth-method-binding-rule=policy:admin is invalid: bind type must be one of \"service\", \"node\" or \"role\"",
+		},
+		{
+			Flags: []string{
+				"-addresses=localhost",
+				"-resource-prefix=prefix",
+				"-jwt-auth-method",
+				"-jwt-auth-method-oidc-discovery-url=https://issuer.example.com",
+				"-jwt-auth-method-binding-rule=role",
+			},
+			ExpErr: "-jwt-auth-method-binding-rule=role is invalid: must be of the form <bind-type>:<bind-name>[:<selector>]",
+		},
 	}
 
 	for _, c := range cases {
@@ -659,6 +706,55 @@ func TestRun_ConnectInjectAuthMethod(t *testing.T) {
 	}
 }
 
+// Test that the auth methods, ACL roles and policies include the cluster name
+// if it is set.
+func TestRun_ClusterName(t *testing.T) {
+	t.Parallel()
+
+	k8s, testClient := completeSetup(t)
+	setUpK8sServiceAccount(t, k8s, ns)
+
+	ui := cli.NewMockUi()
+	cmd := Command{
+		UI:        ui,
+		clientset: k8s,
+	}
+	cmd.init()
+	responseCode := cmd.Run([]string{
+		"-timeout=1m",
+		"-resource-prefix=" + resourcePrefix,
+		"-k8s-namespace=" + ns,
+		"-addresses", strings.Split(testClient.TestServer.HTTPAddr, ":")[0],
+		"-http-port", strings.Split(testClient.TestServer.HTTPAddr, ":")[1],
+		"-grpc-port", strings.Split(testClient.TestServer.GRPCAddr, ":")[1],
+		"-cluster-name=east",
+		"-connect-inject",
+	})
+	require.Equal(t, 0, responseCode, ui.ErrorWriter.String())
+
+	bootToken := getBootToken(t, k8s, resourcePrefix, ns)
+	consul := testClient.APIClient
+	queryOpts := &api.QueryOptions{Token: bootToken}
+
+	for _, name := range []string{
+		resourcePrefix + "-east-k8s-auth-method",
+		resourcePrefix + "-east-k8s-component-auth-method",
+	} {
+		authMethod, _, err := consul.ACL().AuthMethodRead(name, queryOpts)
+		require.NoError(t, err)
+		require.NotNil(t, authMethod, "auth method %s not found", name)
+	}
+
+	policy, _, err := consul.ACL().PolicyReadByName("connect-inject-policy-east", queryOpts)
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+
+	role, _, err := consul.ACL().RoleReadByName(resourcePrefix+"-east-connect-inject-acl-role", queryOpts)
+	require.NoError(t, err)
+	require.NotNil(t, role)
+	require.Equal(t, "connect-inject-policy-east", role.Policies[0].Name)
+}
+
 // Test that when we provide a different k8s auth method parameters,
 // the auth method is updated.
 func TestRun_ConnectInjectAuthMethodUpdates(t *testing.T) {
@@ -2558,6 +2654,139 @@ func TestRun_PrimaryDatacenter_ComponentAuthMethod(t *testing.T) {
 	require.NotNil(t, authMethod)
 }
 
+// Test that the JWT auth method and its binding rules are created.
+func TestRun_JWTAuthMethod(t *testing.T) {
+	t.Parallel()
+
+	k8s, testClient := completeSetup(t)
+	setUpK8sServiceAccount(t, k8s, ns)
+
+	// Run the command.
+	ui := cli.NewMockUi()
+	cmd := Command{
+		UI:        ui,
+		clientset: k8s,
+	}
+	cmd.init()
+	cmdArgs := []string{
+		"-timeout=1m",
+		"-k8s-namespace=" + ns,
+		"-addresses", strings.Split(testClient.TestServer.HTTPAddr, ":")[0],
+		"-http-port", strings.Split(testClient.TestServer.HTTPAddr, ":")[1],
+		"-grpc-port", strings.Split(testClient.TestServer.GRPCAddr, ":")[1],
+		"-resource-prefix=" + resourcePrefix,
+		"-jwt-auth-method",
+		"-jwt-auth-method-jwks-url=https://issuer.example.com/keys",
+		"-jwt-auth-method-bound-issuer=https://issuer.example.com",
+		"-jwt-auth-method-bound-audience=consul",
+		"-jwt-auth-method-claim-mapping=sub=subject",
+		"-jwt-auth-method-list-claim-mapping=groups=groups",
+		"-jwt-auth-method-max-token-ttl=1h",
+		"-jwt-auth-method-binding-rule=role:operators:operators in list.groups",
+		"-jwt-auth-method-binding-rule=service:${value.subject}",
+	}
+
+	responseCode := cmd.Run(cmdArgs)
+	require.Equal(t, 0, responseCode, ui.ErrorWriter.String())
+
+	bootToken := getBootToken(t, k8s, resourcePrefix, ns)
+	consulConfig := testClient.Cfg
+	consulConfig.APIClientConfig.Token = bootToken
+	consulClient, err := api.NewClient(consulConfig.APIClientConfig)
+	require.NoError(t, err)
+
+	authMethodName := resourcePrefix + "-jwt-auth-method"
+	authMethod, _, err := consulClient.ACL().AuthMethodRead(authMethodName, &api.QueryOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, authMethod)
+	require.Equal(t, "jwt", authMethod.Type)
+	require.Equal(t, time.Hour, authMethod.MaxTokenTTL)
+	require.Equal(t, "https://issuer.example.com/keys", authMethod.Config["JWKSURL"])
+	require.Equal(t, "https://issuer.example.com", authMethod.Config["BoundIssuer"])
+	require.Equal(t, []interface{}{"consul"}, authMethod.Config["BoundAudiences"])
+	require.Equal(t, map[string]interface{}{"sub": "subject"}, authMethod.Config["ClaimMappings"])
+	require.Equal(t, map[string]interface{}{"groups": "groups"}, authMethod.Config["ListClaimMappings"])
+
+	rules, _, err := consulClient.ACL().BindingRuleList(authMethodName, &api.QueryOptions{})
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	for _, rule := range rules {
+		switch rule.BindType {
+		case api.BindingRuleBindTypeRole:
+			require.Equal(t, "operators", rule.BindName)
+			require.Equal(t, "operators in list.groups", rule.Selector)
+		case api.BindingRuleBindTypeService:
+			require.Equal(t, "${value.subject}", rule.BindName)
+			require.Empty(t, rule.Selector)
+		default:
+			t.Fatalf("unexpected binding rule type %q", rule.BindType)
+		}
+	}
+}
+
+// Test that the binding rules of the JWT auth method are matched on their bind
+// type, name and selector, and that the ones removed from the flags are deleted.
+func TestReconcileJWTBindingRules(t *testing.T) {
+	t.Parallel()
+
+	existing := []*api.ACLBindingRule{
+		// Created by an earlier run from the same flag, at a different index.
+		{ID: "kept", BindType: api.BindingRuleBindTypeRole, BindName: "operators", Selector: "operators in list.groups", Description: "JWT Auth Method binding rule 2"},
+		// Removed from the flags.
+		{ID: "stale", BindType: api.BindingRuleBindTypeService, BindName: "${value.subject}", Description: "JWT Auth Method binding rule"},
+		// Its selector changed.
+		{ID: "changed", BindType: api.BindingRuleBindTypeRole, BindName: "ci", Selector: "value.ci == \"true\"", Description: "JWT Auth Method binding rule"},
+		// Added by an operator.
+		{ID: "operator", BindType: api.BindingRuleBindTypeRole, BindName: "admins", Description: "added by hand"},
+	}
+	var created []api.ACLBindingRule
+	var deleted []string
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/binding-rules":
+			require.Equal(t, "jwt-auth-method", r.URL.Query().Get("authmethod"))
+			require.NoError(t, json.NewEncoder(w).Encode(existing))
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/acl/binding-rule":
+			var rule api.ACLBindingRule
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&rule))
+			created = append(created, rule)
+			require.NoError(t, json.NewEncoder(w).Encode(rule))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/acl/binding-rule/"):
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/v1/acl/binding-rule/"))
+			fmt.Fprint(w, "true")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer consulServer.Close()
+
+	consulClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cmd := Command{
+		log:           hclog.NewNullLogger(),
+		ctx:           ctx,
+		retryDuration: 10 * time.Millisecond,
+	}
+
+	var rules []*api.ACLBindingRule
+	for _, flag := range []string{"role:ci:value.ci == \"yes\"", "role:operators:operators in list.groups"} {
+		rule, err := parseJWTBindingRule(flag)
+		require.NoError(t, err)
+		rule.AuthMethod = "jwt-auth-method"
+		rule.Description = jwtBindingRuleDescription
+		rules = append(rules, rule)
+	}
+	require.NoError(t, cmd.reconcileJWTBindingRules(consulClient, "jwt-auth-method", rules))
+
+	require.ElementsMatch(t, []string{"stale", "changed"}, deleted)
+	require.Len(t, created, 1)
+	require.Equal(t, "ci", created[0].BindName)
+	require.Equal(t, "value.ci == \"yes\"", created[0].Selector)
+	require.Equal(t, jwtBindingRuleDescription, created[0].Description)
+}
+
 // Test that the local and global component auth methods gets created when run in the
 // secondary datacenter.
 func TestRun_SecondaryDatacenter_ComponentAuthMethod(t *testing.T) {
@@ -2857,3 +3086,404 @@ func policyExists(t require.TestingT, name string, client *api.Client) *api.ACLP
 
 var serviceAccountCACert = "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCk1JSURDekNDQWZPZ0F3SUJBZ0lRS3pzN05qbDlIczZYYzhFWG91MjVoekFOQmdrcWhraUc5dzBCQVFzRkFEQXYKTVMwd0t3WURWUVFERXlRMU9XVTJaR00wTVMweU1EaG1MVFF3T1RVdFlUSTRPUzB4Wm1NM01EQmhZekZqWXpndwpIaGNOTVRrd05qQTNNVEF4TnpNeFdoY05NalF3TmpBMU1URXhOek14V2pBdk1TMHdLd1lEVlFRREV5UTFPV1UyClpHTTBNUzB5TURobUxUUXdPVFV0WVRJNE9TMHhabU0zTURCaFl6RmpZemd3Z2dFaU1BMEdDU3FHU0liM0RRRUIKQVFVQUE0SUJEd0F3Z2dFS0FvSUJBUURaakh6d3FvZnpUcEdwYzBNZElDUzdldXZmdWpVS0UzUEMvYXBmREFnQgo0anpFRktBNzgvOStLVUd3L2MvMFNIZVNRaE4rYThnd2xIUm5BejFOSmNmT0lYeTRkd2VVdU9rQWlGeEg4cGh0CkVDd2tlTk83ejhEb1Y4Y2VtaW5DUkhHamFSbW9NeHBaN2cycFpBSk5aZVB4aTN5MWFOa0ZBWGU5Z1NVU2RqUloKUlhZa2E3d2gyQU85azJkbEdGQVlCK3Qzdld3SjZ0d2pHMFR0S1FyaFlNOU9kMS9vTjBFMDFMekJjWnV4a04xawo4Z2ZJSHk3Yk9GQ0JNMldURURXLzBhQXZjQVByTzhETHFESis2TWpjM3I3K3psemw4YVFzcGIwUzA4cFZ6a2k1CkR6Ly84M2t5dTBwaEp1aWo1ZUI4OFY3VWZQWHhYRi9FdFY2ZnZyTDdNTjRmQWdNQkFBR2pJekFoTUE0R0ExVWQKRHdFQi93UUVBd0lDQkRBUEJnTlZIUk1CQWY4RUJUQURBUUgvTUEwR0NTcUdTSWIzRFFFQkN3VUFBNElCQVFCdgpRc2FHNnFsY2FSa3RKMHpHaHh4SjUyTm5SVjJHY0lZUGVOM1p2MlZYZTNNTDNWZDZHMzJQVjdsSU9oangzS21BCi91TWg2TmhxQnpzZWtrVHowUHVDM3dKeU0yT0dvblZRaXNGbHF4OXNGUTNmVTJtSUdYQ2Ezd0M4ZS9xUDhCSFMKdzcvVmVBN2x6bWozVFFSRS9XMFUwWkdlb0F4bjliNkp0VDBpTXVjWXZQMGhYS1RQQldsbnpJaWphbVU1MHIyWQo3aWEwNjVVZzJ4VU41RkxYL3Z4T0EzeTRyanBraldvVlFjdTFwOFRaclZvTTNkc0dGV3AxMGZETVJpQUhUdk9ICloyM2pHdWs2cm45RFVIQzJ4UGozd0NUbWQ4U0dFSm9WMzFub0pWNWRWZVE5MHd1c1h6M3ZURzdmaWNLbnZIRlMKeHRyNVBTd0gxRHVzWWZWYUdIMk8KLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLQo="
 var serviceAccountToken = "ZXlKaGJHY2lPaUpTVXpJMU5pSXNJbXRwWkNJNklpSjkuZXlKcGMzTWlPaUpyZFdKbGNtNWxkR1Z6TDNObGNuWnBZMlZoWTJOdmRXNTBJaXdpYTNWaVpYSnVaWFJsY3k1cGJ5OXpaWEoyYVdObFlXTmpiM1Z1ZEM5dVlXMWxjM0JoWTJVaU9pSmtaV1poZFd4MElpd2lhM1ZpWlhKdVpYUmxjeTVwYnk5elpYSjJhV05sWVdOamIzVnVkQzl6WldOeVpYUXVibUZ0WlNJNkltdG9ZV3RwTFdGeVlXTm9ibWxrTFdOdmJuTjFiQzFqYjI1dVpXTjBMV2x1YW1WamRHOXlMV0YxZEdodFpYUm9iMlF0YzNaakxXRmpZMjlvYm1SaWRpSXNJbXQxWW1WeWJtVjBaWE11YVc4dmMyVnlkbWxqWldGalkyOTFiblF2YzJWeWRtbGpaUzFoWTJOdmRXNTBMbTVoYldVaU9pSnJhR0ZyYVMxaGNtRmphRzVwWkMxamIyNXpkV3d0WTI5dWJtVmpkQzFwYm1wbFkzUnZjaTFoZFhSb2JXVjBhRzlrTFhOMll5MWhZMk52ZFc1MElpd2lhM1ZpWlhKdVpYUmxjeTVwYnk5elpYSjJhV05sWVdOamIzVnVkQzl6WlhKMmFXTmxMV0ZqWTI5MWJuUXVkV2xrSWpvaU4yVTVOV1V4TWprdFpUUTNNeTB4TVdVNUxUaG1ZV0V0TkRJd01UQmhPREF3TVRJeUlpd2ljM1ZpSWpvaWMzbHpkR1Z0T25ObGNuWnBZMlZoWTJOdmRXNTBPbVJsWm1GMWJIUTZhMmhoYTJrdFlYSmhZMmh1YVdRdFkyOXVjM1ZzTFdOdmJtNWxZM1F0YVc1cVpXTjBiM0l0WVhWMGFHMWxkR2h2WkMxemRtTXRZV05qYjNWdWRDSjkuWWk2M01NdHpoNU1CV0tLZDNhN2R6Q0pqVElURTE1aWtGeV9UbnBka19Bd2R3QTlKNEFNU0dFZUhONXZXdEN1dUZqb19sTUpxQkJQSGtLMkFxYm5vRlVqOW01Q29wV3lxSUNKUWx2RU9QNGZVUS1SYzBXMVBfSmpVMXJaRVJIRzM5YjVUTUxnS1BRZ3V5aGFpWkVKNkNqVnRtOXdVVGFncmdpdXFZVjJpVXFMdUY2U1lObTZTckt0a1BTLWxxSU8tdTdDMDZ3Vms1bTV1cXdJVlFOcFpTSUNfNUxzNWFMbXlaVTNuSHZILVY3RTNIbUJoVnlaQUI3NmpnS0IwVHlWWDFJT3NrdDlQREZhck50VTNzdVp5Q2p2cUMtVUpBNnNZZXlTZTRkQk5Lc0tsU1o2WXV4VVVtbjFSZ3YzMllNZEltbnNXZzhraGYtekp2cWdXazdCNUVB"
+
+// Test that the anonymous token is linked to the builtin/dns templated policy
+// with -use-templated-policies, that its other links are kept, and that the
+// anonymous token policy it replaces is deleted.
+func TestConfigureAnonymousPolicy_TemplatedPolicies(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		supported bool
+		expErr    string
+	}{
+		"templated policies supported": {
+			supported: true,
+		},
+		"templated policies not supported": {
+			supported: false,
+			expErr:    "the Consul servers don't support templated policies",
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var tokenBody map[string]interface{}
+			policyDeleted := false
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/templated-policy/name/builtin/dns":
+					if !c.supported {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					fmt.Fprint(w, `{"TemplateName": "builtin/dns"}`)
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/token/"+anonymousTokenID:
+					fmt.Fprint(w, `{
+						"AccessorID": "`+anonymousTokenID+`",
+						"Description": "Anonymous Token",
+						"Policies": [{"ID": "anonymous-policy-id", "Name": "anonymous-token-policy"}, {"ID": "user-policy-id", "Name": "user-policy"}],
+						"Roles": [{"ID": "user-role-id", "Name": "user-role"}]
+					}`)
+				case r.Method == http.MethodPut && r.URL.Path == "/v1/acl/token/"+anonymousTokenID:
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&tokenBody))
+					require.NoError(t, json.NewEncoder(w).Encode(tokenBody))
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/policy/name/"+anonymousPolicyName:
+					fmt.Fprint(w, `{"ID": "anonymous-policy-id", "Name": "anonymous-token-policy"}`)
+				case r.Method == http.MethodDelete && r.URL.Path == "/v1/acl/policy/anonymous-policy-id":
+					policyDeleted = true
+					fmt.Fprint(w, "true")
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer consulServer.Close()
+
+			consulClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+			require.NoError(t, err)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			cmd := Command{
+				flagUseTemplatedPolicies: true,
+				log:                      hclog.NewNullLogger(),
+				ctx:                      ctx,
+				retryDuration:            10 * time.Millisecond,
+			}
+
+			err = cmd.configureAnonymousPolicy(consulClient)
+			if c.expErr != "" {
+				require.ErrorContains(t, err, c.expErr)
+				// The token must not be written if it would lose its policy.
+				require.Nil(t, tokenBody)
+				require.False(t, policyDeleted)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, []interface{}{map[string]interface{}{"TemplateName": "builtin/dns"}}, tokenBody["TemplatedPolicies"])
+			require.Equal(t, []interface{}{map[string]interface{}{"ID": "user-policy-id", "Name": "user-policy"}}, tokenBody["Policies"])
+			require.Equal(t, []interface{}{map[string]interface{}{"ID": "user-role-id", "Name": "user-role"}}, tokenBody["Roles"])
+			require.True(t, policyDeleted)
+		})
+	}
+}
+
+// Test that the ACL roles of gateways in the default namespace are linked to
+// the builtin/service templated policy with -use-templated-policies, and that
+// the policy they had before is detached and deleted.
+func TestConfigureGateway_TemplatedPolicies(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		gatewayType  string
+		existingRole string
+		expEndpoint  string
+		expPolicies  interface{}
+	}{
+		"new role": {
+			gatewayType: "ingress",
+			expEndpoint: "/v1/acl/role",
+		},
+		"new terminating gateway role": {
+			gatewayType: "terminating",
+			expEndpoint: "/v1/acl/role",
+		},
+		"existing role": {
+			gatewayType: "ingress",
+			existingRole: `{
+				"ID": "role-id",
+				"Name": "consul-gateway-acl-role",
+				"Policies": [{"ID": "gateway-policy-id", "Name": "gateway-policy"}, {"ID": "user-policy-id", "Name": "user-policy"}]
+			}`,
+			expEndpoint: "/v1/acl/role/role-id",
+			expPolicies: []interface{}{map[string]interface{}{"ID": "user-policy-id", "Name": "user-policy"}},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var roleEndpoint string
+			var roleBody, bindingRuleBody map[string]interface{}
+			policyDeleted := false
+			consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/templated-policy/name/builtin/dns":
+					fmt.Fprint(w, `{"TemplateName": "builtin/dns"}`)
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/role/name/consul-gateway-acl-role":
+					if c.existingRole == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					fmt.Fprint(w, c.existingRole)
+				case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/acl/role"):
+					roleEndpoint = r.URL.Path
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&roleBody))
+					require.NoError(t, json.NewEncoder(w).Encode(roleBody))
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/policy/name/gateway-policy":
+					if c.existingRole == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					fmt.Fprint(w, `{"ID": "gateway-policy-id", "Name": "gateway-policy"}`)
+				case r.Method == http.MethodDelete && r.URL.Path == "/v1/acl/policy/gateway-policy-id":
+					policyDeleted = true
+					fmt.Fprint(w, "true")
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/binding-rules":
+					fmt.Fprint(w, "[]")
+				case r.Method == http.MethodPut && r.URL.Path == "/v1/acl/binding-rule":
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&bindingRuleBody))
+					require.NoError(t, json.NewEncoder(w).Encode(bindingRuleBody))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer consulServer.Close()
+
+			consulClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+			require.NoError(t, err)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			cmd := Command{
+				flagUseTemplatedPolicies: true,
+				flagResourcePrefix:       "consul",
+				log:                      hclog.NewNullLogger(),
+				ctx:                      ctx,
+				retryDuration:            10 * time.Millisecond,
+			}
+
+			err = cmd.configureGateway(ConfigureGatewayParams{
+				GatewayType:    c.gatewayType,
+				GatewayNames:   []string{"gateway"},
+				AuthMethodName: "consul-k8s-component-auth-method",
+				RulesGenerator: cmd.ingressGatewayRules,
+				ConsulDC:       "dc1",
+				PrimaryDC:      "dc1",
+				Primary:        true,
+			}, consulClient)
+			require.NoError(t, err)
+
+			require.Equal(t, c.expEndpoint, roleEndpoint)
+			require.Equal(t, "consul-gateway-acl-role", roleBody["Name"])
+			require.Equal(t, []interface{}{map[string]interface{}{
+				"TemplateName":      "builtin/service",
+				"TemplateVariables": map[string]interface{}{"Name": "gateway"},
+				"Datacenters":       []interface{}{"dc1"},
+			}}, roleBody["TemplatedPolicies"])
+			require.Equal(t, c.expPolicies, roleBody["Policies"])
+			require.Equal(t, c.existingRole != "", policyDeleted)
+			require.Equal(t, "consul-gateway-acl-role", bindingRuleBody["BindName"])
+			require.Equal(t, `serviceaccount.name=="consul-gateway"`, bindingRuleBody["Selector"])
+		})
+	}
+}
+
+// Test that with -use-templated-policies, a component whose templated policy
+// doesn't grant all of its rules keeps a policy with the other rules, and that
+// its existing role is linked to both.
+func TestCreateTemplatedPolicyRoleAndBindingRule_Rules(t *testing.T) {
+	t.Parallel()
+
+	var policyBody, roleBody map[string]interface{}
+	policyDeleted := false
+	consulServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/templated-policy/name/builtin/dns":
+			fmt.Fprint(w, `{"TemplateName": "builtin/dns"}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/acl/policy":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&policyBody))
+			require.NoError(t, json.NewEncoder(w).Encode(policyBody))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/role/name/consul-mesh-gateway-acl-role":
+			fmt.Fprint(w, `{
+				"ID": "role-id",
+				"Name": "consul-mesh-gateway-acl-role",
+				"Policies": [{"ID": "mesh-gateway-policy-id", "Name": "mesh-gateway-policy"}]
+			}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/acl/role/role-id":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&roleBody))
+			require.NoError(t, json.NewEncoder(w).Encode(roleBody))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/acl/policy/"):
+			policyDeleted = true
+			fmt.Fprint(w, "true")
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/binding-rules":
+			fmt.Fprint(w, "[]")
+		case r.Method == http.MethodPut && r.URL.Path == "/v1/acl/binding-rule":
+			fmt.Fprint(w, "{}")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer consulServer.Close()
+
+	consulClient, err := api.NewClient(&api.Config{Address: consulServer.URL})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cmd := Command{
+		flagUseTemplatedPolicies: true,
+		flagResourcePrefix:       "consul",
+		consulFlags:              &flags.ConsulFlags{},
+		log:                      hclog.NewNullLogger(),
+		ctx:                      ctx,
+		retryDuration:            10 * time.Millisecond,
+	}
+	rules, err := cmd.meshGatewayRules()
+	require.NoError(t, err)
+
+	err = cmd.createTemplatedPolicyRoleAndBindingRule("mesh-gateway", serviceTemplatedPolicy("mesh-gateway"), rules,
+		"dc1", "dc1", globalPolicy, true, "consul-k8s-component-auth-method", "consul-mesh-gateway", consulClient)
+	require.NoError(t, err)
+
+	require.Equal(t, "mesh-gateway-policy", policyBody["Name"])
+	require.Equal(t, `mesh = "write"`, policyBody["Rules"])
+	require.Equal(t, []interface{}{map[string]interface{}{"ID": "", "Name": "mesh-gateway-policy"}}, roleBody["Policies"])
+	require.Equal(t, []interface{}{map[string]interface{}{
+		"TemplateName":      "builtin/service",
+		"TemplateVariables": map[string]interface{}{"Name": "mesh-gateway"},
+	}}, roleBody["TemplatedPolicies"])
+	require.False(t, policyDeleted)
+}
+
+func TestConfigurePartitionACLs(t *testing.T) {
+	t.Parallel()
+
+	// fakeConsul records the policies, roles and binding rules written to a
+	// partition. Roles are keyed by <namespace>/<name>.
+	type fakeConsul struct {
+		policies     map[string]api.ACLPolicy
+		roles        map[string]api.ACLRole
+		bindingRules []api.ACLBindingRule
+	}
+	newServer := func() (*fakeConsul, *api.Client) {
+		f := &fakeConsul{
+			policies: make(map[string]api.ACLPolicy),
+			roles:    make(map[string]api.ACLRole),
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ns := r.URL.Query().Get("ns")
+			switch {
+			case r.Method == http.MethodPut && r.URL.Path == "/v1/acl/policy":
+				var policy api.ACLPolicy
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&policy))
+				f.policies[policy.Name] = policy
+				require.NoError(t, json.NewEncoder(w).Encode(policy))
+			case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/acl/role/name/"):
+				role, ok := f.roles[ns+"/"+strings.TrimPrefix(r.URL.Path, "/v1/acl/role/name/")]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				require.NoError(t, json.NewEncoder(w).Encode(role))
+			case r.Method == http.MethodPut && r.URL.Path == "/v1/acl/role",
+				r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/acl/role/"):
+				var role api.ACLRole
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&role))
+				role.ID = role.Name + "-id"
+				f.roles[ns+"/"+role.Name] = role
+				require.NoError(t, json.NewEncoder(w).Encode(role))
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/acl/binding-rules":
+				var rules []api.ACLBindingRule
+				for _, rule := range f.bindingRules {
+					if rule.AuthMethod == r.URL.Query().Get("authmethod") && rule.Namespace == ns {
+						rules = append(rules, rule)
+					}
+				}
+				require.NoError(t, json.NewEncoder(w).Encode(rules))
+			case r.Method == http.MethodPut && r.URL.Path == "/v1/acl/binding-rule":
+				var rule api.ACLBindingRule
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&rule))
+				rule.ID = fmt.Sprintf("rule-%d", len(f.bindingRules))
+				f.bindingRules = append(f.bindingRules, rule)
+				require.NoError(t, json.NewEncoder(w).Encode(rule))
+			case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v1/acl/binding-rule/"):
+				var rule api.ACLBindingRule
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&rule))
+				for i := range f.bindingRules {
+					if f.bindingRules[i].ID == rule.ID {
+						f.bindingRules[i] = rule
+					}
+				}
+				require.NoError(t, json.NewEncoder(w).Encode(rule))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(server.Close)
+		client, err := api.NewClient(&api.Config{Address: server.URL})
+		require.NoError(t, err)
+		return f, client
+	}
+
+	partition, partitionClient := newServer()
+	defaultPartition, defaultPartitionClient := newServer()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cmd := Command{
+		consulFlags:                          &flags.ConsulFlags{Partition: "test"},
+		flagResourcePrefix:                   "consul",
+		flagEnableNamespaces:                 true,
+		flagSyncCatalog:                      true,
+		flagConsulSyncDestinationNamespace:   "sync",
+		flagConnectInject:                    true,
+		flagConsulInjectDestinationNamespace: "inject",
+		flagBindingRuleSelector:              "serviceaccount.name!=default",
+		flagDefaultPartitionAuthMethod:       "consul-k8s-auth-method",
+		log:                                  hclog.NewNullLogger(),
+		ctx:                                  ctx,
+		retryDuration:                        10 * time.Millisecond,
+	}
+
+	// The second run checks that the binding rules aren't added twice.
+	for i := 0; i < 2; i++ {
+		require.NoError(t, cmd.configurePartitionACLs(partitionClient, defaultPartitionClient))
+		require.NoError(t, cmd.addPartitionReadBindingRule(partitionClient, "consul-k8s-auth-method"))
+	}
+
+	expPartitionRules := `partition "test" {
+  mesh = "read"
+  namespace_prefix "" {
+    service_prefix "" {
+      policy = "read"
+    }
+    node_prefix "" {
+      policy = "read"
+    }
+  }
+}`
+	require.Equal(t, expPartitionRules, partition.policies["partition-read-policy"].Rules)
+	// The role is created in the namespace of the connect inject binding
+	// rules and only linked by them.
+	require.Equal(t, []*api.ACLRolePolicyLink{{Name: "partition-read-policy"}}, partition.roles["inject/consul-partition-read-role"].Policies)
+	require.Len(t, partition.bindingRules, 1)
+	require.Equal(t, api.BindingRuleBindTypeRole, partition.bindingRules[0].BindType)
+	require.Equal(t, "consul-partition-read-role", partition.bindingRules[0].BindName)
+	require.Equal(t, "inject", partition.bindingRules[0].Namespace)
+	require.Equal(t, "serviceaccount.name!=default", partition.bindingRules[0].Selector)
+
+	require.Len(t, defaultPartition.policies, 1)
+	require.Contains(t, defaultPartition.policies["partition-test-read-policy"].Rules, `partition "test" {`)
+	require.Equal(t, []*api.ACLRolePolicyLink{{Name: "partition-test-read-policy"}}, defaultPartition.roles["/consul-partition-test-read-role"].Policies)
+	require.Len(t, defaultPartition.bindingRules, 1)
+	require.Equal(t, "consul-k8s-auth-method", defaultPartition.bindingRules[0].AuthMethod)
+	require.Equal(t, api.BindingRuleBindTypeRole, defaultPartition.bindingRules[0].BindType)
+	require.Equal(t, "consul-partition-test-read-role", defaultPartition.bindingRules[0].BindName)
+}
+
+func TestConfigurePartitionACLs_NoDefaultPartitionAuthMethod(t *testing.T) {
+	t.Parallel()
+	defaultPartitionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to the default partition: %s %s", r.Method, r.URL.Path)
+	}))
+	defer defaultPartitionServer.Close()
+	defaultPartitionClient, err := api.NewClient(&api.Config{Address: defaultPartitionServer.URL})
+	require.NoError(t, err)
+
+	cmd := Command{
+		consulFlags:        &flags.ConsulFlags{Partition: "test"},
+		flagResourcePrefix: "consul",
+		log:                hclog.NewNullLogger(),
+		ctx:                context.Background(),
+		retryDuration:      10 * time.Millisecond,
+	}
+	require.NoError(t, cmd.configurePartitionACLs(nil, defaultPartitionClient))
+}
@@ -18,12 +18,7 @@ import (
 // to the authMethod, allowing the serviceaccount to later be allowed to issue a Consul Login.
 func (c *Command) createACLPolicyRoleAndBindingRule(componentName, rules, dc, primaryDC string, global, primary bool, authMethodName, serviceAccountName string, client *api.Client) error {
 	// Create policy with the given rules.
-	policyName := fmt.Sprintf("%s-policy", componentName)
-	if c.flagFederation && !primary {
-		// If performing ACL replication, we must ensure policy names are
-		// globally unique so we append the datacenter name but only in secondary datacenters..
-		policyName += fmt.Sprintf("-%s", dc)
-	}
+	policyName := c.componentPolicyName(componentName, primary, dc)
 	var datacenters []string
 	if !global && dc != "" {
 		datacenters = append(datacenters, dc)
@@ -53,16 +48,33 @@ func (c *Command) createACLPolicyRoleAndBindingRule(componentName, rules, dc, pr
 	return c.addRoleAndBindingRule(client, componentName, serviceAccountName, authMethodName, apl, global, primary, primaryDC, dc)
 }
 
-// addRoleAndBindingRule adds an ACLRole and ACLBindingRule which reference the authMethod.
-func (c *Command) addRoleAndBindingRule(client *api.Client, componentName, serviceAccountName, authMethodName string, policies []*api.ACLRolePolicyLink, global, primary bool, primaryDC, dc string) error {
-	// This is the ACLRole which will allow the component which uses the serviceaccount
-	// to be able to do a consul login.
-	aclRoleName := c.withPrefix(fmt.Sprintf("%s-acl-role", componentName))
+// componentPolicyName returns the name of the ACL policy of a component.
+func (c *Command) componentPolicyName(componentName string, primary bool, dc string) string {
+	policyName := c.withClusterSuffix(fmt.Sprintf("%s-policy", componentName))
+	if c.flagFederation && !primary {
+		// If performing ACL replication, we must ensure policy names are
+		// globally unique so we append the datacenter name but only in secondary datacenters..
+		policyName += fmt.Sprintf("-%s", dc)
+	}
+	return policyName
+}
+
+// componentRoleName returns the name of the ACL role of a component.
+func (c *Command) componentRoleName(componentName string, primary bool, dc string) string {
+	aclRoleName := c.withACLPrefix(fmt.Sprintf("%s-acl-role", componentName))
 	if c.flagFederation && !primary {
 		// If performing ACL replication, we must ensure policy names are
 		// globally unique so we append the datacenter name but only in secondary datacenters.
 		aclRoleName += fmt.Sprintf("-%s", dc)
 	}
+	return aclRoleName
+}
+
+// addRoleAndBindingRule adds an ACLRole and ACLBindingRule which reference the authMethod.
+func (c *Command) addRoleAndBindingRule(client *api.Client, componentName, serviceAccountName, authMethodName string, policies []*api.ACLRolePolicyLink, global, primary bool, primaryDC, dc string) error {
+	// This is the ACLRole which will allow the component which uses the serviceaccount
+	// to be able to do a consul login.
+	aclRoleName := c.componentRoleName(componentName, primary, dc)
 	role := &api.ACLRole{
 		Name:        aclRoleName,
 		Description: fmt.Sprintf("ACL Role for %s", serviceAccountName),
@@ -73,7 +85,11 @@ func (c *Command) addRoleAndBindingRule(client *api.Client, componentName, servi
 		c.log.Error("unable to update or create ACL Role", err)
 		return err
 	}
+	return c.addBindingRule(client, aclRoleName, serviceAccountName, authMethodName, global, primaryDC, dc)
+}
 
+// addBindingRule adds an ACLBindingRule that binds the serviceaccount to the ACL role.
+func (c *Command) addBindingRule(client *api.Client, aclRoleName, serviceAccountName, authMethodName string, global bool, primaryDC, dc string) error {
 	// Create the ACLBindingRule, this ties the Policies defined in the Role to the authMethod via serviceaccount.
 	abr := &api.ACLBindingRule{
 		Description: fmt.Sprintf("Binding Rule for %s", serviceAccountName),
@@ -95,20 +111,20 @@ func (c *Command) updateOrCreateACLRole(client *api.Client, role *api.ACLRole) e
 	err := c.untilSucceeds(fmt.Sprintf("update or create acl role for %s", role.Name),
 		func() error {
 			var err error
-			aclRole, _, err := client.ACL().RoleReadByName(role.Name, &api.QueryOptions{})
+			aclRole, _, err := client.ACL().RoleReadByName(role.Name, &api.QueryOptions{Namespace: role.Namespace})
 			if err != nil {
 				c.log.Error("unable to read ACL Roles", err)
 				return err
 			}
 			if aclRole != nil {
-				_, _, err := client.ACL().RoleUpdate(aclRole, &api.WriteOptions{})
+				_, _, err := client.ACL().RoleUpdate(aclRole, &api.WriteOptions{Namespace: role.Namespace})
 				if err != nil {
 					c.log.Error("unable to update role", err)
 					return err
 				}
 				return nil
 			}
-			_, _, err = client.ACL().RoleCreate(role, &api.WriteOptions{})
+			_, _, err = client.ACL().RoleCreate(role, &api.WriteOptions{Namespace: role.Namespace})
 			if err != nil {
 				c.log.Error("unable to create role", err)
 				return err
@@ -216,7 +232,7 @@ func (c *Command) createACLWithSecretID(name, rules, dc string, isPrimary bool,
 // this value already exists in some secrets storage).
 func (c *Command) createACL(name, rules string, localToken bool, dc string, isPrimary bool, consulClient *api.Client, secretID string) error {
 	// Create policy with the given rules.
-	policyName := fmt.Sprintf("%s-token", name)
+	policyName := c.withClusterSuffix(fmt.Sprintf("%s-token", name))
 	if c.flagFederation && !isPrimary {
 		// If performing ACL replication, we must ensure policy names are
 		// globally unique so we append the datacenter name but only in secondary datacenters.
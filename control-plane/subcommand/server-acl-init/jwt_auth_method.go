@@ -0,0 +1,162 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serveraclinit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// jwtAuthMethodType is the type of the auth method created for
+// -jwt-auth-method. JWTs issued by an OIDC provider are validated with the
+// keys from its discovery URL.
+const jwtAuthMethodType = "jwt"
+
+// jwtBindingRuleDescription is the description of the binding rules of the JWT
+// auth method that are created from -jwt-auth-method-binding-rule. Binding rules
+// with other descriptions were added by operators and are left alone.
+const jwtBindingRuleDescription = "JWT Auth Method binding rule"
+
+// configureJWTAuthMethod creates or updates the JWT auth method that human
+// operators and CI systems can log in with using JWTs from an external
+// identity provider, and the binding rules that map its claims to Consul
+// identities.
+func (c *Command) configureJWTAuthMethod(consulClient *api.Client, authMethodName string) error {
+	config := map[string]interface{}{}
+	if c.flagJWTOIDCDiscoveryURL != "" {
+		config["OIDCDiscoveryURL"] = c.flagJWTOIDCDiscoveryURL
+	}
+	if c.flagJWTJWKSURL != "" {
+		config["JWKSURL"] = c.flagJWTJWKSURL
+	}
+	if c.flagJWTBoundIssuer != "" {
+		config["BoundIssuer"] = c.flagJWTBoundIssuer
+	}
+	if len(c.flagJWTBoundAudiences) > 0 {
+		config["BoundAudiences"] = c.flagJWTBoundAudiences
+	}
+	if len(c.flagJWTClaimMappings) > 0 {
+		config["ClaimMappings"] = c.flagJWTClaimMappings
+	}
+	if len(c.flagJWTListClaimMappings) > 0 {
+		config["ListClaimMappings"] = c.flagJWTListClaimMappings
+	}
+
+	authMethod := api.ACLAuthMethod{
+		Name:        authMethodName,
+		Type:        jwtAuthMethodType,
+		Description: "JWT Auth Method",
+		MaxTokenTTL: c.flagJWTMaxTokenTTL,
+		Config:      config,
+	}
+	if err := c.createAuthMethod(consulClient, &authMethod, &api.WriteOptions{}); err != nil {
+		return err
+	}
+
+	var rules []*api.ACLBindingRule
+	for _, rule := range c.flagJWTBindingRules {
+		abr, err := parseJWTBindingRule(rule)
+		if err != nil {
+			return err
+		}
+		abr.AuthMethod = authMethodName
+		abr.Description = jwtBindingRuleDescription
+		rules = append(rules, abr)
+	}
+	return c.reconcileJWTBindingRules(consulClient, authMethodName, rules)
+}
+
+// reconcileJWTBindingRules creates the binding rules of the JWT auth method
+// that don't exist yet and deletes the ones this command created that are no
+// longer in rules. Binding rules are identified by their bind type, bind name
+// and selector, so that reordering the flags doesn't replace them.
+func (c *Command) reconcileJWTBindingRules(consulClient *api.Client, authMethodName string, rules []*api.ACLBindingRule) error {
+	var existingRules []*api.ACLBindingRule
+	err := c.untilSucceeds(fmt.Sprintf("listing binding rules for auth method %s", authMethodName),
+		func() error {
+			var err error
+			existingRules, _, err = consulClient.ACL().BindingRuleList(authMethodName, &api.QueryOptions{})
+			return err
+		})
+	if err != nil {
+		return err
+	}
+
+	desired := make(map[string]*api.ACLBindingRule)
+	for _, rule := range rules {
+		desired[jwtBindingRuleKey(rule)] = rule
+	}
+	for _, existingRule := range existingRules {
+		// Binding rules created by earlier versions have the index of their
+		// flag appended to the description.
+		if !strings.HasPrefix(existingRule.Description, jwtBindingRuleDescription) {
+			continue
+		}
+		key := jwtBindingRuleKey(existingRule)
+		if _, ok := desired[key]; ok {
+			delete(desired, key)
+			continue
+		}
+		id := existingRule.ID
+		err := c.untilSucceeds(fmt.Sprintf("deleting acl binding rule %s for %s", id, authMethodName),
+			func() error {
+				_, err := consulClient.ACL().BindingRuleDelete(id, &api.WriteOptions{})
+				return err
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Rules are created in the order of the flags.
+	for _, rule := range rules {
+		key := jwtBindingRuleKey(rule)
+		if _, ok := desired[key]; !ok {
+			continue
+		}
+		delete(desired, key)
+		abr := rule
+		err := c.untilSucceeds(fmt.Sprintf("creating acl binding rule for %s", authMethodName),
+			func() error {
+				_, _, err := consulClient.ACL().BindingRuleCreate(abr, &api.WriteOptions{})
+				return err
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jwtBindingRuleKey returns the key that identifies a binding rule of the JWT
+// auth method.
+func jwtBindingRuleKey(rule *api.ACLBindingRule) string {
+	return strings.Join([]string{string(rule.BindType), rule.BindName, rule.Selector}, "\x00")
+}
+
+// parseJWTBindingRule parses a binding rule of the form
+// <bind-type>:<bind-name>[:<selector>], e.g.
+// role:${value.team}-operators:value.ci == "true".
+func parseJWTBindingRule(rule string) (*api.ACLBindingRule, error) {
+	parts := strings.SplitN(rule, ":", 3)
+	if len(parts) < 2 || parts[1] == "" {
+		return nil, fmt.Errorf("-jwt-auth-method-binding-rule=%s is invalid: must be of the form <bind-type>:<bind-name>[:<selector>]", rule)
+	}
+	bindType := api.BindingRuleBindType(parts[0])
+	switch bindType {
+	case api.BindingRuleBindTypeService, api.BindingRuleBindTypeRole, "node":
+	default:
+		return nil, fmt.Errorf("-jwt-auth-method-binding-rule=%s is invalid: bind type must be one of \"service\", \"node\" or \"role\"", rule)
+	}
+	abr := &api.ACLBindingRule{
+		BindType: bindType,
+		BindName: parts[1],
+	}
+	if len(parts) == 3 {
+		abr.Selector = parts[2]
+	}
+	return abr, nil
+}
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serveraclinit
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	"github.com/hashicorp/consul/api"
+)
+
+// isNonDefaultPartition returns true if the components are deployed in a
+// non-default admin partition.
+func (c *Command) isNonDefaultPartition() bool {
+	return c.consulFlags.Partition != "" && c.consulFlags.Partition != consulDefaultPartition
+}
+
+// configurePartitionNamespaces creates the Consul namespaces in a non-default
+// partition that the catalog sync and connect inject destinations need, so
+// that the auth method, binding rules and tokens created in them don't rely on
+// the namespaces having been created manually. The namespaces reference the
+// cross-namespace policy of the partition so that services in them can discover
+// services in all namespaces of the partition.
+func (c *Command) configurePartitionNamespaces(consulClient *api.Client) error {
+	if !c.isNonDefaultPartition() || !c.flagEnableNamespaces {
+		return nil
+	}
+
+	var destinationNamespaces []string
+	if c.flagSyncCatalog && !c.flagEnableSyncK8SNSMirroring {
+		destinationNamespaces = append(destinationNamespaces, c.flagConsulSyncDestinationNamespace)
+	}
+	if c.flagConnectInject && !c.flagEnableInjectK8SNSMirroring {
+		destinationNamespaces = append(destinationNamespaces, c.flagConsulInjectDestinationNamespace)
+	}
+
+	for _, ns := range destinationNamespaces {
+		err := c.untilSucceeds(fmt.Sprintf("checking or creating namespace %s in partition %s", ns, c.consulFlags.Partition),
+			func() error {
+				_, err := namespaces.EnsureExists(consulClient, ns, "cross-namespace-policy")
+				return err
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configurePartitionACLs creates the roles and policies that let services in
+// a non-default partition discover services across its namespaces, and that
+// let services in the default partition discover the services exported from
+// the partition.
+//
+// In the partition, a role with read access to the mesh config entries, such
+// as the exported services, and to the services and nodes of all namespaces
+// is created in the namespace of the connect inject binding rules. It's only
+// linked to the tokens of connect injected services, by a binding rule that
+// addPartitionReadBindingRule adds once the auth method exists.
+//
+// Policies in non-default partitions can only grant access to their own
+// partition, so the cross-partition read rules are created in the default
+// partition with defaultPartitionClient. Their role is only linked to the
+// tokens of the auth method in the default partition that
+// -default-partition-auth-method names, if it's set.
+func (c *Command) configurePartitionACLs(consulClient, defaultPartitionClient *api.Client) error {
+	if !c.isNonDefaultPartition() {
+		return nil
+	}
+
+	if c.flagConnectInject {
+		partitionRules, err := c.partitionReadRules()
+		if err != nil {
+			return fmt.Errorf("error templating partition read rules: %w", err)
+		}
+		err = c.createPartitionReadRole(consulClient, c.withClusterSuffix("partition-read-policy"),
+			c.partitionReadRoleName(), c.connectBindingRuleNamespace(), partitionRules)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.flagDefaultPartitionAuthMethod == "" {
+		return nil
+	}
+	crossPartitionRules, err := c.crossNamespaceRules()
+	if err != nil {
+		return fmt.Errorf("error templating cross partition rules: %w", err)
+	}
+	roleName := c.withACLPrefix(fmt.Sprintf("partition-%s-read-role", c.consulFlags.Partition))
+	err = c.createPartitionReadRole(defaultPartitionClient,
+		c.withClusterSuffix(fmt.Sprintf("partition-%s-read-policy", c.consulFlags.Partition)),
+		roleName, "", crossPartitionRules)
+	if err != nil {
+		return err
+	}
+	return c.createOrUpdateBindingRule(defaultPartitionClient, c.flagDefaultPartitionAuthMethod, &api.ACLBindingRule{
+		Description: fmt.Sprintf("Read the services of partition %s", c.consulFlags.Partition),
+		AuthMethod:  c.flagDefaultPartitionAuthMethod,
+		BindType:    api.BindingRuleBindTypeRole,
+		BindName:    roleName,
+	}, &api.QueryOptions{}, nil)
+}
+
+// addPartitionReadBindingRule links the partition read role that
+// configurePartitionACLs created to the tokens of connect injected services.
+func (c *Command) addPartitionReadBindingRule(consulClient *api.Client, authMethodName string) error {
+	if !c.isNonDefaultPartition() {
+		return nil
+	}
+	return c.createConnectBindingRule(consulClient, authMethodName, &api.ACLBindingRule{
+		Description: "Kubernetes binding rule for reading the services of the partition",
+		AuthMethod:  authMethodName,
+		BindType:    api.BindingRuleBindTypeRole,
+		BindName:    c.partitionReadRoleName(),
+		Selector:    c.flagBindingRuleSelector,
+	})
+}
+
+func (c *Command) partitionReadRoleName() string {
+	return c.withACLPrefix("partition-read-role")
+}
+
+// connectBindingRuleNamespace returns the Consul namespace that the connect
+// inject auth method and its binding rules are created in. Roles can only be
+// linked from their own namespace, so the roles that the binding rules link
+// must be created in it too.
+func (c *Command) connectBindingRuleNamespace() string {
+	if c.flagEnableNamespaces && !c.flagEnableInjectK8SNSMirroring {
+		return c.flagConsulInjectDestinationNamespace
+	}
+	return ""
+}
+
+// createPartitionReadRole creates a policy with the given rules and a role in
+// the given Consul namespace that links it.
+func (c *Command) createPartitionReadRole(consulClient *api.Client, policyName, roleName, namespace, rules string) error {
+	policyTmpl := api.ACLPolicy{
+		Name:        policyName,
+		Description: fmt.Sprintf("%s Policy", policyName),
+		Rules:       rules,
+	}
+	err := c.untilSucceeds(fmt.Sprintf("creating %s policy", policyTmpl.Name),
+		func() error {
+			return c.createOrUpdateACLPolicy(policyTmpl, consulClient)
+		})
+	if err != nil {
+		return err
+	}
+
+	return c.updateOrCreateACLRole(consulClient, &api.ACLRole{
+		Name:        roleName,
+		Description: fmt.Sprintf("ACL Role with the %s policy", policyName),
+		Policies:    []*api.ACLRolePolicyLink{{Name: policyName}},
+		Namespace:   namespace,
+	})
+}
@@ -10,9 +10,18 @@ import (
 )
 
 type rulesData struct {
-	EnablePartitions        bool
-	EnablePeering           bool
-	PartitionName           string
+	EnablePartitions bool
+	EnablePeering    bool
+	PartitionName    string
+	// EnableSpireConnectCA is true if the connect injector configures the
+	// Connect CA with a CA from SPIRE.
+	EnableSpireConnectCA bool
+	// EnableServerUpgradeController is true if the connect injector
+	// orchestrates rollouts of the Consul servers.
+	EnableServerUpgradeController bool
+	// ConfigEntryPartitions are the other partitions that the connect injector
+	// in the default partition manages config entries in.
+	ConfigEntryPartitions   []string
 	EnableNamespaces        bool
 	SyncConsulDestNS        string
 	SyncEnableNSMirroring   bool
@@ -21,6 +30,11 @@ type rulesData struct {
 	InjectEnableNSMirroring bool
 	InjectNSMirroringPrefix string
 	SyncConsulNodeName      string
+	SyncNodeTopology        bool
+	// UseTemplatedPolicies is true if the roles of the components are linked to
+	// templated policies, so their policies leave out the rules that the
+	// templated policies grant.
+	UseTemplatedPolicies bool
 }
 
 type gatewayRulesData struct {
@@ -29,17 +43,6 @@ type gatewayRulesData struct {
 	GatewayNamespace string
 }
 
-const snapshotAgentRules = `acl = "write"
-key "consul-snapshot/lock" {
-   policy = "write"
-}
-session_prefix "" {
-   policy = "write"
-}
-service "consul-snapshot" {
-   policy = "write"
-}`
-
 // The enterprise license rules are acl="write" inside partitions as operator="write"
 // is unsupported in partitions.
 const entLicenseRules = `operator = "write"`
@@ -81,6 +84,26 @@ partition "{{ .PartitionName }}" {
 	return c.renderRules(crossNamespaceRulesTpl)
 }
 
+// partitionReadRules are the rules of the role that services in a non-default
+// partition get by default. They allow reading the mesh config entries of the
+// partition, such as its exported services, and the services and nodes in
+// all of its namespaces.
+func (c *Command) partitionReadRules() (string, error) {
+	partitionReadRulesTpl := `partition "{{ .PartitionName }}" {
+  mesh = "read"
+  namespace_prefix "" {
+    service_prefix "" {
+      policy = "read"
+    }
+    node_prefix "" {
+      policy = "read"
+    }
+  }
+}`
+
+	return c.renderRules(partitionReadRulesTpl)
+}
+
 func (c *Command) agentRules() (string, error) {
 	agentRulesTpl := `
 {{- if .EnablePartitions }}
@@ -177,6 +200,23 @@ namespace_prefix "" {
 	return c.renderRules(apiGatewayRulesTpl)
 }
 
+func (c *Command) snapshotAgentRules() (string, error) {
+	snapshotAgentRulesTpl := `acl = "write"
+key "consul-snapshot/lock" {
+   policy = "write"
+}
+session_prefix "" {
+   policy = "write"
+}
+{{- if not .UseTemplatedPolicies }}
+service "consul-snapshot" {
+   policy = "write"
+}
+{{- end }}`
+
+	return c.renderRules(snapshotAgentRulesTpl)
+}
+
 // This assumes users are using the default name for the service, i.e.
 // "mesh-gateway".
 func (c *Command) meshGatewayRules() (string, error) {
@@ -184,7 +224,8 @@ func (c *Command) meshGatewayRules() (string, error) {
 	// namespaces, it needs access to all namespaces. For peering, it requires the ability to list all peers which in
 	// enterprise requires peering:read on all partitions or in OSS requires a top level peering:read. Since we cannot
 	// determine whether we are using an enterprise or OSS consul image based on whether peering is enabled, we include
-	// both permissions here.
+	// both permissions here. With templated policies, builtin/service only grants the reads in the namespace of the
+	// role, so the reads in the other namespaces are kept.
 	meshGatewayRulesTpl := `mesh = "write"
 {{- if .EnablePeering }}
 peering = "read"
@@ -194,6 +235,7 @@ partition_prefix "" {
 }
 {{- end }}
 {{- end }}
+{{- if not .UseTemplatedPolicies }}
 {{- if .EnableNamespaces }}
 namespace "default" {
 {{- end }}
@@ -202,14 +244,19 @@ namespace "default" {
   }
 {{- if .EnableNamespaces }}
 }
+{{- end }}
+{{- end }}
+{{- if .EnableNamespaces }}
 namespace_prefix "" {
 {{- end }}
+{{- if or .EnableNamespaces (not .UseTemplatedPolicies) }}
   node_prefix "" {
   	policy = "read"
   }
   service_prefix "" {
      policy = "read"
   }
+{{- end }}
 {{- if .EnableNamespaces }}
 }
 {{- end }}
@@ -279,10 +326,19 @@ partition "{{ .PartitionName }}" {
 // Attaching a default ACL policy to a namespace requires acl = "write" in the
 // namespace that the policy is defined in, which in our case is "default".
 func (c *Command) syncRules() (string, error) {
+	// The node prefix allows registering the nodes for k8s nodes when
+	// sync-catalog syncs the node topology.
+	// With templated policies, builtin/node grants writing the node.
 	syncRulesTpl := `
-  node "{{ .SyncConsulNodeName }}" {
+{{- if not .UseTemplatedPolicies }}node "{{ .SyncConsulNodeName }}" {
+    policy = "write"
+  }
+{{- end }}
+{{- if .SyncNodeTopology }}
+  node_prefix "{{ .SyncConsulNodeName }}-" {
     policy = "write"
   }
+{{- end }}
 {{- if .EnableNamespaces }}
 {{- if .EnablePartitions }}
 partition "{{ .PartitionName }}" {
@@ -323,12 +379,19 @@ func (c *Command) injectRules() (string, error) {
 	// It must also create/update service health checks via the endpoints controller.
 	// When ACLs are enabled, the endpoints controller needs "acl:write" permissions
 	// to delete ACL tokens created via "consul login". policy = "write" is required when
-	// creating namespaces within a partition.
+	// creating namespaces within a partition. If config entries are managed
+	// in all partitions, the same permissions are needed in every partition.
+	// Configuring the Connect CA and transferring raft leadership require
+	// "operator:write", which the injector only has in partitions if it manages
+	// a Connect CA from SPIRE or rolls out the Consul servers.
 	injectRulesTpl := `
 {{- if .EnablePartitions }}
 partition "{{ .PartitionName }}" {
   mesh = "write"
   acl = "write"
+{{- if or .EnableSpireConnectCA .EnableServerUpgradeController }}
+  operator = "write"
+{{- end }}
 {{- else }}
   operator = "write"
   acl = "write"
@@ -355,6 +418,18 @@ partition "{{ .PartitionName }}" {
 {{- end }}
 {{- if .EnablePartitions }}
 }
+{{- end }}
+{{- range .ConfigEntryPartitions }}
+partition "{{ . }}" {
+  mesh = "write"
+  namespace_prefix "" {
+    policy = "write"
+    service_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+  }
+}
 {{- end }}`
 	return c.renderRules(injectRulesTpl)
 }
@@ -398,9 +473,14 @@ partition "default" {
 
 func (c *Command) rulesData() rulesData {
 	return rulesData{
-		EnablePartitions:        c.consulFlags.Partition != "",
-		EnablePeering:           c.flagEnablePeering,
-		PartitionName:           c.consulFlags.Partition,
+		EnablePartitions: c.consulFlags.Partition != "",
+		EnablePeering:    c.flagEnablePeering,
+		PartitionName:    c.consulFlags.Partition,
+		EnableSpireConnectCA: c.consulFlags.Partition == consulDefaultPartition &&
+			c.flagEnableSpireConnectCA,
+		EnableServerUpgradeController: c.consulFlags.Partition == consulDefaultPartition &&
+			c.flagEnableServerUpgradeController,
+		ConfigEntryPartitions:   c.configEntryPartitions(),
 		EnableNamespaces:        c.flagEnableNamespaces,
 		SyncConsulDestNS:        c.flagConsulSyncDestinationNamespace,
 		SyncEnableNSMirroring:   c.flagEnableSyncK8SNSMirroring,
@@ -409,6 +489,8 @@ func (c *Command) rulesData() rulesData {
 		InjectEnableNSMirroring: c.flagEnableInjectK8SNSMirroring,
 		InjectNSMirroringPrefix: c.flagInjectK8SNSMirroringPrefix,
 		SyncConsulNodeName:      c.flagSyncConsulNodeName,
+		SyncNodeTopology:        c.flagSyncNodeTopology,
+		UseTemplatedPolicies:    c.flagUseTemplatedPolicies,
 	}
 }
 
@@ -445,3 +527,19 @@ func (c *Command) renderRulesGeneric(tmpl string, data interface{}) (string, err
 
 	return buf.String(), nil
 }
+
+// configEntryPartitions returns the partitions, other than the default
+// partition, that the connect injector manages config entries in. Config
+// entries are only managed in other partitions from the default partition.
+func (c *Command) configEntryPartitions() []string {
+	if c.consulFlags.Partition != consulDefaultPartition {
+		return nil
+	}
+	var partitions []string
+	for _, p := range c.flagConfigEntryPartitions {
+		if p != consulDefaultPartition {
+			partitions = append(partitions, p)
+		}
+	}
+	return partitions
+}
@@ -224,6 +224,7 @@ func TestMeshGatewayRules(t *testing.T) {
 		EnableNamespaces bool
 		EnablePeering    bool
 		PartitionName    string
+		UseTemplated     bool
 		Expected         string
 	}{
 		{
@@ -318,6 +319,27 @@ namespace "default" {
      policy = "write"
   }
 }
+namespace_prefix "" {
+  node_prefix "" {
+  	policy = "read"
+  }
+  service_prefix "" {
+     policy = "read"
+  }
+}`,
+		},
+		{
+			Name:          "Templated policies are used",
+			EnablePeering: true,
+			UseTemplated:  true,
+			Expected: `mesh = "write"
+peering = "read"`,
+		},
+		{
+			Name:             "Templated policies are used, namespaces are enabled",
+			EnableNamespaces: true,
+			UseTemplated:     true,
+			Expected: `mesh = "write"
 namespace_prefix "" {
   node_prefix "" {
   	policy = "read"
@@ -332,8 +354,9 @@ namespace_prefix "" {
 	for _, tt := range cases {
 		t.Run(tt.Name, func(t *testing.T) {
 			cmd := Command{
-				flagEnableNamespaces: tt.EnableNamespaces,
-				flagEnablePeering:    tt.EnablePeering,
+				flagEnableNamespaces:     tt.EnableNamespaces,
+				flagEnablePeering:        tt.EnablePeering,
+				flagUseTemplatedPolicies: tt.UseTemplated,
 				consulFlags: &flags.ConsulFlags{
 					Partition: tt.PartitionName,
 				},
@@ -347,6 +370,53 @@ namespace_prefix "" {
 	}
 }
 
+func TestSnapshotAgentRules(t *testing.T) {
+	cases := []struct {
+		Name         string
+		UseTemplated bool
+		Expected     string
+	}{
+		{
+			Name: "Templated policies are not used",
+			Expected: `acl = "write"
+key "consul-snapshot/lock" {
+   policy = "write"
+}
+session_prefix "" {
+   policy = "write"
+}
+service "consul-snapshot" {
+   policy = "write"
+}`,
+		},
+		{
+			Name:         "Templated policies are used",
+			UseTemplated: true,
+			Expected: `acl = "write"
+key "consul-snapshot/lock" {
+   policy = "write"
+}
+session_prefix "" {
+   policy = "write"
+}`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			cmd := Command{
+				flagUseTemplatedPolicies: tt.UseTemplated,
+				consulFlags:              &flags.ConsulFlags{},
+			}
+
+			snapshotAgentRules, err := cmd.snapshotAgentRules()
+
+			require.NoError(t, err)
+			require.Equal(t, tt.Expected, snapshotAgentRules)
+		})
+	}
+}
+
 func TestIngressGatewayRules(t *testing.T) {
 	cases := []struct {
 		Name             string
@@ -584,6 +654,8 @@ func TestSyncRules(t *testing.T) {
 		EnableSyncK8SNSMirroring       bool
 		SyncK8SNSMirroringPrefix       string
 		SyncConsulNodeName             string
+		SyncNodeTopology               bool
+		UseTemplated                   bool
 		Expected                       string
 	}{
 		{
@@ -597,6 +669,29 @@ func TestSyncRules(t *testing.T) {
 			SyncConsulNodeName:             "k8s-sync",
 			Expected: `node "k8s-sync" {
     policy = "write"
+  }
+    node_prefix "" {
+      policy = "read"
+    }
+    service_prefix "" {
+      policy = "write"
+    }`,
+		},
+		{
+			Name:                           "Namespaces are disabled, node topology is synced",
+			EnablePartitions:               false,
+			PartitionName:                  "",
+			EnableNamespaces:               false,
+			ConsulSyncDestinationNamespace: "sync-namespace",
+			EnableSyncK8SNSMirroring:       true,
+			SyncK8SNSMirroringPrefix:       "prefix-",
+			SyncConsulNodeName:             "k8s-sync",
+			SyncNodeTopology:               true,
+			Expected: `node "k8s-sync" {
+    policy = "write"
+  }
+  node_prefix "k8s-sync-" {
+    policy = "write"
   }
     node_prefix "" {
       policy = "read"
@@ -918,6 +1013,40 @@ partition "foo" {
   }
 }`,
 		},
+		{
+			Name:               "Templated policies are used, node topology is synced",
+			SyncConsulNodeName: "k8s-sync",
+			SyncNodeTopology:   true,
+			UseTemplated:       true,
+			Expected: `
+  node_prefix "k8s-sync-" {
+    policy = "write"
+  }
+    node_prefix "" {
+      policy = "read"
+    }
+    service_prefix "" {
+      policy = "write"
+    }`,
+		},
+		{
+			Name:                           "Templated policies are used, namespaces are enabled",
+			EnableNamespaces:               true,
+			ConsulSyncDestinationNamespace: "sync-namespace",
+			SyncConsulNodeName:             "k8s-sync",
+			UseTemplated:                   true,
+			Expected: `
+  operator = "write"
+  acl = "write"
+  namespace "sync-namespace" {
+    node_prefix "" {
+      policy = "read"
+    }
+    service_prefix "" {
+      policy = "write"
+    }
+  }`,
+		},
 	}
 
 	for _, tt := range cases {
@@ -929,6 +1058,8 @@ partition "foo" {
 				flagEnableSyncK8SNSMirroring:       tt.EnableSyncK8SNSMirroring,
 				flagSyncK8SNSMirroringPrefix:       tt.SyncK8SNSMirroringPrefix,
 				flagSyncConsulNodeName:             tt.SyncConsulNodeName,
+				flagSyncNodeTopology:               tt.SyncNodeTopology,
+				flagUseTemplatedPolicies:           tt.UseTemplated,
 			}
 
 			syncRules, err := cmd.syncRules()
@@ -942,11 +1073,14 @@ partition "foo" {
 // Test the inject rules with namespaces enabled or disabled.
 func TestInjectRules(t *testing.T) {
 	cases := []struct {
-		EnableNamespaces bool
-		EnablePartitions bool
-		EnablePeering    bool
-		PartitionName    string
-		Expected         string
+		EnableNamespaces      bool
+		EnablePartitions      bool
+		EnablePeering         bool
+		PartitionName         string
+		ConfigEntryPartitions []string
+		SpireConnectCA        bool
+		ServerUpgrade         bool
+		Expected              string
 	}{
 		{
 			EnableNamespaces: false,
@@ -1044,18 +1178,157 @@ partition "part-1" {
       intentions = "write"
     }
   }
+}`,
+		},
+		{
+			EnableNamespaces:      true,
+			EnablePartitions:      true,
+			EnablePeering:         false,
+			PartitionName:         "default",
+			ConfigEntryPartitions: []string{"default", "ap1", "ap2"},
+			Expected: `
+partition "default" {
+  mesh = "write"
+  acl = "write"
+  node_prefix "" {
+    policy = "write"
+  }
+  namespace_prefix "" {
+    policy = "write"
+    acl = "write"
+    service_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+  }
+}
+partition "ap1" {
+  mesh = "write"
+  namespace_prefix "" {
+    policy = "write"
+    service_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+  }
+}
+partition "ap2" {
+  mesh = "write"
+  namespace_prefix "" {
+    policy = "write"
+    service_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+  }
+}`,
+		},
+		{
+			EnableNamespaces:      true,
+			EnablePartitions:      true,
+			EnablePeering:         false,
+			PartitionName:         "part-1",
+			ConfigEntryPartitions: []string{"default", "ap1", "ap2"},
+			Expected: `
+partition "part-1" {
+  mesh = "write"
+  acl = "write"
+  node_prefix "" {
+    policy = "write"
+  }
+  namespace_prefix "" {
+    policy = "write"
+    acl = "write"
+    service_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+  }
+}`,
+		},
+		{
+			EnableNamespaces: true,
+			EnablePartitions: true,
+			EnablePeering:    false,
+			PartitionName:    "default",
+			SpireConnectCA:   true,
+			Expected: `
+partition "default" {
+  mesh = "write"
+  acl = "write"
+  operator = "write"
+  node_prefix "" {
+    policy = "write"
+  }
+  namespace_prefix "" {
+    policy = "write"
+    acl = "write"
+    service_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+  }
+}`,
+		},
+		{
+			EnableNamespaces: false,
+			EnablePartitions: true,
+			EnablePeering:    false,
+			PartitionName:    "default",
+			ServerUpgrade:    true,
+			Expected: `
+partition "default" {
+  mesh = "write"
+  acl = "write"
+  operator = "write"
+  node_prefix "" {
+    policy = "write"
+  }
+    policy = "write"
+    acl = "write"
+    service_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+}`,
+		},
+		{
+			EnableNamespaces: true,
+			EnablePartitions: true,
+			EnablePeering:    false,
+			PartitionName:    "part-1",
+			SpireConnectCA:   true,
+			ServerUpgrade:    true,
+			Expected: `
+partition "part-1" {
+  mesh = "write"
+  acl = "write"
+  node_prefix "" {
+    policy = "write"
+  }
+  namespace_prefix "" {
+    policy = "write"
+    acl = "write"
+    service_prefix "" {
+      policy = "write"
+      intentions = "write"
+    }
+  }
 }`,
 		},
 	}
 
 	for _, tt := range cases {
-		caseName := fmt.Sprintf("ns=%t, partition=%t, peering=%t", tt.EnableNamespaces, tt.EnablePartitions, tt.EnablePeering)
+		caseName := fmt.Sprintf("ns=%t, partition=%s, peering=%t, config-entry-partitions=%v, spire-connect-ca=%t, server-upgrade=%t", tt.EnableNamespaces, tt.PartitionName, tt.EnablePeering, tt.ConfigEntryPartitions, tt.SpireConnectCA, tt.ServerUpgrade)
 		t.Run(caseName, func(t *testing.T) {
 
 			cmd := Command{
-				consulFlags:          &flags.ConsulFlags{Partition: tt.PartitionName},
-				flagEnableNamespaces: tt.EnableNamespaces,
-				flagEnablePeering:    tt.EnablePeering,
+				consulFlags:                       &flags.ConsulFlags{Partition: tt.PartitionName},
+				flagEnableNamespaces:              tt.EnableNamespaces,
+				flagEnablePeering:                 tt.EnablePeering,
+				flagConfigEntryPartitions:         tt.ConfigEntryPartitions,
+				flagEnableSpireConnectCA:          tt.SpireConnectCA,
+				flagEnableServerUpgradeController: tt.ServerUpgrade,
 			}
 
 			injectorRules, err := cmd.injectRules()
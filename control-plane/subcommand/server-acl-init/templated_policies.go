@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package serveraclinit
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// With -use-templated-policies, the anonymous token and the ACL roles of the
+// components are linked to the builtin templated policies that grant the
+// permissions they need, instead of policies created for them:
+//
+//   - the anonymous token is linked to builtin/dns.
+//   - ingress and terminating gateways in the default Consul namespace are
+//     linked to builtin/service for the gateway.
+//   - mesh gateways and the snapshot agent are linked to builtin/service for
+//     their service, and the catalog sync to builtin/node for its node. Their
+//     policies only keep the rules that the templated policy doesn't grant.
+//
+// The other components keep their policies since no templated policy grants
+// the permissions they need, e.g. acl = "write" for the connect injector.
+
+// serviceTemplatedPolicy returns the builtin/service templated policy for the
+// service, which grants write access to the service and its sidecar proxy, and
+// read access to all nodes and services.
+func serviceTemplatedPolicy(name string) *api.ACLTemplatedPolicy {
+	return &api.ACLTemplatedPolicy{
+		TemplateName:      api.ACLTemplatedPolicyServiceName,
+		TemplateVariables: &api.ACLTemplatedPolicyVariables{Name: name},
+	}
+}
+
+// nodeTemplatedPolicy returns the builtin/node templated policy for the node,
+// which grants write access to the node and read access to all services.
+func nodeTemplatedPolicy(name string) *api.ACLTemplatedPolicy {
+	return &api.ACLTemplatedPolicy{
+		TemplateName:      api.ACLTemplatedPolicyNodeName,
+		TemplateVariables: &api.ACLTemplatedPolicyVariables{Name: name},
+	}
+}
+
+// withTemplatedPolicy returns policies with tp added, replacing a templated
+// policy with the same name and variables.
+func withTemplatedPolicy(policies []*api.ACLTemplatedPolicy, tp *api.ACLTemplatedPolicy) []*api.ACLTemplatedPolicy {
+	var result []*api.ACLTemplatedPolicy
+	for _, p := range policies {
+		if p.TemplateName == tp.TemplateName && sameTemplateVariables(p.TemplateVariables, tp.TemplateVariables) {
+			continue
+		}
+		result = append(result, p)
+	}
+	return append(result, tp)
+}
+
+func sameTemplateVariables(a, b *api.ACLTemplatedPolicyVariables) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// requireTemplatedPolicies returns an error if the Consul servers don't support
+// templated policies. Consul servers before 1.17 ignore the templated policies of
+// tokens and roles that are written to them, which would leave them without the
+// permissions they need, so this is checked before anything is written.
+func (c *Command) requireTemplatedPolicies(consulClient *api.Client) error {
+	if !c.templatedPoliciesChecked {
+		var supported bool
+		err := c.untilSucceeds(fmt.Sprintf("reading templated policy %s - GET /v1/acl/templated-policy/name/%s", api.ACLTemplatedPolicyDNSName, api.ACLTemplatedPolicyDNSName),
+			func() error {
+				tp, _, err := consulClient.ACL().TemplatedPolicyReadByName(api.ACLTemplatedPolicyDNSName, &api.QueryOptions{})
+				supported = tp != nil
+				return err
+			})
+		if err != nil {
+			return err
+		}
+		c.templatedPoliciesChecked = true
+		c.templatedPoliciesSupported = supported
+	}
+	if !c.templatedPoliciesSupported {
+		return errors.New("the Consul servers don't support templated policies, Consul 1.17 or later is required " +
+			"- re-run without -use-templated-policies")
+	}
+	return nil
+}
+
+// createTemplatedPolicyRoleAndBindingRule is like createACLPolicyRoleAndBindingRule,
+// but links the role of the component to the templated policy tp. The policy of the
+// component only has the given rules, which are the rules that tp doesn't grant, and
+// is deleted if there are none. The role is updated in place, so the links that were
+// added to it otherwise are kept, which migrates the roles of existing installations.
+func (c *Command) createTemplatedPolicyRoleAndBindingRule(componentName string, tp *api.ACLTemplatedPolicy, rules, dc, primaryDC string, global, primary bool, authMethodName, serviceAccountName string, client *api.Client) error {
+	if err := c.requireTemplatedPolicies(client); err != nil {
+		return err
+	}
+	var datacenters []string
+	if !global && dc != "" {
+		datacenters = append(datacenters, dc)
+	}
+	tp.Datacenters = datacenters
+
+	policyName := c.componentPolicyName(componentName, primary, dc)
+	if rules != "" {
+		policyTmpl := api.ACLPolicy{
+			Name:        policyName,
+			Description: fmt.Sprintf("%s Token Policy", policyName),
+			Rules:       rules,
+			Datacenters: datacenters,
+		}
+		err := c.untilSucceeds(fmt.Sprintf("creating %s policy", policyTmpl.Name),
+			func() error {
+				return c.createOrUpdateACLPolicy(policyTmpl, client)
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	aclRoleName := c.componentRoleName(componentName, primary, dc)
+	err := c.untilSucceeds(fmt.Sprintf("update or create acl role %s with templated policy %s", aclRoleName, tp.TemplateName),
+		func() error {
+			role, _, err := client.ACL().RoleReadByName(aclRoleName, &api.QueryOptions{})
+			if err != nil {
+				return err
+			}
+			if role == nil {
+				role = &api.ACLRole{
+					Name:        aclRoleName,
+					Description: fmt.Sprintf("ACL Role for %s", serviceAccountName),
+				}
+			}
+			var policies []*api.ACLRolePolicyLink
+			for _, p := range role.Policies {
+				if p.Name != policyName {
+					policies = append(policies, p)
+				}
+			}
+			if rules != "" {
+				policies = append(policies, &api.ACLRolePolicyLink{Name: policyName})
+			}
+			role.Policies = policies
+			role.TemplatedPolicies = withTemplatedPolicy(role.TemplatedPolicies, tp)
+			if role.ID == "" {
+				_, _, err = client.ACL().RoleCreate(role, &api.WriteOptions{})
+			} else {
+				_, _, err = client.ACL().RoleUpdate(role, &api.WriteOptions{})
+			}
+			return err
+		})
+	if err != nil {
+		return err
+	}
+
+	if rules == "" {
+		err = c.untilSucceeds(fmt.Sprintf("deleting policy %s", policyName),
+			func() error {
+				return c.deleteACLPolicy(policyName, client)
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.addBindingRule(client, aclRoleName, serviceAccountName, authMethodName, global, primaryDC, dc)
+}
+
+// deleteACLPolicy deletes the policy with the given name if it exists.
+func (c *Command) deleteACLPolicy(name string, client *api.Client) error {
+	policy, _, err := client.ACL().PolicyReadByName(name, &api.QueryOptions{})
+	if err != nil || policy == nil {
+		return err
+	}
+	_, err = client.ACL().PolicyDelete(policy.ID, &api.WriteOptions{})
+	return err
+}
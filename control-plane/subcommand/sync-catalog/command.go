@@ -5,12 +5,14 @@ package synccatalog
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"regexp"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -20,15 +22,22 @@ import (
 	catalogtok8s "github.com/hashicorp/consul-k8s/control-plane/catalog/to-k8s"
 	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/helper/controller"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/loglevel"
+	"github.com/hashicorp/consul-k8s/control-plane/helper/secretreload"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
 	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
 	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	capi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-rootcerts"
 	"github.com/mitchellh/cli"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 )
 
 // Command is the command for syncing the K8S and Consul service
@@ -57,6 +66,8 @@ type Command struct {
 	flagAddK8SNamespaceSuffix bool
 	flagLogLevel              string
 	flagLogJSON               bool
+	flagLogLevelConfigMap     string
+	flagLogLevelConfigMapNS   string
 
 	// Flags to support namespaces
 	flagEnableNamespaces           bool     // Use namespacing on all components
@@ -66,11 +77,46 @@ type Command struct {
 	flagEnableK8SNSMirroring       bool     // Enables mirroring of k8s namespaces into Consul
 	flagK8SNSMirroringPrefix       string   // Prefix added to Consul namespaces created when mirroring
 	flagCrossNamespaceACLPolicy    string   // The name of the ACL policy to add to every created namespace if ACLs are enabled
+	flagK8SNSMappings              []string // Explicit mappings of k8s namespaces to Consul namespaces in the form <k8s-ns>=<consul-ns>
+	k8sNSMapping                   map[string]string
 
 	// Flags to support Kubernetes Ingress resources
 	flagEnableIngress   bool // Register services using the hostname from an ingress resource
 	flagLoadBalancerIPs bool // Use the load balancer IP of an ingress resource instead of the hostname
 
+	// Register Consul services in Kubernetes with EndpointSlices instead of ExternalName services
+	flagToK8SEndpointSlices bool
+
+	// Register not ready endpoint addresses with a critical health check
+	flagSyncNotReadyEndpoints bool
+
+	// Register instances on a Consul node per K8s node with the node topology
+	flagSyncNodeTopology bool
+
+	// Additional Consul datacenters to sync K8s services to, in the form <dc>[=<k8s-ns>,...]
+	flagDestinationDatacenters []string
+	// Consul servers, ACL token files and CA files of destination datacenters that are
+	// reached directly, in the form <dc>=<value>
+	flagDestinationServerAddresses []string
+	flagDestinationTokenFiles      []string
+	flagDestinationCACertFiles     []string
+	destinations                   []syncDestination
+
+	// Flags to run multiple replicas with one of them elected to sync at a time
+	flagEnableLeaderElection        bool
+	flagLeaderElectionNamespace     string
+	flagLeaderElectionID            string
+	flagLeaderElectionLeaseDuration time.Duration
+	flagLeaderElectionRenewDeadline time.Duration
+	flagLeaderElectionRetryPeriod   time.Duration
+
+	// Label selector of the K8s services to sync to Consul when they aren't annotated
+	flagK8SServiceSelector string
+	k8sServiceSelector     labels.Selector
+
+	// How often to check the secret files rendered by the Vault agent for changes
+	flagSecretReloadInterval time.Duration
+
 	clientset kubernetes.Interface
 
 	// ready indicates whether this controller is ready to sync services. This will be changed to true once the
@@ -91,10 +137,33 @@ func (c *Command) init() {
 		"If true, K8S services will be synced to Consul.")
 	c.flags.BoolVar(&c.flagToK8S, "to-k8s", true,
 		"If true, Consul services will be synced to Kubernetes.")
+	c.flags.BoolVar(&c.flagToK8SEndpointSlices, "to-k8s-endpoint-slices", false,
+		"If true, Consul services will be synced to Kubernetes as headless services with EndpointSlices "+
+			"listing the healthy Consul service instances instead of ExternalName services pointing at Consul DNS.")
 	c.flags.BoolVar(&c.flagK8SDefault, "k8s-default-sync", true,
 		"If true, all valid services in K8S are synced by default. If false, "+
 			"the service must be annotated properly to sync. In either case "+
 			"an annotation can override the default")
+	c.flags.StringVar(&c.flagK8SServiceSelector, "k8s-service-selector", "",
+		"A Kubernetes label selector for the K8S services to sync to Consul. If set, services "+
+			"without the service-sync annotation are synced only if they match the selector, "+
+			"instead of according to -k8s-default-sync. The annotation can override the selector.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagDestinationDatacenters), "destination-datacenter",
+		"An additional Consul datacenter to sync K8S services to, in the form <datacenter>[=<k8s-namespace>,...]. "+
+			"If k8s namespaces are listed, only services in those namespaces are synced to the datacenter. "+
+			"Requests are forwarded to the datacenter by the local Consul servers, so it must be WAN federated "+
+			"with the local datacenter, unless its servers are set with -destination-server-addresses. "+
+			"May be specified multiple times.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagDestinationServerAddresses), "destination-server-addresses",
+		"The Consul servers of a destination datacenter to connect to directly instead of through the local "+
+			"Consul servers, in the form <datacenter>=<addresses>. The addresses use the same format as -addresses, "+
+			"and the ports and TLS settings of the local servers are used. May be specified multiple times.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagDestinationTokenFiles), "destination-token-file",
+		"The file with the ACL token for the Consul servers set with -destination-server-addresses, in the form "+
+			"<datacenter>=<path>. May be specified multiple times.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagDestinationCACertFiles), "destination-ca-file",
+		"The CA certificate file of the Consul servers set with -destination-server-addresses, in the form "+
+			"<datacenter>=<path>. Defaults to the CA of the local servers. May be specified multiple times.")
 	c.flags.StringVar(&c.flagK8SServicePrefix, "k8s-service-prefix", "",
 		"A prefix to prepend to all services written to Kubernetes from Consul. "+
 			"If this is not set then services will have no prefix.")
@@ -125,6 +194,12 @@ func (c *Command) init() {
 	c.flags.BoolVar(&c.flagSyncLBEndpoints, "sync-lb-services-endpoints", false,
 		"If true, LoadBalancer service endpoints instead of ingress addresses will be synced to Consul. If false, "+
 			"LoadBalancer endpoints are not synced to Consul.")
+	c.flags.BoolVar(&c.flagSyncNotReadyEndpoints, "sync-not-ready-endpoints", false,
+		"If true, not ready endpoint addresses of K8S services are synced to Consul with a critical "+
+			"health check. If false, they are not synced until they are ready.")
+	c.flags.BoolVar(&c.flagSyncNodeTopology, "sync-node-topology", false,
+		"If true, K8S service instances backed by pods are registered on a Consul node per K8S node, "+
+			"and the zone, region and instance type of the K8S node are added to the service and node meta.")
 	c.flags.StringVar(&c.flagNodePortSyncType, "node-port-sync-type", "ExternalOnly",
 		"Defines the type of sync for NodePort services. Valid options are ExternalOnly, "+
 			"InternalOnly and ExternalFirst.")
@@ -137,6 +212,12 @@ func (c *Command) init() {
 			"\"debug\", \"info\", \"warn\", and \"error\".")
 	c.flags.BoolVar(&c.flagLogJSON, "log-json", false,
 		"Enable or disable JSON output format for logging.")
+	c.flags.StringVar(&c.flagLogLevelConfigMap, "log-level-configmap", "",
+		"Name of a ConfigMap to change the log level from at runtime. The log level is read from "+
+			"its \"sync-catalog\" key. If the key or the ConfigMap is removed, the log level set with "+
+			"-log-level is used again.")
+	c.flags.StringVar(&c.flagLogLevelConfigMapNS, "log-level-configmap-namespace", metav1.NamespaceDefault,
+		"Namespace of the ConfigMap set with -log-level-configmap.")
 
 	c.flags.Var((*flags.AppendSliceValue)(&c.flagAllowK8sNamespacesList), "allow-k8s-namespace",
 		"K8s namespaces to explicitly allow. May be specified multiple times.")
@@ -151,6 +232,10 @@ func (c *Command) init() {
 		"namespace mirroring.")
 	c.flags.StringVar(&c.flagK8SNSMirroringPrefix, "k8s-namespace-mirroring-prefix", "",
 		"[Enterprise Only] Prefix that will be added to all k8s namespaces mirrored into Consul if mirroring is enabled.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagK8SNSMappings), "k8s-namespace-mapping",
+		"[Enterprise Only] Maps a k8s namespace to the Consul namespace its services are registered into, in the form "+
+			"<k8s-namespace>=<consul-namespace>. Takes precedence over mirroring and '-consul-destination-namespace'. "+
+			"May be specified multiple times.")
 	c.flags.StringVar(&c.flagCrossNamespaceACLPolicy, "consul-cross-namespace-acl-policy", "",
 		"[Enterprise Only] Name of the ACL policy to attach to all created Consul namespaces to allow service "+
 			"discovery across Consul namespaces. Only necessary if ACLs are enabled.")
@@ -160,6 +245,24 @@ func (c *Command) init() {
 	c.flags.BoolVar(&c.flagLoadBalancerIPs, "loadBalancer-ips", false,
 		"[Enterprise Only] Enables namespaces, in either a single Consul namespace or mirrored.")
 
+	c.flags.BoolVar(&c.flagEnableLeaderElection, "enable-leader-election", false,
+		"If true, replicas elect a leader with a Lease and only the leader syncs services. Standby replicas "+
+			"keep watching K8S and Consul services so that they can take over as soon as they are elected.")
+	c.flags.StringVar(&c.flagLeaderElectionNamespace, "leader-election-namespace", metav1.NamespaceDefault,
+		"Namespace of the leader election Lease.")
+	c.flags.StringVar(&c.flagLeaderElectionID, "leader-election-id", "consul-sync-catalog-leader",
+		"Name of the leader election Lease.")
+	c.flags.DurationVar(&c.flagLeaderElectionLeaseDuration, "leader-election-lease-duration", 15*time.Second,
+		"Duration that standby replicas wait before taking over the Lease of a leader that stopped renewing it.")
+	c.flags.DurationVar(&c.flagLeaderElectionRenewDeadline, "leader-election-renew-deadline", 10*time.Second,
+		"Duration that the leader retries renewing the Lease for before giving up leadership and exiting. "+
+			"Must be less than -leader-election-lease-duration.")
+	c.flags.DurationVar(&c.flagLeaderElectionRetryPeriod, "leader-election-retry-period", 2*time.Second,
+		"Duration between attempts to acquire or renew the Lease.")
+	c.flags.DurationVar(&c.flagSecretReloadInterval, "secret-reload-interval", 0,
+		"How often the Consul CA certificate is checked for changes, e.g. when the Vault agent renews it. "+
+			"Changes are reloaded without restarting. Defaults to 0 which disables reloading.")
+
 	c.consul = &flags.ConsulFlags{}
 	c.k8s = &flags.K8SFlags{}
 	flags.Merge(c.flags, c.consul.Flags())
@@ -231,10 +334,40 @@ func (c *Command) Run(args []string) int {
 			c.UI.Error(fmt.Sprintf("unable to create config for consul-server-connection-manager: %s", err))
 			return 1
 		}
-		c.connMgr, err = discovery.NewWatcher(ctx, serverConnMgrCfg, c.logger.Named("consul-server-connection-manager"))
-		if err != nil {
-			c.UI.Error(fmt.Sprintf("unable to create Consul server watcher: %s", err))
-			return 1
+		if c.flagSecretReloadInterval > 0 && serverConnMgrCfg.TLS != nil && c.consul.CACertFile != "" {
+			secretWatcher := &secretreload.Watcher{
+				PollInterval: c.flagSecretReloadInterval,
+				Log:          c.logger.Named("secret-reload"),
+			}
+			// The HTTP API clients read the CA cert file whenever they are
+			// created, so only the gRPC connections need to reload it.
+			if serverName := c.consul.ServerName(); serverName == "" {
+				c.logger.Warn("Consul's CA cert is not reloaded for gRPC connections because -tls-server-name is not set")
+			} else if err := secretWatcher.WatchRootCAs(c.consul.CACertFile, serverConnMgrCfg.TLS, serverName); err != nil {
+				c.UI.Error(fmt.Sprintf("unable to reload Consul's CA cert file %q: %s", c.consul.CACertFile, err))
+				return 1
+			}
+			go secretWatcher.Run(ctx)
+		}
+		if c.consul.TokenFile != "" && c.consul.ConsulLogin.AuthMethod == "" {
+			// The token in the file is rotated, so the watcher is replaced
+			// whenever the token changes.
+			c.connMgr = &consul.TokenFileConnMgr{
+				TokenFile: c.consul.TokenFile,
+				Interval:  tokenFileInterval,
+				NewConnMgr: func(token string) (consul.ServerConnectionManager, error) {
+					cfg := serverConnMgrCfg
+					cfg.Credentials.Static.Token = token
+					return discovery.NewWatcher(ctx, cfg, c.logger.Named("consul-server-connection-manager"))
+				},
+				Log: c.logger.Named("consul-server-connection-manager"),
+			}
+		} else {
+			c.connMgr, err = discovery.NewWatcher(ctx, serverConnMgrCfg, c.logger.Named("consul-server-connection-manager"))
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("unable to create Consul server watcher: %s", err))
+				return 1
+			}
 		}
 
 		go c.connMgr.Run()
@@ -264,6 +397,38 @@ func (c *Command) Run(args []string) int {
 	// Create the context we'll use to cancel everything
 	ctx, cancelF := context.WithCancel(context.Background())
 
+	if c.flagLogLevelConfigMap != "" {
+		logLevelWatcher := &loglevel.Watcher{
+			Client:        c.clientset,
+			Namespace:     c.flagLogLevelConfigMapNS,
+			ConfigMapName: c.flagLogLevelConfigMap,
+			Component:     "sync-catalog",
+			DefaultLevel:  c.flagLogLevel,
+			SetLevel: func(level string) error {
+				return common.SetLoggerLevel(c.logger, level)
+			},
+			Log: c.logger.Named("log-level"),
+		}
+		go logLevelWatcher.Run(ctx)
+	}
+
+	// Without leader election, this replica is always the leader. Otherwise
+	// only the writes to Consul and K8S wait for it to be elected, and the
+	// watches run right away to keep the standby's state warm.
+	leading := make(chan struct{})
+	lostLeadershipCh := make(chan struct{})
+	if c.flagEnableLeaderElection {
+		elector, err := c.leaderElector(leading, lostLeadershipCh)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error setting up leader election: %s", err))
+			cancelF()
+			return 1
+		}
+		go elector.Run(ctx)
+	} else {
+		close(leading)
+	}
+
 	// Start the K8S-to-Consul syncer
 	var toConsulCh chan struct{}
 	if c.flagToConsul {
@@ -279,7 +444,63 @@ func (c *Command) Run(args []string) int {
 			ConsulK8STag:            c.flagConsulK8STag,
 			ConsulNodeName:          c.flagConsulNodeName,
 		}
-		go syncer.Run(ctx)
+		go runWhenLeading(ctx, leading, syncer.Run)
+
+		// Sync to the additional datacenters with a syncer each.
+		var resourceSyncer catalogtoconsul.Syncer = syncer
+		if len(c.destinations) > 0 {
+			syncers := catalogtoconsul.MultiSyncer{syncer}
+			for _, dest := range c.destinations {
+				destConfig, err := datacenterConfig(consulConfig, dest)
+				if err != nil {
+					c.UI.Error(fmt.Sprintf("unable to create Consul config for datacenter %s: %s", dest.datacenter, err))
+					cancelF()
+					return 1
+				}
+				// Destinations with their own servers are connected to directly,
+				// the others through the local servers.
+				destConnMgr := c.connMgr
+				if dest.serverAddresses != "" {
+					destConnMgrCfg, err := c.destinationServerConnMgrConfig(dest)
+					if err != nil {
+						c.UI.Error(fmt.Sprintf("unable to create config for the Consul servers of datacenter %s: %s", dest.datacenter, err))
+						cancelF()
+						return 1
+					}
+					watcher, err := discovery.NewWatcher(ctx, destConnMgrCfg, c.logger.Named("consul-server-connection-manager").With("datacenter", dest.datacenter))
+					if err != nil {
+						c.UI.Error(fmt.Sprintf("unable to create Consul server watcher for datacenter %s: %s", dest.datacenter, err))
+						cancelF()
+						return 1
+					}
+					go watcher.Run()
+					defer watcher.Stop()
+					destConnMgr = watcher
+				}
+				destSyncer := &catalogtoconsul.ConsulSyncer{
+					ConsulClientConfig:      destConfig,
+					ConsulServerConnMgr:     destConnMgr,
+					Log:                     c.logger.Named("to-consul/sink").With("datacenter", dest.datacenter),
+					EnableNamespaces:        c.flagEnableNamespaces,
+					CrossNamespaceACLPolicy: c.flagCrossNamespaceACLPolicy,
+					SyncPeriod:              c.flagConsulWritePeriod,
+					ServicePollPeriod:       c.flagConsulWritePeriod * 2,
+					ConsulK8STag:            c.flagConsulK8STag,
+					ConsulNodeName:          c.flagConsulNodeName,
+				}
+				go runWhenLeading(ctx, leading, destSyncer.Run)
+
+				if dest.k8sNamespaces != nil {
+					syncers = append(syncers, &catalogtoconsul.K8SNamespaceFilterSyncer{
+						Syncer:        destSyncer,
+						K8SNamespaces: dest.k8sNamespaces,
+					})
+				} else {
+					syncers = append(syncers, destSyncer)
+				}
+			}
+			resourceSyncer = syncers
+		}
 
 		// Build the controller and start it
 		ctl := &controller.Controller{
@@ -287,13 +508,16 @@ func (c *Command) Run(args []string) int {
 			Resource: &catalogtoconsul.ServiceResource{
 				Log:                        c.logger.Named("to-consul/source"),
 				Client:                     c.clientset,
-				Syncer:                     syncer,
+				Syncer:                     resourceSyncer,
 				Ctx:                        ctx,
 				AllowK8sNamespacesSet:      allowSet,
 				DenyK8sNamespacesSet:       denySet,
 				ExplicitEnable:             !c.flagK8SDefault,
+				ServiceSelector:            c.k8sServiceSelector,
 				ClusterIPSync:              c.flagSyncClusterIPServices,
 				LoadBalancerEndpointsSync:  c.flagSyncLBEndpoints,
+				SyncNotReadyEndpoints:      c.flagSyncNotReadyEndpoints,
+				SyncNodeTopology:           c.flagSyncNodeTopology,
 				NodePortSync:               catalogtoconsul.NodePortSyncType(c.flagNodePortSyncType),
 				ConsulK8STag:               c.flagConsulK8STag,
 				ConsulServicePrefix:        c.flagConsulServicePrefix,
@@ -302,6 +526,8 @@ func (c *Command) Run(args []string) int {
 				ConsulDestinationNamespace: c.flagConsulDestinationNamespace,
 				EnableK8SNSMirroring:       c.flagEnableK8SNSMirroring,
 				K8SNSMirroringPrefix:       c.flagK8SNSMirroringPrefix,
+				K8SNSMapping:               c.k8sNSMapping,
+				ConsulPartition:            c.consul.Partition,
 				ConsulNodeName:             c.flagConsulNodeName,
 				EnableIngress:              c.flagEnableIngress,
 				SyncLoadBalancerIPs:        c.flagLoadBalancerIPs,
@@ -319,10 +545,12 @@ func (c *Command) Run(args []string) int {
 	var toK8SCh chan struct{}
 	if c.flagToK8S {
 		sink := &catalogtok8s.K8SSink{
-			Client:    c.clientset,
-			Namespace: c.flagK8SWriteNamespace,
-			Log:       c.logger.Named("to-k8s/sink"),
-			Ctx:       ctx,
+			Client:             c.clientset,
+			Namespace:          c.flagK8SWriteNamespace,
+			Log:                c.logger.Named("to-k8s/sink"),
+			Ctx:                ctx,
+			SyncEndpointSlices: c.flagToK8SEndpointSlices,
+			Leading:            leading,
 		}
 
 		source := &catalogtok8s.Source{
@@ -333,6 +561,7 @@ func (c *Command) Run(args []string) int {
 			Prefix:              c.flagK8SServicePrefix,
 			Log:                 c.logger.Named("to-k8s/source"),
 			ConsulK8STag:        c.flagConsulK8STag,
+			SyncEndpoints:       c.flagToK8SEndpointSlices,
 		}
 		go source.Run(ctx)
 
@@ -378,6 +607,18 @@ func (c *Command) Run(args []string) int {
 		}
 		return 1
 
+	// Lost the Lease to another replica, exit so that this one restarts as a standby
+	case <-lostLeadershipCh:
+		c.logger.Error("lost leader election Lease, shutting down")
+		cancelF()
+		if toConsulCh != nil {
+			<-toConsulCh
+		}
+		if toK8SCh != nil {
+			<-toK8SCh
+		}
+		return 1
+
 	// Interrupted/terminated, gracefully exit
 	case sig := <-c.sigCh:
 		c.logger.Info(fmt.Sprintf("%s received, shutting down", sig))
@@ -437,9 +678,227 @@ func (c *Command) validateFlags() error {
 		)
 	}
 
+	if c.flagK8SServiceSelector != "" {
+		selector, err := labels.Parse(c.flagK8SServiceSelector)
+		if err != nil {
+			return fmt.Errorf("-k8s-service-selector=%s is invalid: %s", c.flagK8SServiceSelector, err)
+		}
+		c.k8sServiceSelector = selector
+	}
+
+	for _, raw := range c.flagDestinationDatacenters {
+		dc, k8sNSList, hasNamespaces := strings.Cut(raw, "=")
+		dest := syncDestination{datacenter: strings.TrimSpace(dc)}
+		if dest.datacenter == "" {
+			return fmt.Errorf("-destination-datacenter=%s is invalid: must be in the form <datacenter>[=<k8s-namespace>,...]", raw)
+		}
+		if hasNamespaces {
+			dest.k8sNamespaces = mapset.NewSet()
+			for _, ns := range strings.Split(k8sNSList, ",") {
+				if ns = strings.TrimSpace(ns); ns != "" {
+					dest.k8sNamespaces.Add(ns)
+				}
+			}
+			if dest.k8sNamespaces.Cardinality() == 0 {
+				return fmt.Errorf("-destination-datacenter=%s is invalid: must be in the form <datacenter>[=<k8s-namespace>,...]", raw)
+			}
+		}
+		c.destinations = append(c.destinations, dest)
+	}
+	for _, opt := range []struct {
+		name   string
+		values []string
+		set    func(*syncDestination, string)
+	}{
+		{"destination-server-addresses", c.flagDestinationServerAddresses, func(d *syncDestination, v string) { d.serverAddresses = v }},
+		{"destination-token-file", c.flagDestinationTokenFiles, func(d *syncDestination, v string) { d.tokenFile = v }},
+		{"destination-ca-file", c.flagDestinationCACertFiles, func(d *syncDestination, v string) { d.caCertFile = v }},
+	} {
+		for _, raw := range opt.values {
+			dc, value, ok := strings.Cut(raw, "=")
+			dc, value = strings.TrimSpace(dc), strings.TrimSpace(value)
+			if !ok || dc == "" || value == "" {
+				return fmt.Errorf("-%s=%s is invalid: must be in the form <datacenter>=<value>", opt.name, raw)
+			}
+			dest := c.destination(dc)
+			if dest == nil {
+				return fmt.Errorf("-%s=%s is invalid: %s is not set with -destination-datacenter", opt.name, raw, dc)
+			}
+			opt.set(dest, value)
+		}
+	}
+	for _, dest := range c.destinations {
+		if dest.serverAddresses == "" && (dest.tokenFile != "" || dest.caCertFile != "") {
+			return fmt.Errorf("-destination-token-file and -destination-ca-file require -destination-server-addresses for datacenter %s", dest.datacenter)
+		}
+	}
+
+	for _, mapping := range c.flagK8SNSMappings {
+		k8sNS, consulNS, ok := strings.Cut(mapping, "=")
+		if !ok || k8sNS == "" || consulNS == "" {
+			return fmt.Errorf("-k8s-namespace-mapping=%s is invalid: must be in the form <k8s-namespace>=<consul-namespace>", mapping)
+		}
+		if c.k8sNSMapping == nil {
+			c.k8sNSMapping = make(map[string]string)
+		}
+		c.k8sNSMapping[k8sNS] = consulNS
+	}
+
+	if c.flagEnableLeaderElection {
+		if c.flagLeaderElectionRenewDeadline >= c.flagLeaderElectionLeaseDuration {
+			return fmt.Errorf("-leader-election-renew-deadline=%s must be less than -leader-election-lease-duration=%s",
+				c.flagLeaderElectionRenewDeadline, c.flagLeaderElectionLeaseDuration)
+		}
+		if c.flagLeaderElectionRetryPeriod <= 0 {
+			return fmt.Errorf("-leader-election-retry-period=%s must be greater than 0", c.flagLeaderElectionRetryPeriod)
+		}
+	}
+
 	return nil
 }
 
+// leaderElector returns an elector that closes leading when this replica is
+// elected leader and closes lost if it loses the Lease afterwards. The Lease is
+// released when the elector's context is cancelled so that a standby replica
+// takes over right away on shutdown, e.g. when the node is drained.
+func (c *Command) leaderElector(leading, lost chan struct{}) (*leaderelection.LeaderElector, error) {
+	identity, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	log := c.logger.Named("leader-election")
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      c.flagLeaderElectionID,
+				Namespace: c.flagLeaderElectionNamespace,
+			},
+			Client:     c.clientset.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		},
+		LeaseDuration:   c.flagLeaderElectionLeaseDuration,
+		RenewDeadline:   c.flagLeaderElectionRenewDeadline,
+		RetryPeriod:     c.flagLeaderElectionRetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            c.flagLeaderElectionID,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				log.Info("elected leader", "identity", identity)
+				close(leading)
+			},
+			OnStoppedLeading: func() {
+				close(lost)
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					log.Info("standing by for leader", "leader", leader)
+				}
+			},
+		},
+	})
+}
+
+// runWhenLeading calls run once leading is closed, unless ctx is cancelled first.
+func runWhenLeading(ctx context.Context, leading <-chan struct{}, run func(context.Context)) {
+	select {
+	case <-leading:
+		run(ctx)
+	case <-ctx.Done():
+	}
+}
+
+// syncDestination is an additional Consul datacenter to sync K8s services to.
+type syncDestination struct {
+	datacenter string
+	// k8sNamespaces is the set of k8s namespaces whose services are synced
+	// to the datacenter. If nil, services in all namespaces are synced.
+	k8sNamespaces mapset.Set
+
+	// serverAddresses are the Consul servers of the datacenter to connect to
+	// directly. If empty, requests are forwarded by the local Consul servers.
+	serverAddresses string
+	// tokenFile and caCertFile are the ACL token and the CA certificate used
+	// for the servers in serverAddresses.
+	tokenFile  string
+	caCertFile string
+}
+
+// destination returns the destination for the datacenter dc, or nil if dc is
+// not a destination.
+func (c *Command) destination(dc string) *syncDestination {
+	for i := range c.destinations {
+		if c.destinations[i].datacenter == dc {
+			return &c.destinations[i]
+		}
+	}
+	return nil
+}
+
+// destinationServerConnMgrConfig returns the consul-server-connection-manager
+// config for the servers of a destination that is connected to directly. It
+// uses the ports and TLS settings of the local servers, and the destination's
+// own token and CA certificate.
+func (c *Command) destinationServerConnMgrConfig(dest syncDestination) (discovery.Config, error) {
+	cfg, err := c.consul.ConsulServerConnMgrConfig()
+	if err != nil {
+		return discovery.Config{}, err
+	}
+	cfg.Addresses = dest.serverAddresses
+	// The server CIDRs only apply to the local servers.
+	cfg.ServerEvalFn = nil
+
+	if cfg.TLS != nil && dest.caCertFile != "" {
+		tlsConfig := &tls.Config{ServerName: cfg.TLS.ServerName}
+		if err := rootcerts.ConfigureTLS(tlsConfig, &rootcerts.Config{CAFile: dest.caCertFile}); err != nil {
+			return discovery.Config{}, err
+		}
+		cfg.TLS = tlsConfig
+	}
+
+	// The local credentials, e.g. an auth method, aren't valid in another
+	// datacenter that isn't federated with it.
+	cfg.Credentials = discovery.Credentials{}
+	if dest.tokenFile != "" {
+		token, err := os.ReadFile(dest.tokenFile)
+		if err != nil {
+			return discovery.Config{}, err
+		}
+		cfg.Credentials.Type = discovery.CredentialsTypeStatic
+		cfg.Credentials.Static.Token = strings.TrimSpace(string(token))
+	}
+	return cfg, nil
+}
+
+// datacenterConfig returns a copy of cfg for sending requests to the Consul
+// datacenter of dest. Unless dest has its own servers, the requests are sent
+// to the local Consul servers, which forward them to the datacenter over the
+// WAN. The copy has its own HTTP client and transport so that requests to a
+// slow or unreachable datacenter don't hold the connections used for the
+// local datacenter.
+func datacenterConfig(cfg *consul.Config, dest syncDestination) (*consul.Config, error) {
+	apiCfg := *cfg.APIClientConfig
+	apiCfg.Datacenter = dest.datacenter
+	if dest.serverAddresses != "" {
+		// The token is set from the server connection manager's state.
+		apiCfg.Token, apiCfg.TokenFile = "", ""
+		if dest.caCertFile != "" {
+			apiCfg.TLSConfig.CAFile, apiCfg.TLSConfig.CAPem = dest.caCertFile, nil
+		}
+	}
+	tlsClientConfig, err := capi.SetupTLSConfig(&apiCfg.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	apiCfg.Transport = &http.Transport{TLSClientConfig: tlsClientConfig}
+	apiCfg.HttpClient = &http.Client{Timeout: cfg.APITimeout}
+	dcCfg := *cfg
+	dcCfg.APIClientConfig = &apiCfg
+	return &dcCfg, nil
+}
+
+// tokenFileInterval is how often the ACL token file is checked for a new token.
+const tokenFileInterval = 10 * time.Second
+
 const synopsis = "Sync Kubernetes services and Consul services."
 const help = `
 Usage: consul-k8s-control-plane sync-catalog [options]
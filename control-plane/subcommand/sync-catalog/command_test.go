@@ -5,13 +5,19 @@ package synccatalog
 
 import (
 	"context"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"syscall"
 	"testing"
 	"time"
 
+	mapset "github.com/deckarep/golang-set"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
 	"github.com/hashicorp/consul-k8s/control-plane/helper/test"
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	capi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/go-hclog"
 	"github.com/mitchellh/cli"
@@ -39,6 +45,42 @@ func TestRun_FlagValidation(t *testing.T) {
 			ExpErr: "-consul-node-name=5r9OPGfSRXUdGzNjBdAwmhCBrzHDNYs4XjZVR4wp7lSLIzqwS0ta51nBLIN0TMPV-too-long is invalid: node name will not be discoverable " +
 				"via DNS due to it being too long. Valid lengths are between 1 and 63 bytes",
 		},
+		{
+			Flags:  []string{"-k8s-service-selector=tier in (frontend"},
+			ExpErr: "-k8s-service-selector=tier in (frontend is invalid",
+		},
+		{
+			Flags:  []string{"-k8s-namespace-mapping=foo"},
+			ExpErr: "-k8s-namespace-mapping=foo is invalid: must be in the form <k8s-namespace>=<consul-namespace>",
+		},
+		{
+			Flags:  []string{"-destination-datacenter==foo"},
+			ExpErr: "-destination-datacenter==foo is invalid: must be in the form <datacenter>[=<k8s-namespace>,...]",
+		},
+		{
+			Flags:  []string{"-destination-datacenter=dc2=,"},
+			ExpErr: "-destination-datacenter=dc2=, is invalid: must be in the form <datacenter>[=<k8s-namespace>,...]",
+		},
+		{
+			Flags:  []string{"-destination-server-addresses=dc2=consul.dc2.example.com"},
+			ExpErr: "-destination-server-addresses=dc2=consul.dc2.example.com is invalid: dc2 is not set with -destination-datacenter",
+		},
+		{
+			Flags:  []string{"-destination-datacenter=dc2", "-destination-token-file=dc2"},
+			ExpErr: "-destination-token-file=dc2 is invalid: must be in the form <datacenter>=<value>",
+		},
+		{
+			Flags:  []string{"-destination-datacenter=dc2", "-destination-ca-file=dc2=/ca.pem"},
+			ExpErr: "-destination-token-file and -destination-ca-file require -destination-server-addresses for datacenter dc2",
+		},
+		{
+			Flags:  []string{"-enable-leader-election", "-leader-election-lease-duration=10s"},
+			ExpErr: "-leader-election-renew-deadline=10s must be less than -leader-election-lease-duration=10s",
+		},
+		{
+			Flags:  []string{"-enable-leader-election", "-leader-election-retry-period=0s"},
+			ExpErr: "-leader-election-retry-period=0s must be greater than 0",
+		},
 	}
 
 	for _, c := range cases {
@@ -54,6 +96,91 @@ func TestRun_FlagValidation(t *testing.T) {
 	}
 }
 
+// Test that destination datacenters are parsed and that the Consul config
+// used for each doesn't change the original config.
+func TestRun_DestinationDatacenters(t *testing.T) {
+	t.Parallel()
+	cmd := Command{UI: cli.NewMockUi()}
+	cmd.init()
+	require.NoError(t, cmd.flags.Parse([]string{
+		"-destination-datacenter=dc2",
+		"-destination-datacenter=dc3=foo, bar",
+	}))
+	require.NoError(t, cmd.validateFlags())
+	require.Len(t, cmd.destinations, 2)
+	require.Equal(t, "dc2", cmd.destinations[0].datacenter)
+	require.Nil(t, cmd.destinations[0].k8sNamespaces)
+	require.Equal(t, "dc3", cmd.destinations[1].datacenter)
+	require.True(t, cmd.destinations[1].k8sNamespaces.Equal(mapset.NewSet("foo", "bar")))
+
+	cfg := &consul.Config{
+		APIClientConfig: &capi.Config{Datacenter: "dc1", HttpClient: &http.Client{}},
+		HTTPPort:        8500,
+		APITimeout:      5 * time.Second,
+	}
+	dcCfg, err := datacenterConfig(cfg, cmd.destinations[0])
+	require.NoError(t, err)
+	require.Equal(t, "dc2", dcCfg.APIClientConfig.Datacenter)
+	require.Equal(t, 8500, dcCfg.HTTPPort)
+	require.Equal(t, "dc1", cfg.APIClientConfig.Datacenter)
+
+	// The datacenter has its own HTTP client and transport.
+	require.NotSame(t, cfg.APIClientConfig.HttpClient, dcCfg.APIClientConfig.HttpClient)
+	require.Equal(t, 5*time.Second, dcCfg.APIClientConfig.HttpClient.Timeout)
+	require.NotNil(t, dcCfg.APIClientConfig.Transport)
+	require.Nil(t, cfg.APIClientConfig.Transport)
+}
+
+// Test that destination datacenters with their own servers are connected to
+// directly with their own token and CA, and the ports and TLS settings of the
+// local servers.
+func TestRun_DestinationServers(t *testing.T) {
+	t.Parallel()
+	tmp := t.TempDir()
+	tokenFile := filepath.Join(tmp, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("dc2-token\n"), 0600))
+	caFile, _, _ := test.GenerateServerCerts(t)
+
+	cmd := Command{UI: cli.NewMockUi()}
+	cmd.init()
+	require.NoError(t, cmd.flags.Parse([]string{
+		"-addresses=consul.dc1.example.com",
+		"-grpc-port=8503",
+		"-use-tls",
+		"-tls-server-name=server.dc1.consul",
+		"-token=dc1-token",
+		"-server-cidrs=10.0.0.0/8",
+		"-destination-datacenter=dc2",
+		"-destination-datacenter=dc3",
+		"-destination-server-addresses=dc2=exec=discover -q addrs provider=aws",
+		"-destination-token-file=dc2=" + tokenFile,
+		"-destination-ca-file=dc2=" + caFile,
+	}))
+	require.NoError(t, cmd.validateFlags())
+	require.Equal(t, "exec=discover -q addrs provider=aws", cmd.destinations[0].serverAddresses)
+	require.Equal(t, tokenFile, cmd.destinations[0].tokenFile)
+	require.Equal(t, caFile, cmd.destinations[0].caCertFile)
+	require.Empty(t, cmd.destinations[1].serverAddresses)
+
+	connMgrCfg, err := cmd.destinationServerConnMgrConfig(cmd.destinations[0])
+	require.NoError(t, err)
+	require.Equal(t, "exec=discover -q addrs provider=aws", connMgrCfg.Addresses)
+	require.Equal(t, 8503, connMgrCfg.GRPCPort)
+	require.Equal(t, "server.dc1.consul", connMgrCfg.TLS.ServerName)
+	require.NotNil(t, connMgrCfg.TLS.RootCAs)
+	require.Equal(t, discovery.CredentialsTypeStatic, connMgrCfg.Credentials.Type)
+	require.Equal(t, "dc2-token", connMgrCfg.Credentials.Static.Token)
+	require.Nil(t, connMgrCfg.ServerEvalFn)
+
+	cfg := cmd.consul.ConsulClientConfig()
+	dcCfg, err := datacenterConfig(cfg, cmd.destinations[0])
+	require.NoError(t, err)
+	require.Equal(t, "dc2", dcCfg.APIClientConfig.Datacenter)
+	require.Empty(t, dcCfg.APIClientConfig.Token)
+	require.Equal(t, caFile, dcCfg.APIClientConfig.TLSConfig.CAFile)
+	require.Equal(t, "dc1-token", cfg.APIClientConfig.Token)
+}
+
 // Test that the default consul service is synced to k8s.
 func TestRun_Defaults_SyncsConsulServiceToK8s(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,517 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package uninstallcleanup
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	connectcommon "github.com/hashicorp/consul-k8s/control-plane/connect-inject/common"
+	"github.com/hashicorp/consul-k8s/control-plane/connect-inject/constants"
+	"github.com/hashicorp/consul-k8s/control-plane/consul"
+	"github.com/hashicorp/consul-k8s/control-plane/namespaces"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/flags"
+	"github.com/hashicorp/consul-server-connection-manager/discovery"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-hclog"
+	"github.com/mitchellh/cli"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// metaKeyManagedBy is the meta key of the services registered by the
+	// endpoints controller, whose value is constants.ManagedByValue.
+	metaKeyManagedBy = "managed-by"
+
+	// metaKeyExternalSource is the meta key of the Consul namespaces created
+	// for Kubernetes namespaces, whose value is externalSourceKubernetes.
+	metaKeyExternalSource    = "external-source"
+	externalSourceKubernetes = "kubernetes"
+)
+
+// step is a part of the cleanup that is retried until it succeeds.
+type step struct {
+	name string
+	fn   func() error
+}
+
+type Command struct {
+	UI cli.Ui
+
+	flags  *flag.FlagSet
+	k8s    *flags.K8SFlags
+	consul *flags.ConsulFlags
+
+	flagNamespace        string
+	flagDeployments      []string
+	flagClusterName      string
+	flagAuthMethods      []string
+	flagSyncNodeName     string
+	flagSyncK8sTag       string
+	flagEnableNamespaces bool
+	flagDeleteNamespaces bool
+	flagDeletePartition  bool
+	flagTimeout          time.Duration
+
+	flagLogLevel string
+	flagLogJSON  bool
+
+	k8sClient    client.Client
+	consulClient *api.Client
+
+	// ctx is cancelled when the command timeout is reached.
+	ctx           context.Context
+	retryDuration time.Duration
+
+	log  hclog.Logger
+	once sync.Once
+	help string
+}
+
+func (c *Command) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+
+	c.flags.StringVar(&c.flagLogLevel, "log-level", "info",
+		"Log verbosity level. Supported values (in order of detail) are \"trace\", "+
+			"\"debug\", \"info\", \"warn\", and \"error\".")
+	c.flags.BoolVar(&c.flagLogJSON, "log-json", false, "Enable or disable JSON output format for logging.")
+	c.flags.StringVar(&c.flagNamespace, "k8s-namespace", "", "Name of the Kubernetes namespace of the deployments to stop.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagDeployments), "deployment",
+		"Name of a deployment that registers services in Consul, e.g. the connect injector or the catalog sync. "+
+			"It is scaled down before the cleanup so that the services aren't registered again. May be specified multiple times.")
+	c.flags.StringVar(&c.flagClusterName, "cluster-name", "",
+		"Name of this Kubernetes cluster, if it was set on the connect injector.")
+	c.flags.Var((*flags.AppendSliceValue)(&c.flagAuthMethods), "auth-method",
+		"Name of an auth method whose ACL tokens are deleted. May be specified multiple times.")
+	c.flags.StringVar(&c.flagSyncNodeName, "sync-consul-node-name", "",
+		"Name of the Consul node the catalog sync registers services on. The synced services are only deregistered if it is set.")
+	c.flags.StringVar(&c.flagSyncK8sTag, "sync-consul-k8s-tag", "k8s", "Tag of the services registered by the catalog sync.")
+	c.flags.BoolVar(&c.flagEnableNamespaces, "enable-namespaces", false,
+		"[Enterprise Only] Enables Consul Enterprise namespaces, in which case services and tokens are cleaned up in all namespaces.")
+	c.flags.BoolVar(&c.flagDeleteNamespaces, "delete-namespaces", false,
+		"[Enterprise Only] Delete the Consul namespaces that were created for Kubernetes namespaces.")
+	c.flags.BoolVar(&c.flagDeletePartition, "delete-partition", false,
+		"[Enterprise Only] Delete the Admin Partition set with -partition if it was created by partition-init.")
+	c.flags.DurationVar(&c.flagTimeout, "timeout", 10*time.Minute,
+		"How long we'll try to clean up for before timing out, e.g. 1ms, 2s, 3m")
+
+	c.k8s = &flags.K8SFlags{}
+	c.consul = &flags.ConsulFlags{}
+	flags.Merge(c.flags, c.k8s.Flags())
+	flags.Merge(c.flags, c.consul.Flags())
+	c.help = flags.Usage(help, c.flags)
+
+	// Default retry to 1s. This is exposed for setting in tests.
+	if c.retryDuration == 0 {
+		c.retryDuration = 1 * time.Second
+	}
+}
+
+// Run removes the state this Kubernetes cluster created in Consul. Each step is
+// attempted even if an earlier one failed, and the command always succeeds so
+// that it doesn't block the uninstall.
+func (c *Command) Run(args []string) int {
+	c.once.Do(c.init)
+	if err := c.flags.Parse(args); err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+	if err := c.validateFlags(); err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to validate flags: %v", err))
+		return 1
+	}
+
+	var err error
+	c.log, err = common.Logger(c.flagLogLevel, c.flagLogJSON)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	var cancel context.CancelFunc
+	c.ctx, cancel = context.WithTimeout(context.Background(), c.flagTimeout)
+	// The context will only ever be intentionally ended by the timeout.
+	defer cancel()
+
+	if c.k8sClient == nil {
+		config, err := subcommand.K8SConfig(c.k8s.KubeConfig())
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to create Kubernetes config: %v", err))
+			return 1
+		}
+		s := runtime.NewScheme()
+		if err := clientgoscheme.AddToScheme(s); err != nil {
+			c.UI.Error(fmt.Sprintf("Could not add client-go schema: %s", err))
+			return 1
+		}
+		if err := v1alpha1.AddToScheme(s); err != nil {
+			c.UI.Error(fmt.Sprintf("Could not add consul-k8s schema: %s", err))
+			return 1
+		}
+		c.k8sClient, err = client.New(config, client.Options{Scheme: s})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to create Kubernetes client: %v", err))
+			return 1
+		}
+	}
+
+	if c.consulClient == nil {
+		serverConnMgrCfg, err := c.consul.ConsulServerConnMgrConfig()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("unable to create config for consul-server-connection-manager: %s", err))
+			return 1
+		}
+		serverConnMgrCfg.ServerWatchDisabled = true
+		watcher, err := discovery.NewWatcher(c.ctx, serverConnMgrCfg, c.log.Named("consul-server-connection-manager"))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("unable to create Consul server watcher: %s", err))
+			return 1
+		}
+		go watcher.Run()
+		defer watcher.Stop()
+
+		c.consulClient, err = consul.NewClientFromConnMgr(c.consul.ConsulClientConfig(), watcher)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("unable to create Consul client: %s", err))
+			return 1
+		}
+	}
+
+	// The deployments are stopped first so that they don't register the
+	// services again, and the partition is deleted last since deleting it
+	// removes everything else in it.
+	steps := []step{
+		{"stop the deployments", c.stopDeployments},
+		{"deregister the services", c.deregisterServices},
+		{"delete the ACL tokens", c.deleteTokens},
+		{"delete the peerings", c.deletePeerings},
+	}
+	if c.flagDeleteNamespaces {
+		steps = append(steps, step{"delete the namespaces", c.deleteNamespaces})
+	}
+	if c.flagDeletePartition {
+		steps = append(steps, step{"delete the partition", c.deletePartition})
+	}
+
+	failed := false
+	for _, step := range steps {
+		if err := c.retry(step.name, step.fn); err != nil {
+			c.UI.Error(fmt.Sprintf("Failed to %s: %v", step.name, err))
+			failed = true
+			continue
+		}
+		c.log.Info("Uninstall cleanup step completed", "step", step.name)
+	}
+
+	if failed {
+		// The cleanup is best effort so that an unreachable Consul doesn't
+		// prevent uninstalling.
+		c.UI.Error("Some of the state of this cluster may be left in Consul and need to be removed manually.")
+		return 0
+	}
+	c.UI.Info("Successfully removed the state of this cluster from Consul.")
+	return 0
+}
+
+// stopDeployments scales the deployments down and waits for their pods to
+// be gone.
+func (c *Command) stopDeployments() error {
+	for _, name := range c.flagDeployments {
+		var deployment appsv1.Deployment
+		err := c.k8sClient.Get(c.ctx, types.NamespacedName{Name: name, Namespace: c.flagNamespace}, &deployment)
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 0 {
+			replicas := int32(0)
+			deployment.Spec.Replicas = &replicas
+			if err := c.k8sClient.Update(c.ctx, &deployment); err != nil {
+				return err
+			}
+			c.log.Info("Scaled down deployment", "name", name)
+		}
+
+		if deployment.Spec.Selector == nil {
+			continue
+		}
+		var pods corev1.PodList
+		if err := c.k8sClient.List(c.ctx, &pods, client.InNamespace(c.flagNamespace), client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err != nil {
+			return err
+		}
+		if len(pods.Items) > 0 {
+			return fmt.Errorf("deployment %s still has %d pods", name, len(pods.Items))
+		}
+	}
+	return nil
+}
+
+// deregisterServices deregisters the services registered by the endpoints
+// controller on the virtual nodes of the Kubernetes nodes, and the services
+// registered by the catalog sync, along with the nodes that are left empty.
+func (c *Command) deregisterServices() error {
+	var nodes corev1.NodeList
+	if err := c.k8sClient.List(c.ctx, &nodes); err != nil {
+		return err
+	}
+
+	endpointsFilter := fmt.Sprintf("Meta[%q] == %q", metaKeyManagedBy, constants.ManagedByValue)
+	consulNodes := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		consulNodes[connectcommon.ConsulNodeNameFromK8sNode(node.Name, c.flagClusterName)] = true
+	}
+	for _, node := range nodes.Items {
+		if err := c.deregisterNodeServices(connectcommon.ConsulNodeNameFromK8sNode(node.Name, c.flagClusterName), endpointsFilter); err != nil {
+			return err
+		}
+		// Services registered before the cluster name was set are on the
+		// virtual node without the cluster name.
+		legacyNodeName := connectcommon.ConsulNodeNameFromK8sNode(node.Name, "")
+		if c.flagClusterName != "" && !consulNodes[legacyNodeName] {
+			if err := c.deregisterNodeServices(legacyNodeName, endpointsFilter); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.flagSyncNodeName == "" {
+		return nil
+	}
+	syncFilter := fmt.Sprintf("%q in Tags", c.flagSyncK8sTag)
+	if err := c.deregisterNodeServices(c.flagSyncNodeName, syncFilter); err != nil {
+		return err
+	}
+	// The catalog sync registers services on a node per Kubernetes node if
+	// syncCatalog.syncNodeTopology is enabled.
+	for _, node := range nodes.Items {
+		if err := c.deregisterNodeServices(fmt.Sprintf("%s-%s", c.flagSyncNodeName, node.Name), syncFilter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deregisterNodeServices deregisters the services of the node that match the
+// filter, and the node itself if it has no other services.
+func (c *Command) deregisterNodeServices(nodeName, filter string) error {
+	services, _, err := c.consulClient.Catalog().NodeServiceList(nodeName, c.queryOptions(filter))
+	if err != nil {
+		return err
+	}
+	if services == nil {
+		// The node doesn't exist.
+		return nil
+	}
+	for _, service := range services.Services {
+		_, err := c.consulClient.Catalog().Deregister(&api.CatalogDeregistration{
+			Node:      nodeName,
+			ServiceID: service.ID,
+			Namespace: service.Namespace,
+			Partition: service.Partition,
+		}, nil)
+		if err != nil {
+			return err
+		}
+		c.log.Info("Deregistered service", "node", nodeName, "id", service.ID, "namespace", service.Namespace)
+	}
+
+	remaining, _, err := c.consulClient.Catalog().NodeServiceList(nodeName, c.queryOptions(""))
+	if err != nil {
+		return err
+	}
+	if remaining != nil && len(remaining.Services) == 0 {
+		if _, err := c.consulClient.Catalog().Deregister(&api.CatalogDeregistration{Node: nodeName}, nil); err != nil {
+			return err
+		}
+		c.log.Info("Deregistered node", "node", nodeName)
+	}
+	return nil
+}
+
+// deleteTokens deletes the ACL tokens created by logging in with the auth
+// methods of this cluster.
+func (c *Command) deleteTokens() error {
+	if len(c.flagAuthMethods) == 0 {
+		return nil
+	}
+	authMethods := make(map[string]bool)
+	for _, authMethod := range c.flagAuthMethods {
+		authMethods[authMethod] = true
+	}
+
+	tokens, _, err := c.consulClient.ACL().TokenList(c.queryOptions(""))
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if !authMethods[token.AuthMethod] {
+			continue
+		}
+		if _, err := c.consulClient.ACL().TokenDelete(token.AccessorID, &api.WriteOptions{Namespace: token.Namespace}); err != nil {
+			return err
+		}
+		c.log.Info("Deleted ACL token", "accessor-id", token.AccessorID, "auth-method", token.AuthMethod)
+	}
+	return nil
+}
+
+// deletePeerings deletes the peerings of the PeeringAcceptors and
+// PeeringDialers in the cluster, which are named after them.
+func (c *Command) deletePeerings() error {
+	var names []string
+	var acceptors v1alpha1.PeeringAcceptorList
+	if err := c.k8sClient.List(c.ctx, &acceptors); err != nil && !isNoMatch(err) {
+		return err
+	}
+	for _, acceptor := range acceptors.Items {
+		names = append(names, acceptor.Name)
+	}
+	var dialers v1alpha1.PeeringDialerList
+	if err := c.k8sClient.List(c.ctx, &dialers); err != nil && !isNoMatch(err) {
+		return err
+	}
+	for _, dialer := range dialers.Items {
+		names = append(names, dialer.Name)
+	}
+
+	for _, name := range names {
+		if _, err := c.consulClient.Peerings().Delete(c.ctx, name, nil); err != nil {
+			return err
+		}
+		c.log.Info("Deleted peering", "name", name)
+	}
+	return nil
+}
+
+// deleteNamespaces deletes the Consul namespaces that were created for
+// Kubernetes namespaces.
+func (c *Command) deleteNamespaces() error {
+	consulNamespaces, _, err := c.consulClient.Namespaces().List(nil)
+	if err != nil {
+		return err
+	}
+	for _, ns := range consulNamespaces {
+		if ns.Name == namespaces.DefaultNamespace || ns.Meta[metaKeyExternalSource] != externalSourceKubernetes {
+			continue
+		}
+		if _, err := c.consulClient.Namespaces().Delete(ns.Name, nil); err != nil {
+			return err
+		}
+		c.log.Info("Deleted namespace", "name", ns.Name)
+	}
+	return nil
+}
+
+// deletePartition deletes the partition of this cluster if it was created by
+// partition-init.
+func (c *Command) deletePartition() error {
+	if c.consul.Partition == "" || c.consul.Partition == "default" {
+		return nil
+	}
+	partition, _, err := c.consulClient.Partitions().Read(c.ctx, c.consul.Partition, nil)
+	if err != nil {
+		return err
+	}
+	// The API does not return an error if the Partition does not exist. It returns a nil Partition.
+	if partition == nil {
+		return nil
+	}
+	if partition.Description != common.PartitionDescription {
+		c.log.Info("Not deleting partition that wasn't created by partition-init", "name", partition.Name)
+		return nil
+	}
+	if _, err := c.consulClient.Partitions().Delete(c.ctx, partition.Name, nil); err != nil {
+		return err
+	}
+	c.log.Info("Deleted partition", "name", partition.Name)
+	return nil
+}
+
+func (c *Command) queryOptions(filter string) *api.QueryOptions {
+	opts := &api.QueryOptions{Filter: filter}
+	if c.flagEnableNamespaces {
+		opts.Namespace = namespaces.WildcardNamespace
+	}
+	return opts
+}
+
+// retry runs fn until it succeeds or the command times out.
+func (c *Command) retry(name string, fn func() error) error {
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		c.log.Error("Error during uninstall cleanup", "step", name, "error", err.Error())
+		c.log.Info("Retrying in " + c.retryDuration.String())
+		select {
+		case <-time.After(c.retryDuration):
+		case <-c.ctx.Done():
+			return err
+		}
+	}
+}
+
+// isNoMatch returns true if the error is because the CRD isn't installed,
+// e.g. the peering CRDs if peering isn't enabled.
+func isNoMatch(err error) bool {
+	return meta.IsNoMatchError(err)
+}
+
+func (c *Command) Synopsis() string { return synopsis }
+
+func (c *Command) Help() string {
+	c.once.Do(c.init)
+	return c.help
+}
+
+func (c *Command) validateFlags() error {
+	if len(c.flagDeployments) > 0 && c.flagNamespace == "" {
+		return errors.New("-k8s-namespace must be set if -deployment is set")
+	}
+	if c.flagClusterName != "" {
+		if err := common.ValidateClusterName("-cluster-name", c.flagClusterName); err != nil {
+			return err
+		}
+	}
+	if c.flagDeletePartition && c.consul.Partition == "" {
+		return errors.New("-partition must be set if -delete-partition is set")
+	}
+	if c.consulClient == nil && len(c.consul.Addresses) == 0 {
+		return errors.New("-addresses must be set")
+	}
+	return nil
+}
+
+const synopsis = "Remove the state of this Kubernetes cluster from Consul prior to uninstall."
+const help = `
+Usage: consul-k8s-control-plane uninstall-cleanup [options]
+
+  Stops the deployments that register services in Consul, then deregisters
+  the services registered by the connect injector and the catalog sync,
+  deletes the ACL tokens created by logging in with the auth methods of
+  this cluster and the peerings of its PeeringAcceptors and PeeringDialers.
+  Optionally, it also deletes the Consul namespaces created for Kubernetes
+  namespaces and the Admin Partition created by partition-init.
+
+  The cleanup is best effort, so if it fails or times out, it logs what
+  failed and allows the uninstallation to continue.
+
+`
@@ -0,0 +1,346 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package uninstallcleanup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul-k8s/control-plane/api/v1alpha1"
+	"github.com/hashicorp/consul-k8s/control-plane/subcommand/common"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-bexpr"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const namespace = "consul"
+
+func TestRun_FlagValidation(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		flags  []string
+		expErr string
+	}{
+		{
+			flags:  []string{},
+			expErr: "-addresses must be set",
+		},
+		{
+			flags:  []string{"-addresses", "consul", "-deployment", "consul-connect-injector"},
+			expErr: "-k8s-namespace must be set if -deployment is set",
+		},
+		{
+			flags:  []string{"-addresses", "consul", "-cluster-name", "Not_Valid"},
+			expErr: `-cluster-name value of "Not_Valid" must be at most 63 characters long`,
+		},
+		{
+			flags:  []string{"-addresses", "consul", "-delete-partition"},
+			expErr: "-partition must be set if -delete-partition is set",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			cmd := Command{
+				UI: ui,
+			}
+			code := cmd.Run(c.flags)
+			require.Equal(t, 1, code)
+			require.Contains(t, ui.ErrorWriter.String(), c.expErr)
+		})
+	}
+}
+
+func TestRun_Cleanup(t *testing.T) {
+	t.Parallel()
+	consulServer := &fakeConsul{
+		nodes: map[string][]*api.AgentService{
+			// Services registered by the endpoints controller.
+			"node-1-east-virtual": {
+				{ID: "web-1", Service: "web", Meta: map[string]string{metaKeyManagedBy: "consul-k8s-endpoints-controller"}},
+				{ID: "web-1-sidecar-proxy", Service: "web-sidecar-proxy", Meta: map[string]string{metaKeyManagedBy: "consul-k8s-endpoints-controller"}},
+			},
+			"node-2-east-virtual": {
+				{ID: "api-1", Service: "api", Meta: map[string]string{metaKeyManagedBy: "consul-k8s-endpoints-controller"}},
+				{ID: "registered-by-hand", Service: "db"},
+			},
+			// Services registered by the endpoints controller before the cluster name was set.
+			"node-1-virtual": {
+				{ID: "web-0", Service: "web", Meta: map[string]string{metaKeyManagedBy: "consul-k8s-endpoints-controller"}},
+				{ID: "cache-by-hand", Service: "cache"},
+			},
+			// The virtual node of another cluster.
+			"node-1-west-virtual": {
+				{ID: "web-2", Service: "web", Meta: map[string]string{metaKeyManagedBy: "consul-k8s-endpoints-controller"}},
+			},
+			// Services registered by the catalog sync, with and without node topology.
+			"k8s-sync-east": {
+				{ID: "frontend", Service: "frontend", Tags: []string{"k8s"}},
+				{ID: "other-tag", Service: "other", Tags: []string{"other"}},
+			},
+			"k8s-sync-east-node-1": {
+				{ID: "frontend-1", Service: "frontend", Tags: []string{"k8s"}},
+			},
+		},
+		tokens: []*api.ACLTokenListEntry{
+			{AccessorID: "web-token", AuthMethod: "consul-east-k8s-auth-method"},
+			{AccessorID: "injector-token", AuthMethod: "consul-east-k8s-component-auth-method"},
+			{AccessorID: "west-token", AuthMethod: "consul-west-k8s-auth-method"},
+			{AccessorID: "bootstrap-token"},
+		},
+		peerings: map[string]bool{"acceptor": true, "dialer": true, "created-by-hand": true},
+		namespaces: []*api.Namespace{
+			{Name: "default"},
+			{Name: "web", Meta: map[string]string{metaKeyExternalSource: externalSourceKubernetes}},
+			{Name: "created-by-hand"},
+		},
+		partitions: map[string]*api.Partition{
+			"east": {Name: "east", Description: common.PartitionDescription},
+		},
+	}
+
+	replicas := int32(2)
+	k8s := newK8sClient(t,
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-connect-injector", Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"component": "connect-injector"}},
+			},
+		},
+		&v1alpha1.PeeringAcceptor{ObjectMeta: metav1.ObjectMeta{Name: "acceptor", Namespace: "default"}},
+		&v1alpha1.PeeringDialer{ObjectMeta: metav1.ObjectMeta{Name: "dialer", Namespace: "default"}},
+	)
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, k8sClient: k8s, consulClient: consulServer.client(t)}
+	code := cmd.Run([]string{
+		"-k8s-namespace", namespace,
+		"-deployment", "consul-connect-injector",
+		"-deployment", "consul-sync-catalog",
+		"-cluster-name", "east",
+		"-auth-method", "consul-east-k8s-auth-method",
+		"-auth-method", "consul-east-k8s-component-auth-method",
+		"-sync-consul-node-name", "k8s-sync-east",
+		"-delete-namespaces",
+		"-partition", "east",
+		"-delete-partition",
+	})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+	require.Empty(t, ui.ErrorWriter.String())
+
+	var deployment appsv1.Deployment
+	require.NoError(t, k8s.Get(context.Background(), types.NamespacedName{Name: "consul-connect-injector", Namespace: namespace}, &deployment))
+	require.Equal(t, int32(0), *deployment.Spec.Replicas)
+
+	// Only the services and nodes of this cluster are removed.
+	require.Equal(t, map[string][]string{
+		"node-2-east-virtual": {"registered-by-hand"},
+		"node-1-virtual":      {"cache-by-hand"},
+		"node-1-west-virtual": {"web-2"},
+		"k8s-sync-east":       {"other-tag"},
+	}, consulServer.serviceIDs())
+	require.Equal(t, []string{"bootstrap-token", "west-token"}, consulServer.tokenIDs())
+	require.Equal(t, map[string]bool{"created-by-hand": true}, consulServer.peerings)
+	require.Equal(t, []string{"created-by-hand", "default"}, consulServer.namespaceNames())
+	require.Empty(t, consulServer.partitions)
+}
+
+func TestRun_KeepsPartitionsNotCreatedByPartitionInit(t *testing.T) {
+	t.Parallel()
+	consulServer := &fakeConsul{
+		partitions: map[string]*api.Partition{
+			"east": {Name: "east", Description: "Created by the platform team"},
+		},
+	}
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, k8sClient: newK8sClient(t), consulClient: consulServer.client(t)}
+	code := cmd.Run([]string{"-partition", "east", "-delete-partition"})
+	require.Equal(t, 0, code, ui.ErrorWriter.String())
+	require.Contains(t, consulServer.partitions, "east")
+}
+
+func TestRun_DoesNotBlockUninstall(t *testing.T) {
+	t.Parallel()
+	replicas := int32(1)
+	k8s := newK8sClient(t,
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "consul-connect-injector", Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"component": "connect-injector"}},
+			},
+		},
+		// The pod of the deployment never goes away.
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "consul-connect-injector-abc", Namespace: namespace, Labels: map[string]string{"component": "connect-injector"}}},
+	)
+
+	ui := cli.NewMockUi()
+	cmd := Command{UI: ui, k8sClient: k8s, consulClient: (&fakeConsul{}).client(t), retryDuration: 10 * time.Millisecond}
+	code := cmd.Run([]string{"-k8s-namespace", namespace, "-deployment", "consul-connect-injector", "-timeout", "100ms"})
+	require.Equal(t, 0, code)
+	require.Contains(t, ui.ErrorWriter.String(), "Failed to stop the deployments: deployment consul-connect-injector still has 1 pods")
+	require.Contains(t, ui.ErrorWriter.String(), "need to be removed manually")
+}
+
+func newK8sClient(t *testing.T, objs ...client.Object) client.Client {
+	s := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(s))
+	require.NoError(t, v1alpha1.AddToScheme(s))
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+}
+
+// fakeConsul implements the endpoints of the Consul HTTP API used by the cleanup.
+type fakeConsul struct {
+	mu         sync.Mutex
+	nodes      map[string][]*api.AgentService
+	tokens     []*api.ACLTokenListEntry
+	peerings   map[string]bool
+	namespaces []*api.Namespace
+	partitions map[string]*api.Partition
+}
+
+func (f *fakeConsul) client(t *testing.T) *api.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		path := r.URL.Path
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "/v1/catalog/node-services/"):
+			node := strings.TrimPrefix(path, "/v1/catalog/node-services/")
+			services, ok := f.nodes[node]
+			if !ok {
+				_, _ = w.Write([]byte("null"))
+				return
+			}
+			if filter := r.URL.Query().Get("filter"); filter != "" {
+				eval, err := bexpr.CreateEvaluator(filter)
+				require.NoError(t, err)
+				var filtered []*api.AgentService
+				for _, service := range services {
+					// Services without the meta key don't match rather than fail the filter.
+					if match, err := eval.Evaluate(service); err == nil && match {
+						filtered = append(filtered, service)
+					}
+				}
+				services = filtered
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(api.CatalogNodeServiceList{
+				Node:     &api.Node{Node: node},
+				Services: services,
+			}))
+		case r.Method == http.MethodPut && path == "/v1/catalog/deregister":
+			var req api.CatalogDeregistration
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if req.ServiceID == "" {
+				delete(f.nodes, req.Node)
+				return
+			}
+			var remaining []*api.AgentService
+			for _, service := range f.nodes[req.Node] {
+				if service.ID != req.ServiceID {
+					remaining = append(remaining, service)
+				}
+			}
+			f.nodes[req.Node] = remaining
+		case r.Method == http.MethodGet && path == "/v1/acl/tokens":
+			require.NoError(t, json.NewEncoder(w).Encode(f.tokens))
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, "/v1/acl/token/"):
+			accessorID := strings.TrimPrefix(path, "/v1/acl/token/")
+			var remaining []*api.ACLTokenListEntry
+			for _, token := range f.tokens {
+				if token.AccessorID != accessorID {
+					remaining = append(remaining, token)
+				}
+			}
+			f.tokens = remaining
+			_, _ = w.Write([]byte("true"))
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, "/v1/peering/"):
+			delete(f.peerings, strings.TrimPrefix(path, "/v1/peering/"))
+		case r.Method == http.MethodGet && path == "/v1/namespaces":
+			require.NoError(t, json.NewEncoder(w).Encode(f.namespaces))
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, "/v1/namespace/"):
+			name := strings.TrimPrefix(path, "/v1/namespace/")
+			var remaining []*api.Namespace
+			for _, ns := range f.namespaces {
+				if ns.Name != name {
+					remaining = append(remaining, ns)
+				}
+			}
+			f.namespaces = remaining
+		case r.Method == http.MethodGet && strings.HasPrefix(path, "/v1/partition/"):
+			partition, ok := f.partitions[strings.TrimPrefix(path, "/v1/partition/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(partition))
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, "/v1/partition/"):
+			delete(f.partitions, strings.TrimPrefix(path, "/v1/partition/"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	require.NoError(t, err)
+	return client
+}
+
+// serviceIDs returns the IDs of the services by node.
+func (f *fakeConsul) serviceIDs() map[string][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make(map[string][]string)
+	for node, services := range f.nodes {
+		for _, service := range services {
+			ids[node] = append(ids[node], service.ID)
+		}
+	}
+	return ids
+}
+
+func (f *fakeConsul) tokenIDs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []string
+	for _, token := range f.tokens {
+		ids = append(ids, token.AccessorID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (f *fakeConsul) namespaceNames() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for _, ns := range f.namespaces {
+		names = append(names, ns.Name)
+	}
+	sort.Strings(names)
+	return names
+}